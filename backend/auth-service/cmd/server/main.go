@@ -0,0 +1,443 @@
+package main
+
+import (
+	"context"
+	"log"
+	"os"
+	"strconv"
+
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/expotoworld/expotoworld/backend/auth-service/internal/api"
+	"github.com/expotoworld/expotoworld/backend/auth-service/internal/db"
+	"github.com/expotoworld/expotoworld/backend/auth-service/internal/events"
+	"github.com/expotoworld/expotoworld/backend/auth-service/internal/logging"
+	"github.com/expotoworld/expotoworld/backend/auth-service/internal/services"
+	"github.com/expotoworld/expotoworld/backend/common/httpserver"
+	"github.com/expotoworld/expotoworld/backend/common/jwks"
+	"github.com/expotoworld/expotoworld/backend/common/rbac"
+	"github.com/gin-gonic/gin"
+	"github.com/joho/godotenv"
+)
+
+func main() {
+	// Load environment variables from .env file if it exists
+	if err := godotenv.Load(); err != nil {
+		log.Println("No .env file found, using environment variables")
+	}
+
+	// Ensure all log output goes to stdout so App Runner captures it in Application Logs
+	log.SetOutput(os.Stdout)
+
+	log.Printf("Auth Service starting (GIT_SHA=%s BUILD_TIME=%s)", os.Getenv("GIT_SHA"), os.Getenv("BUILD_TIME"))
+
+	// Initialize database connection (non-fatal; allow process to start for /live)
+	database, err := db.NewDatabase()
+	if err != nil {
+		log.Printf("[WARN] Database initialization failed at startup: %v", err)
+	}
+	if database != nil {
+		defer database.Close()
+	}
+
+	// Initialize user verification schema (best effort)
+	if database != nil {
+		if err := database.InitUserSchema(context.Background()); err != nil {
+			log.Printf("[WARN] Failed to initialize user schema: %v", err)
+		}
+		if err := database.InitRefreshTokenSchema(context.Background()); err != nil {
+			log.Printf("[WARN] Failed to initialize refresh token schema: %v", err)
+		}
+		if err := database.InitAdminScopeSchema(context.Background()); err != nil {
+			log.Printf("[WARN] Failed to initialize admin scope schema: %v", err)
+		}
+		if err := database.InitSecurityKeySchema(context.Background()); err != nil {
+			log.Printf("[WARN] Failed to initialize security key schema: %v", err)
+		}
+		if err := database.InitQRLoginSchema(context.Background()); err != nil {
+			log.Printf("[WARN] Failed to initialize QR login schema: %v", err)
+		}
+		if err := database.InitIdentitySchema(context.Background()); err != nil {
+			log.Printf("[WARN] Failed to initialize identity schema: %v", err)
+		}
+		if err := database.InitAccountSchema(context.Background()); err != nil {
+			log.Printf("[WARN] Failed to initialize account schema: %v", err)
+		}
+		if err := database.InitRBACSchema(context.Background()); err != nil {
+			log.Printf("[WARN] Failed to initialize RBAC schema: %v", err)
+		}
+		if err := database.InitServiceClientSchema(context.Background()); err != nil {
+			log.Printf("[WARN] Failed to initialize service client schema: %v", err)
+		}
+		if err := database.InitAuthAuditSchema(context.Background()); err != nil {
+			log.Printf("[WARN] Failed to initialize auth audit schema: %v", err)
+		}
+		if err := database.InitDeviceSchema(context.Background()); err != nil {
+			log.Printf("[WARN] Failed to initialize device schema: %v", err)
+		}
+		if err := database.InitMagicLinkSchema(context.Background()); err != nil {
+			log.Printf("[WARN] Failed to initialize magic link schema: %v", err)
+		}
+		if err := database.InitLockoutSchema(context.Background()); err != nil {
+			log.Printf("[WARN] Failed to initialize lockout schema: %v", err)
+		}
+		if err := database.InitLoginHistorySchema(context.Background()); err != nil {
+			log.Printf("[WARN] Failed to initialize login history schema: %v", err)
+		}
+	}
+
+	// Tiered verification rate limits; configuration lives in app_rate_limit_tiers and is hot-reloaded
+	rateLimitTiers := services.DefaultRateLimitTiers(getEnvInt("RATE_LIMIT_REQUESTS_PER_HOUR", 5))
+	rateLimits := services.NewRateLimitService(database, rateLimitTiers, getEnvInt("RATE_LIMIT_CONFIG_RELOAD_SECONDS", 60))
+	if database != nil {
+		if err := database.InitRateLimitTierSchema(context.Background(), rateLimitTiers); err != nil {
+			log.Printf("[WARN] Failed to initialize rate limit tier schema: %v", err)
+		}
+	}
+	rateLimitCtx, stopRateLimits := context.WithCancel(context.Background())
+	defer stopRateLimits()
+	rateLimits.Start(rateLimitCtx)
+
+	// Deliver account events (user.deleted) to the services listed in ACCOUNT_EVENT_ENDPOINTS
+	if database != nil && len(events.Endpoints()) > 0 {
+		worker := events.NewWorkerFromEnv(database.Pool)
+		if worker.Secret == "" {
+			log.Printf("[WARN] ACCOUNT_EVENT_SECRET not set; account events are sent with an empty signing secret")
+		}
+		eventsCtx, stopEvents := context.WithCancel(context.Background())
+		defer stopEvents()
+		go worker.Run(eventsCtx)
+	}
+
+	// Initialize AWS configs separately for SES (email) and SNS (SMS)
+	// SES config: use App Runner instance role (no SMTP secrets in prod)
+	sesRegion := os.Getenv("SES_AWS_REGION")
+	if sesRegion == "" {
+		if os.Getenv("AWS_DEFAULT_REGION") != "" {
+			sesRegion = os.Getenv("AWS_DEFAULT_REGION")
+		} else {
+			sesRegion = "eu-central-1"
+		}
+	}
+	sesCfg, sesErr := config.LoadDefaultConfig(context.Background(),
+		config.WithRegion(sesRegion),
+	)
+	if sesErr != nil {
+		log.Printf("[WARN] SES AWS config load failed: %v", sesErr)
+	}
+
+	// SNS config: use App Runner instance role (no static keys in prod)
+	snsRegion := os.Getenv("SNS_AWS_REGION")
+	if snsRegion == "" {
+		// fall back to AWS_DEFAULT_REGION if set, otherwise eu-central-1
+		if os.Getenv("AWS_DEFAULT_REGION") != "" {
+			snsRegion = os.Getenv("AWS_DEFAULT_REGION")
+		} else {
+			snsRegion = "eu-central-1"
+		}
+	}
+	snsCfg, snsErr := config.LoadDefaultConfig(context.Background(),
+		config.WithRegion(snsRegion),
+	)
+	if snsErr != nil {
+		log.Printf("[WARN] SNS AWS config load failed: %v", snsErr)
+	}
+
+	// Initialize services
+	var emailService *services.EmailService
+	if sesErr == nil {
+		if emailService, err = services.NewEmailService(sesCfg); err != nil {
+			log.Printf("[WARN] Email service not initialized: %v", err)
+		}
+	} else {
+		log.Printf("[WARN] Email service not initialized due to SES config error")
+	}
+	var smsService *services.SmsService
+	if snsErr == nil {
+		smsService = services.NewSmsService(snsCfg)
+	} else {
+		log.Printf("[WARN] SMS service not initialized due to SNS config error")
+	}
+
+	// WhatsApp Business API delivery for phone codes (optional; SMS remains the fallback)
+	whatsAppService := services.NewWhatsAppServiceFromEnv()
+	if whatsAppService == nil {
+		log.Printf("[INFO] WhatsApp delivery not configured; phone codes are sent by SMS")
+	}
+
+	// Asymmetric access token signing; other services verify against /.well-known/jwks.json
+	signer, err := jwks.SignerFromEnv()
+	if err != nil {
+		log.Fatalf("Invalid JWT signing key: %v", err)
+	}
+	if signer == nil {
+		log.Printf("[WARN] JWT_SIGNING_KEY not set; access tokens are signed with the shared HS256 JWT_SECRET")
+	} else {
+		log.Printf("[INFO] Signing access tokens with key %s", signer.KeyID())
+	}
+
+	// Sign in with Apple / Google for the mobile apps
+	oidcProviders := services.NewOIDCProvidersFromEnv()
+	for name := range oidcProviders {
+		log.Printf("[INFO] %s sign-in enabled", name)
+	}
+
+	// WeChat login for the mini program and the app
+	weChatService := services.NewWeChatServiceFromEnv()
+	if weChatService == nil {
+		log.Printf("[INFO] WeChat login not configured")
+	}
+
+	// CAPTCHA for verification code requests that look like abuse (e.g. SMS pumping)
+	captchaService := services.NewCaptchaServiceFromEnv()
+	if captchaService == nil {
+		log.Printf("[INFO] CAPTCHA not configured; risky verification requests are only rate limited")
+	}
+
+	// GeoIP for login risk scoring (new countries, networks, impossible travel)
+	geoIPService := services.NewGeoIPServiceFromEnv()
+	if geoIPService == nil {
+		log.Printf("[INFO] GeoIP not configured; login risk is scored on devices and IPs only")
+	}
+
+	// Initialize handlers (DB may be nil; /ready will report accordingly)
+	handler := api.NewHandler(database, emailService, smsService, whatsAppService, rateLimits, signer, oidcProviders, weChatService, captchaService, geoIPService)
+
+	// Periodic cleanup disabled: we now perform opportunistic cleanup during auth requests
+	if database == nil {
+		log.Println("[WARN] Database unavailable at startup; readiness will report accordingly")
+	}
+
+	// Set up Gin router
+	router := setupRouter(handler)
+
+	// Get port from environment or use default
+	port := os.Getenv("AUTH_PORT")
+	if port == "" {
+		port = "8081" // Different port from catalog service
+	}
+
+	// Serve until SIGTERM, then drain in-flight requests before the deferred database close
+	if err := httpserver.Run("auth service", router, port); err != nil {
+		log.Printf("[ERROR] %v", err)
+	}
+}
+
+func setupRouter(handler *api.Handler) *gin.Engine {
+	// Set Gin mode based on environment
+	if os.Getenv("GIN_MODE") == "" {
+		gin.SetMode(gin.ReleaseMode)
+	}
+
+	router := gin.New()
+
+	// Add middleware
+	router.Use(logging.JSONLogger())
+	router.Use(gin.Recovery())
+	router.Use(corsMiddleware())
+
+	// Liveness and readiness endpoints
+	// /live returns 200 if the process is running (no DB checks)
+	router.GET("/live", func(c *gin.Context) { c.Status(200) })
+	// /ready performs DB checks (what /health used to do)
+	router.GET("/ready", handler.Health)
+	// Keep /health for App Runner legacy health checks, but make it liveness-only
+	router.GET("/health", func(c *gin.Context) { c.Status(200) })
+
+	// Public signing keys for verifying access tokens
+	router.GET("/.well-known/jwks.json", handler.JWKS)
+
+	// API routes
+	auth := router.Group("/api/auth")
+	{
+		// Legacy password-based authentication (will be deprecated)
+		auth.POST("/signup", handler.Signup)
+		auth.POST("/login", handler.Login)
+
+		// New passwordless authentication for users
+		auth.POST("/send-verification", handler.UserSendVerification)
+		auth.POST("/verify-code", handler.UserVerifyCode)
+
+		// Single-use login links emailed as an alternative to codes, redeemed by the app
+		auth.POST("/send-magic-link", handler.SendMagicLink)
+		auth.POST("/verify-magic-link", handler.VerifyMagicLink)
+
+		// Phone-based passwordless authentication
+		auth.POST("/send-phone-verification", handler.UserSendPhoneVerification)
+		auth.POST("/verify-phone-code", handler.UserVerifyPhoneCode)
+
+		// Logins paused by their risk score are completed with a code emailed to the account
+		auth.POST("/login/step-up", handler.VerifyLoginStepUp)
+
+		// Sign in with Apple / Google: exchange the provider's ID token for our tokens
+		auth.POST("/oauth/:provider/exchange", handler.OAuthExchange)
+
+		// WeChat login; first-time WeChat accounts are linked to a verified phone number
+		auth.POST("/wechat/login", handler.WeChatLogin)
+		auth.POST("/wechat/link", handler.WeChatLink)
+
+		// Token refresh
+		auth.POST("/refresh", handler.Refresh)
+
+		// Refresh with refresh token (mobile-friendly)
+		auth.POST("/token/refresh", handler.RefreshWithRefreshToken)
+
+		// Internal services: client-credentials grant and token introspection
+		auth.POST("/token", handler.IssueToken)
+		auth.POST("/introspect", handler.Introspect)
+
+		// Admin email verification routes (separate endpoints)
+		auth.POST("/admin/send-verification", handler.AdminSendVerification)
+		auth.POST("/admin/verify-code", handler.AdminVerifyCode)
+
+		// Admin second factor (security keys), redeemed with the mfa_token from verify-code
+		auth.POST("/admin/mfa/verify", handler.AdminVerifySecondFactor)
+		auth.POST("/admin/mfa/enroll/begin", handler.AdminBeginEnrollment)
+		auth.POST("/admin/mfa/enroll/finish", handler.AdminFinishEnrollment)
+
+		// QR code login for desktop clients (ebook editor), approved from the signed-in mobile app
+		auth.POST("/qr/start", handler.StartQRLogin)
+		auth.POST("/qr/poll", handler.PollQRLogin)
+	}
+
+	// QR code login decisions, made by the mobile app with its own access token
+	qrLogin := router.Group("/api/auth/qr")
+	qrLogin.Use(handler.AuthMiddleware(), api.RejectImpersonation())
+	{
+		qrLogin.POST("/scan", handler.ScanQRLogin)
+		qrLogin.POST("/approve", handler.ApproveQRLogin)
+		qrLogin.POST("/deny", handler.DenyQRLogin)
+	}
+
+	// Self-service account deletion, data export (GDPR) and email/phone linking
+	account := router.Group("/api/auth/account")
+	account.Use(handler.AuthMiddleware(), api.RejectImpersonation())
+	{
+		account.POST("/delete", handler.DeleteAccount)
+		account.GET("/export", handler.ExportAccount)
+		account.POST("/identifiers", handler.AddIdentifier)
+		account.PUT("/language", handler.SetLanguage)
+	}
+
+	// Signed-in devices and their push tokens
+	devices := router.Group("/api/auth/devices")
+	devices.Use(handler.AuthMiddleware(), api.RejectImpersonation())
+	{
+		devices.GET("", handler.ListDevices)
+		devices.POST("", handler.RegisterDevice)
+		devices.PUT("/:id", handler.RenameDevice)
+		devices.DELETE("/:id", handler.DeleteDevice)
+	}
+
+	// Push token lookup for internal services (client-credentials tokens with push:send)
+	pushTargets := router.Group("/api/auth/internal/push-targets")
+	pushTargets.Use(handler.ServiceAuthMiddleware(rbac.PushSend))
+	{
+		pushTargets.GET("", handler.ListPushTargets)
+		pushTargets.POST("/invalidate", handler.InvalidatePushTokens)
+	}
+
+	// Protected routes for testing JWT validation
+	protected := router.Group("/api/protected")
+	protected.Use(handler.AuthMiddleware())
+	{
+		protected.GET("/profile", handler.GetProfile)
+		protected.GET("/rate-limit-metrics", handler.GetRateLimitMetrics)
+	}
+
+	// Admin session management (refresh tokens across users)
+	sessions := router.Group("/api/auth/admin/sessions")
+	sessions.Use(handler.AuthMiddleware(), api.RequirePermission(rbac.SessionsManage))
+	{
+		sessions.GET("", handler.AdminSearchSessions)
+		sessions.GET("/user-counts", handler.AdminSessionCounts)
+		sessions.POST("/revoke", handler.AdminRevokeSessions)
+		sessions.POST("/users/:user_id/logout", handler.AdminForceLogout)
+	}
+
+	// Admin security key management (own account; scoped admins included)
+	securityKeys := router.Group("/api/auth/admin/security-keys")
+	securityKeys.Use(handler.AuthMiddleware(), api.RequireAdminRole())
+	{
+		securityKeys.GET("", handler.ListSecurityKeys)
+		securityKeys.POST("/register/begin", handler.BeginSecurityKeyRegistration)
+		securityKeys.POST("/register/finish", handler.FinishSecurityKeyRegistration)
+		securityKeys.DELETE("/:id", handler.DeleteSecurityKey)
+		securityKeys.POST("/recovery-codes", handler.RegenerateRecoveryCodes)
+	}
+
+	// Delegated admin scopes (regional managers)
+	scopes := router.Group("/api/auth/admin/scopes")
+	scopes.Use(handler.AuthMiddleware(), api.RequirePermission(rbac.RolesManage))
+	{
+		scopes.GET("", handler.AdminListScopes)
+		scopes.PUT("/:user_id", handler.AdminSetScope)
+		scopes.DELETE("/:user_id", handler.AdminDeleteScope)
+	}
+
+	// Super-admin impersonation for debugging user-reported issues
+	impersonation := router.Group("/api/auth/admin/impersonate")
+	impersonation.Use(handler.AuthMiddleware(), api.RejectImpersonation(), api.RequirePermission(rbac.UsersImpersonate))
+	{
+		impersonation.POST("", handler.Impersonate)
+	}
+
+	// Auth event audit log for security reviews
+	authEvents := router.Group("/api/admin/auth-events")
+	authEvents.Use(handler.AuthMiddleware(), api.RequirePermission(rbac.SessionsManage))
+	{
+		authEvents.GET("", handler.ListAuthEvents)
+	}
+
+	// Roles, permissions and role assignment
+	roles := router.Group("/api/auth/admin")
+	roles.Use(handler.AuthMiddleware(), api.RequirePermission(rbac.RolesManage))
+	{
+		roles.GET("/permissions", handler.ListPermissions)
+		roles.GET("/roles", handler.ListRoles)
+		roles.POST("/roles", handler.CreateRole)
+		roles.PUT("/roles/:name", handler.UpdateRole)
+		roles.DELETE("/roles/:name", handler.DeleteRole)
+		roles.GET("/users/:user_id/roles", handler.ListUserRoles)
+		roles.POST("/users/:user_id/roles", handler.AssignUserRole)
+		roles.DELETE("/users/:user_id/roles/:role", handler.RevokeUserRole)
+		roles.GET("/service-clients", handler.ListServiceClients)
+		roles.POST("/service-clients", handler.CreateServiceClient)
+		roles.DELETE("/service-clients/:client_id", handler.RevokeServiceClient)
+	}
+
+	// Root endpoint for basic info
+	router.GET("/", func(c *gin.Context) {
+		c.JSON(200, gin.H{
+			"service": "auth-service",
+			"version": "1.0.0",
+			"status":  "running",
+		})
+	})
+
+	return router
+}
+
+// corsMiddleware adds CORS headers to allow cross-origin requests
+func corsMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Header("Access-Control-Allow-Origin", "*")
+		c.Header("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
+		c.Header("Access-Control-Allow-Headers", "Origin, Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, X-Require-Existing, X-Require-Role")
+
+		if c.Request.Method == "OPTIONS" {
+			c.AbortWithStatus(204)
+			return
+		}
+
+		c.Next()
+	}
+}
+
+func getEnvInt(key string, defaultValue int) int {
+	if value := os.Getenv(key); value != "" {
+		if intValue, err := strconv.Atoi(value); err == nil {
+			return intValue
+		}
+	}
+	return defaultValue
+}
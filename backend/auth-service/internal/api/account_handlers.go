@@ -0,0 +1,303 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/expotoworld/expotoworld/backend/auth-service/internal/db"
+	"github.com/expotoworld/expotoworld/backend/auth-service/internal/models"
+	"github.com/expotoworld/expotoworld/backend/auth-service/internal/services"
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+)
+
+// DeleteAccount handles POST /api/auth/account/delete: the signed-in user deletes their own account,
+// confirming with a fresh verification code. The account is anonymized, its sessions revoked and a
+// user.deleted event is queued for the other services. Admin accounts are removed by another admin.
+func (h *Handler) DeleteAccount(c *gin.Context) {
+	userID := c.GetString("user_id")
+	var req models.DeleteAccountRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid request data", Message: err.Error()})
+		return
+	}
+	clientIP := getClientIP(c)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	user, err := h.DB.GetUserByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "User not found", Message: "The account no longer exists"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to retrieve user", Message: err.Error()})
+		return
+	}
+	role, err := h.DB.GetUserRoleByID(ctx, user.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to validate user role", Message: err.Error()})
+		return
+	}
+	if role == "Admin" {
+		c.JSON(http.StatusForbidden, models.ErrorResponse{Error: "User not allowed", Message: "Admin accounts are deleted by another admin"})
+		return
+	}
+
+	// The code must come from a channel the account owns
+	if req.Channel == "phone" || (req.Channel == "" && user.Email == nil) {
+		if user.Phone == nil {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "No phone number", Message: "The account has no phone number to confirm with"})
+			return
+		}
+		if !h.checkPhoneCode(c, ctx, *user.Phone, req.Code) {
+			return
+		}
+	} else {
+		if user.Email == nil {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "No email", Message: "The account has no email to confirm with"})
+			return
+		}
+		if !h.checkEmailCode(c, ctx, *user.Email, req.Code) {
+			return
+		}
+	}
+
+	if err := h.DB.DeleteAccount(ctx, user.ID); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "User not found", Message: "The account no longer exists"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to delete account", Message: err.Error()})
+		return
+	}
+	fmt.Printf("[USER_AUTH][ACCOUNT] User %s deleted their account from IP: %s\n", user.ID, clientIP)
+	if err := h.revokeAccessTokens(ctx, user.ID, "account_deleted"); err != nil {
+		fmt.Printf("[WARN] %v\n", err)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Account deleted", "user_id": user.ID})
+}
+
+// ExportAccount handles GET /api/auth/account/export: a JSON download of the personal data we hold
+// about the signed-in user (profile, sign-in methods, sessions, carts, orders, quotes, reading data,
+// stock alerts, product views and experiment exposures)
+func (h *Handler) ExportAccount(c *gin.Context) {
+	userID := c.GetString("user_id")
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	if _, err := h.DB.GetUserByID(ctx, userID); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "User not found", Message: "The account no longer exists"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to retrieve user", Message: err.Error()})
+		return
+	}
+	data, err := h.DB.ExportAccount(ctx, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to export account", Message: err.Error()})
+		return
+	}
+	fmt.Printf("[USER_AUTH][ACCOUNT] User %s exported their data from IP: %s\n", userID, getClientIP(c))
+
+	c.Header("Cache-Control", "no-store")
+	c.Header("Content-Disposition", `attachment; filename="expotoworld-account-export.json"`)
+	c.JSON(http.StatusOK, gin.H{
+		"user_id":     userID,
+		"exported_at": time.Now().UTC(),
+		"data":        data,
+	})
+}
+
+// AddIdentifier handles POST /api/auth/account/identifiers: the signed-in user adds the email or phone
+// number they lack, proving it with a code sent to it. When the identifier already signs in to another
+// account (someone who used email first and phone later), the first call answers 409 merge_required;
+// calling again with merge=true folds that account into this one so a single user holds both.
+func (h *Handler) AddIdentifier(c *gin.Context) {
+	userID := c.GetString("user_id")
+	var req models.AddIdentifierRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid request data", Message: err.Error()})
+		return
+	}
+	if (req.Email == "") == (req.Phone == "") {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid request data", Message: "Provide either email or phone"})
+		return
+	}
+	kind, value := "email", req.Email
+	if req.Phone != "" {
+		parsed, err := services.ParsePhone(req.Phone, req.Region)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid phone number", Message: "Phone number must include the country code, e.g., +12065550100"})
+			return
+		}
+		kind, value = "phone", parsed.E164
+	}
+	clientIP := getClientIP(c)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	user, err := h.DB.GetUserByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "User not found", Message: "The account no longer exists"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to retrieve user", Message: err.Error()})
+		return
+	}
+	role, err := h.DB.GetUserRoleByID(ctx, user.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to validate user role", Message: err.Error()})
+		return
+	}
+	if role == "Admin" {
+		c.JSON(http.StatusForbidden, models.ErrorResponse{Error: "User not allowed", Message: "Admin sign-in details are managed by another admin"})
+		return
+	}
+	if (kind == "email" && user.Email != nil) || (kind == "phone" && user.Phone != nil) {
+		c.JSON(http.StatusConflict, models.ErrorResponse{Error: "Already set", Message: fmt.Sprintf("The account already has a %s", kind)})
+		return
+	}
+
+	// Settle who holds the identifier before the code is spent, so a merge prompt doesn't burn it
+	var owner *models.User
+	if kind == "email" {
+		owner, err = h.DB.GetUserByEmail(ctx, value)
+	} else {
+		owner, err = h.DB.GetUserByPhone(ctx, value)
+	}
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to retrieve user", Message: err.Error()})
+		return
+	}
+	if owner != nil {
+		if !h.checkMergeable(c, ctx, user, owner, role, kind) {
+			return
+		}
+		if !req.Merge {
+			c.JSON(http.StatusConflict, gin.H{
+				"error":          "Identifier in use",
+				"message":        fmt.Sprintf("This %s signs in to another account; confirm to merge it into this one", kind),
+				"merge_required": true,
+			})
+			return
+		}
+	}
+
+	if kind == "email" {
+		if !h.checkEmailCode(c, ctx, value, req.Code) {
+			return
+		}
+	} else if !h.checkPhoneCode(c, ctx, value, req.Code) {
+		return
+	}
+
+	if owner == nil {
+		err = h.DB.AddUserIdentifier(ctx, user.ID, kind, value)
+	} else {
+		err = h.DB.MergeUsers(ctx, user.ID, owner.ID, kind, value)
+	}
+	if err != nil {
+		if errors.Is(err, db.ErrIdentifierTaken) || errors.Is(err, pgx.ErrNoRows) {
+			// The identifier or the account changed since the checks above
+			c.JSON(http.StatusConflict, models.ErrorResponse{Error: "Account changed", Message: "Please try again"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to update account", Message: err.Error()})
+		return
+	}
+
+	// Sign-in methods changed: tokens issued before must be refreshed (the merged account's are dropped)
+	if err := h.revokeAccessTokens(ctx, user.ID, kind+"_added"); err != nil {
+		fmt.Printf("[WARN] %v\n", err)
+	}
+	resp := gin.H{"message": fmt.Sprintf("The %s was added to the account", kind)}
+	if owner != nil {
+		fmt.Printf("[USER_AUTH][ACCOUNT] Merged user %s into %s via %s from IP: %s\n", owner.ID, user.ID, kind, clientIP)
+		if err := h.revokeAccessTokens(ctx, owner.ID, "account_merged"); err != nil {
+			fmt.Printf("[WARN] %v\n", err)
+		}
+		resp["merged_user_id"] = owner.ID
+	} else {
+		fmt.Printf("[USER_AUTH][ACCOUNT] User %s added a %s from IP: %s\n", user.ID, kind, clientIP)
+	}
+	if updated, err := h.DB.GetUserByID(ctx, user.ID); err == nil {
+		resp["user"] = updated
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// checkMergeable refuses merges that would lose access or data: the other account must have the same
+// role, no organization memberships and no identifier of its own beyond the one being moved
+func (h *Handler) checkMergeable(c *gin.Context, ctx context.Context, user, owner *models.User, role, kind string) bool {
+	if owner.ID == user.ID {
+		c.JSON(http.StatusConflict, models.ErrorResponse{Error: "Already set", Message: fmt.Sprintf("The account already has this %s", kind)})
+		return false
+	}
+	ownerRole, err := h.DB.GetUserRoleByID(ctx, owner.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to validate user role", Message: err.Error()})
+		return false
+	}
+	memberships, err := h.DB.GetOrgMembershipsByUserID(ctx, owner.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to load organizations", Message: err.Error()})
+		return false
+	}
+	if ownerRole != role || len(memberships) > 0 {
+		c.JSON(http.StatusConflict, models.ErrorResponse{Error: "Cannot merge accounts", Message: "The other account has a different role or organization access; contact support"})
+		return false
+	}
+	if (kind == "email" && owner.Phone != nil) || (kind == "phone" && owner.Email != nil) {
+		c.JSON(http.StatusConflict, models.ErrorResponse{Error: "Cannot merge accounts", Message: "The other account has both an email and a phone number; sign in to it instead"})
+		return false
+	}
+	return true
+}
+
+var languageTagPattern = regexp.MustCompile(`^[A-Za-z]{2,3}([-_][A-Za-z0-9]{2,8})*$`)
+
+// SetLanguage handles PUT /api/auth/account/language
+func (h *Handler) SetLanguage(c *gin.Context) {
+	var req models.SetLanguageRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid request data", Message: err.Error()})
+		return
+	}
+	lang := strings.TrimSpace(req.Language)
+	if lang != "" && !languageTagPattern.MatchString(lang) {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid language", Message: "Expected a language tag such as en or zh-CN"})
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := h.DB.SetPreferredLanguage(ctx, c.GetString("user_id"), lang); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "User not found", Message: "The account no longer exists"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to set language", Message: err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"language": lang})
+}
+
+// emailLocale picks the language of an email to address: the account's preferred language, then the
+// one the client asked for, then the request's Accept-Language. h.Email must not be nil.
+func (h *Handler) emailLocale(c *gin.Context, ctx context.Context, email, requested string) string {
+	preferred, err := h.DB.GetPreferredLanguageByEmail(ctx, email)
+	if err != nil {
+		fmt.Printf("[EMAIL] Failed to look up preferred language of %s: %v\n", email, err)
+	}
+	return h.Email.Locale(preferred, requested, c.GetHeader("Accept-Language"))
+}
@@ -64,6 +64,10 @@ func (h *Handler) AdminSendVerification(c *gin.Context) {
 	ctx, cancel = context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
+	if h.subjectLocked(c, ctx, req.Email) {
+		return
+	}
+
 	// Check rate limiting
 	maxRequests := getEnvInt("RATE_LIMIT_REQUESTS_PER_HOUR", 5)
 	rateLimited, err := h.DB.CheckRateLimit(ctx, clientIP, maxRequests, 1)
@@ -144,7 +148,8 @@ func (h *Handler) AdminSendVerification(c *gin.Context) {
 		ExpiresInMin: expirationMinutes,
 	}
 
-	if err := emailService.SendVerificationCode(req.Email, emailData); err != nil {
+	locale := h.emailLocale(c, ctx, req.Email, req.Language)
+	if err := emailService.SendVerificationCode(req.Email, locale, emailData); err != nil {
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
 			Error:   "Failed to send verification email",
 			Message: err.Error(),
@@ -152,9 +157,7 @@ func (h *Handler) AdminSendVerification(c *gin.Context) {
 		return
 	}
 
-	// Security logging - success
-	fmt.Printf("[ADMIN_AUTH] Verification code sent successfully to %s from IP: %s\n",
-		req.Email, clientIP)
+	h.recordAuthEvent(c, authEvent{Type: models.AuthEventCodeSent, Subject: req.Email, Channel: "email", Details: map[string]any{"admin": true}})
 
 	// Opportunistic cleanup after successful send (best effort)
 	if cleanErr := h.DB.CleanupExpiredCodes(ctx); cleanErr != nil {
@@ -206,21 +209,18 @@ func (h *Handler) AdminVerifyCode(c *gin.Context) {
 		return
 	}
 
-	// Get client IP for security logging
-	clientIP := getClientIP(c)
-	userAgent := c.GetHeader("User-Agent")
-
-	// Security logging
-	fmt.Printf("[ADMIN_AUTH] Code verification attempt from IP: %s, Email: %s, UserAgent: %s\n",
-		clientIP, req.Email, userAgent)
-
 	ctx, cancel = context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
+	if h.subjectLocked(c, ctx, req.Email) {
+		return
+	}
+
 	// Get verification code from database
 	verificationCode, err := h.DB.GetVerificationCode(ctx, req.Email)
 	if err != nil {
 		if err == pgx.ErrNoRows {
+			h.recordAuthEvent(c, authEvent{Type: models.AuthEventVerifyFailure, UserID: userID, Subject: req.Email, Channel: "email", Details: map[string]any{"admin": true, "reason": "no_valid_code"}})
 			c.JSON(http.StatusUnauthorized, models.ErrorResponse{
 				Error:   "Invalid or expired code",
 				Message: "No valid verification code found",
@@ -238,6 +238,8 @@ func (h *Handler) AdminVerifyCode(c *gin.Context) {
 	// Check if code has exceeded maximum attempts
 	maxAttempts := getEnvInt("MAX_CODE_ATTEMPTS", 3)
 	if verificationCode.Attempts >= maxAttempts {
+		h.recordAuthEvent(c, authEvent{Type: models.AuthEventVerifyFailure, UserID: userID, Subject: req.Email, Channel: "email", Details: map[string]any{"admin": true, "reason": "max_attempts"}})
+		h.recordSubjectFailure(c, ctx, req.Email, "email")
 		c.JSON(http.StatusUnauthorized, models.ErrorResponse{
 			Error:   "Maximum attempts exceeded",
 			Message: fmt.Sprintf("Code has exceeded maximum %d attempts", maxAttempts),
@@ -245,10 +247,6 @@ func (h *Handler) AdminVerifyCode(c *gin.Context) {
 		return
 	}
 
-	// DEBUG: Log verification details
-	fmt.Printf("[DEBUG] Verification attempt - Email: %s, Code from request: %s, Code hash from DB: %s, Expires at: %v, Used: %v, Attempts: %d\n",
-		req.Email, req.Code, verificationCode.CodeHash, verificationCode.ExpiresAt, verificationCode.Used, verificationCode.Attempts)
-
 	// Verify the code
 	if err := bcrypt.CompareHashAndPassword([]byte(verificationCode.CodeHash), []byte(req.Code)); err != nil {
 		// Increment attempt count
@@ -256,9 +254,9 @@ func (h *Handler) AdminVerifyCode(c *gin.Context) {
 			fmt.Printf("Failed to update attempt count: %v\n", updateErr)
 		}
 
-		// Security logging - failed attempt
-		fmt.Printf("[ADMIN_AUTH] FAILED verification attempt from IP: %s, Email: %s, Attempts: %d, Error: %v\n",
-			clientIP, req.Email, verificationCode.Attempts+1, err)
+		h.recordAuthEvent(c, authEvent{Type: models.AuthEventVerifyFailure, UserID: userID, Subject: req.Email, Channel: "email",
+			Details: map[string]any{"admin": true, "reason": "wrong_code", "attempts": verificationCode.Attempts + 1}})
+		h.recordSubjectFailure(c, ctx, req.Email, "email")
 
 		c.JSON(http.StatusUnauthorized, models.ErrorResponse{
 			Error:   "Invalid verification code",
@@ -279,6 +277,29 @@ func (h *Handler) AdminVerifyCode(c *gin.Context) {
 	}
 	fmt.Printf("[DEBUG] Code marked as used successfully\n")
 
+	if err := h.DB.MarkEmailVerified(ctx, userID); err != nil {
+		fmt.Printf("[DEBUG] Failed to mark email verified for user %s: %v\n", userID, err)
+	}
+
+	h.recordAuthEvent(c, authEvent{Type: models.AuthEventVerifySuccess, UserID: userID, Subject: req.Email, Channel: "email", Details: map[string]any{"admin": true}})
+	h.clearSubjectFailures(ctx, req.Email)
+
+	// Admins with a security key (or all Admins when keys are mandatory) finish with a second factor
+	if role == "Admin" {
+		if pending := h.beginAdminSecondFactor(c, ctx, userID, req.Email, role); pending {
+			return
+		}
+	}
+
+	h.completeAdminLogin(c, ctx, userID, req.Email, role, nil)
+}
+
+// completeAdminLogin issues the access and refresh tokens of a successful admin-panel login.
+// extra fields (e.g. freshly generated recovery codes) are merged into the response.
+func (h *Handler) completeAdminLogin(c *gin.Context, ctx context.Context, userID, email, role string, extra gin.H) {
+	clientIP := getClientIP(c)
+	userAgent := c.GetHeader("User-Agent")
+
 	// Update last login timestamp for the user
 	fmt.Printf("[DEBUG] Updating last login for user: %s\n", userID)
 	if err := h.DB.UpdateLastLogin(ctx, userID); err != nil {
@@ -288,7 +309,7 @@ func (h *Handler) AdminVerifyCode(c *gin.Context) {
 
 	// Generate JWT token for admin with role claim
 	fmt.Printf("[DEBUG] Generating JWT token for user: %s, role: %s\n", userID, role)
-	token, err := h.generateJWTToken(userID, req.Email, role)
+	token, err := h.generateJWTToken(userID, email, role)
 	if err != nil {
 		fmt.Printf("[DEBUG] Failed to generate JWT token - Error: %v\n", err)
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
@@ -318,7 +339,7 @@ func (h *Handler) AdminVerifyCode(c *gin.Context) {
 	refreshHash := hashRefreshTokenString(plainRefresh)
 	refreshExpiresAt := time.Now().Add(refreshTokenTTL())
 	fmt.Printf("[DEBUG] Creating refresh token in database\n")
-	if _, err := h.DB.CreateRefreshToken(ctx, userID, refreshHash, refreshExpiresAt, clientIP, userAgent); err != nil {
+	if _, err := h.DB.CreateRefreshToken(ctx, userID, refreshHash, refreshExpiresAt, clientIP, userAgent, role == "Admin"); err != nil {
 		fmt.Printf("[DEBUG] Failed to create refresh token in database - Error: %v\n", err)
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to persist refresh token", Message: err.Error()})
 		return
@@ -326,24 +347,26 @@ func (h *Handler) AdminVerifyCode(c *gin.Context) {
 
 	// Create admin user response
 	adminUser := models.AdminUser{
-		Email:     req.Email,
+		Email:     email,
 		Role:      role,
 		CreatedAt: time.Now(),
 	}
 
-	// Security logging - successful authentication
-	fmt.Printf("[ADMIN_AUTH] SUCCESSFUL authentication for %s from IP: %s, Token expires: %s\n",
-		req.Email, clientIP, tokenExpiresAt.Format("2006-01-02 15:04:05"))
+	h.recordAuthEvent(c, authEvent{Type: models.AuthEventAdminLogin, UserID: userID, Subject: email, Details: map[string]any{"role": role}})
 
 	// Return success response (include refresh token fields)
-	c.JSON(http.StatusOK, gin.H{
+	resp := gin.H{
 		"token":              token,
 		"expires_at":         tokenExpiresAt,
 		"expiresAt":          tokenExpiresAt,
 		"refresh_token":      plainRefresh,
 		"refresh_expires_at": refreshExpiresAt,
 		"user":               adminUser,
-	})
+	}
+	for k, v := range extra {
+		resp[k] = v
+	}
+	c.JSON(http.StatusOK, resp)
 }
 
 // Helper functions
@@ -0,0 +1,117 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/expotoworld/expotoworld/backend/auth-service/internal/models"
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+)
+
+// normalizeIDs sorts and de-duplicates ids, rejecting non-positive values
+func normalizeIDs(ids []int) ([]int, bool) {
+	out := make([]int, 0, len(ids))
+	seen := map[int]bool{}
+	for _, id := range ids {
+		if id <= 0 {
+			return nil, false
+		}
+		if !seen[id] {
+			seen[id] = true
+			out = append(out, id)
+		}
+	}
+	sort.Ints(out)
+	return out, true
+}
+
+// AdminListScopes handles GET /api/auth/admin/scopes
+func (h *Handler) AdminListScopes(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	scopes, err := h.DB.ListAdminScopes(ctx)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to list admin scopes", Message: err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"scopes": scopes})
+}
+
+// AdminSetScope handles PUT /api/auth/admin/scopes/:user_id
+// Body: {"region_ids": [1, 2], "store_ids": [7]} restricts the admin to those regions' stores plus the
+// listed stores. Takes effect on the admin's next token refresh.
+func (h *Handler) AdminSetScope(c *gin.Context) {
+	var req models.SetAdminScopeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid request", Message: err.Error()})
+		return
+	}
+	regionIDs, ok1 := normalizeIDs(req.RegionIDs)
+	storeIDs, ok2 := normalizeIDs(req.StoreIDs)
+	if !ok1 || !ok2 {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid request", Message: "region_ids and store_ids must be positive integers"})
+		return
+	}
+	if len(regionIDs) == 0 && len(storeIDs) == 0 {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid request", Message: "Provide at least one region_id or store_id; delete the scope to grant full access"})
+		return
+	}
+
+	userID := c.Param("user_id")
+	adminID := fmt.Sprint(c.MustGet("user_id"))
+	if userID == adminID {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid request", Message: "You cannot restrict your own access"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	role, err := h.DB.GetUserRoleByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "User not found", Message: "No user with this id"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "User lookup failed", Message: err.Error()})
+		return
+	}
+	if role != "Admin" {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid request", Message: "Scopes can only be granted to users with the Admin role"})
+		return
+	}
+
+	scope, err := h.DB.SetAdminScope(ctx, userID, regionIDs, storeIDs, adminID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to save admin scope", Message: err.Error()})
+		return
+	}
+	log.Printf("[ADMIN_SCOPE] admin=%s scoped user=%s regions=%v stores=%v", adminID, userID, regionIDs, storeIDs)
+	c.JSON(http.StatusOK, scope)
+}
+
+// AdminDeleteScope handles DELETE /api/auth/admin/scopes/:user_id (restores full admin access)
+func (h *Handler) AdminDeleteScope(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	userID := c.Param("user_id")
+	removed, err := h.DB.DeleteAdminScope(ctx, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to delete admin scope", Message: err.Error()})
+		return
+	}
+	if !removed {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "Scope not found", Message: "This admin is not scoped"})
+		return
+	}
+	log.Printf("[ADMIN_SCOPE] admin=%v removed scope of user=%s", c.MustGet("user_id"), userID)
+	c.JSON(http.StatusOK, gin.H{"message": "Admin scope removed"})
+}
@@ -0,0 +1,127 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/expotoworld/expotoworld/backend/auth-service/internal/logging"
+	"github.com/expotoworld/expotoworld/backend/auth-service/internal/models"
+	"github.com/gin-gonic/gin"
+)
+
+// authEvent describes an event for recordAuthEvent; empty fields are stored as NULL
+type authEvent struct {
+	Type    string
+	UserID  string
+	Subject string
+	Channel string
+	Details map[string]any
+}
+
+func nullString(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
+// recordAuthEvent writes the event to auth_audit_log with the request's IP, user agent and acting
+// user, and logs it. Failures are logged and never fail the request.
+func (h *Handler) recordAuthEvent(c *gin.Context, ev authEvent) {
+	entry := &models.AuthEvent{
+		EventType: ev.Type,
+		UserID:    nullString(ev.UserID),
+		Subject:   nullString(ev.Subject),
+		Channel:   nullString(ev.Channel),
+		ClientIP:  nullString(getClientIP(c)),
+		UserAgent: nullString(c.GetHeader("User-Agent")),
+		ActorID:   nullString(c.GetString("user_id")),
+	}
+	if len(ev.Details) > 0 {
+		entry.Details, _ = json.Marshal(ev.Details)
+	}
+
+	level := "info"
+	if strings.HasSuffix(ev.Type, "_failure") {
+		level = "warn"
+	}
+	fields := map[string]interface{}{"event_type": ev.Type, "client_ip": getClientIP(c)}
+	for k, v := range map[string]string{"user_id": ev.UserID, "subject": ev.Subject, "channel": ev.Channel} {
+		if v != "" {
+			fields[k] = v
+		}
+	}
+	for k, v := range ev.Details {
+		fields[k] = v
+	}
+	logging.LogKV(level, "auth_event", fields)
+
+	if h.DB == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	if err := h.DB.InsertAuthEvent(ctx, entry); err != nil {
+		logging.LogKV("error", "AuthEventWriteFailed", map[string]interface{}{"event_type": ev.Type, "error": err.Error()})
+	}
+}
+
+// ListAuthEvents handles GET /api/admin/auth-events
+// Filters: user_id, subject (email or phone), ip, event_type (comma-separated), from/to (RFC3339);
+// paginate with limit (default 100, max 500) and before_id.
+func (h *Handler) ListAuthEvents(c *gin.Context) {
+	f := models.AuthEventFilter{
+		UserID:   c.Query("user_id"),
+		Subject:  strings.TrimSpace(c.Query("subject")),
+		ClientIP: c.Query("ip"),
+		Limit:    100,
+	}
+	for _, t := range strings.Split(c.Query("event_type"), ",") {
+		if t = strings.TrimSpace(t); t != "" {
+			f.EventType = append(f.EventType, t)
+		}
+	}
+	if v := c.Query("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 || n > 500 {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid limit", Message: "limit must be between 1 and 500"})
+			return
+		}
+		f.Limit = n
+	}
+	if v := c.Query("before_id"); v != "" {
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || n <= 0 {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid before_id", Message: "before_id must be a positive integer"})
+			return
+		}
+		f.BeforeID = n
+	}
+	for key, dst := range map[string]**time.Time{"from": &f.From, "to": &f.To} {
+		if v := c.Query(key); v != "" {
+			t, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid " + key, Message: "Expected an RFC3339 timestamp"})
+				return
+			}
+			*dst = &t
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+	events, err := h.DB.ListAuthEvents(ctx, f)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to list auth events", Message: err.Error()})
+		return
+	}
+	resp := gin.H{"events": events}
+	if len(events) == f.Limit {
+		resp["next_before_id"] = events[len(events)-1].ID
+	}
+	c.JSON(http.StatusOK, resp)
+}
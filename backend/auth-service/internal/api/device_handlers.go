@@ -0,0 +1,149 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/expotoworld/expotoworld/backend/auth-service/internal/models"
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+)
+
+// maxPushTargetUsers caps the users one push target lookup may ask for
+const maxPushTargetUsers = 100
+
+// RegisterDevice handles POST /api/auth/devices: the app registers (or refreshes) its push token
+// for the session identified by its refresh token
+func (h *Handler) RegisterDevice(c *gin.Context) {
+	userID := c.GetString("user_id")
+	var req models.RegisterDeviceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid request data", Message: err.Error()})
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	sessionID, owner, _, expiresAt, _, revoked, _, err := h.DB.GetRefreshToken(ctx, hashRefreshTokenString(strings.TrimSpace(req.RefreshToken)))
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to validate session", Message: err.Error()})
+		return
+	}
+	if err != nil || owner != userID || revoked || time.Now().After(expiresAt) {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid refresh token", Message: "The refresh token is not an active session of this account"})
+		return
+	}
+
+	device, err := h.DB.UpsertDevice(ctx, &models.Device{
+		UserID:     userID,
+		SessionID:  sessionID,
+		Provider:   req.Provider,
+		Platform:   req.Platform,
+		PushToken:  strings.TrimSpace(req.PushToken),
+		Name:       strings.TrimSpace(req.Name),
+		AppVersion: strings.TrimSpace(req.AppVersion),
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to register device", Message: err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"device": device})
+}
+
+// ListDevices handles GET /api/auth/devices: the signed-in user's devices with their sessions
+func (h *Handler) ListDevices(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	devices, err := h.DB.ListDevices(ctx, c.GetString("user_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to list devices", Message: err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"devices": devices})
+}
+
+// RenameDevice handles PUT /api/auth/devices/:id
+func (h *Handler) RenameDevice(c *gin.Context) {
+	var req models.RenameDeviceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid request data", Message: err.Error()})
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	renamed, err := h.DB.RenameDevice(ctx, c.GetString("user_id"), c.Param("id"), strings.TrimSpace(req.Name))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to rename device", Message: err.Error()})
+		return
+	}
+	if !renamed {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "Not found", Message: "No such device"})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// DeleteDevice handles DELETE /api/auth/devices/:id: stops pushes to the device; with
+// ?sign_out=true its session is revoked too
+func (h *Handler) DeleteDevice(c *gin.Context) {
+	userID := c.GetString("user_id")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	sessionID, err := h.DB.DeleteDevice(ctx, userID, c.Param("id"))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "Not found", Message: "No such device"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to remove device", Message: err.Error()})
+		return
+	}
+	if c.Query("sign_out") == "true" && sessionID != "" {
+		if err := h.DB.RevokeRefreshToken(ctx, sessionID); err != nil {
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to sign out device", Message: err.Error()})
+			return
+		}
+		h.recordAuthEvent(c, authEvent{Type: models.AuthEventRevocation, UserID: userID, Details: map[string]any{"session_ids": []string{sessionID}, "device_id": c.Param("id")}})
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// ListPushTargets handles GET /api/auth/internal/push-targets?user_id=...&user_id=... for services
+// sending push notifications; only devices with an active session are returned
+func (h *Handler) ListPushTargets(c *gin.Context) {
+	userIDs := c.QueryArray("user_id")
+	if len(userIDs) == 0 || len(userIDs) > maxPushTargetUsers {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid request", Message: fmt.Sprintf("Provide 1 to %d user_id parameters", maxPushTargetUsers)})
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	targets, err := h.DB.ListPushTargets(ctx, userIDs)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to list push targets", Message: err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"targets": targets})
+}
+
+// InvalidatePushTokens handles POST /api/auth/internal/push-targets/invalidate: services report
+// tokens APNs or FCM rejected as unregistered so they are not returned again
+func (h *Handler) InvalidatePushTokens(c *gin.Context) {
+	var req models.InvalidatePushTokensRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid request data", Message: err.Error()})
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	deleted, err := h.DB.DeletePushTokens(ctx, req.PushTokens)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to invalidate push tokens", Message: err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"deleted": deleted})
+}
@@ -11,11 +11,14 @@ import (
 	"os"
 	"strconv"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/expotoworld/expotoworld/backend/auth-service/internal/db"
 	"github.com/expotoworld/expotoworld/backend/auth-service/internal/models"
 	"github.com/expotoworld/expotoworld/backend/auth-service/internal/services"
+	"github.com/expotoworld/expotoworld/backend/common/auth"
+	"github.com/expotoworld/expotoworld/backend/common/jwks"
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/jackc/pgx/v5"
@@ -24,18 +27,45 @@ import (
 
 // Handler holds the database connection and handles HTTP requests
 type Handler struct {
-	DB    *db.Database
-	Email *services.EmailService
-	SMS   *services.SmsService
+	DB         *db.Database
+	Email      *services.EmailService
+	SMS        *services.SmsService
+	PhoneCodes *services.PhoneCodeSender
+	RateLimits *services.RateLimitService
+	// Signer signs access tokens (nil: HS256 with JWT_SECRET); Tokens verifies them
+	Signer *jwks.Signer
+	Tokens *jwks.Verifier
+	// OIDC holds the configured native sign-in providers ("apple", "google")
+	OIDC map[string]*services.OIDCProvider
+	// WeChat exchanges mini program and app login codes (nil when not configured)
+	WeChat *services.WeChatService
+	// Captcha challenges risky verification code requests (nil when not configured)
+	Captcha *services.CaptchaService
+	// GeoIP locates login IPs for risk scoring (nil when not configured)
+	GeoIP *services.GeoIPService
+	// versions caches users' token versions for AuthMiddleware
+	versions *auth.VersionCache
+	// loginHistoryPrunedAt is when this instance last pruned the login history (unix nanoseconds)
+	loginHistoryPrunedAt atomic.Int64
 }
 
-// NewHandler creates a new handler instance
-func NewHandler(database *db.Database, email *services.EmailService, sms *services.SmsService) *Handler {
-	return &Handler{
-		DB:    database,
-		Email: email,
-		SMS:   sms,
-	}
+// NewHandler creates a new handler instance; sms, whatsapp, signer, wechat, captcha and geoip may be nil and oidc empty when not configured
+func NewHandler(database *db.Database, email *services.EmailService, sms *services.SmsService, whatsapp *services.WhatsAppService, rateLimits *services.RateLimitService, signer *jwks.Signer, oidc map[string]*services.OIDCProvider, wechat *services.WeChatService, captcha *services.CaptchaService, geoip *services.GeoIPService) *Handler {
+	h := &Handler{
+		DB:         database,
+		Email:      email,
+		SMS:        sms,
+		PhoneCodes: &services.PhoneCodeSender{SMS: sms, WhatsApp: whatsapp},
+		RateLimits: rateLimits,
+		Signer:     signer,
+		Tokens:     signer.Verifier([]byte(os.Getenv("JWT_SECRET"))),
+		OIDC:       oidc,
+		WeChat:     wechat,
+		Captcha:    captcha,
+		GeoIP:      geoip,
+	}
+	h.versions = h.newTokenVersionCache()
+	return h
 }
 
 // Health endpoint for health checks (readiness)
@@ -62,6 +92,37 @@ func refreshTokenTTL() time.Duration {
 	return time.Duration(days) * 24 * time.Hour
 }
 
+// refreshTokenInactivity is how long a refresh token may go unused before it is revoked (0 disables).
+func refreshTokenInactivity() time.Duration {
+	days := getEnvInt("REFRESH_TOKEN_INACTIVITY_DAYS", 14)
+	if days <= 0 {
+		return 0
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
+
+// refreshTokenMaxLifetime caps sliding expiration, measured from when the token was issued.
+func refreshTokenMaxLifetime() time.Duration {
+	days := getEnvInt("REFRESH_TOKEN_MAX_LIFETIME_DAYS", 90)
+	return time.Duration(days) * 24 * time.Hour
+}
+
+// slidingRefreshExpiry extends a token's expiry on use (REFRESH_TOKEN_SLIDING, default on),
+// never past its absolute max lifetime and never shortening the current expiry.
+func slidingRefreshExpiry(issuedAt, expiresAt, now time.Time) time.Time {
+	if strings.EqualFold(os.Getenv("REFRESH_TOKEN_SLIDING"), "false") {
+		return expiresAt
+	}
+	next := now.Add(refreshTokenTTL())
+	if limit := issuedAt.Add(refreshTokenMaxLifetime()); next.After(limit) {
+		next = limit
+	}
+	if next.Before(expiresAt) {
+		return expiresAt
+	}
+	return next
+}
+
 func (h *Handler) Health(c *gin.Context) {
 
 	// If DB is not initialized yet, report not ready without panicking
@@ -88,6 +149,17 @@ func (h *Handler) Health(c *gin.Context) {
 	})
 }
 
+// JWKS serves the public keys access tokens are signed with (GET /.well-known/jwks.json). Services
+// cache it, so it also lists keys being rotated in or out; it is empty while tokens are HS256.
+func (h *Handler) JWKS(c *gin.Context) {
+	set := jwks.Set{Keys: []jwks.JWK{}}
+	if h.Signer != nil {
+		set = h.Signer.Set()
+	}
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, set)
+}
+
 // Signup handles user registration (DEPRECATED - use email verification instead)
 func (h *Handler) Signup(c *gin.Context) {
 	c.Header("X-Deprecated", "true")
@@ -108,12 +180,12 @@ func (h *Handler) Login(c *gin.Context) {
 	})
 }
 
-// generateJWTToken creates a JWT token for the user
+// generateJWTToken creates a JWT token for the user, signed with the JWKS signing key (or the
+// legacy HS256 secret when no signing key is configured)
 func (h *Handler) generateJWTToken(userID string, email string, role string) (string, error) {
-	// Get JWT secret from environment
 	secret := os.Getenv("JWT_SECRET")
-	if secret == "" {
-		return "", fmt.Errorf("JWT secret not configured")
+	if h.Signer == nil && secret == "" {
+		return "", fmt.Errorf("JWT signing key not configured")
 	}
 
 	// Get access token expiration: default 30 minutes.
@@ -140,34 +212,73 @@ func (h *Handler) generateJWTToken(userID string, email string, role string) (st
 		claims["role"] = role
 	}
 
-	// Enrich with org memberships
+	// Enrich with org memberships, permissions and admin scope
 	if h.DB != nil {
 		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 		defer cancel()
-		if orgs, err := h.DB.GetOrgMembershipsByUserID(ctx, userID); err == nil {
-			arr := make([]map[string]string, 0, len(orgs))
-			for _, m := range orgs {
-				arr = append(arr, map[string]string{
-					"org_id":   m.OrgID,
-					"org_type": m.OrgType,
-					"org_role": m.OrgRole,
-					"name":     m.Name,
-				})
-			}
-			claims["org_memberships"] = arr
+		extra, err := h.userClaims(ctx, userID, role)
+		if err != nil {
+			return "", err
+		}
+		for k, v := range extra {
+			claims[k] = v
 		}
 	}
 
-	// Create token
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return h.signClaims(claims)
+}
 
-	// Sign token
-	tokenString, err := token.SignedString([]byte(secret))
+// userClaims returns the claims derived from the user's current org memberships, roles and admin
+// scope, as embedded in access tokens and returned by token introspection
+func (h *Handler) userClaims(ctx context.Context, userID, role string) (jwt.MapClaims, error) {
+	claims := jwt.MapClaims{}
+	version, err := h.DB.GetTokenVersion(ctx, userID)
 	if err != nil {
-		return "", err
+		return nil, fmt.Errorf("failed to load token version: %w", err)
+	}
+	claims[auth.TokenVersionClaim] = version
+	if orgs, err := h.DB.GetOrgMembershipsByUserID(ctx, userID); err == nil {
+		arr := make([]map[string]string, 0, len(orgs))
+		for _, m := range orgs {
+			arr = append(arr, map[string]string{
+				"org_id":   m.OrgID,
+				"org_type": m.OrgType,
+				"org_role": m.OrgRole,
+				"name":     m.Name,
+			})
+		}
+		claims["org_memberships"] = arr
+	}
+	// Effective permissions from the user's roles; services check these rather than the role
+	perms, err := h.userPermissions(ctx, userID, role)
+	if err != nil {
+		return nil, err
 	}
+	claims["permissions"] = perms
+	// Regional admins carry their scope; if it can't be read, don't issue an unrestricted token
+	if role == "Admin" {
+		scope, err := h.DB.GetAdminScope(ctx, userID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load admin scope: %w", err)
+		}
+		if scope != nil {
+			claims["admin_scope"] = scope.Claim()
+		}
+	}
+	return claims, nil
+}
 
-	return tokenString, nil
+// signClaims signs an access token with the JWKS signing key (or the legacy HS256 secret)
+func (h *Handler) signClaims(claims jwt.MapClaims) (string, error) {
+	secret := os.Getenv("JWT_SECRET")
+	if h.Signer == nil && secret == "" {
+		return "", fmt.Errorf("JWT signing key not configured")
+	}
+
+	if h.Signer != nil {
+		return h.Signer.Sign(claims)
+	}
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(secret))
 }
 
 // Refresh issues a new JWT based on a valid existing token
@@ -193,23 +304,16 @@ func (h *Handler) Refresh(c *gin.Context) {
 	existingToken := parts[1]
 
 	// Parse existing token
-	secret := os.Getenv("JWT_SECRET")
-	if secret == "" {
-
+	if !h.Tokens.Configured() {
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
 			Error:   "Server not configured",
-			Message: "JWT secret missing",
+			Message: "JWT signing key missing",
 		})
 		return
 	}
 
-	token, err := jwt.Parse(existingToken, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, jwt.ErrSignatureInvalid
-		}
-		return []byte(secret), nil
-	})
-	if err != nil || !token.Valid {
+	claims, err := h.Tokens.Parse(existingToken)
+	if err != nil {
 		c.JSON(http.StatusUnauthorized, models.ErrorResponse{
 			Error:   "Invalid token",
 			Message: "The provided token is invalid or expired",
@@ -217,19 +321,41 @@ func (h *Handler) Refresh(c *gin.Context) {
 		return
 	}
 
-	// Extract claims
-	claims, ok := token.Claims.(jwt.MapClaims)
-	if !ok {
+	userID, _ := claims["user_id"].(string)
+	email, _ := claims["email"].(string)
+	roleStr, _ := claims["role"].(string)
+	if userID == "" {
+		// Service tokens are renewed with the client-credentials grant
 		c.JSON(http.StatusUnauthorized, models.ErrorResponse{
-			Error:   "Invalid token claims",
-			Message: "Could not parse token claims",
+			Error:   "Invalid token",
+			Message: "The provided token does not identify a user",
+		})
+		return
+	}
+	if impersonator(claims) != "" {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+			Error:   "Invalid token",
+			Message: "Impersonation tokens can't be refreshed",
+		})
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	version, err := h.DB.GetTokenVersion(ctx, userID)
+	cancel()
+	if err != nil && err != pgx.ErrNoRows {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "Failed to validate token",
+			Message: err.Error(),
+		})
+		return
+	}
+	if err != nil || auth.TokenVersion(claims) < version {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+			Error:   "Token revoked",
+			Message: "The provided token has been revoked; please sign in again",
 		})
 		return
 	}
-
-	userID, _ := claims["user_id"].(string)
-	email, _ := claims["email"].(string)
-	roleStr, _ := claims["role"].(string)
 
 	// Generate new token
 	newToken, err := h.generateJWTToken(userID, email, roleStr)
@@ -250,6 +376,7 @@ func (h *Handler) Refresh(c *gin.Context) {
 	}
 	expiresAt := time.Now().Add(time.Duration(expirationHours) * time.Hour)
 
+	h.recordAuthEvent(c, authEvent{Type: models.AuthEventTokenRefresh, UserID: userID, Details: map[string]any{"legacy": true}})
 	c.JSON(http.StatusOK, gin.H{
 		"token":      newToken,
 		"expires_at": expiresAt,
@@ -277,11 +404,26 @@ func (h *Handler) RefreshWithRefreshToken(c *gin.Context) {
 
 	// Validate refresh token
 	hash := hashRefreshTokenString(req.RefreshToken)
-	id, userID, expiresAt, revoked, err := h.DB.GetRefreshToken(ctx, hash)
-	if err != nil || revoked || time.Now().After(expiresAt) {
+	id, userID, issuedAt, expiresAt, lastActiveAt, revoked, adminVerified, err := h.DB.GetRefreshToken(ctx, hash)
+	now := time.Now()
+	if err != nil || revoked || now.After(expiresAt) {
+		reason := "unknown_token"
+		if err == nil && revoked {
+			reason = "revoked"
+		} else if err == nil {
+			reason = "expired"
+		}
+		h.recordAuthEvent(c, authEvent{Type: models.AuthEventRefreshFailure, UserID: userID, Details: map[string]any{"reason": reason}})
 		c.JSON(http.StatusUnauthorized, models.ErrorResponse{Error: "Invalid refresh token", Message: "Token is invalid, expired, or revoked"})
 		return
 	}
+	// Abandoned devices lose their session after the inactivity window, regardless of absolute TTL
+	if inactivity := refreshTokenInactivity(); inactivity > 0 && now.Sub(lastActiveAt) > inactivity {
+		_ = h.DB.RevokeRefreshToken(ctx, id)
+		h.recordAuthEvent(c, authEvent{Type: models.AuthEventRefreshFailure, UserID: userID, Details: map[string]any{"reason": "inactive"}})
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse{Error: "Invalid refresh token", Message: "Session expired due to inactivity"})
+		return
+	}
 
 	// Determine rotation behavior (default false)
 	rotate := req.Rotate != nil && *req.Rotate
@@ -309,6 +451,10 @@ func (h *Handler) RefreshWithRefreshToken(c *gin.Context) {
 			}
 		}
 	}
+	// Only sessions that passed the admin login (and its security key step) refresh into Admin tokens
+	if roleStr == "Admin" && !adminVerified {
+		roleStr = ""
+	}
 
 	// Issue new access token
 	token, err := h.generateJWTToken(userID, emailStr, roleStr)
@@ -335,11 +481,17 @@ func (h *Handler) RefreshWithRefreshToken(c *gin.Context) {
 		refreshExpiresAt := time.Now().Add(refreshTokenTTL())
 		clientIP := getClientIP(c)
 		userAgent := c.GetHeader("User-Agent")
-		if _, err := h.DB.CreateRefreshToken(ctx, userID, newHash, refreshExpiresAt, clientIP, userAgent); err != nil {
+		newID, err := h.DB.CreateRefreshToken(ctx, userID, newHash, refreshExpiresAt, clientIP, userAgent, adminVerified)
+		if err != nil {
 			c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to persist refresh token", Message: err.Error()})
 			return
 		}
+		// Registered devices follow their session to the new token
+		if err := h.DB.MoveDeviceSession(ctx, id, newID); err != nil {
+			fmt.Printf("[USER_AUTH] Failed to move devices to rotated session: %v\n", err)
+		}
 
+		h.recordAuthEvent(c, authEvent{Type: models.AuthEventTokenRefresh, UserID: userID, Details: map[string]any{"rotated": true}})
 		// On rotation, return both the new access token and the new refresh token
 		c.JSON(http.StatusOK, gin.H{
 			"token":              token,
@@ -350,10 +502,17 @@ func (h *Handler) RefreshWithRefreshToken(c *gin.Context) {
 		return
 	}
 
-	// No rotation path: only issue a new access token; do not create or return a new refresh token
+	// No rotation path: record activity and slide the existing token's expiry
+	refreshExpiresAt := slidingRefreshExpiry(issuedAt, expiresAt, now)
+	if err := h.DB.TouchRefreshToken(ctx, id, refreshExpiresAt); err != nil {
+		fmt.Printf("[USER_AUTH] Failed to record refresh token use: %v\n", err)
+		refreshExpiresAt = expiresAt
+	}
+	h.recordAuthEvent(c, authEvent{Type: models.AuthEventTokenRefresh, UserID: userID, Details: map[string]any{"rotated": false}})
 	c.JSON(http.StatusOK, gin.H{
-		"token":      token,
-		"expires_at": accessExpiresAt,
+		"token":              token,
+		"expires_at":         accessExpiresAt,
+		"refresh_expires_at": refreshExpiresAt,
 	})
 }
 
@@ -363,65 +522,30 @@ func isDuplicateEmailError(err error) bool {
 		strings.Contains(err.Error(), "users_email_key")
 }
 
-// AuthMiddleware validates JWT tokens
-func AuthMiddleware() gin.HandlerFunc {
+// AuthMiddleware validates JWT tokens; tokens issued before a forced logout or sign-in method
+// change are revoked
+func (h *Handler) AuthMiddleware() gin.HandlerFunc {
+	cfg := auth.Config{
+		Keys: h.Tokens,
+		Error: func(c *gin.Context, status int, err, message string) {
+			c.JSON(status, models.ErrorResponse{Error: err, Message: message})
+		},
+	}
+	if h.DB != nil {
+		cfg.Versions = h.versions
+	}
 	return func(c *gin.Context) {
-		authHeader := c.GetHeader("Authorization")
-		if authHeader == "" {
-			c.JSON(http.StatusUnauthorized, models.ErrorResponse{
-				Error:   "Authorization header required",
-				Message: "Please provide a valid authorization token",
-			})
-			c.Abort()
-			return
-		}
-
-		// Extract token from "Bearer <token>"
-		tokenParts := strings.Split(authHeader, " ")
-		if len(tokenParts) != 2 || tokenParts[0] != "Bearer" {
-			c.JSON(http.StatusUnauthorized, models.ErrorResponse{
-				Error:   "Invalid authorization format",
-				Message: "Authorization header must be in format 'Bearer <token>'",
-			})
-			c.Abort()
+		if !cfg.Authenticate(c) {
 			return
 		}
-
-		tokenString := tokenParts[1]
-
-		// Parse and validate token
-		secret := os.Getenv("JWT_SECRET")
-		if secret == "" {
-			c.JSON(http.StatusInternalServerError, models.ErrorResponse{
-				Error:   "Server not configured",
-				Message: "JWT secret missing",
-			})
-			c.Abort()
-			return
-		}
-
-		token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, jwt.ErrSignatureInvalid
-			}
-			return []byte(secret), nil
-		})
-
-		if err != nil || !token.Valid {
+		if auth.UserID(c) == "" {
 			c.JSON(http.StatusUnauthorized, models.ErrorResponse{
 				Error:   "Invalid token",
-				Message: "The provided token is invalid or expired",
+				Message: "Service tokens do not identify a user",
 			})
 			c.Abort()
 			return
 		}
-
-		// Extract claims
-		if claims, ok := token.Claims.(jwt.MapClaims); ok {
-			c.Set("user_id", claims["user_id"])
-			c.Set("email", claims["email"])
-		}
-
 		c.Next()
 	}
 }
@@ -461,52 +585,23 @@ func (h *Handler) UserSendVerification(c *gin.Context) {
 
 	// Get client IP
 	clientIP := getClientIP(c)
-	userAgent := c.GetHeader("User-Agent")
-
-	// Security logging
-	fmt.Printf("[USER_AUTH] Verification request from IP: %s, Email: %s, UserAgent: %s\n",
-		clientIP, req.Email, userAgent)
 
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	// Optional stricter mode for clients like ebook-editor
-	requireExisting := strings.EqualFold(c.GetHeader("X-Require-Existing"), "true") || c.Query("require_existing") == "true"
-	requiredRole := strings.TrimSpace(c.GetHeader("X-Require-Role"))
-	if requireExisting || requiredRole != "" {
-		// Must be an existing user (and optionally with specific role)
-		if id, role, _, err := h.DB.GetUserRoleStatusByEmail(ctx, req.Email); err != nil {
-			if err == pgx.ErrNoRows {
-				c.JSON(http.StatusForbidden, models.ErrorResponse{Error: "User not allowed", Message: "User does not exist"})
-				return
-			}
-			c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to validate user", Message: err.Error()})
-			return
-		} else {
-			_ = id // not used here, but ensures retrieval succeeded
-			if requiredRole != "" && !strings.EqualFold(role, requiredRole) {
-				c.JSON(http.StatusForbidden, models.ErrorResponse{Error: "User not allowed", Message: "User role not permitted"})
-				return
-			}
-		}
+	if !h.checkEmailAllowed(c, ctx, req.Email) {
+		return
 	}
 
-	// Check rate limiting
-	maxRequests := getEnvInt("RATE_LIMIT_REQUESTS_PER_HOUR", 5)
-	rateLimited, err := h.DB.CheckUserRateLimit(ctx, clientIP, maxRequests, 1)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
-			Error:   "Rate limit check failed",
-			Message: err.Error(),
-		})
+	// Check rate limiting (tier depends on the email and the IP's history)
+	if h.subjectLocked(c, ctx, req.Email) {
 		return
 	}
-
-	if rateLimited {
-		c.JSON(http.StatusTooManyRequests, models.ErrorResponse{
-			Error:   "Rate limit exceeded",
-			Message: fmt.Sprintf("Maximum %d requests per hour allowed", maxRequests),
-		})
+	decision, ok := h.checkUserRateLimit(ctx, c, "email", clientIP, req.Email)
+	if !ok {
+		return
+	}
+	if !h.checkCaptcha(ctx, c, "email", req.Email, req.CaptchaToken, decision) {
 		return
 	}
 
@@ -550,7 +645,7 @@ func (h *Handler) UserSendVerification(c *gin.Context) {
 	}
 
 	// Increment rate limit (best effort)
-	if err := h.DB.IncrementUserRateLimit(ctx, clientIP); err != nil {
+	if err := h.RateLimits.Record(ctx, "email", clientIP, req.Email, decision); err != nil {
 		// Log error but don't fail the request
 		fmt.Printf("Failed to increment user rate limit: %v\n", err)
 	}
@@ -574,7 +669,8 @@ func (h *Handler) UserSendVerification(c *gin.Context) {
 		ExpiresInMin: expirationMinutes,
 	}
 
-	if err := emailService.SendUserVerificationCode(req.Email, emailData); err != nil {
+	locale := h.emailLocale(c, ctx, req.Email, req.Language)
+	if err := emailService.SendUserVerificationCode(req.Email, locale, emailData); err != nil {
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
 			Error:   "Failed to send verification email",
 			Message: err.Error(),
@@ -582,9 +678,7 @@ func (h *Handler) UserSendVerification(c *gin.Context) {
 		return
 	}
 
-	// Security logging - success
-	fmt.Printf("[USER_AUTH] Verification code sent successfully to %s from IP: %s\n",
-		req.Email, clientIP)
+	h.recordAuthEvent(c, authEvent{Type: models.AuthEventCodeSent, Subject: req.Email, Channel: "email"})
 
 	// Opportunistic cleanup after successful send (best effort)
 	if cleanErr := h.DB.CleanupExpiredUserCodes(ctx); cleanErr != nil {
@@ -598,6 +692,31 @@ func (h *Handler) UserSendVerification(c *gin.Context) {
 	})
 }
 
+// checkEmailAllowed applies the strict mode headers of clients like ebook-editor before a code or link
+// is sent: the email must belong to an existing user, optionally with a specific role
+func (h *Handler) checkEmailAllowed(c *gin.Context, ctx context.Context, email string) bool {
+	requireExisting := strings.EqualFold(c.GetHeader("X-Require-Existing"), "true") || c.Query("require_existing") == "true"
+	requiredRole := strings.TrimSpace(c.GetHeader("X-Require-Role"))
+	if requireExisting || requiredRole != "" {
+		// Must be an existing user (and optionally with specific role)
+		if id, role, _, err := h.DB.GetUserRoleStatusByEmail(ctx, email); err != nil {
+			if err == pgx.ErrNoRows {
+				c.JSON(http.StatusForbidden, models.ErrorResponse{Error: "User not allowed", Message: "User does not exist"})
+				return false
+			}
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to validate user", Message: err.Error()})
+			return false
+		} else {
+			_ = id // not used here, but ensures retrieval succeeded
+			if requiredRole != "" && !strings.EqualFold(role, requiredRole) {
+				c.JSON(http.StatusForbidden, models.ErrorResponse{Error: "User not allowed", Message: "User role not permitted"})
+				return false
+			}
+		}
+	}
+	return true
+}
+
 // UserVerifyCode handles verification code validation and JWT generation for users
 func (h *Handler) UserVerifyCode(c *gin.Context) {
 	var req models.VerifyUserCodeRequest
@@ -611,61 +730,123 @@ func (h *Handler) UserVerifyCode(c *gin.Context) {
 		return
 	}
 
-	// Get client IP for security logging
-	clientIP := getClientIP(c)
-	userAgent := c.GetHeader("User-Agent")
-
-	// Security logging
-	fmt.Printf("[USER_AUTH] Code verification attempt from IP: %s, Email: %s, UserAgent: %s\n",
-		clientIP, req.Email, userAgent)
-
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
+	if !h.checkEmailCode(c, ctx, req.Email, req.Code) {
+		return
+	}
+	h.loginVerifiedEmail(c, ctx, req.Email)
+}
+
+// loginVerifiedEmail signs in (or registers) the owner of an email that was just proven with a code
+// or a magic link, honouring the strict mode headers of clients like ebook-editor
+func (h *Handler) loginVerifiedEmail(c *gin.Context, ctx context.Context, email string) {
+	// Optional stricter mode for clients like ebook-editor
+	requireExisting := strings.EqualFold(c.GetHeader("X-Require-Existing"), "true") || c.Query("require_existing") == "true"
+	requiredRole := strings.TrimSpace(c.GetHeader("X-Require-Role"))
+
+	// Retrieve user; conditionally allow auto-registration
+	user, err := h.DB.GetUserByEmail(ctx, email)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			if requireExisting {
+				c.JSON(http.StatusForbidden, models.ErrorResponse{Error: "User not allowed", Message: "User does not exist"})
+				return
+			}
+			// Auto-register only when not in strict mode
+			user, err = h.DB.CreateUserFromEmail(ctx, email)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to create user account", Message: err.Error()})
+				return
+			}
+			fmt.Printf("[USER_AUTH] Auto-registered new user: %s\n", email)
+		} else {
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to retrieve user", Message: err.Error()})
+			return
+		}
+	}
+
+	// If a specific role is required, enforce it (no token if not matching)
+	if requiredRole != "" {
+		if id, role, _, err := h.DB.GetUserRoleStatusByEmail(ctx, email); err != nil {
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to validate user role", Message: err.Error()})
+			return
+		} else {
+			_ = id
+			if !strings.EqualFold(role, requiredRole) {
+				c.JSON(http.StatusForbidden, models.ErrorResponse{Error: "User not allowed", Message: "User role not permitted"})
+				return
+			}
+		}
+	}
+
+	// A correct code proves control of the email (clears any admin-triggered re-verification)
+	if err := h.DB.MarkEmailVerified(ctx, user.ID); err != nil {
+		fmt.Printf("Failed to mark email verified for user %s: %v\n", user.ID, err)
+	}
+
+	// Role claim for downstream authorization (e.g., ebook-service)
+	roleClaim := ""
+	if id, role, _, err := h.DB.GetUserRoleStatusByEmail(ctx, email); err == nil {
+		_ = id
+		roleClaim = role
+	}
+	h.completeUserLogin(c, ctx, user, roleClaim, "email", nil)
+}
+
+// checkEmailCode verifies and consumes the email code, writing the error response and returning false on failure
+func (h *Handler) checkEmailCode(c *gin.Context, ctx context.Context, email, code string) bool {
+	if h.subjectLocked(c, ctx, email) {
+		return false
+	}
 	// Get verification code from database
-	verificationCode, err := h.DB.GetUserVerificationCode(ctx, req.Email)
+	verificationCode, err := h.DB.GetUserVerificationCode(ctx, email)
 	if err != nil {
 		if err == pgx.ErrNoRows {
+			h.recordAuthEvent(c, authEvent{Type: models.AuthEventVerifyFailure, Subject: email, Channel: "email", Details: map[string]any{"reason": "no_valid_code"}})
 			c.JSON(http.StatusUnauthorized, models.ErrorResponse{
 				Error:   "Invalid or expired code",
 				Message: "No valid verification code found",
 			})
-			return
+			return false
 		}
 
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
 			Error:   "Failed to retrieve verification code",
 			Message: err.Error(),
 		})
-		return
+		return false
 	}
 
 	// Check if code has exceeded maximum attempts
 	maxAttempts := getEnvInt("MAX_CODE_ATTEMPTS", 3)
 	if verificationCode.Attempts >= maxAttempts {
+		h.recordAuthEvent(c, authEvent{Type: models.AuthEventVerifyFailure, Subject: email, Channel: "email", Details: map[string]any{"reason": "max_attempts"}})
+		h.recordSubjectFailure(c, ctx, email, "email")
 		c.JSON(http.StatusUnauthorized, models.ErrorResponse{
 			Error:   "Maximum attempts exceeded",
 			Message: fmt.Sprintf("Code has exceeded maximum %d attempts", maxAttempts),
 		})
-		return
+		return false
 	}
 
 	// Verify the code
-	if err := bcrypt.CompareHashAndPassword([]byte(verificationCode.CodeHash), []byte(req.Code)); err != nil {
+	if err := bcrypt.CompareHashAndPassword([]byte(verificationCode.CodeHash), []byte(code)); err != nil {
 		// Increment attempt count
 		if updateErr := h.DB.UpdateUserVerificationCodeAttempts(ctx, verificationCode.ID); updateErr != nil {
 			fmt.Printf("Failed to update user attempt count: %v\n", updateErr)
 		}
 
-		// Security logging - failed attempt
-		fmt.Printf("[USER_AUTH] FAILED verification attempt from IP: %s, Email: %s, Attempts: %d\n",
-			clientIP, req.Email, verificationCode.Attempts+1)
+		h.recordAuthEvent(c, authEvent{Type: models.AuthEventVerifyFailure, Subject: email, Channel: "email",
+			Details: map[string]any{"reason": "wrong_code", "attempts": verificationCode.Attempts + 1}})
+		h.recordSubjectFailure(c, ctx, email, "email")
 
 		c.JSON(http.StatusUnauthorized, models.ErrorResponse{
 			Error:   "Invalid verification code",
 			Message: "The provided code is incorrect",
 		})
-		return
+		return false
 	}
 
 	// Mark code as used
@@ -674,47 +855,34 @@ func (h *Handler) UserVerifyCode(c *gin.Context) {
 			Error:   "Failed to mark code as used",
 			Message: err.Error(),
 		})
-		return
+		return false
 	}
+	h.recordAuthEvent(c, authEvent{Type: models.AuthEventVerifySuccess, Subject: email, Channel: "email"})
+	h.clearSubjectFailures(ctx, email)
+	return true
+}
 
-	// Optional stricter mode for clients like ebook-editor
-	requireExisting := strings.EqualFold(c.GetHeader("X-Require-Existing"), "true") || c.Query("require_existing") == "true"
-	requiredRole := strings.TrimSpace(c.GetHeader("X-Require-Role"))
+// completeUserLogin issues the access and refresh tokens of a successful user login to the requesting client.
+// Entries in extra are merged into the response (e.g. the QR login status); method names how the user signed
+// in ("email", "qr", "wechat", an OAuth provider) for risk scoring.
+// Admins get the same security key step as on the admin panel before any Admin token is issued.
+func (h *Handler) completeUserLogin(c *gin.Context, ctx context.Context, user *models.User, roleClaim, method string, extra gin.H) {
+	clientIP := getClientIP(c)
+	userAgent := c.GetHeader("User-Agent")
 
-	// Retrieve user; conditionally allow auto-registration
-	user, err := h.DB.GetUserByEmail(ctx, req.Email)
-	if err != nil {
-		if err == pgx.ErrNoRows {
-			if requireExisting {
-				c.JSON(http.StatusForbidden, models.ErrorResponse{Error: "User not allowed", Message: "User does not exist"})
-				return
-			}
-			// Auto-register only when not in strict mode
-			user, err = h.DB.CreateUserFromEmail(ctx, req.Email)
-			if err != nil {
-				c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to create user account", Message: err.Error()})
-				return
-			}
-			fmt.Printf("[USER_AUTH] Auto-registered new user: %s\n", req.Email)
-		} else {
-			c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to retrieve user", Message: err.Error()})
-			return
-		}
+	emailStr := ""
+	if user.Email != nil {
+		emailStr = *user.Email
 	}
-
-	// If a specific role is required, enforce it (no token if not matching)
-	if requiredRole != "" {
-		if id, role, _, err := h.DB.GetUserRoleStatusByEmail(ctx, req.Email); err != nil {
-			c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to validate user role", Message: err.Error()})
+	if roleClaim == "Admin" {
+		if pending := h.beginAdminSecondFactor(c, ctx, user.ID, emailStr, roleClaim); pending {
 			return
-		} else {
-			_ = id
-			if !strings.EqualFold(role, requiredRole) {
-				c.JSON(http.StatusForbidden, models.ErrorResponse{Error: "User not allowed", Message: "User role not permitted"})
-				return
-			}
 		}
 	}
+	assessment, ok := h.checkLoginRisk(c, ctx, user, roleClaim, method, extra)
+	if !ok {
+		return
+	}
 
 	// Update last login timestamp
 	if err := h.DB.UpdateLastLogin(ctx, user.ID); err != nil {
@@ -723,15 +891,6 @@ func (h *Handler) UserVerifyCode(c *gin.Context) {
 	}
 
 	// Generate JWT token with role claim for downstream authorization (e.g., ebook-service)
-	emailStr := ""
-	if user.Email != nil {
-		emailStr = *user.Email
-	}
-	roleClaim := ""
-	if id, role, _, err := h.DB.GetUserRoleStatusByEmail(ctx, req.Email); err == nil {
-		_ = id
-		roleClaim = role
-	}
 	token, err := h.generateJWTToken(user.ID, emailStr, roleClaim)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
@@ -757,7 +916,7 @@ func (h *Handler) UserVerifyCode(c *gin.Context) {
 	}
 	refreshHash := hashRefreshTokenString(plainRefresh)
 	refreshExpiresAt := time.Now().Add(refreshTokenTTL())
-	rtID, err := h.DB.CreateRefreshToken(ctx, user.ID, refreshHash, refreshExpiresAt, clientIP, userAgent)
+	rtID, err := h.DB.CreateRefreshToken(ctx, user.ID, refreshHash, refreshExpiresAt, clientIP, userAgent, roleClaim == "Admin")
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to persist refresh token", Message: err.Error()})
 		return
@@ -775,9 +934,13 @@ func (h *Handler) UserVerifyCode(c *gin.Context) {
 		)
 	}
 
-	// Security logging - successful authentication
-	fmt.Printf("[USER_AUTH] SUCCESSFUL authentication for %s from IP: %s, Token expires: %s\n",
-		req.Email, clientIP, tokenExpiresAt.Format("2006-01-02 15:04:05"))
+	h.recordAuthEvent(c, authEvent{Type: models.AuthEventLogin, UserID: user.ID, Subject: emailStr, Details: map[string]any{
+		"role":       roleClaim,
+		"method":     method,
+		"risk_score": assessment.risk.Score,
+		"location":   assessment.location,
+	}})
+	h.recordLogin(c, ctx, user, assessment)
 
 	// Return success response with role included in user payload
 	respUser := gin.H{
@@ -792,17 +955,21 @@ func (h *Handler) UserVerifyCode(c *gin.Context) {
 		"updated_at":  user.UpdatedAt,
 		"role":        roleClaim,
 	}
-	c.JSON(http.StatusOK, gin.H{
+	resp := gin.H{
 		"token":              token,
 		"expires_at":         tokenExpiresAt,
 		"expiresAt":          tokenExpiresAt, // keep camelCase for consistency elsewhere
 		"refresh_token":      plainRefresh,
 		"refresh_expires_at": refreshExpiresAt,
 		"user":               respUser,
-	})
+	}
+	for k, v := range extra {
+		resp[k] = v
+	}
+	c.JSON(http.StatusOK, resp)
 }
 
-// UserSendPhoneVerification handles sending verification codes via SMS for user login/registration
+// UserSendPhoneVerification handles sending verification codes via SMS or WhatsApp for user login/registration
 func (h *Handler) UserSendPhoneVerification(c *gin.Context) {
 	var req models.SendPhoneVerificationRequest
 
@@ -814,40 +981,60 @@ func (h *Handler) UserSendPhoneVerification(c *gin.Context) {
 		return
 	}
 
-	phone := strings.TrimSpace(req.Phone)
-	if !isValidE164(phone) {
+	// Reject numbers that cannot receive the code before spending on SNS or WhatsApp
+	validate, channelName := services.ValidateSMSRecipient, "SMS"
+	if req.Channel == services.ChannelWhatsApp {
+		validate, channelName = services.ValidateWhatsAppRecipient, "WhatsApp"
+	}
+	parsed, err := validate(req.Phone, req.Region)
+	if err != nil {
+		msg := "Phone number must include the country code, e.g., +12065550100"
+		switch err {
+		case services.ErrPhoneNotMobile:
+			msg = fmt.Sprintf("Only mobile numbers can receive verification codes (got %s)", parsed.LineType)
+		case services.ErrPhoneRegionNotAllowed:
+			msg = fmt.Sprintf("%s verification is not available for %s numbers", channelName, parsed.Region)
+		}
 		c.JSON(http.StatusBadRequest, models.ErrorResponse{
-			Error:   "Invalid phone format",
-			Message: "Phone number must be in E.164 format, e.g., +12065550100",
+			Error:   "Invalid phone number",
+			Message: msg,
 		})
 		return
 	}
-	if h.SMS == nil {
+	phone := parsed.E164
+	if !h.PhoneCodes.Available() {
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
 			Error:   "SMS service unavailable",
-			Message: "SMS service not configured",
+			Message: "Neither SMS nor WhatsApp delivery is configured",
+		})
+		return
+	}
+	if !h.PhoneCodes.Supports(req.Channel) {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Channel unavailable",
+			Message: fmt.Sprintf("%s delivery is not configured; request the code without a channel", channelName),
 		})
 		return
 	}
+	// Codes the client asked to get on WhatsApp are limited separately from SMS
+	rateChannel := "phone"
+	if req.Channel == services.ChannelWhatsApp {
+		rateChannel = "whatsapp"
+	}
 
 	clientIP := getClientIP(c)
-	userAgent := c.GetHeader("User-Agent")
-	fmt.Printf("[USER_AUTH][PHONE] Verification request from IP: %s, Phone: %s, UserAgent: %s\n", clientIP, phone, userAgent)
 
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	maxRequests := getEnvInt("RATE_LIMIT_REQUESTS_PER_HOUR", 5)
-	rateLimited, err := h.DB.CheckUserRateLimit(ctx, clientIP, maxRequests, 1)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Rate limit check failed", Message: err.Error()})
+	if h.subjectLocked(c, ctx, phone) {
 		return
 	}
-	if rateLimited {
-		c.JSON(http.StatusTooManyRequests, models.ErrorResponse{
-			Error:   "Rate limit exceeded",
-			Message: fmt.Sprintf("Maximum %d requests per hour allowed", maxRequests),
-		})
+	decision, ok := h.checkUserRateLimit(ctx, c, rateChannel, clientIP, phone)
+	if !ok {
+		return
+	}
+	if !h.checkCaptcha(ctx, c, "phone", phone, req.CaptchaToken, decision) {
 		return
 	}
 
@@ -875,17 +1062,23 @@ func (h *Handler) UserSendPhoneVerification(c *gin.Context) {
 		return
 	}
 
-	if err := h.DB.IncrementUserRateLimit(ctx, clientIP); err != nil {
+	if err := h.RateLimits.Record(ctx, rateChannel, clientIP, phone, decision); err != nil {
 		fmt.Printf("Failed to increment user rate limit: %v\n", err)
 	}
 
 	message := fmt.Sprintf("Your Made in World verification code is: %s. This code expires in %d minutes. If you didn't request this, please ignore.", code, expirationMinutes)
-	if err := h.SMS.SendSMS(ctx, phone, message); err != nil {
-		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to send SMS", Message: err.Error()})
+	channel, err := h.PhoneCodes.Send(ctx, parsed, code, message, req.Channel)
+	if err != nil {
+		msg := err.Error()
+		if req.Channel == services.ChannelWhatsApp {
+			// No SMS fallback: the number was only checked and limited for WhatsApp
+			msg = "WhatsApp delivery failed; request the code by SMS instead"
+		}
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to send verification code", Message: msg})
 		return
 	}
 
-	fmt.Printf("[USER_AUTH][PHONE] Verification code sent successfully to %s from IP: %s\n", phone, clientIP)
+	h.recordAuthEvent(c, authEvent{Type: models.AuthEventCodeSent, Subject: phone, Channel: channel})
 
 	if cleanErr := h.DB.CleanupExpiredPhoneCodes(ctx); cleanErr != nil {
 		fmt.Printf("[USER_AUTH][PHONE] Cleanup after phone code send failed: %v\n", cleanErr)
@@ -894,6 +1087,8 @@ func (h *Handler) UserSendPhoneVerification(c *gin.Context) {
 	c.JSON(http.StatusOK, models.SendUserVerificationResponse{
 		Message:   "Verification code sent successfully",
 		ExpiresAt: verificationCode.ExpiresAt,
+		Phone:     phone,
+		Channel:   channel,
 	})
 }
 
@@ -905,46 +1100,21 @@ func (h *Handler) UserVerifyPhoneCode(c *gin.Context) {
 		return
 	}
 
-	phone := strings.TrimSpace(req.Phone)
-	if !isValidE164(phone) {
-		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid phone format", Message: "Phone number must be in E.164 format"})
+	// Normalize the same way as on send so the stored code is found
+	parsed, err := services.ParsePhone(req.Phone, req.Region)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid phone number", Message: "Phone number must include the country code, e.g., +12065550100"})
 		return
 	}
+	phone := parsed.E164
 
 	clientIP := getClientIP(c)
 	userAgent := c.GetHeader("User-Agent")
-	fmt.Printf("[USER_AUTH][PHONE] Code verification attempt from IP: %s, Phone: %s, UserAgent: %s\n", clientIP, phone, userAgent)
 
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	verificationCode, err := h.DB.GetUserPhoneVerificationCode(ctx, phone)
-	if err != nil {
-		if err == pgx.ErrNoRows {
-			c.JSON(http.StatusUnauthorized, models.ErrorResponse{Error: "Invalid or expired code", Message: "No valid verification code found"})
-			return
-		}
-		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to retrieve verification code", Message: err.Error()})
-		return
-	}
-
-	maxAttempts := getEnvInt("MAX_CODE_ATTEMPTS", 3)
-	if verificationCode.Attempts >= maxAttempts {
-		c.JSON(http.StatusUnauthorized, models.ErrorResponse{Error: "Maximum attempts exceeded", Message: fmt.Sprintf("Code has exceeded maximum %d attempts", maxAttempts)})
-		return
-	}
-
-	if err := bcrypt.CompareHashAndPassword([]byte(verificationCode.CodeHash), []byte(req.Code)); err != nil {
-		if updateErr := h.DB.UpdateUserPhoneVerificationCodeAttempts(ctx, verificationCode.ID); updateErr != nil {
-			fmt.Printf("Failed to update user phone attempt count: %v\n", updateErr)
-		}
-		fmt.Printf("[USER_AUTH][PHONE] FAILED verification attempt from IP: %s, Phone: %s, Attempts: %d\n", clientIP, phone, verificationCode.Attempts+1)
-		c.JSON(http.StatusUnauthorized, models.ErrorResponse{Error: "Invalid verification code", Message: "The provided code is incorrect"})
-		return
-	}
-
-	if err := h.DB.MarkUserPhoneVerificationCodeUsed(ctx, verificationCode.ID); err != nil {
-		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to mark code as used", Message: err.Error()})
+	if !h.checkPhoneCode(c, ctx, phone, req.Code) {
 		return
 	}
 
@@ -963,6 +1133,14 @@ func (h *Handler) UserVerifyPhoneCode(c *gin.Context) {
 		}
 	}
 
+	// A correct code proves control of the phone even when the login is then paused for a step-up
+	if err := h.DB.MarkPhoneVerified(ctx, user.ID); err != nil {
+		fmt.Printf("Failed to mark phone verified for user %s: %v\n", user.ID, err)
+	}
+	assessment, ok := h.checkLoginRisk(c, ctx, user, "", "phone", nil)
+	if !ok {
+		return
+	}
 	if err := h.DB.UpdateLastLogin(ctx, user.ID); err != nil {
 		fmt.Printf("Failed to update last login for user %s: %v\n", user.ID, err)
 	}
@@ -993,7 +1171,7 @@ func (h *Handler) UserVerifyPhoneCode(c *gin.Context) {
 	}
 	refreshHash := hashRefreshTokenString(plainRefresh)
 	refreshExpiresAt := time.Now().Add(refreshTokenTTL())
-	rtID, err := h.DB.CreateRefreshToken(ctx, user.ID, refreshHash, refreshExpiresAt, clientIP, userAgent)
+	rtID, err := h.DB.CreateRefreshToken(ctx, user.ID, refreshHash, refreshExpiresAt, clientIP, userAgent, false)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to persist refresh token", Message: err.Error()})
 		return
@@ -1011,7 +1189,12 @@ func (h *Handler) UserVerifyPhoneCode(c *gin.Context) {
 		)
 	}
 
-	fmt.Printf("[USER_AUTH][PHONE] SUCCESSFUL authentication for %s from IP: %s, Token expires: %s\n", phone, clientIP, tokenExpiresAt.Format("2006-01-02 15:04:05"))
+	h.recordAuthEvent(c, authEvent{Type: models.AuthEventLogin, UserID: user.ID, Subject: phone, Channel: "phone", Details: map[string]any{
+		"method":     "phone",
+		"risk_score": assessment.risk.Score,
+		"location":   assessment.location,
+	}})
+	h.recordLogin(c, ctx, user, assessment)
 
 	c.JSON(http.StatusOK, models.VerifyUserCodeResponse{
 		Token:            token,
@@ -1022,18 +1205,46 @@ func (h *Handler) UserVerifyPhoneCode(c *gin.Context) {
 	})
 }
 
-// isValidE164 validates E.164 phone numbers like +12065550100
-func isValidE164(phone string) bool {
-	if len(phone) < 2 || len(phone) > 16 {
+// checkPhoneCode verifies and consumes the phone code, writing the error response and returning false on failure
+func (h *Handler) checkPhoneCode(c *gin.Context, ctx context.Context, phone, code string) bool {
+	if h.subjectLocked(c, ctx, phone) {
 		return false
 	}
-	if phone[0] != '+' {
+	verificationCode, err := h.DB.GetUserPhoneVerificationCode(ctx, phone)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			h.recordAuthEvent(c, authEvent{Type: models.AuthEventVerifyFailure, Subject: phone, Channel: "phone", Details: map[string]any{"reason": "no_valid_code"}})
+			c.JSON(http.StatusUnauthorized, models.ErrorResponse{Error: "Invalid or expired code", Message: "No valid verification code found"})
+			return false
+		}
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to retrieve verification code", Message: err.Error()})
 		return false
 	}
-	for i := 1; i < len(phone); i++ {
-		if phone[i] < '0' || phone[i] > '9' {
-			return false
+
+	maxAttempts := getEnvInt("MAX_CODE_ATTEMPTS", 3)
+	if verificationCode.Attempts >= maxAttempts {
+		h.recordAuthEvent(c, authEvent{Type: models.AuthEventVerifyFailure, Subject: phone, Channel: "phone", Details: map[string]any{"reason": "max_attempts"}})
+		h.recordSubjectFailure(c, ctx, phone, "phone")
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse{Error: "Maximum attempts exceeded", Message: fmt.Sprintf("Code has exceeded maximum %d attempts", maxAttempts)})
+		return false
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(verificationCode.CodeHash), []byte(code)); err != nil {
+		if updateErr := h.DB.UpdateUserPhoneVerificationCodeAttempts(ctx, verificationCode.ID); updateErr != nil {
+			fmt.Printf("Failed to update user phone attempt count: %v\n", updateErr)
 		}
+		h.recordAuthEvent(c, authEvent{Type: models.AuthEventVerifyFailure, Subject: phone, Channel: "phone",
+			Details: map[string]any{"reason": "wrong_code", "attempts": verificationCode.Attempts + 1}})
+		h.recordSubjectFailure(c, ctx, phone, "phone")
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse{Error: "Invalid verification code", Message: "The provided code is incorrect"})
+		return false
+	}
+
+	if err := h.DB.MarkUserPhoneVerificationCodeUsed(ctx, verificationCode.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to mark code as used", Message: err.Error()})
+		return false
 	}
+	h.recordAuthEvent(c, authEvent{Type: models.AuthEventVerifySuccess, Subject: phone, Channel: "phone"})
+	h.clearSubjectFailures(ctx, phone)
 	return true
 }
@@ -0,0 +1,150 @@
+package api
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/expotoworld/expotoworld/backend/auth-service/internal/models"
+	"github.com/expotoworld/expotoworld/backend/common/rbac"
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/jackc/pgx/v5"
+)
+
+// impersonationTTL is the longest an impersonation token lives (IMPERSONATION_TTL_MINUTES, default 15)
+func impersonationTTL() time.Duration {
+	minutes := getEnvInt("IMPERSONATION_TTL_MINUTES", 15)
+	if minutes <= 0 {
+		minutes = 15
+	}
+	return time.Duration(minutes) * time.Minute
+}
+
+// Impersonate handles POST /api/auth/admin/impersonate: a super-admin (users:impersonate) gets a
+// short-lived access token for another user to reproduce a reported issue. The token carries the
+// admin in its "act" claim (RFC 8693) and can't be refreshed; the gateway logs every request made
+// with it and auth-service refuses it for sign-in and account changes.
+func (h *Handler) Impersonate(c *gin.Context) {
+	var req models.ImpersonateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid request data", Message: err.Error()})
+		return
+	}
+	adminID := c.GetString("user_id")
+	if req.UserID == adminID {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid user", Message: "You can't impersonate yourself"})
+		return
+	}
+	ttl := impersonationTTL()
+	if req.TTLMinutes > 0 && time.Duration(req.TTLMinutes)*time.Minute < ttl {
+		ttl = time.Duration(req.TTLMinutes) * time.Minute
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	role, active, err := h.DB.GetUserAccountState(ctx, req.UserID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "User not found", Message: "No user with this id"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to load user", Message: err.Error()})
+		return
+	}
+	if !active {
+		c.JSON(http.StatusConflict, models.ErrorResponse{Error: "User inactive", Message: "Deactivated or deleted users can't be impersonated"})
+		return
+	}
+	// Admin tokens need the security key step; impersonation must not be a way around it
+	if role == rbac.AdminRole {
+		c.JSON(http.StatusForbidden, models.ErrorResponse{Error: "Access denied", Message: "Admins can't be impersonated"})
+		return
+	}
+	user, err := h.DB.GetUserByID(ctx, req.UserID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to load user", Message: err.Error()})
+		return
+	}
+	email := ""
+	if user.Email != nil {
+		email = *user.Email
+	}
+
+	claims, err := h.userClaims(ctx, user.ID, role)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to generate token", Message: err.Error()})
+		return
+	}
+	jtiBytes := make([]byte, 16)
+	if _, err := rand.Read(jtiBytes); err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to generate token", Message: err.Error()})
+		return
+	}
+	jti := hex.EncodeToString(jtiBytes)
+	now := time.Now()
+	expiresAt := now.Add(ttl)
+	claims["user_id"] = user.ID
+	claims["email"] = email
+	if role != "" {
+		claims["role"] = role
+	}
+	claims["iat"] = now.Unix()
+	claims["exp"] = expiresAt.Unix()
+	claims["jti"] = jti
+	claims["act"] = map[string]string{"sub": adminID, "email": c.GetString("email")}
+	token, err := h.signClaims(claims)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to generate token", Message: err.Error()})
+		return
+	}
+
+	h.recordAuthEvent(c, authEvent{Type: models.AuthEventImpersonation, UserID: user.ID, Subject: email, Details: map[string]any{
+		"impersonation_id": jti,
+		"reason":           req.Reason,
+		"expires_at":       expiresAt.UTC().Format(time.RFC3339),
+	}})
+	log.Printf("[AUDIT][IMPERSONATION][START] by=%s target_user_id=%s impersonation_id=%s expires_at=%s reason=%q",
+		adminID, user.ID, jti, expiresAt.UTC().Format(time.RFC3339), req.Reason)
+
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, gin.H{
+		"token":            token,
+		"expires_at":       expiresAt,
+		"impersonation_id": jti,
+		"user": gin.H{
+			"id":    user.ID,
+			"email": user.Email,
+			"phone": user.Phone,
+			"role":  role,
+		},
+	})
+}
+
+// RejectImpersonation refuses impersonation tokens on routes that sign in other devices or change
+// the account (must run after AuthMiddleware)
+func RejectImpersonation() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.GetString("impersonator") != "" {
+			c.JSON(http.StatusForbidden, models.ErrorResponse{
+				Error:   "Access denied",
+				Message: "Not available while impersonating a user",
+			})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// impersonator returns the admin acting through an impersonation token, or "" for a regular token
+func impersonator(claims jwt.MapClaims) string {
+	act, _ := claims["act"].(map[string]any)
+	sub, _ := act["sub"].(string)
+	return sub
+}
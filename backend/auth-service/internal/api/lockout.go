@@ -0,0 +1,99 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/expotoworld/expotoworld/backend/auth-service/internal/db"
+	"github.com/expotoworld/expotoworld/backend/auth-service/internal/models"
+	"github.com/gin-gonic/gin"
+)
+
+// lockoutPolicy reads the brute-force lockout settings: LOCKOUT_THRESHOLD failed verifications
+// within LOCKOUT_WINDOW_MINUTES lock the email or phone number for LOCKOUT_BASE_MINUTES, doubling
+// with every further lockout up to LOCKOUT_MAX_MINUTES
+func lockoutPolicy() db.LockoutPolicy {
+	p := db.LockoutPolicy{
+		Threshold: getEnvInt("LOCKOUT_THRESHOLD", 5),
+		Window:    time.Duration(getEnvInt("LOCKOUT_WINDOW_MINUTES", 60)) * time.Minute,
+		Base:      time.Duration(getEnvInt("LOCKOUT_BASE_MINUTES", 15)) * time.Minute,
+		Max:       time.Duration(getEnvInt("LOCKOUT_MAX_MINUTES", 24*60)) * time.Minute,
+	}
+	if p.Threshold <= 0 {
+		p.Threshold = 5
+	}
+	if p.Base <= 0 {
+		p.Base = 15 * time.Minute
+	}
+	if p.Max < p.Base {
+		p.Max = p.Base
+	}
+	return p
+}
+
+// subjectLocked writes a 429 and returns true while the email or phone number is locked. Lookup
+// errors let the request through: the per-code attempt limit still applies.
+func (h *Handler) subjectLocked(c *gin.Context, ctx context.Context, subject string) bool {
+	until, err := h.DB.GetSubjectLock(ctx, subject)
+	if err != nil {
+		fmt.Printf("[LOCKOUT] Failed to check lockout of %s: %v\n", subject, err)
+		return false
+	}
+	if until == nil {
+		return false
+	}
+	c.Header("Retry-After", strconv.Itoa(int(time.Until(*until).Seconds())+1))
+	c.JSON(http.StatusTooManyRequests, models.ErrorResponse{
+		Error:   "Too many failed attempts",
+		Message: fmt.Sprintf("Sign-in is locked until %s", until.UTC().Format(time.RFC3339)),
+	})
+	return true
+}
+
+// recordSubjectFailure counts a failed verification against the email or phone number; when that
+// locks it, the event is recorded and the account owner is told by email
+func (h *Handler) recordSubjectFailure(c *gin.Context, ctx context.Context, subject, channel string) {
+	policy := lockoutPolicy()
+	until, err := h.DB.RecordSubjectFailure(ctx, subject, policy)
+	if err != nil {
+		fmt.Printf("[LOCKOUT] Failed to record failure for %s: %v\n", subject, err)
+		return
+	}
+	if until == nil {
+		return
+	}
+	h.recordAuthEvent(c, authEvent{Type: models.AuthEventLockout, Subject: subject, Channel: channel,
+		Details: map[string]any{"locked_until": until.UTC().Format(time.RFC3339)}})
+
+	email := subject
+	if channel != "email" {
+		user, err := h.DB.GetUserByPhone(ctx, subject)
+		if err != nil || user.Email == nil {
+			return
+		}
+		email = *user.Email
+	}
+	if h.Email == nil {
+		return
+	}
+	notice := models.LockoutNoticeData{
+		Email:       email,
+		Failures:    policy.Threshold,
+		LockedUntil: *until,
+		IPAddress:   getClientIP(c),
+		UserAgent:   c.GetHeader("User-Agent"),
+	}
+	if err := h.Email.SendLockoutNotice(email, h.emailLocale(c, ctx, email, ""), notice); err != nil {
+		fmt.Printf("[LOCKOUT] Failed to send lockout notice to %s: %v\n", email, err)
+	}
+}
+
+// clearSubjectFailures forgets earlier failures once the subject verified successfully
+func (h *Handler) clearSubjectFailures(ctx context.Context, subject string) {
+	if err := h.DB.ClearSubjectFailures(ctx, subject); err != nil {
+		fmt.Printf("[LOCKOUT] Failed to clear failures of %s: %v\n", subject, err)
+	}
+}
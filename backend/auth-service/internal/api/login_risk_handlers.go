@@ -0,0 +1,289 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/expotoworld/expotoworld/backend/auth-service/internal/models"
+	"github.com/expotoworld/expotoworld/backend/auth-service/internal/services"
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// steppedUpKey marks a login the user just confirmed with a step-up code so it isn't paused again
+const steppedUpKey = "login_stepped_up"
+
+// stepUpExempt are the login methods an email code can't strengthen: the email was just proven, or
+// a signed-in device approved the login
+var stepUpExempt = map[string]bool{"email": true, "qr": true}
+
+// loginAssessment is the location and risk score of a login about to be granted
+type loginAssessment struct {
+	method   string
+	location *models.GeoLocation
+	risk     models.LoginRisk
+}
+
+// loginHistoryWindow is how far back logins are compared and kept (LOGIN_HISTORY_DAYS, default 180)
+func loginHistoryWindow() time.Duration {
+	days := getEnvInt("LOGIN_HISTORY_DAYS", 180)
+	if days <= 0 {
+		days = 180
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
+
+// assessLogin locates the client IP and scores the login against the user's history. Lookup errors
+// only leave out what couldn't be checked; they never block a login.
+func (h *Handler) assessLogin(c *gin.Context, ctx context.Context, userID, method string) *loginAssessment {
+	a := &loginAssessment{method: method, risk: models.LoginRisk{Reasons: []string{}}}
+	clientIP := getClientIP(c)
+	if h.GeoIP != nil {
+		loc, err := h.GeoIP.Lookup(ctx, clientIP)
+		if err != nil {
+			fmt.Printf("[LOGIN_RISK] GeoIP lookup of %s failed: %v\n", clientIP, err)
+		}
+		a.location = loc
+	}
+	history, err := h.DB.SummarizeLoginHistory(ctx, userID, clientIP, c.GetHeader("User-Agent"), a.location, time.Now().Add(-loginHistoryWindow()))
+	if err != nil {
+		fmt.Printf("[LOGIN_RISK] Failed to load login history of %s: %v\n", userID, err)
+		return a
+	}
+	a.risk = services.ScoreLogin(history, a.location, time.Now())
+	return a
+}
+
+// checkLoginRisk scores a user login before its tokens are issued. Logins scoring at least
+// LOGIN_RISK_STEP_UP_SCORE (default 70, 0 disables) are paused and answered with a step-up
+// challenge when the account has an email to send a code to; it then returns false.
+func (h *Handler) checkLoginRisk(c *gin.Context, ctx context.Context, user *models.User, role, method string, extra gin.H) (*loginAssessment, bool) {
+	a := h.assessLogin(c, ctx, user.ID, method)
+	threshold := getEnvInt("LOGIN_RISK_STEP_UP_SCORE", 70)
+	if threshold <= 0 || a.risk.Score < threshold || stepUpExempt[method] || c.GetBool(steppedUpKey) {
+		return a, true
+	}
+	if user.Email == nil || h.Email == nil {
+		fmt.Printf("[LOGIN_RISK] High risk login of %s (score %d) can't be stepped up: no email\n", user.ID, a.risk.Score)
+		return a, true
+	}
+	h.beginLoginStepUp(c, ctx, user, role, extra, a)
+	return a, false
+}
+
+// beginLoginStepUp emails a verification code to the account and answers with the step_up_token the
+// client redeems with it at /api/auth/login/step-up
+func (h *Handler) beginLoginStepUp(c *gin.Context, ctx context.Context, user *models.User, role string, extra gin.H, a *loginAssessment) {
+	email := *user.Email
+	clientIP := getClientIP(c)
+	if h.subjectLocked(c, ctx, email) {
+		return
+	}
+	decision, ok := h.checkUserRateLimit(ctx, c, "email", clientIP, email)
+	if !ok {
+		return
+	}
+
+	code, err := generateVerificationCode()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to generate verification code", Message: err.Error()})
+		return
+	}
+	codeHash, err := bcrypt.GenerateFromPassword([]byte(code), bcrypt.DefaultCost)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to process verification code", Message: err.Error()})
+		return
+	}
+	expirationMinutes := getEnvInt("CODE_EXPIRATION_MINUTES", 10)
+	expiresAt := time.Now().Add(time.Duration(expirationMinutes) * time.Minute)
+
+	// Opportunistic cleanup before creating a new step-up (best effort)
+	if cleanErr := h.DB.CleanupLoginStepUps(ctx); cleanErr != nil {
+		fmt.Printf("[LOGIN_RISK] Step-up cleanup failed: %v\n", cleanErr)
+	}
+	stepUpToken, err := h.DB.CreateLoginStepUp(ctx, &models.LoginStepUp{
+		UserID: user.ID, Email: email, Role: role, Method: a.method, Extra: extra, CodeHash: string(codeHash), ExpiresAt: expiresAt,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to create step-up", Message: err.Error()})
+		return
+	}
+	if err := h.RateLimits.Record(ctx, "email", clientIP, email, decision); err != nil {
+		fmt.Printf("Failed to increment user rate limit: %v\n", err)
+	}
+
+	emailData := models.EmailVerificationData{
+		Code:         code,
+		Email:        email,
+		ExpiresAt:    expiresAt,
+		IPAddress:    clientIP,
+		UserAgent:    c.GetHeader("User-Agent"),
+		Timestamp:    time.Now(),
+		ExpiresInMin: expirationMinutes,
+	}
+	if err := h.Email.SendUserVerificationCode(email, h.emailLocale(c, ctx, email, ""), emailData); err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to send verification email", Message: err.Error()})
+		return
+	}
+
+	h.recordAuthEvent(c, authEvent{Type: models.AuthEventLoginStepUp, UserID: user.ID, Subject: email, Channel: "email", Details: map[string]any{
+		"method":       a.method,
+		"risk_score":   a.risk.Score,
+		"risk_reasons": a.risk.Reasons,
+		"location":     a.location,
+	}})
+	fmt.Printf("[LOGIN_RISK] Step-up required for %s (score %d: %s)\n", user.ID, a.risk.Score, strings.Join(a.risk.Reasons, ","))
+
+	c.JSON(http.StatusOK, gin.H{
+		"step_up_required":   true,
+		"step_up_token":      stepUpToken,
+		"step_up_expires_at": expiresAt,
+		"channel":            "email",
+		"destination":        maskEmail(email),
+	})
+}
+
+// VerifyLoginStepUp handles POST /api/auth/login/step-up: completes a paused login with the code
+// emailed for it. Only that code is accepted; sign-in codes requested for the same email are not.
+func (h *Handler) VerifyLoginStepUp(c *gin.Context) {
+	var req models.LoginStepUpRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid request data", Message: err.Error()})
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	stepUp, err := h.DB.GetLoginStepUp(ctx, strings.TrimSpace(req.StepUpToken))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			c.JSON(http.StatusUnauthorized, models.ErrorResponse{Error: "Invalid or expired login", Message: "Please sign in again"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to load login", Message: err.Error()})
+		return
+	}
+	if !h.checkStepUpCode(c, ctx, stepUp, req.Code) {
+		return
+	}
+	if ok, err := h.DB.ConsumeLoginStepUp(ctx, stepUp.ID); err != nil || !ok {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse{Error: "Invalid or expired login", Message: "Please sign in again"})
+		return
+	}
+	user, err := h.DB.GetUserByID(ctx, stepUp.UserID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to retrieve user", Message: err.Error()})
+		return
+	}
+	c.Set(steppedUpKey, true)
+	h.completeUserLogin(c, ctx, user, stepUp.Role, stepUp.Method, stepUp.Extra)
+}
+
+// checkStepUpCode verifies the code of a paused login, with the attempt limit (MAX_CODE_ATTEMPTS) and
+// subject lockouts of sign-in codes. It answers the request and returns false when the code is wrong.
+func (h *Handler) checkStepUpCode(c *gin.Context, ctx context.Context, stepUp *models.LoginStepUp, code string) bool {
+	if h.subjectLocked(c, ctx, stepUp.Email) {
+		return false
+	}
+	maxAttempts := getEnvInt("MAX_CODE_ATTEMPTS", 3)
+	if stepUp.Attempts >= maxAttempts {
+		h.recordAuthEvent(c, authEvent{Type: models.AuthEventVerifyFailure, UserID: stepUp.UserID, Subject: stepUp.Email, Channel: "email",
+			Details: map[string]any{"reason": "max_attempts", "step_up": true}})
+		h.recordSubjectFailure(c, ctx, stepUp.Email, "email")
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+			Error:   "Maximum attempts exceeded",
+			Message: fmt.Sprintf("Code has exceeded maximum %d attempts; please sign in again", maxAttempts),
+		})
+		return false
+	}
+	if stepUp.CodeHash == "" || bcrypt.CompareHashAndPassword([]byte(stepUp.CodeHash), []byte(code)) != nil {
+		if err := h.DB.RecordLoginStepUpAttempt(ctx, stepUp.ID); err != nil {
+			fmt.Printf("[LOGIN_RISK] Failed to update step-up attempt count: %v\n", err)
+		}
+		h.recordAuthEvent(c, authEvent{Type: models.AuthEventVerifyFailure, UserID: stepUp.UserID, Subject: stepUp.Email, Channel: "email",
+			Details: map[string]any{"reason": "wrong_code", "attempts": stepUp.Attempts + 1, "step_up": true}})
+		h.recordSubjectFailure(c, ctx, stepUp.Email, "email")
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+			Error:   "Invalid verification code",
+			Message: "The provided code is incorrect",
+		})
+		return false
+	}
+	h.recordAuthEvent(c, authEvent{Type: models.AuthEventVerifySuccess, UserID: stepUp.UserID, Subject: stepUp.Email, Channel: "email",
+		Details: map[string]any{"step_up": true}})
+	h.clearSubjectFailures(ctx, stepUp.Email)
+	return true
+}
+
+// recordLogin keeps a granted login in the user's history and, when it scored at least
+// LOGIN_RISK_NOTIFY_SCORE (default 40, 0 disables), emails the account owner about it
+func (h *Handler) recordLogin(c *gin.Context, ctx context.Context, user *models.User, a *loginAssessment) {
+	clientIP := getClientIP(c)
+	userAgent := c.GetHeader("User-Agent")
+	if err := h.DB.RecordLogin(ctx, &models.LoginRecord{
+		UserID: user.ID, Method: a.method, ClientIP: clientIP, UserAgent: userAgent, Location: a.location, Risk: a.risk,
+	}); err != nil {
+		fmt.Printf("[LOGIN_RISK] %v\n", err)
+	}
+	h.pruneLoginHistory(ctx)
+
+	threshold := getEnvInt("LOGIN_RISK_NOTIFY_SCORE", 40)
+	if threshold <= 0 || a.risk.Score < threshold || user.Email == nil || h.Email == nil {
+		return
+	}
+	email := *user.Email
+	notice := models.NewDeviceLoginData{
+		Email:     email,
+		Location:  describeLocation(a.location),
+		IPAddress: clientIP,
+		UserAgent: userAgent,
+		Time:      time.Now(),
+	}
+	if err := h.Email.SendNewDeviceLogin(email, h.emailLocale(c, ctx, email, ""), notice); err != nil {
+		fmt.Printf("[LOGIN_RISK] Failed to send new device notice to %s: %v\n", email, err)
+	}
+}
+
+// pruneLoginHistory deletes logins older than the history window, at most once per
+// LOGIN_HISTORY_PRUNE_MINUTES (default 60) per instance rather than on every login
+func (h *Handler) pruneLoginHistory(ctx context.Context) {
+	interval := time.Duration(getEnvInt("LOGIN_HISTORY_PRUNE_MINUTES", 60)) * time.Minute
+	now := time.Now()
+	last := h.loginHistoryPrunedAt.Load()
+	if now.Sub(time.Unix(0, last)) < interval || !h.loginHistoryPrunedAt.CompareAndSwap(last, now.UnixNano()) {
+		return
+	}
+	if err := h.DB.PruneLoginHistory(ctx, now.Add(-loginHistoryWindow())); err != nil {
+		fmt.Printf("[LOGIN_RISK] Login history cleanup failed: %v\n", err)
+	}
+}
+
+// describeLocation formats a location as "City, Region, CC"; "" when unknown
+func describeLocation(loc *models.GeoLocation) string {
+	if loc == nil {
+		return ""
+	}
+	parts := []string{}
+	for _, p := range []string{loc.City, loc.Region, loc.Country} {
+		if p != "" && (len(parts) == 0 || parts[len(parts)-1] != p) {
+			parts = append(parts, p)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// maskEmail hides most of the local part, e.g. "a***@example.com"
+func maskEmail(email string) string {
+	at := strings.LastIndex(email, "@")
+	if at <= 0 {
+		return "***"
+	}
+	_, size := utf8.DecodeRuneInString(email)
+	return email[:size] + "***" + email[at:]
+}
@@ -0,0 +1,126 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/expotoworld/expotoworld/backend/auth-service/internal/models"
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+)
+
+// magicLinkURL returns the page login links point to (MAGIC_LINK_URL), an https universal link / app
+// link that opens the app, which then posts the token to /verify-magic-link. Nil when not configured.
+func magicLinkURL() *url.URL {
+	u, err := url.Parse(strings.TrimSpace(os.Getenv("MAGIC_LINK_URL")))
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+		return nil
+	}
+	return u
+}
+
+// SendMagicLink handles POST /api/auth/send-magic-link: emails a single-use login link instead of a
+// code, with the same lockout, rate limit and CAPTCHA checks as /send-verification
+func (h *Handler) SendMagicLink(c *gin.Context) {
+	var req models.SendMagicLinkRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid request data", Message: err.Error()})
+		return
+	}
+	link := magicLinkURL()
+	if link == nil || h.Email == nil {
+		c.JSON(http.StatusServiceUnavailable, models.ErrorResponse{Error: "Magic links unavailable", Message: "Login links are not configured; request a code instead"})
+		return
+	}
+	clientIP := getClientIP(c)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if !h.checkEmailAllowed(c, ctx, req.Email) {
+		return
+	}
+	if h.subjectLocked(c, ctx, req.Email) {
+		return
+	}
+	decision, ok := h.checkUserRateLimit(ctx, c, "email", clientIP, req.Email)
+	if !ok {
+		return
+	}
+	if !h.checkCaptcha(ctx, c, "email", req.Email, req.CaptchaToken, decision) {
+		return
+	}
+
+	token, err := generateRefreshTokenString(32)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to generate login link", Message: err.Error()})
+		return
+	}
+	ttl := getEnvInt("MAGIC_LINK_TTL_MINUTES", 15)
+	if ttl <= 0 {
+		ttl = 15
+	}
+	expiresAt := time.Now().Add(time.Duration(ttl) * time.Minute)
+	if err := h.DB.CreateMagicLink(ctx, req.Email, hashRefreshTokenString(token), clientIP, expiresAt); err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to store login link", Message: err.Error()})
+		return
+	}
+	if err := h.RateLimits.Record(ctx, "email", clientIP, req.Email, decision); err != nil {
+		fmt.Printf("Failed to increment user rate limit: %v\n", err)
+	}
+
+	query := link.Query()
+	query.Set("token", token)
+	link.RawQuery = query.Encode()
+	emailData := models.MagicLinkEmailData{
+		Email:        req.Email,
+		Link:         link.String(),
+		IPAddress:    clientIP,
+		UserAgent:    c.GetHeader("User-Agent"),
+		ExpiresInMin: ttl,
+	}
+	if err := h.Email.SendMagicLink(req.Email, h.emailLocale(c, ctx, req.Email, req.Language), emailData); err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to send login link", Message: err.Error()})
+		return
+	}
+
+	h.recordAuthEvent(c, authEvent{Type: models.AuthEventCodeSent, Subject: req.Email, Channel: "email", Details: map[string]any{"method": "magic_link"}})
+
+	c.JSON(http.StatusOK, models.SendUserVerificationResponse{
+		Message:   "Login link sent successfully",
+		ExpiresAt: expiresAt,
+	})
+}
+
+// VerifyMagicLink handles POST /api/auth/verify-magic-link: the app redeems the token from the link
+// it was opened with and gets the same response as /verify-code
+func (h *Handler) VerifyMagicLink(c *gin.Context) {
+	var req models.VerifyMagicLinkRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid request data", Message: err.Error()})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	email, err := h.DB.ConsumeMagicLink(ctx, hashRefreshTokenString(strings.TrimSpace(req.Token)))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			h.recordAuthEvent(c, authEvent{Type: models.AuthEventVerifyFailure, Channel: "email", Details: map[string]any{"method": "magic_link", "reason": "invalid_link"}})
+			c.JSON(http.StatusUnauthorized, models.ErrorResponse{Error: "Invalid or expired link", Message: "The login link is invalid, expired or was already used"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to verify login link", Message: err.Error()})
+		return
+	}
+	h.recordAuthEvent(c, authEvent{Type: models.AuthEventVerifySuccess, Subject: email, Channel: "email", Details: map[string]any{"method": "magic_link"}})
+	h.clearSubjectFailures(ctx, email)
+	h.loginVerifiedEmail(c, ctx, email)
+}
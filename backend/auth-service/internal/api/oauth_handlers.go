@@ -0,0 +1,117 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/expotoworld/expotoworld/backend/auth-service/internal/models"
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+)
+
+// OAuthExchange handles POST /api/auth/oauth/:provider/exchange: the mobile app signs in with Apple or
+// Google natively and trades the provider's ID token for our access and refresh tokens.
+//   - An account already linked to the provider identity signs in.
+//   - Otherwise an account with the provider-verified email gets the identity linked and signs in.
+//   - Otherwise a new account is created, unless the client requires an existing one (X-Require-Existing).
+//
+// Admin accounts are refused; they log in through the admin panel with their security key.
+func (h *Handler) OAuthExchange(c *gin.Context) {
+	provider, ok := h.OIDC[c.Param("provider")]
+	if !ok {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "Unknown sign-in provider", Message: "Provider is not supported or not configured"})
+		return
+	}
+	var req models.OAuthExchangeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid request data", Message: err.Error()})
+		return
+	}
+	clientIP := getClientIP(c)
+
+	identity, err := provider.Verify(req.IDToken, req.Nonce)
+	if err != nil {
+		fmt.Printf("[USER_AUTH] Rejected %s ID token from IP: %s: %v\n", provider.Name, clientIP, err)
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse{Error: "Invalid ID token", Message: "The sign-in token could not be verified"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	requireExisting := strings.EqualFold(c.GetHeader("X-Require-Existing"), "true") || c.Query("require_existing") == "true"
+	requiredRole := strings.TrimSpace(c.GetHeader("X-Require-Role"))
+
+	linked := true
+	user, err := h.DB.GetUserByIdentity(ctx, identity.Provider, identity.Subject)
+	if errors.Is(err, pgx.ErrNoRows) {
+		linked = false
+		if identity.Email == "" || !identity.EmailVerified {
+			c.JSON(http.StatusForbidden, models.ErrorResponse{Error: "Email not verified", Message: "The provider did not share a verified email for this account"})
+			return
+		}
+		user, err = h.DB.GetUserByEmail(ctx, identity.Email)
+		if errors.Is(err, pgx.ErrNoRows) {
+			if requireExisting {
+				c.JSON(http.StatusForbidden, models.ErrorResponse{Error: "User not allowed", Message: "User does not exist"})
+				return
+			}
+			user, err = h.DB.CreateUserFromEmail(ctx, identity.Email)
+			if err == nil {
+				fmt.Printf("[USER_AUTH] Auto-registered new user via %s: %s\n", provider.Name, identity.Email)
+			}
+		}
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to retrieve user", Message: err.Error()})
+		return
+	}
+
+	// Check the role before linking, so a provider account is never attached to an admin
+	role, err := h.DB.GetUserRoleByID(ctx, user.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to validate user role", Message: err.Error()})
+		return
+	}
+	if role == "Admin" {
+		c.JSON(http.StatusForbidden, models.ErrorResponse{Error: "User not allowed", Message: "Admin accounts must log in through the admin panel"})
+		return
+	}
+	if requiredRole != "" && !strings.EqualFold(role, requiredRole) {
+		c.JSON(http.StatusForbidden, models.ErrorResponse{Error: "User not allowed", Message: "User role not permitted"})
+		return
+	}
+
+	if !linked {
+		if err := h.DB.LinkIdentity(ctx, user.ID, identity); err != nil {
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to link account", Message: err.Error()})
+			return
+		}
+		fmt.Printf("[USER_AUTH] Linked %s identity to user %s\n", provider.Name, user.ID)
+		// The provider verified the address, like a correct email code would
+		if err := h.DB.MarkEmailVerified(ctx, user.ID); err != nil {
+			fmt.Printf("Failed to mark email verified for user %s: %v\n", user.ID, err)
+		}
+	}
+	if err := h.DB.TouchIdentity(ctx, identity.Provider, identity.Subject); err != nil {
+		fmt.Printf("Failed to record %s sign-in for user %s: %v\n", provider.Name, user.ID, err)
+	}
+	if req.FirstName != nil || req.LastName != nil {
+		if err := h.DB.SetUserNameIfEmpty(ctx, user.ID, req.FirstName, req.LastName); err != nil {
+			fmt.Printf("Failed to set name for user %s: %v\n", user.ID, err)
+		} else {
+			if user.FirstName == nil {
+				user.FirstName = req.FirstName
+			}
+			if user.LastName == nil {
+				user.LastName = req.LastName
+			}
+		}
+	}
+
+	h.completeUserLogin(c, ctx, user, role, provider.Name, gin.H{"provider": provider.Name})
+}
@@ -0,0 +1,281 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/expotoworld/expotoworld/backend/auth-service/internal/models"
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+)
+
+// qrLoginPollInterval is how often a waiting poll re-reads the session
+const qrLoginPollInterval = time.Second
+
+func qrLoginTTL() time.Duration {
+	return time.Duration(getEnvInt("QR_LOGIN_TTL_SECONDS", 120)) * time.Second
+}
+
+// qrLoginPollWait is how long a poll is held open waiting for the session to change
+func qrLoginPollWait() time.Duration {
+	return time.Duration(getEnvInt("QR_LOGIN_POLL_WAIT_SECONDS", 25)) * time.Second
+}
+
+// qrLoginPayload is the text encoded in the QR code; the mobile app extracts the code from it
+func qrLoginPayload(code string) string {
+	prefix := os.Getenv("QR_LOGIN_URI_PREFIX")
+	if prefix == "" {
+		prefix = "expotoworld://qr-login?code="
+	}
+	return prefix + code
+}
+
+// qrLoginStatus reports a session's state as seen by clients, treating undecided or unredeemed sessions past expiry as expired
+func qrLoginStatus(s *models.QRLoginSession) string {
+	if s.Status != models.QRLoginDenied && s.Status != models.QRLoginConsumed && time.Now().After(s.ExpiresAt) {
+		return models.QRLoginExpired
+	}
+	return s.Status
+}
+
+// StartQRLogin creates a QR login for a desktop client such as the ebook editor.
+// The client shows qr_payload as a QR code and polls with poll_token until the mobile app approves.
+// X-Require-Role restricts which accounts may approve, as on verify-code. Starts are rate limited per IP.
+func (h *Handler) StartQRLogin(c *gin.Context) {
+	clientIP := getClientIP(c)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	decision, ok := h.checkUserRateLimit(ctx, c, "qr", clientIP, "")
+	if !ok {
+		return
+	}
+
+	code, err := generateRefreshTokenString(24)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to create QR login", Message: err.Error()})
+		return
+	}
+	pollToken, err := generateRefreshTokenString(32)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to create QR login", Message: err.Error()})
+		return
+	}
+
+	// Opportunistic cleanup before creating a new session (best effort)
+	if cleanErr := h.DB.CleanupQRLoginSessions(ctx); cleanErr != nil {
+		fmt.Printf("[QR_AUTH] QR login cleanup failed: %v\n", cleanErr)
+	}
+
+	session := &models.QRLoginSession{
+		CodeHash:           hashRefreshTokenString(code),
+		PollTokenHash:      hashRefreshTokenString(pollToken),
+		RequiredRole:       strings.TrimSpace(c.GetHeader("X-Require-Role")),
+		RequesterIP:        clientIP,
+		RequesterUserAgent: c.GetHeader("User-Agent"),
+		ExpiresAt:          time.Now().Add(qrLoginTTL()),
+	}
+	id, err := h.DB.CreateQRLoginSession(ctx, session)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to create QR login", Message: err.Error()})
+		return
+	}
+	if err := h.RateLimits.Record(ctx, "qr", clientIP, "", decision); err != nil {
+		fmt.Printf("[QR_AUTH] Failed to record QR login rate limit: %v\n", err)
+	}
+
+	fmt.Printf("[QR_AUTH] QR login %s started from IP: %s, UserAgent: %s\n", id, session.RequesterIP, session.RequesterUserAgent)
+	c.JSON(http.StatusCreated, gin.H{
+		"session_id":            id,
+		"poll_token":            pollToken,
+		"code":                  code,
+		"qr_payload":            qrLoginPayload(code),
+		"expires_at":            session.ExpiresAt,
+		"poll_interval_seconds": int(qrLoginPollInterval / time.Second),
+	})
+}
+
+// PollQRLogin waits until the session leaves the status the client last saw (or the wait elapses) and reports it.
+// An approved session is redeemed exactly once: that response carries the access and refresh tokens.
+func (h *Handler) PollQRLogin(c *gin.Context) {
+	var req models.QRLoginPollRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid request data", Message: err.Error()})
+		return
+	}
+
+	wait := qrLoginPollWait()
+	ctx, cancel := context.WithTimeout(c.Request.Context(), wait+10*time.Second)
+	defer cancel()
+	deadline := time.Now().Add(wait)
+	pollHash := hashRefreshTokenString(req.PollToken)
+
+	for {
+		session, err := h.DB.GetQRLoginSessionForPoll(ctx, req.SessionID, pollHash)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "QR login not found", Message: "Start a new QR login"})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to load QR login", Message: err.Error()})
+			return
+		}
+
+		status := qrLoginStatus(session)
+		switch status {
+		case models.QRLoginApproved:
+			h.redeemQRLogin(c, ctx, session)
+			return
+		case models.QRLoginConsumed:
+			c.JSON(http.StatusGone, models.ErrorResponse{Error: "QR login already used", Message: "Start a new QR login"})
+			return
+		}
+		if status != req.Status || status == models.QRLoginDenied || status == models.QRLoginExpired || !time.Now().Before(deadline) {
+			c.JSON(http.StatusOK, gin.H{"status": status, "expires_at": session.ExpiresAt})
+			return
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(qrLoginPollInterval):
+		}
+	}
+}
+
+// redeemQRLogin issues tokens for an approved session to the polling desktop client
+func (h *Handler) redeemQRLogin(c *gin.Context, ctx context.Context, session *models.QRLoginSession) {
+	if ok, err := h.DB.ConsumeQRLoginSession(ctx, session.ID); err != nil || !ok {
+		c.JSON(http.StatusGone, models.ErrorResponse{Error: "QR login already used", Message: "Start a new QR login"})
+		return
+	}
+	if session.UserID == nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to complete QR login", Message: "Approved session has no user"})
+		return
+	}
+	user, err := h.DB.GetUserByID(ctx, *session.UserID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to retrieve user", Message: err.Error()})
+		return
+	}
+	role, err := h.DB.GetUserRoleByID(ctx, user.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to validate user role", Message: err.Error()})
+		return
+	}
+	// The role may have changed between approval and redemption
+	if role == "Admin" || (session.RequiredRole != "" && !strings.EqualFold(role, session.RequiredRole)) {
+		c.JSON(http.StatusForbidden, models.ErrorResponse{Error: "User not allowed", Message: "User role not permitted"})
+		return
+	}
+
+	fmt.Printf("[QR_AUTH] QR login %s redeemed for user %s\n", session.ID, user.ID)
+	h.completeUserLogin(c, ctx, user, role, "qr", gin.H{"status": models.QRLoginApproved})
+}
+
+// loadQRLoginByCode resolves a scanned code to its session, writing the error response on failure
+func (h *Handler) loadQRLoginByCode(c *gin.Context, ctx context.Context) *models.QRLoginSession {
+	var req models.QRLoginCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid request data", Message: err.Error()})
+		return nil
+	}
+	session, err := h.DB.GetQRLoginSessionByCode(ctx, hashRefreshTokenString(strings.TrimSpace(req.Code)))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "QR login not found", Message: "Scan the code shown on your computer again"})
+			return nil
+		}
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to load QR login", Message: err.Error()})
+		return nil
+	}
+	if qrLoginStatus(session) == models.QRLoginExpired {
+		c.JSON(http.StatusGone, models.ErrorResponse{Error: "QR code expired", Message: "Refresh the code on your computer and scan again"})
+		return nil
+	}
+	return session
+}
+
+// ScanQRLogin is called by the signed-in mobile app after reading a QR code. It claims the session for the
+// caller and returns the requesting device so the user can check it before approving.
+func (h *Handler) ScanQRLogin(c *gin.Context) {
+	userID := c.GetString("user_id")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	session := h.loadQRLoginByCode(c, ctx)
+	if session == nil {
+		return
+	}
+	ok, err := h.DB.ScanQRLoginSession(ctx, session.ID, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to update QR login", Message: err.Error()})
+		return
+	}
+	if !ok {
+		c.JSON(http.StatusConflict, models.ErrorResponse{Error: "QR code already used", Message: "Refresh the code on your computer and scan again"})
+		return
+	}
+	session.Status = models.QRLoginScanned
+	c.JSON(http.StatusOK, session)
+}
+
+// ApproveQRLogin lets the signed-in mobile user log the desktop client into their account
+func (h *Handler) ApproveQRLogin(c *gin.Context) {
+	h.decideQRLogin(c, models.QRLoginApproved)
+}
+
+// DenyQRLogin rejects a QR login the mobile user does not recognise
+func (h *Handler) DenyQRLogin(c *gin.Context) {
+	h.decideQRLogin(c, models.QRLoginDenied)
+}
+
+func (h *Handler) decideQRLogin(c *gin.Context, decision string) {
+	userID := c.GetString("user_id")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	session := h.loadQRLoginByCode(c, ctx)
+	if session == nil {
+		return
+	}
+
+	forbidden := ""
+	if decision == models.QRLoginApproved {
+		role, err := h.DB.GetUserRoleByID(ctx, userID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to validate user role", Message: err.Error()})
+			return
+		}
+		// Deny instead of leaving the desktop client waiting for an approval that cannot succeed.
+		// Admin tokens require the security key step, which a QR approval cannot provide.
+		if role == "Admin" {
+			decision = models.QRLoginDenied
+			forbidden = "Admin accounts must log in through the admin panel"
+		} else if session.RequiredRole != "" && !strings.EqualFold(role, session.RequiredRole) {
+			decision = models.QRLoginDenied
+			forbidden = "User role not permitted"
+		}
+	}
+
+	ok, err := h.DB.DecideQRLoginSession(ctx, session.ID, userID, decision)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to update QR login", Message: err.Error()})
+		return
+	}
+	if !ok {
+		c.JSON(http.StatusConflict, models.ErrorResponse{Error: "QR code already used", Message: "Refresh the code on your computer and scan again"})
+		return
+	}
+	fmt.Printf("[QR_AUTH] QR login %s %s by user %s from IP: %s\n", session.ID, decision, userID, getClientIP(c))
+	if forbidden != "" {
+		c.JSON(http.StatusForbidden, models.ErrorResponse{Error: "User not allowed", Message: forbidden})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"session_id": session.ID, "status": decision})
+}
@@ -0,0 +1,102 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/expotoworld/expotoworld/backend/auth-service/internal/logging"
+	"github.com/expotoworld/expotoworld/backend/auth-service/internal/models"
+	"github.com/expotoworld/expotoworld/backend/auth-service/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+// checkUserRateLimit evaluates a user verification request against its risk tier.
+// It writes the error response and returns false when the request must not proceed.
+func (h *Handler) checkUserRateLimit(ctx context.Context, c *gin.Context, channel, clientIP, subject string) (*services.RateLimitDecision, bool) {
+	decision, err := h.RateLimits.Check(ctx, channel, clientIP, subject)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "Rate limit check failed",
+			Message: err.Error(),
+		})
+		return nil, false
+	}
+
+	level := "info"
+	if !decision.Allowed {
+		level = "warn"
+	}
+	logging.LogKV(level, "verification_rate_limit", map[string]interface{}{
+		"channel": channel,
+		"ip":      clientIP,
+		"tier":    decision.Tier.Tier,
+		"scope":   decision.Tier.Scope,
+		"count":   decision.Count,
+		"limit":   decision.Tier.MaxRequests,
+		"allowed": decision.Allowed,
+	})
+
+	if !decision.Allowed {
+		c.JSON(http.StatusTooManyRequests, models.ErrorResponse{
+			Error:   "Rate limit exceeded",
+			Message: fmt.Sprintf("Maximum %d requests per %d minutes allowed", decision.Tier.MaxRequests, decision.Tier.WindowMinutes),
+		})
+		return nil, false
+	}
+	return decision, true
+}
+
+// checkCaptcha requires a valid CAPTCHA token when the code request looks risky (see
+// services.CaptchaService.Risk). It writes the error response and returns false when the request
+// must not proceed; clients show the challenge when the response has captcha_required.
+func (h *Handler) checkCaptcha(ctx context.Context, c *gin.Context, channel, subject, token string, decision *services.RateLimitDecision) bool {
+	reasons := h.Captcha.Risk(channel, subject, decision.Count)
+	if len(reasons) == 0 {
+		return true
+	}
+	if token == "" {
+		token = c.GetHeader("X-Captcha-Token")
+	}
+	challenge := func(message string) bool {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error":            "Captcha required",
+			"message":          message,
+			"captcha_required": true,
+			"captcha_provider": h.Captcha.Provider,
+			"captcha_site_key": h.Captcha.SiteKey,
+		})
+		return false
+	}
+	if token == "" {
+		h.recordAuthEvent(c, authEvent{Type: models.AuthEventCaptchaFailure, Subject: subject, Channel: channel, Details: map[string]any{"reason": "missing", "risk": reasons}})
+		return challenge("Complete the challenge to receive a code")
+	}
+	if err := h.Captcha.Verify(ctx, token, getClientIP(c)); err != nil {
+		if errors.Is(err, services.ErrCaptchaFailed) {
+			h.recordAuthEvent(c, authEvent{Type: models.AuthEventCaptchaFailure, Subject: subject, Channel: channel, Details: map[string]any{"reason": "invalid", "risk": reasons}})
+			return challenge("The challenge could not be verified, please try again")
+		}
+		c.JSON(http.StatusServiceUnavailable, models.ErrorResponse{Error: "Captcha verification unavailable", Message: err.Error()})
+		return false
+	}
+	return true
+}
+
+// GetRateLimitMetrics returns per-tier allow/block counters and the active tier configuration (Admin only)
+func (h *Handler) GetRateLimitMetrics(c *gin.Context) {
+	if role, _ := c.Get("role"); role != "Admin" {
+		c.JSON(http.StatusForbidden, models.ErrorResponse{
+			Error:   "Access denied",
+			Message: "Admin role required",
+		})
+		return
+	}
+
+	metrics, tiers := h.RateLimits.Metrics()
+	c.JSON(http.StatusOK, gin.H{
+		"metrics": metrics,
+		"tiers":   tiers,
+	})
+}
@@ -0,0 +1,282 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"regexp"
+	"time"
+
+	"github.com/expotoworld/expotoworld/backend/auth-service/internal/db"
+	"github.com/expotoworld/expotoworld/backend/auth-service/internal/models"
+	"github.com/expotoworld/expotoworld/backend/common/rbac"
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+)
+
+// RequirePermission only lets through tokens granting perm (use after AuthMiddleware).
+// Regionally scoped admins are refused, as the auth-service admin endpoints are global.
+func RequirePermission(perm string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		perms, _ := c.Get("permissions")
+		role, _ := c.Get("role")
+		roleStr, _ := role.(string)
+		if !rbac.Granted(perms, roleStr, perm) {
+			c.JSON(http.StatusForbidden, models.ErrorResponse{
+				Error:   "Access denied",
+				Message: fmt.Sprintf("Permission %s required", perm),
+			})
+			c.Abort()
+			return
+		}
+		if _, scoped := c.Get("admin_scope"); scoped {
+			c.JSON(http.StatusForbidden, models.ErrorResponse{
+				Error:   "Access denied",
+				Message: "Not available to regionally scoped admins",
+			})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+var roleNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9 _.-]{1,63}$`)
+
+// validateRolePermissions rejects unknown permissions and, for service clients (role ""), the
+// admin-only ones. Other roles may hold admin-only permissions so they can be granted to some admins
+// (e.g. a "Super Admin" role for impersonation); userPermissions drops them for non-Admin users.
+func validateRolePermissions(c *gin.Context, role string, permissions []string) bool {
+	for _, p := range permissions {
+		if !rbac.Valid(p) {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid permission", Message: fmt.Sprintf("Unknown permission %q", p)})
+			return false
+		}
+		if rbac.AdminOnly(p) && role == "" {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid permission", Message: fmt.Sprintf("%s is reserved to Admin users", p)})
+			return false
+		}
+	}
+	return true
+}
+
+// ListPermissions handles GET /api/auth/admin/permissions
+func (h *Handler) ListPermissions(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	perms, err := h.DB.ListPermissions(ctx)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to list permissions", Message: err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"permissions": perms})
+}
+
+// ListRoles handles GET /api/auth/admin/roles
+func (h *Handler) ListRoles(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	roles, err := h.DB.ListRoles(ctx)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to list roles", Message: err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"roles": roles})
+}
+
+// CreateRole handles POST /api/auth/admin/roles
+func (h *Handler) CreateRole(c *gin.Context) {
+	var req models.CreateRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid request data", Message: err.Error()})
+		return
+	}
+	if !roleNamePattern.MatchString(req.Name) {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid role name", Message: "Use letters, digits, spaces, '.', '_' or '-'"})
+		return
+	}
+	if !validateRolePermissions(c, req.Name, req.Permissions) {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if err := h.DB.CreateRole(ctx, req.Name, req.Description, req.Permissions); err != nil {
+		if errors.Is(err, db.ErrRoleExists) {
+			c.JSON(http.StatusConflict, models.ErrorResponse{Error: "Role exists", Message: "A role with this name already exists"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to create role", Message: err.Error()})
+		return
+	}
+	log.Printf("[AUDIT][ROLES][CREATE] by=%s role=%s permissions=%v", c.GetString("user_id"), req.Name, req.Permissions)
+	h.respondRole(c, ctx, req.Name, http.StatusCreated)
+}
+
+// UpdateRole handles PUT /api/auth/admin/roles/:name. The Admin role is fixed so no one can lock
+// every admin out of role management.
+func (h *Handler) UpdateRole(c *gin.Context) {
+	name := c.Param("name")
+	var req models.UpdateRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid request data", Message: err.Error()})
+		return
+	}
+	if name == rbac.AdminRole {
+		c.JSON(http.StatusForbidden, models.ErrorResponse{Error: "Access denied", Message: "The Admin role can't be changed"})
+		return
+	}
+	var permissions []string
+	if req.Permissions != nil {
+		permissions = append([]string{}, *req.Permissions...)
+		if !validateRolePermissions(c, name, permissions) {
+			return
+		}
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if err := h.DB.UpdateRole(ctx, name, req.Description, permissions); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "Role not found", Message: "No role with this name"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to update role", Message: err.Error()})
+		return
+	}
+	log.Printf("[AUDIT][ROLES][UPDATE] by=%s role=%s permissions=%v", c.GetString("user_id"), name, req.Permissions)
+	h.respondRole(c, ctx, name, http.StatusOK)
+}
+
+// DeleteRole handles DELETE /api/auth/admin/roles/:name (custom roles only)
+func (h *Handler) DeleteRole(c *gin.Context) {
+	name := c.Param("name")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	deleted, err := h.DB.DeleteRole(ctx, name)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to delete role", Message: err.Error()})
+		return
+	}
+	if !deleted {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "Role not found", Message: "No custom role with this name; built-in roles can't be deleted"})
+		return
+	}
+	log.Printf("[AUDIT][ROLES][DELETE] by=%s role=%s", c.GetString("user_id"), name)
+	c.Status(http.StatusNoContent)
+}
+
+func (h *Handler) respondRole(c *gin.Context, ctx context.Context, name string, status int) {
+	role, err := h.DB.GetRole(ctx, name)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to load role", Message: err.Error()})
+		return
+	}
+	c.JSON(status, role)
+}
+
+// ListUserRoles handles GET /api/auth/admin/users/:user_id/roles: the primary role, the assigned
+// roles and the permissions they add up to
+func (h *Handler) ListUserRoles(c *gin.Context) {
+	userID := c.Param("user_id")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	primary, err := h.DB.GetUserRoleByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "User not found", Message: "The specified user does not exist"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to retrieve user", Message: err.Error()})
+		return
+	}
+	assigned, err := h.DB.ListUserRoles(ctx, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to list user roles", Message: err.Error()})
+		return
+	}
+	perms, err := h.userPermissions(ctx, userID, primary)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to load permissions", Message: err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"user_id":      userID,
+		"primary_role": primary,
+		"roles":        assigned,
+		"permissions":  perms,
+	})
+}
+
+// AssignUserRole handles POST /api/auth/admin/users/:user_id/roles. The Admin role is only given as
+// a primary role (user-service), since it comes with the security key login.
+func (h *Handler) AssignUserRole(c *gin.Context) {
+	userID := c.Param("user_id")
+	var req models.AssignRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid request data", Message: err.Error()})
+		return
+	}
+	if req.Role == rbac.AdminRole {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid role", Message: "Admin is assigned as the user's primary role"})
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if _, err := h.DB.GetUserRoleByID(ctx, userID); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "User not found", Message: "The specified user does not exist"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to retrieve user", Message: err.Error()})
+		return
+	}
+	if _, err := h.DB.GetRole(ctx, req.Role); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "Role not found", Message: "No role with this name"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to load role", Message: err.Error()})
+		return
+	}
+	if err := h.DB.AssignUserRole(ctx, userID, req.Role, c.GetString("user_id")); err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to assign role", Message: err.Error()})
+		return
+	}
+	log.Printf("[AUDIT][ROLES][ASSIGN] by=%s target_user_id=%s role=%s", c.GetString("user_id"), userID, req.Role)
+	c.JSON(http.StatusOK, gin.H{"message": "Role assigned; it applies from the user's next token refresh", "user_id": userID, "role": req.Role})
+}
+
+// RevokeUserRole handles DELETE /api/auth/admin/users/:user_id/roles/:role
+func (h *Handler) RevokeUserRole(c *gin.Context) {
+	userID, role := c.Param("user_id"), c.Param("role")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	revoked, err := h.DB.RevokeUserRole(ctx, userID, role)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to revoke role", Message: err.Error()})
+		return
+	}
+	if !revoked {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "Role not assigned", Message: "The user doesn't have this role"})
+		return
+	}
+	log.Printf("[AUDIT][ROLES][REVOKE] by=%s target_user_id=%s role=%s", c.GetString("user_id"), userID, role)
+	c.Status(http.StatusNoContent)
+}
+
+// userPermissions returns the user's effective permissions. Admin-only permissions are dropped unless
+// the primary role is Admin, whatever the tables say.
+func (h *Handler) userPermissions(ctx context.Context, userID, role string) ([]string, error) {
+	perms, err := h.DB.GetUserPermissions(ctx, userID, role)
+	if err != nil {
+		return nil, err
+	}
+	return rbac.Effective(role, perms), nil
+}
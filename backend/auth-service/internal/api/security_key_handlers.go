@@ -0,0 +1,523 @@
+package api
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base32"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/expotoworld/expotoworld/backend/auth-service/internal/db"
+	"github.com/expotoworld/expotoworld/backend/auth-service/internal/models"
+	"github.com/expotoworld/expotoworld/backend/auth-service/internal/webauthn"
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+)
+
+const recoveryCodeCount = 10
+
+// securityKeysRequired reports whether every Admin must log in with a security key (enrolling one if needed)
+func securityKeysRequired() bool {
+	v := strings.ToLower(strings.TrimSpace(os.Getenv("ADMIN_SECURITY_KEY_REQUIRED")))
+	return v == "true" || v == "1" || v == "yes"
+}
+
+func mfaChallengeTTL() time.Duration {
+	return time.Duration(getEnvInt("ADMIN_MFA_CHALLENGE_TTL_SECONDS", 300)) * time.Second
+}
+
+// generateRecoveryCodes returns n one-time codes formatted as xxxx-xxxx-xxxx
+func generateRecoveryCodes(n int) ([]string, error) {
+	enc := base32.StdEncoding.WithPadding(base32.NoPadding)
+	codes := make([]string, 0, n)
+	for i := 0; i < n; i++ {
+		b := make([]byte, 8)
+		if _, err := rand.Read(b); err != nil {
+			return nil, err
+		}
+		s := strings.ToLower(enc.EncodeToString(b))[:12]
+		codes = append(codes, s[:4]+"-"+s[4:8]+"-"+s[8:])
+	}
+	return codes, nil
+}
+
+// hashRecoveryCode normalizes a code as typed by the user (case, dashes, spaces) before hashing
+func hashRecoveryCode(code string) string {
+	code = strings.ToLower(code)
+	code = strings.NewReplacer("-", "", " ", "").Replace(code)
+	return hashRefreshTokenString(code)
+}
+
+// issueRecoveryCodes replaces the user's recovery codes and returns the new plaintext codes
+func (h *Handler) issueRecoveryCodes(ctx context.Context, userID string) ([]string, error) {
+	codes, err := generateRecoveryCodes(recoveryCodeCount)
+	if err != nil {
+		return nil, err
+	}
+	hashes := make([]string, len(codes))
+	for i, code := range codes {
+		hashes[i] = hashRecoveryCode(code)
+	}
+	if err := h.DB.ReplaceRecoveryCodes(ctx, userID, hashes); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+func credentialDescriptors(keys []models.SecurityKey) []webauthn.CredentialDescriptor {
+	out := make([]webauthn.CredentialDescriptor, 0, len(keys))
+	for _, k := range keys {
+		out = append(out, webauthn.NewCredentialDescriptor(k.CredentialID, k.Transports))
+	}
+	return out
+}
+
+// beginAdminSecondFactor answers a verified email code with a security key challenge instead of tokens
+// when the Admin has registered keys (or keys are mandatory). It returns false if no second factor applies.
+func (h *Handler) beginAdminSecondFactor(c *gin.Context, ctx context.Context, userID, email, role string) bool {
+	keys, err := h.DB.ListSecurityKeys(ctx, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to load security keys", Message: err.Error()})
+		return true
+	}
+	if len(keys) == 0 && !securityKeysRequired() {
+		return false
+	}
+
+	challenge, err := webauthn.NewChallenge()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to create challenge", Message: err.Error()})
+		return true
+	}
+	// Opportunistic cleanup before creating a new challenge (best effort)
+	if cleanErr := h.DB.CleanupMFAChallenges(ctx); cleanErr != nil {
+		fmt.Printf("[ADMIN_AUTH] MFA challenge cleanup failed: %v\n", cleanErr)
+	}
+	expiresAt := time.Now().Add(mfaChallengeTTL())
+	mfaToken, err := h.DB.CreateMFAChallenge(ctx, &models.MFAChallenge{
+		UserID: userID, Email: email, Role: role, Purpose: models.MFAPurposeLogin,
+		Challenge: challenge, ExpiresAt: expiresAt,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to create challenge", Message: err.Error()})
+		return true
+	}
+
+	if len(keys) == 0 {
+		fmt.Printf("[ADMIN_AUTH] Security key enrollment required for %s\n", email)
+		c.JSON(http.StatusOK, gin.H{
+			"enrollment_required": true,
+			"mfa_token":           mfaToken,
+			"mfa_expires_at":      expiresAt,
+		})
+		return true
+	}
+
+	fmt.Printf("[ADMIN_AUTH] Security key required for %s (%d registered)\n", email, len(keys))
+	c.JSON(http.StatusOK, gin.H{
+		"second_factor_required": true,
+		"mfa_token":              mfaToken,
+		"mfa_expires_at":         expiresAt,
+		"public_key":             webauthn.ConfigFromEnv().RequestOptions(challenge, credentialDescriptors(keys)),
+	})
+	return true
+}
+
+// loadLoginChallenge resolves an mfa_token to its pending login, writing the error response on failure
+func (h *Handler) loadLoginChallenge(c *gin.Context, ctx context.Context, mfaToken string) *models.MFAChallenge {
+	ch, err := h.DB.GetMFAChallenge(ctx, mfaToken, models.MFAPurposeLogin)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			c.JSON(http.StatusUnauthorized, models.ErrorResponse{Error: "Invalid or expired login", Message: "Request a new verification code"})
+			return nil
+		}
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to load login", Message: err.Error()})
+		return nil
+	}
+	if ch.Attempts >= getEnvInt("MAX_CODE_ATTEMPTS", 3) {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse{Error: "Maximum attempts exceeded", Message: "Request a new verification code"})
+		return nil
+	}
+	return ch
+}
+
+// failSecondFactor counts a failed attempt against the pending login
+func (h *Handler) failSecondFactor(c *gin.Context, ctx context.Context, ch *models.MFAChallenge, message string) {
+	if err := h.DB.FailMFAChallenge(ctx, ch.ID); err != nil {
+		fmt.Printf("Failed to update MFA attempt count: %v\n", err)
+	}
+	h.recordAuthEvent(c, authEvent{Type: models.AuthEventSecondFactorFail, UserID: ch.UserID, Subject: ch.Email, Details: map[string]any{"reason": message}})
+	c.JSON(http.StatusUnauthorized, models.ErrorResponse{Error: "Second factor failed", Message: message})
+}
+
+// AdminVerifySecondFactor completes an admin login with a security key assertion or a recovery code
+func (h *Handler) AdminVerifySecondFactor(c *gin.Context) {
+	var req models.SecondFactorRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid request data", Message: err.Error()})
+		return
+	}
+	if (len(req.Credential) == 0) == (req.RecoveryCode == "") {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid request data", Message: "Provide either credential or recovery_code"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	ch := h.loadLoginChallenge(c, ctx, req.MFAToken)
+	if ch == nil {
+		return
+	}
+
+	var extra gin.H
+	if req.RecoveryCode != "" {
+		ok, remaining, err := h.DB.UseRecoveryCode(ctx, ch.UserID, hashRecoveryCode(req.RecoveryCode))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to verify recovery code", Message: err.Error()})
+			return
+		}
+		if !ok {
+			h.failSecondFactor(c, ctx, ch, "Invalid recovery code")
+			return
+		}
+		h.recordAuthEvent(c, authEvent{Type: models.AuthEventVerifySuccess, UserID: ch.UserID, Subject: ch.Email, Channel: "recovery_code",
+			Details: map[string]any{"admin": true, "recovery_codes_remaining": remaining}})
+		extra = gin.H{"recovery_codes_remaining": remaining}
+	} else {
+		var assertion webauthn.AssertionResponse
+		if err := json.Unmarshal(req.Credential, &assertion); err != nil {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid credential", Message: err.Error()})
+			return
+		}
+		credID, err := assertion.CredentialID()
+		if err != nil {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid credential", Message: err.Error()})
+			return
+		}
+		key, err := h.DB.GetSecurityKeyByCredentialID(ctx, ch.UserID, credID)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				h.failSecondFactor(c, ctx, ch, "Unknown security key")
+				return
+			}
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to load security key", Message: err.Error()})
+			return
+		}
+		signCount, err := webauthn.ConfigFromEnv().VerifyAssertion(assertion, ch.Challenge, key.PublicKey, key.SignCount)
+		if err != nil {
+			h.failSecondFactor(c, ctx, ch, err.Error())
+			return
+		}
+		if err := h.DB.TouchSecurityKey(ctx, key.ID, signCount); err != nil {
+			fmt.Printf("Failed to update security key %s: %v\n", key.ID, err)
+		}
+	}
+
+	if ok, err := h.DB.ConsumeMFAChallenge(ctx, ch.ID); err != nil || !ok {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse{Error: "Invalid or expired login", Message: "Request a new verification code"})
+		return
+	}
+	h.completeAdminLogin(c, ctx, ch.UserID, ch.Email, ch.Role, extra)
+}
+
+// AdminBeginEnrollment starts registering the first security key of an Admin who must use one to log in
+func (h *Handler) AdminBeginEnrollment(c *gin.Context) {
+	var req models.MFATokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid request data", Message: err.Error()})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	ch := h.loadLoginChallenge(c, ctx, req.MFAToken)
+	if ch == nil {
+		return
+	}
+	if !h.requireNoSecurityKeys(c, ctx, ch.UserID) {
+		return
+	}
+
+	challenge, err := webauthn.NewChallenge()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to create challenge", Message: err.Error()})
+		return
+	}
+	if err := h.DB.RenewMFAChallenge(ctx, ch.ID, challenge); err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to create challenge", Message: err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"mfa_token":  ch.ID,
+		"public_key": webauthn.ConfigFromEnv().CreationOptions(challenge, []byte(ch.UserID), ch.Email, nil),
+	})
+}
+
+// AdminFinishEnrollment stores the first security key, issues recovery codes and completes the login
+func (h *Handler) AdminFinishEnrollment(c *gin.Context) {
+	var req models.FinishSecurityKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid request data", Message: err.Error()})
+		return
+	}
+	if req.MFAToken == "" {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid request data", Message: "mfa_token is required"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	ch := h.loadLoginChallenge(c, ctx, req.MFAToken)
+	if ch == nil {
+		return
+	}
+	if !h.requireNoSecurityKeys(c, ctx, ch.UserID) {
+		return
+	}
+
+	key := h.registerSecurityKey(c, ctx, ch, req)
+	if key == nil {
+		return
+	}
+	codes, err := h.issueRecoveryCodes(ctx, ch.UserID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to generate recovery codes", Message: err.Error()})
+		return
+	}
+	if ok, err := h.DB.ConsumeMFAChallenge(ctx, ch.ID); err != nil || !ok {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse{Error: "Invalid or expired login", Message: "Request a new verification code"})
+		return
+	}
+	h.completeAdminLogin(c, ctx, ch.UserID, ch.Email, ch.Role, gin.H{
+		"security_key":   key,
+		"recovery_codes": codes,
+	})
+}
+
+// requireNoSecurityKeys rejects login-time enrollment for Admins that already have a key (they must use it)
+func (h *Handler) requireNoSecurityKeys(c *gin.Context, ctx context.Context, userID string) bool {
+	n, err := h.DB.CountSecurityKeys(ctx, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to load security keys", Message: err.Error()})
+		return false
+	}
+	if n > 0 {
+		c.JSON(http.StatusConflict, models.ErrorResponse{Error: "Security key already registered", Message: "Log in with your security key or a recovery code"})
+		return false
+	}
+	return true
+}
+
+// registerSecurityKey verifies an attestation against the pending challenge and stores the key
+func (h *Handler) registerSecurityKey(c *gin.Context, ctx context.Context, ch *models.MFAChallenge, req models.FinishSecurityKeyRequest) *models.SecurityKey {
+	var reg webauthn.RegistrationResponse
+	if err := json.Unmarshal(req.Credential, &reg); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid credential", Message: err.Error()})
+		return nil
+	}
+	cred, err := webauthn.ConfigFromEnv().VerifyRegistration(reg, ch.Challenge)
+	if err != nil {
+		if dbErr := h.DB.FailMFAChallenge(ctx, ch.ID); dbErr != nil {
+			fmt.Printf("Failed to update MFA attempt count: %v\n", dbErr)
+		}
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Security key registration failed", Message: err.Error()})
+		return nil
+	}
+
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		name = "Security key"
+	}
+	transports := cred.Transports
+	if transports == nil {
+		transports = []string{}
+	}
+	key, err := h.DB.CreateSecurityKey(ctx, &models.SecurityKey{
+		UserID:       ch.UserID,
+		Name:         name,
+		CredentialID: cred.ID,
+		PublicKey:    cred.PublicKey,
+		SignCount:    cred.SignCount,
+		Transports:   transports,
+	}, cred.AAGUID)
+	if err != nil {
+		if errors.Is(err, db.ErrCredentialExists) {
+			c.JSON(http.StatusConflict, models.ErrorResponse{Error: "Security key already registered", Message: err.Error()})
+			return nil
+		}
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to save security key", Message: err.Error()})
+		return nil
+	}
+	fmt.Printf("[ADMIN_AUTH] Security key %s registered for %s\n", key.ID, ch.Email)
+	return key
+}
+
+// RequireAdminRole allows any Admin, including regionally scoped ones, to manage their own account
+func RequireAdminRole() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if role, _ := c.Get("role"); role != "Admin" {
+			c.JSON(http.StatusForbidden, models.ErrorResponse{
+				Error:   "Access denied",
+				Message: "Admin role required",
+			})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// ListSecurityKeys returns the caller's security keys and unused recovery code count
+func (h *Handler) ListSecurityKeys(c *gin.Context) {
+	userID := c.GetString("user_id")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	keys, err := h.DB.ListSecurityKeys(ctx, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to load security keys", Message: err.Error()})
+		return
+	}
+	remaining, err := h.DB.CountRecoveryCodes(ctx, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to load recovery codes", Message: err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"security_keys":            keys,
+		"recovery_codes_remaining": remaining,
+		"required":                 securityKeysRequired(),
+	})
+}
+
+// BeginSecurityKeyRegistration starts adding a security key to the signed-in Admin's account
+func (h *Handler) BeginSecurityKeyRegistration(c *gin.Context) {
+	userID := c.GetString("user_id")
+	email := c.GetString("email")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	keys, err := h.DB.ListSecurityKeys(ctx, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to load security keys", Message: err.Error()})
+		return
+	}
+	challenge, err := webauthn.NewChallenge()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to create challenge", Message: err.Error()})
+		return
+	}
+	challengeID, err := h.DB.CreateMFAChallenge(ctx, &models.MFAChallenge{
+		UserID: userID, Email: email, Role: "Admin", Purpose: models.MFAPurposeRegister,
+		Challenge: challenge, ExpiresAt: time.Now().Add(mfaChallengeTTL()),
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to create challenge", Message: err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"challenge_id": challengeID,
+		"public_key":   webauthn.ConfigFromEnv().CreationOptions(challenge, []byte(userID), email, credentialDescriptors(keys)),
+	})
+}
+
+// FinishSecurityKeyRegistration stores the new key; the first key also issues recovery codes
+func (h *Handler) FinishSecurityKeyRegistration(c *gin.Context) {
+	var req models.FinishSecurityKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid request data", Message: err.Error()})
+		return
+	}
+	if req.ChallengeID == "" {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid request data", Message: "challenge_id is required"})
+		return
+	}
+	userID := c.GetString("user_id")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	ch, err := h.DB.GetMFAChallenge(ctx, req.ChallengeID, models.MFAPurposeRegister)
+	if err != nil || ch.UserID != userID {
+		if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to load challenge", Message: err.Error()})
+			return
+		}
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid or expired challenge", Message: "Start the registration again"})
+		return
+	}
+	if ok, err := h.DB.ConsumeMFAChallenge(ctx, ch.ID); err != nil || !ok {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid or expired challenge", Message: "Start the registration again"})
+		return
+	}
+
+	key := h.registerSecurityKey(c, ctx, ch, req)
+	if key == nil {
+		return
+	}
+	resp := gin.H{"security_key": key}
+	if n, err := h.DB.CountSecurityKeys(ctx, userID); err == nil && n == 1 {
+		codes, err := h.issueRecoveryCodes(ctx, userID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to generate recovery codes", Message: err.Error()})
+			return
+		}
+		resp["recovery_codes"] = codes
+	}
+	if err := h.revokeAccessTokens(ctx, userID, "security_key_added"); err != nil {
+		fmt.Printf("[WARN] %v\n", err)
+	}
+	c.JSON(http.StatusCreated, resp)
+}
+
+// DeleteSecurityKey removes one of the caller's security keys
+func (h *Handler) DeleteSecurityKey(c *gin.Context) {
+	userID := c.GetString("user_id")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	removed, remaining, err := h.DB.DeleteSecurityKey(ctx, userID, c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to delete security key", Message: err.Error()})
+		return
+	}
+	if !removed {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "Not found", Message: "Security key not found"})
+		return
+	}
+	fmt.Printf("[ADMIN_AUTH] Security key %s removed by %s, %d remaining\n", c.Param("id"), c.GetString("email"), remaining)
+	if err := h.revokeAccessTokens(ctx, userID, "security_key_removed"); err != nil {
+		fmt.Printf("[WARN] %v\n", err)
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Security key removed", "remaining": remaining})
+}
+
+// RegenerateRecoveryCodes replaces the caller's recovery codes; requires at least one security key
+func (h *Handler) RegenerateRecoveryCodes(c *gin.Context) {
+	userID := c.GetString("user_id")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	n, err := h.DB.CountSecurityKeys(ctx, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to load security keys", Message: err.Error()})
+		return
+	}
+	if n == 0 {
+		c.JSON(http.StatusConflict, models.ErrorResponse{Error: "No security key", Message: "Register a security key first"})
+		return
+	}
+	codes, err := h.issueRecoveryCodes(ctx, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to generate recovery codes", Message: err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"recovery_codes": codes})
+}
@@ -0,0 +1,296 @@
+package api
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/expotoworld/expotoworld/backend/auth-service/internal/models"
+	"github.com/expotoworld/expotoworld/backend/common/auth"
+	"github.com/expotoworld/expotoworld/backend/common/rbac"
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/jackc/pgx/v5"
+)
+
+// tokenUseService marks access tokens issued to service clients rather than users
+const tokenUseService = "service"
+
+// authenticateServiceClient checks the client credentials from HTTP Basic or, failing that, the
+// request body and writes an invalid_client error when they don't match an active client
+func (h *Handler) authenticateServiceClient(c *gin.Context, ctx context.Context, clientID, clientSecret string) (*models.ServiceClient, bool) {
+	if id, secret, ok := c.Request.BasicAuth(); ok {
+		clientID, clientSecret = id, secret
+	}
+	fail := func() (*models.ServiceClient, bool) {
+		c.Header("WWW-Authenticate", `Basic realm="auth-service"`)
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse{Error: "invalid_client", Message: "Client authentication failed"})
+		return nil, false
+	}
+	if clientID == "" || clientSecret == "" {
+		return fail()
+	}
+	client, secretHash, err := h.DB.GetActiveServiceClient(ctx, clientID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return fail()
+		}
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "server_error", Message: err.Error()})
+		return nil, false
+	}
+	if subtle.ConstantTimeCompare([]byte(hashRefreshTokenString(clientSecret)), []byte(secretHash)) != 1 {
+		return fail()
+	}
+	if err := h.DB.TouchServiceClient(ctx, client.ClientID); err != nil {
+		fmt.Printf("[SERVICE_CLIENTS] Failed to record use of %s: %v\n", client.ClientID, err)
+	}
+	return client, true
+}
+
+// ServiceAuthMiddleware authenticates internal services by their client-credentials access token
+// and requires perm. The client is looked up on every request, so revoking it or changing its
+// permissions takes effect before its tokens expire.
+func (h *Handler) ServiceAuthMiddleware(perm string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if !ok || !h.Tokens.Configured() {
+			c.JSON(http.StatusUnauthorized, models.ErrorResponse{Error: "Authorization header required", Message: "Please provide a service access token"})
+			c.Abort()
+			return
+		}
+		claims, err := h.Tokens.Parse(strings.TrimSpace(token))
+		if err != nil || claims["token_use"] != tokenUseService {
+			c.JSON(http.StatusUnauthorized, models.ErrorResponse{Error: "Invalid token", Message: "A valid service access token is required"})
+			c.Abort()
+			return
+		}
+		clientID, _ := claims["client_id"].(string)
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		client, _, err := h.DB.GetActiveServiceClient(ctx, clientID)
+		if errors.Is(err, pgx.ErrNoRows) {
+			c.JSON(http.StatusUnauthorized, models.ErrorResponse{Error: "Invalid token", Message: "The service client was revoked"})
+			c.Abort()
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "server_error", Message: err.Error()})
+			c.Abort()
+			return
+		}
+		if !rbac.Granted(client.Permissions, "", perm) {
+			c.JSON(http.StatusForbidden, models.ErrorResponse{Error: "Access denied", Message: fmt.Sprintf("Permission %s required", perm)})
+			c.Abort()
+			return
+		}
+		c.Set("client_id", client.ClientID)
+		c.Next()
+	}
+}
+
+// IssueToken handles POST /api/auth/token: the OAuth 2.0 client-credentials grant for service
+// clients. The token carries the client's permissions and no user.
+func (h *Handler) IssueToken(c *gin.Context) {
+	var req models.TokenRequest
+	if err := c.ShouldBind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "invalid_request", Message: err.Error()})
+		return
+	}
+	if req.GrantType != "client_credentials" {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "unsupported_grant_type", Message: "Only client_credentials is supported"})
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	client, ok := h.authenticateServiceClient(c, ctx, req.ClientID, req.ClientSecret)
+	if !ok {
+		return
+	}
+
+	ttl := getEnvInt("SERVICE_TOKEN_TTL_MINUTES", 60)
+	if ttl <= 0 {
+		ttl = 60
+	}
+	now := time.Now()
+	token, err := h.signClaims(jwt.MapClaims{
+		"sub":         client.ClientID,
+		"client_id":   client.ClientID,
+		"token_use":   tokenUseService,
+		"permissions": client.Permissions,
+		"iat":         now.Unix(),
+		"exp":         now.Add(time.Duration(ttl) * time.Minute).Unix(),
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "server_error", Message: err.Error()})
+		return
+	}
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, gin.H{
+		"access_token": token,
+		"token_type":   "Bearer",
+		"expires_in":   ttl * 60,
+		"scope":        strings.Join(client.Permissions, " "),
+	})
+}
+
+// Introspect handles POST /api/auth/introspect (RFC 7662) for service clients. Besides validating
+// the token it returns the user's current role, permissions, org memberships and admin scope, so a
+// deactivated user or a role change takes effect before the token expires.
+func (h *Handler) Introspect(c *gin.Context) {
+	var req models.IntrospectRequest
+	if err := c.ShouldBind(&req); err != nil || strings.TrimSpace(req.Token) == "" {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "invalid_request", Message: "token is required"})
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if _, ok := h.authenticateServiceClient(c, ctx, req.ClientID, req.ClientSecret); !ok {
+		return
+	}
+	c.Header("Cache-Control", "no-store")
+	inactive := gin.H{"active": false}
+
+	claims, err := h.Tokens.Parse(strings.TrimSpace(req.Token))
+	if err != nil {
+		c.JSON(http.StatusOK, inactive)
+		return
+	}
+	resp := gin.H{"active": true, "token_type": "access_token", "exp": claims["exp"], "iat": claims["iat"]}
+
+	if claims["token_use"] == tokenUseService {
+		clientID, _ := claims["client_id"].(string)
+		client, _, err := h.DB.GetActiveServiceClient(ctx, clientID)
+		if errors.Is(err, pgx.ErrNoRows) {
+			c.JSON(http.StatusOK, inactive)
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "server_error", Message: err.Error()})
+			return
+		}
+		resp["sub"] = client.ClientID
+		resp["client_id"] = client.ClientID
+		resp["token_use"] = tokenUseService
+		resp["permissions"] = client.Permissions
+		resp["scope"] = strings.Join(client.Permissions, " ")
+		c.JSON(http.StatusOK, resp)
+		return
+	}
+
+	userID, _ := claims["user_id"].(string)
+	if userID == "" {
+		c.JSON(http.StatusOK, inactive)
+		return
+	}
+	role, active, err := h.DB.GetUserAccountState(ctx, userID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		c.JSON(http.StatusOK, inactive)
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "server_error", Message: err.Error()})
+		return
+	}
+	// A role change needs a new login (an Admin one includes the security key step)
+	if tokenRole, _ := claims["role"].(string); !active || tokenRole != role {
+		c.JSON(http.StatusOK, inactive)
+		return
+	}
+	extra, err := h.userClaims(ctx, userID, role)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "server_error", Message: err.Error()})
+		return
+	}
+	// Revoked by a forced logout or sign-in method change since it was issued
+	if version, _ := extra[auth.TokenVersionClaim].(int); auth.TokenVersion(claims) < version {
+		c.JSON(http.StatusOK, inactive)
+		return
+	}
+	for k, v := range extra {
+		resp[k] = v
+	}
+	resp["sub"] = userID
+	resp["user_id"] = userID
+	resp["email"] = claims["email"]
+	resp["role"] = role
+	resp["token_use"] = "user"
+	if act, ok := claims["act"]; ok {
+		resp["act"] = act
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// ListServiceClients handles GET /api/auth/admin/service-clients
+func (h *Handler) ListServiceClients(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	clients, err := h.DB.ListServiceClients(ctx)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to list service clients", Message: err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"service_clients": clients})
+}
+
+// CreateServiceClient handles POST /api/auth/admin/service-clients. The secret is only returned here.
+func (h *Handler) CreateServiceClient(c *gin.Context) {
+	var req models.CreateServiceClientRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid request data", Message: err.Error()})
+		return
+	}
+	// Service tokens skip the admin login's second factor, so they never carry admin-only permissions
+	if !validateRolePermissions(c, "", req.Permissions) {
+		return
+	}
+
+	idBytes := make([]byte, 12)
+	if _, err := rand.Read(idBytes); err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to create service client", Message: err.Error()})
+		return
+	}
+	clientID := "svc_" + hex.EncodeToString(idBytes)
+	secret, err := generateRefreshTokenString(32)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to create service client", Message: err.Error()})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	client, err := h.DB.CreateServiceClient(ctx, clientID, req.Name, hashRefreshTokenString(secret), req.Permissions, c.GetString("user_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to create service client", Message: err.Error()})
+		return
+	}
+	log.Printf("[AUDIT][SERVICE_CLIENTS][CREATE] by=%s client_id=%s name=%q permissions=%v", c.GetString("user_id"), clientID, req.Name, req.Permissions)
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusCreated, gin.H{"service_client": client, "client_secret": secret})
+}
+
+// RevokeServiceClient handles DELETE /api/auth/admin/service-clients/:client_id
+func (h *Handler) RevokeServiceClient(c *gin.Context) {
+	clientID := c.Param("client_id")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	revoked, err := h.DB.RevokeServiceClient(ctx, clientID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to revoke service client", Message: err.Error()})
+		return
+	}
+	if !revoked {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "Not found", Message: "No active service client with this id"})
+		return
+	}
+	log.Printf("[AUDIT][SERVICE_CLIENTS][REVOKE] by=%s client_id=%s", c.GetString("user_id"), clientID)
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,184 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/expotoworld/expotoworld/backend/auth-service/internal/models"
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+)
+
+const maxBulkSessionIDs = 1000
+
+// parseSessionIPFilter normalizes an IP address or CIDR range to CIDR notation ("" stays "")
+func parseSessionIPFilter(v string) (string, error) {
+	v = strings.TrimSpace(v)
+	if v == "" {
+		return "", nil
+	}
+	if _, ipNet, err := net.ParseCIDR(v); err == nil {
+		return ipNet.String(), nil
+	}
+	ip := net.ParseIP(v)
+	if ip == nil {
+		return "", fmt.Errorf("ip must be an IP address or CIDR range")
+	}
+	if ip.To4() != nil {
+		return ip.String() + "/32", nil
+	}
+	return ip.String() + "/128", nil
+}
+
+// AdminSearchSessions handles GET /api/auth/admin/sessions
+// Filters: user_id, email, ip (address or CIDR), user_agent (substring), created_from/created_to (RFC3339),
+// include_ended=true to also list revoked/expired sessions. Paginated with page/limit.
+func (h *Handler) AdminSearchSessions(c *gin.Context) {
+	var f models.SessionFilter
+	if err := c.ShouldBindQuery(&f); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid query parameters", Message: err.Error()})
+		return
+	}
+	ipRange, err := parseSessionIPFilter(f.IP)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid query parameters", Message: err.Error()})
+		return
+	}
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 200 {
+		limit = 50
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	sessions, total, err := h.DB.SearchSessions(ctx, f, ipRange, limit, (page-1)*limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to search sessions", Message: err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, models.SessionListResponse{Sessions: sessions, Total: total, Page: page, Limit: limit})
+}
+
+// AdminRevokeSessions handles POST /api/auth/admin/sessions/revoke
+// Body: {"session_ids": [...]} revokes the listed sessions; {"filter": {...}} revokes every active
+// session matching the filter (at least one criterion required), e.g. {"filter": {"ip": "203.0.113.0/24"}}.
+func (h *Handler) AdminRevokeSessions(c *gin.Context) {
+	var req models.RevokeSessionsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid request", Message: err.Error()})
+		return
+	}
+	if (len(req.SessionIDs) == 0) == (req.Filter == nil) {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid request", Message: "Provide either session_ids or filter"})
+		return
+	}
+	if len(req.SessionIDs) > maxBulkSessionIDs {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid request", Message: fmt.Sprintf("At most %d session_ids per request", maxBulkSessionIDs)})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	var revoked int64
+	var err error
+	details := map[string]any{}
+	if req.Filter != nil {
+		if req.Filter.IsEmpty() {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid request", Message: "filter must have at least one criterion"})
+			return
+		}
+		ipRange, perr := parseSessionIPFilter(req.Filter.IP)
+		if perr != nil {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid request", Message: perr.Error()})
+			return
+		}
+		revoked, err = h.DB.RevokeSessionsMatching(ctx, *req.Filter, ipRange)
+		details["filter"] = req.Filter
+	} else {
+		revoked, err = h.DB.RevokeSessionsByID(ctx, req.SessionIDs)
+		details["session_ids"] = req.SessionIDs
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to revoke sessions", Message: err.Error()})
+		return
+	}
+	details["revoked"] = revoked
+	userID := ""
+	if req.Filter != nil {
+		userID = req.Filter.UserID
+	}
+	// Revoking a user's sessions by filter is a forced logout: their access tokens go too
+	if userID != "" {
+		if err := h.revokeAccessTokens(ctx, userID, "sessions_revoked"); err != nil && !errors.Is(err, pgx.ErrNoRows) {
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to revoke access tokens", Message: err.Error()})
+			return
+		}
+	}
+	h.recordAuthEvent(c, authEvent{Type: models.AuthEventRevocation, UserID: userID, Details: details})
+	c.JSON(http.StatusOK, models.RevokeSessionsResponse{Revoked: revoked})
+}
+
+// AdminSessionCounts handles GET /api/auth/admin/sessions/user-counts
+// Accepts the same filters as AdminSearchSessions (ended sessions are always counted in total_sessions).
+func (h *Handler) AdminSessionCounts(c *gin.Context) {
+	var f models.SessionFilter
+	if err := c.ShouldBindQuery(&f); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid query parameters", Message: err.Error()})
+		return
+	}
+	ipRange, err := parseSessionIPFilter(f.IP)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid query parameters", Message: err.Error()})
+		return
+	}
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "100"))
+	if limit < 1 || limit > 1000 {
+		limit = 100
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	counts, err := h.DB.CountSessionsByUser(ctx, f, ipRange, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to count sessions", Message: err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"users": counts})
+}
+
+// AdminForceLogout handles POST /api/auth/admin/sessions/users/:user_id/logout: revokes all of the
+// user's sessions and every access token issued to them, e.g. after a reported device theft
+func (h *Handler) AdminForceLogout(c *gin.Context) {
+	userID := c.Param("user_id")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if err := h.revokeAccessTokens(ctx, userID, "forced_logout"); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "User not found", Message: "No user with this id"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to revoke access tokens", Message: err.Error()})
+		return
+	}
+	revoked, err := h.DB.RevokeSessionsMatching(ctx, models.SessionFilter{UserID: userID}, "")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to revoke sessions", Message: err.Error()})
+		return
+	}
+	h.recordAuthEvent(c, authEvent{Type: models.AuthEventRevocation, UserID: userID, Details: map[string]any{"forced_logout": true, "revoked": revoked}})
+	c.JSON(http.StatusOK, models.RevokeSessionsResponse{Revoked: revoked})
+}
@@ -0,0 +1,36 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/expotoworld/expotoworld/backend/common/auth"
+	"github.com/jackc/pgx/v5"
+)
+
+// newTokenVersionCache caches the versions AuthMiddleware checks for TOKEN_VERSION_CACHE_SECONDS
+// (default 30). A bump made by this instance is seen immediately; other instances, and the other
+// services, see it once their entry expires.
+func (h *Handler) newTokenVersionCache() *auth.VersionCache {
+	return auth.NewVersionCache(auth.VersionFunc(func(ctx context.Context, userID string) (int, error) {
+		version, err := h.DB.GetTokenVersion(ctx, userID)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, auth.ErrTokenRevoked
+		}
+		return version, err
+	}), auth.VersionCacheTTL())
+}
+
+// revokeAccessTokens bumps the user's token version so every access token issued so far is rejected
+// by AuthMiddleware and introspection; refresh tokens are revoked separately by the caller if needed
+func (h *Handler) revokeAccessTokens(ctx context.Context, userID, reason string) error {
+	version, err := h.DB.BumpTokenVersion(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("failed to revoke access tokens: %w", err)
+	}
+	h.versions.Set(userID, version)
+	log.Printf("[AUDIT][TOKENS][REVOKE] user_id=%s token_version=%d reason=%s", userID, version, reason)
+	return nil
+}
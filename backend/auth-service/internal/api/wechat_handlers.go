@@ -0,0 +1,172 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/expotoworld/expotoworld/backend/auth-service/internal/models"
+	"github.com/expotoworld/expotoworld/backend/auth-service/internal/services"
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+)
+
+// weChatLinkTTL bounds how long a first-time WeChat user has to verify their phone (WECHAT_LINK_TTL_MINUTES)
+func weChatLinkTTL() time.Duration {
+	minutes := getEnvInt("WECHAT_LINK_TTL_MINUTES", 15)
+	if minutes <= 0 {
+		minutes = 15
+	}
+	return time.Duration(minutes) * time.Minute
+}
+
+// WeChatLogin handles POST /api/auth/wechat/login: the mini program (wx.login) or the app (WeChat
+// OAuth) sends its login code.
+//   - A WeChat account already linked, in this app or (by unionid) another of ours, signs in.
+//   - Otherwise WeChat shares no phone or email with us, so the response carries a link_token and the
+//     client verifies a phone number with /send-phone-verification and /wechat/link.
+func (h *Handler) WeChatLogin(c *gin.Context) {
+	var req models.WeChatLoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid request data", Message: err.Error()})
+		return
+	}
+	if !h.WeChat.Enabled(req.App) {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "Unknown sign-in provider", Message: "WeChat login is not configured for this app"})
+		return
+	}
+	clientIP := getClientIP(c)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	identity, err := h.WeChat.Exchange(ctx, req.App, req.Code)
+	if err != nil {
+		fmt.Printf("[USER_AUTH][WECHAT] Code exchange failed from IP: %s: %v\n", clientIP, err)
+		if errors.Is(err, services.ErrInvalidWeChatCode) {
+			c.JSON(http.StatusUnauthorized, models.ErrorResponse{Error: "Invalid WeChat code", Message: "The login code is invalid or expired"})
+			return
+		}
+		c.JSON(http.StatusBadGateway, models.ErrorResponse{Error: "WeChat unavailable", Message: "Could not reach WeChat, please try again"})
+		return
+	}
+
+	user, linked, err := h.DB.GetUserByWeChat(ctx, identity)
+	if errors.Is(err, pgx.ErrNoRows) {
+		// Opportunistic cleanup before creating a new link request (best effort)
+		if cleanErr := h.DB.CleanupWeChatLinkRequests(ctx); cleanErr != nil {
+			fmt.Printf("[USER_AUTH][WECHAT] Link request cleanup failed: %v\n", cleanErr)
+		}
+		expiresAt := time.Now().Add(weChatLinkTTL())
+		linkToken, err := h.DB.CreateWeChatLinkRequest(ctx, identity, expiresAt)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to start account linking", Message: err.Error()})
+			return
+		}
+		fmt.Printf("[USER_AUTH][WECHAT] Phone verification required for new WeChat account from IP: %s\n", clientIP)
+		c.JSON(http.StatusOK, gin.H{
+			"link_required":   true,
+			"link_token":      linkToken,
+			"link_expires_at": expiresAt,
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to retrieve user", Message: err.Error()})
+		return
+	}
+
+	h.completeWeChatLogin(c, ctx, user, identity, !linked)
+}
+
+// WeChatLink handles POST /api/auth/wechat/link: the phone code proves the number, and the WeChat
+// account from the link_token is linked to that phone's account (created if new) and signed in.
+func (h *Handler) WeChatLink(c *gin.Context) {
+	var req models.WeChatLinkRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid request data", Message: err.Error()})
+		return
+	}
+	parsed, err := services.ParsePhone(req.Phone, req.Region)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid phone number", Message: "Phone number must include the country code, e.g., +12065550100"})
+		return
+	}
+	phone := parsed.E164
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	link, err := h.DB.GetWeChatLinkRequest(ctx, req.LinkToken)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			c.JSON(http.StatusUnauthorized, models.ErrorResponse{Error: "Invalid or expired link token", Message: "Sign in with WeChat again"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to retrieve link request", Message: err.Error()})
+		return
+	}
+	if !h.checkPhoneCode(c, ctx, phone, req.Code) {
+		return
+	}
+
+	requireExisting := strings.EqualFold(c.GetHeader("X-Require-Existing"), "true") || c.Query("require_existing") == "true"
+	user, err := h.DB.GetUserByPhone(ctx, phone)
+	if errors.Is(err, pgx.ErrNoRows) {
+		if requireExisting {
+			c.JSON(http.StatusForbidden, models.ErrorResponse{Error: "User not allowed", Message: "User does not exist"})
+			return
+		}
+		user, err = h.DB.CreateUserFromPhone(ctx, phone)
+		if err == nil {
+			fmt.Printf("[USER_AUTH][WECHAT] Auto-registered new user: %s\n", phone)
+		}
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to retrieve user", Message: err.Error()})
+		return
+	}
+	if ok, err := h.DB.ConsumeWeChatLinkRequest(ctx, link.ID); err != nil || !ok {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse{Error: "Invalid or expired link token", Message: "Sign in with WeChat again"})
+		return
+	}
+	if err := h.DB.MarkPhoneVerified(ctx, user.ID); err != nil {
+		fmt.Printf("Failed to mark phone verified for user %s: %v\n", user.ID, err)
+	}
+
+	h.completeWeChatLogin(c, ctx, user, &link.Identity, true)
+}
+
+// completeWeChatLogin applies the role rules, links the WeChat account when asked and issues tokens.
+// Admin accounts are refused; they log in through the admin panel with their security key.
+func (h *Handler) completeWeChatLogin(c *gin.Context, ctx context.Context, user *models.User, identity *models.WeChatIdentity, link bool) {
+	role, err := h.DB.GetUserRoleByID(ctx, user.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to validate user role", Message: err.Error()})
+		return
+	}
+	if role == "Admin" {
+		c.JSON(http.StatusForbidden, models.ErrorResponse{Error: "User not allowed", Message: "Admin accounts must log in through the admin panel"})
+		return
+	}
+	if requiredRole := strings.TrimSpace(c.GetHeader("X-Require-Role")); requiredRole != "" && !strings.EqualFold(role, requiredRole) {
+		c.JSON(http.StatusForbidden, models.ErrorResponse{Error: "User not allowed", Message: "User role not permitted"})
+		return
+	}
+
+	if link {
+		if err := h.DB.LinkWeChat(ctx, user.ID, identity); err != nil {
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to link account", Message: err.Error()})
+			return
+		}
+		fmt.Printf("[USER_AUTH][WECHAT] Linked WeChat account to user %s\n", user.ID)
+	}
+	if err := h.DB.TouchWeChat(ctx, identity); err != nil {
+		fmt.Printf("Failed to record WeChat sign-in for user %s: %v\n", user.ID, err)
+	}
+
+	h.completeUserLogin(c, ctx, user, role, "wechat", gin.H{"provider": "wechat"})
+}
@@ -0,0 +1,215 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/expotoworld/expotoworld/backend/auth-service/internal/events"
+	"github.com/jackc/pgx/v5"
+)
+
+// InitAccountSchema creates the account event outbox, the soft-delete column on app_users (which
+// user-service also maintains), the user's preferred language for emails and the access token version
+func (db *Database) InitAccountSchema(ctx context.Context) error {
+	stmts := []string{
+		`ALTER TABLE app_users ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP WITH TIME ZONE`,
+		`ALTER TABLE app_users ADD COLUMN IF NOT EXISTS preferred_language TEXT`,
+		`ALTER TABLE app_users ADD COLUMN IF NOT EXISTS token_version INTEGER NOT NULL DEFAULT 0`,
+		`CREATE TABLE IF NOT EXISTS app_account_event_deliveries (
+			id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+			endpoint TEXT NOT NULL,
+			event TEXT NOT NULL,
+			payload JSONB NOT NULL,
+			status TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending','delivered','failed')),
+			attempts INTEGER NOT NULL DEFAULT 0,
+			next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+			response_code INTEGER,
+			last_error TEXT,
+			delivered_at TIMESTAMPTZ,
+			created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+			updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_account_event_deliveries_due ON app_account_event_deliveries(next_attempt_at) WHERE status = 'pending'`,
+	}
+	for _, q := range stmts {
+		if _, err := db.Pool.Exec(ctx, q); err != nil {
+			return fmt.Errorf("init account schema: %w", err)
+		}
+	}
+	return nil
+}
+
+// tableExists reports whether a table owned by another service has been created yet
+func tableExists(ctx context.Context, q pgx.Tx, name string) (bool, error) {
+	var exists bool
+	err := q.QueryRow(ctx, `SELECT to_regclass($1) IS NOT NULL`, name).Scan(&exists)
+	return exists, err
+}
+
+// accountErasures clear the user's rows of each table on account deletion. Reading data and stock
+// alerts are removed; product views and experiment exposures are unlinked from the user so catalog
+// statistics keep counting them. Tables of services that have not created them yet are skipped.
+var accountErasures = []struct{ table, query string }{
+	{"app_user_identities", `DELETE FROM app_user_identities WHERE user_id = $1`},
+	{"app_wechat_accounts", `DELETE FROM app_wechat_accounts WHERE user_id = $1`},
+	{"app_refresh_tokens", `UPDATE app_refresh_tokens SET revoked = true WHERE user_id::text = $1 AND revoked = false`},
+	{"app_carts", `DELETE FROM app_carts WHERE user_id::text = $1`},
+	{"app_user_custom_field_values", `DELETE FROM app_user_custom_field_values WHERE user_id::text = $1`},
+	{"ebook_reading_progress", `DELETE FROM ebook_reading_progress WHERE user_id = $1`},
+	{"ebook_bookmarks", `DELETE FROM ebook_bookmarks WHERE user_id = $1`},
+	{"app_product_stock_subscriptions", `DELETE FROM app_product_stock_subscriptions WHERE user_id = $1`},
+	{"app_product_view_events", `UPDATE app_product_view_events SET user_id = NULL WHERE user_id = $1`},
+	{"app_experiment_exposures", `UPDATE app_experiment_exposures SET user_id = NULL WHERE user_id = $1`},
+}
+
+// DeleteAccount anonymizes and deactivates the user the same way an admin deletion in user-service
+// does, removes their sign-in methods, carts, custom fields, reading data and stock alerts, unlinks
+// their product views and experiment exposures, revokes their sessions and queues a user.deleted
+// event for the other services, all in one transaction. Orders are kept (anonymized
+// through the user row) for accounting until user-service purges them after the retention period.
+func (db *Database) DeleteAccount(ctx context.Context, userID string) error {
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	tag, err := tx.Exec(ctx, `
+		UPDATE app_users SET
+			username = 'deleted-' || id::text,
+			email = NULL,
+			phone = NULL,
+			first_name = NULL,
+			middle_name = NULL,
+			last_name = NULL,
+			email_verified_at = NULL,
+			phone_verified_at = NULL,
+			status = 'deactivated',
+			deleted_at = now(),
+			updated_at = now()
+		WHERE id::text = $1 AND deleted_at IS NULL`, userID)
+	if err != nil {
+		return fmt.Errorf("failed to anonymize user: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+
+	for _, s := range accountErasures {
+		exists, err := tableExists(ctx, tx, s.table)
+		if err != nil {
+			return fmt.Errorf("failed to check %s: %w", s.table, err)
+		}
+		if !exists {
+			continue
+		}
+		if _, err := tx.Exec(ctx, s.query, userID); err != nil {
+			return fmt.Errorf("failed to clear %s: %w", s.table, err)
+		}
+	}
+
+	if err := events.Enqueue(ctx, tx, events.EventUserDeleted, map[string]any{"user_id": userID}); err != nil {
+		return err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// exportSections lists what the data export contains: each section is the user's rows of a table,
+// minus secrets. Tables of services that have not created them yet are skipped.
+var exportSections = []struct{ name, table, query string }{
+	{"profile", "app_users", `SELECT to_jsonb(t) - 'password_hash' FROM app_users t WHERE t.id::text = $1`},
+	{"sign_in_identities", "app_user_identities", `SELECT COALESCE(jsonb_agg(to_jsonb(t) ORDER BY t.created_at), '[]') FROM app_user_identities t WHERE t.user_id = $1`},
+	{"wechat_accounts", "app_wechat_accounts", `SELECT COALESCE(jsonb_agg(to_jsonb(t) ORDER BY t.created_at), '[]') FROM app_wechat_accounts t WHERE t.user_id = $1`},
+	{"sessions", "app_refresh_tokens", `SELECT COALESCE(jsonb_agg(to_jsonb(t) - 'token_hash' ORDER BY t.issued_at), '[]') FROM app_refresh_tokens t WHERE t.user_id::text = $1`},
+	{"custom_fields", "app_user_custom_field_values", `SELECT COALESCE(jsonb_agg(to_jsonb(t) ORDER BY t.field_key), '[]') FROM app_user_custom_field_values t WHERE t.user_id::text = $1`},
+	{"carts", "app_carts", `SELECT COALESCE(jsonb_agg(to_jsonb(t) ORDER BY t.created_at), '[]') FROM app_carts t WHERE t.user_id::text = $1`},
+	{"orders", "app_orders", `
+		SELECT COALESCE(jsonb_agg(to_jsonb(o) || jsonb_build_object('items',
+			(SELECT COALESCE(jsonb_agg(to_jsonb(i)), '[]') FROM app_order_items i WHERE i.order_id = o.id)) ORDER BY o.created_at), '[]')
+		FROM app_orders o WHERE o.user_id::text = $1`},
+	{"quotes", "app_quotes", `SELECT COALESCE(jsonb_agg(to_jsonb(t) ORDER BY t.created_at), '[]') FROM app_quotes t WHERE t.customer_user_id::text = $1`},
+	{"reading_progress", "ebook_reading_progress", `SELECT COALESCE(jsonb_agg(to_jsonb(t) ORDER BY t.updated_at), '[]') FROM ebook_reading_progress t WHERE t.user_id = $1`},
+	{"bookmarks", "ebook_bookmarks", `SELECT COALESCE(jsonb_agg(to_jsonb(t) ORDER BY t.updated_at), '[]') FROM ebook_bookmarks t WHERE t.user_id = $1 AND NOT t.deleted`},
+	{"stock_alerts", "app_product_stock_subscriptions", `SELECT COALESCE(jsonb_agg(to_jsonb(t) ORDER BY t.created_at), '[]') FROM app_product_stock_subscriptions t WHERE t.user_id = $1`},
+	{"product_views", "app_product_view_events", `SELECT COALESCE(jsonb_agg(to_jsonb(t) ORDER BY t.viewed_at), '[]') FROM app_product_view_events t WHERE t.user_id = $1`},
+	{"experiment_exposures", "app_experiment_exposures", `SELECT COALESCE(jsonb_agg(to_jsonb(t) ORDER BY t.exposed_at), '[]') FROM app_experiment_exposures t WHERE t.user_id = $1`},
+}
+
+// ExportAccount collects the user's personal data across the shared database, keyed by section
+func (db *Database) ExportAccount(ctx context.Context, userID string) (map[string]json.RawMessage, error) {
+	// A read-only snapshot so the sections are consistent with each other
+	tx, err := db.Pool.BeginTx(ctx, pgx.TxOptions{IsoLevel: pgx.RepeatableRead, AccessMode: pgx.ReadOnly})
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	out := map[string]json.RawMessage{}
+	for _, s := range exportSections {
+		exists, err := tableExists(ctx, tx, s.table)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check %s: %w", s.table, err)
+		}
+		if !exists {
+			continue
+		}
+		var section []byte
+		if err := tx.QueryRow(ctx, s.query, userID).Scan(&section); err != nil {
+			return nil, fmt.Errorf("failed to export %s: %w", s.name, err)
+		}
+		out[s.name] = section
+	}
+	return out, nil
+}
+
+// GetPreferredLanguageByEmail returns the preferred language of the account with this email; empty
+// when the account has none or doesn't exist
+func (db *Database) GetPreferredLanguageByEmail(ctx context.Context, email string) (string, error) {
+	var lang string
+	err := db.Pool.QueryRow(ctx, `
+		SELECT COALESCE(preferred_language, '') FROM app_users
+		WHERE email = $1 AND deleted_at IS NULL`, email).Scan(&lang)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", nil
+	}
+	return lang, err
+}
+
+// SetPreferredLanguage stores the language (a BCP 47 tag) emails to the user are written in; an
+// empty language clears it
+func (db *Database) SetPreferredLanguage(ctx context.Context, userID, lang string) error {
+	tag, err := db.Pool.Exec(ctx, `
+		UPDATE app_users SET preferred_language = NULLIF($2, ''), updated_at = now()
+		WHERE id::text = $1 AND deleted_at IS NULL`, userID, lang)
+	if err != nil {
+		return fmt.Errorf("failed to set preferred language: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
+
+// GetTokenVersion returns the user's access token version; tokens carrying an older one are rejected.
+// pgx.ErrNoRows if there is no such user.
+func (db *Database) GetTokenVersion(ctx context.Context, userID string) (int, error) {
+	var version int
+	err := db.Pool.QueryRow(ctx, `SELECT token_version FROM app_users WHERE id::text = $1`, userID).Scan(&version)
+	return version, err
+}
+
+// BumpTokenVersion invalidates every access token issued to the user so far and returns the new
+// version; pgx.ErrNoRows if there is no such user
+func (db *Database) BumpTokenVersion(ctx context.Context, userID string) (int, error) {
+	var version int
+	err := db.Pool.QueryRow(ctx, `
+		UPDATE app_users SET token_version = token_version + 1, updated_at = now()
+		WHERE id::text = $1
+		RETURNING token_version`, userID).Scan(&version)
+	return version, err
+}
@@ -0,0 +1,164 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/expotoworld/expotoworld/backend/auth-service/internal/events"
+	"github.com/jackc/pgx/v5"
+)
+
+// ErrIdentifierTaken is returned when the email or phone number now belongs to another account
+var ErrIdentifierTaken = errors.New("identifier belongs to another account")
+
+// identifierColumns maps an identifier kind to its app_users columns
+var identifierColumns = map[string]struct{ value, verifiedAt string }{
+	"email": {"email", "email_verified_at"},
+	"phone": {"phone", "phone_verified_at"},
+}
+
+// AddUserIdentifier sets the user's missing email or phone ("email" or "phone" kind) as verified.
+// pgx.ErrNoRows when the user already has one; ErrIdentifierTaken when another account holds it.
+func (db *Database) AddUserIdentifier(ctx context.Context, userID, kind, value string) error {
+	col, ok := identifierColumns[kind]
+	if !ok {
+		return fmt.Errorf("unknown identifier kind %q", kind)
+	}
+	tag, err := db.Pool.Exec(ctx, fmt.Sprintf(`
+		UPDATE app_users SET %[1]s = $2, %[2]s = now(), updated_at = now()
+		WHERE id::text = $1 AND %[1]s IS NULL
+		  AND NOT EXISTS (SELECT 1 FROM app_users o WHERE o.%[1]s = $2)`, col.value, col.verifiedAt), userID, value)
+	if err != nil {
+		return fmt.Errorf("failed to add %s: %w", kind, err)
+	}
+	if tag.RowsAffected() == 0 {
+		var taken bool
+		if err := db.Pool.QueryRow(ctx, fmt.Sprintf(`SELECT EXISTS (SELECT 1 FROM app_users WHERE %s = $1)`, col.value), value).Scan(&taken); err != nil {
+			return fmt.Errorf("failed to add %s: %w", kind, err)
+		}
+		if taken {
+			return ErrIdentifierTaken
+		}
+		return pgx.ErrNoRows
+	}
+	return nil
+}
+
+// MergeUsers folds the source account, which signs in with the given email or phone, into the target
+// account, which gets that identifier. The source's orders, quotes, carts, custom fields, sign-in
+// methods, stock alerts, product views and experiment exposures move to the target (the target's own
+// entries win on conflicts), as do its reading positions and bookmarks (the most recently updated
+// wins). Its sessions are revoked and it is anonymized like a deleted account. A user.merged event is queued for the other services.
+func (db *Database) MergeUsers(ctx context.Context, targetID, sourceID, kind, value string) error {
+	col, ok := identifierColumns[kind]
+	if !ok {
+		return fmt.Errorf("unknown identifier kind %q", kind)
+	}
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	// Free the identifier first; both columns are unique
+	tag, err := tx.Exec(ctx, fmt.Sprintf(`
+		UPDATE app_users SET
+			username = 'merged-' || id::text,
+			email = NULL,
+			phone = NULL,
+			first_name = NULL,
+			middle_name = NULL,
+			last_name = NULL,
+			email_verified_at = NULL,
+			phone_verified_at = NULL,
+			status = 'deactivated',
+			deleted_at = now(),
+			updated_at = now()
+		WHERE id::text = $1 AND %s = $2 AND deleted_at IS NULL`, col.value), sourceID, value)
+	if err != nil {
+		return fmt.Errorf("failed to retire merged user: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	tag, err = tx.Exec(ctx, fmt.Sprintf(`
+		UPDATE app_users SET %[1]s = $2, %[2]s = now(), updated_at = now()
+		WHERE id::text = $1 AND %[1]s IS NULL`, col.value, col.verifiedAt), targetID, value)
+	if err != nil {
+		return fmt.Errorf("failed to add %s: %w", kind, err)
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+
+	// $1 is only compared as text: the user id columns are UUID in some tables and TEXT in others
+	const target = `(SELECT id FROM app_users WHERE id::text = $1)`
+	stmts := []struct{ table, query string }{
+		{"app_orders", `UPDATE app_orders SET user_id = ` + target + ` WHERE user_id::text = $2`},
+		{"app_quotes", `UPDATE app_quotes SET customer_user_id = ` + target + ` WHERE customer_user_id::text = $2`},
+		{"app_carts", `UPDATE app_carts c SET user_id = ` + target + ` WHERE c.user_id::text = $2 AND NOT EXISTS (
+			SELECT 1 FROM app_carts t WHERE t.user_id::text = $1 AND t.product_id = c.product_id
+			  AND t.mini_app_type = c.mini_app_type AND t.store_id IS NOT DISTINCT FROM c.store_id)`},
+		{"app_user_custom_field_values", `UPDATE app_user_custom_field_values v SET user_id = ` + target + ` WHERE v.user_id::text = $2 AND NOT EXISTS (
+			SELECT 1 FROM app_user_custom_field_values t WHERE t.user_id::text = $1 AND t.field_key = v.field_key)`},
+		{"app_user_identities", `UPDATE app_user_identities SET user_id = $1 WHERE user_id = $2`},
+		{"app_wechat_accounts", `UPDATE app_wechat_accounts SET user_id = $1 WHERE user_id = $2`},
+		// Reading positions and bookmarks saved on both accounts: the most recently updated one wins
+		{"ebook_reading_progress", `DELETE FROM ebook_reading_progress t USING ebook_reading_progress s
+			WHERE t.user_id = $1 AND s.user_id = $2 AND s.ebook_id = t.ebook_id AND s.client_updated_at > t.client_updated_at`},
+		{"ebook_reading_progress", `UPDATE ebook_reading_progress p SET user_id = $1 WHERE p.user_id = $2 AND NOT EXISTS (
+			SELECT 1 FROM ebook_reading_progress t WHERE t.user_id = $1 AND t.ebook_id = p.ebook_id)`},
+		{"ebook_bookmarks", `DELETE FROM ebook_bookmarks t USING ebook_bookmarks s
+			WHERE t.user_id = $1 AND s.user_id = $2 AND s.ebook_id = t.ebook_id AND s.bookmark_id = t.bookmark_id
+			  AND s.client_updated_at > t.client_updated_at`},
+		{"ebook_bookmarks", `UPDATE ebook_bookmarks b SET user_id = $1 WHERE b.user_id = $2 AND NOT EXISTS (
+			SELECT 1 FROM ebook_bookmarks t WHERE t.user_id = $1 AND t.ebook_id = b.ebook_id AND t.bookmark_id = b.bookmark_id)`},
+		{"app_product_stock_subscriptions", `UPDATE app_product_stock_subscriptions s SET user_id = $1 WHERE s.user_id = $2 AND NOT EXISTS (
+			SELECT 1 FROM app_product_stock_subscriptions t WHERE t.user_id = $1 AND t.product_id = s.product_id)`},
+		{"app_product_view_events", `UPDATE app_product_view_events SET user_id = $1 WHERE user_id = $2`},
+		{"app_experiment_exposures", `UPDATE app_experiment_exposures SET user_id = $1 WHERE user_id = $2`},
+	}
+	for _, s := range stmts {
+		exists, err := tableExists(ctx, tx, s.table)
+		if err != nil {
+			return fmt.Errorf("failed to check %s: %w", s.table, err)
+		}
+		if !exists {
+			continue
+		}
+		if _, err := tx.Exec(ctx, s.query, targetID, sourceID); err != nil {
+			return fmt.Errorf("failed to merge %s: %w", s.table, err)
+		}
+	}
+
+	// What the target already had wins; the source's leftovers and sessions go
+	leftovers := []struct{ table, query string }{
+		{"app_carts", `DELETE FROM app_carts WHERE user_id::text = $1`},
+		{"app_user_custom_field_values", `DELETE FROM app_user_custom_field_values WHERE user_id::text = $1`},
+		{"ebook_reading_progress", `DELETE FROM ebook_reading_progress WHERE user_id = $1`},
+		{"ebook_bookmarks", `DELETE FROM ebook_bookmarks WHERE user_id = $1`},
+		{"app_product_stock_subscriptions", `DELETE FROM app_product_stock_subscriptions WHERE user_id = $1`},
+		{"app_refresh_tokens", `UPDATE app_refresh_tokens SET revoked = true WHERE user_id::text = $1 AND revoked = false`},
+	}
+	for _, s := range leftovers {
+		exists, err := tableExists(ctx, tx, s.table)
+		if err != nil {
+			return fmt.Errorf("failed to check %s: %w", s.table, err)
+		}
+		if !exists {
+			continue
+		}
+		if _, err := tx.Exec(ctx, s.query, sourceID); err != nil {
+			return fmt.Errorf("failed to clear merged user's %s: %w", s.table, err)
+		}
+	}
+
+	if err := events.Enqueue(ctx, tx, events.EventUserMerged, map[string]any{"user_id": targetID, "merged_user_id": sourceID}); err != nil {
+		return err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
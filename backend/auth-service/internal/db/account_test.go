@@ -0,0 +1,30 @@
+package db
+
+import "testing"
+
+// The per-user tables of the other services must be both exported and erased with the account
+func TestAccountCoversServiceTables(t *testing.T) {
+	exported, erased := map[string]bool{}, map[string]bool{}
+	for _, s := range exportSections {
+		exported[s.table] = true
+	}
+	for _, s := range accountErasures {
+		erased[s.table] = true
+	}
+	for _, table := range []string{
+		"app_user_custom_field_values",
+		"app_carts",
+		"ebook_reading_progress",
+		"ebook_bookmarks",
+		"app_product_stock_subscriptions",
+		"app_product_view_events",
+		"app_experiment_exposures",
+	} {
+		if !exported[table] {
+			t.Errorf("%s is missing from the data export", table)
+		}
+		if !erased[table] {
+			t.Errorf("%s is not erased on account deletion", table)
+		}
+	}
+}
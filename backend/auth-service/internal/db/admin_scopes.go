@@ -0,0 +1,96 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/expotoworld/expotoworld/backend/auth-service/internal/models"
+	"github.com/jackc/pgx/v5"
+)
+
+// InitAdminScopeSchema creates the table of delegated (region/store restricted) admin scopes
+func (db *Database) InitAdminScopeSchema(ctx context.Context) error {
+	q := `
+		CREATE TABLE IF NOT EXISTS app_admin_scopes (
+			user_id TEXT PRIMARY KEY,
+			region_ids INTEGER[] NOT NULL DEFAULT '{}',
+			store_ids INTEGER[] NOT NULL DEFAULT '{}',
+			granted_by TEXT,
+			updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+		)`
+	if _, err := db.Pool.Exec(ctx, q); err != nil {
+		return fmt.Errorf("init admin scope schema: %w", err)
+	}
+	return nil
+}
+
+const adminScopeSelect = `
+	SELECT s.user_id, COALESCE(u.email, ''), s.region_ids, s.store_ids, COALESCE(s.granted_by, ''), s.updated_at
+	FROM app_admin_scopes s
+	LEFT JOIN app_users u ON u.id::text = s.user_id`
+
+func scanAdminScope(row pgx.Row) (*models.AdminScope, error) {
+	var s models.AdminScope
+	if err := row.Scan(&s.UserID, &s.Email, &s.RegionIDs, &s.StoreIDs, &s.GrantedBy, &s.UpdatedAt); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// GetAdminScope returns the user's admin scope, or nil when the admin is unrestricted
+func (db *Database) GetAdminScope(ctx context.Context, userID string) (*models.AdminScope, error) {
+	s, err := scanAdminScope(db.Pool.QueryRow(ctx, adminScopeSelect+` WHERE s.user_id = $1`, userID))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	return s, err
+}
+
+// ListAdminScopes returns every scoped admin
+func (db *Database) ListAdminScopes(ctx context.Context) ([]models.AdminScope, error) {
+	rows, err := db.Pool.Query(ctx, adminScopeSelect+` ORDER BY u.email, s.user_id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := []models.AdminScope{}
+	for rows.Next() {
+		s, err := scanAdminScope(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *s)
+	}
+	return out, rows.Err()
+}
+
+// SetAdminScope grants or replaces a user's admin scope
+func (db *Database) SetAdminScope(ctx context.Context, userID string, regionIDs, storeIDs []int, grantedBy string) (*models.AdminScope, error) {
+	_, err := db.Pool.Exec(ctx, `
+		INSERT INTO app_admin_scopes (user_id, region_ids, store_ids, granted_by)
+		VALUES ($1, $2, $3, NULLIF($4, ''))
+		ON CONFLICT (user_id) DO UPDATE
+		SET region_ids = EXCLUDED.region_ids, store_ids = EXCLUDED.store_ids, granted_by = EXCLUDED.granted_by, updated_at = now()
+	`, userID, regionIDs, storeIDs, grantedBy)
+	if err != nil {
+		return nil, fmt.Errorf("failed to save admin scope: %w", err)
+	}
+	return db.GetAdminScope(ctx, userID)
+}
+
+// DeleteAdminScope lifts a user's restriction; removed is false if there was none
+func (db *Database) DeleteAdminScope(ctx context.Context, userID string) (bool, error) {
+	tag, err := db.Pool.Exec(ctx, `DELETE FROM app_admin_scopes WHERE user_id = $1`, userID)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+// GetUserRoleByID returns a user's role; pgx.ErrNoRows if the user doesn't exist
+func (db *Database) GetUserRoleByID(ctx context.Context, userID string) (string, error) {
+	var role string
+	err := db.Pool.QueryRow(ctx, `SELECT COALESCE(role::text, '') FROM app_users WHERE id::text = $1`, userID).Scan(&role)
+	return role, err
+}
@@ -0,0 +1,109 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/expotoworld/expotoworld/backend/auth-service/internal/models"
+	"github.com/jackc/pgx/v5"
+)
+
+// InitAuthAuditSchema creates auth_audit_log, the structured record of sign-ins, codes, refreshes and
+// revocations used for security reviews
+func (db *Database) InitAuthAuditSchema(ctx context.Context) error {
+	stmts := []string{
+		`CREATE TABLE IF NOT EXISTS auth_audit_log (
+			event_id BIGSERIAL PRIMARY KEY,
+			occurred_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+			event_type TEXT NOT NULL,
+			user_id TEXT,
+			subject TEXT,
+			channel TEXT,
+			client_ip TEXT,
+			user_agent TEXT,
+			actor_user_id TEXT,
+			details JSONB
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_auth_audit_user ON auth_audit_log(user_id, event_id DESC)`,
+		`CREATE INDEX IF NOT EXISTS idx_auth_audit_subject ON auth_audit_log(subject, event_id DESC)`,
+		`CREATE INDEX IF NOT EXISTS idx_auth_audit_ip ON auth_audit_log(client_ip, event_id DESC)`,
+		`CREATE INDEX IF NOT EXISTS idx_auth_audit_type ON auth_audit_log(event_type, event_id DESC)`,
+		`CREATE INDEX IF NOT EXISTS idx_auth_audit_occurred ON auth_audit_log(occurred_at)`,
+	}
+	for _, q := range stmts {
+		if _, err := db.Pool.Exec(ctx, q); err != nil {
+			return fmt.Errorf("init auth audit schema: %w", err)
+		}
+	}
+	return nil
+}
+
+// InsertAuthEvent appends an entry to auth_audit_log
+func (db *Database) InsertAuthEvent(ctx context.Context, e *models.AuthEvent) error {
+	var details any
+	if len(e.Details) > 0 {
+		details = string(e.Details)
+	}
+	_, err := db.Pool.Exec(ctx, `
+		INSERT INTO auth_audit_log (event_type, user_id, subject, channel, client_ip, user_agent, actor_user_id, details)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8::jsonb)
+	`, e.EventType, e.UserID, e.Subject, e.Channel, e.ClientIP, e.UserAgent, e.ActorID, details)
+	return err
+}
+
+// ListAuthEvents returns matching entries, newest first (keyset-paginated by event_id)
+func (db *Database) ListAuthEvents(ctx context.Context, f models.AuthEventFilter) ([]models.AuthEvent, error) {
+	where := []string{"true"}
+	args := []any{}
+	add := func(cond string, v any) {
+		args = append(args, v)
+		where = append(where, fmt.Sprintf(cond, len(args)))
+	}
+	if f.UserID != "" {
+		add("user_id = $%d", f.UserID)
+	}
+	if f.Subject != "" {
+		add("subject = $%d", f.Subject)
+	}
+	if f.ClientIP != "" {
+		add("client_ip = $%d", f.ClientIP)
+	}
+	if len(f.EventType) > 0 {
+		add("event_type = ANY($%d)", f.EventType)
+	}
+	if f.From != nil {
+		add("occurred_at >= $%d", *f.From)
+	}
+	if f.To != nil {
+		add("occurred_at < $%d", *f.To)
+	}
+	if f.BeforeID > 0 {
+		add("event_id < $%d", f.BeforeID)
+	}
+	args = append(args, f.Limit)
+
+	rows, err := db.Pool.Query(ctx, `
+		SELECT event_id, occurred_at, event_type, user_id, subject, channel, client_ip, user_agent, actor_user_id, details
+		FROM auth_audit_log
+		WHERE `+strings.Join(where, " AND ")+`
+		ORDER BY event_id DESC
+		LIMIT $`+fmt.Sprint(len(args)), args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list auth events: %w", err)
+	}
+	events, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (models.AuthEvent, error) {
+		var e models.AuthEvent
+		var details []byte
+		err := row.Scan(&e.ID, &e.OccurredAt, &e.EventType, &e.UserID, &e.Subject, &e.Channel, &e.ClientIP, &e.UserAgent, &e.ActorID, &details)
+		e.Details = details
+		return e, err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list auth events: %w", err)
+	}
+	if events == nil {
+		events = []models.AuthEvent{}
+	}
+	return events, nil
+}
@@ -326,6 +326,38 @@ func (db *Database) UpdateLastLogin(ctx context.Context, userID string) error {
 	return nil
 }
 
+// MarkEmailVerified records that the user proved control of their current email
+func (db *Database) MarkEmailVerified(ctx context.Context, userID string) error {
+	query := `
+		UPDATE app_users
+		SET email_verified_at = now()
+		WHERE id = $1 AND email IS NOT NULL
+	`
+
+	_, err := db.Pool.Exec(ctx, query, userID)
+	if err != nil {
+		return fmt.Errorf("failed to mark email verified: %w", err)
+	}
+
+	return nil
+}
+
+// MarkPhoneVerified records that the user proved control of their current phone number
+func (db *Database) MarkPhoneVerified(ctx context.Context, userID string) error {
+	query := `
+		UPDATE app_users
+		SET phone_verified_at = now()
+		WHERE id = $1 AND phone IS NOT NULL
+	`
+
+	_, err := db.Pool.Exec(ctx, query, userID)
+	if err != nil {
+		return fmt.Errorf("failed to mark phone verified: %w", err)
+	}
+
+	return nil
+}
+
 // ValidatePassword checks if the provided password matches the stored hash
 func (db *Database) ValidatePassword(hashedPassword, password string) error {
 	return bcrypt.CompareHashAndPassword([]byte(hashedPassword), []byte(password))
@@ -600,6 +632,10 @@ func (db *Database) InitUserSchema(ctx context.Context) error {
 			ON app_rate_limits (ip_address, window_start);
 		CREATE INDEX IF NOT EXISTS idx_app_rate_limits_actor_ip_window
 			ON app_rate_limits (actor_type, ip_address, window_start);
+
+		-- Contact verification timestamps (NULL = unverified, e.g. after an admin edit)
+		ALTER TABLE app_users ADD COLUMN IF NOT EXISTS email_verified_at TIMESTAMP WITH TIME ZONE;
+		ALTER TABLE app_users ADD COLUMN IF NOT EXISTS phone_verified_at TIMESTAMP WITH TIME ZONE;
 	`
 
 	if _, err := db.Pool.Exec(ctx, createUnified); err != nil {
@@ -750,7 +786,7 @@ func (db *Database) CleanupExpiredUserCodes(ctx context.Context) error {
 	// Remove old rate limit records (older than 24 hours)
 	deleteRateLimitsQuery := `
 		DELETE FROM app_rate_limits
-		WHERE actor_type = 'user' AND channel_type = 'email' AND window_start < now() - interval '24 hours'
+		WHERE actor_type = 'user' AND window_start < now() - interval '24 hours'
 	`
 
 	if _, err := db.Pool.Exec(ctx, deleteCodesQuery); err != nil {
@@ -0,0 +1,155 @@
+package db
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/expotoworld/expotoworld/backend/auth-service/internal/models"
+	"github.com/jackc/pgx/v5"
+)
+
+// InitDeviceSchema creates the table of signed-in devices and their push tokens. A device belongs to
+// the refresh-token session it registered with; it only receives pushes while that session is active.
+func (db *Database) InitDeviceSchema(ctx context.Context) error {
+	stmts := []string{
+		`CREATE TABLE IF NOT EXISTS app_devices (
+			id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+			user_id TEXT NOT NULL,
+			session_id TEXT,
+			provider TEXT NOT NULL CHECK (provider IN ('apns','fcm')),
+			platform TEXT NOT NULL CHECK (platform IN ('ios','android','web')),
+			push_token TEXT NOT NULL UNIQUE,
+			name TEXT NOT NULL DEFAULT '',
+			app_version TEXT NOT NULL DEFAULT '',
+			created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+			last_seen_at TIMESTAMPTZ NOT NULL DEFAULT now()
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_devices_user ON app_devices(user_id)`,
+		`CREATE INDEX IF NOT EXISTS idx_devices_session ON app_devices(session_id)`,
+	}
+	for _, q := range stmts {
+		if _, err := db.Pool.Exec(ctx, q); err != nil {
+			return fmt.Errorf("init device schema: %w", err)
+		}
+	}
+	return nil
+}
+
+const deviceSelect = `
+	SELECT d.id::text, d.user_id, COALESCE(d.session_id, ''), d.provider, d.platform, d.push_token, d.name,
+	       d.app_version, d.created_at, d.last_seen_at,
+	       COALESCE(NOT t.revoked AND t.expires_at > now(), false), COALESCE(t.last_used_at, t.issued_at),
+	       COALESCE(t.ip_address::text, '')
+	FROM app_devices d
+	LEFT JOIN app_refresh_tokens t ON t.id::text = d.session_id`
+
+func scanDevice(row pgx.Row) (*models.Device, error) {
+	var d models.Device
+	if err := row.Scan(&d.ID, &d.UserID, &d.SessionID, &d.Provider, &d.Platform, &d.PushToken, &d.Name,
+		&d.AppVersion, &d.CreatedAt, &d.LastSeenAt, &d.SessionActive, &d.LastActiveAt, &d.IPAddress); err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+// UpsertDevice registers a push token for the user's session. A token registered before (the app
+// reinstalled, or another account signed in on the phone) moves to the new user and session.
+func (db *Database) UpsertDevice(ctx context.Context, d *models.Device) (*models.Device, error) {
+	var id string
+	err := db.Pool.QueryRow(ctx, `
+		INSERT INTO app_devices (user_id, session_id, provider, platform, push_token, name, app_version)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (push_token) DO UPDATE SET
+			user_id = EXCLUDED.user_id,
+			session_id = EXCLUDED.session_id,
+			provider = EXCLUDED.provider,
+			platform = EXCLUDED.platform,
+			name = CASE WHEN EXCLUDED.name <> '' THEN EXCLUDED.name
+			            WHEN app_devices.user_id = EXCLUDED.user_id THEN app_devices.name ELSE '' END,
+			app_version = EXCLUDED.app_version,
+			last_seen_at = now()
+		RETURNING id::text`,
+		d.UserID, d.SessionID, d.Provider, d.Platform, d.PushToken, d.Name, d.AppVersion).Scan(&id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to register device: %w", err)
+	}
+	return db.GetDevice(ctx, d.UserID, id)
+}
+
+// GetDevice returns one of the user's devices; pgx.ErrNoRows if there is no such device
+func (db *Database) GetDevice(ctx context.Context, userID, id string) (*models.Device, error) {
+	return scanDevice(db.Pool.QueryRow(ctx, deviceSelect+` WHERE d.user_id = $1 AND d.id::text = $2`, userID, id))
+}
+
+// ListDevices returns the user's devices, most recently active first
+func (db *Database) ListDevices(ctx context.Context, userID string) ([]models.Device, error) {
+	rows, err := db.Pool.Query(ctx, deviceSelect+` WHERE d.user_id = $1 ORDER BY d.last_seen_at DESC`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list devices: %w", err)
+	}
+	defer rows.Close()
+	devices := []models.Device{}
+	for rows.Next() {
+		d, err := scanDevice(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan device: %w", err)
+		}
+		devices = append(devices, *d)
+	}
+	return devices, rows.Err()
+}
+
+// RenameDevice sets the name of one of the user's devices; false if there is no such device
+func (db *Database) RenameDevice(ctx context.Context, userID, id, name string) (bool, error) {
+	tag, err := db.Pool.Exec(ctx, `UPDATE app_devices SET name = $3 WHERE user_id = $1 AND id::text = $2`, userID, id, name)
+	if err != nil {
+		return false, fmt.Errorf("failed to rename device: %w", err)
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+// DeleteDevice removes one of the user's devices and returns its session id; pgx.ErrNoRows if there
+// is no such device
+func (db *Database) DeleteDevice(ctx context.Context, userID, id string) (string, error) {
+	var sessionID string
+	err := db.Pool.QueryRow(ctx, `
+		DELETE FROM app_devices WHERE user_id = $1 AND id::text = $2
+		RETURNING COALESCE(session_id, '')`, userID, id).Scan(&sessionID)
+	return sessionID, err
+}
+
+// MoveDeviceSession points the devices of a rotated refresh token at its replacement
+func (db *Database) MoveDeviceSession(ctx context.Context, oldSessionID, newSessionID string) error {
+	_, err := db.Pool.Exec(ctx, `UPDATE app_devices SET session_id = $2 WHERE session_id = $1`, oldSessionID, newSessionID)
+	return err
+}
+
+// ListPushTargets returns the push tokens of the users' devices whose session is still active
+func (db *Database) ListPushTargets(ctx context.Context, userIDs []string) ([]models.PushTarget, error) {
+	rows, err := db.Pool.Query(ctx, `
+		SELECT d.user_id, d.id::text, d.provider, d.platform, d.push_token
+		FROM app_devices d
+		JOIN app_refresh_tokens t ON t.id::text = d.session_id
+		WHERE d.user_id = ANY($1) AND NOT t.revoked AND t.expires_at > now()
+		ORDER BY d.user_id, d.last_seen_at DESC`, userIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list push targets: %w", err)
+	}
+	targets, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.PushTarget])
+	if err != nil {
+		return nil, fmt.Errorf("failed to list push targets: %w", err)
+	}
+	if targets == nil {
+		targets = []models.PushTarget{}
+	}
+	return targets, nil
+}
+
+// DeletePushTokens removes tokens the push provider reported as no longer valid
+func (db *Database) DeletePushTokens(ctx context.Context, tokens []string) (int64, error) {
+	tag, err := db.Pool.Exec(ctx, `DELETE FROM app_devices WHERE push_token = ANY($1)`, tokens)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete push tokens: %w", err)
+	}
+	return tag.RowsAffected(), nil
+}
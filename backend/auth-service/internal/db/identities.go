@@ -0,0 +1,182 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/expotoworld/expotoworld/backend/auth-service/internal/models"
+	"github.com/jackc/pgx/v5"
+)
+
+// InitIdentitySchema creates the tables linking external sign-in accounts (Apple, Google, WeChat) to users
+func (db *Database) InitIdentitySchema(ctx context.Context) error {
+	q := `
+		CREATE TABLE IF NOT EXISTS app_user_identities (
+			provider TEXT NOT NULL,
+			subject TEXT NOT NULL,
+			user_id TEXT NOT NULL,
+			email TEXT,
+			created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+			last_login_at TIMESTAMPTZ,
+			PRIMARY KEY (provider, subject)
+		);
+		CREATE INDEX IF NOT EXISTS idx_app_user_identities_user ON app_user_identities (user_id);
+		CREATE TABLE IF NOT EXISTS app_wechat_accounts (
+			app_id TEXT NOT NULL,
+			open_id TEXT NOT NULL,
+			union_id TEXT,
+			user_id TEXT NOT NULL,
+			created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+			last_login_at TIMESTAMPTZ,
+			PRIMARY KEY (app_id, open_id)
+		);
+		CREATE INDEX IF NOT EXISTS idx_app_wechat_accounts_union ON app_wechat_accounts (union_id) WHERE union_id IS NOT NULL;
+		CREATE INDEX IF NOT EXISTS idx_app_wechat_accounts_user ON app_wechat_accounts (user_id);
+		CREATE TABLE IF NOT EXISTS app_wechat_link_requests (
+			id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+			app_id TEXT NOT NULL,
+			open_id TEXT NOT NULL,
+			union_id TEXT,
+			expires_at TIMESTAMPTZ NOT NULL,
+			used_at TIMESTAMPTZ,
+			created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+		);
+		CREATE INDEX IF NOT EXISTS idx_app_wechat_link_requests_expires ON app_wechat_link_requests (expires_at);
+	`
+	if _, err := db.Pool.Exec(ctx, q); err != nil {
+		return fmt.Errorf("init identity schema: %w", err)
+	}
+	return nil
+}
+
+// GetUserByIdentity returns the user linked to the provider account; pgx.ErrNoRows when unlinked
+func (db *Database) GetUserByIdentity(ctx context.Context, provider, subject string) (*models.User, error) {
+	var user models.User
+	err := db.Pool.QueryRow(ctx, `
+		SELECT u.id, u.username, u.email, u.phone, u.first_name, u.middle_name, u.last_name, u.created_at, u.updated_at
+		FROM app_user_identities i
+		JOIN app_users u ON u.id::text = i.user_id
+		WHERE i.provider = $1 AND i.subject = $2
+	`, provider, subject).Scan(&user.ID, &user.Username, &user.Email, &user.Phone, &user.FirstName, &user.MiddleName, &user.LastName, &user.CreatedAt, &user.UpdatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, pgx.ErrNoRows
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user by identity: %w", err)
+	}
+	return &user, nil
+}
+
+// LinkIdentity links the provider account to the user (a no-op when it already is)
+func (db *Database) LinkIdentity(ctx context.Context, userID string, id *models.OIDCIdentity) error {
+	_, err := db.Pool.Exec(ctx, `
+		INSERT INTO app_user_identities (provider, subject, user_id, email)
+		VALUES ($1, $2, $3, NULLIF($4, ''))
+		ON CONFLICT (provider, subject) DO NOTHING
+	`, id.Provider, id.Subject, userID, id.Email)
+	return err
+}
+
+// TouchIdentity records a sign-in through the provider account
+func (db *Database) TouchIdentity(ctx context.Context, provider, subject string) error {
+	_, err := db.Pool.Exec(ctx, `UPDATE app_user_identities SET last_login_at = now() WHERE provider = $1 AND subject = $2`, provider, subject)
+	return err
+}
+
+// SetUserNameIfEmpty fills in the user's first and last name where they are still unset
+func (db *Database) SetUserNameIfEmpty(ctx context.Context, userID string, firstName, lastName *string) error {
+	_, err := db.Pool.Exec(ctx, `
+		UPDATE app_users
+		SET first_name = COALESCE(first_name, NULLIF($2, '')), last_name = COALESCE(last_name, NULLIF($3, '')), updated_at = now()
+		WHERE id = $1
+	`, userID, firstName, lastName)
+	return err
+}
+
+// GetUserByWeChat returns the user linked to the WeChat account: by its openid in this app, or else by
+// its unionid through another of our apps. linked is false in the second case. pgx.ErrNoRows when unlinked.
+func (db *Database) GetUserByWeChat(ctx context.Context, id *models.WeChatIdentity) (user *models.User, linked bool, err error) {
+	user = &models.User{}
+	err = db.Pool.QueryRow(ctx, `
+		SELECT u.id, u.username, u.email, u.phone, u.first_name, u.middle_name, u.last_name, u.created_at, u.updated_at,
+		       (w.app_id = $1 AND w.open_id = $2)
+		FROM app_wechat_accounts w
+		JOIN app_users u ON u.id::text = w.user_id
+		WHERE (w.app_id = $1 AND w.open_id = $2) OR (NULLIF($3, '') IS NOT NULL AND w.union_id = $3)
+		ORDER BY 10 DESC
+		LIMIT 1
+	`, id.AppID, id.OpenID, id.UnionID).Scan(&user.ID, &user.Username, &user.Email, &user.Phone, &user.FirstName, &user.MiddleName, &user.LastName, &user.CreatedAt, &user.UpdatedAt, &linked)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, false, pgx.ErrNoRows
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to get user by WeChat account: %w", err)
+	}
+	return user, linked, nil
+}
+
+// LinkWeChat links the WeChat account to the user, recording a unionid WeChat starts sending later
+func (db *Database) LinkWeChat(ctx context.Context, userID string, id *models.WeChatIdentity) error {
+	_, err := db.Pool.Exec(ctx, `
+		INSERT INTO app_wechat_accounts (app_id, open_id, union_id, user_id)
+		VALUES ($1, $2, NULLIF($3, ''), $4)
+		ON CONFLICT (app_id, open_id) DO UPDATE SET union_id = COALESCE(app_wechat_accounts.union_id, EXCLUDED.union_id)
+	`, id.AppID, id.OpenID, id.UnionID, userID)
+	return err
+}
+
+// TouchWeChat records a sign-in through the WeChat account
+func (db *Database) TouchWeChat(ctx context.Context, id *models.WeChatIdentity) error {
+	_, err := db.Pool.Exec(ctx, `UPDATE app_wechat_accounts SET last_login_at = now() WHERE app_id = $1 AND open_id = $2`, id.AppID, id.OpenID)
+	return err
+}
+
+// CreateWeChatLinkRequest stores an unlinked WeChat account until the user proves a phone number, and
+// returns the link token
+func (db *Database) CreateWeChatLinkRequest(ctx context.Context, id *models.WeChatIdentity, expiresAt time.Time) (string, error) {
+	var token string
+	err := db.Pool.QueryRow(ctx, `
+		INSERT INTO app_wechat_link_requests (app_id, open_id, union_id, expires_at)
+		VALUES ($1, $2, NULLIF($3, ''), $4)
+		RETURNING id::text
+	`, id.AppID, id.OpenID, id.UnionID, expiresAt).Scan(&token)
+	if err != nil {
+		return "", fmt.Errorf("failed to create WeChat link request: %w", err)
+	}
+	return token, nil
+}
+
+// GetWeChatLinkRequest returns an unused, unexpired link request; pgx.ErrNoRows otherwise
+func (db *Database) GetWeChatLinkRequest(ctx context.Context, token string) (*models.WeChatLinkRequestRecord, error) {
+	var r models.WeChatLinkRequestRecord
+	var unionID *string
+	err := db.Pool.QueryRow(ctx, `
+		SELECT id::text, app_id, open_id, union_id, expires_at
+		FROM app_wechat_link_requests
+		WHERE id::text = $1 AND used_at IS NULL AND expires_at > now()
+	`, token).Scan(&r.ID, &r.Identity.AppID, &r.Identity.OpenID, &unionID, &r.ExpiresAt)
+	if err != nil {
+		return nil, err
+	}
+	if unionID != nil {
+		r.Identity.UnionID = *unionID
+	}
+	return &r, nil
+}
+
+// ConsumeWeChatLinkRequest marks a link request used; false if it was already used (concurrent redemption)
+func (db *Database) ConsumeWeChatLinkRequest(ctx context.Context, token string) (bool, error) {
+	tag, err := db.Pool.Exec(ctx, `UPDATE app_wechat_link_requests SET used_at = now() WHERE id::text = $1 AND used_at IS NULL`, token)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+// CleanupWeChatLinkRequests deletes link requests that expired more than a day ago
+func (db *Database) CleanupWeChatLinkRequests(ctx context.Context) error {
+	_, err := db.Pool.Exec(ctx, `DELETE FROM app_wechat_link_requests WHERE expires_at < $1`, time.Now().Add(-24*time.Hour))
+	return err
+}
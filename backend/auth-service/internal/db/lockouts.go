@@ -0,0 +1,118 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// InitLockoutSchema creates the per-subject failure counters behind the brute-force lockout. A
+// subject is the email address or phone number codes are sent to, so requesting a new code does
+// not reset the count.
+func (db *Database) InitLockoutSchema(ctx context.Context) error {
+	_, err := db.Pool.Exec(ctx, `
+		CREATE TABLE IF NOT EXISTS app_auth_lockouts (
+			subject TEXT PRIMARY KEY,
+			failures INTEGER NOT NULL DEFAULT 0,
+			lockouts INTEGER NOT NULL DEFAULT 0,
+			locked_until TIMESTAMPTZ,
+			last_failure_at TIMESTAMPTZ NOT NULL DEFAULT now()
+		)`)
+	if err != nil {
+		return fmt.Errorf("init lockout schema: %w", err)
+	}
+	return nil
+}
+
+// LockoutPolicy configures when a subject is locked and for how long
+type LockoutPolicy struct {
+	// Threshold failures within Window lock the subject
+	Threshold int
+	Window    time.Duration
+	// The first lockout lasts Base and every further one twice as long, up to Max. The lockout
+	// level decays once a subject has had no failure for Max.
+	Base time.Duration
+	Max  time.Duration
+}
+
+// Duration returns how long the subject is locked after `lockouts` earlier lockouts
+func (p LockoutPolicy) Duration(lockouts int) time.Duration {
+	d := p.Base
+	for i := 0; i < lockouts && d < p.Max; i++ {
+		d *= 2
+	}
+	if d > p.Max {
+		d = p.Max
+	}
+	return d
+}
+
+// GetSubjectLock returns until when the subject is locked; nil when it is not
+func (db *Database) GetSubjectLock(ctx context.Context, subject string) (*time.Time, error) {
+	var until time.Time
+	err := db.Pool.QueryRow(ctx, `
+		SELECT locked_until FROM app_auth_lockouts WHERE subject = $1 AND locked_until > now()
+	`, subject).Scan(&until)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to check lockout: %w", err)
+	}
+	return &until, nil
+}
+
+// RecordSubjectFailure counts a failed verification for the subject and locks it once the policy's
+// threshold is reached. It returns the new lock's expiry when this failure locked the subject.
+func (db *Database) RecordSubjectFailure(ctx context.Context, subject string, p LockoutPolicy) (*time.Time, error) {
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `INSERT INTO app_auth_lockouts (subject) VALUES ($1) ON CONFLICT (subject) DO NOTHING`, subject); err != nil {
+		return nil, fmt.Errorf("failed to record failure: %w", err)
+	}
+	var failures, lockouts int
+	var lastFailure, now time.Time
+	if err := tx.QueryRow(ctx, `
+		SELECT failures, lockouts, last_failure_at, now() FROM app_auth_lockouts WHERE subject = $1 FOR UPDATE
+	`, subject).Scan(&failures, &lockouts, &lastFailure, &now); err != nil {
+		return nil, fmt.Errorf("failed to record failure: %w", err)
+	}
+	if now.Sub(lastFailure) > p.Window {
+		failures = 0
+	}
+	if now.Sub(lastFailure) > p.Max {
+		lockouts = 0
+	}
+	failures++
+	var lockedUntil *time.Time
+	if failures >= p.Threshold {
+		until := now.Add(p.Duration(lockouts))
+		lockedUntil = &until
+		failures = 0
+		lockouts++
+	}
+	if _, err := tx.Exec(ctx, `
+		UPDATE app_auth_lockouts
+		SET failures = $2, lockouts = $3, locked_until = COALESCE($4, locked_until), last_failure_at = now()
+		WHERE subject = $1
+	`, subject, failures, lockouts, lockedUntil); err != nil {
+		return nil, fmt.Errorf("failed to record failure: %w", err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return lockedUntil, nil
+}
+
+// ClearSubjectFailures forgets the subject's failures after a successful verification
+func (db *Database) ClearSubjectFailures(ctx context.Context, subject string) error {
+	_, err := db.Pool.Exec(ctx, `DELETE FROM app_auth_lockouts WHERE subject = $1`, subject)
+	return err
+}
@@ -0,0 +1,19 @@
+package db
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLockoutPolicyDuration(t *testing.T) {
+	p := LockoutPolicy{Base: 15 * time.Minute, Max: 2 * time.Hour}
+	want := []time.Duration{15 * time.Minute, 30 * time.Minute, time.Hour, 2 * time.Hour, 2 * time.Hour}
+	for lockouts, w := range want {
+		if got := p.Duration(lockouts); got != w {
+			t.Errorf("Duration(%d) = %s, want %s", lockouts, got, w)
+		}
+	}
+	if got := p.Duration(1000); got != p.Max {
+		t.Errorf("Duration(1000) = %s, want %s", got, p.Max)
+	}
+}
@@ -0,0 +1,202 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/expotoworld/expotoworld/backend/auth-service/internal/models"
+	"github.com/jackc/pgx/v5"
+)
+
+// InitLoginHistorySchema creates app_login_history, where each successful user login is kept with
+// its location and risk score to score later ones, and app_login_step_ups, the logins paused until
+// the user confirms them with the code emailed for them (kept apart from the sign-in codes of
+// app_user_verification_codes, so neither can be redeemed as the other)
+func (db *Database) InitLoginHistorySchema(ctx context.Context) error {
+	stmts := []string{
+		`CREATE TABLE IF NOT EXISTS app_login_history (
+			id BIGSERIAL PRIMARY KEY,
+			user_id TEXT NOT NULL,
+			method TEXT NOT NULL,
+			client_ip TEXT,
+			user_agent TEXT,
+			country TEXT,
+			region TEXT,
+			city TEXT,
+			latitude DOUBLE PRECISION,
+			longitude DOUBLE PRECISION,
+			asn INTEGER,
+			as_org TEXT,
+			anonymous BOOLEAN NOT NULL DEFAULT false,
+			risk_score INTEGER NOT NULL DEFAULT 0,
+			risk_reasons TEXT[] NOT NULL DEFAULT '{}',
+			created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_login_history_user ON app_login_history(user_id, created_at DESC)`,
+		`CREATE INDEX IF NOT EXISTS idx_login_history_created ON app_login_history(created_at)`,
+		`CREATE TABLE IF NOT EXISTS app_login_step_ups (
+			id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+			user_id TEXT NOT NULL,
+			email TEXT NOT NULL,
+			role TEXT NOT NULL DEFAULT '',
+			method TEXT NOT NULL,
+			extra JSONB,
+			code_hash TEXT NOT NULL DEFAULT '',
+			attempts INTEGER NOT NULL DEFAULT 0,
+			expires_at TIMESTAMPTZ NOT NULL,
+			used_at TIMESTAMPTZ,
+			created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+		)`,
+		`ALTER TABLE app_login_step_ups ADD COLUMN IF NOT EXISTS code_hash TEXT NOT NULL DEFAULT ''`,
+		`ALTER TABLE app_login_step_ups ADD COLUMN IF NOT EXISTS attempts INTEGER NOT NULL DEFAULT 0`,
+		`CREATE INDEX IF NOT EXISTS idx_login_step_ups_expires ON app_login_step_ups(expires_at)`,
+	}
+	for _, q := range stmts {
+		if _, err := db.Pool.Exec(ctx, q); err != nil {
+			return fmt.Errorf("init login history schema: %w", err)
+		}
+	}
+	return nil
+}
+
+// SummarizeLoginHistory compares a login with the user's logins since `since`: how many there were,
+// whether the device, IP, network and country signed in before, and where the last located one was
+func (db *Database) SummarizeLoginHistory(ctx context.Context, userID, clientIP, userAgent string, loc *models.GeoLocation, since time.Time) (models.LoginHistorySummary, error) {
+	var s models.LoginHistorySummary
+	asn, country := 0, ""
+	if loc != nil {
+		asn, country = loc.ASN, loc.Country
+	}
+	err := db.Pool.QueryRow(ctx, `
+		SELECT COUNT(*),
+		       COALESCE(bool_or(user_agent = $3), false),
+		       COALESCE(bool_or(client_ip = $2), false),
+		       COALESCE(bool_or(asn = $4), false),
+		       COALESCE(bool_or(country = $5), false)
+		FROM app_login_history
+		WHERE user_id = $1 AND created_at >= $6
+	`, userID, clientIP, userAgent, asn, country, since).Scan(&s.Logins, &s.KnownDevice, &s.KnownIP, &s.KnownNetwork, &s.KnownCountry)
+	if err != nil {
+		return s, fmt.Errorf("failed to summarize login history: %w", err)
+	}
+	if s.Logins == 0 {
+		return s, nil
+	}
+
+	var last models.GeoLocation
+	var lastCountry *string
+	err = db.Pool.QueryRow(ctx, `
+		SELECT country, latitude, longitude, created_at
+		FROM app_login_history
+		WHERE user_id = $1 AND created_at >= $2 AND latitude IS NOT NULL AND longitude IS NOT NULL
+		ORDER BY created_at DESC
+		LIMIT 1
+	`, userID, since).Scan(&lastCountry, &last.Latitude, &last.Longitude, &s.LastLocatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return s, nil
+	}
+	if err != nil {
+		return s, fmt.Errorf("failed to load last login location: %w", err)
+	}
+	if lastCountry != nil {
+		last.Country = *lastCountry
+	}
+	s.LastLocation = &last
+	return s, nil
+}
+
+// RecordLogin appends a successful login to app_login_history
+func (db *Database) RecordLogin(ctx context.Context, r *models.LoginRecord) error {
+	loc := r.Location
+	if loc == nil {
+		loc = &models.GeoLocation{}
+	}
+	reasons := r.Risk.Reasons
+	if reasons == nil {
+		reasons = []string{}
+	}
+	_, err := db.Pool.Exec(ctx, `
+		INSERT INTO app_login_history (user_id, method, client_ip, user_agent, country, region, city,
+			latitude, longitude, asn, as_org, anonymous, risk_score, risk_reasons)
+		VALUES ($1, $2, NULLIF($3, ''), NULLIF($4, ''), NULLIF($5, ''), NULLIF($6, ''), NULLIF($7, ''),
+			$8, $9, NULLIF($10, 0), NULLIF($11, ''), $12, $13, $14)
+	`, r.UserID, r.Method, r.ClientIP, r.UserAgent, loc.Country, loc.Region, loc.City,
+		loc.Latitude, loc.Longitude, loc.ASN, loc.ASOrg, loc.Anonymous, r.Risk.Score, reasons)
+	if err != nil {
+		return fmt.Errorf("failed to record login: %w", err)
+	}
+	return nil
+}
+
+// PruneLoginHistory deletes logins recorded before `before`
+func (db *Database) PruneLoginHistory(ctx context.Context, before time.Time) error {
+	_, err := db.Pool.Exec(ctx, `DELETE FROM app_login_history WHERE created_at < $1`, before)
+	return err
+}
+
+// CreateLoginStepUp stores a paused login with the hash of its code and returns its id, the
+// step_up_token given to the client
+func (db *Database) CreateLoginStepUp(ctx context.Context, s *models.LoginStepUp) (string, error) {
+	var extra any
+	if len(s.Extra) > 0 {
+		b, err := json.Marshal(s.Extra)
+		if err != nil {
+			return "", err
+		}
+		extra = string(b)
+	}
+	var id string
+	err := db.Pool.QueryRow(ctx, `
+		INSERT INTO app_login_step_ups (user_id, email, role, method, extra, code_hash, expires_at)
+		VALUES ($1, $2, $3, $4, $5::jsonb, $6, $7)
+		RETURNING id::text
+	`, s.UserID, s.Email, s.Role, s.Method, extra, s.CodeHash, s.ExpiresAt).Scan(&id)
+	if err != nil {
+		return "", fmt.Errorf("failed to create login step-up: %w", err)
+	}
+	return id, nil
+}
+
+// GetLoginStepUp returns an unused, unexpired paused login; pgx.ErrNoRows otherwise
+func (db *Database) GetLoginStepUp(ctx context.Context, id string) (*models.LoginStepUp, error) {
+	var s models.LoginStepUp
+	var extra []byte
+	err := db.Pool.QueryRow(ctx, `
+		SELECT id::text, user_id, email, role, method, extra, code_hash, attempts, expires_at
+		FROM app_login_step_ups
+		WHERE id::text = $1 AND used_at IS NULL AND expires_at > now()
+	`, id).Scan(&s.ID, &s.UserID, &s.Email, &s.Role, &s.Method, &extra, &s.CodeHash, &s.Attempts, &s.ExpiresAt)
+	if err != nil {
+		return nil, err
+	}
+	if len(extra) > 0 {
+		if err := json.Unmarshal(extra, &s.Extra); err != nil {
+			return nil, fmt.Errorf("invalid login step-up extra: %w", err)
+		}
+	}
+	return &s, nil
+}
+
+// RecordLoginStepUpAttempt counts a wrong code entered for a paused login
+func (db *Database) RecordLoginStepUpAttempt(ctx context.Context, id string) error {
+	_, err := db.Pool.Exec(ctx, `UPDATE app_login_step_ups SET attempts = attempts + 1 WHERE id::text = $1`, id)
+	return err
+}
+
+// ConsumeLoginStepUp marks a paused login used; false if it was already used (concurrent redemption)
+func (db *Database) ConsumeLoginStepUp(ctx context.Context, id string) (bool, error) {
+	tag, err := db.Pool.Exec(ctx, `UPDATE app_login_step_ups SET used_at = now() WHERE id::text = $1 AND used_at IS NULL`, id)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+// CleanupLoginStepUps deletes paused logins that expired more than a day ago
+func (db *Database) CleanupLoginStepUps(ctx context.Context) error {
+	_, err := db.Pool.Exec(ctx, `DELETE FROM app_login_step_ups WHERE expires_at < $1`, time.Now().Add(-24*time.Hour))
+	return err
+}
@@ -0,0 +1,63 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// InitMagicLinkSchema creates the table of emailed login links
+func (db *Database) InitMagicLinkSchema(ctx context.Context) error {
+	stmts := []string{
+		`CREATE TABLE IF NOT EXISTS app_magic_links (
+			id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+			email VARCHAR(255) NOT NULL,
+			token_hash TEXT NOT NULL UNIQUE,
+			ip_address VARCHAR(45),
+			created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+			expires_at TIMESTAMPTZ NOT NULL,
+			used_at TIMESTAMPTZ
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_magic_links_email ON app_magic_links(email)`,
+		`CREATE INDEX IF NOT EXISTS idx_magic_links_expires ON app_magic_links(expires_at)`,
+	}
+	for _, q := range stmts {
+		if _, err := db.Pool.Exec(ctx, q); err != nil {
+			return fmt.Errorf("init magic link schema: %w", err)
+		}
+	}
+	return nil
+}
+
+// CreateMagicLink stores the hash of a new login link for email. Like a new code, it replaces the
+// links sent to that email before; expired links of any email are cleaned up on the way.
+func (db *Database) CreateMagicLink(ctx context.Context, email, tokenHash, ipAddress string, expiresAt time.Time) error {
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `
+		DELETE FROM app_magic_links
+		WHERE (email = $1 AND used_at IS NULL) OR expires_at < now() - interval '1 day'`, email); err != nil {
+		return fmt.Errorf("failed to replace magic links: %w", err)
+	}
+	if _, err := tx.Exec(ctx, `
+		INSERT INTO app_magic_links (email, token_hash, ip_address, expires_at)
+		VALUES ($1, $2, NULLIF($3, ''), $4)`, email, tokenHash, ipAddress, expiresAt); err != nil {
+		return fmt.Errorf("failed to create magic link: %w", err)
+	}
+	return tx.Commit(ctx)
+}
+
+// ConsumeMagicLink marks an unused, unexpired link as used and returns its email; pgx.ErrNoRows if
+// the link is unknown, expired or was already used
+func (db *Database) ConsumeMagicLink(ctx context.Context, tokenHash string) (string, error) {
+	var email string
+	err := db.Pool.QueryRow(ctx, `
+		UPDATE app_magic_links SET used_at = now()
+		WHERE token_hash = $1 AND used_at IS NULL AND expires_at > now()
+		RETURNING email`, tokenHash).Scan(&email)
+	return email, err
+}
@@ -0,0 +1,135 @@
+package db
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/expotoworld/expotoworld/backend/auth-service/internal/models"
+	"github.com/jackc/pgx/v5"
+)
+
+// InitQRLoginSchema creates the table of pending QR code logins
+func (db *Database) InitQRLoginSchema(ctx context.Context) error {
+	stmts := []string{
+		`CREATE TABLE IF NOT EXISTS app_qr_login_sessions (
+			id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+			code_hash TEXT NOT NULL UNIQUE,
+			poll_token_hash TEXT NOT NULL,
+			status TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending','scanned','approved','denied','consumed')),
+			required_role TEXT NOT NULL DEFAULT '',
+			user_id TEXT,
+			requester_ip TEXT,
+			requester_user_agent TEXT,
+			created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+			expires_at TIMESTAMPTZ NOT NULL,
+			scanned_at TIMESTAMPTZ,
+			decided_at TIMESTAMPTZ,
+			consumed_at TIMESTAMPTZ
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_qr_login_sessions_expires ON app_qr_login_sessions(expires_at)`,
+	}
+	for _, q := range stmts {
+		if _, err := db.Pool.Exec(ctx, q); err != nil {
+			return fmt.Errorf("init qr login schema: %w", err)
+		}
+	}
+	return nil
+}
+
+const qrLoginSelect = `
+	SELECT id::text, code_hash, poll_token_hash, status, required_role, user_id,
+	       COALESCE(requester_ip, ''), COALESCE(requester_user_agent, ''), created_at, expires_at
+	FROM app_qr_login_sessions`
+
+func scanQRLoginSession(row pgx.Row) (*models.QRLoginSession, error) {
+	var s models.QRLoginSession
+	if err := row.Scan(&s.ID, &s.CodeHash, &s.PollTokenHash, &s.Status, &s.RequiredRole, &s.UserID,
+		&s.RequesterIP, &s.RequesterUserAgent, &s.CreatedAt, &s.ExpiresAt); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// CreateQRLoginSession stores a pending QR login and returns its id
+func (db *Database) CreateQRLoginSession(ctx context.Context, s *models.QRLoginSession) (string, error) {
+	var id string
+	err := db.Pool.QueryRow(ctx, `
+		INSERT INTO app_qr_login_sessions (code_hash, poll_token_hash, required_role, requester_ip, requester_user_agent, expires_at)
+		VALUES ($1, $2, $3, NULLIF($4,''), NULLIF($5,''), $6)
+		RETURNING id::text
+	`, s.CodeHash, s.PollTokenHash, s.RequiredRole, s.RequesterIP, s.RequesterUserAgent, s.ExpiresAt).Scan(&id)
+	if err != nil {
+		return "", fmt.Errorf("failed to create QR login session: %w", err)
+	}
+	return id, nil
+}
+
+// GetQRLoginSessionByCode returns the session a scanned code belongs to; pgx.ErrNoRows if unknown
+func (db *Database) GetQRLoginSessionByCode(ctx context.Context, codeHash string) (*models.QRLoginSession, error) {
+	return scanQRLoginSession(db.Pool.QueryRow(ctx, qrLoginSelect+` WHERE code_hash = $1`, codeHash))
+}
+
+// GetQRLoginSessionForPoll returns a session if the poll token matches; pgx.ErrNoRows otherwise
+func (db *Database) GetQRLoginSessionForPoll(ctx context.Context, id, pollTokenHash string) (*models.QRLoginSession, error) {
+	return scanQRLoginSession(db.Pool.QueryRow(ctx, qrLoginSelect+` WHERE id::text = $1 AND poll_token_hash = $2`, id, pollTokenHash))
+}
+
+// ScanQRLoginSession binds an unexpired, undecided session to the user whose app scanned it.
+// It returns false if the session expired, was decided, or was already scanned by someone else.
+func (db *Database) ScanQRLoginSession(ctx context.Context, id, userID string) (bool, error) {
+	tag, err := db.Pool.Exec(ctx, `
+		UPDATE app_qr_login_sessions
+		SET status = 'scanned', user_id = $2, scanned_at = COALESCE(scanned_at, now())
+		WHERE id::text = $1 AND status IN ('pending','scanned') AND (user_id IS NULL OR user_id = $2) AND expires_at > now()
+	`, id, userID)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+// DecideQRLoginSession approves or denies an undecided session on behalf of userID; false if it can no longer be decided
+func (db *Database) DecideQRLoginSession(ctx context.Context, id, userID, status string) (bool, error) {
+	tag, err := db.Pool.Exec(ctx, `
+		UPDATE app_qr_login_sessions
+		SET status = $3, user_id = $2, decided_at = now()
+		WHERE id::text = $1 AND status IN ('pending','scanned') AND (user_id IS NULL OR user_id = $2) AND expires_at > now()
+	`, id, userID, status)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+// ConsumeQRLoginSession marks an approved session redeemed; false if it was already redeemed (concurrent poll)
+func (db *Database) ConsumeQRLoginSession(ctx context.Context, id string) (bool, error) {
+	tag, err := db.Pool.Exec(ctx, `
+		UPDATE app_qr_login_sessions
+		SET status = 'consumed', consumed_at = now()
+		WHERE id::text = $1 AND status = 'approved' AND expires_at > now()
+	`, id)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+// CleanupQRLoginSessions deletes sessions that expired more than a day ago
+func (db *Database) CleanupQRLoginSessions(ctx context.Context) error {
+	_, err := db.Pool.Exec(ctx, `DELETE FROM app_qr_login_sessions WHERE expires_at < now() - interval '1 day'`)
+	return err
+}
+
+// GetUserByID retrieves a user by id
+func (db *Database) GetUserByID(ctx context.Context, id string) (*models.User, error) {
+	var user models.User
+	err := db.Pool.QueryRow(ctx, `
+		SELECT id, username, email, phone, first_name, middle_name, last_name, created_at, updated_at
+		FROM app_users
+		WHERE id::text = $1
+	`, id).Scan(&user.ID, &user.Username, &user.Email, &user.Phone, &user.FirstName, &user.MiddleName, &user.LastName, &user.CreatedAt, &user.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
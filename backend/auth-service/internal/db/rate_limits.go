@@ -0,0 +1,143 @@
+package db
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/expotoworld/expotoworld/backend/auth-service/internal/models"
+	"github.com/jackc/pgx/v5"
+)
+
+// InitRateLimitTierSchema ensures the tier configuration table exists and rate limit rows can carry a subject.
+// Default tiers are only inserted when missing so edits made in the DB are preserved.
+func (db *Database) InitRateLimitTierSchema(ctx context.Context, defaults []models.RateLimitTier) error {
+	ddl := `
+		CREATE TABLE IF NOT EXISTS app_rate_limit_tiers (
+			tier TEXT PRIMARY KEY,
+			max_requests INTEGER NOT NULL CHECK (max_requests > 0),
+			window_minutes INTEGER NOT NULL CHECK (window_minutes > 0 AND window_minutes <= 1440),
+			scope TEXT NOT NULL CHECK (scope IN ('ip','ip_subject')),
+			updated_at TIMESTAMP WITH TIME ZONE DEFAULT now()
+		);
+
+		ALTER TABLE app_rate_limits ADD COLUMN IF NOT EXISTS subject VARCHAR(255);
+		ALTER TABLE app_rate_limits ADD COLUMN IF NOT EXISTS tier TEXT;
+
+		-- QR login starts are limited per IP under their own channel, as are codes sent on WhatsApp
+		ALTER TABLE app_rate_limits DROP CONSTRAINT IF EXISTS app_rate_limits_channel_type_check;
+		ALTER TABLE app_rate_limits ADD CONSTRAINT app_rate_limits_channel_type_check
+			CHECK (channel_type IN ('email','phone','qr','whatsapp'));
+
+		CREATE INDEX IF NOT EXISTS idx_app_rate_limits_ip_subject_window
+			ON app_rate_limits (actor_type, channel_type, ip_address, subject, window_start);
+	`
+	if _, err := db.Pool.Exec(ctx, ddl); err != nil {
+		return fmt.Errorf("failed to ensure rate limit tier schema: %w", err)
+	}
+
+	for _, t := range defaults {
+		if _, err := db.Pool.Exec(ctx, `
+			INSERT INTO app_rate_limit_tiers (tier, max_requests, window_minutes, scope)
+			VALUES ($1, $2, $3, $4)
+			ON CONFLICT (tier) DO NOTHING
+		`, t.Tier, t.MaxRequests, t.WindowMinutes, t.Scope); err != nil {
+			return fmt.Errorf("failed to seed rate limit tier %s: %w", t.Tier, err)
+		}
+	}
+	return nil
+}
+
+// ListRateLimitTiers returns all configured rate limit tiers
+func (db *Database) ListRateLimitTiers(ctx context.Context) ([]models.RateLimitTier, error) {
+	rows, err := db.Pool.Query(ctx, `SELECT tier, max_requests, window_minutes, scope, updated_at FROM app_rate_limit_tiers`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list rate limit tiers: %w", err)
+	}
+	defer rows.Close()
+
+	var tiers []models.RateLimitTier
+	for rows.Next() {
+		var t models.RateLimitTier
+		if err := rows.Scan(&t.Tier, &t.MaxRequests, &t.WindowMinutes, &t.Scope, &t.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan rate limit tier: %w", err)
+		}
+		tiers = append(tiers, t)
+	}
+	return tiers, rows.Err()
+}
+
+// ClassifyRateLimitSubject determines the risk tier of a user verification request.
+// channel is "email", "phone" or "whatsapp"; subject is the email address or E.164 phone number.
+func (db *Database) ClassifyRateLimitSubject(ctx context.Context, channel, subject, ipAddress string) (string, error) {
+	column := "email"
+	if channel == "phone" || channel == "whatsapp" {
+		column = "phone"
+	}
+
+	var userID string
+	var verifiedFromIP bool
+	query := fmt.Sprintf(`
+		SELECT u.id::text,
+			EXISTS (
+				SELECT 1 FROM app_refresh_tokens rt
+				WHERE rt.user_id = u.id AND rt.ip_address = $2
+			)
+		FROM app_users u
+		WHERE u.%s = $1
+		LIMIT 1
+	`, column)
+	err := db.Pool.QueryRow(ctx, query, subject, ipAddress).Scan(&userID, &verifiedFromIP)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return models.RateLimitTierNew, nil
+		}
+		return "", fmt.Errorf("failed to classify rate limit subject: %w", err)
+	}
+	if verifiedFromIP {
+		return models.RateLimitTierTrusted, nil
+	}
+	return models.RateLimitTierKnown, nil
+}
+
+// CountTieredRateLimit returns the number of user requests within the tier's window for its scope
+func (db *Database) CountTieredRateLimit(ctx context.Context, channel, ipAddress, subject string, tier models.RateLimitTier) (int, error) {
+	query := `
+		SELECT COALESCE(SUM(request_count), 0)
+		FROM app_rate_limits
+		WHERE actor_type = 'user' AND channel_type = $1 AND ip_address = $2
+			AND window_start > now() - ($3::int * interval '1 minute')
+	`
+	args := []interface{}{channel, ipAddress, tier.WindowMinutes}
+	if tier.Scope == models.RateLimitScopeIPSubject {
+		query += ` AND subject = $4`
+		args = append(args, subject)
+	}
+
+	var total int
+	if err := db.Pool.QueryRow(ctx, query, args...).Scan(&total); err != nil {
+		return 0, fmt.Errorf("failed to count rate limit: %w", err)
+	}
+	return total, nil
+}
+
+// IncrementTieredRateLimit records a user request for the IP and subject in the current minute bucket
+func (db *Database) IncrementTieredRateLimit(ctx context.Context, channel, ipAddress, subject, tier string) error {
+	result, err := db.Pool.Exec(ctx, `
+		UPDATE app_rate_limits
+		SET request_count = request_count + 1
+		WHERE actor_type = 'user' AND channel_type = $1 AND ip_address = $2 AND subject = $3
+			AND window_start >= date_trunc('minute', now())
+	`, channel, ipAddress, subject)
+	if err != nil {
+		return fmt.Errorf("failed to update rate limit: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		if _, err := db.Pool.Exec(ctx, `
+			INSERT INTO app_rate_limits (actor_type, channel_type, ip_address, subject, tier, request_count, window_start)
+			VALUES ('user', $1, $2, $3, $4, 1, date_trunc('minute', now()))
+		`, channel, ipAddress, subject, tier); err != nil {
+			return fmt.Errorf("failed to create rate limit record: %w", err)
+		}
+	}
+	return nil
+}
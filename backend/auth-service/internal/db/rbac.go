@@ -0,0 +1,256 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/expotoworld/expotoworld/backend/auth-service/internal/models"
+	"github.com/expotoworld/expotoworld/backend/common/rbac"
+	"github.com/jackc/pgx/v5"
+)
+
+// InitRBACSchema creates the roles, permissions, role_permissions and user_roles tables and seeds the
+// permission catalog and the built-in roles (the former app_users.role values). Seeding never
+// overwrites permissions an admin changed on a built-in role.
+func (db *Database) InitRBACSchema(ctx context.Context) error {
+	stmts := []string{
+		`CREATE TABLE IF NOT EXISTS app_permissions (
+			name TEXT PRIMARY KEY,
+			description TEXT NOT NULL DEFAULT ''
+		)`,
+		`CREATE TABLE IF NOT EXISTS app_roles (
+			name TEXT PRIMARY KEY,
+			description TEXT NOT NULL DEFAULT '',
+			built_in BOOLEAN NOT NULL DEFAULT false,
+			created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+			updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+		)`,
+		`CREATE TABLE IF NOT EXISTS app_role_permissions (
+			role TEXT NOT NULL REFERENCES app_roles(name) ON DELETE CASCADE ON UPDATE CASCADE,
+			permission TEXT NOT NULL REFERENCES app_permissions(name) ON DELETE CASCADE,
+			PRIMARY KEY (role, permission)
+		)`,
+		`CREATE TABLE IF NOT EXISTS app_user_roles (
+			user_id TEXT NOT NULL,
+			role TEXT NOT NULL REFERENCES app_roles(name) ON DELETE CASCADE ON UPDATE CASCADE,
+			granted_by TEXT,
+			granted_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+			PRIMARY KEY (user_id, role)
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_app_user_roles_role ON app_user_roles(role)`,
+	}
+	for _, q := range stmts {
+		if _, err := db.Pool.Exec(ctx, q); err != nil {
+			return fmt.Errorf("init RBAC schema: %w", err)
+		}
+	}
+
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("seed RBAC: %w", err)
+	}
+	defer tx.Rollback(ctx)
+	for _, p := range rbac.All {
+		if _, err := tx.Exec(ctx, `
+			INSERT INTO app_permissions (name, description) VALUES ($1, $2)
+			ON CONFLICT (name) DO UPDATE SET description = EXCLUDED.description`, p.Name, p.Description); err != nil {
+			return fmt.Errorf("seed permission %s: %w", p.Name, err)
+		}
+	}
+	for role, perms := range rbac.BuiltInRoles {
+		tag, err := tx.Exec(ctx, `INSERT INTO app_roles (name, built_in) VALUES ($1, true) ON CONFLICT (name) DO NOTHING`, role)
+		if err != nil {
+			return fmt.Errorf("seed role %s: %w", role, err)
+		}
+		if tag.RowsAffected() == 0 {
+			continue
+		}
+		if _, err := tx.Exec(ctx, `
+			INSERT INTO app_role_permissions (role, permission) SELECT $1, unnest($2::text[])
+			ON CONFLICT DO NOTHING`, role, perms); err != nil {
+			return fmt.Errorf("seed role %s: %w", role, err)
+		}
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("seed RBAC: %w", err)
+	}
+	return nil
+}
+
+// GetUserPermissions returns the permissions of the user's primary role (the role claim) and of the
+// roles assigned to them, sorted
+func (db *Database) GetUserPermissions(ctx context.Context, userID, role string) ([]string, error) {
+	rows, err := db.Pool.Query(ctx, `
+		SELECT DISTINCT rp.permission
+		FROM app_role_permissions rp
+		WHERE rp.role = $2
+		   OR rp.role IN (SELECT ur.role FROM app_user_roles ur WHERE ur.user_id = $1)
+		ORDER BY rp.permission
+	`, userID, role)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load permissions: %w", err)
+	}
+	perms, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return nil, fmt.Errorf("failed to load permissions: %w", err)
+	}
+	if perms == nil {
+		perms = []string{}
+	}
+	return perms, nil
+}
+
+// ListPermissions returns the permission catalog
+func (db *Database) ListPermissions(ctx context.Context) ([]models.Permission, error) {
+	rows, err := db.Pool.Query(ctx, `SELECT name, description FROM app_permissions ORDER BY name`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list permissions: %w", err)
+	}
+	return pgx.CollectRows(rows, pgx.RowToStructByPos[models.Permission])
+}
+
+const roleColumns = `r.name, r.description, r.built_in,
+	COALESCE((SELECT array_agg(rp.permission ORDER BY rp.permission) FROM app_role_permissions rp WHERE rp.role = r.name), '{}'),
+	(SELECT COUNT(*) FROM app_user_roles ur WHERE ur.role = r.name),
+	r.created_at, r.updated_at`
+
+func scanRole(row pgx.Row) (*models.Role, error) {
+	var r models.Role
+	if err := row.Scan(&r.Name, &r.Description, &r.BuiltIn, &r.Permissions, &r.AssignedUsers, &r.CreatedAt, &r.UpdatedAt); err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+// ListRoles returns every role with its permissions
+func (db *Database) ListRoles(ctx context.Context) ([]models.Role, error) {
+	rows, err := db.Pool.Query(ctx, `SELECT `+roleColumns+` FROM app_roles r ORDER BY r.built_in DESC, r.name`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list roles: %w", err)
+	}
+	defer rows.Close()
+	roles := []models.Role{}
+	for rows.Next() {
+		r, err := scanRole(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan role: %w", err)
+		}
+		roles = append(roles, *r)
+	}
+	return roles, rows.Err()
+}
+
+// GetRole returns a role; pgx.ErrNoRows if it doesn't exist
+func (db *Database) GetRole(ctx context.Context, name string) (*models.Role, error) {
+	return scanRole(db.Pool.QueryRow(ctx, `SELECT `+roleColumns+` FROM app_roles r WHERE r.name = $1`, name))
+}
+
+// ErrRoleExists is returned when creating a role whose name is taken
+var ErrRoleExists = errors.New("role already exists")
+
+// CreateRole creates a custom role with the given permissions
+func (db *Database) CreateRole(ctx context.Context, name, description string, permissions []string) error {
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+	tag, err := tx.Exec(ctx, `INSERT INTO app_roles (name, description) VALUES ($1, $2) ON CONFLICT (name) DO NOTHING`, name, description)
+	if err != nil {
+		return fmt.Errorf("failed to create role: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrRoleExists
+	}
+	if err := setRolePermissions(ctx, tx, name, permissions); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// UpdateRole replaces a role's description (when not nil) and permissions (when not nil); pgx.ErrNoRows
+// if the role doesn't exist
+func (db *Database) UpdateRole(ctx context.Context, name string, description *string, permissions []string) error {
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+	tag, err := tx.Exec(ctx, `UPDATE app_roles SET description = COALESCE($2, description), updated_at = now() WHERE name = $1`, name, description)
+	if err != nil {
+		return fmt.Errorf("failed to update role: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	if permissions != nil {
+		if _, err := tx.Exec(ctx, `DELETE FROM app_role_permissions WHERE role = $1`, name); err != nil {
+			return fmt.Errorf("failed to update role permissions: %w", err)
+		}
+		if err := setRolePermissions(ctx, tx, name, permissions); err != nil {
+			return err
+		}
+	}
+	return tx.Commit(ctx)
+}
+
+func setRolePermissions(ctx context.Context, tx pgx.Tx, role string, permissions []string) error {
+	if len(permissions) == 0 {
+		return nil
+	}
+	if _, err := tx.Exec(ctx, `
+		INSERT INTO app_role_permissions (role, permission) SELECT $1, unnest($2::text[])
+		ON CONFLICT DO NOTHING`, role, permissions); err != nil {
+		return fmt.Errorf("failed to set role permissions: %w", err)
+	}
+	return nil
+}
+
+// DeleteRole deletes a custom role and its assignments; false if there is no such custom role
+func (db *Database) DeleteRole(ctx context.Context, name string) (bool, error) {
+	tag, err := db.Pool.Exec(ctx, `DELETE FROM app_roles WHERE name = $1 AND built_in = false`, name)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete role: %w", err)
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+// ListUserRoles returns the roles assigned to a user in addition to their primary role
+func (db *Database) ListUserRoles(ctx context.Context, userID string) ([]models.UserRoleAssignment, error) {
+	rows, err := db.Pool.Query(ctx, `
+		SELECT role, COALESCE(granted_by, ''), granted_at FROM app_user_roles WHERE user_id = $1 ORDER BY role
+	`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list user roles: %w", err)
+	}
+	assignments, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.UserRoleAssignment])
+	if err != nil {
+		return nil, fmt.Errorf("failed to list user roles: %w", err)
+	}
+	if assignments == nil {
+		assignments = []models.UserRoleAssignment{}
+	}
+	return assignments, nil
+}
+
+// AssignUserRole assigns a role to a user (a no-op when already assigned)
+func (db *Database) AssignUserRole(ctx context.Context, userID, role, grantedBy string) error {
+	_, err := db.Pool.Exec(ctx, `
+		INSERT INTO app_user_roles (user_id, role, granted_by) VALUES ($1, $2, NULLIF($3, ''))
+		ON CONFLICT (user_id, role) DO NOTHING
+	`, userID, role, grantedBy)
+	if err != nil {
+		return fmt.Errorf("failed to assign role: %w", err)
+	}
+	return nil
+}
+
+// RevokeUserRole removes a role assignment; false if the user didn't have it
+func (db *Database) RevokeUserRole(ctx context.Context, userID, role string) (bool, error) {
+	tag, err := db.Pool.Exec(ctx, `DELETE FROM app_user_roles WHERE user_id = $1 AND role = $2`, userID, role)
+	if err != nil {
+		return false, fmt.Errorf("failed to revoke role: %w", err)
+	}
+	return tag.RowsAffected() == 1, nil
+}
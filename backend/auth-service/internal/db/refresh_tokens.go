@@ -16,30 +16,63 @@ func hashRefreshToken(plain string) string {
 
 // CreateRefreshToken stores a hashed refresh token for a user with expiry and optional metadata.
 // The plain token must NOT be stored in DB. Pass hash generated via hashRefreshToken().
-func (db *Database) CreateRefreshToken(ctx context.Context, userID string, tokenHash string, expiresAt time.Time, ip string, userAgent string) (string, error) {
+// adminVerified marks tokens from a login that passed the admin checks (incl. the security key step);
+// only those may be refreshed into Admin access tokens.
+func (db *Database) CreateRefreshToken(ctx context.Context, userID string, tokenHash string, expiresAt time.Time, ip string, userAgent string, adminVerified bool) (string, error) {
 	query := `
-		INSERT INTO app_refresh_tokens (user_id, token_hash, expires_at, ip_address, user_agent)
-		VALUES ($1, $2, $3, NULLIF($4,''), NULLIF($5,''))
+		INSERT INTO app_refresh_tokens (user_id, token_hash, expires_at, ip_address, user_agent, admin_verified)
+		VALUES ($1, $2, $3, NULLIF($4,''), NULLIF($5,''), $6)
 		RETURNING id
 	`
 	var id string
-	if err := db.Pool.QueryRow(ctx, query, userID, tokenHash, expiresAt, ip, userAgent).Scan(&id); err != nil {
+	if err := db.Pool.QueryRow(ctx, query, userID, tokenHash, expiresAt, ip, userAgent, adminVerified).Scan(&id); err != nil {
 		return "", fmt.Errorf("failed to create refresh token: %w", err)
 	}
 	return id, nil
 }
 
+// InitRefreshTokenSchema adds the activity tracking column used for inactivity expiry.
+// Tokens issued before the column existed fall back to issued_at.
+func (db *Database) InitRefreshTokenSchema(ctx context.Context) error {
+	stmts := []string{
+		`ALTER TABLE app_refresh_tokens ADD COLUMN IF NOT EXISTS last_used_at TIMESTAMPTZ`,
+		// Tokens issued before the column existed refresh into non-admin tokens; admins log in again once
+		`ALTER TABLE app_refresh_tokens ADD COLUMN IF NOT EXISTS admin_verified BOOLEAN NOT NULL DEFAULT false`,
+		`CREATE INDEX IF NOT EXISTS idx_app_refresh_tokens_active_last_used ON app_refresh_tokens (COALESCE(last_used_at, issued_at)) WHERE revoked = false`,
+		// ip_address comes from client headers and may not be a valid address; used by admin IP range searches
+		`CREATE OR REPLACE FUNCTION app_try_inet(v TEXT) RETURNS INET LANGUAGE plpgsql IMMUTABLE AS $$
+		BEGIN
+			RETURN v::inet;
+		EXCEPTION WHEN others THEN
+			RETURN NULL;
+		END $$`,
+	}
+	for _, q := range stmts {
+		if _, err := db.Pool.Exec(ctx, q); err != nil {
+			return fmt.Errorf("init refresh token schema: %w", err)
+		}
+	}
+	return nil
+}
+
 // GetRefreshToken looks up a refresh token by its hash and returns identifying data.
-func (db *Database) GetRefreshToken(ctx context.Context, tokenHash string) (id string, userID string, expiresAt time.Time, revoked bool, err error) {
+// lastActiveAt is the last successful use of the token, or its issue time if never used.
+func (db *Database) GetRefreshToken(ctx context.Context, tokenHash string) (id string, userID string, issuedAt time.Time, expiresAt time.Time, lastActiveAt time.Time, revoked bool, adminVerified bool, err error) {
 	query := `
-		SELECT id::text, user_id::text, expires_at, revoked
+		SELECT id::text, user_id::text, issued_at, expires_at, COALESCE(last_used_at, issued_at), revoked, admin_verified
 		FROM app_refresh_tokens
 		WHERE token_hash = $1
 	`
-	err = db.Pool.QueryRow(ctx, query, tokenHash).Scan(&id, &userID, &expiresAt, &revoked)
+	err = db.Pool.QueryRow(ctx, query, tokenHash).Scan(&id, &userID, &issuedAt, &expiresAt, &lastActiveAt, &revoked, &adminVerified)
 	return
 }
 
+// TouchRefreshToken records a successful use of a token and sets its (possibly slid) expiry.
+func (db *Database) TouchRefreshToken(ctx context.Context, id string, expiresAt time.Time) error {
+	_, err := db.Pool.Exec(ctx, `UPDATE app_refresh_tokens SET last_used_at = now(), expires_at = $2 WHERE id = $1`, id, expiresAt)
+	return err
+}
+
 // RevokeRefreshToken marks a token as revoked.
 func (db *Database) RevokeRefreshToken(ctx context.Context, id string) error {
 	_, err := db.Pool.Exec(ctx, `UPDATE app_refresh_tokens SET revoked = true WHERE id = $1`, id)
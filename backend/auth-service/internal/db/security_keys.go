@@ -0,0 +1,247 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/expotoworld/expotoworld/backend/auth-service/internal/models"
+	"github.com/jackc/pgx/v5"
+)
+
+// ErrCredentialExists is returned when a security key is already registered
+var ErrCredentialExists = errors.New("security key already registered")
+
+// InitSecurityKeySchema creates the admin security key, recovery code and WebAuthn challenge tables
+func (db *Database) InitSecurityKeySchema(ctx context.Context) error {
+	stmts := []string{
+		`CREATE TABLE IF NOT EXISTS app_admin_security_keys (
+			id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+			user_id TEXT NOT NULL,
+			name TEXT NOT NULL DEFAULT '',
+			credential_id BYTEA NOT NULL UNIQUE,
+			public_key BYTEA NOT NULL,
+			sign_count BIGINT NOT NULL DEFAULT 0,
+			aaguid BYTEA,
+			transports TEXT[] NOT NULL DEFAULT '{}',
+			created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+			last_used_at TIMESTAMPTZ
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_admin_security_keys_user ON app_admin_security_keys(user_id)`,
+		`CREATE TABLE IF NOT EXISTS app_admin_recovery_codes (
+			id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+			user_id TEXT NOT NULL,
+			code_hash TEXT NOT NULL,
+			used_at TIMESTAMPTZ,
+			created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_admin_recovery_codes_user ON app_admin_recovery_codes(user_id) WHERE used_at IS NULL`,
+		`CREATE TABLE IF NOT EXISTS app_admin_mfa_challenges (
+			id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+			user_id TEXT NOT NULL,
+			email TEXT NOT NULL DEFAULT '',
+			role TEXT NOT NULL DEFAULT '',
+			purpose TEXT NOT NULL CHECK (purpose IN ('login','register')),
+			challenge BYTEA NOT NULL,
+			attempts INTEGER NOT NULL DEFAULT 0,
+			expires_at TIMESTAMPTZ NOT NULL,
+			used_at TIMESTAMPTZ,
+			created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_admin_mfa_challenges_expires ON app_admin_mfa_challenges(expires_at)`,
+	}
+	for _, q := range stmts {
+		if _, err := db.Pool.Exec(ctx, q); err != nil {
+			return fmt.Errorf("init security key schema: %w", err)
+		}
+	}
+	return nil
+}
+
+const securityKeySelect = `
+	SELECT id::text, user_id, name, credential_id, public_key, sign_count, transports, created_at, last_used_at
+	FROM app_admin_security_keys`
+
+func scanSecurityKey(row pgx.Row) (*models.SecurityKey, error) {
+	var k models.SecurityKey
+	var signCount int64
+	if err := row.Scan(&k.ID, &k.UserID, &k.Name, &k.CredentialID, &k.PublicKey, &signCount, &k.Transports, &k.CreatedAt, &k.LastUsedAt); err != nil {
+		return nil, err
+	}
+	k.SignCount = uint32(signCount)
+	return &k, nil
+}
+
+// ListSecurityKeys returns a user's security keys, oldest first
+func (db *Database) ListSecurityKeys(ctx context.Context, userID string) ([]models.SecurityKey, error) {
+	rows, err := db.Pool.Query(ctx, securityKeySelect+` WHERE user_id = $1 ORDER BY created_at`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := []models.SecurityKey{}
+	for rows.Next() {
+		k, err := scanSecurityKey(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *k)
+	}
+	return out, rows.Err()
+}
+
+// GetSecurityKeyByCredentialID returns one of the user's keys by WebAuthn credential id; pgx.ErrNoRows if unknown
+func (db *Database) GetSecurityKeyByCredentialID(ctx context.Context, userID string, credentialID []byte) (*models.SecurityKey, error) {
+	return scanSecurityKey(db.Pool.QueryRow(ctx, securityKeySelect+` WHERE user_id = $1 AND credential_id = $2`, userID, credentialID))
+}
+
+// CreateSecurityKey stores a newly registered key
+func (db *Database) CreateSecurityKey(ctx context.Context, k *models.SecurityKey, aaguid []byte) (*models.SecurityKey, error) {
+	created, err := scanSecurityKey(db.Pool.QueryRow(ctx, `
+		INSERT INTO app_admin_security_keys (user_id, name, credential_id, public_key, sign_count, aaguid, transports)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (credential_id) DO NOTHING
+		RETURNING id::text, user_id, name, credential_id, public_key, sign_count, transports, created_at, last_used_at
+	`, k.UserID, k.Name, k.CredentialID, k.PublicKey, int64(k.SignCount), aaguid, k.Transports))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrCredentialExists
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to save security key: %w", err)
+	}
+	return created, nil
+}
+
+// TouchSecurityKey records a successful assertion and its signature counter
+func (db *Database) TouchSecurityKey(ctx context.Context, id string, signCount uint32) error {
+	_, err := db.Pool.Exec(ctx, `UPDATE app_admin_security_keys SET sign_count = $2, last_used_at = now() WHERE id::text = $1`, id, int64(signCount))
+	return err
+}
+
+// DeleteSecurityKey removes one of the user's keys; removing the last one also drops the recovery codes.
+// removed is false if the key does not exist.
+func (db *Database) DeleteSecurityKey(ctx context.Context, userID, id string) (removed bool, remaining int, err error) {
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		return false, 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	tag, err := tx.Exec(ctx, `DELETE FROM app_admin_security_keys WHERE user_id = $1 AND id::text = $2`, userID, id)
+	if err != nil {
+		return false, 0, err
+	}
+	if tag.RowsAffected() == 0 {
+		return false, 0, nil
+	}
+	if err := tx.QueryRow(ctx, `SELECT COUNT(*) FROM app_admin_security_keys WHERE user_id = $1`, userID).Scan(&remaining); err != nil {
+		return false, 0, err
+	}
+	if remaining == 0 {
+		if _, err := tx.Exec(ctx, `DELETE FROM app_admin_recovery_codes WHERE user_id = $1`, userID); err != nil {
+			return false, 0, err
+		}
+	}
+	return true, remaining, tx.Commit(ctx)
+}
+
+// CountSecurityKeys returns how many keys the user registered
+func (db *Database) CountSecurityKeys(ctx context.Context, userID string) (int, error) {
+	var n int
+	err := db.Pool.QueryRow(ctx, `SELECT COUNT(*) FROM app_admin_security_keys WHERE user_id = $1`, userID).Scan(&n)
+	return n, err
+}
+
+// ReplaceRecoveryCodes invalidates the user's recovery codes and stores new (hashed) ones
+func (db *Database) ReplaceRecoveryCodes(ctx context.Context, userID string, codeHashes []string) error {
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+	if _, err := tx.Exec(ctx, `DELETE FROM app_admin_recovery_codes WHERE user_id = $1`, userID); err != nil {
+		return err
+	}
+	for _, h := range codeHashes {
+		if _, err := tx.Exec(ctx, `INSERT INTO app_admin_recovery_codes (user_id, code_hash) VALUES ($1, $2)`, userID, h); err != nil {
+			return err
+		}
+	}
+	return tx.Commit(ctx)
+}
+
+// UseRecoveryCode consumes an unused recovery code; ok is false if none matches
+func (db *Database) UseRecoveryCode(ctx context.Context, userID, codeHash string) (ok bool, remaining int, err error) {
+	tag, err := db.Pool.Exec(ctx, `
+		UPDATE app_admin_recovery_codes SET used_at = now()
+		WHERE id = (SELECT id FROM app_admin_recovery_codes WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL LIMIT 1)
+	`, userID, codeHash)
+	if err != nil || tag.RowsAffected() == 0 {
+		return false, 0, err
+	}
+	err = db.Pool.QueryRow(ctx, `SELECT COUNT(*) FROM app_admin_recovery_codes WHERE user_id = $1 AND used_at IS NULL`, userID).Scan(&remaining)
+	return true, remaining, err
+}
+
+// CountRecoveryCodes returns the user's unused recovery codes
+func (db *Database) CountRecoveryCodes(ctx context.Context, userID string) (int, error) {
+	var n int
+	err := db.Pool.QueryRow(ctx, `SELECT COUNT(*) FROM app_admin_recovery_codes WHERE user_id = $1 AND used_at IS NULL`, userID).Scan(&n)
+	return n, err
+}
+
+// CreateMFAChallenge stores a pending ceremony and returns its id
+func (db *Database) CreateMFAChallenge(ctx context.Context, ch *models.MFAChallenge) (string, error) {
+	var id string
+	err := db.Pool.QueryRow(ctx, `
+		INSERT INTO app_admin_mfa_challenges (user_id, email, role, purpose, challenge, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id::text
+	`, ch.UserID, ch.Email, ch.Role, ch.Purpose, ch.Challenge, ch.ExpiresAt).Scan(&id)
+	if err != nil {
+		return "", fmt.Errorf("failed to create MFA challenge: %w", err)
+	}
+	return id, nil
+}
+
+// GetMFAChallenge returns an unused, unexpired challenge of the given purpose; pgx.ErrNoRows otherwise
+func (db *Database) GetMFAChallenge(ctx context.Context, id, purpose string) (*models.MFAChallenge, error) {
+	var ch models.MFAChallenge
+	err := db.Pool.QueryRow(ctx, `
+		SELECT id::text, user_id, email, role, purpose, challenge, attempts, expires_at
+		FROM app_admin_mfa_challenges
+		WHERE id::text = $1 AND purpose = $2 AND used_at IS NULL AND expires_at > now()
+	`, id, purpose).Scan(&ch.ID, &ch.UserID, &ch.Email, &ch.Role, &ch.Purpose, &ch.Challenge, &ch.Attempts, &ch.ExpiresAt)
+	if err != nil {
+		return nil, err
+	}
+	return &ch, nil
+}
+
+// RenewMFAChallenge sets a fresh WebAuthn challenge on a pending ceremony (e.g. before enrolling a key)
+func (db *Database) RenewMFAChallenge(ctx context.Context, id string, challenge []byte) error {
+	_, err := db.Pool.Exec(ctx, `UPDATE app_admin_mfa_challenges SET challenge = $2 WHERE id::text = $1 AND used_at IS NULL`, id, challenge)
+	return err
+}
+
+// FailMFAChallenge counts a failed attempt
+func (db *Database) FailMFAChallenge(ctx context.Context, id string) error {
+	_, err := db.Pool.Exec(ctx, `UPDATE app_admin_mfa_challenges SET attempts = attempts + 1 WHERE id::text = $1`, id)
+	return err
+}
+
+// ConsumeMFAChallenge marks a challenge used; false if it was already used (concurrent redemption)
+func (db *Database) ConsumeMFAChallenge(ctx context.Context, id string) (bool, error) {
+	tag, err := db.Pool.Exec(ctx, `UPDATE app_admin_mfa_challenges SET used_at = now() WHERE id::text = $1 AND used_at IS NULL`, id)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+// CleanupMFAChallenges deletes challenges that expired more than a day ago
+func (db *Database) CleanupMFAChallenges(ctx context.Context) error {
+	_, err := db.Pool.Exec(ctx, `DELETE FROM app_admin_mfa_challenges WHERE expires_at < $1`, time.Now().Add(-24*time.Hour))
+	return err
+}
@@ -0,0 +1,108 @@
+package db
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/expotoworld/expotoworld/backend/auth-service/internal/models"
+	"github.com/jackc/pgx/v5"
+)
+
+// InitServiceClientSchema creates the table of internal service identities used by the
+// client-credentials grant and token introspection
+func (db *Database) InitServiceClientSchema(ctx context.Context) error {
+	_, err := db.Pool.Exec(ctx, `
+		CREATE TABLE IF NOT EXISTS app_service_clients (
+			client_id TEXT PRIMARY KEY,
+			name TEXT NOT NULL,
+			secret_hash TEXT NOT NULL,
+			permissions TEXT[] NOT NULL DEFAULT '{}',
+			created_by TEXT,
+			created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+			last_used_at TIMESTAMPTZ,
+			revoked_at TIMESTAMPTZ
+		)`)
+	if err != nil {
+		return fmt.Errorf("init service client schema: %w", err)
+	}
+	return nil
+}
+
+const serviceClientColumns = `client_id, name, permissions, COALESCE(created_by, ''), created_at, last_used_at, revoked_at`
+
+// CreateServiceClient stores a new service client; only the hash of its secret is kept
+func (db *Database) CreateServiceClient(ctx context.Context, clientID, name, secretHash string, permissions []string, createdBy string) (*models.ServiceClient, error) {
+	if permissions == nil {
+		permissions = []string{}
+	}
+	rows, err := db.Pool.Query(ctx, `
+		INSERT INTO app_service_clients (client_id, name, secret_hash, permissions, created_by)
+		VALUES ($1, $2, $3, $4, NULLIF($5, ''))
+		RETURNING `+serviceClientColumns, clientID, name, secretHash, permissions, createdBy)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create service client: %w", err)
+	}
+	client, err := pgx.CollectExactlyOneRow(rows, pgx.RowToAddrOfStructByPos[models.ServiceClient])
+	if err != nil {
+		return nil, fmt.Errorf("failed to create service client: %w", err)
+	}
+	return client, nil
+}
+
+// GetActiveServiceClient returns a service client that has not been revoked and the hash of its
+// secret; pgx.ErrNoRows otherwise
+func (db *Database) GetActiveServiceClient(ctx context.Context, clientID string) (*models.ServiceClient, string, error) {
+	var secretHash string
+	var client models.ServiceClient
+	err := db.Pool.QueryRow(ctx, `
+		SELECT `+serviceClientColumns+`, secret_hash
+		FROM app_service_clients
+		WHERE client_id = $1 AND revoked_at IS NULL`, clientID).Scan(
+		&client.ClientID, &client.Name, &client.Permissions, &client.CreatedBy, &client.CreatedAt,
+		&client.LastUsedAt, &client.RevokedAt, &secretHash)
+	if err != nil {
+		return nil, "", err
+	}
+	return &client, secretHash, nil
+}
+
+// TouchServiceClient records that the client authenticated
+func (db *Database) TouchServiceClient(ctx context.Context, clientID string) error {
+	_, err := db.Pool.Exec(ctx, `UPDATE app_service_clients SET last_used_at = now() WHERE client_id = $1`, clientID)
+	return err
+}
+
+// ListServiceClients returns every service client, revoked ones included
+func (db *Database) ListServiceClients(ctx context.Context) ([]models.ServiceClient, error) {
+	rows, err := db.Pool.Query(ctx, `SELECT `+serviceClientColumns+` FROM app_service_clients ORDER BY created_at DESC`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list service clients: %w", err)
+	}
+	clients, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.ServiceClient])
+	if err != nil {
+		return nil, fmt.Errorf("failed to list service clients: %w", err)
+	}
+	if clients == nil {
+		clients = []models.ServiceClient{}
+	}
+	return clients, nil
+}
+
+// RevokeServiceClient revokes a client; false if there is no such active client. Tokens it already
+// holds stop introspecting as active right away and expire on their own.
+func (db *Database) RevokeServiceClient(ctx context.Context, clientID string) (bool, error) {
+	tag, err := db.Pool.Exec(ctx, `UPDATE app_service_clients SET revoked_at = now() WHERE client_id = $1 AND revoked_at IS NULL`, clientID)
+	if err != nil {
+		return false, fmt.Errorf("failed to revoke service client: %w", err)
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+// GetUserAccountState returns the user's current role and whether the account can still sign in
+// (active and not deleted); pgx.ErrNoRows if there is no such user
+func (db *Database) GetUserAccountState(ctx context.Context, userID string) (role string, active bool, err error) {
+	err = db.Pool.QueryRow(ctx, `
+		SELECT COALESCE(role::text, ''), COALESCE(status::text, 'active') = 'active' AND deleted_at IS NULL
+		FROM app_users WHERE id::text = $1`, userID).Scan(&role, &active)
+	return role, active, err
+}
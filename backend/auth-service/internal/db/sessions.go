@@ -0,0 +1,140 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/expotoworld/expotoworld/backend/auth-service/internal/models"
+)
+
+// sessionWhere builds the WHERE clause shared by session search, bulk revocation and counts.
+// ipRange must already be normalized to CIDR notation; an empty range matches any address.
+func sessionWhere(f models.SessionFilter, ipRange string) (string, []interface{}) {
+	conds := []string{}
+	args := []interface{}{}
+	add := func(cond string, val interface{}) {
+		args = append(args, val)
+		conds = append(conds, strings.ReplaceAll(cond, "?", fmt.Sprintf("$%d", len(args))))
+	}
+	if !f.IncludeEnded {
+		conds = append(conds, "t.revoked = false AND t.expires_at > now()")
+	}
+	if f.UserID != "" {
+		add("t.user_id::text = ?", f.UserID)
+	}
+	if f.Email != "" {
+		add("LOWER(u.email) = LOWER(?)", f.Email)
+	}
+	if ipRange != "" {
+		add("app_try_inet(t.ip_address::text) <<= ?::cidr", ipRange)
+	}
+	if f.UserAgent != "" {
+		add("t.user_agent ILIKE '%' || ? || '%'", f.UserAgent)
+	}
+	if f.CreatedFrom != nil {
+		add("t.issued_at >= ?", *f.CreatedFrom)
+	}
+	if f.CreatedTo != nil {
+		add("t.issued_at < ?", *f.CreatedTo)
+	}
+	if len(conds) == 0 {
+		return "", args
+	}
+	return "WHERE " + strings.Join(conds, " AND "), args
+}
+
+// SearchSessions returns a page of refresh-token sessions matching the filter, most recently active first
+func (db *Database) SearchSessions(ctx context.Context, f models.SessionFilter, ipRange string, limit, offset int) ([]models.Session, int, error) {
+	where, args := sessionWhere(f, ipRange)
+
+	var total int
+	countQuery := `SELECT COUNT(*) FROM app_refresh_tokens t LEFT JOIN app_users u ON u.id = t.user_id ` + where
+	if err := db.Pool.QueryRow(ctx, countQuery, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count sessions: %w", err)
+	}
+
+	query := fmt.Sprintf(`
+		SELECT t.id::text, t.user_id::text, COALESCE(u.email, ''), COALESCE(t.ip_address::text, ''), COALESCE(t.user_agent, ''),
+			t.issued_at, t.expires_at, COALESCE(t.last_used_at, t.issued_at), t.revoked, (NOT t.revoked AND t.expires_at > now()),
+			COALESCE((SELECT d.name FROM app_devices d WHERE d.session_id = t.id::text ORDER BY d.last_seen_at DESC LIMIT 1), '')
+		FROM app_refresh_tokens t
+		LEFT JOIN app_users u ON u.id = t.user_id
+		%s
+		ORDER BY COALESCE(t.last_used_at, t.issued_at) DESC
+		LIMIT $%d OFFSET $%d
+	`, where, len(args)+1, len(args)+2)
+	rows, err := db.Pool.Query(ctx, query, append(args, limit, offset)...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to search sessions: %w", err)
+	}
+	defer rows.Close()
+
+	sessions := []models.Session{}
+	for rows.Next() {
+		var s models.Session
+		if err := rows.Scan(&s.ID, &s.UserID, &s.Email, &s.IPAddress, &s.UserAgent,
+			&s.IssuedAt, &s.ExpiresAt, &s.LastActiveAt, &s.Revoked, &s.Active, &s.DeviceName); err != nil {
+			return nil, 0, fmt.Errorf("failed to scan session: %w", err)
+		}
+		sessions = append(sessions, s)
+	}
+	return sessions, total, rows.Err()
+}
+
+// RevokeSessionsByID revokes the given sessions; already revoked ones are not counted
+func (db *Database) RevokeSessionsByID(ctx context.Context, ids []string) (int64, error) {
+	tag, err := db.Pool.Exec(ctx, `UPDATE app_refresh_tokens SET revoked = true WHERE id::text = ANY($1) AND revoked = false`, ids)
+	if err != nil {
+		return 0, fmt.Errorf("failed to revoke sessions: %w", err)
+	}
+	return tag.RowsAffected(), nil
+}
+
+// RevokeSessionsMatching revokes every active session matching the filter
+func (db *Database) RevokeSessionsMatching(ctx context.Context, f models.SessionFilter, ipRange string) (int64, error) {
+	f.IncludeEnded = false
+	where, args := sessionWhere(f, ipRange)
+	tag, err := db.Pool.Exec(ctx, `
+		UPDATE app_refresh_tokens SET revoked = true
+		WHERE id IN (SELECT t.id FROM app_refresh_tokens t LEFT JOIN app_users u ON u.id = t.user_id `+where+`)
+	`, args...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to revoke sessions: %w", err)
+	}
+	return tag.RowsAffected(), nil
+}
+
+// CountSessionsByUser returns per-user session counts for sessions matching the filter,
+// users with the most active sessions first
+func (db *Database) CountSessionsByUser(ctx context.Context, f models.SessionFilter, ipRange string, limit int) ([]models.UserSessionCount, error) {
+	f.IncludeEnded = true
+	where, args := sessionWhere(f, ipRange)
+	query := fmt.Sprintf(`
+		SELECT t.user_id::text, COALESCE(u.email, ''),
+			COUNT(*) FILTER (WHERE NOT t.revoked AND t.expires_at > now()),
+			COUNT(*),
+			MAX(COALESCE(t.last_used_at, t.issued_at))
+		FROM app_refresh_tokens t
+		LEFT JOIN app_users u ON u.id = t.user_id
+		%s
+		GROUP BY t.user_id, u.email
+		ORDER BY 3 DESC, 5 DESC
+		LIMIT $%d
+	`, where, len(args)+1)
+	rows, err := db.Pool.Query(ctx, query, append(args, limit)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count sessions: %w", err)
+	}
+	defer rows.Close()
+
+	counts := []models.UserSessionCount{}
+	for rows.Next() {
+		var uc models.UserSessionCount
+		if err := rows.Scan(&uc.UserID, &uc.Email, &uc.ActiveSessions, &uc.TotalSessions, &uc.LastActiveAt); err != nil {
+			return nil, fmt.Errorf("failed to scan session count: %w", err)
+		}
+		counts = append(counts, uc)
+	}
+	return counts, rows.Err()
+}
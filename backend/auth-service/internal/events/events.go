@@ -0,0 +1,268 @@
+// Package events delivers account lifecycle events (e.g. an account deletion) to the other
+// services as signed HTTP callbacks, using an outbox table so events survive restarts.
+package events
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Event names emitted by the auth service
+const (
+	// EventUserDeleted is sent once a user deleted their account; receivers erase or anonymize what
+	// they hold about data.user_id
+	EventUserDeleted = "user.deleted"
+	// EventUserMerged is sent when data.merged_user_id was folded into data.user_id; receivers move
+	// what they hold about the merged user over
+	EventUserMerged = "user.merged"
+)
+
+// SignatureHeader carries "t=<unix>,v1=<hex hmac-sha256(secret, t + "." + body)>"
+const SignatureHeader = "X-Auth-Signature"
+
+const (
+	defaultMaxAttempts  = 12
+	defaultPollInterval = 10 * time.Second
+	batchSize           = 20
+	maxResponseLogBytes = 1024
+)
+
+type execer interface {
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+}
+
+// Envelope is the JSON body POSTed to every endpoint
+type Envelope struct {
+	ID         string         `json:"id"`
+	Event      string         `json:"event"`
+	OccurredAt time.Time      `json:"occurred_at"`
+	Data       map[string]any `json:"data"`
+}
+
+// Endpoints returns the receivers configured in ACCOUNT_EVENT_ENDPOINTS (comma-separated URLs)
+func Endpoints() []string {
+	var out []string
+	for _, v := range strings.Split(os.Getenv("ACCOUNT_EVENT_ENDPOINTS"), ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+// Enqueue queues one delivery per configured endpoint. Call it inside the transaction that performs
+// the change so deliveries only exist for committed changes; the worker sends them.
+func Enqueue(ctx context.Context, q execer, event string, data map[string]any) error {
+	endpoints := Endpoints()
+	if len(endpoints) == 0 {
+		return nil
+	}
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("marshal event data: %w", err)
+	}
+	_, err = q.Exec(ctx, `
+		INSERT INTO app_account_event_deliveries (endpoint, event, payload)
+		SELECT unnest($1::text[]), $2, $3::jsonb`, endpoints, event, string(payload))
+	if err != nil {
+		return fmt.Errorf("enqueue event %s: %w", event, err)
+	}
+	return nil
+}
+
+// Sign returns the signature header value for body at time t
+func Sign(secret string, t time.Time, body []byte) string {
+	ts := strconv.FormatInt(t.Unix(), 10)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(ts))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "t=" + ts + ",v1=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Worker delivers queued events with exponential backoff
+type Worker struct {
+	DB           *pgxpool.Pool
+	Client       *http.Client
+	Secret       string
+	MaxAttempts  int
+	PollInterval time.Duration
+}
+
+// NewWorkerFromEnv builds a worker signing with ACCOUNT_EVENT_SECRET and configured by
+// ACCOUNT_EVENT_MAX_ATTEMPTS, ACCOUNT_EVENT_POLL_SEC and ACCOUNT_EVENT_TIMEOUT_SEC
+func NewWorkerFromEnv(db *pgxpool.Pool) *Worker {
+	w := &Worker{
+		DB:           db,
+		Client:       &http.Client{Timeout: 10 * time.Second},
+		Secret:       os.Getenv("ACCOUNT_EVENT_SECRET"),
+		MaxAttempts:  defaultMaxAttempts,
+		PollInterval: defaultPollInterval,
+	}
+	if n, err := strconv.Atoi(strings.TrimSpace(os.Getenv("ACCOUNT_EVENT_MAX_ATTEMPTS"))); err == nil && n > 0 {
+		w.MaxAttempts = n
+	}
+	if n, err := strconv.Atoi(strings.TrimSpace(os.Getenv("ACCOUNT_EVENT_POLL_SEC"))); err == nil && n > 0 {
+		w.PollInterval = time.Duration(n) * time.Second
+	}
+	if n, err := strconv.Atoi(strings.TrimSpace(os.Getenv("ACCOUNT_EVENT_TIMEOUT_SEC"))); err == nil && n > 0 {
+		w.Client.Timeout = time.Duration(n) * time.Second
+	}
+	return w
+}
+
+// Run polls for due deliveries until ctx is cancelled
+func (w *Worker) Run(ctx context.Context) {
+	if w == nil || w.DB == nil {
+		return
+	}
+	log.Printf("[ACCOUNT_EVENTS] Worker started (poll=%s, max_attempts=%d)", w.PollInterval, w.MaxAttempts)
+	t := time.NewTicker(w.PollInterval)
+	defer t.Stop()
+	for {
+		if n, err := w.processDue(ctx); err != nil {
+			log.Printf("[ACCOUNT_EVENTS] worker: %v", err)
+		} else if n > 0 {
+			log.Printf("[ACCOUNT_EVENTS] worker: processed %d deliveries", n)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+	}
+}
+
+type dueDelivery struct {
+	id       string
+	endpoint string
+	event    string
+	payload  []byte
+	attempts int
+	created  time.Time
+}
+
+// processDue claims a batch of due deliveries and attempts each once
+func (w *Worker) processDue(ctx context.Context) (int, error) {
+	qctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	// Claim rows by pushing next_attempt_at forward so concurrent instances skip them
+	rows, err := w.DB.Query(qctx, `
+		UPDATE app_account_event_deliveries
+		SET next_attempt_at = now() + interval '5 minutes'
+		WHERE id IN (
+			SELECT id FROM app_account_event_deliveries
+			WHERE status = 'pending' AND next_attempt_at <= now()
+			ORDER BY next_attempt_at
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING id::text, endpoint, event, payload::text, attempts, created_at`, batchSize)
+	if err != nil {
+		return 0, fmt.Errorf("claim deliveries: %w", err)
+	}
+	due, err := pgx.CollectRows(rows, func(r pgx.CollectableRow) (dueDelivery, error) {
+		var d dueDelivery
+		var payload string
+		err := r.Scan(&d.id, &d.endpoint, &d.event, &payload, &d.attempts, &d.created)
+		d.payload = []byte(payload)
+		return d, err
+	})
+	if err != nil {
+		return 0, fmt.Errorf("scan deliveries: %w", err)
+	}
+
+	for _, d := range due {
+		w.deliver(ctx, d)
+	}
+	return len(due), nil
+}
+
+func (w *Worker) deliver(ctx context.Context, d dueDelivery) {
+	var data map[string]any
+	_ = json.Unmarshal(d.payload, &data)
+	body, _ := json.Marshal(Envelope{ID: d.id, Event: d.event, OccurredAt: d.created, Data: data})
+
+	code, respBody, sendErr := w.send(ctx, d, body)
+	attempts := d.attempts + 1
+
+	uctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if sendErr == nil && code >= 200 && code < 300 {
+		_, _ = w.DB.Exec(uctx, `UPDATE app_account_event_deliveries
+			SET status='delivered', attempts=$2, response_code=$3, last_error=NULL, delivered_at=now(), updated_at=now()
+			WHERE id::text=$1`, d.id, attempts, code)
+		return
+	}
+
+	errMsg := ""
+	if sendErr != nil {
+		errMsg = sendErr.Error()
+	} else {
+		errMsg = fmt.Sprintf("unexpected status %d: %s", code, respBody)
+	}
+	var codePtr *int
+	if code != 0 {
+		codePtr = &code
+	}
+	if attempts >= w.MaxAttempts {
+		log.Printf("[ACCOUNT_EVENTS] %s %s to %s failed permanently after %d attempts: %s", d.event, d.id, d.endpoint, attempts, errMsg)
+		_, _ = w.DB.Exec(uctx, `UPDATE app_account_event_deliveries
+			SET status='failed', attempts=$2, response_code=$3, last_error=$4, updated_at=now()
+			WHERE id::text=$1`, d.id, attempts, codePtr, errMsg)
+		return
+	}
+	_, _ = w.DB.Exec(uctx, `UPDATE app_account_event_deliveries
+		SET attempts=$2, response_code=$3, last_error=$4, next_attempt_at=now() + ($5::int * interval '1 second'), updated_at=now()
+		WHERE id::text=$1`, d.id, attempts, codePtr, errMsg, int(backoff(attempts).Seconds()))
+}
+
+func (w *Worker) send(ctx context.Context, d dueDelivery, body []byte) (int, string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, d.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return 0, "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "expotoworld-auth-events/1")
+	req.Header.Set("X-Auth-Event", d.event)
+	req.Header.Set("X-Auth-Delivery", d.id)
+	req.Header.Set(SignatureHeader, Sign(w.Secret, time.Now(), body))
+
+	resp, err := w.Client.Do(req)
+	if err != nil {
+		return 0, "", err
+	}
+	defer resp.Body.Close()
+	b, _ := io.ReadAll(io.LimitReader(resp.Body, maxResponseLogBytes))
+	return resp.StatusCode, string(b), nil
+}
+
+// backoff returns the delay before the next attempt: 30s, 1m, 2m, ... capped at 1h
+func backoff(attempts int) time.Duration {
+	d := 30 * time.Second
+	for i := 1; i < attempts; i++ {
+		d *= 2
+		if d >= time.Hour {
+			return time.Hour
+		}
+	}
+	return d
+}
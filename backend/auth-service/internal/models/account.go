@@ -0,0 +1,33 @@
+package models
+
+// DeleteAccountRequest confirms an account deletion with a code sent to the account's email through
+// /send-verification, or to its phone through /send-phone-verification when channel is "phone"
+// (the default for accounts without an email)
+type DeleteAccountRequest struct {
+	Code    string `json:"code" binding:"required,len=6"`
+	Channel string `json:"channel,omitempty" binding:"omitempty,oneof=email phone"`
+}
+
+// AddIdentifierRequest adds an email or a phone number to the signed-in account. The code was sent to
+// the new identifier through /send-verification or /send-phone-verification. If another account signs
+// in with it, merge must be true to fold that account into this one.
+type AddIdentifierRequest struct {
+	Email  string `json:"email,omitempty" binding:"omitempty,email"`
+	Phone  string `json:"phone,omitempty"`
+	Region string `json:"region,omitempty"`
+	Code   string `json:"code" binding:"required,len=6"`
+	Merge  bool   `json:"merge,omitempty"`
+}
+
+// SetLanguageRequest sets the language (a BCP 47 tag such as "zh-CN") of the emails sent to the
+// signed-in account; empty clears it so the app's language is used
+type SetLanguageRequest struct {
+	Language string `json:"language" binding:"max=35"`
+}
+
+// ImpersonateRequest asks for a token acting as another user; the reason is kept in the audit log
+type ImpersonateRequest struct {
+	UserID     string `json:"user_id" binding:"required"`
+	Reason     string `json:"reason" binding:"required,min=5,max=500"`
+	TTLMinutes int    `json:"ttl_minutes,omitempty" binding:"omitempty,min=1"`
+}
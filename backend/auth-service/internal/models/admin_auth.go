@@ -27,6 +27,8 @@ type AdminRateLimit struct {
 // SendVerificationRequest represents the request to send a verification code
 type SendVerificationRequest struct {
 	Email string `json:"email" binding:"required,email"`
+	// Language of the email when the account has no preferred language (defaults to Accept-Language)
+	Language string `json:"language,omitempty"`
 }
 
 // SendVerificationResponse represents the response after sending verification code
@@ -65,3 +67,21 @@ type EmailVerificationData struct {
 	Timestamp    time.Time
 	ExpiresInMin int
 }
+
+// MagicLinkEmailData represents data for the login link email
+type MagicLinkEmailData struct {
+	Email        string
+	Link         string
+	IPAddress    string
+	UserAgent    string
+	ExpiresInMin int
+}
+
+// LockoutNoticeData represents data for the email telling a user their sign-in was locked
+type LockoutNoticeData struct {
+	Email       string
+	Failures    int
+	LockedUntil time.Time
+	IPAddress   string
+	UserAgent   string
+}
@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+// AdminScope restricts an Admin to the stores of some regions and/or individual stores.
+// Admins without a scope manage everything. It is issued in the JWT as the admin_scope claim.
+type AdminScope struct {
+	UserID    string    `json:"user_id"`
+	Email     string    `json:"email,omitempty"`
+	RegionIDs []int     `json:"region_ids"`
+	StoreIDs  []int     `json:"store_ids"`
+	GrantedBy string    `json:"granted_by,omitempty"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Claim is the admin_scope JWT claim
+func (s *AdminScope) Claim() map[string][]int {
+	return map[string][]int{"region_ids": s.RegionIDs, "store_ids": s.StoreIDs}
+}
+
+// SetAdminScopeRequest grants or replaces an admin's scope; at least one id is required
+type SetAdminScopeRequest struct {
+	RegionIDs []int `json:"region_ids"`
+	StoreIDs  []int `json:"store_ids"`
+}
@@ -0,0 +1,50 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Auth event types recorded in auth_audit_log
+const (
+	AuthEventCodeSent         = "code_sent"
+	AuthEventVerifySuccess    = "verification_success"
+	AuthEventVerifyFailure    = "verification_failure"
+	AuthEventLogin            = "login"
+	AuthEventAdminLogin       = "admin_login"
+	AuthEventSecondFactorFail = "second_factor_failure"
+	AuthEventTokenRefresh     = "token_refresh"
+	AuthEventRefreshFailure   = "token_refresh_failure"
+	AuthEventRevocation       = "session_revocation"
+	AuthEventLockout          = "subject_locked"
+	AuthEventCaptchaFailure   = "captcha_failure"
+	AuthEventImpersonation    = "impersonation_started"
+	AuthEventLoginStepUp      = "login_step_up_required"
+)
+
+// AuthEvent is one entry of the auth audit log. Subject is the email or phone number the event was
+// about, also when no account matched it.
+type AuthEvent struct {
+	ID         int64           `json:"event_id"`
+	OccurredAt time.Time       `json:"occurred_at"`
+	EventType  string          `json:"event_type"`
+	UserID     *string         `json:"user_id,omitempty"`
+	Subject    *string         `json:"subject,omitempty"`
+	Channel    *string         `json:"channel,omitempty"`
+	ClientIP   *string         `json:"client_ip,omitempty"`
+	UserAgent  *string         `json:"user_agent,omitempty"`
+	ActorID    *string         `json:"actor_user_id,omitempty"`
+	Details    json.RawMessage `json:"details,omitempty"`
+}
+
+// AuthEventFilter narrows the auth event listing; zero values mean no filter
+type AuthEventFilter struct {
+	UserID    string
+	Subject   string
+	ClientIP  string
+	EventType []string
+	From      *time.Time
+	To        *time.Time
+	BeforeID  int64
+	Limit     int
+}
@@ -0,0 +1,52 @@
+package models
+
+import "time"
+
+// Device is a signed-in app installation with its push token, bound to the refresh-token session
+// it registered with
+type Device struct {
+	ID         string    `json:"id"`
+	UserID     string    `json:"user_id"`
+	SessionID  string    `json:"session_id,omitempty"`
+	Provider   string    `json:"provider"`
+	Platform   string    `json:"platform"`
+	PushToken  string    `json:"-"`
+	Name       string    `json:"name"`
+	AppVersion string    `json:"app_version,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+	// SessionActive is false once the session was revoked or expired (the device is signed out)
+	SessionActive bool       `json:"session_active"`
+	LastActiveAt  *time.Time `json:"last_active_at,omitempty"`
+	IPAddress     string     `json:"ip_address,omitempty"`
+}
+
+// RegisterDeviceRequest registers the app's push token. RefreshToken identifies the session the
+// device belongs to; signing that session out stops its pushes.
+type RegisterDeviceRequest struct {
+	PushToken    string `json:"push_token" binding:"required,max=4096"`
+	Provider     string `json:"provider" binding:"required,oneof=apns fcm"`
+	Platform     string `json:"platform" binding:"required,oneof=ios android web"`
+	Name         string `json:"name" binding:"max=100"`
+	AppVersion   string `json:"app_version" binding:"max=50"`
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+// RenameDeviceRequest sets the name a device is shown with
+type RenameDeviceRequest struct {
+	Name string `json:"name" binding:"required,max=100"`
+}
+
+// PushTarget is a push token other services may send a notification to
+type PushTarget struct {
+	UserID    string `json:"user_id"`
+	DeviceID  string `json:"device_id"`
+	Provider  string `json:"provider"`
+	Platform  string `json:"platform"`
+	PushToken string `json:"push_token"`
+}
+
+// InvalidatePushTokensRequest reports tokens the push provider rejected as unregistered
+type InvalidatePushTokensRequest struct {
+	PushTokens []string `json:"push_tokens" binding:"required,min=1,max=500"`
+}
@@ -0,0 +1,56 @@
+package models
+
+import "time"
+
+// OIDCIdentity is the account at a sign-in provider asserted by a verified ID token
+type OIDCIdentity struct {
+	Provider      string
+	Subject       string
+	Email         string // lower-cased; Apple may omit it after the first sign-in
+	EmailVerified bool
+}
+
+// OAuthExchangeRequest trades a provider ID token from the mobile sign-in SDK for our tokens.
+// Apple only shares the user's name with the app on the first sign-in, so the app forwards it.
+type OAuthExchangeRequest struct {
+	IDToken   string  `json:"id_token" binding:"required"`
+	Nonce     string  `json:"nonce,omitempty"`
+	FirstName *string `json:"first_name,omitempty"`
+	LastName  *string `json:"last_name,omitempty"`
+}
+
+// WeChat apps a login code can come from
+const (
+	WeChatAppMiniProgram = "miniprogram"
+	WeChatAppMobile      = "app"
+)
+
+// WeChatIdentity is a WeChat user as seen by one of our apps. The openid is per app; the unionid
+// is shared by all apps under our Open Platform account and is only present once one is bound.
+type WeChatIdentity struct {
+	AppID   string
+	OpenID  string
+	UnionID string
+}
+
+// WeChatLoginRequest exchanges a login code: wx.login in the mini program, the OAuth code in the app
+type WeChatLoginRequest struct {
+	Code string `json:"code" binding:"required"`
+	App  string `json:"app" binding:"required,oneof=miniprogram app"`
+}
+
+// WeChatLinkRequest completes a first WeChat login by proving a phone number with a code sent
+// through /send-phone-verification; the WeChat account is linked to that phone's account
+type WeChatLinkRequest struct {
+	LinkToken string `json:"link_token" binding:"required"`
+	Phone     string `json:"phone" binding:"required"`
+	Region    string `json:"region,omitempty"`
+	Code      string `json:"code" binding:"required,len=6"`
+}
+
+// WeChatLinkRequestRecord is a pending WeChat account awaiting phone verification
+type WeChatLinkRequestRecord struct {
+	ID        string
+	Identity  WeChatIdentity
+	ExpiresAt time.Time
+}
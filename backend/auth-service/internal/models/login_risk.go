@@ -0,0 +1,73 @@
+package models
+
+import "time"
+
+// GeoLocation is what the GeoIP provider knows about a client IP
+type GeoLocation struct {
+	Country   string   `json:"country,omitempty"` // ISO 3166-1 alpha-2
+	Region    string   `json:"region,omitempty"`
+	City      string   `json:"city,omitempty"`
+	Latitude  *float64 `json:"latitude,omitempty"`
+	Longitude *float64 `json:"longitude,omitempty"`
+	ASN       int      `json:"asn,omitempty"`
+	ASOrg     string   `json:"as_org,omitempty"`
+	// Anonymous marks proxies, VPNs, Tor exits and hosting ranges
+	Anonymous bool `json:"anonymous,omitempty"`
+}
+
+// LoginHistorySummary is what earlier successful logins of a user say about a new one
+type LoginHistorySummary struct {
+	Logins       int
+	KnownDevice  bool // the user agent signed in before
+	KnownIP      bool
+	KnownNetwork bool // the ASN signed in before
+	KnownCountry bool
+	// LastLocation is the most recent earlier login with coordinates, at LastLocatedAt
+	LastLocation  *GeoLocation
+	LastLocatedAt time.Time
+}
+
+// LoginRisk is the score (0-100) of a login and the reasons adding to it
+type LoginRisk struct {
+	Score   int      `json:"score"`
+	Reasons []string `json:"reasons"`
+}
+
+// LoginRecord is an entry of app_login_history
+type LoginRecord struct {
+	UserID    string
+	Method    string
+	ClientIP  string
+	UserAgent string
+	Location  *GeoLocation
+	Risk      LoginRisk
+}
+
+// LoginStepUp is a login paused by its risk score until the user confirms it with an email code
+type LoginStepUp struct {
+	ID        string
+	UserID    string
+	Email     string
+	Role      string
+	Method    string
+	Extra     map[string]any
+	ExpiresAt time.Time
+	// CodeHash is the bcrypt hash of the emailed code; Attempts counts wrong codes entered
+	CodeHash string
+	Attempts int
+}
+
+// LoginStepUpRequest confirms a paused login with the code emailed to the account
+type LoginStepUpRequest struct {
+	StepUpToken string `json:"step_up_token" binding:"required"`
+	Code        string `json:"code" binding:"required,len=6"`
+}
+
+// NewDeviceLoginData represents data for the email telling a user about a sign-in from a new device or place
+type NewDeviceLoginData struct {
+	Email     string
+	Location  string
+	IPAddress string
+	UserAgent string
+	Time      time.Time
+}
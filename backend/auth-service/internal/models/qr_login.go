@@ -0,0 +1,41 @@
+package models
+
+import "time"
+
+// QR login session states. A session starts pending, becomes scanned once a signed-in mobile app
+// read the code, then approved or denied by that user; the desktop client consumes an approval once.
+const (
+	QRLoginPending  = "pending"
+	QRLoginScanned  = "scanned"
+	QRLoginApproved = "approved"
+	QRLoginDenied   = "denied"
+	QRLoginConsumed = "consumed"
+	QRLoginExpired  = "expired" // reported to clients only, never stored
+)
+
+// QRLoginSession is a desktop login (e.g. the ebook editor) waiting to be approved from the mobile app.
+// Only hashes of the displayed code and of the desktop's poll token are stored.
+type QRLoginSession struct {
+	ID                 string    `json:"session_id"`
+	CodeHash           string    `json:"-"`
+	PollTokenHash      string    `json:"-"`
+	Status             string    `json:"status"`
+	RequiredRole       string    `json:"-"`
+	UserID             *string   `json:"-"`
+	RequesterIP        string    `json:"requester_ip,omitempty"`
+	RequesterUserAgent string    `json:"requester_user_agent,omitempty"`
+	CreatedAt          time.Time `json:"created_at"`
+	ExpiresAt          time.Time `json:"expires_at"`
+}
+
+// QRLoginPollRequest is sent by the desktop client waiting for approval; Status is the last status it saw
+type QRLoginPollRequest struct {
+	SessionID string `json:"session_id" binding:"required"`
+	PollToken string `json:"poll_token" binding:"required"`
+	Status    string `json:"status"`
+}
+
+// QRLoginCodeRequest identifies a session by the code the mobile app scanned
+type QRLoginCodeRequest struct {
+	Code string `json:"code" binding:"required"`
+}
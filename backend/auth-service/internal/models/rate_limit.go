@@ -0,0 +1,39 @@
+package models
+
+import "time"
+
+// Rate limit risk tiers for verification requests
+const (
+	// RateLimitTierNew applies to subjects without an account (highest risk)
+	RateLimitTierNew = "new"
+	// RateLimitTierKnown applies to existing accounts requesting from an IP they never verified from
+	RateLimitTierKnown = "known"
+	// RateLimitTierTrusted applies to accounts that previously verified from the same IP
+	RateLimitTierTrusted = "trusted"
+	// RateLimitTierQRLogin applies to QR login sessions started from an IP; they carry no subject to classify
+	RateLimitTierQRLogin = "qr_login"
+)
+
+// Rate limit scopes: what a tier's counter is keyed by
+const (
+	// RateLimitScopeIP counts all requests from the IP (shared by everyone behind it)
+	RateLimitScopeIP = "ip"
+	// RateLimitScopeIPSubject counts requests per IP and email/phone, so one user cannot exhaust a shared IP
+	RateLimitScopeIPSubject = "ip_subject"
+)
+
+// RateLimitTier is a tier's limit configuration, stored in app_rate_limit_tiers
+type RateLimitTier struct {
+	Tier          string    `json:"tier" db:"tier"`
+	MaxRequests   int       `json:"max_requests" db:"max_requests"`
+	WindowMinutes int       `json:"window_minutes" db:"window_minutes"`
+	Scope         string    `json:"scope" db:"scope"`
+	UpdatedAt     time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// RateLimitTierMetrics counts decisions taken for a tier since process start
+type RateLimitTierMetrics struct {
+	Tier    string `json:"tier"`
+	Allowed int64  `json:"allowed"`
+	Blocked int64  `json:"blocked"`
+}
@@ -0,0 +1,46 @@
+package models
+
+import "time"
+
+// Permission is an action a role can grant
+type Permission struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+// Role bundles permissions. Built-in roles are the primary roles stored on app_users.role; custom
+// roles are only assigned on top of a primary role.
+type Role struct {
+	Name          string    `json:"name"`
+	Description   string    `json:"description"`
+	BuiltIn       bool      `json:"built_in"`
+	Permissions   []string  `json:"permissions"`
+	AssignedUsers int64     `json:"assigned_users"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// UserRoleAssignment is a role assigned to a user in addition to their primary role
+type UserRoleAssignment struct {
+	Role      string    `json:"role"`
+	GrantedBy string    `json:"granted_by,omitempty"`
+	GrantedAt time.Time `json:"granted_at"`
+}
+
+// CreateRoleRequest creates a custom role
+type CreateRoleRequest struct {
+	Name        string   `json:"name" binding:"required,min=2,max=64"`
+	Description string   `json:"description"`
+	Permissions []string `json:"permissions"`
+}
+
+// UpdateRoleRequest changes a role; omitted fields are left as they are
+type UpdateRoleRequest struct {
+	Description *string   `json:"description"`
+	Permissions *[]string `json:"permissions"`
+}
+
+// AssignRoleRequest assigns a role to a user
+type AssignRoleRequest struct {
+	Role string `json:"role" binding:"required"`
+}
@@ -0,0 +1,60 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// SecurityKey is a hardware key (WebAuthn credential) an Admin registered as second login factor.
+// These are roaming security keys bound to the admin panel, separate from any customer sign-in.
+type SecurityKey struct {
+	ID           string     `json:"id"`
+	UserID       string     `json:"user_id"`
+	Name         string     `json:"name"`
+	CredentialID []byte     `json:"-"`
+	PublicKey    []byte     `json:"-"` // COSE_Key
+	SignCount    uint32     `json:"-"`
+	Transports   []string   `json:"transports"`
+	CreatedAt    time.Time  `json:"created_at"`
+	LastUsedAt   *time.Time `json:"last_used_at,omitempty"`
+}
+
+// MFAChallenge is a pending WebAuthn ceremony. Login challenges are created once the email code was
+// verified and are redeemed for tokens; register challenges belong to a signed-in admin adding a key.
+type MFAChallenge struct {
+	ID        string
+	UserID    string
+	Email     string
+	Role      string
+	Purpose   string // "login" or "register"
+	Challenge []byte
+	Attempts  int
+	ExpiresAt time.Time
+}
+
+// MFA challenge purposes
+const (
+	MFAPurposeLogin    = "login"
+	MFAPurposeRegister = "register"
+)
+
+// SecondFactorRequest completes an admin login with a security key assertion or a recovery code
+type SecondFactorRequest struct {
+	MFAToken     string          `json:"mfa_token" binding:"required"`
+	Credential   json.RawMessage `json:"credential,omitempty"`
+	RecoveryCode string          `json:"recovery_code,omitempty"`
+}
+
+// MFATokenRequest identifies a pending login
+type MFATokenRequest struct {
+	MFAToken string `json:"mfa_token" binding:"required"`
+}
+
+// FinishSecurityKeyRequest finishes registering a key: MFAToken during login-time enrollment,
+// ChallengeID when a signed-in Admin adds a key
+type FinishSecurityKeyRequest struct {
+	ChallengeID string          `json:"challenge_id"`
+	MFAToken    string          `json:"mfa_token"`
+	Name        string          `json:"name" binding:"max=100"`
+	Credential  json.RawMessage `json:"credential" binding:"required"`
+}
@@ -0,0 +1,36 @@
+package models
+
+import "time"
+
+// ServiceClient is an internal service identity that obtains access tokens with the
+// client-credentials grant and may introspect tokens
+type ServiceClient struct {
+	ClientID    string     `json:"client_id"`
+	Name        string     `json:"name"`
+	Permissions []string   `json:"permissions"`
+	CreatedBy   string     `json:"created_by,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	LastUsedAt  *time.Time `json:"last_used_at,omitempty"`
+	RevokedAt   *time.Time `json:"revoked_at,omitempty"`
+}
+
+// CreateServiceClientRequest registers a service client with the permissions its tokens carry
+type CreateServiceClientRequest struct {
+	Name        string   `json:"name" binding:"required,min=2,max=100"`
+	Permissions []string `json:"permissions"`
+}
+
+// TokenRequest is an OAuth 2.0 token request (form or JSON); only grant_type=client_credentials is
+// supported. The client may authenticate with HTTP Basic instead of client_id/client_secret.
+type TokenRequest struct {
+	GrantType    string `form:"grant_type" json:"grant_type"`
+	ClientID     string `form:"client_id" json:"client_id"`
+	ClientSecret string `form:"client_secret" json:"client_secret"`
+}
+
+// IntrospectRequest is an RFC 7662 introspection request (form or JSON)
+type IntrospectRequest struct {
+	Token        string `form:"token" json:"token"`
+	ClientID     string `form:"client_id" json:"client_id"`
+	ClientSecret string `form:"client_secret" json:"client_secret"`
+}
@@ -0,0 +1,63 @@
+package models
+
+import "time"
+
+// Session is a refresh token as seen by admins (the token hash is never exposed)
+type Session struct {
+	ID           string    `json:"id" db:"id"`
+	UserID       string    `json:"user_id" db:"user_id"`
+	Email        string    `json:"email,omitempty" db:"email"`
+	IPAddress    string    `json:"ip_address,omitempty" db:"ip_address"`
+	UserAgent    string    `json:"user_agent,omitempty" db:"user_agent"`
+	IssuedAt     time.Time `json:"issued_at" db:"issued_at"`
+	ExpiresAt    time.Time `json:"expires_at" db:"expires_at"`
+	LastActiveAt time.Time `json:"last_active_at" db:"last_active_at"`
+	Revoked      bool      `json:"revoked" db:"revoked"`
+	Active       bool      `json:"active"`
+	DeviceName   string    `json:"device_name,omitempty"`
+}
+
+// SessionFilter selects sessions for admin search and bulk revocation.
+// IP accepts a single address or a CIDR range; UserAgent is a case-insensitive substring.
+type SessionFilter struct {
+	UserID       string     `form:"user_id" json:"user_id"`
+	Email        string     `form:"email" json:"email"`
+	IP           string     `form:"ip" json:"ip"`
+	UserAgent    string     `form:"user_agent" json:"user_agent"`
+	CreatedFrom  *time.Time `form:"created_from" time_format:"2006-01-02T15:04:05Z07:00" json:"created_from"`
+	CreatedTo    *time.Time `form:"created_to" time_format:"2006-01-02T15:04:05Z07:00" json:"created_to"`
+	IncludeEnded bool       `form:"include_ended" json:"include_ended"` // also match revoked/expired sessions
+}
+
+// IsEmpty reports whether the filter has no criteria (would match every session)
+func (f SessionFilter) IsEmpty() bool {
+	return f.UserID == "" && f.Email == "" && f.IP == "" && f.UserAgent == "" && f.CreatedFrom == nil && f.CreatedTo == nil
+}
+
+// SessionListResponse is a page of admin session search results
+type SessionListResponse struct {
+	Sessions []Session `json:"sessions"`
+	Total    int       `json:"total"`
+	Page     int       `json:"page"`
+	Limit    int       `json:"limit"`
+}
+
+// RevokeSessionsRequest revokes either the listed sessions or every active session matching Filter
+type RevokeSessionsRequest struct {
+	SessionIDs []string       `json:"session_ids"`
+	Filter     *SessionFilter `json:"filter"`
+}
+
+// RevokeSessionsResponse reports how many sessions were revoked
+type RevokeSessionsResponse struct {
+	Revoked int64 `json:"revoked"`
+}
+
+// UserSessionCount summarizes a user's sessions
+type UserSessionCount struct {
+	UserID         string     `json:"user_id" db:"user_id"`
+	Email          string     `json:"email,omitempty" db:"email"`
+	ActiveSessions int        `json:"active_sessions" db:"active_sessions"`
+	TotalSessions  int        `json:"total_sessions" db:"total_sessions"`
+	LastActiveAt   *time.Time `json:"last_active_at,omitempty" db:"last_active_at"`
+}
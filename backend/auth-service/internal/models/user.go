@@ -74,12 +74,18 @@ type UserRateLimit struct {
 // SendUserVerificationRequest represents the request to send a verification code for users
 type SendUserVerificationRequest struct {
 	Email string `json:"email" binding:"required,email"`
+	// Language of the email when the account has no preferred language (defaults to Accept-Language)
+	Language string `json:"language,omitempty"`
+	// CaptchaToken is required when the request looks risky (also accepted as X-Captcha-Token)
+	CaptchaToken string `json:"captcha_token,omitempty"`
 }
 
 // SendUserVerificationResponse represents the response after sending verification code for users
 type SendUserVerificationResponse struct {
 	Message   string    `json:"message"`
 	ExpiresAt time.Time `json:"expires_at"`
+	Phone     string    `json:"phone,omitempty"`   // normalized E.164 number the code was sent to
+	Channel   string    `json:"channel,omitempty"` // sms or whatsapp, for phone codes
 }
 
 // VerifyUserCodeRequest represents the request to verify a code for users
@@ -88,6 +94,18 @@ type VerifyUserCodeRequest struct {
 	Code  string `json:"code" binding:"required,len=6"`
 }
 
+// SendMagicLinkRequest asks for a login link instead of a code; the same strict mode headers apply
+type SendMagicLinkRequest struct {
+	Email        string `json:"email" binding:"required,email"`
+	Language     string `json:"language,omitempty"`
+	CaptchaToken string `json:"captcha_token,omitempty"`
+}
+
+// VerifyMagicLinkRequest redeems the token from a login link opened in the app
+type VerifyMagicLinkRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
 // VerifyUserCodeResponse represents the response after successful user verification
 type VerifyUserCodeResponse struct {
 	Token            string    `json:"token"`
@@ -111,11 +129,17 @@ type UserPhoneVerificationCode struct {
 
 // SendPhoneVerificationRequest represents the request to send a phone verification code
 type SendPhoneVerificationRequest struct {
-	Phone string `json:"phone" binding:"required"`
+	Phone  string `json:"phone" binding:"required"`
+	Region string `json:"region,omitempty"` // ISO country for numbers entered without +country code
+	// Channel picks the delivery ("sms" or "whatsapp"); empty uses the country preference
+	Channel string `json:"channel,omitempty" binding:"omitempty,oneof=sms whatsapp"`
+	// CaptchaToken is required when the request looks risky (also accepted as X-Captcha-Token)
+	CaptchaToken string `json:"captcha_token,omitempty"`
 }
 
 // VerifyPhoneCodeRequest represents the request to verify a phone code
 type VerifyPhoneCodeRequest struct {
-	Phone string `json:"phone" binding:"required"`
-	Code  string `json:"code" binding:"required,len=6"`
+	Phone  string `json:"phone" binding:"required"`
+	Region string `json:"region,omitempty"`
+	Code   string `json:"code" binding:"required,len=6"`
 }
@@ -0,0 +1,147 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrCaptchaFailed is returned when the provider rejects a CAPTCHA token
+var ErrCaptchaFailed = errors.New("captcha verification failed")
+
+// captchaVerifyURLs are the siteverify endpoints of the supported providers
+var captchaVerifyURLs = map[string]string{
+	"turnstile": "https://challenges.cloudflare.com/turnstile/v0/siteverify",
+	"hcaptcha":  "https://api.hcaptcha.com/siteverify",
+}
+
+// disposableEmailDomains are throwaway inbox providers seen in signup abuse; CAPTCHA_DISPOSABLE_DOMAINS adds more
+var disposableEmailDomains = []string{
+	"mailinator.com", "guerrillamail.com", "guerrillamail.net", "sharklasers.com", "10minutemail.com",
+	"temp-mail.org", "tempmail.com", "yopmail.com", "trashmail.com", "getnada.com", "dispostable.com",
+	"maildrop.cc", "throwawaymail.com", "fakeinbox.com", "mohmal.com", "emailondeck.com",
+}
+
+// CaptchaService decides when a verification code request looks risky and checks the Turnstile or
+// hCaptcha token the client then has to send
+type CaptchaService struct {
+	Provider string
+	SiteKey  string
+	secret   string
+	endpoint string
+	client   *http.Client
+
+	// Always requires a CAPTCHA on every request instead of only risky ones
+	Always bool
+	// VelocityThreshold requires a CAPTCHA once this many codes were requested in the rate limit window
+	VelocityThreshold int
+	disposable        map[string]bool
+	riskyPrefixes     []string
+}
+
+// NewCaptchaServiceFromEnv configures CAPTCHA_PROVIDER ("turnstile" or "hcaptcha"), CAPTCHA_SECRET
+// and CAPTCHA_SITE_KEY, with the risk rules CAPTCHA_MODE ("risk" or "always"),
+// CAPTCHA_VELOCITY_THRESHOLD, CAPTCHA_DISPOSABLE_DOMAINS and CAPTCHA_RISKY_PHONE_PREFIXES (comma-separated
+// E.164 prefixes, e.g. premium-rate ranges). It returns nil when no provider is configured.
+func NewCaptchaServiceFromEnv() *CaptchaService {
+	provider := strings.ToLower(strings.TrimSpace(os.Getenv("CAPTCHA_PROVIDER")))
+	secret := strings.TrimSpace(os.Getenv("CAPTCHA_SECRET"))
+	endpoint, ok := captchaVerifyURLs[provider]
+	if !ok || secret == "" {
+		return nil
+	}
+	s := &CaptchaService{
+		Provider:          provider,
+		SiteKey:           strings.TrimSpace(os.Getenv("CAPTCHA_SITE_KEY")),
+		secret:            secret,
+		endpoint:          endpoint,
+		client:            &http.Client{Timeout: 5 * time.Second},
+		Always:            strings.EqualFold(strings.TrimSpace(os.Getenv("CAPTCHA_MODE")), "always"),
+		VelocityThreshold: 3,
+		disposable:        map[string]bool{},
+	}
+	if n, err := strconv.Atoi(strings.TrimSpace(os.Getenv("CAPTCHA_VELOCITY_THRESHOLD"))); err == nil && n > 0 {
+		s.VelocityThreshold = n
+	}
+	for _, d := range append(disposableEmailDomains, strings.Split(os.Getenv("CAPTCHA_DISPOSABLE_DOMAINS"), ",")...) {
+		if d = strings.ToLower(strings.TrimSpace(d)); d != "" {
+			s.disposable[d] = true
+		}
+	}
+	for _, p := range strings.Split(os.Getenv("CAPTCHA_RISKY_PHONE_PREFIXES"), ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			s.riskyPrefixes = append(s.riskyPrefixes, p)
+		}
+	}
+	return s
+}
+
+// Risk returns why a code request for subject (an email address or E.164 number) needs a CAPTCHA,
+// or nil when it doesn't. recent is how many codes were already requested in the rate limit window.
+func (s *CaptchaService) Risk(channel, subject string, recent int) []string {
+	if s == nil {
+		return nil
+	}
+	var reasons []string
+	if s.Always {
+		reasons = append(reasons, "always")
+	}
+	if recent >= s.VelocityThreshold {
+		reasons = append(reasons, "velocity")
+	}
+	switch channel {
+	case "email":
+		if at := strings.LastIndex(subject, "@"); at >= 0 && s.disposable[strings.ToLower(subject[at+1:])] {
+			reasons = append(reasons, "disposable_email")
+		}
+	case "phone":
+		for _, p := range s.riskyPrefixes {
+			if strings.HasPrefix(subject, p) {
+				reasons = append(reasons, "risky_phone_prefix")
+				break
+			}
+		}
+	}
+	return reasons
+}
+
+type captchaVerifyResponse struct {
+	Success    bool     `json:"success"`
+	ErrorCodes []string `json:"error-codes"`
+}
+
+// Verify checks a client token with the provider; ErrCaptchaFailed when it is rejected
+func (s *CaptchaService) Verify(ctx context.Context, token, remoteIP string) error {
+	form := url.Values{"secret": {s.secret}, "response": {token}}
+	if remoteIP != "" {
+		form.Set("remoteip", remoteIP)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("captcha verification request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("captcha verification returned status %d", resp.StatusCode)
+	}
+	var out captchaVerifyResponse
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return fmt.Errorf("invalid captcha verification response: %w", err)
+	}
+	if !out.Success {
+		return fmt.Errorf("%w: %s", ErrCaptchaFailed, strings.Join(out.ErrorCodes, ","))
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestCaptchaRisk(t *testing.T) {
+	t.Setenv("CAPTCHA_PROVIDER", "turnstile")
+	t.Setenv("CAPTCHA_SECRET", "s3cret")
+	t.Setenv("CAPTCHA_DISPOSABLE_DOMAINS", "junk.example")
+	t.Setenv("CAPTCHA_RISKY_PHONE_PREFIXES", "+882, +6760")
+	s := NewCaptchaServiceFromEnv()
+	if s == nil {
+		t.Fatal("service not configured")
+	}
+
+	cases := []struct {
+		channel, subject string
+		recent           int
+		want             []string
+	}{
+		{"email", "ana@example.com", 0, nil},
+		{"email", "ana@Mailinator.com", 0, []string{"disposable_email"}},
+		{"email", "ana@junk.example", 5, []string{"velocity", "disposable_email"}},
+		{"phone", "+12065550100", 2, nil},
+		{"phone", "+67601234567", 0, []string{"risky_phone_prefix"}},
+	}
+	for _, tc := range cases {
+		if got := s.Risk(tc.channel, tc.subject, tc.recent); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("Risk(%s, %s, %d) = %v, want %v", tc.channel, tc.subject, tc.recent, got, tc.want)
+		}
+	}
+
+	var nilService *CaptchaService
+	if got := nilService.Risk("email", "ana@mailinator.com", 10); got != nil {
+		t.Errorf("unconfigured service reported risk %v", got)
+	}
+}
+
+func TestCaptchaVerify(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil || r.PostForm.Get("secret") != "s3cret" || r.PostForm.Get("remoteip") != "203.0.113.7" {
+			t.Errorf("unexpected form %v", r.PostForm)
+		}
+		if r.PostForm.Get("response") == "good" {
+			w.Write([]byte(`{"success":true}`))
+			return
+		}
+		w.Write([]byte(`{"success":false,"error-codes":["invalid-input-response"]}`))
+	}))
+	defer srv.Close()
+	s := &CaptchaService{secret: "s3cret", endpoint: srv.URL, client: srv.Client()}
+
+	if err := s.Verify(context.Background(), "good", "203.0.113.7"); err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+	if err := s.Verify(context.Background(), "bad", "203.0.113.7"); !errors.Is(err, ErrCaptchaFailed) {
+		t.Fatalf("expected ErrCaptchaFailed, got %v", err)
+	}
+}
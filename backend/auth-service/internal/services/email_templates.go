@@ -0,0 +1,183 @@
+package services
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	"io/fs"
+	"os"
+	"strings"
+	texttemplate "text/template"
+	"time"
+)
+
+// Email template names; each locale directory has <name>.html and <name>.txt
+const (
+	EmailTemplateUserCode  = "user_code"
+	EmailTemplateAdminCode = "admin_code"
+	EmailTemplateMagicLink = "magic_link"
+	EmailTemplateLockout   = "lockout_notice"
+	EmailTemplateNewDevice = "new_device_login"
+)
+
+var emailTemplateNames = []string{EmailTemplateUserCode, EmailTemplateAdminCode, EmailTemplateMagicLink, EmailTemplateLockout, EmailTemplateNewDevice}
+
+//go:embed email_templates
+var embeddedEmailTemplates embed.FS
+
+// EmailBranding is the sender identity and the brand details shown in every email
+type EmailBranding struct {
+	Name      string
+	FromName  string
+	FromEmail string
+	ReplyTo   string
+	Support   string
+	Address   string
+}
+
+// EmailBrandingFromEnv reads EMAIL_BRAND_NAME, EMAIL_FROM_NAME, SES_FROM_EMAIL, EMAIL_REPLY_TO,
+// EMAIL_SUPPORT_ADDRESS and EMAIL_BRAND_ADDRESS
+func EmailBrandingFromEnv() EmailBranding {
+	name := envOr("EMAIL_BRAND_NAME", "EXPO to World")
+	return EmailBranding{
+		Name:      name,
+		FromName:  envOr("EMAIL_FROM_NAME", name),
+		FromEmail: os.Getenv("SES_FROM_EMAIL"),
+		ReplyTo:   envOr("EMAIL_REPLY_TO", "expotobsrl@gmail.com"),
+		Support:   envOr("EMAIL_SUPPORT_ADDRESS", "support@expotoworld.com"),
+		Address:   envOr("EMAIL_BRAND_ADDRESS", "Frankfurt, Germany"),
+	}
+}
+
+// RenderedEmail is a message ready to send
+type RenderedEmail struct {
+	Subject string
+	HTML    string
+	Text    string
+}
+
+// emailView is what the templates see: the brand, the locale and the message's own data
+type emailView struct {
+	Brand EmailBranding
+	Lang  string
+	Year  int
+	Data  any
+}
+
+type emailTemplate struct {
+	html *htmltemplate.Template
+	text *texttemplate.Template
+}
+
+// EmailTemplates holds the parsed templates of every locale. The layout (layout.html, layout.txt)
+// is shared; each locale directory holds common.html/common.txt with the footer and one
+// html/txt pair per template, whose text part also defines the subject.
+type EmailTemplates struct {
+	DefaultLocale string
+	templates     map[string]map[string]*emailTemplate
+}
+
+// NewEmailTemplatesFromEnv loads the templates from EMAIL_TEMPLATE_DIR, or the ones built into the
+// service when it is unset. EMAIL_DEFAULT_LOCALE (default "en") is used when no language matches.
+func NewEmailTemplatesFromEnv() (*EmailTemplates, error) {
+	var fsys fs.FS
+	if dir := strings.TrimSpace(os.Getenv("EMAIL_TEMPLATE_DIR")); dir != "" {
+		fsys = os.DirFS(dir)
+	} else {
+		sub, err := fs.Sub(embeddedEmailTemplates, "email_templates")
+		if err != nil {
+			return nil, err
+		}
+		fsys = sub
+	}
+	return NewEmailTemplates(fsys, envOr("EMAIL_DEFAULT_LOCALE", "en"))
+}
+
+// NewEmailTemplates parses every locale directory in fsys. A locale may leave out templates, which
+// then fall back to the default locale; the default locale must have them all.
+func NewEmailTemplates(fsys fs.FS, defaultLocale string) (*EmailTemplates, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, fmt.Errorf("read email templates: %w", err)
+	}
+	t := &EmailTemplates{DefaultLocale: normalizeLocale(defaultLocale), templates: map[string]map[string]*emailTemplate{}}
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		locale := entry.Name()
+		t.templates[normalizeLocale(locale)] = map[string]*emailTemplate{}
+		for _, name := range emailTemplateNames {
+			if _, err := fs.Stat(fsys, locale+"/"+name+".html"); err != nil {
+				continue
+			}
+			html, err := htmltemplate.ParseFS(fsys, "layout.html", locale+"/common.html", locale+"/"+name+".html")
+			if err != nil {
+				return nil, fmt.Errorf("parse %s/%s.html: %w", locale, name, err)
+			}
+			text, err := texttemplate.ParseFS(fsys, "layout.txt", locale+"/common.txt", locale+"/"+name+".txt")
+			if err != nil {
+				return nil, fmt.Errorf("parse %s/%s.txt: %w", locale, name, err)
+			}
+			t.templates[normalizeLocale(locale)][name] = &emailTemplate{html: html, text: text}
+		}
+	}
+	for _, name := range emailTemplateNames {
+		if t.templates[t.DefaultLocale][name] == nil {
+			return nil, fmt.Errorf("default locale %q has no %s template", t.DefaultLocale, name)
+		}
+	}
+	return t, nil
+}
+
+// Locale returns the first supported locale among candidates, each a language tag ("zh-CN") or an
+// Accept-Language header; the default locale if none matches. Empty candidates are skipped.
+func (t *EmailTemplates) Locale(candidates ...string) string {
+	for _, candidate := range candidates {
+		for _, part := range strings.Split(candidate, ",") {
+			tag := normalizeLocale(strings.SplitN(part, ";", 2)[0])
+			if tag == "" {
+				continue
+			}
+			if t.templates[tag] != nil {
+				return tag
+			}
+			if i := strings.Index(tag, "-"); i > 0 && t.templates[tag[:i]] != nil {
+				return tag[:i]
+			}
+		}
+	}
+	return t.DefaultLocale
+}
+
+// Render renders template name in locale (falling back to the default locale) with data
+func (t *EmailTemplates) Render(name, locale string, brand EmailBranding, data any) (*RenderedEmail, error) {
+	locale = t.Locale(locale)
+	tmpl := t.templates[locale][name]
+	if tmpl == nil {
+		locale = t.DefaultLocale
+		tmpl = t.templates[locale][name]
+	}
+	if tmpl == nil {
+		return nil, fmt.Errorf("unknown email template %q", name)
+	}
+	view := emailView{Brand: brand, Lang: locale, Year: time.Now().Year(), Data: data}
+
+	var subject, text, html bytes.Buffer
+	if err := tmpl.text.ExecuteTemplate(&subject, "subject", view); err != nil {
+		return nil, fmt.Errorf("render %s/%s subject: %w", locale, name, err)
+	}
+	if err := tmpl.text.ExecuteTemplate(&text, "layout.txt", view); err != nil {
+		return nil, fmt.Errorf("render %s/%s.txt: %w", locale, name, err)
+	}
+	if err := tmpl.html.ExecuteTemplate(&html, "layout.html", view); err != nil {
+		return nil, fmt.Errorf("render %s/%s.html: %w", locale, name, err)
+	}
+	return &RenderedEmail{Subject: strings.TrimSpace(subject.String()), HTML: html.String(), Text: text.String()}, nil
+}
+
+// normalizeLocale lowercases a language tag and uses "-" as separator ("zh_CN" -> "zh-cn")
+func normalizeLocale(tag string) string {
+	return strings.ReplaceAll(strings.ToLower(strings.TrimSpace(tag)), "_", "-")
+}
@@ -0,0 +1,74 @@
+package services
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/expotoworld/expotoworld/backend/auth-service/internal/models"
+)
+
+func TestEmailTemplatesRenderEveryLocale(t *testing.T) {
+	templates, err := NewEmailTemplatesFromEnv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	brand := EmailBranding{Name: "EXPO to World", Support: "support@example.com"}
+	code := models.EmailVerificationData{Code: "482913", Email: "a@example.com", IPAddress: "203.0.113.7", UserAgent: "<script>x</script>", ExpiresInMin: 10}
+	link := models.MagicLinkEmailData{Email: "a@example.com", Link: "https://example.com/auth/magic-link?token=abc&x=1", ExpiresInMin: 15}
+	lockout := models.LockoutNoticeData{Email: "a@example.com", Failures: 5, LockedUntil: time.Date(2026, 1, 2, 3, 4, 0, 0, time.UTC)}
+	newDevice := models.NewDeviceLoginData{Email: "a@example.com", Location: "Shanghai, CN", UserAgent: "<script>x</script>", Time: time.Date(2026, 1, 2, 3, 4, 0, 0, time.UTC)}
+
+	for locale := range templates.templates {
+		for name, data := range map[string]any{EmailTemplateUserCode: code, EmailTemplateAdminCode: code, EmailTemplateMagicLink: link, EmailTemplateLockout: lockout, EmailTemplateNewDevice: newDevice} {
+			msg, err := templates.Render(name, locale, brand, data)
+			if err != nil {
+				t.Fatalf("%s/%s: %v", locale, name, err)
+			}
+			if msg.Subject == "" || strings.Contains(msg.Subject, "\n") {
+				t.Errorf("%s/%s: bad subject %q", locale, name, msg.Subject)
+			}
+			if !strings.Contains(msg.HTML, `lang="`+locale+`"`) {
+				t.Errorf("%s/%s: html is not marked as %s", locale, name, locale)
+			}
+			if strings.Contains(msg.HTML, "<script>") {
+				t.Errorf("%s/%s: user agent is not escaped", locale, name)
+			}
+			want, wantHTML := "482913", "482913"
+			switch name {
+			case EmailTemplateMagicLink:
+				want, wantHTML = link.Link, `href="https://example.com/auth/magic-link?token=abc&amp;x=1"`
+			case EmailTemplateLockout:
+				want, wantHTML = "2026-01-02 03:04", "2026-01-02 03:04"
+			case EmailTemplateNewDevice:
+				want, wantHTML = "Shanghai, CN", "Shanghai, CN"
+			}
+			if !strings.Contains(msg.HTML, wantHTML) || !strings.Contains(msg.Text, want) {
+				t.Errorf("%s/%s: missing %q", locale, name, want)
+			}
+		}
+	}
+}
+
+func TestEmailTemplatesLocale(t *testing.T) {
+	templates, err := NewEmailTemplatesFromEnv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		candidates []string
+		want       string
+	}{
+		{nil, "en"},
+		{[]string{"", "zh-CN"}, "zh"},
+		{[]string{"zh_Hans_CN"}, "zh"},
+		{[]string{"", "", "fr-FR,zh-TW;q=0.8,en;q=0.5"}, "zh"},
+		{[]string{"en", "zh"}, "en"},
+		{[]string{"it"}, "en"},
+	}
+	for _, tt := range tests {
+		if got := templates.Locale(tt.candidates...); got != tt.want {
+			t.Errorf("Locale(%q) = %q, want %q", tt.candidates, got, tt.want)
+		}
+	}
+}
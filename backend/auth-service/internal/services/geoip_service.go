@@ -0,0 +1,230 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/expotoworld/expotoworld/backend/auth-service/internal/models"
+)
+
+// geoIPCacheTTL and maxCachedGeoIPs bound the lookup cache; it is cleared rather than evicted when full
+const (
+	geoIPCacheTTL   = time.Hour
+	maxCachedGeoIPs = 10000
+)
+
+// ipAPIFields are the ip-api.com fields Lookup reads
+const ipAPIFields = "status,message,countryCode,regionName,city,lat,lon,as,proxy,hosting"
+
+type cachedGeoIP struct {
+	location  *models.GeoLocation
+	fetchedAt time.Time
+}
+
+// GeoIPService looks up where login IPs are and which network (ASN) they belong to, with the
+// ip-api.com JSON API or the MaxMind GeoIP2 web services
+type GeoIPService struct {
+	Provider  string
+	endpoint  string
+	accountID string
+	key       string
+	client    *http.Client
+
+	mu    sync.Mutex
+	cache map[string]cachedGeoIP
+}
+
+// NewGeoIPServiceFromEnv configures GEOIP_PROVIDER: "ipapi" uses ip-api.com (IPAPI_KEY switches to
+// the pro endpoint; the free one is rate limited and HTTP only), "maxmind" uses MAXMIND_ACCOUNT_ID
+// and MAXMIND_LICENSE_KEY with the MAXMIND_SERVICE web service ("city" by default, "insights" to
+// also detect anonymous IPs; MAXMIND_HOST is "geolite.info" for GeoLite accounts). It returns nil
+// when no provider is configured.
+func NewGeoIPServiceFromEnv() *GeoIPService {
+	s := &GeoIPService{
+		Provider: strings.ToLower(strings.TrimSpace(os.Getenv("GEOIP_PROVIDER"))),
+		client:   &http.Client{Timeout: 3 * time.Second},
+		cache:    map[string]cachedGeoIP{},
+	}
+	switch s.Provider {
+	case "ipapi":
+		s.key = strings.TrimSpace(os.Getenv("IPAPI_KEY"))
+		s.endpoint = "http://ip-api.com/json/"
+		if s.key != "" {
+			s.endpoint = "https://pro.ip-api.com/json/"
+		}
+	case "maxmind":
+		s.accountID = strings.TrimSpace(os.Getenv("MAXMIND_ACCOUNT_ID"))
+		s.key = strings.TrimSpace(os.Getenv("MAXMIND_LICENSE_KEY"))
+		if s.accountID == "" || s.key == "" {
+			return nil
+		}
+		host := envOr("MAXMIND_HOST", "geoip.maxmind.com")
+		s.endpoint = "https://" + host + "/geoip/v2.1/" + envOr("MAXMIND_SERVICE", "city") + "/"
+	default:
+		return nil
+	}
+	return s
+}
+
+// Lookup returns the location of ip; nil without error for private, loopback and malformed addresses
+func (s *GeoIPService) Lookup(ctx context.Context, ip string) (*models.GeoLocation, error) {
+	addr, err := netip.ParseAddr(strings.TrimSpace(ip))
+	if err != nil || !addr.IsGlobalUnicast() || addr.IsPrivate() {
+		return nil, nil
+	}
+	ip = addr.Unmap().String()
+	if loc, ok := s.cached(ip); ok {
+		return loc, nil
+	}
+
+	var loc *models.GeoLocation
+	switch s.Provider {
+	case "ipapi":
+		loc, err = s.lookupIPAPI(ctx, ip)
+	default:
+		loc, err = s.lookupMaxMind(ctx, ip)
+	}
+	if err != nil {
+		return nil, err
+	}
+	s.store(ip, loc)
+	return loc, nil
+}
+
+func (s *GeoIPService) cached(ip string) (*models.GeoLocation, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, ok := s.cache[ip]
+	if !ok || time.Since(e.fetchedAt) >= geoIPCacheTTL {
+		return nil, false
+	}
+	return e.location, true
+}
+
+func (s *GeoIPService) store(ip string, loc *models.GeoLocation) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.cache == nil || len(s.cache) >= maxCachedGeoIPs {
+		s.cache = map[string]cachedGeoIP{}
+	}
+	s.cache[ip] = cachedGeoIP{location: loc, fetchedAt: time.Now()}
+}
+
+func (s *GeoIPService) get(ctx context.Context, endpoint string, out any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return err
+	}
+	if s.accountID != "" {
+		req.SetBasicAuth(s.accountID, s.key)
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("geoip request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("geoip lookup returned status %d", resp.StatusCode)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("invalid geoip response: %w", err)
+	}
+	return nil
+}
+
+type ipAPIResponse struct {
+	Status      string  `json:"status"`
+	Message     string  `json:"message"`
+	CountryCode string  `json:"countryCode"`
+	RegionName  string  `json:"regionName"`
+	City        string  `json:"city"`
+	Lat         float64 `json:"lat"`
+	Lon         float64 `json:"lon"`
+	AS          string  `json:"as"` // "AS15169 Google LLC"
+	Proxy       bool    `json:"proxy"`
+	Hosting     bool    `json:"hosting"`
+}
+
+func (s *GeoIPService) lookupIPAPI(ctx context.Context, ip string) (*models.GeoLocation, error) {
+	q := url.Values{"fields": {ipAPIFields}}
+	if s.key != "" {
+		q.Set("key", s.key)
+	}
+	var out ipAPIResponse
+	if err := s.get(ctx, s.endpoint+url.PathEscape(ip)+"?"+q.Encode(), &out); err != nil {
+		return nil, err
+	}
+	if out.Status != "success" {
+		// "private range", "reserved range" and "invalid query" are not worth retrying
+		if out.Message == "private range" || out.Message == "reserved range" || out.Message == "invalid query" {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("geoip lookup failed: %s", out.Message)
+	}
+	loc := &models.GeoLocation{
+		Country:   out.CountryCode,
+		Region:    out.RegionName,
+		City:      out.City,
+		Latitude:  &out.Lat,
+		Longitude: &out.Lon,
+		Anonymous: out.Proxy || out.Hosting,
+	}
+	if asn, org, ok := strings.Cut(out.AS, " "); ok || asn != "" {
+		loc.ASN, _ = strconv.Atoi(strings.TrimPrefix(asn, "AS"))
+		loc.ASOrg = org
+	}
+	return loc, nil
+}
+
+type maxMindName struct {
+	Names map[string]string `json:"names"`
+}
+
+type maxMindResponse struct {
+	Country struct {
+		ISOCode string `json:"iso_code"`
+	} `json:"country"`
+	City         maxMindName   `json:"city"`
+	Subdivisions []maxMindName `json:"subdivisions"`
+	Location     struct {
+		Latitude  *float64 `json:"latitude"`
+		Longitude *float64 `json:"longitude"`
+	} `json:"location"`
+	Traits struct {
+		ASN               int    `json:"autonomous_system_number"`
+		ASOrg             string `json:"autonomous_system_organization"`
+		IsAnonymous       bool   `json:"is_anonymous"`
+		IsAnonymousProxy  bool   `json:"is_anonymous_proxy"`
+		IsHostingProvider bool   `json:"is_hosting_provider"`
+	} `json:"traits"`
+}
+
+func (s *GeoIPService) lookupMaxMind(ctx context.Context, ip string) (*models.GeoLocation, error) {
+	var out maxMindResponse
+	if err := s.get(ctx, s.endpoint+url.PathEscape(ip), &out); err != nil {
+		return nil, err
+	}
+	loc := &models.GeoLocation{
+		Country:   out.Country.ISOCode,
+		City:      out.City.Names["en"],
+		Latitude:  out.Location.Latitude,
+		Longitude: out.Location.Longitude,
+		ASN:       out.Traits.ASN,
+		ASOrg:     out.Traits.ASOrg,
+		Anonymous: out.Traits.IsAnonymous || out.Traits.IsAnonymousProxy || out.Traits.IsHostingProvider,
+	}
+	if len(out.Subdivisions) > 0 {
+		loc.Region = out.Subdivisions[0].Names["en"]
+	}
+	return loc, nil
+}
@@ -0,0 +1,56 @@
+package services
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestGeoIPLookup(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/json/203.0.113.7":
+			if r.URL.Query().Get("key") != "k" {
+				t.Errorf("missing key in %s", r.URL)
+			}
+			w.Write([]byte(`{"status":"success","countryCode":"DE","regionName":"Hesse","city":"Frankfurt am Main","lat":50.11,"lon":8.68,"as":"AS3320 Deutsche Telekom AG","proxy":false,"hosting":true}`))
+		case "/city/198.51.100.1":
+			if user, pass, _ := r.BasicAuth(); user != "42" || pass != "k" {
+				t.Errorf("unexpected credentials %s:%s", user, pass)
+			}
+			w.Write([]byte(`{"country":{"iso_code":"CN"},"city":{"names":{"en":"Shanghai"}},"subdivisions":[{"names":{"en":"Shanghai"}}],
+				"location":{"latitude":31.23,"longitude":121.47},"traits":{"autonomous_system_number":4812,"autonomous_system_organization":"China Telecom"}}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	ipapi := &GeoIPService{Provider: "ipapi", endpoint: srv.URL + "/json/", key: "k", client: srv.Client()}
+	loc, err := ipapi.Lookup(context.Background(), "203.0.113.7")
+	if err != nil || loc == nil {
+		t.Fatalf("ipapi Lookup: %v %v", loc, err)
+	}
+	if loc.Country != "DE" || loc.City != "Frankfurt am Main" || loc.ASN != 3320 || loc.ASOrg != "Deutsche Telekom AG" || !loc.Anonymous || *loc.Latitude != 50.11 {
+		t.Errorf("ipapi location = %+v", loc)
+	}
+
+	maxmind := &GeoIPService{Provider: "maxmind", endpoint: srv.URL + "/city/", accountID: "42", key: "k", client: srv.Client()}
+	loc, err = maxmind.Lookup(context.Background(), "198.51.100.1")
+	if err != nil || loc == nil {
+		t.Fatalf("maxmind Lookup: %v %v", loc, err)
+	}
+	if loc.Country != "CN" || loc.Region != "Shanghai" || loc.ASN != 4812 || loc.Anonymous || *loc.Longitude != 121.47 {
+		t.Errorf("maxmind location = %+v", loc)
+	}
+
+	for _, ip := range []string{"10.0.0.1", "127.0.0.1", "::1", "not-an-ip"} {
+		if loc, err := maxmind.Lookup(context.Background(), ip); loc != nil || err != nil {
+			t.Errorf("Lookup(%s) = %v %v, want nothing", ip, loc, err)
+		}
+	}
+	if _, err := maxmind.Lookup(context.Background(), "192.0.2.1"); err == nil {
+		t.Error("Lookup of an unknown address should fail")
+	}
+}
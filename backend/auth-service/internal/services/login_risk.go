@@ -0,0 +1,85 @@
+package services
+
+import (
+	"math"
+	"time"
+
+	"github.com/expotoworld/expotoworld/backend/auth-service/internal/models"
+)
+
+// Login risk weights; a score is their sum, capped at 100
+const (
+	riskNewDevice        = 30
+	riskNewIP            = 10
+	riskNewNetwork       = 20
+	riskNewCountry       = 40
+	riskImpossibleTravel = 30
+	riskAnonymousIP      = 20
+)
+
+// maxTravelSpeedKmh is faster than any flight; logins further apart than this allows are impossible travel
+const maxTravelSpeedKmh = 1000
+
+// minTravelDistanceKm ignores jumps GeoIP can't tell apart (neighbouring cities, mobile carriers' gateways)
+const minTravelDistanceKm = 500
+
+// ScoreLogin rates how unusual a login is for the user compared to their login history. The first
+// login of an account has nothing to compare with and scores 0; loc may be nil when the IP couldn't
+// be located, which only leaves the device and IP checks.
+func ScoreLogin(history models.LoginHistorySummary, loc *models.GeoLocation, now time.Time) models.LoginRisk {
+	risk := models.LoginRisk{Reasons: []string{}}
+	if history.Logins == 0 {
+		return risk
+	}
+	add := func(weight int, reason string) {
+		risk.Score += weight
+		risk.Reasons = append(risk.Reasons, reason)
+	}
+	if !history.KnownDevice {
+		add(riskNewDevice, "new_device")
+	}
+	if !history.KnownIP {
+		add(riskNewIP, "new_ip")
+	}
+	if loc != nil {
+		if loc.ASN != 0 && !history.KnownNetwork {
+			add(riskNewNetwork, "new_network")
+		}
+		if loc.Country != "" && !history.KnownCountry {
+			add(riskNewCountry, "new_country")
+		}
+		if impossibleTravel(history.LastLocation, history.LastLocatedAt, loc, now) {
+			add(riskImpossibleTravel, "impossible_travel")
+		}
+		if loc.Anonymous {
+			add(riskAnonymousIP, "anonymous_ip")
+		}
+	}
+	if risk.Score > 100 {
+		risk.Score = 100
+	}
+	return risk
+}
+
+// impossibleTravel reports whether getting from the last login's location to this one in the time
+// between them would have taken a speed no traveller reaches
+func impossibleTravel(last *models.GeoLocation, lastAt time.Time, loc *models.GeoLocation, now time.Time) bool {
+	if last == nil || last.Latitude == nil || last.Longitude == nil || loc.Latitude == nil || loc.Longitude == nil {
+		return false
+	}
+	km := distanceKm(*last.Latitude, *last.Longitude, *loc.Latitude, *loc.Longitude)
+	if km < minTravelDistanceKm {
+		return false
+	}
+	hours := now.Sub(lastAt).Hours()
+	return hours <= 0 || km/hours > maxTravelSpeedKmh
+}
+
+// distanceKm is the great-circle distance between two coordinates (haversine formula)
+func distanceKm(lat1, lon1, lat2, lon2 float64) float64 {
+	const earthRadiusKm = 6371
+	rad := func(deg float64) float64 { return deg * math.Pi / 180 }
+	dLat, dLon := rad(lat2-lat1), rad(lon2-lon1)
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(rad(lat1))*math.Cos(rad(lat2))*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadiusKm * math.Asin(math.Sqrt(a))
+}
@@ -0,0 +1,62 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/expotoworld/expotoworld/backend/auth-service/internal/models"
+)
+
+func coords(lat, lon float64) *models.GeoLocation {
+	return &models.GeoLocation{Country: "DE", ASN: 3320, Latitude: &lat, Longitude: &lon}
+}
+
+func TestScoreLogin(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	frankfurt := coords(50.11, 8.68)
+	berlin := coords(52.52, 13.40)
+	shanghai := coords(31.23, 121.47)
+	shanghai.Country, shanghai.ASN = "CN", 4812
+	known := models.LoginHistorySummary{Logins: 12, KnownDevice: true, KnownIP: true, KnownNetwork: true, KnownCountry: true,
+		LastLocation: frankfurt, LastLocatedAt: now.Add(-2 * time.Hour)}
+
+	newPhone := known
+	newPhone.KnownDevice, newPhone.KnownIP = false, false
+	abroad := newPhone
+	abroad.KnownNetwork, abroad.KnownCountry = false, false
+	anonymous := *berlin
+	anonymous.Anonymous = true
+	cases := []struct {
+		name    string
+		history models.LoginHistorySummary
+		loc     *models.GeoLocation
+		score   int
+		reasons []string
+	}{
+		{"first login", models.LoginHistorySummary{}, shanghai, 0, []string{}},
+		{"usual login", known, berlin, 0, []string{}},
+		{"unlocated", newPhone, nil, 40, []string{"new_device", "new_ip"}},
+		{"new device nearby", newPhone, berlin, 40, []string{"new_device", "new_ip"}},
+		{"anonymous ip", known, &anonymous, 20, []string{"anonymous_ip"}},
+		{"impossible travel", abroad, shanghai, 100, []string{"new_device", "new_ip", "new_network", "new_country", "impossible_travel"}},
+	}
+	for _, tc := range cases {
+		got := ScoreLogin(tc.history, tc.loc, now)
+		if got.Score != tc.score || !reflect.DeepEqual(got.Reasons, tc.reasons) {
+			t.Errorf("%s: got %d %v, want %d %v", tc.name, got.Score, got.Reasons, tc.score, tc.reasons)
+		}
+	}
+
+	// A day is enough to fly Frankfurt-Shanghai
+	abroad.LastLocatedAt = now.Add(-24 * time.Hour)
+	if got := ScoreLogin(abroad, shanghai, now); got.Score != 100 || len(got.Reasons) != 4 {
+		t.Errorf("travel after a day: got %d %v", got.Score, got.Reasons)
+	}
+}
+
+func TestDistanceKm(t *testing.T) {
+	if d := distanceKm(50.11, 8.68, 52.52, 13.40); d < 410 || d > 430 {
+		t.Errorf("Frankfurt-Berlin = %.0f km", d)
+	}
+}
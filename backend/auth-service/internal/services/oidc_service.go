@@ -0,0 +1,115 @@
+package services
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/expotoworld/expotoworld/backend/auth-service/internal/models"
+	"github.com/expotoworld/expotoworld/backend/common/jwks"
+)
+
+// ErrInvalidIDToken is returned for ID tokens that fail signature, issuer, audience, expiry or
+// nonce checks
+var ErrInvalidIDToken = errors.New("invalid ID token")
+
+// OIDCProvider verifies ID tokens issued by a sign-in provider (Apple, Google) to our apps
+type OIDCProvider struct {
+	Name      string
+	issuers   []string
+	clientIDs []string
+	keys      *jwks.Verifier
+}
+
+// NewOIDCProvidersFromEnv configures Sign in with Apple (APPLE_CLIENT_IDS: bundle and services IDs)
+// and Google (GOOGLE_CLIENT_IDS: the iOS, Android and web OAuth client IDs). Providers without
+// client IDs are left out.
+func NewOIDCProvidersFromEnv() map[string]*OIDCProvider {
+	providers := map[string]*OIDCProvider{}
+	if ids := splitList(os.Getenv("APPLE_CLIENT_IDS")); len(ids) > 0 {
+		providers["apple"] = &OIDCProvider{
+			Name:      "apple",
+			issuers:   []string{"https://appleid.apple.com"},
+			clientIDs: ids,
+			keys:      jwks.NewVerifier("https://appleid.apple.com/auth/keys", nil),
+		}
+	}
+	if ids := splitList(os.Getenv("GOOGLE_CLIENT_IDS")); len(ids) > 0 {
+		providers["google"] = &OIDCProvider{
+			Name:      "google",
+			issuers:   []string{"https://accounts.google.com", "accounts.google.com"},
+			clientIDs: ids,
+			keys:      jwks.NewVerifier("https://www.googleapis.com/oauth2/v3/certs", nil),
+		}
+	}
+	return providers
+}
+
+func splitList(s string) []string {
+	var out []string
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+// Verify checks the ID token and returns the identity it asserts. When the app sent a nonce with the
+// sign-in request, nonce must be its raw value: Google echoes it, Apple carries its SHA-256 hex.
+func (p *OIDCProvider) Verify(idToken, nonce string) (*models.OIDCIdentity, error) {
+	claims, err := p.keys.Parse(idToken)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidIDToken, err)
+	}
+	iss, _ := claims.GetIssuer()
+	if !contains(p.issuers, iss) {
+		return nil, fmt.Errorf("%w: unexpected issuer %q", ErrInvalidIDToken, iss)
+	}
+	aud, _ := claims.GetAudience()
+	matched := false
+	for _, a := range aud {
+		matched = matched || contains(p.clientIDs, a)
+	}
+	if !matched {
+		return nil, fmt.Errorf("%w: token was issued to another client", ErrInvalidIDToken)
+	}
+	if exp, _ := claims.GetExpirationTime(); exp == nil {
+		return nil, fmt.Errorf("%w: missing expiry", ErrInvalidIDToken)
+	}
+	if nonce != "" {
+		got, _ := claims["nonce"].(string)
+		sum := sha256.Sum256([]byte(nonce))
+		if got != nonce && got != hex.EncodeToString(sum[:]) {
+			return nil, fmt.Errorf("%w: nonce mismatch", ErrInvalidIDToken)
+		}
+	}
+	sub, _ := claims.GetSubject()
+	if sub == "" {
+		return nil, fmt.Errorf("%w: missing subject", ErrInvalidIDToken)
+	}
+
+	id := &models.OIDCIdentity{Provider: p.Name, Subject: sub}
+	id.Email, _ = claims["email"].(string)
+	id.Email = strings.ToLower(strings.TrimSpace(id.Email))
+	// Apple sends email_verified as a string
+	switch v := claims["email_verified"].(type) {
+	case bool:
+		id.EmailVerified = v
+	case string:
+		id.EmailVerified = v == "true"
+	}
+	return id, nil
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,114 @@
+package services
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/expotoworld/expotoworld/backend/common/jwks"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// testProvider serves a fresh key as the provider's JWKS
+func testProvider(t *testing.T) (*OIDCProvider, *jwks.Signer) {
+	t.Helper()
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer, err := jwks.NewSigner(string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(signer.Set())
+	}))
+	t.Cleanup(srv.Close)
+	return &OIDCProvider{
+		Name:      "apple",
+		issuers:   []string{"https://appleid.apple.com"},
+		clientIDs: []string{"com.expotoworld.app"},
+		keys:      jwks.NewVerifier(srv.URL, nil),
+	}, signer
+}
+
+func TestOIDCProviderVerify(t *testing.T) {
+	p, signer := testProvider(t)
+	hashedNonce := sha256.Sum256([]byte("raw-nonce"))
+	valid := func() jwt.MapClaims {
+		return jwt.MapClaims{
+			"iss":            "https://appleid.apple.com",
+			"aud":            "com.expotoworld.app",
+			"sub":            "000123.abc",
+			"email":          " Someone@Example.com",
+			"email_verified": "true",
+			"nonce":          hex.EncodeToString(hashedNonce[:]),
+			"exp":            time.Now().Add(5 * time.Minute).Unix(),
+		}
+	}
+	sign := func(claims jwt.MapClaims) string {
+		tok, err := signer.Sign(claims)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return tok
+	}
+
+	id, err := p.Verify(sign(valid()), "raw-nonce")
+	if err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+	if id.Provider != "apple" || id.Subject != "000123.abc" || id.Email != "someone@example.com" || !id.EmailVerified {
+		t.Fatalf("identity = %+v", id)
+	}
+
+	tests := []struct {
+		name   string
+		mutate func(jwt.MapClaims)
+		nonce  string
+	}{
+		{"wrong issuer", func(c jwt.MapClaims) { c["iss"] = "https://accounts.google.com" }, "raw-nonce"},
+		{"other client", func(c jwt.MapClaims) { c["aud"] = "com.other.app" }, "raw-nonce"},
+		{"expired", func(c jwt.MapClaims) { c["exp"] = time.Now().Add(-time.Minute).Unix() }, "raw-nonce"},
+		{"no expiry", func(c jwt.MapClaims) { delete(c, "exp") }, "raw-nonce"},
+		{"nonce mismatch", nil, "other-nonce"},
+		{"no subject", func(c jwt.MapClaims) { delete(c, "sub") }, "raw-nonce"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims := valid()
+			if tt.mutate != nil {
+				tt.mutate(claims)
+			}
+			if _, err := p.Verify(sign(claims), tt.nonce); !errors.Is(err, ErrInvalidIDToken) {
+				t.Fatalf("expected ErrInvalidIDToken, got %v", err)
+			}
+		})
+	}
+
+	// Tokens signed by anyone else fail the signature check
+	other, _ := testProvider(t)
+	if _, err := other.Verify(sign(valid()), ""); !errors.Is(err, ErrInvalidIDToken) {
+		t.Fatalf("foreign key: expected ErrInvalidIDToken, got %v", err)
+	}
+
+	// Unverified email is reported, not rejected; the handler refuses to link on it
+	claims := valid()
+	claims["email_verified"] = false
+	if id, err := p.Verify(sign(claims), ""); err != nil || id.EmailVerified {
+		t.Fatalf("unverified email: id=%+v err=%v", id, err)
+	}
+}
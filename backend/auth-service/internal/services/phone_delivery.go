@@ -0,0 +1,95 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+)
+
+// Phone verification delivery channels
+const (
+	ChannelSMS      = "sms"
+	ChannelWhatsApp = "whatsapp"
+)
+
+// ErrNoPhoneChannel is returned when neither SMS nor WhatsApp is configured
+var ErrNoPhoneChannel = errors.New("no phone delivery channel configured")
+
+// PhoneCodeSender delivers phone verification codes over the channel preferred for the number's
+// country, falling back to SMS when a preferred (not requested) WhatsApp delivery fails
+type PhoneCodeSender struct {
+	SMS      *SmsService
+	WhatsApp *WhatsAppService
+}
+
+// Available reports whether any channel is configured
+func (s *PhoneCodeSender) Available() bool {
+	return s.SMS != nil || s.WhatsApp != nil
+}
+
+// Supports reports whether the channel a client asked for is configured ("" means any)
+func (s *PhoneCodeSender) Supports(channel string) bool {
+	switch channel {
+	case ChannelSMS:
+		return s.SMS != nil
+	case ChannelWhatsApp:
+		return s.WhatsApp != nil
+	}
+	return s.Available()
+}
+
+// PreferredPhoneChannel returns the channel configured for a country in PHONE_CHANNEL_PREFERENCES,
+// e.g. "CN=whatsapp,HK=whatsapp,*=sms". Countries not listed use "*", and SMS when that is unset.
+func PreferredPhoneChannel(region string) string {
+	preferred := ChannelSMS
+	for _, entry := range strings.Split(os.Getenv("PHONE_CHANNEL_PREFERENCES"), ",") {
+		key, value, ok := strings.Cut(strings.TrimSpace(entry), "=")
+		if !ok {
+			continue
+		}
+		channel := strings.ToLower(strings.TrimSpace(value))
+		if channel != ChannelSMS && channel != ChannelWhatsApp {
+			continue
+		}
+		key = strings.TrimSpace(key)
+		if strings.EqualFold(key, region) {
+			return channel
+		}
+		if key == "*" {
+			preferred = channel
+		}
+	}
+	return preferred
+}
+
+// Send delivers the code and returns the channel that succeeded. requested is the channel the client
+// chose ("" uses the country preference). A requested channel is the only one tried: the number was
+// validated and rate limited for that channel alone, so a failed WhatsApp delivery must not turn into
+// an SMS. Only deliveries following the country preference fall back from WhatsApp to SMS.
+// smsMessage is the full SMS text; WhatsApp templates only receive the code.
+func (s *PhoneCodeSender) Send(ctx context.Context, phone *PhoneNumber, code, smsMessage, requested string) (string, error) {
+	if !s.Supports(requested) {
+		return "", ErrNoPhoneChannel
+	}
+	preferred := requested
+	if preferred == "" {
+		preferred = PreferredPhoneChannel(phone.Region)
+	}
+	if s.WhatsApp != nil && (s.SMS == nil || preferred == ChannelWhatsApp) && requested != ChannelSMS {
+		err := s.WhatsApp.SendVerificationCode(ctx, phone.E164, code)
+		if err == nil {
+			return ChannelWhatsApp, nil
+		}
+		if s.SMS == nil || requested == ChannelWhatsApp {
+			return "", fmt.Errorf("whatsapp: %w", err)
+		}
+		log.Printf("[PHONE_DELIVERY] WhatsApp delivery to %s failed, falling back to SMS: %v", phone.E164, err)
+	}
+	if err := s.SMS.SendSMS(ctx, phone.E164, smsMessage); err != nil {
+		return "", fmt.Errorf("sms: %w", err)
+	}
+	return ChannelSMS, nil
+}
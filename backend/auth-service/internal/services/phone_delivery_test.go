@@ -0,0 +1,26 @@
+package services
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestPhoneCodeSenderRequestedWhatsAppDoesNotFallBack(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"error":{"code":131026,"message":"Receiver is incapable of receiving this message"}}`))
+	}))
+	defer srv.Close()
+	// The SMS client is unusable: reaching it would panic, failing the test
+	s := &PhoneCodeSender{
+		SMS:      &SmsService{},
+		WhatsApp: &WhatsAppService{client: srv.Client(), endpoint: srv.URL, accessToken: "t", template: "verification_code", language: "en"},
+	}
+	phone := &PhoneNumber{E164: "+8613800138000", Region: "CN"}
+	channel, err := s.Send(context.Background(), phone, "123456", "Your code is 123456", ChannelWhatsApp)
+	if err == nil || channel != "" {
+		t.Fatalf("Send = %q, %v; want the WhatsApp error", channel, err)
+	}
+}
@@ -0,0 +1,121 @@
+package services
+
+import (
+	"errors"
+	"os"
+	"strings"
+
+	"github.com/nyaruka/phonenumbers"
+)
+
+var (
+	// ErrInvalidPhone is returned for numbers that cannot be parsed or are not valid for their country
+	ErrInvalidPhone = errors.New("invalid phone number")
+	// ErrPhoneNotMobile is returned for valid numbers that cannot receive SMS (landline, toll-free, VoIP, ...)
+	ErrPhoneNotMobile = errors.New("phone number is not a mobile number")
+	// ErrPhoneRegionNotAllowed is returned when the number's country is outside SMS_ALLOWED_REGIONS
+	// (WHATSAPP_ALLOWED_REGIONS for WhatsApp delivery)
+	ErrPhoneRegionNotAllowed = errors.New("phone number country is not supported")
+)
+
+// PhoneNumber is a validated phone number normalized to E.164
+type PhoneNumber struct {
+	E164        string `json:"e164"`
+	Region      string `json:"region"`       // ISO 3166-1 alpha-2, e.g. "DE"
+	CountryCode int    `json:"country_code"` // calling code, e.g. 49
+	LineType    string `json:"line_type"`    // mobile, fixed_line, fixed_line_or_mobile, voip, ...
+}
+
+// IsMobile reports whether the number can receive SMS. Numbering plans that do not distinguish
+// mobile from landline (e.g. US/CA) report fixed_line_or_mobile and are accepted.
+func (p *PhoneNumber) IsMobile() bool {
+	return p.LineType == "mobile" || p.LineType == "fixed_line_or_mobile"
+}
+
+// ParsePhone validates and normalizes a phone number. Input in international format ("+4917...") is
+// parsed as-is; national format ("017...") needs defaultRegion, falling back to PHONE_DEFAULT_REGION.
+func ParsePhone(raw, defaultRegion string) (*PhoneNumber, error) {
+	raw = strings.TrimSpace(raw)
+	region := strings.ToUpper(strings.TrimSpace(defaultRegion))
+	if region == "" {
+		region = strings.ToUpper(strings.TrimSpace(os.Getenv("PHONE_DEFAULT_REGION")))
+	}
+	if raw == "" || (!strings.HasPrefix(raw, "+") && region == "") {
+		return nil, ErrInvalidPhone
+	}
+	num, err := phonenumbers.Parse(raw, region)
+	if err != nil || !phonenumbers.IsValidNumber(num) {
+		return nil, ErrInvalidPhone
+	}
+	return &PhoneNumber{
+		E164:        phonenumbers.Format(num, phonenumbers.E164),
+		Region:      phonenumbers.GetRegionCodeForNumber(num),
+		CountryCode: int(num.GetCountryCode()),
+		LineType:    lineTypeName(phonenumbers.GetNumberType(num)),
+	}, nil
+}
+
+// ValidateSMSRecipient parses a number and checks it can receive a verification SMS:
+// it must be a mobile number and, when SMS_ALLOWED_REGIONS is set, in one of those countries.
+func ValidateSMSRecipient(raw, defaultRegion string) (*PhoneNumber, error) {
+	return validateRecipient(raw, defaultRegion, "SMS_ALLOWED_REGIONS")
+}
+
+// ValidateWhatsAppRecipient is ValidateSMSRecipient for codes the client asked to get on WhatsApp;
+// the allowed countries come from WHATSAPP_ALLOWED_REGIONS, so markets where SMS is disabled can
+// still sign in
+func ValidateWhatsAppRecipient(raw, defaultRegion string) (*PhoneNumber, error) {
+	return validateRecipient(raw, defaultRegion, "WHATSAPP_ALLOWED_REGIONS")
+}
+
+func validateRecipient(raw, defaultRegion, allowedRegionsEnv string) (*PhoneNumber, error) {
+	p, err := ParsePhone(raw, defaultRegion)
+	if err != nil {
+		return nil, err
+	}
+	if !p.IsMobile() {
+		return p, ErrPhoneNotMobile
+	}
+	if allowed := strings.TrimSpace(os.Getenv(allowedRegionsEnv)); allowed != "" {
+		ok := false
+		for _, r := range strings.Split(allowed, ",") {
+			if strings.EqualFold(strings.TrimSpace(r), p.Region) {
+				ok = true
+				break
+			}
+		}
+		if !ok {
+			return p, ErrPhoneRegionNotAllowed
+		}
+	}
+	return p, nil
+}
+
+func lineTypeName(t phonenumbers.PhoneNumberType) string {
+	switch t {
+	case phonenumbers.MOBILE:
+		return "mobile"
+	case phonenumbers.FIXED_LINE:
+		return "fixed_line"
+	case phonenumbers.FIXED_LINE_OR_MOBILE:
+		return "fixed_line_or_mobile"
+	case phonenumbers.TOLL_FREE:
+		return "toll_free"
+	case phonenumbers.PREMIUM_RATE:
+		return "premium_rate"
+	case phonenumbers.SHARED_COST:
+		return "shared_cost"
+	case phonenumbers.VOIP:
+		return "voip"
+	case phonenumbers.PERSONAL_NUMBER:
+		return "personal_number"
+	case phonenumbers.PAGER:
+		return "pager"
+	case phonenumbers.UAN:
+		return "uan"
+	case phonenumbers.VOICEMAIL:
+		return "voicemail"
+	default:
+		return "unknown"
+	}
+}
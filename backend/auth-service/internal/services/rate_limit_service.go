@@ -0,0 +1,176 @@
+package services
+
+import (
+	"context"
+	"log"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/expotoworld/expotoworld/backend/auth-service/internal/db"
+	"github.com/expotoworld/expotoworld/backend/auth-service/internal/models"
+)
+
+// RateLimitDecision is the outcome of a tiered rate limit check
+type RateLimitDecision struct {
+	Tier    models.RateLimitTier
+	Count   int
+	Allowed bool
+}
+
+type tierCounters struct {
+	allowed atomic.Int64
+	blocked atomic.Int64
+}
+
+// RateLimitService applies progressive, risk-tiered limits to verification requests.
+// Tier configuration lives in app_rate_limit_tiers and is reloaded periodically without a restart.
+type RateLimitService struct {
+	db       *db.Database
+	interval time.Duration
+
+	mu       sync.RWMutex
+	tiers    map[string]models.RateLimitTier
+	counters sync.Map // tier -> *tierCounters
+}
+
+// DefaultRateLimitTiers returns the built-in tiers. The "new" tier keeps the legacy
+// RATE_LIMIT_REQUESTS_PER_HOUR budget, shared by everyone behind the same IP.
+func DefaultRateLimitTiers(legacyPerHour int) []models.RateLimitTier {
+	return []models.RateLimitTier{
+		{Tier: models.RateLimitTierNew, MaxRequests: legacyPerHour, WindowMinutes: 60, Scope: models.RateLimitScopeIP},
+		{Tier: models.RateLimitTierKnown, MaxRequests: 5, WindowMinutes: 60, Scope: models.RateLimitScopeIPSubject},
+		{Tier: models.RateLimitTierTrusted, MaxRequests: 10, WindowMinutes: 60, Scope: models.RateLimitScopeIPSubject},
+		{Tier: models.RateLimitTierQRLogin, MaxRequests: 30, WindowMinutes: 60, Scope: models.RateLimitScopeIP},
+	}
+}
+
+// NewRateLimitService creates a rate limit service seeded with defaults until the first reload
+func NewRateLimitService(database *db.Database, defaults []models.RateLimitTier, reloadSeconds int) *RateLimitService {
+	s := &RateLimitService{
+		db:       database,
+		interval: time.Duration(reloadSeconds) * time.Second,
+		tiers:    make(map[string]models.RateLimitTier),
+	}
+	for _, t := range defaults {
+		s.tiers[t.Tier] = t
+	}
+	return s
+}
+
+// Start loads the tier configuration and keeps reloading it until ctx is cancelled
+func (s *RateLimitService) Start(ctx context.Context) {
+	if s.db == nil {
+		return
+	}
+	s.Reload(ctx)
+
+	go func() {
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				s.Reload(ctx)
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// Reload replaces the in-memory tier configuration with the rows from the database
+func (s *RateLimitService) Reload(ctx context.Context) {
+	loadCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	tiers, err := s.db.ListRateLimitTiers(loadCtx)
+	if err != nil {
+		log.Printf("[RATE_LIMIT] Failed to reload tier configuration: %v", err)
+		return
+	}
+	if len(tiers) == 0 {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, t := range tiers {
+		if prev, ok := s.tiers[t.Tier]; !ok || prev.MaxRequests != t.MaxRequests || prev.WindowMinutes != t.WindowMinutes || prev.Scope != t.Scope {
+			log.Printf("[RATE_LIMIT] Tier %s: %d requests / %d min (scope=%s)", t.Tier, t.MaxRequests, t.WindowMinutes, t.Scope)
+		}
+		s.tiers[t.Tier] = t
+	}
+}
+
+// Tier returns the configuration for a tier, falling back to the strictest ("new") tier
+func (s *RateLimitService) Tier(name string) models.RateLimitTier {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if t, ok := s.tiers[name]; ok {
+		return t
+	}
+	return s.tiers[models.RateLimitTierNew]
+}
+
+// Check classifies the request and evaluates it against its tier's budget.
+// channel is "email", "phone" or "whatsapp" (codes the client asked to get on WhatsApp); subject is
+// the email or phone the code is requested for.
+// Channel "qr" (QR login starts) has no subject and always uses the qr_login tier.
+func (s *RateLimitService) Check(ctx context.Context, channel, ipAddress, subject string) (*RateLimitDecision, error) {
+	tierName := models.RateLimitTierQRLogin
+	if channel != "qr" {
+		var err error
+		if tierName, err = s.db.ClassifyRateLimitSubject(ctx, channel, subject, ipAddress); err != nil {
+			return nil, err
+		}
+	}
+	tier := s.Tier(tierName)
+
+	count, err := s.db.CountTieredRateLimit(ctx, channel, ipAddress, subject, tier)
+	if err != nil {
+		return nil, err
+	}
+
+	decision := &RateLimitDecision{Tier: tier, Count: count, Allowed: count < tier.MaxRequests}
+	c := s.countersFor(tier.Tier)
+	if decision.Allowed {
+		c.allowed.Add(1)
+	} else {
+		c.blocked.Add(1)
+	}
+	return decision, nil
+}
+
+// Record counts an accepted request against the IP and subject
+func (s *RateLimitService) Record(ctx context.Context, channel, ipAddress, subject string, decision *RateLimitDecision) error {
+	return s.db.IncrementTieredRateLimit(ctx, channel, ipAddress, subject, decision.Tier.Tier)
+}
+
+// Metrics returns per-tier decision counters and the active configuration
+func (s *RateLimitService) Metrics() ([]models.RateLimitTierMetrics, []models.RateLimitTier) {
+	s.mu.RLock()
+	tiers := make([]models.RateLimitTier, 0, len(s.tiers))
+	for _, t := range s.tiers {
+		tiers = append(tiers, t)
+	}
+	s.mu.RUnlock()
+	sort.Slice(tiers, func(i, j int) bool { return tiers[i].Tier < tiers[j].Tier })
+
+	metrics := make([]models.RateLimitTierMetrics, 0, len(tiers))
+	for _, t := range tiers {
+		c := s.countersFor(t.Tier)
+		metrics = append(metrics, models.RateLimitTierMetrics{
+			Tier:    t.Tier,
+			Allowed: c.allowed.Load(),
+			Blocked: c.blocked.Load(),
+		})
+	}
+	return metrics, tiers
+}
+
+func (s *RateLimitService) countersFor(tier string) *tierCounters {
+	v, _ := s.counters.LoadOrStore(tier, &tierCounters{})
+	return v.(*tierCounters)
+}
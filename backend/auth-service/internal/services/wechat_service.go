@@ -0,0 +1,104 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/expotoworld/expotoworld/backend/auth-service/internal/models"
+)
+
+// ErrInvalidWeChatCode is returned when WeChat rejects a login code (expired, used or issued to another app)
+var ErrInvalidWeChatCode = errors.New("invalid WeChat code")
+
+// WeChatService exchanges WeChat login codes for the user's openid/unionid. Each of our WeChat
+// apps has its own AppID: the mini program (wx.login codes) and the mobile app (OAuth codes).
+type WeChatService struct {
+	client *http.Client
+	apps   map[string]*weChatApp
+}
+
+type weChatApp struct {
+	appID    string
+	secret   string
+	endpoint string
+	codeKey  string
+}
+
+// NewWeChatServiceFromEnv configures the mini program (WECHAT_MINIPROGRAM_APP_ID and
+// WECHAT_MINIPROGRAM_APP_SECRET) and the mobile app (WECHAT_APP_ID and WECHAT_APP_SECRET);
+// it returns nil when neither is configured.
+func NewWeChatServiceFromEnv() *WeChatService {
+	s := &WeChatService{client: &http.Client{Timeout: 8 * time.Second}, apps: map[string]*weChatApp{}}
+	if id, secret := strings.TrimSpace(os.Getenv("WECHAT_MINIPROGRAM_APP_ID")), strings.TrimSpace(os.Getenv("WECHAT_MINIPROGRAM_APP_SECRET")); id != "" && secret != "" {
+		s.apps[models.WeChatAppMiniProgram] = &weChatApp{appID: id, secret: secret, endpoint: "https://api.weixin.qq.com/sns/jscode2session", codeKey: "js_code"}
+	}
+	if id, secret := strings.TrimSpace(os.Getenv("WECHAT_APP_ID")), strings.TrimSpace(os.Getenv("WECHAT_APP_SECRET")); id != "" && secret != "" {
+		s.apps[models.WeChatAppMobile] = &weChatApp{appID: id, secret: secret, endpoint: "https://api.weixin.qq.com/sns/oauth2/access_token", codeKey: "code"}
+	}
+	if len(s.apps) == 0 {
+		return nil
+	}
+	return s
+}
+
+// Enabled reports whether logins from the given app are configured
+func (s *WeChatService) Enabled(app string) bool {
+	if s == nil {
+		return false
+	}
+	_, ok := s.apps[app]
+	return ok
+}
+
+// weChatCodeResponse covers both jscode2session and sns/oauth2/access_token; the session key and
+// access token are not kept since we never call WeChat on the user's behalf
+type weChatCodeResponse struct {
+	OpenID  string `json:"openid"`
+	UnionID string `json:"unionid"`
+	ErrCode int    `json:"errcode"`
+	ErrMsg  string `json:"errmsg"`
+}
+
+// Exchange trades a login code from the given app for the WeChat identity behind it
+func (s *WeChatService) Exchange(ctx context.Context, app, code string) (*models.WeChatIdentity, error) {
+	cfg, ok := s.apps[app]
+	if !ok {
+		return nil, fmt.Errorf("WeChat app %q is not configured", app)
+	}
+	q := url.Values{}
+	q.Set("appid", cfg.appID)
+	q.Set("secret", cfg.secret)
+	q.Set(cfg.codeKey, code)
+	q.Set("grant_type", "authorization_code")
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, cfg.endpoint+"?"+q.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("WeChat code exchange failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("WeChat code exchange failed: status %d", resp.StatusCode)
+	}
+	var out weChatCodeResponse
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return nil, fmt.Errorf("WeChat code exchange: decode response: %w", err)
+	}
+	// WeChat answers errors with HTTP 200 and an errcode; -1 means it is busy and worth retrying
+	if out.ErrCode == -1 {
+		return nil, fmt.Errorf("WeChat code exchange failed: %s", out.ErrMsg)
+	}
+	if out.ErrCode != 0 || out.OpenID == "" {
+		return nil, fmt.Errorf("%w: errcode %d: %s", ErrInvalidWeChatCode, out.ErrCode, out.ErrMsg)
+	}
+	return &models.WeChatIdentity{AppID: cfg.appID, OpenID: out.OpenID, UnionID: out.UnionID}, nil
+}
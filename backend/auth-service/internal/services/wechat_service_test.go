@@ -0,0 +1,52 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/expotoworld/expotoworld/backend/auth-service/internal/models"
+)
+
+func TestWeChatExchange(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if q.Get("appid") != "wx123" || q.Get("secret") != "s3cret" || q.Get("grant_type") != "authorization_code" {
+			t.Errorf("unexpected query %v", q)
+		}
+		switch q.Get("js_code") {
+		case "good":
+			w.Write([]byte(`{"openid":"o-1","session_key":"k","unionid":"u-1"}`))
+		case "busy":
+			w.Write([]byte(`{"errcode":-1,"errmsg":"system busy"}`))
+		default:
+			w.Write([]byte(`{"errcode":40029,"errmsg":"invalid code"}`))
+		}
+	}))
+	defer srv.Close()
+	s := &WeChatService{client: srv.Client(), apps: map[string]*weChatApp{
+		models.WeChatAppMiniProgram: {appID: "wx123", secret: "s3cret", endpoint: srv.URL, codeKey: "js_code"},
+	}}
+
+	id, err := s.Exchange(context.Background(), models.WeChatAppMiniProgram, "good")
+	if err != nil {
+		t.Fatalf("Exchange: %v", err)
+	}
+	if *id != (models.WeChatIdentity{AppID: "wx123", OpenID: "o-1", UnionID: "u-1"}) {
+		t.Fatalf("identity = %+v", id)
+	}
+	if _, err := s.Exchange(context.Background(), models.WeChatAppMiniProgram, "used"); !errors.Is(err, ErrInvalidWeChatCode) {
+		t.Fatalf("rejected code: expected ErrInvalidWeChatCode, got %v", err)
+	}
+	if _, err := s.Exchange(context.Background(), models.WeChatAppMiniProgram, "busy"); err == nil || errors.Is(err, ErrInvalidWeChatCode) {
+		t.Fatalf("busy: expected a retryable error, got %v", err)
+	}
+	if s.Enabled(models.WeChatAppMobile) {
+		t.Fatal("unconfigured app reported enabled")
+	}
+	if _, err := s.Exchange(context.Background(), models.WeChatAppMobile, "good"); err == nil {
+		t.Fatal("unconfigured app accepted")
+	}
+}
@@ -0,0 +1,126 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// WhatsAppService sends verification codes as template messages through the WhatsApp Business
+// (Cloud) API. The template must be an approved authentication template whose body takes the code
+// as its only parameter.
+type WhatsAppService struct {
+	client         *http.Client
+	endpoint       string
+	accessToken    string
+	template       string
+	language       string
+	copyCodeButton bool
+}
+
+// NewWhatsAppServiceFromEnv configures the service from WHATSAPP_PHONE_NUMBER_ID and
+// WHATSAPP_ACCESS_TOKEN; it returns nil when either is missing.
+func NewWhatsAppServiceFromEnv() *WhatsAppService {
+	phoneNumberID := strings.TrimSpace(os.Getenv("WHATSAPP_PHONE_NUMBER_ID"))
+	token := strings.TrimSpace(os.Getenv("WHATSAPP_ACCESS_TOKEN"))
+	if phoneNumberID == "" || token == "" {
+		return nil
+	}
+	version := envOr("WHATSAPP_API_VERSION", "v21.0")
+	return &WhatsAppService{
+		client:         &http.Client{Timeout: 8 * time.Second},
+		endpoint:       fmt.Sprintf("https://graph.facebook.com/%s/%s/messages", version, phoneNumberID),
+		accessToken:    token,
+		template:       envOr("WHATSAPP_TEMPLATE_NAME", "verification_code"),
+		language:       envOr("WHATSAPP_TEMPLATE_LANGUAGE", "en"),
+		copyCodeButton: os.Getenv("WHATSAPP_TEMPLATE_COPY_BUTTON") != "false",
+	}
+}
+
+func envOr(key, fallback string) string {
+	if v := strings.TrimSpace(os.Getenv(key)); v != "" {
+		return v
+	}
+	return fallback
+}
+
+type whatsAppParam struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+type whatsAppComponent struct {
+	Type       string          `json:"type"`
+	SubType    string          `json:"sub_type,omitempty"`
+	Index      string          `json:"index,omitempty"`
+	Parameters []whatsAppParam `json:"parameters"`
+}
+
+// SendVerificationCode sends code to an E.164 phone number using the configured template
+func (s *WhatsAppService) SendVerificationCode(ctx context.Context, phoneNumber, code string) error {
+	log.Printf("Attempting to send WhatsApp verification to %s", phoneNumber)
+
+	components := []whatsAppComponent{{Type: "body", Parameters: []whatsAppParam{{Type: "text", Text: code}}}}
+	if s.copyCodeButton {
+		// Authentication templates carry a copy-code button that repeats the code
+		components = append(components, whatsAppComponent{Type: "button", SubType: "url", Index: "0",
+			Parameters: []whatsAppParam{{Type: "text", Text: code}}})
+	}
+	payload := map[string]interface{}{
+		"messaging_product": "whatsapp",
+		"to":                strings.TrimPrefix(phoneNumber, "+"),
+		"type":              "template",
+		"template": map[string]interface{}{
+			"name":       s.template,
+			"language":   map[string]string{"code": s.language},
+			"components": components,
+		},
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+s.accessToken)
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := s.client.Do(req)
+	if err != nil {
+		log.Printf("Failed to send WhatsApp message to %s: %v", phoneNumber, err)
+		return err
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		Messages []struct {
+			ID string `json:"id"`
+		} `json:"messages"`
+		Error *struct {
+			Message string `json:"message"`
+			Code    int    `json:"code"`
+		} `json:"error"`
+	}
+	raw, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	_ = json.Unmarshal(raw, &result)
+	if resp.StatusCode/100 != 2 || len(result.Messages) == 0 {
+		err := fmt.Errorf("whatsapp API returned status %d", resp.StatusCode)
+		if result.Error != nil {
+			err = fmt.Errorf("whatsapp API error %d: %s", result.Error.Code, result.Error.Message)
+		}
+		log.Printf("Failed to send WhatsApp message to %s: %v", phoneNumber, err)
+		return err
+	}
+
+	log.Printf("Successfully sent WhatsApp message. Message ID: %s", result.Messages[0].ID)
+	return nil
+}
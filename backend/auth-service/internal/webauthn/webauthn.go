@@ -0,0 +1,234 @@
+// Package webauthn wraps the go-webauthn protocol package for the subset of WebAuthn (Level 2) the
+// admin panel needs to use hardware security keys as a second factor: ceremony options, registration
+// and assertion verification. Keys are requested with "none" attestation; a key is trusted because an
+// authenticated admin enrolled it.
+package webauthn
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/go-webauthn/webauthn/protocol/webauthncose"
+)
+
+var (
+	// ErrVerification is returned when a ceremony response does not match what was requested
+	ErrVerification = errors.New("webauthn verification failed")
+	// ErrInvalidSignature is returned when an assertion signature does not verify
+	ErrInvalidSignature = errors.New("invalid assertion signature")
+	// ErrClonedAuthenticator is returned when the signature counter did not increase
+	ErrClonedAuthenticator = errors.New("signature counter did not increase (possible cloned key)")
+)
+
+// Config identifies the relying party (the admin panel)
+type Config struct {
+	RPID    string   // effective domain, e.g. "admin.expotoworld.com"
+	RPName  string   // shown by the browser during enrollment
+	Origins []string // allowed origins, e.g. "https://admin.expotoworld.com"
+}
+
+// ConfigFromEnv reads WEBAUTHN_RP_ID (default localhost), WEBAUTHN_RP_NAME and WEBAUTHN_ORIGINS
+// (comma-separated; default https://<rp id>)
+func ConfigFromEnv() Config {
+	cfg := Config{
+		RPID:   strings.TrimSpace(os.Getenv("WEBAUTHN_RP_ID")),
+		RPName: strings.TrimSpace(os.Getenv("WEBAUTHN_RP_NAME")),
+	}
+	if cfg.RPID == "" {
+		cfg.RPID = "localhost"
+	}
+	if cfg.RPName == "" {
+		cfg.RPName = "ExpoToWorld Admin"
+	}
+	for _, o := range strings.Split(os.Getenv("WEBAUTHN_ORIGINS"), ",") {
+		if o = strings.TrimRight(strings.TrimSpace(o), "/"); o != "" {
+			cfg.Origins = append(cfg.Origins, o)
+		}
+	}
+	if len(cfg.Origins) == 0 {
+		cfg.Origins = []string{"https://" + cfg.RPID}
+	}
+	return cfg
+}
+
+// NewChallenge returns 32 random bytes for a ceremony
+func NewChallenge() ([]byte, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// Encode encodes binary values the way the browser JSON does (unpadded base64url)
+func Encode(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// Decode accepts base64url with or without padding
+func Decode(s string) ([]byte, error) {
+	return base64.RawURLEncoding.DecodeString(strings.TrimRight(s, "="))
+}
+
+// CredentialDescriptor references a registered credential in ceremony options
+type CredentialDescriptor struct {
+	Type       string   `json:"type"`
+	ID         string   `json:"id"`
+	Transports []string `json:"transports,omitempty"`
+}
+
+// NewCredentialDescriptor describes a stored credential
+func NewCredentialDescriptor(id []byte, transports []string) CredentialDescriptor {
+	return CredentialDescriptor{Type: "public-key", ID: Encode(id), Transports: transports}
+}
+
+// CreationOptions are the publicKey options for navigator.credentials.create()
+func (c Config) CreationOptions(challenge, userHandle []byte, userName string, exclude []CredentialDescriptor) map[string]interface{} {
+	return map[string]interface{}{
+		"challenge": Encode(challenge),
+		"rp":        map[string]string{"id": c.RPID, "name": c.RPName},
+		"user":      map[string]string{"id": Encode(userHandle), "name": userName, "displayName": userName},
+		"pubKeyCredParams": []map[string]interface{}{
+			{"type": "public-key", "alg": webauthncose.AlgES256},
+			{"type": "public-key", "alg": webauthncose.AlgEdDSA},
+			{"type": "public-key", "alg": webauthncose.AlgRS256},
+		},
+		"timeout":            120000,
+		"attestation":        "none",
+		"excludeCredentials": exclude,
+		// Roaming hardware keys, not platform passkeys
+		"authenticatorSelection": map[string]interface{}{
+			"authenticatorAttachment": "cross-platform",
+			"residentKey":             "discouraged",
+			"userVerification":        "discouraged",
+		},
+	}
+}
+
+// RequestOptions are the publicKey options for navigator.credentials.get()
+func (c Config) RequestOptions(challenge []byte, allow []CredentialDescriptor) map[string]interface{} {
+	return map[string]interface{}{
+		"challenge":        Encode(challenge),
+		"rpId":             c.RPID,
+		"timeout":          120000,
+		"allowCredentials": allow,
+		"userVerification": "discouraged",
+	}
+}
+
+// RegistrationResponse is the PublicKeyCredential returned by navigator.credentials.create(), as JSON
+type RegistrationResponse struct {
+	ID       string `json:"id"`
+	RawID    string `json:"rawId"`
+	Type     string `json:"type"`
+	Response struct {
+		ClientDataJSON    string   `json:"clientDataJSON"`
+		AttestationObject string   `json:"attestationObject"`
+		Transports        []string `json:"transports,omitempty"`
+	} `json:"response"`
+}
+
+// AssertionResponse is the PublicKeyCredential returned by navigator.credentials.get(), as JSON
+type AssertionResponse struct {
+	ID       string `json:"id"`
+	RawID    string `json:"rawId"`
+	Type     string `json:"type"`
+	Response struct {
+		ClientDataJSON    string `json:"clientDataJSON"`
+		AuthenticatorData string `json:"authenticatorData"`
+		Signature         string `json:"signature"`
+		UserHandle        string `json:"userHandle,omitempty"`
+	} `json:"response"`
+}
+
+// CredentialID returns the decoded credential id of an assertion
+func (a AssertionResponse) CredentialID() ([]byte, error) {
+	id := a.RawID
+	if id == "" {
+		id = a.ID
+	}
+	return Decode(id)
+}
+
+// Credential is a verified, newly registered security key
+type Credential struct {
+	ID         []byte
+	PublicKey  []byte // COSE_Key
+	SignCount  uint32
+	AAGUID     []byte
+	Transports []string
+}
+
+// verificationError maps a go-webauthn error onto this package's errors, keeping its detail
+func verificationError(err error) error {
+	var perr *protocol.Error
+	if !errors.As(err, &perr) {
+		return fmt.Errorf("%w: %v", ErrVerification, err)
+	}
+	if perr.Type == protocol.ErrAssertionSignature.Type {
+		return ErrInvalidSignature
+	}
+	msg := perr.Details
+	if info := strings.TrimSpace(perr.DevInfo); info != "" {
+		msg += ": " + info
+	}
+	return fmt.Errorf("%w: %s", ErrVerification, msg)
+}
+
+// VerifyRegistration checks a registration response against the challenge it was created for
+func (c Config) VerifyRegistration(resp RegistrationResponse, challenge []byte) (*Credential, error) {
+	body, err := json.Marshal(resp)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrVerification, err)
+	}
+	pcc, err := protocol.ParseCredentialCreationResponseBody(bytes.NewReader(body))
+	if err != nil {
+		return nil, verificationError(err)
+	}
+	if err := pcc.Verify(Encode(challenge), false, c.RPID, c.Origins); err != nil {
+		return nil, verificationError(err)
+	}
+	ad := pcc.Response.AttestationObject.AuthData
+	if !ad.Flags.HasAttestedCredentialData() {
+		return nil, fmt.Errorf("%w: no attested credential data", ErrVerification)
+	}
+	if _, err := webauthncose.ParsePublicKey(ad.AttData.CredentialPublicKey); err != nil {
+		return nil, fmt.Errorf("%w: unsupported credential public key", ErrVerification)
+	}
+	return &Credential{
+		ID:         append([]byte(nil), ad.AttData.CredentialID...),
+		PublicKey:  append([]byte(nil), ad.AttData.CredentialPublicKey...),
+		SignCount:  ad.Counter,
+		AAGUID:     append([]byte(nil), ad.AttData.AAGUID...),
+		Transports: resp.Response.Transports,
+	}, nil
+}
+
+// VerifyAssertion checks an authentication response for a stored credential and returns the new
+// signature counter to persist
+func (c Config) VerifyAssertion(resp AssertionResponse, challenge, publicKey []byte, storedSignCount uint32) (uint32, error) {
+	body, err := json.Marshal(resp)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %v", ErrVerification, err)
+	}
+	par, err := protocol.ParseCredentialRequestResponseBody(bytes.NewReader(body))
+	if err != nil {
+		return 0, verificationError(err)
+	}
+	if err := par.Verify(Encode(challenge), c.RPID, c.Origins, "", false, publicKey); err != nil {
+		return 0, verificationError(err)
+	}
+	// Keys without a counter always report 0; otherwise it must increase
+	signCount := par.Response.AuthenticatorData.Counter
+	if (signCount != 0 || storedSignCount != 0) && signCount <= storedSignCount {
+		return 0, ErrClonedAuthenticator
+	}
+	return signCount, nil
+}
@@ -0,0 +1,191 @@
+package webauthn
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+// Fixtures are real browser output recorded against https://webauthn.io (published with the
+// go-webauthn test suite): a "none" attestation registration and a macOS Touch ID ES256 assertion.
+const (
+	registrationChallenge = "W8GzFU8pGjhoRbWrLDlamAfq_y4S1CZG1VuoeRLARrE"
+	registrationJSON      = `{
+		"id":"6xrtBhJQW6QU4tOaB4rrHaS2Ks0yDDL_q8jDC16DEjZ-VLVf4kCRkvl2xp2D71sTPYns-exsHQHTy3G-zJRK8g",
+		"rawId":"6xrtBhJQW6QU4tOaB4rrHaS2Ks0yDDL_q8jDC16DEjZ-VLVf4kCRkvl2xp2D71sTPYns-exsHQHTy3G-zJRK8g",
+		"type":"public-key",
+		"response":{
+			"attestationObject":"o2NmbXRkbm9uZWdhdHRTdG10oGhhdXRoRGF0YVjEdKbqkhPJnC90siSSsyDPQCYqlMGpUKA5fyklC2CEHvBBAAAAAAAAAAAAAAAAAAAAAAAAAAAAQOsa7QYSUFukFOLTmgeK6x2ktirNMgwy_6vIwwtegxI2flS1X-JAkZL5dsadg-9bEz2J7PnsbB0B08txvsyUSvKlAQIDJiABIVggLKF5xS0_BntttUIrm2Z2tgZ4uQDwllbdIfrrBMABCNciWCDHwin8Zdkr56iSIh0MrB5qZiEzYLQpEOREhMUkY6q4Vw",
+			"clientDataJSON":"eyJjaGFsbGVuZ2UiOiJXOEd6RlU4cEdqaG9SYldyTERsYW1BZnFfeTRTMUNaRzFWdW9lUkxBUnJFIiwib3JpZ2luIjoiaHR0cHM6Ly93ZWJhdXRobi5pbyIsInR5cGUiOiJ3ZWJhdXRobi5jcmVhdGUifQ",
+			"transports":["usb","nfc"]
+		}
+	}`
+
+	assertionChallenge = "E4PTcIH_HfX1pC6Sigk1SC9NAlgeztN0439vi8z_c9k"
+	assertionPublicKey = "pQMmIAEhWCAoCF-x0dwEhzQo-ABxHIAgr_5WL6cJceREc81oIwFn7iJYIHEHx8ZhBIE42L26-rSC_3l0ZaWEmsHAKyP9rgslApUdAQI"
+	assertionSignCount = 1553097241
+	assertionJSON      = `{
+		"id":"AI7D5q2P0LS-Fal9ZT7CHM2N5BLbUunF92T8b6iYC199bO2kagSuU05-5dZGqb1SP0A0lyTWng",
+		"rawId":"AI7D5q2P0LS-Fal9ZT7CHM2N5BLbUunF92T8b6iYC199bO2kagSuU05-5dZGqb1SP0A0lyTWng",
+		"type":"public-key",
+		"response":{
+			"authenticatorData":"dKbqkhPJnC90siSSsyDPQCYqlMGpUKA5fyklC2CEHvBFXJJiGa3OAAI1vMYKZIsLJfHwVQMANwCOw-atj9C0vhWpfWU-whzNjeQS21Lpxfdk_G-omAtffWztpGoErlNOfuXWRqm9Uj9ANJck1p6lAQIDJiABIVggKAhfsdHcBIc0KPgAcRyAIK_-Vi-nCXHkRHPNaCMBZ-4iWCBxB8fGYQSBONi9uvq0gv95dGWlhJrBwCsj_a4LJQKVHQ",
+			"clientDataJSON":"eyJjaGFsbGVuZ2UiOiJFNFBUY0lIX0hmWDFwQzZTaWdrMVNDOU5BbGdlenROMDQzOXZpOHpfYzlrIiwibmV3X2tleXNfbWF5X2JlX2FkZGVkX2hlcmUiOiJkbyBub3QgY29tcGFyZSBjbGllbnREYXRhSlNPTiBhZ2FpbnN0IGEgdGVtcGxhdGUuIFNlZSBodHRwczovL2dvby5nbC95YWJQZXgiLCJvcmlnaW4iOiJodHRwczovL3dlYmF1dGhuLmlvIiwidHlwZSI6IndlYmF1dGhuLmdldCJ9",
+			"signature":"MEUCIBtIVOQxzFYdyWQyxaLR0tik1TnuPhGVhXVSNgFwLmN5AiEAnxXdCq0UeAVGWxOaFcjBZ_mEZoXqNboY5IkQDdlWZYc",
+			"userHandle":"0ToAAAAAAAAAAA"
+		}
+	}`
+)
+
+var fixtureConfig = Config{RPID: "webauthn.io", RPName: "test", Origins: []string{"https://webauthn.io"}}
+
+func mustDecode(t *testing.T, s string) []byte {
+	t.Helper()
+	b, err := Decode(s)
+	if err != nil {
+		t.Fatalf("decode %q: %v", s, err)
+	}
+	return b
+}
+
+func loadRegistration(t *testing.T) RegistrationResponse {
+	t.Helper()
+	var r RegistrationResponse
+	if err := json.Unmarshal([]byte(registrationJSON), &r); err != nil {
+		t.Fatalf("registration fixture: %v", err)
+	}
+	return r
+}
+
+func loadAssertion(t *testing.T) AssertionResponse {
+	t.Helper()
+	var a AssertionResponse
+	if err := json.Unmarshal([]byte(assertionJSON), &a); err != nil {
+		t.Fatalf("assertion fixture: %v", err)
+	}
+	return a
+}
+
+// clearUserPresent clears the UP flag, which follows the 32-byte RP ID hash in authenticator data
+func clearUserPresent(t *testing.T, encoded string) string {
+	t.Helper()
+	b := mustDecode(t, encoded)
+	rpHash := sha256.Sum256([]byte(fixtureConfig.RPID))
+	i := bytes.Index(b, rpHash[:])
+	if i < 0 {
+		t.Fatal("RP ID hash not found in fixture")
+	}
+	b[i+32] &^= 0x01
+	return Encode(b)
+}
+
+func TestVerifyRegistration(t *testing.T) {
+	challenge := mustDecode(t, registrationChallenge)
+
+	cred, err := fixtureConfig.VerifyRegistration(loadRegistration(t), challenge)
+	if err != nil {
+		t.Fatalf("VerifyRegistration: %v", err)
+	}
+	if want := mustDecode(t, "6xrtBhJQW6QU4tOaB4rrHaS2Ks0yDDL_q8jDC16DEjZ-VLVf4kCRkvl2xp2D71sTPYns-exsHQHTy3G-zJRK8g"); !bytes.Equal(cred.ID, want) {
+		t.Errorf("credential id = %x, want %x", cred.ID, want)
+	}
+	if len(cred.PublicKey) == 0 || len(cred.AAGUID) != 16 {
+		t.Errorf("unexpected credential %+v", cred)
+	}
+
+	tests := []struct {
+		name   string
+		cfg    Config
+		mutate func(*RegistrationResponse)
+	}{
+		{"wrong origin", Config{RPID: "webauthn.io", Origins: []string{"https://admin.expotoworld.com"}}, nil},
+		{"wrong rp id", Config{RPID: "admin.expotoworld.com", Origins: fixtureConfig.Origins}, nil},
+		{"user presence missing", fixtureConfig, func(r *RegistrationResponse) {
+			r.Response.AttestationObject = clearUserPresent(t, r.Response.AttestationObject)
+		}},
+		{"wrong ceremony", fixtureConfig, func(r *RegistrationResponse) {
+			a := loadAssertion(t)
+			r.Response.ClientDataJSON = a.Response.ClientDataJSON
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reg := loadRegistration(t)
+			if tt.mutate != nil {
+				tt.mutate(&reg)
+			}
+			if _, err := tt.cfg.VerifyRegistration(reg, challenge); !errors.Is(err, ErrVerification) {
+				t.Fatalf("expected ErrVerification, got %v", err)
+			}
+		})
+	}
+
+	if _, err := fixtureConfig.VerifyRegistration(loadRegistration(t), mustDecode(t, assertionChallenge)); !errors.Is(err, ErrVerification) {
+		t.Fatalf("wrong challenge: expected ErrVerification, got %v", err)
+	}
+}
+
+func TestVerifyAssertion(t *testing.T) {
+	challenge := mustDecode(t, assertionChallenge)
+	key := mustDecode(t, assertionPublicKey)
+
+	count, err := fixtureConfig.VerifyAssertion(loadAssertion(t), challenge, key, 0)
+	if err != nil {
+		t.Fatalf("VerifyAssertion: %v", err)
+	}
+	if count != assertionSignCount {
+		t.Fatalf("sign count = %d, want %d", count, assertionSignCount)
+	}
+	if _, err := fixtureConfig.VerifyAssertion(loadAssertion(t), challenge, key, assertionSignCount-1); err != nil {
+		t.Fatalf("increasing counter rejected: %v", err)
+	}
+
+	tests := []struct {
+		name        string
+		cfg         Config
+		challenge   []byte
+		storedCount uint32
+		mutate      func(*AssertionResponse)
+		want        error
+	}{
+		{name: "wrong origin", cfg: Config{RPID: "webauthn.io", Origins: []string{"https://admin.expotoworld.com"}}, want: ErrVerification},
+		{name: "wrong rp id", cfg: Config{RPID: "admin.expotoworld.com", Origins: fixtureConfig.Origins}, want: ErrVerification},
+		{name: "wrong challenge", challenge: mustDecode(t, registrationChallenge), want: ErrVerification},
+		{name: "user presence missing", mutate: func(a *AssertionResponse) {
+			a.Response.AuthenticatorData = clearUserPresent(t, a.Response.AuthenticatorData)
+		}, want: ErrVerification},
+		{name: "counter replayed", storedCount: assertionSignCount, want: ErrClonedAuthenticator},
+		{name: "counter went backwards", storedCount: assertionSignCount + 100, want: ErrClonedAuthenticator},
+		{name: "bad signature", mutate: func(a *AssertionResponse) {
+			sig := mustDecode(t, a.Response.Signature)
+			sig[len(sig)-1] ^= 0xff
+			a.Response.Signature = Encode(sig)
+		}, want: ErrInvalidSignature},
+		{name: "tampered authenticator data", mutate: func(a *AssertionResponse) {
+			ad := mustDecode(t, a.Response.AuthenticatorData)
+			ad[36]++ // low byte of the signature counter
+			a.Response.AuthenticatorData = Encode(ad)
+		}, want: ErrInvalidSignature},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := tt.cfg
+			if cfg.RPID == "" {
+				cfg = fixtureConfig
+			}
+			ch := tt.challenge
+			if ch == nil {
+				ch = challenge
+			}
+			a := loadAssertion(t)
+			if tt.mutate != nil {
+				tt.mutate(&a)
+			}
+			if _, err := cfg.VerifyAssertion(a, ch, key, tt.storedCount); !errors.Is(err, tt.want) {
+				t.Fatalf("expected %v, got %v", tt.want, err)
+			}
+		})
+	}
+}
@@ -1,14 +1,17 @@
 package main
 
 import (
+	"context"
 	"log"
 	"os"
-	"os/signal"
-	"syscall"
 
 	"github.com/expotoworld/expotoworld/backend/catalog-service/internal/api"
 	"github.com/expotoworld/expotoworld/backend/catalog-service/internal/db"
 	"github.com/expotoworld/expotoworld/backend/catalog-service/internal/logging"
+	"github.com/expotoworld/expotoworld/backend/common/auth"
+	"github.com/expotoworld/expotoworld/backend/common/awsclient"
+	"github.com/expotoworld/expotoworld/backend/common/httpserver"
+	"github.com/expotoworld/expotoworld/backend/common/storage"
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
 )
@@ -22,6 +25,9 @@ func main() {
 	// Ensure all log output goes to stdout so App Runner captures it in Application Logs
 	log.SetOutput(os.Stdout)
 
+	// AWS clients must use the instance role, not SES SMTP env credentials that may be present
+	awsclient.IgnoreStaticEnvCredentials()
+
 	log.Printf("Catalog Service starting (GIT_SHA=%s BUILD_TIME=%s)", os.Getenv("GIT_SHA"), os.Getenv("BUILD_TIME"))
 
 	// Initialize database connection (non-fatal; allow process to start for /live)
@@ -33,9 +39,29 @@ func main() {
 		defer database.Close()
 	}
 
+	// Reject tokens revoked by a forced logout or sign-in method change
+	if database != nil {
+		api.CheckTokenVersions(auth.NewVersionCache(auth.VersionFunc(database.TokenVersion), auth.VersionCacheTTL()))
+	}
+
 	// Initialize handlers
 	handler := api.NewHandler(database)
 
+	// Background jobs: popularity aggregation, back-in-stock notifications, scheduled changesets, webhooks,
+	// nightly catalog snapshots, low-stock alerts and the trash purge
+	jobsCtx, stopJobs := context.WithCancel(context.Background())
+	defer stopJobs()
+	if database != nil {
+		go handler.StartPopularityJobs(jobsCtx)
+		go handler.StartStockNotificationJobs(jobsCtx)
+		go handler.StartChangesetJobs(jobsCtx)
+		go handler.StartWebhookJobs(jobsCtx)
+		go handler.StartCatalogExportJobs(jobsCtx)
+		go handler.StartLowStockJobs(jobsCtx)
+		go handler.StartTrashPurgeJobs(jobsCtx)
+		go handler.StartProductChangeJobs(jobsCtx)
+	}
+
 	// Set up Gin router
 	router := setupRouter(handler)
 
@@ -45,20 +71,10 @@ func main() {
 		port = "8080"
 	}
 
-	// Set up graceful shutdown
-	go func() {
-		log.Printf("Starting server on port %s", port)
-		if err := router.Run(":" + port); err != nil {
-			log.Fatalf("Failed to start server: %v", err)
-		}
-	}()
-
-	// Wait for interrupt signal to gracefully shutdown the server
-	quit := make(chan os.Signal, 1)
-	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
-	<-quit
-
-	log.Println("Shutting down server...")
+	// Serve until SIGTERM, then drain in-flight requests before the deferred job stop and database close
+	if err := httpserver.Run("catalog service", router, port); err != nil {
+		log.Printf("[ERROR] %v", err)
+	}
 }
 
 func setupRouter(handler *api.Handler) *gin.Engine {
@@ -70,12 +86,14 @@ func setupRouter(handler *api.Handler) *gin.Engine {
 	router := gin.New()
 
 	// Add middleware
+	router.Use(api.RequestIDMiddleware())
 	router.Use(logging.JSONLogger())
-	router.Use(gin.Recovery())
+	router.Use(api.Recovery())
 	router.Use(corsMiddleware())
+	router.NoRoute(api.NoRoute)
 
 	// Serve uploaded files for local development
-	router.Static("/uploads", "./uploads")
+	router.Static("/uploads", storage.ConfigFromEnv().LocalDir)
 
 	// Health and readiness endpoints
 	router.GET("/live", func(c *gin.Context) { c.Status(200) })
@@ -91,12 +109,46 @@ func setupRouter(handler *api.Handler) *gin.Engine {
 		// Product endpoints (public)
 		v1.GET("/products", handler.GetProducts)
 		v1.GET("/products/:id", handler.GetProduct)
+		v1.GET("/products/:id/variants", handler.GetProductVariants)
+		v1.GET("/products/popular", handler.GetPopularProducts)
+		v1.GET("/products/new-arrivals", handler.GetNewArrivals)
+		v1.GET("/products/back-in-stock", handler.GetBackInStock)
+		v1.GET("/products/suggest", handler.GetProductSuggestions)
+		v1.GET("/products/lookup", handler.LookupProductByBarcode)
+		v1.GET("/products/changes", handler.GetProductChanges)
+		v1.POST("/products/views", handler.RecordProductViews)
+
+		// Back-in-stock subscriptions
+		v1.POST("/products/:id/subscribe-stock", api.AuthMiddleware(), handler.SubscribeStock)
+		v1.DELETE("/products/:id/subscribe-stock", api.AuthMiddleware(), handler.UnsubscribeStock)
+		v1.GET("/me/stock-subscriptions", api.AuthMiddleware(), handler.GetMyStockSubscriptions)
+
+		// A/B experiments (assignment by user id, or X-Session-ID for anonymous callers)
+		v1.GET("/experiments/assignments", handler.GetExperimentAssignments)
+		v1.POST("/experiments/exposures", handler.RecordExperimentExposures)
+
+		// Competitor price feed from the scraping job (service token, no user JWT)
+		v1.POST("/internal/competitor-prices", api.MaintenanceTokenMiddleware(), handler.UpdateCompetitorPrices)
 
 		// Manufacturer scoped (authenticated)
 		man := v1.Group("/manufacturer")
 		man.Use(api.AuthMiddleware())
 		{
 			man.GET("/products", handler.GetManufacturerProducts)
+			// New products and changes wait for admin review (see /admin/product-submissions)
+			man.POST("/products", handler.SubmitManufacturerProduct)
+			man.PUT("/products/:id", handler.SubmitManufacturerProductUpdate)
+			man.GET("/submissions", handler.GetManufacturerSubmissions)
+		}
+
+		// Organization graph for partner and manufacturer dashboards (members of the organization or
+		// of one above it, and admins)
+		orgs := v1.Group("/organizations/:id")
+		orgs.Use(api.AuthMiddleware(), handler.OrgMemberMiddleware())
+		{
+			orgs.GET("/hierarchy", handler.GetOrganizationHierarchy)
+			orgs.GET("/stores", handler.GetOrganizationStores)
+			orgs.GET("/products", handler.GetOrganizationProducts)
 		}
 
 		// Validation endpoints (public)
@@ -108,27 +160,55 @@ func setupRouter(handler *api.Handler) *gin.Engine {
 
 		// Store endpoints (public reads)
 		v1.GET("/stores", handler.GetStores)
+		v1.GET("/stores/resolve-qr", handler.ResolveStoreQR)
+
+		// GraphQL reads of products, categories and stores in one round-trip (admin fields for Admins)
+		v1.POST("/graphql", handler.GraphQL)
 
 		// Protected admin endpoints
 		admin := v1.Group("")
-		admin.Use(api.AuthMiddleware(), api.AdminMiddleware())
+		admin.Use(api.AuthMiddleware(), api.AdminMiddleware(), handler.AdminScopeMiddleware(), handler.AuditMiddleware())
 		{
 			// Products (write + images)
 			admin.POST("/products", handler.CreateProduct)
 			admin.PUT("/products/:id", handler.UpdateProduct)
 			admin.DELETE("/products/:id", handler.DeleteProduct)
+			admin.POST("/products/:id/restore", handler.RestoreProduct)
+			admin.POST("/products/:id/clone", handler.CloneProduct)
+			admin.POST("/products/:id/stock-adjustments", handler.AdjustProductStock)
+			admin.GET("/products/:id/stock-history", handler.GetProductStockHistory)
+			admin.PUT("/products/:id/low-stock-threshold", handler.SetProductLowStockThreshold)
+			admin.GET("/admin/products/low-stock", handler.GetLowStockProducts)
 			admin.POST("/products/:id/image", handler.UploadProductImage)
 			admin.POST("/products/:id/images", handler.UploadProductImages)
+			admin.POST("/products/:id/images/presign", handler.PresignProductImageUpload)
+			admin.POST("/products/:id/images/confirm", handler.ConfirmProductImageUpload)
 			admin.GET("/products/:id/images", handler.GetProductImages)
 			admin.PUT("/products/:id/images/reorder", handler.ReorderProductImages)
 			admin.DELETE("/products/:id/images/:image_id", handler.DeleteProductImage)
 			admin.PUT("/products/:id/images/:image_id/primary", handler.SetPrimaryImage)
+			admin.POST("/products/:id/variants", handler.CreateProductVariant)
+			admin.PUT("/products/:id/variants/:variant_id", handler.UpdateProductVariant)
+			admin.DELETE("/products/:id/variants/:variant_id", handler.DeleteProductVariant)
+			admin.GET("/products/:id/related", handler.GetRelatedProducts)
+			admin.PUT("/products/:id/related", handler.SetRelatedProducts)
+			admin.GET("/products/:id/food-label", handler.GetProductFoodLabel)
+			admin.PUT("/products/:id/food-label", handler.SetProductFoodLabel)
+			admin.DELETE("/products/:id/food-label", handler.DeleteProductFoodLabel)
+			// Prices per market region, in the region's currency (listed with GET /products?region=)
+			admin.GET("/products/:id/region-prices", handler.GetProductRegionPrices)
+			admin.PUT("/products/:id/region-prices", handler.SetProductRegionPrices)
+			admin.GET("/products/:id/translations", handler.GetProductTranslations)
+			admin.POST("/products/:id/translations/draft", handler.DraftProductTranslations)
+			admin.PUT("/products/:id/translations/:locale", handler.ConfirmProductTranslation)
 
 			// Categories/Subcategories (write)
 			admin.POST("/categories", handler.CreateCategory)
+			admin.PUT("/categories/reorder", handler.ReorderCategories)
 			admin.PUT("/categories/:id", handler.UpdateCategory)
 			admin.DELETE("/categories/:id", handler.DeleteCategory)
 			admin.POST("/categories/:id/subcategories", handler.CreateSubcategory)
+			admin.PUT("/categories/:id/subcategories/reorder", handler.ReorderSubcategories)
 			admin.PUT("/subcategories/:id", handler.UpdateSubcategory)
 			admin.DELETE("/subcategories/:id", handler.DeleteSubcategory)
 			admin.POST("/subcategories/:id/image", handler.UploadSubcategoryImage)
@@ -138,6 +218,14 @@ func setupRouter(handler *api.Handler) *gin.Engine {
 			admin.PUT("/stores/:id", handler.UpdateStore)
 			admin.DELETE("/stores/:id", handler.DeleteStore)
 			admin.POST("/stores/:id/image", handler.UploadStoreImage)
+			admin.PUT("/stores/:id/low-stock-threshold", handler.SetStoreLowStockThreshold)
+			// Shelf layout of unmanned stores and which products occupy each slot
+			admin.PUT("/stores/:id/shelf-layout", handler.SetStoreShelfLayout)
+			admin.DELETE("/stores/:id/shelf-layout", handler.DeleteStoreShelfLayout)
+			admin.GET("/admin/stores/:id/shelf-map", handler.GetStoreShelfMap)
+			// Signed entry QR codes of stores
+			admin.POST("/admin/stores/:id/qr", handler.CreateStoreQR)
+			admin.GET("/admin/stores/:id/qr", handler.GetStoreQR)
 
 			// Organizations & Regions & Relationship mappings
 			admin.GET("/organizations", handler.GetOrganizations)
@@ -146,6 +234,14 @@ func setupRouter(handler *api.Handler) *gin.Engine {
 			admin.DELETE("/organizations/:id", handler.DeleteOrganization)
 			admin.GET("/organizations/:id/users", handler.GetOrganizationUsers)
 			admin.POST("/organizations/:id/users", handler.SetOrganizationUsers)
+			admin.GET("/organizations/:id/price-lists", handler.GetOrganizationPriceLists)
+			admin.POST("/organizations/:id/price-lists", handler.CreateOrganizationPriceList)
+			admin.GET("/organizations/:id/prices", handler.ResolveOrganizationPrices)
+			admin.PUT("/organizations/:id/stores", handler.SetOrganizationStores)
+			admin.GET("/price-lists/:id", handler.GetPriceList)
+			admin.PUT("/price-lists/:id", handler.UpdatePriceList)
+			admin.DELETE("/price-lists/:id", handler.DeletePriceList)
+			admin.PUT("/price-lists/:id/items", handler.SetPriceListItems)
 
 			admin.GET("/regions", handler.ListRegions)
 			admin.POST("/regions", handler.CreateRegion)
@@ -162,12 +258,81 @@ func setupRouter(handler *api.Handler) *gin.Engine {
 			admin.GET("/store-partners", handler.GetStorePartnersBatch)
 
 			admin.POST("/stores/:id/partners", handler.SetStorePartners)
+			// Store partner, sourcing and logistics mappings as CSV, for moving them between environments
+			admin.GET("/admin/relationships/:kind/export", handler.ExportRelationships)
+			admin.POST("/admin/relationships/:kind/import", handler.ImportRelationships)
+
+			admin.GET("/experiments", handler.ListExperiments)
+			admin.PUT("/experiments/:key", handler.UpsertExperiment)
+			admin.GET("/experiments/:key/results", handler.GetExperimentResults)
+
+			admin.GET("/products/:id/competitor-prices", handler.GetProductCompetitorPrices)
+			admin.PUT("/products/:id/competitor-prices", handler.SetProductCompetitorPrice)
+			admin.DELETE("/products/:id/competitor-prices/:source", handler.DeleteProductCompetitorPrice)
+			admin.GET("/reports/competitor-price-alerts", handler.GetCompetitorPriceAlerts)
+
+			admin.GET("/commission-rates", handler.ListCommissionRates)
+			admin.POST("/commission-rates", handler.CreateCommissionRate)
+			admin.GET("/commission-rates/resolve", handler.ResolveCommissionRate)
+			admin.DELETE("/commission-rates/:id", handler.DeleteCommissionRate)
+
+			admin.GET("/tax-rates", handler.ListRegionTaxRates)
+			admin.GET("/tax-rates/resolve", handler.ResolveTaxRate)
+			admin.GET("/tax-rates/unclassified-products", handler.ListUnclassifiedProducts)
+			admin.PUT("/tax-rates/:region_id/:tax_class", handler.SetRegionTaxRate)
+			admin.DELETE("/tax-rates/:region_id/:tax_class", handler.DeleteRegionTaxRate)
+
+			// Changesets: staged bulk product edits applied atomically (now or at scheduled_for)
+			admin.GET("/changesets", handler.ListChangesets)
+			admin.POST("/changesets", handler.CreateChangeset)
+			admin.GET("/changesets/:id", handler.GetChangeset)
+			admin.PUT("/changesets/:id/items", handler.StageChangesetItems)
+			admin.DELETE("/changesets/:id/items/:product_id", handler.RemoveChangesetItem)
+			admin.GET("/changesets/:id/preview", handler.PreviewChangeset)
+			admin.POST("/changesets/:id/schedule", handler.ScheduleChangeset)
+			admin.POST("/changesets/:id/apply", handler.ApplyChangeset)
+			admin.POST("/changesets/:id/discard", handler.DiscardChangeset)
+
+			// Audit trail of admin writes
+			admin.GET("/admin/audit-log", handler.GetAuditLog)
+			admin.GET("/admin/activity", handler.GetActivityFeed)
+			admin.POST("/admin/activity/:id/undo", handler.UndoActivity)
+
+			// Trash: hard-deleted products, categories and stores until purged (TRASH_RETENTION_DAYS)
+			admin.GET("/admin/trash", handler.ListTrash)
+			admin.POST("/admin/trash/:kind/:id/restore", handler.RestoreTrashedItem)
+
+			// Webhooks: signed catalog change events for external storefronts and kiosks
+			admin.GET("/admin/webhooks", handler.ListWebhookEndpoints)
+			admin.POST("/admin/webhooks", handler.CreateWebhookEndpoint)
+			admin.GET("/admin/webhooks/:id", handler.GetWebhookEndpoint)
+			admin.PUT("/admin/webhooks/:id", handler.UpdateWebhookEndpoint)
+			admin.DELETE("/admin/webhooks/:id", handler.DeleteWebhookEndpoint)
+			admin.POST("/admin/webhooks/:id/ping", handler.PingWebhookEndpoint)
+			admin.GET("/admin/webhooks/:id/deliveries", handler.GetWebhookDeliveries)
+			admin.POST("/admin/webhook-deliveries/:id/redeliver", handler.RedeliverWebhook)
+
+			// Review of manufacturer-submitted products
+			admin.GET("/admin/product-submissions", handler.ListProductSubmissions)
+			admin.POST("/admin/product-submissions/:id/approve", handler.ApproveProductSubmission)
+			admin.POST("/admin/product-submissions/:id/reject", handler.RejectProductSubmission)
+
+			// Catalog snapshots exported to S3 for analytics
+			admin.GET("/admin/catalog-exports", handler.ListCatalogExports)
+			admin.POST("/admin/catalog-exports", handler.TriggerCatalogExport)
+			admin.GET("/admin/catalog-exports/:id", handler.GetCatalogExport)
 
 			// Admin maintenance endpoints
 			admin.POST("/admin/cleanup-s3", handler.AdminCleanupS3)
 		}
 	}
 
+	// OpenAPI document built from the routes registered above, so it cannot drift from them.
+	// The JSON is public for the app teams; the interactive Swagger UI is for admins.
+	spec := api.OpenAPIDocument(router.Routes())
+	v1.GET("/openapi.json", api.ServeOpenAPI(spec))
+	v1.GET("/docs", api.AuthMiddleware(), api.AdminMiddleware(), api.SwaggerUI("/api/v1/openapi.json"))
+
 	// Root endpoint for basic info
 	router.GET("/", func(c *gin.Context) {
 		c.JSON(200, gin.H{
@@ -185,7 +350,8 @@ func corsMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		c.Header("Access-Control-Allow-Origin", "*")
 		c.Header("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		c.Header("Access-Control-Allow-Headers", "Origin, Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, X-Admin-Request")
+		c.Header("Access-Control-Expose-Headers", "X-Experiments, X-Request-ID")
+		c.Header("Access-Control-Allow-Headers", "Origin, Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, X-Admin-Request, X-Session-ID, If-Unmodified-Since, X-Request-ID")
 
 		if c.Request.Method == "OPTIONS" {
 			c.AbortWithStatus(204)
@@ -0,0 +1,121 @@
+// Package alerts publishes operational alerts to Amazon SNS and metrics to Amazon CloudWatch. It
+// calls the services' query APIs directly, signed with the SDK's SigV4 signer, so the service does
+// not need the full SNS and CloudWatch clients for two calls.
+package alerts
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+)
+
+// Metric is one CloudWatch data point
+type Metric struct {
+	Name       string
+	Value      float64
+	Unit       string // e.g. "Count"; empty means "None"
+	Dimensions map[string]string
+}
+
+// Client sends SNS notifications and CloudWatch metrics with the given AWS configuration
+type Client struct {
+	cfg    aws.Config
+	signer *v4.Signer
+	http   *http.Client
+}
+
+// New returns a client using cfg's region and credentials
+func New(cfg aws.Config) *Client {
+	return &Client{cfg: cfg, signer: v4.NewSigner(), http: &http.Client{Timeout: 15 * time.Second}}
+}
+
+// Publish sends a message to an SNS topic. SNS limits subjects to 100 characters.
+func (c *Client) Publish(ctx context.Context, topicARN, subject, message string) error {
+	form := url.Values{
+		"Action":   {"Publish"},
+		"Version":  {"2010-03-31"},
+		"TopicArn": {topicARN},
+		"Message":  {message},
+	}
+	if subject != "" {
+		if len(subject) > 100 {
+			subject = subject[:97] + "..."
+		}
+		form.Set("Subject", subject)
+	}
+	return c.call(ctx, "sns", "sns", form)
+}
+
+// PutMetrics records data points in a CloudWatch namespace, 20 per request
+func (c *Client) PutMetrics(ctx context.Context, namespace string, metrics []Metric) error {
+	for start := 0; start < len(metrics); start += 20 {
+		end := start + 20
+		if end > len(metrics) {
+			end = len(metrics)
+		}
+		form := url.Values{
+			"Action":    {"PutMetricData"},
+			"Version":   {"2010-08-01"},
+			"Namespace": {namespace},
+		}
+		for i, m := range metrics[start:end] {
+			prefix := "MetricData.member." + strconv.Itoa(i+1) + "."
+			form.Set(prefix+"MetricName", m.Name)
+			form.Set(prefix+"Value", strconv.FormatFloat(m.Value, 'f', -1, 64))
+			if m.Unit != "" {
+				form.Set(prefix+"Unit", m.Unit)
+			}
+			n := 0
+			for name, value := range m.Dimensions {
+				n++
+				dim := prefix + "Dimensions.member." + strconv.Itoa(n) + "."
+				form.Set(dim+"Name", name)
+				form.Set(dim+"Value", value)
+			}
+		}
+		if err := c.call(ctx, "monitoring", "monitoring", form); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// call POSTs a signed query API request to https://<host>.<region>.amazonaws.com/
+func (c *Client) call(ctx context.Context, host, service string, form url.Values) error {
+	creds, err := c.cfg.Credentials.Retrieve(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load AWS credentials: %w", err)
+	}
+	body := form.Encode()
+	endpoint := fmt.Sprintf("https://%s.%s.amazonaws.com/", host, c.cfg.Region)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded; charset=utf-8")
+	sum := sha256.Sum256([]byte(body))
+	if err := c.signer.SignHTTP(ctx, creds, req, hex.EncodeToString(sum[:]), service, c.cfg.Region, time.Now()); err != nil {
+		return fmt.Errorf("failed to sign %s request: %w", service, err)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("%s %s: %w", service, form.Get("Action"), err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("%s %s: status %d: %s", service, form.Get("Action"), resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
@@ -0,0 +1,165 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/expotoworld/expotoworld/backend/catalog-service/internal/db"
+	"github.com/expotoworld/expotoworld/backend/catalog-service/internal/models"
+	"github.com/gin-gonic/gin"
+)
+
+// undoWindow is how long after a destructive action it can still be undone (CATALOG_UNDO_WINDOW_MINUTES)
+func undoWindow() time.Duration {
+	return time.Duration(getEnvInt("CATALOG_UNDO_WINDOW_MINUTES", 60)) * time.Minute
+}
+
+// activityItem condenses an audit entry for the feed
+func activityItem(e *models.AuditEntry, window time.Duration, now time.Time) models.ActivityItem {
+	item := models.ActivityItem{
+		AuditID:         e.ID,
+		OccurredAt:      e.OccurredAt,
+		ActorUserID:     e.ActorUserID,
+		ActorEmail:      e.ActorEmail,
+		EntityType:      e.EntityType,
+		EntityID:        e.EntityID,
+		Action:          e.Action,
+		Route:           e.Route,
+		ChangedFields:   []string{},
+		UndoKind:        e.UndoKind(),
+		UndoneByAuditID: e.UndoneBy,
+		UndoOfAuditID:   e.UndoOf,
+	}
+
+	var changes map[string]json.RawMessage
+	_ = json.Unmarshal(e.Changes, &changes)
+	for k := range changes {
+		item.ChangedFields = append(item.ChangedFields, k)
+	}
+	sort.Strings(item.ChangedFields)
+
+	// Label the entity with its title or name as it was when the action happened
+	snapshot := e.Before
+	if snapshot == nil {
+		snapshot = e.After
+	}
+	var row struct {
+		Title string `json:"title"`
+		Name  string `json:"name"`
+		SKU   string `json:"sku"`
+	}
+	_ = json.Unmarshal(snapshot, &row)
+	switch {
+	case row.Title != "" && row.SKU != "":
+		item.EntityLabel = row.SKU + " " + row.Title
+	case row.Title != "":
+		item.EntityLabel = row.Title
+	default:
+		item.EntityLabel = row.Name
+	}
+
+	if item.UndoKind != "" {
+		expires := e.OccurredAt.Add(window)
+		item.UndoExpiresAt = &expires
+		item.Undoable = e.UndoneBy == nil && !e.Superseded && now.Before(expires)
+	}
+	return item
+}
+
+// GetActivityFeed handles GET /admin/activity: recent admin writes, newest first, with whether each can
+// still be undone. Filters: entity_type, entity_id, actor_user_id; paginate with limit (default 50,
+// max 200) and before_id.
+func (h *Handler) GetActivityFeed(c *gin.Context) {
+	f := models.AuditFilter{
+		EntityType: c.Query("entity_type"),
+		EntityID:   c.Query("entity_id"),
+		ActorID:    c.Query("actor_user_id"),
+		Limit:      50,
+	}
+	if v := c.Query("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 || n > 200 {
+			writeError(c, http.StatusBadRequest, "limit must be between 1 and 200")
+			return
+		}
+		f.Limit = n
+	}
+	if v := c.Query("before_id"); v != "" {
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || n <= 0 {
+			writeError(c, http.StatusBadRequest, "Invalid before_id")
+			return
+		}
+		f.BeforeID = n
+	}
+
+	entries, err := h.db.ListAuditEntries(c.Request.Context(), f)
+	if err != nil {
+		log.Printf("Error listing activity: %v", err)
+		writeError(c, http.StatusInternalServerError, "Failed to fetch activity")
+		return
+	}
+	window, now := undoWindow(), time.Now()
+	items := make([]models.ActivityItem, len(entries))
+	for i := range entries {
+		items[i] = activityItem(&entries[i], window, now)
+	}
+	resp := gin.H{"activity": items, "undo_window_minutes": int(window / time.Minute)}
+	if len(entries) == f.Limit {
+		resp["next_before_id"] = entries[len(entries)-1].ID
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// UndoActivity handles POST /admin/activity/:id/undo, reversing a product or category soft delete or a
+// product's category reassignment. The undo is recorded as its own audit entry linked to the original
+// (undo_of_audit_id / undone_by_audit_id).
+func (h *Handler) UndoActivity(c *gin.Context) {
+	auditID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || auditID <= 0 {
+		writeError(c, http.StatusBadRequest, "Invalid audit id")
+		return
+	}
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	entry, err := h.db.UndoAuditEntry(ctx, auditID, undoWindow(), newAuditEntry(c, http.StatusOK), auditChanges)
+	switch {
+	case errors.Is(err, db.ErrAuditEntryNotFound):
+		writeError(c, http.StatusNotFound, "Activity not found")
+		return
+	case errors.Is(err, db.ErrNotUndoable):
+		writeErrorDetails(c, http.StatusBadRequest, "NOT_UNDOABLE", "This action cannot be undone", nil)
+		return
+	case errors.Is(err, db.ErrAlreadyUndone):
+		writeErrorDetails(c, http.StatusConflict, "ALREADY_UNDONE", "This action was already undone", nil)
+		return
+	case errors.Is(err, db.ErrUndoExpired):
+		writeErrorDetails(c, http.StatusConflict, "UNDO_EXPIRED", "The undo window for this action has passed", nil)
+		return
+	case errors.Is(err, db.ErrUndoConflict):
+		writeErrorDetails(c, http.StatusConflict, "UNDO_CONFLICT", "The item was changed after this action; undo it manually", nil)
+		return
+	case errors.Is(err, db.ErrTaxClassRequired):
+		writeErrorDetails(c, http.StatusBadRequest, "INVALID_TAX_CLASS", "Set a tax_class before restoring this product", nil)
+		return
+	case err != nil:
+		log.Printf("Failed to undo audit entry %d: %v", auditID, err)
+		writeError(c, http.StatusInternalServerError, "Failed to undo action")
+		return
+	}
+	c.Set(auditRecordedKey, true)
+	h.publishCatalogEvent(ctx, entry.EntityType, entry.Action, *entry.EntityID, entry.Before, entry.After)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":  "Action undone",
+		"undo":     activityItem(entry, undoWindow(), time.Now()),
+		"audit_id": auditID,
+	})
+}
@@ -0,0 +1,235 @@
+package api
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/expotoworld/expotoworld/backend/catalog-service/internal/db"
+	"github.com/gin-gonic/gin"
+)
+
+// adminScope is the admin_scope JWT claim issued by auth-service to regional managers.
+// Admins without the claim manage everything.
+type adminScope struct {
+	RegionIDs []int
+	StoreIDs  []int
+	// stores is every store in scope, resolved by AdminScopeMiddleware
+	stores map[int]bool
+}
+
+// parseAdminScope reads the admin_scope claim. A malformed claim yields an empty scope (no access)
+// rather than an unrestricted admin.
+func parseAdminScope(v interface{}) *adminScope {
+	s := &adminScope{}
+	m, _ := v.(map[string]interface{})
+	s.RegionIDs = claimIDs(m["region_ids"])
+	s.StoreIDs = claimIDs(m["store_ids"])
+	return s
+}
+
+func claimIDs(v interface{}) []int {
+	arr, _ := v.([]interface{})
+	ids := make([]int, 0, len(arr))
+	for _, x := range arr {
+		if f, ok := x.(float64); ok && f > 0 {
+			ids = append(ids, int(f))
+		}
+	}
+	return ids
+}
+
+// getAdminScope returns the request's admin scope, or nil for unrestricted admins
+func getAdminScope(c *gin.Context) *adminScope {
+	v, ok := c.Get("admin_scope")
+	if !ok {
+		return nil
+	}
+	s, _ := v.(*adminScope)
+	return s
+}
+
+// allowsStore reports whether the scope covers the store (resolved by AdminScopeMiddleware)
+func (s *adminScope) allowsStore(storeID *int) bool {
+	return storeID != nil && s.stores[*storeID]
+}
+
+// storeIDs lists the resolved stores in scope
+func (s *adminScope) storeIDs() []int {
+	ids := make([]int, 0, len(s.stores))
+	for id := range s.stores {
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// scopedAdminRoutes lists the admin routes ("METHOD path") open to regional admins; global settings
+// (categories, organizations, regions, rates, experiments...) stay with unrestricted admins.
+// Product sourcing and logistics span regions, so only their reads are listed. New admin routes
+// are closed to regional admins until they are added here.
+var scopedAdminRoutes = map[string]bool{
+	"POST /api/v1/products":                                 true,
+	"PUT /api/v1/products/:id":                              true,
+	"DELETE /api/v1/products/:id":                           true,
+	"POST /api/v1/products/:id/restore":                     true,
+	"POST /api/v1/products/:id/clone":                       true,
+	"POST /api/v1/products/:id/stock-adjustments":           true,
+	"GET /api/v1/products/:id/stock-history":                true,
+	"PUT /api/v1/products/:id/low-stock-threshold":          true,
+	"POST /api/v1/products/:id/image":                       true,
+	"POST /api/v1/products/:id/images":                      true,
+	"POST /api/v1/products/:id/images/presign":              true,
+	"POST /api/v1/products/:id/images/confirm":              true,
+	"GET /api/v1/products/:id/images":                       true,
+	"PUT /api/v1/products/:id/images/reorder":               true,
+	"DELETE /api/v1/products/:id/images/:image_id":          true,
+	"PUT /api/v1/products/:id/images/:image_id/primary":     true,
+	"POST /api/v1/products/:id/variants":                    true,
+	"PUT /api/v1/products/:id/variants/:variant_id":         true,
+	"DELETE /api/v1/products/:id/variants/:variant_id":      true,
+	"GET /api/v1/products/:id/related":                      true,
+	"PUT /api/v1/products/:id/related":                      true,
+	"GET /api/v1/products/:id/food-label":                   true,
+	"PUT /api/v1/products/:id/food-label":                   true,
+	"DELETE /api/v1/products/:id/food-label":                true,
+	"GET /api/v1/products/:id/region-prices":                true,
+	"PUT /api/v1/products/:id/region-prices":                true,
+	"GET /api/v1/products/:id/translations":                 true,
+	"POST /api/v1/products/:id/translations/draft":          true,
+	"PUT /api/v1/products/:id/translations/:locale":         true,
+	"GET /api/v1/products/:id/sourcing":                     true,
+	"GET /api/v1/products/:id/logistics":                    true,
+	"GET /api/v1/products/:id/competitor-prices":            true,
+	"PUT /api/v1/products/:id/competitor-prices":            true,
+	"DELETE /api/v1/products/:id/competitor-prices/:source": true,
+	"PUT /api/v1/stores/:id":                                true,
+	"POST /api/v1/stores/:id/image":                         true,
+	"PUT /api/v1/stores/:id/low-stock-threshold":            true,
+	"PUT /api/v1/stores/:id/shelf-layout":                   true,
+	"DELETE /api/v1/stores/:id/shelf-layout":                true,
+	"GET /api/v1/stores/:id/partners":                       true,
+	"POST /api/v1/stores/:id/partners":                      true,
+	"GET /api/v1/regions":                                   true,
+	"GET /api/v1/admin/products/low-stock":                  true,
+	"GET /api/v1/admin/stores/:id/shelf-map":                true,
+	"GET /api/v1/admin/stores/:id/qr":                       true,
+	"POST /api/v1/admin/stores/:id/qr":                      true,
+}
+
+// scopedAdminRoute reports whether a regional admin may call the route at all
+func scopedAdminRoute(method, path string) bool {
+	return scopedAdminRoutes[method+" "+path]
+}
+
+// resolveAdminScope loads the stores covered by the request's scope; nil scope for unrestricted admins.
+// It writes the error response itself when ok is false.
+func (h *Handler) resolveAdminScope(c *gin.Context) (*adminScope, bool) {
+	scope := getAdminScope(c)
+	if scope == nil || scope.stores != nil {
+		return scope, true
+	}
+	stores, err := h.db.StoresInAdminScope(c.Request.Context(), scope.RegionIDs, scope.StoreIDs)
+	if err != nil {
+		log.Printf("Failed to resolve admin scope: %v", err)
+		writeError(c, http.StatusInternalServerError, "Failed to resolve admin scope")
+		return nil, false
+	}
+	scope.stores = make(map[int]bool, len(stores))
+	for _, id := range stores {
+		scope.stores[id] = true
+	}
+	return scope, true
+}
+
+// AdminScopeMiddleware confines regional admins to their stores and those stores' products
+// (use after AdminMiddleware). Request bodies that name a store are checked by the handlers.
+func (h *Handler) AdminScopeMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if getAdminScope(c) == nil {
+			c.Next()
+			return
+		}
+		path := c.FullPath()
+		if !scopedAdminRoute(c.Request.Method, path) {
+			writeError(c, http.StatusForbidden, "Not available to regionally scoped admins")
+			c.Abort()
+			return
+		}
+		scope, ok := h.resolveAdminScope(c)
+		if !ok {
+			c.Abort()
+			return
+		}
+
+		var storeID *int
+		switch {
+		case strings.HasPrefix(path, "/api/v1/products/:id"):
+			productID, err := strconv.Atoi(c.Param("id"))
+			if err != nil {
+				writeError(c, http.StatusBadRequest, "Invalid product ID format")
+				c.Abort()
+				return
+			}
+			if storeID, err = h.db.GetProductStoreID(c.Request.Context(), productID); err != nil {
+				if errors.Is(err, db.ErrProductNotFound) {
+					writeError(c, http.StatusNotFound, "Product not found")
+				} else {
+					log.Printf("Failed to check product %d scope: %v", productID, err)
+					writeError(c, http.StatusInternalServerError, "Failed to check admin scope")
+				}
+				c.Abort()
+				return
+			}
+		case strings.HasPrefix(path, "/api/v1/stores/:id"), strings.HasPrefix(path, "/api/v1/admin/stores/:id"):
+			id, err := strconv.Atoi(c.Param("id"))
+			if err != nil {
+				writeError(c, http.StatusBadRequest, "Invalid store id")
+				c.Abort()
+				return
+			}
+			storeID = &id
+		default:
+			c.Next()
+			return
+		}
+		if !scope.allowsStore(storeID) {
+			writeError(c, http.StatusForbidden, "Outside your admin scope")
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// requireStoreInScope rejects a store taken from a request body that the regional admin doesn't manage
+func (h *Handler) requireStoreInScope(c *gin.Context, storeID *int) bool {
+	scope, ok := h.resolveAdminScope(c)
+	if !ok {
+		return false
+	}
+	if scope != nil && !scope.allowsStore(storeID) {
+		writeError(c, http.StatusForbidden, "store_id is outside your admin scope")
+		return false
+	}
+	return true
+}
+
+// keepsStoreInScope reports whether a regional admin may move a store to regionID without losing it
+func (s *adminScope) keepsStoreInScope(storeID int, regionID *int) bool {
+	for _, id := range s.StoreIDs {
+		if id == storeID {
+			return true
+		}
+	}
+	if regionID == nil {
+		return false
+	}
+	for _, id := range s.RegionIDs {
+		if id == *regionID {
+			return true
+		}
+	}
+	return false
+}
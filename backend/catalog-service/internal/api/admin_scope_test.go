@@ -0,0 +1,116 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestScopedAdminRoute(t *testing.T) {
+	tests := []struct {
+		method, path string
+		want         bool
+	}{
+		{http.MethodPost, "/api/v1/products", true},
+		{http.MethodPut, "/api/v1/products/:id", true},
+		{http.MethodPost, "/api/v1/products/:id/images/presign", true},
+		{http.MethodGet, "/api/v1/products/:id/sourcing", true},
+		{http.MethodPost, "/api/v1/products/:id/sourcing", false},
+		{http.MethodPost, "/api/v1/products/:id/logistics", false},
+		{http.MethodPut, "/api/v1/stores/:id", true},
+		{http.MethodDelete, "/api/v1/stores/:id", false},
+		{http.MethodPost, "/api/v1/stores", false},
+		{http.MethodPut, "/api/v1/stores/:id/shelf-layout", true},
+		{http.MethodGet, "/api/v1/regions", true},
+		{http.MethodPost, "/api/v1/regions", false},
+		{http.MethodGet, "/api/v1/admin/stores/:id/shelf-map", true},
+		{http.MethodPost, "/api/v1/admin/stores/:id/qr", true},
+		{http.MethodPost, "/api/v1/categories", false},
+		{http.MethodGet, "/api/v1/organizations", false},
+		{http.MethodGet, "/api/v1/admin/audit-log", false},
+		// Prefixes of allowed routes are not allowed themselves
+		{http.MethodGet, "/api/v1/products/:id/images/extra", false},
+		{http.MethodGet, "/api/v1/stores/:id/anything", false},
+	}
+	for _, tt := range tests {
+		if got := scopedAdminRoute(tt.method, tt.path); got != tt.want {
+			t.Errorf("scopedAdminRoute(%s %s) = %v, want %v", tt.method, tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestParseAdminScope(t *testing.T) {
+	tests := []struct {
+		name    string
+		claim   interface{}
+		regions []int
+		stores  []int
+	}{
+		{"regions and stores", map[string]interface{}{"region_ids": []interface{}{1.0, 2.0}, "store_ids": []interface{}{7.0}}, []int{1, 2}, []int{7}},
+		{"invalid ids dropped", map[string]interface{}{"region_ids": []interface{}{"1", -3.0, 0.0, 4.0}}, []int{4}, []int{}},
+		{"ids not a list", map[string]interface{}{"region_ids": 3.0, "store_ids": "7"}, []int{}, []int{}},
+		{"claim not an object", "everything", []int{}, []int{}},
+		{"null claim", nil, []int{}, []int{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := parseAdminScope(tt.claim)
+			if s == nil {
+				t.Fatal("malformed claim must yield an empty scope, not an unrestricted admin")
+			}
+			if !reflect.DeepEqual(s.RegionIDs, tt.regions) || !reflect.DeepEqual(s.StoreIDs, tt.stores) {
+				t.Errorf("got regions %v stores %v, want %v %v", s.RegionIDs, s.StoreIDs, tt.regions, tt.stores)
+			}
+		})
+	}
+}
+
+func TestAdminScopeMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h := &Handler{}
+
+	tests := []struct {
+		name   string
+		scope  *adminScope
+		method string
+		target string
+		want   int
+	}{
+		{"unrestricted admin on global route", nil, http.MethodPost, "/api/v1/categories", http.StatusNoContent},
+		{"unrestricted admin on any store", nil, http.MethodPut, "/api/v1/stores/9", http.StatusNoContent},
+		{"scoped admin on global route", &adminScope{stores: map[int]bool{3: true}}, http.MethodPost, "/api/v1/categories", http.StatusForbidden},
+		{"scoped admin deleting a store", &adminScope{stores: map[int]bool{3: true}}, http.MethodDelete, "/api/v1/stores/3", http.StatusForbidden},
+		{"scoped admin on own store", &adminScope{stores: map[int]bool{3: true}}, http.MethodPut, "/api/v1/stores/3", http.StatusNoContent},
+		{"scoped admin on own store QR", &adminScope{stores: map[int]bool{3: true}}, http.MethodGet, "/api/v1/admin/stores/3/qr", http.StatusNoContent},
+		{"scoped admin on other store", &adminScope{stores: map[int]bool{3: true}}, http.MethodPut, "/api/v1/stores/4/shelf-layout", http.StatusForbidden},
+		{"empty scope", &adminScope{stores: map[int]bool{}}, http.MethodPut, "/api/v1/stores/3", http.StatusForbidden},
+		{"scoped admin listing regions", &adminScope{stores: map[int]bool{}}, http.MethodGet, "/api/v1/regions", http.StatusNoContent},
+		{"invalid store id", &adminScope{stores: map[int]bool{3: true}}, http.MethodPut, "/api/v1/stores/abc", http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := gin.New()
+			r.Use(func(c *gin.Context) {
+				if tt.scope != nil {
+					c.Set("admin_scope", tt.scope)
+				}
+			}, h.AdminScopeMiddleware())
+			ok := func(c *gin.Context) { c.Status(http.StatusNoContent) }
+			r.POST("/api/v1/categories", ok)
+			r.PUT("/api/v1/stores/:id", ok)
+			r.DELETE("/api/v1/stores/:id", ok)
+			r.PUT("/api/v1/stores/:id/shelf-layout", ok)
+			r.GET("/api/v1/admin/stores/:id/qr", ok)
+			r.GET("/api/v1/regions", ok)
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(tt.method, tt.target, nil))
+			if w.Code != tt.want {
+				t.Fatalf("%s %s: got %d, want %d", tt.method, tt.target, w.Code, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,294 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/expotoworld/expotoworld/backend/catalog-service/internal/logging"
+	"github.com/expotoworld/expotoworld/backend/catalog-service/internal/models"
+	"github.com/gin-gonic/gin"
+)
+
+// auditBodyLimit caps the request/response bodies kept for the audit log
+const auditBodyLimit = 64 << 10
+
+// auditEntity describes how to snapshot an entity: its table, primary key and the key carrying the
+// new id in a create response. Entities without a table are logged without before/after.
+type auditEntity struct {
+	Type      string
+	Table     string
+	PK        string
+	CreateKey string
+}
+
+// auditEntities maps the first path segment under /api/v1 to the entity it writes
+var auditEntities = map[string]auditEntity{
+	"products":         {Type: "product", Table: "admin_products", PK: "product_id", CreateKey: "product_id"},
+	"categories":       {Type: "category", Table: "admin_product_categories", PK: "category_id", CreateKey: "id"},
+	"subcategories":    {Type: "subcategory", Table: "admin_subcategories", PK: "subcategory_id", CreateKey: "id"},
+	"stores":           {Type: "store", Table: "admin_stores", PK: "store_id", CreateKey: "id"},
+	"organizations":    {Type: "organization", Table: "admin_organizations", PK: "org_id", CreateKey: "org_id"},
+	"regions":          {Type: "region", Table: "admin_regions", PK: "region_id", CreateKey: "region_id"},
+	"price-lists":      {Type: "price_list", Table: "app_price_lists", PK: "price_list_id"},
+	"experiments":      {Type: "experiment", Table: "app_experiments", PK: "experiment_key"},
+	"commission-rates": {Type: "commission_rate", Table: "app_commission_rates", PK: "rate_id", CreateKey: "rate_id"},
+	"changesets":       {Type: "changeset", Table: "app_changesets", PK: "changeset_id", CreateKey: "changeset_id"},
+}
+
+// auditTarget resolves the entity, its id and the action of an admin write from the matched route.
+// Sub-resources (images, variants, partners...) are recorded against their parent entity.
+func auditTarget(c *gin.Context) (ent auditEntity, id string, action string) {
+	segs := strings.Split(strings.Trim(strings.TrimPrefix(c.FullPath(), "/api/v1"), "/"), "/")
+	ent, ok := auditEntities[segs[0]]
+	if !ok {
+		var name []string
+		for _, s := range segs {
+			if !strings.HasPrefix(s, ":") {
+				name = append(name, strings.ReplaceAll(s, "-", "_"))
+			}
+		}
+		ent = auditEntity{Type: strings.Join(name, "_")}
+	}
+
+	// POST /categories/:id/subcategories creates a subcategory rather than updating the category
+	if len(segs) == 3 && segs[0] == "categories" && segs[2] == "subcategories" && c.Request.Method == http.MethodPost {
+		return auditEntities["subcategories"], "", models.AuditCreate
+	}
+
+	var params []string
+	for _, s := range segs[1:] {
+		if !strings.HasPrefix(s, ":") {
+			break
+		}
+		params = append(params, c.Param(s[1:]))
+	}
+	id = strings.Join(params, "/")
+
+	switch {
+	case len(segs) == 1 && c.Request.Method == http.MethodPost:
+		return ent, "", models.AuditCreate
+	case len(segs) == 2 && id != "" && c.Request.Method == http.MethodDelete:
+		return ent, id, models.AuditDelete
+	}
+	return ent, id, models.AuditUpdate
+}
+
+// auditResponseWriter keeps a copy of the response body so created ids can be read from it
+type auditResponseWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *auditResponseWriter) Write(b []byte) (int, error) {
+	if w.body.Len() < auditBodyLimit {
+		w.body.Write(b)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// readAuditBody returns the JSON request body (restoring it for the handler); nil for other payloads
+func readAuditBody(c *gin.Context) json.RawMessage {
+	if c.Request.Body == nil || !strings.HasPrefix(c.ContentType(), "application/json") ||
+		c.Request.ContentLength <= 0 || c.Request.ContentLength > auditBodyLimit {
+		return nil
+	}
+	b, err := io.ReadAll(c.Request.Body)
+	c.Request.Body = io.NopCloser(bytes.NewReader(b))
+	if err != nil || !json.Valid(b) {
+		return nil
+	}
+	return b
+}
+
+// auditChanges lists the top-level fields that differ between two row snapshots
+func auditChanges(before, after json.RawMessage) json.RawMessage {
+	var b, a map[string]json.RawMessage
+	_ = json.Unmarshal(before, &b)
+	_ = json.Unmarshal(after, &a)
+	changes := map[string]map[string]json.RawMessage{}
+	diff := func(k string) {
+		if k == "updated_at" {
+			return
+		}
+		from, inBefore := b[k]
+		to, inAfter := a[k]
+		if inBefore && inAfter && bytes.Equal(from, to) {
+			return
+		}
+		if from == nil {
+			from = json.RawMessage("null")
+		}
+		if to == nil {
+			to = json.RawMessage("null")
+		}
+		changes[k] = map[string]json.RawMessage{"from": from, "to": to}
+	}
+	for k := range b {
+		diff(k)
+	}
+	for k := range a {
+		if _, seen := b[k]; !seen {
+			diff(k)
+		}
+	}
+	if len(changes) == 0 {
+		return nil
+	}
+	out, _ := json.Marshal(changes)
+	return out
+}
+
+func contextString(c *gin.Context, key string) *string {
+	v, ok := c.Get(key)
+	if !ok || v == nil {
+		return nil
+	}
+	s := fmt.Sprint(v)
+	return &s
+}
+
+// auditRecordedKey is set by handlers that write their own audit entry (e.g. undo) so the middleware
+// does not record a second one
+const auditRecordedKey = "audit_recorded"
+
+// newAuditEntry starts an entry with the actor and request of c
+func newAuditEntry(c *gin.Context, status int) *models.AuditEntry {
+	entry := &models.AuditEntry{
+		ActorUserID: contextString(c, "user_id"),
+		ActorEmail:  contextString(c, "email"),
+		ActorRole:   contextString(c, "role"),
+		Method:      c.Request.Method,
+		Route:       c.FullPath(),
+		Path:        c.Request.URL.Path,
+		Status:      status,
+	}
+	if ip := c.ClientIP(); ip != "" {
+		entry.ClientIP = &ip
+	}
+	return entry
+}
+
+// AuditMiddleware records every successful admin write in catalog_audit_log with the acting user and
+// the entity row before and after the change (use after the auth/admin middlewares). Failures to write
+// the audit entry are logged and never fail the request.
+func (h *Handler) AuditMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if h.db == nil || c.Request.Method == http.MethodGet || c.Request.Method == http.MethodHead || c.Request.Method == http.MethodOptions {
+			c.Next()
+			return
+		}
+
+		ent, id, action := auditTarget(c)
+		body := readAuditBody(c)
+		var before json.RawMessage
+		if ent.Table != "" && id != "" {
+			var err error
+			if before, err = h.db.SnapshotRow(c.Request.Context(), ent.Table, ent.PK, id); err != nil {
+				log.Printf("[AUDIT] Failed to snapshot %s %s: %v", ent.Type, id, err)
+			}
+		}
+		var rw *auditResponseWriter
+		if action == models.AuditCreate {
+			rw = &auditResponseWriter{ResponseWriter: c.Writer}
+			c.Writer = rw
+		}
+
+		c.Next()
+
+		status := c.Writer.Status()
+		if status >= http.StatusBadRequest || c.GetBool(auditRecordedKey) {
+			return
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		if rw != nil && ent.CreateKey != "" {
+			var resp map[string]interface{}
+			if json.Unmarshal(rw.body.Bytes(), &resp) == nil && resp[ent.CreateKey] != nil {
+				id = fmt.Sprint(resp[ent.CreateKey])
+			}
+		}
+		var after json.RawMessage
+		if ent.Table != "" && id != "" {
+			var err error
+			if after, err = h.db.SnapshotRow(ctx, ent.Table, ent.PK, id); err != nil {
+				log.Printf("[AUDIT] Failed to snapshot %s %s: %v", ent.Type, id, err)
+			}
+		}
+
+		entry := newAuditEntry(c, status)
+		entry.EntityType = ent.Type
+		entry.Action = action
+		entry.Before = before
+		entry.After = after
+		entry.Changes = auditChanges(before, after)
+		entry.RequestBody = body
+		if id != "" {
+			entry.EntityID = &id
+		}
+		if err := h.db.InsertAuditEntry(ctx, entry); err != nil {
+			logging.LogKV("error", "AuditLogWriteFailed", map[string]interface{}{"route": entry.Route, "entity_id": id, "error": err.Error()})
+		}
+		h.publishCatalogEvent(ctx, ent.Type, action, id, before, after)
+	}
+}
+
+// GetAuditLog handles GET /admin/audit-log
+// Filters: entity_type, entity_id, actor_user_id, action, field (entries that changed it), from/to (RFC3339);
+// paginate with limit (default 100, max 500) and before_id.
+func (h *Handler) GetAuditLog(c *gin.Context) {
+	f := models.AuditFilter{
+		EntityType: c.Query("entity_type"),
+		EntityID:   c.Query("entity_id"),
+		ActorID:    c.Query("actor_user_id"),
+		Action:     c.Query("action"),
+		Field:      c.Query("field"),
+		Limit:      100,
+	}
+	if v := c.Query("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 || n > 500 {
+			writeError(c, http.StatusBadRequest, "limit must be between 1 and 500")
+			return
+		}
+		f.Limit = n
+	}
+	if v := c.Query("before_id"); v != "" {
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || n <= 0 {
+			writeError(c, http.StatusBadRequest, "Invalid before_id")
+			return
+		}
+		f.BeforeID = n
+	}
+	for key, dst := range map[string]**time.Time{"from": &f.From, "to": &f.To} {
+		if v := c.Query(key); v != "" {
+			t, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				writeError(c, http.StatusBadRequest, "Invalid "+key+" (expected RFC3339)")
+				return
+			}
+			*dst = &t
+		}
+	}
+
+	entries, err := h.db.ListAuditEntries(c.Request.Context(), f)
+	if err != nil {
+		log.Printf("Error listing audit log: %v", err)
+		writeError(c, http.StatusInternalServerError, "Failed to fetch audit log")
+		return
+	}
+	resp := gin.H{"entries": entries}
+	if len(entries) == f.Limit {
+		resp["next_before_id"] = entries[len(entries)-1].ID
+	}
+	c.JSON(http.StatusOK, resp)
+}
@@ -0,0 +1,68 @@
+package api
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/expotoworld/expotoworld/backend/catalog-service/internal/db"
+	"github.com/expotoworld/expotoworld/backend/catalog-service/internal/models"
+	"github.com/gin-gonic/gin"
+)
+
+// checkProductBarcode normalizes the product's barcode (blank clears it); nil means valid
+func checkProductBarcode(p *models.Product) *models.FieldError {
+	if p.Barcode == nil {
+		return nil
+	}
+	if *p.Barcode == "" {
+		p.Barcode = nil
+		return nil
+	}
+	code, ok := models.NormalizeBarcode(*p.Barcode)
+	if !ok {
+		return &models.FieldError{Field: "barcode", Code: "invalid_barcode", Message: "must be an EAN-8, UPC-A, EAN-13 or GTIN-14 with a valid check digit"}
+	}
+	p.Barcode = &code
+	return nil
+}
+
+// writeBarcodeTaken writes the 409 for a barcode used by another product
+func writeBarcodeTaken(c *gin.Context, p models.Product) {
+	writeErrorDetails(c, http.StatusConflict, "DUPLICATE_BARCODE",
+		"Barcode '"+*p.Barcode+"' is already assigned to another product", nil)
+}
+
+// LookupProductByBarcode handles GET /products/lookup?barcode=&store_id= for the unmanned-store scanner.
+// UPC-A and EAN-13 forms of the same code match; the response is the same as GET /products/:id.
+func (h *Handler) LookupProductByBarcode(c *gin.Context) {
+	code, ok := models.NormalizeBarcode(c.Query("barcode"))
+	if !ok {
+		writeFieldErrors(c, models.FieldError{Field: "barcode", Code: "invalid_barcode"})
+		return
+	}
+	var storeID *int
+	if v := c.Query("store_id"); v != "" {
+		id, err := strconv.Atoi(v)
+		if err != nil {
+			writeError(c, http.StatusBadRequest, "Invalid store_id")
+			return
+		}
+		storeID = &id
+	}
+
+	productID, err := h.db.FindProductByBarcode(c.Request.Context(), code, storeID)
+	if errors.Is(err, db.ErrProductNotFound) {
+		writeErrorDetails(c, http.StatusNotFound, "", "Product not found", gin.H{"barcode": code})
+		return
+	}
+	if err != nil {
+		log.Printf("Error looking up barcode %s: %v", code, err)
+		writeError(c, http.StatusInternalServerError, "Failed to look up barcode")
+		return
+	}
+
+	c.Params = append(c.Params, gin.Param{Key: "id", Value: strconv.Itoa(productID)})
+	h.GetProduct(c)
+}
@@ -0,0 +1,277 @@
+package api
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/expotoworld/expotoworld/backend/catalog-service/internal/db"
+	"github.com/expotoworld/expotoworld/backend/catalog-service/internal/logging"
+	"github.com/expotoworld/expotoworld/backend/catalog-service/internal/models"
+	"github.com/gin-gonic/gin"
+)
+
+// catalogExportTimeout bounds one snapshot run
+const catalogExportTimeout = 30 * time.Minute
+
+// catalogExportBucket is the S3 bucket receiving snapshots; exports are disabled when unset so
+// analytics data never lands in the public media bucket by accident
+func catalogExportBucket() string {
+	return os.Getenv("CATALOG_EXPORT_BUCKET")
+}
+
+func catalogExportPrefix() string {
+	prefix := strings.Trim(os.Getenv("CATALOG_EXPORT_PREFIX"), "/")
+	if prefix == "" {
+		prefix = "catalog-snapshots"
+	}
+	return prefix + "/" + models.CatalogExportSchemaVersion
+}
+
+// StartCatalogExportJobs writes the nightly catalog snapshot once the UTC hour reaches
+// CATALOG_EXPORT_HOUR_UTC. Instances race for the day's run through the catalog_exports table, so
+// only one of them exports.
+func (h *Handler) StartCatalogExportJobs(ctx context.Context) {
+	if catalogExportBucket() == "" {
+		log.Printf("[CATALOG_EXPORT] CATALOG_EXPORT_BUCKET not set; nightly snapshots disabled")
+		return
+	}
+	hour := getEnvInt("CATALOG_EXPORT_HOUR_UTC", 2)
+	interval := time.Duration(getEnvInt("CATALOG_EXPORT_POLL_MINUTES", 15)) * time.Minute
+
+	run := func() {
+		now := time.Now().UTC()
+		if now.Hour() < hour {
+			return
+		}
+		claimCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+		export, ok, err := h.db.ClaimScheduledCatalogExport(claimCtx, now)
+		cancel()
+		if err != nil {
+			logging.LogKV("error", "CatalogExportClaimFailed", map[string]interface{}{"error": err.Error()})
+			return
+		}
+		if ok {
+			h.runCatalogExport(ctx, export)
+		}
+	}
+
+	run()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			run()
+		}
+	}
+}
+
+// runCatalogExport writes every dataset as gzip-compressed JSON Lines under
+// <prefix>/v1/<date>/<export_id>/, then the manifest, and finally repoints <prefix>/v1/latest.json.
+// Consumers should only read snapshots listed by a manifest.
+func (h *Handler) runCatalogExport(parent context.Context, export *models.CatalogExport) {
+	ctx, cancel := context.WithTimeout(parent, catalogExportTimeout)
+	defer cancel()
+
+	dir := fmt.Sprintf("%s/%s/%d", catalogExportPrefix(), export.SnapshotDate, export.ID)
+	manifestKey := dir + "/manifest.json"
+	var datasets []models.CatalogExportDataset
+	err := func() error {
+		s3Client, err := h.aws.S3(ctx)
+		if err != nil {
+			return err
+		}
+
+		files := make(map[string]*snapshotFile, len(db.CatalogExportDatasets))
+		for _, ds := range db.CatalogExportDatasets {
+			files[ds.Name] = newSnapshotFile()
+		}
+		counts, err := h.db.StreamCatalogSnapshot(ctx, func(ds db.CatalogExportDataset, row []byte) error {
+			return files[ds.Name].writeRow(row)
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, ds := range db.CatalogExportDatasets {
+			f := files[ds.Name]
+			if err := f.close(); err != nil {
+				return err
+			}
+			key := fmt.Sprintf("%s/%s.jsonl.gz", dir, ds.Name)
+			if err := putSnapshotObject(ctx, s3Client, key, f.buf.Bytes(), "application/gzip"); err != nil {
+				return err
+			}
+			datasets = append(datasets, models.CatalogExportDataset{
+				Name:   ds.Name,
+				Key:    key,
+				Rows:   counts[ds.Name],
+				Bytes:  int64(f.buf.Len()),
+				SHA256: hex.EncodeToString(f.sum.Sum(nil)),
+			})
+		}
+
+		manifest, err := json.MarshalIndent(models.CatalogExportManifest{
+			SchemaVersion: models.CatalogExportSchemaVersion,
+			ExportID:      export.ID,
+			SnapshotDate:  export.SnapshotDate,
+			GeneratedAt:   time.Now().UTC(),
+			Format:        "jsonl.gz",
+			Datasets:      datasets,
+		}, "", "  ")
+		if err != nil {
+			return err
+		}
+		if err := putSnapshotObject(ctx, s3Client, manifestKey, manifest, "application/json"); err != nil {
+			return err
+		}
+		return putSnapshotObject(ctx, s3Client, catalogExportPrefix()+"/latest.json", manifest, "application/json")
+	}()
+
+	if err != nil {
+		manifestKey = ""
+		logging.LogKV("error", "CatalogExportFailed", map[string]interface{}{"export_id": export.ID, "error": err.Error()})
+	} else {
+		logging.LogKV("info", "CatalogExportCompleted", map[string]interface{}{"export_id": export.ID, "manifest_key": manifestKey, "datasets": len(datasets)})
+	}
+	saveCtx, saveCancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer saveCancel()
+	if ferr := h.db.FinishCatalogExport(saveCtx, export.ID, manifestKey, datasets, err); ferr != nil {
+		logging.LogKV("error", "CatalogExportSaveFailed", map[string]interface{}{"export_id": export.ID, "error": ferr.Error()})
+	}
+}
+
+// snapshotFile gzips JSON Lines in memory while hashing the compressed output
+type snapshotFile struct {
+	buf bytes.Buffer
+	sum hash.Hash
+	gz  *gzip.Writer
+}
+
+func newSnapshotFile() *snapshotFile {
+	f := &snapshotFile{sum: sha256.New()}
+	f.gz = gzip.NewWriter(io.MultiWriter(&f.buf, f.sum))
+	return f
+}
+
+func (f *snapshotFile) writeRow(row []byte) error {
+	if _, err := f.gz.Write(row); err != nil {
+		return err
+	}
+	_, err := f.gz.Write([]byte{'\n'})
+	return err
+}
+
+func (f *snapshotFile) close() error {
+	return f.gz.Close()
+}
+
+func putSnapshotObject(ctx context.Context, client *s3.Client, key string, body []byte, contentType string) error {
+	bucket := catalogExportBucket()
+	length := int64(len(body))
+	_, err := client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket:        &bucket,
+		Key:           &key,
+		Body:          bytes.NewReader(body),
+		ContentLength: &length,
+		ContentType:   &contentType,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to upload %s: %w", key, err)
+	}
+	return nil
+}
+
+// ListCatalogExports handles GET /admin/catalog-exports
+func (h *Handler) ListCatalogExports(c *gin.Context) {
+	limit := 30
+	if v := c.Query("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 || n > 200 {
+			writeError(c, http.StatusBadRequest, "limit must be between 1 and 200")
+			return
+		}
+		limit = n
+	}
+	var beforeID int64
+	if v := c.Query("before_id"); v != "" {
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || n <= 0 {
+			writeError(c, http.StatusBadRequest, "Invalid before_id")
+			return
+		}
+		beforeID = n
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+	exports, err := h.db.ListCatalogExports(ctx, beforeID, limit)
+	if err != nil {
+		log.Printf("Failed to list catalog exports: %v", err)
+		writeError(c, http.StatusInternalServerError, "Failed to list catalog exports")
+		return
+	}
+	resp := gin.H{"exports": exports}
+	if len(exports) == limit {
+		resp["next_before_id"] = exports[len(exports)-1].ID
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// GetCatalogExport handles GET /admin/catalog-exports/:id
+func (h *Handler) GetCatalogExport(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		writeError(c, http.StatusBadRequest, "Invalid export id")
+		return
+	}
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+	export, err := h.db.GetCatalogExport(ctx, id)
+	if errors.Is(err, db.ErrCatalogExportNotFound) {
+		writeError(c, http.StatusNotFound, "Catalog export not found")
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to get catalog export %d: %v", id, err)
+		writeError(c, http.StatusInternalServerError, "Failed to get catalog export")
+		return
+	}
+	c.JSON(http.StatusOK, export)
+}
+
+// TriggerCatalogExport handles POST /admin/catalog-exports; the snapshot is written in the background
+// and the returned export can be polled until it completes
+func (h *Handler) TriggerCatalogExport(c *gin.Context) {
+	if catalogExportBucket() == "" {
+		writeError(c, http.StatusServiceUnavailable, "Catalog exports are not configured")
+		return
+	}
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+	export, err := h.db.CreateManualCatalogExport(ctx)
+	if err != nil {
+		log.Printf("Failed to create catalog export: %v", err)
+		writeError(c, http.StatusInternalServerError, "Failed to start catalog export")
+		return
+	}
+	go h.runCatalogExport(context.Background(), export)
+	c.JSON(http.StatusAccepted, export)
+}
@@ -0,0 +1,59 @@
+package api
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/expotoworld/expotoworld/backend/catalog-service/internal/db"
+	"github.com/gin-gonic/gin"
+)
+
+// writeReorderResult maps reorder errors to HTTP responses
+func writeReorderResult(c *gin.Context, what string, order []db.OrderedID, err error) {
+	switch {
+	case errors.Is(err, db.ErrNotInScope), errors.Is(err, db.ErrDuplicateID):
+		writeError(c, http.StatusBadRequest, err.Error())
+	case err != nil:
+		log.Printf("Failed to reorder %s: %v", what, err)
+		writeError(c, http.StatusInternalServerError, "Failed to reorder "+what)
+	default:
+		c.JSON(http.StatusOK, gin.H{"message": "Display order updated", "order": order})
+	}
+}
+
+// ReorderCategories handles PUT /categories/reorder
+// Body: { "mini_app_type": "RetailStore", "store_id": null, "category_ids": [5, 3, 9] }
+// Renumbers the scope's active categories 1..n in the given order; unlisted categories keep their
+// relative order after the listed ones, so a partial list never conflicts.
+func (h *Handler) ReorderCategories(c *gin.Context) {
+	var req struct {
+		MiniAppType string `json:"mini_app_type" binding:"required"`
+		StoreID     *int   `json:"store_id"`
+		CategoryIDs []int  `json:"category_ids" binding:"required"`
+	}
+	if !bindJSON(c, &req) {
+		return
+	}
+	order, err := h.db.ReorderCategories(c.Request.Context(), req.MiniAppType, req.StoreID, req.CategoryIDs)
+	writeReorderResult(c, "categories", order, err)
+}
+
+// ReorderSubcategories handles PUT /categories/:id/subcategories/reorder
+// Body: { "subcategory_ids": [12, 10, 11] }, with the same semantics as ReorderCategories.
+func (h *Handler) ReorderSubcategories(c *gin.Context) {
+	categoryID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		writeError(c, http.StatusBadRequest, "Invalid category ID")
+		return
+	}
+	var req struct {
+		SubcategoryIDs []int `json:"subcategory_ids" binding:"required"`
+	}
+	if !bindJSON(c, &req) {
+		return
+	}
+	order, err := h.db.ReorderSubcategories(c.Request.Context(), categoryID, req.SubcategoryIDs)
+	writeReorderResult(c, "subcategories", order, err)
+}
@@ -0,0 +1,288 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/expotoworld/expotoworld/backend/catalog-service/internal/db"
+	"github.com/expotoworld/expotoworld/backend/catalog-service/internal/logging"
+	"github.com/expotoworld/expotoworld/backend/catalog-service/internal/models"
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+)
+
+type changesetRequest struct {
+	Name         string     `json:"name" binding:"notblank"`
+	ScheduledFor *time.Time `json:"scheduled_for"`
+}
+
+type changesetItemsRequest struct {
+	Items []models.ChangesetItem `json:"items" binding:"required,min=1,max=1000,dive"`
+}
+
+type changesetScheduleRequest struct {
+	ScheduledFor *time.Time `json:"scheduled_for"` // null unschedules (back to draft)
+}
+
+func parseChangesetID(c *gin.Context) (int64, bool) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		writeError(c, http.StatusBadRequest, "Invalid changeset ID")
+		return 0, false
+	}
+	return id, true
+}
+
+// validateChangesetItem checks one staged item; an empty message means valid
+func validateChangesetItem(it models.ChangesetItem) string {
+	if it.MainPrice != nil && *it.MainPrice < 0 {
+		return fmt.Sprintf("product %d: main_price must be >= 0", it.ProductID)
+	}
+	if it.StrikethroughPrice != nil && *it.StrikethroughPrice < 0 {
+		return fmt.Sprintf("product %d: strikethrough_price must be >= 0", it.ProductID)
+	}
+	if it.ClearStrikethrough && it.StrikethroughPrice != nil {
+		return fmt.Sprintf("product %d: strikethrough_price and clear_strikethrough are exclusive", it.ProductID)
+	}
+	if it.MainPrice == nil && it.StrikethroughPrice == nil && !it.ClearStrikethrough && it.CategoryIDs == nil && it.SubcategoryIDs == nil {
+		return fmt.Sprintf("product %d: no change staged", it.ProductID)
+	}
+	return ""
+}
+
+// writeChangesetError maps changeset errors to responses
+func writeChangesetError(c *gin.Context, id int64, action string, err error) {
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		writeError(c, http.StatusNotFound, "Changeset not found")
+	case errors.Is(err, db.ErrChangesetNotEditable):
+		writeError(c, http.StatusConflict, err.Error())
+	case errors.Is(err, db.ErrProductNotFound):
+		writeError(c, http.StatusBadRequest, err.Error())
+	default:
+		log.Printf("Error %s changeset %d: %v", action, id, err)
+		writeError(c, http.StatusInternalServerError, "Failed to "+action+" changeset")
+	}
+}
+
+// ListChangesets handles GET /changesets[?status=draft]
+func (h *Handler) ListChangesets(c *gin.Context) {
+	list, err := h.db.ListChangesets(c.Request.Context(), c.Query("status"))
+	if err != nil {
+		log.Printf("Error listing changesets: %v", err)
+		writeError(c, http.StatusInternalServerError, "Failed to fetch changesets")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"changesets": list})
+}
+
+// CreateChangeset handles POST /changesets
+func (h *Handler) CreateChangeset(c *gin.Context) {
+	var req changesetRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+	cs := &models.Changeset{Name: strings.TrimSpace(req.Name), ScheduledFor: req.ScheduledFor}
+	if cs.ScheduledFor != nil && !cs.ScheduledFor.After(time.Now()) {
+		writeFieldErrors(c, models.FieldError{Field: "scheduled_for", Code: "must_be_in_future"})
+		return
+	}
+	if v, ok := c.Get("user_id"); ok {
+		by := fmt.Sprint(v)
+		cs.CreatedBy = &by
+	}
+
+	created, err := h.db.CreateChangeset(c.Request.Context(), cs)
+	if err != nil {
+		log.Printf("Error creating changeset: %v", err)
+		writeError(c, http.StatusInternalServerError, "Failed to create changeset")
+		return
+	}
+	c.JSON(http.StatusCreated, created)
+}
+
+// GetChangeset handles GET /changesets/:id (with staged items)
+func (h *Handler) GetChangeset(c *gin.Context) {
+	id, ok := parseChangesetID(c)
+	if !ok {
+		return
+	}
+	cs, err := h.db.GetChangeset(c.Request.Context(), id)
+	if err != nil {
+		writeChangesetError(c, id, "fetch", err)
+		return
+	}
+	items, err := h.db.GetChangesetItems(c.Request.Context(), id)
+	if err != nil {
+		writeChangesetError(c, id, "fetch", err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"changeset": cs, "items": items})
+}
+
+// StageChangesetItems handles PUT /changesets/:id/items
+// Each item replaces whatever was staged for that product in this changeset.
+func (h *Handler) StageChangesetItems(c *gin.Context) {
+	id, ok := parseChangesetID(c)
+	if !ok {
+		return
+	}
+	var req changesetItemsRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+	seen := make(map[int]bool, len(req.Items))
+	for _, it := range req.Items {
+		if seen[it.ProductID] {
+			writeError(c, http.StatusBadRequest, fmt.Sprintf("product %d is listed twice", it.ProductID))
+			return
+		}
+		seen[it.ProductID] = true
+		if msg := validateChangesetItem(it); msg != "" {
+			writeError(c, http.StatusBadRequest, msg)
+			return
+		}
+	}
+
+	if err := h.db.StageChangesetItems(c.Request.Context(), id, req.Items); err != nil {
+		writeChangesetError(c, id, "update", err)
+		return
+	}
+	h.GetChangeset(c)
+}
+
+// RemoveChangesetItem handles DELETE /changesets/:id/items/:product_id
+func (h *Handler) RemoveChangesetItem(c *gin.Context) {
+	id, ok := parseChangesetID(c)
+	if !ok {
+		return
+	}
+	productID, err := strconv.Atoi(c.Param("product_id"))
+	if err != nil {
+		writeError(c, http.StatusBadRequest, "Invalid product ID format")
+		return
+	}
+	if err := h.db.RemoveChangesetItem(c.Request.Context(), id, productID); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			writeError(c, http.StatusNotFound, "Product is not staged in this changeset")
+			return
+		}
+		writeChangesetError(c, id, "update", err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Item removed"})
+}
+
+// PreviewChangeset handles GET /changesets/:id/preview
+// Returns the diff of each staged product against its live values.
+func (h *Handler) PreviewChangeset(c *gin.Context) {
+	id, ok := parseChangesetID(c)
+	if !ok {
+		return
+	}
+	cs, err := h.db.GetChangeset(c.Request.Context(), id)
+	if err != nil {
+		writeChangesetError(c, id, "preview", err)
+		return
+	}
+	diff, err := h.db.PreviewChangeset(c.Request.Context(), id)
+	if err != nil {
+		writeChangesetError(c, id, "preview", err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"changeset": cs, "diff": diff})
+}
+
+// ScheduleChangeset handles POST /changesets/:id/schedule
+func (h *Handler) ScheduleChangeset(c *gin.Context) {
+	id, ok := parseChangesetID(c)
+	if !ok {
+		return
+	}
+	var req changesetScheduleRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+	if req.ScheduledFor != nil && !req.ScheduledFor.After(time.Now()) {
+		writeFieldErrors(c, models.FieldError{Field: "scheduled_for", Code: "must_be_in_future"})
+		return
+	}
+	cs, err := h.db.ScheduleChangeset(c.Request.Context(), id, req.ScheduledFor)
+	if err != nil {
+		writeChangesetError(c, id, "schedule", err)
+		return
+	}
+	c.JSON(http.StatusOK, cs)
+}
+
+// ApplyChangeset handles POST /changesets/:id/apply (all changes go live atomically, now)
+func (h *Handler) ApplyChangeset(c *gin.Context) {
+	id, ok := parseChangesetID(c)
+	if !ok {
+		return
+	}
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 60*time.Second)
+	defer cancel()
+
+	cs, err := h.db.ApplyChangeset(ctx, id)
+	if err != nil {
+		writeChangesetError(c, id, "apply", err)
+		return
+	}
+	logging.LogKV("info", "ChangesetApplied", map[string]interface{}{"changeset_id": id, "items": cs.ItemCount})
+	c.JSON(http.StatusOK, cs)
+}
+
+// DiscardChangeset handles POST /changesets/:id/discard
+func (h *Handler) DiscardChangeset(c *gin.Context) {
+	id, ok := parseChangesetID(c)
+	if !ok {
+		return
+	}
+	cs, err := h.db.DiscardChangeset(c.Request.Context(), id)
+	if err != nil {
+		writeChangesetError(c, id, "discard", err)
+		return
+	}
+	c.JSON(http.StatusOK, cs)
+}
+
+// StartChangesetJobs applies scheduled changesets once their time has come.
+// It returns when ctx is cancelled.
+func (h *Handler) StartChangesetJobs(ctx context.Context) {
+	interval := time.Duration(getEnvInt("CHANGESET_POLL_SECONDS", 60)) * time.Second
+
+	run := func() {
+		for ctx.Err() == nil {
+			jobCtx, cancel := context.WithTimeout(ctx, 2*time.Minute)
+			id, ok, err := h.db.ApplyDueChangeset(jobCtx)
+			cancel()
+			if err != nil {
+				logging.LogKV("error", "ChangesetApplyFailed", map[string]interface{}{"changeset_id": id, "error": err.Error()})
+				return
+			}
+			if !ok {
+				return
+			}
+			logging.LogKV("info", "ChangesetApplied", map[string]interface{}{"changeset_id": id, "scheduled": true})
+		}
+	}
+
+	run()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			run()
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package api
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/expotoworld/expotoworld/backend/catalog-service/internal/models"
+	"github.com/gin-gonic/gin"
+)
+
+const maxCollectionDays = 365
+
+// productCollections caches computed collections per (collection, days, mini-app, limit)
+var productCollections struct {
+	sync.Mutex
+	entries map[string]cachedCollection
+}
+
+type cachedCollection struct {
+	items    []models.ProductCollectionItem
+	loadedAt time.Time
+}
+
+// parseDays reads a positive day-window query parameter; ok is false (and a 400 written) when invalid
+func parseDays(c *gin.Context, key string, defaultValue int) (int, bool) {
+	v := c.Query(key)
+	if v == "" {
+		return defaultValue, true
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n <= 0 || n > maxCollectionDays {
+		writeError(c, http.StatusBadRequest, fmt.Sprintf("Invalid %s (1-%d)", key, maxCollectionDays))
+		return 0, false
+	}
+	return n, true
+}
+
+// GetNewArrivals handles GET /products/new-arrivals?days=30&mini_app_type=&limit=20
+func (h *Handler) GetNewArrivals(c *gin.Context) {
+	h.writeProductCollection(c, models.CollectionNewArrivals, getEnvInt("NEW_ARRIVALS_DEFAULT_DAYS", 30))
+}
+
+// GetBackInStock handles GET /products/back-in-stock?days=14&mini_app_type=&limit=20
+func (h *Handler) GetBackInStock(c *gin.Context) {
+	h.writeProductCollection(c, models.CollectionBackInStock, getEnvInt("BACK_IN_STOCK_DEFAULT_DAYS", 14))
+}
+
+func (h *Handler) writeProductCollection(c *gin.Context, collection models.ProductCollection, defaultDays int) {
+	days, ok := parseDays(c, "days", defaultDays)
+	if !ok {
+		return
+	}
+	limit := 20
+	if v := c.Query("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 || n > 100 {
+			writeError(c, http.StatusBadRequest, "Invalid limit")
+			return
+		}
+		limit = n
+	}
+	miniAppType := c.Query("mini_app_type")
+	ttl := time.Duration(getEnvInt("PRODUCT_COLLECTION_CACHE_SECONDS", 60)) * time.Second
+	key := fmt.Sprintf("%s|%d|%s|%d", collection, days, miniAppType, limit)
+
+	productCollections.Lock()
+	entry, hit := productCollections.entries[key]
+	productCollections.Unlock()
+
+	if !hit || time.Since(entry.loadedAt) >= ttl {
+		items, err := h.db.GetProductCollection(c.Request.Context(), collection, days, miniAppType, limit)
+		if err != nil {
+			log.Printf("Error fetching %s products: %v", collection, err)
+			writeError(c, http.StatusInternalServerError, "Failed to fetch products")
+			return
+		}
+		entry = cachedCollection{items: items, loadedAt: time.Now()}
+		productCollections.Lock()
+		if productCollections.entries == nil {
+			productCollections.entries = map[string]cachedCollection{}
+		}
+		// Drop expired entries so odd parameter combinations don't accumulate
+		for k, e := range productCollections.entries {
+			if time.Since(e.loadedAt) >= ttl {
+				delete(productCollections.entries, k)
+			}
+		}
+		productCollections.entries[key] = entry
+		productCollections.Unlock()
+	}
+
+	c.Header("Cache-Control", fmt.Sprintf("public, max-age=%d", int(ttl.Seconds())))
+	c.JSON(http.StatusOK, gin.H{"collection": collection, "days": days, "products": entry.items})
+}
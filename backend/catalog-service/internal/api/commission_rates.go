@@ -0,0 +1,159 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/expotoworld/expotoworld/backend/catalog-service/internal/db"
+	"github.com/expotoworld/expotoworld/backend/catalog-service/internal/models"
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+type commissionRateRequest struct {
+	CategoryID        *int       `json:"category_id"`
+	ManufacturerOrgID *string    `json:"manufacturer_org_id"`
+	RatePct           *float64   `json:"rate_pct" binding:"required"`
+	EffectiveFrom     *time.Time `json:"effective_from"`
+	Notes             string     `json:"notes"`
+}
+
+// toCommissionRate validates the request and converts it; an empty message means valid
+func (r *commissionRateRequest) toCommissionRate() (*models.CommissionRate, string) {
+	rate := &models.CommissionRate{CategoryID: r.CategoryID, RatePct: *r.RatePct, EffectiveFrom: time.Now(), Notes: strings.TrimSpace(r.Notes)}
+	if rate.RatePct < 0 || rate.RatePct > 100 {
+		return nil, "rate_pct must be between 0 and 100"
+	}
+	if rate.CategoryID != nil && *rate.CategoryID <= 0 {
+		return nil, "invalid category_id"
+	}
+	if r.ManufacturerOrgID != nil {
+		if id := strings.TrimSpace(*r.ManufacturerOrgID); id != "" {
+			rate.ManufacturerOrgID = &id
+		}
+	}
+	if r.EffectiveFrom != nil {
+		rate.EffectiveFrom = *r.EffectiveFrom
+	}
+	return rate, ""
+}
+
+// ListCommissionRates handles GET /commission-rates[?category_id=&manufacturer_org_id=&current=true]
+func (h *Handler) ListCommissionRates(c *gin.Context) {
+	var f db.CommissionRateFilter
+	if v := c.Query("category_id"); v != "" {
+		id, err := strconv.Atoi(v)
+		if err != nil || id <= 0 {
+			writeError(c, http.StatusBadRequest, "Invalid category_id")
+			return
+		}
+		f.CategoryID = &id
+	}
+	f.ManufacturerOrgID = c.Query("manufacturer_org_id")
+	f.CurrentOnly, _ = strconv.ParseBool(c.Query("current"))
+
+	rates, err := h.db.ListCommissionRates(c.Request.Context(), f)
+	if err != nil {
+		log.Printf("Error listing commission rates: %v", err)
+		writeError(c, http.StatusInternalServerError, "Failed to fetch commission rates")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"commission_rates": rates})
+}
+
+// CreateCommissionRate handles POST /commission-rates
+// Body: { "category_id": 3, "manufacturer_org_id": "...", "rate_pct": 12.5, "effective_from": "2025-01-01T00:00:00Z" }
+// Omit category_id and/or manufacturer_org_id to apply the rate to any. A rate is never edited in place:
+// post a new version with a later effective_from to change it.
+func (h *Handler) CreateCommissionRate(c *gin.Context) {
+	var req commissionRateRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+	rate, msg := req.toCommissionRate()
+	if msg != "" {
+		writeError(c, http.StatusBadRequest, msg)
+		return
+	}
+	if v, ok := c.Get("user_id"); ok {
+		rate.CreatedBy = fmt.Sprint(v)
+	}
+
+	created, err := h.db.CreateCommissionRate(c.Request.Context(), rate)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) {
+			switch pgErr.Code {
+			case "23505":
+				writeError(c, http.StatusConflict, "A rate for this scope already starts at effective_from")
+				return
+			case "22P02":
+				writeError(c, http.StatusBadRequest, "Invalid manufacturer_org_id")
+				return
+			}
+		}
+		log.Printf("Error creating commission rate: %v", err)
+		writeError(c, http.StatusInternalServerError, "Failed to create commission rate")
+		return
+	}
+	log.Printf("[COMMISSION] rate %d created by %s: category=%v manufacturer=%v rate=%.2f%% from %s",
+		created.ID, created.CreatedBy, created.CategoryID, created.ManufacturerOrgID, created.RatePct, created.EffectiveFrom.Format(time.RFC3339))
+	c.JSON(http.StatusCreated, created)
+}
+
+// DeleteCommissionRate handles DELETE /commission-rates/:id (scheduled versions only)
+func (h *Handler) DeleteCommissionRate(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		writeError(c, http.StatusBadRequest, "Invalid commission rate ID")
+		return
+	}
+	if err := h.db.DeleteCommissionRate(c.Request.Context(), id); err != nil {
+		switch {
+		case errors.Is(err, pgx.ErrNoRows):
+			writeError(c, http.StatusNotFound, "Commission rate not found")
+		case errors.Is(err, db.ErrCommissionRateInEffect):
+			writeError(c, http.StatusConflict, "Commission rate already took effect; add a new version instead")
+		default:
+			log.Printf("Error deleting commission rate %d: %v", id, err)
+			writeError(c, http.StatusInternalServerError, "Failed to delete commission rate")
+		}
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Commission rate deleted"})
+}
+
+// ResolveCommissionRate handles GET /commission-rates/resolve?product_id=&manufacturer_org_id=[&at=RFC3339]
+func (h *Handler) ResolveCommissionRate(c *gin.Context) {
+	productID, err := strconv.Atoi(c.Query("product_id"))
+	if err != nil || productID <= 0 {
+		writeError(c, http.StatusBadRequest, "product_id is required")
+		return
+	}
+	at := time.Now()
+	if v := c.Query("at"); v != "" {
+		if at, err = time.Parse(time.RFC3339, v); err != nil {
+			writeError(c, http.StatusBadRequest, "at must be an RFC3339 timestamp")
+			return
+		}
+	}
+
+	res, err := h.db.ResolveCommissionRate(c.Request.Context(), productID, c.Query("manufacturer_org_id"), at)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "22P02" {
+			writeError(c, http.StatusBadRequest, "Invalid manufacturer_org_id")
+			return
+		}
+		log.Printf("Error resolving commission rate for product %d: %v", productID, err)
+		writeError(c, http.StatusInternalServerError, "Failed to resolve commission rate")
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
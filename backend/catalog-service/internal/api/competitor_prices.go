@@ -0,0 +1,168 @@
+package api
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/expotoworld/expotoworld/backend/catalog-service/internal/models"
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+)
+
+const maxCompetitorPriceBatch = 500
+
+func parseProductIDParam(c *gin.Context) (int, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		writeError(c, http.StatusBadRequest, "Invalid product ID format")
+		return 0, false
+	}
+	return id, true
+}
+
+// normalizeCompetitorPrice trims fields and checks the URL; an empty message means valid
+func normalizeCompetitorPrice(p *models.CompetitorPrice) string {
+	p.Source = strings.ToLower(strings.TrimSpace(p.Source))
+	p.URL = strings.TrimSpace(p.URL)
+	if p.Source == "" || len(p.Source) > 100 {
+		return "source is required (max 100 characters)"
+	}
+	if p.URL != "" && !strings.HasPrefix(p.URL, "http://") && !strings.HasPrefix(p.URL, "https://") {
+		return "url must be an absolute http(s) URL"
+	}
+	if p.LastCheckedAt.After(time.Now().Add(5 * time.Minute)) {
+		return "last_checked_at is in the future"
+	}
+	return ""
+}
+
+// GetProductCompetitorPrices handles GET /products/:id/competitor-prices
+func (h *Handler) GetProductCompetitorPrices(c *gin.Context) {
+	id, ok := parseProductIDParam(c)
+	if !ok {
+		return
+	}
+	prices, err := h.db.GetCompetitorPrices(c.Request.Context(), id)
+	if err != nil {
+		log.Printf("Error fetching competitor prices for product %d: %v", id, err)
+		writeError(c, http.StatusInternalServerError, "Failed to fetch competitor prices")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"product_id": id, "competitor_prices": prices})
+}
+
+// SetProductCompetitorPrice handles PUT /products/:id/competitor-prices
+// Body: { "source": "amazon", "url": "https://...", "price": 19.9 } creates or replaces that source's entry.
+func (h *Handler) SetProductCompetitorPrice(c *gin.Context) {
+	id, ok := parseProductIDParam(c)
+	if !ok {
+		return
+	}
+	// product_id comes from the path; preset it so the body need not repeat it
+	req := models.CompetitorPrice{ProductID: id}
+	if !bindJSON(c, &req) {
+		return
+	}
+	req.ProductID = id
+	if msg := normalizeCompetitorPrice(&req); msg != "" {
+		writeError(c, http.StatusBadRequest, msg)
+		return
+	}
+
+	stored, err := h.db.UpsertCompetitorPrices(c.Request.Context(), []models.CompetitorPrice{req})
+	if err != nil {
+		log.Printf("Error saving competitor price for product %d: %v", id, err)
+		writeError(c, http.StatusInternalServerError, "Failed to save competitor price")
+		return
+	}
+	if stored == 0 {
+		writeError(c, http.StatusNotFound, "Product not found or a newer price is already recorded")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Competitor price saved"})
+}
+
+// DeleteProductCompetitorPrice handles DELETE /products/:id/competitor-prices/:source
+func (h *Handler) DeleteProductCompetitorPrice(c *gin.Context) {
+	id, ok := parseProductIDParam(c)
+	if !ok {
+		return
+	}
+	source := strings.ToLower(strings.TrimSpace(c.Param("source")))
+	if err := h.db.DeleteCompetitorPrice(c.Request.Context(), id, source); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			writeError(c, http.StatusNotFound, "Competitor price not found")
+			return
+		}
+		log.Printf("Error deleting competitor price for product %d/%s: %v", id, source, err)
+		writeError(c, http.StatusInternalServerError, "Failed to delete competitor price")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Competitor price deleted"})
+}
+
+// UpdateCompetitorPrices handles POST /internal/competitor-prices for the scraping job.
+// Body: { "prices": [{"product_id": 1, "source": "amazon", "url": "...", "price": 19.9, "last_checked_at": "..."}] }
+// Observations older than the stored one are ignored; unknown products are skipped.
+func (h *Handler) UpdateCompetitorPrices(c *gin.Context) {
+	var body struct {
+		Prices []models.CompetitorPrice `json:"prices" binding:"required,dive"`
+	}
+	if !bindJSON(c, &body) {
+		return
+	}
+	if len(body.Prices) > maxCompetitorPriceBatch {
+		writeError(c, http.StatusBadRequest, "At most "+strconv.Itoa(maxCompetitorPriceBatch)+" prices per request")
+		return
+	}
+	for i := range body.Prices {
+		if msg := normalizeCompetitorPrice(&body.Prices[i]); msg != "" {
+			writeError(c, http.StatusBadRequest, "prices["+strconv.Itoa(i)+"]: "+msg)
+			return
+		}
+	}
+
+	stored, err := h.db.UpsertCompetitorPrices(c.Request.Context(), body.Prices)
+	if err != nil {
+		log.Printf("Error saving competitor prices: %v", err)
+		writeError(c, http.StatusInternalServerError, "Failed to save competitor prices")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"received": len(body.Prices), "stored": stored, "skipped": len(body.Prices) - stored})
+}
+
+// GetCompetitorPriceAlerts handles GET /reports/competitor-price-alerts[?margin_pct=5&max_age_days=14&limit=200]
+// Lists products priced more than margin_pct above a recently checked competitor price.
+// The default margin comes from COMPETITOR_PRICE_ALERT_MARGIN_PCT.
+func (h *Handler) GetCompetitorPriceAlerts(c *gin.Context) {
+	margin := float64(getEnvInt("COMPETITOR_PRICE_ALERT_MARGIN_PCT", 5))
+	if v := c.Query("margin_pct"); v != "" {
+		m, err := strconv.ParseFloat(v, 64)
+		if err != nil || m < 0 {
+			writeError(c, http.StatusBadRequest, "Invalid margin_pct")
+			return
+		}
+		margin = m
+	}
+	maxAgeDays, err := strconv.Atoi(c.DefaultQuery("max_age_days", "14"))
+	if err != nil || maxAgeDays <= 0 {
+		writeError(c, http.StatusBadRequest, "Invalid max_age_days")
+		return
+	}
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "200"))
+	if err != nil || limit <= 0 || limit > 1000 {
+		limit = 200
+	}
+
+	alerts, err := h.db.GetCompetitorPriceAlerts(c.Request.Context(), margin, time.Duration(maxAgeDays)*24*time.Hour, limit)
+	if err != nil {
+		log.Printf("Error building competitor price alerts: %v", err)
+		writeError(c, http.StatusInternalServerError, "Failed to build competitor price report")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"margin_pct": margin, "max_age_days": maxAgeDays, "count": len(alerts), "alerts": alerts})
+}
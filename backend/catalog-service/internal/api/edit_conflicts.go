@@ -0,0 +1,93 @@
+package api
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/expotoworld/expotoworld/backend/catalog-service/internal/db"
+	"github.com/gin-gonic/gin"
+)
+
+// parseUnmodifiedSince reads the optional If-Unmodified-Since precondition of product, category and
+// store updates. The panel sends back the updated_at it loaded (RFC 3339, exact); an HTTP date is
+// accepted too but only has second precision. Without the header the update isn't checked.
+// It writes the error response itself when ok is false.
+func parseUnmodifiedSince(c *gin.Context) (*time.Time, bool) {
+	v := strings.TrimSpace(c.GetHeader("If-Unmodified-Since"))
+	if v == "" {
+		return nil, true
+	}
+	if t, err := time.Parse(time.RFC3339Nano, v); err == nil {
+		return &t, true
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		// Changes within that second still count as unmodified
+		t = t.Add(time.Second - time.Microsecond)
+		return &t, true
+	}
+	writeError(c, http.StatusBadRequest, "Invalid If-Unmodified-Since header")
+	return nil, false
+}
+
+// writeEditConflict answers a failed If-Unmodified-Since precondition with 409 and the current
+// version of the item, so the panel can merge. load returns nil once the item is gone (404).
+func writeEditConflict(c *gin.Context, what string, load func(ctx context.Context) (interface{}, error)) {
+	current, err := load(c.Request.Context())
+	if err != nil {
+		log.Printf("Error loading current %s after edit conflict: %v", strings.ToLower(what), err)
+		writeError(c, http.StatusInternalServerError, "Failed to load the current "+strings.ToLower(what))
+		return
+	}
+	if current == nil {
+		writeError(c, http.StatusNotFound, what+" not found")
+		return
+	}
+	writeErrorDetails(c, http.StatusConflict, "EDIT_CONFLICT", what+" was changed by someone else since you loaded it",
+		gin.H{"current": current})
+}
+
+// currentProduct loads a product (active or not) with its images and categories, nil if missing
+func (h *Handler) currentProduct(productID int) func(ctx context.Context) (interface{}, error) {
+	return func(ctx context.Context) (interface{}, error) {
+		products, err := h.db.ListCatalogProducts(ctx, db.CatalogFilter{IDs: []int{productID}, IncludeInactive: true, Limit: 1})
+		if err != nil || len(products) == 0 {
+			return nil, err
+		}
+		relations, err := h.db.GetProductRelations(ctx, []int{productID})
+		if err != nil {
+			return nil, err
+		}
+		p := products[0]
+		r := relations[productID]
+		p.ImageUrls = nonNilStrings(r.ImageURLs)
+		p.ThumbnailUrls = nonNilStrings(r.ThumbnailURLs)
+		p.CategoryIds = nonNilStrings(r.CategoryIDs)
+		p.SubcategoryIds = nonNilStrings(r.SubcategoryIDs)
+		return p, nil
+	}
+}
+
+// currentCategory loads a category (active or not), nil if missing
+func (h *Handler) currentCategory(categoryID int) func(ctx context.Context) (interface{}, error) {
+	return func(ctx context.Context) (interface{}, error) {
+		categories, err := h.db.ListCatalogCategories(ctx, db.CatalogFilter{IDs: []int{categoryID}, IncludeInactive: true})
+		if err != nil || len(categories) == 0 {
+			return nil, err
+		}
+		return categories[0], nil
+	}
+}
+
+// currentStore loads a store (active or not), nil if missing
+func (h *Handler) currentStore(storeID int) func(ctx context.Context) (interface{}, error) {
+	return func(ctx context.Context) (interface{}, error) {
+		stores, err := h.db.ListCatalogStores(ctx, "", db.CatalogFilter{IDs: []int{storeID}, IncludeInactive: true})
+		if err != nil || len(stores) == 0 {
+			return nil, err
+		}
+		return stores[0], nil
+	}
+}
@@ -0,0 +1,103 @@
+package api
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// requestIDHeader carries the request id between the gateway, this service and the apps
+const requestIDHeader = "X-Request-ID"
+
+// ErrorResponse is the body of every failed request:
+//
+//	{"code": "NOT_FOUND", "message": "Product not found", "details": {...}, "request_id": "...", "error": "Product not found"}
+//
+// code is stable and meant for client logic; message is for people. details is omitted when there
+// is nothing to add. error repeats message for clients written before the envelope existed.
+type ErrorResponse struct {
+	Code      string      `json:"code"`
+	Message   string      `json:"message"`
+	Details   interface{} `json:"details,omitempty"`
+	RequestID string      `json:"request_id,omitempty"`
+	Error     string      `json:"error"`
+}
+
+// writeError writes the error envelope with the default code for status
+func writeError(c *gin.Context, status int, message string) {
+	writeErrorDetails(c, status, "", message, nil)
+}
+
+// writeErrorDetails writes the error envelope with a specific code ("" for the status default) and
+// optional details
+func writeErrorDetails(c *gin.Context, status int, code, message string, details interface{}) {
+	if code == "" {
+		code = statusErrorCode(status)
+	}
+	c.JSON(status, ErrorResponse{Code: code, Message: message, Details: details,
+		RequestID: c.GetString("request_id"), Error: message})
+}
+
+// statusErrorCode is the default code of a status, e.g. 404 -> NOT_FOUND, 500 -> INTERNAL_SERVER_ERROR
+func statusErrorCode(status int) string {
+	text := http.StatusText(status)
+	if text == "" {
+		return "ERROR"
+	}
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		case r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			return r
+		case r == ' ' || r == '-':
+			return '_'
+		}
+		return -1
+	}, text)
+}
+
+// RequestIDMiddleware adopts the caller's X-Request-ID (the gateway sets one) or generates one, and
+// echoes it on the response so errors can be matched to logs
+func RequestIDMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := strings.TrimSpace(c.GetHeader(requestIDHeader))
+		if !validRequestID(id) {
+			b := make([]byte, 16)
+			_, _ = rand.Read(b)
+			id = hex.EncodeToString(b)
+		}
+		c.Set("request_id", id)
+		c.Header(requestIDHeader, id)
+		c.Next()
+	}
+}
+
+// validRequestID accepts up to 128 printable ASCII characters, so ids can't forge log lines
+func validRequestID(id string) bool {
+	if id == "" || len(id) > 128 {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x21 || id[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+// NoRoute answers unknown paths with the error envelope
+func NoRoute(c *gin.Context) {
+	writeError(c, http.StatusNotFound, "Not found")
+}
+
+// Recovery answers panics with the error envelope instead of an empty 500
+func Recovery() gin.HandlerFunc {
+	return gin.CustomRecovery(func(c *gin.Context, _ any) {
+		writeError(c, http.StatusInternalServerError, "Internal server error")
+		c.Abort()
+	})
+}
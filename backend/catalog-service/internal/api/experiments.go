@@ -0,0 +1,292 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"log"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/expotoworld/expotoworld/backend/catalog-service/internal/models"
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+)
+
+var experimentKeyPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_\-]{0,63}$`)
+
+// runningExperiments caches running experiment definitions so assignment does not hit the DB per request
+var runningExperiments struct {
+	sync.Mutex
+	items    []models.Experiment
+	loadedAt time.Time
+}
+
+func (h *Handler) getRunningExperiments(c *gin.Context) ([]models.Experiment, error) {
+	runningExperiments.Lock()
+	defer runningExperiments.Unlock()
+
+	ttl := time.Duration(getEnvInt("EXPERIMENT_CACHE_SECONDS", 30)) * time.Second
+	if runningExperiments.items != nil && time.Since(runningExperiments.loadedAt) < ttl {
+		return runningExperiments.items, nil
+	}
+	items, err := h.db.ListExperiments(c.Request.Context(), models.ExperimentStatusRunning)
+	if err != nil {
+		return nil, err
+	}
+	runningExperiments.items = items
+	runningExperiments.loadedAt = time.Now()
+	return items, nil
+}
+
+func invalidateExperimentCache() {
+	runningExperiments.Lock()
+	runningExperiments.items = nil
+	runningExperiments.Unlock()
+}
+
+// assignVariant deterministically buckets a unit (user or session id) into one of the experiment's variants.
+// The same unit always lands in the same variant unless the weights or salt change.
+func assignVariant(e *models.Experiment, unit string) string {
+	total := 0
+	for _, v := range e.Variants {
+		total += v.Weight
+	}
+	if total <= 0 || unit == "" {
+		return e.Variants[0].Key
+	}
+	hs := fnv.New64a()
+	hs.Write([]byte(e.Salt + ":" + e.Key + ":" + unit))
+	bucket := int(hs.Sum64() % uint64(total))
+	for _, v := range e.Variants {
+		if bucket < v.Weight {
+			return v.Key
+		}
+		bucket -= v.Weight
+	}
+	return e.Variants[0].Key
+}
+
+// experimentUnit returns the caller's assignment unit: the authenticated user, or else the client session id
+func experimentUnit(c *gin.Context) (userID *string, sessionID, unit string) {
+	if v, ok := c.Get("user_id"); ok {
+		if s := fmt.Sprint(v); s != "" {
+			return &s, "", "user:" + s
+		}
+	}
+	sessionID = strings.TrimSpace(c.GetHeader("X-Session-ID"))
+	if sessionID == "" {
+		sessionID = strings.TrimSpace(c.Query("session_id"))
+	}
+	if sessionID != "" {
+		return nil, sessionID, "session:" + sessionID
+	}
+	return nil, "", ""
+}
+
+// GetExperimentAssignments handles GET /experiments/assignments
+// Returns the caller's variant for every running experiment; also echoed in the X-Experiments header.
+func (h *Handler) GetExperimentAssignments(c *gin.Context) {
+	experiments, err := h.getRunningExperiments(c)
+	if err != nil {
+		log.Printf("Error loading experiments: %v", err)
+		writeError(c, http.StatusInternalServerError, "Failed to load experiments")
+		return
+	}
+
+	_, _, unit := experimentUnit(c)
+	assignments := make([]models.ExperimentAssignment, 0, len(experiments))
+	header := make([]string, 0, len(experiments))
+	for i := range experiments {
+		variant := assignVariant(&experiments[i], unit)
+		assignments = append(assignments, models.ExperimentAssignment{ExperimentKey: experiments[i].Key, Variant: variant})
+		header = append(header, experiments[i].Key+"="+variant)
+	}
+	c.Header("X-Experiments", strings.Join(header, ";"))
+	c.JSON(http.StatusOK, gin.H{"assignments": assignments, "bucketed": unit != ""})
+}
+
+var (
+	exposureLimiter     *viewRateLimiter
+	exposureLimiterOnce sync.Once
+)
+
+func getExposureLimiter() *viewRateLimiter {
+	exposureLimiterOnce.Do(func() {
+		exposureLimiter = newViewRateLimiter(getEnvInt("EXPERIMENT_EXPOSURE_RATE_LIMIT_PER_MINUTE", 120), time.Minute)
+	})
+	return exposureLimiter
+}
+
+// RecordExperimentExposures handles POST /experiments/exposures
+// The variant is recomputed server-side so clients cannot skew results; events for experiments
+// that are not running, or from callers that cannot be bucketed, are dropped.
+func (h *Handler) RecordExperimentExposures(c *gin.Context) {
+	type reqBody struct {
+		Events []models.ExperimentExposure `json:"events" binding:"required,dive"`
+	}
+	var req reqBody
+	if !bindJSON(c, &req) {
+		return
+	}
+	maxBatch := getEnvInt("PRODUCT_VIEW_MAX_BATCH", 50)
+	if len(req.Events) > maxBatch {
+		writeError(c, http.StatusBadRequest, fmt.Sprintf("At most %d events per batch", maxBatch))
+		return
+	}
+
+	userID, sessionID, unit := experimentUnit(c)
+	if unit == "" {
+		c.JSON(http.StatusAccepted, gin.H{"accepted": 0, "dropped": len(req.Events)})
+		return
+	}
+	key := c.ClientIP()
+	if userID != nil {
+		key = "user:" + *userID
+	}
+	allowed := getExposureLimiter().allow(key, len(req.Events))
+	if allowed == 0 {
+		writeError(c, http.StatusTooManyRequests, "Rate limit exceeded")
+		return
+	}
+
+	experiments, err := h.getRunningExperiments(c)
+	if err != nil {
+		log.Printf("Error loading experiments: %v", err)
+		writeError(c, http.StatusInternalServerError, "Failed to load experiments")
+		return
+	}
+	byKey := make(map[string]*models.Experiment, len(experiments))
+	for i := range experiments {
+		byKey[experiments[i].Key] = &experiments[i]
+	}
+
+	now := time.Now()
+	events := make([]models.ExperimentExposure, 0, allowed)
+	for _, e := range req.Events[:allowed] {
+		exp, ok := byKey[e.ExperimentKey]
+		if !ok {
+			continue
+		}
+		e.Variant = assignVariant(exp, unit)
+		e.UserID = userID
+		e.SessionID = sessionID
+		if ts := e.ExposedAt; ts != nil && (ts.After(now) || now.Sub(*ts) > 48*time.Hour) {
+			e.ExposedAt = nil
+		}
+		events = append(events, e)
+	}
+
+	if err := h.db.InsertExperimentExposures(c.Request.Context(), events); err != nil {
+		log.Printf("Error recording experiment exposures: %v", err)
+		writeError(c, http.StatusInternalServerError, "Failed to record exposures")
+		return
+	}
+	c.JSON(http.StatusAccepted, gin.H{"accepted": len(events), "dropped": len(req.Events) - len(events)})
+}
+
+// ListExperiments handles GET /experiments (admin)
+func (h *Handler) ListExperiments(c *gin.Context) {
+	items, err := h.db.ListExperiments(c.Request.Context(), c.Query("status"))
+	if err != nil {
+		log.Printf("Error listing experiments: %v", err)
+		writeError(c, http.StatusInternalServerError, "Failed to list experiments")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"experiments": items})
+}
+
+// UpsertExperiment handles PUT /experiments/:key (admin)
+func (h *Handler) UpsertExperiment(c *gin.Context) {
+	type reqBody struct {
+		Description string                     `json:"description"`
+		Variants    []models.ExperimentVariant `json:"variants" binding:"required,min=2,dive"`
+		Status      string                     `json:"status"`
+		Salt        string                     `json:"salt"`
+	}
+	var req reqBody
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	key := c.Param("key")
+	if !experimentKeyPattern.MatchString(key) {
+		writeError(c, http.StatusBadRequest, "Invalid experiment key")
+		return
+	}
+	if req.Status == "" {
+		req.Status = models.ExperimentStatusDraft
+	}
+	switch req.Status {
+	case models.ExperimentStatusDraft, models.ExperimentStatusRunning, models.ExperimentStatusStopped:
+	default:
+		writeError(c, http.StatusBadRequest, "Invalid status")
+		return
+	}
+	seen := map[string]bool{}
+	total := 0
+	for _, v := range req.Variants {
+		if seen[v.Key] {
+			writeError(c, http.StatusBadRequest, "Duplicate variant: "+v.Key)
+			return
+		}
+		seen[v.Key] = true
+		total += v.Weight
+	}
+	if total <= 0 {
+		writeError(c, http.StatusBadRequest, "Variant weights must sum to more than 0")
+		return
+	}
+
+	exp, err := h.db.UpsertExperiment(c.Request.Context(), &models.Experiment{
+		Key:         key,
+		Description: req.Description,
+		Variants:    req.Variants,
+		Status:      req.Status,
+		Salt:        req.Salt,
+	})
+	if err != nil {
+		log.Printf("Error saving experiment %s: %v", key, err)
+		writeError(c, http.StatusInternalServerError, "Failed to save experiment")
+		return
+	}
+	invalidateExperimentCache()
+	c.JSON(http.StatusOK, exp)
+}
+
+// GetExperimentResults handles GET /experiments/:key/results (admin)
+func (h *Handler) GetExperimentResults(c *gin.Context) {
+	days := 30
+	if v := c.Query("days"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 || n > 365 {
+			writeError(c, http.StatusBadRequest, "Invalid days")
+			return
+		}
+		days = n
+	}
+
+	ctx := c.Request.Context()
+	key := c.Param("key")
+	exp, err := h.db.GetExperiment(ctx, key)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			writeError(c, http.StatusNotFound, "Experiment not found")
+			return
+		}
+		log.Printf("Error fetching experiment %s: %v", key, err)
+		writeError(c, http.StatusInternalServerError, "Failed to fetch experiment")
+		return
+	}
+	results, err := h.db.GetExperimentResults(ctx, key, time.Now().AddDate(0, 0, -days))
+	if err != nil {
+		log.Printf("Error fetching experiment results %s: %v", key, err)
+		writeError(c, http.StatusInternalServerError, "Failed to fetch experiment results")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"experiment": exp, "days": days, "results": results})
+}
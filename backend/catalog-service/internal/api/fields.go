@@ -0,0 +1,101 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// jsonFieldNames lists the JSON keys a struct type serializes to (including embedded structs)
+func jsonFieldNames(t reflect.Type) []string {
+	for t.Kind() == reflect.Ptr || t.Kind() == reflect.Slice {
+		t = t.Elem()
+	}
+	var names []string
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if f.Anonymous && tag == "" {
+			names = append(names, jsonFieldNames(f.Type)...)
+			continue
+		}
+		if !f.IsExported() || tag == "-" {
+			continue
+		}
+		name := strings.Split(tag, ",")[0]
+		if name == "" {
+			name = f.Name
+		}
+		names = append(names, name)
+	}
+	return names
+}
+
+// parseFields reads the sparse fieldset ?fields=id,title,main_price for a list whose items are of
+// the same type as sample. The allow-list is the item's own JSON keys, so public responses can never
+// be widened to admin-only fields. "id" is always returned. nil means no selection (full objects);
+// on an unknown field it writes a 400 and returns ok=false.
+func parseFields(c *gin.Context, sample interface{}) (map[string]bool, bool) {
+	raw := strings.TrimSpace(c.Query("fields"))
+	if raw == "" {
+		return nil, true
+	}
+	names := jsonFieldNames(reflect.TypeOf(sample))
+	allowed := make(map[string]bool, len(names))
+	for _, n := range names {
+		allowed[n] = true
+	}
+	fields := map[string]bool{"id": true}
+	for _, f := range strings.Split(raw, ",") {
+		f = strings.TrimSpace(f)
+		if f == "" {
+			continue
+		}
+		if !allowed[f] {
+			sort.Strings(names)
+			writeErrorDetails(c, http.StatusBadRequest, "UNKNOWN_FIELD", "Unknown field: "+f, gin.H{"allowed_fields": names})
+			return nil, false
+		}
+		fields[f] = true
+	}
+	return fields, true
+}
+
+// writeFields responds with the list, keeping only the selected fields of each item when fields is set
+func writeFields(c *gin.Context, items interface{}, fields map[string]bool) {
+	out, err := selectFields(items, fields)
+	if err != nil {
+		writeError(c, http.StatusInternalServerError, "Failed to encode response")
+		return
+	}
+	c.JSON(http.StatusOK, out)
+}
+
+// selectFields projects each item of the list onto the selected fields (items unchanged when fields is nil)
+func selectFields(items interface{}, fields map[string]bool) (interface{}, error) {
+	if fields == nil {
+		return items, nil
+	}
+	raw, err := json.Marshal(items)
+	if err != nil {
+		return nil, err
+	}
+	var full []map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &full); err != nil {
+		return nil, err
+	}
+	sparse := make([]map[string]json.RawMessage, len(full))
+	for i, item := range full {
+		sparse[i] = make(map[string]json.RawMessage, len(fields))
+		for k, v := range item {
+			if fields[k] {
+				sparse[i][k] = v
+			}
+		}
+	}
+	return sparse, nil
+}
@@ -0,0 +1,140 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"strings"
+
+	"github.com/expotoworld/expotoworld/backend/catalog-service/internal/db"
+	"github.com/expotoworld/expotoworld/backend/catalog-service/internal/models"
+	"github.com/gin-gonic/gin"
+)
+
+// kJPerKcal converts the two declared energy values; they may differ by rounding only
+const kJPerKcal = 4.184
+
+// checkFoodLabel normalizes a label request and applies the checks the binding tags can't express
+func checkFoodLabel(req *models.SetProductFoodLabelRequest) []models.FieldError {
+	var errs []models.FieldError
+	req.Ingredients = strings.TrimSpace(req.Ingredients)
+
+	if n := req.Nutrition; n != nil {
+		if n.SaturatedFat > n.Fat {
+			errs = append(errs, models.FieldError{Field: "nutrition.saturated_fat", Code: "too_large", Message: "saturated_fat cannot exceed fat"})
+		}
+		if n.Sugars > n.Carbohydrates {
+			errs = append(errs, models.FieldError{Field: "nutrition.sugars", Code: "too_large", Message: "sugars cannot exceed carbohydrates"})
+		}
+		total := n.Fat + n.Carbohydrates + n.Protein + n.Salt
+		if n.Fibre != nil {
+			total += *n.Fibre
+		}
+		if total > 100 {
+			errs = append(errs, models.FieldError{Field: "nutrition", Code: "too_large", Message: "nutrients cannot add up to more than 100 g per 100 g/ml"})
+		}
+		if n.EnergyKcal > 0 && math.Abs(n.EnergyKJ-n.EnergyKcal*kJPerKcal) > math.Max(n.EnergyKJ*0.05, 5) {
+			errs = append(errs, models.FieldError{Field: "nutrition.energy_kj", Code: "invalid",
+				Message: fmt.Sprintf("energy_kj does not match energy_kcal (expected about %.0f kJ)", n.EnergyKcal*kJPerKcal)})
+		}
+	}
+
+	contains := map[models.Allergen]bool{}
+	for i, a := range req.Allergens {
+		a = models.Allergen(strings.ToLower(strings.TrimSpace(string(a))))
+		req.Allergens[i] = a
+		field := fmt.Sprintf("allergens[%d]", i)
+		switch {
+		case !a.IsValid():
+			errs = append(errs, models.FieldError{Field: field, Code: "invalid_choice", Message: "unknown allergen " + string(a)})
+		case contains[a]:
+			errs = append(errs, models.FieldError{Field: field, Code: "duplicate", Message: string(a) + " is listed twice"})
+		}
+		contains[a] = true
+	}
+	traces := map[models.Allergen]bool{}
+	for i, a := range req.MayContain {
+		a = models.Allergen(strings.ToLower(strings.TrimSpace(string(a))))
+		req.MayContain[i] = a
+		field := fmt.Sprintf("may_contain[%d]", i)
+		switch {
+		case !a.IsValid():
+			errs = append(errs, models.FieldError{Field: field, Code: "invalid_choice", Message: "unknown allergen " + string(a)})
+		case traces[a]:
+			errs = append(errs, models.FieldError{Field: field, Code: "duplicate", Message: string(a) + " is listed twice"})
+		case contains[a]:
+			errs = append(errs, models.FieldError{Field: field, Code: "duplicate", Message: string(a) + " is already declared in allergens"})
+		}
+		traces[a] = true
+	}
+	return errs
+}
+
+// GetProductFoodLabel handles GET /products/:id/food-label
+func (h *Handler) GetProductFoodLabel(c *gin.Context) {
+	productID, ok := parseProductIDParam(c)
+	if !ok {
+		return
+	}
+	label, err := h.db.GetProductFoodLabel(c.Request.Context(), productID)
+	if errors.Is(err, db.ErrFoodLabelNotFound) {
+		writeError(c, http.StatusNotFound, "Food label not found")
+		return
+	}
+	if err != nil {
+		log.Printf("Error fetching food label of product %d: %v", productID, err)
+		writeError(c, http.StatusInternalServerError, "Failed to fetch food label")
+		return
+	}
+	c.JSON(http.StatusOK, label)
+}
+
+// SetProductFoodLabel handles PUT /products/:id/food-label
+// Replaces the product's nutrition facts, ingredients, allergens and origin country.
+func (h *Handler) SetProductFoodLabel(c *gin.Context) {
+	productID, ok := parseProductIDParam(c)
+	if !ok {
+		return
+	}
+	var req models.SetProductFoodLabelRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+	if errs := checkFoodLabel(&req); len(errs) > 0 {
+		writeFieldErrors(c, errs...)
+		return
+	}
+
+	label, err := h.db.SetProductFoodLabel(c.Request.Context(), productID, &req)
+	if errors.Is(err, db.ErrProductNotFound) {
+		writeError(c, http.StatusNotFound, "Product not found")
+		return
+	}
+	if err != nil {
+		log.Printf("Error saving food label of product %d: %v", productID, err)
+		writeError(c, http.StatusInternalServerError, "Failed to save food label")
+		return
+	}
+	c.JSON(http.StatusOK, label)
+}
+
+// DeleteProductFoodLabel handles DELETE /products/:id/food-label
+func (h *Handler) DeleteProductFoodLabel(c *gin.Context) {
+	productID, ok := parseProductIDParam(c)
+	if !ok {
+		return
+	}
+	err := h.db.DeleteProductFoodLabel(c.Request.Context(), productID)
+	if errors.Is(err, db.ErrFoodLabelNotFound) {
+		writeError(c, http.StatusNotFound, "Food label not found")
+		return
+	}
+	if err != nil {
+		log.Printf("Error deleting food label of product %d: %v", productID, err)
+		writeError(c, http.StatusInternalServerError, "Failed to delete food label")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Food label deleted successfully"})
+}
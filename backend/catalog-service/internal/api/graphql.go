@@ -0,0 +1,606 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/expotoworld/expotoworld/backend/catalog-service/internal/db"
+	"github.com/expotoworld/expotoworld/backend/catalog-service/internal/graphql"
+	"github.com/expotoworld/expotoworld/backend/catalog-service/internal/models"
+	"github.com/gin-gonic/gin"
+)
+
+// GraphQL handles POST /graphql: read-only queries over products, categories and stores
+// with their nested images and subcategories, so a screen fetches exactly its fields in one call.
+// Visibility matches the REST endpoints: the public sees active rows only, admins see everything
+// (regional admins only their stores' products) plus the admin-only product fields.
+func (h *Handler) GraphQL(c *gin.Context) {
+	var req graphql.Request
+	if !bindJSON(c, &req) {
+		return
+	}
+	if req.Query == "" {
+		writeError(c, http.StatusBadRequest, "query is required")
+		return
+	}
+
+	q := &catalogQuery{h: h, admin: IsAdmin(c)}
+	if q.admin {
+		scope, ok := h.resolveAdminScope(c)
+		if !ok {
+			return
+		}
+		if scope != nil {
+			q.scope = scope.storeIDs()
+		}
+	}
+	q.initLoaders()
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	res := catalogSchema.Execute(ctx, req, q)
+	if res.Data == nil {
+		c.JSON(http.StatusBadRequest, res)
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+// graphQLMaxLimit caps list sizes of the products root field
+const graphQLMaxLimit = 200
+
+// errGraphQLLoad is reported for a field whose data could not be loaded (details are logged)
+var errGraphQLLoad = errors.New("failed to load data")
+
+// catalogQuery is the state of one GraphQL request: who is asking, and loaders that fetch the
+// nested data of every object seen so far in one query instead of one per parent
+type catalogQuery struct {
+	h     *Handler
+	admin bool
+	scope []int // stores of a regional admin; nil when unrestricted
+
+	images          *batchLoader[[]models.ProductImage]
+	relations       *batchLoader[db.ProductRelations]
+	categories      *batchLoader[*gqlCategory]
+	subcategories   *batchLoader[*models.Subcategory]
+	subcatsByParent *batchLoader[[]*models.Subcategory]
+	stores          *batchLoader[*gqlStore]
+}
+
+func (q *catalogQuery) filter() db.CatalogFilter {
+	return db.CatalogFilter{IncludeInactive: q.admin, ScopeStoreIDs: q.scope}
+}
+
+func (q *catalogQuery) initLoaders() {
+	q.images = newBatchLoader(q.h.db.GetImagesForProducts)
+	q.relations = newBatchLoader(func(ctx context.Context, ids []int) (map[int]db.ProductRelations, error) {
+		rels, err := q.h.db.GetProductRelations(ctx, ids)
+		if err != nil {
+			return nil, err
+		}
+		// Categories of every loaded product are fetched together on first use
+		for _, r := range rels {
+			q.categories.want(atoiAll(r.CategoryIDs)...)
+			q.subcategories.want(atoiAll(r.SubcategoryIDs)...)
+		}
+		return rels, nil
+	})
+	q.categories = newBatchLoader(func(ctx context.Context, ids []int) (map[int]*gqlCategory, error) {
+		f := q.filter()
+		f.IDs = ids
+		cats, err := q.h.db.ListCatalogCategories(ctx, f)
+		if err != nil {
+			return nil, err
+		}
+		out := make(map[int]*gqlCategory, len(cats))
+		for _, c := range q.wrapCategories(cats) {
+			out[c.ID] = c
+		}
+		return out, nil
+	})
+	q.subcategories = newBatchLoader(func(ctx context.Context, ids []int) (map[int]*models.Subcategory, error) {
+		f := q.filter()
+		f.IDs = ids
+		subs, err := q.h.db.ListCatalogSubcategories(ctx, nil, f)
+		if err != nil {
+			return nil, err
+		}
+		out := make(map[int]*models.Subcategory, len(subs))
+		for i := range subs {
+			out[subs[i].ID] = &subs[i]
+		}
+		return out, nil
+	})
+	q.subcatsByParent = newBatchLoader(func(ctx context.Context, categoryIDs []int) (map[int][]*models.Subcategory, error) {
+		subs, err := q.h.db.ListCatalogSubcategories(ctx, categoryIDs, q.filter())
+		if err != nil {
+			return nil, err
+		}
+		out := make(map[int][]*models.Subcategory, len(categoryIDs))
+		for i := range subs {
+			out[subs[i].ParentCategoryID] = append(out[subs[i].ParentCategoryID], &subs[i])
+		}
+		return out, nil
+	})
+	q.stores = newBatchLoader(func(ctx context.Context, ids []int) (map[int]*gqlStore, error) {
+		f := q.filter()
+		f.IDs = ids
+		stores, err := q.h.db.ListCatalogStores(ctx, "", f)
+		if err != nil {
+			return nil, err
+		}
+		out := make(map[int]*gqlStore, len(stores))
+		for _, s := range q.wrapStores(stores) {
+			out[s.ID] = s
+		}
+		return out, nil
+	})
+}
+
+// gqlProduct, gqlCategory and gqlStore carry the request so nested fields can use its loaders
+type gqlProduct struct {
+	*models.Product
+	q *catalogQuery
+}
+
+type gqlCategory struct {
+	*models.Category
+	q *catalogQuery
+}
+
+type gqlStore struct {
+	*models.Store
+	q *catalogQuery
+}
+
+func (q *catalogQuery) wrapProducts(products []models.Product) []*gqlProduct {
+	out := make([]*gqlProduct, len(products))
+	for i := range products {
+		out[i] = &gqlProduct{Product: &products[i], q: q}
+		q.images.want(products[i].ID)
+		q.relations.want(products[i].ID)
+		if products[i].StoreID != nil {
+			q.stores.want(*products[i].StoreID)
+		}
+	}
+	return out
+}
+
+func (q *catalogQuery) wrapCategories(categories []models.Category) []*gqlCategory {
+	out := make([]*gqlCategory, len(categories))
+	for i := range categories {
+		out[i] = &gqlCategory{Category: &categories[i], q: q}
+		q.subcatsByParent.want(categories[i].ID)
+		if categories[i].StoreID != nil {
+			q.stores.want(*categories[i].StoreID)
+		}
+	}
+	return out
+}
+
+func (q *catalogQuery) wrapStores(stores []models.Store) []*gqlStore {
+	out := make([]*gqlStore, len(stores))
+	now := time.Now()
+	for i := range stores {
+		stores[i].IsOpenNow = stores[i].IsOpenAt(now)
+		out[i] = &gqlStore{Store: &stores[i], q: q}
+	}
+	return out
+}
+
+// batchLoader collects the keys of every parent seen so far and, on the first lookup of a key not
+// yet loaded, fetches all pending keys in one call. Missing keys resolve to the zero value.
+type batchLoader[T any] struct {
+	load    func(ctx context.Context, ids []int) (map[int]T, error)
+	pending map[int]bool
+	loaded  map[int]T
+}
+
+func newBatchLoader[T any](load func(ctx context.Context, ids []int) (map[int]T, error)) *batchLoader[T] {
+	return &batchLoader[T]{load: load, pending: map[int]bool{}, loaded: map[int]T{}}
+}
+
+func (l *batchLoader[T]) want(ids ...int) {
+	for _, id := range ids {
+		if _, ok := l.loaded[id]; !ok {
+			l.pending[id] = true
+		}
+	}
+}
+
+func (l *batchLoader[T]) get(ctx context.Context, id int) (T, error) {
+	if v, ok := l.loaded[id]; ok {
+		return v, nil
+	}
+	l.pending[id] = true
+	ids := make([]int, 0, len(l.pending))
+	for k := range l.pending {
+		ids = append(ids, k)
+	}
+	l.pending = map[int]bool{}
+	found, err := l.load(ctx, ids)
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	for _, k := range ids {
+		l.loaded[k] = found[k]
+	}
+	return l.loaded[id], nil
+}
+
+func atoiAll(ss []string) []int {
+	out := make([]int, 0, len(ss))
+	for _, s := range ss {
+		if n, err := strconv.Atoi(s); err == nil {
+			out = append(out, n)
+		}
+	}
+	return out
+}
+
+// loadFailed logs the cause and returns the error shown to the client
+func loadFailed(what string, err error) error {
+	log.Printf("GraphQL: failed to load %s: %v", what, err)
+	return errGraphQLLoad
+}
+
+// prop resolves a scalar from the source object
+func prop[T any](get func(T) interface{}) *graphql.Field {
+	return &graphql.Field{Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+		return get(p.Source.(T)), nil
+	}}
+}
+
+// adminProp resolves a scalar only admins may read
+func adminProp(name string, get func(*gqlProduct) interface{}) *graphql.Field {
+	return &graphql.Field{Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+		src := p.Source.(*gqlProduct)
+		if !src.q.admin {
+			return nil, fmt.Errorf("%s is only available to admins", name)
+		}
+		return get(src), nil
+	}}
+}
+
+var catalogSchema = newCatalogSchema()
+
+func newCatalogSchema() *graphql.Schema {
+	product := &graphql.Object{Name: "Product"}
+	image := &graphql.Object{Name: "ProductImage"}
+	category := &graphql.Object{Name: "Category"}
+	subcategory := &graphql.Object{Name: "Subcategory"}
+	store := &graphql.Object{Name: "Store"}
+
+	image.Fields = map[string]*graphql.Field{
+		"id":            prop(func(i *models.ProductImage) interface{} { return i.ID }),
+		"image_url":     prop(func(i *models.ProductImage) interface{} { return i.ImageURL }),
+		"thumbnail_url": prop(func(i *models.ProductImage) interface{} { return i.ThumbnailURL }),
+		"medium_url":    prop(func(i *models.ProductImage) interface{} { return i.MediumURL }),
+		"webp_url":      prop(func(i *models.ProductImage) interface{} { return i.WebPURL }),
+		"display_order": prop(func(i *models.ProductImage) interface{} { return i.DisplayOrder }),
+		"is_primary":    prop(func(i *models.ProductImage) interface{} { return i.IsPrimary }),
+	}
+
+	subcategory.Fields = map[string]*graphql.Field{
+		"id":                 prop(func(s *models.Subcategory) interface{} { return s.ID }),
+		"parent_category_id": prop(func(s *models.Subcategory) interface{} { return s.ParentCategoryID }),
+		"name":               prop(func(s *models.Subcategory) interface{} { return s.Name }),
+		"image_url":          prop(func(s *models.Subcategory) interface{} { return s.ImageURL }),
+		"thumbnail_url":      prop(func(s *models.Subcategory) interface{} { return s.ThumbnailURL }),
+		"medium_url":         prop(func(s *models.Subcategory) interface{} { return s.MediumURL }),
+		"webp_url":           prop(func(s *models.Subcategory) interface{} { return s.WebPURL }),
+		"display_order":      prop(func(s *models.Subcategory) interface{} { return s.DisplayOrder }),
+		"is_active":          prop(func(s *models.Subcategory) interface{} { return s.IsActive }),
+	}
+
+	store.Fields = map[string]*graphql.Field{
+		"id":            prop(func(s *gqlStore) interface{} { return s.ID }),
+		"name":          prop(func(s *gqlStore) interface{} { return s.Name }),
+		"city":          prop(func(s *gqlStore) interface{} { return s.City }),
+		"address":       prop(func(s *gqlStore) interface{} { return s.Address }),
+		"latitude":      prop(func(s *gqlStore) interface{} { return s.Latitude }),
+		"longitude":     prop(func(s *gqlStore) interface{} { return s.Longitude }),
+		"type":          prop(func(s *gqlStore) interface{} { return s.Type }),
+		"region_id":     prop(func(s *gqlStore) interface{} { return s.RegionID }),
+		"currency":      prop(func(s *gqlStore) interface{} { return s.Currency }),
+		"image_url":     prop(func(s *gqlStore) interface{} { return s.ImageURL }),
+		"thumbnail_url": prop(func(s *gqlStore) interface{} { return s.ThumbnailURL }),
+		"medium_url":    prop(func(s *gqlStore) interface{} { return s.MediumURL }),
+		"webp_url":      prop(func(s *gqlStore) interface{} { return s.WebPURL }),
+		"is_active":     prop(func(s *gqlStore) interface{} { return s.IsActive }),
+		"timezone":      prop(func(s *gqlStore) interface{} { return s.Timezone }),
+		"opening_hours": prop(func(s *gqlStore) interface{} { return s.OpeningHours }),
+		"holidays":      prop(func(s *gqlStore) interface{} { return s.Holidays }),
+		"is_open_now":   prop(func(s *gqlStore) interface{} { return s.IsOpenNow }),
+	}
+
+	category.Fields = map[string]*graphql.Field{
+		"id":                     prop(func(c *gqlCategory) interface{} { return c.ID }),
+		"name":                   prop(func(c *gqlCategory) interface{} { return c.Name }),
+		"store_type_association": prop(func(c *gqlCategory) interface{} { return c.StoreTypeAssociation }),
+		"mini_app_association":   prop(func(c *gqlCategory) interface{} { return c.MiniAppAssociation }),
+		"store_id":               prop(func(c *gqlCategory) interface{} { return c.StoreID }),
+		"display_order":          prop(func(c *gqlCategory) interface{} { return c.DisplayOrder }),
+		"is_active":              prop(func(c *gqlCategory) interface{} { return c.IsActive }),
+		"image_url":              prop(func(c *gqlCategory) interface{} { return c.ImageURL }),
+		"thumbnail_url":          prop(func(c *gqlCategory) interface{} { return c.ThumbnailURL }),
+		"medium_url":             prop(func(c *gqlCategory) interface{} { return c.MediumURL }),
+		"webp_url":               prop(func(c *gqlCategory) interface{} { return c.WebPURL }),
+		"subcategories": {
+			Type: subcategory,
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				c := p.Source.(*gqlCategory)
+				subs, err := c.q.subcatsByParent.get(p.Context, c.ID)
+				if err != nil {
+					return nil, loadFailed("subcategories", err)
+				}
+				if subs == nil {
+					subs = []*models.Subcategory{}
+				}
+				return subs, nil
+			},
+		},
+		"store": {
+			Type: store,
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				c := p.Source.(*gqlCategory)
+				if c.StoreID == nil {
+					return nil, nil
+				}
+				s, err := c.q.stores.get(p.Context, *c.StoreID)
+				if err != nil {
+					return nil, loadFailed("stores", err)
+				}
+				return s, nil
+			},
+		},
+	}
+
+	product.Fields = map[string]*graphql.Field{
+		"id":                         prop(func(p *gqlProduct) interface{} { return p.ID }),
+		"uuid":                       prop(func(p *gqlProduct) interface{} { return p.UUID }),
+		"sku":                        prop(func(p *gqlProduct) interface{} { return p.SKU }),
+		"title":                      prop(func(p *gqlProduct) interface{} { return p.Title }),
+		"description_long":           prop(func(p *gqlProduct) interface{} { return p.DescriptionLong }),
+		"store_type":                 prop(func(p *gqlProduct) interface{} { return p.StoreType }),
+		"mini_app_type":              prop(func(p *gqlProduct) interface{} { return p.MiniAppType }),
+		"store_id":                   prop(func(p *gqlProduct) interface{} { return p.StoreID }),
+		"barcode":                    prop(func(p *gqlProduct) interface{} { return p.Barcode }),
+		"main_price":                 prop(func(p *gqlProduct) interface{} { return p.MainPrice }),
+		"strikethrough_price":        prop(func(p *gqlProduct) interface{} { return p.StrikethroughPrice }),
+		"weight":                     prop(func(p *gqlProduct) interface{} { return p.Weight }),
+		"stock_left":                 prop(func(p *gqlProduct) interface{} { return p.StockLeft }),
+		"minimum_order_quantity":     prop(func(p *gqlProduct) interface{} { return p.MinimumOrderQuantity }),
+		"is_active":                  prop(func(p *gqlProduct) interface{} { return p.IsActive }),
+		"is_featured":                prop(func(p *gqlProduct) interface{} { return p.IsFeatured }),
+		"is_mini_app_recommendation": prop(func(p *gqlProduct) interface{} { return p.IsMiniAppRecommendation }),
+		"created_at":                 prop(func(p *gqlProduct) interface{} { return p.CreatedAt }),
+		"updated_at":                 prop(func(p *gqlProduct) interface{} { return p.UpdatedAt }),
+		"shelf_code":                 adminProp("shelf_code", func(p *gqlProduct) interface{} { return p.ShelfCode }),
+		"cost_price":                 adminProp("cost_price", func(p *gqlProduct) interface{} { return p.CostPrice }),
+		"tax_class":                  adminProp("tax_class", func(p *gqlProduct) interface{} { return p.TaxClass }),
+		"images": {
+			Type: image,
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				src := p.Source.(*gqlProduct)
+				images, err := src.q.images.get(p.Context, src.ID)
+				if err != nil {
+					return nil, loadFailed("product images", err)
+				}
+				out := make([]*models.ProductImage, len(images))
+				for i := range images {
+					out[i] = &images[i]
+				}
+				return out, nil
+			},
+		},
+		"categories": {
+			Type: category,
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				src := p.Source.(*gqlProduct)
+				rel, err := src.q.relations.get(p.Context, src.ID)
+				if err != nil {
+					return nil, loadFailed("product categories", err)
+				}
+				out := []*gqlCategory{}
+				for _, id := range atoiAll(rel.CategoryIDs) {
+					c, err := src.q.categories.get(p.Context, id)
+					if err != nil {
+						return nil, loadFailed("categories", err)
+					}
+					// Inactive categories are hidden from the public
+					if c != nil {
+						out = append(out, c)
+					}
+				}
+				return out, nil
+			},
+		},
+		"subcategories": {
+			Type: subcategory,
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				src := p.Source.(*gqlProduct)
+				rel, err := src.q.relations.get(p.Context, src.ID)
+				if err != nil {
+					return nil, loadFailed("product subcategories", err)
+				}
+				out := []*models.Subcategory{}
+				for _, id := range atoiAll(rel.SubcategoryIDs) {
+					s, err := src.q.subcategories.get(p.Context, id)
+					if err != nil {
+						return nil, loadFailed("subcategories", err)
+					}
+					if s != nil {
+						out = append(out, s)
+					}
+				}
+				return out, nil
+			},
+		},
+		"store": {
+			Type: store,
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				src := p.Source.(*gqlProduct)
+				if src.StoreID == nil {
+					return nil, nil
+				}
+				s, err := src.q.stores.get(p.Context, *src.StoreID)
+				if err != nil {
+					return nil, loadFailed("stores", err)
+				}
+				return s, nil
+			},
+		},
+	}
+
+	query := &graphql.Object{Name: "Query", Fields: map[string]*graphql.Field{
+		"products": {
+			Type: product,
+			Args: map[string]graphql.ArgType{
+				"ids": graphql.IntList, "store_id": graphql.Int, "category_id": graphql.Int, "subcategory_id": graphql.Int,
+				"mini_app_type": graphql.String, "featured": graphql.Boolean, "region_id": graphql.Int, "after_id": graphql.Int, "limit": graphql.Int,
+			},
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				q := p.Source.(*catalogQuery)
+				f := q.filter()
+				f.Limit = 50
+				if n, ok := p.Args.Int("limit"); ok {
+					if n < 1 || n > graphQLMaxLimit {
+						return nil, fmt.Errorf("limit must be between 1 and %d", graphQLMaxLimit)
+					}
+					f.Limit = n
+				}
+				if ids, ok := p.Args.IntList("ids"); ok {
+					if len(ids) > maxBatchProductIDs {
+						return nil, fmt.Errorf("at most %d ids per query", maxBatchProductIDs)
+					}
+					f.IDs = ids
+				}
+				f.AfterID, _ = p.Args.Int("after_id")
+				f.MiniAppType, _ = p.Args.String("mini_app_type")
+				if v, ok := p.Args.Int("store_id"); ok {
+					f.StoreID = &v
+				}
+				if v, ok := p.Args.Int("region_id"); ok {
+					f.RegionID = &v
+				}
+				if v, ok := p.Args.Int("category_id"); ok {
+					f.CategoryID = &v
+				}
+				if v, ok := p.Args.Int("subcategory_id"); ok {
+					f.SubcategoryID = &v
+				}
+				if v, ok := p.Args.Bool("featured"); ok {
+					f.Featured = &v
+				}
+				products, err := q.h.db.ListCatalogProducts(p.Context, f)
+				if err != nil {
+					return nil, loadFailed("products", err)
+				}
+				return q.wrapProducts(products), nil
+			},
+		},
+		"product": {
+			Type: product,
+			Args: map[string]graphql.ArgType{"id": graphql.Int},
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				q := p.Source.(*catalogQuery)
+				id, ok := p.Args.Int("id")
+				if !ok {
+					return nil, errors.New("id is required")
+				}
+				f := q.filter()
+				f.IDs = []int{id}
+				f.Limit = 1
+				products, err := q.h.db.ListCatalogProducts(p.Context, f)
+				if err != nil {
+					return nil, loadFailed("products", err)
+				}
+				if len(products) == 0 {
+					return nil, nil
+				}
+				return q.wrapProducts(products)[0], nil
+			},
+		},
+		"categories": {
+			Type: category,
+			Args: map[string]graphql.ArgType{"ids": graphql.IntList, "store_id": graphql.Int, "mini_app_type": graphql.String},
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				q := p.Source.(*catalogQuery)
+				f := db.CatalogFilter{IncludeInactive: q.admin}
+				f.IDs, _ = p.Args.IntList("ids")
+				f.MiniAppType, _ = p.Args.String("mini_app_type")
+				if v, ok := p.Args.Int("store_id"); ok {
+					f.StoreID = &v
+				}
+				cats, err := q.h.db.ListCatalogCategories(p.Context, f)
+				if err != nil {
+					return nil, loadFailed("categories", err)
+				}
+				return q.wrapCategories(cats), nil
+			},
+		},
+		"category": {
+			Type: category,
+			Args: map[string]graphql.ArgType{"id": graphql.Int},
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				q := p.Source.(*catalogQuery)
+				id, ok := p.Args.Int("id")
+				if !ok {
+					return nil, errors.New("id is required")
+				}
+				c, err := q.categories.get(p.Context, id)
+				if err != nil {
+					return nil, loadFailed("categories", err)
+				}
+				return c, nil
+			},
+		},
+		"stores": {
+			Type: store,
+			Args: map[string]graphql.ArgType{"ids": graphql.IntList, "type": graphql.String, "mini_app_type": graphql.String, "region_id": graphql.Int},
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				q := p.Source.(*catalogQuery)
+				f := q.filter()
+				f.IDs, _ = p.Args.IntList("ids")
+				f.MiniAppType, _ = p.Args.String("mini_app_type")
+				if v, ok := p.Args.Int("region_id"); ok {
+					f.RegionID = &v
+				}
+				storeType, _ := p.Args.String("type")
+				if storeType != "" {
+					storeType = convertStoreTypeToDBValue(storeType)
+				}
+				stores, err := q.h.db.ListCatalogStores(p.Context, storeType, f)
+				if err != nil {
+					return nil, loadFailed("stores", err)
+				}
+				return q.wrapStores(stores), nil
+			},
+		},
+		"store": {
+			Type: store,
+			Args: map[string]graphql.ArgType{"id": graphql.Int},
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				q := p.Source.(*catalogQuery)
+				id, ok := p.Args.Int("id")
+				if !ok {
+					return nil, errors.New("id is required")
+				}
+				s, err := q.stores.get(p.Context, id)
+				if err != nil {
+					return nil, loadFailed("stores", err)
+				}
+				return s, nil
+			},
+		},
+	}}
+	return &graphql.Schema{Query: query}
+}
@@ -4,7 +4,7 @@ import (
 	"bytes"
 	"context"
 	"database/sql"
-
+	"errors"
 	"fmt"
 	"io"
 	"log"
@@ -16,12 +16,14 @@ import (
 	"strings"
 	"time"
 
-	"github.com/aws/aws-sdk-go-v2/config"
-	"github.com/aws/aws-sdk-go-v2/service/s3"
-	s3types "github.com/aws/aws-sdk-go-v2/service/s3/types"
 	"github.com/expotoworld/expotoworld/backend/catalog-service/internal/db"
+	"github.com/expotoworld/expotoworld/backend/catalog-service/internal/imaging"
 	"github.com/expotoworld/expotoworld/backend/catalog-service/internal/models"
+	"github.com/expotoworld/expotoworld/backend/catalog-service/internal/translate"
+	"github.com/expotoworld/expotoworld/backend/common/awsclient"
+	"github.com/expotoworld/expotoworld/backend/common/storage"
 	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
 )
 
 // convertStoreTypeToDBValue converts English API enum values to Chinese database values
@@ -60,12 +62,21 @@ func convertStoreTypeToAssociation(apiValue string) string {
 
 // Handler holds the database connection and provides HTTP handlers
 type Handler struct {
-	db *db.Database
+	db         *db.Database
+	translator translate.Provider // nil when machine translation drafting is disabled
+	aws        *awsclient.Provider
+	media      storage.Store
 }
 
 // NewHandler creates a new handler instance
 func NewHandler(database *db.Database) *Handler {
-	return &Handler{db: database}
+	aws := awsclient.NewProviderFromEnv()
+	return &Handler{
+		db:         database,
+		translator: translate.NewProviderFromEnv(),
+		aws:        aws,
+		media:      storage.New(storage.ConfigFromEnv(), aws.S3),
+	}
 }
 
 // =================================================================================
@@ -73,13 +84,27 @@ func NewHandler(database *db.Database) *Handler {
 // =================================================================================
 
 // CreateProduct handles POST /products
+// Optional ?source_locale= and ?draft_locales= (comma-separated or "all") request machine-drafted translations.
 func (h *Handler) CreateProduct(c *gin.Context) {
 	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
 	defer cancel()
 
 	var newProduct models.Product
-	if err := c.ShouldBindJSON(&newProduct); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body: " + err.Error()})
+	if !bindJSON(c, &newProduct) {
+		return
+	}
+	if fe := checkProductTaxClass(&newProduct); fe != nil {
+		writeFieldErrors(c, *fe)
+		return
+	}
+	if fe := checkProductBarcode(&newProduct); fe != nil {
+		writeFieldErrors(c, *fe)
+		return
+	}
+	if !h.requireStoreInScope(c, newProduct.StoreID) {
+		return
+	}
+	if !h.checkProductShelfCode(ctx, c, &newProduct) {
 		return
 	}
 
@@ -87,24 +112,30 @@ func (h *Handler) CreateProduct(c *gin.Context) {
 	productID, err := h.db.CreateProduct(ctx, newProduct)
 	if err != nil {
 		log.Printf("Failed to create product in DB: %v", err)
+		if errors.Is(err, db.ErrBarcodeTaken) {
+			writeBarcodeTaken(c, newProduct)
+			return
+		}
 
 		// Handle specific database errors
 		if strings.Contains(err.Error(), "duplicate key value violates unique constraint \"admin_products_sku_key\"") {
-			c.JSON(http.StatusConflict, gin.H{
-				"error":      fmt.Sprintf("SKU '%s' already exists. Please use a different SKU.", newProduct.SKU),
-				"error_code": "DUPLICATE_SKU",
-			})
+			writeErrorDetails(c, http.StatusConflict, "DUPLICATE_SKU",
+				fmt.Sprintf("SKU '%s' already exists. Please use a different SKU.", newProduct.SKU), nil)
 			return
 		}
 
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create product"})
+		writeError(c, http.StatusInternalServerError, "Failed to create product")
 		return
 	}
 
+	h.startTranslationDrafts(c, productID)
+
 	c.JSON(http.StatusCreated, gin.H{"product_id": productID})
 }
 
 // ValidateShelfCode handles GET /products/validate-shelf-code to check uniqueness per store
+// (across products and product variants) and the fit with the store's shelf layout. shelf_code in
+// the response is the canonical form the product will be saved with.
 func (h *Handler) ValidateShelfCode(c *gin.Context) {
 	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
 	defer cancel()
@@ -112,41 +143,47 @@ func (h *Handler) ValidateShelfCode(c *gin.Context) {
 	storeIDStr := c.Query("store_id")
 	shelfCode := strings.TrimSpace(c.Query("shelf_code"))
 	if storeIDStr == "" || shelfCode == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "store_id and shelf_code are required"})
+		writeError(c, http.StatusBadRequest, "store_id and shelf_code are required")
 		return
 	}
 	storeID, err := strconv.Atoi(storeIDStr)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid store_id"})
+		writeError(c, http.StatusBadRequest, "invalid store_id")
 		return
 	}
 
-	var excludeID *int
-	if pid := c.Query("product_id"); pid != "" {
-		if v, err := strconv.Atoi(pid); err == nil {
-			excludeID = &v
-		}
-	}
+	// product_id / variant_id exclude the row being edited
+	excludeProductID, _ := strconv.Atoi(c.Query("product_id"))
+	excludeVariantID, _ := strconv.Atoi(c.Query("variant_id"))
 
-	var count int
-	if excludeID != nil {
-		err = h.db.Pool.QueryRow(ctx,
-			"SELECT COUNT(1) FROM admin_products WHERE store_id = $1 AND shelf_code = $2 AND product_id != $3",
-			storeID, shelfCode, *excludeID,
-		).Scan(&count)
-	} else {
-		err = h.db.Pool.QueryRow(ctx,
-			"SELECT COUNT(1) FROM admin_products WHERE store_id = $1 AND shelf_code = $2",
-			storeID, shelfCode,
-		).Scan(&count)
+	// The code must also name a slot of the store's shelf layout, when one is configured
+	shelfCode, err = h.db.CanonicalShelfCode(ctx, storeID, shelfCode)
+	var layoutErr *db.ShelfCodeOutsideLayoutError
+	if errors.As(err, &layoutErr) {
+		c.JSON(http.StatusOK, gin.H{"valid": false, "reason": layoutErr.Reason})
+		return
+	}
+	if errors.Is(err, db.ErrStoreNotFound) {
+		writeError(c, http.StatusNotFound, "Store not found")
+		return
 	}
 	if err != nil {
 		log.Printf("Failed to validate shelf code: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to validate shelf code"})
+		writeError(c, http.StatusInternalServerError, "Failed to validate shelf code")
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"valid": count == 0})
+	taken, err := h.db.ShelfCodeTaken(ctx, storeID, shelfCode, excludeProductID, excludeVariantID)
+	if err != nil {
+		log.Printf("Failed to validate shelf code: %v", err)
+		writeError(c, http.StatusInternalServerError, "Failed to validate shelf code")
+		return
+	}
+	if taken {
+		c.JSON(http.StatusOK, gin.H{"valid": false, "reason": "shelf code is already used in this store", "shelf_code": shelfCode})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"valid": true, "shelf_code": shelfCode})
 }
 
 // UploadProductImage handles POST /products/:id/image
@@ -159,7 +196,7 @@ func (h *Handler) UploadProductImage(c *gin.Context) {
 	productID, err := strconv.Atoi(idStr)
 	if err != nil {
 		log.Printf("Invalid product ID format: %s", idStr)
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID format"})
+		writeError(c, http.StatusBadRequest, "Invalid product ID format")
 		return
 	}
 
@@ -167,80 +204,46 @@ func (h *Handler) UploadProductImage(c *gin.Context) {
 	fileHeader, err := c.FormFile("productImage") // "productImage" is the name of the form field.
 	if err != nil {
 		log.Printf("Missing productImage form field: %v", err)
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Missing 'productImage' form field"})
-		return
-	}
-
-	// Validate file size (max 10MB)
-	if fileHeader.Size > 10*1024*1024 {
-		log.Printf("File too large: %d bytes", fileHeader.Size)
-		c.JSON(http.StatusBadRequest, gin.H{"error": "File size exceeds 10MB limit"})
+		writeError(c, http.StatusBadRequest, "Missing 'productImage' form field")
 		return
 	}
 
-	// Validate file type
-	allowedTypes := map[string]bool{
-		"image/jpeg": true,
-		"image/jpg":  true,
-		"image/png":  true,
-		"image/gif":  true,
-		"image/webp": true,
-	}
-
-	// Open the file to check content type
 	file, err := fileHeader.Open()
 	if err != nil {
 		log.Printf("Failed to open uploaded file: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to open uploaded file"})
+		writeError(c, http.StatusInternalServerError, "Failed to open uploaded file")
 		return
 	}
 	defer file.Close()
 
-	// Read first 512 bytes to detect content type
-	buffer := make([]byte, 512)
-	_, err = file.Read(buffer)
-	if err != nil {
-		log.Printf("Failed to read file content: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read file content"})
-		return
-	}
-
-	// Reset file pointer
-	file.Seek(0, 0)
-
-	// Detect content type
-	contentType := http.DetectContentType(buffer)
-	if !allowedTypes[contentType] {
-		log.Printf("Invalid file type: %s", contentType)
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid file type. Only images are allowed"})
+	// Decode and sanitize the image (max 10MB)
+	img, ok := readImage(c, file, maxImageUploadBytes)
+	if !ok {
 		return
 	}
 
-	// Try S3 upload first, fallback to local storage if AWS is not configured
-	imageURL, err := h.uploadToS3(ctx, productID, fileHeader, file)
+	imageURL, err := h.uploadProductImage(ctx, productID, img)
 	if err != nil {
-		log.Printf("S3 upload failed, falling back to local storage: %v", err)
-		// Fallback to local storage for development
-		imageURL, err = h.uploadToLocal(productID, fileHeader, file)
-		if err != nil {
-			log.Printf("Local upload also failed: %v", err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to upload file"})
-			return
-		}
+		log.Printf("Product image upload failed: %v", err)
+		writeError(c, http.StatusInternalServerError, "Failed to upload file")
+		return
 	}
 
 	// --- Save to Database (Replace existing image) ---
 	if err := h.db.ReplaceProductImage(ctx, productID, imageURL); err != nil {
 		log.Printf("Failed to save image URL to DB: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "File uploaded but failed to update product record"})
+		writeError(c, http.StatusInternalServerError, "File uploaded but failed to update product record")
 		return
 	}
+	h.startRenditions(db.RenditionsProductImages, strconv.Itoa(productID), imageURL, img.Data)
 
 	// --- Return Success Response ---
 	c.JSON(http.StatusCreated, gin.H{"image_url": imageURL})
 }
 
 // UpdateProduct handles PUT /products/:id
+// stock_left is ignored for products that already track stock; use POST /products/:id/stock-adjustments.
+// With If-Unmodified-Since (the loaded updated_at), a concurrent edit gets 409 with the current product.
 func (h *Handler) UpdateProduct(c *gin.Context) {
 	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
 	defer cancel()
@@ -251,15 +254,32 @@ func (h *Handler) UpdateProduct(c *gin.Context) {
 
 	productID, err := strconv.Atoi(idStr)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID format"})
+		writeError(c, http.StatusBadRequest, "Invalid product ID format")
+		return
+	}
+
+	unmodifiedSince, ok := parseUnmodifiedSince(c)
+	if !ok {
 		return
 	}
 
 	// Parse request body
 	var updatedProduct models.Product
-	if err := c.ShouldBindJSON(&updatedProduct); err != nil {
-		log.Printf("[UpdateProduct] bind error: %v", err)
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body: " + err.Error()})
+	if !bindJSON(c, &updatedProduct) {
+		return
+	}
+	if fe := checkProductTaxClass(&updatedProduct); fe != nil {
+		writeFieldErrors(c, *fe)
+		return
+	}
+	if fe := checkProductBarcode(&updatedProduct); fe != nil {
+		writeFieldErrors(c, *fe)
+		return
+	}
+	if !h.requireStoreInScope(c, updatedProduct.StoreID) {
+		return
+	}
+	if !h.checkProductShelfCode(ctx, c, &updatedProduct) {
 		return
 	}
 
@@ -274,12 +294,16 @@ func (h *Handler) UpdateProduct(c *gin.Context) {
 	)
 
 	// Update the product in the database
-	if err := h.db.UpdateProduct(ctx, productID, updatedProduct); err != nil {
+	if err := h.db.UpdateProduct(ctx, productID, updatedProduct, unmodifiedSince); err != nil {
 		log.Printf("[UpdateProduct] db error for product %d: %v", productID, err)
-		if err.Error() == fmt.Sprintf("product with ID %d not found", productID) {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Product not found"})
+		if errors.Is(err, db.ErrEditConflict) {
+			writeEditConflict(c, "Product", h.currentProduct(productID))
+		} else if errors.Is(err, db.ErrBarcodeTaken) {
+			writeBarcodeTaken(c, updatedProduct)
+		} else if err.Error() == fmt.Sprintf("product with ID %d not found", productID) {
+			writeError(c, http.StatusNotFound, "Product not found")
 		} else {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update product", "details": err.Error()})
+			writeError(c, http.StatusInternalServerError, "Failed to update product")
 		}
 		return
 	}
@@ -291,7 +315,7 @@ func (h *Handler) UpdateProduct(c *gin.Context) {
 	})
 }
 
-// DeleteProduct handles DELETE /products/:id
+// DeleteProduct handles DELETE /products/:id (hard=true moves it to the trash, see moveToTrash)
 func (h *Handler) DeleteProduct(c *gin.Context) {
 	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
 	defer cancel()
@@ -300,7 +324,7 @@ func (h *Handler) DeleteProduct(c *gin.Context) {
 	idStr := c.Param("id")
 	productID, err := strconv.Atoi(idStr)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID format"})
+		writeError(c, http.StatusBadRequest, "Invalid product ID format")
 		return
 	}
 
@@ -308,35 +332,16 @@ func (h *Handler) DeleteProduct(c *gin.Context) {
 	hardDelete := c.Query("hard") == "true"
 
 	if hardDelete {
-		// Delete S3 images first (before database deletion)
-		s3Prefix := fmt.Sprintf("admin-panel/products/%d/images/", productID)
-		if err := h.deleteS3Folder(ctx, s3Prefix); err != nil {
-			log.Printf("Warning: Failed to delete S3 images for product %d: %v", productID, err)
-			// Continue with database deletion even if S3 cleanup fails
-		}
-
-		// Perform hard delete (permanent removal)
-		if err := h.db.HardDeleteProduct(ctx, productID); err != nil {
-			log.Printf("Failed to hard delete product %d: %v", productID, err)
-			if err.Error() == fmt.Sprintf("product with ID %d not found", productID) {
-				c.JSON(http.StatusNotFound, gin.H{"error": "Product not found"})
-			} else {
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete product"})
-			}
-			return
-		}
-		c.JSON(http.StatusOK, gin.H{
-			"message":    "Product and images permanently deleted",
-			"product_id": productID,
-		})
+		// Two-phase: trashed now, purged with its images after the retention window
+		h.moveToTrash(c, models.TrashProduct, idStr)
 	} else {
 		// Perform soft delete (set is_active = false)
 		if err := h.db.DeleteProduct(ctx, productID); err != nil {
 			log.Printf("Failed to delete product %d: %v", productID, err)
 			if err.Error() == fmt.Sprintf("product with ID %d not found or already deleted", productID) {
-				c.JSON(http.StatusNotFound, gin.H{"error": "Product not found or already deleted"})
+				writeError(c, http.StatusNotFound, "Product not found or already deleted")
 			} else {
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete product"})
+				writeError(c, http.StatusInternalServerError, "Failed to delete product")
 			}
 			return
 		}
@@ -347,11 +352,41 @@ func (h *Handler) DeleteProduct(c *gin.Context) {
 	}
 }
 
+// RestoreProduct handles POST /products/:id/restore, reactivating a soft-deleted product.
+// Deleted products are listed by GET /products?state=deleted (admin).
+func (h *Handler) RestoreProduct(c *gin.Context) {
+	productID, ok := parseProductIDParam(c)
+	if !ok {
+		return
+	}
+	err := h.db.RestoreProduct(c.Request.Context(), productID)
+	switch {
+	case errors.Is(err, db.ErrProductNotFound):
+		writeError(c, http.StatusNotFound, "Product not found")
+		return
+	case errors.Is(err, db.ErrProductNotDeleted):
+		writeError(c, http.StatusConflict, "Product is not deleted")
+		return
+	case errors.Is(err, db.ErrTaxClassRequired):
+		writeErrorDetails(c, http.StatusBadRequest, "INVALID_TAX_CLASS", "Set a tax_class before restoring this product", nil)
+		return
+	case err != nil:
+		log.Printf("Failed to restore product %d: %v", productID, err)
+		writeError(c, http.StatusInternalServerError, "Failed to restore product")
+		return
+	}
+	log.Printf("Product %d restored by %s", productID, currentUserID(c))
+	c.JSON(http.StatusOK, gin.H{
+		"message":    "Product restored successfully",
+		"product_id": productID,
+	})
+}
+
 // =================================================================================
 // EXISTING HANDLERS (Unchanged)
 // =================================================================================
 
-// GetProducts handles GET /products
+// GetProducts handles GET /products (?ids=1,2,3 fetches several products, with images, in one call)
 func (h *Handler) GetProducts(c *gin.Context) {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
@@ -361,16 +396,56 @@ func (h *Handler) GetProducts(c *gin.Context) {
 	miniAppType := c.Query("mini_app_type")
 	featured := c.Query("featured")
 	storeID := c.Query("store_id")
+	sortBy := c.Query("sort")
+	if sortBy != "" && sortBy != "popularity" {
+		writeError(c, http.StatusBadRequest, "Invalid sort")
+		return
+	}
+	newWithinDays, ok := parseDays(c, "new_within_days", 0)
+	if !ok {
+		return
+	}
+	restockedWithinDays, ok := parseDays(c, "restocked_within_days", 0)
+	if !ok {
+		return
+	}
+	ids, ok := parseProductIDs(c)
+	if !ok {
+		return
+	}
+	// ?region= (id or code such as CH) keeps the products sellable there and adds their regional price
+	region, ok := h.parseRegion(c)
+	if !ok {
+		return
+	}
 
 	// Check if this is an admin request based on JWT role (for admin panel use)
 	isAdminRequest := IsAdmin(c)
 
+	// Optional sparse fieldset, validated against the fields of the response type
+	var fieldsSample interface{} = models.PublicProduct{}
+	if isAdminRequest {
+		fieldsSample = models.Product{}
+	}
+	fields, ok := parseFields(c, fieldsSample)
+	if !ok {
+		return
+	}
+
+	// Admins may narrow the list by state: active, deleted (soft-deleted, is_active=false) or all (default)
+	state := c.DefaultQuery("state", "all")
+	if isAdminRequest && state != "all" && state != "active" && state != "deleted" {
+		writeError(c, http.StatusBadRequest, "Invalid state (use active, deleted or all)")
+		return
+	}
+
 	// Build the query - include cost_price only for admin requests
 	// For location-dependent mini-apps (UnmannedStore, ExhibitionSales), we need to JOIN with stores table
 	// to get the actual store type from the associated store
 	var query string
 	if isAdminRequest {
-		// Admin requests show ALL products (active and inactive) for complete management
+		// Admin requests show ALL products (active and inactive) for complete management; trashed ones
+		// are listed by GET /admin/trash
 		query = `
             SELECT
                 p.product_id, p.product_uuid,
@@ -385,10 +460,12 @@ func (h *Handler) GetProducts(c *gin.Context) {
                 END as store_type,
                 COALESCE(p.mini_app_type::text, '') as mini_app_type,
                 p.store_id,
+                p.barcode,
                 p.shelf_code,
                 COALESCE(p.main_price, 0) as main_price,
                 p.strikethrough_price,
                 p.cost_price,
+                p.tax_class,
                 COALESCE(p.weight, 1.00) as weight,
                 COALESCE(p.stock_left, 0) as stock_left,
                 COALESCE(p.minimum_order_quantity, 1) as minimum_order_quantity,
@@ -399,7 +476,7 @@ func (h *Handler) GetProducts(c *gin.Context) {
                 COALESCE(p.updated_at, NOW()) as updated_at
             FROM admin_products p
             LEFT JOIN admin_stores s ON p.store_id = s.store_id AND p.mini_app_type IN ('UnmannedStore', 'ExhibitionSales')
-            WHERE 1=1
+            WHERE p.trashed_at IS NULL
         `
 	} else {
 		// Public requests only show active products
@@ -417,6 +494,7 @@ func (h *Handler) GetProducts(c *gin.Context) {
                 END as store_type,
                 COALESCE(p.mini_app_type::text, '') as mini_app_type,
                 p.store_id,
+                p.barcode,
                 COALESCE(p.main_price, 0) as main_price,
                 p.strikethrough_price,
                 COALESCE(p.weight, 1.00) as weight,
@@ -436,6 +514,32 @@ func (h *Handler) GetProducts(c *gin.Context) {
 	args := []interface{}{}
 	argIndex := 1
 
+	if isAdminRequest && state == "active" {
+		query += " AND p.is_active = true"
+	} else if isAdminRequest && state == "deleted" {
+		query += " AND COALESCE(p.is_active, false) = false"
+	}
+
+	// Regional admins only see the products of their stores
+	if isAdminRequest {
+		scope, ok := h.resolveAdminScope(c)
+		if !ok {
+			return
+		}
+		if scope != nil {
+			query += fmt.Sprintf(" AND p.store_id = ANY($%d::int[])", argIndex)
+			args = append(args, scope.storeIDs())
+			argIndex++
+		}
+	}
+
+	// Batch lookup: carts and orders fetch all their line items in one call
+	if ids != nil {
+		query += fmt.Sprintf(" AND p.product_id = ANY($%d::int[])", argIndex)
+		args = append(args, ids)
+		argIndex++
+	}
+
 	// Add mini-app type filter first (authoritative)
 	if miniAppType != "" {
 		query += fmt.Sprintf(" AND p.mini_app_type = $%d", argIndex)
@@ -467,7 +571,7 @@ func (h *Handler) GetProducts(c *gin.Context) {
 		sid, err := strconv.Atoi(storeID)
 		if err != nil {
 			log.Printf("Invalid store_id param '%s': %v", storeID, err)
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid store_id"})
+			writeError(c, http.StatusBadRequest, "Invalid store_id")
 			return
 		}
 		query += fmt.Sprintf(" AND p.store_id = $%d", argIndex)
@@ -475,6 +579,12 @@ func (h *Handler) GetProducts(c *gin.Context) {
 		argIndex++
 	}
 
+	if region != nil {
+		query += " AND " + fmt.Sprintf(db.RegionSellableSQL, fmt.Sprintf("$%d", argIndex))
+		args = append(args, region.ID)
+		argIndex++
+	}
+
 	// Add featured filter
 	if featured == "true" {
 		query += fmt.Sprintf(" AND p.is_featured = $%d", argIndex)
@@ -482,13 +592,29 @@ func (h *Handler) GetProducts(c *gin.Context) {
 		argIndex++
 	}
 
-	query += " ORDER BY p.product_id"
+	// Computed collections: created / restocked within N days
+	if newWithinDays > 0 {
+		query += " AND " + fmt.Sprintf(db.NewArrivalsSQL, fmt.Sprintf("$%d", argIndex))
+		args = append(args, newWithinDays)
+		argIndex++
+	}
+	if restockedWithinDays > 0 {
+		query += " AND " + fmt.Sprintf(db.BackInStockSQL, fmt.Sprintf("$%d", argIndex))
+		args = append(args, restockedWithinDays)
+		argIndex++
+	}
+
+	if sortBy == "popularity" {
+		query += " ORDER BY " + db.PopularityScoreSQL + " DESC, p.product_id"
+	} else {
+		query += " ORDER BY p.product_id"
+	}
 
 	// Execute query
 	rows, err := h.db.Pool.Query(ctx, query, args...)
 	if err != nil {
 		log.Printf("Error querying products: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch products"})
+		writeError(c, http.StatusInternalServerError, "Failed to fetch products")
 		return
 	}
 	defer rows.Close()
@@ -511,10 +637,12 @@ func (h *Handler) GetProducts(c *gin.Context) {
 				&storeType,
 				&product.MiniAppType,
 				&product.StoreID,
+				&product.Barcode,
 				&product.ShelfCode,
 				&product.MainPrice,
 				&product.StrikethroughPrice,
 				&product.CostPrice,
+				&product.TaxClass,
 				&product.Weight,
 
 				&product.StockLeft,
@@ -536,6 +664,7 @@ func (h *Handler) GetProducts(c *gin.Context) {
 				&storeType,
 				&product.MiniAppType,
 				&product.StoreID,
+				&product.Barcode,
 				&product.MainPrice,
 				&product.StrikethroughPrice,
 				&product.Weight,
@@ -550,42 +679,12 @@ func (h *Handler) GetProducts(c *gin.Context) {
 		}
 		if err != nil {
 			log.Printf("Error scanning product: %v", err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to scan product"})
+			writeError(c, http.StatusInternalServerError, "Failed to scan product")
 			return
 		}
 		// Normalize nullable store_type from DB into string type
 		product.StoreType = models.StoreType(storeType.String)
 
-		// Get product images
-		images, err := h.getProductImages(ctx, product.ID)
-		if err != nil {
-			log.Printf("Error getting product images for product %d: %v", product.ID, err)
-			// Continue without images rather than failing
-			product.ImageUrls = []string{}
-		} else {
-			product.ImageUrls = images
-		}
-
-		// Get product categories
-		categories, err := h.getProductCategories(ctx, product.ID)
-		if err != nil {
-			log.Printf("Error getting product categories for product %d: %v", product.ID, err)
-			// Continue without categories rather than failing
-			product.CategoryIds = []string{}
-		} else {
-			product.CategoryIds = categories
-		}
-
-		// Get product subcategories
-		subcategories, err := h.getProductSubcategories(ctx, product.ID)
-		if err != nil {
-			log.Printf("Error getting product subcategories for product %d: %v", product.ID, err)
-			// Continue without subcategories rather than failing
-			product.SubcategoryIds = []string{}
-		} else {
-			product.SubcategoryIds = subcategories
-		}
-
 		// Add product to the list regardless of admin/public request
 		// The conversion to public format will happen later
 		products = append(products, product)
@@ -593,9 +692,42 @@ func (h *Handler) GetProducts(c *gin.Context) {
 
 	if err := rows.Err(); err != nil {
 		log.Printf("Error iterating products: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to process products"})
+		writeError(c, http.StatusInternalServerError, "Failed to process products")
 		return
 	}
+	rows.Close()
+
+	// Images and category mappings for the whole page in one query
+	productIDs := make([]int, len(products))
+	for i := range products {
+		productIDs[i] = products[i].ID
+	}
+	relations, err := h.db.GetProductRelations(ctx, productIDs)
+	if err != nil {
+		// Continue without images/categories rather than failing
+		log.Printf("Error getting product images/categories: %v", err)
+	}
+	for i := range products {
+		r := relations[products[i].ID]
+		products[i].ImageUrls = nonNilStrings(r.ImageURLs)
+		products[i].ThumbnailUrls = nonNilStrings(r.ThumbnailURLs)
+		products[i].CategoryIds = nonNilStrings(r.CategoryIDs)
+		products[i].SubcategoryIds = nonNilStrings(r.SubcategoryIDs)
+	}
+	variants, err := h.db.GetProductVariants(ctx, productIDs)
+	if err != nil {
+		log.Printf("Error getting product variants: %v", err)
+	}
+	for i := range products {
+		products[i].Variants = nonNilVariants(variants[products[i].ID])
+	}
+	if region != nil {
+		if err := h.applyRegionPricing(ctx, region, products); err != nil {
+			log.Printf("Error getting regional prices for region %d: %v", region.ID, err)
+			writeError(c, http.StatusInternalServerError, "Failed to fetch regional prices")
+			return
+		}
+	}
 
 	// Results ready
 
@@ -604,15 +736,42 @@ func (h *Handler) GetProducts(c *gin.Context) {
 		if products == nil {
 			products = []models.Product{}
 		}
-		c.JSON(http.StatusOK, products)
+		writeFields(c, products, fields)
 	} else {
 		// Convert all products to public format
 		publicProducts := make([]models.PublicProduct, len(products))
 		for i, product := range products {
 			publicProducts[i] = product.ToPublicProduct()
 		}
-		c.JSON(http.StatusOK, publicProducts)
+		writeFields(c, publicProducts, fields)
+	}
+}
+
+// maxBatchProductIDs caps ?ids= so a single request stays a bounded query
+const maxBatchProductIDs = 100
+
+// parseProductIDs parses the optional comma-separated ?ids= list; nil means no id filter.
+// Unknown or inactive ids are simply missing from the result.
+func parseProductIDs(c *gin.Context) ([]int, bool) {
+	v := strings.TrimSpace(c.Query("ids"))
+	if v == "" {
+		return nil, true
+	}
+	parts := strings.Split(v, ",")
+	if len(parts) > maxBatchProductIDs {
+		writeError(c, http.StatusBadRequest, fmt.Sprintf("At most %d ids per request", maxBatchProductIDs))
+		return nil, false
+	}
+	ids := make([]int, 0, len(parts))
+	for _, part := range parts {
+		id, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil || id <= 0 {
+			writeError(c, http.StatusBadRequest, fmt.Sprintf("Invalid product id in ids: %q", part))
+			return nil, false
+		}
+		ids = append(ids, id)
 	}
+	return ids, true
 }
 
 // GetProduct handles GET /products/:id (accepts both integer ID and UUID)
@@ -647,10 +806,12 @@ func (h *Handler) GetProduct(c *gin.Context) {
 	                END as store_type,
 	                COALESCE(p.mini_app_type::text, '') as mini_app_type,
                 p.store_id,
+                p.barcode,
                 p.shelf_code,
                 COALESCE(p.main_price, 0) as main_price,
                 p.strikethrough_price,
 	                p.cost_price,
+	                p.tax_class,
                 COALESCE(p.weight, 1.00) as weight,
                 COALESCE(p.stock_left, 0) as stock_left,
                 COALESCE(p.minimum_order_quantity, 1) as minimum_order_quantity,
@@ -678,6 +839,7 @@ func (h *Handler) GetProduct(c *gin.Context) {
 	                END as store_type,
 	                COALESCE(p.mini_app_type::text, '') as mini_app_type,
                 p.store_id,
+                p.barcode,
                 COALESCE(p.main_price, 0) as main_price,
                 p.strikethrough_price,
 	                COALESCE(p.stock_left, 0) as stock_left,
@@ -710,11 +872,13 @@ func (h *Handler) GetProduct(c *gin.Context) {
 	                END as store_type,
 	                COALESCE(p.mini_app_type::text, '') as mini_app_type,
                 p.store_id,
+                p.barcode,
                 p.shelf_code,
 
                 COALESCE(p.main_price, 0) as main_price,
                 p.strikethrough_price,
 	                p.cost_price,
+	                p.tax_class,
                 COALESCE(p.weight, 1.00) as weight,
 
                 COALESCE(p.stock_left, 0) as stock_left,
@@ -745,6 +909,7 @@ func (h *Handler) GetProduct(c *gin.Context) {
 	                END as store_type,
 	                COALESCE(p.mini_app_type::text, '') as mini_app_type,
                 p.store_id,
+                p.barcode,
                 COALESCE(p.main_price, 0) as main_price,
                 p.strikethrough_price,
                 COALESCE(p.weight, 1.00) as weight,
@@ -778,10 +943,12 @@ func (h *Handler) GetProduct(c *gin.Context) {
 			&storeType,
 			&product.MiniAppType,
 			&product.StoreID,
+			&product.Barcode,
 			&product.ShelfCode,
 			&product.MainPrice,
 			&product.StrikethroughPrice,
 			&product.CostPrice,
+			&product.TaxClass,
 			&product.Weight,
 			&product.StockLeft,
 			&product.MinimumOrderQuantity,
@@ -802,6 +969,7 @@ func (h *Handler) GetProduct(c *gin.Context) {
 			&storeType,
 			&product.MiniAppType,
 			&product.StoreID,
+			&product.Barcode,
 			&product.MainPrice,
 			&product.StrikethroughPrice,
 			&product.Weight,
@@ -817,19 +985,21 @@ func (h *Handler) GetProduct(c *gin.Context) {
 
 	if err != nil {
 		log.Printf("Error querying product %v: %v", queryParam, err)
-		c.JSON(http.StatusNotFound, gin.H{"error": "Product not found"})
+		writeError(c, http.StatusNotFound, "Product not found")
 		return
 	}
 	// Normalize nullable store_type from DB into string type
 	product.StoreType = models.StoreType(storeType.String)
 
 	// Get product images
-	images, err := h.getProductImages(ctx, product.ID)
+	images, thumbnails, err := h.getProductImages(ctx, product.ID)
 	if err != nil {
 		log.Printf("Error getting product images for product %d: %v", product.ID, err)
 		product.ImageUrls = []string{}
+		product.ThumbnailUrls = []string{}
 	} else {
 		product.ImageUrls = images
+		product.ThumbnailUrls = thumbnails
 	}
 
 	// Get product categories
@@ -850,6 +1020,28 @@ func (h *Handler) GetProduct(c *gin.Context) {
 		product.SubcategoryIds = subcategories
 	}
 
+	// Get product variants
+	variants, err := h.db.GetProductVariants(ctx, []int{product.ID})
+	if err != nil {
+		log.Printf("Error getting product variants for product %d: %v", product.ID, err)
+	}
+	product.Variants = nonNilVariants(variants[product.ID])
+
+	// Get curated related products (accessories, replacements, upsells)
+	related, err := h.db.GetRelatedProducts(ctx, product.ID)
+	if err != nil {
+		log.Printf("Error getting related products for product %d: %v", product.ID, err)
+		related = []models.RelatedProduct{}
+	}
+	product.RelatedProducts = related
+
+	// Food label data (nutrition, allergens, origin); absent for non-food products
+	label, err := h.db.GetProductFoodLabel(ctx, product.ID)
+	if err != nil && !errors.Is(err, db.ErrFoodLabelNotFound) {
+		log.Printf("Error getting food label for product %d: %v", product.ID, err)
+	}
+	product.FoodLabel = label
+
 	if isAdminRequest {
 		c.JSON(http.StatusOK, product)
 	} else {
@@ -867,6 +1059,10 @@ func (h *Handler) GetCategories(c *gin.Context) {
 	storeID := c.Query("store_id")
 	includeSubcategories := c.Query("include_subcategories") == "true"
 	includeStoreInfo := c.Query("include_store_info") == "true"
+	fields, ok := parseFields(c, models.Category{})
+	if !ok {
+		return
+	}
 
 	// Base query with optional store information
 	var query string
@@ -874,7 +1070,7 @@ func (h *Handler) GetCategories(c *gin.Context) {
 		query = `
             SELECT
                 c.category_id, c.name, c.store_type_association, c.mini_app_association,
-                c.store_id, c.display_order, c.is_active, c.image_url, c.created_at, c.updated_at,
+                c.store_id, c.display_order, c.is_active, c.image_url, c.thumbnail_url, c.medium_url, c.webp_url, c.created_at, c.updated_at,
                 s.name as store_name, s.city as store_city, s.latitude as store_latitude,
                 s.longitude as store_longitude, s.type as store_type
             FROM admin_product_categories c
@@ -884,7 +1080,7 @@ func (h *Handler) GetCategories(c *gin.Context) {
 		query = `
             SELECT
                 category_id, name, store_type_association, mini_app_association,
-                store_id, display_order, is_active, image_url, created_at, updated_at
+                store_id, display_order, is_active, image_url, thumbnail_url, medium_url, webp_url, created_at, updated_at
             FROM admin_product_categories
         `
 	}
@@ -937,7 +1133,7 @@ func (h *Handler) GetCategories(c *gin.Context) {
 	rows, err := h.db.Pool.Query(ctx, query, args...)
 	if err != nil {
 		log.Printf("Error querying categories: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch categories"})
+		writeError(c, http.StatusInternalServerError, "Failed to fetch categories")
 		return
 	}
 	defer rows.Close()
@@ -957,6 +1153,9 @@ func (h *Handler) GetCategories(c *gin.Context) {
 				&category.DisplayOrder,
 				&category.IsActive,
 				&category.ImageURL,
+				&category.ThumbnailURL,
+				&category.MediumURL,
+				&category.WebPURL,
 				&category.CreatedAt,
 				&category.UpdatedAt,
 				&category.StoreName,
@@ -967,7 +1166,7 @@ func (h *Handler) GetCategories(c *gin.Context) {
 			)
 			if err != nil {
 				log.Printf("Error scanning category with store info: %v", err)
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to scan category"})
+				writeError(c, http.StatusInternalServerError, "Failed to scan category")
 				return
 			}
 		} else {
@@ -980,12 +1179,15 @@ func (h *Handler) GetCategories(c *gin.Context) {
 				&category.DisplayOrder,
 				&category.IsActive,
 				&category.ImageURL,
+				&category.ThumbnailURL,
+				&category.MediumURL,
+				&category.WebPURL,
 				&category.CreatedAt,
 				&category.UpdatedAt,
 			)
 			if err != nil {
 				log.Printf("Error scanning category: %v", err)
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to scan category"})
+				writeError(c, http.StatusInternalServerError, "Failed to scan category")
 				return
 			}
 		}
@@ -1009,13 +1211,13 @@ func (h *Handler) GetCategories(c *gin.Context) {
 		categories = []models.Category{}
 	}
 
-	c.JSON(http.StatusOK, categories)
+	writeFields(c, categories, fields)
 }
 
 // Helper function to get subcategories for a category
 func (h *Handler) getSubcategoriesForCategory(ctx context.Context, categoryID int) ([]models.Subcategory, error) {
 	query := `
-        SELECT subcategory_id, parent_category_id, name, image_url, display_order, is_active, created_at, updated_at
+        SELECT subcategory_id, parent_category_id, name, image_url, thumbnail_url, medium_url, webp_url, display_order, is_active, created_at, updated_at
         FROM admin_subcategories
         WHERE parent_category_id = $1 AND is_active = true
         ORDER BY display_order, subcategory_id
@@ -1036,6 +1238,9 @@ func (h *Handler) getSubcategoriesForCategory(ctx context.Context, categoryID in
 			&subcategory.ParentCategoryID,
 			&subcategory.Name,
 			&subcategory.ImageURL,
+			&subcategory.ThumbnailURL,
+			&subcategory.MediumURL,
+			&subcategory.WebPURL,
 			&subcategory.DisplayOrder,
 			&subcategory.IsActive,
 			&subcategory.CreatedAt,
@@ -1056,42 +1261,100 @@ func (h *Handler) getSubcategoriesForCategory(ctx context.Context, categoryID in
 	return subcategories, nil
 }
 
-// GetStores handles GET /stores
+// GetStores handles GET /stores[?region_id=]
+// Optional: ?user_lat=&user_lng= adds distance_km; &order_by_distance=true sorts nearest first and
+// &within_km= keeps stores inside the radius (both served by the GiST index on admin_stores.geog).
+// ?page=&limit= switches the response to a paginated envelope {stores,total,page,limit,total_pages}.
 func (h *Handler) GetStores(c *gin.Context) {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
 	storeType := c.Query("type")
 	miniAppType := c.Query("mini_app_type")
-	userLat := c.Query("user_lat")
-	userLng := c.Query("user_lng")
 	orderByDistance := c.Query("order_by_distance") == "true"
+	var regionID int
+	if v := c.Query("region_id"); v != "" {
+		id, err := strconv.Atoi(v)
+		if err != nil || id <= 0 {
+			writeError(c, http.StatusBadRequest, "Invalid region_id")
+			return
+		}
+		regionID = id
+	}
+	fields, ok := parseFields(c, models.Store{})
+	if !ok {
+		return
+	}
 
-	// Base query with distance calculation if user location provided
-	var query string
-	if userLat != "" && userLng != "" && orderByDistance {
-		query = `
-            SELECT
-                store_id, name, city, address, latitude, longitude, type, region_id, image_url, is_active, created_at, updated_at,
-                (6371 * acos(cos(radians($1)) * cos(radians(latitude)) * cos(radians(longitude) - radians($2)) + sin(radians($1)) * sin(radians(latitude)))) AS distance_km
-            FROM admin_stores
-            WHERE is_active = true
-        `
-	} else {
-		query = `
-            SELECT store_id, name, city, address, latitude, longitude, type, region_id, image_url, is_active, created_at, updated_at
-            FROM admin_stores
-            WHERE is_active = true
-        `
+	var userLat, userLng float64
+	hasLocation := c.Query("user_lat") != "" && c.Query("user_lng") != ""
+	if hasLocation {
+		var errLat, errLng error
+		userLat, errLat = strconv.ParseFloat(c.Query("user_lat"), 64)
+		userLng, errLng = strconv.ParseFloat(c.Query("user_lng"), 64)
+		if errLat != nil || errLng != nil || userLat < -90 || userLat > 90 || userLng < -180 || userLng > 180 {
+			writeError(c, http.StatusBadRequest, "Invalid user_lat/user_lng")
+			return
+		}
+	}
+	var withinKm float64
+	if v := c.Query("within_km"); v != "" {
+		n, err := strconv.ParseFloat(v, 64)
+		if err != nil || n <= 0 {
+			writeError(c, http.StatusBadRequest, "Invalid within_km")
+			return
+		}
+		if !hasLocation {
+			writeError(c, http.StatusBadRequest, "within_km requires user_lat and user_lng")
+			return
+		}
+		withinKm = n
+	}
+
+	paginated := c.Query("page") != "" || c.Query("limit") != ""
+	page, limit := 1, 50
+	if paginated {
+		var errPage, errLimit error
+		page, errPage = strconv.Atoi(c.DefaultQuery("page", "1"))
+		limit, errLimit = strconv.Atoi(c.DefaultQuery("limit", "50"))
+		if errPage != nil || page < 1 || errLimit != nil || limit < 1 || limit > 200 {
+			writeError(c, http.StatusBadRequest, "Invalid page or limit (limit 1-200)")
+			return
+		}
 	}
 
 	args := []interface{}{}
 	argIndex := 1
 
-	// Add user coordinates to args if distance calculation is requested
-	if userLat != "" && userLng != "" && orderByDistance {
+	// Base query; the user's position is a geography point compared against the indexed geog column
+	query := `
+            SELECT store_id, name, city, address, latitude, longitude, type, region_id, image_url, thumbnail_url, medium_url, webp_url, is_active, created_at, updated_at, timezone, opening_hours, holidays,
+                (SELECT r.currency FROM admin_regions r WHERE r.region_id = admin_stores.region_id) AS currency,
+                %s AS distance_km, COUNT(*) OVER() AS total
+            FROM admin_stores
+            WHERE is_active = true
+        `
+	point := ""
+	if hasLocation {
+		point = "ST_SetSRID(ST_MakePoint($2, $1), 4326)::geography"
+		query = fmt.Sprintf(query, "ST_Distance(geog, "+point+") / 1000.0")
 		args = append(args, userLat, userLng)
 		argIndex = 3
+	} else {
+		query = fmt.Sprintf(query, "NULL::float8")
+	}
+
+	// Radius filter (index-assisted)
+	if withinKm > 0 {
+		query += fmt.Sprintf(" AND ST_DWithin(geog, %s, $%d)", point, argIndex)
+		args = append(args, withinKm*1000)
+		argIndex++
+	}
+
+	if regionID > 0 {
+		query += fmt.Sprintf(" AND region_id = $%d", argIndex)
+		args = append(args, regionID)
+		argIndex++
 	}
 
 	// Filter by store type
@@ -1117,78 +1380,106 @@ func (h *Handler) GetStores(c *gin.Context) {
 		}
 	}
 
-	// Order by distance if requested, otherwise by store_id
-	if userLat != "" && userLng != "" && orderByDistance {
-		query += " ORDER BY distance_km"
+	// Order by distance if requested (KNN operator uses the GiST index), otherwise by store_id
+	if hasLocation && orderByDistance {
+		query += " ORDER BY geog <-> " + point + ", store_id"
 	} else {
 		query += " ORDER BY store_id"
 	}
+	if paginated {
+		query += fmt.Sprintf(" LIMIT $%d OFFSET $%d", argIndex, argIndex+1)
+		args = append(args, limit, (page-1)*limit)
+	}
+
 	rows, err := h.db.Pool.Query(ctx, query, args...)
 	if err != nil {
 		log.Printf("Error querying stores: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch stores"})
+		writeError(c, http.StatusInternalServerError, "Failed to fetch stores")
 		return
 	}
 	defer rows.Close()
 
 	var stores []models.Store
+	total := 0
+	now := time.Now()
 
 	for rows.Next() {
 		var store models.Store
-		var distanceKm *float64
+		var openingHours, holidays []byte
 
-		if userLat != "" && userLng != "" && orderByDistance {
-			err := rows.Scan(
-				&store.ID,
-				&store.Name,
-				&store.City,
-				&store.Address,
-				&store.Latitude,
-				&store.Longitude,
-				&store.Type,
-				&store.RegionID,
-				&store.ImageURL,
-				&store.IsActive,
-				&store.CreatedAt,
-				&store.UpdatedAt,
-				&distanceKm,
-			)
-			if err != nil {
-				log.Printf("Error scanning store with distance: %v", err)
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to scan store"})
-				return
-			}
-		} else {
-			err := rows.Scan(
-				&store.ID,
-				&store.Name,
-				&store.City,
-				&store.Address,
-				&store.Latitude,
-				&store.Longitude,
-				&store.Type,
-				&store.RegionID,
-				&store.ImageURL,
-				&store.IsActive,
-				&store.CreatedAt,
-				&store.UpdatedAt,
-			)
-			if err != nil {
-				log.Printf("Error scanning store: %v", err)
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to scan store"})
-				return
-			}
+		err := rows.Scan(
+			&store.ID,
+			&store.Name,
+			&store.City,
+			&store.Address,
+			&store.Latitude,
+			&store.Longitude,
+			&store.Type,
+			&store.RegionID,
+			&store.ImageURL,
+			&store.ThumbnailURL,
+			&store.MediumURL,
+			&store.WebPURL,
+			&store.IsActive,
+			&store.CreatedAt,
+			&store.UpdatedAt,
+			&store.Timezone,
+			&openingHours,
+			&holidays,
+			&store.Currency,
+			&store.DistanceKm,
+			&total,
+		)
+		if err != nil {
+			log.Printf("Error scanning store: %v", err)
+			writeError(c, http.StatusInternalServerError, "Failed to scan store")
+			return
+		}
+
+		if err := store.UnmarshalColumns(openingHours, holidays); err != nil {
+			log.Printf("Error decoding opening hours of store %d: %v", store.ID, err)
 		}
+		store.IsOpenNow = store.IsOpenAt(now)
 
 		stores = append(stores, store)
 	}
+	if err := rows.Err(); err != nil {
+		log.Printf("Error iterating stores: %v", err)
+		writeError(c, http.StatusInternalServerError, "Failed to fetch stores")
+		return
+	}
 
 	// Ensure we return an empty array instead of null when no stores exist
 	if stores == nil {
 		stores = []models.Store{}
 	}
 
-	c.JSON(http.StatusOK, stores)
+	if !paginated {
+		writeFields(c, stores, fields)
+		return
+	}
+
+	// A page past the end has no rows to carry the window count
+	if len(stores) == 0 && page > 1 {
+		countQuery := "SELECT COUNT(*) FROM (" + query[:strings.LastIndex(query, " ORDER BY")] + ") s"
+		if err := h.db.Pool.QueryRow(ctx, countQuery, args[:len(args)-2]...).Scan(&total); err != nil {
+			log.Printf("Error counting stores: %v", err)
+			writeError(c, http.StatusInternalServerError, "Failed to fetch stores")
+			return
+		}
+	}
+	items, err := selectFields(stores, fields)
+	if err != nil {
+		writeError(c, http.StatusInternalServerError, "Failed to encode response")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"stores":      items,
+		"total":       total,
+		"page":        page,
+		"limit":       limit,
+		"total_pages": (total + limit - 1) / limit,
+	})
 }
 
 // Health handles GET /health
@@ -1213,9 +1504,24 @@ func (h *Handler) Health(c *gin.Context) {
 
 // Helper functions
 
-func (h *Handler) getProductImages(ctx context.Context, productID int) ([]string, error) {
+// nonNilStrings returns s, or an empty slice so JSON renders [] instead of null
+func nonNilStrings(s []string) []string {
+	if s == nil {
+		return []string{}
+	}
+	return s
+}
+
+func nonNilVariants(v []models.ProductVariant) []models.ProductVariant {
+	if v == nil {
+		return []models.ProductVariant{}
+	}
+	return v
+}
+
+func (h *Handler) getProductImages(ctx context.Context, productID int) ([]string, []string, error) {
 	query := `
-        SELECT image_url
+        SELECT image_url, COALESCE(thumbnail_url, image_url)
         FROM admin_product_images
         WHERE product_id = $1
         ORDER BY display_order
@@ -1223,20 +1529,21 @@ func (h *Handler) getProductImages(ctx context.Context, productID int) ([]string
 
 	rows, err := h.db.Pool.Query(ctx, query, productID)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	defer rows.Close()
 
-	var images []string
+	images, thumbnails := []string{}, []string{}
 	for rows.Next() {
-		var imageURL string
-		if err := rows.Scan(&imageURL); err != nil {
-			return nil, err
+		var imageURL, thumbnailURL string
+		if err := rows.Scan(&imageURL, &thumbnailURL); err != nil {
+			return nil, nil, err
 		}
 		images = append(images, imageURL)
+		thumbnails = append(thumbnails, thumbnailURL)
 	}
 
-	return images, rows.Err()
+	return images, thumbnails, rows.Err()
 }
 
 func (h *Handler) getProductCategories(ctx context.Context, productID int) ([]string, error) {
@@ -1324,129 +1631,10 @@ func (h *Handler) getProductStock(ctx context.Context, productID int, storeID st
 	return &quantity, nil
 }
 
-// uploadToS3 uploads file to AWS S3 bucket
-func (h *Handler) uploadToS3(ctx context.Context, productID int, fileHeader *multipart.FileHeader, file multipart.File) (string, error) {
-	// Reset file pointer
-	file.Seek(0, 0)
-
-	// Set up AWS S3 Client using default credential chain (App Runner instance role in AWS)
-	region := os.Getenv("AWS_REGION")
-	if region == "" {
-		region = os.Getenv("AWS_DEFAULT_REGION")
-	}
-	if region == "" {
-		region = "eu-central-1" // default to Frankfurt
-	}
-	// Ensure we use container/instance credentials, not SES SMTP env vars that may be present
-	_ = os.Unsetenv("AWS_ACCESS_KEY_ID")
-	_ = os.Unsetenv("AWS_SECRET_ACCESS_KEY")
-	_ = os.Unsetenv("AWS_SESSION_TOKEN")
-
-	cfg, err := config.LoadDefaultConfig(ctx, config.WithRegion(region))
-	if err != nil {
-		return "", fmt.Errorf("failed to load AWS default config: %w", err)
-	}
-	s3Client := s3.NewFromConfig(cfg)
-
-	// Upload to S3
-	bucketName := "expotoworld-media"
-	objectKey := fmt.Sprintf("admin-panel/products/%d/images/%d%s", productID, time.Now().UnixNano(), filepath.Ext(fileHeader.Filename))
-
-	_, err = s3Client.PutObject(ctx, &s3.PutObjectInput{
-		Bucket: &bucketName,
-		Key:    &objectKey,
-		Body:   file,
-	})
-	if err != nil {
-		return "", fmt.Errorf("failed to upload file to S3: %w", err)
-	}
-
-	// Construct the new CloudFront URL using the environment variable
-	cdnBase := os.Getenv("ASSETS_CDN_BASE_URL")
-	if cdnBase == "" {
-		cdnBase = "https://assets.expotoworld.com" // Fallback
-	}
-	imageURL := fmt.Sprintf("%s/%s", strings.TrimRight(cdnBase, "/"), objectKey)
-	return imageURL, nil
-}
-
-// uploadGenericToS3 uploads a file stream to the given S3 key and returns a public URL
-func (h *Handler) uploadGenericToS3(ctx context.Context, objectKey string, file multipart.File) (string, error) {
-	// Reset file pointer
-	file.Seek(0, 0)
-
-	region := os.Getenv("AWS_REGION")
-	if region == "" {
-		region = os.Getenv("AWS_DEFAULT_REGION")
-	}
-	if region == "" {
-		region = "eu-central-1"
-	}
-	// Ensure we use container/instance credentials, not SES SMTP env vars that may be present
-	_ = os.Unsetenv("AWS_ACCESS_KEY_ID")
-	_ = os.Unsetenv("AWS_SECRET_ACCESS_KEY")
-	_ = os.Unsetenv("AWS_SESSION_TOKEN")
-
-	cfg, err := config.LoadDefaultConfig(ctx, config.WithRegion(region))
-	if err != nil {
-		return "", fmt.Errorf("failed to load AWS default config: %w", err)
-	}
-	s3Client := s3.NewFromConfig(cfg)
-
-	bucketName := "expotoworld-media"
-	_, err = s3Client.PutObject(ctx, &s3.PutObjectInput{
-		Bucket: &bucketName,
-		Key:    &objectKey,
-		Body:   file,
-	})
-	if err != nil {
-		return "", fmt.Errorf("failed to upload file to S3: %w", err)
-	}
-
-	// Build CloudFront URL using env var (same behavior as uploadToS3)
-	cdnBase := os.Getenv("ASSETS_CDN_BASE_URL")
-	if cdnBase == "" {
-		cdnBase = "https://assets.expotoworld.com"
-	}
-	imageURL := fmt.Sprintf("%s/%s", strings.TrimRight(cdnBase, "/"), objectKey)
-	return imageURL, nil
-}
-
-// uploadToLocal uploads file to local storage for development
-func (h *Handler) uploadToLocal(productID int, fileHeader *multipart.FileHeader, file multipart.File) (string, error) {
-	// Reset file pointer
-	file.Seek(0, 0)
-
-	// Create uploads directory if it doesn't exist
-	uploadsDir := "./uploads/products"
-	if err := os.MkdirAll(uploadsDir, 0755); err != nil {
-		return "", fmt.Errorf("failed to create uploads directory: %w", err)
-	}
-
-	// Generate unique filename
-	ext := filepath.Ext(fileHeader.Filename)
-	filename := fmt.Sprintf("%d_%d%s", productID, time.Now().UnixNano(), ext)
-	filePath := filepath.Join(uploadsDir, filename)
-
-	// Create the file
-	dst, err := os.Create(filePath)
-	if err != nil {
-		return "", fmt.Errorf("failed to create file: %w", err)
-	}
-	defer dst.Close()
-
-	// Copy file content
-	if _, err := io.Copy(dst, file); err != nil {
-		return "", fmt.Errorf("failed to save file: %w", err)
-	}
-
-	// Return URL - use environment variable for base URL or default to localhost for development
-	baseURL := os.Getenv("SERVICE_BASE_URL")
-	if baseURL == "" {
-		baseURL = "http://localhost:8080"
-	}
-	imageURL := fmt.Sprintf("%s/uploads/products/%s", baseURL, filename)
-	return imageURL, nil
+// uploadProductImage stores a product image upload and returns its public URL
+func (h *Handler) uploadProductImage(ctx context.Context, productID int, img *imaging.Upload) (string, error) {
+	objectKey := fmt.Sprintf("%s%d%s", productImagePrefix(productID), time.Now().UnixNano(), img.Ext())
+	return h.storeImage(ctx, objectKey, img)
 }
 
 // GetSubcategories handles GET /categories/:id/subcategories
@@ -1457,7 +1645,7 @@ func (h *Handler) GetSubcategories(c *gin.Context) {
 	categoryID := c.Param("id")
 
 	query := `
-        SELECT subcategory_id, parent_category_id, name, image_url, display_order, is_active, created_at, updated_at
+        SELECT subcategory_id, parent_category_id, name, image_url, thumbnail_url, medium_url, webp_url, display_order, is_active, created_at, updated_at
         FROM admin_subcategories
         WHERE parent_category_id = $1 AND is_active = true
         ORDER BY display_order, subcategory_id
@@ -1466,7 +1654,7 @@ func (h *Handler) GetSubcategories(c *gin.Context) {
 	rows, err := h.db.Pool.Query(ctx, query, categoryID)
 	if err != nil {
 		log.Printf("Error querying subcategories: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch subcategories"})
+		writeError(c, http.StatusInternalServerError, "Failed to fetch subcategories")
 		return
 	}
 	defer rows.Close()
@@ -1480,6 +1668,9 @@ func (h *Handler) GetSubcategories(c *gin.Context) {
 			&subcategory.ParentCategoryID,
 			&subcategory.Name,
 			&subcategory.ImageURL,
+			&subcategory.ThumbnailURL,
+			&subcategory.MediumURL,
+			&subcategory.WebPURL,
 			&subcategory.DisplayOrder,
 			&subcategory.IsActive,
 			&subcategory.CreatedAt,
@@ -1487,7 +1678,7 @@ func (h *Handler) GetSubcategories(c *gin.Context) {
 		)
 		if err != nil {
 			log.Printf("Error scanning subcategory: %v", err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to scan subcategory"})
+			writeError(c, http.StatusInternalServerError, "Failed to scan subcategory")
 			return
 		}
 
@@ -1510,20 +1701,13 @@ func (h *Handler) CreateSubcategory(c *gin.Context) {
 	categoryID := c.Param("id")
 
 	var newSubcategory models.Subcategory
-	if err := c.ShouldBindJSON(&newSubcategory); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body: " + err.Error()})
+	if !bindJSON(c, &newSubcategory) {
 		return
 	}
 
 	// Set the parent category ID from URL parameter
 	newSubcategory.ParentCategoryID, _ = strconv.Atoi(categoryID)
 
-	// Validate display order
-	if newSubcategory.DisplayOrder < 1 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Display order must be at least 1"})
-		return
-	}
-
 	// Check for display order conflicts within the same category
 	conflictQuery := `
         SELECT COUNT(*) FROM admin_subcategories
@@ -1540,12 +1724,12 @@ func (h *Handler) CreateSubcategory(c *gin.Context) {
 
 	if err != nil {
 		log.Printf("Failed to check display order conflict: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to validate display order"})
+		writeError(c, http.StatusInternalServerError, "Failed to validate display order")
 		return
 	}
 
 	if conflictCount > 0 {
-		c.JSON(http.StatusConflict, gin.H{"error": "Display order already exists for this category"})
+		writeError(c, http.StatusConflict, "Display order already exists for this category")
 		return
 	}
 
@@ -1567,7 +1751,7 @@ func (h *Handler) CreateSubcategory(c *gin.Context) {
 
 	if err != nil {
 		log.Printf("Failed to create subcategory in DB: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create subcategory"})
+		writeError(c, http.StatusInternalServerError, "Failed to create subcategory")
 		return
 	}
 
@@ -1586,14 +1770,7 @@ func (h *Handler) UpdateSubcategory(c *gin.Context) {
 	subcategoryID := c.Param("id")
 
 	var updatedSubcategory models.Subcategory
-	if err := c.ShouldBindJSON(&updatedSubcategory); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body: " + err.Error()})
-		return
-	}
-
-	// Validate display order
-	if updatedSubcategory.DisplayOrder < 1 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Display order must be at least 1"})
+	if !bindJSON(c, &updatedSubcategory) {
 		return
 	}
 
@@ -1602,7 +1779,7 @@ func (h *Handler) UpdateSubcategory(c *gin.Context) {
 	err := h.db.Pool.QueryRow(ctx, "SELECT parent_category_id FROM admin_subcategories WHERE subcategory_id = $1", subcategoryID).Scan(&parentCategoryID)
 	if err != nil {
 		log.Printf("Failed to get parent category ID: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to validate subcategory"})
+		writeError(c, http.StatusInternalServerError, "Failed to validate subcategory")
 		return
 	}
 
@@ -1624,18 +1801,21 @@ func (h *Handler) UpdateSubcategory(c *gin.Context) {
 
 	if err != nil {
 		log.Printf("Failed to check display order conflict: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to validate display order"})
+		writeError(c, http.StatusInternalServerError, "Failed to validate display order")
 		return
 	}
 
 	if conflictCount > 0 {
-		c.JSON(http.StatusConflict, gin.H{"error": "Display order already exists for this category"})
+		writeError(c, http.StatusConflict, "Display order already exists for this category")
 		return
 	}
 
 	query := `
         UPDATE admin_subcategories
-        SET name = $2, image_url = $3, display_order = $4, is_active = $5, updated_at = CURRENT_TIMESTAMP
+        SET name = $2, image_url = $3, display_order = $4, is_active = $5, updated_at = CURRENT_TIMESTAMP,
+            thumbnail_url = CASE WHEN image_url IS DISTINCT FROM $3 THEN NULL ELSE thumbnail_url END,
+            medium_url = CASE WHEN image_url IS DISTINCT FROM $3 THEN NULL ELSE medium_url END,
+            webp_url = CASE WHEN image_url IS DISTINCT FROM $3 THEN NULL ELSE webp_url END
         WHERE subcategory_id = $1
         RETURNING updated_at
     `
@@ -1651,7 +1831,7 @@ func (h *Handler) UpdateSubcategory(c *gin.Context) {
 
 	if err != nil {
 		log.Printf("Failed to update subcategory in DB: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update subcategory"})
+		writeError(c, http.StatusInternalServerError, "Failed to update subcategory")
 		return
 	}
 
@@ -1668,7 +1848,7 @@ func (h *Handler) DeleteSubcategory(c *gin.Context) {
 
 	// Delete S3 images first (before database deletion)
 	s3Prefix := fmt.Sprintf("admin-panel/subcategories/%s/images/", subcategoryID)
-	if err := h.deleteS3Folder(ctx, s3Prefix); err != nil {
+	if err := h.deleteMediaFolder(ctx, s3Prefix); err != nil {
 		log.Printf("Warning: Failed to delete S3 images for subcategory %s: %v", subcategoryID, err)
 		// Continue with database deletion even if S3 cleanup fails
 	}
@@ -1678,12 +1858,12 @@ func (h *Handler) DeleteSubcategory(c *gin.Context) {
 	result, err := h.db.Pool.Exec(ctx, query, subcategoryID)
 	if err != nil {
 		log.Printf("Failed to delete subcategory from DB: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete subcategory"})
+		writeError(c, http.StatusInternalServerError, "Failed to delete subcategory")
 		return
 	}
 
 	if result.RowsAffected() == 0 {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Subcategory not found"})
+		writeError(c, http.StatusNotFound, "Subcategory not found")
 		return
 	}
 
@@ -1696,14 +1876,7 @@ func (h *Handler) CreateCategory(c *gin.Context) {
 	defer cancel()
 
 	var newCategory models.Category
-	if err := c.ShouldBindJSON(&newCategory); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body: " + err.Error()})
-		return
-	}
-
-	// Validate display order
-	if newCategory.DisplayOrder < 1 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Display order must be at least 1"})
+	if !bindJSON(c, &newCategory) {
 		return
 	}
 
@@ -1741,12 +1914,12 @@ func (h *Handler) CreateCategory(c *gin.Context) {
 
 	if err != nil {
 		log.Printf("Failed to check display order conflict: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to validate display order"})
+		writeError(c, http.StatusInternalServerError, "Failed to validate display order")
 		return
 	}
 
 	if conflictCount > 0 {
-		c.JSON(http.StatusConflict, gin.H{"error": "Display order already exists for this mini-app and store scope"})
+		writeError(c, http.StatusConflict, "Display order already exists for this mini-app and store scope")
 		return
 	}
 
@@ -1769,7 +1942,7 @@ func (h *Handler) CreateCategory(c *gin.Context) {
 
 	if err != nil {
 		log.Printf("Failed to create category in DB: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create category"})
+		writeError(c, http.StatusInternalServerError, "Failed to create category")
 		return
 	}
 
@@ -1781,28 +1954,30 @@ func (h *Handler) CreateCategory(c *gin.Context) {
 }
 
 // UpdateCategory handles PUT /categories/:id
+// With If-Unmodified-Since (the loaded updated_at), a concurrent edit gets 409 with the current category.
 func (h *Handler) UpdateCategory(c *gin.Context) {
 	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
 	defer cancel()
 
 	categoryID := c.Param("id")
-
-	var updatedCategory models.Category
-	if err := c.ShouldBindJSON(&updatedCategory); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body: " + err.Error()})
+	cid, err := strconv.Atoi(categoryID)
+	if err != nil {
+		writeError(c, http.StatusBadRequest, "Invalid category ID")
+		return
+	}
+	unmodifiedSince, ok := parseUnmodifiedSince(c)
+	if !ok {
 		return
 	}
 
-	// Validate display order
-	if updatedCategory.DisplayOrder < 1 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Display order must be at least 1"})
+	var updatedCategory models.Category
+	if !bindJSON(c, &updatedCategory) {
 		return
 	}
 
 	// Check for display order conflicts within the same scope (excluding current category)
 	var conflictQuery string
 	var conflictCount int
-	var err error
 
 	if updatedCategory.StoreID == nil {
 		conflictQuery = `
@@ -1837,12 +2012,12 @@ func (h *Handler) UpdateCategory(c *gin.Context) {
 
 	if err != nil {
 		log.Printf("Failed to check display order conflict: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to validate display order"})
+		writeError(c, http.StatusInternalServerError, "Failed to validate display order")
 		return
 	}
 
 	if conflictCount > 0 {
-		c.JSON(http.StatusConflict, gin.H{"error": "Display order already exists for this mini-app and store scope"})
+		writeError(c, http.StatusConflict, "Display order already exists for this mini-app and store scope")
 		return
 	}
 
@@ -1850,11 +2025,8 @@ func (h *Handler) UpdateCategory(c *gin.Context) {
         UPDATE admin_product_categories
         SET name = $2, store_type_association = $3, mini_app_association = $4, store_id = $5, display_order = $6, is_active = $7, updated_at = CURRENT_TIMESTAMP
         WHERE category_id = $1
-        RETURNING updated_at
     `
-
-	var updatedAt time.Time
-	err = h.db.Pool.QueryRow(ctx, query,
+	args := []interface{}{
 		categoryID,
 		updatedCategory.Name,
 		updatedCategory.StoreTypeAssociation,
@@ -1862,11 +2034,26 @@ func (h *Handler) UpdateCategory(c *gin.Context) {
 		updatedCategory.StoreID,
 		updatedCategory.DisplayOrder,
 		updatedCategory.IsActive,
-	).Scan(&updatedAt)
+	}
+	if unmodifiedSince != nil {
+		args = append(args, *unmodifiedSince)
+		query += fmt.Sprintf(" AND updated_at <= $%d", len(args))
+	}
+	query += " RETURNING updated_at"
 
+	var updatedAt time.Time
+	err = h.db.Pool.QueryRow(ctx, query, args...).Scan(&updatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		if unmodifiedSince != nil {
+			writeEditConflict(c, "Category", h.currentCategory(cid))
+		} else {
+			writeError(c, http.StatusNotFound, "Category not found")
+		}
+		return
+	}
 	if err != nil {
 		log.Printf("Failed to update category in DB: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update category"})
+		writeError(c, http.StatusInternalServerError, "Failed to update category")
 		return
 	}
 
@@ -1874,7 +2061,7 @@ func (h *Handler) UpdateCategory(c *gin.Context) {
 	c.JSON(http.StatusOK, updatedCategory)
 }
 
-// DeleteCategory handles DELETE /categories/:id
+// DeleteCategory handles DELETE /categories/:id (hard=true moves it to the trash, see moveToTrash)
 func (h *Handler) DeleteCategory(c *gin.Context) {
 	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
 	defer cancel()
@@ -1885,25 +2072,8 @@ func (h *Handler) DeleteCategory(c *gin.Context) {
 	hardDelete := c.Query("hard") == "true"
 
 	if hardDelete {
-		// Delete S3 images first (before database deletion)
-		s3Prefix := fmt.Sprintf("admin-panel/categories/%s/images/", categoryID)
-		if err := h.deleteS3Folder(ctx, s3Prefix); err != nil {
-			log.Printf("Warning: Failed to delete S3 images for category %s: %v", categoryID, err)
-			// Continue with database deletion even if S3 cleanup fails
-		}
-
-		// Perform hard delete (completely remove from database)
-		query := `DELETE FROM admin_product_categories WHERE category_id = $1`
-		_, err := h.db.Pool.Exec(ctx, query, categoryID)
-		if err != nil {
-			log.Printf("Failed to hard delete category %s: %v", categoryID, err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete category"})
-			return
-		}
-		c.JSON(http.StatusOK, gin.H{
-			"message":     "Category and images permanently deleted",
-			"category_id": categoryID,
-		})
+		// Two-phase: trashed now, purged with its images after the retention window
+		h.moveToTrash(c, models.TrashCategory, categoryID)
 	} else {
 		// Perform soft delete (set is_active = false)
 		query := `
@@ -1914,13 +2084,13 @@ func (h *Handler) DeleteCategory(c *gin.Context) {
 		result, err := h.db.Pool.Exec(ctx, query, categoryID)
 		if err != nil {
 			log.Printf("Failed to soft delete category %s: %v", categoryID, err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete category"})
+			writeError(c, http.StatusInternalServerError, "Failed to delete category")
 			return
 		}
 
 		rowsAffected := result.RowsAffected()
 		if rowsAffected == 0 {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Category not found"})
+			writeError(c, http.StatusNotFound, "Category not found")
 			return
 		}
 
@@ -1941,20 +2111,28 @@ func (h *Handler) CreateStore(c *gin.Context) {
 		PartnerOrgID *string `json:"partner_org_id"`
 	}
 	var payload createStorePayload
-	if err := c.ShouldBindJSON(&payload); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body: " + err.Error()})
+	if !bindJSON(c, &payload) {
+		return
+	}
+	if err := payload.StoreSchedule.Validate(); err != nil {
+		writeFieldErrors(c, models.FieldError{Field: "opening_hours", Code: "invalid_schedule", Message: err.Error()})
+		return
+	}
+	openingHours, holidays, err := payload.StoreSchedule.MarshalColumns()
+	if err != nil {
+		writeError(c, http.StatusBadRequest, "Invalid opening hours: "+err.Error())
 		return
 	}
 
 	query := `
-        INSERT INTO admin_stores (name, city, address, latitude, longitude, type, region_id, image_url, is_active)
-        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+        INSERT INTO admin_stores (name, city, address, latitude, longitude, type, region_id, image_url, is_active, timezone, opening_hours, holidays)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
         RETURNING store_id, created_at, updated_at
     `
 
 	var storeID int
 	var createdAt, updatedAt time.Time
-	err := h.db.Pool.QueryRow(ctx, query,
+	err = h.db.Pool.QueryRow(ctx, query,
 		payload.Name,
 		payload.City,
 		payload.Address,
@@ -1964,11 +2142,14 @@ func (h *Handler) CreateStore(c *gin.Context) {
 		payload.RegionID,
 		payload.ImageURL,
 		payload.IsActive,
+		payload.Timezone,
+		openingHours,
+		holidays,
 	).Scan(&storeID, &createdAt, &updatedAt)
 
 	if err != nil {
 		log.Printf("Failed to create store in DB: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create store"})
+		writeError(c, http.StatusInternalServerError, "Failed to create store")
 		return
 	}
 
@@ -1980,16 +2161,16 @@ func (h *Handler) CreateStore(c *gin.Context) {
 		} else {
 			var orgType string
 			if err := h.db.Pool.QueryRow(ctx, `SELECT org_type::text FROM admin_organizations WHERE org_id = $1`, partner).Scan(&orgType); err != nil {
-				c.JSON(http.StatusBadRequest, gin.H{"error": "Selected organization not found"})
+				writeError(c, http.StatusBadRequest, "Selected organization not found")
 				return
 			}
 			if orgType != string(models.OrgTypePartner) {
-				c.JSON(http.StatusBadRequest, gin.H{"error": "Selected organization is not of type 'Partner'"})
+				writeError(c, http.StatusBadRequest, "Selected organization is not of type 'Partner'")
 				return
 			}
 			if err := h.db.SetStorePartners(ctx, storeID, []models.StorePartner{{StoreID: storeID, PartnerOrgID: partner}}); err != nil {
 				log.Printf("Failed to assign partner to store %d: %v", storeID, err)
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to assign partner organization"})
+				writeError(c, http.StatusInternalServerError, "Failed to assign partner organization")
 				return
 			}
 		}
@@ -1998,12 +2179,14 @@ func (h *Handler) CreateStore(c *gin.Context) {
 	payload.ID = storeID
 	payload.CreatedAt = createdAt
 	payload.UpdatedAt = updatedAt
+	payload.IsOpenNow = payload.IsOpenAt(time.Now())
 
 	// Return the Store portion to keep response consistent
 	c.JSON(http.StatusCreated, payload.Store)
 }
 
 // UpdateStore handles PUT /stores/:id
+// With If-Unmodified-Since (the loaded updated_at), a concurrent edit gets 409 with the current store.
 func (h *Handler) UpdateStore(c *gin.Context) {
 	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
 	defer cancel()
@@ -2013,7 +2196,11 @@ func (h *Handler) UpdateStore(c *gin.Context) {
 	// Validate store ID
 	sid, err := strconv.Atoi(storeID)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid store id"})
+		writeError(c, http.StatusBadRequest, "Invalid store id")
+		return
+	}
+	unmodifiedSince, ok := parseUnmodifiedSince(c)
+	if !ok {
 		return
 	}
 
@@ -2027,9 +2214,23 @@ func (h *Handler) UpdateStore(c *gin.Context) {
 		PartnerOrgID *string `json:"partner_org_id"`
 	}
 	var payload updateStorePayload
-	if err := c.ShouldBindJSON(&payload); err != nil {
-		log.Printf("[UpdateStore] JSON bind error for store_id=%s: %v", storeID, err)
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body: " + err.Error()})
+	if !bindJSON(c, &payload) {
+		return
+	}
+
+	// Opening hours, holidays and time zone are only changed when present in the payload
+	if err := payload.StoreSchedule.Validate(); err != nil {
+		writeFieldErrors(c, models.FieldError{Field: "opening_hours", Code: "invalid_schedule", Message: err.Error()})
+		return
+	}
+	openingHours, holidays, err := payload.StoreSchedule.MarshalColumns()
+	if err != nil {
+		writeError(c, http.StatusBadRequest, "Invalid opening hours: "+err.Error())
+		return
+	}
+
+	if scope := getAdminScope(c); scope != nil && !scope.keepsStoreInScope(sid, payload.RegionID) {
+		writeError(c, http.StatusForbidden, "region_id is outside your admin scope")
 		return
 	}
 
@@ -2039,7 +2240,11 @@ func (h *Handler) UpdateStore(c *gin.Context) {
 
 	query := `
         UPDATE admin_stores
-        SET name = $2, city = $3, address = $4, latitude = $5, longitude = $6, type = $7, region_id = $8, image_url = $9, is_active = $10, updated_at = CURRENT_TIMESTAMP
+        SET name = $2, city = $3, address = $4, latitude = $5, longitude = $6, type = $7, region_id = $8, image_url = $9, is_active = $10, updated_at = CURRENT_TIMESTAMP,
+            thumbnail_url = CASE WHEN image_url IS DISTINCT FROM $9 THEN NULL ELSE thumbnail_url END,
+            medium_url = CASE WHEN image_url IS DISTINCT FROM $9 THEN NULL ELSE medium_url END,
+            webp_url = CASE WHEN image_url IS DISTINCT FROM $9 THEN NULL ELSE webp_url END,
+            timezone = COALESCE($11, timezone), opening_hours = COALESCE($12, opening_hours), holidays = COALESCE($13, holidays)
         WHERE store_id = $1
     `
 
@@ -2054,6 +2259,13 @@ func (h *Handler) UpdateStore(c *gin.Context) {
 		payload.RegionID,
 		payload.ImageURL,
 		payload.IsActive,
+		payload.Timezone,
+		openingHours,
+		holidays,
+	}
+	if unmodifiedSince != nil {
+		args = append(args, *unmodifiedSince)
+		query += fmt.Sprintf(" AND updated_at <= $%d", len(args))
 	}
 	log.Printf("[UpdateStore] SQL args: $1=%v $2=%v $3=%v $4=%v $5=%v $6=%v $7=%v $8=%v $9=%v $10=%v",
 		args[0], args[1], args[2], args[3], args[4], args[5], args[6], args[7], args[8], args[9],
@@ -2062,11 +2274,19 @@ func (h *Handler) UpdateStore(c *gin.Context) {
 	cmdTag, err := h.db.Pool.Exec(ctx, query, args...)
 	if err != nil {
 		log.Printf("[UpdateStore] DB Exec error for store_id=%s: %v", storeID, err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update store"})
+		writeError(c, http.StatusInternalServerError, "Failed to update store")
 		return
 	}
 	rowsAffected := cmdTag.RowsAffected()
 	log.Printf("[UpdateStore] RowsAffected=%d for store_id=%s", rowsAffected, storeID)
+	if rowsAffected == 0 {
+		if unmodifiedSince != nil {
+			writeEditConflict(c, "Store", h.currentStore(sid))
+		} else {
+			writeError(c, http.StatusNotFound, "Store not found")
+		}
+		return
+	}
 
 	// Update partner mapping if provided
 	if payload.PartnerOrgID != nil {
@@ -2074,32 +2294,27 @@ func (h *Handler) UpdateStore(c *gin.Context) {
 		if partner == "" {
 			if err := h.db.SetStorePartners(ctx, sid, []models.StorePartner{}); err != nil {
 				log.Printf("[UpdateStore] Failed to clear partner mapping for store_id=%s: %v", storeID, err)
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update partner organization mapping"})
+				writeError(c, http.StatusInternalServerError, "Failed to update partner organization mapping")
 				return
 			}
 		} else {
 			var orgType string
 			if err := h.db.Pool.QueryRow(ctx, `SELECT org_type::text FROM admin_organizations WHERE org_id = $1`, partner).Scan(&orgType); err != nil {
-				c.JSON(http.StatusBadRequest, gin.H{"error": "Selected organization not found"})
+				writeError(c, http.StatusBadRequest, "Selected organization not found")
 				return
 			}
 			if orgType != string(models.OrgTypePartner) {
-				c.JSON(http.StatusBadRequest, gin.H{"error": "Selected organization is not of type 'Partner'"})
+				writeError(c, http.StatusBadRequest, "Selected organization is not of type 'Partner'")
 				return
 			}
 			if err := h.db.SetStorePartners(ctx, sid, []models.StorePartner{{StoreID: sid, PartnerOrgID: partner}}); err != nil {
 				log.Printf("[UpdateStore] Failed to set partner mapping for store_id=%s: %v", storeID, err)
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update partner organization mapping"})
+				writeError(c, http.StatusInternalServerError, "Failed to update partner organization mapping")
 				return
 			}
 		}
 	}
 
-	if rowsAffected == 0 {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Store not found"})
-		return
-	}
-
 	// Fetch updated_at to include in response
 	var updatedAt time.Time
 	if err := h.db.Pool.QueryRow(ctx, `SELECT updated_at FROM admin_stores WHERE store_id = $1`, storeID).Scan(&updatedAt); err != nil {
@@ -2108,10 +2323,11 @@ func (h *Handler) UpdateStore(c *gin.Context) {
 	}
 
 	payload.UpdatedAt = updatedAt
+	payload.IsOpenNow = payload.IsOpenAt(time.Now())
 	c.JSON(http.StatusOK, payload.Store)
 }
 
-// DeleteStore handles DELETE /stores/:id
+// DeleteStore handles DELETE /stores/:id (hard=true moves it to the trash, see moveToTrash)
 func (h *Handler) DeleteStore(c *gin.Context) {
 	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
 	defer cancel()
@@ -2122,25 +2338,8 @@ func (h *Handler) DeleteStore(c *gin.Context) {
 	hardDelete := c.Query("hard") == "true"
 
 	if hardDelete {
-		// Delete S3 images first (before database deletion)
-		s3Prefix := fmt.Sprintf("admin-panel/stores/%s/images/", storeID)
-		if err := h.deleteS3Folder(ctx, s3Prefix); err != nil {
-			log.Printf("Warning: Failed to delete S3 images for store %s: %v", storeID, err)
-			// Continue with database deletion even if S3 cleanup fails
-		}
-
-		// Perform hard delete (completely remove from database)
-		query := `DELETE FROM admin_stores WHERE store_id = $1`
-		_, err := h.db.Pool.Exec(ctx, query, storeID)
-		if err != nil {
-			log.Printf("Failed to hard delete store %s: %v", storeID, err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete store"})
-			return
-		}
-		c.JSON(http.StatusOK, gin.H{
-			"message":  "Store and images permanently deleted",
-			"store_id": storeID,
-		})
+		// Two-phase: trashed now, purged with its images after the retention window
+		h.moveToTrash(c, models.TrashStore, storeID)
 	} else {
 		// Perform soft delete (set is_active = false)
 		query := `
@@ -2151,13 +2350,13 @@ func (h *Handler) DeleteStore(c *gin.Context) {
 		result, err := h.db.Pool.Exec(ctx, query, storeID)
 		if err != nil {
 			log.Printf("Failed to soft delete store %s: %v", storeID, err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete store"})
+			writeError(c, http.StatusInternalServerError, "Failed to delete store")
 			return
 		}
 
 		rowsAffected := result.RowsAffected()
 		if rowsAffected == 0 {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Store not found"})
+			writeError(c, http.StatusNotFound, "Store not found")
 			return
 		}
 
@@ -2168,71 +2367,13 @@ func (h *Handler) DeleteStore(c *gin.Context) {
 	}
 }
 
-// deleteS3Folder deletes all objects under the given S3 prefix (folder)
-func (h *Handler) deleteS3Folder(ctx context.Context, prefix string) error {
-	region := os.Getenv("AWS_REGION")
-	if region == "" {
-		region = os.Getenv("AWS_DEFAULT_REGION")
-	}
-	if region == "" {
-		region = "eu-central-1"
-	}
-
-	// Clear any existing credentials to use IAM role
-	_ = os.Unsetenv("AWS_ACCESS_KEY_ID")
-	_ = os.Unsetenv("AWS_SECRET_ACCESS_KEY")
-	_ = os.Unsetenv("AWS_SESSION_TOKEN")
-
-	cfg, err := config.LoadDefaultConfig(ctx, config.WithRegion(region))
-	if err != nil {
-		return fmt.Errorf("failed to load AWS config: %w", err)
-	}
-
-	s3Client := s3.NewFromConfig(cfg)
-	bucketName := "expotoworld-media"
-
-	// List and delete all objects with the given prefix
-	var token *string
-	for {
-		out, err := s3Client.ListObjectsV2(ctx, &s3.ListObjectsV2Input{
-			Bucket:            &bucketName,
-			Prefix:            &prefix,
-			ContinuationToken: token,
-		})
-		if err != nil {
-			return fmt.Errorf("failed to list S3 objects: %w", err)
-		}
-
-		if len(out.Contents) == 0 {
-			break
-		}
-
-		// Batch delete up to 1000 objects
-		var objs []s3types.ObjectIdentifier
-		for _, o := range out.Contents {
-			key := *o.Key
-			objs = append(objs, s3types.ObjectIdentifier{Key: &key})
-		}
-
-		if len(objs) > 0 {
-			_, err = s3Client.DeleteObjects(ctx, &s3.DeleteObjectsInput{
-				Bucket: &bucketName,
-				Delete: &s3types.Delete{Objects: objs},
-			})
-			if err != nil {
-				return fmt.Errorf("failed to delete S3 objects: %w", err)
-			}
-			log.Printf("Deleted %d objects from S3 with prefix: %s", len(objs), prefix)
-		}
-
-		if out.NextContinuationToken != nil {
-			token = out.NextContinuationToken
-			continue
-		}
-		break
+// deleteMediaFolder deletes all stored objects under the given prefix (folder)
+func (h *Handler) deleteMediaFolder(ctx context.Context, prefix string) error {
+	deleted, err := h.media.DeletePrefix(ctx, prefix)
+	if deleted > 0 {
+		log.Printf("Deleted %d stored objects with prefix: %s", deleted, prefix)
 	}
-
-	return nil
+	return err
 }
 
 // AdminCleanupS3 deletes all objects under the given prefixes. Guarded by X-Maintenance-Token.
@@ -2242,77 +2383,36 @@ func (h *Handler) AdminCleanupS3(c *gin.Context) {
 
 	secret := os.Getenv("MAINTENANCE_TOKEN")
 	if secret == "" || c.GetHeader("X-Maintenance-Token") != secret {
-		c.JSON(http.StatusForbidden, gin.H{"error": "Forbidden"})
+		writeError(c, http.StatusForbidden, "Forbidden")
 		return
 	}
 
 	prefixesParam := c.Query("prefixes")
 	if prefixesParam == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "prefixes query param required (comma-separated)"})
+		writeError(c, http.StatusBadRequest, "prefixes query param required (comma-separated)")
 		return
 	}
 	prefixes := strings.Split(prefixesParam, ",")
 
-	region := os.Getenv("AWS_REGION")
-	if region == "" {
-		region = os.Getenv("AWS_DEFAULT_REGION")
-	}
-	if region == "" {
-		region = "eu-central-1"
-	}
-	_ = os.Unsetenv("AWS_ACCESS_KEY_ID")
-	_ = os.Unsetenv("AWS_SECRET_ACCESS_KEY")
-	_ = os.Unsetenv("AWS_SESSION_TOKEN")
-	cfg, err := config.LoadDefaultConfig(ctx, config.WithRegion(region))
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load AWS config", "details": err.Error()})
-		return
-	}
-	s3Client := s3.NewFromConfig(cfg)
-	bucketName := "expotoworld-media"
-
 	deleted := 0
 	for _, p := range prefixes {
 		p = strings.TrimSpace(p)
 		if p == "" {
 			continue
 		}
-		var token *string
-		for {
-			out, err := s3Client.ListObjectsV2(ctx, &s3.ListObjectsV2Input{Bucket: &bucketName, Prefix: &p, ContinuationToken: token})
-			if err != nil {
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "list failed", "prefix": p, "details": err.Error()})
-				return
-			}
-			if len(out.Contents) == 0 {
-				break
-			}
-			// batch delete up to 1000
-			var objs []s3types.ObjectIdentifier
-			for _, o := range out.Contents {
-				key := *o.Key
-				objs = append(objs, s3types.ObjectIdentifier{Key: &key})
-			}
-			if len(objs) > 0 {
-				_, err = s3Client.DeleteObjects(ctx, &s3.DeleteObjectsInput{Bucket: &bucketName, Delete: &s3types.Delete{Objects: objs}})
-				if err != nil {
-					c.JSON(http.StatusInternalServerError, gin.H{"error": "delete failed", "prefix": p, "details": err.Error()})
-					return
-				}
-				deleted += len(objs)
-			}
-			if out.NextContinuationToken != nil {
-				token = out.NextContinuationToken
-				continue
-			}
-			break
+		n, err := h.media.DeletePrefix(ctx, p)
+		deleted += n
+		if err != nil {
+			log.Printf("Media cleanup of prefix %s failed: %v", p, err)
+			writeErrorDetails(c, http.StatusInternalServerError, "", "delete failed", gin.H{"prefix": p, "deleted": deleted})
+			return
 		}
 	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "cleanup complete", "deleted": deleted})
 }
 
-// UploadSubcategoryImage handles POST /subcategories/:id/image (S3 storage)
+// UploadSubcategoryImage handles POST /subcategories/:id/image (media storage)
 func (h *Handler) UploadSubcategoryImage(c *gin.Context) {
 	ctx, cancel := context.WithTimeout(c.Request.Context(), 30*time.Second)
 	defer cancel()
@@ -2322,22 +2422,21 @@ func (h *Handler) UploadSubcategoryImage(c *gin.Context) {
 	// Parse multipart form
 	file, header, err := c.Request.FormFile("image")
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "No image file provided"})
+		writeError(c, http.StatusBadRequest, "No image file provided")
 		return
 	}
 	defer file.Close()
 
 	// Validate file type
-	if !isValidImageType(header.Header.Get("Content-Type")) {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid image type. Only JPEG, PNG, and WebP are allowed"})
+	img, ok := readImage(c, file, maxImageUploadBytes)
+	if !ok {
 		return
 	}
 
-	// Upload to S3
-	imageURL, err := h.uploadGenericToS3(ctx, fmt.Sprintf("admin-panel/subcategories/%s/images/%d_%s", subcategoryID, time.Now().Unix(), header.Filename), file)
+	imageURL, err := h.storeImage(ctx, fmt.Sprintf("admin-panel/subcategories/%s/images/%d_%s", subcategoryID, time.Now().Unix(), imageObjectName(header.Filename, img)), img)
 	if err != nil {
-		log.Printf("Failed to upload subcategory image to S3: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to upload image"})
+		log.Printf("Failed to upload subcategory image to storage: %v", err)
+		writeError(c, http.StatusInternalServerError, "Failed to upload image")
 		return
 	}
 
@@ -2353,9 +2452,10 @@ func (h *Handler) UploadSubcategoryImage(c *gin.Context) {
 	err = h.db.Pool.QueryRow(ctx, query, subcategoryID, imageURL).Scan(&updatedAt)
 	if err != nil {
 		log.Printf("Failed to update subcategory image URL: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update subcategory"})
+		writeError(c, http.StatusInternalServerError, "Failed to update subcategory")
 		return
 	}
+	h.startRenditions(db.RenditionsSubcategories, subcategoryID, imageURL, img.Data)
 
 	c.JSON(http.StatusOK, gin.H{
 		"message":    "Image uploaded successfully",
@@ -2364,7 +2464,7 @@ func (h *Handler) UploadSubcategoryImage(c *gin.Context) {
 	})
 }
 
-// UploadStoreImage handles POST /stores/:id/image (S3 storage)
+// UploadStoreImage handles POST /stores/:id/image (media storage)
 func (h *Handler) UploadStoreImage(c *gin.Context) {
 	ctx, cancel := context.WithTimeout(c.Request.Context(), 30*time.Second)
 	defer cancel()
@@ -2374,22 +2474,21 @@ func (h *Handler) UploadStoreImage(c *gin.Context) {
 	// Parse multipart form
 	file, header, err := c.Request.FormFile("image")
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "No image file provided"})
+		writeError(c, http.StatusBadRequest, "No image file provided")
 		return
 	}
 	defer file.Close()
 
 	// Validate file type
-	if !isValidImageType(header.Header.Get("Content-Type")) {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid image type. Only JPEG, PNG, and WebP are allowed"})
+	img, ok := readImage(c, file, maxImageUploadBytes)
+	if !ok {
 		return
 	}
 
-	// Upload to S3
-	imageURL, err := h.uploadGenericToS3(ctx, fmt.Sprintf("admin-panel/stores/%s/images/%d_%s", storeID, time.Now().Unix(), header.Filename), file)
+	imageURL, err := h.storeImage(ctx, fmt.Sprintf("admin-panel/stores/%s/images/%d_%s", storeID, time.Now().Unix(), imageObjectName(header.Filename, img)), img)
 	if err != nil {
-		log.Printf("Failed to upload store image to S3: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to upload image"})
+		log.Printf("Failed to upload store image to storage: %v", err)
+		writeError(c, http.StatusInternalServerError, "Failed to upload image")
 		return
 	}
 
@@ -2405,9 +2504,10 @@ func (h *Handler) UploadStoreImage(c *gin.Context) {
 	err = h.db.Pool.QueryRow(ctx, query, storeID, imageURL).Scan(&updatedAt)
 	if err != nil {
 		log.Printf("Failed to update store image URL: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update store"})
+		writeError(c, http.StatusInternalServerError, "Failed to update store")
 		return
 	}
+	h.startRenditions(db.RenditionsStores, storeID, imageURL, img.Data)
 
 	c.JSON(http.StatusOK, gin.H{
 		"message":    "Image uploaded successfully",
@@ -2416,23 +2516,6 @@ func (h *Handler) UploadStoreImage(c *gin.Context) {
 	})
 }
 
-// Helper function to validate image file types
-func isValidImageType(contentType string) bool {
-	validTypes := []string{
-		"image/jpeg",
-		"image/jpg",
-		"image/png",
-		"image/webp",
-	}
-
-	for _, validType := range validTypes {
-		if contentType == validType {
-			return true
-		}
-	}
-	return false
-}
-
 // Helper function to save uploaded file to disk
 func saveUploadedFile(file multipart.File, filepath string) error {
 	// Create directory if it doesn't exist
@@ -2464,39 +2547,50 @@ func (h *Handler) UploadProductImages(c *gin.Context) {
 	productIDStr := c.Param("id")
 	productID, err := strconv.Atoi(productIDStr)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID"})
+		writeError(c, http.StatusBadRequest, "Invalid product ID")
 		return
 	}
 
 	// Parse multipart form
 	err = c.Request.ParseMultipartForm(32 << 20) // 32 MB max
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to parse form"})
+		writeError(c, http.StatusBadRequest, "Failed to parse form")
 		return
 	}
 
 	files := c.Request.MultipartForm.File["images"]
 	if len(files) == 0 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "No images provided"})
+		writeError(c, http.StatusBadRequest, "No images provided")
 		return
 	}
 
-	var uploadedImages []models.ProductImage
-
+	// Check every image before storing any, so one bad file doesn't leave a partial gallery
+	images := make([]*imaging.Upload, len(files))
 	for i, fileHeader := range files {
 		file, err := fileHeader.Open()
 		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to open file"})
+			writeError(c, http.StatusBadRequest, "Failed to open file")
 			return
 		}
-		defer file.Close()
+		img, ok := readImage(c, file, maxImageUploadBytes)
+		file.Close()
+		if !ok {
+			return
+		}
+		images[i] = img
+	}
+
+	var uploadedImages []models.ProductImage
+
+	for i, fileHeader := range files {
+		img := images[i]
 
-		// Upload the file to S3 and get the CloudFront URL
-		objectKey := fmt.Sprintf("admin-panel/products/%d/images/%d_%s", productID, time.Now().UnixNano(), fileHeader.Filename)
-		imageURL, err := h.uploadGenericToS3(ctx, objectKey, file)
+		// Upload the file and get its public URL
+		objectKey := fmt.Sprintf("admin-panel/products/%d/images/%d_%s", productID, time.Now().UnixNano(), imageObjectName(fileHeader.Filename, img))
+		imageURL, err := h.storeImage(ctx, objectKey, img)
 		if err != nil {
-			log.Printf("Failed to upload image to S3: %v", err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to upload an image"})
+			log.Printf("Failed to upload image to storage: %v", err)
+			writeError(c, http.StatusInternalServerError, "Failed to upload an image")
 			return
 		}
 
@@ -2514,9 +2608,10 @@ func (h *Handler) UploadProductImages(c *gin.Context) {
 		imageID, err := h.addProductImage(ctx, productID, imageURL, displayOrder, isPrimary)
 		if err != nil {
 			log.Printf("Failed to save image to database: %v", err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save image metadata"})
+			writeError(c, http.StatusInternalServerError, "Failed to save image metadata")
 			return
 		}
+		h.startRenditions(db.RenditionsProductImages, strconv.Itoa(productID), imageURL, img.Data)
 
 		uploadedImages = append(uploadedImages, models.ProductImage{
 			ID:           imageID,
@@ -2541,14 +2636,14 @@ func (h *Handler) GetProductImages(c *gin.Context) {
 	productIDStr := c.Param("id")
 	productID, err := strconv.Atoi(productIDStr)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID"})
+		writeError(c, http.StatusBadRequest, "Invalid product ID")
 		return
 	}
 
 	images, err := h.getProductImagesDetailed(ctx, productID)
 	if err != nil {
 		log.Printf("Failed to get product images: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get images"})
+		writeError(c, http.StatusInternalServerError, "Failed to get images")
 		return
 	}
 
@@ -2563,7 +2658,7 @@ func (h *Handler) ReorderProductImages(c *gin.Context) {
 	productIDStr := c.Param("id")
 	productID, err := strconv.Atoi(productIDStr)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID"})
+		writeError(c, http.StatusBadRequest, "Invalid product ID")
 		return
 	}
 
@@ -2574,8 +2669,7 @@ func (h *Handler) ReorderProductImages(c *gin.Context) {
 		} `json:"image_orders"`
 	}
 
-	if err := c.ShouldBindJSON(&reorderRequest); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+	if !bindJSON(c, &reorderRequest) {
 		return
 	}
 
@@ -2584,7 +2678,7 @@ func (h *Handler) ReorderProductImages(c *gin.Context) {
 		err := h.updateImageDisplayOrder(ctx, productID, order.ImageID, order.DisplayOrder)
 		if err != nil {
 			log.Printf("Failed to update image order: %v", err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update image order"})
+			writeError(c, http.StatusInternalServerError, "Failed to update image order")
 			return
 		}
 	}
@@ -2600,21 +2694,21 @@ func (h *Handler) DeleteProductImage(c *gin.Context) {
 	productIDStr := c.Param("id")
 	productID, err := strconv.Atoi(productIDStr)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID"})
+		writeError(c, http.StatusBadRequest, "Invalid product ID")
 		return
 	}
 
 	imageIDStr := c.Param("image_id")
 	imageID, err := strconv.Atoi(imageIDStr)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid image ID"})
+		writeError(c, http.StatusBadRequest, "Invalid image ID")
 		return
 	}
 
 	err = h.deleteProductImage(ctx, productID, imageID)
 	if err != nil {
 		log.Printf("Failed to delete product image: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete image"})
+		writeError(c, http.StatusInternalServerError, "Failed to delete image")
 		return
 	}
 
@@ -2629,21 +2723,21 @@ func (h *Handler) SetPrimaryImage(c *gin.Context) {
 	productIDStr := c.Param("id")
 	productID, err := strconv.Atoi(productIDStr)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID"})
+		writeError(c, http.StatusBadRequest, "Invalid product ID")
 		return
 	}
 
 	imageIDStr := c.Param("image_id")
 	imageID, err := strconv.Atoi(imageIDStr)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid image ID"})
+		writeError(c, http.StatusBadRequest, "Invalid image ID")
 		return
 	}
 
 	err = h.setPrimaryImage(ctx, productID, imageID)
 	if err != nil {
 		log.Printf("Failed to set primary image: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to set primary image"})
+		writeError(c, http.StatusInternalServerError, "Failed to set primary image")
 		return
 	}
 
@@ -2723,53 +2817,18 @@ func (h *Handler) deleteProductImage(ctx context.Context, productID, imageID int
 		return err
 	}
 
-	// Extract S3 object key from CloudFront URL
-	// URL format: https://assets.expotoworld.com/products/{product_id}/{timestamp}_{filename}
-	// We need to extract: products/{product_id}/{timestamp}_{filename}
-	cdnBase := os.Getenv("ASSETS_CDN_BASE_URL")
-	if cdnBase == "" {
-		cdnBase = "https://assets.expotoworld.com"
-	}
-
-	objectKey := strings.TrimPrefix(imageURL, cdnBase+"/")
-	if objectKey == imageURL {
+	objectKey, ok := h.media.KeyFromURL(imageURL)
+	if !ok {
 		// URL doesn't match expected format, log warning but don't fail
-		log.Printf("Warning: Could not extract S3 key from URL: %s", imageURL)
+		log.Printf("Warning: Could not extract storage key from URL: %s", imageURL)
 		return nil
 	}
 
-	// Delete from S3
-	region := os.Getenv("AWS_REGION")
-	if region == "" {
-		region = os.Getenv("AWS_DEFAULT_REGION")
-	}
-	if region == "" {
-		region = "eu-central-1"
-	}
-
-	// Clear any existing credentials to use IAM role
-	_ = os.Unsetenv("AWS_ACCESS_KEY_ID")
-	_ = os.Unsetenv("AWS_SECRET_ACCESS_KEY")
-	_ = os.Unsetenv("AWS_SESSION_TOKEN")
-
-	cfg, err := config.LoadDefaultConfig(ctx, config.WithRegion(region))
-	if err != nil {
-		log.Printf("Warning: Failed to load AWS config for S3 deletion: %v", err)
-		return nil // Don't fail the request if S3 cleanup fails
-	}
-
-	s3Client := s3.NewFromConfig(cfg)
-	bucketName := "expotoworld-media"
-
-	_, err = s3Client.DeleteObject(ctx, &s3.DeleteObjectInput{
-		Bucket: &bucketName,
-		Key:    &objectKey,
-	})
-	if err != nil {
-		log.Printf("Warning: Failed to delete S3 object %s: %v", objectKey, err)
-		// Don't fail the request if S3 cleanup fails
+	// Don't fail the request if storage cleanup fails
+	if err := h.media.Delete(ctx, append([]string{objectKey}, renditionKeys(objectKey)...)...); err != nil {
+		log.Printf("Warning: Failed to delete stored image %s: %v", objectKey, err)
 	} else {
-		log.Printf("Successfully deleted S3 object: %s", objectKey)
+		log.Printf("Successfully deleted stored image: %s", objectKey)
 	}
 
 	return nil
@@ -2780,7 +2839,7 @@ func (h *Handler) setPrimaryImage(ctx context.Context, productID, imageID int) e
 	return h.db.SetPrimaryImage(ctx, productID, imageID)
 }
 
-// UploadCategoryImage handles POST /categories/:id/image (S3 storage)
+// UploadCategoryImage handles POST /categories/:id/image (media storage)
 func (h *Handler) UploadCategoryImage(c *gin.Context) {
 	ctx, cancel := context.WithTimeout(c.Request.Context(), 30*time.Second)
 	defer cancel()
@@ -2789,20 +2848,20 @@ func (h *Handler) UploadCategoryImage(c *gin.Context) {
 
 	file, header, err := c.Request.FormFile("image")
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "No image file provided"})
+		writeError(c, http.StatusBadRequest, "No image file provided")
 		return
 	}
 	defer file.Close()
 
-	if !isValidImageType(header.Header.Get("Content-Type")) {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid image type. Only JPEG, PNG, and WebP are allowed"})
+	img, ok := readImage(c, file, maxImageUploadBytes)
+	if !ok {
 		return
 	}
 
-	imageURL, err := h.uploadGenericToS3(ctx, fmt.Sprintf("admin-panel/categories/%s/images/%d_%s", categoryID, time.Now().Unix(), header.Filename), file)
+	imageURL, err := h.storeImage(ctx, fmt.Sprintf("admin-panel/categories/%s/images/%d_%s", categoryID, time.Now().Unix(), imageObjectName(header.Filename, img)), img)
 	if err != nil {
-		log.Printf("Failed to upload category image to S3: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to upload image"})
+		log.Printf("Failed to upload category image to storage: %v", err)
+		writeError(c, http.StatusInternalServerError, "Failed to upload image")
 		return
 	}
 
@@ -2817,9 +2876,10 @@ func (h *Handler) UploadCategoryImage(c *gin.Context) {
 	err = h.db.Pool.QueryRow(ctx, query, categoryID, imageURL).Scan(&updatedAt)
 	if err != nil {
 		log.Printf("Failed to update category image URL: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update category"})
+		writeError(c, http.StatusInternalServerError, "Failed to update category")
 		return
 	}
+	h.startRenditions(db.RenditionsCategories, categoryID, imageURL, img.Data)
 
 	c.JSON(http.StatusOK, gin.H{
 		"message":    "Image uploaded successfully",
@@ -2833,28 +2893,7 @@ func (h *Handler) GetManufacturerProducts(c *gin.Context) {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	orgs, ok := c.Get("org_memberships")
-	if !ok || orgs == nil {
-		c.JSON(http.StatusOK, []models.Product{})
-		return
-	}
-	arr, ok := orgs.([]interface{})
-	if !ok {
-		c.JSON(http.StatusOK, []models.Product{})
-		return
-	}
-	orgIDs := make([]string, 0, len(arr))
-	for _, it := range arr {
-		m, ok := it.(map[string]interface{})
-		if !ok {
-			continue
-		}
-		if t, ok := m["org_type"].(string); ok && t == "Manufacturer" {
-			if id, ok := m["org_id"].(string); ok && id != "" {
-				orgIDs = append(orgIDs, id)
-			}
-		}
-	}
+	orgIDs := manufacturerOrgIDs(c)
 	if len(orgIDs) == 0 {
 		c.JSON(http.StatusOK, []models.Product{})
 		return
@@ -2870,6 +2909,7 @@ func (h *Handler) GetManufacturerProducts(c *gin.Context) {
 			CASE WHEN p.mini_app_type IN ('UnmannedStore','ExhibitionSales') AND s.type IS NOT NULL THEN s.type::text ELSE p.store_type::text END as store_type,
 			COALESCE(p.mini_app_type::text, '') as mini_app_type,
 			p.store_id,
+			p.barcode,
 			p.shelf_code,
 			COALESCE(p.main_price, 0) as main_price,
 			p.strikethrough_price,
@@ -2897,7 +2937,7 @@ func (h *Handler) GetManufacturerProducts(c *gin.Context) {
 	rows, err := h.db.Pool.Query(ctx, finalQuery, args...)
 	if err != nil {
 		log.Printf("manufacturer products query failed: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch products"})
+		writeError(c, http.StatusInternalServerError, "Failed to fetch products")
 		return
 	}
 	defer rows.Close()
@@ -2916,6 +2956,7 @@ func (h *Handler) GetManufacturerProducts(c *gin.Context) {
 			&storeType,
 			&product.MiniAppType,
 			&product.StoreID,
+			&product.Barcode,
 			&product.ShelfCode,
 			&product.MainPrice,
 			&product.StrikethroughPrice,
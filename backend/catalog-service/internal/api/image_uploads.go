@@ -0,0 +1,260 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/expotoworld/expotoworld/backend/catalog-service/internal/db"
+	"github.com/expotoworld/expotoworld/backend/catalog-service/internal/imaging"
+	"github.com/expotoworld/expotoworld/backend/common/storage"
+	"github.com/gin-gonic/gin"
+)
+
+// Presigned uploads let the admin panel PUT large images straight to S3 instead of
+// streaming them through this service; the confirm call then records the image row.
+
+var unsafeKeyChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+type presignImageRequest struct {
+	Filename    string `json:"filename" binding:"required"`
+	ContentType string `json:"content_type" binding:"required"`
+	Size        int64  `json:"size" binding:"required"`
+}
+
+type confirmImageRequest struct {
+	Key string `json:"key" binding:"required"`
+}
+
+// maxImageUploadBytes is the largest image accepted through a multipart upload
+const maxImageUploadBytes = 10 << 20
+
+// readImage reads an uploaded image of at most maxBytes and sanitizes it (see imaging.Sanitize).
+// It writes the error response itself when ok is false.
+func readImage(c *gin.Context, r io.Reader, maxBytes int64) (*imaging.Upload, bool) {
+	data, err := io.ReadAll(io.LimitReader(r, maxBytes+1))
+	if err != nil {
+		log.Printf("Failed to read uploaded image: %v", err)
+		writeError(c, http.StatusInternalServerError, "Failed to read uploaded file")
+		return nil, false
+	}
+	if int64(len(data)) > maxBytes {
+		writeError(c, http.StatusBadRequest, fmt.Sprintf("File size exceeds %dMB limit", maxBytes>>20))
+		return nil, false
+	}
+	img, err := imaging.Sanitize(data)
+	if err != nil {
+		writeImageError(c, err)
+		return nil, false
+	}
+	return img, true
+}
+
+// writeImageError answers an image rejected by imaging.Sanitize
+func writeImageError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, imaging.ErrTooLarge):
+		maxSide, maxPixels := imaging.UploadLimits()
+		writeErrorDetails(c, http.StatusBadRequest, "IMAGE_TOO_LARGE",
+			fmt.Sprintf("Image must be at most %d pixels per side and %d megapixels", maxSide, maxPixels/1_000_000),
+			gin.H{"max_side_px": maxSide, "max_pixels": maxPixels})
+	case errors.Is(err, imaging.ErrPolyglot):
+		writeErrorDetails(c, http.StatusBadRequest, "INVALID_IMAGE", "Image contains embedded content of another file type", nil)
+	default:
+		writeErrorDetails(c, http.StatusBadRequest, "INVALID_IMAGE", "File is not a valid JPEG, PNG, GIF or WebP image", nil)
+	}
+}
+
+// imageObjectName is a storage-safe file name for an upload, with the extension of its actual format
+func imageObjectName(filename string, img *imaging.Upload) string {
+	name := path.Base(strings.ReplaceAll(filename, "\\", "/"))
+	name = unsafeKeyChars.ReplaceAllString(strings.TrimSuffix(name, path.Ext(name)), "_")
+	if name == "" || name == "." || name == "_" {
+		name = "image"
+	}
+	return name + img.Ext()
+}
+
+// storeImage writes a sanitized image under the given key and returns its public URL
+func (h *Handler) storeImage(ctx context.Context, objectKey string, img *imaging.Upload) (string, error) {
+	meta := storage.ObjectMeta{ContentType: img.ContentType, Size: int64(len(img.Data))}
+	if err := h.media.PutObject(ctx, objectKey, bytes.NewReader(img.Data), meta); err != nil {
+		return "", err
+	}
+	return h.media.PublicURL(objectKey), nil
+}
+
+// productImageMaxBytes is the largest image accepted through a presigned upload
+func productImageMaxBytes() int64 {
+	return int64(getEnvInt("PRODUCT_IMAGE_MAX_MB", 25)) << 20
+}
+
+// productImagePrefix is the S3 folder holding a product's gallery images
+func productImagePrefix(productID int) string {
+	return fmt.Sprintf("admin-panel/products/%d/images/", productID)
+}
+
+// PresignProductImageUpload handles POST /products/:id/images/presign
+// Returns a presigned PUT URL; the client must send the signed Content-Type and Content-Length headers.
+func (h *Handler) PresignProductImageUpload(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	productID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		writeError(c, http.StatusBadRequest, "Invalid product ID")
+		return
+	}
+
+	var req presignImageRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+	contentType := strings.ToLower(strings.TrimSpace(req.ContentType))
+	if !h.isValidImageType(contentType) {
+		writeError(c, http.StatusBadRequest, "Invalid file type. Only images are allowed")
+		return
+	}
+	maxBytes := productImageMaxBytes()
+	if req.Size <= 0 || req.Size > maxBytes {
+		writeError(c, http.StatusBadRequest, fmt.Sprintf("File size must be between 1 byte and %dMB", maxBytes>>20))
+		return
+	}
+
+	var exists bool
+	if err := h.db.Pool.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM admin_products WHERE product_id = $1)`, productID).Scan(&exists); err != nil {
+		log.Printf("Failed to check product %d: %v", productID, err)
+		writeError(c, http.StatusInternalServerError, "Failed to check product")
+		return
+	}
+	if !exists {
+		writeError(c, http.StatusNotFound, "Product not found")
+		return
+	}
+
+	name := unsafeKeyChars.ReplaceAllString(path.Base(strings.ReplaceAll(req.Filename, "\\", "/")), "_")
+	if name == "" || name == "." || name == "_" {
+		name = "image"
+	}
+	objectKey := fmt.Sprintf("%s%d_%s", productImagePrefix(productID), time.Now().UnixNano(), name)
+
+	expires := time.Duration(getEnvInt("PRODUCT_IMAGE_PRESIGN_MINUTES", 15)) * time.Minute
+	presigned, err := h.media.PresignedURL(ctx, objectKey, storage.ObjectMeta{ContentType: contentType, Size: req.Size}, expires)
+	if errors.Is(err, storage.ErrPresignUnsupported) {
+		writeError(c, http.StatusNotImplemented, "Direct uploads are not available with this storage driver; use POST /products/:id/images")
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to presign upload for product %d: %v", productID, err)
+		writeError(c, http.StatusInternalServerError, "Failed to create upload URL")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"upload_url": presigned.URL,
+		"method":     presigned.Method,
+		"headers":    presigned.Headers,
+		"key":        objectKey,
+		"image_url":  h.media.PublicURL(objectKey),
+		"expires_at": time.Now().Add(expires).UTC(),
+	})
+}
+
+// ConfirmProductImageUpload handles POST /products/:id/images/confirm
+// Checks the uploaded object in storage and appends it to the product's gallery.
+func (h *Handler) ConfirmProductImageUpload(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 15*time.Second)
+	defer cancel()
+
+	productID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		writeError(c, http.StatusBadRequest, "Invalid product ID")
+		return
+	}
+
+	var req confirmImageRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+	objectKey := strings.TrimSpace(req.Key)
+	if !strings.HasPrefix(objectKey, productImagePrefix(productID)) || strings.Contains(objectKey, "..") {
+		writeError(c, http.StatusBadRequest, "Key does not belong to this product")
+		return
+	}
+
+	head, err := h.media.Stat(ctx, objectKey)
+	if err != nil {
+		log.Printf("Uploaded object %s not found: %v", objectKey, err)
+		writeError(c, http.StatusNotFound, "Uploaded file not found; upload it before confirming")
+		return
+	}
+	if !h.isValidImageType(strings.ToLower(head.ContentType)) || head.Size > productImageMaxBytes() {
+		// The object was not what was presigned; remove it rather than leave it orphaned
+		if err := h.media.Delete(ctx, objectKey); err != nil {
+			log.Printf("Failed to delete rejected upload %s: %v", objectKey, err)
+		}
+		writeError(c, http.StatusBadRequest, "Uploaded file is not an accepted image")
+		return
+	}
+	// Presigned uploads skip this service, so check the object now: reject what doesn't decode and
+	// store the sanitized copy in place of the original
+	obj, err := h.media.GetObject(ctx, objectKey)
+	if err != nil {
+		log.Printf("Failed to read uploaded object %s: %v", objectKey, err)
+		writeError(c, http.StatusInternalServerError, "Failed to read uploaded file")
+		return
+	}
+	data, err := io.ReadAll(io.LimitReader(obj, productImageMaxBytes()+1))
+	obj.Close()
+	if err != nil {
+		log.Printf("Failed to read uploaded object %s: %v", objectKey, err)
+		writeError(c, http.StatusInternalServerError, "Failed to read uploaded file")
+		return
+	}
+	img, err := imaging.Sanitize(data)
+	if err != nil {
+		if err := h.media.Delete(ctx, objectKey); err != nil {
+			log.Printf("Failed to delete rejected upload %s: %v", objectKey, err)
+		}
+		writeImageError(c, err)
+		return
+	}
+	if !bytes.Equal(img.Data, data) || !strings.EqualFold(head.ContentType, img.ContentType) {
+		if _, err := h.storeImage(ctx, objectKey, img); err != nil {
+			log.Printf("Failed to store sanitized upload %s: %v", objectKey, err)
+			writeError(c, http.StatusInternalServerError, "Failed to store uploaded file")
+			return
+		}
+	}
+
+	image, created, err := h.db.AppendProductImage(ctx, productID, h.media.PublicURL(objectKey))
+	if err != nil {
+		if errors.Is(err, db.ErrProductNotFound) {
+			writeError(c, http.StatusNotFound, "Product not found")
+			return
+		}
+		log.Printf("Failed to save image to database: %v", err)
+		writeError(c, http.StatusInternalServerError, "Failed to save image metadata")
+		return
+	}
+
+	status := http.StatusCreated
+	if created {
+		h.startRenditions(db.RenditionsProductImages, strconv.Itoa(productID), image.ImageURL, img.Data)
+	} else {
+		status = http.StatusOK
+	}
+	c.JSON(status, gin.H{
+		"message": "Image uploaded successfully",
+		"image":   image,
+	})
+}
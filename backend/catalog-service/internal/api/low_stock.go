@@ -0,0 +1,212 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/expotoworld/expotoworld/backend/catalog-service/internal/alerts"
+	"github.com/expotoworld/expotoworld/backend/catalog-service/internal/db"
+	"github.com/expotoworld/expotoworld/backend/catalog-service/internal/logging"
+	"github.com/expotoworld/expotoworld/backend/catalog-service/internal/models"
+	"github.com/gin-gonic/gin"
+)
+
+// lowStockDefaultThreshold applies to products whose product and store set no threshold; 0 disables it
+func lowStockDefaultThreshold() int {
+	return getEnvInt("LOW_STOCK_DEFAULT_THRESHOLD", 0)
+}
+
+// StartLowStockJobs periodically announces products that fell below their low-stock threshold on the
+// LOW_STOCK_SNS_TOPIC_ARN topic and publishes low-stock counts to the LOW_STOCK_METRIC_NAMESPACE
+// CloudWatch namespace. Each product is announced once until its stock recovers.
+func (h *Handler) StartLowStockJobs(ctx context.Context) {
+	topicARN := os.Getenv("LOW_STOCK_SNS_TOPIC_ARN")
+	namespace := os.Getenv("LOW_STOCK_METRIC_NAMESPACE")
+	if topicARN == "" && namespace == "" {
+		log.Printf("[LOW_STOCK] LOW_STOCK_SNS_TOPIC_ARN and LOW_STOCK_METRIC_NAMESPACE not set; low-stock alerts disabled")
+		return
+	}
+	interval := time.Duration(getEnvInt("LOW_STOCK_CHECK_SECONDS", 300)) * time.Second
+
+	var client *alerts.Client
+	run := func() {
+		jobCtx, cancel := context.WithTimeout(ctx, time.Minute)
+		defer cancel()
+		if client == nil {
+			cfg, err := h.aws.Config(jobCtx)
+			if err != nil {
+				logging.LogKV("error", "LowStockCheckFailed", map[string]interface{}{"error": err.Error()})
+				return
+			}
+			client = alerts.New(cfg)
+		}
+		threshold := lowStockDefaultThreshold()
+
+		if _, err := h.db.ResetRecoveredLowStockAlerts(jobCtx, threshold); err != nil {
+			logging.LogKV("error", "LowStockCheckFailed", map[string]interface{}{"error": err.Error()})
+			return
+		}
+		if topicARN != "" {
+			h.announceLowStock(jobCtx, client, topicARN, threshold)
+		}
+		if namespace != "" {
+			h.publishLowStockMetrics(jobCtx, client, namespace, threshold)
+		}
+	}
+
+	run()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			run()
+		}
+	}
+}
+
+// announceLowStock sends one SNS message listing the products that went low since the last check
+func (h *Handler) announceLowStock(ctx context.Context, client *alerts.Client, topicARN string, threshold int) {
+	products, err := h.db.ClaimLowStockAlerts(ctx, threshold)
+	if err != nil {
+		logging.LogKV("error", "LowStockAlertFailed", map[string]interface{}{"error": err.Error()})
+		return
+	}
+	if len(products) == 0 {
+		return
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d product(s) fell below their low-stock threshold:\n\n", len(products))
+	ids := make([]int, len(products))
+	for i, p := range products {
+		ids[i] = p.ProductID
+		store := "no store"
+		if p.StoreName != nil {
+			store = *p.StoreName
+		}
+		fmt.Fprintf(&b, "- %s %s (%s): %d left, threshold %d\n", p.SKU, p.Title, store, p.StockLeft, p.Threshold)
+	}
+	subject := fmt.Sprintf("Low stock: %d product(s)", len(products))
+	if err := client.Publish(ctx, topicARN, subject, b.String()); err != nil {
+		logging.LogKV("error", "LowStockAlertFailed", map[string]interface{}{"products": len(products), "error": err.Error()})
+		// Give the products back so the next check retries them
+		releaseCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := h.db.ReleaseLowStockAlerts(releaseCtx, ids); err != nil {
+			logging.LogKV("error", "LowStockAlertReleaseFailed", map[string]interface{}{"error": err.Error()})
+		}
+		return
+	}
+	logging.LogKV("info", "LowStockAlertSent", map[string]interface{}{"products": len(products)})
+}
+
+// publishLowStockMetrics records LowStockProducts in total and per store (StoreId dimension)
+func (h *Handler) publishLowStockMetrics(ctx context.Context, client *alerts.Client, namespace string, threshold int) {
+	counts, err := h.db.CountLowStockByStore(ctx, threshold)
+	if err != nil {
+		logging.LogKV("error", "LowStockMetricsFailed", map[string]interface{}{"error": err.Error()})
+		return
+	}
+	total := 0
+	metrics := make([]alerts.Metric, 0, len(counts)+1)
+	for storeID, n := range counts {
+		total += n
+		if storeID > 0 {
+			metrics = append(metrics, alerts.Metric{Name: "LowStockProducts", Value: float64(n), Unit: "Count",
+				Dimensions: map[string]string{"StoreId": strconv.Itoa(storeID)}})
+		}
+	}
+	metrics = append(metrics, alerts.Metric{Name: "LowStockProducts", Value: float64(total), Unit: "Count"})
+	if err := client.PutMetrics(ctx, namespace, metrics); err != nil {
+		logging.LogKV("error", "LowStockMetricsFailed", map[string]interface{}{"error": err.Error()})
+	}
+}
+
+// GetLowStockProducts handles GET /admin/products/low-stock[?limit=100]
+// Regional admins only see their stores' products.
+func (h *Handler) GetLowStockProducts(c *gin.Context) {
+	limit := 100
+	if v := c.Query("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 || n > 500 {
+			writeError(c, http.StatusBadRequest, "limit must be between 1 and 500")
+			return
+		}
+		limit = n
+	}
+	scope, ok := h.resolveAdminScope(c)
+	if !ok {
+		return
+	}
+	var storeIDs []int
+	if scope != nil {
+		storeIDs = scope.storeIDs()
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+	products, err := h.db.ListLowStockProducts(ctx, lowStockDefaultThreshold(), storeIDs, limit)
+	if err != nil {
+		log.Printf("Failed to list low-stock products: %v", err)
+		writeError(c, http.StatusInternalServerError, "Failed to fetch low-stock products")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"products": products, "default_threshold": lowStockDefaultThreshold()})
+}
+
+// SetProductLowStockThreshold handles PUT /products/:id/low-stock-threshold
+func (h *Handler) SetProductLowStockThreshold(c *gin.Context) {
+	productID, ok := parseProductIDParam(c)
+	if !ok {
+		return
+	}
+	var req models.LowStockThresholdRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+	err := h.db.SetProductLowStockThreshold(c.Request.Context(), productID, req.Threshold)
+	if errors.Is(err, db.ErrProductNotFound) {
+		writeError(c, http.StatusNotFound, "Product not found")
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to set low-stock threshold of product %d: %v", productID, err)
+		writeError(c, http.StatusInternalServerError, "Failed to set low-stock threshold")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"product_id": productID, "threshold": req.Threshold})
+}
+
+// SetStoreLowStockThreshold handles PUT /stores/:id/low-stock-threshold
+func (h *Handler) SetStoreLowStockThreshold(c *gin.Context) {
+	storeID, err := strconv.Atoi(c.Param("id"))
+	if err != nil || storeID <= 0 {
+		writeError(c, http.StatusBadRequest, "Invalid store id")
+		return
+	}
+	var req models.LowStockThresholdRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+	err = h.db.SetStoreLowStockThreshold(c.Request.Context(), storeID, req.Threshold)
+	if errors.Is(err, db.ErrStoreNotFound) {
+		writeError(c, http.StatusNotFound, "Store not found")
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to set low-stock threshold of store %d: %v", storeID, err)
+		writeError(c, http.StatusInternalServerError, "Failed to set low-stock threshold")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"store_id": storeID, "threshold": req.Threshold})
+}
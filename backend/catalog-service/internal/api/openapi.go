@@ -0,0 +1,324 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/expotoworld/expotoworld/backend/catalog-service/internal/graphql"
+	"github.com/expotoworld/expotoworld/backend/catalog-service/internal/models"
+	"github.com/expotoworld/expotoworld/backend/catalog-service/internal/openapi"
+	"github.com/gin-gonic/gin"
+)
+
+// Shorthands for the endpoint table below
+var (
+	obj           = openapi.Object
+	messageBody   = obj("message", "")
+	miniAppParam  = openapi.Param{Name: "mini_app_type", Description: "RetailStore, UnmannedStore, ExhibitionSales or GroupBuying"}
+	limitParam    = openapi.Param{Name: "limit", Type: "integer"}
+	storeQRParams = []openapi.Param{{Name: "format", Description: "png (default), svg or json"}, {Name: "scale", Type: "integer", Description: "Pixels per module, 1-40 (default 8)"}}
+	fieldsParam   = openapi.Param{Name: "fields", Description: "Comma-separated list of fields to return"}
+	storeRequest  = struct {
+		models.Store
+		PartnerOrgID *string `json:"partner_org_id"`
+	}{}
+)
+
+// endpointDocs describes each handler for the OpenAPI document, keyed by handler name.
+// Routes are read from the router, so a handler missing here is still listed, just without schemas.
+var endpointDocs = map[string]openapi.Endpoint{
+	// Public catalog
+	"GetProducts": {Summary: "List products (public fields unless the caller is an Admin)",
+		Query: []openapi.Param{{Name: "store_type"}, miniAppParam, {Name: "featured", Type: "boolean"}, {Name: "store_id", Type: "integer"},
+			{Name: "sort", Description: "popular"}, {Name: "state", Description: "active, deleted or all (Admin only)"},
+			{Name: "ids", Description: "Comma-separated product ids (at most 100) to fetch in one call"},
+			{Name: "region", Description: "Region id or code (e.g. CH): only products sellable there, with region_pricing"}, fieldsParam},
+		Response: []models.PublicProduct{}},
+	"GetProduct":         {Summary: "Get a product by id or uuid", Query: []openapi.Param{fieldsParam}, Response: models.PublicProduct{}},
+	"GetProductVariants": {Summary: "List a product's variants", Response: obj("variants", []models.PublicProductVariant{})},
+	"GetPopularProducts": {Summary: "List the most viewed products", Query: []openapi.Param{miniAppParam, limitParam},
+		Response: obj("products", []models.ProductPopularity{})},
+	"GetNewArrivals": {Summary: "List recently added products", Query: []openapi.Param{miniAppParam, limitParam, {Name: "days", Type: "integer"}},
+		Response: obj("collection", "", "days", 0, "products", []models.ProductCollectionItem{})},
+	"GetBackInStock": {Summary: "List products that recently came back in stock", Query: []openapi.Param{miniAppParam, limitParam, {Name: "days", Type: "integer"}},
+		Response: obj("collection", "", "days", 0, "products", []models.ProductCollectionItem{})},
+	"GetProductSuggestions": {Summary: "Suggest products, SKUs and categories for a search prefix, then similar titles and SKUs (at most 10)",
+		Query:    []openapi.Param{{Name: "q"}, miniAppParam, limitParam},
+		Response: obj("query", "", "suggestions", []models.Suggestion{})},
+	"LookupProductByBarcode": {Summary: "Find the active product with a barcode (EAN-8, UPC-A, EAN-13 or GTIN-14)",
+		Query:    []openapi.Param{{Name: "barcode"}, {Name: "store_id", Type: "integer"}},
+		Response: models.PublicProduct{}},
+	"GetProductChanges": {Summary: "List products created, updated or deleted since a cursor, for delta sync (without since: the current cursor)",
+		Query:    []openapi.Param{{Name: "since", Description: "Cursor from next_cursor"}, {Name: "store_id", Type: "integer"}, limitParam},
+		Response: models.ProductChangeFeed{}},
+	"RecordProductViews": {Summary: "Record product view events"},
+	"ValidateShelfCode": {Summary: "Check that a shelf code is free in a store and fits its shelf layout",
+		Query:    []openapi.Param{{Name: "store_id", Type: "integer"}, {Name: "shelf_code"}, {Name: "product_id", Type: "integer"}, {Name: "variant_id", Type: "integer"}},
+		Response: obj("valid", false, "reason", "", "shelf_code", "")},
+	"GetCategories": {Summary: "List categories",
+		Query: []openapi.Param{{Name: "store_type"}, miniAppParam, {Name: "store_id", Type: "integer"},
+			{Name: "include_subcategories", Type: "boolean"}, {Name: "include_store_info", Type: "boolean"}, fieldsParam},
+		Response: []models.Category{}},
+	"GetSubcategories": {Summary: "List a category's subcategories", Response: []models.Subcategory{}},
+	"GetStores": {Summary: "List stores, optionally ordered by distance",
+		Query: []openapi.Param{{Name: "type"}, miniAppParam, {Name: "region_id", Type: "integer"}, {Name: "page", Type: "integer"}, limitParam,
+			{Name: "user_lat", Type: "number"}, {Name: "user_lng", Type: "number"}, {Name: "within_km", Type: "number"}, {Name: "order_by_distance", Type: "boolean"}},
+		Response: obj("stores", []models.Store{}, "total", 0, "page", 0, "limit", 0, "total_pages", 0)},
+	"GraphQL": {Summary: "Run a read-only GraphQL query over products, categories and stores (with images and subcategories)",
+		Request: graphql.Request{}, Response: graphql.Result{}},
+	"GetExperimentAssignments":  {Summary: "Get the caller's experiment variants", Query: []openapi.Param{{Name: "session_id"}}, Response: obj("assignments", []models.ExperimentAssignment{}, "bucketed", false)},
+	"RecordExperimentExposures": {Summary: "Record experiment exposures"},
+
+	// Signed-in users
+	"SubscribeStock":                  {Summary: "Get notified when a product is back in stock", Auth: openapi.AuthUser},
+	"UnsubscribeStock":                {Summary: "Stop back-in-stock notifications for a product", Auth: openapi.AuthUser, Status: http.StatusNoContent},
+	"GetMyStockSubscriptions":         {Summary: "List the caller's back-in-stock subscriptions", Auth: openapi.AuthUser, Response: obj("subscriptions", []models.StockSubscription{})},
+	"GetManufacturerProducts":         {Summary: "List the products of the caller's manufacturer organizations", Auth: openapi.AuthUser, Response: []models.Product{}},
+	"SubmitManufacturerProduct":       {Summary: "Submit a new product for admin review", Auth: openapi.AuthUser, Request: models.ProductSubmissionRequest{}, Response: models.ProductSubmission{}, Status: http.StatusCreated},
+	"SubmitManufacturerProductUpdate": {Summary: "Submit changes to one of your products for admin review", Auth: openapi.AuthUser, Request: models.ProductSubmissionRequest{}, Response: models.ProductSubmission{}, Status: http.StatusAccepted},
+	"GetManufacturerSubmissions": {Summary: "List your product submissions and their review status", Auth: openapi.AuthUser,
+		Query:    []openapi.Param{{Name: "status"}, limitParam, {Name: "before_id", Type: "integer", Description: "Cursor from next_before_id"}},
+		Response: obj("submissions", []models.ProductSubmission{})},
+
+	// Internal jobs
+	"UpdateCompetitorPrices": {Summary: "Ingest competitor prices from the price crawler", Auth: openapi.AuthMaintenance,
+		Response: obj("received", 0, "stored", 0, "skipped", 0)},
+
+	// Admin: products
+	"CreateProduct":  {Summary: "Create a product", Auth: openapi.AuthAdmin, Request: models.Product{}, Response: obj("product_id", 0), Status: http.StatusCreated},
+	"UpdateProduct":  {Summary: "Update a product (If-Unmodified-Since: 409 with the current product on a concurrent edit)", Auth: openapi.AuthAdmin, Request: models.Product{}, Response: obj("message", "", "product_id", 0)},
+	"DeleteProduct":  {Summary: "Soft-delete a product (hard=true moves it to the trash, purged with its images after the retention window)", Auth: openapi.AuthAdmin, Query: []openapi.Param{{Name: "hard", Type: "boolean"}}, Response: obj("message", "", "product_id", 0)},
+	"RestoreProduct": {Summary: "Restore a soft-deleted product", Auth: openapi.AuthAdmin, Response: obj("message", "", "product_id", 0)},
+	"CloneProduct": {Summary: "Copy a product into a new inactive draft with a new SKU", Auth: openapi.AuthAdmin, Request: models.CloneProductRequest{},
+		Response: obj("product_id", 0, "source_product_id", 0, "sku", "", "images_copied", 0, "images_failed", 0), Status: http.StatusCreated},
+	"AdjustProductStock":     {Summary: "Adjust a product's stock with a reason", Auth: openapi.AuthAdmin, Request: models.StockAdjustmentRequest{}, Response: models.StockMovement{}, Status: http.StatusCreated},
+	"GetProductStockHistory": {Summary: "List a product's stock movements", Auth: openapi.AuthAdmin, Response: obj("product_id", 0, "movements", []models.StockMovement{})},
+	"UploadProductImage":     {Summary: "Upload a product's main image (multipart)", Auth: openapi.AuthAdmin, Response: obj("image_url", ""), Status: http.StatusCreated},
+	"UploadProductImages":    {Summary: "Upload product gallery images (multipart)", Auth: openapi.AuthAdmin, Status: http.StatusCreated},
+	"PresignProductImageUpload": {Summary: "Get a presigned URL to upload a product image directly to storage", Auth: openapi.AuthAdmin,
+		Request: presignImageRequest{}},
+	"ConfirmProductImageUpload": {Summary: "Register an image uploaded with a presigned URL", Auth: openapi.AuthAdmin, Request: confirmImageRequest{}},
+	"GetProductImages":          {Summary: "List a product's images", Auth: openapi.AuthAdmin, Response: []models.ProductImage{}},
+	"ReorderProductImages":      {Summary: "Reorder a product's images", Auth: openapi.AuthAdmin, Response: messageBody},
+	"DeleteProductImage":        {Summary: "Delete a product image", Auth: openapi.AuthAdmin, Response: messageBody},
+	"SetPrimaryImage":           {Summary: "Make an image the product's primary image", Auth: openapi.AuthAdmin, Response: messageBody},
+	"CreateProductVariant":      {Summary: "Create a product variant", Auth: openapi.AuthAdmin, Request: variantRequest{}, Response: models.ProductVariant{}, Status: http.StatusCreated},
+	"UpdateProductVariant":      {Summary: "Update a product variant", Auth: openapi.AuthAdmin, Request: variantRequest{}, Response: models.ProductVariant{}},
+	"DeleteProductVariant":      {Summary: "Delete a product variant", Auth: openapi.AuthAdmin, Response: obj("message", "", "variant_id", 0)},
+	"GetRelatedProducts":        {Summary: "List a product's related products", Auth: openapi.AuthAdmin, Response: obj("related_products", []models.RelatedProduct{})},
+	"SetRelatedProducts":        {Summary: "Replace a product's related products", Auth: openapi.AuthAdmin, Response: obj("related_products", []models.RelatedProduct{})},
+	"GetProductFoodLabel":       {Summary: "Get a product's nutrition, allergen and origin label data", Auth: openapi.AuthAdmin, Response: models.ProductFoodLabel{}},
+	"SetProductFoodLabel":       {Summary: "Replace a product's nutrition, allergen and origin label data", Auth: openapi.AuthAdmin, Request: models.SetProductFoodLabelRequest{}, Response: models.ProductFoodLabel{}},
+	"DeleteProductFoodLabel":    {Summary: "Delete a product's food label data", Auth: openapi.AuthAdmin, Response: messageBody},
+	"GetProductRegionPrices": {Summary: "List a product's prices per region", Auth: openapi.AuthAdmin,
+		Response: obj("base_currency", "", "region_prices", []models.ProductRegionPrice{})},
+	"SetProductRegionPrices": {Summary: "Replace a product's prices per region (in each region's currency)", Auth: openapi.AuthAdmin,
+		Request: setRegionPricesRequest{}, Response: obj("base_currency", "", "region_prices", []models.ProductRegionPrice{})},
+	"GetProductTranslations":    {Summary: "List a product's translations", Auth: openapi.AuthAdmin, Response: obj("translations", []models.ProductTranslation{}, "locales", []string{})},
+	"DraftProductTranslations":  {Summary: "Machine-translate missing locales as drafts", Auth: openapi.AuthAdmin},
+	"ConfirmProductTranslation": {Summary: "Edit and confirm a product translation", Auth: openapi.AuthAdmin, Response: models.ProductTranslation{}},
+	"SetProductSourcing":        {Summary: "Set a product's manufacturer sourcing", Auth: openapi.AuthAdmin, Response: obj("status", "")},
+	"GetProductSourcing":        {Summary: "Get a product's manufacturer sourcing", Auth: openapi.AuthAdmin, Response: obj("mappings", []models.ProductSourcing{})},
+	"SetProductLogistics":       {Summary: "Set a product's logistics partners", Auth: openapi.AuthAdmin, Response: obj("status", "")},
+	"GetProductLogistics":       {Summary: "Get a product's logistics partners", Auth: openapi.AuthAdmin, Response: obj("mappings", []models.ProductLogistics{})},
+
+	// Admin: categories and subcategories
+	"CreateCategory":         {Summary: "Create a category", Auth: openapi.AuthAdmin, Request: models.Category{}, Response: models.Category{}, Status: http.StatusCreated},
+	"ReorderCategories":      {Summary: "Set the display order of categories", Auth: openapi.AuthAdmin},
+	"UpdateCategory":         {Summary: "Update a category (If-Unmodified-Since: 409 with the current category on a concurrent edit)", Auth: openapi.AuthAdmin, Request: models.Category{}, Response: models.Category{}},
+	"DeleteCategory":         {Summary: "Soft-delete a category (hard=true moves it to the trash, purged with its images after the retention window)", Auth: openapi.AuthAdmin, Query: []openapi.Param{{Name: "hard", Type: "boolean"}}, Response: obj("message", "", "category_id", 0)},
+	"CreateSubcategory":      {Summary: "Create a subcategory", Auth: openapi.AuthAdmin, Request: models.Subcategory{}, Response: models.Subcategory{}, Status: http.StatusCreated},
+	"ReorderSubcategories":   {Summary: "Set the display order of a category's subcategories", Auth: openapi.AuthAdmin},
+	"UpdateSubcategory":      {Summary: "Update a subcategory", Auth: openapi.AuthAdmin, Request: models.Subcategory{}, Response: models.Subcategory{}},
+	"DeleteSubcategory":      {Summary: "Delete a subcategory and its image", Auth: openapi.AuthAdmin, Response: messageBody},
+	"UploadSubcategoryImage": {Summary: "Upload a subcategory image (multipart)", Auth: openapi.AuthAdmin},
+
+	// Admin: stores
+	"CreateStore":      {Summary: "Create a store", Auth: openapi.AuthAdmin, Request: storeRequest, Response: models.Store{}, Status: http.StatusCreated},
+	"UpdateStore":      {Summary: "Update a store (If-Unmodified-Since: 409 with the current store on a concurrent edit)", Auth: openapi.AuthAdmin, Request: storeRequest, Response: models.Store{}},
+	"DeleteStore":      {Summary: "Soft-delete a store (hard=true moves it to the trash, purged with its images after the retention window)", Auth: openapi.AuthAdmin, Query: []openapi.Param{{Name: "hard", Type: "boolean"}}, Response: obj("message", "", "store_id", 0)},
+	"UploadStoreImage": {Summary: "Upload a store image (multipart)", Auth: openapi.AuthAdmin},
+	"GetStorePartners": {Summary: "List a store's partner organizations", Auth: openapi.AuthAdmin, Response: obj("partners", []models.StorePartner{})},
+	"GetStorePartnersBatch": {Summary: "List partner organizations of several stores", Auth: openapi.AuthAdmin,
+		Query: []openapi.Param{{Name: "store_ids", Description: "Comma-separated store ids"}}},
+	"ExportRelationships": {Summary: "Export store-partners, product-sourcing or product-logistics mappings as CSV with ids and natural keys",
+		Auth: openapi.AuthAdmin},
+	"ImportRelationships": {Summary: "Import a mapping CSV (multipart file or raw body); all rows must resolve or nothing is saved",
+		Auth: openapi.AuthAdmin, Query: []openapi.Param{
+			{Name: "mode", Description: "replace (default): the file's stores or products keep only its mappings; merge: only add"},
+			{Name: "match", Description: "key (default): by store name or SKU, organization name and region code; id: by ids"},
+			{Name: "dry_run", Type: "boolean", Description: "Report the changes without saving"}},
+		Response: models.RelationshipImportResult{}},
+	"SetStorePartners": {Summary: "Replace a store's partner organizations", Auth: openapi.AuthAdmin, Response: obj("status", "")},
+
+	// Admin: organizations and price lists
+	"GetOrganizations":     {Summary: "List organizations", Auth: openapi.AuthAdmin, Query: []openapi.Param{{Name: "org_type"}}, Response: obj("organizations", []models.Organization{})},
+	"CreateOrganization":   {Summary: "Create an organization", Auth: openapi.AuthAdmin, Request: models.Organization{}, Response: obj("org_id", ""), Status: http.StatusCreated},
+	"UpdateOrganization":   {Summary: "Update an organization", Auth: openapi.AuthAdmin, Request: models.Organization{}, Response: messageBody},
+	"DeleteOrganization":   {Summary: "Delete an organization", Auth: openapi.AuthAdmin, Response: messageBody},
+	"GetOrganizationUsers": {Summary: "List an organization's users", Auth: openapi.AuthAdmin, Response: obj("users", []models.OrganizationUser{})},
+	"SetOrganizationUsers": {Summary: "Replace an organization's users", Auth: openapi.AuthAdmin, Response: messageBody},
+	"SetOrganizationStores": {Summary: "Replace the stores a Partner organization manages", Auth: openapi.AuthAdmin,
+		Request: obj("store_ids", []int{}), Response: obj("org_id", "", "store_ids", []int{})},
+	"GetOrganizationHierarchy": {Summary: "An organization with its ancestors, descendants and link counts (members and admins)",
+		Auth: openapi.AuthUser, Response: models.OrgHierarchy{}},
+	"GetOrganizationStores": {Summary: "Stores managed by an organization or the partners below it (members and admins)", Auth: openapi.AuthUser,
+		Query: []openapi.Param{{Name: "include_inactive", Type: "boolean"}}, Response: obj("stores", []models.OrgStore{})},
+	"GetOrganizationProducts": {Summary: "Products owned by an organization or the manufacturers below it (members and admins)", Auth: openapi.AuthUser,
+		Query:    []openapi.Param{limitParam, {Name: "after_id", Type: "integer", Description: "Cursor from next_after_id"}},
+		Response: obj("products", []models.OrgProduct{}, "next_after_id", 0)},
+	"GetOrganizationPriceLists":   {Summary: "List an organization's price lists", Auth: openapi.AuthAdmin, Response: obj("price_lists", []models.PriceList{})},
+	"CreateOrganizationPriceList": {Summary: "Create a price list for an organization", Auth: openapi.AuthAdmin, Request: priceListRequest{}, Response: models.PriceList{}, Status: http.StatusCreated},
+	"ResolveOrganizationPrices": {Summary: "Resolve the prices an organization pays", Auth: openapi.AuthAdmin,
+		Query:    []openapi.Param{{Name: "product_ids", Description: "Comma-separated product ids"}, {Name: "at", Description: "RFC 3339 time, default now"}},
+		Response: obj("org_id", "", "prices", []models.ResolvedPrice{})},
+	"GetPriceList":      {Summary: "Get a price list with its items", Auth: openapi.AuthAdmin, Response: obj("price_list", models.PriceList{}, "items", []models.PriceListItem{})},
+	"UpdatePriceList":   {Summary: "Update a price list", Auth: openapi.AuthAdmin, Request: priceListRequest{}, Response: models.PriceList{}},
+	"DeletePriceList":   {Summary: "Delete a price list", Auth: openapi.AuthAdmin, Response: messageBody},
+	"SetPriceListItems": {Summary: "Replace a price list's items", Auth: openapi.AuthAdmin, Response: obj("message", "", "count", 0)},
+
+	// Admin: regions, commission and tax
+	"ListRegions":  {Summary: "List regions", Auth: openapi.AuthAdmin, Response: obj("regions", []models.Region{})},
+	"CreateRegion": {Summary: "Create a region", Auth: openapi.AuthAdmin, Request: models.Region{}, Response: models.Region{}, Status: http.StatusCreated},
+	"UpdateRegion": {Summary: "Update a region", Auth: openapi.AuthAdmin, Request: models.Region{}, Response: models.Region{}},
+	"DeleteRegion": {Summary: "Delete a region", Auth: openapi.AuthAdmin, Status: http.StatusNoContent},
+	"ListCommissionRates": {Summary: "List commission rates", Auth: openapi.AuthAdmin,
+		Query:    []openapi.Param{{Name: "category_id", Type: "integer"}, {Name: "manufacturer_org_id"}, {Name: "current", Type: "boolean"}},
+		Response: obj("commission_rates", []models.CommissionRate{})},
+	"CreateCommissionRate": {Summary: "Create a commission rate", Auth: openapi.AuthAdmin, Request: commissionRateRequest{}, Response: models.CommissionRate{}, Status: http.StatusCreated},
+	"ResolveCommissionRate": {Summary: "Resolve the commission rate for a product", Auth: openapi.AuthAdmin,
+		Query:    []openapi.Param{{Name: "product_id", Type: "integer"}, {Name: "manufacturer_org_id"}, {Name: "at"}},
+		Response: models.ResolvedCommissionRate{}},
+	"DeleteCommissionRate": {Summary: "Delete a commission rate", Auth: openapi.AuthAdmin, Response: messageBody},
+	"ListRegionTaxRates": {Summary: "List tax rates by region and tax class", Auth: openapi.AuthAdmin, Query: []openapi.Param{{Name: "region_id", Type: "integer"}},
+		Response: obj("tax_classes", []models.TaxClass{}, "tax_rates", []models.RegionTaxRate{})},
+	"ResolveTaxRate": {Summary: "Resolve the tax rate of a product in a region", Auth: openapi.AuthAdmin,
+		Query: []openapi.Param{{Name: "product_id", Type: "integer"}, {Name: "region_id", Type: "integer"}}, Response: models.ResolvedTaxRate{}},
+	"ListUnclassifiedProducts": {Summary: "List products without a tax class", Auth: openapi.AuthAdmin, Response: obj("products", []models.UnclassifiedProduct{})},
+	"SetRegionTaxRate":         {Summary: "Set the tax rate of a tax class in a region", Auth: openapi.AuthAdmin, Request: taxRateRequest{}, Response: models.RegionTaxRate{}},
+	"DeleteRegionTaxRate":      {Summary: "Delete a regional tax rate", Auth: openapi.AuthAdmin, Response: messageBody},
+
+	// Admin: experiments and competitor prices
+	"ListExperiments":  {Summary: "List experiments", Auth: openapi.AuthAdmin, Query: []openapi.Param{{Name: "status"}}, Response: obj("experiments", []models.Experiment{})},
+	"UpsertExperiment": {Summary: "Create or update an experiment", Auth: openapi.AuthAdmin, Response: models.Experiment{}},
+	"GetExperimentResults": {Summary: "Get per-variant experiment results", Auth: openapi.AuthAdmin, Query: []openapi.Param{{Name: "days", Type: "integer"}},
+		Response: obj("experiment", models.Experiment{}, "days", 0, "results", []models.ExperimentVariantResult{})},
+	"GetProductCompetitorPrices":   {Summary: "List competitor prices of a product", Auth: openapi.AuthAdmin, Response: obj("product_id", 0, "competitor_prices", []models.CompetitorPrice{})},
+	"SetProductCompetitorPrice":    {Summary: "Set a competitor price manually", Auth: openapi.AuthAdmin, Response: messageBody},
+	"DeleteProductCompetitorPrice": {Summary: "Delete a competitor price", Auth: openapi.AuthAdmin, Response: messageBody},
+	"GetCompetitorPriceAlerts": {Summary: "List products priced above competitors", Auth: openapi.AuthAdmin,
+		Query:    []openapi.Param{{Name: "margin_pct", Type: "number"}, {Name: "max_age_days", Type: "integer"}, limitParam},
+		Response: obj("margin_pct", 0.0, "max_age_days", 0, "count", 0, "alerts", []models.CompetitorPriceAlert{})},
+
+	// Admin: changesets and maintenance
+	"ListChangesets":      {Summary: "List changesets", Auth: openapi.AuthAdmin, Query: []openapi.Param{{Name: "status"}}, Response: obj("changesets", []models.Changeset{})},
+	"CreateChangeset":     {Summary: "Create a changeset", Auth: openapi.AuthAdmin, Request: changesetRequest{}, Response: models.Changeset{}, Status: http.StatusCreated},
+	"GetChangeset":        {Summary: "Get a changeset with its staged items", Auth: openapi.AuthAdmin, Response: obj("changeset", models.Changeset{}, "items", []models.ChangesetItem{})},
+	"StageChangesetItems": {Summary: "Stage product changes in a changeset", Auth: openapi.AuthAdmin, Request: changesetItemsRequest{}},
+	"RemoveChangesetItem": {Summary: "Remove a staged product from a changeset", Auth: openapi.AuthAdmin, Response: messageBody},
+	"PreviewChangeset":    {Summary: "Preview a changeset against current values", Auth: openapi.AuthAdmin, Response: obj("changeset", models.Changeset{}, "diff", []models.ChangesetDiff{})},
+	"ScheduleChangeset":   {Summary: "Schedule a changeset to apply later", Auth: openapi.AuthAdmin, Request: changesetScheduleRequest{}, Response: models.Changeset{}},
+	"ApplyChangeset":      {Summary: "Apply a changeset now", Auth: openapi.AuthAdmin, Response: models.Changeset{}},
+	"DiscardChangeset":    {Summary: "Discard a changeset", Auth: openapi.AuthAdmin, Response: models.Changeset{}},
+	"GetAuditLog": {Summary: "Search the admin audit log", Auth: openapi.AuthAdmin,
+		Query: []openapi.Param{{Name: "entity_type"}, {Name: "entity_id"}, {Name: "actor_user_id"}, {Name: "action"}, {Name: "field"},
+			limitParam, {Name: "before_id", Type: "integer", Description: "Cursor from next_before_id"}},
+		Response: obj("entries", []models.AuditEntry{})},
+	"GetActivityFeed": {Summary: "List recent admin activity and whether each action can still be undone", Auth: openapi.AuthAdmin,
+		Query: []openapi.Param{{Name: "entity_type"}, {Name: "entity_id"}, {Name: "actor_user_id"},
+			limitParam, {Name: "before_id", Type: "integer", Description: "Cursor from next_before_id"}},
+		Response: obj("activity", []models.ActivityItem{}, "undo_window_minutes", 0)},
+	"UndoActivity": {Summary: "Undo a recent product/category delete or category reassignment", Auth: openapi.AuthAdmin,
+		Response: obj("message", "", "undo", models.ActivityItem{}, "audit_id", 0)},
+	"ListTrash": {Summary: "List hard-deleted products, categories and stores awaiting purge", Auth: openapi.AuthAdmin,
+		Response: obj("items", []models.TrashedItem{}, "retention_days", 0)},
+	"RestoreTrashedItem": {Summary: "Restore a trashed product, category or store (kind: product, category, store); it comes back inactive", Auth: openapi.AuthAdmin,
+		Response: obj("message", "", "kind", "", "id", 0)},
+	"ListWebhookEndpoints":  {Summary: "List webhook endpoints and the subscribable events", Auth: openapi.AuthAdmin, Response: obj("webhooks", []models.WebhookEndpoint{}, "events", []string{})},
+	"CreateWebhookEndpoint": {Summary: "Register a webhook endpoint (the signing secret is only returned here)", Auth: openapi.AuthAdmin, Request: models.WebhookEndpointRequest{}, Response: models.WebhookEndpoint{}, Status: http.StatusCreated},
+	"GetWebhookEndpoint":    {Summary: "Get a webhook endpoint", Auth: openapi.AuthAdmin, Response: models.WebhookEndpoint{}},
+	"UpdateWebhookEndpoint": {Summary: "Update a webhook endpoint, optionally rotating its secret", Auth: openapi.AuthAdmin, Request: models.WebhookEndpointRequest{}, Response: models.WebhookEndpoint{}},
+	"DeleteWebhookEndpoint": {Summary: "Delete a webhook endpoint and its deliveries", Auth: openapi.AuthAdmin, Response: messageBody},
+	"PingWebhookEndpoint":   {Summary: "Send a test ping event to a webhook endpoint", Auth: openapi.AuthAdmin, Response: models.WebhookDelivery{}, Status: http.StatusAccepted},
+	"GetWebhookDeliveries": {Summary: "List a webhook endpoint's deliveries (status=failed is the dead-letter queue)", Auth: openapi.AuthAdmin,
+		Query:    []openapi.Param{{Name: "status"}, limitParam, {Name: "before_id", Type: "integer", Description: "Cursor from next_before_id"}},
+		Response: obj("deliveries", []models.WebhookDelivery{})},
+	"RedeliverWebhook": {Summary: "Requeue a webhook delivery with a fresh attempt budget", Auth: openapi.AuthAdmin, Response: models.WebhookDelivery{}, Status: http.StatusAccepted},
+	"ListCatalogExports": {Summary: "List catalog snapshot exports, newest first", Auth: openapi.AuthAdmin,
+		Query:    []openapi.Param{limitParam, {Name: "before_id", Type: "integer", Description: "Cursor from next_before_id"}},
+		Response: obj("exports", []models.CatalogExport{})},
+	"TriggerCatalogExport": {Summary: "Start a catalog snapshot export to S3 now", Auth: openapi.AuthAdmin, Response: models.CatalogExport{}, Status: http.StatusAccepted},
+	"GetCatalogExport":     {Summary: "Get a catalog snapshot export and its datasets", Auth: openapi.AuthAdmin, Response: models.CatalogExport{}},
+	"GetLowStockProducts": {Summary: "List stock-tracked products below their low-stock threshold", Auth: openapi.AuthAdmin,
+		Query: []openapi.Param{limitParam}, Response: obj("products", []models.LowStockProduct{}, "default_threshold", 0)},
+	"SetProductLowStockThreshold": {Summary: "Set or clear a product's low-stock threshold", Auth: openapi.AuthAdmin, Request: models.LowStockThresholdRequest{}, Response: obj("product_id", 0, "threshold", 0)},
+	"SetStoreLowStockThreshold":   {Summary: "Set or clear the default low-stock threshold of a store's products", Auth: openapi.AuthAdmin, Request: models.LowStockThresholdRequest{}, Response: obj("store_id", 0, "threshold", 0)},
+	"SetStoreShelfLayout": {Summary: "Set a store's shelf layout (slots are <unit>-<level>-<position>)", Auth: openapi.AuthAdmin,
+		Request: models.ShelfLayout{}, Response: obj("store_id", 0, "layout", models.ShelfLayout{}, "capacity", 0)},
+	"DeleteStoreShelfLayout": {Summary: "Remove a store's shelf layout", Auth: openapi.AuthAdmin, Status: http.StatusNoContent},
+	"GetStoreShelfMap": {Summary: "Shelf occupancy of a store: slots with their products, gaps, conflicts and codes outside the layout",
+		Auth: openapi.AuthAdmin, Response: models.ShelfMap{}},
+	"CreateStoreQR": {Summary: "Provision a new signed entry QR code for a store, revoking the previous one (PNG by default)",
+		Auth: openapi.AuthAdmin, Query: storeQRParams, Response: models.StoreQR{}, Status: http.StatusCreated},
+	"GetStoreQR": {Summary: "Get a store's current entry QR code (PNG by default)", Auth: openapi.AuthAdmin,
+		Query: storeQRParams, Response: models.StoreQR{}},
+	"ResolveStoreQR": {Summary: "Validate a scanned store QR code; 400 INVALID_QR when forged, 410 QR_REVOKED when replaced",
+		Query:    []openapi.Param{{Name: "payload", Description: "Text of the scanned code"}},
+		Response: models.StoreQRResolution{}},
+	"ListProductSubmissions": {Summary: "List manufacturer product submissions (pending_review by default)", Auth: openapi.AuthAdmin,
+		Query:    []openapi.Param{{Name: "status"}, limitParam, {Name: "before_id", Type: "integer", Description: "Cursor from next_before_id"}},
+		Response: obj("submissions", []models.ProductSubmission{})},
+	"ApproveProductSubmission": {Summary: "Approve a product submission, creating or updating the product", Auth: openapi.AuthAdmin, Request: models.ReviewSubmissionRequest{}, Response: models.ProductSubmission{}},
+	"RejectProductSubmission":  {Summary: "Reject a product submission with a comment", Auth: openapi.AuthAdmin, Request: models.ReviewSubmissionRequest{}, Response: models.ProductSubmission{}},
+	"AdminCleanupS3":           {Summary: "Delete orphaned images from storage", Auth: openapi.AuthAdmin, Response: obj("message", "", "deleted", 0)},
+}
+
+// OpenAPIDocument builds the API description from the routes registered on the router
+func OpenAPIDocument(routes gin.RoutesInfo) *openapi.Document {
+	var rs []openapi.Route
+	for _, r := range routes {
+		if !strings.HasPrefix(r.Path, "/api/v1/") {
+			continue
+		}
+		rs = append(rs, openapi.Route{Method: r.Method, Path: r.Path, Handler: r.Handler})
+	}
+	return openapi.Build(openapi.Info{
+		Title:       "Catalog Service API",
+		Version:     "1.0.0",
+		Description: "Products, categories, stores and their admin management. Errors are returned as {\"code\", \"message\", \"details\", \"request_id\"} (plus \"error\", a copy of message); validation failures use code VALIDATION_FAILED with details.fields.",
+	}, rs, endpointDocs)
+}
+
+// ServeOpenAPI handles GET /api/v1/openapi.json
+func ServeOpenAPI(doc *openapi.Document) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Header("Cache-Control", "public, max-age=300")
+		c.JSON(http.StatusOK, doc)
+	}
+}
+
+// swaggerUIPage loads Swagger UI from a CDN and points it at the spec URL
+const swaggerUIPage = `<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Catalog Service API</title>
+<link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@5/swagger-ui.css">
+</head>
+<body>
+<div id="swagger-ui"></div>
+<script src="https://unpkg.com/swagger-ui-dist@5/swagger-ui-bundle.js"></script>
+<script>
+window.ui = SwaggerUIBundle({ url: %q, dom_id: "#swagger-ui", persistAuthorization: true });
+</script>
+</body>
+</html>`
+
+// SwaggerUI serves the interactive documentation for the spec at specURL
+func SwaggerUI(specURL string) gin.HandlerFunc {
+	page := fmt.Sprintf(swaggerUIPage, specURL)
+	return func(c *gin.Context) {
+		c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(page))
+	}
+}
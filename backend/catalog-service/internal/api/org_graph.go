@@ -0,0 +1,141 @@
+package api
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/expotoworld/expotoworld/backend/catalog-service/internal/db"
+	"github.com/gin-gonic/gin"
+)
+
+// OrgMemberMiddleware lets unrestricted admins and members of the organization in :id, or of an
+// organization above it, read its graph (use after AuthMiddleware). A Brand's members thereby see
+// all its partners and manufacturers.
+func (h *Handler) OrgMemberMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if IsAdmin(c) && getAdminScope(c) == nil {
+			c.Next()
+			return
+		}
+		member, err := h.db.OrgWithinAny(c.Request.Context(), c.Param("id"), memberOrgIDs(c, ""))
+		if err != nil {
+			log.Printf("Failed to check membership of organization %s: %v", c.Param("id"), err)
+			writeError(c, http.StatusInternalServerError, "Failed to check organization membership")
+			c.Abort()
+			return
+		}
+		if !member {
+			writeError(c, http.StatusForbidden, "You are not a member of this organization")
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// GetOrganizationHierarchy handles GET /organizations/:id/hierarchy: the organization, its ancestors
+// and descendants, each with its link type and link counts
+func (h *Handler) GetOrganizationHierarchy(c *gin.Context) {
+	hierarchy, err := h.db.GetOrgHierarchy(c.Request.Context(), c.Param("id"))
+	if errors.Is(err, db.ErrOrganizationNotFound) {
+		writeError(c, http.StatusNotFound, "Organization not found")
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to load hierarchy of organization %s: %v", c.Param("id"), err)
+		writeError(c, http.StatusInternalServerError, "Failed to fetch organization hierarchy")
+		return
+	}
+	c.JSON(http.StatusOK, hierarchy)
+}
+
+// GetOrganizationStores handles GET /organizations/:id/stores?include_inactive=: every store
+// reachable from the organization, i.e. managed by it or by a partner below it
+func (h *Handler) GetOrganizationStores(c *gin.Context) {
+	stores, err := h.db.ListOrgStores(c.Request.Context(), c.Param("id"), c.Query("include_inactive") == "true")
+	if errors.Is(err, db.ErrOrganizationNotFound) {
+		writeError(c, http.StatusNotFound, "Organization not found")
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to list stores of organization %s: %v", c.Param("id"), err)
+		writeError(c, http.StatusInternalServerError, "Failed to fetch organization stores")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"stores": stores})
+}
+
+// GetOrganizationProducts handles GET /organizations/:id/products?limit=&after_id=: the products
+// owned by the organization or a manufacturer below it, by product id
+func (h *Handler) GetOrganizationProducts(c *gin.Context) {
+	limit := 100
+	if v := c.Query("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 || n > 500 {
+			writeError(c, http.StatusBadRequest, "limit must be between 1 and 500")
+			return
+		}
+		limit = n
+	}
+	afterID := 0
+	if v := c.Query("after_id"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			writeError(c, http.StatusBadRequest, "Invalid after_id")
+			return
+		}
+		afterID = n
+	}
+
+	products, err := h.db.ListOrgProducts(c.Request.Context(), c.Param("id"), afterID, limit)
+	if errors.Is(err, db.ErrOrganizationNotFound) {
+		writeError(c, http.StatusNotFound, "Organization not found")
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to list products of organization %s: %v", c.Param("id"), err)
+		writeError(c, http.StatusInternalServerError, "Failed to fetch organization products")
+		return
+	}
+	resp := gin.H{"products": products}
+	if len(products) == limit {
+		resp["next_after_id"] = products[len(products)-1].ProductID
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// SetOrganizationStores handles PUT /organizations/:id/stores: replaces the stores a Partner manages
+// Body: { "store_ids": [7, 10] }
+func (h *Handler) SetOrganizationStores(c *gin.Context) {
+	var body struct {
+		StoreIDs []int `json:"store_ids" binding:"required"`
+	}
+	if !bindJSON(c, &body) {
+		return
+	}
+	seen := map[int]bool{}
+	storeIDs := make([]int, 0, len(body.StoreIDs))
+	for _, id := range body.StoreIDs {
+		if !seen[id] {
+			seen[id] = true
+			storeIDs = append(storeIDs, id)
+		}
+	}
+
+	err := h.db.SetPartnerStores(c.Request.Context(), c.Param("id"), storeIDs)
+	switch {
+	case err == nil:
+		c.JSON(http.StatusOK, gin.H{"org_id": c.Param("id"), "store_ids": storeIDs})
+	case errors.Is(err, db.ErrOrganizationNotFound):
+		writeError(c, http.StatusNotFound, "Organization not found")
+	case errors.Is(err, db.ErrStoreNotFound):
+		writeError(c, http.StatusBadRequest, "One or more stores not found")
+	case errors.Is(err, db.ErrOrgLinkType):
+		writeError(c, http.StatusConflict, "Only Partner organizations manage stores")
+	default:
+		log.Printf("Failed to set stores of organization %s: %v", c.Param("id"), err)
+		writeError(c, http.StatusInternalServerError, "Failed to save organization stores")
+	}
+}
@@ -1,9 +1,11 @@
 package api
 
 import (
+	"errors"
 	"net/http"
 	"strings"
 
+	"github.com/expotoworld/expotoworld/backend/catalog-service/internal/db"
 	"github.com/expotoworld/expotoworld/backend/catalog-service/internal/models"
 	"github.com/gin-gonic/gin"
 )
@@ -21,52 +23,67 @@ func (h *Handler) GetOrganizations(c *gin.Context) {
 
 	orgs, err := h.db.GetOrganizations(ctx, orgTypePtr)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch organizations"})
+		writeError(c, http.StatusInternalServerError, "Failed to fetch organizations")
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{"organizations": orgs})
 }
 
+// checkOrganizationParent applies the parent rules: Manufacturer and 3PL organizations need a
+// parent, Brands cannot have one, and Partners may.
+func checkOrganizationParent(o *models.Organization) *models.FieldError {
+	hasParent := o.ParentOrgID != nil && *o.ParentOrgID != ""
+	switch o.OrgType {
+	case models.OrgTypeManufacturer, models.OrgType3PL:
+		if !hasParent {
+			return &models.FieldError{Field: "parent_org_id", Code: "required", Message: "Parent organization is required for Manufacturer and 3PL"}
+		}
+	case models.OrgTypeBrand:
+		if hasParent {
+			return &models.FieldError{Field: "parent_org_id", Code: "not_allowed", Message: "Brand organizations cannot have a parent"}
+		}
+	}
+	return nil
+}
+
+// writeOrganizationGraphError writes the response for hierarchy and link violations and reports
+// whether err was one
+func writeOrganizationGraphError(c *gin.Context, err error) bool {
+	switch {
+	case errors.Is(err, db.ErrOrgParentType):
+		writeFieldErrors(c, models.FieldError{Field: "parent_org_id", Code: "invalid_type",
+			Message: "Parent must be a Brand or an organization of the same type"})
+	case errors.Is(err, db.ErrOrgParentCycle):
+		writeFieldErrors(c, models.FieldError{Field: "parent_org_id", Code: "cycle", Message: "Organization cannot be its own ancestor"})
+	case errors.Is(err, db.ErrOrgLinkType):
+		writeError(c, http.StatusConflict, "Only Partners manage stores and only Manufacturers own products")
+	default:
+		return false
+	}
+	return true
+}
+
 // CreateOrganization handles POST /organizations
 func (h *Handler) CreateOrganization(c *gin.Context) {
 	ctx := c.Request.Context()
 	var payload models.Organization
-	if err := c.ShouldBindJSON(&payload); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+	if !bindJSON(c, &payload) {
 		return
 	}
-	// Basic validation
-	if payload.Name == "" || payload.OrgType == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "org_type and name are required"})
+	if fe := checkOrganizationParent(&payload); fe != nil {
+		writeFieldErrors(c, *fe)
 		return
 	}
-	// Parent rules
-	switch payload.OrgType {
-	case models.OrgTypeManufacturer, models.OrgType3PL:
-		if payload.ParentOrgID == nil || *payload.ParentOrgID == "" {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Parent organization is required for Manufacturer and 3PL"})
-			return
-		}
-	case models.OrgTypeBrand:
-		if payload.ParentOrgID != nil && *payload.ParentOrgID != "" {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Brand organizations cannot have a parent"})
-			return
-		}
-		// Partner: optional parent
-	}
 	id, err := h.db.CreateOrganization(ctx, payload)
 	if err != nil {
-		// Surface meaningful validation errors
 		errStr := err.Error()
-		if strings.Contains(errStr, "organizations_parent_must_be_brand") || strings.Contains(errStr, "CHECK (is_brand_org") {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Parent organization must be of type 'Brand'"})
-			return
-		}
-		if strings.Contains(errStr, "organizations_parent_org_id_fkey") || strings.Contains(errStr, "foreign key constraint") {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid parent organization"})
-			return
+		switch {
+		case writeOrganizationGraphError(c, err):
+		case strings.Contains(errStr, "organizations_parent_org_id_fkey") || strings.Contains(errStr, "foreign key constraint"):
+			writeError(c, http.StatusBadRequest, "Invalid parent organization")
+		default:
+			writeError(c, http.StatusInternalServerError, "Failed to create organization")
 		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create organization"})
 		return
 	}
 	c.JSON(http.StatusCreated, gin.H{"org_id": id})
@@ -77,39 +94,22 @@ func (h *Handler) UpdateOrganization(c *gin.Context) {
 	ctx := c.Request.Context()
 	id := c.Param("id")
 	var payload models.Organization
-	if err := c.ShouldBindJSON(&payload); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+	if !bindJSON(c, &payload) {
 		return
 	}
-	if payload.Name == "" || payload.OrgType == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "org_type and name are required"})
+	if fe := checkOrganizationParent(&payload); fe != nil {
+		writeFieldErrors(c, *fe)
 		return
 	}
-	// Parent rules
-	switch payload.OrgType {
-	case models.OrgTypeManufacturer, models.OrgType3PL:
-		if payload.ParentOrgID == nil || *payload.ParentOrgID == "" {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Parent organization is required for Manufacturer and 3PL"})
-			return
-		}
-	case models.OrgTypeBrand:
-		if payload.ParentOrgID != nil && *payload.ParentOrgID != "" {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Brand organizations cannot have a parent"})
-			return
-		}
-	}
 	if err := h.db.UpdateOrganization(ctx, id, payload); err != nil {
-		// Surface meaningful validation errors
 		errStr := err.Error()
-		if strings.Contains(errStr, "organizations_parent_must_be_brand") || strings.Contains(errStr, "CHECK (is_brand_org") {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Parent organization must be of type 'Brand'"})
-			return
-		}
-		if strings.Contains(errStr, "organizations_parent_org_id_fkey") || strings.Contains(errStr, "foreign key constraint") {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid parent organization"})
-			return
+		switch {
+		case writeOrganizationGraphError(c, err):
+		case strings.Contains(errStr, "organizations_parent_org_id_fkey") || strings.Contains(errStr, "foreign key constraint"):
+			writeError(c, http.StatusBadRequest, "Invalid parent organization")
+		default:
+			writeError(c, http.StatusInternalServerError, "Failed to update organization")
 		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update organization"})
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{"message": "Organization updated"})
@@ -120,7 +120,7 @@ func (h *Handler) DeleteOrganization(c *gin.Context) {
 	ctx := c.Request.Context()
 	id := c.Param("id")
 	if err := h.db.DeleteOrganization(ctx, id); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete organization"})
+		writeError(c, http.StatusInternalServerError, "Failed to delete organization")
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{"message": "Organization deleted"})
@@ -132,7 +132,7 @@ func (h *Handler) GetOrganizationUsers(c *gin.Context) {
 	id := c.Param("id")
 	users, err := h.db.GetOrganizationUsers(ctx, id)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch organization users"})
+		writeError(c, http.StatusInternalServerError, "Failed to fetch organization users")
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{"users": users})
@@ -146,18 +146,17 @@ func (h *Handler) SetOrganizationUsers(c *gin.Context) {
 	var body struct {
 		Assignments []models.OrganizationUserAssignment `json:"assignments" binding:"required"`
 	}
-	if err := c.ShouldBindJSON(&body); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+	if !bindJSON(c, &body) {
 		return
 	}
 	if err := h.db.SetOrganizationUsers(ctx, id, body.Assignments); err != nil {
 		// Map validation errors to 400
 		errStr := err.Error()
 		if strings.Contains(strings.ToLower(errStr), "brand") || strings.Contains(strings.ToLower(errStr), "role") || strings.Contains(strings.ToLower(errStr), "not found") {
-			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			writeError(c, http.StatusBadRequest, err.Error())
 			return
 		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to set organization users"})
+		writeError(c, http.StatusInternalServerError, "Failed to set organization users")
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{"message": "Organization users updated"})
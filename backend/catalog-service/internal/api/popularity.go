@@ -0,0 +1,204 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/expotoworld/expotoworld/backend/catalog-service/internal/logging"
+	"github.com/expotoworld/expotoworld/backend/catalog-service/internal/models"
+	"github.com/gin-gonic/gin"
+)
+
+// viewRateLimiter is a fixed-window, in-memory limiter on view events per client
+type viewRateLimiter struct {
+	mu      sync.Mutex
+	limit   int
+	window  time.Duration
+	buckets map[string]*viewBucket
+}
+
+type viewBucket struct {
+	start time.Time
+	count int
+}
+
+func newViewRateLimiter(limit int, window time.Duration) *viewRateLimiter {
+	return &viewRateLimiter{limit: limit, window: window, buckets: make(map[string]*viewBucket)}
+}
+
+// allow reserves n events for key and reports how many of them fit in the current window
+func (l *viewRateLimiter) allow(key string, n int) int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	b, ok := l.buckets[key]
+	if !ok || now.Sub(b.start) >= l.window {
+		b = &viewBucket{start: now}
+		l.buckets[key] = b
+	}
+	remaining := l.limit - b.count
+	if remaining <= 0 {
+		return 0
+	}
+	if n > remaining {
+		n = remaining
+	}
+	b.count += n
+
+	// Opportunistically drop stale buckets so the map does not grow unbounded
+	if len(l.buckets) > 10000 {
+		for k, v := range l.buckets {
+			if now.Sub(v.start) >= l.window {
+				delete(l.buckets, k)
+			}
+		}
+	}
+	return n
+}
+
+var (
+	productViewLimiter     *viewRateLimiter
+	productViewLimiterOnce sync.Once
+)
+
+func getProductViewLimiter() *viewRateLimiter {
+	productViewLimiterOnce.Do(func() {
+		productViewLimiter = newViewRateLimiter(getEnvInt("PRODUCT_VIEW_RATE_LIMIT_PER_MINUTE", 120), time.Minute)
+	})
+	return productViewLimiter
+}
+
+// RecordProductViews handles POST /products/views
+// Accepts a batch of view events from the mini-apps; excess events beyond the client's rate limit are dropped.
+func (h *Handler) RecordProductViews(c *gin.Context) {
+	type reqBody struct {
+		Events []models.ProductViewEvent `json:"events" binding:"required,dive"`
+	}
+	var req reqBody
+	if !bindJSON(c, &req) {
+		return
+	}
+	maxBatch := getEnvInt("PRODUCT_VIEW_MAX_BATCH", 50)
+	if len(req.Events) > maxBatch {
+		writeError(c, http.StatusBadRequest, fmt.Sprintf("At most %d events per batch", maxBatch))
+		return
+	}
+
+	var userID *string
+	if v, ok := c.Get("user_id"); ok {
+		if s := fmt.Sprint(v); s != "" {
+			userID = &s
+		}
+	}
+	key := c.ClientIP()
+	if userID != nil {
+		key = "user:" + *userID
+	}
+
+	allowed := getProductViewLimiter().allow(key, len(req.Events))
+	if allowed == 0 {
+		writeError(c, http.StatusTooManyRequests, "Rate limit exceeded")
+		return
+	}
+
+	// Clamp client timestamps: future or very old events are recorded as "now"
+	now := time.Now()
+	events := req.Events[:allowed]
+	for i := range events {
+		events[i].UserID = userID
+		if ts := events[i].ViewedAt; ts != nil && (ts.After(now) || now.Sub(*ts) > 48*time.Hour) {
+			events[i].ViewedAt = nil
+		}
+	}
+
+	ctx := c.Request.Context()
+	if err := h.db.InsertProductViewEvents(ctx, events); err != nil {
+		log.Printf("Error recording product views: %v", err)
+		writeError(c, http.StatusInternalServerError, "Failed to record product views")
+		return
+	}
+	c.JSON(http.StatusAccepted, gin.H{"accepted": len(events), "dropped": len(req.Events) - len(events)})
+}
+
+// GetPopularProducts handles GET /products/popular
+// Returns ranked product ids with their popularity scores (consumed by the recommendation engine).
+func (h *Handler) GetPopularProducts(c *gin.Context) {
+	limit := 20
+	if v := c.Query("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 || n > 100 {
+			writeError(c, http.StatusBadRequest, "Invalid limit")
+			return
+		}
+		limit = n
+	}
+	ctx := c.Request.Context()
+	items, err := h.db.GetPopularProducts(ctx, c.Query("mini_app_type"), limit)
+	if err != nil {
+		log.Printf("Error fetching popular products: %v", err)
+		writeError(c, http.StatusInternalServerError, "Failed to fetch popular products")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"products": items})
+}
+
+// StartPopularityJobs periodically aggregates view events into daily popularity scores
+// and purges raw events past the retention window. It returns when ctx is cancelled.
+func (h *Handler) StartPopularityJobs(ctx context.Context) {
+	interval := time.Duration(getEnvInt("PRODUCT_POPULARITY_INTERVAL_MINUTES", 60)) * time.Minute
+	retention := time.Duration(getEnvInt("PRODUCT_VIEW_RETENTION_DAYS", 90)) * 24 * time.Hour
+
+	run := func() {
+		jobCtx, cancel := context.WithTimeout(ctx, 2*time.Minute)
+		defer cancel()
+
+		// Re-aggregate yesterday as well so late-arriving events are counted
+		now := time.Now().UTC()
+		for _, day := range []time.Time{now.AddDate(0, 0, -1), now} {
+			n, err := h.db.AggregateProductPopularity(jobCtx, day)
+			if err != nil {
+				logging.LogKV("error", "ProductPopularityAggregateFailed", map[string]interface{}{"day": day.Format("2006-01-02"), "error": err.Error()})
+				continue
+			}
+			logging.LogKV("info", "ProductPopularityAggregated", map[string]interface{}{"day": day.Format("2006-01-02"), "products": n})
+		}
+
+		purged, err := h.db.PurgeProductViewEvents(jobCtx, now.Add(-retention))
+		if err != nil {
+			logging.LogKV("error", "ProductViewPurgeFailed", map[string]interface{}{"error": err.Error()})
+			return
+		}
+		if purged > 0 {
+			logging.LogKV("info", "ProductViewEventsPurged", map[string]interface{}{"deleted": purged})
+		}
+	}
+
+	run()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			run()
+		}
+	}
+}
+
+// getEnvInt gets an environment variable as integer with default value
+func getEnvInt(key string, defaultValue int) int {
+	if value := os.Getenv(key); value != "" {
+		if intValue, err := strconv.Atoi(value); err == nil {
+			return intValue
+		}
+	}
+	return defaultValue
+}
@@ -0,0 +1,229 @@
+package api
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/expotoworld/expotoworld/backend/catalog-service/internal/models"
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+)
+
+type priceListRequest struct {
+	Name       string     `json:"name" binding:"required"`
+	IsActive   *bool      `json:"is_active"`
+	ValidFrom  *time.Time `json:"valid_from"`
+	ValidUntil *time.Time `json:"valid_until"`
+}
+
+// toPriceList validates the request and converts it; an empty message means valid
+func (r *priceListRequest) toPriceList() (*models.PriceList, string) {
+	pl := &models.PriceList{Name: strings.TrimSpace(r.Name), IsActive: true, ValidFrom: time.Now(), ValidUntil: r.ValidUntil}
+	if pl.Name == "" {
+		return nil, "name is required"
+	}
+	if r.IsActive != nil {
+		pl.IsActive = *r.IsActive
+	}
+	if r.ValidFrom != nil {
+		pl.ValidFrom = *r.ValidFrom
+	}
+	if pl.ValidUntil != nil && !pl.ValidUntil.After(pl.ValidFrom) {
+		return nil, "valid_until must be after valid_from"
+	}
+	return pl, ""
+}
+
+func parsePriceListID(c *gin.Context) (int64, bool) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		writeError(c, http.StatusBadRequest, "Invalid price list ID")
+		return 0, false
+	}
+	return id, true
+}
+
+// GetOrganizationPriceLists handles GET /organizations/:id/price-lists
+func (h *Handler) GetOrganizationPriceLists(c *gin.Context) {
+	lists, err := h.db.ListPriceLists(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		log.Printf("Error listing price lists for org %s: %v", c.Param("id"), err)
+		writeError(c, http.StatusInternalServerError, "Failed to fetch price lists")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"price_lists": lists})
+}
+
+// CreateOrganizationPriceList handles POST /organizations/:id/price-lists
+func (h *Handler) CreateOrganizationPriceList(c *gin.Context) {
+	var req priceListRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+	pl, msg := req.toPriceList()
+	if msg != "" {
+		writeError(c, http.StatusBadRequest, msg)
+		return
+	}
+	pl.OrgID = c.Param("id")
+
+	created, err := h.db.CreatePriceList(c.Request.Context(), pl)
+	if err != nil {
+		log.Printf("Error creating price list for org %s: %v", pl.OrgID, err)
+		writeError(c, http.StatusInternalServerError, "Failed to create price list")
+		return
+	}
+	c.JSON(http.StatusCreated, created)
+}
+
+// GetPriceList handles GET /price-lists/:id (list details with items)
+func (h *Handler) GetPriceList(c *gin.Context) {
+	id, ok := parsePriceListID(c)
+	if !ok {
+		return
+	}
+	ctx := c.Request.Context()
+	pl, err := h.db.GetPriceList(ctx, id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			writeError(c, http.StatusNotFound, "Price list not found")
+			return
+		}
+		log.Printf("Error fetching price list %d: %v", id, err)
+		writeError(c, http.StatusInternalServerError, "Failed to fetch price list")
+		return
+	}
+	items, err := h.db.GetPriceListItems(ctx, id)
+	if err != nil {
+		log.Printf("Error fetching price list items %d: %v", id, err)
+		writeError(c, http.StatusInternalServerError, "Failed to fetch price list items")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"price_list": pl, "items": items})
+}
+
+// UpdatePriceList handles PUT /price-lists/:id
+func (h *Handler) UpdatePriceList(c *gin.Context) {
+	id, ok := parsePriceListID(c)
+	if !ok {
+		return
+	}
+	var req priceListRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+	pl, msg := req.toPriceList()
+	if msg != "" {
+		writeError(c, http.StatusBadRequest, msg)
+		return
+	}
+	pl.ID = id
+
+	updated, err := h.db.UpdatePriceList(c.Request.Context(), pl)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			writeError(c, http.StatusNotFound, "Price list not found")
+			return
+		}
+		log.Printf("Error updating price list %d: %v", id, err)
+		writeError(c, http.StatusInternalServerError, "Failed to update price list")
+		return
+	}
+	c.JSON(http.StatusOK, updated)
+}
+
+// DeletePriceList handles DELETE /price-lists/:id
+func (h *Handler) DeletePriceList(c *gin.Context) {
+	id, ok := parsePriceListID(c)
+	if !ok {
+		return
+	}
+	if err := h.db.DeletePriceList(c.Request.Context(), id); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			writeError(c, http.StatusNotFound, "Price list not found")
+			return
+		}
+		log.Printf("Error deleting price list %d: %v", id, err)
+		writeError(c, http.StatusInternalServerError, "Failed to delete price list")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Price list deleted"})
+}
+
+// SetPriceListItems handles PUT /price-lists/:id/items
+// Body: { "items": [{"product_id": 1, "price": 9.5}] } replaces all items of the list.
+func (h *Handler) SetPriceListItems(c *gin.Context) {
+	id, ok := parsePriceListID(c)
+	if !ok {
+		return
+	}
+	var body struct {
+		Items []models.PriceListItem `json:"items" binding:"dive"`
+	}
+	if !bindJSON(c, &body) {
+		return
+	}
+	seen := map[int]bool{}
+	for _, it := range body.Items {
+		if seen[it.ProductID] {
+			writeError(c, http.StatusBadRequest, "Duplicate product_id: "+strconv.Itoa(it.ProductID))
+			return
+		}
+		seen[it.ProductID] = true
+	}
+
+	if err := h.db.ReplacePriceListItems(c.Request.Context(), id, body.Items); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			writeError(c, http.StatusNotFound, "Price list not found")
+			return
+		}
+		log.Printf("Error setting price list items %d: %v", id, err)
+		writeError(c, http.StatusInternalServerError, "Failed to save price list items")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Price list items updated", "count": len(body.Items)})
+}
+
+// ResolveOrganizationPrices handles GET /organizations/:id/prices?product_ids=1,2,3[&at=RFC3339]
+// Resolution order: the org's most recently effective active price list containing the product,
+// then the product's default main_price. order-service applies the same rule at checkout.
+func (h *Handler) ResolveOrganizationPrices(c *gin.Context) {
+	var productIDs []int
+	for _, s := range strings.Split(c.Query("product_ids"), ",") {
+		s = strings.TrimSpace(s)
+		if s == "" {
+			continue
+		}
+		n, err := strconv.Atoi(s)
+		if err != nil || n <= 0 {
+			writeError(c, http.StatusBadRequest, "Invalid product_ids")
+			return
+		}
+		productIDs = append(productIDs, n)
+	}
+	if len(productIDs) == 0 || len(productIDs) > 200 {
+		writeError(c, http.StatusBadRequest, "product_ids must list between 1 and 200 products")
+		return
+	}
+	at := time.Now()
+	if v := c.Query("at"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			writeError(c, http.StatusBadRequest, "Invalid at (expected RFC3339)")
+			return
+		}
+		at = t
+	}
+
+	prices, err := h.db.ResolveOrgPrices(c.Request.Context(), c.Param("id"), productIDs, at)
+	if err != nil {
+		log.Printf("Error resolving prices for org %s: %v", c.Param("id"), err)
+		writeError(c, http.StatusInternalServerError, "Failed to resolve prices")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"org_id": c.Param("id"), "at": at, "prices": prices})
+}
@@ -0,0 +1,111 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/expotoworld/expotoworld/backend/catalog-service/internal/db"
+	"github.com/expotoworld/expotoworld/backend/catalog-service/internal/logging"
+	"github.com/expotoworld/expotoworld/backend/catalog-service/internal/models"
+	"github.com/gin-gonic/gin"
+)
+
+// productChangesRetention is how long the change log is kept (PRODUCT_CHANGES_RETENTION_DAYS, default 30);
+// clients that stay away longer get 410 and download the catalog again
+func productChangesRetention() time.Duration {
+	days := getEnvInt("PRODUCT_CHANGES_RETENTION_DAYS", 30)
+	if days < 1 {
+		days = 1
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
+
+// GetProductChanges handles GET /products/changes?since=<cursor>&store_id=&limit= for delta sync.
+// Without since it only returns the current cursor: take it before a full download, then poll with
+// next_cursor until has_more is false. limit defaults to 500 (max 1000).
+func (h *Handler) GetProductChanges(c *gin.Context) {
+	ctx := c.Request.Context()
+	limit := 500
+	if v := c.Query("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 || n > 1000 {
+			writeError(c, http.StatusBadRequest, "limit must be between 1 and 1000")
+			return
+		}
+		limit = n
+	}
+	var storeID *int
+	if v := c.Query("store_id"); v != "" {
+		id, err := strconv.Atoi(v)
+		if err != nil {
+			writeError(c, http.StatusBadRequest, "Invalid store_id")
+			return
+		}
+		storeID = &id
+	}
+
+	raw := c.Query("since")
+	if raw == "" {
+		head, err := h.db.ProductChangesHead(ctx)
+		if err != nil {
+			log.Printf("Error reading product change head: %v", err)
+			writeError(c, http.StatusInternalServerError, "Failed to fetch product changes")
+			return
+		}
+		c.JSON(http.StatusOK, models.ProductChangeFeed{Changes: []models.ProductChange{}, NextCursor: head.String()})
+		return
+	}
+	since, ok := models.ParseChangeCursor(raw)
+	if !ok {
+		writeError(c, http.StatusBadRequest, "Invalid since cursor")
+		return
+	}
+
+	feed, err := h.db.ListProductChanges(ctx, since, storeID, limit)
+	if errors.Is(err, db.ErrChangeCursorExpired) {
+		writeErrorDetails(c, http.StatusGone, "CURSOR_EXPIRED",
+			"Changes since this cursor are no longer kept; download the catalog again and sync from a new cursor", nil)
+		return
+	}
+	if err != nil {
+		log.Printf("Error listing product changes since %s: %v", raw, err)
+		writeError(c, http.StatusInternalServerError, "Failed to fetch product changes")
+		return
+	}
+	c.JSON(http.StatusOK, feed)
+}
+
+// StartProductChangeJobs purges the product change log past its retention every
+// PRODUCT_CHANGES_PURGE_CHECK_MINUTES
+func (h *Handler) StartProductChangeJobs(ctx context.Context) {
+	interval := time.Duration(getEnvInt("PRODUCT_CHANGES_PURGE_CHECK_MINUTES", 60)) * time.Minute
+
+	run := func() {
+		jobCtx, cancel := context.WithTimeout(ctx, 5*time.Minute)
+		defer cancel()
+		n, err := h.db.PurgeProductChanges(jobCtx, time.Now().Add(-productChangesRetention()))
+		if err != nil {
+			logging.LogKV("error", "ProductChangePurgeFailed", map[string]interface{}{"error": err.Error()})
+			return
+		}
+		if n > 0 {
+			logging.LogKV("info", "ProductChangesPurged", map[string]interface{}{"deleted": n})
+		}
+	}
+
+	run()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			run()
+		}
+	}
+}
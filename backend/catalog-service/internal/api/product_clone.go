@@ -0,0 +1,108 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/expotoworld/expotoworld/backend/catalog-service/internal/db"
+	"github.com/expotoworld/expotoworld/backend/catalog-service/internal/models"
+	"github.com/gin-gonic/gin"
+)
+
+// CloneProduct handles POST /products/:id/clone
+// Creates an inactive draft copy with a new SKU so near-identical products needn't be re-entered.
+// Gallery images are copied to the new product's S3 folder; a failed image copy doesn't undo the clone.
+func (h *Handler) CloneProduct(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 60*time.Second)
+	defer cancel()
+
+	sourceID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		writeError(c, http.StatusBadRequest, "Invalid product ID format")
+		return
+	}
+
+	var req models.CloneProductRequest
+	if c.Request.ContentLength != 0 && !bindJSON(c, &req) {
+		return
+	}
+	copyImages := req.CopyImages == nil || *req.CopyImages
+	copyCategories := req.CopyCategories == nil || *req.CopyCategories
+	if req.Title != nil {
+		title := strings.TrimSpace(*req.Title)
+		req.Title = &title
+	}
+
+	productID, sku, err := h.db.CloneProduct(ctx, sourceID, strings.TrimSpace(req.SKU), req.Title, copyCategories)
+	if err != nil {
+		if errors.Is(err, db.ErrProductNotFound) {
+			writeError(c, http.StatusNotFound, "Product not found")
+			return
+		}
+		if strings.Contains(err.Error(), "duplicate key value violates unique constraint \"admin_products_sku_key\"") {
+			writeErrorDetails(c, http.StatusConflict, "DUPLICATE_SKU",
+				fmt.Sprintf("SKU '%s' already exists. Please use a different SKU.", req.SKU), nil)
+			return
+		}
+		log.Printf("Failed to clone product %d: %v", sourceID, err)
+		writeError(c, http.StatusInternalServerError, "Failed to clone product")
+		return
+	}
+
+	copied, failed := 0, 0
+	if copyImages {
+		copied, failed = h.cloneProductImages(ctx, sourceID, productID)
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"product_id":        productID,
+		"source_product_id": sourceID,
+		"sku":               sku,
+		"images_copied":     copied,
+		"images_failed":     failed,
+	})
+}
+
+// cloneProductImages copies the source's gallery to the clone, keeping order and primary image.
+// Stored objects are duplicated so deleting either product leaves the other's images intact;
+// renditions are regenerated from the copies. Images from elsewhere (external URLs) are linked as-is.
+func (h *Handler) cloneProductImages(ctx context.Context, sourceID, productID int) (copied, failed int) {
+	images, err := h.db.GetProductImages(ctx, sourceID)
+	if err != nil {
+		log.Printf("Failed to list images of product %d for clone: %v", sourceID, err)
+		return 0, 0
+	}
+	if len(images) == 0 {
+		return 0, 0
+	}
+
+	for i, image := range images {
+		imageURL := image.ImageURL
+		if srcKey, ok := h.media.KeyFromURL(image.ImageURL); ok {
+			dstKey := fmt.Sprintf("%s%d_%d%s", productImagePrefix(productID), time.Now().UnixNano(), i, path.Ext(srcKey))
+			if err := h.media.CopyObject(ctx, srcKey, dstKey); err != nil {
+				log.Printf("Failed to copy image %s for clone of product %d: %v", srcKey, sourceID, err)
+				failed++
+				continue
+			}
+			imageURL = h.media.PublicURL(dstKey)
+		}
+		if _, err := h.db.AddProductImage(ctx, productID, imageURL, image.DisplayOrder, image.IsPrimary); err != nil {
+			log.Printf("Failed to save cloned image for product %d: %v", productID, err)
+			failed++
+			continue
+		}
+		copied++
+		if imageURL != image.ImageURL {
+			h.startRenditions(db.RenditionsProductImages, strconv.Itoa(productID), imageURL, nil)
+		}
+	}
+	return copied, failed
+}
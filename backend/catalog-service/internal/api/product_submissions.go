@@ -0,0 +1,307 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/expotoworld/expotoworld/backend/catalog-service/internal/db"
+	"github.com/expotoworld/expotoworld/backend/catalog-service/internal/models"
+	"github.com/expotoworld/expotoworld/backend/common/auth"
+	"github.com/gin-gonic/gin"
+)
+
+// manufacturerOrgIDs lists the Manufacturer organizations in the caller's org_memberships claim
+func manufacturerOrgIDs(c *gin.Context) []string {
+	return memberOrgIDs(c, models.OrgTypeManufacturer)
+}
+
+// memberOrgIDs lists the organizations in the caller's org_memberships claim, only those of orgType
+// unless it is empty
+func memberOrgIDs(c *gin.Context, orgType models.OrgType) []string {
+	return auth.OrgIDs(c, string(orgType))
+}
+
+// bindProductSubmission binds and validates a manufacturer's proposal and resolves the organization
+// it is made for. It writes the error response itself when ok is false.
+func (h *Handler) bindProductSubmission(c *gin.Context) (models.ProductSubmissionRequest, bool) {
+	var req models.ProductSubmissionRequest
+	if !bindJSON(c, &req) {
+		return req, false
+	}
+	orgIDs := manufacturerOrgIDs(c)
+	if len(orgIDs) == 0 {
+		writeError(c, http.StatusForbidden, "Only manufacturer users can submit products")
+		return req, false
+	}
+	if req.OrgID == "" {
+		if len(orgIDs) > 1 {
+			writeFieldErrors(c, models.FieldError{Field: "org_id", Code: "required", Message: "required when you belong to several manufacturers"})
+			return req, false
+		}
+		req.OrgID = orgIDs[0]
+	} else if !containsString(orgIDs, req.OrgID) {
+		writeError(c, http.StatusForbidden, "You are not a member of this manufacturer")
+		return req, false
+	}
+
+	req.SKU = strings.TrimSpace(req.SKU)
+	req.Title = strings.TrimSpace(req.Title)
+	p := models.Product{Barcode: req.Barcode}
+	if fe := checkProductBarcode(&p); fe != nil {
+		writeFieldErrors(c, *fe)
+		return req, false
+	}
+	req.Barcode = p.Barcode
+
+	ok, err := h.db.CategoriesExist(c.Request.Context(), req.CategoryIDs, req.SubcategoryIDs)
+	if err != nil {
+		log.Printf("Failed to check submission categories: %v", err)
+		writeError(c, http.StatusInternalServerError, "Failed to check categories")
+		return req, false
+	}
+	if !ok {
+		writeFieldErrors(c, models.FieldError{Field: "category_ids", Code: "not_found", Message: "unknown category or subcategory"})
+		return req, false
+	}
+	return req, true
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// SubmitManufacturerProduct handles POST /manufacturer/products: proposes a new product, which stays
+// invisible until an admin approves it
+func (h *Handler) SubmitManufacturerProduct(c *gin.Context) {
+	req, ok := h.bindProductSubmission(c)
+	if !ok {
+		return
+	}
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	sub, err := h.db.SaveProductSubmission(ctx, &models.ProductSubmission{
+		OrgID:       req.OrgID,
+		Kind:        "create",
+		Product:     req.ProductSubmissionFields,
+		SubmittedBy: contextString(c, "user_id"),
+	})
+	if err != nil {
+		log.Printf("Failed to save product submission: %v", err)
+		writeError(c, http.StatusInternalServerError, "Failed to submit product")
+		return
+	}
+	c.JSON(http.StatusCreated, sub)
+}
+
+// SubmitManufacturerProductUpdate handles PUT /manufacturer/products/:id: proposes changes to one of
+// the manufacturer's products, replacing any change still pending review. The live product is
+// unchanged until an admin approves.
+func (h *Handler) SubmitManufacturerProductUpdate(c *gin.Context) {
+	productID, ok := parseProductIDParam(c)
+	if !ok {
+		return
+	}
+	req, ok := h.bindProductSubmission(c)
+	if !ok {
+		return
+	}
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	owner, err := h.db.ProductOwnerOrgID(ctx, productID)
+	if errors.Is(err, db.ErrProductNotFound) {
+		writeError(c, http.StatusNotFound, "Product not found")
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to check owner of product %d: %v", productID, err)
+		writeError(c, http.StatusInternalServerError, "Failed to submit product changes")
+		return
+	}
+	if owner != req.OrgID {
+		writeError(c, http.StatusForbidden, "Product does not belong to this manufacturer")
+		return
+	}
+
+	sub, err := h.db.SaveProductSubmission(ctx, &models.ProductSubmission{
+		OrgID:       req.OrgID,
+		ProductID:   &productID,
+		Kind:        "update",
+		Product:     req.ProductSubmissionFields,
+		SubmittedBy: contextString(c, "user_id"),
+	})
+	if err != nil {
+		log.Printf("Failed to save submission for product %d: %v", productID, err)
+		writeError(c, http.StatusInternalServerError, "Failed to submit product changes")
+		return
+	}
+	c.JSON(http.StatusAccepted, sub)
+}
+
+// listSubmissions parses ?status=&limit=&before_id= and writes the page for the given organizations
+// (nil for all)
+func (h *Handler) listSubmissions(c *gin.Context, orgIDs []string, defaultStatus string) {
+	status := c.DefaultQuery("status", defaultStatus)
+	switch status {
+	case "", models.SubmissionPendingReview, models.SubmissionApproved, models.SubmissionRejected:
+	default:
+		writeError(c, http.StatusBadRequest, "status must be pending_review, approved or rejected")
+		return
+	}
+	limit := 50
+	if v := c.Query("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 || n > 200 {
+			writeError(c, http.StatusBadRequest, "limit must be between 1 and 200")
+			return
+		}
+		limit = n
+	}
+	var beforeID int64
+	if v := c.Query("before_id"); v != "" {
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || n <= 0 {
+			writeError(c, http.StatusBadRequest, "Invalid before_id")
+			return
+		}
+		beforeID = n
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+	subs, err := h.db.ListProductSubmissions(ctx, orgIDs, status, beforeID, limit)
+	if err != nil {
+		log.Printf("Failed to list product submissions: %v", err)
+		writeError(c, http.StatusInternalServerError, "Failed to fetch submissions")
+		return
+	}
+	resp := gin.H{"submissions": subs}
+	if len(subs) == limit {
+		resp["next_before_id"] = subs[len(subs)-1].ID
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// GetManufacturerSubmissions handles GET /manufacturer/submissions: the caller's manufacturers'
+// submissions with their review status and comments
+func (h *Handler) GetManufacturerSubmissions(c *gin.Context) {
+	h.listSubmissions(c, manufacturerOrgIDs(c), "")
+}
+
+// ListProductSubmissions handles GET /admin/product-submissions (pending_review by default; pass
+// status= for all)
+func (h *Handler) ListProductSubmissions(c *gin.Context) {
+	h.listSubmissions(c, nil, models.SubmissionPendingReview)
+}
+
+func submissionID(c *gin.Context) (int64, bool) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		writeError(c, http.StatusBadRequest, "Invalid submission id")
+		return 0, false
+	}
+	return id, true
+}
+
+// writeSubmissionError maps review errors to responses
+func writeSubmissionError(c *gin.Context, id int64, err error) {
+	switch {
+	case errors.Is(err, db.ErrSubmissionNotFound):
+		writeError(c, http.StatusNotFound, "Submission not found")
+	case errors.Is(err, db.ErrSubmissionNotPending):
+		writeError(c, http.StatusConflict, "Submission was already reviewed")
+	case errors.Is(err, db.ErrBarcodeTaken):
+		writeErrorDetails(c, http.StatusConflict, "DUPLICATE_BARCODE", "Barcode is already assigned to another product", nil)
+	case errors.Is(err, db.ErrSKUTaken):
+		writeErrorDetails(c, http.StatusConflict, "DUPLICATE_SKU", "SKU already exists. Ask the manufacturer to use a different SKU.", nil)
+	default:
+		log.Printf("Failed to review submission %d: %v", id, err)
+		writeError(c, http.StatusInternalServerError, "Failed to review submission")
+	}
+}
+
+// ApproveProductSubmission handles POST /admin/product-submissions/:id/approve. New products go live
+// immediately and need tax_class; approved changes overwrite the submitted fields only.
+func (h *Handler) ApproveProductSubmission(c *gin.Context) {
+	id, ok := submissionID(c)
+	if !ok {
+		return
+	}
+	var req models.ReviewSubmissionRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	sub, err := h.db.GetProductSubmission(ctx, id)
+	if err != nil {
+		writeSubmissionError(c, id, err)
+		return
+	}
+	if sub.Kind == "create" {
+		p := models.Product{TaxClass: req.TaxClass, IsActive: true}
+		if fe := checkProductTaxClass(&p); fe != nil {
+			writeFieldErrors(c, *fe)
+			return
+		}
+		req.TaxClass = p.TaxClass
+	}
+
+	var before []byte
+	if sub.ProductID != nil {
+		before, _ = h.db.SnapshotRow(ctx, "admin_products", "product_id", strconv.Itoa(*sub.ProductID))
+	}
+	sub, err = h.db.ApproveProductSubmission(ctx, id, contextString(c, "user_id"), strings.TrimSpace(req.Comment), req.TaxClass)
+	if err != nil {
+		writeSubmissionError(c, id, err)
+		return
+	}
+
+	// The route is not a product route, so announce the product change to webhooks here
+	productID := strconv.Itoa(*sub.ProductID)
+	if after, err := h.db.SnapshotRow(ctx, "admin_products", "product_id", productID); err == nil {
+		action := models.AuditUpdate
+		if sub.Kind == "create" {
+			action = models.AuditCreate
+			h.startTranslationDrafts(c, *sub.ProductID)
+		}
+		h.publishCatalogEvent(ctx, "product", action, productID, before, after)
+	}
+	c.JSON(http.StatusOK, sub)
+}
+
+// RejectProductSubmission handles POST /admin/product-submissions/:id/reject; a comment telling the
+// manufacturer why is required
+func (h *Handler) RejectProductSubmission(c *gin.Context) {
+	id, ok := submissionID(c)
+	if !ok {
+		return
+	}
+	var req models.ReviewSubmissionRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+	comment := strings.TrimSpace(req.Comment)
+	if comment == "" {
+		writeFieldErrors(c, models.FieldError{Field: "comment", Code: "required", Message: "explain why the submission is rejected"})
+		return
+	}
+	sub, err := h.db.RejectProductSubmission(c.Request.Context(), id, contextString(c, "user_id"), comment)
+	if err != nil {
+		writeSubmissionError(c, id, err)
+		return
+	}
+	c.JSON(http.StatusOK, sub)
+}
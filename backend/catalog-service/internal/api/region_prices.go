@@ -0,0 +1,138 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/expotoworld/expotoworld/backend/catalog-service/internal/db"
+	"github.com/expotoworld/expotoworld/backend/catalog-service/internal/models"
+	"github.com/gin-gonic/gin"
+)
+
+// baseCurrency is the currency of main_price (CATALOG_BASE_CURRENCY, default EUR)
+func baseCurrency() string {
+	if v := strings.ToUpper(strings.TrimSpace(os.Getenv("CATALOG_BASE_CURRENCY"))); v != "" {
+		return v
+	}
+	return "EUR"
+}
+
+// parseRegion resolves the optional ?region= (region id or code, e.g. CH); nil without one.
+// It writes the error response itself when ok is false.
+func (h *Handler) parseRegion(c *gin.Context) (*models.Region, bool) {
+	ref := strings.TrimSpace(c.Query("region"))
+	if ref == "" {
+		return nil, true
+	}
+	region, err := h.db.ResolveRegion(c.Request.Context(), ref)
+	if errors.Is(err, db.ErrRegionNotFound) {
+		writeError(c, http.StatusBadRequest, "Unknown region")
+		return nil, false
+	}
+	if err != nil {
+		log.Printf("Error resolving region %q: %v", ref, err)
+		writeError(c, http.StatusInternalServerError, "Failed to resolve region")
+		return nil, false
+	}
+	return region, true
+}
+
+// applyRegionPricing sets RegionPricing on each product: the regional price in the region's currency
+// when one is set, otherwise main_price in the base currency
+func (h *Handler) applyRegionPricing(ctx context.Context, region *models.Region, products []models.Product) error {
+	ids := make([]int, len(products))
+	for i := range products {
+		ids[i] = products[i].ID
+	}
+	prices, err := h.db.GetRegionPrices(ctx, region.ID, ids)
+	if err != nil {
+		return err
+	}
+	base := baseCurrency()
+	for i := range products {
+		p := &products[i]
+		pricing := &models.RegionPricing{RegionID: region.ID, Currency: base, Price: p.MainPrice,
+			StrikethroughPrice: p.StrikethroughPrice, Source: models.RegionPriceSourceDefault}
+		if rp, ok := prices[p.ID]; ok {
+			pricing.Currency = rp.Currency
+			pricing.Price = rp.Price
+			pricing.StrikethroughPrice = rp.StrikethroughPrice
+			pricing.Source = models.RegionPriceSourceRegion
+		}
+		p.RegionPricing = pricing
+	}
+	return nil
+}
+
+// GetProductRegionPrices handles GET /products/:id/region-prices
+func (h *Handler) GetProductRegionPrices(c *gin.Context) {
+	productID, ok := parseProductIDParam(c)
+	if !ok {
+		return
+	}
+	prices, err := h.db.ListProductRegionPrices(c.Request.Context(), productID)
+	if err != nil {
+		log.Printf("Error fetching regional prices of product %d: %v", productID, err)
+		writeError(c, http.StatusInternalServerError, "Failed to fetch regional prices")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"base_currency": baseCurrency(), "region_prices": prices})
+}
+
+type setRegionPricesRequest struct {
+	Prices []models.ProductRegionPrice `json:"region_prices" binding:"dive"`
+}
+
+// SetProductRegionPrices handles PUT /products/:id/region-prices
+// Body: { "region_prices": [{ "region_id": 2, "price": 12.9, "strikethrough_price": 14.9 }] } replaces all
+// regional prices of the product; prices are in each region's currency.
+func (h *Handler) SetProductRegionPrices(c *gin.Context) {
+	productID, ok := parseProductIDParam(c)
+	if !ok {
+		return
+	}
+	var req setRegionPricesRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+	var errs []models.FieldError
+	seen := map[int]bool{}
+	for i, p := range req.Prices {
+		field := fmt.Sprintf("region_prices[%d]", i)
+		if seen[p.RegionID] {
+			errs = append(errs, models.FieldError{Field: field + ".region_id", Code: "duplicate", Message: "region is listed twice"})
+		}
+		seen[p.RegionID] = true
+		if p.StrikethroughPrice != nil && *p.StrikethroughPrice <= p.Price {
+			errs = append(errs, models.FieldError{Field: field + ".strikethrough_price", Code: "too_small", Message: "strikethrough_price must exceed price"})
+		}
+	}
+	if len(errs) > 0 {
+		writeFieldErrors(c, errs...)
+		return
+	}
+
+	var actor string
+	if v, ok := c.Get("user_id"); ok {
+		actor = fmt.Sprint(v)
+	}
+	err := h.db.SetProductRegionPrices(c.Request.Context(), productID, req.Prices, actor)
+	switch {
+	case errors.Is(err, db.ErrProductNotFound):
+		writeError(c, http.StatusNotFound, "Product not found")
+		return
+	case errors.Is(err, db.ErrRegionNotFound):
+		writeError(c, http.StatusBadRequest, "Unknown region_id")
+		return
+	case err != nil:
+		log.Printf("Error saving regional prices of product %d: %v", productID, err)
+		writeError(c, http.StatusInternalServerError, "Failed to save regional prices")
+		return
+	}
+	h.GetProductRegionPrices(c)
+}
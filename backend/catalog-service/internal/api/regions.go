@@ -1,9 +1,14 @@
 package api
 
 import (
+	"errors"
 	"net/http"
+	"regexp"
 	"strconv"
+	"strings"
 
+	"github.com/expotoworld/expotoworld/backend/catalog-service/internal/db"
+	"github.com/expotoworld/expotoworld/backend/catalog-service/internal/models"
 	"github.com/gin-gonic/gin"
 )
 
@@ -12,7 +17,7 @@ func (h *Handler) ListRegions(c *gin.Context) {
 	ctx := c.Request.Context()
 	regions, err := h.db.ListRegions(ctx)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch regions"})
+		writeError(c, http.StatusInternalServerError, "Failed to fetch regions")
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{"regions": regions})
@@ -20,19 +25,18 @@ func (h *Handler) ListRegions(c *gin.Context) {
 
 // CreateRegion handles POST /regions
 func (h *Handler) CreateRegion(c *gin.Context) {
-	type reqBody struct {
-		Name        string  `json:"name" binding:"required"`
-		Description *string `json:"description"`
-	}
-	var req reqBody
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+	req, ok := bindRegion(c)
+	if !ok {
 		return
 	}
 	ctx := c.Request.Context()
-	region, err := h.db.CreateRegion(ctx, req.Name, req.Description)
+	region, err := h.db.CreateRegion(ctx, req)
+	if errors.Is(err, db.ErrRegionCodeTaken) {
+		writeError(c, http.StatusConflict, "Region code already in use")
+		return
+	}
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create region"})
+		writeError(c, http.StatusInternalServerError, "Failed to create region")
 		return
 	}
 	c.JSON(http.StatusCreated, region)
@@ -43,38 +47,70 @@ func (h *Handler) UpdateRegion(c *gin.Context) {
 	idStr := c.Param("id")
 	id, err := strconv.Atoi(idStr)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid region id"})
+		writeError(c, http.StatusBadRequest, "Invalid region id")
 		return
 	}
-	type reqBody struct {
-		Name        string  `json:"name" binding:"required"`
-		Description *string `json:"description"`
-	}
-	var req reqBody
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+	req, ok := bindRegion(c)
+	if !ok {
 		return
 	}
 	ctx := c.Request.Context()
-	region, err := h.db.UpdateRegion(ctx, id, req.Name, req.Description)
+	region, err := h.db.UpdateRegion(ctx, id, req)
+	if errors.Is(err, db.ErrRegionCodeTaken) {
+		writeError(c, http.StatusConflict, "Region code already in use")
+		return
+	}
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update region"})
+		writeError(c, http.StatusInternalServerError, "Failed to update region")
 		return
 	}
 	c.JSON(http.StatusOK, region)
 }
 
+var (
+	regionCodePattern = regexp.MustCompile(`^[A-Z][A-Z0-9-]{1,15}$`)
+	currencyPattern   = regexp.MustCompile(`^[A-Z]{3}$`)
+)
+
+// bindRegion reads a region body: name, description, code (e.g. "CH") and currency (ISO 4217, e.g.
+// "CHF"). Codes and currencies are upper-cased; it writes the error response itself.
+func bindRegion(c *gin.Context) (models.Region, bool) {
+	var req struct {
+		Name        string  `json:"name" binding:"required"`
+		Description *string `json:"description"`
+		Code        *string `json:"code"`
+		Currency    string  `json:"currency"`
+	}
+	if !bindJSON(c, &req) {
+		return models.Region{}, false
+	}
+	r := models.Region{Name: req.Name, Description: req.Description, Currency: strings.ToUpper(strings.TrimSpace(req.Currency))}
+	if req.Code != nil {
+		code := strings.ToUpper(strings.TrimSpace(*req.Code))
+		if !regionCodePattern.MatchString(code) {
+			writeFieldErrors(c, models.FieldError{Field: "code", Code: "invalid_format", Message: "2-16 letters, digits or dashes, starting with a letter"})
+			return models.Region{}, false
+		}
+		r.Code = &code
+	}
+	if r.Currency != "" && !currencyPattern.MatchString(r.Currency) {
+		writeFieldErrors(c, models.FieldError{Field: "currency", Code: "invalid_format", Message: "ISO 4217 code such as EUR or CHF"})
+		return models.Region{}, false
+	}
+	return r, true
+}
+
 // DeleteRegion handles DELETE /regions/:id
 func (h *Handler) DeleteRegion(c *gin.Context) {
 	idStr := c.Param("id")
 	id, err := strconv.Atoi(idStr)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid region id"})
+		writeError(c, http.StatusBadRequest, "Invalid region id")
 		return
 	}
 	ctx := c.Request.Context()
 	if err := h.db.DeleteRegion(ctx, id); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete region"})
+		writeError(c, http.StatusInternalServerError, "Failed to delete region")
 		return
 	}
 	c.Status(http.StatusNoContent)
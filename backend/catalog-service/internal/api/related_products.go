@@ -0,0 +1,91 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/expotoworld/expotoworld/backend/catalog-service/internal/db"
+	"github.com/expotoworld/expotoworld/backend/catalog-service/internal/models"
+	"github.com/gin-gonic/gin"
+)
+
+// maxRelatedProducts caps the curated list per product
+const maxRelatedProducts = 50
+
+// GetRelatedProducts handles GET /products/:id/related (admin; includes inactive related products)
+func (h *Handler) GetRelatedProducts(c *gin.Context) {
+	productID, ok := parseProductIDParam(c)
+	if !ok {
+		return
+	}
+	related, err := h.db.GetRelatedProducts(c.Request.Context(), productID)
+	if err != nil {
+		log.Printf("Error fetching relations of product %d: %v", productID, err)
+		writeError(c, http.StatusInternalServerError, "Failed to fetch product relations")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"related_products": related})
+}
+
+// SetRelatedProducts handles PUT /products/:id/related
+// Body: { "relations": [ {"related_product_id": 12, "relation_type": "accessory", "display_order": 0}, ... ] }
+// replaces the product's relations; an empty list removes them all.
+func (h *Handler) SetRelatedProducts(c *gin.Context) {
+	productID, ok := parseProductIDParam(c)
+	if !ok {
+		return
+	}
+	var body struct {
+		Relations []models.ProductRelation `json:"relations" binding:"required,dive"`
+	}
+	if !bindJSON(c, &body) {
+		return
+	}
+	if len(body.Relations) > maxRelatedProducts {
+		writeError(c, http.StatusBadRequest, fmt.Sprintf("At most %d relations per product", maxRelatedProducts))
+		return
+	}
+	seen := map[models.ProductRelation]bool{}
+	for i, r := range body.Relations {
+		if !r.RelationType.IsValid() {
+			writeFieldErrors(c, models.FieldError{Field: fmt.Sprintf("relations[%d].relation_type", i), Code: "invalid_choice",
+				Message: "relation_type must be one of: accessory, replacement, upsell"})
+			return
+		}
+		if r.RelatedProductID == productID {
+			writeError(c, http.StatusBadRequest, "A product cannot be related to itself")
+			return
+		}
+		key := models.ProductRelation{RelatedProductID: r.RelatedProductID, RelationType: r.RelationType}
+		if seen[key] {
+			writeError(c, http.StatusBadRequest, fmt.Sprintf("Product %d is listed twice as %s", r.RelatedProductID, r.RelationType))
+			return
+		}
+		seen[key] = true
+	}
+
+	ctx := c.Request.Context()
+	err := h.db.SetRelatedProducts(ctx, productID, body.Relations)
+	switch {
+	case errors.Is(err, db.ErrProductNotFound):
+		writeError(c, http.StatusNotFound, "Product not found")
+		return
+	case errors.Is(err, db.ErrRelatedProductNotFound):
+		writeError(c, http.StatusBadRequest, "Related product not found")
+		return
+	case err != nil:
+		log.Printf("Error saving relations of product %d: %v", productID, err)
+		writeError(c, http.StatusInternalServerError, "Failed to save product relations")
+		return
+	}
+
+	related, err := h.db.GetRelatedProducts(ctx, productID)
+	if err != nil {
+		log.Printf("Error fetching relations of product %d: %v", productID, err)
+		writeError(c, http.StatusInternalServerError, "Failed to fetch product relations")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"related_products": related})
+}
@@ -0,0 +1,322 @@
+package api
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/expotoworld/expotoworld/backend/catalog-service/internal/db"
+	"github.com/expotoworld/expotoworld/backend/catalog-service/internal/models"
+	"github.com/gin-gonic/gin"
+)
+
+// maxRelationshipCSVBytes is the largest mapping CSV accepted for import
+const maxRelationshipCSVBytes = 5 << 20
+
+// maxRelationshipImportErrors caps the line errors reported for one import
+const maxRelationshipImportErrors = 100
+
+// relationshipColumns are the CSV columns of each mapping: entity id, entity key, organization id,
+// organization name and, for sourcing, region id and region (code, or name without one)
+var relationshipColumns = map[models.RelationshipKind][]string{
+	models.RelationshipStorePartners:    {"store_id", "store_name", "partner_org_id", "partner_org_name"},
+	models.RelationshipProductSourcing:  {"product_id", "sku", "manufacturer_org_id", "manufacturer_org_name", "region_id", "region"},
+	models.RelationshipProductLogistics: {"product_id", "sku", "tpl_org_id", "tpl_org_name"},
+}
+
+func parseRelationshipKind(c *gin.Context) (models.RelationshipKind, bool) {
+	kind := models.RelationshipKind(c.Param("kind"))
+	if !kind.Valid() {
+		writeError(c, http.StatusNotFound, "Unknown mapping; use store-partners, product-sourcing or product-logistics")
+		return "", false
+	}
+	return kind, true
+}
+
+// ExportRelationships handles GET /admin/relationships/:kind/export: every mapping of the kind as
+// CSV with both ids and natural keys (store name or SKU, organization name, region code), so the
+// file can be imported into another environment
+func (h *Handler) ExportRelationships(c *gin.Context) {
+	kind, ok := parseRelationshipKind(c)
+	if !ok {
+		return
+	}
+	mappings, err := h.db.ListRelationshipMappings(c.Request.Context(), kind)
+	if err != nil {
+		log.Printf("Failed to export %s: %v", kind, err)
+		writeError(c, http.StatusInternalServerError, "Failed to export mappings")
+		return
+	}
+
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	_ = w.Write(relationshipColumns[kind])
+	for _, m := range mappings {
+		record := []string{strconv.Itoa(m.EntityID), m.EntityKey, m.OrgID, m.OrgName}
+		if kind == models.RelationshipProductSourcing {
+			regionID, region := "", ""
+			if m.RegionID != nil {
+				regionID = strconv.Itoa(*m.RegionID)
+			}
+			if m.Region != nil {
+				region = *m.Region
+			}
+			record = append(record, regionID, region)
+		}
+		_ = w.Write(record)
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		log.Printf("Failed to write %s CSV: %v", kind, err)
+		writeError(c, http.StatusInternalServerError, "Failed to export mappings")
+		return
+	}
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.csv"`, kind))
+	c.Data(http.StatusOK, "text/csv; charset=utf-8", buf.Bytes())
+}
+
+// ImportRelationships handles POST /admin/relationships/:kind/import?mode=&match=&dry_run= with a
+// CSV in the export's format, as the multipart field "file" or the raw body.
+//   - mode=replace (default): the stores or products in the file keep exactly the file's mappings;
+//     mode=merge only adds mappings
+//   - match=key (default) resolves rows by store name or SKU, organization name and region code;
+//     match=id by the id columns, for files from the same environment
+//
+// Nothing is written unless every row resolves; dry_run=true reports the changes without saving.
+func (h *Handler) ImportRelationships(c *gin.Context) {
+	kind, ok := parseRelationshipKind(c)
+	if !ok {
+		return
+	}
+	mode := c.DefaultQuery("mode", "replace")
+	if mode != "replace" && mode != "merge" {
+		writeError(c, http.StatusBadRequest, "mode must be replace or merge")
+		return
+	}
+	match := c.DefaultQuery("match", "key")
+	if match != "key" && match != "id" {
+		writeError(c, http.StatusBadRequest, "match must be key or id")
+		return
+	}
+	dryRun := c.Query("dry_run") == "true"
+
+	data, ok := readRelationshipCSV(c)
+	if !ok {
+		return
+	}
+	ctx := c.Request.Context()
+	keys, err := h.db.LoadRelationshipKeys(ctx, kind)
+	if err != nil {
+		log.Printf("Failed to load %s import keys: %v", kind, err)
+		writeError(c, http.StatusInternalServerError, "Failed to import mappings")
+		return
+	}
+
+	result := models.RelationshipImportResult{Kind: kind, Mode: mode, DryRun: dryRun}
+	mappings, errs, err := resolveRelationshipCSV(kind, data, match == "id", keys, &result)
+	if err != nil {
+		writeError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if len(errs) > 0 {
+		writeErrorDetails(c, http.StatusUnprocessableEntity, "INVALID_ROWS",
+			fmt.Sprintf("%d rows could not be resolved; nothing was imported", len(errs)), gin.H{"errors": errs})
+		return
+	}
+
+	result.Added, result.Removed, err = h.db.ApplyRelationshipMappings(ctx, kind, mappings, mode == "replace", dryRun)
+	if err != nil {
+		if !writeOrganizationGraphError(c, err) {
+			log.Printf("Failed to import %s: %v", kind, err)
+			writeError(c, http.StatusInternalServerError, "Failed to import mappings")
+		}
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// readRelationshipCSV reads the uploaded CSV. It writes the error response itself when ok is false.
+func readRelationshipCSV(c *gin.Context) ([]byte, bool) {
+	var r io.Reader = c.Request.Body
+	if strings.HasPrefix(c.ContentType(), "multipart/") {
+		fh, err := c.FormFile("file")
+		if err != nil {
+			writeError(c, http.StatusBadRequest, "No file provided")
+			return nil, false
+		}
+		f, err := fh.Open()
+		if err != nil {
+			writeError(c, http.StatusBadRequest, "Failed to open uploaded file")
+			return nil, false
+		}
+		defer f.Close()
+		r = f
+	}
+	data, err := io.ReadAll(io.LimitReader(r, maxRelationshipCSVBytes+1))
+	if err != nil {
+		writeError(c, http.StatusBadRequest, "Failed to read uploaded file")
+		return nil, false
+	}
+	if len(data) > maxRelationshipCSVBytes {
+		writeError(c, http.StatusBadRequest, fmt.Sprintf("File size exceeds %dMB limit", maxRelationshipCSVBytes>>20))
+		return nil, false
+	}
+	return data, true
+}
+
+// resolveRelationshipCSV parses an import and resolves its rows to de-duplicated mappings. err is
+// set for files that can't be read at all, errs for rows that don't resolve.
+func resolveRelationshipCSV(kind models.RelationshipKind, data []byte, byID bool, keys *db.RelationshipKeys, result *models.RelationshipImportResult) (mappings []models.RelationshipMapping, errs []models.RelationshipImportError, err error) {
+	r := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))))
+	r.TrimLeadingSpace = true
+	r.FieldsPerRecord = -1
+	header, err := r.Read()
+	if err != nil {
+		return nil, nil, errors.New("CSV header row is missing")
+	}
+	index := map[string]int{}
+	for i, name := range header {
+		index[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	cols := relationshipColumns[kind]
+	// Column positions in relationshipColumns: id and key of the entity, organization, region
+	entityCol, orgCol, regionCol := cols[1], cols[3], ""
+	if byID {
+		entityCol, orgCol = cols[0], cols[2]
+	}
+	required := []string{entityCol, orgCol}
+	if kind == models.RelationshipProductSourcing {
+		regionCol = cols[5]
+		if byID {
+			regionCol = cols[4]
+		}
+		required = append(required, regionCol)
+	}
+	for _, col := range required {
+		if _, ok := index[col]; !ok {
+			return nil, nil, fmt.Errorf("CSV is missing the %s column", col)
+		}
+	}
+
+	type mappingKey struct {
+		entity, region int
+		org            string
+	}
+	seen := map[mappingKey]bool{}
+	entities := map[int]bool{}
+	fail := func(line int, format string, args ...interface{}) {
+		if len(errs) < maxRelationshipImportErrors {
+			errs = append(errs, models.RelationshipImportError{Line: line, Message: fmt.Sprintf(format, args...)})
+		}
+	}
+	for {
+		record, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		line, _ := r.FieldPos(0)
+		if err != nil {
+			var parseErr *csv.ParseError
+			if errors.As(err, &parseErr) {
+				line = parseErr.Line
+			}
+			fail(line, "malformed CSV: %v", err)
+			break
+		}
+		field := func(col string) string {
+			if i := index[col]; i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+		if strings.Join(record, "") == "" {
+			continue
+		}
+		result.Rows++
+
+		m := models.RelationshipMapping{}
+		entity := field(entityCol)
+		if byID {
+			id, err := strconv.Atoi(entity)
+			if err != nil || !keys.EntityIDs[id] {
+				fail(line, "unknown %s %q", entityCol, entity)
+				continue
+			}
+			m.EntityID = id
+		} else {
+			key := entity
+			if kind == models.RelationshipStorePartners {
+				key = strings.ToLower(key)
+			}
+			switch ids := keys.Entities[key]; len(ids) {
+			case 0:
+				fail(line, "unknown %s %q", entityCol, entity)
+				continue
+			case 1:
+				m.EntityID = ids[0]
+			default:
+				fail(line, "%s %q matches %d entries; import with match=id", entityCol, entity, len(ids))
+				continue
+			}
+		}
+
+		org := field(orgCol)
+		if byID {
+			if !keys.OrgIDs[org] {
+				fail(line, "unknown %s organization %q", kind.OrgType(), org)
+				continue
+			}
+			m.OrgID = org
+		} else {
+			switch ids := keys.Orgs[strings.ToLower(org)]; len(ids) {
+			case 0:
+				fail(line, "unknown %s organization %q", kind.OrgType(), org)
+				continue
+			case 1:
+				m.OrgID = ids[0]
+			default:
+				fail(line, "%s organization name %q is ambiguous; import with match=id", kind.OrgType(), org)
+				continue
+			}
+		}
+
+		if regionCol != "" {
+			region := field(regionCol)
+			var regionID int
+			var found bool
+			if byID {
+				regionID, _ = strconv.Atoi(region)
+				found = keys.RegionIDs[regionID]
+			} else if regionID, found = keys.Regions[strings.ToUpper(region)]; !found {
+				regionID, found = keys.Regions[strings.ToLower(region)]
+			}
+			if !found {
+				fail(line, "unknown region %q", region)
+				continue
+			}
+			m.RegionID = &regionID
+		}
+
+		k := mappingKey{entity: m.EntityID, org: m.OrgID}
+		if m.RegionID != nil {
+			k.region = *m.RegionID
+		}
+		if seen[k] {
+			continue
+		}
+		seen[k] = true
+		entities[m.EntityID] = true
+		mappings = append(mappings, m)
+	}
+	if len(errs) == 0 && result.Rows == 0 {
+		return nil, nil, errors.New("CSV has no mapping rows")
+	}
+	result.Entities = len(entities)
+	return mappings, errs, nil
+}
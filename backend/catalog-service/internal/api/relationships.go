@@ -15,15 +15,14 @@ func (h *Handler) SetProductSourcing(c *gin.Context) {
 	productIDStr := c.Param("id")
 	productID, err := strconv.Atoi(productIDStr)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product id"})
+		writeError(c, http.StatusBadRequest, "Invalid product id")
 		return
 	}
 
 	var body struct {
 		Mappings []models.ProductSourcing `json:"mappings" binding:"required"`
 	}
-	if err := c.ShouldBindJSON(&body); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+	if !bindJSON(c, &body) {
 		return
 	}
 
@@ -33,7 +32,7 @@ func (h *Handler) SetProductSourcing(c *gin.Context) {
 	}
 
 	if err := h.db.SetProductSourcing(c.Request.Context(), productID, body.Mappings); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save product sourcing"})
+		writeError(c, http.StatusInternalServerError, "Failed to save product sourcing")
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{"status": "ok"})
@@ -45,15 +44,14 @@ func (h *Handler) SetProductLogistics(c *gin.Context) {
 	productIDStr := c.Param("id")
 	productID, err := strconv.Atoi(productIDStr)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product id"})
+		writeError(c, http.StatusBadRequest, "Invalid product id")
 		return
 	}
 
 	var body struct {
 		Mappings []models.ProductLogistics `json:"mappings" binding:"required"`
 	}
-	if err := c.ShouldBindJSON(&body); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+	if !bindJSON(c, &body) {
 		return
 	}
 
@@ -63,7 +61,7 @@ func (h *Handler) SetProductLogistics(c *gin.Context) {
 	}
 
 	if err := h.db.SetProductLogistics(c.Request.Context(), productID, body.Mappings); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save product logistics"})
+		writeError(c, http.StatusInternalServerError, "Failed to save product logistics")
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{"status": "ok"})
@@ -75,7 +73,7 @@ func (h *Handler) GetProductSourcing(c *gin.Context) {
 	productIDStr := c.Param("id")
 	productID, err := strconv.Atoi(productIDStr)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product id"})
+		writeError(c, http.StatusBadRequest, "Invalid product id")
 		return
 	}
 
@@ -87,7 +85,7 @@ func (h *Handler) GetProductSourcing(c *gin.Context) {
         ORDER BY o.name
     `, productID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch product sourcing"})
+		writeError(c, http.StatusInternalServerError, "Failed to fetch product sourcing")
 		return
 	}
 	defer rows.Close()
@@ -101,7 +99,7 @@ func (h *Handler) GetProductSourcing(c *gin.Context) {
 	for rows.Next() {
 		var item sourcingResp
 		if err := rows.Scan(&item.ManufacturerOrgID, &item.RegionID, &item.Name); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to scan product sourcing"})
+			writeError(c, http.StatusInternalServerError, "Failed to scan product sourcing")
 			return
 		}
 		mappings = append(mappings, item)
@@ -119,7 +117,7 @@ func (h *Handler) GetProductLogistics(c *gin.Context) {
 	productIDStr := c.Param("id")
 	productID, err := strconv.Atoi(productIDStr)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product id"})
+		writeError(c, http.StatusBadRequest, "Invalid product id")
 		return
 	}
 
@@ -131,7 +129,7 @@ func (h *Handler) GetProductLogistics(c *gin.Context) {
         ORDER BY o.name
     `, productID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch product logistics"})
+		writeError(c, http.StatusInternalServerError, "Failed to fetch product logistics")
 		return
 	}
 	defer rows.Close()
@@ -144,7 +142,7 @@ func (h *Handler) GetProductLogistics(c *gin.Context) {
 	for rows.Next() {
 		var item logisticsResp
 		if err := rows.Scan(&item.TPLOrgID, &item.Name); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to scan product logistics"})
+			writeError(c, http.StatusInternalServerError, "Failed to scan product logistics")
 			return
 		}
 		mappings = append(mappings, item)
@@ -162,15 +160,14 @@ func (h *Handler) SetStorePartners(c *gin.Context) {
 	storeIDStr := c.Param("id")
 	storeID, err := strconv.Atoi(storeIDStr)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid store id"})
+		writeError(c, http.StatusBadRequest, "Invalid store id")
 		return
 	}
 
 	var body struct {
 		Mappings []models.StorePartner `json:"mappings" binding:"required"`
 	}
-	if err := c.ShouldBindJSON(&body); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+	if !bindJSON(c, &body) {
 		return
 	}
 
@@ -180,7 +177,9 @@ func (h *Handler) SetStorePartners(c *gin.Context) {
 	}
 
 	if err := h.db.SetStorePartners(c.Request.Context(), storeID, body.Mappings); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save store partners"})
+		if !writeOrganizationGraphError(c, err) {
+			writeError(c, http.StatusInternalServerError, "Failed to save store partners")
+		}
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{"status": "ok"})
@@ -192,7 +191,7 @@ func (h *Handler) GetStorePartners(c *gin.Context) {
 	storeIDStr := c.Param("id")
 	storeID, err := strconv.Atoi(storeIDStr)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid store id"})
+		writeError(c, http.StatusBadRequest, "Invalid store id")
 		return
 	}
 
@@ -204,7 +203,7 @@ func (h *Handler) GetStorePartners(c *gin.Context) {
         ORDER BY o.name
     `, storeID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch store partners"})
+		writeError(c, http.StatusInternalServerError, "Failed to fetch store partners")
 		return
 	}
 	defer rows.Close()
@@ -217,7 +216,7 @@ func (h *Handler) GetStorePartners(c *gin.Context) {
 	for rows.Next() {
 		var item partnerResp
 		if err := rows.Scan(&item.PartnerOrgID, &item.Name); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to scan store partners"})
+			writeError(c, http.StatusInternalServerError, "Failed to scan store partners")
 			return
 		}
 		partners = append(partners, item)
@@ -234,7 +233,7 @@ func (h *Handler) GetStorePartners(c *gin.Context) {
 func (h *Handler) GetStorePartnersBatch(c *gin.Context) {
 	idsParam := strings.TrimSpace(c.Query("store_ids"))
 	if idsParam == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "store_ids query parameter is required"})
+		writeError(c, http.StatusBadRequest, "store_ids query parameter is required")
 		return
 	}
 
@@ -247,13 +246,13 @@ func (h *Handler) GetStorePartnersBatch(c *gin.Context) {
 		}
 		id, err := strconv.Atoi(s)
 		if err != nil || id <= 0 {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid store id in store_ids: " + s})
+			writeError(c, http.StatusBadRequest, "invalid store id in store_ids: "+s)
 			return
 		}
 		ids = append(ids, id)
 	}
 	if len(ids) == 0 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "no valid store ids found"})
+		writeError(c, http.StatusBadRequest, "no valid store ids found")
 		return
 	}
 
@@ -273,7 +272,7 @@ func (h *Handler) GetStorePartnersBatch(c *gin.Context) {
   `
 	rows, err := h.db.Pool.Query(c.Request.Context(), sql, args...)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch store partners"})
+		writeError(c, http.StatusInternalServerError, "Failed to fetch store partners")
 		return
 	}
 	defer rows.Close()
@@ -295,7 +294,7 @@ func (h *Handler) GetStorePartnersBatch(c *gin.Context) {
 		var storeID int
 		var pid, name string
 		if err := rows.Scan(&storeID, &pid, &name); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to scan store partners"})
+			writeError(c, http.StatusInternalServerError, "Failed to scan store partners")
 			return
 		}
 		key := strconv.Itoa(storeID)
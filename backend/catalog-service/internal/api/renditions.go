@@ -0,0 +1,107 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/expotoworld/expotoworld/backend/catalog-service/internal/db"
+	"github.com/expotoworld/expotoworld/backend/catalog-service/internal/imaging"
+	"github.com/expotoworld/expotoworld/backend/catalog-service/internal/logging"
+	"github.com/expotoworld/expotoworld/backend/catalog-service/internal/models"
+	"github.com/expotoworld/expotoworld/backend/common/storage"
+)
+
+// renditionSlots bounds concurrent image processing so bulk uploads don't starve request handling
+var renditionSlots = make(chan struct{}, 2)
+
+// renditionKey is the S3 key of a rendition stored next to its original
+func renditionKey(objectKey, name string) string {
+	return strings.TrimSuffix(objectKey, path.Ext(objectKey)) + "_" + name + ".webp"
+}
+
+// renditionKeys lists every rendition key derived from an original's key
+func renditionKeys(objectKey string) []string {
+	specs := imaging.Specs()
+	keys := make([]string, 0, len(specs))
+	for _, spec := range specs {
+		keys = append(keys, renditionKey(objectKey, spec.Name))
+	}
+	return keys
+}
+
+// startRenditions generates renditions for an uploaded image in the background. data may be nil,
+// in which case the original is read back from storage first.
+func (h *Handler) startRenditions(table db.RenditionTable, ownerID, imageURL string, data []byte) {
+	if h.db == nil || os.Getenv("IMAGE_RENDITIONS_DISABLED") == "true" {
+		return
+	}
+	objectKey, ok := h.media.KeyFromURL(imageURL)
+	if !ok {
+		return
+	}
+	go func() {
+		renditionSlots <- struct{}{}
+		defer func() { <-renditionSlots }()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+		defer cancel()
+		fields := map[string]interface{}{"table": string(table), "owner_id": ownerID, "key": objectKey}
+		if err := h.generateRenditions(ctx, table, ownerID, imageURL, objectKey, data); err != nil {
+			fields["error"] = err.Error()
+			logging.LogKV("warn", "image_renditions_failed", fields)
+			return
+		}
+		logging.LogKV("info", "image_renditions_stored", fields)
+	}()
+}
+
+func (h *Handler) generateRenditions(ctx context.Context, table db.RenditionTable, ownerID, imageURL, objectKey string, data []byte) error {
+	if data == nil {
+		obj, err := h.media.GetObject(ctx, objectKey)
+		if err != nil {
+			return err
+		}
+		data, err = io.ReadAll(obj)
+		obj.Close()
+		if err != nil {
+			return err
+		}
+	}
+
+	outputs, err := imaging.Render(data, imaging.Specs())
+	if err != nil {
+		return err
+	}
+	var r models.ImageRenditions
+	meta := storage.ObjectMeta{ContentType: "image/webp", CacheControl: "public, max-age=31536000, immutable"}
+	for _, out := range outputs {
+		key := renditionKey(objectKey, out.Name)
+		if err := h.media.PutObject(ctx, key, bytes.NewReader(out.Data), meta); err != nil {
+			return err
+		}
+		url := h.media.PublicURL(key)
+		switch out.Name {
+		case imaging.Thumbnail:
+			r.ThumbnailURL = &url
+		case imaging.Medium:
+			r.MediumURL = &url
+		case imaging.Original:
+			r.WebPURL = &url
+		}
+	}
+
+	updated, err := h.db.SetImageRenditions(ctx, table, ownerID, imageURL, r)
+	if err != nil {
+		return err
+	}
+	if !updated {
+		// The image was replaced or removed meanwhile; don't leave its renditions behind
+		_ = h.media.Delete(ctx, renditionKeys(objectKey)...)
+	}
+	return nil
+}
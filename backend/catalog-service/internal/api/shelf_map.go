@@ -0,0 +1,129 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/expotoworld/expotoworld/backend/catalog-service/internal/db"
+	"github.com/expotoworld/expotoworld/backend/catalog-service/internal/models"
+	"github.com/gin-gonic/gin"
+)
+
+func shelfLayoutFieldError(err *db.ShelfCodeOutsideLayoutError) models.FieldError {
+	return models.FieldError{Field: "shelf_code", Code: "outside_layout", Message: err.Reason}
+}
+
+// checkProductShelfCode checks the shelf code a product write keeps (location-based mini-apps with a
+// store) against the store's shelf layout and stores it in canonical form. It writes the error
+// response itself when it returns false.
+func (h *Handler) checkProductShelfCode(ctx context.Context, c *gin.Context, p *models.Product) bool {
+	if p.ShelfCode == nil || strings.TrimSpace(*p.ShelfCode) == "" || p.StoreID == nil ||
+		p.MiniAppType == models.MiniAppTypeRetailStore || p.MiniAppType == models.MiniAppTypeGroupBuying {
+		return true
+	}
+	code, err := h.db.CanonicalShelfCode(ctx, *p.StoreID, strings.TrimSpace(*p.ShelfCode))
+	var layoutErr *db.ShelfCodeOutsideLayoutError
+	switch {
+	case errors.As(err, &layoutErr):
+		writeFieldErrors(c, shelfLayoutFieldError(layoutErr))
+		return false
+	case errors.Is(err, db.ErrStoreNotFound):
+		writeFieldErrors(c, models.FieldError{Field: "store_id", Code: "not_found", Message: "store not found"})
+		return false
+	case err != nil:
+		log.Printf("Failed to check shelf code against the layout of store %d: %v", *p.StoreID, err)
+		writeError(c, http.StatusInternalServerError, "Failed to validate shelf code")
+		return false
+	}
+	p.ShelfCode = &code
+	return true
+}
+
+func parseStoreIDParam(c *gin.Context) (int, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		writeError(c, http.StatusBadRequest, "Invalid store id")
+		return 0, false
+	}
+	return id, true
+}
+
+// GetStoreShelfMap handles GET /admin/stores/:id/shelf-map: every slot of the store's shelf layout
+// with the products and variants placed there, the free slots (gaps), slots used twice (conflicts)
+// and codes that don't fit the layout. Without a layout only the codes in use are listed.
+func (h *Handler) GetStoreShelfMap(c *gin.Context) {
+	storeID, ok := parseStoreIDParam(c)
+	if !ok {
+		return
+	}
+	ctx := c.Request.Context()
+	layout, err := h.db.GetStoreShelfLayout(ctx, storeID)
+	if errors.Is(err, db.ErrStoreNotFound) {
+		writeError(c, http.StatusNotFound, "Store not found")
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to load shelf layout of store %d: %v", storeID, err)
+		writeError(c, http.StatusInternalServerError, "Failed to load shelf map")
+		return
+	}
+	assignments, err := h.db.ListStoreShelfAssignments(ctx, storeID)
+	if err != nil {
+		log.Printf("Failed to load shelf assignments of store %d: %v", storeID, err)
+		writeError(c, http.StatusInternalServerError, "Failed to load shelf map")
+		return
+	}
+	c.JSON(http.StatusOK, models.BuildShelfMap(storeID, layout, assignments))
+}
+
+// SetStoreShelfLayout handles PUT /stores/:id/shelf-layout
+// Body: { "units": [{ "code": "A", "levels": 5, "positions": 12 }] }. Codes already in use that don't
+// fit the new layout are kept and listed under outside_layout in the shelf map.
+func (h *Handler) SetStoreShelfLayout(c *gin.Context) {
+	storeID, ok := parseStoreIDParam(c)
+	if !ok {
+		return
+	}
+	var layout models.ShelfLayout
+	if !bindJSON(c, &layout) {
+		return
+	}
+	if errs := layout.Normalize(); len(errs) > 0 {
+		writeFieldErrors(c, errs...)
+		return
+	}
+	err := h.db.SetStoreShelfLayout(c.Request.Context(), storeID, &layout)
+	if errors.Is(err, db.ErrStoreNotFound) {
+		writeError(c, http.StatusNotFound, "Store not found")
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to set shelf layout of store %d: %v", storeID, err)
+		writeError(c, http.StatusInternalServerError, "Failed to set shelf layout")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"store_id": storeID, "layout": layout, "capacity": layout.Capacity()})
+}
+
+// DeleteStoreShelfLayout handles DELETE /stores/:id/shelf-layout; shelf codes are free-form again
+func (h *Handler) DeleteStoreShelfLayout(c *gin.Context) {
+	storeID, ok := parseStoreIDParam(c)
+	if !ok {
+		return
+	}
+	err := h.db.SetStoreShelfLayout(c.Request.Context(), storeID, nil)
+	if errors.Is(err, db.ErrStoreNotFound) {
+		writeError(c, http.StatusNotFound, "Store not found")
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to remove shelf layout of store %d: %v", storeID, err)
+		writeError(c, http.StatusInternalServerError, "Failed to remove shelf layout")
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
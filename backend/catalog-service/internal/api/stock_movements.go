@@ -0,0 +1,98 @@
+package api
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/expotoworld/expotoworld/backend/catalog-service/internal/db"
+	"github.com/expotoworld/expotoworld/backend/catalog-service/internal/models"
+	"github.com/gin-gonic/gin"
+)
+
+const maxStockNoteLength = 500
+
+// AdjustProductStock handles POST /products/:id/stock-adjustments
+// Body: { "delta": -2, "reason": "damaged", "note": "Dropped during shelving" }
+// restock and customer_return must increase stock, damaged/expired/lost must decrease it, correction goes either way.
+func (h *Handler) AdjustProductStock(c *gin.Context) {
+	productID, ok := parseProductIDParam(c)
+	if !ok {
+		return
+	}
+	var req models.StockAdjustmentRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+	req.Reason = models.StockReason(strings.ToLower(strings.TrimSpace(string(req.Reason))))
+	req.Note = strings.TrimSpace(req.Note)
+	sign, ok := req.Reason.AdjustmentSign()
+	switch {
+	case !ok:
+		writeFieldErrors(c, models.FieldError{Field: "reason", Code: "invalid_choice",
+			Message: "reason must be one of restock, customer_return, damaged, expired, lost or correction"})
+		return
+	case sign > 0 && req.Delta < 0, sign < 0 && req.Delta > 0:
+		writeFieldErrors(c, models.FieldError{Field: "delta", Code: "wrong_sign", Message: "delta has the wrong sign for reason " + string(req.Reason)})
+		return
+	case len(req.Note) > maxStockNoteLength:
+		writeFieldErrors(c, models.FieldError{Field: "note", Code: "too_long"})
+		return
+	}
+
+	movement, err := h.db.AdjustProductStock(c.Request.Context(), productID, req, currentUserID(c))
+	if err != nil {
+		switch {
+		case errors.Is(err, db.ErrProductNotFound):
+			writeError(c, http.StatusNotFound, "Product not found")
+		case errors.Is(err, db.ErrInsufficientStock):
+			writeErrorDetails(c, http.StatusConflict, "INSUFFICIENT_STOCK", "Adjustment would take stock below zero", nil)
+		default:
+			log.Printf("Error adjusting stock for product %d: %v", productID, err)
+			writeError(c, http.StatusInternalServerError, "Failed to adjust stock")
+		}
+		return
+	}
+	log.Printf("[STOCK] product %d %+d (%s) by %s: %d -> %d", productID, movement.Delta, movement.Reason, movement.Actor, movement.StockBefore, movement.StockAfter)
+	c.JSON(http.StatusCreated, movement)
+}
+
+// GetProductStockHistory handles GET /products/:id/stock-history[?limit=50&before_id=]
+func (h *Handler) GetProductStockHistory(c *gin.Context) {
+	productID, ok := parseProductIDParam(c)
+	if !ok {
+		return
+	}
+	limit := 50
+	if v := c.Query("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 || n > 500 {
+			writeError(c, http.StatusBadRequest, "limit must be between 1 and 500")
+			return
+		}
+		limit = n
+	}
+	var beforeID int64
+	if v := c.Query("before_id"); v != "" {
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || n <= 0 {
+			writeError(c, http.StatusBadRequest, "Invalid before_id")
+			return
+		}
+		beforeID = n
+	}
+
+	movements, err := h.db.ListStockMovements(c.Request.Context(), productID, beforeID, limit)
+	if err != nil {
+		log.Printf("Error listing stock history for product %d: %v", productID, err)
+		writeError(c, http.StatusInternalServerError, "Failed to fetch stock history")
+		return
+	}
+	resp := gin.H{"product_id": productID, "movements": movements}
+	if len(movements) == limit {
+		resp["next_before_id"] = movements[len(movements)-1].ID
+	}
+	c.JSON(http.StatusOK, resp)
+}
@@ -0,0 +1,174 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/expotoworld/expotoworld/backend/catalog-service/internal/db"
+	"github.com/expotoworld/expotoworld/backend/catalog-service/internal/logging"
+	"github.com/expotoworld/expotoworld/backend/catalog-service/internal/models"
+	"github.com/expotoworld/expotoworld/backend/catalog-service/internal/notify"
+	"github.com/gin-gonic/gin"
+)
+
+// currentUserID returns the authenticated user's id ("" if absent)
+func currentUserID(c *gin.Context) string {
+	if v, ok := c.Get("user_id"); ok && v != nil {
+		return fmt.Sprint(v)
+	}
+	return ""
+}
+
+// SubscribeStock handles POST /products/:id/subscribe-stock
+// Products that are currently in stock cannot be subscribed to (409).
+func (h *Handler) SubscribeStock(c *gin.Context) {
+	userID := currentUserID(c)
+	if userID == "" {
+		writeError(c, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+	var req struct {
+		MiniAppType string `json:"mini_app_type"`
+	}
+	if c.Request.ContentLength > 0 {
+		if !bindJSON(c, &req) {
+			return
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	productID, stock, err := h.db.ResolveActiveProduct(ctx, c.Param("id"))
+	if errors.Is(err, db.ErrProductNotFound) {
+		writeError(c, http.StatusNotFound, "Product not found")
+		return
+	}
+	if err != nil {
+		log.Printf("Error resolving product %s: %v", c.Param("id"), err)
+		writeError(c, http.StatusInternalServerError, "Failed to subscribe")
+		return
+	}
+	if stock > 0 {
+		writeErrorDetails(c, http.StatusConflict, "IN_STOCK", "Product is in stock", gin.H{"stock_left": stock})
+		return
+	}
+
+	created, err := h.db.SubscribeStock(ctx, productID, userID, req.MiniAppType)
+	if err != nil {
+		log.Printf("Error subscribing user %s to product %d: %v", userID, productID, err)
+		writeError(c, http.StatusInternalServerError, "Failed to subscribe")
+		return
+	}
+	status := http.StatusOK
+	if created {
+		status = http.StatusCreated
+	}
+	c.JSON(status, gin.H{"product_id": productID, "subscribed": true})
+}
+
+// UnsubscribeStock handles DELETE /products/:id/subscribe-stock
+func (h *Handler) UnsubscribeStock(c *gin.Context) {
+	userID := currentUserID(c)
+	if userID == "" {
+		writeError(c, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	productID, _, err := h.db.ResolveActiveProduct(ctx, c.Param("id"))
+	if errors.Is(err, db.ErrProductNotFound) {
+		writeError(c, http.StatusNotFound, "Product not found")
+		return
+	}
+	if err != nil {
+		log.Printf("Error resolving product %s: %v", c.Param("id"), err)
+		writeError(c, http.StatusInternalServerError, "Failed to unsubscribe")
+		return
+	}
+	removed, err := h.db.UnsubscribeStock(ctx, productID, userID)
+	if err != nil {
+		log.Printf("Error unsubscribing user %s from product %d: %v", userID, productID, err)
+		writeError(c, http.StatusInternalServerError, "Failed to unsubscribe")
+		return
+	}
+	if !removed {
+		writeError(c, http.StatusNotFound, "Subscription not found")
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// GetMyStockSubscriptions handles GET /me/stock-subscriptions
+func (h *Handler) GetMyStockSubscriptions(c *gin.Context) {
+	userID := currentUserID(c)
+	if userID == "" {
+		writeError(c, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	subs, err := h.db.ListUserStockSubscriptions(ctx, userID)
+	if err != nil {
+		log.Printf("Error listing stock subscriptions for %s: %v", userID, err)
+		writeError(c, http.StatusInternalServerError, "Failed to fetch subscriptions")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"subscriptions": subs})
+}
+
+// StartStockNotificationJobs drains back-in-stock events, notifying and unsubscribing the waiting users.
+// It returns when ctx is cancelled.
+func (h *Handler) StartStockNotificationJobs(ctx context.Context) {
+	interval := time.Duration(getEnvInt("STOCK_NOTIFY_POLL_SECONDS", 30)) * time.Second
+	maxAttempts := getEnvInt("STOCK_NOTIFY_MAX_ATTEMPTS", 5)
+	dispatcher := notify.NewDispatcherFromEnv()
+
+	send := func(ctx context.Context, ev models.StockEvent, s models.StockSubscription) error {
+		data := map[string]interface{}{"product_id": ev.ProductID, "product_uuid": ev.ProductUUID, "mini_app_type": ev.MiniAppType}
+		if ev.ImageURL != nil {
+			data["image_url"] = *ev.ImageURL
+		}
+		return dispatcher.Dispatch(ctx, notify.Notification{
+			Type:   "product_back_in_stock",
+			UserID: s.UserID,
+			Title:  "Back in stock",
+			Body:   ev.Title + " is available again.",
+			Data:   data,
+		})
+	}
+
+	run := func() {
+		for ctx.Err() == nil {
+			jobCtx, cancel := context.WithTimeout(ctx, 2*time.Minute)
+			ok, err := h.db.ProcessNextStockEvent(jobCtx, maxAttempts, send)
+			cancel()
+			if err != nil {
+				logging.LogKV("error", "StockNotificationFailed", map[string]interface{}{"error": err.Error()})
+				return
+			}
+			if !ok {
+				return
+			}
+		}
+	}
+
+	run()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			run()
+		}
+	}
+}
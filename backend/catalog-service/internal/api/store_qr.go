@@ -0,0 +1,218 @@
+package api
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/expotoworld/expotoworld/backend/catalog-service/internal/db"
+	"github.com/expotoworld/expotoworld/backend/catalog-service/internal/models"
+	"github.com/expotoworld/expotoworld/backend/catalog-service/internal/qrcode"
+	"github.com/gin-gonic/gin"
+)
+
+// storeQRPrefix is the deep link the store QR codes open (STORE_QR_URI_PREFIX)
+func storeQRPrefix() string {
+	if v := strings.TrimSpace(os.Getenv("STORE_QR_URI_PREFIX")); v != "" {
+		return v
+	}
+	return "expotoworld://store"
+}
+
+// storeQRSignature signs the fields of a store QR code with STORE_QR_SECRET; ok is false without a secret
+func storeQRSignature(storeID int, miniAppType models.MiniAppType, version int) (string, bool) {
+	secret := os.Getenv("STORE_QR_SECRET")
+	if secret == "" {
+		return "", false
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	fmt.Fprintf(mac, "store=%d&type=%s&v=%d", storeID, miniAppType, version)
+	// 128 bits keep the payload short enough for a small, easily scanned code
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil)[:16]), true
+}
+
+func storeQRPayload(qr models.StoreQR, sig string) string {
+	q := url.Values{}
+	q.Set("store", strconv.Itoa(qr.StoreID))
+	q.Set("type", string(qr.MiniAppType))
+	q.Set("v", strconv.Itoa(qr.Version))
+	q.Set("sig", sig)
+	return storeQRPrefix() + "?" + q.Encode()
+}
+
+// parseStoreQRPayload checks the signature of a scanned payload and returns the store, mini-app
+// type and version it names
+func parseStoreQRPayload(payload string) (storeID int, miniAppType models.MiniAppType, version int, ok bool) {
+	_, rawQuery, found := strings.Cut(payload, "?")
+	if !found {
+		return 0, "", 0, false
+	}
+	q, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return 0, "", 0, false
+	}
+	storeID, err1 := strconv.Atoi(q.Get("store"))
+	version, err2 := strconv.Atoi(q.Get("v"))
+	miniAppType = models.MiniAppType(q.Get("type"))
+	if err1 != nil || err2 != nil || storeID <= 0 || version <= 0 {
+		return 0, "", 0, false
+	}
+	want, configured := storeQRSignature(storeID, miniAppType, version)
+	if !configured || !hmac.Equal([]byte(want), []byte(q.Get("sig"))) {
+		return 0, "", 0, false
+	}
+	return storeID, miniAppType, version, true
+}
+
+// writeStoreQR renders a store QR code in the format query parameter: png (default), svg or json.
+// scale is the PNG/SVG size of a module in pixels (default 8).
+func writeStoreQR(c *gin.Context, status int, qr *models.StoreQR) {
+	format := c.DefaultQuery("format", "png")
+	if format == "json" {
+		c.JSON(status, qr)
+		return
+	}
+	if format != "png" && format != "svg" {
+		writeError(c, http.StatusBadRequest, "format must be png, svg or json")
+		return
+	}
+	scale := 8
+	if v := c.Query("scale"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > 40 {
+			writeError(c, http.StatusBadRequest, "scale must be between 1 and 40")
+			return
+		}
+		scale = n
+	}
+	code, err := qrcode.Encode(qr.Payload)
+	if err != nil {
+		log.Printf("Failed to encode QR code of store %d: %v", qr.StoreID, err)
+		writeError(c, http.StatusInternalServerError, "Failed to render QR code")
+		return
+	}
+
+	c.Header("X-QR-Version", strconv.Itoa(qr.Version))
+	c.Header("Content-Disposition", fmt.Sprintf(`inline; filename="store-%d-qr-v%d.%s"`, qr.StoreID, qr.Version, format))
+	if format == "svg" {
+		c.Data(status, "image/svg+xml", code.SVG(scale))
+		return
+	}
+	img, err := code.PNG(scale)
+	if err != nil {
+		log.Printf("Failed to render QR code of store %d: %v", qr.StoreID, err)
+		writeError(c, http.StatusInternalServerError, "Failed to render QR code")
+		return
+	}
+	c.Data(status, "image/png", img)
+}
+
+// CreateStoreQR handles POST /admin/stores/:id/qr: provisions a new signed entry QR code for the
+// store, revoking the previous one, and returns it as PNG, SVG or JSON (?format=)
+func (h *Handler) CreateStoreQR(c *gin.Context) {
+	storeID, ok := parseStoreIDParam(c)
+	if !ok {
+		return
+	}
+	if _, configured := storeQRSignature(storeID, "", 0); !configured {
+		writeError(c, http.StatusServiceUnavailable, "Store QR codes are not configured")
+		return
+	}
+	qr, err := h.db.RotateStoreQR(c.Request.Context(), storeID, func(qr models.StoreQR) string {
+		sig, _ := storeQRSignature(qr.StoreID, qr.MiniAppType, qr.Version)
+		return storeQRPayload(qr, sig)
+	})
+	if errors.Is(err, db.ErrStoreNotFound) {
+		writeError(c, http.StatusNotFound, "Store not found")
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to provision QR code of store %d: %v", storeID, err)
+		writeError(c, http.StatusInternalServerError, "Failed to provision QR code")
+		return
+	}
+	writeStoreQR(c, http.StatusCreated, qr)
+}
+
+// GetStoreQR handles GET /admin/stores/:id/qr: the store's current QR code, for reprinting
+func (h *Handler) GetStoreQR(c *gin.Context) {
+	storeID, ok := parseStoreIDParam(c)
+	if !ok {
+		return
+	}
+	qr, err := h.db.GetStoreQR(c.Request.Context(), storeID)
+	if errors.Is(err, db.ErrStoreNotFound) {
+		writeError(c, http.StatusNotFound, "Store not found")
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to load QR code of store %d: %v", storeID, err)
+		writeError(c, http.StatusInternalServerError, "Failed to load QR code")
+		return
+	}
+	if qr == nil {
+		writeError(c, http.StatusNotFound, "No QR code provisioned for this store")
+		return
+	}
+	writeStoreQR(c, http.StatusOK, qr)
+}
+
+// ResolveStoreQR handles GET /stores/resolve-qr?payload=: the app validates a scanned store code
+// before entering the store. Forged codes get 400 INVALID_QR, codes replaced by a newer one or
+// printed before the store changed type get 410 QR_REVOKED, inactive stores 404.
+func (h *Handler) ResolveStoreQR(c *gin.Context) {
+	payload := strings.TrimSpace(c.Query("payload"))
+	if payload == "" {
+		writeError(c, http.StatusBadRequest, "payload is required")
+		return
+	}
+	storeID, miniAppType, version, ok := parseStoreQRPayload(payload)
+	if !ok {
+		writeErrorDetails(c, http.StatusBadRequest, "INVALID_QR", "Not a valid store QR code", nil)
+		return
+	}
+
+	ctx := c.Request.Context()
+	current, err := h.db.GetStoreQR(ctx, storeID)
+	if errors.Is(err, db.ErrStoreNotFound) {
+		writeError(c, http.StatusNotFound, "Store not found")
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to load QR code of store %d: %v", storeID, err)
+		writeError(c, http.StatusInternalServerError, "Failed to resolve QR code")
+		return
+	}
+	if current == nil || current.Version != version || current.MiniAppType != miniAppType {
+		writeErrorDetails(c, http.StatusGone, "QR_REVOKED", "This store QR code has been replaced", nil)
+		return
+	}
+
+	stores, err := h.db.ListCatalogStores(ctx, "", db.CatalogFilter{IDs: []int{storeID}})
+	if err != nil {
+		log.Printf("Failed to load store %d: %v", storeID, err)
+		writeError(c, http.StatusInternalServerError, "Failed to resolve QR code")
+		return
+	}
+	if len(stores) == 0 {
+		writeError(c, http.StatusNotFound, "Store not found")
+		return
+	}
+	store := stores[0]
+	store.IsOpenNow = store.IsOpenAt(time.Now())
+	c.JSON(http.StatusOK, models.StoreQRResolution{
+		StoreID:     storeID,
+		StoreType:   current.StoreType,
+		MiniAppType: current.MiniAppType,
+		Store:       store,
+	})
+}
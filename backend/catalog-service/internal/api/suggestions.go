@@ -0,0 +1,99 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/expotoworld/expotoworld/backend/catalog-service/internal/logging"
+	"github.com/expotoworld/expotoworld/backend/catalog-service/internal/models"
+	"github.com/gin-gonic/gin"
+)
+
+// Suggestions back the mobile search bar's type-ahead, so they are capped and held to a latency budget
+const (
+	maxSuggestions = 10
+	// suggestBudget is the latency target; the trigram lookup only gets what the prefix match left of it
+	suggestBudget = 50 * time.Millisecond
+	// minFuzzyBudget is the least time worth giving the trigram lookup
+	minFuzzyBudget = 10 * time.Millisecond
+	// minFuzzyQueryRunes: shorter queries have too few trigrams to match on
+	minFuzzyQueryRunes = 3
+)
+
+// GetProductSuggestions handles GET /products/suggest?q=&mini_app_type=&limit=10 for the search box's
+// type-ahead. An empty q returns no suggestions rather than an error, since it fires on every keystroke.
+// Prefix matches on titles, SKUs and categories come first; when they don't fill the limit, products
+// with a similar title or SKU (trigrams, e.g. despite a typo) follow if time remains in the budget.
+// limit is capped at 10.
+func (h *Handler) GetProductSuggestions(c *gin.Context) {
+	start := time.Now()
+	q := strings.TrimSpace(c.Query("q"))
+	if utf8.RuneCountInString(q) > 100 {
+		writeError(c, http.StatusBadRequest, "q must be at most 100 characters")
+		return
+	}
+	limit := maxSuggestions
+	if v := c.Query("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			writeError(c, http.StatusBadRequest, "Invalid limit")
+			return
+		}
+		limit = min(n, maxSuggestions)
+	}
+	miniAppType := c.Query("mini_app_type")
+
+	suggestions := []models.Suggestion{}
+	if q != "" {
+		ctx := c.Request.Context()
+		var err error
+		suggestions, err = h.db.GetSuggestions(ctx, q, miniAppType, limit)
+		if err != nil {
+			log.Printf("Error fetching suggestions for %q: %v", q, err)
+			writeError(c, http.StatusInternalServerError, "Failed to fetch suggestions")
+			return
+		}
+		if len(suggestions) < limit && utf8.RuneCountInString(q) >= minFuzzyQueryRunes && h.db.FuzzySuggestionsAvailable() {
+			suggestions = h.appendFuzzySuggestions(ctx, q, miniAppType, suggestions, limit, suggestBudget-time.Since(start))
+		}
+	}
+
+	elapsed := time.Since(start)
+	if elapsed > suggestBudget {
+		logging.LogKV("warn", "SlowProductSuggestions", map[string]interface{}{"q_length": utf8.RuneCountInString(q), "duration_ms": elapsed.Milliseconds()})
+	}
+	c.Header("Server-Timing", fmt.Sprintf("suggest;dur=%.1f", float64(elapsed.Microseconds())/1000))
+	c.Header("Cache-Control", fmt.Sprintf("public, max-age=%d", getEnvInt("PRODUCT_SUGGEST_CACHE_SECONDS", 30)))
+	c.JSON(http.StatusOK, gin.H{"query": q, "suggestions": suggestions})
+}
+
+// appendFuzzySuggestions fills the remaining places with trigram matches. A lookup that runs out of
+// budget is dropped: the prefix matches are still worth returning quickly.
+func (h *Handler) appendFuzzySuggestions(ctx context.Context, q, miniAppType string, suggestions []models.Suggestion, limit int, budget time.Duration) []models.Suggestion {
+	if budget < minFuzzyBudget {
+		return suggestions
+	}
+	var seen []int
+	for _, s := range suggestions {
+		if s.ProductID != nil {
+			seen = append(seen, *s.ProductID)
+		}
+	}
+	fuzzyCtx, cancel := context.WithTimeout(ctx, budget)
+	defer cancel()
+	fuzzy, err := h.db.GetFuzzySuggestions(fuzzyCtx, q, miniAppType, seen, limit-len(suggestions))
+	if err != nil {
+		if !errors.Is(fuzzyCtx.Err(), context.DeadlineExceeded) {
+			log.Printf("Error fetching fuzzy suggestions for %q: %v", q, err)
+		}
+		return suggestions
+	}
+	return append(suggestions, fuzzy...)
+}
@@ -0,0 +1,169 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/expotoworld/expotoworld/backend/catalog-service/internal/db"
+	"github.com/expotoworld/expotoworld/backend/catalog-service/internal/models"
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+)
+
+type taxRateRequest struct {
+	RatePct *float64 `json:"rate_pct" binding:"required,min=0,max=100"`
+}
+
+// checkProductTaxClass normalizes the product's tax class and validates it; nil means valid.
+// Active products must have a class, otherwise checkout can't find their rate.
+func checkProductTaxClass(p *models.Product) *models.FieldError {
+	if p.TaxClass != nil {
+		class := models.TaxClass(strings.ToLower(strings.TrimSpace(string(*p.TaxClass))))
+		if class == "" {
+			p.TaxClass = nil
+		} else if !class.IsValid() {
+			return &models.FieldError{Field: "tax_class", Code: "invalid_choice"}
+		} else {
+			p.TaxClass = &class
+		}
+	}
+	if p.IsActive && p.TaxClass == nil {
+		return &models.FieldError{Field: "tax_class", Code: "required_when_active"}
+	}
+	return nil
+}
+
+// parseTaxRateCell reads :region_id and :tax_class; it writes the error response itself
+func parseTaxRateCell(c *gin.Context) (int, models.TaxClass, bool) {
+	regionID, err := strconv.Atoi(c.Param("region_id"))
+	if err != nil || regionID <= 0 {
+		writeError(c, http.StatusBadRequest, "Invalid region id")
+		return 0, "", false
+	}
+	class := models.TaxClass(strings.ToLower(c.Param("tax_class")))
+	if !class.IsValid() {
+		writeError(c, http.StatusBadRequest, "tax_class must be one of standard, reduced or exempt")
+		return 0, "", false
+	}
+	return regionID, class, true
+}
+
+// ListRegionTaxRates handles GET /tax-rates[?region_id=]
+func (h *Handler) ListRegionTaxRates(c *gin.Context) {
+	var regionID *int
+	if v := c.Query("region_id"); v != "" {
+		id, err := strconv.Atoi(v)
+		if err != nil || id <= 0 {
+			writeError(c, http.StatusBadRequest, "Invalid region_id")
+			return
+		}
+		regionID = &id
+	}
+	rates, err := h.db.ListRegionTaxRates(c.Request.Context(), regionID)
+	if err != nil {
+		log.Printf("Error listing tax rates: %v", err)
+		writeError(c, http.StatusInternalServerError, "Failed to fetch tax rates")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"tax_classes": models.TaxClasses, "tax_rates": rates})
+}
+
+// SetRegionTaxRate handles PUT /tax-rates/:region_id/:tax_class
+// Body: { "rate_pct": 19 }
+func (h *Handler) SetRegionTaxRate(c *gin.Context) {
+	regionID, class, ok := parseTaxRateCell(c)
+	if !ok {
+		return
+	}
+	var req taxRateRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+	if class == models.TaxClassExempt && *req.RatePct != 0 {
+		writeFieldErrors(c, models.FieldError{Field: "rate_pct", Code: "must_be_zero", Message: "exempt products must have a rate of 0"})
+		return
+	}
+
+	rate := &models.RegionTaxRate{RegionID: regionID, TaxClass: class, RatePct: *req.RatePct}
+	if v, ok := c.Get("user_id"); ok {
+		rate.UpdatedBy = fmt.Sprint(v)
+	}
+	saved, err := h.db.SetRegionTaxRate(c.Request.Context(), rate)
+	if err != nil {
+		if errors.Is(err, db.ErrRegionNotFound) {
+			writeError(c, http.StatusNotFound, "Region not found")
+			return
+		}
+		log.Printf("Error setting tax rate for region %d (%s): %v", regionID, class, err)
+		writeError(c, http.StatusInternalServerError, "Failed to save tax rate")
+		return
+	}
+	log.Printf("[TAX] region %d %s rate set to %.2f%% by %s", regionID, class, saved.RatePct, saved.UpdatedBy)
+	c.JSON(http.StatusOK, saved)
+}
+
+// DeleteRegionTaxRate handles DELETE /tax-rates/:region_id/:tax_class
+// Products of that class can no longer be sold in the region until a rate is set again.
+func (h *Handler) DeleteRegionTaxRate(c *gin.Context) {
+	regionID, class, ok := parseTaxRateCell(c)
+	if !ok {
+		return
+	}
+	if err := h.db.DeleteRegionTaxRate(c.Request.Context(), regionID, class); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			writeError(c, http.StatusNotFound, "Tax rate not found")
+			return
+		}
+		log.Printf("Error deleting tax rate for region %d (%s): %v", regionID, class, err)
+		writeError(c, http.StatusInternalServerError, "Failed to delete tax rate")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Tax rate deleted"})
+}
+
+// ResolveTaxRate handles GET /tax-rates/resolve?product_id=[&region_id=]
+// Without region_id the region of the product's store is used.
+func (h *Handler) ResolveTaxRate(c *gin.Context) {
+	productID, err := strconv.Atoi(c.Query("product_id"))
+	if err != nil || productID <= 0 {
+		writeError(c, http.StatusBadRequest, "product_id is required")
+		return
+	}
+	var regionID *int
+	if v := c.Query("region_id"); v != "" {
+		id, err := strconv.Atoi(v)
+		if err != nil || id <= 0 {
+			writeError(c, http.StatusBadRequest, "Invalid region_id")
+			return
+		}
+		regionID = &id
+	}
+
+	res, err := h.db.ResolveTaxRate(c.Request.Context(), productID, regionID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			writeError(c, http.StatusNotFound, "Product not found")
+			return
+		}
+		log.Printf("Error resolving tax rate for product %d: %v", productID, err)
+		writeError(c, http.StatusInternalServerError, "Failed to resolve tax rate")
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+// ListUnclassifiedProducts handles GET /tax-rates/unclassified-products
+// Lists active products created before tax classes existed, which can't be sold until classified.
+func (h *Handler) ListUnclassifiedProducts(c *gin.Context) {
+	products, err := h.db.ListUnclassifiedProducts(c.Request.Context())
+	if err != nil {
+		log.Printf("Error listing unclassified products: %v", err)
+		writeError(c, http.StatusInternalServerError, "Failed to fetch products")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"products": products})
+}
@@ -0,0 +1,231 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/expotoworld/expotoworld/backend/catalog-service/internal/logging"
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+)
+
+// productLocales returns the locales products are localized into (PRODUCT_LOCALES, comma-separated)
+func productLocales() []string {
+	raw := os.Getenv("PRODUCT_LOCALES")
+	if strings.TrimSpace(raw) == "" {
+		raw = "en,de,zh"
+	}
+	var out []string
+	for _, l := range strings.Split(raw, ",") {
+		if l = normalizeLocale(l); l != "" {
+			out = append(out, l)
+		}
+	}
+	return out
+}
+
+// defaultProductLocale is the locale products are assumed to be authored in (PRODUCT_DEFAULT_LOCALE)
+func defaultProductLocale() string {
+	if l := normalizeLocale(os.Getenv("PRODUCT_DEFAULT_LOCALE")); l != "" {
+		return l
+	}
+	return "en"
+}
+
+// normalizeLocale lower-cases the language and upper-cases the region ("zh_cn" -> "zh-CN")
+func normalizeLocale(l string) string {
+	parts := strings.SplitN(strings.ReplaceAll(strings.TrimSpace(l), "_", "-"), "-", 2)
+	if parts[0] == "" {
+		return ""
+	}
+	out := strings.ToLower(parts[0])
+	if len(parts) == 2 && parts[1] != "" {
+		out += "-" + strings.ToUpper(parts[1])
+	}
+	return out
+}
+
+// draftTargets resolves the requested draft locales ("all" = every configured locale), excluding the source
+func draftTargets(requested, sourceLocale string) []string {
+	var targets []string
+	if strings.EqualFold(strings.TrimSpace(requested), "all") {
+		targets = productLocales()
+	} else {
+		for _, l := range strings.Split(requested, ",") {
+			if l = normalizeLocale(l); l != "" {
+				targets = append(targets, l)
+			}
+		}
+	}
+	out := make([]string, 0, len(targets))
+	seen := map[string]bool{sourceLocale: true}
+	for _, l := range targets {
+		if !seen[l] {
+			seen[l] = true
+			out = append(out, l)
+		}
+	}
+	return out
+}
+
+// draftProductTranslations machine-translates the product's title/description into each target locale
+// and stores the results as unconfirmed drafts. Returns the locales that were drafted.
+func (h *Handler) draftProductTranslations(ctx context.Context, productID int, sourceLocale string, targets []string) ([]string, error) {
+	title, description, err := h.db.GetProductText(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+	drafted := []string{}
+	var lastErr error
+	for _, locale := range targets {
+		texts, err := h.translator.Translate(ctx, []string{title, description}, sourceLocale, locale)
+		if err != nil {
+			lastErr = err
+			logging.LogKV("error", "ProductTranslationDraftFailed", map[string]interface{}{"product_id": productID, "locale": locale, "error": err.Error()})
+			continue
+		}
+		saved, err := h.db.SaveMachineDraft(ctx, productID, locale, sourceLocale, h.translator.Name(), texts[0], texts[1])
+		if err != nil {
+			return drafted, err
+		}
+		if saved {
+			drafted = append(drafted, locale)
+		}
+	}
+	if len(drafted) == 0 && lastErr != nil {
+		return drafted, lastErr
+	}
+	return drafted, nil
+}
+
+// startTranslationDrafts records the source text of a newly created product and, when requested via
+// ?draft_locales= (comma-separated or "all") and a provider is configured, drafts the other locales in
+// the background so product creation is not slowed down by the provider.
+func (h *Handler) startTranslationDrafts(c *gin.Context, productID int) {
+	sourceLocale := normalizeLocale(c.Query("source_locale"))
+	if sourceLocale == "" {
+		sourceLocale = defaultProductLocale()
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	title, description, err := h.db.GetProductText(ctx, productID)
+	if err == nil {
+		err = h.db.SaveSourceTranslation(ctx, productID, sourceLocale, title, description)
+	}
+	if err != nil {
+		log.Printf("Failed to record source translation for product %d: %v", productID, err)
+	}
+
+	targets := draftTargets(c.Query("draft_locales"), sourceLocale)
+	if h.translator == nil || len(targets) == 0 {
+		return
+	}
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+		defer cancel()
+		drafted, err := h.draftProductTranslations(ctx, productID, sourceLocale, targets)
+		if err != nil {
+			logging.LogKV("error", "ProductTranslationDraftsFailed", map[string]interface{}{"product_id": productID, "error": err.Error()})
+			return
+		}
+		logging.LogKV("info", "ProductTranslationsDrafted", map[string]interface{}{"product_id": productID, "locales": drafted})
+	}()
+}
+
+// GetProductTranslations handles GET /products/:id/translations
+func (h *Handler) GetProductTranslations(c *gin.Context) {
+	productID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		writeError(c, http.StatusBadRequest, "Invalid product ID")
+		return
+	}
+	translations, err := h.db.GetProductTranslations(c.Request.Context(), productID)
+	if err != nil {
+		log.Printf("Error fetching translations for product %d: %v", productID, err)
+		writeError(c, http.StatusInternalServerError, "Failed to fetch translations")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"translations": translations, "locales": productLocales()})
+}
+
+// DraftProductTranslations handles POST /products/:id/translations/draft?source_locale=&locales=
+// Synchronously (re)drafts machine translations; confirmed translations are left untouched.
+func (h *Handler) DraftProductTranslations(c *gin.Context) {
+	productID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		writeError(c, http.StatusBadRequest, "Invalid product ID")
+		return
+	}
+	if h.translator == nil {
+		writeError(c, http.StatusServiceUnavailable, "No translation provider configured")
+		return
+	}
+	sourceLocale := normalizeLocale(c.Query("source_locale"))
+	if sourceLocale == "" {
+		sourceLocale = defaultProductLocale()
+	}
+	targets := draftTargets(c.DefaultQuery("locales", "all"), sourceLocale)
+	if len(targets) == 0 {
+		writeError(c, http.StatusBadRequest, "No target locales")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 2*time.Minute)
+	defer cancel()
+	drafted, err := h.draftProductTranslations(ctx, productID, sourceLocale, targets)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			writeError(c, http.StatusNotFound, "Product not found")
+			return
+		}
+		log.Printf("Error drafting translations for product %d: %v", productID, err)
+		writeError(c, http.StatusBadGateway, "Failed to draft translations: "+err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"drafted": drafted})
+}
+
+// ConfirmProductTranslation handles PUT /products/:id/translations/:locale
+// Saving a translation marks it as reviewed; machine drafting will no longer overwrite it.
+func (h *Handler) ConfirmProductTranslation(c *gin.Context) {
+	productID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		writeError(c, http.StatusBadRequest, "Invalid product ID")
+		return
+	}
+	locale := normalizeLocale(c.Param("locale"))
+	if locale == "" {
+		writeError(c, http.StatusBadRequest, "Invalid locale")
+		return
+	}
+	var req struct {
+		Title       string `json:"title" binding:"required"`
+		Description string `json:"description"`
+	}
+	if !bindJSON(c, &req) {
+		return
+	}
+	if _, _, err := h.db.GetProductText(c.Request.Context(), productID); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			writeError(c, http.StatusNotFound, "Product not found")
+			return
+		}
+		log.Printf("Error fetching product %d: %v", productID, err)
+		writeError(c, http.StatusInternalServerError, "Failed to save translation")
+		return
+	}
+
+	t, err := h.db.ConfirmTranslation(c.Request.Context(), productID, locale, strings.TrimSpace(req.Title), req.Description, currentUserID(c))
+	if err != nil {
+		log.Printf("Error saving translation %s for product %d: %v", locale, productID, err)
+		writeError(c, http.StatusInternalServerError, "Failed to save translation")
+		return
+	}
+	c.JSON(http.StatusOK, t)
+}
@@ -0,0 +1,147 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/expotoworld/expotoworld/backend/catalog-service/internal/db"
+	"github.com/expotoworld/expotoworld/backend/catalog-service/internal/logging"
+	"github.com/expotoworld/expotoworld/backend/catalog-service/internal/models"
+	"github.com/gin-gonic/gin"
+)
+
+// trashRetention is how long hard-deleted items stay in the trash (TRASH_RETENTION_DAYS, default 30)
+func trashRetention() time.Duration {
+	days := getEnvInt("TRASH_RETENTION_DAYS", 30)
+	if days < 1 {
+		days = 1
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
+
+// trashMediaPrefix is the storage folder of an item's images, removed when it is purged
+func trashMediaPrefix(kind string, id int) string {
+	folder := map[string]string{
+		models.TrashProduct:  "products",
+		models.TrashCategory: "categories",
+		models.TrashStore:    "stores",
+	}[kind]
+	return fmt.Sprintf("admin-panel/%s/%d/images/", folder, id)
+}
+
+// moveToTrash answers DELETE /<kind>s/:id?hard=true: the item is deactivated and kept with its images
+// until the purge job removes it after the retention window; POST /admin/trash/:kind/:id/restore
+// brings it back before then.
+func (h *Handler) moveToTrash(c *gin.Context, kind, idParam string) {
+	what := map[string]string{models.TrashProduct: "Product", models.TrashCategory: "Category", models.TrashStore: "Store"}[kind]
+	id, err := strconv.Atoi(idParam)
+	if err != nil {
+		writeError(c, http.StatusBadRequest, "Invalid "+kind+" ID")
+		return
+	}
+	var actor string
+	if v, ok := c.Get("user_id"); ok {
+		actor = fmt.Sprint(v)
+	}
+	err = h.db.TrashItem(c.Request.Context(), kind, id, actor)
+	if errors.Is(err, db.ErrTrashItemNotFound) {
+		writeError(c, http.StatusNotFound, what+" not found or already in trash")
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to trash %s %d: %v", kind, id, err)
+		writeError(c, http.StatusInternalServerError, "Failed to delete "+kind)
+		return
+	}
+	retention := trashRetention()
+	c.JSON(http.StatusOK, gin.H{
+		"message":     fmt.Sprintf("%s moved to trash; it is permanently deleted with its images after %d days", what, int(retention.Hours()/24)),
+		kind + "_id":  id,
+		"purge_at":    time.Now().Add(retention),
+		"restore_url": fmt.Sprintf("/api/v1/admin/trash/%s/%d/restore", kind, id),
+	})
+}
+
+// ListTrash handles GET /admin/trash
+func (h *Handler) ListTrash(c *gin.Context) {
+	retention := trashRetention()
+	items, err := h.db.ListTrash(c.Request.Context(), retention)
+	if err != nil {
+		log.Printf("Error listing trash: %v", err)
+		writeError(c, http.StatusInternalServerError, "Failed to list trash")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"items": items, "retention_days": int(retention.Hours() / 24)})
+}
+
+// RestoreTrashedItem handles POST /admin/trash/:kind/:id/restore (kind: product, category or store).
+// The item comes back inactive, as after a soft delete.
+func (h *Handler) RestoreTrashedItem(c *gin.Context) {
+	kind := c.Param("kind")
+	if kind != models.TrashProduct && kind != models.TrashCategory && kind != models.TrashStore {
+		writeError(c, http.StatusBadRequest, "Invalid kind (use product, category or store)")
+		return
+	}
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		writeError(c, http.StatusBadRequest, "Invalid ID")
+		return
+	}
+	err = h.db.RestoreTrashedItem(c.Request.Context(), kind, id)
+	if errors.Is(err, db.ErrTrashItemNotFound) {
+		writeError(c, http.StatusNotFound, "Item not found in trash")
+		return
+	}
+	if err != nil {
+		log.Printf("Error restoring %s %d from trash: %v", kind, id, err)
+		writeError(c, http.StatusInternalServerError, "Failed to restore "+kind)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Restored from trash; reactivate it to publish it again", "kind": kind, "id": id})
+}
+
+// StartTrashPurgeJobs permanently deletes trashed items and their stored images once their retention
+// (TRASH_RETENTION_DAYS) has passed, checking every TRASH_PURGE_CHECK_MINUTES.
+func (h *Handler) StartTrashPurgeJobs(ctx context.Context) {
+	interval := time.Duration(getEnvInt("TRASH_PURGE_CHECK_MINUTES", 60)) * time.Minute
+
+	run := func() {
+		jobCtx, cancel := context.WithTimeout(ctx, 5*time.Minute)
+		defer cancel()
+		items, err := h.db.ListTrashDue(jobCtx, trashRetention())
+		if err != nil {
+			logging.LogKV("error", "TrashPurgeFailed", map[string]interface{}{"error": err.Error()})
+			return
+		}
+		for _, it := range items {
+			// Rows first: an item restored meanwhile keeps its images
+			if err := h.db.PurgeTrashedItem(jobCtx, it.Kind, it.ID); err != nil {
+				if !errors.Is(err, db.ErrTrashItemNotFound) {
+					logging.LogKV("error", "TrashPurgeFailed", map[string]interface{}{"kind": it.Kind, "id": it.ID, "error": err.Error()})
+				}
+				continue
+			}
+			if err := h.deleteMediaFolder(jobCtx, trashMediaPrefix(it.Kind, it.ID)); err != nil {
+				logging.LogKV("warn", "TrashMediaPurgeFailed", map[string]interface{}{"kind": it.Kind, "id": it.ID, "error": err.Error()})
+			}
+			logging.LogKV("info", "TrashItemPurged", map[string]interface{}{"kind": it.Kind, "id": it.ID, "trashed_at": it.TrashedAt})
+		}
+	}
+
+	run()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			run()
+		}
+	}
+}
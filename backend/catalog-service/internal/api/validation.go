@@ -0,0 +1,155 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/expotoworld/expotoworld/backend/catalog-service/internal/models"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+// Catalog-specific binding tags, registered on Gin's validator alongside the built-in ones:
+//
+//	notblank       string is not empty after trimming whitespace
+//	mini_app_type  one of the MiniAppType constants
+func init() {
+	v, ok := binding.Validator.Engine().(*validator.Validate)
+	if !ok {
+		return
+	}
+	// Report JSON names so field errors match the payload the client sent
+	v.RegisterTagNameFunc(func(f reflect.StructField) string {
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		switch name {
+		case "-":
+			return ""
+		case "":
+			return f.Name
+		}
+		return name
+	})
+	_ = v.RegisterValidation("notblank", func(fl validator.FieldLevel) bool {
+		return strings.TrimSpace(fl.Field().String()) != ""
+	})
+	_ = v.RegisterValidation("mini_app_type", func(fl validator.FieldLevel) bool {
+		switch models.MiniAppType(fl.Field().String()) {
+		case models.MiniAppTypeRetailStore, models.MiniAppTypeUnmannedStore,
+			models.MiniAppTypeExhibitionSales, models.MiniAppTypeGroupBuying:
+			return true
+		}
+		return false
+	})
+}
+
+// bindJSON binds and validates the request body into obj. On failure it writes a 400 with
+// field-level errors and returns false.
+func bindJSON(c *gin.Context, obj any) bool {
+	if err := c.ShouldBindJSON(obj); err != nil {
+		writeFieldErrors(c, bindingFieldErrors(err)...)
+		return false
+	}
+	return true
+}
+
+// writeFieldErrors writes the standard validation failure response, the error envelope with
+// code VALIDATION_FAILED and details {"fields": [{"field": "main_price", "code": "must_not_be_negative"}]}
+func writeFieldErrors(c *gin.Context, fields ...models.FieldError) {
+	msgs := make([]string, len(fields))
+	for i, f := range fields {
+		msgs[i] = f.Field + ": " + f.Code
+		if f.Message != "" {
+			msgs[i] = f.Field + ": " + f.Message
+		}
+	}
+	writeErrorDetails(c, http.StatusBadRequest, "VALIDATION_FAILED", "Invalid request: "+strings.Join(msgs, ", "),
+		gin.H{"fields": fields})
+}
+
+// bindingFieldErrors turns a Gin binding error (JSON decoding or validator) into field errors
+func bindingFieldErrors(err error) []models.FieldError {
+	var verrs validator.ValidationErrors
+	var typeErr *json.UnmarshalTypeError
+	var syntaxErr *json.SyntaxError
+	switch {
+	case errors.As(err, &verrs):
+		out := make([]models.FieldError, 0, len(verrs))
+		for _, fe := range verrs {
+			out = append(out, models.FieldError{Field: fieldPath(fe), Code: validationCode(fe)})
+		}
+		return out
+	case errors.As(err, &typeErr):
+		field := typeErr.Field
+		if field == "" {
+			field = "body"
+		}
+		return []models.FieldError{{Field: field, Code: "invalid_type"}}
+	case errors.As(err, &syntaxErr), errors.Is(err, io.ErrUnexpectedEOF):
+		return []models.FieldError{{Field: "body", Code: "malformed_json"}}
+	case errors.Is(err, io.EOF):
+		return []models.FieldError{{Field: "body", Code: "required"}}
+	}
+	return []models.FieldError{{Field: "body", Code: "invalid"}}
+}
+
+// fieldPath drops the Go type name the validator puts in front of the JSON path
+func fieldPath(fe validator.FieldError) string {
+	ns := fe.Namespace()
+	if i := strings.Index(ns, "."); i >= 0 {
+		return ns[i+1:]
+	}
+	return fe.Field()
+}
+
+func validationCode(fe validator.FieldError) string {
+	numeric := false
+	switch fe.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		numeric = true
+	}
+
+	switch fe.Tag() {
+	case "required", "required_if", "required_with", "required_without":
+		return "required"
+	case "notblank":
+		return "must_not_be_blank"
+	case "mini_app_type":
+		return "invalid_mini_app_type"
+	case "oneof":
+		return "invalid_choice"
+	case "email":
+		return "invalid_email"
+	case "url", "http_url":
+		return "invalid_url"
+	case "uuid", "uuid4":
+		return "invalid_uuid"
+	case "len":
+		return "invalid_length"
+	case "iso3166_1_alpha2":
+		return "invalid_country_code"
+	case "gt", "gte", "min":
+		if !numeric {
+			return "too_short"
+		}
+		switch {
+		case fe.Tag() == "gt" && fe.Param() == "0":
+			return "must_be_positive"
+		case fe.Param() == "0":
+			return "must_not_be_negative"
+		}
+		return "too_small"
+	case "lt", "lte", "max":
+		if !numeric {
+			return "too_long"
+		}
+		return "too_large"
+	}
+	return "invalid"
+}
@@ -0,0 +1,195 @@
+package api
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/expotoworld/expotoworld/backend/catalog-service/internal/db"
+	"github.com/expotoworld/expotoworld/backend/catalog-service/internal/models"
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+)
+
+type variantRequest struct {
+	SKU                string            `json:"sku" binding:"required"`
+	Name               string            `json:"name"`
+	Options            map[string]string `json:"options"`
+	MainPrice          *float64          `json:"main_price" binding:"required"`
+	StrikethroughPrice *float64          `json:"strikethrough_price"`
+	CostPrice          *float64          `json:"cost_price"`
+	StockLeft          int               `json:"stock_left"`
+	ShelfCode          *string           `json:"shelf_code"`
+	IsActive           *bool             `json:"is_active"`
+	DisplayOrder       int               `json:"display_order"`
+}
+
+// toVariant validates the request and converts it; an empty message means valid
+func (r *variantRequest) toVariant() (*models.ProductVariant, string) {
+	v := &models.ProductVariant{
+		SKU:                strings.TrimSpace(r.SKU),
+		Name:               strings.TrimSpace(r.Name),
+		Options:            map[string]string{},
+		MainPrice:          *r.MainPrice,
+		StrikethroughPrice: r.StrikethroughPrice,
+		CostPrice:          r.CostPrice,
+		StockLeft:          r.StockLeft,
+		IsActive:           true,
+		DisplayOrder:       r.DisplayOrder,
+	}
+	if v.SKU == "" {
+		return nil, "sku is required"
+	}
+	if v.MainPrice < 0 || (v.StrikethroughPrice != nil && *v.StrikethroughPrice < 0) || (v.CostPrice != nil && *v.CostPrice < 0) {
+		return nil, "prices must not be negative"
+	}
+	if v.StockLeft < 0 {
+		return nil, "stock_left must not be negative"
+	}
+	for k, val := range r.Options {
+		k = strings.TrimSpace(k)
+		if k == "" {
+			return nil, "option names must not be empty"
+		}
+		v.Options[k] = strings.TrimSpace(val)
+	}
+	if r.ShelfCode != nil {
+		if sc := strings.TrimSpace(*r.ShelfCode); sc != "" {
+			v.ShelfCode = &sc
+		}
+	}
+	if r.IsActive != nil {
+		v.IsActive = *r.IsActive
+	}
+	return v, ""
+}
+
+func parseVariantIDs(c *gin.Context) (int, int, bool) {
+	productID, err := strconv.Atoi(c.Param("id"))
+	if err != nil || productID <= 0 {
+		writeError(c, http.StatusBadRequest, "Invalid product ID")
+		return 0, 0, false
+	}
+	variantID := 0
+	if s := c.Param("variant_id"); s != "" {
+		variantID, err = strconv.Atoi(s)
+		if err != nil || variantID <= 0 {
+			writeError(c, http.StatusBadRequest, "Invalid variant ID")
+			return 0, 0, false
+		}
+	}
+	return productID, variantID, true
+}
+
+// writeVariantError maps variant write errors to HTTP responses
+func writeVariantError(c *gin.Context, action string, err error) {
+	var layoutErr *db.ShelfCodeOutsideLayoutError
+	switch {
+	case errors.As(err, &layoutErr):
+		writeFieldErrors(c, shelfLayoutFieldError(layoutErr))
+	case errors.Is(err, db.ErrProductNotFound):
+		writeError(c, http.StatusNotFound, "Product not found")
+	case errors.Is(err, pgx.ErrNoRows):
+		writeError(c, http.StatusNotFound, "Variant not found")
+	case errors.Is(err, db.ErrVariantSKUTaken), errors.Is(err, db.ErrShelfCodeTaken):
+		writeError(c, http.StatusConflict, err.Error())
+	default:
+		log.Printf("Error trying to %s: %v", action, err)
+		writeError(c, http.StatusInternalServerError, "Failed to "+action)
+	}
+}
+
+// GetProductVariants handles GET /products/:id/variants (integer ID or UUID).
+// Public callers only see active variants of active products, without cost prices.
+func (h *Handler) GetProductVariants(c *gin.Context) {
+	ctx := c.Request.Context()
+	isAdminRequest := IsAdmin(c)
+
+	productID, err := strconv.Atoi(c.Param("id"))
+	if err != nil || !isAdminRequest {
+		productID, _, err = h.db.ResolveActiveProduct(ctx, c.Param("id"))
+		if err != nil {
+			writeVariantError(c, "fetch variants", err)
+			return
+		}
+	}
+	variants, err := h.db.GetProductVariants(ctx, []int{productID})
+	if err != nil {
+		writeVariantError(c, "fetch variants", err)
+		return
+	}
+	if isAdminRequest {
+		list := variants[productID]
+		if list == nil {
+			list = []models.ProductVariant{}
+		}
+		c.JSON(http.StatusOK, gin.H{"variants": list})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"variants": models.PublicVariants(variants[productID])})
+}
+
+// CreateProductVariant handles POST /products/:id/variants
+func (h *Handler) CreateProductVariant(c *gin.Context) {
+	productID, _, ok := parseVariantIDs(c)
+	if !ok {
+		return
+	}
+	var req variantRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+	v, msg := req.toVariant()
+	if msg != "" {
+		writeError(c, http.StatusBadRequest, msg)
+		return
+	}
+	v.ProductID = productID
+
+	created, err := h.db.CreateProductVariant(c.Request.Context(), v)
+	if err != nil {
+		writeVariantError(c, "create variant", err)
+		return
+	}
+	c.JSON(http.StatusCreated, created)
+}
+
+// UpdateProductVariant handles PUT /products/:id/variants/:variant_id
+func (h *Handler) UpdateProductVariant(c *gin.Context) {
+	productID, variantID, ok := parseVariantIDs(c)
+	if !ok {
+		return
+	}
+	var req variantRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+	v, msg := req.toVariant()
+	if msg != "" {
+		writeError(c, http.StatusBadRequest, msg)
+		return
+	}
+	v.ProductID, v.ID = productID, variantID
+
+	updated, err := h.db.UpdateProductVariant(c.Request.Context(), v)
+	if err != nil {
+		writeVariantError(c, "update variant", err)
+		return
+	}
+	c.JSON(http.StatusOK, updated)
+}
+
+// DeleteProductVariant handles DELETE /products/:id/variants/:variant_id
+func (h *Handler) DeleteProductVariant(c *gin.Context) {
+	productID, variantID, ok := parseVariantIDs(c)
+	if !ok {
+		return
+	}
+	if err := h.db.DeleteProductVariant(c.Request.Context(), productID, variantID); err != nil {
+		writeVariantError(c, "delete variant", err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Variant deleted", "variant_id": variantID})
+}
@@ -0,0 +1,352 @@
+package api
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/expotoworld/expotoworld/backend/catalog-service/internal/db"
+	"github.com/expotoworld/expotoworld/backend/catalog-service/internal/logging"
+	"github.com/expotoworld/expotoworld/backend/catalog-service/internal/models"
+	"github.com/expotoworld/expotoworld/backend/catalog-service/internal/webhooks"
+	"github.com/gin-gonic/gin"
+)
+
+// webhookEntities are the audited entity types that emit webhook events
+var webhookEntities = map[string]bool{"product": true, "category": true, "subcategory": true, "store": true}
+
+// newWebhookEnvelope builds the JSON body for an event
+func newWebhookEnvelope(event string, data map[string]any) ([]byte, error) {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return nil, err
+	}
+	return json.Marshal(webhooks.Envelope{
+		ID:         "evt_" + hex.EncodeToString(id),
+		Event:      event,
+		OccurredAt: time.Now().UTC(),
+		Data:       data,
+	})
+}
+
+// publishCatalogEvent queues "<entity>.<created|updated|deleted>" for the subscribed endpoints. The data
+// carries the entity id and its row after the change (before it, for deletes). Called by the audit
+// middleware once an admin write succeeded; failures are logged and never fail the request.
+func (h *Handler) publishCatalogEvent(ctx context.Context, entityType, action, id string, before, after json.RawMessage) {
+	if !webhookEntities[entityType] || id == "" {
+		return
+	}
+	event := entityType + "." + action + "d"
+	data := map[string]any{"id": id}
+	obj := after
+	if action == models.AuditDelete && obj == nil {
+		obj = before
+	}
+	if obj != nil {
+		data["object"] = obj
+	}
+	body, err := newWebhookEnvelope(event, data)
+	if err == nil {
+		_, err = h.db.EnqueueWebhookEvent(ctx, event, body)
+	}
+	if err != nil {
+		logging.LogKV("error", "WebhookEnqueueFailed", map[string]interface{}{"event": event, "entity_id": id, "error": err.Error()})
+	}
+}
+
+// StartWebhookJobs sends due webhook deliveries, retrying failures with exponential backoff until
+// WEBHOOK_MAX_ATTEMPTS, after which they stay failed (the dead-letter queue) until redelivered.
+// It returns when ctx is cancelled.
+func (h *Handler) StartWebhookJobs(ctx context.Context) {
+	interval := time.Duration(getEnvInt("WEBHOOK_POLL_SECONDS", 10)) * time.Second
+	maxAttempts := getEnvInt("WEBHOOK_MAX_ATTEMPTS", 8)
+	timeout := time.Duration(getEnvInt("WEBHOOK_TIMEOUT_SECONDS", 10)) * time.Second
+	client := &http.Client{Timeout: timeout}
+
+	send := func(d models.DueWebhookDelivery) {
+		sendCtx, cancel := context.WithTimeout(ctx, timeout)
+		status, respBody, err := webhooks.Send(sendCtx, client, d.URL, d.Secret, strconv.FormatInt(d.ID, 10), d.Event, d.Payload)
+		cancel()
+
+		delivered := err == nil && status >= 200 && status < 300
+		var errMsg string
+		switch {
+		case err != nil:
+			errMsg = err.Error()
+		case !delivered:
+			errMsg = fmt.Sprintf("endpoint returned %d: %s", status, respBody)
+		}
+		var retryAt *time.Time
+		if !delivered && d.Attempts+1 < maxAttempts {
+			t := time.Now().Add(webhooks.Backoff(d.Attempts + 1))
+			retryAt = &t
+		}
+		if !delivered && retryAt == nil {
+			logging.LogKV("warn", "WebhookDeadLettered", map[string]interface{}{"delivery_id": d.ID, "event": d.Event, "error": errMsg})
+		}
+
+		recCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := h.db.RecordWebhookAttempt(recCtx, d.ID, status, errMsg, delivered, retryAt); err != nil {
+			logging.LogKV("error", "WebhookRecordFailed", map[string]interface{}{"delivery_id": d.ID, "error": err.Error()})
+		}
+	}
+
+	run := func() {
+		for ctx.Err() == nil {
+			claimCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+			due, err := h.db.ClaimDueWebhookDeliveries(claimCtx, 20, 2*timeout+time.Minute)
+			cancel()
+			if err != nil {
+				logging.LogKV("error", "WebhookClaimFailed", map[string]interface{}{"error": err.Error()})
+				return
+			}
+			if len(due) == 0 {
+				return
+			}
+			for _, d := range due {
+				send(d)
+			}
+		}
+	}
+
+	run()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			run()
+		}
+	}
+}
+
+// webhookEndpointID parses the :id path parameter, writing a 400 on failure
+func webhookEndpointID(c *gin.Context) (int64, bool) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		writeError(c, http.StatusBadRequest, "Invalid webhook id")
+		return 0, false
+	}
+	return id, true
+}
+
+// bindWebhookEndpoint binds and validates an endpoint request, rejecting unknown event names
+func bindWebhookEndpoint(c *gin.Context) (models.WebhookEndpointRequest, bool) {
+	var req models.WebhookEndpointRequest
+	if !bindJSON(c, &req) {
+		return req, false
+	}
+	var fields []models.FieldError
+	for i, e := range req.Events {
+		if !webhooks.ValidEvent(e) {
+			fields = append(fields, models.FieldError{Field: fmt.Sprintf("events[%d]", i), Code: "invalid_event", Message: "unknown event " + strconv.Quote(e)})
+		}
+	}
+	if len(fields) > 0 {
+		writeFieldErrors(c, fields...)
+		return req, false
+	}
+	if req.Events == nil {
+		req.Events = []string{}
+	}
+	return req, true
+}
+
+// ListWebhookEndpoints handles GET /admin/webhooks
+func (h *Handler) ListWebhookEndpoints(c *gin.Context) {
+	endpoints, err := h.db.ListWebhookEndpoints(c.Request.Context())
+	if err != nil {
+		log.Printf("Error listing webhook endpoints: %v", err)
+		writeError(c, http.StatusInternalServerError, "Failed to fetch webhooks")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"webhooks": endpoints, "events": webhooks.Events})
+}
+
+// CreateWebhookEndpoint handles POST /admin/webhooks. The generated signing secret is only returned here
+// (and on rotation).
+func (h *Handler) CreateWebhookEndpoint(c *gin.Context) {
+	req, ok := bindWebhookEndpoint(c)
+	if !ok {
+		return
+	}
+	secret, err := webhooks.NewSecret()
+	if err != nil {
+		writeError(c, http.StatusInternalServerError, "Failed to generate secret")
+		return
+	}
+	endpoint, err := h.db.CreateWebhookEndpoint(c.Request.Context(), req, secret)
+	if err != nil {
+		log.Printf("Error creating webhook endpoint: %v", err)
+		writeError(c, http.StatusInternalServerError, "Failed to create webhook")
+		return
+	}
+	c.JSON(http.StatusCreated, endpoint)
+}
+
+// GetWebhookEndpoint handles GET /admin/webhooks/:id
+func (h *Handler) GetWebhookEndpoint(c *gin.Context) {
+	id, ok := webhookEndpointID(c)
+	if !ok {
+		return
+	}
+	endpoint, err := h.db.GetWebhookEndpoint(c.Request.Context(), id)
+	if errors.Is(err, db.ErrWebhookEndpointNotFound) {
+		writeError(c, http.StatusNotFound, "Webhook not found")
+		return
+	}
+	if err != nil {
+		writeError(c, http.StatusInternalServerError, "Failed to fetch webhook")
+		return
+	}
+	c.JSON(http.StatusOK, endpoint)
+}
+
+// UpdateWebhookEndpoint handles PUT /admin/webhooks/:id (rotate_secret=true returns a new secret)
+func (h *Handler) UpdateWebhookEndpoint(c *gin.Context) {
+	id, ok := webhookEndpointID(c)
+	if !ok {
+		return
+	}
+	req, ok := bindWebhookEndpoint(c)
+	if !ok {
+		return
+	}
+	var secret string
+	if req.RotateSecret {
+		var err error
+		if secret, err = webhooks.NewSecret(); err != nil {
+			writeError(c, http.StatusInternalServerError, "Failed to generate secret")
+			return
+		}
+	}
+	endpoint, err := h.db.UpdateWebhookEndpoint(c.Request.Context(), id, req, secret)
+	if errors.Is(err, db.ErrWebhookEndpointNotFound) {
+		writeError(c, http.StatusNotFound, "Webhook not found")
+		return
+	}
+	if err != nil {
+		log.Printf("Error updating webhook endpoint %d: %v", id, err)
+		writeError(c, http.StatusInternalServerError, "Failed to update webhook")
+		return
+	}
+	c.JSON(http.StatusOK, endpoint)
+}
+
+// DeleteWebhookEndpoint handles DELETE /admin/webhooks/:id
+func (h *Handler) DeleteWebhookEndpoint(c *gin.Context) {
+	id, ok := webhookEndpointID(c)
+	if !ok {
+		return
+	}
+	err := h.db.DeleteWebhookEndpoint(c.Request.Context(), id)
+	if errors.Is(err, db.ErrWebhookEndpointNotFound) {
+		writeError(c, http.StatusNotFound, "Webhook not found")
+		return
+	}
+	if err != nil {
+		writeError(c, http.StatusInternalServerError, "Failed to delete webhook")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Webhook deleted"})
+}
+
+// PingWebhookEndpoint handles POST /admin/webhooks/:id/ping, queueing a "ping" event for the endpoint
+func (h *Handler) PingWebhookEndpoint(c *gin.Context) {
+	id, ok := webhookEndpointID(c)
+	if !ok {
+		return
+	}
+	body, err := newWebhookEnvelope(webhooks.EventPing, map[string]any{"webhook_id": id})
+	if err != nil {
+		writeError(c, http.StatusInternalServerError, "Failed to build ping")
+		return
+	}
+	delivery, err := h.db.EnqueueWebhookPing(c.Request.Context(), id, webhooks.EventPing, body)
+	if errors.Is(err, db.ErrWebhookEndpointNotFound) {
+		writeError(c, http.StatusNotFound, "Webhook not found")
+		return
+	}
+	if err != nil {
+		writeError(c, http.StatusInternalServerError, "Failed to queue ping")
+		return
+	}
+	c.JSON(http.StatusAccepted, delivery)
+}
+
+// GetWebhookDeliveries handles GET /admin/webhooks/:id/deliveries
+// Filter with status (pending, delivered or failed: the dead-letter queue); paginate with limit
+// (default 50, max 200) and before_id.
+func (h *Handler) GetWebhookDeliveries(c *gin.Context) {
+	id, ok := webhookEndpointID(c)
+	if !ok {
+		return
+	}
+	status := c.Query("status")
+	switch status {
+	case "", models.WebhookPending, models.WebhookDelivered, models.WebhookFailed:
+	default:
+		writeError(c, http.StatusBadRequest, "status must be pending, delivered or failed")
+		return
+	}
+	limit := 50
+	if v := c.Query("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 || n > 200 {
+			writeError(c, http.StatusBadRequest, "limit must be between 1 and 200")
+			return
+		}
+		limit = n
+	}
+	var beforeID int64
+	if v := c.Query("before_id"); v != "" {
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || n <= 0 {
+			writeError(c, http.StatusBadRequest, "Invalid before_id")
+			return
+		}
+		beforeID = n
+	}
+
+	deliveries, err := h.db.ListWebhookDeliveries(c.Request.Context(), id, status, beforeID, limit)
+	if err != nil {
+		log.Printf("Error listing webhook deliveries: %v", err)
+		writeError(c, http.StatusInternalServerError, "Failed to fetch deliveries")
+		return
+	}
+	resp := gin.H{"deliveries": deliveries}
+	if len(deliveries) == limit {
+		resp["next_before_id"] = deliveries[len(deliveries)-1].ID
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// RedeliverWebhook handles POST /admin/webhook-deliveries/:id/redeliver, requeueing a (typically failed)
+// delivery with a fresh attempt budget
+func (h *Handler) RedeliverWebhook(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		writeError(c, http.StatusBadRequest, "Invalid delivery id")
+		return
+	}
+	delivery, err := h.db.RedeliverWebhook(c.Request.Context(), id)
+	if errors.Is(err, db.ErrWebhookDeliveryNotFound) {
+		writeError(c, http.StatusNotFound, "Delivery not found")
+		return
+	}
+	if err != nil {
+		writeError(c, http.StatusInternalServerError, "Failed to requeue delivery")
+		return
+	}
+	c.JSON(http.StatusAccepted, delivery)
+}
@@ -0,0 +1,156 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/expotoworld/expotoworld/backend/catalog-service/internal/models"
+	"github.com/jackc/pgx/v5"
+)
+
+var (
+	// ErrAuditEntryNotFound is returned when an audit id does not exist
+	ErrAuditEntryNotFound = errors.New("audit entry not found")
+	// ErrNotUndoable is returned when undoing an action type that cannot be reversed
+	ErrNotUndoable = errors.New("action cannot be undone")
+	// ErrAlreadyUndone is returned when the action was undone before
+	ErrAlreadyUndone = errors.New("action was already undone")
+	// ErrUndoExpired is returned when the undo window has passed
+	ErrUndoExpired = errors.New("undo window has passed")
+	// ErrUndoConflict is returned when the entity changed after the action, so undoing it would
+	// overwrite later work
+	ErrUndoConflict = errors.New("entity changed after this action")
+)
+
+// UndoAuditEntry reverses a recent destructive action in one transaction: it restores the entity,
+// records the undo as its own audit entry (a template holding the actor and request fields) linked to
+// the original, and marks the original undone. diff computes the undo entry's changes from its
+// before/after snapshots. It returns the recorded undo entry.
+func (db *Database) UndoAuditEntry(ctx context.Context, auditID int64, window time.Duration, undo *models.AuditEntry,
+	diff func(before, after json.RawMessage) json.RawMessage) (*models.AuditEntry, error) {
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	orig, err := scanAuditEntry(tx.QueryRow(ctx, `SELECT `+auditColumns+` FROM catalog_audit_log a WHERE a.audit_id = $1 FOR UPDATE OF a`, auditID))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrAuditEntryNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	kind := orig.UndoKind()
+	switch {
+	case kind == "":
+		return nil, ErrNotUndoable
+	case orig.UndoneBy != nil:
+		return nil, ErrAlreadyUndone
+	case time.Since(orig.OccurredAt) > window:
+		return nil, ErrUndoExpired
+	case orig.Superseded:
+		return nil, ErrUndoConflict
+	}
+
+	ent := map[string][2]string{
+		"product":  {"admin_products", "product_id"},
+		"category": {"admin_product_categories", "category_id"},
+	}[orig.EntityType]
+	id := *orig.EntityID
+	before, err := snapshotRow(ctx, tx, ent[0], ent[1], id)
+	if err != nil {
+		return nil, err
+	}
+	if before == nil {
+		return nil, ErrUndoConflict
+	}
+
+	switch kind {
+	case models.UndoRestoreProduct:
+		var taxClass *string
+		var isActive bool
+		if err := tx.QueryRow(ctx, `SELECT COALESCE(is_active, false), tax_class FROM admin_products WHERE product_id::text = $1`, id).
+			Scan(&isActive, &taxClass); err != nil {
+			return nil, fmt.Errorf("failed to load product: %w", err)
+		}
+		if isActive {
+			return nil, ErrUndoConflict
+		}
+		if taxClass == nil {
+			return nil, ErrTaxClassRequired
+		}
+		_, err = tx.Exec(ctx, `UPDATE admin_products SET is_active = true, updated_at = CURRENT_TIMESTAMP WHERE product_id::text = $1`, id)
+	case models.UndoRestoreCategory:
+		_, err = tx.Exec(ctx, `UPDATE admin_product_categories SET is_active = true, updated_at = CURRENT_TIMESTAMP WHERE category_id::text = $1`, id)
+	case models.UndoRevertCategories:
+		err = revertProductCategories(ctx, tx, id, orig.Before)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to undo audit entry %d: %w", auditID, err)
+	}
+
+	after, err := snapshotRow(ctx, tx, ent[0], ent[1], id)
+	if err != nil {
+		return nil, err
+	}
+	e := *undo
+	e.EntityType, e.EntityID, e.Action = orig.EntityType, orig.EntityID, models.AuditUpdate
+	e.Before, e.After, e.Changes = before, after, diff(before, after)
+	e.UndoOf = &orig.ID
+	if e.ID, err = insertAuditEntry(ctx, tx, &e); err != nil {
+		return nil, fmt.Errorf("failed to record undo: %w", err)
+	}
+	if _, err := tx.Exec(ctx, `UPDATE catalog_audit_log SET undone_by = $2 WHERE audit_id = $1`, orig.ID, e.ID); err != nil {
+		return nil, fmt.Errorf("failed to link undo: %w", err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	e.OccurredAt = time.Now()
+	return &e, nil
+}
+
+// revertProductCategories puts back the category and subcategory assignment recorded in a product
+// snapshot
+func revertProductCategories(ctx context.Context, tx pgx.Tx, id string, snapshot json.RawMessage) error {
+	productID, err := strconv.Atoi(id)
+	if err != nil {
+		return fmt.Errorf("invalid product id %q", id)
+	}
+	var prev struct {
+		CategoryIDs    []int `json:"category_ids"`
+		SubcategoryIDs []int `json:"subcategory_ids"`
+	}
+	if err := json.Unmarshal(snapshot, &prev); err != nil {
+		return fmt.Errorf("decode snapshot: %w", err)
+	}
+	if prev.CategoryIDs == nil {
+		prev.CategoryIDs = []int{}
+	}
+	if prev.SubcategoryIDs == nil {
+		prev.SubcategoryIDs = []int{}
+	}
+	for _, stmt := range []struct {
+		sql  string
+		args []any
+	}{
+		{`DELETE FROM admin_product_category_mapping WHERE product_id = $1`, []any{productID}},
+		// Categories deleted since are skipped rather than failing the undo
+		{`INSERT INTO admin_product_category_mapping (product_id, category_id)
+			SELECT $1::int, c.category_id FROM admin_product_categories c WHERE c.category_id = ANY($2::int[])`, []any{productID, prev.CategoryIDs}},
+		{`DELETE FROM admin_product_subcategory_mapping WHERE product_id = $1`, []any{productID}},
+		{`INSERT INTO admin_product_subcategory_mapping (product_id, subcategory_id)
+			SELECT $1::int, s.subcategory_id FROM admin_subcategories s WHERE s.subcategory_id = ANY($2::int[])`, []any{productID, prev.SubcategoryIDs}},
+		{`UPDATE admin_products SET updated_at = CURRENT_TIMESTAMP WHERE product_id = $1`, []any{productID}},
+	} {
+		if _, err := tx.Exec(ctx, stmt.sql, stmt.args...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
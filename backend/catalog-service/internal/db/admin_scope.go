@@ -0,0 +1,42 @@
+package db
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// StoresInAdminScope returns the stores a regional admin manages: the stores of the given regions
+// plus the individually granted stores
+func (db *Database) StoresInAdminScope(ctx context.Context, regionIDs, storeIDs []int) ([]int, error) {
+	rows, err := db.Pool.Query(ctx, `
+		SELECT store_id FROM admin_stores
+		WHERE region_id = ANY($1::int[]) OR store_id = ANY($2::int[])
+		ORDER BY store_id
+	`, regionIDs, storeIDs)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := []int{}
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		out = append(out, id)
+	}
+	return out, rows.Err()
+}
+
+// GetProductStoreID returns the store a product is sold in (nil for non-location mini-apps).
+// ErrProductNotFound if the product doesn't exist.
+func (db *Database) GetProductStoreID(ctx context.Context, productID int) (*int, error) {
+	var storeID *int
+	err := db.Pool.QueryRow(ctx, `SELECT store_id FROM admin_products WHERE product_id = $1`, productID).Scan(&storeID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrProductNotFound
+	}
+	return storeID, err
+}
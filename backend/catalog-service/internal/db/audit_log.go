@@ -0,0 +1,142 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/expotoworld/expotoworld/backend/catalog-service/internal/models"
+	"github.com/jackc/pgx/v5"
+)
+
+// snapshotExtras adds related rows to an entity's snapshot so changes to them show up in the audit log
+// (and can be reverted)
+var snapshotExtras = map[string]string{
+	"admin_products": ` || jsonb_build_object(
+		'category_ids', COALESCE((SELECT jsonb_agg(m.category_id ORDER BY m.category_id)
+			FROM admin_product_category_mapping m WHERE m.product_id = t.product_id), '[]'::jsonb),
+		'subcategory_ids', COALESCE((SELECT jsonb_agg(m.subcategory_id ORDER BY m.subcategory_id)
+			FROM admin_product_subcategory_mapping m WHERE m.product_id = t.product_id), '[]'::jsonb))`,
+}
+
+// SnapshotRow returns the row as JSON, or nil if it doesn't exist. table and pk come from the
+// audit registry, never from the request.
+func (db *Database) SnapshotRow(ctx context.Context, table, pk, id string) (json.RawMessage, error) {
+	return snapshotRow(ctx, db.Pool, table, pk, id)
+}
+
+func snapshotRow(ctx context.Context, q rowQuerier, table, pk, id string) (json.RawMessage, error) {
+	var raw []byte
+	err := q.QueryRow(ctx, fmt.Sprintf(`SELECT to_jsonb(t)%s FROM %s t WHERE t.%s::text = $1`, snapshotExtras[table], table, pk), id).Scan(&raw)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return raw, nil
+}
+
+// nullJSON maps an empty document to SQL NULL
+func nullJSON(b json.RawMessage) interface{} {
+	if len(b) == 0 {
+		return nil
+	}
+	return string(b)
+}
+
+// InsertAuditEntry appends an entry to catalog_audit_log
+func (db *Database) InsertAuditEntry(ctx context.Context, e *models.AuditEntry) error {
+	_, err := insertAuditEntry(ctx, db.Pool, e)
+	return err
+}
+
+func insertAuditEntry(ctx context.Context, q rowQuerier, e *models.AuditEntry) (int64, error) {
+	var id int64
+	err := q.QueryRow(ctx, `
+		INSERT INTO catalog_audit_log (actor_user_id, actor_email, actor_role, method, route, path, entity_type, entity_id,
+			action, status, before, after, changes, request_body, client_ip, undo_of)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11::jsonb, $12::jsonb, $13::jsonb, $14::jsonb, $15, $16)
+		RETURNING audit_id
+	`, e.ActorUserID, e.ActorEmail, e.ActorRole, e.Method, e.Route, e.Path, e.EntityType, e.EntityID,
+		e.Action, e.Status, nullJSON(e.Before), nullJSON(e.After), nullJSON(e.Changes), nullJSON(e.RequestBody), e.ClientIP,
+		e.UndoOf).Scan(&id)
+	return id, err
+}
+
+// ListAuditEntries returns matching entries, newest first (keyset-paginated by audit_id)
+func (db *Database) ListAuditEntries(ctx context.Context, f models.AuditFilter) ([]models.AuditEntry, error) {
+	where := []string{"true"}
+	args := []interface{}{}
+	add := func(cond string, v interface{}) {
+		args = append(args, v)
+		where = append(where, fmt.Sprintf(cond, len(args)))
+	}
+	if f.EntityType != "" {
+		add("entity_type = $%d", f.EntityType)
+	}
+	if f.EntityID != "" {
+		add("entity_id = $%d", f.EntityID)
+	}
+	if f.ActorID != "" {
+		add("actor_user_id = $%d", f.ActorID)
+	}
+	if f.Action != "" {
+		add("action = $%d", f.Action)
+	}
+	if f.Field != "" {
+		add("changes ? $%d", f.Field)
+	}
+	if f.From != nil {
+		add("occurred_at >= $%d", *f.From)
+	}
+	if f.To != nil {
+		add("occurred_at < $%d", *f.To)
+	}
+	if f.BeforeID > 0 {
+		add("audit_id < $%d", f.BeforeID)
+	}
+	args = append(args, f.Limit)
+
+	rows, err := db.Pool.Query(ctx, `
+		SELECT `+auditColumns+`
+		FROM catalog_audit_log a
+		WHERE `+strings.Join(where, " AND ")+`
+		ORDER BY audit_id DESC
+		LIMIT $`+fmt.Sprint(len(args)), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := []models.AuditEntry{}
+	for rows.Next() {
+		e, err := scanAuditEntry(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *e)
+	}
+	return out, rows.Err()
+}
+
+// auditColumns selects an entry aliased a, including whether a later write touched the same entity
+const auditColumns = `a.audit_id, a.occurred_at, a.actor_user_id, a.actor_email, a.actor_role, a.method, a.route, a.path,
+	a.entity_type, a.entity_id, a.action, a.status, a.before, a.after, a.changes, a.request_body, a.client_ip,
+	a.undo_of, a.undone_by,
+	EXISTS (SELECT 1 FROM catalog_audit_log l
+		WHERE l.entity_type = a.entity_type AND l.entity_id = a.entity_id AND l.audit_id > a.audit_id) AS superseded`
+
+func scanAuditEntry(row pgx.Row) (*models.AuditEntry, error) {
+	var e models.AuditEntry
+	var before, after, changes, body []byte
+	if err := row.Scan(&e.ID, &e.OccurredAt, &e.ActorUserID, &e.ActorEmail, &e.ActorRole, &e.Method, &e.Route, &e.Path,
+		&e.EntityType, &e.EntityID, &e.Action, &e.Status, &before, &after, &changes, &body, &e.ClientIP,
+		&e.UndoOf, &e.UndoneBy, &e.Superseded); err != nil {
+		return nil, err
+	}
+	e.Before, e.After, e.Changes, e.RequestBody = before, after, changes, body
+	return &e, nil
+}
@@ -0,0 +1,33 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// ErrBarcodeTaken is returned when a barcode is already assigned to another product
+var ErrBarcodeTaken = errors.New("barcode already in use")
+
+// barcodeTaken reports whether err is a violation of the product barcode unique index
+func barcodeTaken(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23505" && strings.Contains(pgErr.ConstraintName, "barcode")
+}
+
+// FindProductByBarcode returns the id of the active product with a normalized barcode, optionally
+// limited to one store
+func (db *Database) FindProductByBarcode(ctx context.Context, barcode string, storeID *int) (int, error) {
+	var id int
+	err := db.Pool.QueryRow(ctx, `
+		SELECT product_id FROM admin_products
+		WHERE barcode = $1 AND is_active = true AND ($2::int IS NULL OR store_id = $2)
+	`, barcode, storeID).Scan(&id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, ErrProductNotFound
+	}
+	return id, err
+}
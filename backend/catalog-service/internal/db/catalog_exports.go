@@ -0,0 +1,171 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/expotoworld/expotoworld/backend/catalog-service/internal/models"
+	"github.com/jackc/pgx/v5"
+)
+
+// CatalogExportDataset is a table exported into catalog snapshots; Query selects one JSON object per row
+type CatalogExportDataset struct {
+	Name  string
+	Query string
+}
+
+// CatalogExportDatasets lists the snapshot contents in export order. Rows are ordered by primary key
+// so consecutive snapshots diff cleanly.
+var CatalogExportDatasets = []CatalogExportDataset{
+	{"products", `SELECT to_jsonb(p) FROM admin_products p ORDER BY p.product_id`},
+	{"product_variants", `SELECT to_jsonb(v) FROM app_product_variants v ORDER BY v.variant_id`},
+	{"categories", `SELECT to_jsonb(c) FROM admin_product_categories c ORDER BY c.category_id`},
+	{"subcategories", `SELECT to_jsonb(s) FROM admin_subcategories s ORDER BY s.subcategory_id`},
+	{"product_categories", `SELECT to_jsonb(m) FROM admin_product_category_mapping m ORDER BY m.product_id, m.category_id`},
+	{"product_subcategories", `SELECT to_jsonb(m) FROM admin_product_subcategory_mapping m ORDER BY m.product_id, m.subcategory_id`},
+	{"stores", `SELECT to_jsonb(s) - 'geog' FROM admin_stores s ORDER BY s.store_id`},
+	{"price_lists", `SELECT to_jsonb(pl) FROM app_price_lists pl ORDER BY pl.price_list_id`},
+	{"price_list_items", `SELECT to_jsonb(i) FROM app_price_list_items i ORDER BY i.price_list_id, i.product_id`},
+}
+
+// ErrCatalogExportNotFound is returned when an export id does not exist
+var ErrCatalogExportNotFound = errors.New("catalog export not found")
+
+const catalogExportColumns = `export_id, to_char(snapshot_date, 'YYYY-MM-DD'), trigger, status, manifest_key, datasets, error, started_at, finished_at`
+
+func scanCatalogExport(row pgx.Row) (*models.CatalogExport, error) {
+	var e models.CatalogExport
+	var datasets []byte
+	if err := row.Scan(&e.ID, &e.SnapshotDate, &e.Trigger, &e.Status, &e.ManifestKey, &datasets, &e.Error, &e.StartedAt, &e.FinishedAt); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(datasets, &e.Datasets); err != nil {
+		return nil, fmt.Errorf("decode export datasets: %w", err)
+	}
+	return &e, nil
+}
+
+// ClaimScheduledCatalogExport starts the scheduled export for day. It returns false when another
+// instance already completed it or is still running it; a failed or abandoned run is retried once it
+// is an hour old.
+func (db *Database) ClaimScheduledCatalogExport(ctx context.Context, day time.Time) (*models.CatalogExport, bool, error) {
+	e, err := scanCatalogExport(db.Pool.QueryRow(ctx, `
+		INSERT INTO catalog_exports (snapshot_date, trigger)
+		VALUES ($1::date, 'scheduled')
+		ON CONFLICT (snapshot_date) WHERE trigger = 'scheduled' DO UPDATE
+		SET status = 'running', manifest_key = NULL, datasets = '[]', error = NULL, started_at = now(), finished_at = NULL
+		WHERE catalog_exports.status <> 'completed' AND catalog_exports.started_at < now() - interval '1 hour'
+		RETURNING `+catalogExportColumns, day.Format("2006-01-02")))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return e, true, nil
+}
+
+// CreateManualCatalogExport records an admin-triggered export of today's catalog
+func (db *Database) CreateManualCatalogExport(ctx context.Context) (*models.CatalogExport, error) {
+	return scanCatalogExport(db.Pool.QueryRow(ctx, `
+		INSERT INTO catalog_exports (snapshot_date, trigger)
+		VALUES ((now() AT TIME ZONE 'UTC')::date, 'manual')
+		RETURNING `+catalogExportColumns))
+}
+
+// FinishCatalogExport records the outcome of an export: its manifest and datasets, or the error that stopped it
+func (db *Database) FinishCatalogExport(ctx context.Context, id int64, manifestKey string, datasets []models.CatalogExportDataset, exportErr error) error {
+	if datasets == nil {
+		datasets = []models.CatalogExportDataset{}
+	}
+	raw, err := json.Marshal(datasets)
+	if err != nil {
+		return err
+	}
+	status, errText := models.CatalogExportCompleted, ""
+	if exportErr != nil {
+		status, errText = models.CatalogExportFailed, exportErr.Error()
+	}
+	_, err = db.Pool.Exec(ctx, `
+		UPDATE catalog_exports
+		SET status = $2, manifest_key = NULLIF($3, ''), datasets = $4, error = NULLIF($5, ''), finished_at = now()
+		WHERE export_id = $1`, id, status, manifestKey, raw, errText)
+	return err
+}
+
+// GetCatalogExport returns one export run
+func (db *Database) GetCatalogExport(ctx context.Context, id int64) (*models.CatalogExport, error) {
+	e, err := scanCatalogExport(db.Pool.QueryRow(ctx, `SELECT `+catalogExportColumns+` FROM catalog_exports WHERE export_id = $1`, id))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrCatalogExportNotFound
+	}
+	return e, err
+}
+
+// ListCatalogExports returns export runs, newest first
+func (db *Database) ListCatalogExports(ctx context.Context, beforeID int64, limit int) ([]models.CatalogExport, error) {
+	args := []interface{}{limit}
+	where := "TRUE"
+	if beforeID > 0 {
+		args = append(args, beforeID)
+		where = "export_id < $2"
+	}
+	rows, err := db.Pool.Query(ctx, `
+		SELECT `+catalogExportColumns+`
+		FROM catalog_exports
+		WHERE `+where+`
+		ORDER BY export_id DESC
+		LIMIT $1`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make([]models.CatalogExport, 0)
+	for rows.Next() {
+		e, err := scanCatalogExport(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *e)
+	}
+	return out, rows.Err()
+}
+
+// StreamCatalogSnapshot reads every dataset in one repeatable-read transaction, so the snapshot is
+// consistent across tables, passing each row's JSON to fn. It returns the row count per dataset.
+func (db *Database) StreamCatalogSnapshot(ctx context.Context, fn func(ds CatalogExportDataset, row []byte) error) (map[string]int64, error) {
+	tx, err := db.Pool.BeginTx(ctx, pgx.TxOptions{IsoLevel: pgx.RepeatableRead, AccessMode: pgx.ReadOnly})
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	counts := make(map[string]int64, len(CatalogExportDatasets))
+	for _, ds := range CatalogExportDatasets {
+		rows, err := tx.Query(ctx, ds.Query)
+		if err != nil {
+			return nil, fmt.Errorf("query %s: %w", ds.Name, err)
+		}
+		for rows.Next() {
+			var raw []byte
+			if err := rows.Scan(&raw); err != nil {
+				rows.Close()
+				return nil, fmt.Errorf("scan %s: %w", ds.Name, err)
+			}
+			if err := fn(ds, raw); err != nil {
+				rows.Close()
+				return nil, err
+			}
+			counts[ds.Name]++
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return nil, fmt.Errorf("read %s: %w", ds.Name, err)
+		}
+	}
+	return counts, nil
+}
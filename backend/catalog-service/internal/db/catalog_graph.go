@@ -0,0 +1,310 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/expotoworld/expotoworld/backend/catalog-service/internal/models"
+)
+
+// CatalogFilter narrows the GraphQL catalog reads. Zero values don't filter; inactive rows are
+// only listed with IncludeInactive (admins), trashed ones never.
+type CatalogFilter struct {
+	IDs             []int
+	StoreID         *int
+	ScopeStoreIDs   []int // regional admins: products/stores outside these stores are hidden (nil = all)
+	RegionID        *int  // products sellable in the region (see RegionSellableSQL), stores located in it
+	CategoryID      *int
+	SubcategoryID   *int
+	MiniAppType     string
+	Featured        *bool
+	IncludeInactive bool
+	AfterID         int
+	Limit           int
+}
+
+// ListCatalogProducts lists products by ascending ID. The effective store type of location-based
+// mini-apps comes from the store, as in GET /products.
+func (db *Database) ListCatalogProducts(ctx context.Context, f CatalogFilter) ([]models.Product, error) {
+	query := `
+        SELECT p.product_id, p.product_uuid, COALESCE(p.sku, ''), COALESCE(p.title, ''), COALESCE(p.description, ''),
+            CASE
+                WHEN p.mini_app_type IN ('UnmannedStore', 'ExhibitionSales') AND s.type IS NOT NULL
+                THEN s.type::text
+                ELSE p.store_type::text
+            END,
+            COALESCE(p.mini_app_type::text, ''), p.store_id, p.barcode, p.shelf_code,
+            COALESCE(p.main_price, 0), p.strikethrough_price, p.cost_price, p.tax_class,
+            COALESCE(p.weight, 1.00), COALESCE(p.stock_left, 0), COALESCE(p.minimum_order_quantity, 1),
+            COALESCE(p.is_active, false), COALESCE(p.is_featured, false), COALESCE(p.is_mini_app_recommendation, false),
+            COALESCE(p.created_at, NOW()), COALESCE(p.updated_at, NOW())
+        FROM admin_products p
+        LEFT JOIN admin_stores s ON p.store_id = s.store_id AND p.mini_app_type IN ('UnmannedStore', 'ExhibitionSales')
+        WHERE p.product_id > $1
+    `
+	args := []interface{}{f.AfterID}
+	if !f.IncludeInactive {
+		query += " AND p.is_active = true"
+	} else {
+		query += " AND p.trashed_at IS NULL"
+	}
+	if f.IDs != nil {
+		args = append(args, f.IDs)
+		query += fmt.Sprintf(" AND p.product_id = ANY($%d::int[])", len(args))
+	}
+	if f.ScopeStoreIDs != nil {
+		args = append(args, f.ScopeStoreIDs)
+		query += fmt.Sprintf(" AND p.store_id = ANY($%d::int[])", len(args))
+	}
+	if f.StoreID != nil {
+		args = append(args, *f.StoreID)
+		query += fmt.Sprintf(" AND p.store_id = $%d", len(args))
+	}
+	if f.RegionID != nil {
+		args = append(args, *f.RegionID)
+		query += " AND " + fmt.Sprintf(RegionSellableSQL, fmt.Sprintf("$%d", len(args)))
+	}
+	if f.CategoryID != nil {
+		args = append(args, *f.CategoryID)
+		query += fmt.Sprintf(" AND EXISTS (SELECT 1 FROM admin_product_category_mapping pcm WHERE pcm.product_id = p.product_id AND pcm.category_id = $%d)", len(args))
+	}
+	if f.SubcategoryID != nil {
+		args = append(args, *f.SubcategoryID)
+		query += fmt.Sprintf(" AND EXISTS (SELECT 1 FROM admin_product_subcategory_mapping psm WHERE psm.product_id = p.product_id AND psm.subcategory_id = $%d)", len(args))
+	}
+	if f.MiniAppType != "" {
+		args = append(args, f.MiniAppType)
+		query += fmt.Sprintf(" AND p.mini_app_type = $%d", len(args))
+	}
+	if f.Featured != nil {
+		args = append(args, *f.Featured)
+		query += fmt.Sprintf(" AND COALESCE(p.is_featured, false) = $%d", len(args))
+	}
+	args = append(args, f.Limit)
+	query += fmt.Sprintf(" ORDER BY p.product_id LIMIT $%d", len(args))
+
+	rows, err := db.Pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query products: %w", err)
+	}
+	defer rows.Close()
+
+	products := []models.Product{}
+	for rows.Next() {
+		var p models.Product
+		var storeType sql.NullString
+		if err := rows.Scan(
+			&p.ID, &p.UUID, &p.SKU, &p.Title, &p.DescriptionLong, &storeType, &p.MiniAppType, &p.StoreID,
+			&p.Barcode, &p.ShelfCode, &p.MainPrice, &p.StrikethroughPrice, &p.CostPrice, &p.TaxClass,
+			&p.Weight, &p.StockLeft, &p.MinimumOrderQuantity, &p.IsActive, &p.IsFeatured,
+			&p.IsMiniAppRecommendation, &p.CreatedAt, &p.UpdatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan product: %w", err)
+		}
+		p.StoreType = models.StoreType(storeType.String)
+		products = append(products, p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating products: %w", err)
+	}
+	return products, nil
+}
+
+// GetImagesForProducts loads the images of many products in one query, keyed by product ID
+func (db *Database) GetImagesForProducts(ctx context.Context, productIDs []int) (map[int][]models.ProductImage, error) {
+	out := make(map[int][]models.ProductImage, len(productIDs))
+	if len(productIDs) == 0 {
+		return out, nil
+	}
+	rows, err := db.Pool.Query(ctx, `
+        SELECT image_id, product_id, image_url, thumbnail_url, medium_url, webp_url, display_order, is_primary, created_at
+        FROM admin_product_images
+        WHERE product_id = ANY($1::int[])
+        ORDER BY product_id, display_order, image_id
+    `, productIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query product images: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var image models.ProductImage
+		if err := rows.Scan(
+			&image.ID, &image.ProductID, &image.ImageURL, &image.ThumbnailURL, &image.MediumURL,
+			&image.WebPURL, &image.DisplayOrder, &image.IsPrimary, &image.CreatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan product image: %w", err)
+		}
+		out[image.ProductID] = append(out[image.ProductID], image)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating product images: %w", err)
+	}
+	return out, nil
+}
+
+// ListCatalogCategories lists categories by display order. StoreID keeps the store's categories
+// and the shared ones (no store), as in GET /categories.
+func (db *Database) ListCatalogCategories(ctx context.Context, f CatalogFilter) ([]models.Category, error) {
+	query := `
+        SELECT category_id, name, store_type_association, mini_app_association, store_id, display_order, is_active,
+            image_url, thumbnail_url, medium_url, webp_url, created_at, updated_at
+        FROM admin_product_categories
+        WHERE true
+    `
+	args := []interface{}{}
+	if !f.IncludeInactive {
+		query += " AND is_active = true"
+	} else {
+		query += " AND trashed_at IS NULL"
+	}
+	if f.IDs != nil {
+		args = append(args, f.IDs)
+		query += fmt.Sprintf(" AND category_id = ANY($%d::int[])", len(args))
+	}
+	if f.StoreID != nil {
+		args = append(args, *f.StoreID)
+		query += fmt.Sprintf(" AND (store_id = $%d OR store_id IS NULL)", len(args))
+	}
+	if f.MiniAppType != "" {
+		args = append(args, f.MiniAppType)
+		query += fmt.Sprintf(" AND $%d = ANY(mini_app_association)", len(args))
+	}
+	query += " ORDER BY display_order, category_id"
+
+	rows, err := db.Pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query categories: %w", err)
+	}
+	defer rows.Close()
+
+	categories := []models.Category{}
+	for rows.Next() {
+		var c models.Category
+		if err := rows.Scan(
+			&c.ID, &c.Name, &c.StoreTypeAssociation, &c.MiniAppAssociation, &c.StoreID, &c.DisplayOrder, &c.IsActive,
+			&c.ImageURL, &c.ThumbnailURL, &c.MediumURL, &c.WebPURL, &c.CreatedAt, &c.UpdatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan category: %w", err)
+		}
+		categories = append(categories, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating categories: %w", err)
+	}
+	return categories, nil
+}
+
+// ListCatalogSubcategories lists subcategories by IDs and/or parent categories (f.IDs, categoryIDs)
+func (db *Database) ListCatalogSubcategories(ctx context.Context, categoryIDs []int, f CatalogFilter) ([]models.Subcategory, error) {
+	query := `
+        SELECT subcategory_id, parent_category_id, name, image_url, thumbnail_url, medium_url, webp_url,
+            display_order, is_active, created_at, updated_at
+        FROM admin_subcategories
+        WHERE true
+    `
+	args := []interface{}{}
+	if !f.IncludeInactive {
+		query += " AND is_active = true"
+	}
+	if f.IDs != nil {
+		args = append(args, f.IDs)
+		query += fmt.Sprintf(" AND subcategory_id = ANY($%d::int[])", len(args))
+	}
+	if categoryIDs != nil {
+		args = append(args, categoryIDs)
+		query += fmt.Sprintf(" AND parent_category_id = ANY($%d::int[])", len(args))
+	}
+	query += " ORDER BY parent_category_id, display_order, subcategory_id"
+
+	rows, err := db.Pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query subcategories: %w", err)
+	}
+	defer rows.Close()
+
+	subcategories := []models.Subcategory{}
+	for rows.Next() {
+		var s models.Subcategory
+		if err := rows.Scan(
+			&s.ID, &s.ParentCategoryID, &s.Name, &s.ImageURL, &s.ThumbnailURL, &s.MediumURL, &s.WebPURL,
+			&s.DisplayOrder, &s.IsActive, &s.CreatedAt, &s.UpdatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan subcategory: %w", err)
+		}
+		subcategories = append(subcategories, s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating subcategories: %w", err)
+	}
+	return subcategories, nil
+}
+
+// ListCatalogStores lists stores by ID. MiniAppType maps to the store types the mini-app serves,
+// as in GET /stores.
+func (db *Database) ListCatalogStores(ctx context.Context, storeType string, f CatalogFilter) ([]models.Store, error) {
+	query := `
+        SELECT store_id, name, city, address, latitude, longitude, type, region_id, image_url, thumbnail_url, medium_url,
+            webp_url, is_active, created_at, updated_at, timezone, opening_hours, holidays,
+            (SELECT r.currency FROM admin_regions r WHERE r.region_id = admin_stores.region_id)
+        FROM admin_stores
+        WHERE true
+    `
+	args := []interface{}{}
+	if !f.IncludeInactive {
+		query += " AND is_active = true"
+	} else {
+		query += " AND trashed_at IS NULL"
+	}
+	if f.IDs != nil {
+		args = append(args, f.IDs)
+		query += fmt.Sprintf(" AND store_id = ANY($%d::int[])", len(args))
+	}
+	if f.ScopeStoreIDs != nil {
+		args = append(args, f.ScopeStoreIDs)
+		query += fmt.Sprintf(" AND store_id = ANY($%d::int[])", len(args))
+	}
+	if f.RegionID != nil {
+		args = append(args, *f.RegionID)
+		query += fmt.Sprintf(" AND region_id = $%d", len(args))
+	}
+	if storeType != "" {
+		args = append(args, storeType)
+		query += fmt.Sprintf(" AND type = $%d", len(args))
+	}
+	switch f.MiniAppType {
+	case "UnmannedStore":
+		query += " AND type IN ('无人门店', '无人仓店')"
+	case "ExhibitionSales":
+		query += " AND type IN ('展销商店', '展销商城')"
+	}
+	query += " ORDER BY store_id"
+
+	rows, err := db.Pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query stores: %w", err)
+	}
+	defer rows.Close()
+
+	stores := []models.Store{}
+	for rows.Next() {
+		var s models.Store
+		var openingHours, holidays []byte
+		if err := rows.Scan(
+			&s.ID, &s.Name, &s.City, &s.Address, &s.Latitude, &s.Longitude, &s.Type, &s.RegionID, &s.ImageURL,
+			&s.ThumbnailURL, &s.MediumURL, &s.WebPURL, &s.IsActive, &s.CreatedAt, &s.UpdatedAt, &s.Timezone,
+			&openingHours, &holidays, &s.Currency,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan store: %w", err)
+		}
+		if err := s.UnmarshalColumns(openingHours, holidays); err != nil {
+			return nil, fmt.Errorf("failed to decode opening hours of store %d: %w", s.ID, err)
+		}
+		stores = append(stores, s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating stores: %w", err)
+	}
+	return stores, nil
+}
@@ -0,0 +1,104 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrNotInScope is returned when a reorder lists an id that is not an active member of the scope
+	ErrNotInScope = errors.New("not an active member of this scope")
+	// ErrDuplicateID is returned when a reorder lists the same id twice
+	ErrDuplicateID = errors.New("listed more than once")
+)
+
+// OrderedID is an id with the display_order it was given
+type OrderedID struct {
+	ID           int `json:"id"`
+	DisplayOrder int `json:"display_order"`
+}
+
+// ReorderCategories renumbers the active categories of a mini-app/store scope 1..n: the listed ids first in
+// the given order, then the unlisted ones in their previous order. store_id nil is the store-independent scope.
+func (db *Database) ReorderCategories(ctx context.Context, miniAppType string, storeID *int, ids []int) ([]OrderedID, error) {
+	return db.renumber(ctx, ids, `
+		SELECT category_id FROM admin_product_categories
+		WHERE $1 = ANY(mini_app_association) AND store_id IS NOT DISTINCT FROM $2 AND is_active = true
+		ORDER BY display_order, category_id
+		FOR UPDATE`,
+		`UPDATE admin_product_categories SET display_order = $2, updated_at = CURRENT_TIMESTAMP WHERE category_id = $1 AND display_order <> $2`,
+		miniAppType, storeID)
+}
+
+// ReorderSubcategories renumbers the active subcategories of a category like ReorderCategories
+func (db *Database) ReorderSubcategories(ctx context.Context, categoryID int, ids []int) ([]OrderedID, error) {
+	return db.renumber(ctx, ids, `
+		SELECT subcategory_id FROM admin_subcategories
+		WHERE parent_category_id = $1 AND is_active = true
+		ORDER BY display_order, subcategory_id
+		FOR UPDATE`,
+		`UPDATE admin_subcategories SET display_order = $2, updated_at = CURRENT_TIMESTAMP WHERE subcategory_id = $1 AND display_order <> $2`,
+		categoryID)
+}
+
+// renumber locks the scope's rows (selectSQL), checks the requested ids against them and writes the new order
+func (db *Database) renumber(ctx context.Context, ids []int, selectSQL, updateSQL string, scopeArgs ...any) ([]OrderedID, error) {
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	rows, err := tx.Query(ctx, selectSQL, scopeArgs...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock scope: %w", err)
+	}
+	var current []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan scope: %w", err)
+		}
+		current = append(current, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to lock scope: %w", err)
+	}
+
+	inScope := make(map[int]bool, len(current))
+	for _, id := range current {
+		inScope[id] = true
+	}
+	listed := make(map[int]bool, len(ids))
+	order := make([]int, 0, len(current))
+	for _, id := range ids {
+		if !inScope[id] {
+			return nil, fmt.Errorf("id %d: %w", id, ErrNotInScope)
+		}
+		if listed[id] {
+			return nil, fmt.Errorf("id %d: %w", id, ErrDuplicateID)
+		}
+		listed[id] = true
+		order = append(order, id)
+	}
+	for _, id := range current {
+		if !listed[id] {
+			order = append(order, id)
+		}
+	}
+
+	out := make([]OrderedID, 0, len(order))
+	for i, id := range order {
+		if _, err := tx.Exec(ctx, updateSQL, id, i+1); err != nil {
+			return nil, fmt.Errorf("failed to update display order: %w", err)
+		}
+		out = append(out, OrderedID{ID: id, DisplayOrder: i + 1})
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit reorder: %w", err)
+	}
+	return out, nil
+}
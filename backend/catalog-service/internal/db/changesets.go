@@ -0,0 +1,416 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/expotoworld/expotoworld/backend/catalog-service/internal/models"
+	"github.com/jackc/pgx/v5"
+)
+
+// ErrChangesetNotEditable is returned when staging into, scheduling or applying a changeset
+// that was already applied or discarded
+var ErrChangesetNotEditable = errors.New("changeset is no longer editable")
+
+const changesetColumns = `cs.changeset_id, cs.name, cs.status, cs.scheduled_for,
+	(SELECT COUNT(*) FROM app_changeset_items i WHERE i.changeset_id = cs.changeset_id),
+	cs.created_by, cs.applied_at, cs.last_error, cs.created_at, cs.updated_at`
+
+func scanChangeset(row pgx.Row) (*models.Changeset, error) {
+	var cs models.Changeset
+	if err := row.Scan(&cs.ID, &cs.Name, &cs.Status, &cs.ScheduledFor, &cs.ItemCount, &cs.CreatedBy, &cs.AppliedAt, &cs.LastError, &cs.CreatedAt, &cs.UpdatedAt); err != nil {
+		return nil, err
+	}
+	return &cs, nil
+}
+
+// ListChangesets returns changesets, newest first, optionally filtered by status
+func (db *Database) ListChangesets(ctx context.Context, status string) ([]models.Changeset, error) {
+	rows, err := db.Pool.Query(ctx, `
+		SELECT `+changesetColumns+`
+		FROM app_changesets cs
+		WHERE ($1 = '' OR cs.status = $1)
+		ORDER BY cs.changeset_id DESC
+	`, status)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := []models.Changeset{}
+	for rows.Next() {
+		cs, err := scanChangeset(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *cs)
+	}
+	return out, rows.Err()
+}
+
+// GetChangeset returns a single changeset
+func (db *Database) GetChangeset(ctx context.Context, id int64) (*models.Changeset, error) {
+	return scanChangeset(db.Pool.QueryRow(ctx, `SELECT `+changesetColumns+` FROM app_changesets cs WHERE cs.changeset_id = $1`, id))
+}
+
+// CreateChangeset inserts an empty draft (scheduled when ScheduledFor is set)
+func (db *Database) CreateChangeset(ctx context.Context, cs *models.Changeset) (*models.Changeset, error) {
+	status := models.ChangesetDraft
+	if cs.ScheduledFor != nil {
+		status = models.ChangesetScheduled
+	}
+	var id int64
+	err := db.Pool.QueryRow(ctx, `
+		INSERT INTO app_changesets (name, status, scheduled_for, created_by)
+		VALUES ($1, $2, $3, $4)
+		RETURNING changeset_id
+	`, cs.Name, status, cs.ScheduledFor, cs.CreatedBy).Scan(&id)
+	if err != nil {
+		return nil, err
+	}
+	return db.GetChangeset(ctx, id)
+}
+
+// lockEditableChangeset locks the changeset row for the rest of tx; pgx.ErrNoRows if it doesn't exist
+func lockEditableChangeset(ctx context.Context, tx pgx.Tx, id int64) error {
+	var status string
+	if err := tx.QueryRow(ctx, `SELECT status FROM app_changesets WHERE changeset_id = $1 FOR UPDATE`, id).Scan(&status); err != nil {
+		return err
+	}
+	if !(&models.Changeset{Status: status}).Editable() {
+		return ErrChangesetNotEditable
+	}
+	return nil
+}
+
+// GetChangesetItems returns the staged items of a changeset
+func (db *Database) GetChangesetItems(ctx context.Context, id int64) ([]models.ChangesetItem, error) {
+	rows, err := db.Pool.Query(ctx, `
+		SELECT product_id, main_price::float8, strikethrough_price::float8, clear_strikethrough, category_ids, subcategory_ids
+		FROM app_changeset_items
+		WHERE changeset_id = $1
+		ORDER BY product_id
+	`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := []models.ChangesetItem{}
+	for rows.Next() {
+		var it models.ChangesetItem
+		if err := rows.Scan(&it.ProductID, &it.MainPrice, &it.StrikethroughPrice, &it.ClearStrikethrough, &it.CategoryIDs, &it.SubcategoryIDs); err != nil {
+			return nil, err
+		}
+		out = append(out, it)
+	}
+	return out, rows.Err()
+}
+
+// StageChangesetItems adds or replaces the staged change of each product. A product that doesn't
+// exist yields ErrProductNotFound wrapped with its id; nothing is staged in that case.
+func (db *Database) StageChangesetItems(ctx context.Context, id int64, items []models.ChangesetItem) error {
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := lockEditableChangeset(ctx, tx, id); err != nil {
+		return err
+	}
+	for _, it := range items {
+		var exists bool
+		if err := tx.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM admin_products WHERE product_id = $1)`, it.ProductID).Scan(&exists); err != nil {
+			return err
+		}
+		if !exists {
+			return fmt.Errorf("product %d: %w", it.ProductID, ErrProductNotFound)
+		}
+		_, err := tx.Exec(ctx, `
+			INSERT INTO app_changeset_items (changeset_id, product_id, main_price, strikethrough_price, clear_strikethrough, category_ids, subcategory_ids)
+			VALUES ($1, $2, $3, $4, $5, $6, $7)
+			ON CONFLICT (changeset_id, product_id) DO UPDATE SET
+				main_price = EXCLUDED.main_price,
+				strikethrough_price = EXCLUDED.strikethrough_price,
+				clear_strikethrough = EXCLUDED.clear_strikethrough,
+				category_ids = EXCLUDED.category_ids,
+				subcategory_ids = EXCLUDED.subcategory_ids,
+				updated_at = now()
+		`, id, it.ProductID, it.MainPrice, it.StrikethroughPrice, it.ClearStrikethrough, it.CategoryIDs, it.SubcategoryIDs)
+		if err != nil {
+			return fmt.Errorf("stage product %d: %w", it.ProductID, err)
+		}
+	}
+	if _, err := tx.Exec(ctx, `UPDATE app_changesets SET updated_at = now() WHERE changeset_id = $1`, id); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// RemoveChangesetItem unstages a product; pgx.ErrNoRows if it wasn't staged
+func (db *Database) RemoveChangesetItem(ctx context.Context, id int64, productID int) error {
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := lockEditableChangeset(ctx, tx, id); err != nil {
+		return err
+	}
+	tag, err := tx.Exec(ctx, `DELETE FROM app_changeset_items WHERE changeset_id = $1 AND product_id = $2`, id, productID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	if _, err := tx.Exec(ctx, `UPDATE app_changesets SET updated_at = now() WHERE changeset_id = $1`, id); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// ScheduleChangeset sets (or with nil clears) the time the changeset goes live automatically
+func (db *Database) ScheduleChangeset(ctx context.Context, id int64, at *time.Time) (*models.Changeset, error) {
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := lockEditableChangeset(ctx, tx, id); err != nil {
+		return nil, err
+	}
+	status := models.ChangesetDraft
+	if at != nil {
+		status = models.ChangesetScheduled
+	}
+	if _, err := tx.Exec(ctx, `
+		UPDATE app_changesets SET status = $2, scheduled_for = $3, last_error = NULL, updated_at = now()
+		WHERE changeset_id = $1
+	`, id, status, at); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return db.GetChangeset(ctx, id)
+}
+
+// DiscardChangeset abandons a changeset without touching any product
+func (db *Database) DiscardChangeset(ctx context.Context, id int64) (*models.Changeset, error) {
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := lockEditableChangeset(ctx, tx, id); err != nil {
+		return nil, err
+	}
+	if _, err := tx.Exec(ctx, `UPDATE app_changesets SET status = 'discarded', updated_at = now() WHERE changeset_id = $1`, id); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return db.GetChangeset(ctx, id)
+}
+
+// PreviewChangeset diffs every staged item against the live product
+func (db *Database) PreviewChangeset(ctx context.Context, id int64) ([]models.ChangesetDiff, error) {
+	rows, err := db.Pool.Query(ctx, `
+		SELECT i.product_id, p.product_id IS NOT NULL, COALESCE(p.title, ''),
+			p.main_price::float8, p.strikethrough_price::float8,
+			COALESCE((SELECT array_agg(m.category_id ORDER BY m.category_id) FROM admin_product_category_mapping m WHERE m.product_id = i.product_id), '{}'),
+			COALESCE((SELECT array_agg(m.subcategory_id ORDER BY m.subcategory_id) FROM admin_product_subcategory_mapping m WHERE m.product_id = i.product_id), '{}'),
+			i.main_price::float8, i.strikethrough_price::float8, i.clear_strikethrough, i.category_ids, i.subcategory_ids
+		FROM app_changeset_items i
+		LEFT JOIN admin_products p ON p.product_id = i.product_id
+		WHERE i.changeset_id = $1
+		ORDER BY i.product_id
+	`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := []models.ChangesetDiff{}
+	for rows.Next() {
+		var d models.ChangesetDiff
+		var exists bool
+		var livePrice, liveStrike *float64
+		var liveCats, liveSubs []int
+		var it models.ChangesetItem
+		if err := rows.Scan(&d.ProductID, &exists, &d.Title, &livePrice, &liveStrike, &liveCats, &liveSubs,
+			&it.MainPrice, &it.StrikethroughPrice, &it.ClearStrikethrough, &it.CategoryIDs, &it.SubcategoryIDs); err != nil {
+			return nil, err
+		}
+		d.Missing = !exists
+		d.Changes = []models.FieldChange{}
+		if it.MainPrice != nil && (livePrice == nil || *livePrice != *it.MainPrice) {
+			d.Changes = append(d.Changes, models.FieldChange{Field: "main_price", From: livePrice, To: *it.MainPrice})
+		}
+		switch {
+		case it.ClearStrikethrough && liveStrike != nil:
+			d.Changes = append(d.Changes, models.FieldChange{Field: "strikethrough_price", From: *liveStrike, To: nil})
+		case !it.ClearStrikethrough && it.StrikethroughPrice != nil && (liveStrike == nil || *liveStrike != *it.StrikethroughPrice):
+			d.Changes = append(d.Changes, models.FieldChange{Field: "strikethrough_price", From: liveStrike, To: *it.StrikethroughPrice})
+		}
+		if it.CategoryIDs != nil && !sameIDs(liveCats, it.CategoryIDs) {
+			d.Changes = append(d.Changes, models.FieldChange{Field: "category_ids", From: liveCats, To: sortedIDs(it.CategoryIDs)})
+		}
+		if it.SubcategoryIDs != nil && !sameIDs(liveSubs, it.SubcategoryIDs) {
+			d.Changes = append(d.Changes, models.FieldChange{Field: "subcategory_ids", From: liveSubs, To: sortedIDs(it.SubcategoryIDs)})
+		}
+		out = append(out, d)
+	}
+	return out, rows.Err()
+}
+
+func sortedIDs(ids []int) []int {
+	out := append([]int{}, ids...)
+	sort.Ints(out)
+	return out
+}
+
+// sameIDs compares a sorted id list with an unordered one
+func sameIDs(sorted, ids []int) bool {
+	if len(sorted) != len(ids) {
+		return false
+	}
+	for i, id := range sortedIDs(ids) {
+		if sorted[i] != id {
+			return false
+		}
+	}
+	return true
+}
+
+// ApplyChangeset makes every staged change live in one transaction: either all products are
+// updated and the changeset is marked applied, or nothing changes.
+func (db *Database) ApplyChangeset(ctx context.Context, id int64) (*models.Changeset, error) {
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := lockEditableChangeset(ctx, tx, id); err != nil {
+		return nil, err
+	}
+	if err := applyChangesetItems(ctx, tx, id); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return db.GetChangeset(ctx, id)
+}
+
+// ApplyDueChangeset applies the oldest scheduled changeset whose time has come. ok is false when none
+// is due. A changeset that fails to apply is left untouched and marked failed with the error.
+func (db *Database) ApplyDueChangeset(ctx context.Context) (id int64, ok bool, err error) {
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		return 0, false, err
+	}
+	defer tx.Rollback(ctx)
+
+	err = tx.QueryRow(ctx, `
+		SELECT changeset_id FROM app_changesets
+		WHERE status = 'scheduled' AND scheduled_for <= now()
+		ORDER BY scheduled_for, changeset_id
+		LIMIT 1
+		FOR UPDATE SKIP LOCKED
+	`).Scan(&id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, err
+	}
+
+	if applyErr := applyChangesetItems(ctx, tx, id); applyErr != nil {
+		tx.Rollback(ctx)
+		if _, err := db.Pool.Exec(ctx, `
+			UPDATE app_changesets SET status = 'failed', last_error = $2, updated_at = now()
+			WHERE changeset_id = $1 AND status = 'scheduled'
+		`, id, applyErr.Error()); err != nil {
+			return id, true, err
+		}
+		return id, true, applyErr
+	}
+	return id, true, tx.Commit(ctx)
+}
+
+// applyChangesetItems writes the staged items to the products and marks the changeset applied (within tx)
+func applyChangesetItems(ctx context.Context, tx pgx.Tx, id int64) error {
+	rows, err := tx.Query(ctx, `
+		SELECT product_id, main_price::float8, strikethrough_price::float8, clear_strikethrough, category_ids, subcategory_ids
+		FROM app_changeset_items WHERE changeset_id = $1 ORDER BY product_id
+	`, id)
+	if err != nil {
+		return err
+	}
+	var items []models.ChangesetItem
+	for rows.Next() {
+		var it models.ChangesetItem
+		if err := rows.Scan(&it.ProductID, &it.MainPrice, &it.StrikethroughPrice, &it.ClearStrikethrough, &it.CategoryIDs, &it.SubcategoryIDs); err != nil {
+			rows.Close()
+			return err
+		}
+		items = append(items, it)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, it := range items {
+		tag, err := tx.Exec(ctx, `
+			UPDATE admin_products SET
+				main_price = COALESCE($2, main_price),
+				strikethrough_price = CASE WHEN $4 THEN NULL ELSE COALESCE($3, strikethrough_price) END,
+				updated_at = CURRENT_TIMESTAMP
+			WHERE product_id = $1
+		`, it.ProductID, it.MainPrice, it.StrikethroughPrice, it.ClearStrikethrough)
+		if err != nil {
+			return fmt.Errorf("update product %d: %w", it.ProductID, err)
+		}
+		if tag.RowsAffected() == 0 {
+			return fmt.Errorf("product %d: %w", it.ProductID, ErrProductNotFound)
+		}
+		if it.CategoryIDs != nil {
+			if _, err := tx.Exec(ctx, `DELETE FROM admin_product_category_mapping WHERE product_id = $1`, it.ProductID); err != nil {
+				return fmt.Errorf("move product %d: %w", it.ProductID, err)
+			}
+			for _, categoryID := range it.CategoryIDs {
+				if _, err := tx.Exec(ctx, `INSERT INTO admin_product_category_mapping (product_id, category_id) VALUES ($1, $2)`, it.ProductID, categoryID); err != nil {
+					return fmt.Errorf("move product %d to category %d: %w", it.ProductID, categoryID, err)
+				}
+			}
+		}
+		if it.SubcategoryIDs != nil {
+			if _, err := tx.Exec(ctx, `DELETE FROM admin_product_subcategory_mapping WHERE product_id = $1`, it.ProductID); err != nil {
+				return fmt.Errorf("move product %d: %w", it.ProductID, err)
+			}
+			for _, subcategoryID := range it.SubcategoryIDs {
+				if _, err := tx.Exec(ctx, `INSERT INTO admin_product_subcategory_mapping (product_id, subcategory_id) VALUES ($1, $2)`, it.ProductID, subcategoryID); err != nil {
+					return fmt.Errorf("move product %d to subcategory %d: %w", it.ProductID, subcategoryID, err)
+				}
+			}
+		}
+	}
+
+	_, err = tx.Exec(ctx, `
+		UPDATE app_changesets SET status = 'applied', applied_at = now(), last_error = NULL, updated_at = now()
+		WHERE changeset_id = $1
+	`, id)
+	return err
+}
@@ -0,0 +1,55 @@
+package db
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/expotoworld/expotoworld/backend/catalog-service/internal/models"
+)
+
+// NewArrivalsSQL and BackInStockSQL are conditions on product alias p for products created, or restocked
+// (stock 0 -> >0, still in stock), within the last $N days; GetProducts reuses them as filters.
+const (
+	NewArrivalsSQL = `p.created_at >= now() - make_interval(days => %s)`
+	BackInStockSQL = `p.restocked_at >= now() - make_interval(days => %s) AND p.stock_left > 0`
+)
+
+// GetProductCollection returns active products of a computed collection, newest first
+func (db *Database) GetProductCollection(ctx context.Context, collection models.ProductCollection, days int, miniAppType string, limit int) ([]models.ProductCollectionItem, error) {
+	var cond, since string
+	switch collection {
+	case models.CollectionNewArrivals:
+		cond, since = fmt.Sprintf(NewArrivalsSQL, "$1"), "p.created_at"
+	case models.CollectionBackInStock:
+		cond, since = fmt.Sprintf(BackInStockSQL, "$1"), "p.restocked_at"
+	default:
+		return nil, fmt.Errorf("unknown collection %q", collection)
+	}
+
+	rows, err := db.Pool.Query(ctx, `
+		SELECT p.product_id, p.product_uuid, COALESCE(p.title, ''), COALESCE(p.mini_app_type::text, ''),
+			COALESCE(p.main_price, 0), p.strikethrough_price, COALESCE(p.stock_left, 0),
+			(SELECT i.image_url FROM admin_product_images i WHERE i.product_id = p.product_id
+				ORDER BY i.is_primary DESC, i.display_order, i.image_id LIMIT 1),
+			`+since+`
+		FROM admin_products p
+		WHERE p.is_active = true AND `+cond+` AND ($2 = '' OR p.mini_app_type::text = $2)
+		ORDER BY `+since+` DESC, p.product_id
+		LIMIT $3
+	`, days, miniAppType, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := make([]models.ProductCollectionItem, 0)
+	for rows.Next() {
+		var it models.ProductCollectionItem
+		if err := rows.Scan(&it.ProductID, &it.UUID, &it.Title, &it.MiniAppType, &it.MainPrice, &it.StrikethroughPrice,
+			&it.StockLeft, &it.ImageURL, &it.Since); err != nil {
+			return nil, err
+		}
+		items = append(items, it)
+	}
+	return items, rows.Err()
+}
@@ -0,0 +1,115 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/expotoworld/expotoworld/backend/catalog-service/internal/models"
+	"github.com/jackc/pgx/v5"
+)
+
+// ErrCommissionRateInEffect is returned when deleting a rate version that has already taken effect;
+// settled periods were computed with it, so it can only be superseded by a newer version.
+var ErrCommissionRateInEffect = errors.New("commission rate already in effect")
+
+const commissionRateColumns = `r.rate_id, r.category_id, COALESCE(c.name, ''), r.manufacturer_org_id::text, COALESCE(o.name, ''),
+	r.rate_pct::float8, r.effective_from, COALESCE(r.notes, ''), COALESCE(r.created_by, ''), r.created_at`
+
+const commissionRateJoins = `
+	FROM app_commission_rates r
+	LEFT JOIN admin_product_categories c ON c.category_id = r.category_id
+	LEFT JOIN admin_organizations o ON o.org_id = r.manufacturer_org_id`
+
+func scanCommissionRate(row pgx.Row) (*models.CommissionRate, error) {
+	var r models.CommissionRate
+	if err := row.Scan(&r.ID, &r.CategoryID, &r.CategoryName, &r.ManufacturerOrgID, &r.ManufacturerName,
+		&r.RatePct, &r.EffectiveFrom, &r.Notes, &r.CreatedBy, &r.CreatedAt); err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+// CommissionRateFilter narrows ListCommissionRates. CurrentOnly keeps the version in force now
+// for each scope plus any scheduled future versions.
+type CommissionRateFilter struct {
+	CategoryID        *int
+	ManufacturerOrgID string
+	CurrentOnly       bool
+}
+
+// ListCommissionRates returns commission rate versions grouped by scope, newest version first
+func (db *Database) ListCommissionRates(ctx context.Context, f CommissionRateFilter) ([]models.CommissionRate, error) {
+	rows, err := db.Pool.Query(ctx, `
+		SELECT `+commissionRateColumns+commissionRateJoins+`
+		WHERE ($1::int IS NULL OR r.category_id = $1)
+		  AND ($2 = '' OR r.manufacturer_org_id::text = $2)
+		  AND (NOT $3 OR r.effective_from > now() OR r.rate_id = (
+			SELECT v.rate_id FROM app_commission_rates v
+			WHERE v.category_id IS NOT DISTINCT FROM r.category_id
+			  AND v.manufacturer_org_id IS NOT DISTINCT FROM r.manufacturer_org_id
+			  AND v.effective_from <= now()
+			ORDER BY v.effective_from DESC LIMIT 1))
+		ORDER BY r.manufacturer_org_id NULLS FIRST, r.category_id NULLS FIRST, r.effective_from DESC
+	`, f.CategoryID, f.ManufacturerOrgID, f.CurrentOnly)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := []models.CommissionRate{}
+	for rows.Next() {
+		r, err := scanCommissionRate(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *r)
+	}
+	return out, rows.Err()
+}
+
+// GetCommissionRate returns a single commission rate version
+func (db *Database) GetCommissionRate(ctx context.Context, id int64) (*models.CommissionRate, error) {
+	return scanCommissionRate(db.Pool.QueryRow(ctx, `SELECT `+commissionRateColumns+commissionRateJoins+` WHERE r.rate_id = $1`, id))
+}
+
+// CreateCommissionRate adds a new rate version for its scope
+func (db *Database) CreateCommissionRate(ctx context.Context, r *models.CommissionRate) (*models.CommissionRate, error) {
+	var id int64
+	err := db.Pool.QueryRow(ctx, `
+		INSERT INTO app_commission_rates (category_id, manufacturer_org_id, rate_pct, effective_from, notes, created_by)
+		VALUES ($1, $2::uuid, $3, $4, NULLIF($5, ''), NULLIF($6, ''))
+		RETURNING rate_id
+	`, r.CategoryID, r.ManufacturerOrgID, r.RatePct, r.EffectiveFrom, r.Notes, r.CreatedBy).Scan(&id)
+	if err != nil {
+		return nil, err
+	}
+	return db.GetCommissionRate(ctx, id)
+}
+
+// DeleteCommissionRate removes a scheduled rate version that has not taken effect yet
+func (db *Database) DeleteCommissionRate(ctx context.Context, id int64) error {
+	tag, err := db.Pool.Exec(ctx, `DELETE FROM app_commission_rates WHERE rate_id = $1 AND effective_from > now()`, id)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		if _, err := db.GetCommissionRate(ctx, id); err != nil {
+			return err
+		}
+		return ErrCommissionRateInEffect
+	}
+	return nil
+}
+
+// ResolveCommissionRate returns the rate that applies to a product sold by a manufacturer at the given time.
+// RateID and RatePct are nil when no rate is configured.
+func (db *Database) ResolveCommissionRate(ctx context.Context, productID int, manufacturerOrgID string, at time.Time) (*models.ResolvedCommissionRate, error) {
+	res := &models.ResolvedCommissionRate{ProductID: productID, ManufacturerOrgID: manufacturerOrgID, At: at}
+	err := db.Pool.QueryRow(ctx, `SELECT rate_id, rate_pct::float8 FROM app_commission_rate($1, NULLIF($2, '')::uuid, $3)`,
+		productID, manufacturerOrgID, at).Scan(&res.RateID, &res.RatePct)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return nil, err
+	}
+	return res, nil
+}
@@ -0,0 +1,113 @@
+package db
+
+import (
+	"context"
+	"time"
+
+	"github.com/expotoworld/expotoworld/backend/catalog-service/internal/models"
+	"github.com/jackc/pgx/v5"
+)
+
+// GetCompetitorPrices returns the tracked competitor prices of a product, cheapest first
+func (db *Database) GetCompetitorPrices(ctx context.Context, productID int) ([]models.CompetitorPrice, error) {
+	rows, err := db.Pool.Query(ctx, `
+		SELECT product_id, source, COALESCE(url, ''), price::float8, last_checked_at
+		FROM app_product_competitor_prices
+		WHERE product_id = $1
+		ORDER BY price, source
+	`, productID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := []models.CompetitorPrice{}
+	for rows.Next() {
+		var cp models.CompetitorPrice
+		if err := rows.Scan(&cp.ProductID, &cp.Source, &cp.URL, &cp.Price, &cp.LastCheckedAt); err != nil {
+			return nil, err
+		}
+		out = append(out, cp)
+	}
+	return out, rows.Err()
+}
+
+// UpsertCompetitorPrices records observed competitor prices. Rows for unknown products are skipped;
+// the number of stored rows is returned. A zero LastCheckedAt means now.
+func (db *Database) UpsertCompetitorPrices(ctx context.Context, prices []models.CompetitorPrice) (int, error) {
+	if len(prices) == 0 {
+		return 0, nil
+	}
+	batch := &pgx.Batch{}
+	for _, p := range prices {
+		checkedAt := p.LastCheckedAt
+		if checkedAt.IsZero() {
+			checkedAt = time.Now()
+		}
+		batch.Queue(`
+			INSERT INTO app_product_competitor_prices (product_id, source, url, price, last_checked_at)
+			SELECT $1, $2, NULLIF($3, ''), $4, $5
+			WHERE EXISTS (SELECT 1 FROM admin_products WHERE product_id = $1)
+			ON CONFLICT (product_id, source) DO UPDATE SET
+				url = COALESCE(EXCLUDED.url, app_product_competitor_prices.url),
+				price = EXCLUDED.price,
+				last_checked_at = EXCLUDED.last_checked_at,
+				updated_at = now()
+			WHERE app_product_competitor_prices.last_checked_at <= EXCLUDED.last_checked_at
+		`, p.ProductID, p.Source, p.URL, p.Price, checkedAt)
+	}
+	br := db.Pool.SendBatch(ctx, batch)
+	defer br.Close()
+
+	stored := 0
+	for range prices {
+		tag, err := br.Exec()
+		if err != nil {
+			return stored, err
+		}
+		stored += int(tag.RowsAffected())
+	}
+	return stored, nil
+}
+
+// DeleteCompetitorPrice stops tracking a competitor source for a product
+func (db *Database) DeleteCompetitorPrice(ctx context.Context, productID int, source string) error {
+	tag, err := db.Pool.Exec(ctx, `DELETE FROM app_product_competitor_prices WHERE product_id = $1 AND source = $2`, productID, source)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
+
+// GetCompetitorPriceAlerts lists active products priced more than marginPct percent above a competitor
+// price checked within maxAge, largest gap first
+func (db *Database) GetCompetitorPriceAlerts(ctx context.Context, marginPct float64, maxAge time.Duration, limit int) ([]models.CompetitorPriceAlert, error) {
+	rows, err := db.Pool.Query(ctx, `
+		SELECT p.product_id, p.sku, p.title, p.main_price::float8, cp.source, COALESCE(cp.url, ''), cp.price::float8,
+			((p.main_price - cp.price) / cp.price * 100)::float8 AS difference_pct, cp.last_checked_at
+		FROM app_product_competitor_prices cp
+		JOIN admin_products p ON p.product_id = cp.product_id
+		WHERE p.is_active
+		  AND cp.last_checked_at >= now() - make_interval(secs => $2)
+		  AND p.main_price > cp.price * (1 + $1 / 100.0)
+		ORDER BY difference_pct DESC, p.product_id
+		LIMIT $3
+	`, marginPct, maxAge.Seconds(), limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := []models.CompetitorPriceAlert{}
+	for rows.Next() {
+		var a models.CompetitorPriceAlert
+		if err := rows.Scan(&a.ProductID, &a.SKU, &a.Title, &a.OurPrice, &a.Source, &a.URL, &a.CompetitorPrice, &a.DifferencePct, &a.LastCheckedAt); err != nil {
+			return nil, err
+		}
+		out = append(out, a)
+	}
+	return out, rows.Err()
+}
@@ -2,6 +2,7 @@ package db
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"net"
@@ -11,6 +12,7 @@ import (
 	"time"
 
 	"github.com/expotoworld/expotoworld/backend/catalog-service/internal/models"
+	"github.com/expotoworld/expotoworld/backend/common/auth"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
@@ -18,6 +20,8 @@ import (
 // Database holds the database connection pool
 type Database struct {
 	Pool *pgxpool.Pool
+	// trigramSearch is set once InitSearchSchema installed pg_trgm and its indexes
+	trigramSearch bool
 }
 
 // Config holds database configuration
@@ -162,8 +166,23 @@ func NewDatabaseWithRetry(maxRetries int, initialDelay time.Duration) (*Database
 		return nil, fmt.Errorf("failed to connect to database after %d attempts: %w", maxRetries, lastErr)
 	}
 
+	db := &Database{Pool: pool}
+
+	// Initialize auxiliary schema (non-fatal)
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if err := db.InitSchema(ctx); err != nil {
+		log.Printf("[CATALOG-DB] Warning: Failed to initialize database schema: %v", err)
+	}
+	if err := db.InitGeoSchema(ctx); err != nil {
+		log.Printf("[CATALOG-DB] Warning: Failed to initialize PostGIS store locations (nearby-store search unavailable): %v", err)
+	}
+	if err := db.InitSearchSchema(ctx); err != nil {
+		log.Printf("[CATALOG-DB] Warning: Failed to initialize search indexes (suggestions fall back to scans): %v", err)
+	}
+
 	log.Println("[CATALOG-DB] Database connection established successfully")
-	return &Database{Pool: pool}, nil
+	return db, nil
 }
 
 // Close closes the database connection pool
@@ -179,6 +198,17 @@ func (db *Database) Health(ctx context.Context) error {
 	return db.Pool.Ping(ctx)
 }
 
+// TokenVersion returns the user's access token version from app_users (owned by auth-service);
+// auth.ErrTokenRevoked if the user no longer exists
+func (db *Database) TokenVersion(ctx context.Context, userID string) (int, error) {
+	var version int
+	err := db.Pool.QueryRow(ctx, `SELECT token_version FROM app_users WHERE id::text = $1`, userID).Scan(&version)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, auth.ErrTokenRevoked
+	}
+	return version, err
+}
+
 // =================================================================================
 // NEW FUNCTIONS FOR WRITING DATA
 // =================================================================================
@@ -222,9 +252,9 @@ func (db *Database) CreateProduct(ctx context.Context, product models.Product) (
 	var productID int
 	query := `
         INSERT INTO admin_products
-            (sku, title, description, store_type, mini_app_type, store_id, shelf_code, main_price, strikethrough_price, cost_price, weight, stock_left, minimum_order_quantity, is_active, is_featured, is_mini_app_recommendation)
+            (sku, title, description, store_type, mini_app_type, store_id, shelf_code, main_price, strikethrough_price, cost_price, weight, stock_left, minimum_order_quantity, is_active, is_featured, is_mini_app_recommendation, tax_class, barcode)
         VALUES
-            ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16)
+            ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18)
         RETURNING product_id
     `
 	err = tx.QueryRow(ctx, query,
@@ -244,9 +274,14 @@ func (db *Database) CreateProduct(ctx context.Context, product models.Product) (
 		product.IsActive,
 		product.IsFeatured,
 		product.IsMiniAppRecommendation,
+		product.TaxClass,
+		product.Barcode,
 	).Scan(&productID)
 
 	if err != nil {
+		if barcodeTaken(err) {
+			return 0, ErrBarcodeTaken
+		}
 		return 0, fmt.Errorf("failed to insert product: %w", err)
 	}
 
@@ -348,8 +383,9 @@ func (db *Database) ReplaceProductImage(ctx context.Context, productID int, imag
 	return nil
 }
 
-// UpdateProduct updates an existing product in the database
-func (db *Database) UpdateProduct(ctx context.Context, productID int, product models.Product) error {
+// UpdateProduct updates an existing product in the database. With unmodifiedSince set, the update
+// only applies if the product wasn't changed after it (ErrEditConflict otherwise).
+func (db *Database) UpdateProduct(ctx context.Context, productID int, product models.Product, unmodifiedSince *time.Time) error {
 	// Start a transaction to ensure atomicity
 	// Determine store_type param: NULL for RetailStore and GroupBuying
 	var storeTypeParam interface{}
@@ -375,7 +411,8 @@ func (db *Database) UpdateProduct(ctx context.Context, productID int, product mo
 		shelfCodeParam = nil
 	}
 
-	// Determine stock_left param: only Unmanned store types track inventory
+	// Determine stock_left param: only Unmanned store types track inventory. Tracked stock is changed
+	// through AdjustProductStock; the payload value only seeds products that weren't tracked before.
 	var stockLeftParam interface{}
 	switch product.StoreType {
 	case models.StoreTypeUnmannedStore, models.StoreTypeUnmannedWarehouse:
@@ -402,15 +439,17 @@ func (db *Database) UpdateProduct(ctx context.Context, productID int, product mo
             strikethrough_price = $10,
             cost_price = $11,
             weight = $12,
-            stock_left = $13,
+            stock_left = CASE WHEN $13::int IS NULL THEN NULL ELSE COALESCE(stock_left, $13::int) END,
             minimum_order_quantity = $14,
             is_active = $15,
             is_featured = $16,
             is_mini_app_recommendation = $17,
+            tax_class = $18,
+            barcode = $19,
             updated_at = CURRENT_TIMESTAMP
         WHERE product_id = $1
     `
-	result, err := tx.Exec(ctx, query,
+	args := []interface{}{
 		productID,
 		product.SKU,
 		product.Title,
@@ -428,15 +467,34 @@ func (db *Database) UpdateProduct(ctx context.Context, productID int, product mo
 		product.IsActive,
 		product.IsFeatured,
 		product.IsMiniAppRecommendation,
-	)
+		product.TaxClass,
+		product.Barcode,
+	}
+	if unmodifiedSince != nil {
+		args = append(args, *unmodifiedSince)
+		query += fmt.Sprintf(" AND updated_at <= $%d", len(args))
+	}
+	result, err := tx.Exec(ctx, query, args...)
 
 	if err != nil {
 		log.Printf("[DB.UpdateProduct] update error: %v", err)
+		if barcodeTaken(err) {
+			return ErrBarcodeTaken
+		}
 		return fmt.Errorf("failed to update product: %w", err)
 	}
 
 	rowsAffected := result.RowsAffected()
 	if rowsAffected == 0 {
+		if unmodifiedSince != nil {
+			var exists bool
+			if err := tx.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM admin_products WHERE product_id = $1)`, productID).Scan(&exists); err != nil {
+				return fmt.Errorf("failed to check product: %w", err)
+			}
+			if exists {
+				return ErrEditConflict
+			}
+		}
 		return fmt.Errorf("product with ID %d not found", productID)
 	}
 
@@ -519,18 +577,52 @@ func (db *Database) DeleteProduct(ctx context.Context, productID int) error {
 	return nil
 }
 
-// HardDeleteProduct permanently removes a product from the database
-// Use with caution - this is irreversible
-func (db *Database) HardDeleteProduct(ctx context.Context, productID int) error {
-	// Start a transaction to ensure data consistency
+var (
+	// ErrProductNotDeleted is returned when restoring a product that is still active
+	ErrProductNotDeleted = errors.New("product is not deleted")
+	// ErrTaxClassRequired is returned when a product without a tax class would become active
+	ErrTaxClassRequired = errors.New("tax_class is required for active products")
+	// ErrEditConflict is returned when an update's If-Unmodified-Since precondition fails: the row
+	// was changed by someone else after the caller loaded it
+	ErrEditConflict = errors.New("modified since it was loaded")
+)
+
+// RestoreProduct reactivates a soft-deleted product
+func (db *Database) RestoreProduct(ctx context.Context, productID int) error {
 	tx, err := db.Pool.Begin(ctx)
 	if err != nil {
 		return fmt.Errorf("failed to start transaction: %w", err)
 	}
 	defer tx.Rollback(ctx)
 
+	var isActive bool
+	var taxClass *string
+	err = tx.QueryRow(ctx, `SELECT COALESCE(is_active, false), tax_class FROM admin_products WHERE product_id = $1 FOR UPDATE`,
+		productID).Scan(&isActive, &taxClass)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ErrProductNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to load product: %w", err)
+	}
+	if isActive {
+		return ErrProductNotDeleted
+	}
+	if taxClass == nil {
+		return ErrTaxClassRequired
+	}
+
+	if _, err := tx.Exec(ctx, `UPDATE admin_products SET is_active = true, updated_at = CURRENT_TIMESTAMP WHERE product_id = $1`, productID); err != nil {
+		return fmt.Errorf("failed to restore product: %w", err)
+	}
+	return tx.Commit(ctx)
+}
+
+// deleteProductRows permanently removes a product and its dependent rows in tx. Use with
+// caution - this is irreversible; hard deletes go through the trash (see PurgeTrashedItem).
+func deleteProductRows(ctx context.Context, tx pgx.Tx, productID int) error {
 	// Delete product images first (foreign key constraint)
-	_, err = tx.Exec(ctx, "DELETE FROM admin_product_images WHERE product_id = $1", productID)
+	_, err := tx.Exec(ctx, "DELETE FROM admin_product_images WHERE product_id = $1", productID)
 	if err != nil {
 		return fmt.Errorf("failed to delete product images: %w", err)
 	}
@@ -559,6 +651,30 @@ func (db *Database) HardDeleteProduct(ctx context.Context, productID int) error
 		return fmt.Errorf("failed to delete product logistics: %w", err)
 	}
 
+	// Delete product translations
+	_, err = tx.Exec(ctx, "DELETE FROM app_product_translations WHERE product_id = $1", productID)
+	if err != nil {
+		return fmt.Errorf("failed to delete product translations: %w", err)
+	}
+
+	// Delete food label data
+	_, err = tx.Exec(ctx, "DELETE FROM app_product_food_labels WHERE product_id = $1", productID)
+	if err != nil {
+		return fmt.Errorf("failed to delete product food label: %w", err)
+	}
+
+	// Delete product variants
+	_, err = tx.Exec(ctx, "DELETE FROM app_product_variants WHERE product_id = $1", productID)
+	if err != nil {
+		return fmt.Errorf("failed to delete product variants: %w", err)
+	}
+
+	// Delete relations in both directions
+	_, err = tx.Exec(ctx, "DELETE FROM app_product_relations WHERE product_id = $1 OR related_product_id = $1", productID)
+	if err != nil {
+		return fmt.Errorf("failed to delete product relations: %w", err)
+	}
+
 	// Finally delete the product
 	result, err := tx.Exec(ctx, "DELETE FROM admin_products WHERE product_id = $1", productID)
 	if err != nil {
@@ -569,12 +685,6 @@ func (db *Database) HardDeleteProduct(ctx context.Context, productID int) error
 	if rowsAffected == 0 {
 		return fmt.Errorf("product with ID %d not found", productID)
 	}
-
-	// Commit the transaction
-	if err := tx.Commit(ctx); err != nil {
-		return fmt.Errorf("failed to commit transaction: %w", err)
-	}
-
 	return nil
 }
 
@@ -593,10 +703,56 @@ func (db *Database) AddProductImage(ctx context.Context, productID int, imageURL
 	return imageID, nil
 }
 
+// AppendProductImage adds an image after the product's existing ones; the first image becomes primary.
+// Confirming the same URL twice returns the existing row, with created false.
+func (db *Database) AppendProductImage(ctx context.Context, productID int, imageURL string) (*models.ProductImage, bool, error) {
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	// Lock the product so concurrent confirms get distinct display orders
+	var locked int
+	err = tx.QueryRow(ctx, `SELECT product_id FROM admin_products WHERE product_id = $1 FOR UPDATE`, productID).Scan(&locked)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, false, ErrProductNotFound
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to lock product: %w", err)
+	}
+
+	image := models.ProductImage{ProductID: productID, ImageURL: imageURL}
+	err = tx.QueryRow(ctx, `
+		SELECT image_id, display_order, is_primary, created_at
+		FROM admin_product_images WHERE product_id = $1 AND image_url = $2
+	`, productID, imageURL).Scan(&image.ID, &image.DisplayOrder, &image.IsPrimary, &image.CreatedAt)
+	if err == nil {
+		return &image, false, nil
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return nil, false, fmt.Errorf("failed to check existing image: %w", err)
+	}
+
+	err = tx.QueryRow(ctx, `
+		INSERT INTO admin_product_images (product_id, image_url, display_order, is_primary)
+		SELECT $1, $2, COALESCE(MAX(display_order), 0) + 1, COUNT(*) = 0
+		FROM admin_product_images WHERE product_id = $1
+		RETURNING image_id, display_order, is_primary, created_at
+	`, productID, imageURL).Scan(&image.ID, &image.DisplayOrder, &image.IsPrimary, &image.CreatedAt)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to insert product image: %w", err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, false, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return &image, true, nil
+}
+
 // GetProductImages retrieves all images for a product
 func (db *Database) GetProductImages(ctx context.Context, productID int) ([]models.ProductImage, error) {
 	query := `
-        SELECT image_id, product_id, image_url, display_order, is_primary, created_at
+        SELECT image_id, product_id, image_url, thumbnail_url, medium_url, webp_url, display_order, is_primary, created_at
         FROM admin_product_images
         WHERE product_id = $1
         ORDER BY display_order, image_id
@@ -615,6 +771,9 @@ func (db *Database) GetProductImages(ctx context.Context, productID int) ([]mode
 			&image.ID,
 			&image.ProductID,
 			&image.ImageURL,
+			&image.ThumbnailURL,
+			&image.MediumURL,
+			&image.WebPURL,
 			&image.DisplayOrder,
 			&image.IsPrimary,
 			&image.CreatedAt,
@@ -632,6 +791,55 @@ func (db *Database) GetProductImages(ctx context.Context, productID int) ([]mode
 	return images, nil
 }
 
+// ProductRelations holds a product's image URLs and category/subcategory IDs as listed by GetProducts.
+// ThumbnailURLs parallels ImageURLs, using the original where no thumbnail was generated yet.
+type ProductRelations struct {
+	ImageURLs      []string
+	ThumbnailURLs  []string
+	CategoryIDs    []string
+	SubcategoryIDs []string
+}
+
+// GetProductRelations loads images and category mappings for many products in a single query.
+// Every requested ID is present in the result, with empty slices when it has no rows.
+func (db *Database) GetProductRelations(ctx context.Context, productIDs []int) (map[int]ProductRelations, error) {
+	out := make(map[int]ProductRelations, len(productIDs))
+	if len(productIDs) == 0 {
+		return out, nil
+	}
+	query := `
+        SELECT ids.product_id,
+            COALESCE((SELECT array_agg(i.image_url ORDER BY i.display_order, i.image_id)
+                FROM admin_product_images i WHERE i.product_id = ids.product_id), '{}'),
+            COALESCE((SELECT array_agg(COALESCE(i.thumbnail_url, i.image_url) ORDER BY i.display_order, i.image_id)
+                FROM admin_product_images i WHERE i.product_id = ids.product_id), '{}'),
+            COALESCE((SELECT array_agg(pcm.category_id::text ORDER BY pcm.category_id)
+                FROM admin_product_category_mapping pcm WHERE pcm.product_id = ids.product_id), '{}'),
+            COALESCE((SELECT array_agg(psm.subcategory_id::text ORDER BY psm.subcategory_id)
+                FROM admin_product_subcategory_mapping psm WHERE psm.product_id = ids.product_id), '{}')
+        FROM unnest($1::int[]) AS ids(product_id)
+    `
+
+	rows, err := db.Pool.Query(ctx, query, productIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query product relations: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id int
+		var r ProductRelations
+		if err := rows.Scan(&id, &r.ImageURLs, &r.ThumbnailURLs, &r.CategoryIDs, &r.SubcategoryIDs); err != nil {
+			return nil, fmt.Errorf("failed to scan product relations: %w", err)
+		}
+		out[id] = r
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating product relations: %w", err)
+	}
+	return out, nil
+}
+
 // UpdateImageDisplayOrder updates the display order of a product image
 func (db *Database) UpdateImageDisplayOrder(ctx context.Context, productID, imageID, displayOrder int) error {
 	query := `
@@ -0,0 +1,125 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/expotoworld/expotoworld/backend/catalog-service/internal/models"
+	"github.com/jackc/pgx/v5"
+)
+
+const experimentColumns = `experiment_key, COALESCE(description, ''), variants, status, salt, created_at, updated_at`
+
+func scanExperiment(row pgx.Row) (*models.Experiment, error) {
+	var e models.Experiment
+	var variants []byte
+	if err := row.Scan(&e.Key, &e.Description, &variants, &e.Status, &e.Salt, &e.CreatedAt, &e.UpdatedAt); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(variants, &e.Variants); err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
+
+// ListExperiments returns experiments, optionally filtered by status
+func (db *Database) ListExperiments(ctx context.Context, status string) ([]models.Experiment, error) {
+	rows, err := db.Pool.Query(ctx, `
+		SELECT `+experimentColumns+`
+		FROM app_experiments
+		WHERE ($1 = '' OR status = $1)
+		ORDER BY experiment_key
+	`, status)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := []models.Experiment{}
+	for rows.Next() {
+		e, err := scanExperiment(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *e)
+	}
+	return out, rows.Err()
+}
+
+// GetExperiment returns a single experiment by key
+func (db *Database) GetExperiment(ctx context.Context, key string) (*models.Experiment, error) {
+	return scanExperiment(db.Pool.QueryRow(ctx, `SELECT `+experimentColumns+` FROM app_experiments WHERE experiment_key = $1`, key))
+}
+
+// UpsertExperiment creates or replaces an experiment definition
+func (db *Database) UpsertExperiment(ctx context.Context, e *models.Experiment) (*models.Experiment, error) {
+	variants, err := json.Marshal(e.Variants)
+	if err != nil {
+		return nil, err
+	}
+	return scanExperiment(db.Pool.QueryRow(ctx, `
+		INSERT INTO app_experiments (experiment_key, description, variants, status, salt)
+		VALUES ($1, NULLIF($2, ''), $3, $4, $5)
+		ON CONFLICT (experiment_key) DO UPDATE SET
+			description = EXCLUDED.description,
+			variants = EXCLUDED.variants,
+			status = EXCLUDED.status,
+			salt = EXCLUDED.salt,
+			updated_at = now()
+		RETURNING `+experimentColumns,
+		e.Key, e.Description, variants, e.Status, e.Salt,
+	))
+}
+
+// InsertExperimentExposures stores a batch of exposure events
+func (db *Database) InsertExperimentExposures(ctx context.Context, exposures []models.ExperimentExposure) error {
+	if len(exposures) == 0 {
+		return nil
+	}
+	batch := &pgx.Batch{}
+	for _, e := range exposures {
+		exposedAt := time.Now()
+		if e.ExposedAt != nil {
+			exposedAt = *e.ExposedAt
+		}
+		batch.Queue(
+			`INSERT INTO app_experiment_exposures (experiment_key, variant, user_id, session_id, context, exposed_at)
+			 VALUES ($1, $2, $3, NULLIF($4,''), NULLIF($5,''), $6)`,
+			e.ExperimentKey, e.Variant, e.UserID, e.SessionID, e.Context, exposedAt,
+		)
+	}
+	br := db.Pool.SendBatch(ctx, batch)
+	defer br.Close()
+	for range exposures {
+		if _, err := br.Exec(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetExperimentResults aggregates exposures per variant since the given time
+func (db *Database) GetExperimentResults(ctx context.Context, key string, since time.Time) ([]models.ExperimentVariantResult, error) {
+	rows, err := db.Pool.Query(ctx, `
+		SELECT variant, COUNT(*), COUNT(DISTINCT COALESCE(user_id, session_id))
+		FROM app_experiment_exposures
+		WHERE experiment_key = $1 AND exposed_at >= $2
+		GROUP BY variant
+		ORDER BY variant
+	`, key, since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := []models.ExperimentVariantResult{}
+	for rows.Next() {
+		var r models.ExperimentVariantResult
+		if err := rows.Scan(&r.Variant, &r.Exposures, &r.UniqueUsers); err != nil {
+			return nil, err
+		}
+		out = append(out, r)
+	}
+	return out, rows.Err()
+}
@@ -0,0 +1,84 @@
+package db
+
+import (
+	"context"
+	"errors"
+
+	"github.com/expotoworld/expotoworld/backend/catalog-service/internal/models"
+	"github.com/jackc/pgx/v5"
+)
+
+// ErrFoodLabelNotFound is returned when a product has no label data
+var ErrFoodLabelNotFound = errors.New("food label not found")
+
+func allergenStrings(in []models.Allergen) []string {
+	out := make([]string, len(in))
+	for i, a := range in {
+		out[i] = string(a)
+	}
+	return out
+}
+
+func toAllergens(in []string) []models.Allergen {
+	out := make([]models.Allergen, len(in))
+	for i, a := range in {
+		out[i] = models.Allergen(a)
+	}
+	return out
+}
+
+func scanFoodLabel(row pgx.Row) (*models.ProductFoodLabel, error) {
+	var l models.ProductFoodLabel
+	var allergens, mayContain []string
+	err := row.Scan(&l.ProductID, &l.Nutrition, &l.Ingredients, &allergens, &mayContain, &l.OriginCountry, &l.UpdatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrFoodLabelNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	l.Allergens, l.MayContain = toAllergens(allergens), toAllergens(mayContain)
+	return &l, nil
+}
+
+const foodLabelColumns = `product_id, nutrition, COALESCE(ingredients, ''), allergens, may_contain, COALESCE(origin_country, ''), updated_at`
+
+// GetProductFoodLabel returns a product's label data; ErrFoodLabelNotFound if none was entered
+func (db *Database) GetProductFoodLabel(ctx context.Context, productID int) (*models.ProductFoodLabel, error) {
+	return scanFoodLabel(db.Pool.QueryRow(ctx, `SELECT `+foodLabelColumns+` FROM app_product_food_labels WHERE product_id = $1`, productID))
+}
+
+// SetProductFoodLabel creates or replaces a product's label data
+func (db *Database) SetProductFoodLabel(ctx context.Context, productID int, req *models.SetProductFoodLabelRequest) (*models.ProductFoodLabel, error) {
+	var exists bool
+	if err := db.Pool.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM admin_products WHERE product_id = $1)`, productID).Scan(&exists); err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, ErrProductNotFound
+	}
+	return scanFoodLabel(db.Pool.QueryRow(ctx, `
+		INSERT INTO app_product_food_labels (product_id, nutrition, ingredients, allergens, may_contain, origin_country, updated_at)
+		VALUES ($1, $2, NULLIF($3, ''), $4, $5, NULLIF($6, ''), now())
+		ON CONFLICT (product_id) DO UPDATE SET
+			nutrition = EXCLUDED.nutrition,
+			ingredients = EXCLUDED.ingredients,
+			allergens = EXCLUDED.allergens,
+			may_contain = EXCLUDED.may_contain,
+			origin_country = EXCLUDED.origin_country,
+			updated_at = now()
+		RETURNING `+foodLabelColumns,
+		productID, req.Nutrition, req.Ingredients, allergenStrings(req.Allergens), allergenStrings(req.MayContain), req.OriginCountry))
+}
+
+// DeleteProductFoodLabel removes a product's label data; ErrFoodLabelNotFound if there was none
+func (db *Database) DeleteProductFoodLabel(ctx context.Context, productID int) error {
+	tag, err := db.Pool.Exec(ctx, `DELETE FROM app_product_food_labels WHERE product_id = $1`, productID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrFoodLabelNotFound
+	}
+	return nil
+}
@@ -0,0 +1,140 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/expotoworld/expotoworld/backend/catalog-service/internal/models"
+	"github.com/jackc/pgx/v5"
+)
+
+// ErrStoreNotFound is returned when a store id does not exist
+var ErrStoreNotFound = errors.New("store not found")
+
+// lowStockFrom joins products to their stores and resolves the effective threshold; $1 is the
+// default threshold. Only active products that track stock can be low.
+const lowStockFrom = `
+	FROM admin_products p
+	LEFT JOIN admin_stores s ON s.store_id = p.store_id
+	CROSS JOIN LATERAL (SELECT COALESCE(p.low_stock_threshold, s.low_stock_threshold, $1::int) AS threshold) t
+	WHERE p.is_active = true AND p.stock_left IS NOT NULL AND p.stock_left < t.threshold`
+
+const lowStockColumns = `p.product_id, COALESCE(p.sku, ''), COALESCE(p.title, ''), p.store_id, s.name, p.stock_left, t.threshold,
+	CASE WHEN p.low_stock_threshold IS NOT NULL THEN 'product' WHEN s.low_stock_threshold IS NOT NULL THEN 'store' ELSE 'default' END,
+	p.low_stock_alerted_at`
+
+func scanLowStockProducts(rows pgx.Rows) ([]models.LowStockProduct, error) {
+	defer rows.Close()
+	out := make([]models.LowStockProduct, 0)
+	for rows.Next() {
+		var p models.LowStockProduct
+		if err := rows.Scan(&p.ProductID, &p.SKU, &p.Title, &p.StoreID, &p.StoreName, &p.StockLeft, &p.Threshold,
+			&p.ThresholdSource, &p.AlertedAt); err != nil {
+			return nil, err
+		}
+		out = append(out, p)
+	}
+	return out, rows.Err()
+}
+
+// ListLowStockProducts returns products below their threshold, emptiest first. storeIDs limits the
+// result to those stores when non-nil (regional admins).
+func (db *Database) ListLowStockProducts(ctx context.Context, defaultThreshold int, storeIDs []int, limit int) ([]models.LowStockProduct, error) {
+	args := []interface{}{defaultThreshold, limit}
+	where := ""
+	if storeIDs != nil {
+		args = append(args, storeIDs)
+		where = " AND p.store_id = ANY($3::int[])"
+	}
+	rows, err := db.Pool.Query(ctx, `SELECT `+lowStockColumns+lowStockFrom+where+`
+		ORDER BY p.stock_left, p.product_id
+		LIMIT $2`, args...)
+	if err != nil {
+		return nil, err
+	}
+	return scanLowStockProducts(rows)
+}
+
+// ClaimLowStockAlerts marks products that went low since the last check as alerted and returns them.
+// The update makes concurrent instances announce each product only once.
+func (db *Database) ClaimLowStockAlerts(ctx context.Context, defaultThreshold int) ([]models.LowStockProduct, error) {
+	rows, err := db.Pool.Query(ctx, `
+		WITH due AS (
+			SELECT p.product_id, s.name AS store_name, t.threshold,
+				CASE WHEN p.low_stock_threshold IS NOT NULL THEN 'product' WHEN s.low_stock_threshold IS NOT NULL THEN 'store' ELSE 'default' END AS source
+			`+lowStockFrom+` AND p.low_stock_alerted_at IS NULL
+			FOR UPDATE OF p SKIP LOCKED
+		)
+		UPDATE admin_products p SET low_stock_alerted_at = now()
+		FROM due
+		WHERE p.product_id = due.product_id
+		RETURNING p.product_id, COALESCE(p.sku, ''), COALESCE(p.title, ''), p.store_id, due.store_name, p.stock_left, due.threshold,
+			due.source, p.low_stock_alerted_at`, defaultThreshold)
+	if err != nil {
+		return nil, fmt.Errorf("claim low-stock alerts: %w", err)
+	}
+	return scanLowStockProducts(rows)
+}
+
+// ReleaseLowStockAlerts un-marks products whose alert could not be sent so the next check retries them
+func (db *Database) ReleaseLowStockAlerts(ctx context.Context, productIDs []int) error {
+	_, err := db.Pool.Exec(ctx, `UPDATE admin_products SET low_stock_alerted_at = NULL WHERE product_id = ANY($1::int[])`, productIDs)
+	return err
+}
+
+// ResetRecoveredLowStockAlerts clears the alert mark of products back at or above their threshold
+// (or no longer tracked), so they alert again the next time they run low
+func (db *Database) ResetRecoveredLowStockAlerts(ctx context.Context, defaultThreshold int) (int64, error) {
+	tag, err := db.Pool.Exec(ctx, `
+		UPDATE admin_products SET low_stock_alerted_at = NULL
+		WHERE low_stock_alerted_at IS NOT NULL
+		  AND product_id NOT IN (SELECT p.product_id`+lowStockFrom+`)`, defaultThreshold)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
+
+// CountLowStockByStore returns the number of low products per store; products without a store are
+// counted under store 0
+func (db *Database) CountLowStockByStore(ctx context.Context, defaultThreshold int) (map[int]int, error) {
+	rows, err := db.Pool.Query(ctx, `SELECT COALESCE(p.store_id, 0), COUNT(*)`+lowStockFrom+` GROUP BY 1`, defaultThreshold)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	counts := make(map[int]int)
+	for rows.Next() {
+		var storeID, n int
+		if err := rows.Scan(&storeID, &n); err != nil {
+			return nil, err
+		}
+		counts[storeID] = n
+	}
+	return counts, rows.Err()
+}
+
+// SetProductLowStockThreshold sets or clears (nil) a product's own threshold
+func (db *Database) SetProductLowStockThreshold(ctx context.Context, productID int, threshold *int) error {
+	tag, err := db.Pool.Exec(ctx, `UPDATE admin_products SET low_stock_threshold = $2, updated_at = CURRENT_TIMESTAMP WHERE product_id = $1`, productID, threshold)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrProductNotFound
+	}
+	return nil
+}
+
+// SetStoreLowStockThreshold sets or clears (nil) the threshold for a store's products without their own
+func (db *Database) SetStoreLowStockThreshold(ctx context.Context, storeID int, threshold *int) error {
+	tag, err := db.Pool.Exec(ctx, `UPDATE admin_stores SET low_stock_threshold = $2, updated_at = CURRENT_TIMESTAMP WHERE store_id = $1`, storeID, threshold)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrStoreNotFound
+	}
+	return nil
+}
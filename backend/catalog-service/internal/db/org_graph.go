@@ -0,0 +1,248 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/expotoworld/expotoworld/backend/catalog-service/internal/models"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+var (
+	// ErrOrganizationNotFound is returned for unknown organization ids
+	ErrOrganizationNotFound = errors.New("organization not found")
+	// ErrOrgParentType is returned when the parent's type doesn't allow the child (see OrgType.ParentTypes)
+	ErrOrgParentType = errors.New("parent organization type not allowed")
+	// ErrOrgParentCycle is returned when an organization would become its own ancestor
+	ErrOrgParentCycle = errors.New("organization would be its own ancestor")
+	// ErrOrgLinkType is returned for links the organization's type can't hold (see OrgType.LinkType)
+	ErrOrgLinkType = errors.New("link not allowed for this organization type")
+)
+
+// orgGraphError maps the errors raised by the organization hierarchy and link triggers
+func orgGraphError(err error) error {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) || pgErr.Code != "23514" {
+		return err
+	}
+	switch pgErr.ConstraintName {
+	case "organizations_parent_type":
+		return ErrOrgParentType
+	case "organizations_parent_cycle":
+		return ErrOrgParentCycle
+	case "organizations_link_type":
+		return ErrOrgLinkType
+	}
+	return err
+}
+
+// orgSubtreeCTE lists an organization ($1, as text) and its descendants with their depth below it;
+// the depth cap only guards against cycles from before the hierarchy trigger
+const orgSubtreeCTE = `
+    WITH RECURSIVE subtree AS (
+        SELECT org_id, 0 AS depth, ARRAY[COALESCE(name, ''), org_id::text] AS path
+        FROM admin_organizations WHERE org_id::text = $1
+        UNION ALL
+        SELECT o.org_id, t.depth + 1, t.path || COALESCE(o.name, '') || o.org_id::text
+        FROM admin_organizations o JOIN subtree t ON o.parent_org_id = t.org_id
+        WHERE t.depth < 32
+    )`
+
+func (db *Database) orgExists(ctx context.Context, orgID string) error {
+	var exists bool
+	err := db.Pool.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM admin_organizations WHERE org_id::text = $1)`, orgID).Scan(&exists)
+	if err != nil {
+		return fmt.Errorf("failed to look up organization: %w", err)
+	}
+	if !exists {
+		return ErrOrganizationNotFound
+	}
+	return nil
+}
+
+// GetOrgHierarchy returns an organization with its ancestors and descendants and each one's link counts
+func (db *Database) GetOrgHierarchy(ctx context.Context, orgID string) (*models.OrgHierarchy, error) {
+	rows, err := db.Pool.Query(ctx, orgSubtreeCTE+`, ancestors AS (
+            SELECT parent_org_id AS org_id, -1 AS depth
+            FROM admin_organizations WHERE org_id::text = $1 AND parent_org_id IS NOT NULL
+            UNION ALL
+            SELECT o.parent_org_id, a.depth - 1
+            FROM admin_organizations o JOIN ancestors a ON o.org_id = a.org_id
+            WHERE o.parent_org_id IS NOT NULL AND a.depth > -32
+        ), nodes AS (
+            SELECT org_id, depth, path FROM subtree
+            UNION ALL
+            SELECT org_id, depth, NULL FROM ancestors
+        )
+        SELECT o.org_id::text, o.org_type::text, COALESCE(o.name, ''), o.parent_org_id::text, n.depth,
+            (SELECT count(*) FROM admin_store_partners sp WHERE sp.partner_org_id = o.org_id),
+            (SELECT count(*) FROM admin_products p WHERE p.owner_org_id = o.org_id AND p.trashed_at IS NULL)
+        FROM nodes n JOIN admin_organizations o ON o.org_id = n.org_id
+        ORDER BY n.path NULLS FIRST, n.depth
+    `, orgID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load organization hierarchy: %w", err)
+	}
+	defer rows.Close()
+
+	h := &models.OrgHierarchy{Ancestors: []models.OrgNode{}, Descendants: []models.OrgNode{}}
+	found := false
+	for rows.Next() {
+		var n models.OrgNode
+		if err := rows.Scan(&n.OrgID, &n.OrgType, &n.Name, &n.ParentOrgID, &n.Depth, &n.StoreCount, &n.ProductCount); err != nil {
+			return nil, fmt.Errorf("failed to scan organization: %w", err)
+		}
+		n.LinkType = n.OrgType.LinkType()
+		switch {
+		case n.Depth < 0:
+			h.Ancestors = append(h.Ancestors, n)
+		case n.Depth == 0:
+			h.Organization = n
+			found = true
+		default:
+			h.Descendants = append(h.Descendants, n)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to load organization hierarchy: %w", err)
+	}
+	if !found {
+		return nil, ErrOrganizationNotFound
+	}
+	return h, nil
+}
+
+// OrgWithinAny reports whether the organization is one of orgIDs or below one of them
+func (db *Database) OrgWithinAny(ctx context.Context, orgID string, orgIDs []string) (bool, error) {
+	if len(orgIDs) == 0 {
+		return false, nil
+	}
+	var within bool
+	err := db.Pool.QueryRow(ctx, `
+        WITH RECURSIVE up AS (
+            SELECT org_id, parent_org_id, 0 AS depth FROM admin_organizations WHERE org_id::text = $1
+            UNION ALL
+            SELECT o.org_id, o.parent_org_id, up.depth + 1
+            FROM admin_organizations o JOIN up ON o.org_id = up.parent_org_id
+            WHERE up.depth < 32
+        )
+        SELECT EXISTS (SELECT 1 FROM up WHERE org_id::text = ANY($2))
+    `, orgID, orgIDs).Scan(&within)
+	if err != nil {
+		return false, fmt.Errorf("failed to check organization membership: %w", err)
+	}
+	return within, nil
+}
+
+// ListOrgStores returns the stores managed by an organization or its descendants, by store id
+func (db *Database) ListOrgStores(ctx context.Context, orgID string, includeInactive bool) ([]models.OrgStore, error) {
+	if err := db.orgExists(ctx, orgID); err != nil {
+		return nil, err
+	}
+	rows, err := db.Pool.Query(ctx, orgSubtreeCTE+`
+        SELECT DISTINCT ON (s.store_id)
+            s.store_id, COALESCE(s.name, ''), COALESCE(s.city, ''), s.type::text, s.region_id,
+            COALESCE(s.is_active, false), o.org_id::text, COALESCE(o.name, '')
+        FROM subtree t
+        JOIN admin_store_partners sp ON sp.partner_org_id = t.org_id
+        JOIN admin_stores s ON s.store_id = sp.store_id AND s.trashed_at IS NULL
+        JOIN admin_organizations o ON o.org_id = t.org_id
+        WHERE $2 OR s.is_active
+        ORDER BY s.store_id, t.depth, o.name
+    `, orgID, includeInactive)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list organization stores: %w", err)
+	}
+	defer rows.Close()
+
+	stores := []models.OrgStore{}
+	for rows.Next() {
+		var s models.OrgStore
+		if err := rows.Scan(&s.StoreID, &s.Name, &s.City, &s.Type, &s.RegionID, &s.IsActive, &s.ViaOrgID, &s.ViaOrgName); err != nil {
+			return nil, fmt.Errorf("failed to scan organization store: %w", err)
+		}
+		stores = append(stores, s)
+	}
+	return stores, rows.Err()
+}
+
+// ListOrgProducts returns up to limit products owned by an organization or its descendants, by
+// product id after afterID
+func (db *Database) ListOrgProducts(ctx context.Context, orgID string, afterID, limit int) ([]models.OrgProduct, error) {
+	if err := db.orgExists(ctx, orgID); err != nil {
+		return nil, err
+	}
+	rows, err := db.Pool.Query(ctx, orgSubtreeCTE+`
+        SELECT p.product_id, COALESCE(p.sku, ''), COALESCE(p.title, ''), COALESCE(p.mini_app_type::text, ''),
+            p.store_id, COALESCE(p.is_active, false), o.org_id::text, COALESCE(o.name, '')
+        FROM subtree t
+        JOIN admin_products p ON p.owner_org_id = t.org_id AND p.trashed_at IS NULL
+        JOIN admin_organizations o ON o.org_id = t.org_id
+        WHERE p.product_id > $2
+        ORDER BY p.product_id
+        LIMIT $3
+    `, orgID, afterID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list organization products: %w", err)
+	}
+	defer rows.Close()
+
+	products := []models.OrgProduct{}
+	for rows.Next() {
+		var p models.OrgProduct
+		if err := rows.Scan(&p.ProductID, &p.SKU, &p.Title, &p.MiniAppType, &p.StoreID, &p.IsActive, &p.OwnerOrgID, &p.OwnerOrgName); err != nil {
+			return nil, fmt.Errorf("failed to scan organization product: %w", err)
+		}
+		products = append(products, p)
+	}
+	return products, rows.Err()
+}
+
+// SetPartnerStores replaces the stores a Partner organization manages (its manages_store links)
+func (db *Database) SetPartnerStores(ctx context.Context, orgID string, storeIDs []int) error {
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	var orgType models.OrgType
+	err = tx.QueryRow(ctx, `SELECT org_type::text FROM admin_organizations WHERE org_id::text = $1 FOR UPDATE`, orgID).Scan(&orgType)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ErrOrganizationNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to load organization: %w", err)
+	}
+	if orgType.LinkType() != models.OrgLinkManagesStore {
+		return ErrOrgLinkType
+	}
+
+	var known int
+	if err := tx.QueryRow(ctx, `
+        SELECT count(*) FROM admin_stores WHERE store_id = ANY($1) AND trashed_at IS NULL
+    `, storeIDs).Scan(&known); err != nil {
+		return fmt.Errorf("failed to check stores: %w", err)
+	}
+	if known != len(storeIDs) {
+		return ErrStoreNotFound
+	}
+
+	if _, err := tx.Exec(ctx, `
+        DELETE FROM admin_store_partners WHERE partner_org_id::text = $1 AND store_id <> ALL($2)
+    `, orgID, storeIDs); err != nil {
+		return fmt.Errorf("failed to unlink stores: %w", err)
+	}
+	if _, err := tx.Exec(ctx, `
+        INSERT INTO admin_store_partners (store_id, partner_org_id)
+        SELECT s.store_id, o.org_id
+        FROM admin_stores s, admin_organizations o
+        WHERE s.store_id = ANY($2) AND o.org_id::text = $1
+          AND NOT EXISTS (SELECT 1 FROM admin_store_partners sp WHERE sp.store_id = s.store_id AND sp.partner_org_id = o.org_id)
+    `, orgID, storeIDs); err != nil {
+		return fmt.Errorf("failed to link stores: %w", orgGraphError(err))
+	}
+	return tx.Commit(ctx)
+}
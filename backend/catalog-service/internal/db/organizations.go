@@ -87,7 +87,7 @@ func (db *Database) CreateOrganization(ctx context.Context, org models.Organizat
 		string(org.OrgType), org.Name, org.ContactEmail, org.ContactPhone, org.ContactAddress, org.ParentOrgID,
 	).Scan(&id)
 	if err != nil {
-		return "", fmt.Errorf("failed to create organization: %w", err)
+		return "", fmt.Errorf("failed to create organization: %w", orgGraphError(err))
 	}
 	return id, nil
 }
@@ -109,7 +109,7 @@ func (db *Database) UpdateOrganization(ctx context.Context, id string, org model
 		id, string(org.OrgType), org.Name, org.ContactEmail, org.ContactPhone, org.ContactAddress, org.ParentOrgID,
 	)
 	if err != nil {
-		return fmt.Errorf("failed to update organization: %w", err)
+		return fmt.Errorf("failed to update organization: %w", orgGraphError(err))
 	}
 	if cmd.RowsAffected() == 0 {
 		return fmt.Errorf("organization not found")
@@ -0,0 +1,112 @@
+package db
+
+import (
+	"context"
+	"time"
+
+	"github.com/expotoworld/expotoworld/backend/catalog-service/internal/models"
+	"github.com/jackc/pgx/v5"
+)
+
+// PopularityScoreSQL returns a scalar subquery computing the decayed popularity of product alias p.
+// Daily scores are halved every 7 days so recent interest outweighs old spikes.
+const PopularityScoreSQL = `COALESCE((
+	SELECT SUM(pd.score * POWER(0.5, (CURRENT_DATE - pd.day) / 7.0))
+	FROM app_product_popularity_daily pd
+	WHERE pd.product_id = p.product_id AND pd.day >= CURRENT_DATE - 30
+), 0)`
+
+// InsertProductViewEvents stores a batch of raw product view events
+func (db *Database) InsertProductViewEvents(ctx context.Context, events []models.ProductViewEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+	batch := &pgx.Batch{}
+	for _, e := range events {
+		viewedAt := time.Now()
+		if e.ViewedAt != nil {
+			viewedAt = *e.ViewedAt
+		}
+		batch.Queue(
+			`INSERT INTO app_product_view_events (product_id, user_id, session_id, mini_app_type, source, viewed_at)
+			 VALUES ($1, $2, NULLIF($3,''), NULLIF($4,''), NULLIF($5,''), $6)`,
+			e.ProductID, e.UserID, e.SessionID, e.MiniAppType, e.Source, viewedAt,
+		)
+	}
+	br := db.Pool.SendBatch(ctx, batch)
+	defer br.Close()
+	for range events {
+		if _, err := br.Exec(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// AggregateProductPopularity (re)computes the daily popularity rows for the given day.
+// A unique viewer counts fully; repeat views by the same viewer add a quarter point each.
+func (db *Database) AggregateProductPopularity(ctx context.Context, day time.Time) (int64, error) {
+	tag, err := db.Pool.Exec(ctx, `
+		INSERT INTO app_product_popularity_daily (product_id, day, views, unique_viewers, score, updated_at)
+		SELECT
+			e.product_id,
+			$1::date,
+			COUNT(*) AS views,
+			COUNT(DISTINCT COALESCE(e.user_id, e.session_id)) AS unique_viewers,
+			COUNT(DISTINCT COALESCE(e.user_id, e.session_id))
+				+ 0.25 * (COUNT(*) - COUNT(DISTINCT COALESCE(e.user_id, e.session_id))) AS score,
+			now()
+		FROM app_product_view_events e
+		WHERE e.viewed_at >= $1::date AND e.viewed_at < $1::date + 1
+		GROUP BY e.product_id
+		ON CONFLICT (product_id, day) DO UPDATE SET
+			views = EXCLUDED.views,
+			unique_viewers = EXCLUDED.unique_viewers,
+			score = EXCLUDED.score,
+			updated_at = now()
+	`, day.Format("2006-01-02"))
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
+
+// GetPopularProducts returns the most popular active products, optionally scoped to a mini-app
+func (db *Database) GetPopularProducts(ctx context.Context, miniAppType string, limit int) ([]models.ProductPopularity, error) {
+	rows, err := db.Pool.Query(ctx, `
+		SELECT p.product_id,
+			COALESCE((SELECT SUM(pd.views) FROM app_product_popularity_daily pd
+				WHERE pd.product_id = p.product_id AND pd.day >= CURRENT_DATE - 30), 0)::int AS views,
+			`+PopularityScoreSQL+` AS score
+		FROM admin_products p
+		WHERE p.is_active = true AND ($1 = '' OR p.mini_app_type::text = $1)
+		ORDER BY score DESC, p.product_id
+		LIMIT $2
+	`, miniAppType, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := make([]models.ProductPopularity, 0)
+	for rows.Next() {
+		var it models.ProductPopularity
+		if err := rows.Scan(&it.ProductID, &it.Views, &it.Score); err != nil {
+			return nil, err
+		}
+		if it.Score <= 0 {
+			break
+		}
+		items = append(items, it)
+	}
+	return items, rows.Err()
+}
+
+// PurgeProductViewEvents deletes raw view events older than the cutoff
+func (db *Database) PurgeProductViewEvents(ctx context.Context, before time.Time) (int64, error) {
+	tag, err := db.Pool.Exec(ctx, `DELETE FROM app_product_view_events WHERE viewed_at < $1`, before)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
@@ -0,0 +1,185 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/expotoworld/expotoworld/backend/catalog-service/internal/models"
+	"github.com/jackc/pgx/v5"
+)
+
+const priceListColumns = `pl.price_list_id, pl.org_id::text, pl.name, pl.is_active, pl.valid_from, pl.valid_until,
+	(SELECT COUNT(*) FROM app_price_list_items i WHERE i.price_list_id = pl.price_list_id), pl.created_at, pl.updated_at`
+
+func scanPriceList(row pgx.Row) (*models.PriceList, error) {
+	var pl models.PriceList
+	if err := row.Scan(&pl.ID, &pl.OrgID, &pl.Name, &pl.IsActive, &pl.ValidFrom, &pl.ValidUntil, &pl.ItemCount, &pl.CreatedAt, &pl.UpdatedAt); err != nil {
+		return nil, err
+	}
+	return &pl, nil
+}
+
+// ListPriceLists returns the price lists of an organization, newest first
+func (db *Database) ListPriceLists(ctx context.Context, orgID string) ([]models.PriceList, error) {
+	rows, err := db.Pool.Query(ctx, `
+		SELECT `+priceListColumns+`
+		FROM app_price_lists pl
+		WHERE pl.org_id = $1
+		ORDER BY pl.valid_from DESC, pl.price_list_id DESC
+	`, orgID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := []models.PriceList{}
+	for rows.Next() {
+		pl, err := scanPriceList(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *pl)
+	}
+	return out, rows.Err()
+}
+
+// GetPriceList returns a single price list
+func (db *Database) GetPriceList(ctx context.Context, id int64) (*models.PriceList, error) {
+	return scanPriceList(db.Pool.QueryRow(ctx, `SELECT `+priceListColumns+` FROM app_price_lists pl WHERE pl.price_list_id = $1`, id))
+}
+
+// CreatePriceList inserts a price list for an organization
+func (db *Database) CreatePriceList(ctx context.Context, pl *models.PriceList) (*models.PriceList, error) {
+	var id int64
+	err := db.Pool.QueryRow(ctx, `
+		INSERT INTO app_price_lists (org_id, name, is_active, valid_from, valid_until)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING price_list_id
+	`, pl.OrgID, pl.Name, pl.IsActive, pl.ValidFrom, pl.ValidUntil).Scan(&id)
+	if err != nil {
+		return nil, err
+	}
+	return db.GetPriceList(ctx, id)
+}
+
+// UpdatePriceList updates the name, active flag and effective dates of a price list
+func (db *Database) UpdatePriceList(ctx context.Context, pl *models.PriceList) (*models.PriceList, error) {
+	tag, err := db.Pool.Exec(ctx, `
+		UPDATE app_price_lists
+		SET name = $2, is_active = $3, valid_from = $4, valid_until = $5, updated_at = now()
+		WHERE price_list_id = $1
+	`, pl.ID, pl.Name, pl.IsActive, pl.ValidFrom, pl.ValidUntil)
+	if err != nil {
+		return nil, err
+	}
+	if tag.RowsAffected() == 0 {
+		return nil, pgx.ErrNoRows
+	}
+	return db.GetPriceList(ctx, pl.ID)
+}
+
+// DeletePriceList removes a price list and its items
+func (db *Database) DeletePriceList(ctx context.Context, id int64) error {
+	tag, err := db.Pool.Exec(ctx, `DELETE FROM app_price_lists WHERE price_list_id = $1`, id)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
+
+// GetPriceListItems returns the items of a price list with the product's default price for comparison
+func (db *Database) GetPriceListItems(ctx context.Context, id int64) ([]models.PriceListItem, error) {
+	rows, err := db.Pool.Query(ctx, `
+		SELECT i.product_id, i.price::float8, COALESCE(p.title, ''), COALESCE(p.main_price, 0)::float8
+		FROM app_price_list_items i
+		LEFT JOIN admin_products p ON p.product_id = i.product_id
+		WHERE i.price_list_id = $1
+		ORDER BY i.product_id
+	`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := []models.PriceListItem{}
+	for rows.Next() {
+		var it models.PriceListItem
+		if err := rows.Scan(&it.ProductID, &it.Price, &it.ProductTitle, &it.DefaultPrice); err != nil {
+			return nil, err
+		}
+		out = append(out, it)
+	}
+	return out, rows.Err()
+}
+
+// ReplacePriceListItems atomically replaces all items of a price list
+func (db *Database) ReplacePriceListItems(ctx context.Context, id int64, items []models.PriceListItem) error {
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	var exists bool
+	if err := tx.QueryRow(ctx, `SELECT true FROM app_price_lists WHERE price_list_id = $1 FOR UPDATE`, id).Scan(&exists); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(ctx, `DELETE FROM app_price_list_items WHERE price_list_id = $1`, id); err != nil {
+		return err
+	}
+	for _, it := range items {
+		if _, err := tx.Exec(ctx, `INSERT INTO app_price_list_items (price_list_id, product_id, price) VALUES ($1, $2, $3)`, id, it.ProductID, it.Price); err != nil {
+			return fmt.Errorf("insert item for product %d: %w", it.ProductID, err)
+		}
+	}
+	if _, err := tx.Exec(ctx, `UPDATE app_price_lists SET updated_at = now() WHERE price_list_id = $1`, id); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// ResolveOrgPrices returns the price an organization pays for each product at the given time:
+// the contract price from the most recently effective matching list, else the product's default price.
+// Unknown products are omitted.
+func (db *Database) ResolveOrgPrices(ctx context.Context, orgID string, productIDs []int, at time.Time) ([]models.ResolvedPrice, error) {
+	rows, err := db.Pool.Query(ctx, `
+		SELECT p.product_id, p.main_price::float8, op.price::float8, op.price_list_id
+		FROM admin_products p
+		LEFT JOIN LATERAL (
+			SELECT i.price, pl.price_list_id
+			FROM app_price_lists pl
+			JOIN app_price_list_items i ON i.price_list_id = pl.price_list_id
+			WHERE pl.org_id = $1 AND i.product_id = p.product_id AND pl.is_active
+			  AND pl.valid_from <= $3 AND (pl.valid_until IS NULL OR pl.valid_until > $3)
+			ORDER BY pl.valid_from DESC, pl.price_list_id DESC
+			LIMIT 1
+		) op ON true
+		WHERE p.product_id = ANY($2)
+		ORDER BY p.product_id
+	`, orgID, productIDs, at)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := []models.ResolvedPrice{}
+	for rows.Next() {
+		var r models.ResolvedPrice
+		var contract *float64
+		if err := rows.Scan(&r.ProductID, &r.DefaultPrice, &contract, &r.PriceListID); err != nil {
+			return nil, err
+		}
+		r.Price = r.DefaultPrice
+		r.Source = models.PriceSourceDefault
+		if contract != nil {
+			r.Price = *contract
+			r.Source = models.PriceSourcePriceList
+		}
+		out = append(out, r)
+	}
+	return out, rows.Err()
+}
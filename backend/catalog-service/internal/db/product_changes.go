@@ -0,0 +1,170 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/expotoworld/expotoworld/backend/catalog-service/internal/models"
+)
+
+// ErrChangeCursorExpired is returned when changes after the cursor have already been purged
+var ErrChangeCursorExpired = errors.New("change cursor expired")
+
+// ProductChangesHead is the cursor of the current end of the change log. Transactions still running
+// are after it, so a client that takes it before a full download misses nothing.
+func (db *Database) ProductChangesHead(ctx context.Context) (models.ChangeCursor, error) {
+	var head models.ChangeCursor
+	err := db.Pool.QueryRow(ctx, `SELECT txid_snapshot_xmin(txid_current_snapshot())`).Scan(&head.Txid)
+	if err != nil {
+		return head, fmt.Errorf("failed to read change log head: %w", err)
+	}
+	return head, nil
+}
+
+// ListProductChanges returns up to limit changes after since, collapsed per product, optionally only
+// those of one store. Only changes of finished transactions are returned, in transaction order.
+func (db *Database) ListProductChanges(ctx context.Context, since models.ChangeCursor, storeID *int, limit int) (*models.ProductChangeFeed, error) {
+	var purged int64
+	if err := db.Pool.QueryRow(ctx, `SELECT purged_through_txid FROM app_product_change_log_state`).Scan(&purged); err != nil {
+		return nil, fmt.Errorf("failed to read change log state: %w", err)
+	}
+	if purged > 0 && since.Txid <= purged {
+		return nil, ErrChangeCursorExpired
+	}
+
+	var xmin int64
+	rows, err := db.Pool.Query(ctx, `
+        WITH snap AS (SELECT txid_snapshot_xmin(txid_current_snapshot()) AS xmin)
+        SELECT snap.xmin, c.txid, c.change_id, c.product_id, c.created, c.changed_at
+        FROM snap
+        LEFT JOIN LATERAL (
+            SELECT txid, change_id, product_id, created, changed_at
+            FROM app_product_changes
+            WHERE (txid, change_id) > ($1, $2) AND txid < snap.xmin
+              AND ($3::int IS NULL OR store_id = $3)
+            ORDER BY txid, change_id
+            LIMIT $4
+        ) c ON true
+        ORDER BY c.txid, c.change_id
+    `, since.Txid, since.ChangeID, storeID, limit+1)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list product changes: %w", err)
+	}
+	defer rows.Close()
+
+	type entry struct {
+		change  models.ProductChange
+		created bool
+		last    int
+	}
+	byProduct := map[int]*entry{}
+	var order []int
+	feed := &models.ProductChangeFeed{Changes: []models.ProductChange{}}
+	last := since
+	n := 0
+	for rows.Next() {
+		var (
+			txid, id  *int64
+			productID *int
+			created   *bool
+			changedAt *time.Time
+		)
+		if err := rows.Scan(&xmin, &txid, &id, &productID, &created, &changedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan product change: %w", err)
+		}
+		if txid == nil {
+			continue // no changes; the row only carries xmin
+		}
+		if n++; n > limit {
+			feed.HasMore = true
+			break
+		}
+		last = models.ChangeCursor{Txid: *txid, ChangeID: *id}
+		e, ok := byProduct[*productID]
+		if !ok {
+			e = &entry{change: models.ProductChange{ProductID: *productID}}
+			byProduct[*productID] = e
+		}
+		e.created = e.created || *created
+		e.change.ChangedAt = *changedAt
+		e.last = n
+		order = append(order, *productID)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list product changes: %w", err)
+	}
+	rows.Close()
+
+	// Every change of a transaction before xmin has been read, so an exhausted feed can skip ahead
+	feed.NextCursor = last.String()
+	if head := (models.ChangeCursor{Txid: xmin}); !feed.HasMore && head.After(last) {
+		feed.NextCursor = head.String()
+	}
+	if len(byProduct) == 0 {
+		return feed, nil
+	}
+
+	ids := make([]int, 0, len(byProduct))
+	for id := range byProduct {
+		ids = append(ids, id)
+	}
+	live := map[int]bool{}
+	prows, err := db.Pool.Query(ctx, `
+        SELECT product_id FROM admin_products
+        WHERE product_id = ANY($1) AND is_active AND ($2::int IS NULL OR store_id = $2)
+    `, ids, storeID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load changed products: %w", err)
+	}
+	defer prows.Close()
+	for prows.Next() {
+		var id int
+		if err := prows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan changed product: %w", err)
+		}
+		live[id] = true
+	}
+	if err := prows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to load changed products: %w", err)
+	}
+
+	// List each product at its last change in the page
+	for i, id := range order {
+		e := byProduct[id]
+		if e.last != i+1 {
+			continue
+		}
+		switch {
+		case !live[id]:
+			e.change.Change = models.ProductChangeDeleted
+		case e.created:
+			e.change.Change = models.ProductChangeCreated
+		default:
+			e.change.Change = models.ProductChangeUpdated
+		}
+		feed.Changes = append(feed.Changes, e.change)
+	}
+	return feed, nil
+}
+
+// PurgeProductChanges deletes changes recorded before the cutoff and remembers the last purged
+// transaction, so older cursors are refused instead of silently skipping changes
+func (db *Database) PurgeProductChanges(ctx context.Context, before time.Time) (int64, error) {
+	var n int64
+	err := db.Pool.QueryRow(ctx, `
+        WITH gone AS (
+            DELETE FROM app_product_changes WHERE changed_at < $1 RETURNING txid
+        ), marked AS (
+            UPDATE app_product_change_log_state
+            SET purged_through_txid = GREATEST(purged_through_txid, (SELECT max(txid) FROM gone))
+            WHERE EXISTS (SELECT 1 FROM gone)
+        )
+        SELECT count(*) FROM gone
+    `, before).Scan(&n)
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge product changes: %w", err)
+	}
+	return n, nil
+}
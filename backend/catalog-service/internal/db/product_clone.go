@@ -0,0 +1,89 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// CloneProduct copies a product into a new inactive (draft) product and returns its ID and SKU.
+// An empty sku derives the next free "<source sku>-COPY[-n]". Stock, shelf code, images, variants and
+// translations are not copied; category and subcategory mappings are when copyCategories is set.
+func (db *Database) CloneProduct(ctx context.Context, sourceID int, sku string, title *string, copyCategories bool) (int, string, error) {
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		return 0, "", fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	var sourceSKU string
+	err = tx.QueryRow(ctx, `SELECT sku FROM admin_products WHERE product_id = $1`, sourceID).Scan(&sourceSKU)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, "", ErrProductNotFound
+	}
+	if err != nil {
+		return 0, "", fmt.Errorf("failed to load product: %w", err)
+	}
+
+	if sku == "" {
+		if sku, err = nextCloneSKU(ctx, tx, sourceSKU); err != nil {
+			return 0, "", err
+		}
+	}
+
+	// Tracked stock starts empty; untracked (NULL) stays untracked
+	var productID int
+	err = tx.QueryRow(ctx, `
+        INSERT INTO admin_products
+            (sku, title, description, store_type, mini_app_type, store_id, shelf_code, main_price, strikethrough_price, cost_price, weight, stock_left, minimum_order_quantity, is_active, is_featured, is_mini_app_recommendation, tax_class)
+        SELECT
+            $2, COALESCE($3, title), description, store_type, mini_app_type, store_id, NULL, main_price, strikethrough_price, cost_price, weight,
+            CASE WHEN stock_left IS NULL THEN NULL ELSE 0 END, minimum_order_quantity, false, is_featured, is_mini_app_recommendation, tax_class
+        FROM admin_products
+        WHERE product_id = $1
+        RETURNING product_id
+    `, sourceID, sku, title).Scan(&productID)
+	if err != nil {
+		return 0, "", fmt.Errorf("failed to insert product: %w", err)
+	}
+
+	if copyCategories {
+		if _, err := tx.Exec(ctx, `
+            INSERT INTO admin_product_category_mapping (product_id, category_id)
+            SELECT $2, category_id FROM admin_product_category_mapping WHERE product_id = $1
+        `, sourceID, productID); err != nil {
+			return 0, "", fmt.Errorf("failed to copy category mappings: %w", err)
+		}
+		if _, err := tx.Exec(ctx, `
+            INSERT INTO admin_product_subcategory_mapping (product_id, subcategory_id)
+            SELECT $2, subcategory_id FROM admin_product_subcategory_mapping WHERE product_id = $1
+        `, sourceID, productID); err != nil {
+			return 0, "", fmt.Errorf("failed to copy subcategory mappings: %w", err)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, "", fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return productID, sku, nil
+}
+
+// nextCloneSKU returns the first of "<base>-COPY", "<base>-COPY-2", ... not used by any product
+func nextCloneSKU(ctx context.Context, tx pgx.Tx, base string) (string, error) {
+	for n := 1; n <= 100; n++ {
+		candidate := base + "-COPY"
+		if n > 1 {
+			candidate = fmt.Sprintf("%s-COPY-%d", base, n)
+		}
+		var taken bool
+		if err := tx.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM admin_products WHERE sku = $1)`, candidate).Scan(&taken); err != nil {
+			return "", fmt.Errorf("failed to check SKU: %w", err)
+		}
+		if !taken {
+			return candidate, nil
+		}
+	}
+	return "", fmt.Errorf("no free copy SKU for %q; provide a sku", base)
+}
@@ -0,0 +1,240 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/expotoworld/expotoworld/backend/catalog-service/internal/models"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+var (
+	// ErrSubmissionNotFound is returned when a submission id does not exist
+	ErrSubmissionNotFound = errors.New("product submission not found")
+	// ErrSubmissionNotPending is returned when reviewing a submission that was already reviewed
+	ErrSubmissionNotPending = errors.New("product submission is not pending review")
+	// ErrSKUTaken is returned when an approved submission would duplicate another product's SKU
+	ErrSKUTaken = errors.New("sku already in use")
+)
+
+const submissionColumns = `submission_id, org_id, product_id, kind, status, payload, submitted_by, review_comment, reviewed_by,
+	reviewed_at, created_at, updated_at`
+
+func scanSubmission(row pgx.Row) (*models.ProductSubmission, error) {
+	var s models.ProductSubmission
+	var payload []byte
+	if err := row.Scan(&s.ID, &s.OrgID, &s.ProductID, &s.Kind, &s.Status, &payload, &s.SubmittedBy, &s.ReviewComment,
+		&s.ReviewedBy, &s.ReviewedAt, &s.CreatedAt, &s.UpdatedAt); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(payload, &s.Product); err != nil {
+		return nil, fmt.Errorf("decode submission payload: %w", err)
+	}
+	return &s, nil
+}
+
+// ProductOwnerOrgID returns the organization owning a product ("" when it has none)
+func (db *Database) ProductOwnerOrgID(ctx context.Context, productID int) (string, error) {
+	var orgID *string
+	err := db.Pool.QueryRow(ctx, `SELECT owner_org_id::text FROM admin_products WHERE product_id = $1`, productID).Scan(&orgID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", ErrProductNotFound
+	}
+	if err != nil || orgID == nil {
+		return "", err
+	}
+	return *orgID, nil
+}
+
+// CategoriesExist reports whether every category and subcategory id exists
+func (db *Database) CategoriesExist(ctx context.Context, categoryIDs, subcategoryIDs []int) (bool, error) {
+	var ok bool
+	err := db.Pool.QueryRow(ctx, `
+		SELECT NOT EXISTS (SELECT 1 FROM unnest($1::int[]) AS id WHERE id NOT IN (SELECT category_id FROM admin_product_categories))
+		   AND NOT EXISTS (SELECT 1 FROM unnest($2::int[]) AS id WHERE id NOT IN (SELECT subcategory_id FROM admin_subcategories))`,
+		categoryIDs, subcategoryIDs).Scan(&ok)
+	return ok, err
+}
+
+// SaveProductSubmission stores a submission for review. A change to a product that already has one
+// pending replaces the pending proposal.
+func (db *Database) SaveProductSubmission(ctx context.Context, s *models.ProductSubmission) (*models.ProductSubmission, error) {
+	payload, err := json.Marshal(s.Product)
+	if err != nil {
+		return nil, err
+	}
+	return scanSubmission(db.Pool.QueryRow(ctx, `
+		INSERT INTO app_product_submissions (org_id, product_id, kind, payload, submitted_by)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (product_id) WHERE status = 'pending_review' AND kind = 'update'
+		DO UPDATE SET org_id = EXCLUDED.org_id, payload = EXCLUDED.payload, submitted_by = EXCLUDED.submitted_by, updated_at = now()
+		RETURNING `+submissionColumns, s.OrgID, s.ProductID, s.Kind, payload, s.SubmittedBy))
+}
+
+// GetProductSubmission returns one submission
+func (db *Database) GetProductSubmission(ctx context.Context, id int64) (*models.ProductSubmission, error) {
+	s, err := scanSubmission(db.Pool.QueryRow(ctx, `SELECT `+submissionColumns+` FROM app_product_submissions WHERE submission_id = $1`, id))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrSubmissionNotFound
+	}
+	return s, err
+}
+
+// ListProductSubmissions returns submissions newest first. orgIDs limits them to those manufacturers
+// when non-nil; status filters when set.
+func (db *Database) ListProductSubmissions(ctx context.Context, orgIDs []string, status string, beforeID int64, limit int) ([]models.ProductSubmission, error) {
+	args := []interface{}{limit}
+	where := "TRUE"
+	if orgIDs != nil {
+		args = append(args, orgIDs)
+		where += fmt.Sprintf(" AND org_id = ANY($%d::text[])", len(args))
+	}
+	if status != "" {
+		args = append(args, status)
+		where += fmt.Sprintf(" AND status = $%d", len(args))
+	}
+	if beforeID > 0 {
+		args = append(args, beforeID)
+		where += fmt.Sprintf(" AND submission_id < $%d", len(args))
+	}
+	rows, err := db.Pool.Query(ctx, `
+		SELECT `+submissionColumns+`
+		FROM app_product_submissions
+		WHERE `+where+`
+		ORDER BY submission_id DESC
+		LIMIT $1`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make([]models.ProductSubmission, 0)
+	for rows.Next() {
+		s, err := scanSubmission(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *s)
+	}
+	return out, rows.Err()
+}
+
+// lockPendingSubmission loads a submission for review inside tx
+func lockPendingSubmission(ctx context.Context, tx pgx.Tx, id int64) (*models.ProductSubmission, error) {
+	s, err := scanSubmission(tx.QueryRow(ctx, `SELECT `+submissionColumns+` FROM app_product_submissions WHERE submission_id = $1 FOR UPDATE`, id))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrSubmissionNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if s.Status != models.SubmissionPendingReview {
+		return nil, ErrSubmissionNotPending
+	}
+	return s, nil
+}
+
+// ApproveProductSubmission applies a submission in one transaction. New products are created active
+// with the given tax class and owned by the submitting manufacturer; changes overwrite only the
+// submitted fields of the existing product.
+func (db *Database) ApproveProductSubmission(ctx context.Context, id int64, reviewer *string, comment string, taxClass *models.TaxClass) (*models.ProductSubmission, error) {
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	s, err := lockPendingSubmission(ctx, tx, id)
+	if err != nil {
+		return nil, err
+	}
+	f := s.Product
+	var productID int
+	if s.Kind == "create" {
+		err = tx.QueryRow(ctx, `
+			INSERT INTO admin_products
+				(sku, title, description, mini_app_type, main_price, strikethrough_price, weight, minimum_order_quantity, barcode,
+				 tax_class, owner_org_id, is_active)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, true)
+			RETURNING product_id`,
+			f.SKU, f.Title, f.Description, f.MiniAppType, f.MainPrice, f.StrikethroughPrice, f.Weight, f.MinimumOrderQuantity,
+			f.Barcode, taxClass, s.OrgID).Scan(&productID)
+	} else {
+		productID = *s.ProductID
+		_, err = tx.Exec(ctx, `
+			UPDATE admin_products
+			SET sku = $2, title = $3, description = $4, mini_app_type = $5, main_price = $6, strikethrough_price = $7,
+				weight = $8, minimum_order_quantity = $9, barcode = $10, updated_at = CURRENT_TIMESTAMP
+			WHERE product_id = $1`,
+			productID, f.SKU, f.Title, f.Description, f.MiniAppType, f.MainPrice, f.StrikethroughPrice, f.Weight,
+			f.MinimumOrderQuantity, f.Barcode)
+	}
+	if err != nil {
+		var pgErr *pgconn.PgError
+		switch {
+		case barcodeTaken(err):
+			return nil, ErrBarcodeTaken
+		case errors.As(err, &pgErr) && pgErr.Code == "23505" && pgErr.ConstraintName == "admin_products_sku_key":
+			return nil, ErrSKUTaken
+		}
+		return nil, fmt.Errorf("failed to apply submission: %w", err)
+	}
+
+	if _, err := tx.Exec(ctx, `DELETE FROM admin_product_category_mapping WHERE product_id = $1`, productID); err != nil {
+		return nil, fmt.Errorf("failed to replace category mappings: %w", err)
+	}
+	if _, err := tx.Exec(ctx, `
+		INSERT INTO admin_product_category_mapping (product_id, category_id)
+		SELECT $1, id FROM unnest($2::int[]) AS id`, productID, f.CategoryIDs); err != nil {
+		return nil, fmt.Errorf("failed to insert category mappings: %w", err)
+	}
+	if _, err := tx.Exec(ctx, `DELETE FROM admin_product_subcategory_mapping WHERE product_id = $1`, productID); err != nil {
+		return nil, fmt.Errorf("failed to replace subcategory mappings: %w", err)
+	}
+	if _, err := tx.Exec(ctx, `
+		INSERT INTO admin_product_subcategory_mapping (product_id, subcategory_id)
+		SELECT $1, id FROM unnest($2::int[]) AS id`, productID, f.SubcategoryIDs); err != nil {
+		return nil, fmt.Errorf("failed to insert subcategory mappings: %w", err)
+	}
+
+	s, err = scanSubmission(tx.QueryRow(ctx, `
+		UPDATE app_product_submissions
+		SET status = 'approved', product_id = $2, review_comment = NULLIF($3, ''), reviewed_by = $4, reviewed_at = now(), updated_at = now()
+		WHERE submission_id = $1
+		RETURNING `+submissionColumns, id, productID, comment, reviewer))
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return s, nil
+}
+
+// RejectProductSubmission closes a pending submission with the reviewer's comment
+func (db *Database) RejectProductSubmission(ctx context.Context, id int64, reviewer *string, comment string) (*models.ProductSubmission, error) {
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := lockPendingSubmission(ctx, tx, id); err != nil {
+		return nil, err
+	}
+	s, err := scanSubmission(tx.QueryRow(ctx, `
+		UPDATE app_product_submissions
+		SET status = 'rejected', review_comment = $2, reviewed_by = $3, reviewed_at = now(), updated_at = now()
+		WHERE submission_id = $1
+		RETURNING `+submissionColumns, id, comment, reviewer))
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return s, nil
+}
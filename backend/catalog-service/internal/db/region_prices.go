@@ -0,0 +1,106 @@
+package db
+
+import (
+	"context"
+	"errors"
+
+	"github.com/expotoworld/expotoworld/backend/catalog-service/internal/models"
+	"github.com/jackc/pgx/v5"
+)
+
+// RegionSellableSQL keeps products of alias p that can be sold in region %[1]s: products bound to a
+// store only in that store's region, and only once the region has a tax rate for their class
+// (the rate checkout needs, see app_product_tax_rate).
+const RegionSellableSQL = `(p.store_id IS NULL OR EXISTS (
+	SELECT 1 FROM admin_stores rs WHERE rs.store_id = p.store_id AND rs.region_id = %[1]s
+)) AND EXISTS (
+	SELECT 1 FROM app_region_tax_rates rt WHERE rt.region_id = %[1]s AND rt.tax_class = p.tax_class
+)`
+
+// GetRegionPrices returns the regional prices set for the given products in a region, keyed by product ID
+func (db *Database) GetRegionPrices(ctx context.Context, regionID int, productIDs []int) (map[int]models.ProductRegionPrice, error) {
+	out := make(map[int]models.ProductRegionPrice)
+	if len(productIDs) == 0 {
+		return out, nil
+	}
+	rows, err := db.Pool.Query(ctx, `
+		SELECT rp.product_id, rp.region_id, r.currency, rp.price::float8, rp.strikethrough_price::float8, rp.updated_at
+		FROM app_product_region_prices rp
+		JOIN admin_regions r ON r.region_id = rp.region_id
+		WHERE rp.region_id = $1 AND rp.product_id = ANY($2::int[])
+	`, regionID, productIDs)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var productID int
+		var p models.ProductRegionPrice
+		if err := rows.Scan(&productID, &p.RegionID, &p.Currency, &p.Price, &p.StrikethroughPrice, &p.UpdatedAt); err != nil {
+			return nil, err
+		}
+		out[productID] = p
+	}
+	return out, rows.Err()
+}
+
+// ListProductRegionPrices returns a product's regional prices ordered by region name
+func (db *Database) ListProductRegionPrices(ctx context.Context, productID int) ([]models.ProductRegionPrice, error) {
+	rows, err := db.Pool.Query(ctx, `
+		SELECT rp.region_id, r.name, r.currency, rp.price::float8, rp.strikethrough_price::float8, COALESCE(rp.updated_by, ''), rp.updated_at
+		FROM app_product_region_prices rp
+		JOIN admin_regions r ON r.region_id = rp.region_id
+		WHERE rp.product_id = $1
+		ORDER BY r.name, rp.region_id
+	`, productID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := []models.ProductRegionPrice{}
+	for rows.Next() {
+		var p models.ProductRegionPrice
+		if err := rows.Scan(&p.RegionID, &p.RegionName, &p.Currency, &p.Price, &p.StrikethroughPrice, &p.UpdatedBy, &p.UpdatedAt); err != nil {
+			return nil, err
+		}
+		out = append(out, p)
+	}
+	return out, rows.Err()
+}
+
+// SetProductRegionPrices replaces all regional prices of a product. ErrProductNotFound if the
+// product doesn't exist, ErrRegionNotFound if a region doesn't.
+func (db *Database) SetProductRegionPrices(ctx context.Context, productID int, prices []models.ProductRegionPrice, actor string) error {
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	// Lock the product so concurrent replacements don't interleave
+	var exists int
+	if err := tx.QueryRow(ctx, `SELECT 1 FROM admin_products WHERE product_id = $1 FOR UPDATE`, productID).Scan(&exists); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrProductNotFound
+		}
+		return err
+	}
+	if _, err := tx.Exec(ctx, `DELETE FROM app_product_region_prices WHERE product_id = $1`, productID); err != nil {
+		return err
+	}
+	for _, p := range prices {
+		tag, err := tx.Exec(ctx, `
+			INSERT INTO app_product_region_prices (product_id, region_id, price, strikethrough_price, updated_by)
+			SELECT $1, r.region_id, $3, $4, NULLIF($5, '') FROM admin_regions r WHERE r.region_id = $2
+		`, productID, p.RegionID, p.Price, p.StrikethroughPrice, actor)
+		if err != nil {
+			return err
+		}
+		if tag.RowsAffected() == 0 {
+			return ErrRegionNotFound
+		}
+	}
+	return tx.Commit(ctx)
+}
@@ -2,13 +2,25 @@ package db
 
 import (
 	"context"
+	"errors"
 
 	"github.com/expotoworld/expotoworld/backend/catalog-service/internal/models"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 )
 
+// ErrRegionCodeTaken is returned when another region already uses the code
+var ErrRegionCodeTaken = errors.New("region code already in use")
+
+// regionCodeTaken reports whether err is a violation of the region code unique index
+func regionCodeTaken(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23505" && pgErr.ConstraintName == "idx_admin_regions_code"
+}
+
 // ListRegions returns all regions ordered by name
 func (db *Database) ListRegions(ctx context.Context) ([]models.Region, error) {
-	rows, err := db.Pool.Query(ctx, `SELECT region_id, name, description FROM admin_regions ORDER BY name`)
+	rows, err := db.Pool.Query(ctx, `SELECT region_id, name, description, code, currency FROM admin_regions ORDER BY name`)
 	if err != nil {
 		return nil, err
 	}
@@ -17,7 +29,7 @@ func (db *Database) ListRegions(ctx context.Context) ([]models.Region, error) {
 	regions := make([]models.Region, 0)
 	for rows.Next() {
 		var r models.Region
-		if err := rows.Scan(&r.ID, &r.Name, &r.Description); err != nil {
+		if err := rows.Scan(&r.ID, &r.Name, &r.Description, &r.Code, &r.Currency); err != nil {
 			return nil, err
 		}
 		regions = append(regions, r)
@@ -25,26 +37,52 @@ func (db *Database) ListRegions(ctx context.Context) ([]models.Region, error) {
 	return regions, rows.Err()
 }
 
-// CreateRegion inserts a new region
-func (db *Database) CreateRegion(ctx context.Context, name string, description *string) (*models.Region, error) {
+// CreateRegion inserts a new region; an empty currency defaults to EUR
+func (db *Database) CreateRegion(ctx context.Context, in models.Region) (*models.Region, error) {
+	var r models.Region
+	err := db.Pool.QueryRow(ctx,
+		`INSERT INTO admin_regions (name, description, code, currency) VALUES ($1, $2, $3, COALESCE(NULLIF($4, ''), 'EUR'))
+		 RETURNING region_id, name, description, code, currency`,
+		in.Name, in.Description, in.Code, in.Currency,
+	).Scan(&r.ID, &r.Name, &r.Description, &r.Code, &r.Currency)
+	if regionCodeTaken(err) {
+		return nil, ErrRegionCodeTaken
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+// UpdateRegion updates region fields; a nil code or empty currency keeps the current value
+func (db *Database) UpdateRegion(ctx context.Context, id int, in models.Region) (*models.Region, error) {
 	var r models.Region
 	err := db.Pool.QueryRow(ctx,
-		`INSERT INTO admin_regions (name, description) VALUES ($1, $2) RETURNING region_id, name, description`,
-		name, description,
-	).Scan(&r.ID, &r.Name, &r.Description)
+		`UPDATE admin_regions SET name = $2, description = $3, code = COALESCE($4, code), currency = COALESCE(NULLIF($5, ''), currency)
+		 WHERE region_id = $1 RETURNING region_id, name, description, code, currency`,
+		id, in.Name, in.Description, in.Code, in.Currency,
+	).Scan(&r.ID, &r.Name, &r.Description, &r.Code, &r.Currency)
+	if regionCodeTaken(err) {
+		return nil, ErrRegionCodeTaken
+	}
 	if err != nil {
 		return nil, err
 	}
 	return &r, nil
 }
 
-// UpdateRegion updates region fields
-func (db *Database) UpdateRegion(ctx context.Context, id int, name string, description *string) (*models.Region, error) {
+// ResolveRegion finds a region by numeric ID or by code (case-insensitive); ErrRegionNotFound otherwise
+func (db *Database) ResolveRegion(ctx context.Context, ref string) (*models.Region, error) {
 	var r models.Region
 	err := db.Pool.QueryRow(ctx,
-		`UPDATE admin_regions SET name = $2, description = $3 WHERE region_id = $1 RETURNING region_id, name, description`,
-		id, name, description,
-	).Scan(&r.ID, &r.Name, &r.Description)
+		`SELECT region_id, name, description, code, currency FROM admin_regions
+		 WHERE region_id::text = $1 OR upper(code) = upper($1)
+		 ORDER BY region_id::text = $1 DESC LIMIT 1`,
+		ref,
+	).Scan(&r.ID, &r.Name, &r.Description, &r.Code, &r.Currency)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrRegionNotFound
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,95 @@
+package db
+
+import (
+	"context"
+	"errors"
+
+	"github.com/expotoworld/expotoworld/backend/catalog-service/internal/models"
+)
+
+// ErrRelatedProductNotFound is returned when a relation points at a product that doesn't exist
+var ErrRelatedProductNotFound = errors.New("related product not found")
+
+// GetRelatedProducts returns a product's related products by relation type and display order
+func (db *Database) GetRelatedProducts(ctx context.Context, productID int) ([]models.RelatedProduct, error) {
+	rows, err := db.Pool.Query(ctx, `
+		SELECT r.relation_type, r.display_order, p.product_id, p.product_uuid::text, COALESCE(p.sku, ''), COALESCE(p.title, ''),
+			COALESCE(p.main_price, 0)::float8, p.strikethrough_price::float8, COALESCE(p.stock_left, 0),
+			COALESCE(p.is_active, false),
+			(SELECT COALESCE(i.thumbnail_url, i.image_url) FROM admin_product_images i
+				WHERE i.product_id = p.product_id ORDER BY i.display_order LIMIT 1),
+			r.created_at
+		FROM app_product_relations r
+		JOIN admin_products p ON p.product_id = r.related_product_id
+		WHERE r.product_id = $1
+		ORDER BY r.relation_type, r.display_order, p.product_id
+	`, productID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	related := []models.RelatedProduct{}
+	for rows.Next() {
+		var r models.RelatedProduct
+		if err := rows.Scan(&r.RelationType, &r.DisplayOrder, &r.ID, &r.UUID, &r.SKU, &r.Title, &r.MainPrice,
+			&r.StrikethroughPrice, &r.StockLeft, &r.IsActive, &r.ThumbnailURL, &r.CreatedAt); err != nil {
+			return nil, err
+		}
+		related = append(related, r)
+	}
+	return related, rows.Err()
+}
+
+// SetRelatedProducts replaces a product's relations atomically
+func (db *Database) SetRelatedProducts(ctx context.Context, productID int, relations []models.ProductRelation) error {
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	var exists bool
+	if err := tx.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM admin_products WHERE product_id = $1)`, productID).Scan(&exists); err != nil {
+		return err
+	}
+	if !exists {
+		return ErrProductNotFound
+	}
+
+	ids := make([]int, 0, len(relations))
+	for _, r := range relations {
+		ids = append(ids, r.RelatedProductID)
+	}
+	ids = dedupeInts(ids)
+	var found int
+	if err := tx.QueryRow(ctx, `SELECT COUNT(*) FROM admin_products WHERE product_id = ANY($1::int[])`, ids).Scan(&found); err != nil {
+		return err
+	}
+	if found != len(ids) {
+		return ErrRelatedProductNotFound
+	}
+
+	if _, err := tx.Exec(ctx, `DELETE FROM app_product_relations WHERE product_id = $1`, productID); err != nil {
+		return err
+	}
+	for _, r := range relations {
+		if _, err := tx.Exec(ctx, `INSERT INTO app_product_relations (product_id, related_product_id, relation_type, display_order) VALUES ($1,$2,$3,$4)`,
+			productID, r.RelatedProductID, string(r.RelationType), r.DisplayOrder,
+		); err != nil {
+			return err
+		}
+	}
+	return tx.Commit(ctx)
+}
+
+func dedupeInts(ids []int) []int {
+	seen := make(map[int]bool, len(ids))
+	out := make([]int, 0, len(ids))
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			out = append(out, id)
+		}
+	}
+	return out
+}
@@ -0,0 +1,220 @@
+package db
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/expotoworld/expotoworld/backend/catalog-service/internal/models"
+)
+
+// relationshipTable is where a models.RelationshipKind is stored
+type relationshipTable struct {
+	table, entityCol, orgCol string
+	entityJoin               string // joins the store or product as e, exposing key
+	regional                 bool
+}
+
+var relationshipTables = map[models.RelationshipKind]relationshipTable{
+	models.RelationshipStorePartners: {
+		table: "admin_store_partners", entityCol: "store_id", orgCol: "partner_org_id",
+		entityJoin: "JOIN (SELECT store_id AS id, COALESCE(name, '') AS key FROM admin_stores WHERE trashed_at IS NULL) e ON e.id = m.store_id",
+	},
+	models.RelationshipProductSourcing: {
+		table: "admin_product_sourcing", entityCol: "product_id", orgCol: "manufacturer_org_id", regional: true,
+		entityJoin: "JOIN (SELECT product_id AS id, COALESCE(sku, '') AS key FROM admin_products WHERE trashed_at IS NULL) e ON e.id = m.product_id",
+	},
+	models.RelationshipProductLogistics: {
+		table: "admin_product_logistics", entityCol: "product_id", orgCol: "tpl_org_id",
+		entityJoin: "JOIN (SELECT product_id AS id, COALESCE(sku, '') AS key FROM admin_products WHERE trashed_at IS NULL) e ON e.id = m.product_id",
+	},
+}
+
+// ListRelationshipMappings returns every mapping of a kind with the natural keys of its ends, by
+// store or product id
+func (db *Database) ListRelationshipMappings(ctx context.Context, kind models.RelationshipKind) ([]models.RelationshipMapping, error) {
+	t := relationshipTables[kind]
+	region := "NULL::int, NULL::text"
+	regionJoin := ""
+	if t.regional {
+		region = "m.region_id, COALESCE(r.code, r.name)"
+		regionJoin = "LEFT JOIN admin_regions r ON r.region_id = m.region_id"
+	}
+	rows, err := db.Pool.Query(ctx, fmt.Sprintf(`
+        SELECT m.%[2]s, e.key, m.%[3]s::text, COALESCE(o.name, ''), %[4]s
+        FROM %[1]s m
+        %[5]s
+        LEFT JOIN admin_organizations o ON o.org_id = m.%[3]s
+        %[6]s
+        ORDER BY m.%[2]s, o.name
+    `, t.table, t.entityCol, t.orgCol, region, t.entityJoin, regionJoin))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list %s: %w", kind, err)
+	}
+	defer rows.Close()
+
+	mappings := []models.RelationshipMapping{}
+	for rows.Next() {
+		var m models.RelationshipMapping
+		if err := rows.Scan(&m.EntityID, &m.EntityKey, &m.OrgID, &m.OrgName, &m.RegionID, &m.Region); err != nil {
+			return nil, fmt.Errorf("failed to scan %s: %w", kind, err)
+		}
+		mappings = append(mappings, m)
+	}
+	return mappings, rows.Err()
+}
+
+// RelationshipKeys resolves the ids and natural keys a relationship CSV may name. Names are
+// matched case-insensitively (lowercased keys), SKUs exactly.
+type RelationshipKeys struct {
+	Entities  map[string][]int    // store name or SKU -> ids
+	EntityIDs map[int]bool        // stores or products
+	Orgs      map[string][]string // organization name -> ids, of the kind's organization type
+	OrgIDs    map[string]bool
+	Regions   map[string]int // upper-cased code and lowercased name -> id
+	RegionIDs map[int]bool
+}
+
+// LoadRelationshipKeys loads the stores or products, organizations and regions an import of kind
+// can refer to
+func (db *Database) LoadRelationshipKeys(ctx context.Context, kind models.RelationshipKind) (*RelationshipKeys, error) {
+	keys := &RelationshipKeys{
+		Entities: map[string][]int{}, EntityIDs: map[int]bool{},
+		Orgs: map[string][]string{}, OrgIDs: map[string]bool{},
+		Regions: map[string]int{}, RegionIDs: map[int]bool{},
+	}
+
+	entities := `SELECT store_id, lower(COALESCE(name, '')) FROM admin_stores WHERE trashed_at IS NULL`
+	if kind != models.RelationshipStorePartners {
+		entities = `SELECT product_id, COALESCE(sku, '') FROM admin_products WHERE trashed_at IS NULL`
+	}
+	rows, err := db.Pool.Query(ctx, entities)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load import keys: %w", err)
+	}
+	for rows.Next() {
+		var id int
+		var key string
+		if err := rows.Scan(&id, &key); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan import key: %w", err)
+		}
+		keys.EntityIDs[id] = true
+		if key != "" {
+			keys.Entities[key] = append(keys.Entities[key], id)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to load import keys: %w", err)
+	}
+
+	rows, err = db.Pool.Query(ctx, `
+        SELECT org_id::text, lower(COALESCE(name, '')) FROM admin_organizations WHERE org_type::text = $1
+    `, string(kind.OrgType()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load import organizations: %w", err)
+	}
+	for rows.Next() {
+		var id, name string
+		if err := rows.Scan(&id, &name); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan import organization: %w", err)
+		}
+		keys.OrgIDs[id] = true
+		keys.Orgs[name] = append(keys.Orgs[name], id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to load import organizations: %w", err)
+	}
+
+	if kind == models.RelationshipProductSourcing {
+		rows, err = db.Pool.Query(ctx, `SELECT region_id, upper(COALESCE(code, '')), lower(name) FROM admin_regions`)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load import regions: %w", err)
+		}
+		defer rows.Close()
+		for rows.Next() {
+			var id int
+			var code, name string
+			if err := rows.Scan(&id, &code, &name); err != nil {
+				return nil, fmt.Errorf("failed to scan import region: %w", err)
+			}
+			keys.RegionIDs[id] = true
+			keys.Regions[name] = id
+			if code != "" {
+				keys.Regions[code] = id
+			}
+		}
+		if err := rows.Err(); err != nil {
+			return nil, fmt.Errorf("failed to load import regions: %w", err)
+		}
+	}
+	return keys, nil
+}
+
+// ApplyRelationshipMappings writes resolved, de-duplicated mappings of a kind. With replace, the
+// stores or products in mappings lose the mappings the file doesn't list. A dry run reports the
+// counts and rolls back.
+func (db *Database) ApplyRelationshipMappings(ctx context.Context, kind models.RelationshipKind, mappings []models.RelationshipMapping, replace, dryRun bool) (added, removed int, err error) {
+	t := relationshipTables[kind]
+	entityIDs := make([]int, len(mappings))
+	orgIDs := make([]string, len(mappings))
+	regionIDs := make([]int, len(mappings))
+	seen := map[int]bool{}
+	var entities []int
+	for i, m := range mappings {
+		entityIDs[i], orgIDs[i] = m.EntityID, m.OrgID
+		if m.RegionID != nil {
+			regionIDs[i] = *m.RegionID
+		}
+		if !seen[m.EntityID] {
+			seen[m.EntityID] = true
+			entities = append(entities, m.EntityID)
+		}
+	}
+	sameRegion, regionCol, regionVal := "", "", ""
+	if t.regional {
+		sameRegion, regionCol, regionVal = "AND f.region_id = m.region_id", ", region_id", ", f.region_id"
+	}
+
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	if replace {
+		tag, err := tx.Exec(ctx, fmt.Sprintf(`
+            DELETE FROM %[1]s m
+            WHERE m.%[2]s = ANY($1) AND NOT EXISTS (
+                SELECT 1 FROM unnest($2::int[], $3::text[], $4::int[]) AS f(entity_id, org_id, region_id)
+                WHERE f.entity_id = m.%[2]s AND f.org_id = m.%[3]s::text %[4]s
+            )
+        `, t.table, t.entityCol, t.orgCol, sameRegion), entities, entityIDs, orgIDs, regionIDs)
+		if err != nil {
+			return 0, 0, fmt.Errorf("failed to remove %s: %w", kind, err)
+		}
+		removed = int(tag.RowsAffected())
+	}
+	tag, err := tx.Exec(ctx, fmt.Sprintf(`
+        INSERT INTO %[1]s (%[2]s, %[3]s%[5]s)
+        SELECT f.entity_id, f.org_id::uuid%[6]s
+        FROM unnest($1::int[], $2::text[], $3::int[]) AS f(entity_id, org_id, region_id)
+        WHERE NOT EXISTS (
+            SELECT 1 FROM %[1]s m WHERE m.%[2]s = f.entity_id AND m.%[3]s::text = f.org_id %[4]s
+        )
+    `, t.table, t.entityCol, t.orgCol, sameRegion, regionCol, regionVal), entityIDs, orgIDs, regionIDs)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to add %s: %w", kind, orgGraphError(err))
+	}
+	added = int(tag.RowsAffected())
+
+	if dryRun {
+		return added, removed, nil
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return 0, 0, err
+	}
+	return added, removed, nil
+}
@@ -52,7 +52,7 @@ func (db *Database) SetStorePartners(ctx context.Context, storeID int, partners
 	for _, p := range partners {
 		if _, err := tx.Exec(ctx, `INSERT INTO admin_store_partners (store_id, partner_org_id) VALUES ($1,$2)`,
 			storeID, p.PartnerOrgID,
-		); err != nil { return err }
+		); err != nil { return orgGraphError(err) }
 	}
 	return tx.Commit(ctx)
 }
@@ -0,0 +1,44 @@
+package db
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/expotoworld/expotoworld/backend/catalog-service/internal/models"
+)
+
+// RenditionTable is a table whose rows carry an image_url plus generated renditions
+type RenditionTable string
+
+const (
+	RenditionsProductImages RenditionTable = "admin_product_images"
+	RenditionsCategories    RenditionTable = "admin_product_categories"
+	RenditionsSubcategories RenditionTable = "admin_subcategories"
+	RenditionsStores        RenditionTable = "admin_stores"
+)
+
+// renditionOwnerColumns maps each table to the column identifying the owner of the image
+var renditionOwnerColumns = map[RenditionTable]string{
+	RenditionsProductImages: "product_id",
+	RenditionsCategories:    "category_id",
+	RenditionsSubcategories: "subcategory_id",
+	RenditionsStores:        "store_id",
+}
+
+// SetImageRenditions records renditions for the row still pointing at sourceURL. It reports false
+// when the image was replaced or deleted while the renditions were being generated.
+func (db *Database) SetImageRenditions(ctx context.Context, table RenditionTable, ownerID, sourceURL string, r models.ImageRenditions) (bool, error) {
+	col, ok := renditionOwnerColumns[table]
+	if !ok {
+		return false, fmt.Errorf("unknown rendition table %q", table)
+	}
+	tag, err := db.Pool.Exec(ctx, `
+		UPDATE `+string(table)+`
+		SET thumbnail_url = $3, medium_url = $4, webp_url = $5
+		WHERE `+col+`::text = $1 AND image_url = $2
+	`, ownerID, sourceURL, r.ThumbnailURL, r.MediumURL, r.WebPURL)
+	if err != nil {
+		return false, fmt.Errorf("failed to save image renditions: %w", err)
+	}
+	return tag.RowsAffected() > 0, nil
+}
@@ -0,0 +1,665 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"log"
+)
+
+// InitSchema creates/verifies the tables owned by the catalog service.
+// The core admin_* tables are managed externally; only auxiliary tables are created here.
+// Safe to call at startup; idempotent.
+func (db *Database) InitSchema(ctx context.Context) error {
+	stmts := []string{
+		// Product view tracking (raw events + daily aggregates)
+		`CREATE TABLE IF NOT EXISTS app_product_view_events (
+			event_id BIGSERIAL PRIMARY KEY,
+			product_id INTEGER NOT NULL,
+			user_id TEXT,
+			session_id TEXT,
+			mini_app_type TEXT,
+			source TEXT,
+			viewed_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+			received_at TIMESTAMPTZ NOT NULL DEFAULT now()
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_product_view_events_viewed_at ON app_product_view_events(viewed_at);`,
+		`CREATE INDEX IF NOT EXISTS idx_product_view_events_product ON app_product_view_events(product_id, viewed_at);`,
+		`CREATE TABLE IF NOT EXISTS app_product_popularity_daily (
+			product_id INTEGER NOT NULL,
+			day DATE NOT NULL,
+			views INTEGER NOT NULL DEFAULT 0,
+			unique_viewers INTEGER NOT NULL DEFAULT 0,
+			score DOUBLE PRECISION NOT NULL DEFAULT 0,
+			updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+			PRIMARY KEY (product_id, day)
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_product_popularity_day ON app_product_popularity_daily(day);`,
+		// A/B experiments (definitions + exposure log)
+		`CREATE TABLE IF NOT EXISTS app_experiments (
+			experiment_key TEXT PRIMARY KEY,
+			description TEXT,
+			variants JSONB NOT NULL,
+			status TEXT NOT NULL DEFAULT 'draft' CHECK (status IN ('draft','running','stopped')),
+			salt TEXT NOT NULL DEFAULT '',
+			created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+			updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+		);`,
+		`CREATE TABLE IF NOT EXISTS app_experiment_exposures (
+			exposure_id BIGSERIAL PRIMARY KEY,
+			experiment_key TEXT NOT NULL,
+			variant TEXT NOT NULL,
+			user_id TEXT,
+			session_id TEXT,
+			context TEXT,
+			exposed_at TIMESTAMPTZ NOT NULL DEFAULT now()
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_experiment_exposures_key ON app_experiment_exposures(experiment_key, variant, exposed_at);`,
+		// Organization price lists (B2B contract prices; falls back to admin_products.main_price)
+		`CREATE TABLE IF NOT EXISTS app_price_lists (
+			price_list_id BIGSERIAL PRIMARY KEY,
+			org_id UUID NOT NULL,
+			name TEXT NOT NULL,
+			is_active BOOLEAN NOT NULL DEFAULT true,
+			valid_from TIMESTAMPTZ NOT NULL DEFAULT now(),
+			valid_until TIMESTAMPTZ,
+			created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+			updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+			CHECK (valid_until IS NULL OR valid_until > valid_from)
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_price_lists_org ON app_price_lists(org_id, valid_from);`,
+		`CREATE TABLE IF NOT EXISTS app_price_list_items (
+			price_list_id BIGINT NOT NULL REFERENCES app_price_lists(price_list_id) ON DELETE CASCADE,
+			product_id INTEGER NOT NULL,
+			price NUMERIC(12,2) NOT NULL CHECK (price >= 0),
+			PRIMARY KEY (price_list_id, product_id)
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_price_list_items_product ON app_price_list_items(product_id);`,
+		// Currently effective contract price per (org, product); read by order-service at checkout
+		`CREATE OR REPLACE VIEW app_effective_org_prices AS
+			SELECT DISTINCT ON (pl.org_id, i.product_id)
+				pl.org_id, i.product_id, i.price, pl.price_list_id
+			FROM app_price_lists pl
+			JOIN app_price_list_items i ON i.price_list_id = pl.price_list_id
+			WHERE pl.is_active AND pl.valid_from <= now() AND (pl.valid_until IS NULL OR pl.valid_until > now())
+			ORDER BY pl.org_id, i.product_id, pl.valid_from DESC, pl.price_list_id DESC;`,
+		// Competitor prices observed by the scraping job (latest observation per product and source)
+		`CREATE TABLE IF NOT EXISTS app_product_competitor_prices (
+			product_id INTEGER NOT NULL,
+			source TEXT NOT NULL,
+			url TEXT,
+			price NUMERIC(12,2) NOT NULL CHECK (price > 0),
+			last_checked_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+			created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+			updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+			PRIMARY KEY (product_id, source)
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_competitor_prices_checked ON app_product_competitor_prices(last_checked_at);`,
+		// Commission rates (versioned by effective_from; NULL category/manufacturer = any)
+		`CREATE TABLE IF NOT EXISTS app_commission_rates (
+			rate_id BIGSERIAL PRIMARY KEY,
+			category_id INTEGER,
+			manufacturer_org_id UUID,
+			rate_pct NUMERIC(5,2) NOT NULL CHECK (rate_pct >= 0 AND rate_pct <= 100),
+			effective_from TIMESTAMPTZ NOT NULL,
+			notes TEXT,
+			created_by TEXT,
+			created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+		);`,
+		`CREATE UNIQUE INDEX IF NOT EXISTS ux_commission_rates_scope ON app_commission_rates(
+			COALESCE(category_id, 0), COALESCE(manufacturer_org_id, '00000000-0000-0000-0000-000000000000'::uuid), effective_from);`,
+		// Rate for a product sold by a manufacturer at a point in time; read by order-service settlements.
+		// Specificity first (manufacturer+category, manufacturer, category, default), then latest version.
+		`CREATE OR REPLACE FUNCTION app_commission_rate(p_product_id INTEGER, p_manufacturer_org_id UUID, p_at TIMESTAMPTZ)
+		RETURNS TABLE (rate_id BIGINT, rate_pct NUMERIC)
+		LANGUAGE sql STABLE AS $$
+			SELECT r.rate_id, r.rate_pct
+			FROM app_commission_rates r
+			WHERE r.effective_from <= p_at
+			  AND (r.manufacturer_org_id IS NULL OR r.manufacturer_org_id = p_manufacturer_org_id)
+			  AND (r.category_id IS NULL OR r.category_id IN (
+				SELECT m.category_id FROM admin_product_category_mapping m WHERE m.product_id = p_product_id))
+			ORDER BY (r.manufacturer_org_id IS NOT NULL) DESC, (r.category_id IS NOT NULL) DESC,
+				r.effective_from DESC, r.rate_id DESC
+			LIMIT 1
+		$$;`,
+		// Stock transitions: restocked_at marks the last 0 -> >0 change; events feed back-in-stock notifications.
+		// A trigger catches every writer (admin edits, order-service stock deduction, imports).
+		`ALTER TABLE admin_products ADD COLUMN IF NOT EXISTS restocked_at TIMESTAMPTZ;`,
+		`CREATE TABLE IF NOT EXISTS app_product_stock_events (
+			event_id BIGSERIAL PRIMARY KEY,
+			product_id INTEGER NOT NULL,
+			event_type TEXT NOT NULL CHECK (event_type IN ('back_in_stock','out_of_stock')),
+			old_stock INTEGER NOT NULL,
+			new_stock INTEGER NOT NULL,
+			occurred_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+			processed_at TIMESTAMPTZ
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_product_stock_events_unprocessed ON app_product_stock_events(event_id) WHERE processed_at IS NULL;`,
+		`CREATE INDEX IF NOT EXISTS idx_products_created_at ON admin_products(created_at);`,
+		`CREATE INDEX IF NOT EXISTS idx_products_restocked_at ON admin_products(restocked_at) WHERE restocked_at IS NOT NULL;`,
+		`CREATE OR REPLACE FUNCTION app_track_product_stock() RETURNS trigger
+		LANGUAGE plpgsql AS $$
+		BEGIN
+			IF COALESCE(OLD.stock_left, 0) <= 0 AND COALESCE(NEW.stock_left, 0) > 0 THEN
+				NEW.restocked_at := now();
+				INSERT INTO app_product_stock_events (product_id, event_type, old_stock, new_stock)
+				VALUES (NEW.product_id, 'back_in_stock', COALESCE(OLD.stock_left, 0), NEW.stock_left);
+			ELSIF COALESCE(OLD.stock_left, 0) > 0 AND COALESCE(NEW.stock_left, 0) <= 0 THEN
+				INSERT INTO app_product_stock_events (product_id, event_type, old_stock, new_stock)
+				VALUES (NEW.product_id, 'out_of_stock', OLD.stock_left, COALESCE(NEW.stock_left, 0));
+			END IF;
+			RETURN NEW;
+		END
+		$$;`,
+		`DROP TRIGGER IF EXISTS trg_admin_products_stock ON admin_products;`,
+		`CREATE TRIGGER trg_admin_products_stock BEFORE UPDATE OF stock_left ON admin_products
+			FOR EACH ROW EXECUTE FUNCTION app_track_product_stock();`,
+		// Back-in-stock subscriptions (removed after the user is notified)
+		`ALTER TABLE app_product_stock_events ADD COLUMN IF NOT EXISTS attempts INTEGER NOT NULL DEFAULT 0;`,
+		`ALTER TABLE app_product_stock_events ADD COLUMN IF NOT EXISTS last_error TEXT;`,
+		`CREATE TABLE IF NOT EXISTS app_product_stock_subscriptions (
+			product_id INTEGER NOT NULL,
+			user_id TEXT NOT NULL,
+			mini_app_type TEXT,
+			created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+			PRIMARY KEY (product_id, user_id)
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_stock_subscriptions_user ON app_product_stock_subscriptions(user_id, created_at DESC);`,
+		// Product variants (size/color/flavor SKUs of a parent product)
+		`CREATE TABLE IF NOT EXISTS app_product_variants (
+			variant_id SERIAL PRIMARY KEY,
+			product_id INTEGER NOT NULL,
+			sku TEXT NOT NULL,
+			name TEXT NOT NULL DEFAULT '',
+			options JSONB NOT NULL DEFAULT '{}'::jsonb,
+			main_price NUMERIC(12,2) NOT NULL CHECK (main_price >= 0),
+			strikethrough_price NUMERIC(12,2),
+			cost_price NUMERIC(12,2),
+			stock_left INTEGER NOT NULL DEFAULT 0 CHECK (stock_left >= 0),
+			shelf_code TEXT,
+			is_active BOOLEAN NOT NULL DEFAULT true,
+			display_order INTEGER NOT NULL DEFAULT 0,
+			created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+			updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+		);`,
+		`CREATE UNIQUE INDEX IF NOT EXISTS uq_product_variants_sku ON app_product_variants(lower(sku));`,
+		`CREATE INDEX IF NOT EXISTS idx_product_variants_product ON app_product_variants(product_id, display_order);`,
+		// Product translations (per-locale title/description; machine drafts await review)
+		`CREATE TABLE IF NOT EXISTS app_product_translations (
+			product_id INTEGER NOT NULL,
+			locale TEXT NOT NULL,
+			title TEXT NOT NULL DEFAULT '',
+			description TEXT NOT NULL DEFAULT '',
+			status TEXT NOT NULL CHECK (status IN ('source','machine_draft','confirmed')),
+			source_locale TEXT,
+			provider TEXT,
+			reviewed_by TEXT,
+			reviewed_at TIMESTAMPTZ,
+			created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+			updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+			PRIMARY KEY (product_id, locale)
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_product_translations_review ON app_product_translations(status) WHERE status = 'machine_draft';`,
+		// WebP renditions generated after upload (NULL until processed; clients fall back to image_url)
+		`ALTER TABLE admin_product_images ADD COLUMN IF NOT EXISTS thumbnail_url TEXT, ADD COLUMN IF NOT EXISTS medium_url TEXT, ADD COLUMN IF NOT EXISTS webp_url TEXT;`,
+		`ALTER TABLE admin_product_categories ADD COLUMN IF NOT EXISTS thumbnail_url TEXT, ADD COLUMN IF NOT EXISTS medium_url TEXT, ADD COLUMN IF NOT EXISTS webp_url TEXT;`,
+		`ALTER TABLE admin_subcategories ADD COLUMN IF NOT EXISTS thumbnail_url TEXT, ADD COLUMN IF NOT EXISTS medium_url TEXT, ADD COLUMN IF NOT EXISTS webp_url TEXT;`,
+		`ALTER TABLE admin_stores ADD COLUMN IF NOT EXISTS thumbnail_url TEXT, ADD COLUMN IF NOT EXISTS medium_url TEXT, ADD COLUMN IF NOT EXISTS webp_url TEXT;`,
+		// Tax classes and the region tax matrix (rate per region and class)
+		`ALTER TABLE admin_products ADD COLUMN IF NOT EXISTS tax_class TEXT CHECK (tax_class IN ('standard','reduced','exempt'));`,
+		`CREATE TABLE IF NOT EXISTS app_region_tax_rates (
+			region_id INTEGER NOT NULL,
+			tax_class TEXT NOT NULL CHECK (tax_class IN ('standard','reduced','exempt')),
+			rate_pct NUMERIC(5,2) NOT NULL CHECK (rate_pct >= 0 AND rate_pct <= 100),
+			updated_by TEXT,
+			updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+			PRIMARY KEY (region_id, tax_class)
+		);`,
+		// Tax rate for a product sold in a region; read by order-service at checkout. Without an explicit
+		// region the product's store region is used. No row means the product can't be sold there yet.
+		`CREATE OR REPLACE FUNCTION app_product_tax_rate(p_product_id INTEGER, p_region_id INTEGER)
+		RETURNS TABLE (region_id INTEGER, tax_class TEXT, rate_pct NUMERIC)
+		LANGUAGE sql STABLE AS $$
+			SELECT t.region_id, t.tax_class, t.rate_pct
+			FROM admin_products p
+			LEFT JOIN admin_stores s ON s.store_id = p.store_id
+			JOIN app_region_tax_rates t ON t.region_id = COALESCE(p_region_id, s.region_id) AND t.tax_class = p.tax_class
+			WHERE p.product_id = p_product_id
+		$$;`,
+		// Stock movements: every stock_left change with who/why. The adjustment API passes the reason, actor
+		// and note through transaction-local settings; other writers are recorded as 'system'.
+		`CREATE TABLE IF NOT EXISTS app_stock_movements (
+			movement_id BIGSERIAL PRIMARY KEY,
+			product_id INTEGER NOT NULL,
+			delta INTEGER NOT NULL,
+			stock_before INTEGER NOT NULL,
+			stock_after INTEGER NOT NULL,
+			reason TEXT NOT NULL CHECK (reason IN ('restock','customer_return','damaged','expired','lost','correction','initial','system')),
+			note TEXT,
+			actor TEXT,
+			created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_stock_movements_product ON app_stock_movements(product_id, movement_id DESC);`,
+		`CREATE OR REPLACE FUNCTION app_log_stock_movement() RETURNS trigger
+		LANGUAGE plpgsql AS $$
+		DECLARE
+			v_before INTEGER := 0;
+			v_reason TEXT := 'system';
+		BEGIN
+			IF TG_OP = 'UPDATE' THEN
+				v_before := COALESCE(OLD.stock_left, 0);
+			ELSE
+				v_reason := 'initial';
+			END IF;
+			IF v_before = COALESCE(NEW.stock_left, 0) THEN
+				RETURN NULL;
+			END IF;
+			INSERT INTO app_stock_movements (product_id, delta, stock_before, stock_after, reason, note, actor)
+			VALUES (NEW.product_id, COALESCE(NEW.stock_left, 0) - v_before, v_before, COALESCE(NEW.stock_left, 0),
+				COALESCE(NULLIF(current_setting('app.stock_reason', true), ''), v_reason),
+				NULLIF(current_setting('app.stock_note', true), ''),
+				NULLIF(current_setting('app.stock_actor', true), ''));
+			RETURN NULL;
+		END
+		$$;`,
+		`DROP TRIGGER IF EXISTS trg_admin_products_stock_movements ON admin_products;`,
+		`CREATE TRIGGER trg_admin_products_stock_movements AFTER INSERT OR UPDATE OF stock_left ON admin_products
+			FOR EACH ROW EXECUTE FUNCTION app_log_stock_movement();`,
+		// Curated product-to-product relations shown on the product page
+		`CREATE TABLE IF NOT EXISTS app_product_relations (
+			product_id INTEGER NOT NULL,
+			related_product_id INTEGER NOT NULL,
+			relation_type TEXT NOT NULL CHECK (relation_type IN ('accessory','replacement','upsell')),
+			display_order INTEGER NOT NULL DEFAULT 0,
+			created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+			PRIMARY KEY (product_id, relation_type, related_product_id),
+			CHECK (product_id <> related_product_id)
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_product_relations_related ON app_product_relations(related_product_id);`,
+		// Store opening hours (weekly periods) and holiday exceptions, in the store's IANA time zone
+		`ALTER TABLE admin_stores ADD COLUMN IF NOT EXISTS timezone TEXT, ADD COLUMN IF NOT EXISTS opening_hours JSONB, ADD COLUMN IF NOT EXISTS holidays JSONB;`,
+		// Staged bulk edits (changesets) applied atomically, immediately or at scheduled_for
+		`CREATE TABLE IF NOT EXISTS app_changesets (
+			changeset_id BIGSERIAL PRIMARY KEY,
+			name TEXT NOT NULL,
+			status TEXT NOT NULL DEFAULT 'draft' CHECK (status IN ('draft','scheduled','applied','discarded','failed')),
+			scheduled_for TIMESTAMPTZ,
+			created_by TEXT,
+			applied_at TIMESTAMPTZ,
+			last_error TEXT,
+			created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+			updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_changesets_due ON app_changesets(scheduled_for) WHERE status = 'scheduled';`,
+		`CREATE TABLE IF NOT EXISTS app_changeset_items (
+			changeset_id BIGINT NOT NULL REFERENCES app_changesets(changeset_id) ON DELETE CASCADE,
+			product_id INTEGER NOT NULL,
+			main_price NUMERIC(12,2) CHECK (main_price >= 0),
+			strikethrough_price NUMERIC(12,2) CHECK (strikethrough_price >= 0),
+			clear_strikethrough BOOLEAN NOT NULL DEFAULT false,
+			category_ids INTEGER[],
+			subcategory_ids INTEGER[],
+			updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+			PRIMARY KEY (changeset_id, product_id)
+		);`,
+		// Audit trail of admin writes (actor from the JWT, entity row before/after)
+		`CREATE TABLE IF NOT EXISTS catalog_audit_log (
+			audit_id BIGSERIAL PRIMARY KEY,
+			occurred_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+			actor_user_id TEXT,
+			actor_email TEXT,
+			actor_role TEXT,
+			method TEXT NOT NULL,
+			route TEXT NOT NULL,
+			path TEXT NOT NULL,
+			entity_type TEXT NOT NULL,
+			entity_id TEXT,
+			action TEXT NOT NULL,
+			status INTEGER NOT NULL,
+			before JSONB,
+			after JSONB,
+			changes JSONB,
+			request_body JSONB,
+			client_ip TEXT
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_catalog_audit_entity ON catalog_audit_log(entity_type, entity_id, audit_id DESC);`,
+		`CREATE INDEX IF NOT EXISTS idx_catalog_audit_actor ON catalog_audit_log(actor_user_id, audit_id DESC);`,
+		`CREATE INDEX IF NOT EXISTS idx_catalog_audit_occurred ON catalog_audit_log(occurred_at);`,
+		// Undo of recent destructive actions links the undo entry and the entry it reversed
+		`ALTER TABLE catalog_audit_log ADD COLUMN IF NOT EXISTS undo_of BIGINT REFERENCES catalog_audit_log(audit_id);`,
+		`ALTER TABLE catalog_audit_log ADD COLUMN IF NOT EXISTS undone_by BIGINT REFERENCES catalog_audit_log(audit_id);`,
+		// Food label data (nutrition per 100 g/ml, allergens, origin) required by Swiss labelling rules
+		`CREATE TABLE IF NOT EXISTS app_product_food_labels (
+			product_id INTEGER PRIMARY KEY,
+			nutrition JSONB,
+			ingredients TEXT,
+			allergens TEXT[] NOT NULL DEFAULT '{}',
+			may_contain TEXT[] NOT NULL DEFAULT '{}',
+			origin_country CHAR(2) CHECK (origin_country ~ '^[A-Z]{2}$'),
+			updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+		);`,
+		// Product barcodes (GTIN, UPC-A stored as EAN-13) for the unmanned-store scanner
+		`ALTER TABLE admin_products ADD COLUMN IF NOT EXISTS barcode TEXT CHECK (barcode ~ '^([0-9]{8}|[0-9]{13}|[0-9]{14})$');`,
+		`CREATE UNIQUE INDEX IF NOT EXISTS idx_admin_products_barcode ON admin_products(barcode) WHERE barcode IS NOT NULL;`,
+		// Webhook endpoints (external storefronts, kiosks) and their signed event deliveries
+		`CREATE TABLE IF NOT EXISTS catalog_webhook_endpoints (
+			endpoint_id BIGSERIAL PRIMARY KEY,
+			url TEXT NOT NULL,
+			events TEXT[] NOT NULL DEFAULT '{}',
+			description TEXT,
+			secret TEXT NOT NULL,
+			is_active BOOLEAN NOT NULL DEFAULT true,
+			created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+			updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+		);`,
+		`CREATE TABLE IF NOT EXISTS catalog_webhook_deliveries (
+			delivery_id BIGSERIAL PRIMARY KEY,
+			endpoint_id BIGINT NOT NULL REFERENCES catalog_webhook_endpoints(endpoint_id) ON DELETE CASCADE,
+			event TEXT NOT NULL,
+			payload JSONB NOT NULL,
+			status TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'delivered', 'failed')),
+			attempts INTEGER NOT NULL DEFAULT 0,
+			response_code INTEGER,
+			last_error TEXT,
+			next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+			delivered_at TIMESTAMPTZ,
+			created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_due ON catalog_webhook_deliveries(next_attempt_at) WHERE status = 'pending';`,
+		`CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_endpoint ON catalog_webhook_deliveries(endpoint_id, delivery_id DESC);`,
+		// Catalog snapshot exports to S3 for analytics; one scheduled run per day
+		`CREATE TABLE IF NOT EXISTS catalog_exports (
+			export_id BIGSERIAL PRIMARY KEY,
+			snapshot_date DATE NOT NULL,
+			trigger TEXT NOT NULL CHECK (trigger IN ('scheduled', 'manual')),
+			status TEXT NOT NULL DEFAULT 'running' CHECK (status IN ('running', 'completed', 'failed')),
+			manifest_key TEXT,
+			datasets JSONB NOT NULL DEFAULT '[]',
+			error TEXT,
+			started_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+			finished_at TIMESTAMPTZ
+		);`,
+		`CREATE UNIQUE INDEX IF NOT EXISTS idx_catalog_exports_scheduled ON catalog_exports(snapshot_date) WHERE trigger = 'scheduled';`,
+		// Low-stock thresholds per product or store; low_stock_alerted_at marks products already announced
+		// and is cleared once stock recovers
+		`ALTER TABLE admin_products ADD COLUMN IF NOT EXISTS low_stock_threshold INTEGER CHECK (low_stock_threshold >= 0),
+			ADD COLUMN IF NOT EXISTS low_stock_alerted_at TIMESTAMPTZ;`,
+		`ALTER TABLE admin_stores ADD COLUMN IF NOT EXISTS low_stock_threshold INTEGER CHECK (low_stock_threshold >= 0);`,
+		// Manufacturer product submissions awaiting admin review; one pending change per existing product
+		`CREATE TABLE IF NOT EXISTS app_product_submissions (
+			submission_id BIGSERIAL PRIMARY KEY,
+			org_id TEXT NOT NULL,
+			product_id INTEGER REFERENCES admin_products(product_id) ON DELETE CASCADE,
+			kind TEXT NOT NULL CHECK (kind IN ('create', 'update')),
+			status TEXT NOT NULL DEFAULT 'pending_review' CHECK (status IN ('pending_review', 'approved', 'rejected')),
+			payload JSONB NOT NULL,
+			submitted_by TEXT,
+			review_comment TEXT,
+			reviewed_by TEXT,
+			reviewed_at TIMESTAMPTZ,
+			created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+			updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+		);`,
+		`CREATE UNIQUE INDEX IF NOT EXISTS idx_product_submissions_pending_product ON app_product_submissions(product_id)
+			WHERE status = 'pending_review' AND kind = 'update';`,
+		`CREATE INDEX IF NOT EXISTS idx_product_submissions_org ON app_product_submissions(org_id, submission_id DESC);`,
+		`CREATE INDEX IF NOT EXISTS idx_product_submissions_status ON app_product_submissions(status, submission_id DESC);`,
+		// Market regions (e.g. CH/CHF vs IT/EUR): a code usable as ?region=, the currency of regional
+		// prices, and per-region product prices in that currency
+		`ALTER TABLE admin_regions ADD COLUMN IF NOT EXISTS code TEXT, ADD COLUMN IF NOT EXISTS currency TEXT NOT NULL DEFAULT 'EUR';`,
+		`CREATE UNIQUE INDEX IF NOT EXISTS idx_admin_regions_code ON admin_regions(upper(code)) WHERE code IS NOT NULL;`,
+		`CREATE TABLE IF NOT EXISTS app_product_region_prices (
+			product_id INTEGER NOT NULL,
+			region_id INTEGER NOT NULL,
+			price NUMERIC(12,2) NOT NULL CHECK (price >= 0),
+			strikethrough_price NUMERIC(12,2) CHECK (strikethrough_price >= 0),
+			updated_by TEXT,
+			updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+			PRIMARY KEY (product_id, region_id)
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_product_region_prices_region ON app_product_region_prices(region_id);`,
+		// Trash: hard deletes only mark the row (inactive, trashed_at); the purge job removes it and its
+		// images after the retention window. Reactivating a trashed row takes it out of the trash.
+		`ALTER TABLE admin_products ADD COLUMN IF NOT EXISTS trashed_at TIMESTAMPTZ;`,
+		`ALTER TABLE admin_products ADD COLUMN IF NOT EXISTS trashed_by TEXT;`,
+		`ALTER TABLE admin_product_categories ADD COLUMN IF NOT EXISTS trashed_at TIMESTAMPTZ;`,
+		`ALTER TABLE admin_product_categories ADD COLUMN IF NOT EXISTS trashed_by TEXT;`,
+		`ALTER TABLE admin_stores ADD COLUMN IF NOT EXISTS trashed_at TIMESTAMPTZ;`,
+		`ALTER TABLE admin_stores ADD COLUMN IF NOT EXISTS trashed_by TEXT;`,
+		`CREATE INDEX IF NOT EXISTS idx_admin_products_trashed ON admin_products(trashed_at) WHERE trashed_at IS NOT NULL;`,
+		`CREATE INDEX IF NOT EXISTS idx_admin_product_categories_trashed ON admin_product_categories(trashed_at) WHERE trashed_at IS NOT NULL;`,
+		`CREATE INDEX IF NOT EXISTS idx_admin_stores_trashed ON admin_stores(trashed_at) WHERE trashed_at IS NOT NULL;`,
+		`CREATE OR REPLACE FUNCTION app_untrash_on_activate() RETURNS trigger
+		LANGUAGE plpgsql AS $$
+		BEGIN
+			IF NEW.is_active AND NEW.trashed_at IS NOT NULL THEN
+				NEW.trashed_at := NULL;
+				NEW.trashed_by := NULL;
+			END IF;
+			RETURN NEW;
+		END
+		$$;`,
+		`DROP TRIGGER IF EXISTS trg_admin_products_untrash ON admin_products;`,
+		`CREATE TRIGGER trg_admin_products_untrash BEFORE UPDATE OF is_active ON admin_products
+			FOR EACH ROW EXECUTE FUNCTION app_untrash_on_activate();`,
+		`DROP TRIGGER IF EXISTS trg_admin_product_categories_untrash ON admin_product_categories;`,
+		`CREATE TRIGGER trg_admin_product_categories_untrash BEFORE UPDATE OF is_active ON admin_product_categories
+			FOR EACH ROW EXECUTE FUNCTION app_untrash_on_activate();`,
+		`DROP TRIGGER IF EXISTS trg_admin_stores_untrash ON admin_stores;`,
+		`CREATE TRIGGER trg_admin_stores_untrash BEFORE UPDATE OF is_active ON admin_stores
+			FOR EACH ROW EXECUTE FUNCTION app_untrash_on_activate();`,
+		// Shelf layout of unmanned stores (models.ShelfLayout); shelf codes must fit it once set
+		`ALTER TABLE admin_stores ADD COLUMN IF NOT EXISTS shelf_layout JSONB;`,
+		// Organization hierarchy: Brands are roots, other organizations sit under a Brand or an
+		// organization of their own type (see models.OrgType.ParentTypes), without cycles. Replaces the
+		// Brand-only parent constraint.
+		`ALTER TABLE admin_organizations DROP CONSTRAINT IF EXISTS organizations_parent_must_be_brand;`,
+		`CREATE OR REPLACE FUNCTION app_org_parent_allowed(child_type TEXT, parent_type TEXT) RETURNS BOOLEAN
+		LANGUAGE sql IMMUTABLE AS $$
+			SELECT child_type <> 'Brand' AND parent_type IN ('Brand', child_type)
+		$$;`,
+		`CREATE OR REPLACE FUNCTION app_check_org_hierarchy() RETURNS trigger LANGUAGE plpgsql AS $$
+		DECLARE
+			parent_type TEXT;
+		BEGIN
+			IF NEW.parent_org_id IS NOT NULL THEN
+				SELECT org_type::text INTO parent_type FROM admin_organizations WHERE org_id = NEW.parent_org_id;
+				-- unknown parents are left to the foreign key
+				IF parent_type IS NOT NULL AND NOT app_org_parent_allowed(NEW.org_type::text, parent_type) THEN
+					RAISE EXCEPTION 'a % organization cannot have a % parent', NEW.org_type, parent_type
+						USING ERRCODE = 'check_violation', CONSTRAINT = 'organizations_parent_type';
+				END IF;
+				IF TG_OP = 'UPDATE' AND EXISTS (
+					WITH RECURSIVE up AS (
+						SELECT NEW.parent_org_id AS org_id, 1 AS depth
+						UNION ALL
+						SELECT o.parent_org_id, up.depth + 1
+						FROM admin_organizations o JOIN up ON o.org_id = up.org_id
+						WHERE o.parent_org_id IS NOT NULL AND up.depth < 64
+					)
+					SELECT 1 FROM up WHERE org_id = NEW.org_id
+				) THEN
+					RAISE EXCEPTION 'organization % cannot be its own ancestor', NEW.org_id
+						USING ERRCODE = 'check_violation', CONSTRAINT = 'organizations_parent_cycle';
+				END IF;
+			END IF;
+			IF TG_OP = 'UPDATE' AND NEW.org_type IS DISTINCT FROM OLD.org_type THEN
+				IF EXISTS (SELECT 1 FROM admin_organizations c WHERE c.parent_org_id = NEW.org_id
+						AND NOT app_org_parent_allowed(c.org_type::text, NEW.org_type::text)) THEN
+					RAISE EXCEPTION 'organization % has children that cannot sit under a %', NEW.org_id, NEW.org_type
+						USING ERRCODE = 'check_violation', CONSTRAINT = 'organizations_parent_type';
+				END IF;
+				IF (OLD.org_type::text = 'Partner' AND EXISTS (SELECT 1 FROM admin_store_partners WHERE partner_org_id = NEW.org_id))
+					OR (OLD.org_type::text = 'Manufacturer' AND EXISTS (SELECT 1 FROM admin_products WHERE owner_org_id = NEW.org_id)) THEN
+					RAISE EXCEPTION 'organization % still has % links', NEW.org_id, OLD.org_type
+						USING ERRCODE = 'check_violation', CONSTRAINT = 'organizations_link_type';
+				END IF;
+			END IF;
+			RETURN NEW;
+		END
+		$$;`,
+		`DROP TRIGGER IF EXISTS trg_admin_organizations_hierarchy ON admin_organizations;`,
+		`CREATE TRIGGER trg_admin_organizations_hierarchy BEFORE INSERT OR UPDATE OF parent_org_id, org_type ON admin_organizations
+			FOR EACH ROW EXECUTE FUNCTION app_check_org_hierarchy();`,
+		// Typed organization links (models.OrgLinkType): only Partners manage stores and only
+		// Manufacturers own products
+		`CREATE OR REPLACE FUNCTION app_check_org_link_type() RETURNS trigger LANGUAGE plpgsql AS $$
+		DECLARE
+			linked_org UUID;
+			wanted TEXT;
+			actual TEXT;
+		BEGIN
+			IF TG_TABLE_NAME = 'admin_store_partners' THEN
+				linked_org := NEW.partner_org_id;
+				wanted := 'Partner';
+			ELSE
+				linked_org := NEW.owner_org_id;
+				wanted := 'Manufacturer';
+			END IF;
+			IF linked_org IS NULL THEN
+				RETURN NEW;
+			END IF;
+			SELECT org_type::text INTO actual FROM admin_organizations WHERE org_id = linked_org;
+			IF actual IS NOT NULL AND actual <> wanted THEN
+				RAISE EXCEPTION '% links need a % organization, not a %', TG_TABLE_NAME, wanted, actual
+					USING ERRCODE = 'check_violation', CONSTRAINT = 'organizations_link_type';
+			END IF;
+			RETURN NEW;
+		END
+		$$;`,
+		`DROP TRIGGER IF EXISTS trg_admin_store_partners_link_type ON admin_store_partners;`,
+		`CREATE TRIGGER trg_admin_store_partners_link_type BEFORE INSERT OR UPDATE OF partner_org_id ON admin_store_partners
+			FOR EACH ROW EXECUTE FUNCTION app_check_org_link_type();`,
+		`DROP TRIGGER IF EXISTS trg_admin_products_owner_link_type ON admin_products;`,
+		`CREATE TRIGGER trg_admin_products_owner_link_type BEFORE INSERT OR UPDATE OF owner_org_id ON admin_products
+			FOR EACH ROW EXECUTE FUNCTION app_check_org_link_type();`,
+		// Entry QR code of a store; provisioning a new one bumps qr_version and revokes the earlier codes
+		`ALTER TABLE admin_stores ADD COLUMN IF NOT EXISTS qr_version INTEGER NOT NULL DEFAULT 0,
+			ADD COLUMN IF NOT EXISTS qr_payload TEXT, ADD COLUMN IF NOT EXISTS qr_generated_at TIMESTAMPTZ;`,
+		// Product change log behind GET /products/changes. Writes to a product or the rows the apps show
+		// with it are logged with the writing transaction, which orders the feed; moving a product to
+		// another store is logged for both stores. The purge job records how far it has deleted.
+		`CREATE TABLE IF NOT EXISTS app_product_changes (
+			change_id BIGSERIAL PRIMARY KEY,
+			txid BIGINT NOT NULL DEFAULT txid_current(),
+			product_id INTEGER NOT NULL,
+			store_id INTEGER,
+			created BOOLEAN NOT NULL DEFAULT false,
+			changed_at TIMESTAMPTZ NOT NULL DEFAULT now()
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_product_changes_txid ON app_product_changes(txid, change_id);`,
+		`CREATE INDEX IF NOT EXISTS idx_product_changes_store ON app_product_changes(store_id, txid, change_id);`,
+		`CREATE INDEX IF NOT EXISTS idx_product_changes_changed_at ON app_product_changes(changed_at);`,
+		`CREATE TABLE IF NOT EXISTS app_product_change_log_state (
+			id BOOLEAN PRIMARY KEY DEFAULT true CHECK (id),
+			purged_through_txid BIGINT NOT NULL DEFAULT 0
+		);`,
+		`INSERT INTO app_product_change_log_state (id) VALUES (true) ON CONFLICT DO NOTHING;`,
+		`CREATE OR REPLACE FUNCTION app_log_product_change() RETURNS trigger
+		LANGUAGE plpgsql AS $$
+		BEGIN
+			IF TG_OP = 'DELETE' THEN
+				INSERT INTO app_product_changes (product_id, store_id) VALUES (OLD.product_id, OLD.store_id);
+				RETURN NULL;
+			END IF;
+			INSERT INTO app_product_changes (product_id, store_id, created) VALUES (NEW.product_id, NEW.store_id, TG_OP = 'INSERT');
+			IF TG_OP = 'UPDATE' AND OLD.store_id IS DISTINCT FROM NEW.store_id THEN
+				INSERT INTO app_product_changes (product_id, store_id) VALUES (OLD.product_id, OLD.store_id);
+			END IF;
+			RETURN NULL;
+		END
+		$$;`,
+		`DROP TRIGGER IF EXISTS trg_admin_products_changes ON admin_products;`,
+		`CREATE TRIGGER trg_admin_products_changes AFTER INSERT OR DELETE ON admin_products
+			FOR EACH ROW EXECUTE FUNCTION app_log_product_change();`,
+		`DROP TRIGGER IF EXISTS trg_admin_products_changes_update ON admin_products;`,
+		`CREATE TRIGGER trg_admin_products_changes_update AFTER UPDATE ON admin_products
+			FOR EACH ROW WHEN (OLD.* IS DISTINCT FROM NEW.*) EXECUTE FUNCTION app_log_product_change();`,
+		// Rows deleted along with their product find no product and log nothing more
+		`CREATE OR REPLACE FUNCTION app_log_product_child_change() RETURNS trigger
+		LANGUAGE plpgsql AS $$
+		DECLARE
+			pid INTEGER;
+		BEGIN
+			IF TG_OP = 'DELETE' THEN
+				pid := OLD.product_id;
+			ELSE
+				pid := NEW.product_id;
+			END IF;
+			INSERT INTO app_product_changes (product_id, store_id)
+			SELECT product_id, store_id FROM admin_products WHERE product_id = pid;
+			RETURN NULL;
+		END
+		$$;`,
+		`DO $$
+		DECLARE
+			t TEXT;
+		BEGIN
+			FOREACH t IN ARRAY ARRAY['admin_product_images', 'admin_product_category_mapping', 'admin_product_subcategory_mapping',
+				'app_product_variants', 'app_product_translations', 'app_product_region_prices']
+			LOOP
+				EXECUTE format('DROP TRIGGER IF EXISTS trg_%s_product_changes ON %I', t, t);
+				EXECUTE format('CREATE TRIGGER trg_%s_product_changes AFTER INSERT OR UPDATE OR DELETE ON %I
+					FOR EACH ROW EXECUTE FUNCTION app_log_product_child_change()', t, t);
+			END LOOP;
+		END
+		$$;`,
+	}
+
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("begin schema tx: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	for _, s := range stmts {
+		if _, err := tx.Exec(ctx, s); err != nil {
+			return fmt.Errorf("exec schema: %w", err)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("commit schema: %w", err)
+	}
+
+	log.Println("[CATALOG-DB] Auxiliary schema verified")
+	return nil
+}
+
+// InitGeoSchema enables PostGIS and maintains admin_stores.geog, a geography point generated from
+// latitude/longitude with a GiST index for radius and nearest-store queries. Kept apart from InitSchema
+// because CREATE EXTENSION needs privileges the auxiliary tables do not.
+func (db *Database) InitGeoSchema(ctx context.Context) error {
+	stmts := []string{
+		`CREATE EXTENSION IF NOT EXISTS postgis;`,
+		`ALTER TABLE admin_stores ADD COLUMN IF NOT EXISTS geog geography(Point, 4326)
+			GENERATED ALWAYS AS (ST_SetSRID(ST_MakePoint(longitude, latitude), 4326)::geography) STORED;`,
+		`CREATE INDEX IF NOT EXISTS idx_admin_stores_geog ON admin_stores USING GIST (geog);`,
+	}
+	for _, s := range stmts {
+		if _, err := db.Pool.Exec(ctx, s); err != nil {
+			return fmt.Errorf("exec geo schema: %w", err)
+		}
+	}
+	log.Println("[CATALOG-DB] PostGIS store locations verified")
+	return nil
+}
+
+// InitSearchSchema enables pg_trgm and indexes product titles, SKUs and category names so the
+// suggestion endpoint's LIKE matches use an index. Without it suggestions still work, just slower.
+func (db *Database) InitSearchSchema(ctx context.Context) error {
+	stmts := []string{
+		`CREATE EXTENSION IF NOT EXISTS pg_trgm;`,
+		`CREATE INDEX IF NOT EXISTS idx_admin_products_title_trgm ON admin_products USING GIN (lower(title) gin_trgm_ops);`,
+		`CREATE INDEX IF NOT EXISTS idx_admin_products_sku_trgm ON admin_products USING GIN (lower(sku) gin_trgm_ops);`,
+		`CREATE INDEX IF NOT EXISTS idx_admin_product_categories_name_trgm ON admin_product_categories USING GIN (lower(name) gin_trgm_ops);`,
+	}
+	for _, s := range stmts {
+		if _, err := db.Pool.Exec(ctx, s); err != nil {
+			return fmt.Errorf("exec search schema: %w", err)
+		}
+	}
+	db.trigramSearch = true
+	log.Println("[CATALOG-DB] Search suggestion indexes verified")
+	return nil
+}
@@ -0,0 +1,113 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/expotoworld/expotoworld/backend/catalog-service/internal/models"
+	"github.com/jackc/pgx/v5"
+)
+
+// ShelfCodeOutsideLayoutError is returned when a shelf code names no slot of the store's layout
+type ShelfCodeOutsideLayoutError struct {
+	Reason string
+}
+
+func (e *ShelfCodeOutsideLayoutError) Error() string {
+	return "shelf code does not fit the store's shelf layout: " + e.Reason
+}
+
+// GetStoreShelfLayout returns a store's shelf layout, nil when none is configured
+func (db *Database) GetStoreShelfLayout(ctx context.Context, storeID int) (*models.ShelfLayout, error) {
+	return storeShelfLayout(ctx, db.Pool, storeID)
+}
+
+func storeShelfLayout(ctx context.Context, q rowQuerier, storeID int) (*models.ShelfLayout, error) {
+	var raw []byte
+	err := q.QueryRow(ctx, `SELECT shelf_layout FROM admin_stores WHERE store_id = $1`, storeID).Scan(&raw)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrStoreNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if raw == nil {
+		return nil, nil
+	}
+	var layout models.ShelfLayout
+	if err := json.Unmarshal(raw, &layout); err != nil {
+		return nil, fmt.Errorf("decode shelf layout of store %d: %w", storeID, err)
+	}
+	return &layout, nil
+}
+
+// SetStoreShelfLayout replaces a store's shelf layout; nil removes it
+func (db *Database) SetStoreShelfLayout(ctx context.Context, storeID int, layout *models.ShelfLayout) error {
+	var raw []byte
+	if layout != nil {
+		var err error
+		if raw, err = json.Marshal(layout); err != nil {
+			return err
+		}
+	}
+	tag, err := db.Pool.Exec(ctx, `UPDATE admin_stores SET shelf_layout = $2, updated_at = CURRENT_TIMESTAMP WHERE store_id = $1`, storeID, raw)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrStoreNotFound
+	}
+	return nil
+}
+
+// CanonicalShelfCode checks a shelf code against the store's layout and returns it in canonical form
+// ("a-02-5" becomes "A-2-5"). Without a layout any code is accepted unchanged; a code that doesn't fit
+// returns *ShelfCodeOutsideLayoutError.
+func (db *Database) CanonicalShelfCode(ctx context.Context, storeID int, shelfCode string) (string, error) {
+	return canonicalShelfCode(ctx, db.Pool, storeID, shelfCode)
+}
+
+func canonicalShelfCode(ctx context.Context, q rowQuerier, storeID int, shelfCode string) (string, error) {
+	layout, err := storeShelfLayout(ctx, q, storeID)
+	if err != nil || layout == nil {
+		return shelfCode, err
+	}
+	ref, issue := layout.Locate(shelfCode)
+	if issue != "" {
+		return "", &ShelfCodeOutsideLayoutError{Reason: issue}
+	}
+	return ref.String(), nil
+}
+
+// ListStoreShelfAssignments lists the shelf codes used by a store's products and their variants,
+// ordered by shelf code. Trashed products are left out.
+func (db *Database) ListStoreShelfAssignments(ctx context.Context, storeID int) ([]models.ShelfAssignment, error) {
+	rows, err := db.Pool.Query(ctx, `
+        SELECT p.shelf_code, p.product_id, NULL::int, COALESCE(p.sku, ''), COALESCE(p.title, ''), p.stock_left, COALESCE(p.is_active, false)
+        FROM admin_products p
+        WHERE p.store_id = $1 AND p.shelf_code IS NOT NULL AND p.shelf_code <> '' AND p.trashed_at IS NULL
+        UNION ALL
+        SELECT v.shelf_code, v.product_id, v.variant_id, v.sku, COALESCE(p.title, '') || CASE WHEN v.name <> '' THEN ' - ' || v.name ELSE '' END,
+            v.stock_left, v.is_active AND COALESCE(p.is_active, false)
+        FROM app_product_variants v
+        JOIN admin_products p ON p.product_id = v.product_id
+        WHERE p.store_id = $1 AND v.shelf_code IS NOT NULL AND v.shelf_code <> '' AND p.trashed_at IS NULL
+        ORDER BY 1, 2, 3 NULLS FIRST
+    `, storeID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query shelf assignments: %w", err)
+	}
+	defer rows.Close()
+
+	out := []models.ShelfAssignment{}
+	for rows.Next() {
+		var a models.ShelfAssignment
+		if err := rows.Scan(&a.ShelfCode, &a.ProductID, &a.VariantID, &a.SKU, &a.Title, &a.StockLeft, &a.IsActive); err != nil {
+			return nil, fmt.Errorf("failed to scan shelf assignment: %w", err)
+		}
+		out = append(out, a)
+	}
+	return out, rows.Err()
+}
@@ -0,0 +1,94 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/expotoworld/expotoworld/backend/catalog-service/internal/models"
+	"github.com/jackc/pgx/v5"
+)
+
+// ErrInsufficientStock is returned when a decrement would take stock_left below zero
+var ErrInsufficientStock = errors.New("insufficient stock")
+
+const stockMovementColumns = `movement_id, product_id, delta, stock_before, stock_after, reason, COALESCE(note, ''), COALESCE(actor, ''), created_at`
+
+func scanStockMovement(row pgx.Row) (*models.StockMovement, error) {
+	var m models.StockMovement
+	if err := row.Scan(&m.ID, &m.ProductID, &m.Delta, &m.StockBefore, &m.StockAfter, &m.Reason, &m.Note, &m.Actor, &m.CreatedAt); err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+// AdjustProductStock atomically adds delta to a product's stock_left and returns the recorded movement.
+// Decrements below zero fail with ErrInsufficientStock; a missing product with ErrProductNotFound.
+func (db *Database) AdjustProductStock(ctx context.Context, productID int, req models.StockAdjustmentRequest, actor string) (*models.StockMovement, error) {
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	// Picked up by the app_log_stock_movement trigger; local to this transaction
+	if _, err := tx.Exec(ctx, `SELECT set_config('app.stock_reason', $1, true), set_config('app.stock_actor', $2, true), set_config('app.stock_note', $3, true)`,
+		string(req.Reason), actor, req.Note); err != nil {
+		return nil, fmt.Errorf("failed to set stock audit context: %w", err)
+	}
+
+	tag, err := tx.Exec(ctx, `
+		UPDATE admin_products
+		SET stock_left = COALESCE(stock_left, 0) + $2, updated_at = CURRENT_TIMESTAMP
+		WHERE product_id = $1 AND COALESCE(stock_left, 0) + $2 >= 0
+	`, productID, req.Delta)
+	if err != nil {
+		return nil, fmt.Errorf("failed to adjust stock: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		var exists bool
+		if err := tx.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM admin_products WHERE product_id = $1)`, productID).Scan(&exists); err != nil {
+			return nil, err
+		}
+		if !exists {
+			return nil, ErrProductNotFound
+		}
+		return nil, ErrInsufficientStock
+	}
+
+	// The row stays locked until commit, so the latest movement is the one just recorded
+	m, err := scanStockMovement(tx.QueryRow(ctx, `SELECT `+stockMovementColumns+` FROM app_stock_movements
+		WHERE product_id = $1 ORDER BY movement_id DESC LIMIT 1`, productID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read stock movement: %w", err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit stock adjustment: %w", err)
+	}
+	return m, nil
+}
+
+// ListStockMovements returns a product's stock history, newest first. beforeID pages backwards (0 = latest).
+func (db *Database) ListStockMovements(ctx context.Context, productID int, beforeID int64, limit int) ([]models.StockMovement, error) {
+	rows, err := db.Pool.Query(ctx, `
+		SELECT `+stockMovementColumns+`
+		FROM app_stock_movements
+		WHERE product_id = $1 AND ($2 = 0 OR movement_id < $2)
+		ORDER BY movement_id DESC
+		LIMIT $3
+	`, productID, beforeID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := []models.StockMovement{}
+	for rows.Next() {
+		m, err := scanStockMovement(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *m)
+	}
+	return out, rows.Err()
+}
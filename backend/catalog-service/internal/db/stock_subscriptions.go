@@ -0,0 +1,183 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/expotoworld/expotoworld/backend/catalog-service/internal/models"
+	"github.com/jackc/pgx/v5"
+)
+
+// ErrProductNotFound is returned when a product id/uuid does not resolve to an active product
+var ErrProductNotFound = errors.New("product not found")
+
+// ResolveActiveProduct maps an integer id or product_uuid to the product id and its current stock
+func (db *Database) ResolveActiveProduct(ctx context.Context, idOrUUID string) (int, int, error) {
+	where := "p.product_uuid::text = $1"
+	var arg interface{} = idOrUUID
+	if id, err := strconv.Atoi(idOrUUID); err == nil {
+		where, arg = "p.product_id = $1", id
+	}
+	var productID, stock int
+	err := db.Pool.QueryRow(ctx, `SELECT p.product_id, COALESCE(p.stock_left, 0) FROM admin_products p
+		WHERE `+where+` AND p.is_active = true`, arg).Scan(&productID, &stock)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, 0, ErrProductNotFound
+	}
+	return productID, stock, err
+}
+
+// SubscribeStock registers a back-in-stock subscription; created is false if it already existed
+func (db *Database) SubscribeStock(ctx context.Context, productID int, userID, miniAppType string) (bool, error) {
+	tag, err := db.Pool.Exec(ctx, `
+		INSERT INTO app_product_stock_subscriptions (product_id, user_id, mini_app_type)
+		VALUES ($1, $2, NULLIF($3, ''))
+		ON CONFLICT (product_id, user_id) DO NOTHING
+	`, productID, userID, miniAppType)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+// UnsubscribeStock removes a subscription; removed is false if there was none
+func (db *Database) UnsubscribeStock(ctx context.Context, productID int, userID string) (bool, error) {
+	tag, err := db.Pool.Exec(ctx, `DELETE FROM app_product_stock_subscriptions WHERE product_id = $1 AND user_id = $2`, productID, userID)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+// ListUserStockSubscriptions returns a user's pending subscriptions, newest first
+func (db *Database) ListUserStockSubscriptions(ctx context.Context, userID string) ([]models.StockSubscription, error) {
+	rows, err := db.Pool.Query(ctx, `
+		SELECT s.product_id, p.product_uuid, COALESCE(p.title, ''), COALESCE(p.stock_left, 0), COALESCE(s.mini_app_type, ''), s.created_at
+		FROM app_product_stock_subscriptions s
+		JOIN admin_products p ON p.product_id = s.product_id
+		WHERE s.user_id = $1
+		ORDER BY s.created_at DESC
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	subs := make([]models.StockSubscription, 0)
+	for rows.Next() {
+		var s models.StockSubscription
+		if err := rows.Scan(&s.ProductID, &s.ProductUUID, &s.Title, &s.StockLeft, &s.MiniAppType, &s.CreatedAt); err != nil {
+			return nil, err
+		}
+		s.UserID = userID
+		subs = append(subs, s)
+	}
+	return subs, rows.Err()
+}
+
+// ProcessNextStockEvent claims the oldest unprocessed stock event and, for a back-in-stock transition of a
+// product that is still in stock, calls notify for every subscription made before the transition. Notified
+// subscriptions are deleted; the rest stay for the next attempt. The event is marked processed once every
+// subscriber was notified or it has failed maxAttempts times. ok is false when there was nothing to do.
+func (db *Database) ProcessNextStockEvent(ctx context.Context, maxAttempts int, notify func(context.Context, models.StockEvent, models.StockSubscription) error) (bool, error) {
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback(ctx)
+
+	var ev models.StockEvent
+	err = tx.QueryRow(ctx, `
+		SELECT e.event_id, e.product_id, p.product_uuid, COALESCE(p.title, ''), COALESCE(p.mini_app_type::text, ''),
+			(SELECT i.image_url FROM admin_product_images i WHERE i.product_id = p.product_id
+				ORDER BY i.is_primary DESC, i.display_order, i.image_id LIMIT 1),
+			e.event_type, COALESCE(p.stock_left, 0), e.attempts, e.occurred_at
+		FROM app_product_stock_events e
+		JOIN admin_products p ON p.product_id = e.product_id
+		WHERE e.processed_at IS NULL
+		ORDER BY e.attempts, e.event_id
+		LIMIT 1
+		FOR UPDATE OF e SKIP LOCKED
+	`).Scan(&ev.EventID, &ev.ProductID, &ev.ProductUUID, &ev.Title, &ev.MiniAppType, &ev.ImageURL,
+		&ev.EventType, &ev.StockLeft, &ev.Attempts, &ev.OccurredAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		// Events of deleted products can never be delivered
+		_, err = tx.Exec(ctx, `UPDATE app_product_stock_events e SET processed_at = now()
+			WHERE e.processed_at IS NULL AND NOT EXISTS (SELECT 1 FROM admin_products p WHERE p.product_id = e.product_id)`)
+		if err != nil {
+			return false, err
+		}
+		return false, tx.Commit(ctx)
+	}
+	if err != nil {
+		return false, err
+	}
+
+	// Sold out again before we got to it: nothing to announce, subscriptions stay
+	if ev.EventType != "back_in_stock" || ev.StockLeft <= 0 {
+		if _, err := tx.Exec(ctx, `UPDATE app_product_stock_events SET processed_at = now() WHERE event_id = $1`, ev.EventID); err != nil {
+			return false, err
+		}
+		return true, tx.Commit(ctx)
+	}
+
+	rows, err := tx.Query(ctx, `
+		SELECT user_id, COALESCE(mini_app_type, ''), created_at
+		FROM app_product_stock_subscriptions
+		WHERE product_id = $1 AND created_at <= $2
+		ORDER BY created_at
+	`, ev.ProductID, ev.OccurredAt)
+	if err != nil {
+		return false, err
+	}
+	var subs []models.StockSubscription
+	for rows.Next() {
+		s := models.StockSubscription{ProductID: ev.ProductID, ProductUUID: ev.ProductUUID, Title: ev.Title, StockLeft: ev.StockLeft}
+		if err := rows.Scan(&s.UserID, &s.MiniAppType, &s.CreatedAt); err != nil {
+			rows.Close()
+			return false, err
+		}
+		subs = append(subs, s)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return false, err
+	}
+
+	var failed int
+	var lastErr error
+	for _, s := range subs {
+		if err := notify(ctx, ev, s); err != nil {
+			failed++
+			lastErr = err
+			continue
+		}
+		if _, err := tx.Exec(ctx, `DELETE FROM app_product_stock_subscriptions WHERE product_id = $1 AND user_id = $2`, s.ProductID, s.UserID); err != nil {
+			return false, err
+		}
+	}
+
+	if failed == 0 {
+		_, err = tx.Exec(ctx, `UPDATE app_product_stock_events SET processed_at = now(), last_error = NULL WHERE event_id = $1`, ev.EventID)
+	} else {
+		msg := fmt.Sprintf("%d of %d notifications failed: %v", failed, len(subs), lastErr)
+		_, err = tx.Exec(ctx, `
+			UPDATE app_product_stock_events
+			SET attempts = attempts + 1, last_error = $2,
+				processed_at = CASE WHEN attempts + 1 >= $3 THEN now() END
+			WHERE event_id = $1
+		`, ev.EventID, msg, maxAttempts)
+	}
+	if err != nil {
+		return false, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return false, err
+	}
+	if failed > 0 {
+		return true, fmt.Errorf("stock event %d: %w", ev.EventID, lastErr)
+	}
+	return true, nil
+}
@@ -0,0 +1,74 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/expotoworld/expotoworld/backend/catalog-service/internal/models"
+	"github.com/jackc/pgx/v5"
+)
+
+// RotateStoreQR provisions the next QR code version of a store; sign builds the payload of the new
+// version. Codes of earlier versions stop resolving.
+func (db *Database) RotateStoreQR(ctx context.Context, storeID int, sign func(qr models.StoreQR) string) (*models.StoreQR, error) {
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	qr := models.StoreQR{StoreID: storeID}
+	err = tx.QueryRow(ctx, `
+        SELECT type, qr_version FROM admin_stores WHERE store_id = $1 AND trashed_at IS NULL FOR UPDATE
+    `, storeID).Scan(&qr.StoreType, &qr.Version)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrStoreNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock store %d: %w", storeID, err)
+	}
+	qr.Version++
+	qr.MiniAppType = models.MiniAppTypeForStore(qr.StoreType)
+	qr.Payload = sign(qr)
+
+	err = tx.QueryRow(ctx, `
+        UPDATE admin_stores SET qr_version = $2, qr_payload = $3, qr_generated_at = now()
+        WHERE store_id = $1
+        RETURNING qr_generated_at
+    `, storeID, qr.Version, qr.Payload).Scan(&qr.GeneratedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to store QR code of store %d: %w", storeID, err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return &qr, nil
+}
+
+// GetStoreQR returns the current QR code of a store, or nil if none was provisioned yet
+func (db *Database) GetStoreQR(ctx context.Context, storeID int) (*models.StoreQR, error) {
+	qr := models.StoreQR{StoreID: storeID}
+	var (
+		payload     *string
+		generatedAt *time.Time
+	)
+	err := db.Pool.QueryRow(ctx, `
+        SELECT type, qr_version, qr_payload, qr_generated_at
+        FROM admin_stores WHERE store_id = $1 AND trashed_at IS NULL
+    `, storeID).Scan(&qr.StoreType, &qr.Version, &payload, &generatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrStoreNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load QR code of store %d: %w", storeID, err)
+	}
+	if payload == nil || generatedAt == nil {
+		return nil, nil
+	}
+	qr.MiniAppType = models.MiniAppTypeForStore(qr.StoreType)
+	qr.Payload = *payload
+	qr.GeneratedAt = *generatedAt
+	return &qr, nil
+}
@@ -0,0 +1,102 @@
+package db
+
+import (
+	"context"
+	"strings"
+
+	"github.com/expotoworld/expotoworld/backend/catalog-service/internal/models"
+)
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// GetSuggestions returns active products (by title or SKU) and categories matching the start of q.
+// Whole-text prefixes rank before matches at the start of a later word; shorter texts come first.
+func (db *Database) GetSuggestions(ctx context.Context, q, miniAppType string, limit int) ([]models.Suggestion, error) {
+	prefix := likeEscaper.Replace(strings.ToLower(q))
+	rows, err := db.Pool.Query(ctx, `
+		WITH matches AS (
+			SELECT 'category' AS kind, c.name AS text, NULL::int AS product_id, NULL::text AS product_uuid, c.category_id,
+				CASE WHEN lower(c.name) LIKE $1 || '%' THEN 0 ELSE 3 END AS rank
+			FROM admin_product_categories c
+			WHERE c.is_active = true AND ($2 = '' OR $2 = ANY(c.mini_app_association))
+				AND (lower(c.name) LIKE $1 || '%' OR lower(c.name) LIKE '% ' || $1 || '%')
+			UNION ALL
+			SELECT 'product', p.title, p.product_id, p.product_uuid::text, NULL,
+				CASE WHEN lower(p.title) LIKE $1 || '%' THEN 1 ELSE 4 END
+			FROM admin_products p
+			WHERE p.is_active = true AND ($2 = '' OR p.mini_app_type::text = $2)
+				AND (lower(p.title) LIKE $1 || '%' OR lower(p.title) LIKE '% ' || $1 || '%')
+			UNION ALL
+			SELECT 'sku', p.sku, p.product_id, p.product_uuid::text, NULL, 2
+			FROM admin_products p
+			WHERE p.is_active = true AND ($2 = '' OR p.mini_app_type::text = $2)
+				AND lower(p.sku) LIKE $1 || '%'
+		)
+		SELECT kind, text, product_id, product_uuid, category_id
+		FROM matches
+		ORDER BY rank, length(text), text
+		LIMIT $3
+	`, prefix, miniAppType, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make([]models.Suggestion, 0, limit)
+	for rows.Next() {
+		var s models.Suggestion
+		if err := rows.Scan(&s.Kind, &s.Text, &s.ProductID, &s.ProductUUID, &s.CategoryID); err != nil {
+			return nil, err
+		}
+		s.Match = models.SuggestionMatchPrefix
+		out = append(out, s)
+	}
+	return out, rows.Err()
+}
+
+// FuzzySuggestionsAvailable reports whether pg_trgm is installed for GetFuzzySuggestions
+func (db *Database) FuzzySuggestionsAvailable() bool {
+	return db.trigramSearch
+}
+
+// GetFuzzySuggestions returns active products whose title (a word of it) or SKU is similar to q by
+// trigrams, so typos still find them. excludeProductIDs were already suggested by GetSuggestions.
+// Best matches come first, one suggestion per product.
+func (db *Database) GetFuzzySuggestions(ctx context.Context, q, miniAppType string, excludeProductIDs []int, limit int) ([]models.Suggestion, error) {
+	if excludeProductIDs == nil {
+		excludeProductIDs = []int{}
+	}
+	rows, err := db.Pool.Query(ctx, `
+		WITH matches AS (
+			SELECT 'product' AS kind, p.title AS text, p.product_id, p.product_uuid::text AS product_uuid,
+				word_similarity($1, lower(p.title)) AS score
+			FROM admin_products p
+			WHERE p.is_active = true AND ($2 = '' OR p.mini_app_type::text = $2) AND NOT (p.product_id = ANY($3::int[]))
+				AND $1 <% lower(p.title)
+			UNION ALL
+			SELECT 'sku', p.sku, p.product_id, p.product_uuid::text, similarity($1, lower(p.sku))
+			FROM admin_products p
+			WHERE p.is_active = true AND ($2 = '' OR p.mini_app_type::text = $2) AND NOT (p.product_id = ANY($3::int[]))
+				AND lower(p.sku) % $1
+		)
+		SELECT kind, text, product_id, product_uuid
+		FROM (SELECT DISTINCT ON (product_id) * FROM matches ORDER BY product_id, score DESC) best
+		ORDER BY score DESC, length(text), text
+		LIMIT $4
+	`, strings.ToLower(q), miniAppType, excludeProductIDs, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make([]models.Suggestion, 0, limit)
+	for rows.Next() {
+		var s models.Suggestion
+		if err := rows.Scan(&s.Kind, &s.Text, &s.ProductID, &s.ProductUUID); err != nil {
+			return nil, err
+		}
+		s.Match = models.SuggestionMatchFuzzy
+		out = append(out, s)
+	}
+	return out, rows.Err()
+}
@@ -0,0 +1,108 @@
+package db
+
+import (
+	"context"
+	"errors"
+
+	"github.com/expotoworld/expotoworld/backend/catalog-service/internal/models"
+	"github.com/jackc/pgx/v5"
+)
+
+// ErrRegionNotFound is returned when a tax rate refers to a region that doesn't exist
+var ErrRegionNotFound = errors.New("region not found")
+
+// ListRegionTaxRates returns the tax matrix, optionally for a single region
+func (db *Database) ListRegionTaxRates(ctx context.Context, regionID *int) ([]models.RegionTaxRate, error) {
+	rows, err := db.Pool.Query(ctx, `
+		SELECT t.region_id, COALESCE(r.name, ''), t.tax_class, t.rate_pct::float8, COALESCE(t.updated_by, ''), t.updated_at
+		FROM app_region_tax_rates t
+		LEFT JOIN admin_regions r ON r.region_id = t.region_id
+		WHERE ($1::int IS NULL OR t.region_id = $1)
+		ORDER BY r.name, t.region_id, t.tax_class
+	`, regionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := []models.RegionTaxRate{}
+	for rows.Next() {
+		var t models.RegionTaxRate
+		if err := rows.Scan(&t.RegionID, &t.RegionName, &t.TaxClass, &t.RatePct, &t.UpdatedBy, &t.UpdatedAt); err != nil {
+			return nil, err
+		}
+		out = append(out, t)
+	}
+	return out, rows.Err()
+}
+
+// SetRegionTaxRate creates or replaces the rate of a tax class in a region
+func (db *Database) SetRegionTaxRate(ctx context.Context, t *models.RegionTaxRate) (*models.RegionTaxRate, error) {
+	err := db.Pool.QueryRow(ctx, `
+		INSERT INTO app_region_tax_rates (region_id, tax_class, rate_pct, updated_by)
+		SELECT r.region_id, $2, $3, NULLIF($4, '') FROM admin_regions r WHERE r.region_id = $1
+		ON CONFLICT (region_id, tax_class) DO UPDATE
+		SET rate_pct = EXCLUDED.rate_pct, updated_by = EXCLUDED.updated_by, updated_at = now()
+		RETURNING rate_pct::float8, updated_at
+	`, t.RegionID, t.TaxClass, t.RatePct, t.UpdatedBy).Scan(&t.RatePct, &t.UpdatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrRegionNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// DeleteRegionTaxRate removes a cell of the tax matrix; pgx.ErrNoRows if it wasn't set
+func (db *Database) DeleteRegionTaxRate(ctx context.Context, regionID int, class models.TaxClass) error {
+	tag, err := db.Pool.Exec(ctx, `DELETE FROM app_region_tax_rates WHERE region_id = $1 AND tax_class = $2`, regionID, class)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
+
+// ResolveTaxRate returns the tax class and rate for a product sold in a region (the product's store
+// region when regionID is nil). pgx.ErrNoRows if the product doesn't exist.
+func (db *Database) ResolveTaxRate(ctx context.Context, productID int, regionID *int) (*models.ResolvedTaxRate, error) {
+	res := &models.ResolvedTaxRate{ProductID: productID}
+	err := db.Pool.QueryRow(ctx, `
+		SELECT COALESCE($2::int, s.region_id), p.tax_class, t.rate_pct::float8
+		FROM admin_products p
+		LEFT JOIN admin_stores s ON s.store_id = p.store_id
+		LEFT JOIN app_region_tax_rates t ON t.region_id = COALESCE($2::int, s.region_id) AND t.tax_class = p.tax_class
+		WHERE p.product_id = $1
+	`, productID, regionID).Scan(&res.RegionID, &res.TaxClass, &res.RatePct)
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// ListUnclassifiedProducts returns active products without a tax class; they can't be sold until one is set
+func (db *Database) ListUnclassifiedProducts(ctx context.Context) ([]models.UnclassifiedProduct, error) {
+	rows, err := db.Pool.Query(ctx, `
+		SELECT product_id, product_uuid, COALESCE(sku, ''), COALESCE(title, ''), store_id
+		FROM admin_products
+		WHERE is_active = true AND tax_class IS NULL
+		ORDER BY product_id
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := []models.UnclassifiedProduct{}
+	for rows.Next() {
+		var p models.UnclassifiedProduct
+		if err := rows.Scan(&p.ProductID, &p.UUID, &p.SKU, &p.Title, &p.StoreID); err != nil {
+			return nil, err
+		}
+		out = append(out, p)
+	}
+	return out, rows.Err()
+}
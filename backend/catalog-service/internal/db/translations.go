@@ -0,0 +1,89 @@
+package db
+
+import (
+	"context"
+
+	"github.com/expotoworld/expotoworld/backend/catalog-service/internal/models"
+)
+
+// GetProductTranslations returns all translations of a product ordered by locale
+func (db *Database) GetProductTranslations(ctx context.Context, productID int) ([]models.ProductTranslation, error) {
+	rows, err := db.Pool.Query(ctx, `
+		SELECT product_id, locale, title, description, status, source_locale, provider, reviewed_by, reviewed_at, created_at, updated_at
+		FROM app_product_translations
+		WHERE product_id = $1
+		ORDER BY locale
+	`, productID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make([]models.ProductTranslation, 0)
+	for rows.Next() {
+		var t models.ProductTranslation
+		if err := rows.Scan(&t.ProductID, &t.Locale, &t.Title, &t.Description, &t.Status, &t.SourceLocale, &t.Provider,
+			&t.ReviewedBy, &t.ReviewedAt, &t.CreatedAt, &t.UpdatedAt); err != nil {
+			return nil, err
+		}
+		t.NeedsReview = t.Status == models.TranslationStatusMachineDraft
+		out = append(out, t)
+	}
+	return out, rows.Err()
+}
+
+// GetProductText returns the title and description stored on the product itself
+func (db *Database) GetProductText(ctx context.Context, productID int) (string, string, error) {
+	var title, description string
+	err := db.Pool.QueryRow(ctx, `SELECT COALESCE(title, ''), COALESCE(description, '') FROM admin_products WHERE product_id = $1`,
+		productID).Scan(&title, &description)
+	return title, description, err
+}
+
+// SaveSourceTranslation records the locale a product was authored in
+func (db *Database) SaveSourceTranslation(ctx context.Context, productID int, locale, title, description string) error {
+	_, err := db.Pool.Exec(ctx, `
+		INSERT INTO app_product_translations (product_id, locale, title, description, status)
+		VALUES ($1, $2, $3, $4, 'source')
+		ON CONFLICT (product_id, locale) DO UPDATE
+		SET title = EXCLUDED.title, description = EXCLUDED.description, status = 'source',
+			source_locale = NULL, provider = NULL, updated_at = now()
+	`, productID, locale, title, description)
+	return err
+}
+
+// SaveMachineDraft stores a machine translation for review. Existing source or confirmed translations
+// are never overwritten; saved is false in that case.
+func (db *Database) SaveMachineDraft(ctx context.Context, productID int, locale, sourceLocale, provider, title, description string) (bool, error) {
+	tag, err := db.Pool.Exec(ctx, `
+		INSERT INTO app_product_translations (product_id, locale, title, description, status, source_locale, provider)
+		VALUES ($1, $2, $3, $4, 'machine_draft', $5, $6)
+		ON CONFLICT (product_id, locale) DO UPDATE
+		SET title = EXCLUDED.title, description = EXCLUDED.description, source_locale = EXCLUDED.source_locale,
+			provider = EXCLUDED.provider, updated_at = now()
+		WHERE app_product_translations.status = 'machine_draft'
+	`, productID, locale, title, description, sourceLocale, provider)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+// ConfirmTranslation saves a reviewed translation, replacing any machine draft for the locale
+func (db *Database) ConfirmTranslation(ctx context.Context, productID int, locale, title, description, reviewerID string) (*models.ProductTranslation, error) {
+	var t models.ProductTranslation
+	err := db.Pool.QueryRow(ctx, `
+		INSERT INTO app_product_translations (product_id, locale, title, description, status, reviewed_by, reviewed_at)
+		VALUES ($1, $2, $3, $4, 'confirmed', NULLIF($5, ''), now())
+		ON CONFLICT (product_id, locale) DO UPDATE
+		SET title = EXCLUDED.title, description = EXCLUDED.description,
+			status = CASE WHEN app_product_translations.status = 'source' THEN 'source' ELSE 'confirmed' END,
+			reviewed_by = EXCLUDED.reviewed_by, reviewed_at = now(), updated_at = now()
+		RETURNING product_id, locale, title, description, status, source_locale, provider, reviewed_by, reviewed_at, created_at, updated_at
+	`, productID, locale, title, description, reviewerID).Scan(&t.ProductID, &t.Locale, &t.Title, &t.Description, &t.Status,
+		&t.SourceLocale, &t.Provider, &t.ReviewedBy, &t.ReviewedAt, &t.CreatedAt, &t.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
@@ -0,0 +1,135 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/expotoworld/expotoworld/backend/catalog-service/internal/models"
+	"github.com/jackc/pgx/v5"
+)
+
+// ErrTrashItemNotFound is returned when the item doesn't exist or isn't in the trash
+var ErrTrashItemNotFound = errors.New("item not found in trash")
+
+// trashTables maps trash kinds to their table, id and name columns
+var trashTables = map[string][3]string{
+	models.TrashProduct:  {"admin_products", "product_id", "COALESCE(title, sku, '')"},
+	models.TrashCategory: {"admin_product_categories", "category_id", "name"},
+	models.TrashStore:    {"admin_stores", "store_id", "name"},
+}
+
+// TrashItem moves a product, category or store to the trash: it is deactivated and kept, with its
+// images, until the purge job removes it. ErrTrashItemNotFound if it doesn't exist or is already trashed.
+func (db *Database) TrashItem(ctx context.Context, kind string, id int, actor string) error {
+	t, ok := trashTables[kind]
+	if !ok {
+		return fmt.Errorf("unknown trash kind %q", kind)
+	}
+	tag, err := db.Pool.Exec(ctx, fmt.Sprintf(`
+        UPDATE %s SET is_active = false, trashed_at = now(), trashed_by = NULLIF($2, ''), updated_at = CURRENT_TIMESTAMP
+        WHERE %s = $1 AND trashed_at IS NULL
+    `, t[0], t[1]), id, actor)
+	if err != nil {
+		return fmt.Errorf("failed to trash %s: %w", kind, err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrTrashItemNotFound
+	}
+	return nil
+}
+
+// RestoreTrashedItem takes an item out of the trash. It stays inactive (like a soft delete) until an
+// admin reactivates it.
+func (db *Database) RestoreTrashedItem(ctx context.Context, kind string, id int) error {
+	t, ok := trashTables[kind]
+	if !ok {
+		return ErrTrashItemNotFound
+	}
+	tag, err := db.Pool.Exec(ctx, fmt.Sprintf(`
+        UPDATE %s SET trashed_at = NULL, trashed_by = NULL, updated_at = CURRENT_TIMESTAMP
+        WHERE %s = $1 AND trashed_at IS NOT NULL
+    `, t[0], t[1]), id)
+	if err != nil {
+		return fmt.Errorf("failed to restore %s: %w", kind, err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrTrashItemNotFound
+	}
+	return nil
+}
+
+// ListTrash lists trashed items, oldest first, with the time the purge job removes them
+func (db *Database) ListTrash(ctx context.Context, retention time.Duration) ([]models.TrashedItem, error) {
+	return db.listTrash(ctx, retention, nil)
+}
+
+// ListTrashDue lists trashed items whose retention has passed
+func (db *Database) ListTrashDue(ctx context.Context, retention time.Duration) ([]models.TrashedItem, error) {
+	cutoff := time.Now().Add(-retention)
+	return db.listTrash(ctx, retention, &cutoff)
+}
+
+func (db *Database) listTrash(ctx context.Context, retention time.Duration, trashedBefore *time.Time) ([]models.TrashedItem, error) {
+	query := ""
+	for _, kind := range []string{models.TrashProduct, models.TrashCategory, models.TrashStore} {
+		t := trashTables[kind]
+		if query != "" {
+			query += " UNION ALL "
+		}
+		query += fmt.Sprintf(`SELECT '%s', %s, %s, trashed_at, trashed_by FROM %s
+            WHERE trashed_at IS NOT NULL AND ($1::timestamptz IS NULL OR trashed_at < $1)`, kind, t[1], t[2], t[0])
+	}
+	rows, err := db.Pool.Query(ctx, query+" ORDER BY 4, 1, 2", trashedBefore)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query trash: %w", err)
+	}
+	defer rows.Close()
+
+	items := []models.TrashedItem{}
+	for rows.Next() {
+		var it models.TrashedItem
+		if err := rows.Scan(&it.Kind, &it.ID, &it.Name, &it.TrashedAt, &it.TrashedBy); err != nil {
+			return nil, fmt.Errorf("failed to scan trash item: %w", err)
+		}
+		it.PurgeAt = it.TrashedAt.Add(retention)
+		items = append(items, it)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating trash: %w", err)
+	}
+	return items, nil
+}
+
+// PurgeTrashedItem permanently deletes a trashed item's rows. ErrTrashItemNotFound if it was
+// restored (or purged) in the meantime; its stored images are left to the caller.
+func (db *Database) PurgeTrashedItem(ctx context.Context, kind string, id int) error {
+	t, ok := trashTables[kind]
+	if !ok {
+		return fmt.Errorf("unknown trash kind %q", kind)
+	}
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	var locked int
+	err = tx.QueryRow(ctx, fmt.Sprintf(`SELECT %[2]s FROM %[1]s WHERE %[2]s = $1 AND trashed_at IS NOT NULL FOR UPDATE`, t[0], t[1]), id).Scan(&locked)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ErrTrashItemNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to lock %s: %w", kind, err)
+	}
+	if kind == models.TrashProduct {
+		err = deleteProductRows(ctx, tx, id)
+	} else {
+		_, err = tx.Exec(ctx, fmt.Sprintf(`DELETE FROM %s WHERE %s = $1`, t[0], t[1]), id)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to purge %s %d: %w", kind, id, err)
+	}
+	return tx.Commit(ctx)
+}
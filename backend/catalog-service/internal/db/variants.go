@@ -0,0 +1,205 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+
+	"github.com/expotoworld/expotoworld/backend/catalog-service/internal/models"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+var (
+	// ErrVariantSKUTaken is returned when a variant SKU is already used by another variant or product
+	ErrVariantSKUTaken = errors.New("sku already in use")
+	// ErrShelfCodeTaken is returned when a shelf code is already used in the product's store
+	ErrShelfCodeTaken = errors.New("shelf code already in use in this store")
+)
+
+const variantColumns = `v.variant_id, v.product_id, v.sku, v.name, v.options, v.main_price::float8, v.strikethrough_price::float8,
+	v.cost_price::float8, v.stock_left, v.shelf_code, v.is_active, v.display_order, v.created_at, v.updated_at`
+
+func scanVariant(row pgx.Row) (*models.ProductVariant, error) {
+	var v models.ProductVariant
+	var options []byte
+	if err := row.Scan(&v.ID, &v.ProductID, &v.SKU, &v.Name, &options, &v.MainPrice, &v.StrikethroughPrice,
+		&v.CostPrice, &v.StockLeft, &v.ShelfCode, &v.IsActive, &v.DisplayOrder, &v.CreatedAt, &v.UpdatedAt); err != nil {
+		return nil, err
+	}
+	v.Options = map[string]string{}
+	if len(options) > 0 {
+		if err := json.Unmarshal(options, &v.Options); err != nil {
+			return nil, err
+		}
+	}
+	return &v, nil
+}
+
+// GetProductVariants returns the variants of the given products keyed by product id, in display order
+func (db *Database) GetProductVariants(ctx context.Context, productIDs []int) (map[int][]models.ProductVariant, error) {
+	out := make(map[int][]models.ProductVariant, len(productIDs))
+	if len(productIDs) == 0 {
+		return out, nil
+	}
+	rows, err := db.Pool.Query(ctx, `
+		SELECT `+variantColumns+`
+		FROM app_product_variants v
+		WHERE v.product_id = ANY($1::int[])
+		ORDER BY v.product_id, v.display_order, v.variant_id
+	`, productIDs)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		v, err := scanVariant(rows)
+		if err != nil {
+			return nil, err
+		}
+		out[v.ProductID] = append(out[v.ProductID], *v)
+	}
+	return out, rows.Err()
+}
+
+// GetProductVariant returns one variant of a product
+func (db *Database) GetProductVariant(ctx context.Context, productID, variantID int) (*models.ProductVariant, error) {
+	return scanVariant(db.Pool.QueryRow(ctx, `SELECT `+variantColumns+` FROM app_product_variants v
+		WHERE v.product_id = $1 AND v.variant_id = $2`, productID, variantID))
+}
+
+// rowQuerier is satisfied by both the pool and a transaction
+type rowQuerier interface {
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+// ShelfCodeTaken reports whether a shelf code is used in a store by another product or variant.
+// excludeProductID / excludeVariantID (0 = none) skip the row being edited.
+func (db *Database) ShelfCodeTaken(ctx context.Context, storeID int, shelfCode string, excludeProductID, excludeVariantID int) (bool, error) {
+	return shelfCodeTaken(ctx, db.Pool, storeID, shelfCode, excludeProductID, excludeVariantID)
+}
+
+func shelfCodeTaken(ctx context.Context, q rowQuerier, storeID int, shelfCode string, excludeProductID, excludeVariantID int) (bool, error) {
+	var taken bool
+	err := q.QueryRow(ctx, `
+		SELECT EXISTS (SELECT 1 FROM admin_products WHERE store_id = $1 AND shelf_code = $2 AND product_id <> $3)
+			OR EXISTS (SELECT 1 FROM app_product_variants v JOIN admin_products p ON p.product_id = v.product_id
+				WHERE p.store_id = $1 AND v.shelf_code = $2 AND v.variant_id <> $4)
+	`, storeID, shelfCode, excludeProductID, excludeVariantID).Scan(&taken)
+	return taken, err
+}
+
+// checkVariant enforces SKU uniqueness against products and a store-unique shelf code that fits the
+// store's shelf layout.
+// The parent product row is locked so concurrent writes to its variants serialize.
+func checkVariant(ctx context.Context, tx pgx.Tx, v *models.ProductVariant) error {
+	var storeID *int
+	err := tx.QueryRow(ctx, `SELECT store_id FROM admin_products WHERE product_id = $1 FOR UPDATE`, v.ProductID).Scan(&storeID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrProductNotFound
+		}
+		return err
+	}
+	var skuTaken bool
+	if err := tx.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM admin_products WHERE lower(sku) = lower($1))`, v.SKU).Scan(&skuTaken); err != nil {
+		return err
+	}
+	if skuTaken {
+		return ErrVariantSKUTaken
+	}
+	if v.ShelfCode != nil && storeID != nil {
+		code, err := canonicalShelfCode(ctx, tx, *storeID, *v.ShelfCode)
+		if err != nil {
+			return err
+		}
+		v.ShelfCode = &code
+		taken, err := shelfCodeTaken(ctx, tx, *storeID, *v.ShelfCode, 0, v.ID)
+		if err != nil {
+			return err
+		}
+		if taken {
+			return ErrShelfCodeTaken
+		}
+	}
+	return nil
+}
+
+// CreateProductVariant inserts a variant under its parent product
+func (db *Database) CreateProductVariant(ctx context.Context, v *models.ProductVariant) (*models.ProductVariant, error) {
+	options, err := json.Marshal(v.Options)
+	if err != nil {
+		return nil, err
+	}
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := checkVariant(ctx, tx, v); err != nil {
+		return nil, err
+	}
+	created, err := scanVariant(tx.QueryRow(ctx, `
+		INSERT INTO app_product_variants AS v (product_id, sku, name, options, main_price, strikethrough_price, cost_price,
+			stock_left, shelf_code, is_active, display_order)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		RETURNING `+variantColumns,
+		v.ProductID, v.SKU, v.Name, options, v.MainPrice, v.StrikethroughPrice, v.CostPrice,
+		v.StockLeft, v.ShelfCode, v.IsActive, v.DisplayOrder))
+	if err != nil {
+		return nil, variantWriteError(err)
+	}
+	return created, tx.Commit(ctx)
+}
+
+// UpdateProductVariant replaces the editable fields of a variant
+func (db *Database) UpdateProductVariant(ctx context.Context, v *models.ProductVariant) (*models.ProductVariant, error) {
+	options, err := json.Marshal(v.Options)
+	if err != nil {
+		return nil, err
+	}
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := checkVariant(ctx, tx, v); err != nil {
+		return nil, err
+	}
+	updated, err := scanVariant(tx.QueryRow(ctx, `
+		UPDATE app_product_variants AS v
+		SET sku = $3, name = $4, options = $5, main_price = $6, strikethrough_price = $7, cost_price = $8,
+			stock_left = $9, shelf_code = $10, is_active = $11, display_order = $12, updated_at = now()
+		WHERE v.product_id = $1 AND v.variant_id = $2
+		RETURNING `+variantColumns,
+		v.ProductID, v.ID, v.SKU, v.Name, options, v.MainPrice, v.StrikethroughPrice, v.CostPrice,
+		v.StockLeft, v.ShelfCode, v.IsActive, v.DisplayOrder))
+	if err != nil {
+		return nil, variantWriteError(err)
+	}
+	return updated, tx.Commit(ctx)
+}
+
+// DeleteProductVariant removes a variant; pgx.ErrNoRows if it does not exist
+func (db *Database) DeleteProductVariant(ctx context.Context, productID, variantID int) error {
+	tag, err := db.Pool.Exec(ctx, `DELETE FROM app_product_variants WHERE product_id = $1 AND variant_id = $2`, productID, variantID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
+
+// variantWriteError maps the SKU unique index violation to ErrVariantSKUTaken
+func variantWriteError(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" && strings.Contains(pgErr.ConstraintName, "sku") {
+		return ErrVariantSKUTaken
+	}
+	return err
+}
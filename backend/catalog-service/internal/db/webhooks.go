@@ -0,0 +1,245 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/expotoworld/expotoworld/backend/catalog-service/internal/models"
+	"github.com/jackc/pgx/v5"
+)
+
+var (
+	ErrWebhookEndpointNotFound = errors.New("webhook endpoint not found")
+	ErrWebhookDeliveryNotFound = errors.New("webhook delivery not found")
+)
+
+const webhookEndpointColumns = `endpoint_id, url, events, description, is_active, created_at, updated_at`
+
+func scanWebhookEndpoint(row pgx.Row) (*models.WebhookEndpoint, error) {
+	var e models.WebhookEndpoint
+	if err := row.Scan(&e.ID, &e.URL, &e.Events, &e.Description, &e.Active, &e.CreatedAt, &e.UpdatedAt); err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
+
+// ListWebhookEndpoints returns all endpoints, oldest first (without secrets)
+func (db *Database) ListWebhookEndpoints(ctx context.Context) ([]models.WebhookEndpoint, error) {
+	rows, err := db.Pool.Query(ctx, `SELECT `+webhookEndpointColumns+` FROM catalog_webhook_endpoints ORDER BY endpoint_id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make([]models.WebhookEndpoint, 0)
+	for rows.Next() {
+		e, err := scanWebhookEndpoint(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *e)
+	}
+	return out, rows.Err()
+}
+
+// GetWebhookEndpoint returns one endpoint (without its secret)
+func (db *Database) GetWebhookEndpoint(ctx context.Context, id int64) (*models.WebhookEndpoint, error) {
+	e, err := scanWebhookEndpoint(db.Pool.QueryRow(ctx, `SELECT `+webhookEndpointColumns+` FROM catalog_webhook_endpoints WHERE endpoint_id = $1`, id))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrWebhookEndpointNotFound
+	}
+	return e, err
+}
+
+// CreateWebhookEndpoint inserts an endpoint with the given signing secret
+func (db *Database) CreateWebhookEndpoint(ctx context.Context, req models.WebhookEndpointRequest, secret string) (*models.WebhookEndpoint, error) {
+	active := req.Active == nil || *req.Active
+	e, err := scanWebhookEndpoint(db.Pool.QueryRow(ctx, `
+		INSERT INTO catalog_webhook_endpoints (url, events, description, secret, is_active)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING `+webhookEndpointColumns,
+		req.URL, req.Events, req.Description, secret, active))
+	if err != nil {
+		return nil, err
+	}
+	e.Secret = secret
+	return e, nil
+}
+
+// UpdateWebhookEndpoint replaces an endpoint's settings; a non-empty secret replaces the signing secret
+func (db *Database) UpdateWebhookEndpoint(ctx context.Context, id int64, req models.WebhookEndpointRequest, secret string) (*models.WebhookEndpoint, error) {
+	e, err := scanWebhookEndpoint(db.Pool.QueryRow(ctx, `
+		UPDATE catalog_webhook_endpoints
+		SET url = $2, events = $3, description = $4, is_active = COALESCE($5, is_active),
+			secret = COALESCE(NULLIF($6, ''), secret), updated_at = now()
+		WHERE endpoint_id = $1
+		RETURNING `+webhookEndpointColumns,
+		id, req.URL, req.Events, req.Description, req.Active, secret))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrWebhookEndpointNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	e.Secret = secret
+	return e, nil
+}
+
+// DeleteWebhookEndpoint removes an endpoint and its deliveries
+func (db *Database) DeleteWebhookEndpoint(ctx context.Context, id int64) error {
+	tag, err := db.Pool.Exec(ctx, `DELETE FROM catalog_webhook_endpoints WHERE endpoint_id = $1`, id)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrWebhookEndpointNotFound
+	}
+	return nil
+}
+
+// EnqueueWebhookEvent queues payload for every active endpoint subscribed to event and returns how many
+// deliveries were created
+func (db *Database) EnqueueWebhookEvent(ctx context.Context, event string, payload []byte) (int64, error) {
+	tag, err := db.Pool.Exec(ctx, `
+		INSERT INTO catalog_webhook_deliveries (endpoint_id, event, payload)
+		SELECT endpoint_id, $1, $2::jsonb FROM catalog_webhook_endpoints
+		WHERE is_active AND (cardinality(events) = 0 OR $1 = ANY(events))
+	`, event, string(payload))
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
+
+// EnqueueWebhookPing queues a test event for one endpoint regardless of its subscriptions
+func (db *Database) EnqueueWebhookPing(ctx context.Context, endpointID int64, event string, payload []byte) (*models.WebhookDelivery, error) {
+	var d models.WebhookDelivery
+	err := db.Pool.QueryRow(ctx, `
+		INSERT INTO catalog_webhook_deliveries (endpoint_id, event, payload)
+		SELECT endpoint_id, $2, $3::jsonb FROM catalog_webhook_endpoints WHERE endpoint_id = $1
+		RETURNING delivery_id, endpoint_id, event, status, attempts, next_attempt_at, created_at
+	`, endpointID, event, string(payload)).Scan(&d.ID, &d.EndpointID, &d.Event, &d.Status, &d.Attempts, &d.NextAttemptAt, &d.CreatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrWebhookEndpointNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+// ClaimDueWebhookDeliveries leases up to limit pending deliveries that are due, pushing their
+// next_attempt_at out by lease so a crashed worker's claims are retried by the next poll
+func (db *Database) ClaimDueWebhookDeliveries(ctx context.Context, limit int, lease time.Duration) ([]models.DueWebhookDelivery, error) {
+	rows, err := db.Pool.Query(ctx, `
+		WITH due AS (
+			SELECT d.delivery_id FROM catalog_webhook_deliveries d
+			WHERE d.status = 'pending' AND d.next_attempt_at <= now()
+			ORDER BY d.next_attempt_at
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		)
+		UPDATE catalog_webhook_deliveries d
+		SET next_attempt_at = now() + make_interval(secs => $2)
+		FROM due, catalog_webhook_endpoints e
+		WHERE d.delivery_id = due.delivery_id AND e.endpoint_id = d.endpoint_id
+		RETURNING d.delivery_id, d.event, d.payload::text, d.attempts, d.created_at, e.url, e.secret
+	`, limit, lease.Seconds())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []models.DueWebhookDelivery
+	for rows.Next() {
+		var d models.DueWebhookDelivery
+		var payload string
+		if err := rows.Scan(&d.ID, &d.Event, &payload, &d.Attempts, &d.CreatedAt, &d.URL, &d.Secret); err != nil {
+			return nil, err
+		}
+		d.Payload = []byte(payload)
+		out = append(out, d)
+	}
+	return out, rows.Err()
+}
+
+// RecordWebhookAttempt stores the outcome of one attempt. A failed attempt is retried at retryAt, or
+// moves the delivery to failed (dead letter) when retryAt is nil.
+func (db *Database) RecordWebhookAttempt(ctx context.Context, id int64, responseCode int, errMsg string, delivered bool, retryAt *time.Time) error {
+	status := models.WebhookPending
+	switch {
+	case delivered:
+		status = models.WebhookDelivered
+	case retryAt == nil:
+		status = models.WebhookFailed
+	}
+	var code *int
+	if responseCode > 0 {
+		code = &responseCode
+	}
+	_, err := db.Pool.Exec(ctx, `
+		UPDATE catalog_webhook_deliveries
+		SET status = $2, attempts = attempts + 1, response_code = $3, last_error = NULLIF($4, ''),
+			next_attempt_at = COALESCE($5, next_attempt_at),
+			delivered_at = CASE WHEN $2 = 'delivered' THEN now() ELSE NULL END
+		WHERE delivery_id = $1
+	`, id, status, code, errMsg, retryAt)
+	return err
+}
+
+// ListWebhookDeliveries returns an endpoint's deliveries, newest first, optionally filtered by status
+// (keyset-paginated by delivery_id)
+func (db *Database) ListWebhookDeliveries(ctx context.Context, endpointID int64, status string, beforeID int64, limit int) ([]models.WebhookDelivery, error) {
+	args := []interface{}{endpointID, limit}
+	where := "endpoint_id = $1"
+	if status != "" {
+		args = append(args, status)
+		where += fmt.Sprintf(" AND status = $%d", len(args))
+	}
+	if beforeID > 0 {
+		args = append(args, beforeID)
+		where += fmt.Sprintf(" AND delivery_id < $%d", len(args))
+	}
+	rows, err := db.Pool.Query(ctx, `
+		SELECT delivery_id, endpoint_id, event, status, attempts, response_code, last_error, next_attempt_at, delivered_at, created_at
+		FROM catalog_webhook_deliveries
+		WHERE `+where+`
+		ORDER BY delivery_id DESC
+		LIMIT $2`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make([]models.WebhookDelivery, 0)
+	for rows.Next() {
+		var d models.WebhookDelivery
+		if err := rows.Scan(&d.ID, &d.EndpointID, &d.Event, &d.Status, &d.Attempts, &d.ResponseCode, &d.LastError,
+			&d.NextAttemptAt, &d.DeliveredAt, &d.CreatedAt); err != nil {
+			return nil, err
+		}
+		out = append(out, d)
+	}
+	return out, rows.Err()
+}
+
+// RedeliverWebhook puts a delivery back in the queue for an immediate attempt with a fresh attempt budget
+func (db *Database) RedeliverWebhook(ctx context.Context, id int64) (*models.WebhookDelivery, error) {
+	var d models.WebhookDelivery
+	err := db.Pool.QueryRow(ctx, `
+		UPDATE catalog_webhook_deliveries
+		SET status = 'pending', attempts = 0, next_attempt_at = now(), delivered_at = NULL
+		WHERE delivery_id = $1
+		RETURNING delivery_id, endpoint_id, event, status, attempts, response_code, last_error, next_attempt_at, delivered_at, created_at
+	`, id).Scan(&d.ID, &d.EndpointID, &d.Event, &d.Status, &d.Attempts, &d.ResponseCode, &d.LastError,
+		&d.NextAttemptAt, &d.DeliveredAt, &d.CreatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrWebhookDeliveryNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
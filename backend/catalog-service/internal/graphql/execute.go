@@ -0,0 +1,368 @@
+package graphql
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+)
+
+// Query cost limits: how deeply selections may nest, how many top-level fields (each one runs its
+// own resolver, usually a database query) a query may select under distinct response keys, and how
+// many fields it may select in total once fragments are expanded
+const (
+	maxDepth      = 8
+	maxRootFields = 20
+	maxFields     = 1000
+)
+
+// Request is a GraphQL request as sent over HTTP
+type Request struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+}
+
+// Result is the response body. Data is nil when the request itself is invalid.
+type Result struct {
+	Data   interface{} `json:"data"`
+	Errors []*Error    `json:"errors,omitempty"`
+}
+
+// Error is a request or field error; Path locates a failed field in Data
+type Error struct {
+	Message string        `json:"message"`
+	Path    []interface{} `json:"path,omitempty"`
+}
+
+func requestError(format string, args ...interface{}) *Result {
+	return &Result{Errors: []*Error{{Message: fmt.Sprintf(format, args...)}}}
+}
+
+// Execute parses, validates and runs a query against root (the source of top-level fields).
+// A resolver error nulls its field and is reported with the field's path.
+func (s *Schema) Execute(ctx context.Context, req Request, root interface{}) *Result {
+	doc, err := Parse(req.Query)
+	if err != nil {
+		return requestError("%v", err)
+	}
+
+	var op *Operation
+	for _, o := range doc.Operations {
+		if req.OperationName == "" || o.Name == req.OperationName {
+			if op != nil {
+				return requestError("operationName is required when the document has several operations")
+			}
+			op = o
+		}
+	}
+	if op == nil {
+		return requestError("unknown operation %q", req.OperationName)
+	}
+	if op.Type != "query" {
+		return requestError("only queries are supported")
+	}
+
+	e := &executor{ctx: ctx, doc: doc, vars: map[string]interface{}{}}
+	for _, def := range op.Variables {
+		v, ok := req.Variables[def.Name]
+		if !ok && def.HasDefault {
+			if v, err = e.value(def.Default); err != nil {
+				return requestError("%v", err)
+			}
+			ok = true
+		}
+		if def.NonNull && (!ok || v == nil) {
+			return requestError("variable $%s of type %s is required", def.Name, def.Type)
+		}
+		if ok {
+			e.vars[def.Name] = v
+		}
+		e.declared = append(e.declared, def.Name)
+	}
+
+	if err := e.validate(s.Query, op.Selections, 1, map[string]bool{}); err != nil {
+		return requestError("%v", err)
+	}
+	if n := len(e.collect(op.Selections)); n > maxRootFields {
+		return requestError("query selects %d top-level fields; at most %d are allowed", n, maxRootFields)
+	}
+	data := e.selectionSet(s.Query, root, op.Selections, nil)
+	return &Result{Data: data, Errors: e.errors}
+}
+
+type executor struct {
+	ctx      context.Context
+	doc      *Document
+	vars     map[string]interface{}
+	declared []string
+	errors   []*Error
+	// fields counts the selections seen by validate, against maxFields
+	fields int
+}
+
+// validate checks selections against the schema before anything is resolved
+func (e *executor) validate(obj *Object, sels []Selection, depth int, spreading map[string]bool) error {
+	if depth > maxDepth {
+		return fmt.Errorf("query is nested more than %d levels deep", maxDepth)
+	}
+	for _, sel := range sels {
+		switch s := sel.(type) {
+		case *SelectedField:
+			if e.fields++; e.fields > maxFields {
+				return fmt.Errorf("query selects more than %d fields", maxFields)
+			}
+			if _, err := e.included(s.Directives); err != nil {
+				return err
+			}
+			if s.Name == "__typename" {
+				if len(s.Arguments) > 0 || len(s.Selections) > 0 {
+					return fmt.Errorf("__typename takes no arguments or selections")
+				}
+				continue
+			}
+			def, ok := obj.Fields[s.Name]
+			if !ok {
+				return fmt.Errorf("cannot query field %q on type %q", s.Name, obj.Name)
+			}
+			if _, err := e.args(def, s); err != nil {
+				return err
+			}
+			switch {
+			case def.Type != nil && len(s.Selections) == 0:
+				return fmt.Errorf("field %q of type %q must have a selection of subfields", s.Name, def.Type.Name)
+			case def.Type == nil && len(s.Selections) > 0:
+				return fmt.Errorf("field %q cannot have a selection of subfields", s.Name)
+			case def.Type != nil:
+				if err := e.validate(def.Type, s.Selections, depth+1, spreading); err != nil {
+					return err
+				}
+			}
+		case *FragmentSpread:
+			if _, err := e.included(s.Directives); err != nil {
+				return err
+			}
+			frag, ok := e.doc.Fragments[s.Name]
+			if !ok {
+				return fmt.Errorf("unknown fragment %q", s.Name)
+			}
+			if spreading[s.Name] {
+				return fmt.Errorf("fragment %q spreads itself", s.Name)
+			}
+			if frag.TypeCondition != obj.Name {
+				return fmt.Errorf("fragment %q on %q cannot be spread on type %q", s.Name, frag.TypeCondition, obj.Name)
+			}
+			spreading[s.Name] = true
+			err := e.validate(obj, frag.Selections, depth, spreading)
+			delete(spreading, s.Name)
+			if err != nil {
+				return err
+			}
+		case *InlineFragment:
+			if _, err := e.included(s.Directives); err != nil {
+				return err
+			}
+			if s.TypeCondition != "" && s.TypeCondition != obj.Name {
+				return fmt.Errorf("fragment on %q cannot be spread on type %q", s.TypeCondition, obj.Name)
+			}
+			if err := e.validate(obj, s.Selections, depth, spreading); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// collect flattens fragments and merges fields sharing a response key (already validated)
+func (e *executor) collect(sels []Selection) []*SelectedField {
+	var out []*SelectedField
+	byKey := map[string]*SelectedField{}
+	var walk func(sels []Selection)
+	walk = func(sels []Selection) {
+		for _, sel := range sels {
+			switch s := sel.(type) {
+			case *SelectedField:
+				if ok, _ := e.included(s.Directives); !ok {
+					continue
+				}
+				key := s.ResponseKey()
+				if prev, ok := byKey[key]; ok {
+					prev.Selections = append(prev.Selections, s.Selections...)
+					continue
+				}
+				f := *s
+				f.Selections = append([]Selection(nil), s.Selections...)
+				byKey[key] = &f
+				out = append(out, &f)
+			case *FragmentSpread:
+				if ok, _ := e.included(s.Directives); ok {
+					walk(e.doc.Fragments[s.Name].Selections)
+				}
+			case *InlineFragment:
+				if ok, _ := e.included(s.Directives); ok {
+					walk(s.Selections)
+				}
+			}
+		}
+	}
+	walk(sels)
+	return out
+}
+
+func (e *executor) selectionSet(obj *Object, source interface{}, sels []Selection, path []interface{}) *orderedObject {
+	out := &orderedObject{}
+	for _, f := range e.collect(sels) {
+		key := f.ResponseKey()
+		if f.Name == "__typename" {
+			out.set(key, obj.Name)
+			continue
+		}
+		fieldPath := append(path[:len(path):len(path)], key)
+		def := obj.Fields[f.Name]
+		args, _ := e.args(def, f)
+		v, err := def.Resolve(ResolveParams{Context: e.ctx, Source: source, Args: args})
+		if err != nil {
+			e.errors = append(e.errors, &Error{Message: err.Error(), Path: fieldPath})
+			out.set(key, nil)
+			continue
+		}
+		out.set(key, e.complete(def.Type, v, f.Selections, fieldPath))
+	}
+	return out
+}
+
+// complete resolves the sub-selection of an object or list value
+func (e *executor) complete(t *Object, v interface{}, sels []Selection, path []interface{}) interface{} {
+	if t == nil || v == nil {
+		return v
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Slice:
+		list := make([]interface{}, rv.Len())
+		for i := range list {
+			list[i] = e.complete(t, rv.Index(i).Interface(), sels, append(path[:len(path):len(path)], i))
+		}
+		return list
+	case reflect.Ptr, reflect.Map, reflect.Interface:
+		if rv.IsNil() {
+			return nil
+		}
+	}
+	return e.selectionSet(t, v, sels, path)
+}
+
+// args resolves variables and coerces the arguments of a field
+func (e *executor) args(def *Field, f *SelectedField) (Args, error) {
+	out := Args{}
+	for _, a := range f.Arguments {
+		t, ok := def.Args[a.Name]
+		if !ok {
+			return nil, fmt.Errorf("unknown argument %q on field %q", a.Name, f.Name)
+		}
+		v, err := e.value(a.Value)
+		if err != nil {
+			return nil, err
+		}
+		if v == nil {
+			continue
+		}
+		if out[a.Name], err = coerce(t, v); err != nil {
+			return nil, fmt.Errorf("argument %q on field %q: %v", a.Name, f.Name, err)
+		}
+	}
+	return out, nil
+}
+
+// value substitutes variables in a literal
+func (e *executor) value(v Value) (interface{}, error) {
+	switch x := v.(type) {
+	case Variable:
+		declared := false
+		for _, n := range e.declared {
+			declared = declared || n == string(x)
+		}
+		if !declared {
+			return nil, fmt.Errorf("variable $%s is not defined", x)
+		}
+		return e.vars[string(x)], nil
+	case []Value:
+		list := make([]interface{}, len(x))
+		for i, item := range x {
+			r, err := e.value(item)
+			if err != nil {
+				return nil, err
+			}
+			list[i] = r
+		}
+		return list, nil
+	case map[string]Value:
+		obj := make(map[string]interface{}, len(x))
+		for k, item := range x {
+			r, err := e.value(item)
+			if err != nil {
+				return nil, err
+			}
+			obj[k] = r
+		}
+		return obj, nil
+	}
+	return v, nil
+}
+
+// included evaluates @skip(if:) and @include(if:)
+func (e *executor) included(dirs []Directive) (bool, error) {
+	for _, d := range dirs {
+		if d.Name != "skip" && d.Name != "include" {
+			return false, fmt.Errorf("unknown directive @%s", d.Name)
+		}
+		if len(d.Arguments) != 1 || d.Arguments[0].Name != "if" {
+			return false, fmt.Errorf("@%s requires a single if argument", d.Name)
+		}
+		v, err := e.value(d.Arguments[0].Value)
+		if err != nil {
+			return false, err
+		}
+		b, ok := v.(bool)
+		if !ok {
+			return false, fmt.Errorf("@%s(if:) must be a Boolean", d.Name)
+		}
+		if b == (d.Name == "skip") {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// orderedObject keeps response keys in query order when encoded
+type orderedObject struct {
+	keys   []string
+	values []interface{}
+}
+
+func (o *orderedObject) set(key string, v interface{}) {
+	o.keys = append(o.keys, key)
+	o.values = append(o.values, v)
+}
+
+// MarshalJSON encodes the object with its keys in insertion order
+func (o *orderedObject) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, k := range o.keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		kb, _ := json.Marshal(k)
+		buf.Write(kb)
+		buf.WriteByte(':')
+		vb, err := json.Marshal(o.values[i])
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(vb)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
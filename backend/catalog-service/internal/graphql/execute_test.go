@@ -0,0 +1,146 @@
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+type testItem struct {
+	ID   int
+	Name string
+}
+
+// testSchema serves items(limit), item(id) and a failing field; calls counts root resolver runs
+func testSchema(calls *int) *Schema {
+	item := &Object{Name: "Item"}
+	item.Fields = map[string]*Field{
+		"id":   {Resolve: func(p ResolveParams) (interface{}, error) { return p.Source.(*testItem).ID, nil }},
+		"name": {Resolve: func(p ResolveParams) (interface{}, error) { return p.Source.(*testItem).Name, nil }},
+		"broken": {Resolve: func(ResolveParams) (interface{}, error) {
+			return nil, errors.New("boom")
+		}},
+		"self": {Type: item, Resolve: func(p ResolveParams) (interface{}, error) { return p.Source, nil }},
+	}
+	return &Schema{Query: &Object{Name: "Query", Fields: map[string]*Field{
+		"items": {
+			Type: item,
+			Args: map[string]ArgType{"limit": Int, "ids": IntList},
+			Resolve: func(p ResolveParams) (interface{}, error) {
+				*calls++
+				n, ok := p.Args.Int("limit")
+				if !ok {
+					n = 2
+				}
+				out := []*testItem{}
+				for i := 1; i <= n; i++ {
+					out = append(out, &testItem{ID: i, Name: fmt.Sprintf("item %d", i)})
+				}
+				return out, nil
+			},
+		},
+		"item": {
+			Type: item,
+			Args: map[string]ArgType{"id": Int},
+			Resolve: func(p ResolveParams) (interface{}, error) {
+				*calls++
+				id, _ := p.Args.Int("id")
+				if id == 0 {
+					return nil, nil
+				}
+				return &testItem{ID: id, Name: fmt.Sprintf("item %d", id)}, nil
+			},
+		},
+	}}}
+}
+
+func TestExecute(t *testing.T) {
+	tests := []struct {
+		name  string
+		req   Request
+		want  string
+		calls int
+	}{
+		{"aliases keep query order", Request{Query: `{ b: item(id: 2) { name } a: item(id: 1) { id __typename } }`},
+			`{"data":{"b":{"name":"item 2"},"a":{"id":1,"__typename":"Item"}}}`, 2},
+		{"variables and defaults", Request{Query: `query ($n: Int = 1, $id: Int!) { items(limit: $n) { id } item(id: $id) { id } }`,
+			Variables: map[string]interface{}{"id": 7.0}},
+			`{"data":{"items":[{"id":1}],"item":{"id":7}}}`, 2},
+		{"fragments merge into one field", Request{Query: `{ item(id: 3) { ...A ... on Item { name } } item(id: 3) { id } } fragment A on Item { id }`},
+			`{"data":{"item":{"id":3,"name":"item 3"}}}`, 1},
+		{"skip and include", Request{Query: `query ($on: Boolean!) { item(id: 1) { id @skip(if: $on) name @include(if: $on) } }`,
+			Variables: map[string]interface{}{"on": true}},
+			`{"data":{"item":{"name":"item 1"}}}`, 1},
+		{"null object", Request{Query: `{ item { id } }`}, `{"data":{"item":null}}`, 1},
+		{"field error nulls the field with its path", Request{Query: `{ items { id broken } }`},
+			`{"data":{"items":[{"id":1,"broken":null},{"id":2,"broken":null}]},"errors":[{"message":"boom","path":["items",0,"broken"]},{"message":"boom","path":["items",1,"broken"]}]}`, 1},
+		{"operation name", Request{Query: `query A { item(id: 1) { id } } query B { item(id: 2) { id } }`, OperationName: "B"},
+			`{"data":{"item":{"id":2}}}`, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			res := testSchema(&calls).Execute(context.Background(), tt.req, nil)
+			got, err := json.Marshal(res)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Fatalf("got  %s\nwant %s", got, tt.want)
+			}
+			if calls != tt.calls {
+				t.Fatalf("root resolvers ran %d times, want %d", calls, tt.calls)
+			}
+		})
+	}
+}
+
+func TestExecuteRejects(t *testing.T) {
+	aliases := make([]string, maxRootFields+1)
+	for i := range aliases {
+		aliases[i] = fmt.Sprintf("p%d: items(limit: 200) { id }", i)
+	}
+	tests := []struct {
+		name string
+		req  Request
+		want string
+	}{
+		{"syntax error", Request{Query: `{ items { id }`}, "unexpected end of query"},
+		{"mutation", Request{Query: `mutation { items { id } }`}, "only queries are supported"},
+		{"ambiguous operation", Request{Query: `query A { item { id } } query B { item { id } }`}, "operationName is required"},
+		{"unknown operation", Request{Query: `query A { item { id } }`, OperationName: "B"}, `unknown operation "B"`},
+		{"unknown field", Request{Query: `{ items { price } }`}, `cannot query field "price" on type "Item"`},
+		{"unknown argument", Request{Query: `{ items(first: 1) { id } }`}, `unknown argument "first"`},
+		{"argument type", Request{Query: `{ items(limit: "ten") { id } }`}, `argument "limit" on field "items": expected Int`},
+		{"missing subselection", Request{Query: `{ items }`}, "must have a selection of subfields"},
+		{"scalar subselection", Request{Query: `{ items { id { x } } }`}, "cannot have a selection of subfields"},
+		{"required variable", Request{Query: `query ($id: Int!) { item(id: $id) { id } }`}, "variable $id of type Int! is required"},
+		{"undefined variable", Request{Query: `{ item(id: $id) { id } }`}, "variable $id is not defined"},
+		{"unknown fragment", Request{Query: `{ item { ...Nope } }`}, `unknown fragment "Nope"`},
+		{"fragment cycle", Request{Query: `{ item { ...A } } fragment A on Item { self { ...A } }`}, `fragment "A" spreads itself`},
+		{"fragment type", Request{Query: `{ item { ...Q } } fragment Q on Query { item { id } }`}, `cannot be spread on type "Item"`},
+		{"unknown directive", Request{Query: `{ item @cached { id } }`}, "unknown directive @cached"},
+		{"too deep", Request{Query: `{ item { self { self { self { self { self { self { self { id } } } } } } } } }`}, "nested more than 8 levels"},
+		{"too many root fields", Request{Query: "{ " + strings.Join(aliases, " ") + " }"}, "at most 20 are allowed"},
+		{"fragment fan-out", Request{Query: `{ item { ...F4 } }
+			fragment F1 on Item { a1: id a2: id a3: id a4: id a5: id a6: id a7: id a8: id a9: id a10: id }
+			fragment F2 on Item { b1: self { ...F1 } b2: self { ...F1 } b3: self { ...F1 } b4: self { ...F1 } b5: self { ...F1 } b6: self { ...F1 } b7: self { ...F1 } b8: self { ...F1 } b9: self { ...F1 } b10: self { ...F1 } }
+			fragment F3 on Item { c1: self { ...F2 } c2: self { ...F2 } c3: self { ...F2 } c4: self { ...F2 } c5: self { ...F2 } c6: self { ...F2 } c7: self { ...F2 } c8: self { ...F2 } c9: self { ...F2 } c10: self { ...F2 } }
+			fragment F4 on Item { d1: self { ...F3 } d2: self { ...F3 } }`}, "more than 1000 fields"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			res := testSchema(&calls).Execute(context.Background(), tt.req, nil)
+			if res.Data != nil || len(res.Errors) != 1 || !strings.Contains(res.Errors[0].Message, tt.want) {
+				t.Fatalf("got %+v, want a request error containing %q", res.Errors, tt.want)
+			}
+			if calls != 0 {
+				t.Fatalf("%d resolvers ran for a rejected query", calls)
+			}
+		})
+	}
+}
@@ -0,0 +1,695 @@
+// Package graphql executes the read-only subset of GraphQL served by the catalog: queries with
+// arguments, variables, aliases, fragments, @skip/@include and nested selections. Introspection
+// is limited to __typename; mutations and subscriptions are rejected. Queries are bounded in depth,
+// top-level fields and total fields so one request can't fan out into hundreds of database queries.
+package graphql
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Document is a parsed request
+type Document struct {
+	Operations []*Operation
+	Fragments  map[string]*Fragment
+}
+
+// Operation is a query, mutation or subscription definition
+type Operation struct {
+	Type       string // "query", "mutation" or "subscription"
+	Name       string
+	Variables  []VariableDefinition
+	Selections []Selection
+}
+
+// VariableDefinition declares $name: Type = default
+type VariableDefinition struct {
+	Name       string
+	Type       string
+	NonNull    bool
+	Default    Value
+	HasDefault bool
+}
+
+// Selection is a *SelectedField, *FragmentSpread or *InlineFragment
+type Selection interface{}
+
+// SelectedField selects a field, optionally under an alias
+type SelectedField struct {
+	Alias      string
+	Name       string
+	Arguments  []Argument
+	Directives []Directive
+	Selections []Selection
+}
+
+// ResponseKey is the alias, or the field name when there is none
+func (f *SelectedField) ResponseKey() string {
+	if f.Alias != "" {
+		return f.Alias
+	}
+	return f.Name
+}
+
+// FragmentSpread is ...Name
+type FragmentSpread struct {
+	Name       string
+	Directives []Directive
+}
+
+// InlineFragment is ... on Type { ... }
+type InlineFragment struct {
+	TypeCondition string
+	Directives    []Directive
+	Selections    []Selection
+}
+
+// Fragment is a named fragment definition
+type Fragment struct {
+	Name          string
+	TypeCondition string
+	Selections    []Selection
+}
+
+// Argument is name: value
+type Argument struct {
+	Name  string
+	Value Value
+}
+
+// Directive is @name(args)
+type Directive struct {
+	Name      string
+	Arguments []Argument
+}
+
+// Value is a literal: nil, bool, int, float64, string, EnumValue, Variable, []Value or map[string]Value
+type Value interface{}
+
+// Variable references $name
+type Variable string
+
+// EnumValue is an unquoted name used as a value
+type EnumValue string
+
+// maxQueryLength bounds the request text the parser accepts
+const maxQueryLength = 32 * 1024
+
+// Parse parses a GraphQL request document
+func Parse(src string) (*Document, error) {
+	if len(src) > maxQueryLength {
+		return nil, fmt.Errorf("query exceeds %d bytes", maxQueryLength)
+	}
+	p := &parser{lex: lexer{src: src}}
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+	doc := &Document{Fragments: map[string]*Fragment{}}
+	for p.tok.kind != tokEOF {
+		switch {
+		case p.tok.kind == tokPunct && p.tok.value == "{":
+			sel, err := p.selectionSet()
+			if err != nil {
+				return nil, err
+			}
+			doc.Operations = append(doc.Operations, &Operation{Type: "query", Selections: sel})
+		case p.tok.kind == tokName && p.tok.value == "fragment":
+			frag, err := p.fragment()
+			if err != nil {
+				return nil, err
+			}
+			if _, dup := doc.Fragments[frag.Name]; dup {
+				return nil, fmt.Errorf("fragment %q is defined more than once", frag.Name)
+			}
+			doc.Fragments[frag.Name] = frag
+		case p.tok.kind == tokName && (p.tok.value == "query" || p.tok.value == "mutation" || p.tok.value == "subscription"):
+			op, err := p.operation()
+			if err != nil {
+				return nil, err
+			}
+			doc.Operations = append(doc.Operations, op)
+		default:
+			return nil, p.unexpected()
+		}
+	}
+	if len(doc.Operations) == 0 {
+		return nil, fmt.Errorf("document contains no operation")
+	}
+	return doc, nil
+}
+
+type parser struct {
+	lex lexer
+	tok token
+}
+
+func (p *parser) advance() error {
+	t, err := p.lex.next()
+	if err != nil {
+		return err
+	}
+	p.tok = t
+	return nil
+}
+
+func (p *parser) unexpected() error {
+	if p.tok.kind == tokEOF {
+		return fmt.Errorf("unexpected end of query")
+	}
+	return fmt.Errorf("unexpected %q at line %d", p.tok.value, p.tok.line)
+}
+
+func (p *parser) peek(punct string) bool {
+	return p.tok.kind == tokPunct && p.tok.value == punct
+}
+
+func (p *parser) expect(punct string) error {
+	if !p.peek(punct) {
+		return p.unexpected()
+	}
+	return p.advance()
+}
+
+func (p *parser) name() (string, error) {
+	if p.tok.kind != tokName {
+		return "", p.unexpected()
+	}
+	n := p.tok.value
+	return n, p.advance()
+}
+
+func (p *parser) operation() (*Operation, error) {
+	op := &Operation{Type: p.tok.value}
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+	if p.tok.kind == tokName {
+		op.Name = p.tok.value
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+	}
+	if p.peek("(") {
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		for !p.peek(")") {
+			def, err := p.variableDefinition()
+			if err != nil {
+				return nil, err
+			}
+			op.Variables = append(op.Variables, def)
+		}
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+	}
+	if _, err := p.directives(); err != nil {
+		return nil, err
+	}
+	sel, err := p.selectionSet()
+	if err != nil {
+		return nil, err
+	}
+	op.Selections = sel
+	return op, nil
+}
+
+func (p *parser) variableDefinition() (VariableDefinition, error) {
+	var def VariableDefinition
+	if err := p.expect("$"); err != nil {
+		return def, err
+	}
+	n, err := p.name()
+	if err != nil {
+		return def, err
+	}
+	def.Name = n
+	if err := p.expect(":"); err != nil {
+		return def, err
+	}
+	if def.Type, err = p.typeRef(); err != nil {
+		return def, err
+	}
+	def.NonNull = strings.HasSuffix(def.Type, "!")
+	if p.peek("=") {
+		if err := p.advance(); err != nil {
+			return def, err
+		}
+		if def.Default, err = p.value(true); err != nil {
+			return def, err
+		}
+		def.HasDefault = true
+	}
+	_, err = p.directives()
+	return def, err
+}
+
+// typeRef reads a type such as [Int!]! and returns it as written
+func (p *parser) typeRef() (string, error) {
+	var t string
+	if p.peek("[") {
+		if err := p.advance(); err != nil {
+			return "", err
+		}
+		inner, err := p.typeRef()
+		if err != nil {
+			return "", err
+		}
+		if err := p.expect("]"); err != nil {
+			return "", err
+		}
+		t = "[" + inner + "]"
+	} else {
+		n, err := p.name()
+		if err != nil {
+			return "", err
+		}
+		t = n
+	}
+	if p.peek("!") {
+		t += "!"
+		if err := p.advance(); err != nil {
+			return "", err
+		}
+	}
+	return t, nil
+}
+
+func (p *parser) fragment() (*Fragment, error) {
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+	n, err := p.name()
+	if err != nil {
+		return nil, err
+	}
+	if n == "on" {
+		return nil, fmt.Errorf("fragment must be named")
+	}
+	if p.tok.kind != tokName || p.tok.value != "on" {
+		return nil, p.unexpected()
+	}
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+	cond, err := p.name()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := p.directives(); err != nil {
+		return nil, err
+	}
+	sel, err := p.selectionSet()
+	if err != nil {
+		return nil, err
+	}
+	return &Fragment{Name: n, TypeCondition: cond, Selections: sel}, nil
+}
+
+func (p *parser) selectionSet() ([]Selection, error) {
+	if err := p.expect("{"); err != nil {
+		return nil, err
+	}
+	var out []Selection
+	for !p.peek("}") {
+		sel, err := p.selection()
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, sel)
+	}
+	if len(out) == 0 {
+		return nil, fmt.Errorf("empty selection set at line %d", p.tok.line)
+	}
+	return out, p.advance()
+}
+
+func (p *parser) selection() (Selection, error) {
+	if p.peek("...") {
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		if p.tok.kind == tokName && p.tok.value != "on" {
+			n, err := p.name()
+			if err != nil {
+				return nil, err
+			}
+			dirs, err := p.directives()
+			if err != nil {
+				return nil, err
+			}
+			return &FragmentSpread{Name: n, Directives: dirs}, nil
+		}
+		frag := &InlineFragment{}
+		if p.tok.kind == tokName {
+			if err := p.advance(); err != nil {
+				return nil, err
+			}
+			cond, err := p.name()
+			if err != nil {
+				return nil, err
+			}
+			frag.TypeCondition = cond
+		}
+		var err error
+		if frag.Directives, err = p.directives(); err != nil {
+			return nil, err
+		}
+		if frag.Selections, err = p.selectionSet(); err != nil {
+			return nil, err
+		}
+		return frag, nil
+	}
+
+	f := &SelectedField{}
+	n, err := p.name()
+	if err != nil {
+		return nil, err
+	}
+	if p.peek(":") {
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		f.Alias = n
+		if n, err = p.name(); err != nil {
+			return nil, err
+		}
+	}
+	f.Name = n
+	if f.Arguments, err = p.arguments(false); err != nil {
+		return nil, err
+	}
+	if f.Directives, err = p.directives(); err != nil {
+		return nil, err
+	}
+	if p.peek("{") {
+		if f.Selections, err = p.selectionSet(); err != nil {
+			return nil, err
+		}
+	}
+	return f, nil
+}
+
+func (p *parser) arguments(constant bool) ([]Argument, error) {
+	if !p.peek("(") {
+		return nil, nil
+	}
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+	var args []Argument
+	for !p.peek(")") {
+		n, err := p.name()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(":"); err != nil {
+			return nil, err
+		}
+		v, err := p.value(constant)
+		if err != nil {
+			return nil, err
+		}
+		for _, a := range args {
+			if a.Name == n {
+				return nil, fmt.Errorf("argument %q is given more than once", n)
+			}
+		}
+		args = append(args, Argument{Name: n, Value: v})
+	}
+	return args, p.advance()
+}
+
+func (p *parser) directives() ([]Directive, error) {
+	var dirs []Directive
+	for p.peek("@") {
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		n, err := p.name()
+		if err != nil {
+			return nil, err
+		}
+		args, err := p.arguments(false)
+		if err != nil {
+			return nil, err
+		}
+		dirs = append(dirs, Directive{Name: n, Arguments: args})
+	}
+	return dirs, nil
+}
+
+// value reads a literal; constant rejects variables (defaults must be constant)
+func (p *parser) value(constant bool) (Value, error) {
+	t := p.tok
+	switch t.kind {
+	case tokInt:
+		n, err := strconv.Atoi(t.value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid Int %s at line %d", t.value, t.line)
+		}
+		return n, p.advance()
+	case tokFloat:
+		f, err := strconv.ParseFloat(t.value, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid Float %s at line %d", t.value, t.line)
+		}
+		return f, p.advance()
+	case tokString:
+		return t.value, p.advance()
+	case tokName:
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		switch t.value {
+		case "true":
+			return true, nil
+		case "false":
+			return false, nil
+		case "null":
+			return nil, nil
+		}
+		return EnumValue(t.value), nil
+	}
+	switch {
+	case p.peek("$"):
+		if constant {
+			return nil, fmt.Errorf("variables are not allowed here (line %d)", t.line)
+		}
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		n, err := p.name()
+		if err != nil {
+			return nil, err
+		}
+		return Variable(n), nil
+	case p.peek("["):
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		list := []Value{}
+		for !p.peek("]") {
+			v, err := p.value(constant)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, v)
+		}
+		return list, p.advance()
+	case p.peek("{"):
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		obj := map[string]Value{}
+		for !p.peek("}") {
+			n, err := p.name()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect(":"); err != nil {
+				return nil, err
+			}
+			if obj[n], err = p.value(constant); err != nil {
+				return nil, err
+			}
+		}
+		return obj, p.advance()
+	}
+	return nil, p.unexpected()
+}
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokPunct
+	tokName
+	tokInt
+	tokFloat
+	tokString
+)
+
+type token struct {
+	kind  tokenKind
+	value string
+	line  int
+}
+
+type lexer struct {
+	src  string
+	pos  int
+	line int
+}
+
+func (l *lexer) next() (token, error) {
+	if l.line == 0 {
+		l.line = 1
+	}
+	// Skip whitespace, commas, the BOM and comments
+	for l.pos < len(l.src) {
+		c := l.src[l.pos]
+		switch {
+		case c == '\n':
+			l.line++
+			l.pos++
+		case c == ' ' || c == '\t' || c == '\r' || c == ',':
+			l.pos++
+		case c == '#':
+			for l.pos < len(l.src) && l.src[l.pos] != '\n' {
+				l.pos++
+			}
+		case strings.HasPrefix(l.src[l.pos:], "\uFEFF"):
+			l.pos += len("\uFEFF")
+		default:
+			goto scan
+		}
+	}
+	return token{kind: tokEOF, line: l.line}, nil
+
+scan:
+	start := l.pos
+	c := l.src[l.pos]
+	switch {
+	case strings.HasPrefix(l.src[l.pos:], "..."):
+		l.pos += 3
+		return token{kind: tokPunct, value: "...", line: l.line}, nil
+	case strings.IndexByte("!$()&:=@[]{}|", c) >= 0:
+		l.pos++
+		return token{kind: tokPunct, value: string(c), line: l.line}, nil
+	case c == '_' || isLetter(c):
+		for l.pos < len(l.src) && (l.src[l.pos] == '_' || isLetter(l.src[l.pos]) || isDigit(l.src[l.pos])) {
+			l.pos++
+		}
+		return token{kind: tokName, value: l.src[start:l.pos], line: l.line}, nil
+	case c == '-' || isDigit(c):
+		return l.number()
+	case c == '"':
+		return l.string()
+	}
+	r, _ := utf8.DecodeRuneInString(l.src[l.pos:])
+	return token{}, fmt.Errorf("unexpected character %q at line %d", r, l.line)
+}
+
+func (l *lexer) number() (token, error) {
+	start := l.pos
+	kind := tokInt
+	if l.src[l.pos] == '-' {
+		l.pos++
+	}
+	digits := func() int {
+		n := 0
+		for l.pos < len(l.src) && isDigit(l.src[l.pos]) {
+			l.pos++
+			n++
+		}
+		return n
+	}
+	if digits() == 0 {
+		return token{}, fmt.Errorf("invalid number at line %d", l.line)
+	}
+	if l.pos < len(l.src) && l.src[l.pos] == '.' {
+		kind = tokFloat
+		l.pos++
+		if digits() == 0 {
+			return token{}, fmt.Errorf("invalid number at line %d", l.line)
+		}
+	}
+	if l.pos < len(l.src) && (l.src[l.pos] == 'e' || l.src[l.pos] == 'E') {
+		kind = tokFloat
+		l.pos++
+		if l.pos < len(l.src) && (l.src[l.pos] == '+' || l.src[l.pos] == '-') {
+			l.pos++
+		}
+		if digits() == 0 {
+			return token{}, fmt.Errorf("invalid number at line %d", l.line)
+		}
+	}
+	return token{kind: kind, value: l.src[start:l.pos], line: l.line}, nil
+}
+
+func (l *lexer) string() (token, error) {
+	line := l.line
+	if strings.HasPrefix(l.src[l.pos:], `"""`) {
+		end := strings.Index(l.src[l.pos+3:], `"""`)
+		if end < 0 {
+			return token{}, fmt.Errorf("unterminated string at line %d", line)
+		}
+		raw := l.src[l.pos+3 : l.pos+3+end]
+		l.line += strings.Count(raw, "\n")
+		l.pos += end + 6
+		return token{kind: tokString, value: strings.TrimSpace(raw), line: line}, nil
+	}
+	l.pos++
+	var b strings.Builder
+	for l.pos < len(l.src) {
+		c := l.src[l.pos]
+		switch {
+		case c == '"':
+			l.pos++
+			return token{kind: tokString, value: b.String(), line: line}, nil
+		case c == '\n':
+			return token{}, fmt.Errorf("unterminated string at line %d", line)
+		case c == '\\' && l.pos+1 < len(l.src):
+			esc := l.src[l.pos+1]
+			l.pos += 2
+			switch esc {
+			case '"', '\\', '/':
+				b.WriteByte(esc)
+			case 'b':
+				b.WriteByte('\b')
+			case 'f':
+				b.WriteByte('\f')
+			case 'n':
+				b.WriteByte('\n')
+			case 'r':
+				b.WriteByte('\r')
+			case 't':
+				b.WriteByte('\t')
+			case 'u':
+				if l.pos+4 > len(l.src) {
+					return token{}, fmt.Errorf("invalid escape at line %d", line)
+				}
+				n, err := strconv.ParseUint(l.src[l.pos:l.pos+4], 16, 32)
+				if err != nil {
+					return token{}, fmt.Errorf("invalid escape at line %d", line)
+				}
+				b.WriteRune(rune(n))
+				l.pos += 4
+			default:
+				return token{}, fmt.Errorf("invalid escape \\%c at line %d", esc, line)
+			}
+		default:
+			b.WriteByte(c)
+			l.pos++
+		}
+	}
+	return token{}, fmt.Errorf("unterminated string at line %d", line)
+}
+
+func isLetter(c byte) bool { return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') }
+func isDigit(c byte) bool  { return c >= '0' && c <= '9' }
@@ -0,0 +1,117 @@
+package graphql
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		check func(t *testing.T, doc *Document)
+	}{
+		{"shorthand query", `{ products { id } }`, func(t *testing.T, doc *Document) {
+			op := doc.Operations[0]
+			f := op.Selections[0].(*SelectedField)
+			if op.Type != "query" || f.Name != "products" || f.Selections[0].(*SelectedField).Name != "id" {
+				t.Fatalf("unexpected operation %+v", op)
+			}
+		}},
+		{"named operation with variables", `query Shop($store: Int!, $ids: [Int!] = [1, 2], $q: String) { store(id: $store) { name } }`, func(t *testing.T, doc *Document) {
+			op := doc.Operations[0]
+			want := []VariableDefinition{
+				{Name: "store", Type: "Int!", NonNull: true},
+				{Name: "ids", Type: "[Int!]", Default: []Value{1, 2}, HasDefault: true},
+				{Name: "q", Type: "String"},
+			}
+			if op.Name != "Shop" || !reflect.DeepEqual(op.Variables, want) {
+				t.Fatalf("variables = %+v", op.Variables)
+			}
+			if arg := op.Selections[0].(*SelectedField).Arguments[0]; arg.Value != Variable("store") {
+				t.Fatalf("argument = %+v", arg)
+			}
+		}},
+		{"aliases and literals", `{ cheap: products(limit: 5, min: -1.5e2, name: "a\"bé", featured: true, sort: PRICE, after: null) { id } }`, func(t *testing.T, doc *Document) {
+			f := doc.Operations[0].Selections[0].(*SelectedField)
+			if f.ResponseKey() != "cheap" || f.Name != "products" {
+				t.Fatalf("field = %+v", f)
+			}
+			want := []Argument{
+				{"limit", 5}, {"min", -150.0}, {"name", "a\"bé"}, {"featured", true}, {"sort", EnumValue("PRICE")}, {"after", nil},
+			}
+			if !reflect.DeepEqual(f.Arguments, want) {
+				t.Fatalf("arguments = %#v", f.Arguments)
+			}
+		}},
+		{"fragments and directives", `
+			query { product(id: 1) { ...Basics ... on Product @include(if: $full) { images { url } } } }
+			fragment Basics on Product { id name @skip(if: false) }`, func(t *testing.T, doc *Document) {
+			frag := doc.Fragments["Basics"]
+			if frag == nil || frag.TypeCondition != "Product" || len(frag.Selections) != 2 {
+				t.Fatalf("fragment = %+v", frag)
+			}
+			sels := doc.Operations[0].Selections[0].(*SelectedField).Selections
+			if spread := sels[0].(*FragmentSpread); spread.Name != "Basics" {
+				t.Fatalf("spread = %+v", spread)
+			}
+			inline := sels[1].(*InlineFragment)
+			if inline.TypeCondition != "Product" || inline.Directives[0].Name != "include" || inline.Directives[0].Arguments[0].Value != Variable("full") {
+				t.Fatalf("inline fragment = %+v", inline)
+			}
+		}},
+		{"comments, commas and block strings", "# list\n{ a(s: \"\"\"\n  multi\n  line\n\"\"\"),, b }", func(t *testing.T, doc *Document) {
+			sels := doc.Operations[0].Selections
+			if len(sels) != 2 || sels[0].(*SelectedField).Arguments[0].Value != "multi\n  line" {
+				t.Fatalf("selections = %+v", sels)
+			}
+		}},
+		{"several operations", `query A { a } query B { b }`, func(t *testing.T, doc *Document) {
+			if len(doc.Operations) != 2 || doc.Operations[1].Name != "B" {
+				t.Fatalf("operations = %+v", doc.Operations)
+			}
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := Parse(tt.query)
+			if err != nil {
+				t.Fatalf("Parse: %v", err)
+			}
+			tt.check(t, doc)
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		want  string
+	}{
+		{"empty document", ``, "no operation"},
+		{"only a fragment", `fragment F on Product { id }`, "no operation"},
+		{"unclosed selection", `{ products { id }`, "unexpected end of query"},
+		{"empty selection", `{ products { } }`, "empty selection set"},
+		{"missing argument value", `{ product(id:) { id } }`, `unexpected ")"`},
+		{"duplicate argument", `{ product(id: 1, id: 2) { id } }`, `argument "id" is given more than once`},
+		{"variable in default", `query ($a: Int = $b) { a }`, "variables are not allowed"},
+		{"unnamed fragment", `fragment on Product { id } { a }`, "fragment must be named"},
+		{"duplicate fragment", `{ a } fragment F on P { id } fragment F on P { id }`, `fragment "F" is defined more than once`},
+		{"unterminated string", `{ a(s: "abc) }`, "unterminated string"},
+		{"bad escape", `{ a(s: "\q") }`, "invalid escape"},
+		{"bad number", `{ a(n: 1.) }`, "invalid number"},
+		{"unexpected character", `{ a ? }`, `unexpected character '?'`},
+		{"error line", "{\n  a\n  }\n}", `unexpected "}" at line 4`},
+		{"too long", "{ a }" + strings.Repeat(" ", maxQueryLength), "exceeds"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse(tt.query)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("Parse error = %v, want it to contain %q", err, tt.want)
+			}
+		})
+	}
+}
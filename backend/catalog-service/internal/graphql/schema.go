@@ -0,0 +1,148 @@
+package graphql
+
+import (
+	"context"
+	"fmt"
+	"math"
+)
+
+// Object is an object type: a named set of fields
+type Object struct {
+	Name   string
+	Fields map[string]*Field
+}
+
+// Field is a field of an object type. Fields with a Type resolve to objects (or lists of them,
+// returned as slices) and need a sub-selection; fields without one resolve to JSON values.
+type Field struct {
+	Type    *Object
+	Args    map[string]ArgType
+	Resolve func(p ResolveParams) (interface{}, error)
+}
+
+// ArgType is the type of a field argument
+type ArgType int
+
+const (
+	Int ArgType = iota
+	IntList
+	Float
+	String
+	Boolean
+)
+
+func (t ArgType) String() string {
+	switch t {
+	case Int:
+		return "Int"
+	case IntList:
+		return "[Int]"
+	case Float:
+		return "Float"
+	case String:
+		return "String"
+	default:
+		return "Boolean"
+	}
+}
+
+// Schema is the entry point of queries
+type Schema struct {
+	Query *Object
+}
+
+// ResolveParams are passed to a field resolver
+type ResolveParams struct {
+	Context context.Context
+	// Source is the parent object (the root value for top-level fields)
+	Source interface{}
+	Args   Args
+}
+
+// Args are the coerced arguments of a field; absent and null arguments are missing
+type Args map[string]interface{}
+
+// Int returns an Int argument
+func (a Args) Int(name string) (int, bool) {
+	v, ok := a[name].(int)
+	return v, ok
+}
+
+// IntList returns an [Int] argument
+func (a Args) IntList(name string) ([]int, bool) {
+	v, ok := a[name].([]int)
+	return v, ok
+}
+
+// Float returns a Float argument
+func (a Args) Float(name string) (float64, bool) {
+	v, ok := a[name].(float64)
+	return v, ok
+}
+
+// String returns a String argument
+func (a Args) String(name string) (string, bool) {
+	v, ok := a[name].(string)
+	return v, ok
+}
+
+// Bool returns a Boolean argument
+func (a Args) Bool(name string) (bool, bool) {
+	v, ok := a[name].(bool)
+	return v, ok
+}
+
+// coerce converts a literal or variable value to the argument type. JSON variables arrive as
+// float64, so integral numbers are accepted as Int.
+func coerce(t ArgType, v interface{}) (interface{}, error) {
+	switch t {
+	case Int:
+		switch n := v.(type) {
+		case int:
+			return n, nil
+		case float64:
+			if n == math.Trunc(n) && math.Abs(n) <= math.MaxInt32 {
+				return int(n), nil
+			}
+		}
+	case IntList:
+		switch l := v.(type) {
+		case []interface{}:
+			out := make([]int, 0, len(l))
+			for _, x := range l {
+				n, err := coerce(Int, x)
+				if err != nil {
+					return nil, err
+				}
+				out = append(out, n.(int))
+			}
+			return out, nil
+		default:
+			// A single value is accepted as a list of one
+			n, err := coerce(Int, v)
+			if err != nil {
+				return nil, err
+			}
+			return []int{n.(int)}, nil
+		}
+	case Float:
+		switch n := v.(type) {
+		case int:
+			return float64(n), nil
+		case float64:
+			return n, nil
+		}
+	case String:
+		switch s := v.(type) {
+		case string:
+			return s, nil
+		case EnumValue:
+			return string(s), nil
+		}
+	case Boolean:
+		if b, ok := v.(bool); ok {
+			return b, nil
+		}
+	}
+	return nil, fmt.Errorf("expected %s, got %v", t, v)
+}
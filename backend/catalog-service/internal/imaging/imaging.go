@@ -0,0 +1,111 @@
+// Package imaging produces the standardized WebP renditions served to the apps instead of
+// the uploaded originals.
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"os"
+	"strconv"
+
+	"github.com/HugoSmits86/nativewebp"
+	xdraw "golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+// Rendition names, also used as the suffix of the stored object key
+const (
+	Thumbnail = "thumb"
+	Medium    = "medium"
+	Original  = "original"
+)
+
+// maxSourcePixels guards against decompression bombs (about 50 megapixels)
+const maxSourcePixels = 50_000_000
+
+var ErrTooLarge = errors.New("image dimensions too large")
+
+// Spec is a rendition bounded by MaxSide pixels on its longest side
+type Spec struct {
+	Name    string
+	MaxSide int
+}
+
+// Output is an encoded rendition
+type Output struct {
+	Name   string
+	Data   []byte
+	Width  int
+	Height int
+}
+
+// Specs returns the renditions to generate. The "original" rendition keeps the source size
+// up to IMAGE_RENDITION_MAX_PX (default 2048), since lossless WebP grows quickly for photos.
+func Specs() []Spec {
+	return []Spec{
+		{Name: Thumbnail, MaxSide: envInt("IMAGE_THUMBNAIL_PX", 320)},
+		{Name: Medium, MaxSide: envInt("IMAGE_MEDIUM_PX", 960)},
+		{Name: Original, MaxSide: envInt("IMAGE_RENDITION_MAX_PX", 2048)},
+	}
+}
+
+// Render decodes a JPEG, PNG, GIF or WebP image and encodes every spec as WebP.
+// Images are never upscaled, so small sources yield renditions of their own size.
+func Render(data []byte, specs []Spec) ([]Output, error) {
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read image header: %w", err)
+	}
+	if cfg.Width <= 0 || cfg.Height <= 0 || cfg.Width*cfg.Height > maxSourcePixels {
+		return nil, ErrTooLarge
+	}
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode image: %w", err)
+	}
+
+	out := make([]Output, 0, len(specs))
+	for _, spec := range specs {
+		img := resize(src, spec.MaxSide)
+		var buf bytes.Buffer
+		if err := nativewebp.Encode(&buf, img, nil); err != nil {
+			return nil, fmt.Errorf("failed to encode %s rendition: %w", spec.Name, err)
+		}
+		b := img.Bounds()
+		out = append(out, Output{Name: spec.Name, Data: buf.Bytes(), Width: b.Dx(), Height: b.Dy()})
+	}
+	return out, nil
+}
+
+// resize scales src to fit within maxSide x maxSide, returning an RGBA copy either way
+func resize(src image.Image, maxSide int) image.Image {
+	b := src.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if maxSide > 0 && (w > maxSide || h > maxSide) {
+		if w >= h {
+			w, h = maxSide, max(1, h*maxSide/w)
+		} else {
+			w, h = max(1, w*maxSide/h), maxSide
+		}
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	if w == b.Dx() && h == b.Dy() {
+		draw.Draw(dst, dst.Bounds(), src, b.Min, draw.Src)
+		return dst
+	}
+	xdraw.CatmullRom.Scale(dst, dst.Bounds(), src, b, draw.Src, nil)
+	return dst
+}
+
+func envInt(key string, def int) int {
+	if v, err := strconv.Atoi(os.Getenv(key)); err == nil && v > 0 {
+		return v
+	}
+	return def
+}
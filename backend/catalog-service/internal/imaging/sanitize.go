@@ -0,0 +1,415 @@
+package imaging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	"image/gif"
+	"net/http"
+)
+
+var (
+	ErrUnsupportedFormat = errors.New("not a JPEG, PNG, GIF or WebP image")
+	ErrMalformed         = errors.New("malformed image")
+	ErrPolyglot          = errors.New("image carries content of another file type")
+)
+
+// Upload is an accepted upload with its metadata removed
+type Upload struct {
+	Data        []byte
+	Format      string // jpeg, png, gif or webp
+	ContentType string
+	Width       int
+	Height      int
+}
+
+// Ext is the file extension matching the format
+func (u *Upload) Ext() string {
+	if u.Format == "jpeg" {
+		return ".jpg"
+	}
+	return "." + u.Format
+}
+
+// UploadLimits returns the largest accepted side (IMAGE_UPLOAD_MAX_SIDE_PX, default 12000) and pixel
+// count (IMAGE_UPLOAD_MAX_PIXELS, default and at most the 50 megapixels renditions can decode)
+func UploadLimits() (maxSide, maxPixels int) {
+	return envInt("IMAGE_UPLOAD_MAX_SIDE_PX", 12000), min(envInt("IMAGE_UPLOAD_MAX_PIXELS", maxSourcePixels), maxSourcePixels)
+}
+
+// Sanitize accepts a complete JPEG, PNG, GIF or WebP image within the upload limits and returns it
+// without metadata (EXIF other than orientation, XMP, comments, text chunks) or bytes after its end.
+// Files whose removed parts hold another file type's signature, e.g. HTML or a ZIP appended to a
+// JPEG, are rejected as polyglots. Pixel data is kept as uploaded; nothing is re-encoded.
+func Sanitize(data []byte) (*Upload, error) {
+	cfg, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrUnsupportedFormat
+	}
+	if cfg.Width <= 0 || cfg.Height <= 0 {
+		return nil, ErrMalformed
+	}
+	maxSide, maxPixels := UploadLimits()
+	if cfg.Width > maxSide || cfg.Height > maxSide || cfg.Width*cfg.Height > maxPixels {
+		return nil, ErrTooLarge
+	}
+
+	var clean, removed []byte
+	switch format {
+	case "jpeg":
+		clean, removed, err = stripJPEG(data)
+	case "png":
+		clean, removed, err = stripPNG(data)
+	case "gif":
+		clean, removed, err = stripGIF(data)
+	case "webp":
+		clean, removed, err = stripWebP(data)
+	default:
+		return nil, ErrUnsupportedFormat
+	}
+	if err != nil {
+		return nil, err
+	}
+	if hasForeignSignature(removed) {
+		return nil, ErrPolyglot
+	}
+	contentType := "image/" + format
+	if http.DetectContentType(clean) != contentType {
+		return nil, ErrMalformed
+	}
+
+	// A valid header can front a truncated or corrupt body, so decode the pixels too
+	if format == "gif" {
+		_, err = gif.DecodeAll(bytes.NewReader(clean))
+	} else {
+		_, _, err = image.Decode(bytes.NewReader(clean))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrMalformed, err)
+	}
+	return &Upload{Data: clean, Format: format, ContentType: contentType, Width: cfg.Width, Height: cfg.Height}, nil
+}
+
+// foreignSignatures mark markup, archives, documents and executables hidden in an image
+var foreignSignatures = [][]byte{
+	[]byte("PK\x03\x04"), []byte("PK\x05\x06"), []byte("%PDF-"), []byte("\x7fELF"),
+}
+
+var foreignMarkup = [][]byte{
+	[]byte("<html"), []byte("<script"), []byte("<?php"), []byte("<svg"), []byte("<!doctype"), []byte("<iframe"), []byte("<body"),
+}
+
+func hasForeignSignature(b []byte) bool {
+	if len(b) == 0 {
+		return false
+	}
+	for _, sig := range foreignSignatures {
+		if bytes.Contains(b, sig) {
+			return true
+		}
+	}
+	lower := bytes.ToLower(b)
+	for _, sig := range foreignMarkup {
+		if bytes.Contains(lower, sig) {
+			return true
+		}
+	}
+	return false
+}
+
+// stripJPEG keeps the JFIF header, ICC profile, Adobe color marker, tables, frames and scans. A
+// non-default EXIF orientation is kept as a minimal EXIF segment so photos still display upright.
+func stripJPEG(data []byte) (clean, removed []byte, err error) {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != 0xD8 {
+		return nil, nil, ErrMalformed
+	}
+	out := make([]byte, 0, len(data))
+	out = append(out, 0xFF, 0xD8)
+	exifAt := len(out)
+	orientation := 0
+	i := 2
+	for {
+		for i+1 < len(data) && data[i] == 0xFF && data[i+1] == 0xFF {
+			i++ // fill bytes
+		}
+		if i+2 > len(data) || data[i] != 0xFF {
+			return nil, nil, ErrMalformed
+		}
+		marker := data[i+1]
+		if marker == 0xD9 {
+			out = append(out, 0xFF, 0xD9)
+			removed = append(removed, data[i+2:]...)
+			break
+		}
+		if marker == 0x01 || (marker >= 0xD0 && marker <= 0xD7) {
+			out = append(out, 0xFF, marker)
+			i += 2
+			continue
+		}
+		if i+4 > len(data) {
+			return nil, nil, ErrMalformed
+		}
+		end := i + 2 + int(binary.BigEndian.Uint16(data[i+2:]))
+		if end < i+4 || end > len(data) {
+			return nil, nil, ErrMalformed
+		}
+		seg, payload := data[i:end], data[i+4:end]
+		keep := true
+		switch {
+		case marker == 0xE0:
+			keep = bytes.HasPrefix(payload, []byte("JFIF\x00"))
+			if keep {
+				exifAt = len(out) + len(seg)
+			}
+		case marker == 0xE1:
+			keep = false
+			if o := exifOrientation(payload); o > 1 {
+				orientation = o
+			}
+		case marker == 0xE2:
+			keep = bytes.HasPrefix(payload, []byte("ICC_PROFILE\x00"))
+		case marker == 0xEE:
+			keep = bytes.HasPrefix(payload, []byte("Adobe"))
+		case marker >= 0xE3 && marker <= 0xEF, marker == 0xFE:
+			keep = false
+		}
+		if keep {
+			out = append(out, seg...)
+		} else {
+			removed = append(removed, seg...)
+		}
+		i = end
+
+		if marker == 0xDA {
+			// Entropy-coded data runs to the next marker that is neither a stuffed zero nor a restart
+			j := i
+			for {
+				if j+1 >= len(data) {
+					return nil, nil, ErrMalformed
+				}
+				if data[j] == 0xFF && data[j+1] != 0 && (data[j+1] < 0xD0 || data[j+1] > 0xD7) {
+					break
+				}
+				j++
+			}
+			out = append(out, data[i:j]...)
+			i = j
+		}
+	}
+
+	if orientation > 1 {
+		seg := orientationSegment(orientation)
+		out = append(out[:exifAt], append(seg, out[exifAt:]...)...)
+	}
+	return out, removed, nil
+}
+
+// exifOrientation reads the orientation tag from an APP1 EXIF payload; 0 when absent
+func exifOrientation(payload []byte) int {
+	if !bytes.HasPrefix(payload, []byte("Exif\x00\x00")) || len(payload) < 14 {
+		return 0
+	}
+	tiff := payload[6:]
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 0
+	}
+	ifd := int(order.Uint32(tiff[4:]))
+	if ifd < 8 || ifd+2 > len(tiff) {
+		return 0
+	}
+	n := int(order.Uint16(tiff[ifd:]))
+	for k := 0; k < n; k++ {
+		e := ifd + 2 + 12*k
+		if e+12 > len(tiff) {
+			return 0
+		}
+		if order.Uint16(tiff[e:]) == 0x0112 && order.Uint16(tiff[e+2:]) == 3 {
+			if o := int(order.Uint16(tiff[e+8:])); o >= 1 && o <= 8 {
+				return o
+			}
+			return 0
+		}
+	}
+	return 0
+}
+
+// orientationSegment is an APP1 EXIF segment holding only the orientation tag
+func orientationSegment(orientation int) []byte {
+	seg := []byte{
+		0xFF, 0xE1, 0x00, 0x22, // APP1, length 34
+		'E', 'x', 'i', 'f', 0, 0,
+		'M', 'M', 0x00, 0x2A, 0x00, 0x00, 0x00, 0x08, // big-endian TIFF, IFD0 at 8
+		0x00, 0x01, // one entry
+		0x01, 0x12, 0x00, 0x03, 0x00, 0x00, 0x00, 0x01, 0x00, 0x00, 0x00, 0x00, // orientation, SHORT, 1
+		0x00, 0x00, 0x00, 0x00, // no next IFD
+	}
+	seg[29] = byte(orientation)
+	return seg
+}
+
+// pngKeep are the chunks that affect how a PNG (or APNG) renders
+var pngKeep = map[string]bool{
+	"IHDR": true, "PLTE": true, "IDAT": true, "IEND": true, "tRNS": true, "gAMA": true, "cHRM": true,
+	"sRGB": true, "iCCP": true, "sBIT": true, "pHYs": true, "bKGD": true, "acTL": true, "fcTL": true, "fdAT": true,
+}
+
+func stripPNG(data []byte) (clean, removed []byte, err error) {
+	const sig = "\x89PNG\r\n\x1a\n"
+	if !bytes.HasPrefix(data, []byte(sig)) {
+		return nil, nil, ErrMalformed
+	}
+	out := make([]byte, 0, len(data))
+	out = append(out, sig...)
+	i := len(sig)
+	for {
+		if i+12 > len(data) {
+			return nil, nil, ErrMalformed
+		}
+		n := binary.BigEndian.Uint32(data[i:])
+		if n > 1<<31-1 || uint64(i)+12+uint64(n) > uint64(len(data)) {
+			return nil, nil, ErrMalformed
+		}
+		end := i + 12 + int(n)
+		typ := string(data[i+4 : i+8])
+		if pngKeep[typ] {
+			out = append(out, data[i:end]...)
+		} else {
+			removed = append(removed, data[i:end]...)
+		}
+		i = end
+		if typ == "IEND" {
+			removed = append(removed, data[i:]...)
+			return out, removed, nil
+		}
+	}
+}
+
+// stripGIF drops comments and application extensions other than the animation loop count
+func stripGIF(data []byte) (clean, removed []byte, err error) {
+	if len(data) < 13 || (string(data[:6]) != "GIF87a" && string(data[:6]) != "GIF89a") {
+		return nil, nil, ErrMalformed
+	}
+	i := 13
+	if data[10]&0x80 != 0 {
+		i += 3 << (data[10]&0x07 + 1)
+	}
+	if i > len(data) {
+		return nil, nil, ErrMalformed
+	}
+	out := make([]byte, 0, len(data))
+	out = append(out, data[:i]...)
+	for {
+		if i >= len(data) {
+			return nil, nil, ErrMalformed
+		}
+		start := i
+		switch data[i] {
+		case 0x3B: // trailer
+			out = append(out, 0x3B)
+			removed = append(removed, data[i+1:]...)
+			return out, removed, nil
+		case 0x2C: // image descriptor, optional local color table, LZW code size, data
+			if i+10 > len(data) {
+				return nil, nil, ErrMalformed
+			}
+			flags := data[i+9]
+			i += 10
+			if flags&0x80 != 0 {
+				i += 3 << (flags&0x07 + 1)
+			}
+			if i, err = skipSubBlocks(data, i+1); err != nil {
+				return nil, nil, err
+			}
+			out = append(out, data[start:i]...)
+		case 0x21: // extension
+			if i+2 > len(data) {
+				return nil, nil, ErrMalformed
+			}
+			label := data[i+1]
+			if i, err = skipSubBlocks(data, i+2); err != nil {
+				return nil, nil, err
+			}
+			keep := label == 0xF9 || label == 0x01
+			if label == 0xFF && start+14 <= len(data) && data[start+2] == 11 {
+				app := string(data[start+3 : start+14])
+				keep = app == "NETSCAPE2.0" || app == "ANIMEXTS1.0"
+			}
+			if keep {
+				out = append(out, data[start:i]...)
+			} else {
+				removed = append(removed, data[start:i]...)
+			}
+		default:
+			return nil, nil, ErrMalformed
+		}
+	}
+}
+
+// skipSubBlocks returns the offset after a run of GIF data sub-blocks ending in an empty block
+func skipSubBlocks(data []byte, i int) (int, error) {
+	for {
+		if i >= len(data) {
+			return 0, ErrMalformed
+		}
+		n := int(data[i])
+		i++
+		if n == 0 {
+			return i, nil
+		}
+		i += n
+	}
+}
+
+// webpKeep are the chunks that affect how a WebP renders
+var webpKeep = map[string]bool{"VP8 ": true, "VP8L": true, "VP8X": true, "ALPH": true, "ANIM": true, "ANMF": true, "ICCP": true}
+
+// stripWebP drops EXIF, XMP and unknown chunks and clears their VP8X flags
+func stripWebP(data []byte) (clean, removed []byte, err error) {
+	if len(data) < 12 || string(data[:4]) != "RIFF" || string(data[8:12]) != "WEBP" {
+		return nil, nil, ErrMalformed
+	}
+	size := binary.LittleEndian.Uint32(data[4:])
+	if size < 4 || uint64(size)+8 > uint64(len(data)) {
+		return nil, nil, ErrMalformed
+	}
+	end := 8 + int(size)
+	removed = append(removed, data[end:]...)
+	out := make([]byte, 12, len(data))
+	copy(out, data[:12])
+	i := 12
+	for i < end {
+		if i+8 > end {
+			return nil, nil, ErrMalformed
+		}
+		n := binary.LittleEndian.Uint32(data[i+4:])
+		if uint64(i)+8+uint64(n) > uint64(end) {
+			return nil, nil, ErrMalformed
+		}
+		chunkEnd := min(i+8+int(n)+int(n&1), end)
+		fourCC := string(data[i : i+4])
+		if !webpKeep[fourCC] {
+			removed = append(removed, data[i:chunkEnd]...)
+			i = chunkEnd
+			continue
+		}
+		at := len(out)
+		out = append(out, data[i:chunkEnd]...)
+		if n&1 == 1 && chunkEnd == end {
+			out = append(out, 0) // restore a missing pad byte
+		}
+		if fourCC == "VP8X" && n >= 1 {
+			out[at+8] &^= 0x08 | 0x04 // EXIF and XMP present
+		}
+		i = chunkEnd
+	}
+	binary.LittleEndian.PutUint32(out[4:], uint32(len(out)-8))
+	return out, removed, nil
+}
@@ -56,6 +56,9 @@ func JSONLogger() gin.HandlerFunc {
 			"bytes_in":    c.Request.ContentLength,
 			"bytes_out":   c.Writer.Size(),
 		}
+		if id := c.GetString("request_id"); id != "" {
+			fields["request_id"] = id
+		}
 		if len(c.Errors) > 0 {
 			fields["error"] = c.Errors.String()
 		}
@@ -0,0 +1,105 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Audit actions
+const (
+	AuditCreate = "create"
+	AuditUpdate = "update"
+	AuditDelete = "delete"
+)
+
+// AuditEntry records one admin write: who did it, through which route, and the entity row before and
+// after. Changes holds only the top-level fields that differ ({"field": {"from": .., "to": ..}}).
+type AuditEntry struct {
+	ID          int64           `json:"audit_id"`
+	OccurredAt  time.Time       `json:"occurred_at"`
+	ActorUserID *string         `json:"actor_user_id,omitempty"`
+	ActorEmail  *string         `json:"actor_email,omitempty"`
+	ActorRole   *string         `json:"actor_role,omitempty"`
+	Method      string          `json:"method"`
+	Route       string          `json:"route"`
+	Path        string          `json:"path"`
+	EntityType  string          `json:"entity_type"`
+	EntityID    *string         `json:"entity_id,omitempty"`
+	Action      string          `json:"action"`
+	Status      int             `json:"status"`
+	Before      json.RawMessage `json:"before,omitempty"`
+	After       json.RawMessage `json:"after,omitempty"`
+	Changes     json.RawMessage `json:"changes,omitempty"`
+	RequestBody json.RawMessage `json:"request_body,omitempty"`
+	ClientIP    *string         `json:"client_ip,omitempty"`
+	// Undo linkage: an undo entry points at the entry it reversed and vice versa
+	UndoOf   *int64 `json:"undo_of_audit_id,omitempty"`
+	UndoneBy *int64 `json:"undone_by_audit_id,omitempty"`
+	// Superseded is set when a later write touched the same entity
+	Superseded bool `json:"-"`
+}
+
+// AuditFilter narrows the audit log listing; zero values mean no filter
+type AuditFilter struct {
+	EntityType string
+	EntityID   string
+	ActorID    string
+	Action     string
+	Field      string // only entries that changed this field
+	From       *time.Time
+	To         *time.Time
+	BeforeID   int64
+	Limit      int
+}
+
+// Undo kinds: the destructive admin actions that can be reversed from the activity feed
+const (
+	UndoRestoreProduct   = "restore_product"
+	UndoRestoreCategory  = "restore_category"
+	UndoRevertCategories = "revert_categories"
+)
+
+// UndoKind returns how the entry can be reversed, or "" when it cannot. Only soft deletes of
+// products and categories and changes to a product's category assignment are undoable.
+func (e *AuditEntry) UndoKind() string {
+	if e.UndoOf != nil || e.EntityID == nil || e.Before == nil {
+		return ""
+	}
+	switch {
+	case e.EntityType == "product" && e.Action == AuditDelete && e.After != nil:
+		return UndoRestoreProduct
+	case e.EntityType == "category" && e.Action == AuditDelete && e.After != nil:
+		return UndoRestoreCategory
+	case e.EntityType == "product" && e.Action == AuditUpdate:
+		var changes, before map[string]json.RawMessage
+		_ = json.Unmarshal(e.Changes, &changes)
+		_ = json.Unmarshal(e.Before, &before)
+		for _, k := range []string{"category_ids", "subcategory_ids"} {
+			// Snapshots taken before mappings were recorded cannot be reverted
+			if _, changed := changes[k]; changed && before[k] != nil {
+				return UndoRevertCategories
+			}
+		}
+	}
+	return ""
+}
+
+// ActivityItem is an audit entry as shown in the admin activity feed
+type ActivityItem struct {
+	AuditID       int64     `json:"audit_id"`
+	OccurredAt    time.Time `json:"occurred_at"`
+	ActorUserID   *string   `json:"actor_user_id,omitempty"`
+	ActorEmail    *string   `json:"actor_email,omitempty"`
+	EntityType    string    `json:"entity_type"`
+	EntityID      *string   `json:"entity_id,omitempty"`
+	EntityLabel   string    `json:"entity_label,omitempty"`
+	Action        string    `json:"action"`
+	Route         string    `json:"route"`
+	ChangedFields []string  `json:"changed_fields"`
+	// UndoKind is set for undoable action types; Undoable says whether it can still be undone now
+	UndoKind        string     `json:"undo_kind,omitempty"`
+	Undoable        bool       `json:"undoable"`
+	UndoExpiresAt   *time.Time `json:"undo_expires_at,omitempty"`
+	UndoneByAuditID *int64     `json:"undone_by_audit_id,omitempty"`
+	UndoOfAuditID   *int64     `json:"undo_of_audit_id,omitempty"`
+}
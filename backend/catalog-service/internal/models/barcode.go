@@ -0,0 +1,36 @@
+package models
+
+import "strings"
+
+// NormalizeBarcode validates a scanned or entered GTIN (EAN-8, UPC-A, EAN-13 or GTIN-14) by length and
+// check digit and returns its canonical form: UPC-A codes are stored as their EAN-13 equivalent (leading
+// zero) so the same item can't be registered twice. ok is false for anything else.
+func NormalizeBarcode(code string) (string, bool) {
+	code = strings.ReplaceAll(strings.TrimSpace(code), " ", "")
+	switch len(code) {
+	case 8, 13, 14:
+	case 12:
+		code = "0" + code
+	default:
+		return "", false
+	}
+	for i := 0; i < len(code); i++ {
+		if code[i] < '0' || code[i] > '9' {
+			return "", false
+		}
+	}
+	// Weights alternate 3, 1, 3, ... leftwards from the digit before the check digit
+	sum := 0
+	last := len(code) - 1
+	for i := 0; i < last; i++ {
+		d := int(code[i] - '0')
+		if (last-i)%2 == 1 {
+			d *= 3
+		}
+		sum += d
+	}
+	if (10-sum%10)%10 != int(code[last]-'0') {
+		return "", false
+	}
+	return code, true
+}
@@ -0,0 +1,48 @@
+package models
+
+import "time"
+
+// Catalog export statuses
+const (
+	CatalogExportRunning   = "running"
+	CatalogExportCompleted = "completed"
+	CatalogExportFailed    = "failed"
+)
+
+// CatalogExportSchemaVersion is bumped whenever a dataset's columns change incompatibly; it is part
+// of every snapshot key so consumers can pin to a layout
+const CatalogExportSchemaVersion = "v1"
+
+// CatalogExport is one snapshot run. Scheduled runs happen once per UTC day; admins can trigger
+// extra manual runs.
+type CatalogExport struct {
+	ID           int64                  `json:"export_id"`
+	SnapshotDate string                 `json:"snapshot_date"`
+	Trigger      string                 `json:"trigger"`
+	Status       string                 `json:"status"`
+	ManifestKey  *string                `json:"manifest_key,omitempty"`
+	Datasets     []CatalogExportDataset `json:"datasets"`
+	Error        *string                `json:"error,omitempty"`
+	StartedAt    time.Time              `json:"started_at"`
+	FinishedAt   *time.Time             `json:"finished_at,omitempty"`
+}
+
+// CatalogExportDataset describes one gzip-compressed JSON Lines file of a snapshot
+type CatalogExportDataset struct {
+	Name   string `json:"name"`
+	Key    string `json:"key"`
+	Rows   int64  `json:"rows"`
+	Bytes  int64  `json:"bytes"`
+	SHA256 string `json:"sha256"`
+}
+
+// CatalogExportManifest is written next to the datasets (and as latest.json) so consumers can
+// verify a snapshot is complete before reading it
+type CatalogExportManifest struct {
+	SchemaVersion string                 `json:"schema_version"`
+	ExportID      int64                  `json:"export_id"`
+	SnapshotDate  string                 `json:"snapshot_date"`
+	GeneratedAt   time.Time              `json:"generated_at"`
+	Format        string                 `json:"format"`
+	Datasets      []CatalogExportDataset `json:"datasets"`
+}
@@ -0,0 +1,58 @@
+package models
+
+import "time"
+
+// Changeset statuses
+const (
+	ChangesetDraft     = "draft"
+	ChangesetScheduled = "scheduled"
+	ChangesetApplied   = "applied"
+	ChangesetDiscarded = "discarded"
+	ChangesetFailed    = "failed"
+)
+
+// Changeset stages product edits (prices, category moves) that go live together, either when an
+// admin applies it or at ScheduledFor. Draft and scheduled changesets can still be edited.
+type Changeset struct {
+	ID           int64      `json:"changeset_id"`
+	Name         string     `json:"name"`
+	Status       string     `json:"status"`
+	ScheduledFor *time.Time `json:"scheduled_for,omitempty"`
+	ItemCount    int        `json:"item_count"`
+	CreatedBy    *string    `json:"created_by,omitempty"`
+	AppliedAt    *time.Time `json:"applied_at,omitempty"`
+	LastError    *string    `json:"last_error,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
+
+// Editable reports whether items may still be staged or removed
+func (cs *Changeset) Editable() bool {
+	return cs.Status == ChangesetDraft || cs.Status == ChangesetScheduled || cs.Status == ChangesetFailed
+}
+
+// ChangesetItem is the staged change of one product. Nil fields are left untouched on apply;
+// ClearStrikethrough removes the strikethrough price, and non-nil category lists replace the mappings.
+type ChangesetItem struct {
+	ProductID          int      `json:"product_id" binding:"required"`
+	MainPrice          *float64 `json:"main_price,omitempty"`
+	StrikethroughPrice *float64 `json:"strikethrough_price,omitempty"`
+	ClearStrikethrough bool     `json:"clear_strikethrough,omitempty"`
+	CategoryIDs        []int    `json:"category_ids"`
+	SubcategoryIDs     []int    `json:"subcategory_ids"`
+}
+
+// FieldChange is one line of a changeset preview
+type FieldChange struct {
+	Field string      `json:"field"`
+	From  interface{} `json:"from"`
+	To    interface{} `json:"to"`
+}
+
+// ChangesetDiff is the preview of a staged item against the live product
+type ChangesetDiff struct {
+	ProductID int           `json:"product_id"`
+	Title     string        `json:"title"`
+	Missing   bool          `json:"missing,omitempty"` // product no longer exists; the item would fail the apply
+	Changes   []FieldChange `json:"changes"`
+}
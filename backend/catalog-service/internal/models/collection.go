@@ -0,0 +1,26 @@
+package models
+
+import "time"
+
+// ProductCollection names a computed product collection
+type ProductCollection string
+
+const (
+	// CollectionNewArrivals holds products created within the window
+	CollectionNewArrivals ProductCollection = "new_arrivals"
+	// CollectionBackInStock holds products whose stock went from 0 to >0 within the window and are still in stock
+	CollectionBackInStock ProductCollection = "back_in_stock"
+)
+
+// ProductCollectionItem is a product in a computed collection; Since is its creation or restock time
+type ProductCollectionItem struct {
+	ProductID          int       `json:"product_id"`
+	UUID               string    `json:"uuid"`
+	Title              string    `json:"title"`
+	MiniAppType        string    `json:"mini_app_type"`
+	MainPrice          float64   `json:"main_price"`
+	StrikethroughPrice *float64  `json:"strikethrough_price"`
+	StockLeft          int       `json:"stock_left"`
+	ImageURL           *string   `json:"image_url"`
+	Since              time.Time `json:"since"`
+}
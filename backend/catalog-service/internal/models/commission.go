@@ -0,0 +1,28 @@
+package models
+
+import "time"
+
+// CommissionRate is one version of the commission charged on a manufacturer's sales.
+// A rate applies to a category, a manufacturer, both, or (neither) everything; a new version of the
+// same scope supersedes the previous one from EffectiveFrom onwards. The most specific scope wins.
+type CommissionRate struct {
+	ID                int64     `json:"rate_id"`
+	CategoryID        *int      `json:"category_id,omitempty"`
+	CategoryName      string    `json:"category_name,omitempty"`
+	ManufacturerOrgID *string   `json:"manufacturer_org_id,omitempty"`
+	ManufacturerName  string    `json:"manufacturer_name,omitempty"`
+	RatePct           float64   `json:"rate_pct"`
+	EffectiveFrom     time.Time `json:"effective_from"`
+	Notes             string    `json:"notes,omitempty"`
+	CreatedBy         string    `json:"created_by,omitempty"`
+	CreatedAt         time.Time `json:"created_at"`
+}
+
+// ResolvedCommissionRate is the rate that applies to a product sold by a manufacturer at a point in time
+type ResolvedCommissionRate struct {
+	ProductID         int       `json:"product_id"`
+	ManufacturerOrgID string    `json:"manufacturer_org_id,omitempty"`
+	At                time.Time `json:"at"`
+	RateID            *int64    `json:"rate_id"`
+	RatePct           *float64  `json:"rate_pct"`
+}
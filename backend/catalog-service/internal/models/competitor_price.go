@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+// CompetitorPrice is the last price observed for a product at a competitor (one row per source)
+type CompetitorPrice struct {
+	ProductID     int       `json:"product_id" binding:"required"`
+	Source        string    `json:"source" binding:"required"`
+	URL           string    `json:"url,omitempty"`
+	Price         float64   `json:"price" binding:"gt=0"`
+	LastCheckedAt time.Time `json:"last_checked_at"`
+}
+
+// CompetitorPriceAlert reports a product whose price exceeds a competitor's by more than the margin
+type CompetitorPriceAlert struct {
+	ProductID       int       `json:"product_id"`
+	SKU             string    `json:"sku"`
+	Title           string    `json:"title"`
+	OurPrice        float64   `json:"our_price"`
+	Source          string    `json:"source"`
+	URL             string    `json:"url,omitempty"`
+	CompetitorPrice float64   `json:"competitor_price"`
+	DifferencePct   float64   `json:"difference_pct"`
+	LastCheckedAt   time.Time `json:"last_checked_at"`
+}
@@ -0,0 +1,51 @@
+package models
+
+import "time"
+
+// Experiment statuses
+const (
+	ExperimentStatusDraft   = "draft"
+	ExperimentStatusRunning = "running"
+	ExperimentStatusStopped = "stopped"
+)
+
+// ExperimentVariant is one arm of an experiment; Weight is relative to the other arms
+type ExperimentVariant struct {
+	Key    string `json:"key" binding:"required"`
+	Weight int    `json:"weight" binding:"min=0"`
+}
+
+// Experiment defines an A/B test on product presentation (e.g. featured ordering, price display).
+// The first variant is the control, served when a caller cannot be bucketed.
+type Experiment struct {
+	Key         string              `json:"key"`
+	Description string              `json:"description,omitempty"`
+	Variants    []ExperimentVariant `json:"variants"`
+	Status      string              `json:"status"`
+	Salt        string              `json:"salt,omitempty"`
+	CreatedAt   time.Time           `json:"created_at"`
+	UpdatedAt   time.Time           `json:"updated_at"`
+}
+
+// ExperimentAssignment is the variant a caller sees for an experiment
+type ExperimentAssignment struct {
+	ExperimentKey string `json:"experiment_key"`
+	Variant       string `json:"variant"`
+}
+
+// ExperimentExposure records that a caller was actually shown a variant
+type ExperimentExposure struct {
+	ExperimentKey string     `json:"experiment_key" binding:"required"`
+	Context       string     `json:"context"`
+	ExposedAt     *time.Time `json:"exposed_at"`
+	Variant       string     `json:"-"`
+	UserID        *string    `json:"-"`
+	SessionID     string     `json:"-"`
+}
+
+// ExperimentVariantResult aggregates exposures for one variant
+type ExperimentVariantResult struct {
+	Variant     string `json:"variant"`
+	Exposures   int    `json:"exposures"`
+	UniqueUsers int    `json:"unique_users"`
+}
@@ -0,0 +1,76 @@
+package models
+
+import "time"
+
+// Allergen is one of the 14 allergens that Swiss food labelling law (like the EU's) requires to be declared
+type Allergen string
+
+const (
+	AllergenGluten      Allergen = "gluten"
+	AllergenCrustaceans Allergen = "crustaceans"
+	AllergenEggs        Allergen = "eggs"
+	AllergenFish        Allergen = "fish"
+	AllergenPeanuts     Allergen = "peanuts"
+	AllergenSoybeans    Allergen = "soybeans"
+	AllergenMilk        Allergen = "milk"
+	AllergenNuts        Allergen = "nuts" // tree nuts
+	AllergenCelery      Allergen = "celery"
+	AllergenMustard     Allergen = "mustard"
+	AllergenSesame      Allergen = "sesame"
+	AllergenSulphites   Allergen = "sulphites"
+	AllergenLupin       Allergen = "lupin"
+	AllergenMolluscs    Allergen = "molluscs"
+)
+
+// Allergens lists the declarable allergens in label order
+var Allergens = []Allergen{
+	AllergenGluten, AllergenCrustaceans, AllergenEggs, AllergenFish, AllergenPeanuts, AllergenSoybeans, AllergenMilk,
+	AllergenNuts, AllergenCelery, AllergenMustard, AllergenSesame, AllergenSulphites, AllergenLupin, AllergenMolluscs,
+}
+
+// IsValid reports whether a is a declarable allergen
+func (a Allergen) IsValid() bool {
+	for _, v := range Allergens {
+		if a == v {
+			return true
+		}
+	}
+	return false
+}
+
+// NutritionFacts is the nutrition declaration per 100 g or 100 ml. Energy, fat, saturates, carbohydrate,
+// sugars, protein and salt are mandatory; fibre and the serving size are optional. Nutrients are in grams.
+type NutritionFacts struct {
+	Basis         string   `json:"basis" binding:"required,oneof=100g 100ml"`
+	EnergyKJ      float64  `json:"energy_kj" binding:"gte=0,lte=4000"`
+	EnergyKcal    float64  `json:"energy_kcal" binding:"gte=0,lte=1000"`
+	Fat           float64  `json:"fat" binding:"gte=0,lte=100"`
+	SaturatedFat  float64  `json:"saturated_fat" binding:"gte=0,lte=100"`
+	Carbohydrates float64  `json:"carbohydrates" binding:"gte=0,lte=100"`
+	Sugars        float64  `json:"sugars" binding:"gte=0,lte=100"`
+	Fibre         *float64 `json:"fibre,omitempty" binding:"omitempty,gte=0,lte=100"`
+	Protein       float64  `json:"protein" binding:"gte=0,lte=100"`
+	Salt          float64  `json:"salt" binding:"gte=0,lte=100"`
+	ServingSize   *float64 `json:"serving_size,omitempty" binding:"omitempty,gt=0"` // in g or ml, matching the basis
+}
+
+// ProductFoodLabel is the compliance label data of a food product: nutrition facts, ingredients,
+// declared allergens (and possible traces) and the country of origin (ISO 3166-1 alpha-2, e.g. "CH").
+type ProductFoodLabel struct {
+	ProductID     int             `json:"product_id"`
+	Nutrition     *NutritionFacts `json:"nutrition"`
+	Ingredients   string          `json:"ingredients,omitempty"`
+	Allergens     []Allergen      `json:"allergens"`
+	MayContain    []Allergen      `json:"may_contain"`
+	OriginCountry string          `json:"origin_country,omitempty"`
+	UpdatedAt     time.Time       `json:"updated_at"`
+}
+
+// SetProductFoodLabelRequest replaces a product's label data
+type SetProductFoodLabelRequest struct {
+	Nutrition     *NutritionFacts `json:"nutrition"`
+	Ingredients   string          `json:"ingredients" binding:"max=5000"`
+	Allergens     []Allergen      `json:"allergens" binding:"max=14"`
+	MayContain    []Allergen      `json:"may_contain" binding:"max=14"`
+	OriginCountry string          `json:"origin_country" binding:"omitempty,iso3166_1_alpha2"`
+}
@@ -0,0 +1,29 @@
+package models
+
+import "time"
+
+// Low-stock threshold sources, from most to least specific
+const (
+	LowStockThresholdProduct = "product"
+	LowStockThresholdStore   = "store"
+	LowStockThresholdDefault = "default"
+)
+
+// LowStockProduct is a stock-tracked product whose stock_left fell below its threshold: the
+// product's own, else its store's, else LOW_STOCK_DEFAULT_THRESHOLD
+type LowStockProduct struct {
+	ProductID       int        `json:"product_id"`
+	SKU             string     `json:"sku"`
+	Title           string     `json:"title"`
+	StoreID         *int       `json:"store_id,omitempty"`
+	StoreName       *string    `json:"store_name,omitempty"`
+	StockLeft       int        `json:"stock_left"`
+	Threshold       int        `json:"threshold"`
+	ThresholdSource string     `json:"threshold_source"`
+	AlertedAt       *time.Time `json:"alerted_at,omitempty"`
+}
+
+// LowStockThresholdRequest sets a product or store threshold; null clears it so the next level applies
+type LowStockThresholdRequest struct {
+	Threshold *int `json:"threshold" binding:"omitempty,gte=0"`
+}
@@ -14,8 +14,8 @@ const (
 // Backed by table `organizations`
 type Organization struct {
 	ID             string  `json:"org_id" db:"org_id"`
-	OrgType        OrgType `json:"org_type" db:"org_type"`
-	Name           string  `json:"name" db:"name"`
+	OrgType        OrgType `json:"org_type" db:"org_type" binding:"required"`
+	Name           string  `json:"name" db:"name" binding:"notblank"`
 	ContactEmail   *string `json:"contact_email,omitempty" db:"contact_email"`
 	ContactPhone   *string `json:"contact_phone,omitempty" db:"contact_phone"`
 	ContactAddress *string `json:"contact_address,omitempty" db:"contact_address"`
@@ -29,6 +29,8 @@ type Region struct {
 	ID          int     `json:"region_id" db:"region_id"`
 	Name        string  `json:"name" db:"name"`
 	Description *string `json:"description,omitempty" db:"description"`
+	Code        *string `json:"code,omitempty" db:"code"` // short market code usable as ?region=, e.g. "CH" or "IT"
+	Currency    string  `json:"currency" db:"currency"`   // ISO 4217 code of the region's prices, e.g. "CHF"
 }
 
 // ProductSourcing maps product -> manufacturer org -> region
@@ -68,3 +70,78 @@ type OrganizationUserAssignment struct {
 	UserID  string `json:"user_id"`
 	OrgRole string `json:"org_role"`
 }
+
+// ParentTypes lists the types an organization of this type may have as parent. Brands are roots;
+// the others sit under a Brand or under an organization of their own type (subsidiaries, sub-partners).
+func (t OrgType) ParentTypes() []OrgType {
+	if t == OrgTypeBrand {
+		return nil
+	}
+	return []OrgType{OrgTypeBrand, t}
+}
+
+// OrgLinkType names a typed link from an organization to catalog entities
+type OrgLinkType string
+
+const (
+	// OrgLinkManagesStore links a Partner to the stores it runs (admin_store_partners)
+	OrgLinkManagesStore OrgLinkType = "manages_store"
+	// OrgLinkOwnsProduct links a Manufacturer to its products (admin_products.owner_org_id)
+	OrgLinkOwnsProduct OrgLinkType = "owns_product"
+)
+
+// LinkType is the link organizations of this type hold, empty if none
+func (t OrgType) LinkType() OrgLinkType {
+	switch t {
+	case OrgTypePartner:
+		return OrgLinkManagesStore
+	case OrgTypeManufacturer:
+		return OrgLinkOwnsProduct
+	}
+	return ""
+}
+
+// OrgNode is an organization in a hierarchy. Depth is relative to the queried organization and
+// negative for its ancestors; the counts are of the organization's own links.
+type OrgNode struct {
+	OrgID        string      `json:"org_id"`
+	OrgType      OrgType     `json:"org_type"`
+	Name         string      `json:"name"`
+	ParentOrgID  *string     `json:"parent_org_id,omitempty"`
+	Depth        int         `json:"depth"`
+	LinkType     OrgLinkType `json:"link_type,omitempty"`
+	StoreCount   int         `json:"store_count"`
+	ProductCount int         `json:"product_count"`
+}
+
+// OrgHierarchy is an organization with its ancestors (root first) and descendants (depth-first)
+type OrgHierarchy struct {
+	Organization OrgNode   `json:"organization"`
+	Ancestors    []OrgNode `json:"ancestors"`
+	Descendants  []OrgNode `json:"descendants"`
+}
+
+// OrgStore is a store reachable from an organization: managed by it or by one of its descendants
+// (ViaOrgID, the closest one if several)
+type OrgStore struct {
+	StoreID    int       `json:"store_id"`
+	Name       string    `json:"name"`
+	City       string    `json:"city"`
+	Type       StoreType `json:"type"`
+	RegionID   *int      `json:"region_id,omitempty"`
+	IsActive   bool      `json:"is_active"`
+	ViaOrgID   string    `json:"via_org_id"`
+	ViaOrgName string    `json:"via_org_name"`
+}
+
+// OrgProduct is a product owned by an organization or one of its descendants (OwnerOrgID)
+type OrgProduct struct {
+	ProductID    int         `json:"product_id"`
+	SKU          string      `json:"sku"`
+	Title        string      `json:"title"`
+	MiniAppType  MiniAppType `json:"mini_app_type"`
+	StoreID      *int        `json:"store_id,omitempty"`
+	IsActive     bool        `json:"is_active"`
+	OwnerOrgID   string      `json:"owner_org_id"`
+	OwnerOrgName string      `json:"owner_org_name"`
+}
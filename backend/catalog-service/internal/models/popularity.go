@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+// ProductViewEvent represents a single product view reported by a mini-app
+type ProductViewEvent struct {
+	ProductID   int        `json:"product_id" binding:"required"`
+	SessionID   string     `json:"session_id"`
+	MiniAppType string     `json:"mini_app_type"`
+	Source      string     `json:"source"`
+	ViewedAt    *time.Time `json:"viewed_at"`
+	UserID      *string    `json:"-"`
+}
+
+// ProductPopularity is the decayed popularity score of a product over a window of days
+type ProductPopularity struct {
+	ProductID int     `json:"product_id"`
+	Views     int     `json:"views"`
+	Score     float64 `json:"score"`
+}
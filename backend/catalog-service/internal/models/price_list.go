@@ -0,0 +1,41 @@
+package models
+
+import "time"
+
+// PriceList is a set of negotiated (contract) prices for one organization.
+// A list applies while it is active and ValidFrom <= now < ValidUntil (nil = open-ended).
+type PriceList struct {
+	ID         int64      `json:"price_list_id"`
+	OrgID      string     `json:"org_id"`
+	Name       string     `json:"name"`
+	IsActive   bool       `json:"is_active"`
+	ValidFrom  time.Time  `json:"valid_from"`
+	ValidUntil *time.Time `json:"valid_until,omitempty"`
+	ItemCount  int        `json:"item_count"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+}
+
+// PriceListItem is the contract price of one product within a price list
+type PriceListItem struct {
+	ProductID    int     `json:"product_id" binding:"required"`
+	Price        float64 `json:"price" binding:"min=0"`
+	ProductTitle string  `json:"product_title,omitempty"`
+	DefaultPrice float64 `json:"default_price,omitempty"`
+}
+
+// ResolvedPrice is the price an organization pays for a product.
+// Source is "price_list" when a contract price applies, otherwise "default".
+type ResolvedPrice struct {
+	ProductID    int     `json:"product_id"`
+	Price        float64 `json:"price"`
+	DefaultPrice float64 `json:"default_price"`
+	Source       string  `json:"source"`
+	PriceListID  *int64  `json:"price_list_id,omitempty"`
+}
+
+// Price resolution sources
+const (
+	PriceSourcePriceList = "price_list"
+	PriceSourceDefault   = "default"
+)
@@ -75,58 +75,79 @@ func (a *MiniAppTypeArray) Scan(value interface{}) error {
 
 // Product represents a product in the catalog
 type Product struct {
-	ID                      int         `json:"id" db:"product_id"`
-	UUID                    string      `json:"uuid" db:"product_uuid"`
-	SKU                     string      `json:"sku" db:"sku"`
-	Title                   string      `json:"title" db:"title"`
-	DescriptionShort        string      `json:"description_short" db:"description_short"`
-	DescriptionLong         string      `json:"description_long" db:"description_long"`
-	StoreType               StoreType   `json:"store_type" db:"store_type"`
-	MiniAppType             MiniAppType `json:"mini_app_type" db:"mini_app_type"`
-	StoreID                 *int        `json:"store_id" db:"store_id"`
-	ShelfCode               *string     `json:"shelf_code,omitempty" db:"shelf_code"`
-	MainPrice               float64     `json:"main_price" db:"main_price"`
-	StrikethroughPrice      *float64    `json:"strikethrough_price" db:"strikethrough_price"`
-	CostPrice               *float64    `json:"cost_price,omitempty" db:"cost_price"` // Admin only - excluded from public API
-	Weight                  float64     `json:"weight" db:"weight"`
-	StockLeft               int         `json:"stock_left" db:"stock_left"`
-	MinimumOrderQuantity    int         `json:"minimum_order_quantity" db:"minimum_order_quantity"`
-	IsActive                bool        `json:"is_active" db:"is_active"`
-	IsFeatured              bool        `json:"is_featured" db:"is_featured"`
-	IsMiniAppRecommendation bool        `json:"is_mini_app_recommendation" db:"is_mini_app_recommendation"`
-	ImageUrls               []string    `json:"image_urls"`
-	CategoryIds             []string    `json:"category_ids"`
-	SubcategoryIds          []string    `json:"subcategory_ids"`
-	StockQuantity           *int        `json:"stock_quantity"` // Legacy field for backward compatibility
-	CreatedAt               time.Time   `json:"created_at" db:"created_at"`
-	UpdatedAt               time.Time   `json:"updated_at" db:"updated_at"`
+	ID                      int              `json:"id" db:"product_id"`
+	UUID                    string           `json:"uuid" db:"product_uuid"`
+	SKU                     string           `json:"sku" db:"sku" binding:"notblank"`
+	Title                   string           `json:"title" db:"title" binding:"notblank"`
+	DescriptionShort        string           `json:"description_short" db:"description_short"`
+	DescriptionLong         string           `json:"description_long" db:"description_long"`
+	StoreType               StoreType        `json:"store_type" db:"store_type"`
+	MiniAppType             MiniAppType      `json:"mini_app_type" db:"mini_app_type" binding:"mini_app_type"`
+	StoreID                 *int             `json:"store_id" db:"store_id"`
+	ShelfCode               *string          `json:"shelf_code,omitempty" db:"shelf_code"`
+	Barcode                 *string          `json:"barcode" db:"barcode"` // EAN-8/EAN-13/UPC-A/GTIN-14, unique across products
+	MainPrice               float64          `json:"main_price" db:"main_price" binding:"gte=0"`
+	StrikethroughPrice      *float64         `json:"strikethrough_price" db:"strikethrough_price" binding:"omitempty,gte=0"`
+	CostPrice               *float64         `json:"cost_price,omitempty" db:"cost_price" binding:"omitempty,gte=0"` // Admin only - excluded from public API
+	TaxClass                *TaxClass        `json:"tax_class" db:"tax_class"`                                       // Admin only; required before a product can be active
+	Weight                  float64          `json:"weight" db:"weight" binding:"gte=0"`
+	StockLeft               int              `json:"stock_left" db:"stock_left" binding:"gte=0"`
+	MinimumOrderQuantity    int              `json:"minimum_order_quantity" db:"minimum_order_quantity" binding:"gte=0"`
+	IsActive                bool             `json:"is_active" db:"is_active"`
+	IsFeatured              bool             `json:"is_featured" db:"is_featured"`
+	IsMiniAppRecommendation bool             `json:"is_mini_app_recommendation" db:"is_mini_app_recommendation"`
+	ImageUrls               []string         `json:"image_urls"`
+	ThumbnailUrls           []string         `json:"thumbnail_urls"` // per image, falling back to the original
+	CategoryIds             []string         `json:"category_ids"`
+	SubcategoryIds          []string         `json:"subcategory_ids"`
+	StockQuantity           *int             `json:"stock_quantity"` // Legacy field for backward compatibility
+	Variants                []ProductVariant `json:"variants"`
+	RelatedProducts         []RelatedProduct `json:"related_products"`
+	CreatedAt               time.Time        `json:"created_at" db:"created_at"`
+	UpdatedAt               time.Time        `json:"updated_at" db:"updated_at"`
+
+	// Nutrition, allergens and origin of food products; nil when not entered (managed via /products/:id/food-label)
+	FoodLabel *ProductFoodLabel `json:"food_label,omitempty"`
+
+	// Price in the requested region; only set when listing with ?region=
+	RegionPricing *RegionPricing `json:"region_pricing,omitempty"`
 }
 
 // PublicProduct represents a product for public API (excludes cost_price)
 type PublicProduct struct {
-	ID                      int         `json:"id"`
-	UUID                    string      `json:"uuid"`
-	SKU                     string      `json:"sku"`
-	Title                   string      `json:"title"`
-	DescriptionShort        string      `json:"description_short"`
-	DescriptionLong         string      `json:"description_long"`
-	StoreType               StoreType   `json:"store_type"`
-	MiniAppType             MiniAppType `json:"mini_app_type"`
-	StoreID                 *int        `json:"store_id"`
-	MainPrice               float64     `json:"main_price"`
-	StrikethroughPrice      *float64    `json:"strikethrough_price"`
-	Weight                  float64     `json:"weight"`
-	StockLeft               int         `json:"stock_left"`
-	MinimumOrderQuantity    int         `json:"minimum_order_quantity"`
-	IsActive                bool        `json:"is_active"`
-	IsFeatured              bool        `json:"is_featured"`
-	IsMiniAppRecommendation bool        `json:"is_mini_app_recommendation"`
-	ImageUrls               []string    `json:"image_urls"`
-	CategoryIds             []string    `json:"category_ids"`
-	SubcategoryIds          []string    `json:"subcategory_ids"`
-	StockQuantity           *int        `json:"stock_quantity"`
-	CreatedAt               time.Time   `json:"created_at"`
-	UpdatedAt               time.Time   `json:"updated_at"`
+	ID                      int                    `json:"id"`
+	UUID                    string                 `json:"uuid"`
+	SKU                     string                 `json:"sku"`
+	Title                   string                 `json:"title"`
+	DescriptionShort        string                 `json:"description_short"`
+	DescriptionLong         string                 `json:"description_long"`
+	StoreType               StoreType              `json:"store_type"`
+	MiniAppType             MiniAppType            `json:"mini_app_type"`
+	StoreID                 *int                   `json:"store_id"`
+	Barcode                 *string                `json:"barcode,omitempty"`
+	MainPrice               float64                `json:"main_price"`
+	StrikethroughPrice      *float64               `json:"strikethrough_price"`
+	Weight                  float64                `json:"weight"`
+	StockLeft               int                    `json:"stock_left"`
+	MinimumOrderQuantity    int                    `json:"minimum_order_quantity"`
+	IsActive                bool                   `json:"is_active"`
+	IsFeatured              bool                   `json:"is_featured"`
+	IsMiniAppRecommendation bool                   `json:"is_mini_app_recommendation"`
+	ImageUrls               []string               `json:"image_urls"`
+	ThumbnailUrls           []string               `json:"thumbnail_urls"`
+	CategoryIds             []string               `json:"category_ids"`
+	SubcategoryIds          []string               `json:"subcategory_ids"`
+	StockQuantity           *int                   `json:"stock_quantity"`
+	Variants                []PublicProductVariant `json:"variants"`
+	RelatedProducts         []RelatedProduct       `json:"related_products"`
+	CreatedAt               time.Time              `json:"created_at"`
+	UpdatedAt               time.Time              `json:"updated_at"`
+
+	// Nutrition, allergens and origin of food products; nil when not entered
+	FoodLabel *ProductFoodLabel `json:"food_label,omitempty"`
+
+	// Price in the requested region; only set when listing with ?region=
+	RegionPricing *RegionPricing `json:"region_pricing,omitempty"`
 }
 
 // ToPublicProduct converts a Product to PublicProduct (excludes cost_price)
@@ -141,6 +162,7 @@ func (p *Product) ToPublicProduct() PublicProduct {
 		StoreType:               p.StoreType,
 		MiniAppType:             p.MiniAppType,
 		StoreID:                 p.StoreID,
+		Barcode:                 p.Barcode,
 		MainPrice:               p.MainPrice,
 		StrikethroughPrice:      p.StrikethroughPrice,
 		Weight:                  p.Weight,
@@ -150,9 +172,14 @@ func (p *Product) ToPublicProduct() PublicProduct {
 		IsFeatured:              p.IsFeatured,
 		IsMiniAppRecommendation: p.IsMiniAppRecommendation,
 		ImageUrls:               p.ImageUrls,
+		ThumbnailUrls:           p.ThumbnailUrls,
 		CategoryIds:             p.CategoryIds,
 		SubcategoryIds:          p.SubcategoryIds,
 		StockQuantity:           p.StockQuantity,
+		Variants:                PublicVariants(p.Variants),
+		RelatedProducts:         PublicRelatedProducts(p.RelatedProducts),
+		FoodLabel:               p.FoodLabel,
+		RegionPricing:           p.RegionPricing,
 		CreatedAt:               p.CreatedAt,
 		UpdatedAt:               p.UpdatedAt,
 	}
@@ -182,11 +209,11 @@ func (p *Product) HasStock() bool {
 // Category represents a product category
 type Category struct {
 	ID                   int              `json:"id" db:"category_id"`
-	Name                 string           `json:"name" db:"name"`
+	Name                 string           `json:"name" db:"name" binding:"notblank"`
 	StoreTypeAssociation string           `json:"store_type_association" db:"store_type_association"`
-	MiniAppAssociation   MiniAppTypeArray `json:"mini_app_association" db:"mini_app_association"`
+	MiniAppAssociation   MiniAppTypeArray `json:"mini_app_association" db:"mini_app_association" binding:"min=1,dive,mini_app_type"`
 	StoreID              *int             `json:"store_id" db:"store_id"`
-	DisplayOrder         int              `json:"display_order" db:"display_order"`
+	DisplayOrder         int              `json:"display_order" db:"display_order" binding:"min=1"`
 	IsActive             bool             `json:"is_active" db:"is_active"`
 	ImageURL             *string          `json:"image_url" db:"image_url"`
 	Subcategories        []Subcategory    `json:"subcategories,omitempty"`
@@ -198,34 +225,41 @@ type Category struct {
 	StoreType      *StoreType `json:"store_type,omitempty"`
 	CreatedAt      time.Time  `json:"created_at" db:"created_at"`
 	UpdatedAt      time.Time  `json:"updated_at" db:"updated_at"`
+	ImageRenditions
 }
 
 // Subcategory represents a product subcategory
 type Subcategory struct {
 	ID               int       `json:"id" db:"subcategory_id"`
 	ParentCategoryID int       `json:"parent_category_id" db:"parent_category_id"`
-	Name             string    `json:"name" db:"name"`
+	Name             string    `json:"name" db:"name" binding:"notblank"`
 	ImageURL         *string   `json:"image_url" db:"image_url"`
-	DisplayOrder     int       `json:"display_order" db:"display_order"`
+	DisplayOrder     int       `json:"display_order" db:"display_order" binding:"min=1"`
 	IsActive         bool      `json:"is_active" db:"is_active"`
 	CreatedAt        time.Time `json:"created_at" db:"created_at"`
 	UpdatedAt        time.Time `json:"updated_at" db:"updated_at"`
+	ImageRenditions
 }
 
 // Store represents a physical store location
 type Store struct {
 	ID        int       `json:"id" db:"store_id"`
-	Name      string    `json:"name" db:"name"`
+	Name      string    `json:"name" db:"name" binding:"notblank"`
 	City      string    `json:"city" db:"city"`
 	Address   string    `json:"address" db:"address"`
-	Latitude  float64   `json:"latitude" db:"latitude"`
-	Longitude float64   `json:"longitude" db:"longitude"`
+	Latitude  float64   `json:"latitude" db:"latitude" binding:"min=-90,max=90"`
+	Longitude float64   `json:"longitude" db:"longitude" binding:"min=-180,max=180"`
 	Type      StoreType `json:"type" db:"type"`
 	RegionID  *int      `json:"region_id,omitempty" db:"region_id"`
+	Currency  *string   `json:"currency,omitempty"` // currency of the store's region; nil without a region
 	ImageURL  *string   `json:"image_url" db:"image_url"`
 	IsActive  bool      `json:"is_active" db:"is_active"`
 	CreatedAt time.Time `json:"created_at" db:"created_at"`
 	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+	ImageRenditions
+	StoreSchedule
+	IsOpenNow  *bool    `json:"is_open_now,omitempty"` // computed; omitted when no opening hours are configured
+	DistanceKm *float64 `json:"distance_km,omitempty"` // computed; only when the caller's location is given
 }
 
 // Manufacturer represents a product manufacturer
@@ -239,6 +273,15 @@ type Manufacturer struct {
 	UpdatedAt     time.Time `json:"updated_at" db:"updated_at"`
 }
 
+// CloneProductRequest configures POST /products/:id/clone. An empty SKU derives one from the source
+// ("<sku>-COPY", "<sku>-COPY-2", ...); images and category mappings are copied unless disabled.
+type CloneProductRequest struct {
+	SKU            string  `json:"sku" binding:"omitempty,notblank,max=100"`
+	Title          *string `json:"title" binding:"omitempty,notblank"`
+	CopyImages     *bool   `json:"copy_images"`
+	CopyCategories *bool   `json:"copy_categories"`
+}
+
 // ProductImage represents a product image with enhanced functionality
 type ProductImage struct {
 	ID           int       `json:"id" db:"image_id"`
@@ -247,6 +290,14 @@ type ProductImage struct {
 	DisplayOrder int       `json:"display_order" db:"display_order"`
 	IsPrimary    bool      `json:"is_primary" db:"is_primary"`
 	CreatedAt    time.Time `json:"created_at" db:"created_at"`
+	ImageRenditions
+}
+
+// ImageRenditions are the WebP versions generated after an image upload; nil until processed
+type ImageRenditions struct {
+	ThumbnailURL *string `json:"thumbnail_url,omitempty" db:"thumbnail_url"`
+	MediumURL    *string `json:"medium_url,omitempty" db:"medium_url"`
+	WebPURL      *string `json:"webp_url,omitempty" db:"webp_url"`
 }
 
 // Inventory represents stock quantity for a product at a specific store
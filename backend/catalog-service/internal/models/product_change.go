@@ -0,0 +1,62 @@
+package models
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Product change kinds
+const (
+	ProductChangeCreated = "created"
+	ProductChangeUpdated = "updated"
+	ProductChangeDeleted = "deleted"
+)
+
+// ProductChange is one product of the change feed. Deleted covers products removed, deactivated or
+// moved out of the requested store; clients drop those and re-fetch the others.
+type ProductChange struct {
+	ProductID int       `json:"product_id"`
+	Change    string    `json:"change"`
+	ChangedAt time.Time `json:"changed_at"`
+}
+
+// ProductChangeFeed is one page of the change feed. A product changed several times within the page
+// is listed once.
+type ProductChangeFeed struct {
+	Changes    []ProductChange `json:"changes"`
+	NextCursor string          `json:"next_cursor"`
+	HasMore    bool            `json:"has_more"`
+}
+
+// ChangeCursor is a position in the product change log: the writing transaction, then the change
+// within it. Changes are read in transaction order so none commits behind a cursor already handed out.
+type ChangeCursor struct {
+	Txid     int64
+	ChangeID int64
+}
+
+// String is the opaque form given to clients, "<txid>-<change id>"
+func (c ChangeCursor) String() string {
+	return fmt.Sprintf("%d-%d", c.Txid, c.ChangeID)
+}
+
+// After reports whether c is past o
+func (c ChangeCursor) After(o ChangeCursor) bool {
+	return c.Txid > o.Txid || (c.Txid == o.Txid && c.ChangeID > o.ChangeID)
+}
+
+// ParseChangeCursor parses a cursor from String; ok is false for anything else
+func ParseChangeCursor(s string) (ChangeCursor, bool) {
+	txid, id, found := strings.Cut(strings.TrimSpace(s), "-")
+	if !found {
+		return ChangeCursor{}, false
+	}
+	t, err1 := strconv.ParseInt(txid, 10, 64)
+	n, err2 := strconv.ParseInt(id, 10, 64)
+	if err1 != nil || err2 != nil || t < 0 || n < 0 {
+		return ChangeCursor{}, false
+	}
+	return ChangeCursor{Txid: t, ChangeID: n}, true
+}
@@ -0,0 +1,57 @@
+package models
+
+import "time"
+
+// Product submission statuses
+const (
+	SubmissionPendingReview = "pending_review"
+	SubmissionApproved      = "approved"
+	SubmissionRejected      = "rejected"
+)
+
+// ProductSubmissionFields are the product fields a manufacturer may propose. Store placement, stock,
+// tax class and merchandising flags stay with admins.
+type ProductSubmissionFields struct {
+	SKU                  string      `json:"sku" binding:"notblank"`
+	Title                string      `json:"title" binding:"notblank"`
+	Description          string      `json:"description"`
+	MiniAppType          MiniAppType `json:"mini_app_type" binding:"mini_app_type"`
+	MainPrice            float64     `json:"main_price" binding:"gte=0"`
+	StrikethroughPrice   *float64    `json:"strikethrough_price" binding:"omitempty,gte=0"`
+	Weight               float64     `json:"weight" binding:"gte=0"`
+	MinimumOrderQuantity int         `json:"minimum_order_quantity" binding:"gte=0"`
+	Barcode              *string     `json:"barcode"`
+	CategoryIDs          []int       `json:"category_ids"`
+	SubcategoryIDs       []int       `json:"subcategory_ids"`
+}
+
+// ProductSubmissionRequest is the body of POST/PUT /manufacturer/products. org_id may be omitted
+// by users belonging to a single manufacturer.
+type ProductSubmissionRequest struct {
+	OrgID string `json:"org_id,omitempty"`
+	ProductSubmissionFields
+}
+
+// ProductSubmission is a manufacturer's proposed new product (ProductID nil until approved) or change
+// to one of its products, waiting for admin review
+type ProductSubmission struct {
+	ID            int64                   `json:"submission_id"`
+	OrgID         string                  `json:"org_id"`
+	ProductID     *int                    `json:"product_id,omitempty"`
+	Kind          string                  `json:"kind"` // create or update
+	Status        string                  `json:"status"`
+	Product       ProductSubmissionFields `json:"product"`
+	SubmittedBy   *string                 `json:"submitted_by,omitempty"`
+	ReviewComment *string                 `json:"review_comment,omitempty"`
+	ReviewedBy    *string                 `json:"reviewed_by,omitempty"`
+	ReviewedAt    *time.Time              `json:"reviewed_at,omitempty"`
+	CreatedAt     time.Time               `json:"created_at"`
+	UpdatedAt     time.Time               `json:"updated_at"`
+}
+
+// ReviewSubmissionRequest approves or rejects a submission. Rejections need a comment; approving a
+// new product needs its tax class so it can go live.
+type ReviewSubmissionRequest struct {
+	Comment  string    `json:"comment"`
+	TaxClass *TaxClass `json:"tax_class,omitempty"`
+}
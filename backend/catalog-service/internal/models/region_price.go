@@ -0,0 +1,31 @@
+package models
+
+import "time"
+
+// ProductRegionPrice is a product's price in one region, in that region's currency.
+// Products without one fall back to main_price in the base currency.
+type ProductRegionPrice struct {
+	RegionID           int       `json:"region_id" binding:"min=1"`
+	RegionName         string    `json:"region_name,omitempty"`
+	Currency           string    `json:"currency,omitempty"`
+	Price              float64   `json:"price" binding:"gte=0"`
+	StrikethroughPrice *float64  `json:"strikethrough_price" binding:"omitempty,gte=0"`
+	UpdatedBy          string    `json:"updated_by,omitempty"`
+	UpdatedAt          time.Time `json:"updated_at"`
+}
+
+// RegionPricing is the price shown for a product listed with ?region=.
+// Source is "region" when a regional price is set, otherwise "default" (main_price in the base currency).
+type RegionPricing struct {
+	RegionID           int      `json:"region_id"`
+	Currency           string   `json:"currency"`
+	Price              float64  `json:"price"`
+	StrikethroughPrice *float64 `json:"strikethrough_price"`
+	Source             string   `json:"source"`
+}
+
+// Region pricing sources
+const (
+	RegionPriceSourceRegion  = "region"
+	RegionPriceSourceDefault = "default"
+)
@@ -0,0 +1,55 @@
+package models
+
+import "time"
+
+// ProductRelationType says how a related product is offered on a product page
+type ProductRelationType string
+
+const (
+	RelationAccessory   ProductRelationType = "accessory"   // complements the product
+	RelationReplacement ProductRelationType = "replacement" // substitute when the product is unavailable
+	RelationUpsell      ProductRelationType = "upsell"      // higher-end alternative
+)
+
+// IsValid reports whether t is a known relation type
+func (t ProductRelationType) IsValid() bool {
+	switch t {
+	case RelationAccessory, RelationReplacement, RelationUpsell:
+		return true
+	}
+	return false
+}
+
+// ProductRelation links a product to a curated related product
+type ProductRelation struct {
+	RelatedProductID int                 `json:"related_product_id" binding:"required"`
+	RelationType     ProductRelationType `json:"relation_type" binding:"required"`
+	DisplayOrder     int                 `json:"display_order"`
+}
+
+// RelatedProduct is a related product as shown on a product page
+type RelatedProduct struct {
+	RelationType       ProductRelationType `json:"relation_type"`
+	DisplayOrder       int                 `json:"display_order"`
+	ID                 int                 `json:"id"`
+	UUID               string              `json:"uuid"`
+	SKU                string              `json:"sku"`
+	Title              string              `json:"title"`
+	MainPrice          float64             `json:"main_price"`
+	StrikethroughPrice *float64            `json:"strikethrough_price"`
+	StockLeft          int                 `json:"stock_left"`
+	IsActive           bool                `json:"is_active"`
+	ThumbnailURL       *string             `json:"thumbnail_url"`
+	CreatedAt          time.Time           `json:"created_at"`
+}
+
+// PublicRelatedProducts drops inactive related products
+func PublicRelatedProducts(related []RelatedProduct) []RelatedProduct {
+	out := make([]RelatedProduct, 0, len(related))
+	for _, r := range related {
+		if r.IsActive {
+			out = append(out, r)
+		}
+	}
+	return out
+}
@@ -0,0 +1,56 @@
+package models
+
+// RelationshipKind names a mapping that can be exported and imported as CSV
+type RelationshipKind string
+
+const (
+	RelationshipStorePartners    RelationshipKind = "store-partners"    // store -> Partner organization
+	RelationshipProductSourcing  RelationshipKind = "product-sourcing"  // product -> Manufacturer organization in a region
+	RelationshipProductLogistics RelationshipKind = "product-logistics" // product -> 3PL organization
+)
+
+// OrgType is the type of the organizations the mapping links to
+func (k RelationshipKind) OrgType() OrgType {
+	switch k {
+	case RelationshipStorePartners:
+		return OrgTypePartner
+	case RelationshipProductSourcing:
+		return OrgTypeManufacturer
+	default:
+		return OrgType3PL
+	}
+}
+
+// Valid reports whether k is a known mapping
+func (k RelationshipKind) Valid() bool {
+	return k == RelationshipStorePartners || k == RelationshipProductSourcing || k == RelationshipProductLogistics
+}
+
+// RelationshipMapping is one mapping row: a store or product (EntityID, with its store name or SKU
+// as EntityKey) linked to an organization, in a region for product sourcing
+type RelationshipMapping struct {
+	EntityID  int
+	EntityKey string
+	OrgID     string
+	OrgName   string
+	RegionID  *int
+	Region    *string // region code, or name for regions without one
+}
+
+// RelationshipImportError is a CSV line that could not be imported
+type RelationshipImportError struct {
+	Line    int    `json:"line"`
+	Message string `json:"message"`
+}
+
+// RelationshipImportResult summarizes an import. In replace mode the stores or products named in
+// the file keep exactly the file's mappings; in merge mode mappings are only added.
+type RelationshipImportResult struct {
+	Kind     RelationshipKind `json:"kind"`
+	Mode     string           `json:"mode"`
+	DryRun   bool             `json:"dry_run"`
+	Rows     int              `json:"rows"`
+	Entities int              `json:"entities"`
+	Added    int              `json:"added"`
+	Removed  int              `json:"removed"`
+}
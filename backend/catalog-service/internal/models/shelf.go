@@ -0,0 +1,233 @@
+package models
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Shelf layout limits
+const (
+	maxShelfUnits     = 200
+	maxShelfLevels    = 50
+	maxShelfPositions = 200
+	maxShelfSlots     = 10000
+)
+
+var shelfUnitCode = regexp.MustCompile(`^[A-Z0-9]{1,10}$`)
+
+// ShelfUnit is one shelf of an unmanned store. Its slots are addressed "<code>-<level>-<position>",
+// e.g. "A-2-5" (levels count from the bottom, both from 1; leading zeros are ignored).
+type ShelfUnit struct {
+	Code      string `json:"code"`
+	Levels    int    `json:"levels"`
+	Positions int    `json:"positions"`
+}
+
+// ShelfLayout is a store's configured shelves. Shelf codes of the store's products and variants must
+// name one of its slots once a layout is set.
+type ShelfLayout struct {
+	Units []ShelfUnit `json:"units"`
+}
+
+// ShelfSlotRef is a parsed shelf code
+type ShelfSlotRef struct {
+	Unit     string
+	Level    int
+	Position int
+}
+
+// String is the canonical shelf code of the slot
+func (r ShelfSlotRef) String() string {
+	return fmt.Sprintf("%s-%d-%d", r.Unit, r.Level, r.Position)
+}
+
+// Normalize upper-cases unit codes and checks the layout, returning the field errors
+func (l *ShelfLayout) Normalize() []FieldError {
+	var errs []FieldError
+	if len(l.Units) == 0 {
+		return []FieldError{{Field: "units", Code: "required", Message: "at least one shelf unit is required"}}
+	}
+	if len(l.Units) > maxShelfUnits {
+		return []FieldError{{Field: "units", Code: "too_many", Message: fmt.Sprintf("at most %d shelf units", maxShelfUnits)}}
+	}
+	seen := map[string]bool{}
+	for i := range l.Units {
+		u := &l.Units[i]
+		field := fmt.Sprintf("units[%d]", i)
+		u.Code = strings.ToUpper(strings.TrimSpace(u.Code))
+		switch {
+		case !shelfUnitCode.MatchString(u.Code):
+			errs = append(errs, FieldError{Field: field + ".code", Code: "invalid", Message: "code must be 1-10 letters or digits"})
+		case seen[u.Code]:
+			errs = append(errs, FieldError{Field: field + ".code", Code: "duplicate", Message: "unit code is listed twice"})
+		}
+		seen[u.Code] = true
+		if u.Levels < 1 || u.Levels > maxShelfLevels {
+			errs = append(errs, FieldError{Field: field + ".levels", Code: "out_of_range", Message: fmt.Sprintf("levels must be 1-%d", maxShelfLevels)})
+		}
+		if u.Positions < 1 || u.Positions > maxShelfPositions {
+			errs = append(errs, FieldError{Field: field + ".positions", Code: "out_of_range", Message: fmt.Sprintf("positions must be 1-%d", maxShelfPositions)})
+		}
+	}
+	if len(errs) == 0 && l.Capacity() > maxShelfSlots {
+		errs = append(errs, FieldError{Field: "units", Code: "too_many", Message: fmt.Sprintf("at most %d slots in total", maxShelfSlots)})
+	}
+	return errs
+}
+
+// ParseShelfCode splits "<unit>-<level>-<position>"; ok is false for codes in another format
+func ParseShelfCode(code string) (ShelfSlotRef, bool) {
+	parts := strings.Split(strings.ToUpper(strings.TrimSpace(code)), "-")
+	if len(parts) != 3 || !shelfUnitCode.MatchString(parts[0]) {
+		return ShelfSlotRef{}, false
+	}
+	level, err1 := strconv.Atoi(parts[1])
+	position, err2 := strconv.Atoi(parts[2])
+	if err1 != nil || err2 != nil || level < 1 || position < 1 {
+		return ShelfSlotRef{}, false
+	}
+	return ShelfSlotRef{Unit: parts[0], Level: level, Position: position}, true
+}
+
+// Locate returns the slot a shelf code names, or why the code doesn't fit the layout
+func (l *ShelfLayout) Locate(code string) (ShelfSlotRef, string) {
+	ref, ok := ParseShelfCode(code)
+	if !ok {
+		return ref, "shelf code must look like <unit>-<level>-<position>, e.g. A-2-5"
+	}
+	for _, u := range l.Units {
+		if u.Code != ref.Unit {
+			continue
+		}
+		if ref.Level > u.Levels {
+			return ref, fmt.Sprintf("shelf unit %s has %d levels", u.Code, u.Levels)
+		}
+		if ref.Position > u.Positions {
+			return ref, fmt.Sprintf("shelf unit %s has %d positions per level", u.Code, u.Positions)
+		}
+		return ref, ""
+	}
+	return ref, fmt.Sprintf("the store has no shelf unit %s", ref.Unit)
+}
+
+// Capacity is the number of slots in the layout
+func (l *ShelfLayout) Capacity() int {
+	n := 0
+	for _, u := range l.Units {
+		n += u.Levels * u.Positions
+	}
+	return n
+}
+
+// ShelfAssignment is a product or variant placed at a shelf code
+type ShelfAssignment struct {
+	ShelfCode string  `json:"shelf_code"`
+	ProductID int     `json:"product_id"`
+	VariantID *int    `json:"variant_id,omitempty"`
+	SKU       string  `json:"sku"`
+	Title     string  `json:"title"`
+	StockLeft *int    `json:"stock_left"`
+	IsActive  bool    `json:"is_active"`
+	Issue     *string `json:"issue,omitempty"` // why the code doesn't fit the layout
+}
+
+// ShelfSlot is one slot of the shelf map. More than one assignment is a conflict.
+type ShelfSlot struct {
+	ShelfCode   string            `json:"shelf_code"`
+	Unit        string            `json:"unit"`
+	Level       int               `json:"level"`
+	Position    int               `json:"position"`
+	Assignments []ShelfAssignment `json:"assignments"`
+}
+
+// ShelfUnitOccupancy summarizes one shelf unit of the map
+type ShelfUnitOccupancy struct {
+	Code      string  `json:"code"`
+	Capacity  int     `json:"capacity"`
+	Occupied  int     `json:"occupied"`
+	Occupancy float64 `json:"occupancy"` // occupied / capacity
+}
+
+// ShelfMap is the occupancy of a store's shelves. Without a layout Slots only lists the codes in use
+// and Gaps, Units and Capacity stay empty.
+type ShelfMap struct {
+	StoreID       int                  `json:"store_id"`
+	Layout        *ShelfLayout         `json:"layout"`
+	Capacity      int                  `json:"capacity"`
+	Occupied      int                  `json:"occupied"`
+	Slots         []ShelfSlot          `json:"slots"`
+	Gaps          []string             `json:"gaps"`
+	Conflicts     []string             `json:"conflicts"`
+	OutsideLayout []ShelfAssignment    `json:"outside_layout"`
+	Units         []ShelfUnitOccupancy `json:"units"`
+}
+
+// BuildShelfMap lays the store's assignments out on its layout (nil when none is configured)
+func BuildShelfMap(storeID int, layout *ShelfLayout, assignments []ShelfAssignment) *ShelfMap {
+	m := &ShelfMap{StoreID: storeID, Layout: layout, Slots: []ShelfSlot{}, Gaps: []string{}, Conflicts: []string{},
+		OutsideLayout: []ShelfAssignment{}, Units: []ShelfUnitOccupancy{}}
+
+	bySlot := map[string][]ShelfAssignment{}
+	var order []string
+	for _, a := range assignments {
+		key := strings.ToUpper(strings.TrimSpace(a.ShelfCode))
+		if layout != nil {
+			ref, issue := layout.Locate(a.ShelfCode)
+			if issue != "" {
+				a.Issue = &issue
+				m.OutsideLayout = append(m.OutsideLayout, a)
+				continue
+			}
+			key = ref.String()
+		} else if ref, ok := ParseShelfCode(a.ShelfCode); ok {
+			key = ref.String()
+		}
+		if _, ok := bySlot[key]; !ok {
+			order = append(order, key)
+		}
+		bySlot[key] = append(bySlot[key], a)
+	}
+
+	slot := func(code string) ShelfSlot {
+		s := ShelfSlot{ShelfCode: code, Assignments: bySlot[code]}
+		if ref, ok := ParseShelfCode(code); ok {
+			s.Unit, s.Level, s.Position = ref.Unit, ref.Level, ref.Position
+		}
+		if s.Assignments == nil {
+			s.Assignments = []ShelfAssignment{}
+		}
+		if len(s.Assignments) > 1 {
+			m.Conflicts = append(m.Conflicts, code)
+		}
+		return s
+	}
+
+	if layout == nil {
+		for _, code := range order {
+			m.Slots = append(m.Slots, slot(code))
+		}
+		m.Occupied = len(order)
+		return m
+	}
+	m.Capacity = layout.Capacity()
+	for _, u := range layout.Units {
+		occ := ShelfUnitOccupancy{Code: u.Code, Capacity: u.Levels * u.Positions}
+		for level := 1; level <= u.Levels; level++ {
+			for pos := 1; pos <= u.Positions; pos++ {
+				s := slot(ShelfSlotRef{Unit: u.Code, Level: level, Position: pos}.String())
+				if len(s.Assignments) == 0 {
+					m.Gaps = append(m.Gaps, s.ShelfCode)
+				} else {
+					occ.Occupied++
+				}
+				m.Slots = append(m.Slots, s)
+			}
+		}
+		occ.Occupancy = float64(occ.Occupied) / float64(occ.Capacity)
+		m.Occupied += occ.Occupied
+		m.Units = append(m.Units, occ)
+	}
+	return m
+}
@@ -0,0 +1,54 @@
+package models
+
+import "time"
+
+// StockReason explains a change of a product's stock_left
+type StockReason string
+
+const (
+	// Reasons accepted by the adjustment API
+	StockReasonRestock        StockReason = "restock"
+	StockReasonCustomerReturn StockReason = "customer_return"
+	StockReasonDamaged        StockReason = "damaged"
+	StockReasonExpired        StockReason = "expired"
+	StockReasonLost           StockReason = "lost"
+	StockReasonCorrection     StockReason = "correction"
+	// Recorded by the database trigger only
+	StockReasonInitial StockReason = "initial"
+	StockReasonSystem  StockReason = "system"
+)
+
+// AdjustmentSign reports the direction an adjustment with this reason must go:
+// 1 for increments, -1 for decrements, 0 for either. ok is false for reasons the API doesn't accept.
+func (r StockReason) AdjustmentSign() (sign int, ok bool) {
+	switch r {
+	case StockReasonRestock, StockReasonCustomerReturn:
+		return 1, true
+	case StockReasonDamaged, StockReasonExpired, StockReasonLost:
+		return -1, true
+	case StockReasonCorrection:
+		return 0, true
+	}
+	return 0, false
+}
+
+// StockMovement is one audited change of a product's stock_left. Changes made outside the
+// adjustment API (order-service deductions, imports) are recorded with reason "system".
+type StockMovement struct {
+	ID          int64       `json:"movement_id"`
+	ProductID   int         `json:"product_id"`
+	Delta       int         `json:"delta"`
+	StockBefore int         `json:"stock_before"`
+	StockAfter  int         `json:"stock_after"`
+	Reason      StockReason `json:"reason"`
+	Note        string      `json:"note,omitempty"`
+	Actor       string      `json:"actor,omitempty"`
+	CreatedAt   time.Time   `json:"created_at"`
+}
+
+// StockAdjustmentRequest increments (positive delta) or decrements (negative delta) stock_left
+type StockAdjustmentRequest struct {
+	Delta  int         `json:"delta" binding:"required"`
+	Reason StockReason `json:"reason" binding:"required"`
+	Note   string      `json:"note"`
+}
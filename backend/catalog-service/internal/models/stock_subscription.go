@@ -0,0 +1,29 @@
+package models
+
+import "time"
+
+// StockSubscription asks for a notification when an out-of-stock product is restocked.
+// It is removed once the user has been notified.
+type StockSubscription struct {
+	ProductID   int       `json:"product_id"`
+	ProductUUID string    `json:"product_uuid"`
+	Title       string    `json:"title"`
+	StockLeft   int       `json:"stock_left"`
+	UserID      string    `json:"-"`
+	MiniAppType string    `json:"mini_app_type,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// StockEvent is a stock transition recorded by the admin_products trigger
+type StockEvent struct {
+	EventID     int64     `json:"event_id"`
+	ProductID   int       `json:"product_id"`
+	ProductUUID string    `json:"product_uuid"`
+	Title       string    `json:"title"`
+	MiniAppType string    `json:"mini_app_type"`
+	ImageURL    *string   `json:"image_url"`
+	EventType   string    `json:"event_type"`
+	StockLeft   int       `json:"stock_left"`
+	Attempts    int       `json:"attempts"`
+	OccurredAt  time.Time `json:"occurred_at"`
+}
@@ -0,0 +1,36 @@
+package models
+
+import "time"
+
+// StoreQR is the entry QR code provisioned for a store. Payload is the signed deep link the code
+// encodes; provisioning a new code bumps Version, which revokes the earlier ones.
+type StoreQR struct {
+	StoreID     int         `json:"store_id"`
+	StoreType   StoreType   `json:"store_type"`
+	MiniAppType MiniAppType `json:"mini_app_type"`
+	Version     int         `json:"version"`
+	Payload     string      `json:"payload"`
+	GeneratedAt time.Time   `json:"generated_at"`
+}
+
+// StoreQRResolution is the store behind a scanned QR code
+type StoreQRResolution struct {
+	StoreID     int         `json:"store_id"`
+	StoreType   StoreType   `json:"store_type"`
+	MiniAppType MiniAppType `json:"mini_app_type"`
+	Store       Store       `json:"store"`
+}
+
+// MiniAppTypeForStore is the mini-app that serves a store type
+func MiniAppTypeForStore(t StoreType) MiniAppType {
+	switch t {
+	case StoreTypeUnmannedStore, StoreTypeUnmannedWarehouse:
+		return MiniAppTypeUnmannedStore
+	case StoreTypeExhibitionStore, StoreTypeExhibitionMall:
+		return MiniAppTypeExhibitionSales
+	case StoreTypeGroupBuying:
+		return MiniAppTypeGroupBuying
+	default:
+		return MiniAppTypeRetailStore
+	}
+}
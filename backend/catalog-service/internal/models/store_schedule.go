@@ -0,0 +1,173 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+	_ "time/tzdata" // store time zones must resolve in minimal container images
+)
+
+// OpeningPeriod is one interval a store is open on a weekday (0 = Sunday ... 6 = Saturday).
+// Opens/Closes are "HH:MM" in the store's time zone; Closes may be "24:00". Periods do not span midnight;
+// split late opening into two periods instead.
+type OpeningPeriod struct {
+	Day    int    `json:"day"`
+	Opens  string `json:"opens"`
+	Closes string `json:"closes"`
+}
+
+// StoreHoliday overrides the regular opening hours on one date ("YYYY-MM-DD").
+// Without periods the store is closed all day; otherwise it is open only during them.
+type StoreHoliday struct {
+	Date    string          `json:"date"`
+	Periods []HolidayPeriod `json:"periods,omitempty"`
+	Note    string          `json:"note,omitempty"`
+}
+
+// HolidayPeriod is a special-hours interval on a holiday
+type HolidayPeriod struct {
+	Opens  string `json:"opens"`
+	Closes string `json:"closes"`
+}
+
+// StoreSchedule holds a store's opening hours and holiday exceptions
+type StoreSchedule struct {
+	Timezone     *string         `json:"timezone,omitempty"` // IANA name, e.g. "Europe/Berlin"; UTC when unset
+	OpeningHours []OpeningPeriod `json:"opening_hours,omitempty"`
+	Holidays     []StoreHoliday  `json:"holidays,omitempty"`
+}
+
+// parseClock converts "HH:MM" (00:00-24:00) to minutes after midnight
+func parseClock(s string) (int, error) {
+	var h, m int
+	if _, err := fmt.Sscanf(s, "%d:%d", &h, &m); err != nil || len(s) != 5 || h < 0 || m < 0 || m > 59 || h > 24 || (h == 24 && m != 0) {
+		return 0, fmt.Errorf("invalid time %q (use HH:MM)", s)
+	}
+	return h*60 + m, nil
+}
+
+func validateInterval(opens, closes string) error {
+	o, err := parseClock(opens)
+	if err != nil {
+		return err
+	}
+	c, err := parseClock(closes)
+	if err != nil {
+		return err
+	}
+	if c <= o {
+		return fmt.Errorf("closing time %s must be after opening time %s", closes, opens)
+	}
+	return nil
+}
+
+// Validate checks the time zone, periods and holiday dates
+func (s *StoreSchedule) Validate() error {
+	if s.Timezone != nil {
+		if _, err := time.LoadLocation(*s.Timezone); err != nil || *s.Timezone == "" {
+			return fmt.Errorf("unknown timezone %q", *s.Timezone)
+		}
+	}
+	if len(s.OpeningHours) > 50 || len(s.Holidays) > 366 {
+		return fmt.Errorf("too many opening periods or holidays")
+	}
+	for _, p := range s.OpeningHours {
+		if p.Day < 0 || p.Day > 6 {
+			return fmt.Errorf("invalid day %d (0 = Sunday ... 6 = Saturday)", p.Day)
+		}
+		if err := validateInterval(p.Opens, p.Closes); err != nil {
+			return err
+		}
+	}
+	seen := map[string]bool{}
+	for _, hd := range s.Holidays {
+		if _, err := time.Parse("2006-01-02", hd.Date); err != nil {
+			return fmt.Errorf("invalid holiday date %q (use YYYY-MM-DD)", hd.Date)
+		}
+		if seen[hd.Date] {
+			return fmt.Errorf("holiday %s listed more than once", hd.Date)
+		}
+		seen[hd.Date] = true
+		for _, p := range hd.Periods {
+			if err := validateInterval(p.Opens, p.Closes); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Location returns the store's time zone (UTC when unset or unknown)
+func (s *StoreSchedule) Location() *time.Location {
+	if s.Timezone != nil {
+		if loc, err := time.LoadLocation(*s.Timezone); err == nil {
+			return loc
+		}
+	}
+	return time.UTC
+}
+
+// IsOpenAt reports whether the store is open at t; nil when no opening hours are configured
+func (s *StoreSchedule) IsOpenAt(t time.Time) *bool {
+	if len(s.OpeningHours) == 0 {
+		return nil
+	}
+	local := t.In(s.Location())
+	minute := local.Hour()*60 + local.Minute()
+	within := func(opens, closes string) bool {
+		o, err1 := parseClock(opens)
+		c, err2 := parseClock(closes)
+		return err1 == nil && err2 == nil && minute >= o && minute < c
+	}
+
+	open := false
+	date := local.Format("2006-01-02")
+	holiday := false
+	for _, hd := range s.Holidays {
+		if hd.Date == date {
+			holiday = true
+			for _, p := range hd.Periods {
+				open = open || within(p.Opens, p.Closes)
+			}
+			break
+		}
+	}
+	if !holiday {
+		for _, p := range s.OpeningHours {
+			if p.Day == int(local.Weekday()) {
+				open = open || within(p.Opens, p.Closes)
+			}
+		}
+	}
+	return &open
+}
+
+// MarshalColumns encodes the schedule for the opening_hours and holidays columns (nil slices stay NULL)
+func (s *StoreSchedule) MarshalColumns() (hours, holidays []byte, err error) {
+	if s.OpeningHours != nil {
+		if hours, err = json.Marshal(s.OpeningHours); err != nil {
+			return nil, nil, err
+		}
+	}
+	if s.Holidays != nil {
+		if holidays, err = json.Marshal(s.Holidays); err != nil {
+			return nil, nil, err
+		}
+	}
+	return hours, holidays, nil
+}
+
+// UnmarshalColumns decodes the opening_hours and holidays columns
+func (s *StoreSchedule) UnmarshalColumns(hours, holidays []byte) error {
+	if len(hours) > 0 {
+		if err := json.Unmarshal(hours, &s.OpeningHours); err != nil {
+			return err
+		}
+	}
+	if len(holidays) > 0 {
+		if err := json.Unmarshal(holidays, &s.Holidays); err != nil {
+			return err
+		}
+	}
+	return nil
+}
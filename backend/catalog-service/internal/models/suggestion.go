@@ -0,0 +1,27 @@
+package models
+
+// SuggestionKind says what a search suggestion points at
+type SuggestionKind string
+
+const (
+	SuggestionProduct  SuggestionKind = "product"
+	SuggestionSKU      SuggestionKind = "sku"
+	SuggestionCategory SuggestionKind = "category"
+)
+
+// How a suggestion matched the query
+const (
+	SuggestionMatchPrefix = "prefix" // the text or one of its words starts with the query
+	SuggestionMatchFuzzy  = "fuzzy"  // trigram similarity, e.g. despite a typo
+)
+
+// Suggestion is a type-ahead match for the search box. Product and SKU matches carry the
+// product's ids; category matches carry CategoryID.
+type Suggestion struct {
+	Kind        SuggestionKind `json:"kind"`
+	Text        string         `json:"text"`
+	Match       string         `json:"match"`
+	ProductID   *int           `json:"product_id,omitempty"`
+	ProductUUID *string        `json:"product_uuid,omitempty"`
+	CategoryID  *int           `json:"category_id,omitempty"`
+}
@@ -0,0 +1,53 @@
+package models
+
+import "time"
+
+// TaxClass groups products that are taxed at the same rate within a region
+type TaxClass string
+
+const (
+	TaxClassStandard TaxClass = "standard"
+	TaxClassReduced  TaxClass = "reduced"
+	TaxClassExempt   TaxClass = "exempt"
+)
+
+// TaxClasses lists every supported tax class
+var TaxClasses = []TaxClass{TaxClassStandard, TaxClassReduced, TaxClassExempt}
+
+// IsValid reports whether t is a supported tax class
+func (t TaxClass) IsValid() bool {
+	switch t {
+	case TaxClassStandard, TaxClassReduced, TaxClassExempt:
+		return true
+	}
+	return false
+}
+
+// RegionTaxRate is one cell of the region tax matrix
+type RegionTaxRate struct {
+	RegionID   int       `json:"region_id"`
+	RegionName string    `json:"region_name,omitempty"`
+	TaxClass   TaxClass  `json:"tax_class"`
+	RatePct    float64   `json:"rate_pct"`
+	UpdatedBy  string    `json:"updated_by,omitempty"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// ResolvedTaxRate is the rate that applies to a product sold in a region. TaxClass is nil when the
+// product has none yet and RatePct is nil when the region has no rate for its class; either way the
+// product cannot be sold there.
+type ResolvedTaxRate struct {
+	ProductID int       `json:"product_id"`
+	RegionID  *int      `json:"region_id"`
+	TaxClass  *TaxClass `json:"tax_class"`
+	RatePct   *float64  `json:"rate_pct"`
+}
+
+// UnclassifiedProduct is an active product still missing its tax class
+type UnclassifiedProduct struct {
+	ProductID int    `json:"product_id"`
+	UUID      string `json:"uuid"`
+	SKU       string `json:"sku"`
+	Title     string `json:"title"`
+	StoreID   *int   `json:"store_id"`
+}
@@ -0,0 +1,27 @@
+package models
+
+import "time"
+
+// Translation statuses. Machine drafts are unconfirmed and flagged for human review;
+// confirming (or editing) a draft makes it a regular translation that drafting never overwrites.
+const (
+	TranslationStatusSource       = "source"
+	TranslationStatusMachineDraft = "machine_draft"
+	TranslationStatusConfirmed    = "confirmed"
+)
+
+// ProductTranslation is a product's title/description in one locale
+type ProductTranslation struct {
+	ProductID    int        `json:"product_id"`
+	Locale       string     `json:"locale"`
+	Title        string     `json:"title"`
+	Description  string     `json:"description"`
+	Status       string     `json:"status"`
+	NeedsReview  bool       `json:"needs_review"`
+	SourceLocale *string    `json:"source_locale,omitempty"`
+	Provider     *string    `json:"provider,omitempty"`
+	ReviewedBy   *string    `json:"reviewed_by,omitempty"`
+	ReviewedAt   *time.Time `json:"reviewed_at,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+// Trash item kinds (the audit entity types)
+const (
+	TrashProduct  = "product"
+	TrashCategory = "category"
+	TrashStore    = "store"
+)
+
+// TrashedItem is a hard-deleted product, category or store waiting for the purge job. Its row and
+// images are kept until PurgeAt and it can be restored until then.
+type TrashedItem struct {
+	Kind      string    `json:"kind"`
+	ID        int       `json:"id"`
+	Name      string    `json:"name"`
+	TrashedAt time.Time `json:"trashed_at"`
+	TrashedBy *string   `json:"trashed_by,omitempty"`
+	PurgeAt   time.Time `json:"purge_at"`
+}
@@ -0,0 +1,10 @@
+package models
+
+// FieldError is one rejected request field. Field is the JSON path (e.g. "items[2].price", or
+// "body" when the payload itself is unreadable) and Code a stable snake_case reason for clients;
+// Message adds detail for rule checks whose code alone doesn't say what to fix.
+type FieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message,omitempty"`
+}
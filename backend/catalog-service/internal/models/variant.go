@@ -0,0 +1,57 @@
+package models
+
+import "time"
+
+// ProductVariant is a purchasable variation (size, color, flavor, ...) of a parent product
+// with its own SKU, price, stock and shelf position. Options holds the distinguishing attributes.
+type ProductVariant struct {
+	ID                 int               `json:"variant_id"`
+	ProductID          int               `json:"product_id"`
+	SKU                string            `json:"sku"`
+	Name               string            `json:"name"`
+	Options            map[string]string `json:"options"`
+	MainPrice          float64           `json:"main_price"`
+	StrikethroughPrice *float64          `json:"strikethrough_price"`
+	CostPrice          *float64          `json:"cost_price,omitempty"` // Admin only - excluded from public API
+	StockLeft          int               `json:"stock_left"`
+	ShelfCode          *string           `json:"shelf_code,omitempty"`
+	IsActive           bool              `json:"is_active"`
+	DisplayOrder       int               `json:"display_order"`
+	CreatedAt          time.Time         `json:"created_at"`
+	UpdatedAt          time.Time         `json:"updated_at"`
+}
+
+// PublicProductVariant represents a variant for the public API (excludes cost_price)
+type PublicProductVariant struct {
+	ID                 int               `json:"variant_id"`
+	SKU                string            `json:"sku"`
+	Name               string            `json:"name"`
+	Options            map[string]string `json:"options"`
+	MainPrice          float64           `json:"main_price"`
+	StrikethroughPrice *float64          `json:"strikethrough_price"`
+	StockLeft          int               `json:"stock_left"`
+	ShelfCode          *string           `json:"shelf_code,omitempty"`
+	DisplayOrder       int               `json:"display_order"`
+}
+
+// PublicVariants converts variants to their public form, dropping inactive ones
+func PublicVariants(variants []ProductVariant) []PublicProductVariant {
+	out := make([]PublicProductVariant, 0, len(variants))
+	for _, v := range variants {
+		if !v.IsActive {
+			continue
+		}
+		out = append(out, PublicProductVariant{
+			ID:                 v.ID,
+			SKU:                v.SKU,
+			Name:               v.Name,
+			Options:            v.Options,
+			MainPrice:          v.MainPrice,
+			StrikethroughPrice: v.StrikethroughPrice,
+			StockLeft:          v.StockLeft,
+			ShelfCode:          v.ShelfCode,
+			DisplayOrder:       v.DisplayOrder,
+		})
+	}
+	return out
+}
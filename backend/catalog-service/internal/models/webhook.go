@@ -0,0 +1,59 @@
+package models
+
+import "time"
+
+// Webhook delivery states. A delivery that used up its attempts is failed (the dead-letter queue)
+// until an admin redelivers it.
+const (
+	WebhookPending   = "pending"
+	WebhookDelivered = "delivered"
+	WebhookFailed    = "failed"
+)
+
+// WebhookEndpoint is an external receiver of catalog change events; empty Events subscribes to all.
+// The signing secret is only returned when it is generated.
+type WebhookEndpoint struct {
+	ID          int64     `json:"id"`
+	URL         string    `json:"url"`
+	Events      []string  `json:"events"`
+	Description *string   `json:"description,omitempty"`
+	Active      bool      `json:"active"`
+	Secret      string    `json:"secret,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// WebhookEndpointRequest creates or replaces an endpoint. Secrets are always generated by the service;
+// RotateSecret issues a new one on update.
+type WebhookEndpointRequest struct {
+	URL          string   `json:"url" binding:"required,url"`
+	Events       []string `json:"events"`
+	Description  *string  `json:"description"`
+	Active       *bool    `json:"active"`
+	RotateSecret bool     `json:"rotate_secret"`
+}
+
+// WebhookDelivery is one queued event for one endpoint
+type WebhookDelivery struct {
+	ID            int64      `json:"id"`
+	EndpointID    int64      `json:"endpoint_id"`
+	Event         string     `json:"event"`
+	Status        string     `json:"status"`
+	Attempts      int        `json:"attempts"`
+	ResponseCode  *int       `json:"response_code,omitempty"`
+	LastError     *string    `json:"last_error,omitempty"`
+	NextAttemptAt time.Time  `json:"next_attempt_at"`
+	DeliveredAt   *time.Time `json:"delivered_at,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+}
+
+// DueWebhookDelivery is a claimed delivery with what the worker needs to send it
+type DueWebhookDelivery struct {
+	ID        int64
+	Event     string
+	Payload   []byte
+	Attempts  int
+	CreatedAt time.Time
+	URL       string
+	Secret    string
+}
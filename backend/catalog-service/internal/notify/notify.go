@@ -0,0 +1,83 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/expotoworld/expotoworld/backend/catalog-service/internal/logging"
+)
+
+// Notification is a user-facing message handed to the dispatcher; Data carries deep-link details
+type Notification struct {
+	Type   string                 `json:"type"`
+	UserID string                 `json:"user_id"`
+	Title  string                 `json:"title"`
+	Body   string                 `json:"body"`
+	Data   map[string]interface{} `json:"data,omitempty"`
+}
+
+// Dispatcher delivers notifications to users (push, email, in-app, ...)
+type Dispatcher interface {
+	Dispatch(ctx context.Context, n Notification) error
+}
+
+// NewDispatcherFromEnv posts to NOTIFICATION_DISPATCH_URL (bearer NOTIFICATION_DISPATCH_TOKEN) when set,
+// otherwise notifications are only logged.
+func NewDispatcherFromEnv() Dispatcher {
+	url := strings.TrimSpace(os.Getenv("NOTIFICATION_DISPATCH_URL"))
+	if url == "" {
+		return LogDispatcher{}
+	}
+	return &HTTPDispatcher{
+		URL:    url,
+		Token:  os.Getenv("NOTIFICATION_DISPATCH_TOKEN"),
+		Client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// HTTPDispatcher POSTs each notification as JSON; any 2xx response counts as delivered
+type HTTPDispatcher struct {
+	URL    string
+	Token  string
+	Client *http.Client
+}
+
+func (d *HTTPDispatcher) Dispatch(ctx context.Context, n Notification) error {
+	body, err := json.Marshal(n)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, d.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if d.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+d.Token)
+	}
+	resp, err := d.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("dispatcher returned %d: %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
+
+// LogDispatcher only logs notifications (local development, or no dispatcher configured)
+type LogDispatcher struct{}
+
+func (LogDispatcher) Dispatch(_ context.Context, n Notification) error {
+	logging.LogKV("info", "NotificationDispatched", map[string]interface{}{"type": n.Type, "user_id": n.UserID, "title": n.Title, "data": n.Data})
+	return nil
+}
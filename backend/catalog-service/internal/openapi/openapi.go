@@ -0,0 +1,477 @@
+// Package openapi builds an OpenAPI 3 document from the routes registered on the router and a table
+// of endpoint descriptions, deriving request and response schemas from the Go models by reflection.
+package openapi
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Auth levels an endpoint can require
+const (
+	AuthNone        = ""
+	AuthUser        = "user"        // any valid JWT
+	AuthAdmin       = "admin"       // JWT with the Admin role (optionally region-scoped)
+	AuthMaintenance = "maintenance" // X-Maintenance-Token shared secret
+)
+
+// Param describes a query parameter
+type Param struct {
+	Name        string
+	Type        string // string (default), integer, number or boolean
+	Description string
+}
+
+// Endpoint describes one handler. Request and Response are example values of the body types
+// (e.g. models.Product{} or []models.Category{}); nil leaves the body undocumented.
+type Endpoint struct {
+	Summary  string
+	Auth     string
+	Query    []Param
+	Request  any
+	Response any
+	Status   int // success status, 200 when zero
+}
+
+// Object describes a JSON object body such as gin.H{"products": ...} from key/example value pairs,
+// e.g. Object("products", []models.PublicProduct{}, "total", 0)
+func Object(pairs ...any) any {
+	fields := make([]reflect.StructField, 0, len(pairs)/2)
+	for i := 0; i+1 < len(pairs); i += 2 {
+		fields = append(fields, reflect.StructField{
+			Name: "F" + strconv.Itoa(i/2),
+			Type: reflect.TypeOf(pairs[i+1]),
+			Tag:  reflect.StructTag(`json:"` + pairs[i].(string) + `"`),
+		})
+	}
+	return reflect.New(reflect.StructOf(fields)).Elem().Interface()
+}
+
+// Route is a registered route; Handler is the handler's function name as reported by the router
+type Route struct {
+	Method  string
+	Path    string
+	Handler string
+}
+
+// Document is the subset of OpenAPI 3.0 this package emits
+type Document struct {
+	OpenAPI    string                           `json:"openapi"`
+	Info       Info                             `json:"info"`
+	Paths      map[string]map[string]*Operation `json:"paths"`
+	Components Components                       `json:"components"`
+}
+
+// Info is the document's title and version
+type Info struct {
+	Title       string `json:"title"`
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+}
+
+// Components holds the shared schemas and security schemes
+type Components struct {
+	Schemas         map[string]*Schema         `json:"schemas"`
+	SecuritySchemes map[string]*SecurityScheme `json:"securitySchemes"`
+}
+
+// SecurityScheme is a bearer or API key scheme
+type SecurityScheme struct {
+	Type         string `json:"type"`
+	Scheme       string `json:"scheme,omitempty"`
+	BearerFormat string `json:"bearerFormat,omitempty"`
+	In           string `json:"in,omitempty"`
+	Name         string `json:"name,omitempty"`
+	Description  string `json:"description,omitempty"`
+}
+
+// Operation is one method on a path
+type Operation struct {
+	OperationID string                `json:"operationId"`
+	Summary     string                `json:"summary,omitempty"`
+	Tags        []string              `json:"tags,omitempty"`
+	Parameters  []Parameter           `json:"parameters,omitempty"`
+	RequestBody *RequestBody          `json:"requestBody,omitempty"`
+	Responses   map[string]*Response  `json:"responses"`
+	Security    []map[string][]string `json:"security,omitempty"`
+}
+
+// Parameter is a path or query parameter
+type Parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Required    bool    `json:"required,omitempty"`
+	Description string  `json:"description,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+// RequestBody is a JSON request body
+type RequestBody struct {
+	Required bool                  `json:"required"`
+	Content  map[string]*MediaType `json:"content"`
+}
+
+// Response is a response for one status code
+type Response struct {
+	Description string                `json:"description"`
+	Content     map[string]*MediaType `json:"content,omitempty"`
+}
+
+// MediaType wraps a schema
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+// Schema is a JSON schema as used by OpenAPI 3.0
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Nullable             bool               `json:"nullable,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	Enum                 []string           `json:"enum,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	ExclusiveMinimum     bool               `json:"exclusiveMinimum,omitempty"`
+	Maximum              *float64           `json:"maximum,omitempty"`
+	ExclusiveMaximum     bool               `json:"exclusiveMaximum,omitempty"`
+	MinLength            *int               `json:"minLength,omitempty"`
+	MaxLength            *int               `json:"maxLength,omitempty"`
+	MinItems             *int               `json:"minItems,omitempty"`
+}
+
+var (
+	timeType       = reflect.TypeOf(time.Time{})
+	rawMessageType = reflect.TypeOf(json.RawMessage{})
+	marshalerType  = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+)
+
+// Build returns the document for routes. Endpoints are looked up by handler name; routes without an
+// entry are still listed, with a summary derived from the handler name.
+func Build(info Info, routes []Route, endpoints map[string]Endpoint) *Document {
+	doc := &Document{
+		OpenAPI: "3.0.3",
+		Info:    info,
+		Paths:   map[string]map[string]*Operation{},
+		Components: Components{
+			Schemas: map[string]*Schema{},
+			SecuritySchemes: map[string]*SecurityScheme{
+				"bearerAuth": {Type: "http", Scheme: "bearer", BearerFormat: "JWT",
+					Description: "Access token issued by auth-service"},
+				"maintenanceToken": {Type: "apiKey", In: "header", Name: "X-Maintenance-Token",
+					Description: "Shared secret for internal jobs"},
+			},
+		},
+	}
+	g := &generator{schemas: doc.Components.Schemas}
+
+	sort.Slice(routes, func(i, j int) bool {
+		if routes[i].Path != routes[j].Path {
+			return routes[i].Path < routes[j].Path
+		}
+		return routes[i].Method < routes[j].Method
+	})
+	for _, r := range routes {
+		name := HandlerName(r.Handler)
+		ep, ok := endpoints[name]
+		if !ok {
+			ep.Summary = humanize(name)
+		}
+		path, pathParams := convertPath(r.Path)
+
+		op := &Operation{
+			OperationID: lowerFirst(name),
+			Summary:     ep.Summary,
+			Tags:        []string{tagFor(r.Path)},
+			Parameters:  pathParams,
+			Responses:   map[string]*Response{},
+		}
+		for _, p := range ep.Query {
+			t := p.Type
+			if t == "" {
+				t = "string"
+			}
+			op.Parameters = append(op.Parameters, Parameter{Name: p.Name, In: "query", Description: p.Description, Schema: &Schema{Type: t}})
+		}
+		if ep.Request != nil {
+			op.RequestBody = &RequestBody{Required: true, Content: jsonContent(g.schemaFor(reflect.TypeOf(ep.Request)))}
+		}
+
+		status := ep.Status
+		if status == 0 {
+			status = 200
+		}
+		success := &Response{Description: "Success"}
+		if ep.Response != nil {
+			success.Content = jsonContent(g.schemaFor(reflect.TypeOf(ep.Response)))
+		}
+		op.Responses[strconv.Itoa(status)] = success
+		op.Responses["default"] = &Response{Description: "Error", Content: jsonContent(errorSchema())}
+
+		switch ep.Auth {
+		case AuthUser, AuthAdmin:
+			op.Security = []map[string][]string{{"bearerAuth": {}}}
+			op.Responses["401"] = &Response{Description: "Missing or invalid token"}
+			if ep.Auth == AuthAdmin {
+				op.Responses["403"] = &Response{Description: "Admin role required or outside the admin's scope"}
+			}
+		case AuthMaintenance:
+			op.Security = []map[string][]string{{"maintenanceToken": {}}}
+		}
+
+		if doc.Paths[path] == nil {
+			doc.Paths[path] = map[string]*Operation{}
+		}
+		doc.Paths[path][strings.ToLower(r.Method)] = op
+	}
+	return doc
+}
+
+// HandlerName reduces a runtime function name such as
+// "github.com/x/internal/api.(*Handler).GetProducts-fm" to "GetProducts"
+func HandlerName(fn string) string {
+	fn = strings.TrimSuffix(fn, "-fm")
+	if i := strings.LastIndex(fn, "."); i >= 0 {
+		fn = fn[i+1:]
+	}
+	return fn
+}
+
+// convertPath turns gin's /products/:id into /products/{id} and lists the path parameters
+func convertPath(p string) (string, []Parameter) {
+	segs := strings.Split(p, "/")
+	var params []Parameter
+	for i, s := range segs {
+		if strings.HasPrefix(s, ":") || strings.HasPrefix(s, "*") {
+			name := s[1:]
+			segs[i] = "{" + name + "}"
+			params = append(params, Parameter{Name: name, In: "path", Required: true, Schema: &Schema{Type: "string"}})
+		}
+	}
+	return strings.Join(segs, "/"), params
+}
+
+// tagFor groups operations by the first path segment after the version, e.g. /api/v1/products/:id -> products
+func tagFor(p string) string {
+	p = strings.TrimPrefix(p, "/api/v1/")
+	seg, _, _ := strings.Cut(strings.TrimPrefix(p, "/"), "/")
+	if seg == "" || strings.HasPrefix(seg, ":") {
+		return "default"
+	}
+	return seg
+}
+
+func jsonContent(s *Schema) map[string]*MediaType {
+	return map[string]*MediaType{"application/json": {Schema: s}}
+}
+
+// errorSchema is the error envelope every handler writes on failure
+func errorSchema() *Schema {
+	return &Schema{Type: "object", Required: []string{"code", "message", "error"}, Properties: map[string]*Schema{
+		"code":       {Type: "string"},
+		"message":    {Type: "string"},
+		"details":    {Type: "object"},
+		"request_id": {Type: "string"},
+		"error":      {Type: "string"}, // copy of message for older clients
+	}}
+}
+
+type generator struct {
+	schemas map[string]*Schema
+}
+
+// schemaFor returns an inline schema for basic types and a $ref to a component for named structs
+func (g *generator) schemaFor(t reflect.Type) *Schema {
+	nullable := false
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+		nullable = true
+	}
+	s := g.baseSchema(t)
+	if nullable && s.Ref == "" {
+		s.Nullable = true
+	}
+	return s
+}
+
+func (g *generator) baseSchema(t reflect.Type) *Schema {
+	switch {
+	case t == timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case t == rawMessageType:
+		return &Schema{}
+	}
+	// Structs with their own JSON encoding don't follow their fields, so they are left open
+	if t.Kind() == reflect.Struct && (t.Implements(marshalerType) || reflect.PointerTo(t).Implements(marshalerType)) {
+		return &Schema{}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Int64, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Float32:
+		return &Schema{Type: "number", Format: "float"}
+	case reflect.Float64:
+		return &Schema{Type: "number", Format: "double"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: g.schemaFor(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: g.schemaFor(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return g.structSchema(t)
+		}
+		name := componentName(t)
+		if _, ok := g.schemas[name]; !ok {
+			g.schemas[name] = &Schema{} // placeholder so self references terminate
+			g.schemas[name] = g.structSchema(t)
+		}
+		return &Schema{Ref: "#/components/schemas/" + name}
+	}
+	return &Schema{}
+}
+
+func (g *generator) structSchema(t reflect.Type) *Schema {
+	s := &Schema{Type: "object", Properties: map[string]*Schema{}}
+	g.addFields(s, t)
+	sort.Strings(s.Required)
+	return s
+}
+
+func (g *generator) addFields(s *Schema, t reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		if f.Anonymous && name == "" {
+			ft := f.Type
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				g.addFields(s, ft)
+				continue
+			}
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		prop := g.schemaFor(f.Type)
+		if strings.Contains(opts, "string") {
+			prop = &Schema{Type: "string"}
+		}
+		if applyBinding(prop, f.Tag.Get("binding")) && !strings.Contains(opts, "omitempty") {
+			s.Required = append(s.Required, name)
+		}
+		s.Properties[name] = prop
+	}
+}
+
+// applyBinding copies validator constraints onto a property schema and reports whether it is required
+func applyBinding(s *Schema, binding string) bool {
+	required := false
+	if binding == "" || s.Ref != "" {
+		return strings.Contains(binding, "required")
+	}
+	for _, rule := range strings.Split(binding, ",") {
+		if rule == "dive" {
+			break
+		}
+		key, val, _ := strings.Cut(rule, "=")
+		switch key {
+		case "required", "notblank":
+			required = true
+		case "oneof":
+			if s.Type == "string" {
+				s.Enum = strings.Fields(val)
+			}
+		case "min", "gte", "gt", "max", "lte", "lt":
+			n, err := strconv.ParseFloat(val, 64)
+			if err != nil {
+				continue
+			}
+			setBound(s, key, n)
+		}
+	}
+	return required
+}
+
+func setBound(s *Schema, key string, n float64) {
+	lower := key == "min" || key == "gte" || key == "gt"
+	switch s.Type {
+	case "string":
+		v := int(n)
+		if lower {
+			s.MinLength = &v
+		} else {
+			s.MaxLength = &v
+		}
+	case "array":
+		if lower {
+			v := int(n)
+			s.MinItems = &v
+		}
+	case "integer", "number":
+		if lower {
+			s.Minimum = &n
+			s.ExclusiveMinimum = key == "gt"
+		} else {
+			s.Maximum = &n
+			s.ExclusiveMaximum = key == "lt"
+		}
+	}
+}
+
+// componentName is the schema name for a named type
+func componentName(t reflect.Type) string {
+	return upperFirst(t.Name())
+}
+
+// humanize turns "GetProductStockHistory" into "Get product stock history"
+func humanize(name string) string {
+	var b strings.Builder
+	for i, r := range name {
+		if i > 0 && unicode.IsUpper(r) {
+			b.WriteByte(' ')
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+func lowerFirst(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToLower(s[:1]) + s[1:]
+}
+
+func upperFirst(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToUpper(s[:1]) + s[1:]
+}
@@ -0,0 +1,446 @@
+// Package qrcode encodes short texts, such as signed deep links, as QR codes (byte mode, error
+// correction level M, versions 1-10, up to 213 bytes) and renders them as PNG or SVG.
+package qrcode
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+)
+
+// QuietZone is the light border, in modules, that scanners need around the code
+const QuietZone = 4
+
+// ErrTooLong is returned for texts that don't fit version 10
+var ErrTooLong = errors.New("text too long for a QR code")
+
+// versionInfo is the level M block structure of a version: error correction codewords per block
+// and the data codewords of each block
+type versionInfo struct {
+	ecPerBlock int
+	blocks     []int
+	alignment  []int
+}
+
+var versions = [...]versionInfo{
+	1:  {10, []int{16}, nil},
+	2:  {16, []int{28}, []int{6, 18}},
+	3:  {26, []int{44}, []int{6, 22}},
+	4:  {18, []int{32, 32}, []int{6, 26}},
+	5:  {24, []int{43, 43}, []int{6, 30}},
+	6:  {16, []int{27, 27, 27, 27}, []int{6, 34}},
+	7:  {18, []int{31, 31, 31, 31}, []int{6, 22, 38}},
+	8:  {22, []int{38, 38, 39, 39}, []int{6, 24, 42}},
+	9:  {22, []int{36, 36, 36, 37, 37}, []int{6, 26, 46}},
+	10: {26, []int{43, 43, 43, 43, 44}, []int{6, 28, 50}},
+}
+
+func (v versionInfo) dataCodewords() int {
+	n := 0
+	for _, b := range v.blocks {
+		n += b
+	}
+	return n
+}
+
+// Code is an encoded QR code; Dark reports the module at column x, row y
+type Code struct {
+	Size    int
+	modules [][]bool
+}
+
+// Dark reports whether the module at column x, row y is dark
+func (c *Code) Dark(x, y int) bool {
+	return c.modules[y][x]
+}
+
+// Encode encodes text in the smallest version that fits
+func Encode(text string) (*Code, error) {
+	data := []byte(text)
+	version := 0
+	for v := 1; v < len(versions); v++ {
+		countBits := 8
+		if v >= 10 {
+			countBits = 16
+		}
+		if 4+countBits+8*len(data) <= 8*versions[v].dataCodewords() {
+			version = v
+			break
+		}
+	}
+	if version == 0 {
+		return nil, ErrTooLong
+	}
+	info := versions[version]
+
+	// Mode 0100 (byte), character count, data, terminator, then pad bytes
+	var bits bitBuffer
+	bits.append(0b0100, 4)
+	if version >= 10 {
+		bits.append(len(data), 16)
+	} else {
+		bits.append(len(data), 8)
+	}
+	for _, b := range data {
+		bits.append(int(b), 8)
+	}
+	capacity := 8 * info.dataCodewords()
+	bits.append(0, min(4, capacity-len(bits)))
+	bits.append(0, (8-len(bits)%8)%8)
+	for pad := 0xEC; len(bits) < capacity; pad ^= 0xEC ^ 0x11 {
+		bits.append(pad, 8)
+	}
+
+	q := newGrid(version)
+	q.drawCodewords(interleave(bits.bytes(), info))
+	best, bestPenalty := -1, 0
+	for mask := 0; mask < 8; mask++ {
+		q.applyMask(mask)
+		q.drawFormatBits(mask)
+		if p := q.penalty(); best < 0 || p < bestPenalty {
+			best, bestPenalty = mask, p
+		}
+		q.applyMask(mask) // masking is its own inverse
+	}
+	q.applyMask(best)
+	q.drawFormatBits(best)
+	return &Code{Size: q.size, modules: q.modules}, nil
+}
+
+// PNG renders the code with scale pixels per module and the quiet zone
+func (c *Code) PNG(scale int) ([]byte, error) {
+	if scale < 1 {
+		return nil, fmt.Errorf("invalid scale %d", scale)
+	}
+	side := (c.Size + 2*QuietZone) * scale
+	img := image.NewGray(image.Rect(0, 0, side, side))
+	for i := range img.Pix {
+		img.Pix[i] = 0xFF
+	}
+	for y := 0; y < c.Size; y++ {
+		for x := 0; x < c.Size; x++ {
+			if !c.modules[y][x] {
+				continue
+			}
+			for dy := 0; dy < scale; dy++ {
+				for dx := 0; dx < scale; dx++ {
+					img.SetGray((x+QuietZone)*scale+dx, (y+QuietZone)*scale+dy, color.Gray{})
+				}
+			}
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// SVG renders the code as one path in a viewBox of modules, scale pixels per module by default
+func (c *Code) SVG(scale int) []byte {
+	side := c.Size + 2*QuietZone
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" shape-rendering="crispEdges">`,
+		side*scale, side*scale, side, side)
+	fmt.Fprintf(&buf, `<rect width="%d" height="%d" fill="#fff"/><path fill="#000" d="`, side, side)
+	for y := 0; y < c.Size; y++ {
+		for x := 0; x < c.Size; x++ {
+			if c.modules[y][x] {
+				fmt.Fprintf(&buf, "M%d %dh1v1h-1z", x+QuietZone, y+QuietZone)
+			}
+		}
+	}
+	buf.WriteString(`"/></svg>`)
+	return buf.Bytes()
+}
+
+type bitBuffer []bool
+
+func (b *bitBuffer) append(v, n int) {
+	for i := n - 1; i >= 0; i-- {
+		*b = append(*b, v>>i&1 == 1)
+	}
+}
+
+func (b bitBuffer) bytes() []byte {
+	out := make([]byte, len(b)/8)
+	for i, bit := range b {
+		if bit {
+			out[i/8] |= 0x80 >> (i % 8)
+		}
+	}
+	return out
+}
+
+// interleave splits data into the version's blocks, adds Reed-Solomon codewords and interleaves them
+func interleave(data []byte, info versionInfo) []byte {
+	divisor := rsDivisor(info.ecPerBlock)
+	var blocks, ecc [][]byte
+	for _, n := range info.blocks {
+		blocks = append(blocks, data[:n])
+		ecc = append(ecc, rsRemainder(data[:n], divisor))
+		data = data[n:]
+	}
+	var out []byte
+	longest := info.blocks[len(info.blocks)-1]
+	for i := 0; i < longest; i++ {
+		for _, b := range blocks {
+			if i < len(b) {
+				out = append(out, b[i])
+			}
+		}
+	}
+	for i := 0; i < info.ecPerBlock; i++ {
+		for _, e := range ecc {
+			out = append(out, e[i])
+		}
+	}
+	return out
+}
+
+// gfMul multiplies in GF(256) modulo x^8 + x^4 + x^3 + x^2 + 1
+func gfMul(x, y byte) byte {
+	var z int
+	for i := 7; i >= 0; i-- {
+		z = z<<1 ^ (z>>7)*0x11D
+		z ^= int(y>>i&1) * int(x)
+	}
+	return byte(z)
+}
+
+func rsDivisor(degree int) []byte {
+	result := make([]byte, degree)
+	result[degree-1] = 1
+	root := byte(1)
+	for i := 0; i < degree; i++ {
+		for j := range result {
+			result[j] = gfMul(result[j], root)
+			if j+1 < degree {
+				result[j] ^= result[j+1]
+			}
+		}
+		root = gfMul(root, 0x02)
+	}
+	return result
+}
+
+func rsRemainder(data, divisor []byte) []byte {
+	result := make([]byte, len(divisor))
+	for _, b := range data {
+		factor := b ^ result[0]
+		copy(result, result[1:])
+		result[len(result)-1] = 0
+		for i := range result {
+			result[i] ^= gfMul(divisor[i], factor)
+		}
+	}
+	return result
+}
+
+type grid struct {
+	size     int
+	modules  [][]bool
+	function [][]bool
+}
+
+func newGrid(version int) *grid {
+	size := 17 + 4*version
+	g := &grid{size: size, modules: make([][]bool, size), function: make([][]bool, size)}
+	for i := range g.modules {
+		g.modules[i] = make([]bool, size)
+		g.function[i] = make([]bool, size)
+	}
+
+	for i := 0; i < size; i++ {
+		g.set(6, i, i%2 == 0)
+		g.set(i, 6, i%2 == 0)
+	}
+	for _, p := range [][2]int{{3, 3}, {size - 4, 3}, {3, size - 4}} {
+		for dy := -4; dy <= 4; dy++ {
+			for dx := -4; dx <= 4; dx++ {
+				x, y := p[0]+dx, p[1]+dy
+				if x >= 0 && x < size && y >= 0 && y < size {
+					d := max(abs(dx), abs(dy))
+					g.set(x, y, d != 2 && d != 4)
+				}
+			}
+		}
+	}
+	align := versions[version].alignment
+	for i, ax := range align {
+		for j, ay := range align {
+			last := len(align) - 1
+			if (i == 0 && j == 0) || (i == 0 && j == last) || (i == last && j == 0) {
+				continue // overlaps a finder
+			}
+			for dy := -2; dy <= 2; dy++ {
+				for dx := -2; dx <= 2; dx++ {
+					g.set(ax+dx, ay+dy, max(abs(dx), abs(dy)) != 1)
+				}
+			}
+		}
+	}
+	g.drawFormatBits(0) // reserves the format areas
+	if version >= 7 {
+		rem := version
+		for i := 0; i < 12; i++ {
+			rem = rem<<1 ^ (rem>>11)*0x1F25
+		}
+		bits := version<<12 | rem
+		for i := 0; i < 18; i++ {
+			bit := bits>>i&1 == 1
+			a, b := size-11+i%3, i/3
+			g.set(a, b, bit)
+			g.set(b, a, bit)
+		}
+	}
+	return g
+}
+
+func (g *grid) set(x, y int, dark bool) {
+	g.modules[y][x] = dark
+	g.function[y][x] = true
+}
+
+// drawFormatBits writes level M and the mask, with their BCH code, in both copies
+func (g *grid) drawFormatBits(mask int) {
+	data := 0<<3 | mask // level M is 00
+	rem := data
+	for i := 0; i < 10; i++ {
+		rem = rem<<1 ^ (rem>>9)*0x537
+	}
+	bits := (data<<10 | rem) ^ 0x5412
+	bit := func(i int) bool { return bits>>i&1 == 1 }
+
+	for i := 0; i <= 5; i++ {
+		g.set(8, i, bit(i))
+	}
+	g.set(8, 7, bit(6))
+	g.set(8, 8, bit(7))
+	g.set(7, 8, bit(8))
+	for i := 9; i < 15; i++ {
+		g.set(14-i, 8, bit(i))
+	}
+	for i := 0; i < 8; i++ {
+		g.set(g.size-1-i, 8, bit(i))
+	}
+	for i := 8; i < 15; i++ {
+		g.set(8, g.size-15+i, bit(i))
+	}
+	g.set(8, g.size-8, true) // always dark
+}
+
+// drawCodewords places the codewords in the zigzag order, skipping the timing column
+func (g *grid) drawCodewords(data []byte) {
+	i := 0
+	for right := g.size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5
+		}
+		for vert := 0; vert < g.size; vert++ {
+			for j := 0; j < 2; j++ {
+				x := right - j
+				y := vert
+				if (right+1)&2 == 0 {
+					y = g.size - 1 - vert
+				}
+				if !g.function[y][x] && i < len(data)*8 {
+					g.modules[y][x] = data[i>>3]>>(7-i&7)&1 == 1
+					i++
+				}
+			}
+		}
+	}
+}
+
+func (g *grid) applyMask(mask int) {
+	for y := 0; y < g.size; y++ {
+		for x := 0; x < g.size; x++ {
+			var invert bool
+			switch mask {
+			case 0:
+				invert = (x+y)%2 == 0
+			case 1:
+				invert = y%2 == 0
+			case 2:
+				invert = x%3 == 0
+			case 3:
+				invert = (x+y)%3 == 0
+			case 4:
+				invert = (x/3+y/2)%2 == 0
+			case 5:
+				invert = x*y%2+x*y%3 == 0
+			case 6:
+				invert = (x*y%2+x*y%3)%2 == 0
+			case 7:
+				invert = ((x+y)%2+x*y%3)%2 == 0
+			}
+			if invert && !g.function[y][x] {
+				g.modules[y][x] = !g.modules[y][x]
+			}
+		}
+	}
+}
+
+// penalty scores a masked grid by the four rules of the standard; lower is easier to scan
+func (g *grid) penalty() int {
+	score := 0
+	line := func(at func(i int) bool) {
+		run := 1
+		for i := 1; i <= g.size; i++ {
+			if i < g.size && at(i) == at(i-1) {
+				run++
+				continue
+			}
+			if run >= 5 {
+				score += 3 + run - 5
+			}
+			run = 1
+		}
+		// 1:1:3:1:1 finder-like patterns with four light modules on one side
+		for i := 0; i+11 <= g.size; i++ {
+			a, b := true, true
+			for k, dark := range finderLike {
+				a = a && at(i+k) == dark
+				b = b && at(i+10-k) == dark
+			}
+			if a {
+				score += 40
+			}
+			if b {
+				score += 40
+			}
+		}
+	}
+	dark := 0
+	for y := 0; y < g.size; y++ {
+		line(func(i int) bool { return g.modules[y][i] })
+		line(func(i int) bool { return g.modules[i][y] })
+		for x := 0; x < g.size; x++ {
+			if g.modules[y][x] {
+				dark++
+			}
+			if x+1 < g.size && y+1 < g.size {
+				c := g.modules[y][x]
+				if g.modules[y][x+1] == c && g.modules[y+1][x] == c && g.modules[y+1][x+1] == c {
+					score += 3
+				}
+			}
+		}
+	}
+	total := g.size * g.size
+	score += abs(dark*20-total*10) / total * 10
+	return score
+}
+
+var finderLike = []bool{true, false, true, true, true, false, true, false, false, false, false}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
@@ -0,0 +1,152 @@
+package translate
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// Provider machine-translates a batch of texts; the result has the same length and order as texts.
+// Locales are BCP 47 tags as used in app_product_translations (e.g. "en", "de", "zh-CN").
+type Provider interface {
+	Name() string
+	Translate(ctx context.Context, texts []string, sourceLocale, targetLocale string) ([]string, error)
+}
+
+// NewProviderFromEnv returns the provider selected by TRANSLATION_PROVIDER, or nil when machine
+// drafting is disabled:
+//   - "deepl": DEEPL_API_KEY (DEEPL_API_URL overrides the endpoint, e.g. the free API host)
+//   - "http":  TRANSLATION_PROVIDER_URL, optional bearer TRANSLATION_PROVIDER_TOKEN
+func NewProviderFromEnv() Provider {
+	client := &http.Client{Timeout: 20 * time.Second}
+	switch strings.ToLower(strings.TrimSpace(os.Getenv("TRANSLATION_PROVIDER"))) {
+	case "deepl":
+		key := strings.TrimSpace(os.Getenv("DEEPL_API_KEY"))
+		if key == "" {
+			return nil
+		}
+		url := strings.TrimSpace(os.Getenv("DEEPL_API_URL"))
+		if url == "" {
+			url = "https://api.deepl.com/v2/translate"
+		}
+		return &DeepL{URL: url, Key: key, Client: client}
+	case "http":
+		url := strings.TrimSpace(os.Getenv("TRANSLATION_PROVIDER_URL"))
+		if url == "" {
+			return nil
+		}
+		return &HTTPProvider{URL: url, Token: os.Getenv("TRANSLATION_PROVIDER_TOKEN"), Client: client}
+	}
+	return nil
+}
+
+// DeepL calls the DeepL v2 translate API
+type DeepL struct {
+	URL    string
+	Key    string
+	Client *http.Client
+}
+
+func (d *DeepL) Name() string { return "deepl" }
+
+func (d *DeepL) Translate(ctx context.Context, texts []string, sourceLocale, targetLocale string) ([]string, error) {
+	req := map[string]interface{}{
+		"text":        texts,
+		"source_lang": deeplLang(sourceLocale, false),
+		"target_lang": deeplLang(targetLocale, true),
+	}
+	var resp struct {
+		Translations []struct {
+			Text string `json:"text"`
+		} `json:"translations"`
+	}
+	if err := postJSON(ctx, d.Client, d.URL, "DeepL-Auth-Key "+d.Key, req, &resp); err != nil {
+		return nil, err
+	}
+	out := make([]string, len(resp.Translations))
+	for i, t := range resp.Translations {
+		out[i] = t.Text
+	}
+	return checkCount(out, texts)
+}
+
+// deeplLang maps a locale to DeepL's language codes; source languages carry no region,
+// and English/Portuguese targets require one.
+func deeplLang(locale string, target bool) string {
+	l := strings.ToUpper(strings.ReplaceAll(locale, "_", "-"))
+	base := strings.SplitN(l, "-", 2)[0]
+	if !target {
+		return base
+	}
+	switch l {
+	case "EN":
+		return "EN-GB"
+	case "PT":
+		return "PT-PT"
+	case "ZH-CN", "ZH-HANS":
+		return "ZH"
+	}
+	return l
+}
+
+// HTTPProvider posts {"texts", "source_locale", "target_locale"} and expects {"translations": [...]}
+type HTTPProvider struct {
+	URL    string
+	Token  string
+	Client *http.Client
+}
+
+func (p *HTTPProvider) Name() string { return "http" }
+
+func (p *HTTPProvider) Translate(ctx context.Context, texts []string, sourceLocale, targetLocale string) ([]string, error) {
+	auth := ""
+	if p.Token != "" {
+		auth = "Bearer " + p.Token
+	}
+	var resp struct {
+		Translations []string `json:"translations"`
+	}
+	req := map[string]interface{}{"texts": texts, "source_locale": sourceLocale, "target_locale": targetLocale}
+	if err := postJSON(ctx, p.Client, p.URL, auth, req, &resp); err != nil {
+		return nil, err
+	}
+	return checkCount(resp.Translations, texts)
+}
+
+func postJSON(ctx context.Context, client *http.Client, url, auth string, body, out interface{}) error {
+	raw, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(raw))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if auth != "" {
+		req.Header.Set("Authorization", auth)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("translation provider returned %d: %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+func checkCount(out, texts []string) ([]string, error) {
+	if len(out) != len(texts) {
+		return nil, fmt.Errorf("translation provider returned %d texts for %d inputs", len(out), len(texts))
+	}
+	return out, nil
+}
@@ -0,0 +1,117 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Event names emitted by the catalog service: "<entity>.<created|updated|deleted>"
+const (
+	EventProductCreated     = "product.created"
+	EventProductUpdated     = "product.updated"
+	EventProductDeleted     = "product.deleted"
+	EventCategoryCreated    = "category.created"
+	EventCategoryUpdated    = "category.updated"
+	EventCategoryDeleted    = "category.deleted"
+	EventSubcategoryCreated = "subcategory.created"
+	EventSubcategoryUpdated = "subcategory.updated"
+	EventSubcategoryDeleted = "subcategory.deleted"
+	EventStoreCreated       = "store.created"
+	EventStoreUpdated       = "store.updated"
+	EventStoreDeleted       = "store.deleted"
+
+	// EventPing is only sent by the endpoint test action
+	EventPing = "ping"
+)
+
+// Events lists every event an endpoint may subscribe to
+var Events = []string{
+	EventProductCreated, EventProductUpdated, EventProductDeleted,
+	EventCategoryCreated, EventCategoryUpdated, EventCategoryDeleted,
+	EventSubcategoryCreated, EventSubcategoryUpdated, EventSubcategoryDeleted,
+	EventStoreCreated, EventStoreUpdated, EventStoreDeleted,
+}
+
+// ValidEvent reports whether name is a known event
+func ValidEvent(name string) bool {
+	for _, e := range Events {
+		if e == name {
+			return true
+		}
+	}
+	return false
+}
+
+// SignatureHeader carries "t=<unix>,v1=<hex hmac-sha256(secret, t + "." + body)>"
+const SignatureHeader = "X-Catalog-Signature"
+
+// maxResponseLogBytes caps the endpoint response kept on the delivery
+const maxResponseLogBytes = 1024
+
+// Envelope is the JSON body POSTed to every endpoint
+type Envelope struct {
+	ID         string         `json:"id"`
+	Event      string         `json:"event"`
+	OccurredAt time.Time      `json:"occurred_at"`
+	Data       map[string]any `json:"data"`
+}
+
+// Sign returns the signature header value for body at time t
+func Sign(secret string, t time.Time, body []byte) string {
+	ts := strconv.FormatInt(t.Unix(), 10)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(ts))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "t=" + ts + ",v1=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// NewSecret generates an endpoint signing secret
+func NewSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return "whsec_" + hex.EncodeToString(b), nil
+}
+
+// Send POSTs a signed envelope and returns the response status and (truncated) body
+func Send(ctx context.Context, client *http.Client, url, secret, deliveryID, event string, body []byte) (int, string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return 0, "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "expotoworld-catalog-webhooks/1")
+	req.Header.Set("X-Catalog-Event", event)
+	req.Header.Set("X-Catalog-Delivery", deliveryID)
+	req.Header.Set(SignatureHeader, Sign(secret, time.Now(), body))
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, "", err
+	}
+	defer resp.Body.Close()
+	b, _ := io.ReadAll(io.LimitReader(resp.Body, maxResponseLogBytes))
+	return resp.StatusCode, string(b), nil
+}
+
+// Backoff returns the delay before the next attempt: 30s, 1m, 2m, ... capped at 1h
+func Backoff(attempts int) time.Duration {
+	d := 30 * time.Second
+	for i := 1; i < attempts; i++ {
+		d *= 2
+		if d >= time.Hour {
+			return time.Hour
+		}
+	}
+	return d
+}
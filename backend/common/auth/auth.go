@@ -0,0 +1,257 @@
+// Package auth is the gin middleware services use to accept auth-service access tokens. It verifies
+// the bearer token and exposes its claims under the same context keys in every service.
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/expotoworld/expotoworld/backend/common/jwks"
+	"github.com/expotoworld/expotoworld/backend/common/rbac"
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Context keys the middleware sets from the token's claims
+const (
+	KeyUserID         = "user_id"
+	KeyEmail          = "email"
+	KeyRole           = "role"
+	KeyPermissions    = "permissions"
+	KeyOrgMemberships = "org_memberships"
+	KeyAdminScope     = "admin_scope"
+	// KeyImpersonator is the admin acting through an impersonation token
+	KeyImpersonator = "impersonator"
+	// KeyClientID identifies the service client of a client-credentials token
+	KeyClientID = "client_id"
+)
+
+// OrgMembership is an entry of the org_memberships claim
+type OrgMembership struct {
+	OrgID   string
+	OrgType string
+	OrgRole string
+	Name    string
+}
+
+// Config adapts the middleware to a service. The zero value verifies tokens with jwks.FromEnv and
+// answers rejections with {"error": ..., "message": ...}.
+type Config struct {
+	// Keys verifies tokens; nil uses jwks.FromEnv()
+	Keys *jwks.Verifier
+	// Error writes a rejection; err is a short title and message explains it
+	Error func(c *gin.Context, status int, err, message string)
+	// AdminScope converts the admin_scope claim into the value stored under KeyAdminScope; nil
+	// stores the claim as is
+	AdminScope func(claim interface{}) interface{}
+	// Versions rejects user tokens whose token_version is older than the user's current one; nil
+	// skips the check (see VersionCache)
+	Versions VersionChecker
+}
+
+func (cfg Config) keys() *jwks.Verifier {
+	if cfg.Keys != nil {
+		return cfg.Keys
+	}
+	return jwks.FromEnv()
+}
+
+func (cfg Config) reject(c *gin.Context, status int, err, message string) {
+	if cfg.Error != nil {
+		cfg.Error(c, status, err, message)
+	} else {
+		c.JSON(status, gin.H{"error": err, "message": message})
+	}
+	c.Abort()
+}
+
+// BearerToken extracts the token from an "Authorization: Bearer <token>" header
+func BearerToken(header string) (string, bool) {
+	token, ok := strings.CutPrefix(header, "Bearer ")
+	token = strings.TrimSpace(token)
+	return token, ok && token != ""
+}
+
+// Required rejects requests without a valid access token
+func (cfg Config) Required() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if cfg.Authenticate(c) {
+			c.Next()
+		}
+	}
+}
+
+// Authenticate verifies the request's access token and sets its claims, like Required without
+// calling the next handler. It answers and aborts the request and returns false when it's rejected.
+func (cfg Config) Authenticate(c *gin.Context) bool {
+	header := c.GetHeader("Authorization")
+	if header == "" {
+		cfg.reject(c, http.StatusUnauthorized, "Authorization header required", "Please provide a valid authorization token")
+		return false
+	}
+	token, ok := BearerToken(header)
+	if !ok {
+		cfg.reject(c, http.StatusUnauthorized, "Invalid authorization format", "Authorization header must be in format 'Bearer <token>'")
+		return false
+	}
+	keys := cfg.keys()
+	if !keys.Configured() {
+		cfg.reject(c, http.StatusInternalServerError, "Server not configured", "JWKS_URL or JWT_SECRET missing")
+		return false
+	}
+	claims, err := keys.Parse(token)
+	if err != nil {
+		cfg.reject(c, http.StatusUnauthorized, "Invalid token", "The provided token is invalid or expired")
+		return false
+	}
+	if err := cfg.checkVersion(claims, token); err != nil {
+		if errors.Is(err, ErrTokenRevoked) {
+			cfg.reject(c, http.StatusUnauthorized, "Token revoked", "The provided token has been revoked; please sign in again")
+		} else {
+			cfg.reject(c, http.StatusServiceUnavailable, "Token check failed", "Could not validate the token; please retry")
+		}
+		return false
+	}
+	cfg.SetClaims(c, claims)
+	return true
+}
+
+// Optional sets the claims of a valid access token but lets every request through; combine it with
+// RequireUser or RequirePermission on the routes that need a caller. Revoked tokens, and tokens
+// whose version couldn't be checked, are treated as absent.
+func (cfg Config) Optional() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if token, ok := BearerToken(c.GetHeader("Authorization")); ok {
+			if claims, err := cfg.keys().Parse(token); err == nil && cfg.checkVersion(claims, token) == nil {
+				cfg.SetClaims(c, claims)
+			}
+		}
+		c.Next()
+	}
+}
+
+// checkVersion runs CheckTokenVersion with cfg.Versions
+func (cfg Config) checkVersion(claims jwt.MapClaims, token string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	return CheckTokenVersion(ctx, cfg.Versions, claims, token)
+}
+
+// SetClaims stores the claims services rely on under the Key* context keys
+func (cfg Config) SetClaims(c *gin.Context, claims jwt.MapClaims) {
+	if v, ok := claims["user_id"].(string); ok && v != "" {
+		c.Set(KeyUserID, v)
+	}
+	if v, ok := claims["email"].(string); ok {
+		c.Set(KeyEmail, v)
+	}
+	if v, ok := claims["role"].(string); ok {
+		c.Set(KeyRole, v)
+	}
+	if v, ok := claims["permissions"]; ok {
+		c.Set(KeyPermissions, v)
+	}
+	if v, ok := claims["org_memberships"]; ok {
+		c.Set(KeyOrgMemberships, v)
+	}
+	if v, ok := claims["admin_scope"]; ok {
+		if cfg.AdminScope != nil {
+			v = cfg.AdminScope(v)
+		}
+		c.Set(KeyAdminScope, v)
+	}
+	if act, ok := claims["act"].(map[string]interface{}); ok {
+		if sub, _ := act["sub"].(string); sub != "" {
+			c.Set(KeyImpersonator, sub)
+		}
+	}
+	if v, ok := claims["client_id"].(string); ok && v != "" {
+		c.Set(KeyClientID, v)
+	}
+}
+
+// RequireUser rejects requests whose token (if any) doesn't identify a user
+func (cfg Config) RequireUser() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if UserID(c) == "" {
+			cfg.reject(c, http.StatusUnauthorized, "Authentication required", "Please provide a valid authorization token")
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequirePermission rejects callers whose token doesn't grant perm (tokens without a permissions
+// claim fall back to the built-in permissions of their role)
+func (cfg Config) RequirePermission(perm string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !HasPermission(c, perm) {
+			cfg.reject(c, http.StatusForbidden, "Admin access required", "The "+perm+" permission is required")
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequireRole rejects callers whose primary role isn't role (compared case-insensitively)
+func (cfg Config) RequireRole(role string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !strings.EqualFold(Role(c), role) {
+			cfg.reject(c, http.StatusForbidden, "Access denied", "The "+role+" role is required")
+			return
+		}
+		c.Next()
+	}
+}
+
+// UserID returns the caller's user id, "" for anonymous requests and service tokens
+func UserID(c *gin.Context) string {
+	return c.GetString(KeyUserID)
+}
+
+// Role returns the caller's primary role
+func Role(c *gin.Context) string {
+	return c.GetString(KeyRole)
+}
+
+// HasPermission reports whether the caller's token grants perm
+func HasPermission(c *gin.Context, perm string) bool {
+	perms, _ := c.Get(KeyPermissions)
+	return rbac.Granted(perms, Role(c), perm)
+}
+
+// OrgMemberships returns the organizations in the caller's org_memberships claim
+func OrgMemberships(c *gin.Context) []OrgMembership {
+	v, _ := c.Get(KeyOrgMemberships)
+	arr, _ := v.([]interface{})
+	orgs := make([]OrgMembership, 0, len(arr))
+	for _, it := range arr {
+		m, ok := it.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		id, _ := m["org_id"].(string)
+		if id == "" {
+			continue
+		}
+		orgType, _ := m["org_type"].(string)
+		orgRole, _ := m["org_role"].(string)
+		name, _ := m["name"].(string)
+		orgs = append(orgs, OrgMembership{OrgID: id, OrgType: orgType, OrgRole: orgRole, Name: name})
+	}
+	return orgs
+}
+
+// OrgIDs lists the caller's organizations of orgType, or all of them when orgType is empty
+func OrgIDs(c *gin.Context, orgType string) []string {
+	ids := []string{}
+	for _, m := range OrgMemberships(c) {
+		if orgType == "" || m.OrgType == orgType {
+			ids = append(ids, m.OrgID)
+		}
+	}
+	return ids
+}
@@ -0,0 +1,195 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/expotoworld/expotoworld/backend/common/jwks"
+	"github.com/expotoworld/expotoworld/backend/common/rbac"
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const testSecret = "test-secret"
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+func sign(t *testing.T, claims jwt.MapClaims) string {
+	t.Helper()
+	if _, ok := claims["exp"]; !ok {
+		claims["exp"] = time.Now().Add(time.Hour).Unix()
+	}
+	s, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(testSecret))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+// serve runs the handlers with a final handler recording the context, and returns the response and context
+func serve(t *testing.T, header string, handlers ...gin.HandlerFunc) (*httptest.ResponseRecorder, *gin.Context) {
+	t.Helper()
+	var seen *gin.Context
+	r := gin.New()
+	r.GET("/", append(handlers, func(c *gin.Context) {
+		seen = c.Copy()
+		c.Status(http.StatusOK)
+	})...)
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	if header != "" {
+		req.Header.Set("Authorization", header)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w, seen
+}
+
+func testConfig() Config {
+	return Config{Keys: jwks.NewVerifier("", []byte(testSecret))}
+}
+
+func TestRequired(t *testing.T) {
+	cfg := testConfig()
+	valid := sign(t, jwt.MapClaims{"user_id": "u1"})
+	expired := sign(t, jwt.MapClaims{"user_id": "u1", "exp": time.Now().Add(-time.Minute).Unix()})
+	tests := []struct {
+		name   string
+		header string
+		want   int
+	}{
+		{"valid token", "Bearer " + valid, http.StatusOK},
+		{"missing header", "", http.StatusUnauthorized},
+		{"wrong scheme", "Basic " + valid, http.StatusUnauthorized},
+		{"empty token", "Bearer ", http.StatusUnauthorized},
+		{"expired token", "Bearer " + expired, http.StatusUnauthorized},
+		{"garbage token", "Bearer abc.def.ghi", http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if w, _ := serve(t, tt.header, cfg.Required()); w.Code != tt.want {
+				t.Fatalf("status = %d, want %d", w.Code, tt.want)
+			}
+		})
+	}
+}
+
+func TestRequiredUnconfigured(t *testing.T) {
+	cfg := Config{Keys: jwks.NewVerifier("", nil)}
+	if w, _ := serve(t, "Bearer x", cfg.Required()); w.Code != http.StatusInternalServerError {
+		t.Fatalf("status = %d, want 500", w.Code)
+	}
+}
+
+func TestCustomError(t *testing.T) {
+	cfg := testConfig()
+	var got int
+	cfg.Error = func(c *gin.Context, status int, err, message string) {
+		got = status
+		c.JSON(status, gin.H{"code": "UNAUTHORIZED"})
+	}
+	w, _ := serve(t, "", cfg.Required())
+	if got != http.StatusUnauthorized || w.Code != http.StatusUnauthorized {
+		t.Fatalf("Error called with %d, response %d", got, w.Code)
+	}
+}
+
+func TestSetClaims(t *testing.T) {
+	cfg := testConfig()
+	cfg.AdminScope = func(v interface{}) interface{} { return "parsed" }
+	token := sign(t, jwt.MapClaims{
+		"user_id":     "u1",
+		"email":       "a@example.com",
+		"role":        "Manufacturer",
+		"permissions": []string{rbac.AdminPanel},
+		"org_memberships": []map[string]string{
+			{"org_id": "o1", "org_type": "Manufacturer", "org_role": "Owner", "name": "Acme"},
+			{"org_id": "o2", "org_type": "Partner"},
+			{"org_type": "Partner"},
+		},
+		"admin_scope": map[string]any{"region_ids": []int{1}},
+		"act":         map[string]string{"sub": "admin-1"},
+	})
+	w, c := serve(t, "Bearer "+token, cfg.Required())
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d", w.Code)
+	}
+	if UserID(c) != "u1" || c.GetString(KeyEmail) != "a@example.com" || Role(c) != "Manufacturer" {
+		t.Fatalf("identity = %q %q %q", UserID(c), c.GetString(KeyEmail), Role(c))
+	}
+	if c.GetString(KeyImpersonator) != "admin-1" {
+		t.Fatalf("impersonator = %q", c.GetString(KeyImpersonator))
+	}
+	if v, _ := c.Get(KeyAdminScope); v != "parsed" {
+		t.Fatalf("admin scope = %v", v)
+	}
+	if !HasPermission(c, rbac.AdminPanel) || HasPermission(c, rbac.CatalogWrite) {
+		t.Fatal("permissions claim not applied")
+	}
+	want := []OrgMembership{{OrgID: "o1", OrgType: "Manufacturer", OrgRole: "Owner", Name: "Acme"}, {OrgID: "o2", OrgType: "Partner"}}
+	if got := OrgMemberships(c); !reflect.DeepEqual(got, want) {
+		t.Fatalf("memberships = %+v", got)
+	}
+	if got := OrgIDs(c, "Partner"); !reflect.DeepEqual(got, []string{"o2"}) {
+		t.Fatalf("partner orgs = %v", got)
+	}
+	if got := OrgIDs(c, ""); len(got) != 2 {
+		t.Fatalf("all orgs = %v", got)
+	}
+}
+
+func TestServiceTokenHasNoUser(t *testing.T) {
+	cfg := testConfig()
+	token := sign(t, jwt.MapClaims{"client_id": "svc", "token_use": "service", "permissions": []string{rbac.PushSend}})
+	w, c := serve(t, "Bearer "+token, cfg.Required())
+	if w.Code != http.StatusOK || UserID(c) != "" || c.GetString(KeyClientID) != "svc" {
+		t.Fatalf("status %d user %q client %q", w.Code, UserID(c), c.GetString(KeyClientID))
+	}
+	if w, _ := serve(t, "Bearer "+token, cfg.Required(), cfg.RequireUser()); w.Code != http.StatusUnauthorized {
+		t.Fatalf("RequireUser status = %d", w.Code)
+	}
+}
+
+func TestOptional(t *testing.T) {
+	cfg := testConfig()
+	token := sign(t, jwt.MapClaims{"user_id": "u1"})
+	for _, header := range []string{"", "Bearer bad", "Basic x"} {
+		if w, c := serve(t, header, cfg.Optional()); w.Code != http.StatusOK || UserID(c) != "" {
+			t.Fatalf("header %q: status %d user %q", header, w.Code, UserID(c))
+		}
+	}
+	if _, c := serve(t, "Bearer "+token, cfg.Optional()); UserID(c) != "u1" {
+		t.Fatalf("user = %q", UserID(c))
+	}
+	if w, _ := serve(t, "", cfg.Optional(), cfg.RequireUser()); w.Code != http.StatusUnauthorized {
+		t.Fatalf("RequireUser status = %d", w.Code)
+	}
+}
+
+func TestRequirePermissionAndRole(t *testing.T) {
+	cfg := testConfig()
+	legacyAdmin := sign(t, jwt.MapClaims{"user_id": "u1", "role": "Admin"})
+	author := sign(t, jwt.MapClaims{"user_id": "u2", "role": "author", "permissions": []string{}})
+	tests := []struct {
+		name    string
+		token   string
+		handler gin.HandlerFunc
+		want    int
+	}{
+		{"role fallback grants", legacyAdmin, cfg.RequirePermission(rbac.OrdersManage), http.StatusOK},
+		{"empty claim denies", author, cfg.RequirePermission(rbac.AdminPanel), http.StatusForbidden},
+		{"role matches case-insensitively", author, cfg.RequireRole("Author"), http.StatusOK},
+		{"other role", legacyAdmin, cfg.RequireRole("Author"), http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if w, _ := serve(t, "Bearer "+tt.token, cfg.Required(), tt.handler); w.Code != tt.want {
+				t.Fatalf("status = %d, want %d", w.Code, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,184 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// TokenVersionClaim carries the user's token version in access tokens. auth-service bumps the stored
+// version on a forced logout or sign-in method change, making every token issued before stale.
+const TokenVersionClaim = "token_version"
+
+// ErrTokenRevoked is returned by a VersionChecker when the token can't be current whatever its
+// version, e.g. its user was deleted
+var ErrTokenRevoked = errors.New("token revoked")
+
+// VersionChecker returns a user's current token version. token is the access token being checked,
+// for checkers that ask auth-service about it rather than read the database.
+type VersionChecker interface {
+	TokenVersion(ctx context.Context, userID, token string) (int, error)
+}
+
+// VersionFunc reads the version from the shared database (app_users.token_version)
+type VersionFunc func(ctx context.Context, userID string) (int, error)
+
+// TokenVersion implements VersionChecker
+func (f VersionFunc) TokenVersion(ctx context.Context, userID, _ string) (int, error) {
+	return f(ctx, userID)
+}
+
+// TokenVersion reads the version claim; tokens issued before versions existed count as version 0
+func TokenVersion(claims jwt.MapClaims) int {
+	v, _ := claims[TokenVersionClaim].(float64)
+	return int(v)
+}
+
+// CheckTokenVersion returns ErrTokenRevoked when a user token's version is older than the user's
+// current one, or the checker's error. Service tokens carry no user and aren't versioned; a nil
+// versions skips the check.
+func CheckTokenVersion(ctx context.Context, versions VersionChecker, claims jwt.MapClaims, token string) error {
+	userID, _ := claims["user_id"].(string)
+	if versions == nil || userID == "" {
+		return nil
+	}
+	version, err := versions.TokenVersion(ctx, userID, token)
+	if err != nil {
+		return err
+	}
+	if TokenVersion(claims) < version {
+		return ErrTokenRevoked
+	}
+	return nil
+}
+
+// maxCachedTokenVersions bounds the cache; it is cleared rather than evicted when full
+const maxCachedTokenVersions = 10000
+
+type cachedTokenVersion struct {
+	version   int
+	fetchedAt time.Time
+}
+
+// VersionCache keeps the versions returned by another checker for a short TTL so the middleware
+// doesn't look them up on every request. A bump is seen once the user's entry expires, or at once
+// by the instance that made it and called Set.
+type VersionCache struct {
+	src VersionChecker
+	ttl time.Duration
+
+	mu      sync.Mutex
+	entries map[string]cachedTokenVersion
+}
+
+// NewVersionCache caches src's versions for ttl
+func NewVersionCache(src VersionChecker, ttl time.Duration) *VersionCache {
+	return &VersionCache{src: src, ttl: ttl, entries: map[string]cachedTokenVersion{}}
+}
+
+// VersionCacheTTL is TOKEN_VERSION_CACHE_SECONDS (default 30), the longest a revoked token is
+// still accepted
+func VersionCacheTTL() time.Duration {
+	seconds := 30
+	if n, err := strconv.Atoi(strings.TrimSpace(os.Getenv("TOKEN_VERSION_CACHE_SECONDS"))); err == nil && n >= 0 {
+		seconds = n
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// TokenVersion implements VersionChecker, from the cache when fresh. Errors are not cached.
+func (vc *VersionCache) TokenVersion(ctx context.Context, userID, token string) (int, error) {
+	vc.mu.Lock()
+	e, ok := vc.entries[userID]
+	vc.mu.Unlock()
+	if ok && time.Since(e.fetchedAt) < vc.ttl {
+		return e.version, nil
+	}
+	version, err := vc.src.TokenVersion(ctx, userID, token)
+	if err != nil {
+		return 0, err
+	}
+	vc.Set(userID, version)
+	return version, nil
+}
+
+// Set caches the user's version, e.g. right after bumping it
+func (vc *VersionCache) Set(userID string, version int) {
+	vc.mu.Lock()
+	defer vc.mu.Unlock()
+	if vc.entries == nil || len(vc.entries) >= maxCachedTokenVersions {
+		vc.entries = map[string]cachedTokenVersion{}
+	}
+	vc.entries[userID] = cachedTokenVersion{version: version, fetchedAt: time.Now()}
+}
+
+// Introspector checks tokens with auth-service's introspection endpoint (POST /api/auth/introspect),
+// for services without access to the shared database. An inactive token is ErrTokenRevoked.
+type Introspector struct {
+	URL          string
+	ClientID     string
+	ClientSecret string
+	Client       *http.Client
+}
+
+// IntrospectorFromEnv configures TOKEN_INTROSPECTION_URL (defaultURL when unset) with the
+// SERVICE_CLIENT_ID and SERVICE_CLIENT_SECRET of a service client; nil when any of them is missing
+func IntrospectorFromEnv(defaultURL string) *Introspector {
+	in := &Introspector{
+		URL:          strings.TrimSpace(os.Getenv("TOKEN_INTROSPECTION_URL")),
+		ClientID:     strings.TrimSpace(os.Getenv("SERVICE_CLIENT_ID")),
+		ClientSecret: strings.TrimSpace(os.Getenv("SERVICE_CLIENT_SECRET")),
+		Client:       &http.Client{Timeout: 5 * time.Second},
+	}
+	if in.URL == "" {
+		in.URL = defaultURL
+	}
+	if in.URL == "" || in.ClientID == "" || in.ClientSecret == "" {
+		return nil
+	}
+	return in
+}
+
+// TokenVersion implements VersionChecker
+func (in *Introspector) TokenVersion(ctx context.Context, _, token string) (int, error) {
+	form := url.Values{"token": {token}}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, in.URL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(in.ClientID, in.ClientSecret)
+	client := in.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("introspection request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("introspection returned status %d", resp.StatusCode)
+	}
+	var out struct {
+		Active       bool `json:"active"`
+		TokenVersion int  `json:"token_version"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return 0, fmt.Errorf("invalid introspection response: %w", err)
+	}
+	if !out.Active {
+		return 0, ErrTokenRevoked
+	}
+	return out.TokenVersion, nil
+}
@@ -0,0 +1,99 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func TestRequiredTokenVersion(t *testing.T) {
+	versions := map[string]int{"u1": 2}
+	cfg := testConfig()
+	cfg.Versions = VersionFunc(func(_ context.Context, userID string) (int, error) {
+		if userID == "down" {
+			return 0, errors.New("connection refused")
+		}
+		v, ok := versions[userID]
+		if !ok {
+			return 0, ErrTokenRevoked
+		}
+		return v, nil
+	})
+	tests := []struct {
+		name   string
+		claims jwt.MapClaims
+		want   int
+	}{
+		{"current version", jwt.MapClaims{"user_id": "u1", TokenVersionClaim: 2}, http.StatusOK},
+		{"stale version", jwt.MapClaims{"user_id": "u1", TokenVersionClaim: 1}, http.StatusUnauthorized},
+		{"no version claim", jwt.MapClaims{"user_id": "u1"}, http.StatusUnauthorized},
+		{"deleted user", jwt.MapClaims{"user_id": "gone", TokenVersionClaim: 5}, http.StatusUnauthorized},
+		{"lookup failure", jwt.MapClaims{"user_id": "down"}, http.StatusServiceUnavailable},
+		{"service token", jwt.MapClaims{"client_id": "svc", "token_use": "service"}, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if w, _ := serve(t, "Bearer "+sign(t, tt.claims), cfg.Required()); w.Code != tt.want {
+				t.Fatalf("status = %d, want %d", w.Code, tt.want)
+			}
+		})
+	}
+
+	stale := sign(t, jwt.MapClaims{"user_id": "u1", TokenVersionClaim: 1})
+	if w, c := serve(t, "Bearer "+stale, cfg.Optional()); w.Code != http.StatusOK || UserID(c) != "" {
+		t.Fatalf("Optional with a stale token: status %d, user %q", w.Code, UserID(c))
+	}
+}
+
+func TestVersionCache(t *testing.T) {
+	calls := 0
+	cache := NewVersionCache(VersionFunc(func(context.Context, string) (int, error) {
+		calls++
+		return 1, nil
+	}), time.Minute)
+	for i := 0; i < 3; i++ {
+		if v, err := cache.TokenVersion(context.Background(), "u1", ""); v != 1 || err != nil {
+			t.Fatalf("TokenVersion = %d %v", v, err)
+		}
+	}
+	if calls != 1 {
+		t.Fatalf("source queried %d times, want 1", calls)
+	}
+	cache.Set("u1", 4)
+	if v, _ := cache.TokenVersion(context.Background(), "u1", ""); v != 4 || calls != 1 {
+		t.Fatalf("after Set: version %d, %d calls", v, calls)
+	}
+}
+
+func TestIntrospector(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if id, secret, _ := r.BasicAuth(); id != "gateway" || secret != "s3cret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch r.PostFormValue("token") {
+		case "current":
+			w.Write([]byte(`{"active":true,"user_id":"u1","token_version":3}`))
+		default:
+			w.Write([]byte(`{"active":false}`))
+		}
+	}))
+	defer srv.Close()
+
+	in := &Introspector{URL: srv.URL, ClientID: "gateway", ClientSecret: "s3cret", Client: srv.Client()}
+	if v, err := in.TokenVersion(context.Background(), "u1", "current"); v != 3 || err != nil {
+		t.Fatalf("active token: %d %v", v, err)
+	}
+	if _, err := in.TokenVersion(context.Background(), "u1", "revoked"); !errors.Is(err, ErrTokenRevoked) {
+		t.Fatalf("inactive token: %v", err)
+	}
+	in.ClientSecret = "wrong"
+	if _, err := in.TokenVersion(context.Background(), "u1", "current"); err == nil || errors.Is(err, ErrTokenRevoked) {
+		t.Fatalf("bad credentials: %v", err)
+	}
+}
@@ -0,0 +1,88 @@
+// Package awsclient loads the service's AWS configuration once and shares the clients built from it,
+// instead of resolving credentials on every request.
+package awsclient
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// Provider lazily loads the AWS configuration on first use. A failed load is not cached, so a
+// later call retries it.
+type Provider struct {
+	mu   sync.Mutex
+	load func(ctx context.Context) (aws.Config, error)
+	cfg  *aws.Config
+	s3   *s3.Client
+}
+
+// NewProvider returns a provider using load to resolve the configuration
+func NewProvider(load func(ctx context.Context) (aws.Config, error)) *Provider {
+	return &Provider{load: load}
+}
+
+// NewProviderFromEnv returns a provider for the default credential chain (the App Runner instance role
+// in AWS) in AWS_REGION, AWS_DEFAULT_REGION or eu-central-1
+func NewProviderFromEnv() *Provider {
+	region := os.Getenv("AWS_REGION")
+	if region == "" {
+		region = os.Getenv("AWS_DEFAULT_REGION")
+	}
+	if region == "" {
+		region = "eu-central-1"
+	}
+	return NewProvider(func(ctx context.Context) (aws.Config, error) {
+		cfg, err := config.LoadDefaultConfig(ctx, config.WithRegion(region))
+		if err != nil {
+			return aws.Config{}, fmt.Errorf("failed to load AWS default config: %w", err)
+		}
+		return cfg, nil
+	})
+}
+
+// Config returns the shared AWS configuration, loading it on first use
+func (p *Provider) Config(ctx context.Context) (aws.Config, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.configLocked(ctx)
+}
+
+func (p *Provider) configLocked(ctx context.Context) (aws.Config, error) {
+	if p.cfg == nil {
+		cfg, err := p.load(ctx)
+		if err != nil {
+			return aws.Config{}, err
+		}
+		p.cfg = &cfg
+	}
+	return *p.cfg, nil
+}
+
+// S3 returns the shared S3 client. It matches storage.S3ClientFunc.
+func (p *Provider) S3(ctx context.Context) (*s3.Client, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.s3 == nil {
+		cfg, err := p.configLocked(ctx)
+		if err != nil {
+			return nil, err
+		}
+		p.s3 = s3.NewFromConfig(cfg)
+	}
+	return p.s3, nil
+}
+
+// IgnoreStaticEnvCredentials removes AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY and AWS_SESSION_TOKEN
+// from the environment so the default chain uses the container/instance role, not SES SMTP
+// credentials that may be present. Call it once at startup, before any goroutine reads the environment.
+func IgnoreStaticEnvCredentials() {
+	_ = os.Unsetenv("AWS_ACCESS_KEY_ID")
+	_ = os.Unsetenv("AWS_SECRET_ACCESS_KEY")
+	_ = os.Unsetenv("AWS_SESSION_TOKEN")
+}
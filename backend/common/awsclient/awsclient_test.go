@@ -0,0 +1,86 @@
+package awsclient
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+)
+
+func staticConfig() aws.Config {
+	return aws.Config{Region: "eu-central-1", Credentials: aws.AnonymousCredentials{}}
+}
+
+func TestProviderLoadsConfigOnce(t *testing.T) {
+	var loads atomic.Int32
+	p := NewProvider(func(ctx context.Context) (aws.Config, error) {
+		loads.Add(1)
+		return staticConfig(), nil
+	})
+
+	ctx := context.Background()
+	first, err := p.S3(ctx)
+	if err != nil {
+		t.Fatalf("S3: %v", err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			c, err := p.S3(ctx)
+			if err != nil || c != first {
+				t.Errorf("expected the shared client, got %p (err %v)", c, err)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			if _, err := p.Config(ctx); err != nil {
+				t.Errorf("Config: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if n := loads.Load(); n != 1 {
+		t.Fatalf("expected the config to load once, loaded %d times", n)
+	}
+}
+
+func TestProviderRetriesFailedLoad(t *testing.T) {
+	var loads atomic.Int32
+	p := NewProvider(func(ctx context.Context) (aws.Config, error) {
+		if loads.Add(1) == 1 {
+			return aws.Config{}, errors.New("no credentials")
+		}
+		return staticConfig(), nil
+	})
+
+	ctx := context.Background()
+	if _, err := p.S3(ctx); err == nil {
+		t.Fatal("expected the first load to fail")
+	}
+	c, err := p.S3(ctx)
+	if err != nil || c == nil {
+		t.Fatalf("expected the second call to load a client, got %v", err)
+	}
+	cfg, err := p.Config(ctx)
+	if err != nil || cfg.Region != "eu-central-1" {
+		t.Fatalf("unexpected config %+v (err %v)", cfg, err)
+	}
+	if n := loads.Load(); n != 2 {
+		t.Fatalf("expected 2 loads, got %d", n)
+	}
+}
+
+func TestNewProviderFromEnvDoesNotLoadEagerly(t *testing.T) {
+	t.Setenv("AWS_REGION", "eu-west-1")
+	p := NewProviderFromEnv()
+	if p.cfg != nil || p.s3 != nil {
+		t.Fatal("expected the provider to defer loading until first use")
+	}
+}
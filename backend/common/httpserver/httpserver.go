@@ -0,0 +1,63 @@
+// Package httpserver runs a service's HTTP handler with graceful shutdown, so deploys drain
+// in-flight requests instead of dropping them.
+package httpserver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+// DefaultShutdownTimeout is how long in-flight requests may take to finish once SIGTERM arrives.
+// App Runner kills the instance 30s after the signal.
+const DefaultShutdownTimeout = 20 * time.Second
+
+// ShutdownTimeout reads SHUTDOWN_TIMEOUT_SECONDS, falling back to DefaultShutdownTimeout
+func ShutdownTimeout() time.Duration {
+	if n, err := strconv.Atoi(os.Getenv("SHUTDOWN_TIMEOUT_SECONDS")); err == nil && n > 0 {
+		return time.Duration(n) * time.Second
+	}
+	return DefaultShutdownTimeout
+}
+
+// Run serves handler on port until SIGINT or SIGTERM, then stops accepting connections and drains
+// in-flight requests for up to ShutdownTimeout. It returns the listen error when the server can't
+// start and the shutdown error when requests didn't drain in time; callers close their database
+// pools after it returns.
+func Run(name string, handler http.Handler, port string) error {
+	srv := &http.Server{Addr: ":" + port, Handler: handler}
+
+	failed := make(chan error, 1)
+	go func() {
+		log.Printf("Starting %s on port %s", name, port)
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			failed <- err
+		}
+	}()
+
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(quit)
+	select {
+	case err := <-failed:
+		return fmt.Errorf("failed to start %s: %w", name, err)
+	case sig := <-quit:
+		log.Printf("Received %s, shutting down %s...", sig, name)
+	}
+
+	timeout := ShutdownTimeout()
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	if err := srv.Shutdown(ctx); err != nil {
+		return fmt.Errorf("%s did not drain within %s: %w", name, timeout, err)
+	}
+	log.Printf("%s stopped", name)
+	return nil
+}
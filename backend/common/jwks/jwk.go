@@ -0,0 +1,87 @@
+// Package jwks signs and verifies the platform's access tokens with asymmetric keys. auth-service signs
+// with an RSA (RS256) or Ed25519 (EdDSA) key and publishes the public keys at /.well-known/jwks.json;
+// the other services verify against that key set, cached and refetched when a token names a key
+// (kid) they haven't seen, so keys rotate without redeploying them.
+package jwks
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+// JWK is a public key in JSON Web Key form (RFC 7517); only RSA and Ed25519 signing keys are used
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use,omitempty"`
+	Alg string `json:"alg,omitempty"`
+	// RSA
+	N string `json:"n,omitempty"`
+	E string `json:"e,omitempty"`
+	// OKP (Ed25519)
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+// Set is the JWKS document served at /.well-known/jwks.json
+type Set struct {
+	Keys []JWK `json:"keys"`
+}
+
+var b64 = base64.RawURLEncoding
+
+// NewJWK describes pub as a signing JWK. Its kid is the key's RFC 7638 thumbprint, so the same key
+// always gets the same id without any configuration.
+func NewJWK(pub crypto.PublicKey) (JWK, error) {
+	var k JWK
+	switch pub := pub.(type) {
+	case *rsa.PublicKey:
+		k = JWK{Kty: "RSA", Alg: "RS256", N: b64.EncodeToString(pub.N.Bytes()), E: b64.EncodeToString(big.NewInt(int64(pub.E)).Bytes())}
+		k.Kid = thumbprint(fmt.Sprintf(`{"e":%q,"kty":"RSA","n":%q}`, k.E, k.N))
+	case ed25519.PublicKey:
+		k = JWK{Kty: "OKP", Alg: "EdDSA", Crv: "Ed25519", X: b64.EncodeToString(pub)}
+		k.Kid = thumbprint(fmt.Sprintf(`{"crv":"Ed25519","kty":"OKP","x":%q}`, k.X))
+	default:
+		return JWK{}, fmt.Errorf("unsupported key type %T", pub)
+	}
+	k.Use = "sig"
+	return k, nil
+}
+
+func thumbprint(canonical string) string {
+	sum := sha256.Sum256([]byte(canonical))
+	return b64.EncodeToString(sum[:])
+}
+
+// PublicKey decodes the key
+func (k JWK) PublicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := b64.DecodeString(k.N)
+		if err != nil {
+			return nil, fmt.Errorf("jwk %s: invalid n: %w", k.Kid, err)
+		}
+		e, err := b64.DecodeString(k.E)
+		if err != nil || len(e) == 0 || len(e) > 4 {
+			return nil, fmt.Errorf("jwk %s: invalid e", k.Kid)
+		}
+		pub := &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+		if pub.N.BitLen() < 2048 {
+			return nil, fmt.Errorf("jwk %s: RSA keys must be at least 2048 bits", k.Kid)
+		}
+		return pub, nil
+	case "OKP":
+		x, err := b64.DecodeString(k.X)
+		if err != nil || k.Crv != "Ed25519" || len(x) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("jwk %s: invalid Ed25519 key", k.Kid)
+		}
+		return ed25519.PublicKey(x), nil
+	}
+	return nil, errors.New("jwk " + k.Kid + ": unsupported key type " + k.Kty)
+}
@@ -0,0 +1,221 @@
+package jwks
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func rsaPEM(t *testing.T) (string, *rsa.PrivateKey) {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})), key
+}
+
+func ed25519PEM(t *testing.T) string {
+	t.Helper()
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}))
+}
+
+func publicPEM(t *testing.T, s *Signer) string {
+	t.Helper()
+	der, err := x509.MarshalPKIXPublicKey(s.key.Public())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+}
+
+func mustSigner(t *testing.T, priv, pubs string) *Signer {
+	t.Helper()
+	s, err := NewSigner(priv, pubs)
+	if err != nil {
+		t.Fatalf("NewSigner: %v", err)
+	}
+	return s
+}
+
+func claims() jwt.MapClaims {
+	return jwt.MapClaims{"user_id": "u1", "exp": time.Now().Add(time.Minute).Unix()}
+}
+
+// jwksServer serves whatever signer is current and counts fetches
+func jwksServer(t *testing.T, current *atomic.Pointer[Signer], fetches *atomic.Int32) *httptest.Server {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches.Add(1)
+		json.NewEncoder(w).Encode(current.Load().Set())
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestSignAndVerify(t *testing.T) {
+	rsaKey, _ := rsaPEM(t)
+	for name, priv := range map[string]string{"RS256": rsaKey, "EdDSA": ed25519PEM(t)} {
+		t.Run(name, func(t *testing.T) {
+			s := mustSigner(t, priv, "")
+			var current atomic.Pointer[Signer]
+			current.Store(s)
+			var fetches atomic.Int32
+			v := NewVerifier(jwksServer(t, &current, &fetches).URL, nil)
+
+			tok, err := s.Sign(claims())
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := v.Parse(tok)
+			if err != nil {
+				t.Fatalf("Parse: %v", err)
+			}
+			if got["user_id"] != "u1" {
+				t.Fatalf("claims = %v", got)
+			}
+			if _, err := v.Parse(tok); err != nil || fetches.Load() != 1 {
+				t.Fatalf("second parse: err=%v fetches=%d, want cached key", err, fetches.Load())
+			}
+			if _, err := s.Verifier(nil).Parse(tok); err != nil {
+				t.Fatalf("local verifier: %v", err)
+			}
+		})
+	}
+}
+
+func TestRotation(t *testing.T) {
+	oldKey, _ := rsaPEM(t)
+	newKey := ed25519PEM(t)
+	old := mustSigner(t, oldKey, "")
+	next := mustSigner(t, newKey, publicPEM(t, old))
+
+	var current atomic.Pointer[Signer]
+	current.Store(old)
+	var fetches atomic.Int32
+	v := NewVerifier(jwksServer(t, &current, &fetches).URL, nil)
+
+	oldTok, _ := old.Sign(claims())
+	if _, err := v.Parse(oldTok); err != nil {
+		t.Fatal(err)
+	}
+
+	// auth-service switches keys: the unknown kid triggers a refetch, and the old key is still published
+	current.Store(next)
+	v.lastAttempt = time.Time{}
+	newTok, _ := next.Sign(claims())
+	if _, err := v.Parse(newTok); err != nil {
+		t.Fatalf("token from rotated key: %v", err)
+	}
+	if _, err := v.Parse(oldTok); err != nil {
+		t.Fatalf("token from retired key: %v", err)
+	}
+	if n := fetches.Load(); n != 2 {
+		t.Fatalf("fetches = %d, want 2", n)
+	}
+	if set := next.Set(); len(set.Keys) != 2 || set.Keys[0].Kid != next.KeyID() {
+		t.Fatalf("published set = %+v", set)
+	}
+
+	// Unknown kids can't make the verifier hammer the JWKS endpoint
+	other := mustSigner(t, ed25519PEM(t), "")
+	otherTok, _ := other.Sign(claims())
+	for i := 0; i < 3; i++ {
+		if _, err := v.Parse(otherTok); !errors.Is(err, ErrUnknownKey) {
+			t.Fatalf("expected ErrUnknownKey, got %v", err)
+		}
+	}
+	if n := fetches.Load(); n != 2 {
+		t.Fatalf("fetches = %d after unknown kids, want 2", n)
+	}
+}
+
+func TestRejectedTokens(t *testing.T) {
+	priv, rsaKey := rsaPEM(t)
+	s := mustSigner(t, priv, "")
+	secret := []byte("shared-secret")
+
+	hs256 := func(key []byte, header map[string]interface{}) string {
+		tok := jwt.NewWithClaims(jwt.SigningMethodHS256, claims())
+		for k, v := range header {
+			tok.Header[k] = v
+		}
+		str, err := tok.SignedString(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return str
+	}
+	pubDER, _ := x509.MarshalPKIXPublicKey(&rsaKey.PublicKey)
+	noKid := jwt.NewWithClaims(jwt.SigningMethodRS256, claims())
+	noKidTok, _ := noKid.SignedString(rsaKey)
+	rs512 := jwt.NewWithClaims(jwt.SigningMethodRS512, claims())
+	rs512.Header["kid"] = s.KeyID()
+	rs512Tok, _ := rs512.SignedString(rsaKey)
+
+	tests := []struct {
+		name  string
+		v     *Verifier
+		token string
+	}{
+		{"HS256 without a shared secret", s.Verifier(nil), hs256(secret, nil)},
+		{"HS256 with the wrong secret", s.Verifier(secret), hs256([]byte("other"), nil)},
+		{"HS256 signed with the public key", s.Verifier(nil), hs256(pubDER, map[string]interface{}{"kid": s.KeyID()})},
+		{"RS256 without kid", s.Verifier(nil), noKidTok},
+		{"algorithm not allowed", s.Verifier(nil), rs512Tok},
+		{"alg none", s.Verifier(secret), strings.Join([]string{b64.EncodeToString([]byte(`{"alg":"none"}`)), b64.EncodeToString([]byte(`{"user_id":"u1"}`)), ""}, ".")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := tt.v.Parse(tt.token); err == nil {
+				t.Fatal("token accepted")
+			}
+		})
+	}
+
+	if _, err := s.Verifier(secret).Parse(hs256(secret, nil)); err != nil {
+		t.Fatalf("legacy HS256 token rejected while JWT_SECRET is set: %v", err)
+	}
+}
+
+func TestNewSignerRejectsWeakKeys(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	weak := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+	if _, err := NewSigner(string(weak), ""); err == nil {
+		t.Fatal("1024-bit RSA key accepted")
+	}
+	if _, err := NewSigner("not a key", ""); err == nil {
+		t.Fatal("garbage accepted")
+	}
+	// Single-line environment values with \n escapes
+	priv := strings.ReplaceAll(ed25519PEM(t), "\n", `\n`)
+	if _, err := NewSigner(priv, ""); err != nil {
+		t.Fatalf("escaped PEM: %v", err)
+	}
+}
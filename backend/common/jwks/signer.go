@@ -0,0 +1,130 @@
+package jwks
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Signer signs access tokens with the current key and publishes it alongside the keys being rotated
+// in or out.
+//
+// Rotating keys:
+//  1. Put the new key's public PEM in JWT_VERIFICATION_KEYS and deploy auth-service; verifiers pick
+//     it up on their next refresh.
+//  2. Make the new key JWT_SIGNING_KEY, moving the old key's public PEM to JWT_VERIFICATION_KEYS.
+//  3. Once the longest access token lifetime has passed, drop the old key from JWT_VERIFICATION_KEYS.
+//
+// Step 1 only avoids a refetch per service: verifiers also refetch when they meet an unknown kid.
+type Signer struct {
+	kid    string
+	key    crypto.Signer
+	method jwt.SigningMethod
+	keys   []JWK
+}
+
+// NewSigner parses the PEM private key (PKCS#8 RSA or Ed25519, or PKCS#1 RSA) and any extra PEM
+// public keys to publish next to it
+func NewSigner(privatePEM string, publicPEMs string) (*Signer, error) {
+	block, _ := pem.Decode([]byte(unescapeNewlines(privatePEM)))
+	if block == nil {
+		return nil, errors.New("signing key is not PEM encoded")
+	}
+	var priv interface{}
+	var err error
+	if block.Type == "RSA PRIVATE KEY" {
+		priv, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	} else {
+		priv, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("signing key: %w", err)
+	}
+
+	s := &Signer{}
+	switch k := priv.(type) {
+	case *rsa.PrivateKey:
+		if k.N.BitLen() < 2048 {
+			return nil, errors.New("signing key: RSA keys must be at least 2048 bits")
+		}
+		s.key, s.method = k, jwt.SigningMethodRS256
+	case ed25519.PrivateKey:
+		s.key, s.method = k, jwt.SigningMethodEdDSA
+	default:
+		return nil, fmt.Errorf("signing key: unsupported key type %T", priv)
+	}
+	current, err := NewJWK(s.key.Public())
+	if err != nil {
+		return nil, err
+	}
+	s.kid = current.Kid
+	s.keys = []JWK{current}
+
+	rest := []byte(unescapeNewlines(publicPEMs))
+	for {
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		pub, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("verification key: %w", err)
+		}
+		k, err := NewJWK(pub)
+		if err != nil {
+			return nil, fmt.Errorf("verification key: %w", err)
+		}
+		if k.Kid != s.kid {
+			s.keys = append(s.keys, k)
+		}
+	}
+	return s, nil
+}
+
+// SignerFromEnv builds the signer from JWT_SIGNING_KEY and JWT_VERIFICATION_KEYS; nil when no
+// signing key is configured (tokens are then signed with the shared HS256 JWT_SECRET)
+func SignerFromEnv() (*Signer, error) {
+	key := os.Getenv("JWT_SIGNING_KEY")
+	if strings.TrimSpace(key) == "" {
+		return nil, nil
+	}
+	return NewSigner(key, os.Getenv("JWT_VERIFICATION_KEYS"))
+}
+
+// unescapeNewlines lets PEM be passed in single-line environment variables with \n escapes
+func unescapeNewlines(s string) string {
+	return strings.ReplaceAll(s, `\n`, "\n")
+}
+
+// KeyID returns the kid of the signing key
+func (s *Signer) KeyID() string { return s.kid }
+
+// Sign signs the claims with the current key, naming it in the kid header
+func (s *Signer) Sign(claims jwt.Claims) (string, error) {
+	token := jwt.NewWithClaims(s.method, claims)
+	token.Header["kid"] = s.kid
+	return token.SignedString(s.key)
+}
+
+// Set returns the published key set: the signing key first, then the rotating keys
+func (s *Signer) Set() Set {
+	return Set{Keys: append([]JWK(nil), s.keys...)}
+}
+
+// Verifier verifies tokens against the published keys locally, accepting HS256 tokens signed with
+// hmacSecret while it is set. A nil signer yields an HS256-only verifier.
+func (s *Signer) Verifier(hmacSecret []byte) *Verifier {
+	v := NewVerifier("", hmacSecret)
+	if s != nil {
+		v.setKeys(s.keys)
+	}
+	return v
+}
@@ -0,0 +1,213 @@
+package jwks
+
+import (
+	"context"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Verification errors
+var (
+	ErrNotConfigured = errors.New("no JWKS_URL or JWT_SECRET configured")
+	ErrUnknownKey    = errors.New("token signed with an unknown key")
+	ErrHMACDisabled  = errors.New("HS256 tokens are not accepted")
+)
+
+// minRefetchInterval limits how often tokens naming unknown keys can make the verifier refetch
+const minRefetchInterval = 30 * time.Second
+
+// validMethods are the algorithms accepted; the key type must also match (see Keyfunc)
+var validMethods = []string{jwt.SigningMethodRS256.Alg(), jwt.SigningMethodEdDSA.Alg(), jwt.SigningMethodHS256.Alg()}
+
+// Verifier checks token signatures against a JWKS document, cached for ttl and refetched early
+// when a token names a kid it doesn't know. While hmacSecret is set it also accepts HS256 tokens
+// signed with the shared secret, so services can move over before auth-service stops issuing them.
+type Verifier struct {
+	url    string
+	secret []byte
+	ttl    time.Duration
+	client *http.Client
+
+	mu          sync.Mutex
+	keys        map[string]crypto.PublicKey
+	fetchedAt   time.Time
+	lastAttempt time.Time
+}
+
+// NewVerifier returns a verifier for the JWKS at url ("" for locally provided keys only)
+func NewVerifier(url string, hmacSecret []byte) *Verifier {
+	return &Verifier{
+		url:    url,
+		secret: hmacSecret,
+		ttl:    5 * time.Minute,
+		client: &http.Client{Timeout: 5 * time.Second},
+		keys:   map[string]crypto.PublicKey{},
+	}
+}
+
+var (
+	fromEnvOnce sync.Once
+	fromEnv     *Verifier
+)
+
+// FromEnv returns the process-wide verifier for JWKS_URL (cached for JWKS_CACHE_SECONDS, default
+// 300) and the legacy JWT_SECRET. It is built on first use, after main has loaded .env.
+func FromEnv() *Verifier {
+	fromEnvOnce.Do(func() {
+		fromEnv = NewVerifier(os.Getenv("JWKS_URL"), []byte(os.Getenv("JWT_SECRET")))
+		if n, err := strconv.Atoi(os.Getenv("JWKS_CACHE_SECONDS")); err == nil && n > 0 {
+			fromEnv.ttl = time.Duration(n) * time.Second
+		}
+		if fromEnv.url == "" {
+			log.Println("[WARN] JWKS_URL not set; only HS256 tokens signed with JWT_SECRET are accepted")
+		}
+	})
+	return fromEnv
+}
+
+// Configured reports whether the verifier can accept any token at all
+func (v *Verifier) Configured() bool {
+	if v.url != "" || len(v.secret) > 0 {
+		return true
+	}
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	return len(v.keys) > 0
+}
+
+// Parse verifies the token and returns its claims
+func (v *Verifier) Parse(tokenString string) (jwt.MapClaims, error) {
+	claims := jwt.MapClaims{}
+	token, err := jwt.ParseWithClaims(tokenString, claims, v.Keyfunc, jwt.WithValidMethods(validMethods))
+	if err != nil {
+		return nil, err
+	}
+	if !token.Valid {
+		return nil, jwt.ErrTokenSignatureInvalid
+	}
+	return claims, nil
+}
+
+// Keyfunc resolves the verification key for jwt.Parse. HS256 tokens get the shared secret and never
+// a public key; RS256 and EdDSA tokens get the key named by their kid, of the matching type.
+func (v *Verifier) Keyfunc(t *jwt.Token) (interface{}, error) {
+	switch t.Method.(type) {
+	case *jwt.SigningMethodHMAC:
+		if len(v.secret) == 0 {
+			return nil, ErrHMACDisabled
+		}
+		return v.secret, nil
+	case *jwt.SigningMethodRSA, *jwt.SigningMethodEd25519:
+	default:
+		return nil, jwt.ErrTokenSignatureInvalid
+	}
+	kid, _ := t.Header["kid"].(string)
+	if kid == "" {
+		return nil, ErrUnknownKey
+	}
+	key, err := v.key(kid)
+	if err != nil {
+		return nil, err
+	}
+	switch key.(type) {
+	case *rsa.PublicKey:
+		if _, ok := t.Method.(*jwt.SigningMethodRSA); ok {
+			return key, nil
+		}
+	case ed25519.PublicKey:
+		if _, ok := t.Method.(*jwt.SigningMethodEd25519); ok {
+			return key, nil
+		}
+	}
+	return nil, fmt.Errorf("key %s does not match algorithm %s", kid, t.Method.Alg())
+}
+
+// key returns the cached key, refreshing the set when it is stale or the kid is unknown. A failed
+// refresh keeps serving the keys already cached.
+func (v *Verifier) key(kid string) (crypto.PublicKey, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	key, ok := v.keys[kid]
+	if v.url == "" {
+		if !ok {
+			return nil, ErrUnknownKey
+		}
+		return key, nil
+	}
+	stale := time.Since(v.fetchedAt) > v.ttl
+	if (stale || !ok) && time.Since(v.lastAttempt) >= minRefetchInterval {
+		v.lastAttempt = time.Now()
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		keys, err := v.fetch(ctx)
+		cancel()
+		if err != nil {
+			log.Printf("[WARN] JWKS refresh from %s failed: %v", v.url, err)
+		} else {
+			v.keys, v.fetchedAt = keys, time.Now()
+			key, ok = v.keys[kid]
+		}
+	}
+	if !ok {
+		return nil, ErrUnknownKey
+	}
+	return key, nil
+}
+
+func (v *Verifier) fetch(ctx context.Context) (map[string]crypto.PublicKey, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, v.url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("status %d", resp.StatusCode)
+	}
+	var set Set
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&set); err != nil {
+		return nil, err
+	}
+	keys := make(map[string]crypto.PublicKey, len(set.Keys))
+	for _, k := range set.Keys {
+		if k.Kid == "" || (k.Use != "" && k.Use != "sig") {
+			continue
+		}
+		pub, err := k.PublicKey()
+		if err != nil {
+			log.Printf("[WARN] skipping JWKS key: %v", err)
+			continue
+		}
+		keys[k.Kid] = pub
+	}
+	if len(keys) == 0 {
+		return nil, errors.New("no usable keys")
+	}
+	return keys, nil
+}
+
+func (v *Verifier) setKeys(keys []JWK) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	for _, k := range keys {
+		if pub, err := k.PublicKey(); err == nil {
+			v.keys[k.Kid] = pub
+		}
+	}
+}
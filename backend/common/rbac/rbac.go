@@ -0,0 +1,113 @@
+// Package rbac names the permissions access tokens carry and decides whether a token grants one.
+//
+// auth-service stores roles, their permissions and additional role assignments in the database and
+// embeds the user's effective permissions in the "permissions" claim. Tokens issued before that
+// claim existed fall back to the built-in permissions of their "role" claim.
+package rbac
+
+// Permissions checked by the services
+const (
+	// AdminPanel opens the admin panel (user-service admin routes)
+	AdminPanel = "admin_panel:access"
+	// CatalogWrite manages products, categories and stores (catalog-service admin routes)
+	CatalogWrite = "catalog:write"
+	// OrdersManage manages all orders (order-service admin routes)
+	OrdersManage = "orders:manage"
+	// SessionsManage searches and revokes other users' sessions
+	SessionsManage = "sessions:manage"
+	// RolesManage edits roles and assigns them to users
+	RolesManage = "roles:manage"
+	// PushSend looks up users' device push tokens (service clients sending notifications)
+	PushSend = "push:send"
+	// UsersImpersonate mints short-lived tokens acting as another user (super-admins only)
+	UsersImpersonate = "users:impersonate"
+)
+
+// All lists every permission with its description
+var All = []struct{ Name, Description string }{
+	{AdminPanel, "Open the admin panel"},
+	{CatalogWrite, "Manage products, categories and stores"},
+	{OrdersManage, "Manage all orders"},
+	{SessionsManage, "Search and revoke user sessions"},
+	{RolesManage, "Edit roles and assign them to users"},
+	{PushSend, "Look up users' device push tokens to send notifications"},
+	{UsersImpersonate, "Sign in as another user to debug reported issues"},
+}
+
+// Valid reports whether name is a known permission
+func Valid(name string) bool {
+	for _, p := range All {
+		if p.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// AdminRole is the role whose logins require a security key; AdminOnly permissions only take effect
+// for users whose primary role it is
+const AdminRole = "Admin"
+
+// AdminOnly reports whether the permission is reserved to Admin users, so it is never granted
+// without the admin login's second factor. Catalog and order management are included: either lets a
+// stolen password change prices or refund orders.
+func AdminOnly(name string) bool {
+	switch name {
+	case CatalogWrite, OrdersManage, SessionsManage, RolesManage, UsersImpersonate:
+		return true
+	}
+	return false
+}
+
+// Effective returns the permissions a user whose primary role is role actually gets from perms: the
+// AdminOnly ones are dropped unless role is AdminRole. perms is filtered in place.
+func Effective(role string, perms []string) []string {
+	if role == AdminRole {
+		return perms
+	}
+	out := perms[:0]
+	for _, p := range perms {
+		if !AdminOnly(p) {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+// BuiltInRoles holds the roles that existed as plain role strings with the permissions they implied
+var BuiltInRoles = map[string][]string{
+	"Admin":        {AdminPanel, CatalogWrite, OrdersManage, SessionsManage, RolesManage},
+	"Manufacturer": {AdminPanel},
+	"3PL":          {AdminPanel},
+	"Partner":      {AdminPanel},
+	"Author":       {},
+	"Customer":     {},
+}
+
+// Granted reports whether a token grants perm. permissions is the token's "permissions" claim
+// (nil when absent, else a list as decoded from JSON); role is its "role" claim.
+func Granted(permissions any, role, perm string) bool {
+	switch list := permissions.(type) {
+	case []any:
+		for _, p := range list {
+			if p == perm {
+				return true
+			}
+		}
+		return false
+	case []string:
+		for _, p := range list {
+			if p == perm {
+				return true
+			}
+		}
+		return false
+	case nil:
+		for _, p := range BuiltInRoles[role] {
+			if p == perm {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,72 @@
+package rbac
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestGranted(t *testing.T) {
+	var decoded map[string]any
+	if err := json.Unmarshal([]byte(`{"permissions":["catalog:write"],"empty":[]}`), &decoded); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name        string
+		permissions any
+		role        string
+		perm        string
+		want        bool
+	}{
+		{"claim grants", decoded["permissions"], "Customer", CatalogWrite, true},
+		{"claim wins over role", decoded["permissions"], "Admin", OrdersManage, false},
+		{"empty claim grants nothing", decoded["empty"], "Admin", AdminPanel, false},
+		{"legacy Admin token", nil, "Admin", RolesManage, true},
+		{"legacy Manufacturer token", nil, "Manufacturer", AdminPanel, true},
+		{"legacy Manufacturer token, admin-only", nil, "Manufacturer", OrdersManage, false},
+		{"unknown role", nil, "Someone", AdminPanel, false},
+		{"malformed claim", "catalog:write", "Admin", CatalogWrite, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Granted(tt.permissions, tt.role, tt.perm); got != tt.want {
+				t.Fatalf("Granted = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuiltInRolesKeepAdminOnlyPermissionsOnAdmin(t *testing.T) {
+	for role, perms := range BuiltInRoles {
+		for _, p := range perms {
+			if !Valid(p) {
+				t.Errorf("%s: unknown permission %q", role, p)
+			}
+			if AdminOnly(p) && role != AdminRole {
+				t.Errorf("%s holds admin-only %q", role, p)
+			}
+		}
+	}
+}
+
+func TestEffectiveDropsPrivilegedPermissionsFromNonAdminRoles(t *testing.T) {
+	privileged := []string{CatalogWrite, OrdersManage, SessionsManage, RolesManage, UsersImpersonate}
+	for _, p := range privileged {
+		if !AdminOnly(p) {
+			t.Errorf("%s is not admin-only", p)
+		}
+		for role := range BuiltInRoles {
+			got := Effective(role, []string{AdminPanel, p})
+			want := []string{AdminPanel}
+			if role == AdminRole {
+				want = []string{AdminPanel, p}
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("%s granted %s: effective %v, want %v", role, p, got, want)
+			}
+		}
+	}
+	if AdminOnly(AdminPanel) || AdminOnly(PushSend) {
+		t.Error("admin_panel:access and push:send must stay grantable to other roles")
+	}
+}
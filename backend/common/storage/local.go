@@ -0,0 +1,164 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"mime"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Local stores objects as files under a directory the service serves statically. It is meant for
+// development without AWS.
+type Local struct {
+	dir     string
+	baseURL string
+}
+
+// NewLocal returns a store writing under dir whose files are served from baseURL
+func NewLocal(dir, baseURL string) *Local {
+	return &Local{dir: dir, baseURL: strings.TrimRight(baseURL, "/")}
+}
+
+// Dir is the directory holding the files
+func (l *Local) Dir() string { return l.dir }
+
+// path maps a key to a file under dir; keys cannot escape it
+func (l *Local) path(key string) (string, error) {
+	clean := path.Clean("/" + key)
+	if clean == "/" {
+		return "", fmt.Errorf("invalid object key %q", key)
+	}
+	return filepath.Join(l.dir, filepath.FromSlash(clean)), nil
+}
+
+func (l *Local) PutObject(_ context.Context, key string, body io.Reader, _ ObjectMeta) error {
+	p, err := l.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+	f, err := os.Create(p)
+	if err != nil {
+		return fmt.Errorf("failed to create file: %w", err)
+	}
+	if _, err := io.Copy(f, body); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to save file: %w", err)
+	}
+	return f.Close()
+}
+
+func (l *Local) GetObject(_ context.Context, key string) (io.ReadCloser, error) {
+	p, err := l.path(key)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(p)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	return f, err
+}
+
+func (l *Local) Stat(_ context.Context, key string) (*ObjectMeta, error) {
+	p, err := l.path(key)
+	if err != nil {
+		return nil, err
+	}
+	info, err := os.Stat(p)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	meta := &ObjectMeta{Size: info.Size(), ContentType: mime.TypeByExtension(filepath.Ext(p))}
+	if meta.ContentType == "" {
+		// Sniff like the static file server does
+		if f, err := os.Open(p); err == nil {
+			buf := make([]byte, 512)
+			n, _ := io.ReadFull(f, buf)
+			f.Close()
+			meta.ContentType = http.DetectContentType(buf[:n])
+		}
+	}
+	return meta, nil
+}
+
+func (l *Local) CopyObject(ctx context.Context, srcKey, dstKey string) error {
+	src, err := l.GetObject(ctx, srcKey)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	return l.PutObject(ctx, dstKey, src, ObjectMeta{})
+}
+
+func (l *Local) Delete(_ context.Context, keys ...string) error {
+	var errs []error
+	for _, key := range keys {
+		p, err := l.path(key)
+		if err == nil {
+			err = os.Remove(p)
+		}
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			errs = append(errs, fmt.Errorf("delete %s: %w", key, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (l *Local) DeletePrefix(_ context.Context, prefix string) (int, error) {
+	deleted := 0
+	err := filepath.WalkDir(l.dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(l.dir, p)
+		if err != nil || !strings.HasPrefix(filepath.ToSlash(rel), prefix) {
+			return err
+		}
+		if err := os.Remove(p); err != nil {
+			return err
+		}
+		deleted++
+		return nil
+	})
+	return deleted, err
+}
+
+func (l *Local) PresignedURL(context.Context, string, ObjectMeta, time.Duration) (*PresignedUpload, error) {
+	return nil, ErrPresignUnsupported
+}
+
+// DownloadURL returns the file's static URL: local files are served without signing (development only)
+func (l *Local) DownloadURL(_ context.Context, key, _ string, _ time.Duration) (string, error) {
+	if _, err := l.path(key); err != nil {
+		return "", err
+	}
+	return l.PublicURL(key), nil
+}
+
+func (l *Local) PublicURL(key string) string {
+	return l.baseURL + "/" + key
+}
+
+func (l *Local) KeyFromURL(url string) (string, bool) {
+	return keyFromURL(l.baseURL, url)
+}
@@ -0,0 +1,201 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	s3types "github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// S3ClientFunc returns the S3 client to use for a call
+type S3ClientFunc func(ctx context.Context) (*s3.Client, error)
+
+// S3 stores objects in one bucket served through a CDN
+type S3 struct {
+	client  S3ClientFunc
+	bucket  string
+	baseURL string
+}
+
+// NewS3 returns a store for bucket whose objects are served from baseURL
+func NewS3(client S3ClientFunc, bucket, baseURL string) *S3 {
+	return &S3{client: client, bucket: bucket, baseURL: strings.TrimRight(baseURL, "/")}
+}
+
+func (s *S3) PutObject(ctx context.Context, key string, body io.Reader, meta ObjectMeta) error {
+	client, err := s.client(ctx)
+	if err != nil {
+		return err
+	}
+	in := &s3.PutObjectInput{Bucket: &s.bucket, Key: &key, Body: body}
+	if meta.ContentType != "" {
+		in.ContentType = &meta.ContentType
+	}
+	if meta.CacheControl != "" {
+		in.CacheControl = &meta.CacheControl
+	}
+	if meta.Size > 0 {
+		in.ContentLength = &meta.Size
+	}
+	if _, err := client.PutObject(ctx, in); err != nil {
+		return fmt.Errorf("failed to upload %s to S3: %w", key, err)
+	}
+	return nil
+}
+
+func (s *S3) GetObject(ctx context.Context, key string) (io.ReadCloser, error) {
+	client, err := s.client(ctx)
+	if err != nil {
+		return nil, err
+	}
+	out, err := client.GetObject(ctx, &s3.GetObjectInput{Bucket: &s.bucket, Key: &key})
+	var noKey *s3types.NoSuchKey
+	if errors.As(err, &noKey) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return out.Body, nil
+}
+
+func (s *S3) Stat(ctx context.Context, key string) (*ObjectMeta, error) {
+	client, err := s.client(ctx)
+	if err != nil {
+		return nil, err
+	}
+	head, err := client.HeadObject(ctx, &s3.HeadObjectInput{Bucket: &s.bucket, Key: &key})
+	var notFound *s3types.NotFound
+	if errors.As(err, &notFound) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	meta := &ObjectMeta{}
+	if head.ContentType != nil {
+		meta.ContentType = *head.ContentType
+	}
+	if head.CacheControl != nil {
+		meta.CacheControl = *head.CacheControl
+	}
+	if head.ContentLength != nil {
+		meta.Size = *head.ContentLength
+	}
+	return meta, nil
+}
+
+func (s *S3) CopyObject(ctx context.Context, srcKey, dstKey string) error {
+	client, err := s.client(ctx)
+	if err != nil {
+		return err
+	}
+	copySource := s.bucket + "/" + srcKey
+	_, err = client.CopyObject(ctx, &s3.CopyObjectInput{Bucket: &s.bucket, Key: &dstKey, CopySource: &copySource})
+	return err
+}
+
+func (s *S3) Delete(ctx context.Context, keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	client, err := s.client(ctx)
+	if err != nil {
+		return err
+	}
+	var errs []error
+	for _, key := range keys {
+		if _, err := client.DeleteObject(ctx, &s3.DeleteObjectInput{Bucket: &s.bucket, Key: &key}); err != nil {
+			errs = append(errs, fmt.Errorf("delete %s: %w", key, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (s *S3) DeletePrefix(ctx context.Context, prefix string) (int, error) {
+	client, err := s.client(ctx)
+	if err != nil {
+		return 0, err
+	}
+	deleted := 0
+	var token *string
+	for {
+		out, err := client.ListObjectsV2(ctx, &s3.ListObjectsV2Input{Bucket: &s.bucket, Prefix: &prefix, ContinuationToken: token})
+		if err != nil {
+			return deleted, fmt.Errorf("failed to list S3 objects: %w", err)
+		}
+		if len(out.Contents) == 0 {
+			break
+		}
+		// A listing page holds at most 1000 keys, the DeleteObjects batch limit
+		objs := make([]s3types.ObjectIdentifier, 0, len(out.Contents))
+		for _, o := range out.Contents {
+			objs = append(objs, s3types.ObjectIdentifier{Key: o.Key})
+		}
+		if _, err := client.DeleteObjects(ctx, &s3.DeleteObjectsInput{Bucket: &s.bucket, Delete: &s3types.Delete{Objects: objs}}); err != nil {
+			return deleted, fmt.Errorf("failed to delete S3 objects: %w", err)
+		}
+		deleted += len(objs)
+		if out.NextContinuationToken == nil {
+			break
+		}
+		token = out.NextContinuationToken
+	}
+	return deleted, nil
+}
+
+func (s *S3) PresignedURL(ctx context.Context, key string, meta ObjectMeta, ttl time.Duration) (*PresignedUpload, error) {
+	client, err := s.client(ctx)
+	if err != nil {
+		return nil, err
+	}
+	in := &s3.PutObjectInput{Bucket: &s.bucket, Key: &key}
+	if meta.ContentType != "" {
+		in.ContentType = &meta.ContentType
+	}
+	if meta.Size > 0 {
+		in.ContentLength = &meta.Size
+	}
+	req, err := s3.NewPresignClient(client).PresignPutObject(ctx, in, s3.WithPresignExpires(ttl))
+	if err != nil {
+		return nil, err
+	}
+	headers := map[string]string{}
+	for k, v := range req.SignedHeader {
+		if strings.EqualFold(k, "host") || len(v) == 0 {
+			continue
+		}
+		headers[k] = v[0]
+	}
+	return &PresignedUpload{URL: req.URL, Method: req.Method, Headers: headers}, nil
+}
+
+func (s *S3) DownloadURL(ctx context.Context, key, fileName string, ttl time.Duration) (string, error) {
+	client, err := s.client(ctx)
+	if err != nil {
+		return "", err
+	}
+	disposition := fmt.Sprintf("attachment; filename=%q", fileName)
+	req, err := s3.NewPresignClient(client).PresignGetObject(ctx, &s3.GetObjectInput{
+		Bucket:                     &s.bucket,
+		Key:                        &key,
+		ResponseContentDisposition: &disposition,
+	}, s3.WithPresignExpires(ttl))
+	if err != nil {
+		return "", err
+	}
+	return req.URL, nil
+}
+
+func (s *S3) PublicURL(key string) string {
+	return s.baseURL + "/" + key
+}
+
+func (s *S3) KeyFromURL(url string) (string, bool) {
+	return keyFromURL(s.baseURL, url)
+}
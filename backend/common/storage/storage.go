@@ -0,0 +1,104 @@
+// Package storage keeps uploaded objects (catalog images and renditions, ebook media, order documents)
+// behind one interface shared by the services, so the bucket, CDN and backend are configuration rather
+// than code.
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrNotFound is returned when an object does not exist
+	ErrNotFound = errors.New("object not found")
+	// ErrPresignUnsupported is returned by backends clients cannot upload to directly
+	ErrPresignUnsupported = errors.New("presigned uploads are not supported by this storage driver")
+)
+
+// ObjectMeta describes an object being written, or one already stored
+type ObjectMeta struct {
+	ContentType  string
+	CacheControl string
+	Size         int64 // 0 when unknown
+}
+
+// PresignedUpload is a time-limited request the client sends to upload one object itself
+type PresignedUpload struct {
+	URL     string
+	Method  string
+	Headers map[string]string // headers the client must send as signed
+}
+
+// Store reads and writes media objects by key. Keys are slash-separated paths such as
+// "admin-panel/products/12/images/1700000000_photo.jpg".
+type Store interface {
+	PutObject(ctx context.Context, key string, body io.Reader, meta ObjectMeta) error
+	// GetObject returns the object's content; the caller closes it
+	GetObject(ctx context.Context, key string) (io.ReadCloser, error)
+	Stat(ctx context.Context, key string) (*ObjectMeta, error)
+	CopyObject(ctx context.Context, srcKey, dstKey string) error
+	// Delete removes the keys; missing objects are not an error
+	Delete(ctx context.Context, keys ...string) error
+	// DeletePrefix removes every object under prefix and returns how many were deleted
+	DeletePrefix(ctx context.Context, prefix string) (int, error)
+	PresignedURL(ctx context.Context, key string, meta ObjectMeta, ttl time.Duration) (*PresignedUpload, error)
+	// DownloadURL returns a time-limited URL that serves a private object as an attachment named fileName
+	DownloadURL(ctx context.Context, key, fileName string, ttl time.Duration) (string, error)
+	// PublicURL is the URL clients load the object from
+	PublicURL(key string) string
+	// KeyFromURL reverses PublicURL; ok is false for URLs this store does not serve
+	KeyFromURL(url string) (key string, ok bool)
+}
+
+// Config selects and configures the storage driver
+type Config struct {
+	Driver        string // "s3" or "local"
+	Bucket        string
+	PublicBaseURL string // CDN (s3) or static file (local) base that objects are served from
+	LocalDir      string
+}
+
+// ConfigFromEnv reads the storage settings:
+//   - STORAGE_DRIVER: "s3" (default) or "local" for development without AWS
+//   - MEDIA_BUCKET (default expotoworld-media) and ASSETS_CDN_BASE_URL for s3
+//   - LOCAL_STORAGE_DIR (default ./uploads) and LOCAL_STORAGE_BASE_URL (default SERVICE_BASE_URL + "/uploads")
+//     for local
+func ConfigFromEnv() Config {
+	cfg := Config{
+		Driver:   strings.ToLower(envOr("STORAGE_DRIVER", "s3")),
+		Bucket:   envOr("MEDIA_BUCKET", "expotoworld-media"),
+		LocalDir: envOr("LOCAL_STORAGE_DIR", "./uploads"),
+	}
+	if cfg.Driver == "local" {
+		cfg.PublicBaseURL = envOr("LOCAL_STORAGE_BASE_URL", envOr("SERVICE_BASE_URL", "http://localhost:8080")+"/uploads")
+	} else {
+		cfg.PublicBaseURL = envOr("ASSETS_CDN_BASE_URL", "https://assets.expotoworld.com")
+	}
+	cfg.PublicBaseURL = strings.TrimRight(cfg.PublicBaseURL, "/")
+	return cfg
+}
+
+// New returns the store for cfg. s3Client supplies the S3 client for the s3 driver.
+func New(cfg Config, s3Client S3ClientFunc) Store {
+	if cfg.Driver == "local" {
+		return NewLocal(cfg.LocalDir, cfg.PublicBaseURL)
+	}
+	return NewS3(s3Client, cfg.Bucket, cfg.PublicBaseURL)
+}
+
+func envOr(key, fallback string) string {
+	if v := strings.TrimSpace(os.Getenv(key)); v != "" {
+		return v
+	}
+	return fallback
+}
+
+// keyFromURL strips base from url
+func keyFromURL(base, url string) (string, bool) {
+	key := strings.TrimPrefix(url, base+"/")
+	return key, key != url && key != ""
+}
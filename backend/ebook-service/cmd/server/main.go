@@ -9,8 +9,15 @@ import (
 	"os"
 	"time"
 
+	"github.com/expotoworld/expotoworld/backend/common/auth"
+	"github.com/expotoworld/expotoworld/backend/common/awsclient"
+	"github.com/expotoworld/expotoworld/backend/common/httpserver"
+	objectstore "github.com/expotoworld/expotoworld/backend/common/storage"
 	api "github.com/expotoworld/expotoworld/backend/ebook-service/internal/api"
+	"github.com/expotoworld/expotoworld/backend/ebook-service/internal/bundles"
 	"github.com/expotoworld/expotoworld/backend/ebook-service/internal/ebookschema"
+	"github.com/expotoworld/expotoworld/backend/ebook-service/internal/storage"
+	"github.com/expotoworld/expotoworld/backend/ebook-service/internal/webhooks"
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 	"github.com/jackc/pgx/v5/pgxpool"
@@ -23,6 +30,9 @@ func main() {
 		log.Println("No .env file found, using environment variables")
 	}
 
+	// AWS clients must use the instance role, not SES SMTP env credentials that may be present
+	awsclient.IgnoreStaticEnvCredentials()
+
 	port := getEnv("PORT", "8084")
 	dbURL := getEnv("DATABASE_URL", "")
 	if dbURL == "" {
@@ -41,6 +51,10 @@ func main() {
 		log.Println("WARNING: No database configuration; set DATABASE_URL or DB_* envs")
 	}
 
+	// Background workers stop once the server has drained
+	workersCtx, stopWorkers := context.WithCancel(context.Background())
+	defer stopWorkers()
+
 	// DB pool
 	var pool *pgxpool.Pool
 	if dbURL != "" {
@@ -60,10 +74,31 @@ func main() {
 		if err := ebookschema.Init(ctx, pool); err != nil {
 			log.Printf("[EBOOK] Warning: media schema init failed: %v", err)
 		}
+
+		// Outbound webhook delivery (publish/version events)
+		go webhooks.NewWorkerFromEnv(pool).Run(workersCtx)
+
+		// Archival ZIP bundles of versions
+		go bundles.NewWorkerFromEnv(pool).Run(workersCtx)
+
+		// Reading progress/bookmark retention
+		go api.RunReadingRetention(workersCtx, pool)
+
+		// Reject tokens revoked by a forced logout or sign-in method change
+		api.CheckTokenVersions(auth.NewVersionCache(api.TokenVersions(pool), auth.VersionCacheTTL()))
 	}
 
+	// Media stores share one lazily loaded S3 client across requests and per-ebook buckets
+	storageCfg := objectstore.ConfigFromEnv()
+	media := storage.NewMedia(storageCfg, awsclient.NewProviderFromEnv().S3)
+
 	r := gin.Default()
 
+	// Serve media uploaded with STORAGE_DRIVER=local (development)
+	if storageCfg.Driver == "local" {
+		r.Static("/uploads", storageCfg.LocalDir)
+	}
+
 	// CORS restricted to editor origin if provided
 	editorOrigin := getEnv("EDITOR_ORIGIN", "")
 	corsCfg := cors.Config{AllowMethods: []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"}, AllowHeaders: []string{"Authorization", "Content-Type"}}
@@ -75,46 +110,78 @@ func main() {
 	r.Use(cors.New(corsCfg))
 
 	// Health
-	r.GET("/health", func(c *gin.Context) { c.JSON(http.StatusOK, gin.H{"status": "ok"}) })
-	r.GET("/live", func(c *gin.Context) { c.JSON(http.StatusOK, gin.H{"status": "ok"}) })
+	r.GET("/health", api.CacheControl(api.CacheNone), func(c *gin.Context) { c.JSON(http.StatusOK, gin.H{"status": "ok"}) })
+	r.GET("/live", api.CacheControl(api.CacheNone), func(c *gin.Context) { c.JSON(http.StatusOK, gin.H{"status": "ok"}) })
 
-	// Public/app-auth routes (require JWT but any role is fine): published reading
+	// Public/app-auth routes (require JWT but any role is fine): published reading, served through the CDN
 	app := r.Group("/api")
-	app.Use(api.JWTOptionalMiddleware()) // Accepts JWT if provided; we will enforce on specific routes
+	app.Use(api.OriginVerifyMiddleware(), api.JWTOptionalMiddleware()) // Accepts JWT if provided; we will enforce on specific routes
 	{
-		app.GET("/ebook/versions", api.RequireJWT(), api.GetEbookVersionsHandler(pool))
+		app.GET("/ebook/versions", api.CacheControl(api.CachePublishedList), api.RequireJWT(), api.GetEbookVersionsHandler(pool))
+
+		// Reader sync (position + bookmarks per ebook slug) and the user's own GDPR export/erasure
+		reader := app.Group("", api.CacheControl(api.CacheReaderData), api.RequireJWT())
+		reader.GET("/ebook/reading/:slug", api.GetReadingStateHandler(pool))
+		reader.PUT("/ebook/reading/:slug/progress", api.PutReadingProgressHandler(pool))
+		reader.PUT("/ebook/reading/:slug/bookmarks/:bookmark_id", api.PutBookmarkHandler(pool))
+		reader.DELETE("/ebook/reading/:slug/bookmarks/:bookmark_id", api.DeleteBookmarkHandler(pool))
+		reader.GET("/ebook/me/reading-data", api.ExportReadingDataHandler(pool))
+		reader.DELETE("/ebook/me/reading-data", api.EraseReadingDataHandler(pool))
 	}
 
 	// Author-only routes (draft edits)
 	author := r.Group("/api")
-	author.Use(api.JWTMiddleware(), api.RequireAuthor())
+	author.Use(api.CacheControl(api.CacheNone), api.JWTMiddleware(), api.RequireAuthor())
 	{
 		author.GET("/ebook", api.GetDraftEbookHandler(pool))
 		author.PUT("/ebook", api.PutAutosaveEbookHandler(pool))
 		author.POST("/ebook/versions", api.PostManualVersionHandler(pool))
 		// New version-management endpoints
 		author.GET("/ebook/versions/:id/content", api.GetVersionContentHandler(pool))
+		author.GET("/ebook/versions/:id/stats", api.GetVersionStatsHandler(pool))
+		author.GET("/ebook/stats", api.GetEbookStatsHandler(pool))
 		author.POST("/ebook/versions/:id/restore", api.RestoreVersionHandler(pool))
 		author.POST("/ebook/versions/:id/publish", api.PublishFromManualVersionHandler(pool))
+		// Staged rollout of published versions (percentage / beta readers first), promotion and rollback
+		author.PUT("/ebook/versions/:id/rollout", api.PutVersionRolloutHandler(pool))
+		author.POST("/ebook/versions/:id/promote", api.PromoteVersionHandler(pool))
+		author.POST("/ebook/versions/:id/rollback", api.RollbackVersionHandler(pool))
 		author.DELETE("/ebook/versions/:id", api.DeleteVersionHandler(pool))
 		author.PATCH("/ebook/versions/:id", api.PatchVersionLabelHandler(pool))
+		// Archival bundles (async ZIP of version JSON + media with checksum manifest)
+		author.POST("/ebook/versions/:id/bundle", api.CreateVersionBundleHandler(pool))
+		author.GET("/ebook/versions/:id/bundles", api.ListVersionBundlesHandler(pool))
+		author.GET("/ebook/bundles/:id", api.GetBundleJobHandler(pool))
 
 		// Legacy publish-from-autosave (kept for compatibility; UI will not use it)
 		author.POST("/ebook/publish", api.PostPublishHandler(pool))
 
-		author.POST("/ebook/upload-image", api.UploadImageHandler(pool))
-		author.POST("/ebook/upload-media", api.UploadMediaHandler(pool))
+		// Manuscript import (DOCX/Markdown -> editor blocks) into a draft or manual version
+		author.POST("/ebook/:slug/import", api.ImportEbookHandler(pool, media))
+
+		author.POST("/ebook/upload-image", api.UploadImageHandler(pool, media))
+		author.POST("/ebook/upload-media", api.UploadMediaHandler(pool, media))
 		author.DELETE("/ebook/delete-image", api.DeleteImageHandler(pool))
 		author.DELETE("/ebook/delete-media", api.DeleteMediaHandler(pool))
 
 		// Admin tools (author-gated)
 		author.POST("/ebook/admin/reindex", api.AdminReindexHandler(pool))
 		author.GET("/ebook/admin/pending", api.AdminListPendingHandler(pool))
+		author.GET("/ebook/admin/webhooks", api.ListWebhookEndpointsHandler(pool))
+		author.POST("/ebook/admin/webhooks", api.CreateWebhookEndpointHandler(pool))
+		author.PUT("/ebook/admin/webhooks/:id", api.UpdateWebhookEndpointHandler(pool))
+		author.DELETE("/ebook/admin/webhooks/:id", api.DeleteWebhookEndpointHandler(pool))
+		author.GET("/ebook/admin/webhook-deliveries", api.ListWebhookDeliveriesHandler(pool))
+		author.POST("/ebook/admin/webhook-deliveries/:id/redeliver", api.RedeliverWebhookHandler(pool))
+		author.DELETE("/ebook/admin/users/:user_id/reading-data", api.AdminEraseReadingDataHandler(pool))
+		author.GET("/ebook/admin/beta-readers", api.ListBetaReadersHandler(pool))
+		author.PUT("/ebook/admin/beta-readers/:user_id", api.PutBetaReaderHandler(pool))
+		author.DELETE("/ebook/admin/beta-readers/:user_id", api.DeleteBetaReaderHandler(pool))
 	}
 
-	log.Printf("ebook-service listening on :%s", port)
-	if err := r.Run(fmt.Sprintf(":%s", port)); err != nil {
-		log.Fatalf("server error: %v", err)
+	// Serve until SIGTERM, then drain in-flight requests before the deferred worker stop and pool close
+	if err := httpserver.Run("ebook-service", r, port); err != nil {
+		log.Printf("[ERROR] %v", err)
 	}
 }
 
@@ -3,16 +3,12 @@ package api
 import (
 	"context"
 	"encoding/json"
-	"io"
 	"log"
 	"net/http"
-	"os"
 	"strconv"
 	"time"
 
-	"github.com/aws/aws-sdk-go-v2/config"
-	"github.com/aws/aws-sdk-go-v2/service/s3"
-	"github.com/expotoworld/expotoworld/backend/ebook-service/internal/mediatools"
+	"github.com/expotoworld/expotoworld/backend/ebook-service/internal/storage"
 	"github.com/gin-gonic/gin"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
@@ -39,18 +35,14 @@ func AdminReindexHandler(db *pgxpool.Pool) gin.HandlerFunc {
 			return
 		}
 
-		cdnBase := os.Getenv("ASSETS_CDN_BASE_URL")
-		if cdnBase == "" {
-			cdnBase = "https://assets.expotoworld.com"
-		}
-		allowedPrefix := "ebooks/huashangdao/"
+		mc := loadMediaConfig(ctx, db)
 
 		// Autosave content
 		var contentRaw []byte
 		if err := db.QueryRow(ctx, `SELECT COALESCE(content,'{}'::jsonb)::text FROM ebooks WHERE slug='main'`).Scan(&contentRaw); err == nil {
 			var content any
 			_ = json.Unmarshal(contentRaw, &content)
-			keys := mediatools.ExtractMediaKeys(content, cdnBase, allowedPrefix)
+			keys := mc.ExtractKeys(content)
 			for _, k := range keys {
 				_, _ = db.Exec(ctx, `INSERT INTO ebook_media_usage(media_key,in_autosave,last_seen_at) VALUES ($1,true,now()) ON CONFLICT (media_key) DO UPDATE SET in_autosave=true,last_seen_at=now()`, k)
 			}
@@ -63,33 +55,19 @@ func AdminReindexHandler(db *pgxpool.Pool) gin.HandlerFunc {
 			return
 		}
 		defer rows.Close()
-		region := os.Getenv("AWS_REGION")
-		if region == "" {
-			region = os.Getenv("AWS_DEFAULT_REGION")
-		}
-		if region == "" {
-			region = "eu-central-1"
-		}
-		cfg, _ := config.LoadDefaultConfig(ctx, config.WithRegion(region))
-		s3c := s3.NewFromConfig(cfg)
-		bucket := os.Getenv("EBOOK_S3_BUCKET")
-		if bucket == "" {
-			bucket = "expotoworld-ebook-versions"
-		}
+		u, _ := storage.NewS3Uploader(ctx)
 		for rows.Next() {
 			var id, kind, key string
 			if err := rows.Scan(&id, &kind, &key); err != nil {
 				continue
 			}
-			obj, err := s3c.GetObject(ctx, &s3.GetObjectInput{Bucket: &bucket, Key: &key})
+			b, err := u.GetJSON(ctx, key)
 			if err != nil {
 				continue
 			}
-			b, _ := io.ReadAll(obj.Body)
-			_ = obj.Body.Close()
 			var content any
 			_ = json.Unmarshal(b, &content)
-			keys := mediatools.ExtractMediaKeys(content, cdnBase, allowedPrefix)
+			keys := mc.ExtractKeys(content)
 			for _, mk := range keys {
 				if kind == "manual" {
 					_, _ = db.Exec(ctx, `INSERT INTO ebook_media_usage(media_key,manual_refs,last_seen_at) VALUES ($1,1,now()) ON CONFLICT (media_key) DO UPDATE SET manual_refs=ebook_media_usage.manual_refs+1,last_seen_at=now()`, mk)
@@ -102,13 +80,13 @@ func AdminReindexHandler(db *pgxpool.Pool) gin.HandlerFunc {
 
 		// Enqueue any zero-reference media not already pending (sweep after reindex)
 		_, _ = db.Exec(ctx, `
-                INSERT INTO ebook_media_pending_deletion (media_key, requested_at, not_before, attempts, last_checked_at)
-                SELECT mu.media_key, now(), now() + interval '15 minutes', 0, NULL
+                INSERT INTO ebook_media_pending_deletion (media_key, media_bucket, requested_at, not_before, attempts, last_checked_at)
+                SELECT mu.media_key, $1, now(), now() + interval '15 minutes', 0, NULL
                 FROM ebook_media_usage mu
                 LEFT JOIN ebook_media_pending_deletion pd ON pd.media_key = mu.media_key
                 WHERE mu.in_autosave = false AND mu.manual_refs = 0 AND mu.published_refs = 0
                   AND pd.media_key IS NULL
-            `)
+            `, mc.Bucket)
 
 		c.JSON(http.StatusOK, gin.H{"status": "reindexed"})
 	}
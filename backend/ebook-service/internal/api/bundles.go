@@ -0,0 +1,100 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/expotoworld/expotoworld/backend/ebook-service/internal/bundles"
+	"github.com/expotoworld/expotoworld/backend/ebook-service/internal/storage"
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// bundleDownloadTTL bounds how long a presigned bundle URL stays valid.
+const bundleDownloadTTL = time.Hour
+
+// CreateVersionBundleHandler queues a ZIP bundle (version JSON + referenced media + manifest.json with
+// SHA-256 checksums) for archival/legal deposit. Returns 202 with the job, or 200 with an unfinished one.
+func CreateVersionBundleHandler(db *pgxpool.Pool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := strings.TrimSpace(c.Param("id"))
+		if id == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "id required"})
+			return
+		}
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+		defer cancel()
+
+		u, _ := storage.NewS3Uploader(ctx)
+		if !u.Enabled() {
+			c.JSON(http.StatusFailedDependency, gin.H{"error": "s3 not configured"})
+			return
+		}
+		userID, _ := readerUserID(c)
+		job, created, err := bundles.Enqueue(ctx, db, id, userID)
+		if err != nil {
+			if errors.Is(err, bundles.ErrVersionNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "version not found"})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if !created {
+			c.JSON(http.StatusOK, gin.H{"job": job})
+			return
+		}
+		log.Printf("[EBOOK] bundle %s queued for version %s by %s", job.ID, id, userID)
+		c.JSON(http.StatusAccepted, gin.H{"job": job})
+	}
+}
+
+// ListVersionBundlesHandler lists the latest bundle jobs of a version.
+func ListVersionBundlesHandler(db *pgxpool.Pool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+		defer cancel()
+		items, err := bundles.ListForVersion(ctx, db, strings.TrimSpace(c.Param("id")), 20)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"items": items})
+	}
+}
+
+// GetBundleJobHandler reports a bundle job's status; completed jobs include a presigned download URL.
+func GetBundleJobHandler(db *pgxpool.Pool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+		defer cancel()
+		job, err := bundles.Get(ctx, db, strings.TrimSpace(c.Param("id")))
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "bundle not found"})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		resp := gin.H{"job": job}
+		if job.Status == bundles.StatusCompleted && job.S3Key != nil {
+			u, _ := storage.NewS3Uploader(ctx)
+			if u.Enabled() {
+				url, err := u.PresignGet(ctx, *job.S3Key, bundles.FileName(job), bundleDownloadTTL)
+				if err != nil {
+					log.Printf("[EBOOK] presign bundle %s: %v", job.ID, err)
+				} else {
+					resp["download_url"] = url
+					resp["download_expires_at"] = time.Now().Add(bundleDownloadTTL).UTC()
+				}
+			}
+		}
+		c.JSON(http.StatusOK, resp)
+	}
+}
@@ -15,24 +15,69 @@ import (
 	"strings"
 	"time"
 
-	"github.com/aws/aws-sdk-go-v2/config"
-	"github.com/aws/aws-sdk-go-v2/service/s3"
+	objectstore "github.com/expotoworld/expotoworld/backend/common/storage"
 	"github.com/expotoworld/expotoworld/backend/ebook-service/internal/mediatools"
 	"github.com/expotoworld/expotoworld/backend/ebook-service/internal/storage"
+	"github.com/expotoworld/expotoworld/backend/ebook-service/internal/webhooks"
 	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 type Handlers struct{ DB *pgxpool.Pool }
 
+type rowQuerier interface {
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+// loadMediaConfig returns the env media config with the main ebook's bucket/prefix overrides applied.
+func loadMediaConfig(ctx context.Context, q rowQuerier) mediatools.Config {
+	return loadMediaConfigFor(ctx, q, "main")
+}
+
+// loadMediaConfigFor returns the env media config with the given ebook's bucket/prefix overrides applied.
+func loadMediaConfigFor(ctx context.Context, q rowQuerier, slug string) mediatools.Config {
+	mc := mediatools.ConfigFromEnv()
+	if q == nil {
+		return mc
+	}
+	var bucket, prefix sql.NullString
+	if err := q.QueryRow(ctx, `SELECT media_bucket, media_prefix FROM ebooks WHERE slug=$1`, slug).Scan(&bucket, &prefix); err != nil {
+		return mc
+	}
+	return mc.WithOverrides(bucket.String, prefix.String)
+}
+
+// enqueueWebhook queues an event inside a savepoint of the caller's transaction so
+// a webhook failure is logged and never aborts the authoring change itself.
+func enqueueWebhook(ctx context.Context, tx pgx.Tx, event string, data map[string]any) {
+	sp, err := tx.Begin(ctx)
+	if err != nil {
+		log.Printf("[EBOOK] webhook savepoint failed event=%s err=%v", event, err)
+		return
+	}
+	if err := webhooks.Enqueue(ctx, sp, event, data); err != nil {
+		_ = sp.Rollback(ctx)
+		log.Printf("[EBOOK] webhook enqueue failed event=%s err=%v", event, err)
+		return
+	}
+	_ = sp.Commit(ctx)
+}
+
 type versionItem struct {
-	ID        string    `json:"id"`
-	Kind      string    `json:"kind"`
-	S3Key     string    `json:"s3_key"`
-	Label     *string   `json:"label,omitempty"`
-	CreatedAt time.Time `json:"created_at"`
+	ID             string     `json:"id"`
+	Kind           string     `json:"kind"`
+	S3Key          string     `json:"s3_key"`
+	Label          *string    `json:"label,omitempty"`
+	RolloutPercent *int       `json:"rollout_percent,omitempty"` // published versions only
+	Staged         bool       `json:"staged,omitempty"`          // published to only part of the readers so far
+	RolledBackAt   *time.Time `json:"rolled_back_at,omitempty"`  // authors only; rolled back versions are hidden from readers
+	WordCount      *int       `json:"word_count,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
 }
 
+// GetEbookVersionsHandler lists versions of the main ebook, newest first. Readers only get the published
+// versions rolled out to them (see rolloutVisibleSQL); authors see every version with its rollout state.
 func GetEbookVersionsHandler(db *pgxpool.Pool) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		kind := strings.TrimSpace(c.Query("kind"))
@@ -50,13 +95,17 @@ func GetEbookVersionsHandler(db *pgxpool.Pool) gin.HandlerFunc {
 		ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
 		defer cancel()
 
+		userID, _ := readerUserID(c)
+		author := isAuthor(c)
+
 		rows, err := db.Query(ctx,
-			`SELECT ev.id, ev.kind, ev.s3_key, ev.label, ev.created_at
+			`SELECT ev.id, ev.kind, ev.s3_key, ev.label, ev.rollout_percent, ev.rolled_back_at, ev.word_count, ev.created_at
 			 FROM ebook_versions ev
 			 JOIN ebooks e ON e.id = ev.ebook_id
 			 WHERE e.slug='main' AND ($1='' OR ev.kind=$1)
+			   AND ($5 OR `+rolloutVisibleSQL+`)
 			 ORDER BY ev.created_at DESC
-			 LIMIT $2 OFFSET $3`, kind, limit, offset,
+			 LIMIT $2 OFFSET $3`, kind, limit, offset, userID, author,
 		)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -67,10 +116,18 @@ func GetEbookVersionsHandler(db *pgxpool.Pool) gin.HandlerFunc {
 		items := make([]versionItem, 0, limit)
 		for rows.Next() {
 			var v versionItem
-			if err := rows.Scan(&v.ID, &v.Kind, &v.S3Key, &v.Label, &v.CreatedAt); err != nil {
+			var percent int
+			if err := rows.Scan(&v.ID, &v.Kind, &v.S3Key, &v.Label, &percent, &v.RolledBackAt, &v.WordCount, &v.CreatedAt); err != nil {
 				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 				return
 			}
+			if v.Kind == "published" {
+				v.RolloutPercent = &percent
+				v.Staged = percent < 100
+			}
+			if !author {
+				v.RolledBackAt = nil
+			}
 			items = append(items, v)
 		}
 		c.JSON(http.StatusOK, gin.H{"items": items, "kind": kind, "limit": limit, "offset": offset})
@@ -118,47 +175,12 @@ func PutAutosaveEbookHandler(db *pgxpool.Pool) gin.HandlerFunc {
 			return
 		}
 
-		cdnBase := os.Getenv("ASSETS_CDN_BASE_URL")
-		if cdnBase == "" {
-			cdnBase = "https://assets.expotoworld.com"
-		}
-		allowedPrefix := "ebooks/huashangdao/"
-
-		// Compute old/new media sets
-		oldKeys := map[string]struct{}{}
-		if oldContent.Valid && strings.TrimSpace(oldContent.String) != "" {
-			var oc any
-			_ = json.Unmarshal([]byte(oldContent.String), &oc)
-			for _, k := range mediatools.ExtractMediaKeys(oc, cdnBase, allowedPrefix) {
-				oldKeys[k] = struct{}{}
-			}
-		}
-		newKeys := map[string]struct{}{}
-		for _, k := range mediatools.ExtractMediaKeys(newContent, cdnBase, allowedPrefix) {
-			newKeys[k] = struct{}{}
-		}
-
-		// Update ebooks.content
-		b, _ := json.Marshal(newContent)
-		if _, err := tx.Exec(ctx, `UPDATE ebooks SET content=$1::jsonb, updated_at=now() WHERE id=$2`, string(b), ebookID); err != nil {
+		mc := loadMediaConfig(ctx, tx)
+		if err := saveDraftContent(ctx, tx, mc, ebookID, oldContent.String, newContent); err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
 		}
 
-		// Maintain usage flags within same transaction
-		for k := range newKeys {
-			if _, existed := oldKeys[k]; !existed {
-				_, _ = tx.Exec(ctx, `INSERT INTO ebook_media_usage(media_key,in_autosave,last_seen_at) VALUES ($1,true,now()) ON CONFLICT (media_key) DO UPDATE SET in_autosave=true,last_seen_at=now()`, k)
-			} else {
-				_, _ = tx.Exec(ctx, `UPDATE ebook_media_usage SET last_seen_at=now() WHERE media_key=$1`, k)
-			}
-		}
-		for k := range oldKeys {
-			if _, still := newKeys[k]; !still {
-				_, _ = tx.Exec(ctx, `UPDATE ebook_media_usage SET in_autosave=false WHERE media_key=$1`, k)
-			}
-		}
-
 		if err := tx.Commit(ctx); err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
@@ -167,6 +189,73 @@ func PutAutosaveEbookHandler(db *pgxpool.Pool) gin.HandlerFunc {
 	}
 }
 
+// saveDraftContent replaces an ebook's autosave content and maintains the in_autosave media usage flags
+// within the caller's transaction. oldContent is the previous content as JSON text (may be empty).
+func saveDraftContent(ctx context.Context, tx pgx.Tx, mc mediatools.Config, ebookID, oldContent string, newContent any) error {
+	// Compute old/new media sets
+	oldKeys := map[string]struct{}{}
+	if strings.TrimSpace(oldContent) != "" {
+		var oc any
+		_ = json.Unmarshal([]byte(oldContent), &oc)
+		for _, k := range mc.ExtractKeys(oc) {
+			oldKeys[k] = struct{}{}
+		}
+	}
+	newKeys := map[string]struct{}{}
+	for _, k := range mc.ExtractKeys(newContent) {
+		newKeys[k] = struct{}{}
+	}
+
+	// Update ebooks.content
+	b, _ := json.Marshal(newContent)
+	if _, err := tx.Exec(ctx, `UPDATE ebooks SET content=$1::jsonb, updated_at=now() WHERE id=$2`, string(b), ebookID); err != nil {
+		return err
+	}
+	if err := storeDraftStats(ctx, tx, ebookID, newContent); err != nil {
+		return err
+	}
+
+	// Maintain usage flags within same transaction
+	for k := range newKeys {
+		if _, existed := oldKeys[k]; !existed {
+			_, _ = tx.Exec(ctx, `INSERT INTO ebook_media_usage(media_key,in_autosave,last_seen_at) VALUES ($1,true,now()) ON CONFLICT (media_key) DO UPDATE SET in_autosave=true,last_seen_at=now()`, k)
+		} else {
+			_, _ = tx.Exec(ctx, `UPDATE ebook_media_usage SET last_seen_at=now() WHERE media_key=$1`, k)
+		}
+	}
+	for k := range oldKeys {
+		if _, still := newKeys[k]; !still {
+			_, _ = tx.Exec(ctx, `UPDATE ebook_media_usage SET in_autosave=false WHERE media_key=$1`, k)
+		}
+	}
+	return nil
+}
+
+// createManualVersion stores content as a manual version (S3 JSON + version row + media refs) and
+// queues the version.created webhook, all within the caller's transaction.
+func createManualVersion(ctx context.Context, tx pgx.Tx, uploader *storage.S3Uploader, mc mediatools.Config, ebookID string, content any, label *string) (string, error) {
+	key := storage.TimestampKey("ebook/versions/manual/")
+	if _, err := uploader.UploadJSON(ctx, key, content); err != nil {
+		return "", err
+	}
+	var versionID string
+	if err := tx.QueryRow(ctx, `INSERT INTO ebook_versions(ebook_id, kind, s3_key, label) VALUES ($1,'manual',$2,$3) RETURNING id`, ebookID, key, label).Scan(&versionID); err != nil {
+		return "", err
+	}
+	if _, err := storeVersionStats(ctx, tx, versionID, content); err != nil {
+		return "", err
+	}
+
+	keys := mc.ExtractKeys(content)
+	for _, mk := range keys {
+		_, _ = tx.Exec(ctx, `INSERT INTO ebook_media_usage(media_key,manual_refs,last_seen_at) VALUES ($1,1,now()) ON CONFLICT (media_key) DO UPDATE SET manual_refs=ebook_media_usage.manual_refs+1,last_seen_at=now()`, mk)
+		_, _ = tx.Exec(ctx, `INSERT INTO ebook_version_media(version_id,media_key) VALUES ($1,$2) ON CONFLICT DO NOTHING`, versionID, mk)
+	}
+
+	enqueueWebhook(ctx, tx, webhooks.EventVersionCreated, map[string]any{"ebook_id": ebookID, "version_id": versionID, "kind": "manual", "label": label})
+	return versionID, nil
+}
+
 type manualReq struct {
 	Label string `json:"label"`
 }
@@ -201,32 +290,15 @@ func PostManualVersionHandler(db *pgxpool.Pool) gin.HandlerFunc {
 		var content any
 		_ = json.Unmarshal(contentRaw, &content)
 
-		key := storage.TimestampKey("ebook/versions/manual/")
-		if _, err := uploader.UploadJSON(ctx, key, content); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-			return
-		}
 		var lbl *string
 		if s := strings.TrimSpace(req.Label); s != "" {
 			lbl = &s
 		}
-		var versionID string
-		if err := tx.QueryRow(ctx, `INSERT INTO ebook_versions(ebook_id, kind, s3_key, label) VALUES ($1,'manual',$2,$3) RETURNING id`, ebookID, key, lbl).Scan(&versionID); err != nil {
+		if _, err := createManualVersion(ctx, tx, uploader, loadMediaConfig(ctx, tx), ebookID, content, lbl); err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
 		}
 
-		cdnBase := os.Getenv("ASSETS_CDN_BASE_URL")
-		if cdnBase == "" {
-			cdnBase = "https://assets.expotoworld.com"
-		}
-		allowedPrefix := "ebooks/huashangdao/"
-		keys := mediatools.ExtractMediaKeys(content, cdnBase, allowedPrefix)
-		for _, mk := range keys {
-			_, _ = tx.Exec(ctx, `INSERT INTO ebook_media_usage(media_key,manual_refs,last_seen_at) VALUES ($1,1,now()) ON CONFLICT (media_key) DO UPDATE SET manual_refs=ebook_media_usage.manual_refs+1,last_seen_at=now()`, mk)
-			_, _ = tx.Exec(ctx, `INSERT INTO ebook_version_media(version_id,media_key) VALUES ($1,$2) ON CONFLICT DO NOTHING`, versionID, mk)
-		}
-
 		if err := tx.Commit(ctx); err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
@@ -272,18 +344,20 @@ func PostPublishHandler(db *pgxpool.Pool) gin.HandlerFunc {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
 		}
-
-		cdnBase := os.Getenv("ASSETS_CDN_BASE_URL")
-		if cdnBase == "" {
-			cdnBase = "https://assets.expotoworld.com"
+		if _, err := storeVersionStats(ctx, tx, versionID, content); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
 		}
-		allowedPrefix := "ebooks/huashangdao/"
-		keys := mediatools.ExtractMediaKeys(content, cdnBase, allowedPrefix)
+
+		mc := loadMediaConfig(ctx, tx)
+		keys := mc.ExtractKeys(content)
 		for _, mk := range keys {
 			_, _ = tx.Exec(ctx, `INSERT INTO ebook_media_usage(media_key,published_refs,last_seen_at) VALUES ($1,1,now()) ON CONFLICT (media_key) DO UPDATE SET published_refs=ebook_media_usage.published_refs+1,last_seen_at=now()`, mk)
 			_, _ = tx.Exec(ctx, `INSERT INTO ebook_version_media(version_id,media_key) VALUES ($1,$2) ON CONFLICT DO NOTHING`, versionID, mk)
 		}
 
+		enqueueWebhook(ctx, tx, webhooks.EventPublished, map[string]any{"ebook_id": ebookID, "version_id": versionID, "label": nil})
+
 		if err := tx.Commit(ctx); err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
@@ -293,7 +367,7 @@ func PostPublishHandler(db *pgxpool.Pool) gin.HandlerFunc {
 }
 
 // UploadImageHandler handles POST /api/ebook/upload-image
-func UploadImageHandler(db *pgxpool.Pool) gin.HandlerFunc {
+func UploadImageHandler(db *pgxpool.Pool, mediaStores *storage.Media) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		ctx, cancel := context.WithTimeout(c.Request.Context(), 30*time.Second)
 		defer cancel()
@@ -322,56 +396,19 @@ func UploadImageHandler(db *pgxpool.Pool) gin.HandlerFunc {
 		}
 
 		// Use the media bucket (same as catalog service) for ebook images
-		// This ensures CloudFront can serve the images via assets.expotoworld.com
-		bucket := "expotoworld-media"
-
-		// Get AWS region
-		region := os.Getenv("AWS_REGION")
-		if region == "" {
-			region = os.Getenv("AWS_DEFAULT_REGION")
-		}
-		if region == "" {
-			region = "eu-central-1"
-		}
-
-		// Clear any existing credentials to use IAM role
-		_ = os.Unsetenv("AWS_ACCESS_KEY_ID")
-		_ = os.Unsetenv("AWS_SECRET_ACCESS_KEY")
-		_ = os.Unsetenv("AWS_SESSION_TOKEN")
-
-		// Load AWS config
-		cfg, err := config.LoadDefaultConfig(ctx, config.WithRegion(region))
-		if err != nil {
-			log.Printf("Failed to load AWS config: %v", err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to configure S3"})
-			return
-		}
+		// This ensures CloudFront can serve the images via the assets CDN
+		mc := loadMediaConfig(ctx, db)
+		media := mediaStores.For(mc)
 
-		s3Client := s3.NewFromConfig(cfg)
-
-		// Generate S3 object key
 		ext := filepath.Ext(header.Filename)
-		objectKey := fmt.Sprintf("ebooks/huashangdao/images/%d%s", time.Now().UnixNano(), ext)
-
-		// Upload to S3
-		_, err = s3Client.PutObject(ctx, &s3.PutObjectInput{
-			Bucket:      &bucket,
-			Key:         &objectKey,
-			Body:        bytes.NewReader(fileContent),
-			ContentType: &contentType,
-		})
-		if err != nil {
-			log.Printf("Failed to upload to S3: %v", err)
+		objectKey := mc.ObjectKey("images", fmt.Sprintf("%d%s", time.Now().UnixNano(), ext))
+		if err := media.PutObject(ctx, objectKey, bytes.NewReader(fileContent), objectstore.ObjectMeta{ContentType: contentType}); err != nil {
+			log.Printf("Failed to upload image: %v", err)
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to upload image"})
 			return
 		}
 
-		// Build CloudFront URL
-		cdnBase := os.Getenv("ASSETS_CDN_BASE_URL")
-		if cdnBase == "" {
-			cdnBase = "https://assets.expotoworld.com"
-		}
-		imageURL := fmt.Sprintf("%s/%s", strings.TrimRight(cdnBase, "/"), objectKey)
+		imageURL := media.PublicURL(objectKey)
 
 		// Upsert into ebook_media_assets for consistency with new media endpoint
 		if db != nil {
@@ -382,13 +419,13 @@ func UploadImageHandler(db *pgxpool.Pool) gin.HandlerFunc {
 			)
 		}
 
-		log.Printf("Successfully uploaded image to S3: %s", objectKey)
+		log.Printf("Successfully uploaded image: %s", objectKey)
 		c.JSON(http.StatusOK, gin.H{"url": imageURL})
 	}
 }
 
 // UploadMediaHandler handles POST /api/ebook/upload-media for image, video, and audio
-func UploadMediaHandler(db *pgxpool.Pool) gin.HandlerFunc {
+func UploadMediaHandler(db *pgxpool.Pool, mediaStores *storage.Media) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		ctx, cancel := context.WithTimeout(c.Request.Context(), 45*time.Second)
 		defer cancel()
@@ -451,36 +488,18 @@ func UploadMediaHandler(db *pgxpool.Pool) gin.HandlerFunc {
 			}
 		}
 
-		bucket := "expotoworld-media"
-		region := os.Getenv("AWS_REGION")
-		if region == "" {
-			region = os.Getenv("AWS_DEFAULT_REGION")
-		}
-		if region == "" {
-			region = "eu-central-1"
-		}
-
-		_ = os.Unsetenv("AWS_ACCESS_KEY_ID")
-		_ = os.Unsetenv("AWS_SECRET_ACCESS_KEY")
-		_ = os.Unsetenv("AWS_SESSION_TOKEN")
-
-		cfg, err := config.LoadDefaultConfig(ctx, config.WithRegion(region))
-		if err != nil {
-			log.Printf("aws cfg: %v", err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to configure S3"})
-			return
-		}
-		s3Client := s3.NewFromConfig(cfg)
+		mc := loadMediaConfig(ctx, db)
+		media := mediaStores.For(mc)
 
 		// Determine prefix and key
-		var prefix string
+		var folder string
 		switch category {
 		case "image":
-			prefix = "ebooks/huashangdao/images/"
+			folder = "images"
 		case "video":
-			prefix = "ebooks/huashangdao/videos/"
+			folder = "videos"
 		case "audio":
-			prefix = "ebooks/huashangdao/audio/"
+			folder = "audio"
 		}
 		ext := filepath.Ext(header.Filename)
 		if ext == "" {
@@ -516,26 +535,16 @@ func UploadMediaHandler(db *pgxpool.Pool) gin.HandlerFunc {
 				ext = ".wav"
 			}
 		}
-		objectKey := fmt.Sprintf("%s%d%s", prefix, time.Now().UnixNano(), ext)
+		objectKey := mc.ObjectKey(folder, fmt.Sprintf("%d%s", time.Now().UnixNano(), ext))
 
 		// Upload
-		_, err = s3Client.PutObject(ctx, &s3.PutObjectInput{
-			Bucket:      &bucket,
-			Key:         &objectKey,
-			Body:        bytes.NewReader(fileBytes),
-			ContentType: &contentType,
-		})
-		if err != nil {
-			log.Printf("s3 put: %v", err)
+		if err := media.PutObject(ctx, objectKey, bytes.NewReader(fileBytes), objectstore.ObjectMeta{ContentType: contentType}); err != nil {
+			log.Printf("media put: %v", err)
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to upload media"})
 			return
 		}
 
-		cdnBase := os.Getenv("ASSETS_CDN_BASE_URL")
-		if cdnBase == "" {
-			cdnBase = "https://assets.expotoworld.com"
-		}
-		url := fmt.Sprintf("%s/%s", strings.TrimRight(cdnBase, "/"), objectKey)
+		url := media.PublicURL(objectKey)
 		log.Printf("[UPLOAD media] cat=%s ct=%s name=%s size=%d key=%s", category, contentType, header.Filename, len(fileBytes), objectKey)
 
 		// Upsert metadata (no duration)
@@ -585,16 +594,13 @@ func DeleteMediaHandler(db *pgxpool.Pool) gin.HandlerFunc {
 			return
 		}
 
-		cdnBase := os.Getenv("ASSETS_CDN_BASE_URL")
-		if cdnBase == "" {
-			cdnBase = "https://assets.expotoworld.com"
-		}
-		if !strings.HasPrefix(urlStr, strings.TrimRight(cdnBase, "/")+"/") {
+		mc := loadMediaConfig(ctx, db)
+		objectKey, ok := mc.KeyFromURL(urlStr)
+		if !ok {
 			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid media URL"})
 			return
 		}
-		objectKey := strings.TrimPrefix(urlStr, strings.TrimRight(cdnBase, "/")+"/")
-		if !strings.HasPrefix(objectKey, "ebooks/huashangdao/") {
+		if !mc.Owns(objectKey) {
 			c.JSON(http.StatusForbidden, gin.H{"error": "Can only delete ebook media"})
 			return
 		}
@@ -617,9 +623,9 @@ func DeleteMediaHandler(db *pgxpool.Pool) gin.HandlerFunc {
 				ttlMin = n
 			}
 		}
-		_, _ = db.Exec(ctx, `INSERT INTO ebook_media_pending_deletion(media_key,requested_at,not_before,attempts,last_checked_at)
-			VALUES ($1, now(), now() + ($2::int * interval '1 minute'), 0, NULL)
-			ON CONFLICT (media_key) DO UPDATE SET requested_at=now(), not_before=now() + ($2::int * interval '1 minute')`, objectKey, ttlMin)
+		_, _ = db.Exec(ctx, `INSERT INTO ebook_media_pending_deletion(media_key,media_bucket,requested_at,not_before,attempts,last_checked_at)
+			VALUES ($1, $3, now(), now() + ($2::int * interval '1 minute'), 0, NULL)
+			ON CONFLICT (media_key) DO UPDATE SET media_bucket=EXCLUDED.media_bucket, requested_at=now(), not_before=now() + ($2::int * interval '1 minute')`, objectKey, ttlMin, mc.Bucket)
 
 		c.JSON(http.StatusOK, gin.H{"status": "scheduled", "key": objectKey, "ttl_minutes": ttlMin})
 	}
@@ -650,18 +656,15 @@ func DeleteImageHandler(db *pgxpool.Pool) gin.HandlerFunc {
 		}
 
 		// Extract S3 object key from CloudFront URL
-		cdnBase := os.Getenv("ASSETS_CDN_BASE_URL")
-		if cdnBase == "" {
-			cdnBase = "https://assets.expotoworld.com"
-		}
-		if !strings.HasPrefix(req.ImageURL, cdnBase) {
+		mc := loadMediaConfig(ctx, db)
+		objectKey, ok := mc.KeyFromURL(req.ImageURL)
+		if !ok {
 			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid image URL"})
 			return
 		}
-		objectKey := strings.TrimPrefix(req.ImageURL, strings.TrimRight(cdnBase, "/")+"/")
 
 		// Only allow deletion under our ebook namespace (images today; future types too)
-		if !strings.HasPrefix(objectKey, "ebooks/huashangdao/") {
+		if !mc.Owns(objectKey) {
 			c.JSON(http.StatusForbidden, gin.H{"error": "Can only delete ebook media"})
 			return
 		}
@@ -684,7 +687,7 @@ func DeleteImageHandler(db *pgxpool.Pool) gin.HandlerFunc {
 				ttlMin = n
 			}
 		}
-		_, _ = db.Exec(ctx, `INSERT INTO ebook_media_pending_deletion(media_key,requested_at,not_before,attempts,last_checked_at) VALUES ($1, now(), now() + ($2::int * interval '1 minute'), 0, NULL) ON CONFLICT (media_key) DO UPDATE SET requested_at=now(), not_before=now() + ($2::int * interval '1 minute')`, objectKey, ttlMin)
+		_, _ = db.Exec(ctx, `INSERT INTO ebook_media_pending_deletion(media_key,media_bucket,requested_at,not_before,attempts,last_checked_at) VALUES ($1, $3, now(), now() + ($2::int * interval '1 minute'), 0, NULL) ON CONFLICT (media_key) DO UPDATE SET media_bucket=EXCLUDED.media_bucket, requested_at=now(), not_before=now() + ($2::int * interval '1 minute')`, objectKey, ttlMin, mc.Bucket)
 
 		c.JSON(http.StatusOK, gin.H{"status": "scheduled", "key": objectKey, "ttl_minutes": ttlMin})
 	}
@@ -770,21 +773,17 @@ func RestoreVersionHandler(db *pgxpool.Pool) gin.HandlerFunc {
 		var newContent any
 		_ = json.Unmarshal(b, &newContent)
 
-		cdnBase := os.Getenv("ASSETS_CDN_BASE_URL")
-		if cdnBase == "" {
-			cdnBase = "https://assets.expotoworld.com"
-		}
-		allowedPrefix := "ebooks/huashangdao/"
+		mc := loadMediaConfig(ctx, tx)
 		oldKeys := map[string]struct{}{}
 		if oldContent.Valid && strings.TrimSpace(oldContent.String) != "" {
 			var oc any
 			_ = json.Unmarshal([]byte(oldContent.String), &oc)
-			for _, k := range mediatools.ExtractMediaKeys(oc, cdnBase, allowedPrefix) {
+			for _, k := range mc.ExtractKeys(oc) {
 				oldKeys[k] = struct{}{}
 			}
 		}
 		newKeys := map[string]struct{}{}
-		for _, k := range mediatools.ExtractMediaKeys(newContent, cdnBase, allowedPrefix) {
+		for _, k := range mc.ExtractKeys(newContent) {
 			newKeys[k] = struct{}{}
 		}
 
@@ -793,6 +792,10 @@ func RestoreVersionHandler(db *pgxpool.Pool) gin.HandlerFunc {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
 		}
+		if err := storeDraftStats(ctx, tx, ebookID, newContent); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
 		// Maintain usage flags
 		for k := range newKeys {
 			if _, existed := oldKeys[k]; !existed {
@@ -807,6 +810,8 @@ func RestoreVersionHandler(db *pgxpool.Pool) gin.HandlerFunc {
 			}
 		}
 
+		enqueueWebhook(ctx, tx, webhooks.EventRestored, map[string]any{"ebook_id": ebookID, "version_id": id})
+
 		if err := tx.Commit(ctx); err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
@@ -839,6 +844,8 @@ func DeleteVersionHandler(db *pgxpool.Pool) gin.HandlerFunc {
 		}
 		defer tx.Rollback(ctx)
 
+		mc := loadMediaConfig(ctx, tx)
+
 		// Ensure version belongs to our ebook and capture s3 key BEFORE deleting rows
 		var kind, key, ebookID string
 		if err := tx.QueryRow(ctx, `SELECT ev.kind, ev.s3_key, ev.ebook_id
@@ -877,9 +884,9 @@ func DeleteVersionHandler(db *pgxpool.Pool) gin.HandlerFunc {
 						ttlMin = n
 					}
 				}
-				_, _ = tx.Exec(ctx, `INSERT INTO ebook_media_pending_deletion(media_key,requested_at,not_before,attempts,last_checked_at)
-					VALUES ($1, now(), now() + ($2::int * interval '1 minute'), 0, NULL)
-					ON CONFLICT (media_key) DO UPDATE SET requested_at=now(), not_before=now() + ($2::int * interval '1 minute')`, mk, ttlMin)
+				_, _ = tx.Exec(ctx, `INSERT INTO ebook_media_pending_deletion(media_key,media_bucket,requested_at,not_before,attempts,last_checked_at)
+					VALUES ($1, $3, now(), now() + ($2::int * interval '1 minute'), 0, NULL)
+					ON CONFLICT (media_key) DO UPDATE SET media_bucket=EXCLUDED.media_bucket, requested_at=now(), not_before=now() + ($2::int * interval '1 minute')`, mk, ttlMin, mc.Bucket)
 			}
 		}
 		// Remove mapping rows then the version row (explicitly for both kinds)
@@ -892,6 +899,8 @@ func DeleteVersionHandler(db *pgxpool.Pool) gin.HandlerFunc {
 			return
 		}
 
+		enqueueWebhook(ctx, tx, webhooks.EventVersionDeleted, map[string]any{"ebook_id": ebookID, "version_id": id, "kind": kind})
+
 		if err := tx.Commit(ctx); err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
@@ -917,8 +926,19 @@ func PublishFromManualVersionHandler(db *pgxpool.Pool) gin.HandlerFunc {
 		}
 		var req struct {
 			Label string `json:"label"`
+			// RolloutPercent stages the release: readers in this share (and beta readers) see it first;
+			// 0 = beta readers only, default 100 = everyone
+			RolloutPercent *int `json:"rollout_percent"`
 		}
 		_ = c.ShouldBindJSON(&req)
+		percent := 100
+		if req.RolloutPercent != nil {
+			percent = *req.RolloutPercent
+		}
+		if percent < 0 || percent > 100 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "rollout_percent must be between 0 and 100"})
+			return
+		}
 		ctx, cancel := context.WithTimeout(c.Request.Context(), 20*time.Second)
 		defer cancel()
 
@@ -967,26 +987,28 @@ func PublishFromManualVersionHandler(db *pgxpool.Pool) gin.HandlerFunc {
 			lbl = &s
 		}
 		var newID string
-		if err := tx.QueryRow(ctx, `INSERT INTO ebook_versions(ebook_id, kind, s3_key, label) VALUES ($1,'published',$2,$3) RETURNING id`, ebookID, pubKey, lbl).Scan(&newID); err != nil {
+		if err := tx.QueryRow(ctx, `INSERT INTO ebook_versions(ebook_id, kind, s3_key, label, rollout_percent) VALUES ($1,'published',$2,$3,$4) RETURNING id`, ebookID, pubKey, lbl, percent).Scan(&newID); err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
 		}
-
-		cdnBase := os.Getenv("ASSETS_CDN_BASE_URL")
-		if cdnBase == "" {
-			cdnBase = "https://assets.expotoworld.com"
+		if _, err := storeVersionStats(ctx, tx, newID, content); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
 		}
-		allowedPrefix := "ebooks/huashangdao/"
-		for _, mk := range mediatools.ExtractMediaKeys(content, cdnBase, allowedPrefix) {
+
+		mc := loadMediaConfig(ctx, tx)
+		for _, mk := range mc.ExtractKeys(content) {
 			_, _ = tx.Exec(ctx, `INSERT INTO ebook_media_usage(media_key,published_refs,last_seen_at) VALUES ($1,1,now()) ON CONFLICT (media_key) DO UPDATE SET published_refs=ebook_media_usage.published_refs+1,last_seen_at=now()`, mk)
 			_, _ = tx.Exec(ctx, `INSERT INTO ebook_version_media(version_id,media_key) VALUES ($1,$2) ON CONFLICT DO NOTHING`, newID, mk)
 		}
 
+		enqueueWebhook(ctx, tx, webhooks.EventPublished, map[string]any{"ebook_id": ebookID, "version_id": newID, "source_version_id": id, "label": lbl, "rollout_percent": percent})
+
 		if err := tx.Commit(ctx); err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
 		}
-		c.JSON(http.StatusOK, gin.H{"status": "published", "id": newID})
+		c.JSON(http.StatusOK, gin.H{"status": "published", "id": newID, "rollout_percent": percent})
 	}
 }
 
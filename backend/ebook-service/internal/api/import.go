@@ -0,0 +1,156 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	objectstore "github.com/expotoworld/expotoworld/backend/common/storage"
+	"github.com/expotoworld/expotoworld/backend/ebook-service/internal/docimport"
+	"github.com/expotoworld/expotoworld/backend/ebook-service/internal/storage"
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var unsafeFileChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// ImportEbookHandler handles POST /api/ebook/:slug/import (multipart field "file", .docx or .md).
+// The document is converted to editor blocks with embedded images uploaded to the ebook's media prefix.
+// mode=draft (default) replaces the autosave draft, keeping the previous draft as a manual version;
+// mode=manual stores the import as a new manual version and leaves the draft untouched.
+func ImportEbookHandler(db *pgxpool.Pool, mediaStores *storage.Media) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		slug := strings.TrimSpace(c.Param("slug"))
+		mode := strings.ToLower(strings.TrimSpace(c.DefaultPostForm("mode", c.DefaultQuery("mode", "draft"))))
+		if mode != "draft" && mode != "manual" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "mode must be draft or manual"})
+			return
+		}
+
+		maxMB := 25
+		if s := strings.TrimSpace(os.Getenv("EBOOK_IMPORT_MAX_MB")); s != "" {
+			if n, e := strconv.Atoi(s); e == nil && n > 0 {
+				maxMB = n
+			}
+		}
+		file, header, err := c.Request.FormFile("file")
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "No file provided"})
+			return
+		}
+		defer file.Close()
+		if header.Size > int64(maxMB)<<20 {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": fmt.Sprintf("file exceeds %d MB", maxMB)})
+			return
+		}
+		data, err := io.ReadAll(io.LimitReader(file, int64(maxMB)<<20+1))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read file"})
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 2*time.Minute)
+		defer cancel()
+
+		if _, err := resolveEbookID(ctx, db, slug); err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "ebook not found"})
+			} else {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			}
+			return
+		}
+		uploader, _ := storage.NewS3Uploader(ctx)
+		if !uploader.Enabled() {
+			c.JSON(http.StatusFailedDependency, gin.H{"error": "s3 not configured"})
+			return
+		}
+
+		// Images are uploaded before the transaction; unreferenced uploads are reclaimed by media cleanup
+		mc := loadMediaConfigFor(ctx, db, slug)
+		media := mediaStores.For(mc)
+		upload := func(ctx context.Context, img docimport.Image) (string, error) {
+			name := unsafeFileChars.ReplaceAllString(strings.TrimSuffix(img.Name, filepath.Ext(img.Name)), "-")
+			key := mc.ObjectKey("images", fmt.Sprintf("%d-%s%s", time.Now().UnixNano(), strings.Trim(name, "-"), strings.ToLower(filepath.Ext(img.Name))))
+			if err := media.PutObject(ctx, key, bytes.NewReader(img.Data), objectstore.ObjectMeta{ContentType: img.ContentType}); err != nil {
+				return "", err
+			}
+			_, _ = db.Exec(ctx, `INSERT INTO ebook_media_assets(media_key, file_type, mime_type, file_size, created_at, updated_at)
+				VALUES ($1,'image',$2,$3, now(), now())
+				ON CONFLICT (media_key) DO UPDATE SET file_type='image', mime_type=EXCLUDED.mime_type, file_size=EXCLUDED.file_size, updated_at=now()`,
+				key, img.ContentType, int64(len(img.Data)))
+			return media.PublicURL(key), nil
+		}
+
+		result, err := docimport.Convert(ctx, header.Filename, data, upload)
+		if err != nil {
+			status := http.StatusUnprocessableEntity
+			if errors.Is(err, docimport.ErrUnsupportedFormat) {
+				status = http.StatusBadRequest
+			}
+			c.JSON(status, gin.H{"error": err.Error()})
+			return
+		}
+
+		tx, err := db.Begin(ctx)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		defer tx.Rollback(ctx)
+
+		var ebookID, oldContent string
+		if err := tx.QueryRow(ctx, `SELECT id, COALESCE(content,'{}'::jsonb)::text FROM ebooks WHERE slug=$1 FOR UPDATE`, slug).Scan(&ebookID, &oldContent); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		label := strings.TrimSpace(c.PostForm("label"))
+		if label == "" {
+			label = "Import: " + filepath.Base(header.Filename)
+		}
+		resp := gin.H{"mode": mode, "import": result}
+		if mode == "manual" {
+			versionID, err := createManualVersion(ctx, tx, uploader, mc, ebookID, result.Doc, &label)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+			resp["version_id"] = versionID
+		} else {
+			// Keep the draft being replaced so the import can be undone via restore
+			if s := strings.TrimSpace(oldContent); s != "" && s != "{}" {
+				var prev any
+				_ = json.Unmarshal([]byte(oldContent), &prev)
+				backup := "Before import: " + filepath.Base(header.Filename)
+				versionID, err := createManualVersion(ctx, tx, uploader, mc, ebookID, prev, &backup)
+				if err != nil {
+					c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+					return
+				}
+				resp["backup_version_id"] = versionID
+			}
+			if err := saveDraftContent(ctx, tx, mc, ebookID, oldContent, result.Doc); err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+		}
+
+		if err := tx.Commit(ctx); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, resp)
+	}
+}
@@ -1,120 +1,52 @@
 package api
 
 import (
-	"log"
-	"net/http"
-	"os"
-	"strings"
+	"context"
+	"errors"
 
+	"github.com/expotoworld/expotoworld/backend/common/auth"
 	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt/v5"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
 )
 
+// authConfig verifies auth-service tokens with the shared middleware
+var authConfig = auth.Config{}
+
+// CheckTokenVersions makes the auth middleware reject tokens revoked since they were issued (forced
+// logout, sign-in method change); call it before setting up the routes
+func CheckTokenVersions(versions auth.VersionChecker) {
+	authConfig.Versions = versions
+}
+
+// TokenVersions reads users' access token versions from app_users (owned by auth-service)
+func TokenVersions(db *pgxpool.Pool) auth.VersionFunc {
+	return func(ctx context.Context, userID string) (int, error) {
+		var version int
+		err := db.QueryRow(ctx, `SELECT token_version FROM app_users WHERE id::text = $1`, userID).Scan(&version)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, auth.ErrTokenRevoked
+		}
+		return version, err
+	}
+}
+
 // JWTOptionalMiddleware parses JWT if present but does not enforce it
 func JWTOptionalMiddleware() gin.HandlerFunc {
-	return func(c *gin.Context) {
-		secret := os.Getenv("JWT_SECRET")
-		auth := c.GetHeader("Authorization")
-		if len(auth) > 7 && auth[:7] == "Bearer " && secret != "" {
-			tokStr := auth[7:]
-			if token, err := jwt.Parse(tokStr, func(token *jwt.Token) (interface{}, error) {
-				return []byte(secret), nil
-			}); err == nil && token != nil && token.Valid {
-				if claims, ok := token.Claims.(jwt.MapClaims); ok {
-					if v, ok := claims["user_id"]; ok {
-						c.Set("user_id", v)
-					}
-					if v, ok := claims["email"]; ok {
-						c.Set("email", v)
-					}
-					if v, ok := claims["role"].(string); ok {
-						c.Set("role", v)
-					}
-				}
-			}
-		}
-		c.Next()
-	}
+	return authConfig.Optional()
 }
 
 // JWTMiddleware requires a valid JWT
 func JWTMiddleware() gin.HandlerFunc {
-	return func(c *gin.Context) {
-		secret := os.Getenv("JWT_SECRET")
-		auth := c.GetHeader("Authorization")
-		if secret == "" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "missing or invalid token", "detail": "server JWT secret not configured"})
-			c.Abort()
-			return
-		}
-		if len(auth) <= 7 || auth[:7] != "Bearer " {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "missing or invalid token", "detail": "authorization header missing or malformed"})
-			c.Abort()
-			return
-		}
-		// Debug: log presence and short prefix of token (dev only)
-		if len(auth) > 20 {
-			log.Printf("[JWT] Authorization header present, token prefix: %s...", auth[7:27])
-		}
-		tokStr := auth[7:]
-		token, err := jwt.Parse(tokStr, func(token *jwt.Token) (interface{}, error) {
-			// Accept only HMAC-signed tokens
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, jwt.ErrSignatureInvalid
-			}
-			return []byte(secret), nil
-		})
-		if err != nil || token == nil || !token.Valid {
-			msg := "invalid token"
-			if err != nil {
-				msg = err.Error()
-			}
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token", "detail": msg})
-			c.Abort()
-			return
-		}
-		if claims, ok := token.Claims.(jwt.MapClaims); ok {
-			if v, ok := claims["user_id"]; ok {
-				c.Set("user_id", v)
-			}
-			if v, ok := claims["email"]; ok {
-				c.Set("email", v)
-			}
-			if v, ok := claims["role"].(string); ok {
-				c.Set("role", v)
-			}
-		}
-		c.Next()
-	}
+	return authConfig.Required()
 }
 
-// RequireJWT ensures a token was parsed by JWTOptionalMiddleware and is present
+// RequireJWT ensures a token was parsed by JWTOptionalMiddleware and identifies a user
 func RequireJWT() gin.HandlerFunc {
-	return func(c *gin.Context) {
-		if _, ok := c.Get("user_id"); !ok {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "authentication required"})
-			c.Abort()
-			return
-		}
-		c.Next()
-	}
+	return authConfig.RequireUser()
 }
 
 // RequireAuthor ensures role=Author (case-insensitive)
 func RequireAuthor() gin.HandlerFunc {
-	return func(c *gin.Context) {
-		v, ok := c.Get("role")
-		if !ok {
-			c.JSON(http.StatusForbidden, gin.H{"error": "author role required"})
-			c.Abort()
-			return
-		}
-		roleStr, _ := v.(string)
-		if !strings.EqualFold(roleStr, "Author") {
-			c.JSON(http.StatusForbidden, gin.H{"error": "author role required"})
-			c.Abort()
-			return
-		}
-		c.Next()
-	}
+	return authConfig.RequireRole("Author")
 }
@@ -0,0 +1,78 @@
+package api
+
+import (
+	"crypto/subtle"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Published reading is served through CloudFront. The distribution adds a secret custom origin header
+// to every request it forwards, so requests reaching the origin without it bypassed the CDN and its
+// access rules (WAF, geo and rate limits) and are rejected.
+
+// originHeaderName is the header CloudFront sets on origin requests (EBOOK_ORIGIN_HEADER, default X-Origin-Verify)
+func originHeaderName() string {
+	if h := strings.TrimSpace(os.Getenv("EBOOK_ORIGIN_HEADER")); h != "" {
+		return h
+	}
+	return "X-Origin-Verify"
+}
+
+// originSecrets reads EBOOK_ORIGIN_SECRETS, a comma-separated list so a new secret can be added to the
+// origin before CloudFront switches to it and the old one removed afterwards
+func originSecrets() [][]byte {
+	var secrets [][]byte
+	for _, s := range strings.Split(os.Getenv("EBOOK_ORIGIN_SECRETS"), ",") {
+		if s = strings.TrimSpace(s); s != "" {
+			secrets = append(secrets, []byte(s))
+		}
+	}
+	return secrets
+}
+
+// OriginVerifyMiddleware rejects requests that did not come through the CDN. Without EBOOK_ORIGIN_SECRETS
+// (local development, or before the distribution is set up) every request is let through.
+func OriginVerifyMiddleware() gin.HandlerFunc {
+	header := originHeaderName()
+	secrets := originSecrets()
+	if len(secrets) == 0 {
+		log.Printf("[EBOOK] EBOOK_ORIGIN_SECRETS not set; published reading API accepts direct requests")
+		return func(c *gin.Context) { c.Next() }
+	}
+	return func(c *gin.Context) {
+		got := []byte(c.GetHeader(header))
+		for _, s := range secrets {
+			if subtle.ConstantTimeCompare(got, s) == 1 {
+				c.Next()
+				return
+			}
+		}
+		c.JSON(http.StatusForbidden, gin.H{"error": "direct origin access not allowed"})
+		c.Abort()
+	}
+}
+
+// Cache policies of the published reading API. Responses depend on the reader (rollout cohort, own
+// reading state), so only the reader's client may keep them and the CDN passes them through; reading
+// data is never stored at all.
+const (
+	CachePublishedList = "private, max-age=60, stale-while-revalidate=300"
+	CacheReaderData    = "private, no-store"
+	CacheNone          = "no-store"
+)
+
+// CacheControl sets the Cache-Control header of a route. Private responses also vary by Authorization
+// so no intermediary serves one reader's response to another.
+func CacheControl(policy string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Header("Cache-Control", policy)
+		if strings.HasPrefix(policy, "private") {
+			c.Header("Vary", "Authorization")
+		}
+		c.Next()
+	}
+}
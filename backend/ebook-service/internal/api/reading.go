@@ -0,0 +1,439 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Reader sync: one reading position per user and ebook plus any number of bookmarks.
+// Every write carries the client's updated_at; the newest write wins, so a device that was offline
+// cannot overwrite a position saved later on another device. Client clocks ahead of the server are
+// clamped to server time so a skewed device cannot win every future conflict.
+
+type readingProgress struct {
+	Position        json.RawMessage `json:"position"`
+	Percent         *float64        `json:"percent,omitempty"`
+	VersionID       *string         `json:"version_id,omitempty"`
+	DeviceID        *string         `json:"device_id,omitempty"`
+	ClientUpdatedAt time.Time       `json:"updated_at"`
+	ServerUpdatedAt time.Time       `json:"server_updated_at"`
+}
+
+type bookmark struct {
+	ID              string          `json:"id"`
+	Position        json.RawMessage `json:"position"`
+	Note            *string         `json:"note,omitempty"`
+	Deleted         bool            `json:"deleted,omitempty"`
+	ClientUpdatedAt time.Time       `json:"updated_at"`
+	ServerUpdatedAt time.Time       `json:"server_updated_at"`
+}
+
+type progressReq struct {
+	Position  json.RawMessage `json:"position"`
+	Percent   *float64        `json:"percent"`
+	VersionID *string         `json:"version_id"`
+	DeviceID  *string         `json:"device_id"`
+	UpdatedAt *time.Time      `json:"updated_at"`
+}
+
+type bookmarkReq struct {
+	Position  json.RawMessage `json:"position"`
+	Note      *string         `json:"note"`
+	UpdatedAt *time.Time      `json:"updated_at"`
+}
+
+// readerUserID returns the caller's user id from the JWT claims set by the middleware.
+func readerUserID(c *gin.Context) (string, bool) {
+	v, ok := c.Get("user_id")
+	if !ok || v == nil {
+		return "", false
+	}
+	id := strings.TrimSpace(fmt.Sprint(v))
+	return id, id != ""
+}
+
+// clientTimestamp returns the write's conflict timestamp: the client's, clamped to now, or now if absent.
+func clientTimestamp(t *time.Time) time.Time {
+	now := time.Now()
+	if t == nil || t.IsZero() || t.After(now) {
+		return now
+	}
+	return *t
+}
+
+// resolveEbookID maps an ebook slug to its id.
+func resolveEbookID(ctx context.Context, db *pgxpool.Pool, slug string) (string, error) {
+	var id string
+	err := db.QueryRow(ctx, `SELECT id::text FROM ebooks WHERE slug=$1`, slug).Scan(&id)
+	return id, err
+}
+
+// readerContext extracts user and ebook for reader routes, writing the error response on failure.
+func readerContext(ctx context.Context, c *gin.Context, db *pgxpool.Pool) (userID, ebookID string, ok bool) {
+	userID, ok = readerUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "authentication required"})
+		return "", "", false
+	}
+	ebookID, err := resolveEbookID(ctx, db, strings.TrimSpace(c.Param("slug")))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "ebook not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return "", "", false
+	}
+	return userID, ebookID, true
+}
+
+func getProgress(q pgx.Row) (*readingProgress, error) {
+	var p readingProgress
+	var position []byte
+	if err := q.Scan(&position, &p.Percent, &p.VersionID, &p.DeviceID, &p.ClientUpdatedAt, &p.ServerUpdatedAt); err != nil {
+		return nil, err
+	}
+	p.Position = position
+	return &p, nil
+}
+
+const progressColumns = `position, percent::float8, version_id::text, device_id, client_updated_at, updated_at`
+const bookmarkColumns = `bookmark_id, position, note, deleted, client_updated_at, updated_at`
+
+func scanBookmarks(rows pgx.Rows) ([]bookmark, error) {
+	defer rows.Close()
+	items := []bookmark{}
+	for rows.Next() {
+		var b bookmark
+		var position []byte
+		if err := rows.Scan(&b.ID, &position, &b.Note, &b.Deleted, &b.ClientUpdatedAt, &b.ServerUpdatedAt); err != nil {
+			return nil, err
+		}
+		b.Position = position
+		items = append(items, b)
+	}
+	return items, rows.Err()
+}
+
+// GetReadingStateHandler returns the caller's position and bookmarks for an ebook.
+// With ?since=RFC3339 only bookmarks changed after that time are returned, including deletions.
+func GetReadingStateHandler(db *pgxpool.Pool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+		defer cancel()
+		userID, ebookID, ok := readerContext(ctx, c, db)
+		if !ok {
+			return
+		}
+		var since *time.Time
+		if s := strings.TrimSpace(c.Query("since")); s != "" {
+			t, err := time.Parse(time.RFC3339, s)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "since must be RFC3339"})
+				return
+			}
+			since = &t
+		}
+
+		progress, err := getProgress(db.QueryRow(ctx, `SELECT `+progressColumns+` FROM ebook_reading_progress WHERE user_id=$1 AND ebook_id=$2`, userID, ebookID))
+		if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		rows, err := db.Query(ctx, `SELECT `+bookmarkColumns+` FROM ebook_bookmarks
+			WHERE user_id=$1 AND ebook_id=$2 AND (($3::timestamptz IS NULL AND NOT deleted) OR updated_at > $3)
+			ORDER BY client_updated_at`, userID, ebookID, since)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		bookmarks, err := scanBookmarks(rows)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"ebook_id": ebookID, "progress": progress, "bookmarks": bookmarks, "server_time": time.Now()})
+	}
+}
+
+// PutReadingProgressHandler stores the caller's position unless a newer one is already saved.
+// The response always carries the winning position and "applied" tells the client whether it was theirs.
+func PutReadingProgressHandler(db *pgxpool.Pool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req progressReq
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid JSON"})
+			return
+		}
+		if len(req.Position) == 0 || string(req.Position) == "null" || !json.Valid(req.Position) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "position is required"})
+			return
+		}
+		if req.Percent != nil && (*req.Percent < 0 || *req.Percent > 100) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "percent must be between 0 and 100"})
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+		defer cancel()
+		userID, ebookID, ok := readerContext(ctx, c, db)
+		if !ok {
+			return
+		}
+
+		progress, err := getProgress(db.QueryRow(ctx, `INSERT INTO ebook_reading_progress(user_id, ebook_id, position, percent, version_id, device_id, client_updated_at)
+			VALUES ($1,$2,$3::jsonb,$4,$5::uuid,$6,$7)
+			ON CONFLICT (user_id, ebook_id) DO UPDATE SET position=EXCLUDED.position, percent=EXCLUDED.percent,
+				version_id=EXCLUDED.version_id, device_id=EXCLUDED.device_id, client_updated_at=EXCLUDED.client_updated_at, updated_at=now()
+			WHERE ebook_reading_progress.client_updated_at < EXCLUDED.client_updated_at
+			RETURNING `+progressColumns, userID, ebookID, string(req.Position), req.Percent, req.VersionID, req.DeviceID, clientTimestamp(req.UpdatedAt)))
+		applied := true
+		if errors.Is(err, pgx.ErrNoRows) {
+			// A newer position is already stored; hand it back so the client can jump there
+			applied = false
+			progress, err = getProgress(db.QueryRow(ctx, `SELECT `+progressColumns+` FROM ebook_reading_progress WHERE user_id=$1 AND ebook_id=$2`, userID, ebookID))
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"applied": applied, "progress": progress})
+	}
+}
+
+// writeBookmark upserts a bookmark (or its tombstone) with last-writer-wins semantics.
+func writeBookmark(c *gin.Context, db *pgxpool.Pool, position json.RawMessage, note *string, deleted bool, updatedAt *time.Time) {
+	bookmarkID := strings.TrimSpace(c.Param("bookmark_id"))
+	if bookmarkID == "" || len(bookmarkID) > 100 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid bookmark id"})
+		return
+	}
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+	userID, ebookID, ok := readerContext(ctx, c, db)
+	if !ok {
+		return
+	}
+
+	pos := "{}"
+	if len(position) > 0 {
+		pos = string(position)
+	}
+	rows, err := db.Query(ctx, `INSERT INTO ebook_bookmarks(user_id, ebook_id, bookmark_id, position, note, deleted, client_updated_at)
+		VALUES ($1,$2,$3,$4::jsonb,$5,$6,$7)
+		ON CONFLICT (user_id, ebook_id, bookmark_id) DO UPDATE SET
+			position=CASE WHEN EXCLUDED.deleted THEN ebook_bookmarks.position ELSE EXCLUDED.position END,
+			note=CASE WHEN EXCLUDED.deleted THEN ebook_bookmarks.note ELSE EXCLUDED.note END,
+			deleted=EXCLUDED.deleted, client_updated_at=EXCLUDED.client_updated_at, updated_at=now()
+		WHERE ebook_bookmarks.client_updated_at < EXCLUDED.client_updated_at
+		RETURNING `+bookmarkColumns, userID, ebookID, bookmarkID, pos, note, deleted, clientTimestamp(updatedAt))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	items, err := scanBookmarks(rows)
+	applied := len(items) == 1
+	if err == nil && !applied {
+		rows, err = db.Query(ctx, `SELECT `+bookmarkColumns+` FROM ebook_bookmarks WHERE user_id=$1 AND ebook_id=$2 AND bookmark_id=$3`, userID, ebookID, bookmarkID)
+		if err == nil {
+			items, err = scanBookmarks(rows)
+		}
+	}
+	if err != nil || len(items) == 0 {
+		if err == nil {
+			err = errors.New("bookmark not found after write")
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"applied": applied, "bookmark": items[0]})
+}
+
+// PutBookmarkHandler creates or updates a bookmark; ids are chosen by the client so devices can sync offline.
+func PutBookmarkHandler(db *pgxpool.Pool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req bookmarkReq
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid JSON"})
+			return
+		}
+		if len(req.Position) == 0 || string(req.Position) == "null" || !json.Valid(req.Position) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "position is required"})
+			return
+		}
+		writeBookmark(c, db, req.Position, req.Note, false, req.UpdatedAt)
+	}
+}
+
+// DeleteBookmarkHandler deletes a bookmark by writing a tombstone (?updated_at=RFC3339 for offline deletes).
+func DeleteBookmarkHandler(db *pgxpool.Pool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var updatedAt *time.Time
+		if s := strings.TrimSpace(c.Query("updated_at")); s != "" {
+			t, err := time.Parse(time.RFC3339, s)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "updated_at must be RFC3339"})
+				return
+			}
+			updatedAt = &t
+		}
+		writeBookmark(c, db, nil, nil, true, updatedAt)
+	}
+}
+
+// ExportReadingDataHandler returns all reading data stored for the caller (GDPR access/portability).
+func ExportReadingDataHandler(db *pgxpool.Pool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, ok := readerUserID(c)
+		if !ok {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "authentication required"})
+			return
+		}
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+		defer cancel()
+
+		rows, err := db.Query(ctx, `SELECT ebook_id::text, `+progressColumns+` FROM ebook_reading_progress WHERE user_id=$1 ORDER BY ebook_id`, userID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		defer rows.Close()
+		progress := []gin.H{}
+		for rows.Next() {
+			var ebookID string
+			var p readingProgress
+			var position []byte
+			if err := rows.Scan(&ebookID, &position, &p.Percent, &p.VersionID, &p.DeviceID, &p.ClientUpdatedAt, &p.ServerUpdatedAt); err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+			p.Position = position
+			progress = append(progress, gin.H{"ebook_id": ebookID, "progress": p})
+		}
+		rows.Close()
+
+		bmRows, err := db.Query(ctx, `SELECT `+bookmarkColumns+` FROM ebook_bookmarks WHERE user_id=$1 AND NOT deleted ORDER BY ebook_id, client_updated_at`, userID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		bookmarks, err := scanBookmarks(bmRows)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"user_id": userID, "reading_progress": progress, "bookmarks": bookmarks, "exported_at": time.Now()})
+	}
+}
+
+// eraseReadingData removes every reading position and bookmark (including tombstones) of a user.
+func eraseReadingData(ctx context.Context, db *pgxpool.Pool, userID string) (int64, int64, error) {
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer tx.Rollback(ctx)
+	p, err := tx.Exec(ctx, `DELETE FROM ebook_reading_progress WHERE user_id=$1`, userID)
+	if err != nil {
+		return 0, 0, err
+	}
+	b, err := tx.Exec(ctx, `DELETE FROM ebook_bookmarks WHERE user_id=$1`, userID)
+	if err != nil {
+		return 0, 0, err
+	}
+	return p.RowsAffected(), b.RowsAffected(), tx.Commit(ctx)
+}
+
+// EraseReadingDataHandler deletes the caller's reading data (GDPR erasure, self-service).
+func EraseReadingDataHandler(db *pgxpool.Pool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, ok := readerUserID(c)
+		if !ok {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "authentication required"})
+			return
+		}
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+		defer cancel()
+		p, b, err := eraseReadingData(ctx, db, userID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		log.Printf("[EBOOK] Erased reading data for user %s (progress=%d bookmarks=%d)", userID, p, b)
+		c.JSON(http.StatusOK, gin.H{"status": "erased", "progress_deleted": p, "bookmarks_deleted": b})
+	}
+}
+
+// AdminEraseReadingDataHandler deletes a user's reading data on behalf of a GDPR erasure request.
+func AdminEraseReadingDataHandler(db *pgxpool.Pool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := strings.TrimSpace(c.Param("user_id"))
+		if userID == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "user_id required"})
+			return
+		}
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+		defer cancel()
+		p, b, err := eraseReadingData(ctx, db, userID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		actor, _ := readerUserID(c)
+		log.Printf("[EBOOK] Admin %s erased reading data for user %s (progress=%d bookmarks=%d)", actor, userID, p, b)
+		c.JSON(http.StatusOK, gin.H{"status": "erased", "user_id": userID, "progress_deleted": p, "bookmarks_deleted": b})
+	}
+}
+
+// RunReadingRetention periodically purges reading data untouched for EBOOK_READING_RETENTION_DAYS
+// (default 730; 0 disables) and bookmark tombstones older than 90 days, which every device has synced by then.
+func RunReadingRetention(ctx context.Context, db *pgxpool.Pool) {
+	days, _ := strconv.Atoi(os.Getenv("EBOOK_READING_RETENTION_DAYS"))
+	if os.Getenv("EBOOK_READING_RETENTION_DAYS") == "" {
+		days = 730
+	}
+	purge := func() {
+		c, cancel := context.WithTimeout(ctx, 60*time.Second)
+		defer cancel()
+		var p, b int64
+		if days > 0 {
+			if tag, err := db.Exec(c, `DELETE FROM ebook_reading_progress WHERE updated_at < now() - make_interval(days => $1)`, days); err != nil {
+				log.Printf("[EBOOK] Reading retention (progress) failed: %v", err)
+			} else {
+				p = tag.RowsAffected()
+			}
+		}
+		if tag, err := db.Exec(c, `DELETE FROM ebook_bookmarks WHERE (deleted AND updated_at < now() - interval '90 days') OR ($1 > 0 AND updated_at < now() - make_interval(days => $1))`, days); err != nil {
+			log.Printf("[EBOOK] Reading retention (bookmarks) failed: %v", err)
+		} else {
+			b = tag.RowsAffected()
+		}
+		if p > 0 || b > 0 {
+			log.Printf("[EBOOK] Reading retention purged progress=%d bookmarks=%d", p, b)
+		}
+	}
+
+	purge()
+	ticker := time.NewTicker(24 * time.Hour)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			purge()
+		}
+	}
+}
@@ -0,0 +1,175 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/expotoworld/expotoworld/backend/ebook-service/internal/webhooks"
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// rolloutVisibleSQL filters versions (alias ev) to those the reader with user id $4 may see: anything not
+// published, and published versions that are not rolled back and are fully rolled out, or the reader is a
+// beta reader, or the reader's stable bucket for that version (0-99, hashed from version and user id) falls
+// under rollout_percent. Raising the percentage only ever adds readers.
+const rolloutVisibleSQL = `(ev.kind <> 'published' OR (ev.rolled_back_at IS NULL AND (
+	ev.rollout_percent >= 100
+	OR EXISTS (SELECT 1 FROM ebook_beta_readers br WHERE br.user_id = $4)
+	OR mod(abs(hashtext(ev.id::text || ':' || $4)::bigint), 100) < ev.rollout_percent)))`
+
+// isAuthor reports whether the caller has role=Author (case-insensitive)
+func isAuthor(c *gin.Context) bool {
+	v, _ := c.Get("role")
+	role, _ := v.(string)
+	return strings.EqualFold(role, "Author")
+}
+
+// setRollout updates a published version's rollout and emits ebook.rollout.changed.
+// percent nil keeps the current share; rollback hides the version from every reader.
+func setRollout(ctx context.Context, db *pgxpool.Pool, id string, percent *int, rollback bool, action, actor string) (int, error) {
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	var ebookID string
+	var newPercent int
+	err = tx.QueryRow(ctx, `UPDATE ebook_versions ev
+		SET rollout_percent = COALESCE($2, ev.rollout_percent),
+			rolled_back_at = CASE WHEN $3 THEN now() ELSE NULL END
+		FROM ebooks e
+		WHERE ev.ebook_id = e.id AND e.slug = 'main' AND ev.id = $1 AND ev.kind = 'published'
+		RETURNING ev.ebook_id, ev.rollout_percent`, id, percent, rollback).Scan(&ebookID, &newPercent)
+	if err != nil {
+		return 0, err
+	}
+	enqueueWebhook(ctx, tx, webhooks.EventRolloutChanged, map[string]any{
+		"ebook_id": ebookID, "version_id": id, "action": action, "rollout_percent": newPercent, "rolled_back": rollback, "actor": actor,
+	})
+	return newPercent, tx.Commit(ctx)
+}
+
+func writeRollout(c *gin.Context, db *pgxpool.Pool, percent *int, rollback bool, action string) {
+	id := strings.TrimSpace(c.Param("id"))
+	if id == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "id required"})
+		return
+	}
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+	actor, _ := readerUserID(c)
+	newPercent, err := setRollout(ctx, db, id, percent, rollback, action, actor)
+	if err != nil {
+		// Unknown ids, manual versions and malformed UUIDs all end up here
+		c.JSON(http.StatusNotFound, gin.H{"error": "published version not found"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": action, "id": id, "rollout_percent": newPercent, "rolled_back": rollback})
+}
+
+// PutVersionRolloutHandler changes the share of readers a published version is rolled out to.
+// It also resumes a rolled back version.
+func PutVersionRolloutHandler(db *pgxpool.Pool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req struct {
+			RolloutPercent *int `json:"rollout_percent"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil || req.RolloutPercent == nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "rollout_percent required"})
+			return
+		}
+		if *req.RolloutPercent < 0 || *req.RolloutPercent > 100 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "rollout_percent must be between 0 and 100"})
+			return
+		}
+		writeRollout(c, db, req.RolloutPercent, false, "updated")
+	}
+}
+
+// PromoteVersionHandler rolls a published version out to every reader.
+func PromoteVersionHandler(db *pgxpool.Pool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		full := 100
+		writeRollout(c, db, &full, false, "promoted")
+	}
+}
+
+// RollbackVersionHandler hides a published version from every reader, who fall back to the newest
+// earlier version still rolled out to them.
+func RollbackVersionHandler(db *pgxpool.Pool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		writeRollout(c, db, nil, true, "rolled_back")
+	}
+}
+
+// ListBetaReadersHandler lists the beta reader group, who see staged versions first.
+func ListBetaReadersHandler(db *pgxpool.Pool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+		defer cancel()
+		rows, err := db.Query(ctx, `SELECT user_id, note, added_by, added_at FROM ebook_beta_readers ORDER BY added_at ASC`)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		defer rows.Close()
+		items := []gin.H{}
+		for rows.Next() {
+			var userID string
+			var note, addedBy *string
+			var addedAt time.Time
+			if err := rows.Scan(&userID, &note, &addedBy, &addedAt); err != nil {
+				continue
+			}
+			items = append(items, gin.H{"user_id": userID, "note": note, "added_by": addedBy, "added_at": addedAt})
+		}
+		c.JSON(http.StatusOK, gin.H{"items": items})
+	}
+}
+
+// PutBetaReaderHandler adds a user to the beta reader group (or updates the note).
+func PutBetaReaderHandler(db *pgxpool.Pool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := strings.TrimSpace(c.Param("user_id"))
+		if userID == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "user_id required"})
+			return
+		}
+		var req struct {
+			Note *string `json:"note"`
+		}
+		_ = c.ShouldBindJSON(&req)
+		actor, _ := readerUserID(c)
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+		defer cancel()
+		if _, err := db.Exec(ctx, `INSERT INTO ebook_beta_readers(user_id, note, added_by) VALUES ($1,$2,NULLIF($3,''))
+			ON CONFLICT (user_id) DO UPDATE SET note=EXCLUDED.note`, userID, req.Note, actor); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"status": "added", "user_id": userID})
+	}
+}
+
+// DeleteBetaReaderHandler removes a user from the beta reader group.
+func DeleteBetaReaderHandler(db *pgxpool.Pool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := strings.TrimSpace(c.Param("user_id"))
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+		defer cancel()
+		cmd, err := db.Exec(ctx, `DELETE FROM ebook_beta_readers WHERE user_id=$1`, userID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if cmd.RowsAffected() == 0 {
+			c.JSON(http.StatusNotFound, gin.H{"error": "beta reader not found"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"status": "removed"})
+	}
+}
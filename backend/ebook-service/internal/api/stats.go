@@ -0,0 +1,156 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/expotoworld/expotoworld/backend/ebook-service/internal/contentstats"
+	"github.com/expotoworld/expotoworld/backend/ebook-service/internal/storage"
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type execer interface {
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+}
+
+// storeVersionStats computes and stores the content statistics of a version
+func storeVersionStats(ctx context.Context, ex execer, versionID string, content any) (contentstats.Stats, error) {
+	st := contentstats.Compute(content)
+	b, _ := json.Marshal(st)
+	_, err := ex.Exec(ctx, `UPDATE ebook_versions SET word_count=$2, content_stats=$3::jsonb WHERE id=$1`, versionID, st.Words, string(b))
+	return st, err
+}
+
+// storeDraftStats computes and stores the content statistics of an ebook's autosave content
+func storeDraftStats(ctx context.Context, ex execer, ebookID string, content any) error {
+	b, _ := json.Marshal(contentstats.Compute(content))
+	_, err := ex.Exec(ctx, `UPDATE ebooks SET content_stats=$2::jsonb WHERE id=$1`, ebookID, string(b))
+	return err
+}
+
+type versionStatsItem struct {
+	ID                   string    `json:"id"`
+	Kind                 string    `json:"kind"`
+	Label                *string   `json:"label,omitempty"`
+	CreatedAt            time.Time `json:"created_at"`
+	Words                *int      `json:"words"` // nil until the version's stats are computed (see GetVersionStatsHandler)
+	Characters           *int      `json:"characters"`
+	CharactersWithSpaces *int      `json:"characters_with_spaces"`
+	Images               *int      `json:"images"`
+	Chapters             *int      `json:"chapters"`
+}
+
+// GetEbookStatsHandler returns the draft's statistics and the totals of each version, oldest first,
+// so editorial can follow manuscript growth.
+func GetEbookStatsHandler(db *pgxpool.Pool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		kind := strings.TrimSpace(c.Query("kind"))
+		limit, _ := strconv.Atoi(c.DefaultQuery("limit", "100"))
+		if limit <= 0 || limit > 1000 {
+			limit = 100
+		}
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+		defer cancel()
+
+		var draftRaw []byte
+		if err := db.QueryRow(ctx, `SELECT content_stats::text FROM ebooks WHERE slug='main'`).Scan(&draftRaw); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		var draft *contentstats.Stats
+		if len(draftRaw) > 0 {
+			draft = &contentstats.Stats{}
+			_ = json.Unmarshal(draftRaw, draft)
+		}
+
+		// Latest versions, returned in chronological order
+		rows, err := db.Query(ctx,
+			`SELECT id, kind, label, created_at, words, characters, characters_with_spaces, images, chapters FROM (
+				SELECT ev.id, ev.kind, ev.label, ev.created_at, ev.word_count AS words,
+					(ev.content_stats->>'characters')::int AS characters,
+					(ev.content_stats->>'characters_with_spaces')::int AS characters_with_spaces,
+					(ev.content_stats->>'images')::int AS images,
+					jsonb_array_length(ev.content_stats->'chapters') AS chapters
+				FROM ebook_versions ev
+				JOIN ebooks e ON e.id = ev.ebook_id
+				WHERE e.slug='main' AND ($1='' OR ev.kind=$1)
+				ORDER BY ev.created_at DESC
+				LIMIT $2
+			) v ORDER BY created_at`, kind, limit)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		defer rows.Close()
+
+		items := []versionStatsItem{}
+		for rows.Next() {
+			var v versionStatsItem
+			if err := rows.Scan(&v.ID, &v.Kind, &v.Label, &v.CreatedAt, &v.Words, &v.Characters, &v.CharactersWithSpaces, &v.Images, &v.Chapters); err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+			items = append(items, v)
+		}
+		if err := rows.Err(); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"draft": draft, "versions": items})
+	}
+}
+
+// GetVersionStatsHandler returns the per-chapter statistics of a version. Versions saved before
+// statistics existed are computed from their S3 JSON on first request and stored.
+func GetVersionStatsHandler(db *pgxpool.Pool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := strings.TrimSpace(c.Param("id"))
+		if id == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "id required"})
+			return
+		}
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+		defer cancel()
+
+		var kind, key string
+		var label *string
+		var createdAt time.Time
+		var statsRaw []byte
+		if err := db.QueryRow(ctx, `SELECT ev.kind, ev.label, ev.created_at, ev.s3_key, ev.content_stats::text
+			FROM ebook_versions ev
+			JOIN ebooks e ON e.id=ev.ebook_id
+			WHERE e.slug='main' AND ev.id=$1`, id).Scan(&kind, &label, &createdAt, &key, &statsRaw); err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "version not found"})
+			return
+		}
+
+		var st contentstats.Stats
+		if len(statsRaw) > 0 {
+			_ = json.Unmarshal(statsRaw, &st)
+		} else {
+			u, _ := storage.NewS3Uploader(ctx)
+			if !u.Enabled() {
+				c.JSON(http.StatusFailedDependency, gin.H{"error": "s3 not configured"})
+				return
+			}
+			b, err := u.GetJSON(ctx, key)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+			var content any
+			_ = json.Unmarshal(b, &content)
+			if st, err = storeVersionStats(ctx, db, id, content); err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+		}
+		c.JSON(http.StatusOK, gin.H{"id": id, "kind": kind, "label": label, "created_at": createdAt, "stats": st})
+	}
+}
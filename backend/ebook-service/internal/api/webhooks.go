@@ -0,0 +1,240 @@
+package api
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/expotoworld/expotoworld/backend/ebook-service/internal/webhooks"
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type webhookEndpointReq struct {
+	URL         string   `json:"url"`
+	Secret      string   `json:"secret"`
+	Events      []string `json:"events"`
+	Description *string  `json:"description"`
+	Active      *bool    `json:"active"`
+}
+
+// validateWebhookReq checks the URL and event names, returning a client-facing error message.
+func validateWebhookReq(req *webhookEndpointReq) string {
+	u, err := url.Parse(strings.TrimSpace(req.URL))
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+		return "url must be an absolute http(s) URL"
+	}
+	req.URL = u.String()
+	events := []string{}
+	for _, e := range req.Events {
+		e = strings.TrimSpace(e)
+		if e == "" {
+			continue
+		}
+		if !webhooks.ValidEvent(e) {
+			return "unknown event: " + e
+		}
+		events = append(events, e)
+	}
+	req.Events = events
+	return ""
+}
+
+func newWebhookSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return "whsec_" + hex.EncodeToString(b), nil
+}
+
+// ListWebhookEndpointsHandler lists configured endpoints (secrets are never returned here).
+func ListWebhookEndpointsHandler(db *pgxpool.Pool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+		defer cancel()
+		rows, err := db.Query(ctx, `SELECT id, url, events, description, active, created_at, updated_at FROM ebook_webhook_endpoints ORDER BY created_at ASC`)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		defer rows.Close()
+		items := []gin.H{}
+		for rows.Next() {
+			var id, u string
+			var events []string
+			var desc *string
+			var active bool
+			var createdAt, updatedAt time.Time
+			if err := rows.Scan(&id, &u, &events, &desc, &active, &createdAt, &updatedAt); err != nil {
+				continue
+			}
+			items = append(items, gin.H{"id": id, "url": u, "events": events, "description": desc, "active": active, "created_at": createdAt, "updated_at": updatedAt})
+		}
+		c.JSON(http.StatusOK, gin.H{"items": items, "available_events": webhooks.Events})
+	}
+}
+
+// CreateWebhookEndpointHandler registers an endpoint. The signing secret is returned only once.
+func CreateWebhookEndpointHandler(db *pgxpool.Pool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req webhookEndpointReq
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid JSON"})
+			return
+		}
+		if msg := validateWebhookReq(&req); msg != "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+			return
+		}
+		secret := strings.TrimSpace(req.Secret)
+		if secret == "" {
+			s, err := newWebhookSecret()
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate secret"})
+				return
+			}
+			secret = s
+		}
+		active := true
+		if req.Active != nil {
+			active = *req.Active
+		}
+
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+		defer cancel()
+		var id string
+		if err := db.QueryRow(ctx, `INSERT INTO ebook_webhook_endpoints(url, secret, events, description, active) VALUES ($1,$2,$3,$4,$5) RETURNING id`,
+			req.URL, secret, req.Events, req.Description, active).Scan(&id); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusCreated, gin.H{"id": id, "url": req.URL, "events": req.Events, "active": active, "secret": secret})
+	}
+}
+
+// UpdateWebhookEndpointHandler replaces url/events and optionally description, active flag and secret.
+func UpdateWebhookEndpointHandler(db *pgxpool.Pool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := strings.TrimSpace(c.Param("id"))
+		var req webhookEndpointReq
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid JSON"})
+			return
+		}
+		if msg := validateWebhookReq(&req); msg != "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+			return
+		}
+		var secret *string
+		if s := strings.TrimSpace(req.Secret); s != "" {
+			secret = &s
+		}
+
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+		defer cancel()
+		cmd, err := db.Exec(ctx, `UPDATE ebook_webhook_endpoints
+			SET url=$2, events=$3, description=COALESCE($4, description), active=COALESCE($5, active), secret=COALESCE($6, secret), updated_at=now()
+			WHERE id=$1`, id, req.URL, req.Events, req.Description, req.Active, secret)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if cmd.RowsAffected() == 0 {
+			c.JSON(http.StatusNotFound, gin.H{"error": "endpoint not found"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"status": "updated"})
+	}
+}
+
+// DeleteWebhookEndpointHandler removes an endpoint and its delivery log.
+func DeleteWebhookEndpointHandler(db *pgxpool.Pool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := strings.TrimSpace(c.Param("id"))
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+		defer cancel()
+		cmd, err := db.Exec(ctx, `DELETE FROM ebook_webhook_endpoints WHERE id=$1`, id)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if cmd.RowsAffected() == 0 {
+			c.JSON(http.StatusNotFound, gin.H{"error": "endpoint not found"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"status": "deleted"})
+	}
+}
+
+// ListWebhookDeliveriesHandler returns the delivery log, optionally filtered by endpoint_id and status.
+func ListWebhookDeliveriesHandler(db *pgxpool.Pool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+		if limit <= 0 || limit > 200 {
+			limit = 50
+		}
+		offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+		if offset < 0 {
+			offset = 0
+		}
+		var endpointID, status *string
+		if s := strings.TrimSpace(c.Query("endpoint_id")); s != "" {
+			endpointID = &s
+		}
+		if s := strings.TrimSpace(c.Query("status")); s != "" {
+			status = &s
+		}
+
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+		defer cancel()
+		rows, err := db.Query(ctx, `SELECT id, endpoint_id, event, status, attempts, response_code, last_error, next_attempt_at, delivered_at, created_at
+			FROM ebook_webhook_deliveries
+			WHERE ($1::uuid IS NULL OR endpoint_id=$1) AND ($2::text IS NULL OR status=$2)
+			ORDER BY created_at DESC LIMIT $3 OFFSET $4`, endpointID, status, limit, offset)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		defer rows.Close()
+		items := []gin.H{}
+		for rows.Next() {
+			var id, epID, event, st string
+			var attempts int
+			var code *int
+			var lastErr *string
+			var nextAt time.Time
+			var deliveredAt *time.Time
+			var createdAt time.Time
+			if err := rows.Scan(&id, &epID, &event, &st, &attempts, &code, &lastErr, &nextAt, &deliveredAt, &createdAt); err != nil {
+				continue
+			}
+			items = append(items, gin.H{"id": id, "endpoint_id": epID, "event": event, "status": st, "attempts": attempts, "response_code": code, "last_error": lastErr, "next_attempt_at": nextAt, "delivered_at": deliveredAt, "created_at": createdAt})
+		}
+		c.JSON(http.StatusOK, gin.H{"items": items, "limit": limit, "offset": offset})
+	}
+}
+
+// RedeliverWebhookHandler resets a delivery so the worker sends it again.
+func RedeliverWebhookHandler(db *pgxpool.Pool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := strings.TrimSpace(c.Param("id"))
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+		defer cancel()
+		cmd, err := db.Exec(ctx, `UPDATE ebook_webhook_deliveries SET status='pending', attempts=0, next_attempt_at=now(), updated_at=now() WHERE id=$1`, id)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if cmd.RowsAffected() == 0 {
+			c.JSON(http.StatusNotFound, gin.H{"error": "delivery not found"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"status": "queued"})
+	}
+}
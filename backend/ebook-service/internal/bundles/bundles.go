@@ -0,0 +1,408 @@
+package bundles
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/expotoworld/expotoworld/backend/ebook-service/internal/mediatools"
+	"github.com/expotoworld/expotoworld/backend/ebook-service/internal/storage"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Job statuses.
+const (
+	StatusPending   = "pending"
+	StatusRunning   = "running"
+	StatusCompleted = "completed"
+	StatusFailed    = "failed"
+)
+
+// ManifestFormat identifies the layout of manifest.json inside a bundle.
+const ManifestFormat = "expotoworld-ebook-bundle/1"
+
+const (
+	defaultMaxAttempts  = 3
+	defaultPollInterval = 10 * time.Second
+	// leaseDuration is how long a claimed job stays invisible to other workers;
+	// a job still "running" after that (crashed instance) is picked up again.
+	leaseDuration = 30 * time.Minute
+	keyPrefix     = "bundles/"
+)
+
+// ErrVersionNotFound is returned by Enqueue for unknown versions.
+var ErrVersionNotFound = errors.New("version not found")
+
+// Job is a bundle export request and its outcome.
+type Job struct {
+	ID           string     `json:"id"`
+	VersionID    string     `json:"version_id"`
+	Status       string     `json:"status"`
+	RequestedBy  *string    `json:"requested_by,omitempty"`
+	Attempts     int        `json:"attempts"`
+	S3Key        *string    `json:"s3_key,omitempty"`
+	SizeBytes    *int64     `json:"size_bytes,omitempty"`
+	SHA256       *string    `json:"sha256,omitempty"`
+	FileCount    *int       `json:"file_count,omitempty"`
+	MissingMedia *int       `json:"missing_media,omitempty"`
+	LastError    *string    `json:"last_error,omitempty"`
+	StartedAt    *time.Time `json:"started_at,omitempty"`
+	CompletedAt  *time.Time `json:"completed_at,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
+
+const jobColumns = `id, version_id, status, requested_by, attempts, s3_key, size_bytes, sha256, file_count, missing_media,
+	last_error, started_at, completed_at, created_at, updated_at`
+
+func scanJob(r pgx.Row) (Job, error) {
+	var j Job
+	err := r.Scan(&j.ID, &j.VersionID, &j.Status, &j.RequestedBy, &j.Attempts, &j.S3Key, &j.SizeBytes, &j.SHA256,
+		&j.FileCount, &j.MissingMedia, &j.LastError, &j.StartedAt, &j.CompletedAt, &j.CreatedAt, &j.UpdatedAt)
+	return j, err
+}
+
+// Enqueue queues a bundle of a main-ebook version. An unfinished job for the same
+// version is returned instead of queuing a duplicate; created reports which happened.
+func Enqueue(ctx context.Context, db *pgxpool.Pool, versionID, requestedBy string) (job Job, created bool, err error) {
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		return Job{}, false, err
+	}
+	defer tx.Rollback(ctx)
+
+	// Lock the version row so concurrent requests agree on a single job.
+	var id string
+	if err := tx.QueryRow(ctx, `SELECT ev.id FROM ebook_versions ev
+		JOIN ebooks e ON e.id=ev.ebook_id
+		WHERE e.slug='main' AND ev.id=$1
+		FOR UPDATE OF ev`, versionID).Scan(&id); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return Job{}, false, ErrVersionNotFound
+		}
+		return Job{}, false, err
+	}
+
+	job, err = scanJob(tx.QueryRow(ctx, `SELECT `+jobColumns+` FROM ebook_bundle_jobs
+		WHERE version_id=$1 AND status IN ('pending','running')
+		ORDER BY created_at DESC LIMIT 1`, id))
+	if err == nil {
+		return job, false, nil
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return Job{}, false, err
+	}
+
+	var by *string
+	if requestedBy != "" {
+		by = &requestedBy
+	}
+	job, err = scanJob(tx.QueryRow(ctx, `INSERT INTO ebook_bundle_jobs (version_id, requested_by)
+		VALUES ($1, $2) RETURNING `+jobColumns, id, by))
+	if err != nil {
+		return Job{}, false, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return Job{}, false, err
+	}
+	return job, true, nil
+}
+
+// Get returns a job by id; pgx.ErrNoRows when it does not exist.
+func Get(ctx context.Context, db *pgxpool.Pool, id string) (Job, error) {
+	return scanJob(db.QueryRow(ctx, `SELECT `+jobColumns+` FROM ebook_bundle_jobs WHERE id=$1`, id))
+}
+
+// ListForVersion returns a version's jobs, newest first.
+func ListForVersion(ctx context.Context, db *pgxpool.Pool, versionID string, limit int) ([]Job, error) {
+	rows, err := db.Query(ctx, `SELECT `+jobColumns+` FROM ebook_bundle_jobs
+		WHERE version_id=$1 ORDER BY created_at DESC LIMIT $2`, versionID, limit)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, func(r pgx.CollectableRow) (Job, error) { return scanJob(r) })
+}
+
+// FileName is the download name of a job's ZIP.
+func FileName(j Job) string {
+	return fmt.Sprintf("ebook-version-%s.zip", j.VersionID)
+}
+
+// Manifest is written as manifest.json, the last entry of every bundle.
+type Manifest struct {
+	Format       string          `json:"format"`
+	JobID        string          `json:"job_id"`
+	GeneratedAt  time.Time       `json:"generated_at"`
+	Ebook        ManifestEbook   `json:"ebook"`
+	Version      ManifestVersion `json:"version"`
+	MediaBucket  string          `json:"media_bucket"`
+	Files        []ManifestFile  `json:"files"`
+	MissingMedia []string        `json:"missing_media"`
+}
+
+// ManifestEbook identifies the ebook a bundle belongs to.
+type ManifestEbook struct {
+	ID   string `json:"id"`
+	Slug string `json:"slug"`
+}
+
+// ManifestVersion describes the bundled version.
+type ManifestVersion struct {
+	ID        string    `json:"id"`
+	Kind      string    `json:"kind"`
+	Label     *string   `json:"label,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	S3Key     string    `json:"s3_key"`
+}
+
+// ManifestFile describes one bundle entry; SHA256 is over the uncompressed bytes.
+type ManifestFile struct {
+	Path        string `json:"path"`
+	Source      string `json:"source"`
+	Size        int64  `json:"size"`
+	SHA256      string `json:"sha256"`
+	ContentType string `json:"content_type,omitempty"`
+}
+
+// Worker builds queued bundles one at a time.
+type Worker struct {
+	DB           *pgxpool.Pool
+	MaxAttempts  int
+	PollInterval time.Duration
+}
+
+// NewWorkerFromEnv builds a worker configured by EBOOK_BUNDLE_MAX_ATTEMPTS and EBOOK_BUNDLE_POLL_SEC.
+func NewWorkerFromEnv(db *pgxpool.Pool) *Worker {
+	w := &Worker{DB: db, MaxAttempts: defaultMaxAttempts, PollInterval: defaultPollInterval}
+	if n, err := strconv.Atoi(strings.TrimSpace(os.Getenv("EBOOK_BUNDLE_MAX_ATTEMPTS"))); err == nil && n > 0 {
+		w.MaxAttempts = n
+	}
+	if n, err := strconv.Atoi(strings.TrimSpace(os.Getenv("EBOOK_BUNDLE_POLL_SEC"))); err == nil && n > 0 {
+		w.PollInterval = time.Duration(n) * time.Second
+	}
+	return w
+}
+
+// Run polls for due jobs until ctx is cancelled.
+func (w *Worker) Run(ctx context.Context) {
+	if w == nil || w.DB == nil {
+		return
+	}
+	log.Printf("[EBOOK] Bundle worker started (poll=%s, max_attempts=%d)", w.PollInterval, w.MaxAttempts)
+	t := time.NewTicker(w.PollInterval)
+	defer t.Stop()
+	for {
+		// Drain the queue before sleeping again.
+		for {
+			ok, err := w.processNext(ctx)
+			if err != nil {
+				log.Printf("[EBOOK] bundle worker: %v", err)
+			}
+			if !ok || ctx.Err() != nil {
+				break
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+	}
+}
+
+// processNext claims and builds a single due job; ok is false when none was due.
+func (w *Worker) processNext(ctx context.Context) (bool, error) {
+	qctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	var id, versionID string
+	var attempts int
+	err := w.DB.QueryRow(qctx, `
+		UPDATE ebook_bundle_jobs
+		SET status='running', attempts=attempts+1, started_at=now(), updated_at=now(),
+			next_attempt_at=now() + ($1::int * interval '1 second')
+		WHERE id = (
+			SELECT id FROM ebook_bundle_jobs
+			WHERE status IN ('pending','running') AND next_attempt_at <= now()
+			ORDER BY next_attempt_at
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING id, version_id, attempts`, int(leaseDuration.Seconds())).Scan(&id, &versionID, &attempts)
+	cancel()
+	if errors.Is(err, pgx.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("claim job: %w", err)
+	}
+
+	bctx, cancel := context.WithTimeout(ctx, leaseDuration)
+	res, buildErr := w.build(bctx, id, versionID)
+	cancel()
+
+	uctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if buildErr == nil {
+		log.Printf("[EBOOK] bundle %s for version %s completed (%d files, %d bytes, %d missing media)",
+			id, versionID, res.files, res.size, res.missing)
+		_, err = w.DB.Exec(uctx, `UPDATE ebook_bundle_jobs
+			SET status='completed', s3_key=$2, size_bytes=$3, sha256=$4, file_count=$5, missing_media=$6,
+				last_error=NULL, completed_at=now(), updated_at=now()
+			WHERE id=$1`, id, res.key, res.size, res.sha256, res.files, res.missing)
+		return true, err
+	}
+	if attempts >= w.MaxAttempts {
+		log.Printf("[EBOOK] bundle %s for version %s failed permanently after %d attempts: %v", id, versionID, attempts, buildErr)
+		_, err = w.DB.Exec(uctx, `UPDATE ebook_bundle_jobs
+			SET status='failed', last_error=$2, completed_at=now(), updated_at=now()
+			WHERE id=$1`, id, buildErr.Error())
+		return true, err
+	}
+	log.Printf("[EBOOK] bundle %s attempt %d failed, will retry: %v", id, attempts, buildErr)
+	_, err = w.DB.Exec(uctx, `UPDATE ebook_bundle_jobs
+		SET status='pending', last_error=$2, next_attempt_at=now() + ($3::int * interval '1 second'), updated_at=now()
+		WHERE id=$1`, id, buildErr.Error(), attempts*60)
+	return true, err
+}
+
+type buildResult struct {
+	key     string
+	size    int64
+	sha256  string
+	files   int
+	missing int
+}
+
+// build writes the ZIP to a temp file and uploads it to EBOOK_S3_BUCKET.
+func (w *Worker) build(ctx context.Context, jobID, versionID string) (buildResult, error) {
+	u, err := storage.NewS3Uploader(ctx)
+	if err != nil {
+		return buildResult{}, fmt.Errorf("s3 config: %w", err)
+	}
+	if !u.Enabled() {
+		return buildResult{}, fmt.Errorf("s3 not configured")
+	}
+
+	m := Manifest{Format: ManifestFormat, JobID: jobID, GeneratedAt: time.Now().UTC(), Files: []ManifestFile{}, MissingMedia: []string{}}
+	var label, bucket, prefix sql.NullString
+	if err := w.DB.QueryRow(ctx, `SELECT ev.id, ev.kind, ev.label, ev.created_at, ev.s3_key, e.id, e.slug, e.media_bucket, e.media_prefix
+		FROM ebook_versions ev
+		JOIN ebooks e ON e.id=ev.ebook_id
+		WHERE ev.id=$1`, versionID).Scan(&m.Version.ID, &m.Version.Kind, &label, &m.Version.CreatedAt, &m.Version.S3Key,
+		&m.Ebook.ID, &m.Ebook.Slug, &bucket, &prefix); err != nil {
+		return buildResult{}, fmt.Errorf("load version: %w", err)
+	}
+	if label.Valid {
+		m.Version.Label = &label.String
+	}
+	mc := mediatools.ConfigFromEnv().WithOverrides(bucket.String, prefix.String)
+	m.MediaBucket = mc.Bucket
+
+	raw, err := u.GetJSON(ctx, m.Version.S3Key)
+	if err != nil {
+		return buildResult{}, fmt.Errorf("load version content: %w", err)
+	}
+	var content any
+	if err := json.Unmarshal(raw, &content); err != nil {
+		return buildResult{}, fmt.Errorf("decode version content: %w", err)
+	}
+	keys := mc.ExtractKeys(content)
+	sort.Strings(keys)
+
+	tmp, err := os.CreateTemp("", "ebook-bundle-*.zip")
+	if err != nil {
+		return buildResult{}, err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	zw := zip.NewWriter(tmp)
+	f, err := addFile(zw, "content.json", bytes.NewReader(raw), m.GeneratedAt)
+	if err != nil {
+		return buildResult{}, err
+	}
+	f.Source = fmt.Sprintf("s3://%s/%s", u.Bucket, m.Version.S3Key)
+	f.ContentType = "application/json"
+	m.Files = append(m.Files, f)
+
+	for _, key := range keys {
+		body, contentType, err := u.OpenObject(ctx, mc.Bucket, key)
+		if err != nil {
+			// Assets deleted from the bucket are listed rather than failing the whole archive.
+			log.Printf("[EBOOK] bundle %s: media %s unavailable: %v", jobID, key, err)
+			m.MissingMedia = append(m.MissingMedia, key)
+			continue
+		}
+		f, err := addFile(zw, "media/"+strings.TrimPrefix(key, mc.Prefix), body, m.GeneratedAt)
+		body.Close()
+		if err != nil {
+			return buildResult{}, fmt.Errorf("add media %s: %w", key, err)
+		}
+		f.Source = fmt.Sprintf("s3://%s/%s", mc.Bucket, key)
+		f.ContentType = contentType
+		m.Files = append(m.Files, f)
+	}
+
+	mb, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return buildResult{}, err
+	}
+	if _, err := addFile(zw, "manifest.json", bytes.NewReader(mb), m.GeneratedAt); err != nil {
+		return buildResult{}, err
+	}
+	if err := zw.Close(); err != nil {
+		return buildResult{}, fmt.Errorf("finish zip: %w", err)
+	}
+
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		return buildResult{}, err
+	}
+	h := sha256.New()
+	size, err := io.Copy(h, tmp)
+	if err != nil {
+		return buildResult{}, err
+	}
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		return buildResult{}, err
+	}
+	key := keyPrefix + versionID + "/" + jobID + ".zip"
+	if err := u.PutFile(ctx, key, tmp, size, "application/zip"); err != nil {
+		return buildResult{}, fmt.Errorf("upload bundle: %w", err)
+	}
+	return buildResult{
+		key:     key,
+		size:    size,
+		sha256:  hex.EncodeToString(h.Sum(nil)),
+		files:   len(m.Files),
+		missing: len(m.MissingMedia),
+	}, nil
+}
+
+// addFile writes one entry and returns its manifest record (path, size, checksum).
+func addFile(zw *zip.Writer, name string, r io.Reader, modified time.Time) (ManifestFile, error) {
+	name = path.Clean(name)
+	fw, err := zw.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: modified})
+	if err != nil {
+		return ManifestFile{}, fmt.Errorf("zip %s: %w", name, err)
+	}
+	h := sha256.New()
+	n, err := io.Copy(io.MultiWriter(fw, h), r)
+	if err != nil {
+		return ManifestFile{}, fmt.Errorf("zip %s: %w", name, err)
+	}
+	return ManifestFile{Path: name, Size: n, SHA256: hex.EncodeToString(h.Sum(nil))}, nil
+}
@@ -0,0 +1,167 @@
+// Package contentstats counts words, characters and images in the editor's TipTap JSON, per chapter.
+// A chapter starts at each top-level level-1 heading; content before the first one is front matter.
+package contentstats
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Chapter holds the counts of one chapter. Characters excludes whitespace;
+// CharactersWithSpaces counts every character of the text, with one space between blocks.
+type Chapter struct {
+	Index                int    `json:"index"`
+	Title                string `json:"title"`
+	Words                int    `json:"words"`
+	Characters           int    `json:"characters"`
+	CharactersWithSpaces int    `json:"characters_with_spaces"`
+	Images               int    `json:"images"`
+}
+
+// Stats are the totals of a document plus its chapter breakdown
+type Stats struct {
+	Words                int       `json:"words"`
+	Characters           int       `json:"characters"`
+	CharactersWithSpaces int       `json:"characters_with_spaces"`
+	Images               int       `json:"images"`
+	Chapters             []Chapter `json:"chapters"`
+}
+
+// Compute counts a TipTap document (as decoded from JSON). Anything that is not a doc node yields empty stats.
+func Compute(doc any) Stats {
+	st := Stats{Chapters: []Chapter{}}
+	root, _ := doc.(map[string]any)
+	blocks, _ := root["content"].([]any)
+
+	var cur *chapterText
+	flush := func() {
+		if cur == nil {
+			return
+		}
+		ch := cur.chapter(len(st.Chapters))
+		// Front matter only counts when it has content
+		if cur.heading || ch.Words > 0 || ch.Images > 0 {
+			st.Chapters = append(st.Chapters, ch)
+			st.Words += ch.Words
+			st.Characters += ch.Characters
+			st.CharactersWithSpaces += ch.CharactersWithSpaces
+			st.Images += ch.Images
+		}
+		cur = nil
+	}
+	for _, b := range blocks {
+		n, _ := b.(map[string]any)
+		if n == nil {
+			continue
+		}
+		if isChapterHeading(n) {
+			flush()
+			cur = &chapterText{heading: true}
+			cur.title = strings.TrimSpace(plainText(n))
+		} else if cur == nil {
+			cur = &chapterText{}
+		}
+		cur.addBlock(n)
+	}
+	flush()
+	return st
+}
+
+func isChapterHeading(n map[string]any) bool {
+	if n["type"] != "heading" {
+		return false
+	}
+	attrs, _ := n["attrs"].(map[string]any)
+	level, _ := attrs["level"].(float64)
+	if lv, ok := attrs["level"].(int); ok {
+		level = float64(lv)
+	}
+	return level == 1
+}
+
+// chapterText accumulates the text and images of one chapter
+type chapterText struct {
+	heading bool
+	title   string
+	text    strings.Builder
+	images  int
+}
+
+func (c *chapterText) addBlock(n map[string]any) {
+	if c.text.Len() > 0 {
+		c.text.WriteByte(' ')
+	}
+	c.walk(n)
+}
+
+func (c *chapterText) walk(n map[string]any) {
+	switch n["type"] {
+	case "text":
+		s, _ := n["text"].(string)
+		c.text.WriteString(s)
+		return
+	case "hardBreak":
+		c.text.WriteByte(' ')
+		return
+	case "image":
+		c.images++
+	}
+	children, _ := n["content"].([]any)
+	for i, ch := range children {
+		cn, _ := ch.(map[string]any)
+		if cn == nil {
+			continue
+		}
+		// Nested blocks (list items, blockquote paragraphs) are separated like top-level ones
+		if i > 0 && cn["type"] != "text" && cn["type"] != "hardBreak" {
+			c.text.WriteByte(' ')
+		}
+		c.walk(cn)
+	}
+}
+
+func (c *chapterText) chapter(index int) Chapter {
+	s := c.text.String()
+	words, chars, all := countText(s)
+	return Chapter{Index: index, Title: c.title, Words: words, Characters: chars, CharactersWithSpaces: all, Images: c.images}
+}
+
+// plainText returns the concatenated text of a node
+func plainText(n map[string]any) string {
+	var c chapterText
+	c.walk(n)
+	return c.text.String()
+}
+
+// countText counts words, non-space characters and all characters. Words are runs of letters, digits
+// and joiners (apostrophes, hyphens inside a word); every Han, Hiragana or Katakana character counts as
+// a word of its own, since those scripts are written without spaces.
+func countText(s string) (words, chars, all int) {
+	inWord := false
+	prevJoin := false
+	for _, r := range s {
+		all++
+		if unicode.IsSpace(r) {
+			inWord = false
+			continue
+		}
+		chars++
+		switch {
+		case unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana):
+			words++
+			inWord = false
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.IsMark(r):
+			if !inWord {
+				words++
+				inWord = true
+			}
+			prevJoin = false
+		case inWord && !prevJoin && (r == '\'' || r == '’' || r == '-'):
+			prevJoin = true
+		default:
+			inWord = false
+			prevJoin = false
+		}
+	}
+	return words, chars, all
+}
@@ -0,0 +1,162 @@
+// Package docimport converts manuscripts (DOCX, Markdown) into the editor's TipTap block JSON.
+package docimport
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+)
+
+// ErrUnsupportedFormat is returned for files that are neither DOCX nor Markdown
+var ErrUnsupportedFormat = errors.New("unsupported file format (expected .docx or .md)")
+
+// Image is an embedded image found in the source document
+type Image struct {
+	Name        string
+	ContentType string
+	Data        []byte
+}
+
+// Uploader stores an extracted image and returns the URL the document should reference
+type Uploader func(ctx context.Context, img Image) (string, error)
+
+// Result is a converted document. Doc is a TipTap "doc" node ready to be stored as ebook content.
+type Result struct {
+	Doc      map[string]any `json:"-"`
+	Format   string         `json:"format"`
+	Blocks   int            `json:"blocks"`
+	Images   int            `json:"images"`
+	Warnings []string       `json:"warnings"`
+}
+
+// Convert picks the converter from the file extension
+func Convert(ctx context.Context, filename string, data []byte, upload Uploader) (*Result, error) {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".docx":
+		return FromDOCX(ctx, data, upload)
+	case ".md", ".markdown":
+		return FromMarkdown(ctx, data, upload)
+	}
+	return nil, ErrUnsupportedFormat
+}
+
+// builder accumulates blocks plus image/warning bookkeeping shared by both converters
+type builder struct {
+	ctx      context.Context
+	upload   Uploader
+	images   int
+	warnings []string
+	warned   map[string]bool
+}
+
+func newBuilder(ctx context.Context, upload Uploader) *builder {
+	return &builder{ctx: ctx, upload: upload, warned: map[string]bool{}}
+}
+
+// warn records a warning once
+func (b *builder) warn(msg string) {
+	if !b.warned[msg] {
+		b.warned[msg] = true
+		b.warnings = append(b.warnings, msg)
+	}
+}
+
+func (b *builder) result(format string, blocks []any) *Result {
+	if len(blocks) == 0 {
+		blocks = []any{node("paragraph", nil, nil)}
+	}
+	warnings := b.warnings
+	if warnings == nil {
+		warnings = []string{}
+	}
+	return &Result{
+		Doc:      node("doc", nil, blocks),
+		Format:   format,
+		Blocks:   len(blocks),
+		Images:   b.images,
+		Warnings: warnings,
+	}
+}
+
+// node builds a TipTap node; attrs and content are omitted when empty
+func node(typ string, attrs map[string]any, content []any) map[string]any {
+	n := map[string]any{"type": typ}
+	if len(attrs) > 0 {
+		n["attrs"] = attrs
+	}
+	if len(content) > 0 {
+		n["content"] = content
+	}
+	return n
+}
+
+// textNode builds a text node carrying the given marks (bold, italic, link, ...)
+func textNode(text string, marks []map[string]any) map[string]any {
+	n := map[string]any{"type": "text", "text": text}
+	if len(marks) > 0 {
+		ms := make([]any, len(marks))
+		for i, m := range marks {
+			ms[i] = m
+		}
+		n["marks"] = ms
+	}
+	return n
+}
+
+func mark(typ string) map[string]any { return map[string]any{"type": typ} }
+
+func linkMark(href string) map[string]any {
+	return map[string]any{"type": "link", "attrs": map[string]any{"href": href}}
+}
+
+// withMark returns a copy of marks with m appended
+func withMark(marks []map[string]any, m map[string]any) []map[string]any {
+	out := make([]map[string]any, 0, len(marks)+1)
+	out = append(out, marks...)
+	return append(out, m)
+}
+
+// mergeText joins adjacent text nodes with identical marks and drops empty ones
+func mergeText(nodes []any) []any {
+	out := make([]any, 0, len(nodes))
+	for _, n := range nodes {
+		m, ok := n.(map[string]any)
+		if ok && m["type"] == "text" {
+			if m["text"] == "" {
+				continue
+			}
+			if len(out) > 0 {
+				if prev, ok := out[len(out)-1].(map[string]any); ok && prev["type"] == "text" && sameMarks(prev["marks"], m["marks"]) {
+					prev["text"] = prev["text"].(string) + m["text"].(string)
+					continue
+				}
+			}
+		}
+		out = append(out, n)
+	}
+	return out
+}
+
+func sameMarks(a, b any) bool {
+	as, _ := a.([]any)
+	bs, _ := b.([]any)
+	if len(as) != len(bs) {
+		return false
+	}
+	for i := range as {
+		am, _ := as[i].(map[string]any)
+		bm, _ := bs[i].(map[string]any)
+		if am["type"] != bm["type"] {
+			return false
+		}
+		if am["type"] == "link" {
+			aa, _ := am["attrs"].(map[string]any)
+			ba, _ := bm["attrs"].(map[string]any)
+			if aa["href"] != ba["href"] {
+				return false
+			}
+		}
+	}
+	return true
+}
@@ -0,0 +1,532 @@
+package docimport
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"mime"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// maxDOCXPart bounds the uncompressed size of a single DOCX part (guards against zip bombs)
+const maxDOCXPart = 64 << 20
+
+var docxHeadingStyle = regexp.MustCompile(`^heading\s*([1-6])$`)
+
+// xnode is a generic XML element; DOCX parts are small enough to decode fully
+type xnode struct {
+	XMLName xml.Name
+	Attrs   []xml.Attr `xml:",any,attr"`
+	Nodes   []xnode    `xml:",any"`
+	Text    string     `xml:",chardata"`
+}
+
+func (n *xnode) attr(local string) string {
+	for _, a := range n.Attrs {
+		if a.Name.Local == local {
+			return a.Value
+		}
+	}
+	return ""
+}
+
+func (n *xnode) child(local string) *xnode {
+	for i := range n.Nodes {
+		if n.Nodes[i].XMLName.Local == local {
+			return &n.Nodes[i]
+		}
+	}
+	return nil
+}
+
+// find returns the first descendant with the given local name
+func (n *xnode) find(local string) *xnode {
+	for i := range n.Nodes {
+		if n.Nodes[i].XMLName.Local == local {
+			return &n.Nodes[i]
+		}
+		if f := n.Nodes[i].find(local); f != nil {
+			return f
+		}
+	}
+	return nil
+}
+
+// onOff reads a WordprocessingML toggle property such as <w:b/> or <w:b w:val="0"/>
+func onOff(n *xnode) bool {
+	if n == nil {
+		return false
+	}
+	v := n.attr("val")
+	return v == "" || v == "1" || v == "true" || v == "on"
+}
+
+type docxRel struct {
+	Target   string
+	External bool
+}
+
+type docxReader struct {
+	*builder
+	files    map[string]*zip.File
+	rels     map[string]docxRel
+	styles   map[string]string       // styleId -> lower-cased style name
+	ordered  map[string]map[int]bool // numId -> ilvl -> ordered
+	uploaded map[string]string       // media part -> URL
+}
+
+// FromDOCX converts a Word document: headings (by style, language-independent), paragraphs with
+// bold/italic/underline/strike, hyperlinks, line breaks, bullet/numbered lists with nesting, quotes
+// and embedded images (uploaded). Tables are flattened to one paragraph per row.
+func FromDOCX(ctx context.Context, data []byte, upload Uploader) (*Result, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("not a valid DOCX file: %w", err)
+	}
+	d := &docxReader{
+		builder:  newBuilder(ctx, upload),
+		files:    map[string]*zip.File{},
+		rels:     map[string]docxRel{},
+		styles:   map[string]string{},
+		ordered:  map[string]map[int]bool{},
+		uploaded: map[string]string{},
+	}
+	for _, f := range zr.File {
+		d.files[f.Name] = f
+	}
+
+	var doc xnode
+	if err := d.decode("word/document.xml", &doc); err != nil {
+		return nil, fmt.Errorf("not a valid DOCX file: %w", err)
+	}
+	d.loadRels()
+	d.loadStyles()
+	d.loadNumbering()
+
+	body := doc.child("body")
+	if body == nil {
+		return nil, fmt.Errorf("not a valid DOCX file: document has no body")
+	}
+	blocks, err := d.blocks(body.Nodes)
+	if err != nil {
+		return nil, err
+	}
+	return d.result("docx", blocks), nil
+}
+
+func (d *docxReader) read(name string) ([]byte, error) {
+	f, ok := d.files[name]
+	if !ok {
+		return nil, fmt.Errorf("missing %s", name)
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	data, err := io.ReadAll(io.LimitReader(rc, maxDOCXPart+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxDOCXPart {
+		return nil, fmt.Errorf("%s is too large", name)
+	}
+	return data, nil
+}
+
+func (d *docxReader) decode(name string, v any) error {
+	data, err := d.read(name)
+	if err != nil {
+		return err
+	}
+	return xml.Unmarshal(data, v)
+}
+
+func (d *docxReader) loadRels() {
+	var rels xnode
+	if err := d.decode("word/_rels/document.xml.rels", &rels); err != nil {
+		return
+	}
+	for _, r := range rels.Nodes {
+		d.rels[r.attr("Id")] = docxRel{Target: r.attr("Target"), External: r.attr("TargetMode") == "External"}
+	}
+}
+
+func (d *docxReader) loadStyles() {
+	var styles xnode
+	if err := d.decode("word/styles.xml", &styles); err != nil {
+		return
+	}
+	for _, s := range styles.Nodes {
+		if s.XMLName.Local != "style" {
+			continue
+		}
+		if name := s.child("name"); name != nil {
+			d.styles[s.attr("styleId")] = strings.ToLower(name.attr("val"))
+		}
+	}
+}
+
+// loadNumbering records which list levels are numbered (anything but bullets)
+func (d *docxReader) loadNumbering() {
+	var numbering xnode
+	if err := d.decode("word/numbering.xml", &numbering); err != nil {
+		return
+	}
+	abstract := map[string]map[int]bool{}
+	for _, a := range numbering.Nodes {
+		if a.XMLName.Local != "abstractNum" {
+			continue
+		}
+		levels := map[int]bool{}
+		for _, lvl := range a.Nodes {
+			if lvl.XMLName.Local != "lvl" {
+				continue
+			}
+			ilvl, _ := strconv.Atoi(lvl.attr("ilvl"))
+			if f := lvl.child("numFmt"); f != nil {
+				levels[ilvl] = f.attr("val") != "bullet" && f.attr("val") != "none"
+			}
+		}
+		abstract[a.attr("abstractNumId")] = levels
+	}
+	for _, n := range numbering.Nodes {
+		if n.XMLName.Local != "num" {
+			continue
+		}
+		if a := n.child("abstractNumId"); a != nil {
+			d.ordered[n.attr("numId")] = abstract[a.attr("val")]
+		}
+	}
+}
+
+// listLevel tracks an open list while consecutive list paragraphs are grouped
+type listLevel struct {
+	list    map[string]any
+	ordered bool
+}
+
+func (d *docxReader) blocks(nodes []xnode) ([]any, error) {
+	var out []any
+	var stack []listLevel
+
+	for i := range nodes {
+		n := &nodes[i]
+		switch n.XMLName.Local {
+		case "p":
+			para, images, item, err := d.paragraph(n)
+			if err != nil {
+				return nil, err
+			}
+			if item == nil {
+				stack = nil
+				if para != nil {
+					out = append(out, para)
+				}
+				out = append(out, images...)
+				continue
+			}
+			if para == nil {
+				para = node("paragraph", nil, nil)
+			}
+			content := append([]any{para}, images...)
+			stack, out = d.addListItem(stack, out, item, node("listItem", nil, content))
+
+		case "tbl":
+			stack = nil
+			d.warn("tables were converted to plain paragraphs (one per row)")
+			for _, tr := range n.Nodes {
+				if tr.XMLName.Local != "tr" {
+					continue
+				}
+				var cells []string
+				for _, tc := range tr.Nodes {
+					if tc.XMLName.Local == "tc" {
+						cells = append(cells, strings.TrimSpace(plainText(&tc)))
+					}
+				}
+				if row := strings.Join(cells, " | "); strings.Trim(row, " |") != "" {
+					out = append(out, node("paragraph", nil, []any{textNode(row, nil)}))
+				}
+			}
+
+		case "sdt":
+			// Content controls (e.g. a generated table of contents) wrap ordinary paragraphs
+			if c := n.child("sdtContent"); c != nil {
+				inner, err := d.blocks(c.Nodes)
+				if err != nil {
+					return nil, err
+				}
+				stack = nil
+				out = append(out, inner...)
+			}
+		}
+	}
+	return out, nil
+}
+
+type docxListItem struct {
+	level   int
+	ordered bool
+}
+
+// addListItem appends an item at its nesting level, opening/closing (nested) lists as needed
+func (d *docxReader) addListItem(stack []listLevel, out []any, item *docxListItem, li map[string]any) ([]listLevel, []any) {
+	for len(stack) > item.level+1 {
+		stack = stack[:len(stack)-1]
+	}
+	if len(stack) == item.level+1 && stack[item.level].ordered != item.ordered {
+		stack = stack[:item.level]
+	}
+	for len(stack) < item.level+1 {
+		typ := "bulletList"
+		if item.ordered {
+			typ = "orderedList"
+		}
+		list := node(typ, nil, nil)
+		if len(stack) == 0 {
+			out = append(out, list)
+		} else {
+			// Nest inside the last item of the enclosing list
+			parent := stack[len(stack)-1].list
+			items, _ := parent["content"].([]any)
+			if len(items) == 0 {
+				parent["content"] = []any{node("listItem", nil, []any{node("paragraph", nil, nil)})}
+				items = parent["content"].([]any)
+			}
+			last := items[len(items)-1].(map[string]any)
+			lc, _ := last["content"].([]any)
+			last["content"] = append(lc, list)
+		}
+		stack = append(stack, listLevel{list: list, ordered: item.ordered})
+	}
+	top := stack[len(stack)-1].list
+	items, _ := top["content"].([]any)
+	top["content"] = append(items, li)
+	return stack, out
+}
+
+// paragraph converts a w:p. It returns the block (nil if empty), images found in it (block-level in the
+// editor) and list membership when the paragraph is numbered.
+func (d *docxReader) paragraph(p *xnode) (map[string]any, []any, *docxListItem, error) {
+	style := ""
+	var item *docxListItem
+	if ppr := p.child("pPr"); ppr != nil {
+		if ps := ppr.child("pStyle"); ps != nil {
+			style = d.styles[ps.attr("val")]
+			if style == "" {
+				style = strings.ToLower(ps.attr("val"))
+			}
+		}
+		if num := ppr.child("numPr"); num != nil {
+			numID := ""
+			if n := num.child("numId"); n != nil {
+				numID = n.attr("val")
+			}
+			if numID != "" && numID != "0" {
+				level := 0
+				if l := num.child("ilvl"); l != nil {
+					level, _ = strconv.Atoi(l.attr("val"))
+				}
+				if level > 8 {
+					level = 8
+				}
+				item = &docxListItem{level: level, ordered: d.ordered[numID][level]}
+			}
+		}
+	}
+
+	var images []any
+	content, err := d.inline(p.Nodes, nil, &images)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	content = trimBreaks(mergeText(content))
+
+	var block map[string]any
+	switch {
+	case len(content) == 0:
+	case style == "title":
+		block = node("heading", map[string]any{"level": 1}, content)
+	case style == "subtitle":
+		block = node("heading", map[string]any{"level": 2}, content)
+	case docxHeadingStyle.MatchString(style):
+		level, _ := strconv.Atoi(docxHeadingStyle.FindStringSubmatch(style)[1])
+		block = node("heading", map[string]any{"level": level}, content)
+	case style == "quote" || style == "intense quote":
+		block = node("blockquote", nil, []any{node("paragraph", nil, content)})
+	default:
+		block = node("paragraph", nil, content)
+	}
+	if block != nil && block["type"] == "heading" {
+		item = nil
+	}
+	return block, images, item, nil
+}
+
+// inline converts runs (and the containers they appear in) into text nodes with marks
+func (d *docxReader) inline(nodes []xnode, marks []map[string]any, images *[]any) ([]any, error) {
+	var out []any
+	for i := range nodes {
+		n := &nodes[i]
+		switch n.XMLName.Local {
+		case "r":
+			runMarks := marks
+			if rpr := n.child("rPr"); rpr != nil {
+				if onOff(rpr.child("b")) {
+					runMarks = withMark(runMarks, mark("bold"))
+				}
+				if onOff(rpr.child("i")) {
+					runMarks = withMark(runMarks, mark("italic"))
+				}
+				if u := rpr.child("u"); u != nil && u.attr("val") != "none" {
+					runMarks = withMark(runMarks, mark("underline"))
+				}
+				if onOff(rpr.child("strike")) || onOff(rpr.child("dstrike")) {
+					runMarks = withMark(runMarks, mark("strike"))
+				}
+			}
+			for j := range n.Nodes {
+				c := &n.Nodes[j]
+				switch c.XMLName.Local {
+				case "t":
+					out = append(out, textNode(c.Text, runMarks))
+				case "tab":
+					out = append(out, textNode("\t", runMarks))
+				case "br", "cr":
+					if c.attr("type") != "page" {
+						out = append(out, node("hardBreak", nil, nil))
+					}
+				case "drawing", "pict", "object":
+					img, err := d.image(c)
+					if err != nil {
+						return nil, err
+					}
+					if img != nil {
+						*images = append(*images, img)
+					}
+				}
+			}
+
+		case "hyperlink":
+			linkMarks := marks
+			if rel, ok := d.rels[n.attr("id")]; ok && rel.External {
+				linkMarks = withMark(marks, linkMark(rel.Target))
+			}
+			inner, err := d.inline(n.Nodes, linkMarks, images)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, inner...)
+
+		case "ins", "smartTag", "fldSimple", "customXml", "sdt", "sdtContent":
+			// Accepted tracked insertions and wrappers: keep their runs
+			inner, err := d.inline(n.Nodes, marks, images)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, inner...)
+		}
+	}
+	return out, nil
+}
+
+// image uploads the picture referenced by a drawing (DrawingML blip or legacy VML imagedata)
+func (d *docxReader) image(n *xnode) (map[string]any, error) {
+	relID := ""
+	if blip := n.find("blip"); blip != nil {
+		relID = blip.attr("embed")
+		if relID == "" {
+			relID = blip.attr("link")
+		}
+	} else if data := n.find("imagedata"); data != nil {
+		relID = data.attr("id")
+	}
+	rel, ok := d.rels[relID]
+	if !ok {
+		return nil, nil
+	}
+	alt := ""
+	if pr := n.find("docPr"); pr != nil {
+		alt = pr.attr("descr")
+	}
+	if rel.External {
+		return node("image", map[string]any{"src": rel.Target, "alt": alt}, nil), nil
+	}
+
+	part := path.Clean(path.Join("word", rel.Target))
+	if strings.HasPrefix(rel.Target, "/") {
+		part = strings.TrimPrefix(rel.Target, "/")
+	}
+	if url, done := d.uploaded[part]; done {
+		return node("image", map[string]any{"src": url, "alt": alt}, nil), nil
+	}
+	ext := strings.ToLower(path.Ext(part))
+	ct := mime.TypeByExtension(ext)
+	if !strings.HasPrefix(ct, "image/") || ext == ".emf" || ext == ".wmf" || ext == ".tif" || ext == ".tiff" {
+		d.warn(fmt.Sprintf("skipped %s images (not displayable in browsers)", strings.TrimPrefix(ext, ".")))
+		return nil, nil
+	}
+	data, err := d.read(part)
+	if err != nil {
+		d.warn(fmt.Sprintf("image %s is missing from the document", path.Base(part)))
+		return nil, nil
+	}
+	url, err := d.upload(d.ctx, Image{Name: path.Base(part), ContentType: ct, Data: data})
+	if err != nil {
+		return nil, fmt.Errorf("upload image: %w", err)
+	}
+	d.uploaded[part] = url
+	d.images++
+	return node("image", map[string]any{"src": url, "alt": alt}, nil), nil
+}
+
+// plainText concatenates the text of all runs below n
+func plainText(n *xnode) string {
+	var sb strings.Builder
+	var walk func(*xnode)
+	walk = func(x *xnode) {
+		switch x.XMLName.Local {
+		case "t":
+			sb.WriteString(x.Text)
+		case "tab":
+			sb.WriteString(" ")
+		case "p":
+			if sb.Len() > 0 {
+				sb.WriteString(" ")
+			}
+		}
+		for i := range x.Nodes {
+			walk(&x.Nodes[i])
+		}
+	}
+	walk(n)
+	return sb.String()
+}
+
+// trimBreaks drops leading/trailing hard breaks and paragraphs that contain only whitespace
+func trimBreaks(content []any) []any {
+	for len(content) > 0 && isBreak(content[0]) {
+		content = content[1:]
+	}
+	for len(content) > 0 && isBreak(content[len(content)-1]) {
+		content = content[:len(content)-1]
+	}
+	for _, n := range content {
+		if m, ok := n.(map[string]any); ok && (m["type"] != "text" || strings.TrimSpace(m["text"].(string)) != "") {
+			return content
+		}
+	}
+	return nil
+}
+
+func isBreak(n any) bool {
+	m, ok := n.(map[string]any)
+	return ok && m["type"] == "hardBreak"
+}
@@ -0,0 +1,458 @@
+package docimport
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var (
+	mdHeading   = regexp.MustCompile(`^(#{1,6})\s+(.*?)(?:\s+#+)?\s*$`)
+	mdRule      = regexp.MustCompile(`^\s{0,3}(?:(?:\*\s*){3,}|(?:-\s*){3,}|(?:_\s*){3,})$`)
+	mdListItem  = regexp.MustCompile(`^(\s{0,3})([-*+]|\d{1,9}[.)])\s+(.*)$`)
+	mdFence     = regexp.MustCompile("^\\s{0,3}(```+|~~~+)\\s*([\\w+-]*)")
+	mdImageLine = regexp.MustCompile(`^!\[([^\]]*)\]\(\s*(\S+?)(?:\s+"([^"]*)")?\s*\)$`)
+	mdSetextH1  = regexp.MustCompile(`^=+\s*$`)
+	mdSetextH2  = regexp.MustCompile(`^-+\s*$`)
+)
+
+// FromMarkdown converts CommonMark-style Markdown: ATX/setext headings, paragraphs, emphasis, inline code,
+// links, block quotes, nested bullet/ordered lists, fenced code and images. Images given as data: URIs are
+// uploaded; absolute URLs are kept; relative paths cannot be resolved and are reported as warnings.
+func FromMarkdown(ctx context.Context, data []byte, upload Uploader) (*Result, error) {
+	b := newBuilder(ctx, upload)
+	src := strings.ReplaceAll(strings.ReplaceAll(string(data), "\r\n", "\n"), "\t", "    ")
+	src = strings.TrimPrefix(src, "\ufeff")
+	blocks, err := b.mdBlocks(strings.Split(src, "\n"))
+	if err != nil {
+		return nil, err
+	}
+	return b.result("markdown", blocks), nil
+}
+
+func (b *builder) mdBlocks(lines []string) ([]any, error) {
+	var blocks []any
+	var para []string
+
+	flush := func() error {
+		if len(para) == 0 {
+			return nil
+		}
+		nodes, err := b.mdParagraph(para)
+		para = nil
+		if err != nil {
+			return err
+		}
+		blocks = append(blocks, nodes...)
+		return nil
+	}
+
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		trimmed := strings.TrimSpace(line)
+
+		switch {
+		case trimmed == "":
+			if err := flush(); err != nil {
+				return nil, err
+			}
+
+		case mdFence.MatchString(line):
+			if err := flush(); err != nil {
+				return nil, err
+			}
+			m := mdFence.FindStringSubmatch(line)
+			var code []string
+			for i++; i < len(lines) && !strings.HasPrefix(strings.TrimSpace(lines[i]), m[1]); i++ {
+				code = append(code, lines[i])
+			}
+			var content []any
+			if text := strings.Join(code, "\n"); text != "" {
+				content = []any{textNode(text, nil)}
+			}
+			var attrs map[string]any
+			if m[2] != "" {
+				attrs = map[string]any{"language": m[2]}
+			}
+			blocks = append(blocks, node("codeBlock", attrs, content))
+
+		case mdHeading.MatchString(trimmed):
+			if err := flush(); err != nil {
+				return nil, err
+			}
+			m := mdHeading.FindStringSubmatch(trimmed)
+			blocks = append(blocks, b.mdHeadingNode(len(m[1]), m[2]))
+
+		case len(para) == 1 && (mdSetextH1.MatchString(trimmed) || mdSetextH2.MatchString(trimmed)):
+			level := 2
+			if mdSetextH1.MatchString(trimmed) {
+				level = 1
+			}
+			blocks = append(blocks, b.mdHeadingNode(level, strings.TrimSpace(para[0])))
+			para = nil
+
+		case mdRule.MatchString(line):
+			if err := flush(); err != nil {
+				return nil, err
+			}
+			blocks = append(blocks, node("horizontalRule", nil, nil))
+
+		case strings.HasPrefix(trimmed, ">"):
+			if err := flush(); err != nil {
+				return nil, err
+			}
+			var quoted []string
+			for ; i < len(lines) && strings.HasPrefix(strings.TrimSpace(lines[i]), ">"); i++ {
+				q := strings.TrimPrefix(strings.TrimSpace(lines[i]), ">")
+				quoted = append(quoted, strings.TrimPrefix(q, " "))
+			}
+			i--
+			inner, err := b.mdBlocks(quoted)
+			if err != nil {
+				return nil, err
+			}
+			blocks = append(blocks, node("blockquote", nil, inner))
+
+		case mdListItem.MatchString(line):
+			if err := flush(); err != nil {
+				return nil, err
+			}
+			list, next, err := b.mdList(lines, i)
+			if err != nil {
+				return nil, err
+			}
+			blocks = append(blocks, list)
+			i = next - 1
+
+		default:
+			para = append(para, line)
+		}
+	}
+	if err := flush(); err != nil {
+		return nil, err
+	}
+	return blocks, nil
+}
+
+func (b *builder) mdHeadingNode(level int, text string) map[string]any {
+	content, _ := b.mdInline(text, nil)
+	return node("heading", map[string]any{"level": level}, content)
+}
+
+// mdList parses consecutive items of one list starting at lines[start]; item bodies (continuation and
+// indented lines) are parsed recursively so nested lists and multi-paragraph items work.
+func (b *builder) mdList(lines []string, start int) (map[string]any, int, error) {
+	first := mdListItem.FindStringSubmatch(lines[start])
+	ordered := !strings.ContainsAny(first[2][:1], "-*+")
+	baseIndent := len(first[1])
+
+	var items []any
+	i := start
+	for i < len(lines) {
+		m := mdListItem.FindStringSubmatch(lines[i])
+		if m == nil || len(m[1]) != baseIndent || (!strings.ContainsAny(m[2][:1], "-*+")) != ordered {
+			break
+		}
+		contentIndent := len(m[1]) + len(m[2]) + 1
+		body := []string{m[3]}
+		for i++; i < len(lines); i++ {
+			l := lines[i]
+			if strings.TrimSpace(l) == "" {
+				// A blank line continues the item only if the next line is indented into it
+				if i+1 < len(lines) && leadingSpaces(lines[i+1]) >= contentIndent {
+					body = append(body, "")
+					continue
+				}
+				break
+			}
+			if leadingSpaces(l) >= contentIndent || (leadingSpaces(l) > baseIndent && mdListItem.MatchString(l)) {
+				body = append(body, dedent(l, contentIndent))
+				continue
+			}
+			if mdListItem.MatchString(l) || mdHeading.MatchString(strings.TrimSpace(l)) || strings.HasPrefix(strings.TrimSpace(l), ">") {
+				break
+			}
+			body = append(body, strings.TrimSpace(l)) // lazy continuation
+		}
+		inner, err := b.mdBlocks(body)
+		if err != nil {
+			return nil, i, err
+		}
+		if len(inner) == 0 {
+			inner = []any{node("paragraph", nil, nil)}
+		}
+		items = append(items, node("listItem", nil, inner))
+		// Skip blank lines between items of the same list
+		for i < len(lines) && strings.TrimSpace(lines[i]) == "" && i+1 < len(lines) && mdListItem.MatchString(lines[i+1]) {
+			i++
+		}
+	}
+
+	if ordered {
+		var attrs map[string]any
+		if n := strings.TrimRight(first[2], ".)"); n != "1" {
+			var startNum int
+			fmt.Sscanf(n, "%d", &startNum)
+			attrs = map[string]any{"start": startNum}
+		}
+		return node("orderedList", attrs, items), i, nil
+	}
+	return node("bulletList", nil, items), i, nil
+}
+
+// mdParagraph converts paragraph lines; a line ending in two spaces or a backslash is a hard break.
+// Images become their own blocks after the paragraph since the editor's image node is block-level.
+func (b *builder) mdParagraph(lines []string) ([]any, error) {
+	if len(lines) == 1 {
+		if m := mdImageLine.FindStringSubmatch(strings.TrimSpace(lines[0])); m != nil {
+			img, err := b.mdImage(m[2], m[1], m[3])
+			if err != nil || img == nil {
+				return nil, err
+			}
+			return []any{img}, nil
+		}
+	}
+	var content, images []any
+	for i, line := range lines {
+		hard := strings.HasSuffix(line, "  ") || strings.HasSuffix(line, "\\")
+		text := strings.TrimRight(strings.TrimSpace(line), "\\")
+		inl, imgs := b.mdInline(text, nil)
+		content = append(content, inl...)
+		for _, im := range imgs {
+			img, err := b.mdImage(im[0], im[1], im[2])
+			if err != nil {
+				return nil, err
+			}
+			if img != nil {
+				images = append(images, img)
+			}
+		}
+		if i < len(lines)-1 {
+			if hard {
+				content = append(content, node("hardBreak", nil, nil))
+			} else {
+				content = append(content, textNode(" ", nil))
+			}
+		}
+	}
+	var out []any
+	if content = mergeText(content); len(content) > 0 {
+		out = append(out, node("paragraph", nil, content))
+	}
+	return append(out, images...), nil
+}
+
+// mdImage resolves an image reference to an image node (nil when it cannot be resolved)
+func (b *builder) mdImage(src, alt, title string) (map[string]any, error) {
+	attrs := map[string]any{"src": src, "alt": alt}
+	if title != "" {
+		attrs["title"] = title
+	}
+	switch {
+	case strings.HasPrefix(src, "data:"):
+		img, err := decodeDataURI(src)
+		if err != nil {
+			b.warn("skipped an invalid data: image")
+			return nil, nil
+		}
+		url, err := b.upload(b.ctx, *img)
+		if err != nil {
+			return nil, fmt.Errorf("upload image: %w", err)
+		}
+		b.images++
+		attrs["src"] = url
+	case strings.HasPrefix(src, "http://") || strings.HasPrefix(src, "https://"):
+		// External images are referenced as-is
+	default:
+		b.warn(fmt.Sprintf("relative image %q cannot be resolved from a single Markdown file and was skipped", src))
+		return nil, nil
+	}
+	return node("image", attrs, nil), nil
+}
+
+func decodeDataURI(uri string) (*Image, error) {
+	meta, payload, ok := strings.Cut(strings.TrimPrefix(uri, "data:"), ",")
+	if !ok || !strings.HasSuffix(meta, ";base64") {
+		return nil, fmt.Errorf("unsupported data URI")
+	}
+	ct := strings.TrimSuffix(meta, ";base64")
+	if !strings.HasPrefix(ct, "image/") {
+		return nil, fmt.Errorf("not an image")
+	}
+	data, err := base64.StdEncoding.DecodeString(payload)
+	if err != nil {
+		return nil, err
+	}
+	ext := strings.TrimPrefix(ct, "image/")
+	if ext == "jpeg" {
+		ext = "jpg"
+	}
+	return &Image{Name: "image." + strings.SplitN(ext, "+", 2)[0], ContentType: ct, Data: data}, nil
+}
+
+// mdInline parses emphasis, strong, strikethrough, code spans, links and autolinks. Inline images are
+// returned separately as [src, alt, title].
+func (b *builder) mdInline(s string, marks []map[string]any) ([]any, [][3]string) {
+	var out []any
+	var images [][3]string
+	var buf strings.Builder
+	emit := func() {
+		if buf.Len() > 0 {
+			out = append(out, textNode(buf.String(), marks))
+			buf.Reset()
+		}
+	}
+	nested := func(inner string, m map[string]any) {
+		emit()
+		nodes, imgs := b.mdInline(inner, withMark(marks, m))
+		out = append(out, nodes...)
+		images = append(images, imgs...)
+	}
+
+	for i := 0; i < len(s); {
+		rest := s[i:]
+		switch {
+		case rest[0] == '\\' && len(rest) > 1 && strings.ContainsRune("\\`*_{}[]()#+-.!~>|<", rune(rest[1])):
+			buf.WriteByte(rest[1])
+			i += 2
+			continue
+
+		case rest[0] == '`':
+			ticks := len(rest) - len(strings.TrimLeft(rest, "`"))
+			if end := strings.Index(rest[ticks:], rest[:ticks]); end >= 0 {
+				emit()
+				code := strings.TrimSpace(rest[ticks : ticks+end])
+				out = append(out, textNode(code, withMark(marks, mark("code"))))
+				i += 2*ticks + end
+				continue
+			}
+
+		case strings.HasPrefix(rest, "**") || strings.HasPrefix(rest, "__"):
+			if end := strings.Index(rest[2:], rest[:2]); end > 0 {
+				nested(rest[2:2+end], mark("bold"))
+				i += 4 + end
+				continue
+			}
+
+		case strings.HasPrefix(rest, "~~"):
+			if end := strings.Index(rest[2:], "~~"); end > 0 {
+				nested(rest[2:2+end], mark("strike"))
+				i += 4 + end
+				continue
+			}
+
+		case rest[0] == '*' || (rest[0] == '_' && (i == 0 || !isWordByte(s[i-1]))):
+			if end := closingEmphasis(rest); end > 0 {
+				nested(rest[1:end], mark("italic"))
+				i += end + 1
+				continue
+			}
+
+		case strings.HasPrefix(rest, "!["):
+			if text, dest, n, ok := parseLink(rest[1:]); ok {
+				emit()
+				src, title := splitLinkTitle(dest)
+				images = append(images, [3]string{src, text, title})
+				i += 1 + n
+				continue
+			}
+
+		case rest[0] == '[':
+			if text, dest, n, ok := parseLink(rest); ok {
+				href, _ := splitLinkTitle(dest)
+				nested(text, linkMark(href))
+				i += n
+				continue
+			}
+
+		case rest[0] == '<':
+			if end := strings.IndexByte(rest, '>'); end > 0 {
+				if u := rest[1:end]; strings.HasPrefix(u, "http://") || strings.HasPrefix(u, "https://") || strings.HasPrefix(u, "mailto:") {
+					emit()
+					out = append(out, textNode(strings.TrimPrefix(u, "mailto:"), withMark(marks, linkMark(u))))
+					i += end + 1
+					continue
+				}
+			}
+		}
+		buf.WriteByte(rest[0])
+		i++
+	}
+	emit()
+	return mergeText(out), images
+}
+
+// closingEmphasis finds the single closing delimiter of *x* / _x_ (not part of a double delimiter)
+func closingEmphasis(s string) int {
+	d := s[0]
+	if len(s) < 3 || s[1] == ' ' {
+		return -1
+	}
+	for j := 1; j < len(s); j++ {
+		if s[j] == '\\' {
+			j++
+			continue
+		}
+		if s[j] == d && s[j-1] != ' ' {
+			if j+1 < len(s) && s[j+1] == d {
+				j++
+				continue
+			}
+			if d == '_' && j+1 < len(s) && isWordByte(s[j+1]) {
+				continue
+			}
+			return j
+		}
+	}
+	return -1
+}
+
+// parseLink parses "[text](dest)" at the start of s, returning the consumed length
+func parseLink(s string) (text, dest string, n int, ok bool) {
+	depth := 0
+	for j := 0; j < len(s); j++ {
+		switch s[j] {
+		case '\\':
+			j++
+		case '[':
+			depth++
+		case ']':
+			depth--
+			if depth == 0 {
+				if j+1 >= len(s) || s[j+1] != '(' {
+					return "", "", 0, false
+				}
+				end := strings.IndexByte(s[j+2:], ')')
+				if end < 0 {
+					return "", "", 0, false
+				}
+				return s[1:j], strings.TrimSpace(s[j+2 : j+2+end]), j + 3 + end, true
+			}
+		}
+	}
+	return "", "", 0, false
+}
+
+func splitLinkTitle(dest string) (string, string) {
+	if url, title, ok := strings.Cut(dest, " "); ok {
+		return strings.Trim(url, "<>"), strings.Trim(strings.TrimSpace(title), `"'`)
+	}
+	return strings.Trim(dest, "<>"), ""
+}
+
+func isWordByte(c byte) bool {
+	return c == '_' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= 0x80
+}
+
+func leadingSpaces(s string) int {
+	return len(s) - len(strings.TrimLeft(s, " "))
+}
+
+func dedent(s string, n int) string {
+	if leadingSpaces(s) >= n {
+		return s[n:]
+	}
+	return strings.TrimLeft(s, " ")
+}
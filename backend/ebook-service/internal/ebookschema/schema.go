@@ -44,6 +44,100 @@ func Init(ctx context.Context, pool *pgxpool.Pool) error {
 			created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
 			updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
 		);`,
+		// Per-ebook media location overrides (NULL = use MEDIA_BUCKET / EBOOK_MEDIA_PREFIX)
+		`ALTER TABLE IF EXISTS ebooks ADD COLUMN IF NOT EXISTS media_bucket TEXT;`,
+		`ALTER TABLE IF EXISTS ebooks ADD COLUMN IF NOT EXISTS media_prefix TEXT;`,
+		// Bucket the pending key lives in, so cleanup never deletes from another environment's bucket
+		`ALTER TABLE ebook_media_pending_deletion ADD COLUMN IF NOT EXISTS media_bucket TEXT;`,
+		// Outbound webhooks (empty events = subscribe to all)
+		`CREATE TABLE IF NOT EXISTS ebook_webhook_endpoints (
+			id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+			url TEXT NOT NULL,
+			secret TEXT NOT NULL,
+			events TEXT[] NOT NULL DEFAULT '{}',
+			description TEXT,
+			active BOOLEAN NOT NULL DEFAULT true,
+			created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+			updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+		);`,
+		`CREATE TABLE IF NOT EXISTS ebook_webhook_deliveries (
+			id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+			endpoint_id UUID NOT NULL REFERENCES ebook_webhook_endpoints(id) ON DELETE CASCADE,
+			event TEXT NOT NULL,
+			payload JSONB NOT NULL,
+			status TEXT NOT NULL DEFAULT 'pending',
+			attempts INTEGER NOT NULL DEFAULT 0,
+			response_code INTEGER,
+			response_body TEXT,
+			last_error TEXT,
+			next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+			delivered_at TIMESTAMPTZ,
+			created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+			updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_due ON ebook_webhook_deliveries(next_attempt_at) WHERE status = 'pending';`,
+		`CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_endpoint ON ebook_webhook_deliveries(endpoint_id, created_at DESC);`,
+		// Reader sync: last position per user and ebook, plus bookmarks (deleted ones kept as tombstones
+		// so other devices learn about the deletion). Conflicts resolve by client_updated_at.
+		`CREATE TABLE IF NOT EXISTS ebook_reading_progress (
+			user_id TEXT NOT NULL,
+			ebook_id UUID NOT NULL,
+			position JSONB NOT NULL,
+			percent NUMERIC(5,2),
+			version_id UUID,
+			device_id TEXT,
+			client_updated_at TIMESTAMPTZ NOT NULL,
+			updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+			PRIMARY KEY (user_id, ebook_id)
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_reading_progress_updated ON ebook_reading_progress(updated_at);`,
+		`CREATE TABLE IF NOT EXISTS ebook_bookmarks (
+			user_id TEXT NOT NULL,
+			ebook_id UUID NOT NULL,
+			bookmark_id TEXT NOT NULL,
+			position JSONB NOT NULL DEFAULT '{}',
+			note TEXT,
+			deleted BOOLEAN NOT NULL DEFAULT false,
+			client_updated_at TIMESTAMPTZ NOT NULL,
+			updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+			PRIMARY KEY (user_id, ebook_id, bookmark_id)
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_bookmarks_updated ON ebook_bookmarks(updated_at);`,
+		// Archival bundles: a version's JSON plus referenced media zipped into EBOOK_S3_BUCKET by a background worker
+		`CREATE TABLE IF NOT EXISTS ebook_bundle_jobs (
+			id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+			version_id UUID NOT NULL,
+			status TEXT NOT NULL DEFAULT 'pending',
+			requested_by TEXT,
+			attempts INTEGER NOT NULL DEFAULT 0,
+			next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+			s3_key TEXT,
+			size_bytes BIGINT,
+			sha256 TEXT,
+			file_count INTEGER,
+			missing_media INTEGER,
+			last_error TEXT,
+			started_at TIMESTAMPTZ,
+			completed_at TIMESTAMPTZ,
+			created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+			updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_bundle_jobs_due ON ebook_bundle_jobs(next_attempt_at) WHERE status IN ('pending','running');`,
+		`CREATE INDEX IF NOT EXISTS idx_bundle_jobs_version ON ebook_bundle_jobs(version_id, created_at DESC);`,
+		// Staged rollout of published versions: readers see a version when it is fully rolled out, they are
+		// beta readers, or their stable per-version bucket falls under rollout_percent (0 = beta readers only).
+		`ALTER TABLE IF EXISTS ebook_versions ADD COLUMN IF NOT EXISTS rollout_percent SMALLINT NOT NULL DEFAULT 100 CHECK (rollout_percent BETWEEN 0 AND 100);`,
+		`ALTER TABLE IF EXISTS ebook_versions ADD COLUMN IF NOT EXISTS rolled_back_at TIMESTAMPTZ;`,
+		`CREATE TABLE IF NOT EXISTS ebook_beta_readers (
+			user_id TEXT PRIMARY KEY,
+			note TEXT,
+			added_by TEXT,
+			added_at TIMESTAMPTZ NOT NULL DEFAULT now()
+		);`,
+		// Content statistics (words, characters, images per chapter), computed on save/publish
+		`ALTER TABLE IF EXISTS ebook_versions ADD COLUMN IF NOT EXISTS word_count INTEGER;`,
+		`ALTER TABLE IF EXISTS ebook_versions ADD COLUMN IF NOT EXISTS content_stats JSONB;`,
+		`ALTER TABLE IF EXISTS ebooks ADD COLUMN IF NOT EXISTS content_stats JSONB;`,
 	}
 
 	tx, err := pool.Begin(ctx)
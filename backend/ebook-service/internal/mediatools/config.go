@@ -0,0 +1,102 @@
+package mediatools
+
+import (
+	"os"
+	"strings"
+)
+
+// Defaults used when no environment or per-ebook configuration is present.
+const (
+	DefaultBucket  = "expotoworld-media"
+	DefaultPrefix  = "ebooks/huashangdao/"
+	DefaultCDNBase = "https://assets.expotoworld.com"
+)
+
+// Config describes where ebook media lives: the S3 bucket, the key prefix
+// owned by the ebook, and the CDN base that serves the bucket.
+type Config struct {
+	Bucket  string
+	Prefix  string
+	CDNBase string
+}
+
+// ConfigFromEnv reads MEDIA_BUCKET, EBOOK_MEDIA_PREFIX and ASSETS_CDN_BASE_URL.
+// The media cleanup lambda reads the same variables so both agree per environment.
+// With STORAGE_DRIVER=local the CDN base defaults to the service's own /uploads
+// (LOCAL_STORAGE_BASE_URL, or SERVICE_BASE_URL + "/uploads").
+func ConfigFromEnv() Config {
+	c := Config{
+		Bucket:  os.Getenv("MEDIA_BUCKET"),
+		Prefix:  os.Getenv("EBOOK_MEDIA_PREFIX"),
+		CDNBase: os.Getenv("ASSETS_CDN_BASE_URL"),
+	}
+	if c.Bucket == "" {
+		c.Bucket = DefaultBucket
+	}
+	if c.Prefix == "" {
+		c.Prefix = DefaultPrefix
+	}
+	if c.CDNBase == "" && strings.EqualFold(strings.TrimSpace(os.Getenv("STORAGE_DRIVER")), "local") {
+		c.CDNBase = os.Getenv("LOCAL_STORAGE_BASE_URL")
+		if c.CDNBase == "" {
+			base := os.Getenv("SERVICE_BASE_URL")
+			if base == "" {
+				base = "http://localhost:8084"
+			}
+			c.CDNBase = strings.TrimRight(base, "/") + "/uploads"
+		}
+	}
+	if c.CDNBase == "" {
+		c.CDNBase = DefaultCDNBase
+	}
+	c.Prefix = normalizePrefix(c.Prefix)
+	c.CDNBase = strings.TrimRight(c.CDNBase, "/")
+	return c
+}
+
+// WithOverrides applies per-ebook bucket/prefix overrides; empty values keep the current setting.
+func (c Config) WithOverrides(bucket, prefix string) Config {
+	if s := strings.TrimSpace(bucket); s != "" {
+		c.Bucket = s
+	}
+	if s := strings.TrimSpace(prefix); s != "" {
+		c.Prefix = normalizePrefix(s)
+	}
+	return c
+}
+
+// ObjectKey builds a key for a new object of the given category (images, videos, audio).
+func (c Config) ObjectKey(category, name string) string {
+	return c.Prefix + category + "/" + name
+}
+
+// URL returns the public CDN URL for a key.
+func (c Config) URL(key string) string {
+	return c.CDNBase + "/" + key
+}
+
+// KeyFromURL converts a CDN URL back to an object key. ok is false for foreign URLs.
+func (c Config) KeyFromURL(url string) (key string, ok bool) {
+	if !strings.HasPrefix(url, c.CDNBase+"/") {
+		return "", false
+	}
+	return strings.TrimPrefix(url, c.CDNBase+"/"), true
+}
+
+// Owns reports whether a key belongs to this ebook's media namespace.
+func (c Config) Owns(key string) bool {
+	return strings.HasPrefix(key, c.Prefix)
+}
+
+// ExtractKeys returns the media keys referenced by content under this config's prefix.
+func (c Config) ExtractKeys(content any) []string {
+	return ExtractMediaKeys(content, c.CDNBase, c.Prefix)
+}
+
+func normalizePrefix(p string) string {
+	p = strings.TrimLeft(strings.TrimSpace(p), "/")
+	if p != "" && !strings.HasSuffix(p, "/") {
+		p += "/"
+	}
+	return p
+}
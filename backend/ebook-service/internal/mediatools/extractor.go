@@ -8,6 +8,7 @@ import (
 // ExtractMediaKeys walks a TipTap-like JSON document and returns S3 object keys
 // by normalizing CDN URLs to keys. Only keys under the allowedPrefix are returned.
 // Example: cdnBase="https://assets.expotoworld.com", allowedPrefix="ebooks/huashangdao/"
+// Prefer Config.ExtractKeys so the prefix follows the environment/ebook configuration.
 func ExtractMediaKeys(content any, cdnBase, allowedPrefix string) []string {
 	set := map[string]struct{}{}
 	walk(content, func(val any) {
@@ -0,0 +1,28 @@
+package storage
+
+import (
+	objectstore "github.com/expotoworld/expotoworld/backend/common/storage"
+	"github.com/expotoworld/expotoworld/backend/ebook-service/internal/mediatools"
+)
+
+// Media hands out the store for an ebook's media configuration. It is built once at startup: every
+// bucket an ebook may override shares the service's S3 client. Deleting unused media stays with the
+// cleanup lambda, which reads ebook_media_pending_deletion.
+type Media struct {
+	cfg      objectstore.Config
+	s3Client objectstore.S3ClientFunc
+}
+
+// NewMedia returns the media stores for cfg's driver (STORAGE_DRIVER), using s3Client for the s3 driver
+func NewMedia(cfg objectstore.Config, s3Client objectstore.S3ClientFunc) *Media {
+	return &Media{cfg: cfg, s3Client: s3Client}
+}
+
+// For returns the store for mc's bucket, or files under the local directory with the local driver.
+// mc.CDNBase is used as the public base in both cases.
+func (m *Media) For(mc mediatools.Config) objectstore.Store {
+	if m.cfg.Driver == "local" {
+		return objectstore.NewLocal(m.cfg.LocalDir, mc.CDNBase)
+	}
+	return objectstore.NewS3(m.s3Client, mc.Bucket, mc.CDNBase)
+}
@@ -83,6 +83,69 @@ func (u *S3Uploader) DeleteObject(ctx context.Context, key string) error {
 	return err
 }
 
+// PutFile uploads a large object (e.g. a bundle ZIP) from a seekable reader.
+func (u *S3Uploader) PutFile(ctx context.Context, key string, body io.ReadSeeker, size int64, contentType string) error {
+	if !u.Enabled() {
+		return fmt.Errorf("s3 uploader not configured")
+	}
+	_, err := u.Client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket:        &u.Bucket,
+		Key:           &key,
+		Body:          body,
+		ContentLength: &size,
+		ContentType:   &contentType,
+	})
+	return err
+}
+
+// OpenObject streams an object from any bucket the service can read (e.g. the media bucket).
+// The caller closes the body.
+func (u *S3Uploader) OpenObject(ctx context.Context, bucket, key string) (io.ReadCloser, string, error) {
+	if !u.Enabled() {
+		return nil, "", fmt.Errorf("s3 uploader not configured")
+	}
+	obj, err := u.Client.GetObject(ctx, &s3.GetObjectInput{Bucket: &bucket, Key: &key})
+	if err != nil {
+		return nil, "", err
+	}
+	contentType := ""
+	if obj.ContentType != nil {
+		contentType = *obj.ContentType
+	}
+	return obj.Body, contentType, nil
+}
+
+// PutObjectTo uploads a small object to any bucket the service can write (e.g. the media bucket).
+func (u *S3Uploader) PutObjectTo(ctx context.Context, bucket, key string, data []byte, contentType string) error {
+	if !u.Enabled() {
+		return fmt.Errorf("s3 uploader not configured")
+	}
+	_, err := u.Client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket:      &bucket,
+		Key:         &key,
+		Body:        bytes.NewReader(data),
+		ContentType: &contentType,
+	})
+	return err
+}
+
+// PresignGet returns a time-limited download URL that saves the object as filename.
+func (u *S3Uploader) PresignGet(ctx context.Context, key, filename string, ttl time.Duration) (string, error) {
+	if !u.Enabled() {
+		return "", fmt.Errorf("s3 uploader not configured")
+	}
+	disposition := fmt.Sprintf("attachment; filename=%q", filename)
+	req, err := s3.NewPresignClient(u.Client).PresignGetObject(ctx, &s3.GetObjectInput{
+		Bucket:                     &u.Bucket,
+		Key:                        &key,
+		ResponseContentDisposition: &disposition,
+	}, s3.WithPresignExpires(ttl))
+	if err != nil {
+		return "", err
+	}
+	return req.URL, nil
+}
+
 func TimestampKey(prefix string) string {
 	return fmt.Sprintf("%s%s.json", prefix, time.Now().UTC().Format("20060102T150405Z"))
 }
@@ -0,0 +1,265 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Event names emitted by the ebook service.
+const (
+	EventPublished      = "ebook.published"
+	EventVersionCreated = "ebook.version.created"
+	EventVersionDeleted = "ebook.version.deleted"
+	EventRestored       = "ebook.restored"
+	EventRolloutChanged = "ebook.rollout.changed"
+)
+
+// Events lists every event an endpoint may subscribe to.
+var Events = []string{EventPublished, EventVersionCreated, EventVersionDeleted, EventRestored, EventRolloutChanged}
+
+// SignatureHeader carries "t=<unix>,v1=<hex hmac-sha256(secret, t + "." + body)>".
+const SignatureHeader = "X-Ebook-Signature"
+
+const (
+	defaultMaxAttempts  = 8
+	defaultPollInterval = 5 * time.Second
+	batchSize           = 20
+	maxResponseLogBytes = 1024
+)
+
+type execer interface {
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+}
+
+// Envelope is the JSON body POSTed to every endpoint.
+type Envelope struct {
+	ID         string         `json:"id"`
+	Event      string         `json:"event"`
+	OccurredAt time.Time      `json:"occurred_at"`
+	Data       map[string]any `json:"data"`
+}
+
+// ValidEvent reports whether name is a known event.
+func ValidEvent(name string) bool {
+	for _, e := range Events {
+		if e == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Enqueue queues one delivery per active endpoint subscribed to event.
+// Call it inside the transaction that performs the change so deliveries only
+// exist for committed changes (outbox pattern); the worker sends them.
+func Enqueue(ctx context.Context, q execer, event string, data map[string]any) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("marshal webhook data: %w", err)
+	}
+	_, err = q.Exec(ctx, `
+		INSERT INTO ebook_webhook_deliveries (endpoint_id, event, payload)
+		SELECT id, $1, $2::jsonb FROM ebook_webhook_endpoints
+		WHERE active = true AND (cardinality(events) = 0 OR $1 = ANY(events))`, event, string(payload))
+	if err != nil {
+		return fmt.Errorf("enqueue webhook %s: %w", event, err)
+	}
+	return nil
+}
+
+// Sign returns the signature header value for body at time t.
+func Sign(secret string, t time.Time, body []byte) string {
+	ts := strconv.FormatInt(t.Unix(), 10)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(ts))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "t=" + ts + ",v1=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Worker delivers queued webhooks with exponential backoff.
+type Worker struct {
+	DB           *pgxpool.Pool
+	Client       *http.Client
+	MaxAttempts  int
+	PollInterval time.Duration
+}
+
+// NewWorkerFromEnv builds a worker configured by EBOOK_WEBHOOK_MAX_ATTEMPTS,
+// EBOOK_WEBHOOK_POLL_SEC and EBOOK_WEBHOOK_TIMEOUT_SEC.
+func NewWorkerFromEnv(db *pgxpool.Pool) *Worker {
+	w := &Worker{
+		DB:           db,
+		Client:       &http.Client{Timeout: 10 * time.Second},
+		MaxAttempts:  defaultMaxAttempts,
+		PollInterval: defaultPollInterval,
+	}
+	if n, err := strconv.Atoi(strings.TrimSpace(os.Getenv("EBOOK_WEBHOOK_MAX_ATTEMPTS"))); err == nil && n > 0 {
+		w.MaxAttempts = n
+	}
+	if n, err := strconv.Atoi(strings.TrimSpace(os.Getenv("EBOOK_WEBHOOK_POLL_SEC"))); err == nil && n > 0 {
+		w.PollInterval = time.Duration(n) * time.Second
+	}
+	if n, err := strconv.Atoi(strings.TrimSpace(os.Getenv("EBOOK_WEBHOOK_TIMEOUT_SEC"))); err == nil && n > 0 {
+		w.Client.Timeout = time.Duration(n) * time.Second
+	}
+	return w
+}
+
+// Run polls for due deliveries until ctx is cancelled.
+func (w *Worker) Run(ctx context.Context) {
+	if w == nil || w.DB == nil {
+		return
+	}
+	log.Printf("[EBOOK] Webhook worker started (poll=%s, max_attempts=%d)", w.PollInterval, w.MaxAttempts)
+	t := time.NewTicker(w.PollInterval)
+	defer t.Stop()
+	for {
+		if n, err := w.processDue(ctx); err != nil {
+			log.Printf("[EBOOK] webhook worker: %v", err)
+		} else if n > 0 {
+			log.Printf("[EBOOK] webhook worker: processed %d deliveries", n)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+	}
+}
+
+type dueDelivery struct {
+	id       string
+	event    string
+	payload  []byte
+	attempts int
+	created  time.Time
+	url      string
+	secret   string
+}
+
+// processDue claims a batch of due deliveries and attempts each once.
+func (w *Worker) processDue(ctx context.Context) (int, error) {
+	qctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	// Claim rows by pushing next_attempt_at forward so concurrent instances skip them.
+	rows, err := w.DB.Query(qctx, `
+		UPDATE ebook_webhook_deliveries d
+		SET next_attempt_at = now() + interval '5 minutes'
+		FROM ebook_webhook_endpoints e
+		WHERE d.id IN (
+			SELECT id FROM ebook_webhook_deliveries
+			WHERE status = 'pending' AND next_attempt_at <= now()
+			ORDER BY next_attempt_at
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		) AND e.id = d.endpoint_id
+		RETURNING d.id, d.event, d.payload::text, d.attempts, d.created_at, e.url, e.secret`, batchSize)
+	if err != nil {
+		return 0, fmt.Errorf("claim deliveries: %w", err)
+	}
+	due, err := pgx.CollectRows(rows, func(r pgx.CollectableRow) (dueDelivery, error) {
+		var d dueDelivery
+		var payload string
+		err := r.Scan(&d.id, &d.event, &payload, &d.attempts, &d.created, &d.url, &d.secret)
+		d.payload = []byte(payload)
+		return d, err
+	})
+	if err != nil {
+		return 0, fmt.Errorf("scan deliveries: %w", err)
+	}
+
+	for _, d := range due {
+		w.deliver(ctx, d)
+	}
+	return len(due), nil
+}
+
+func (w *Worker) deliver(ctx context.Context, d dueDelivery) {
+	var data map[string]any
+	_ = json.Unmarshal(d.payload, &data)
+	body, _ := json.Marshal(Envelope{ID: d.id, Event: d.event, OccurredAt: d.created, Data: data})
+
+	code, respBody, sendErr := w.send(ctx, d, body)
+	attempts := d.attempts + 1
+
+	uctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if sendErr == nil && code >= 200 && code < 300 {
+		_, _ = w.DB.Exec(uctx, `UPDATE ebook_webhook_deliveries
+			SET status='delivered', attempts=$2, response_code=$3, response_body=$4, last_error=NULL, delivered_at=now(), updated_at=now()
+			WHERE id=$1`, d.id, attempts, code, respBody)
+		return
+	}
+
+	errMsg := ""
+	if sendErr != nil {
+		errMsg = sendErr.Error()
+	} else {
+		errMsg = fmt.Sprintf("unexpected status %d", code)
+	}
+	var codePtr *int
+	if code != 0 {
+		codePtr = &code
+	}
+	if attempts >= w.MaxAttempts {
+		log.Printf("[EBOOK] webhook %s to %s failed permanently after %d attempts: %s", d.id, d.url, attempts, errMsg)
+		_, _ = w.DB.Exec(uctx, `UPDATE ebook_webhook_deliveries
+			SET status='failed', attempts=$2, response_code=$3, response_body=$4, last_error=$5, updated_at=now()
+			WHERE id=$1`, d.id, attempts, codePtr, respBody, errMsg)
+		return
+	}
+	_, _ = w.DB.Exec(uctx, `UPDATE ebook_webhook_deliveries
+		SET attempts=$2, response_code=$3, response_body=$4, last_error=$5, next_attempt_at=now() + ($6::int * interval '1 second'), updated_at=now()
+		WHERE id=$1`, d.id, attempts, codePtr, respBody, errMsg, int(backoff(attempts).Seconds()))
+}
+
+func (w *Worker) send(ctx context.Context, d dueDelivery, body []byte) (int, string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, d.url, bytes.NewReader(body))
+	if err != nil {
+		return 0, "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "expotoworld-ebook-webhooks/1")
+	req.Header.Set("X-Ebook-Event", d.event)
+	req.Header.Set("X-Ebook-Delivery", d.id)
+	req.Header.Set(SignatureHeader, Sign(d.secret, time.Now(), body))
+
+	resp, err := w.Client.Do(req)
+	if err != nil {
+		return 0, "", err
+	}
+	defer resp.Body.Close()
+	b, _ := io.ReadAll(io.LimitReader(resp.Body, maxResponseLogBytes))
+	return resp.StatusCode, string(b), nil
+}
+
+// backoff returns the delay before the next attempt: 30s, 1m, 2m, ... capped at 1h.
+func backoff(attempts int) time.Duration {
+	d := 30 * time.Second
+	for i := 1; i < attempts; i++ {
+		d *= 2
+		if d >= time.Hour {
+			return time.Hour
+		}
+	}
+	return d
+}
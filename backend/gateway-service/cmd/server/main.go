@@ -0,0 +1,78 @@
+package main
+
+import (
+	"log"
+	"os"
+	"strings"
+
+	"github.com/expotoworld/expotoworld/backend/common/httpserver"
+	"github.com/expotoworld/expotoworld/backend/gateway-service/internal/gateway"
+	"github.com/expotoworld/expotoworld/backend/gateway-service/internal/logging"
+	"github.com/gin-gonic/gin"
+	"github.com/joho/godotenv"
+)
+
+func main() {
+	// Load environment variables from .env file if it exists
+	if err := godotenv.Load(); err != nil {
+		log.Println("No .env file found, using environment variables")
+	}
+
+	// Ensure all log output goes to stdout so App Runner captures it in Application Logs
+	log.SetOutput(os.Stdout)
+
+	log.Printf("Gateway Service starting (GIT_SHA=%s BUILD_TIME=%s)", os.Getenv("GIT_SHA"), os.Getenv("BUILD_TIME"))
+
+	services, err := gateway.ServicesFromEnv()
+	if err != nil {
+		log.Fatalf("Invalid service configuration: %v", err)
+	}
+	for name, u := range services {
+		log.Printf("Routing %s service to %s", name, u)
+	}
+
+	router := setupRouter(gateway.New(services))
+
+	port := os.Getenv("PORT")
+	if port == "" {
+		port = "8090"
+	}
+
+	// Serve until SIGTERM, then drain in-flight (proxied) requests
+	if err := httpserver.Run("gateway", router, port); err != nil {
+		log.Printf("[ERROR] %v", err)
+	}
+}
+
+func setupRouter(gw *gateway.Gateway) *gin.Engine {
+	// Set Gin mode based on environment
+	if os.Getenv("GIN_MODE") == "" {
+		gin.SetMode(gin.ReleaseMode)
+	}
+
+	router := gin.New()
+
+	// Client IPs come from X-Forwarded-For only when the gateway sits behind a known proxy
+	// (GATEWAY_TRUSTED_PROXIES, comma-separated CIDRs or IPs)
+	var trusted []string
+	if v := strings.TrimSpace(os.Getenv("GATEWAY_TRUSTED_PROXIES")); v != "" {
+		trusted = strings.Split(v, ",")
+	}
+	if err := router.SetTrustedProxies(trusted); err != nil {
+		log.Fatalf("Invalid GATEWAY_TRUSTED_PROXIES: %v", err)
+	}
+
+	router.Use(gateway.RequestID())
+	router.Use(logging.JSONLogger())
+	router.Use(gin.Recovery())
+	router.Use(gateway.CORS())
+
+	// Health endpoints of the gateway itself; services are checked through their own /ready
+	router.GET("/live", func(c *gin.Context) { c.Status(200) })
+	router.GET("/health", func(c *gin.Context) { c.Status(200) })
+
+	// Everything else is proxied according to gateway.Routes
+	router.NoRoute(gw.Handle)
+
+	return router
+}
@@ -0,0 +1,207 @@
+// Package gateway fronts the backend services: it maps public paths to the service owning them,
+// validates the caller's JWT once, rate limits clients and tags every request with an ID.
+package gateway
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/expotoworld/expotoworld/backend/common/auth"
+	"github.com/expotoworld/expotoworld/backend/common/jwks"
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Identity headers set on proxied requests once the token is verified. Values sent by clients are
+// dropped, so services can trust them on requests that came through the gateway.
+const (
+	HeaderUserID = "X-Gateway-User-ID"
+	HeaderRole   = "X-Gateway-Role"
+	// HeaderImpersonator carries the admin acting through an impersonation token
+	HeaderImpersonator = "X-Gateway-Impersonator"
+)
+
+// Gateway proxies API requests to the backend services
+type Gateway struct {
+	proxies  map[string]*httputil.ReverseProxy
+	limiters map[string]*rateLimiter
+	keys     *jwks.Verifier
+	// versions rejects tokens revoked since they were issued (nil: not checked)
+	versions auth.VersionChecker
+}
+
+// New builds the gateway for the given service base URLs (see ServicesFromEnv)
+func New(services map[string]*url.URL) *Gateway {
+	g := &Gateway{
+		proxies:  make(map[string]*httputil.ReverseProxy, len(services)),
+		limiters: limitersFromEnv(),
+		keys:     jwks.FromEnv(),
+	}
+	if !g.keys.Configured() {
+		log.Println("[WARN] neither JWKS_URL nor JWT_SECRET set; requests carrying a token will be rejected")
+	}
+	// The gateway has no database, so it asks auth-service whether tokens were revoked
+	var introspectionURL string
+	if u := services["auth"]; u != nil {
+		introspectionURL = u.JoinPath("/api/auth/introspect").String()
+	}
+	if in := auth.IntrospectorFromEnv(introspectionURL); in != nil {
+		g.versions = auth.NewVersionCache(in, auth.VersionCacheTTL())
+	} else {
+		log.Println("[WARN] SERVICE_CLIENT_ID or SERVICE_CLIENT_SECRET not set; revoked tokens are accepted until they expire")
+	}
+	timeout := time.Duration(getEnvInt("GATEWAY_UPSTREAM_TIMEOUT_SECONDS", 60)) * time.Second
+	for name, target := range services {
+		g.proxies[name] = newServiceProxy(name, target, timeout)
+	}
+	return g
+}
+
+func newServiceProxy(name string, target *url.URL, timeout time.Duration) *httputil.ReverseProxy {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.ResponseHeaderTimeout = timeout
+	return &httputil.ReverseProxy{
+		Transport: transport,
+		Rewrite: func(r *httputil.ProxyRequest) {
+			r.SetURL(target)
+			r.SetXForwarded()
+			// App Runner routes by Host, so send the service's own host rather than the public one
+			r.Out.Host = target.Host
+		},
+		ModifyResponse: func(resp *http.Response) error {
+			// CORS is answered by the gateway; the services' own headers would duplicate it
+			for k := range resp.Header {
+				if strings.HasPrefix(k, "Access-Control-") {
+					resp.Header.Del(k)
+				}
+			}
+			return nil
+		},
+		ErrorHandler: func(w http.ResponseWriter, r *http.Request, err error) {
+			log.Printf("Proxy to %s service failed for %s %s: %v", name, r.Method, r.URL.Path, err)
+			status := http.StatusBadGateway
+			if errors.Is(err, http.ErrHandlerTimeout) || strings.Contains(err.Error(), "timeout") {
+				status = http.StatusGatewayTimeout
+			}
+			w.Header().Set("Content-Type", "application/json; charset=utf-8")
+			w.WriteHeader(status)
+			fmt.Fprintf(w, `{"error":%q,"message":"The %s service is unavailable"}`, http.StatusText(status), name)
+		},
+	}
+}
+
+// Handle serves every path not answered by the gateway itself
+func (g *Gateway) Handle(c *gin.Context) {
+	route := MatchRoute(c.Request.URL.Path)
+	if route == nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   "Not Found",
+			"message": "No backend service configured for path: " + c.Request.URL.Path,
+		})
+		return
+	}
+	proxy, ok := g.proxies[route.Service]
+	if !ok {
+		c.JSON(http.StatusBadGateway, gin.H{"error": "Bad Gateway", "message": "Unknown service " + route.Service})
+		return
+	}
+	c.Set("service", route.Service)
+
+	if limiter := g.limiters[route.Limit]; limiter != nil {
+		if ok, reset := limiter.allow(route.Limit + ":" + c.ClientIP()); !ok {
+			c.Header("Retry-After", strconv.Itoa(int(time.Until(reset).Seconds())+1))
+			c.JSON(http.StatusTooManyRequests, gin.H{"error": "Rate limit exceeded", "message": "Too many requests; try again later"})
+			return
+		}
+	}
+
+	c.Request.Header.Del(HeaderUserID)
+	c.Request.Header.Del(HeaderRole)
+	c.Request.Header.Del(HeaderImpersonator)
+	if route.Auth == AuthPublic {
+		// Public routes check tokens themselves; impersonated requests are still tagged in the log
+		if header := c.GetHeader("Authorization"); header != "" && g.keys.Configured() {
+			if claims, _, _ := g.authenticate(c.Request.Context(), header, false); claims != nil {
+				g.tagImpersonation(c, claims)
+			}
+		}
+	} else {
+		claims, status, msg := g.authenticate(c.Request.Context(), c.GetHeader("Authorization"), route.Auth == AuthRequired)
+		if status != 0 {
+			c.JSON(status, gin.H{"error": http.StatusText(status), "message": msg})
+			return
+		}
+		if claims != nil {
+			if id, ok := claims["user_id"].(string); ok {
+				c.Request.Header.Set(HeaderUserID, id)
+				c.Set("user_id", id)
+			}
+			if role, ok := claims["role"].(string); ok {
+				c.Request.Header.Set(HeaderRole, role)
+			}
+			if actor := g.tagImpersonation(c, claims); actor != "" {
+				c.Request.Header.Set(HeaderImpersonator, actor)
+			}
+		}
+	}
+
+	proxy.ServeHTTP(c.Writer, c.Request)
+}
+
+// tagImpersonation marks the request for the access log when the token is an impersonation token
+// (its "act" claim names the admin), so every action taken with it is audited. Returns the admin id.
+func (g *Gateway) tagImpersonation(c *gin.Context, claims jwt.MapClaims) string {
+	act, _ := claims["act"].(map[string]any)
+	actor, _ := act["sub"].(string)
+	if actor == "" {
+		return ""
+	}
+	c.Set("impersonator", actor)
+	if jti, ok := claims["jti"].(string); ok {
+		c.Set("impersonation_id", jti)
+	}
+	if id, ok := claims["user_id"].(string); ok {
+		c.Set("user_id", id)
+	}
+	return actor
+}
+
+// authenticate verifies the bearer token and that it wasn't revoked. It returns nil claims for an
+// anonymous request that is allowed, or a non-zero status and message when the request must be rejected.
+func (g *Gateway) authenticate(ctx context.Context, header string, required bool) (jwt.MapClaims, int, string) {
+	if header == "" {
+		if required {
+			return nil, http.StatusUnauthorized, "Please provide a valid authorization token"
+		}
+		return nil, 0, ""
+	}
+	tokenString, ok := strings.CutPrefix(header, "Bearer ")
+	if !ok || tokenString == "" {
+		return nil, http.StatusUnauthorized, "Authorization header must be in format 'Bearer <token>'"
+	}
+	if !g.keys.Configured() {
+		return nil, http.StatusInternalServerError, "JWT keys missing"
+	}
+	claims, err := g.keys.Parse(tokenString)
+	if err != nil {
+		return nil, http.StatusUnauthorized, "The provided token is invalid or expired"
+	}
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	if err := auth.CheckTokenVersion(ctx, g.versions, claims, tokenString); err != nil {
+		if errors.Is(err, auth.ErrTokenRevoked) {
+			return nil, http.StatusUnauthorized, "The provided token has been revoked; please sign in again"
+		}
+		log.Printf("[WARN] Token version check failed: %v", err)
+		return nil, http.StatusServiceUnavailable, "Could not validate the token; please retry"
+	}
+	return claims, 0, ""
+}
@@ -0,0 +1,78 @@
+package gateway
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// HeaderRequestID carries the request ID to the services and back to the client
+const HeaderRequestID = "X-Request-ID"
+
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._-]{8,128}$`)
+
+// RequestID tags the request with an ID, keeping a well-formed one sent by the client (e.g. the CDN)
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(HeaderRequestID)
+		if !validRequestID.MatchString(id) {
+			b := make([]byte, 16)
+			_, _ = rand.Read(b)
+			id = hex.EncodeToString(b)
+		}
+		c.Request.Header.Set(HeaderRequestID, id)
+		c.Header(HeaderRequestID, id)
+		c.Set("request_id", id)
+		c.Next()
+	}
+}
+
+// defaultOrigins are the browser apps calling the API
+var defaultOrigins = []string{
+	"https://admin.expotoworld.com",
+	"https://huashangdao.expotoworld.com",
+	"http://localhost:3000",
+	"http://localhost:5173",
+	"http://127.0.0.1:3000",
+	"http://127.0.0.1:5173",
+}
+
+// CORS answers cross-origin requests for all services. Known origins (GATEWAY_ALLOWED_ORIGINS,
+// comma-separated) may send credentials; others get a wildcard without them.
+func CORS() gin.HandlerFunc {
+	allowed := map[string]bool{}
+	origins := defaultOrigins
+	if v := strings.TrimSpace(os.Getenv("GATEWAY_ALLOWED_ORIGINS")); v != "" {
+		origins = strings.Split(v, ",")
+	}
+	for _, o := range origins {
+		if o = strings.TrimSpace(o); o != "" {
+			allowed[o] = true
+		}
+	}
+	return func(c *gin.Context) {
+		origin := c.GetHeader("Origin")
+		if allowed[origin] {
+			c.Header("Access-Control-Allow-Origin", origin)
+			c.Header("Access-Control-Allow-Credentials", "true")
+			c.Header("Vary", "Origin")
+		} else {
+			c.Header("Access-Control-Allow-Origin", "*")
+		}
+		c.Header("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
+		c.Header("Access-Control-Allow-Headers", "Origin, Content-Type, Content-Length, Accept-Encoding, Authorization, X-CSRF-Token, X-Requested-With, X-Require-Existing, X-Require-Role, X-Admin-Request, X-Session-ID, X-Request-ID, If-Unmodified-Since")
+		c.Header("Access-Control-Expose-Headers", "X-Request-ID, X-Experiments, Retry-After")
+		c.Header("Access-Control-Max-Age", "86400")
+
+		if c.Request.Method == http.MethodOptions {
+			c.AbortWithStatus(http.StatusNoContent)
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,74 @@
+package gateway
+
+import (
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// rateLimiter is a fixed-window, in-memory limiter on requests per client. Each gateway instance
+// counts on its own, so the effective limit scales with the number of instances.
+type rateLimiter struct {
+	mu      sync.Mutex
+	limit   int
+	window  time.Duration
+	buckets map[string]*rateBucket
+}
+
+type rateBucket struct {
+	start time.Time
+	count int
+}
+
+func newRateLimiter(limit int, window time.Duration) *rateLimiter {
+	return &rateLimiter{limit: limit, window: window, buckets: make(map[string]*rateBucket)}
+}
+
+// allow counts one request for key and reports whether it fits in the current window, and when the
+// window resets
+func (l *rateLimiter) allow(key string) (bool, time.Time) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	b, ok := l.buckets[key]
+	if !ok || now.Sub(b.start) >= l.window {
+		b = &rateBucket{start: now}
+		l.buckets[key] = b
+	}
+	reset := b.start.Add(l.window)
+	if b.count >= l.limit {
+		return false, reset
+	}
+	b.count++
+
+	// Opportunistically drop stale buckets so the map does not grow unbounded
+	if len(l.buckets) > 10000 {
+		for k, v := range l.buckets {
+			if now.Sub(v.start) >= l.window {
+				delete(l.buckets, k)
+			}
+		}
+	}
+	return true, reset
+}
+
+// limitersFromEnv builds the per-minute limits named by Route.Limit:
+//   - default: GATEWAY_RATE_LIMIT_PER_MINUTE (600)
+//   - auth: GATEWAY_AUTH_RATE_LIMIT_PER_MINUTE (60), for login and code delivery
+func limitersFromEnv() map[string]*rateLimiter {
+	return map[string]*rateLimiter{
+		"default": newRateLimiter(getEnvInt("GATEWAY_RATE_LIMIT_PER_MINUTE", 600), time.Minute),
+		"auth":    newRateLimiter(getEnvInt("GATEWAY_AUTH_RATE_LIMIT_PER_MINUTE", 60), time.Minute),
+	}
+}
+
+func getEnvInt(key string, def int) int {
+	if v := os.Getenv(key); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			return n
+		}
+	}
+	return def
+}
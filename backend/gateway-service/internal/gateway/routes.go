@@ -0,0 +1,98 @@
+package gateway
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// AuthMode is how the gateway treats the caller's bearer token on a route
+type AuthMode int
+
+const (
+	// AuthPublic forwards the request without looking at the token (login, refresh, tracking links)
+	AuthPublic AuthMode = iota
+	// AuthOptional rejects invalid or expired tokens but lets anonymous requests through
+	AuthOptional
+	// AuthRequired rejects requests without a valid token
+	AuthRequired
+)
+
+// Route maps a public path prefix to the service that owns it
+type Route struct {
+	Prefix  string
+	Service string
+	Auth    AuthMode
+	// Limit names the rate limit applied to the route ("default" or "auth")
+	Limit string
+}
+
+// Routes is the public path table. The longest matching prefix wins, so specific admin paths owned by
+// another service are listed next to the general /api/admin mapping.
+var Routes = []Route{
+	{Prefix: "/api/auth", Service: "auth", Auth: AuthPublic, Limit: "auth"},
+	{Prefix: "/api/protected", Service: "auth", Auth: AuthRequired, Limit: "default"},
+	{Prefix: "/api/admin/auth-events", Service: "auth", Auth: AuthRequired, Limit: "default"},
+
+	{Prefix: "/api/me", Service: "user", Auth: AuthRequired, Limit: "default"},
+	{Prefix: "/api/admin/users", Service: "user", Auth: AuthRequired, Limit: "default"},
+	{Prefix: "/api/admin/custom-fields", Service: "user", Auth: AuthRequired, Limit: "default"},
+
+	{Prefix: "/api/v1", Service: "catalog", Auth: AuthOptional, Limit: "default"},
+
+	{Prefix: "/api/admin", Service: "order", Auth: AuthRequired, Limit: "default"},
+	{Prefix: "/api/cart", Service: "order", Auth: AuthRequired, Limit: "default"},
+	{Prefix: "/api/orders", Service: "order", Auth: AuthRequired, Limit: "default"},
+	{Prefix: "/api/order", Service: "order", Auth: AuthRequired, Limit: "default"},
+	{Prefix: "/api/checkout-config", Service: "order", Auth: AuthRequired, Limit: "default"},
+	{Prefix: "/api/my-quotes", Service: "order", Auth: AuthRequired, Limit: "default"},
+	{Prefix: "/api/quotes", Service: "order", Auth: AuthRequired, Limit: "default"},
+	{Prefix: "/api/manufacturer", Service: "order", Auth: AuthRequired, Limit: "default"},
+	{Prefix: "/track", Service: "order", Auth: AuthPublic, Limit: "default"},
+
+	{Prefix: "/api/ebook", Service: "ebook", Auth: AuthOptional, Limit: "default"},
+}
+
+// MatchRoute returns the route owning path, or nil. Prefixes match whole path segments, so
+// /api/orders does not claim /api/ordersx.
+func MatchRoute(path string) *Route {
+	var best *Route
+	for i := range Routes {
+		r := &Routes[i]
+		if path != r.Prefix && !strings.HasPrefix(path, r.Prefix+"/") {
+			continue
+		}
+		if best == nil || len(r.Prefix) > len(best.Prefix) {
+			best = r
+		}
+	}
+	return best
+}
+
+// serviceDefaults are the local development addresses (see the Makefile's dev-backend target)
+var serviceDefaults = map[string]string{
+	"auth":    "http://localhost:8081",
+	"order":   "http://localhost:8082",
+	"catalog": "http://localhost:8080",
+	"user":    "http://localhost:8083",
+	"ebook":   "http://localhost:8084",
+}
+
+// ServicesFromEnv reads each service's base URL from <NAME>_SERVICE_URL (e.g. CATALOG_SERVICE_URL),
+// falling back to the local development ports
+func ServicesFromEnv() (map[string]*url.URL, error) {
+	services := make(map[string]*url.URL, len(serviceDefaults))
+	for name, def := range serviceDefaults {
+		raw := strings.TrimSpace(os.Getenv(strings.ToUpper(name) + "_SERVICE_URL"))
+		if raw == "" {
+			raw = def
+		}
+		u, err := url.Parse(strings.TrimRight(raw, "/"))
+		if err != nil || u.Scheme == "" || u.Host == "" {
+			return nil, fmt.Errorf("invalid %s service URL %q", name, raw)
+		}
+		services[name] = u
+	}
+	return services, nil
+}
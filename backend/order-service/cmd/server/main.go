@@ -1,11 +1,14 @@
 package main
 
 import (
+	"context"
 	"log"
 	"os"
-	"os/signal"
-	"syscall"
 
+	"github.com/expotoworld/expotoworld/backend/common/auth"
+	"github.com/expotoworld/expotoworld/backend/common/awsclient"
+	"github.com/expotoworld/expotoworld/backend/common/httpserver"
+	"github.com/expotoworld/expotoworld/backend/common/storage"
 	"github.com/expotoworld/expotoworld/backend/order-service/internal/api"
 	"github.com/expotoworld/expotoworld/backend/order-service/internal/db"
 	"github.com/expotoworld/expotoworld/backend/order-service/internal/logging"
@@ -31,13 +34,34 @@ func main() {
 	}
 	if database != nil {
 		defer database.Close()
+
+		// Reject tokens revoked by a forced logout or sign-in method change
+		api.CheckTokenVersions(auth.NewVersionCache(auth.VersionFunc(database.TokenVersion), auth.VersionCacheTTL()))
+	}
+
+	// Order document storage (disabled unless ORDER_ATTACHMENTS_BUCKET is set or STORAGE_DRIVER=local)
+	storageCfg := storage.ConfigFromEnv()
+	storageCfg.Bucket = os.Getenv("ORDER_ATTACHMENTS_BUCKET")
+	var attachments storage.Store
+	if storageCfg.Driver == "local" || storageCfg.Bucket != "" {
+		attachments = storage.New(storageCfg, awsclient.NewProviderFromEnv().S3)
 	}
 
 	// Initialize handlers
-	handler := api.NewHandler(database)
+	handler := api.NewHandler(database, attachments)
+
+	// Background job: flag orders stuck past their status SLA
+	jobsCtx, stopJobs := context.WithCancel(context.Background())
+	defer stopJobs()
+	if database != nil {
+		go handler.StartSLAChecker(jobsCtx)
+	}
 
 	// Set up Gin router
 	router := setupRouter(handler)
+	if storageCfg.Driver == "local" {
+		router.Static("/uploads", storageCfg.LocalDir)
+	}
 
 	// Get port from environment or use default
 	port := os.Getenv("ORDER_PORT")
@@ -45,20 +69,10 @@ func main() {
 		port = "8082" // Different port from auth and catalog services
 	}
 
-	// Set up graceful shutdown
-	go func() {
-		log.Printf("Starting order service on port %s", port)
-		if err := router.Run(":" + port); err != nil {
-			log.Fatalf("Failed to start server: %v", err)
-		}
-	}()
-
-	// Wait for interrupt signal to gracefully shutdown the server
-	quit := make(chan os.Signal, 1)
-	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
-	<-quit
-
-	log.Println("Shutting down order service...")
+	// Serve until SIGTERM, then drain in-flight requests before the deferred job stop and database close
+	if err := httpserver.Run("order service", router, port); err != nil {
+		log.Printf("[ERROR] %v", err)
+	}
 }
 
 func setupRouter(handler *api.Handler) *gin.Engine {
@@ -80,6 +94,9 @@ func setupRouter(handler *api.Handler) *gin.Engine {
 	// Keep /health as liveness-only for App Runner health checks
 	router.GET("/health", func(c *gin.Context) { c.Status(200) })
 
+	// Public order tracking via the signed link sent to customers (no login)
+	router.GET("/track/:token", handler.GetOrderTracking)
+
 	// API routes with JWT protection
 	apiGroup := router.Group("/api")
 	apiGroup.Use(api.AuthMiddleware())
@@ -93,22 +110,62 @@ func setupRouter(handler *api.Handler) *gin.Engine {
 		// Order endpoints - mini-app specific
 		apiGroup.POST("/orders/:mini_app_type", handler.CreateOrder)
 		apiGroup.GET("/orders/:mini_app_type", handler.GetOrders)
+		apiGroup.GET("/checkout-config/:mini_app_type", handler.GetCheckoutConfig)
 
 		// Specific order endpoint (different path to avoid conflict)
 		apiGroup.GET("/order/:order_id", handler.GetOrder)
+		apiGroup.GET("/order/:order_id/timeline", handler.GetOrderTimeline)
+		apiGroup.GET("/order/:order_id/calendar.ics", handler.GetOrderCalendar)
+		apiGroup.POST("/order/:order_id/attachments", handler.UploadOrderAttachment(api.AttachmentRoleCustomer))
+		apiGroup.GET("/order/:order_id/attachments/:attachment_id/download", handler.DownloadOrderAttachment(api.AttachmentRoleCustomer))
+		apiGroup.DELETE("/order/:order_id/attachments/:attachment_id", handler.DeleteOrderAttachment(api.AttachmentRoleCustomer))
+		apiGroup.GET("/order/:order_id/documents/:doc_type", handler.GetOrderDocument(api.AttachmentRoleCustomer))
+
+		// Quotes addressed to the current customer
+		apiGroup.GET("/my-quotes", handler.GetMyQuotes)
+		apiGroup.GET("/my-quotes/:quote_id", handler.GetMyQuote)
+		apiGroup.POST("/my-quotes/:quote_id/convert", handler.ConvertQuote)
+	}
+
+	// Quote authoring (Admin or Partner)
+	quoteGroup := router.Group("/api/quotes")
+	quoteGroup.Use(api.AuthMiddleware())
+	quoteGroup.Use(api.QuoteAuthorMiddleware())
+	{
+		quoteGroup.POST("", handler.CreateQuote)
+		quoteGroup.GET("", handler.GetQuotes)
+		quoteGroup.GET("/:quote_id", handler.GetQuote)
+		quoteGroup.PUT("/:quote_id", handler.UpdateQuote)
+		quoteGroup.POST("/:quote_id/send", handler.SendQuote)
+		quoteGroup.POST("/:quote_id/cancel", handler.CancelQuote)
 	}
 
 	// Admin API routes with authentication and admin middleware
 	adminGroup := router.Group("/api/admin")
 	adminGroup.Use(api.AuthMiddleware())
 	adminGroup.Use(api.AdminMiddleware())
+	adminGroup.Use(handler.AdminScopeMiddleware())
 	{
 		// Order management endpoints
 		adminGroup.GET("/orders", handler.GetAdminOrders)
+		adminGroup.GET("/orders/aging", handler.GetAgingOrders)
 		adminGroup.GET("/orders/:order_id", handler.GetAdminOrder)
 		adminGroup.PUT("/orders/:order_id/status", handler.UpdateOrderStatus)
 		adminGroup.DELETE("/orders/:order_id", handler.DeleteOrder)
 		adminGroup.POST("/orders/bulk-update", handler.BulkUpdateOrders)
+		adminGroup.POST("/orders/import", handler.ImportOfflineOrders)
+		adminGroup.POST("/orders/print", handler.PrintOrders)
+		adminGroup.GET("/orders/print/:job_id", handler.GetPrintJob)
+		adminGroup.GET("/orders/:order_id/timeline", handler.GetAdminOrderTimeline)
+		adminGroup.POST("/orders/:order_id/events", handler.RecordOrderEvent)
+		adminGroup.PUT("/orders/:order_id/schedule", handler.SetOrderSchedule)
+		adminGroup.POST("/orders/:order_id/attachments", handler.UploadOrderAttachment(api.AttachmentRoleAdmin))
+		adminGroup.GET("/orders/:order_id/attachments/:attachment_id/download", handler.DownloadOrderAttachment(api.AttachmentRoleAdmin))
+		adminGroup.DELETE("/orders/:order_id/attachments/:attachment_id", handler.DeleteOrderAttachment(api.AttachmentRoleAdmin))
+		adminGroup.GET("/orders/:order_id/documents/:doc_type", handler.GetOrderDocument(api.AttachmentRoleAdmin))
+		adminGroup.GET("/orders/:order_id/ledger", handler.GetOrderLedger)
+		adminGroup.POST("/orders/:order_id/refunds", handler.RecordRefund)
+		adminGroup.POST("/orders/:order_id/adjustments", handler.RecordAdjustment)
 
 		// Cart management endpoints
 		adminGroup.GET("/carts", handler.GetAdminCarts)
@@ -119,6 +176,24 @@ func setupRouter(handler *api.Handler) *gin.Engine {
 		// Statistics endpoints
 		adminGroup.GET("/orders/statistics", handler.GetOrderStatistics)
 		adminGroup.GET("/carts/statistics", handler.GetCartStatistics)
+
+		// Manufacturer settlements (commission rates maintained in catalog-service)
+		adminGroup.GET("/settlements", handler.GetSettlements)
+		adminGroup.GET("/accounting/export", handler.ExportLedger)
+
+		// Order SLA thresholds (stuck orders are listed at /orders/aging)
+		adminGroup.GET("/sla-thresholds", handler.GetOrderSLAThresholds)
+		adminGroup.PUT("/sla-thresholds/:status", handler.SetOrderSLAThreshold)
+		adminGroup.DELETE("/sla-thresholds/:status", handler.DeleteOrderSLAThreshold)
+
+		// Checkout rules per mini-app (payment methods, minimum order, delivery options, fees)
+		adminGroup.GET("/checkout-configs", handler.GetCheckoutConfigs)
+		adminGroup.PUT("/checkout-configs/:mini_app_type", handler.SetCheckoutConfig)
+		adminGroup.DELETE("/checkout-configs/:mini_app_type", handler.DeleteCheckoutConfig)
+
+		// Multi-warehouse stock sources used for order line allocation
+		adminGroup.GET("/products/:product_id/stock-sources", handler.GetStockSources)
+		adminGroup.PUT("/products/:product_id/stock-sources", handler.SetStockSources)
 	}
 
 	// Manufacturer-scoped routes (authenticated)
@@ -128,6 +203,11 @@ func setupRouter(handler *api.Handler) *gin.Engine {
 		manufacturer.GET("/orders", handler.GetManufacturerOrders)
 		manufacturer.GET("/orders/:order_id", handler.GetManufacturerOrder)
 		manufacturer.PUT("/orders/:order_id/status", handler.UpdateManufacturerOrderStatus)
+		manufacturer.POST("/orders/:order_id/attachments", handler.UploadOrderAttachment(api.AttachmentRoleManufacturer))
+		manufacturer.GET("/orders/:order_id/attachments/:attachment_id/download", handler.DownloadOrderAttachment(api.AttachmentRoleManufacturer))
+		manufacturer.DELETE("/orders/:order_id/attachments/:attachment_id", handler.DeleteOrderAttachment(api.AttachmentRoleManufacturer))
+		manufacturer.GET("/orders/:order_id/documents/:doc_type", handler.GetOrderDocument(api.AttachmentRoleManufacturer))
+		manufacturer.GET("/settlements", handler.GetManufacturerSettlements)
 	}
 
 	// Alias under /api/admin/manufacturer to pass through the existing gateway mapping for order-service
@@ -137,6 +217,11 @@ func setupRouter(handler *api.Handler) *gin.Engine {
 		adminManufacturer.GET("/orders", handler.GetManufacturerOrders)
 		adminManufacturer.GET("/orders/:order_id", handler.GetManufacturerOrder)
 		adminManufacturer.PUT("/orders/:order_id/status", handler.UpdateManufacturerOrderStatus)
+		adminManufacturer.POST("/orders/:order_id/attachments", handler.UploadOrderAttachment(api.AttachmentRoleManufacturer))
+		adminManufacturer.GET("/orders/:order_id/attachments/:attachment_id/download", handler.DownloadOrderAttachment(api.AttachmentRoleManufacturer))
+		adminManufacturer.DELETE("/orders/:order_id/attachments/:attachment_id", handler.DeleteOrderAttachment(api.AttachmentRoleManufacturer))
+		adminManufacturer.GET("/orders/:order_id/documents/:doc_type", handler.GetOrderDocument(api.AttachmentRoleManufacturer))
+		adminManufacturer.GET("/settlements", handler.GetManufacturerSettlements)
 	}
 
 	// Root endpoint for basic info
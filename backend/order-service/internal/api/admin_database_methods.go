@@ -6,6 +6,7 @@ import (
 	"strings"
 
 	"github.com/expotoworld/expotoworld/backend/order-service/internal/models"
+	"github.com/expotoworld/expotoworld/backend/order-service/internal/money"
 	"github.com/jackc/pgx/v5"
 )
 
@@ -40,6 +41,18 @@ func (h *Handler) getAdminOrders(ctx context.Context, req *models.AdminOrderList
 		argIndex++
 	}
 
+	if req.StoreID != nil {
+		whereConditions = append(whereConditions, fmt.Sprintf("o.store_id = $%d", argIndex))
+		args = append(args, *req.StoreID)
+		argIndex++
+	}
+
+	if req.ScopeStoreIDs != nil {
+		whereConditions = append(whereConditions, fmt.Sprintf("o.store_id = ANY($%d::int[])", argIndex))
+		args = append(args, req.ScopeStoreIDs)
+		argIndex++
+	}
+
 	if req.DateFrom != "" {
 		whereConditions = append(whereConditions, fmt.Sprintf("o.created_at >= $%d", argIndex))
 		args = append(args, req.DateFrom+" 00:00:00")
@@ -135,8 +148,10 @@ func (h *Handler) getAdminOrders(ctx context.Context, req *models.AdminOrderList
 			o.mini_app_type,
 
 			o.total_amount,
+			o.currency,
 			o.status,
 			(SELECT COUNT(*) FROM app_order_items oi WHERE oi.order_id = o.id) as item_count,
+			EXISTS (SELECT 1 FROM app_order_gifts g WHERE g.order_id = o.id) as is_gift,
 			o.created_at,
 			o.updated_at
 		FROM app_orders o
@@ -182,8 +197,10 @@ func (h *Handler) getAdminOrders(ctx context.Context, req *models.AdminOrderList
 			&order.UserName,
 			&order.MiniAppType,
 			&order.TotalAmount,
+			&order.Currency,
 			&order.Status,
 			&order.ItemCount,
+			&order.IsGift,
 			&order.CreatedAt,
 			&order.UpdatedAt,
 		)
@@ -220,6 +237,7 @@ func (h *Handler) getAdminOrderByID(ctx context.Context, orderID string) (*model
 
 			o.mini_app_type,
 			o.total_amount,
+			o.currency,
 			o.status,
 			(SELECT COUNT(*) FROM app_order_items oi WHERE oi.order_id = o.id) as item_count,
 			o.created_at,
@@ -266,6 +284,7 @@ func (h *Handler) getAdminOrderByID(ctx context.Context, orderID string) (*model
 		&order.UserName,
 		&order.MiniAppType,
 		&order.TotalAmount,
+		&order.Currency,
 		&order.Status,
 		&order.ItemCount,
 		&order.CreatedAt,
@@ -284,8 +303,15 @@ func (h *Handler) getAdminOrderByID(ctx context.Context, orderID string) (*model
 		return nil, fmt.Errorf("failed to get order items: %w", err)
 	}
 
+	gift, err := getOrderGift(ctx, h.db.Pool, orderID)
+	if err != nil {
+		return nil, err
+	}
+	order.IsGift = gift != nil
+
 	response := &models.AdminOrderDetailResponse{
 		Order: order,
+		Gift:  gift,
 		Items: items,
 	}
 
@@ -319,6 +345,18 @@ func (h *Handler) updateOrderStatus(ctx context.Context, orderID string, newStat
 		return fmt.Errorf("failed to update order status: %w", err)
 	}
 
+	// Record the transition on the order timeline
+	if currentStatus != newStatus {
+		if err := insertOrderEvent(ctx, tx, orderID, models.OrderEventStatusChanged, &currentStatus, &newStatus, reason, changedBy, nil); err != nil {
+			return err
+		}
+		if newStatus == models.OrderStatusCancelled {
+			if err := reverseUnpaidOrder(ctx, tx, orderID, reason, changedBy); err != nil {
+				return err
+			}
+		}
+	}
+
 	// Commit transaction
 	err = tx.Commit(ctx)
 	if err != nil {
@@ -350,7 +388,7 @@ func (h *Handler) getOrderStatistics(ctx context.Context, dateFrom, dateTo strin
 	stats := &models.OrderStatistics{
 		OrdersByStatus:   make(map[models.OrderStatus]int),
 		OrdersByMiniApp:  make(map[models.MiniAppType]int),
-		RevenueByMiniApp: make(map[models.MiniAppType]float64),
+		RevenueByMiniApp: make(map[models.MiniAppType]money.Amount),
 	}
 
 	// Build date filter
@@ -402,7 +440,7 @@ func (h *Handler) getOrderStatistics(ctx context.Context, dateFrom, dateTo strin
 	for rows.Next() {
 		var miniAppType models.MiniAppType
 		var count int
-		var revenue float64
+		var revenue money.Amount
 		if err := rows.Scan(&miniAppType, &count, &revenue); err != nil {
 			return nil, fmt.Errorf("failed to scan mini-app statistics: %w", err)
 		}
@@ -728,7 +766,7 @@ func (h *Handler) deleteAdminCart(ctx context.Context, cartID string) error {
 func (h *Handler) getCartStatistics(ctx context.Context, dateFrom, dateTo string) (*models.CartStatistics, error) {
 	stats := &models.CartStatistics{
 		CartsByMiniApp:     make(map[models.MiniAppType]int),
-		CartValueByMiniApp: make(map[models.MiniAppType]float64),
+		CartValueByMiniApp: make(map[models.MiniAppType]money.Amount),
 	}
 
 	// Build date filter
@@ -767,7 +805,7 @@ func (h *Handler) getCartStatistics(ctx context.Context, dateFrom, dateTo string
 
 	// Calculate average cart value
 	if stats.TotalCarts > 0 {
-		stats.AverageCartValue = stats.TotalCartValue / float64(stats.TotalCarts)
+		stats.AverageCartValue = stats.TotalCartValue.Div(stats.TotalCarts)
 	}
 
 	// Get statistics by mini-app type
@@ -791,7 +829,7 @@ func (h *Handler) getCartStatistics(ctx context.Context, dateFrom, dateTo string
 	for rows.Next() {
 		var miniAppType models.MiniAppType
 		var count int
-		var value float64
+		var value money.Amount
 		if err := rows.Scan(&miniAppType, &count, &value); err != nil {
 			return nil, fmt.Errorf("failed to scan mini-app cart statistics: %w", err)
 		}
@@ -36,6 +36,9 @@ func (h *Handler) GetAdminOrders(c *gin.Context) {
 	if req.SortOrder == "" {
 		req.SortOrder = "desc"
 	}
+	if scope := getAdminScope(c); scope != nil {
+		req.ScopeStoreIDs = scope.stores
+	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
 	defer cancel()
@@ -88,6 +91,8 @@ func (h *Handler) GetAdminOrder(c *gin.Context) {
 		})
 		return
 	}
+	order.Attachments = h.visibleAttachments(ctx, orderID, attachmentViewer{Role: AttachmentRoleAdmin})
+	order.TrackingURL = orderTrackingURL(orderTrackingToken(orderID))
 
 	c.JSON(http.StatusOK, order)
 }
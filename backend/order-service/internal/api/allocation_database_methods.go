@@ -0,0 +1,215 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+
+	"github.com/expotoworld/expotoworld/backend/order-service/internal/models"
+	"github.com/jackc/pgx/v5"
+)
+
+// errInsufficientSourceStock is returned when no single stock source can fill a line item
+var errInsufficientSourceStock = errors.New("no store has enough stock")
+
+// geoPoint is a latitude/longitude pair in degrees
+type geoPoint struct {
+	Lat, Lng float64
+}
+
+// distanceKm is the great-circle distance between two points
+func distanceKm(a, b geoPoint) float64 {
+	const earthRadiusKm = 6371.0
+	toRad := func(d float64) float64 { return d * math.Pi / 180 }
+	dLat := toRad(b.Lat - a.Lat)
+	dLng := toRad(b.Lng - a.Lng)
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(toRad(a.Lat))*math.Cos(toRad(b.Lat))*math.Sin(dLng/2)*math.Sin(dLng/2)
+	return 2 * earthRadiusKm * math.Asin(math.Sqrt(h))
+}
+
+// stockSourceCandidate is a source considered by the allocator
+type stockSourceCandidate struct {
+	StoreID  int
+	Quantity int
+	Priority int
+	Location *geoPoint // nil when the store has no coordinates
+}
+
+// allocationStrategyFromEnv reads ORDER_ALLOCATION_STRATEGY (nearest, most_stock or priority; default priority)
+func allocationStrategyFromEnv() models.AllocationStrategy {
+	if s := models.AllocationStrategy(os.Getenv("ORDER_ALLOCATION_STRATEGY")); s.IsValid() {
+		return s
+	}
+	return models.AllocationPriority
+}
+
+// chooseStockSource picks the source a line of qty units ships from. Only sources that can fill the whole
+// line are eligible. Nearest needs a location and falls back to priority without one (as do sources
+// without coordinates, which rank after located ones). Ties go to priority, then stock, then store id.
+// It returns the chosen source and the strategy that was actually applied.
+func chooseStockSource(sources []stockSourceCandidate, qty int, strategy models.AllocationStrategy, loc *geoPoint) (*stockSourceCandidate, models.AllocationStrategy, error) {
+	var eligible []stockSourceCandidate
+	for _, s := range sources {
+		if s.Quantity >= qty {
+			eligible = append(eligible, s)
+		}
+	}
+	if len(eligible) == 0 {
+		return nil, strategy, errInsufficientSourceStock
+	}
+	if strategy == models.AllocationNearest && loc == nil {
+		strategy = models.AllocationPriority
+	}
+
+	byPriority := func(a, b stockSourceCandidate) bool {
+		if a.Priority != b.Priority {
+			return a.Priority < b.Priority
+		}
+		if a.Quantity != b.Quantity {
+			return a.Quantity > b.Quantity
+		}
+		return a.StoreID < b.StoreID
+	}
+	sort.SliceStable(eligible, func(i, j int) bool {
+		a, b := eligible[i], eligible[j]
+		switch strategy {
+		case models.AllocationNearest:
+			if (a.Location == nil) != (b.Location == nil) {
+				return a.Location != nil
+			}
+			if a.Location != nil {
+				if da, db := distanceKm(*loc, *a.Location), distanceKm(*loc, *b.Location); da != db {
+					return da < db
+				}
+			}
+		case models.AllocationMostStock:
+			if a.Quantity != b.Quantity {
+				return a.Quantity > b.Quantity
+			}
+		}
+		return byPriority(a, b)
+	})
+	return &eligible[0], strategy, nil
+}
+
+// allocateStock picks and debits the stock source of one order line inside the order transaction.
+// Products without stock sources return a nil store and strategy: they ship from the order's store.
+func allocateStock(ctx context.Context, tx pgx.Tx, productID string, qty int, strategy models.AllocationStrategy, loc *geoPoint) (*int, *models.AllocationStrategy, error) {
+	rows, err := tx.Query(ctx, `
+		SELECT s.store_id, s.quantity, s.priority, st.latitude, st.longitude
+		FROM app_product_stock_sources s
+		JOIN admin_stores st ON st.store_id = s.store_id
+		WHERE s.product_id = $1::uuid AND COALESCE(st.is_active, true)
+		FOR UPDATE OF s
+	`, productID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load stock sources for product %s: %w", productID, err)
+	}
+	var sources []stockSourceCandidate
+	for rows.Next() {
+		var s stockSourceCandidate
+		var lat, lng *float64
+		if err := rows.Scan(&s.StoreID, &s.Quantity, &s.Priority, &lat, &lng); err != nil {
+			rows.Close()
+			return nil, nil, fmt.Errorf("failed to scan stock source: %w", err)
+		}
+		if lat != nil && lng != nil {
+			s.Location = &geoPoint{Lat: *lat, Lng: *lng}
+		}
+		sources = append(sources, s)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, nil, fmt.Errorf("failed to load stock sources for product %s: %w", productID, err)
+	}
+	if len(sources) == 0 {
+		return nil, nil, nil
+	}
+
+	chosen, applied, err := chooseStockSource(sources, qty, strategy, loc)
+	if err != nil {
+		return nil, nil, fmt.Errorf("insufficient stock for product %s: %w", productID, err)
+	}
+	if _, err := tx.Exec(ctx, `
+		UPDATE app_product_stock_sources SET quantity = quantity - $3, updated_at = now()
+		WHERE product_id = $1::uuid AND store_id = $2
+	`, productID, chosen.StoreID, qty); err != nil {
+		return nil, nil, fmt.Errorf("failed to debit stock source of product %s: %w", productID, err)
+	}
+	storeID := chosen.StoreID
+	return &storeID, &applied, nil
+}
+
+// storeLocation returns the coordinates of a store, nil if unknown
+func storeLocation(ctx context.Context, tx pgx.Tx, storeID *int) *geoPoint {
+	if storeID == nil {
+		return nil
+	}
+	var lat, lng *float64
+	if err := tx.QueryRow(ctx, `SELECT latitude, longitude FROM admin_stores WHERE store_id = $1`, *storeID).Scan(&lat, &lng); err != nil || lat == nil || lng == nil {
+		return nil
+	}
+	return &geoPoint{Lat: *lat, Lng: *lng}
+}
+
+// getStockSources lists a product's stock sources, lowest priority number first
+func (h *Handler) getStockSources(ctx context.Context, productID string) ([]models.StockSource, error) {
+	rows, err := h.db.Pool.Query(ctx, `
+		SELECT s.product_id::text, s.store_id, COALESCE(st.name, ''), s.quantity, s.priority, s.updated_at
+		FROM app_product_stock_sources s
+		LEFT JOIN admin_stores st ON st.store_id = s.store_id
+		WHERE s.product_id::text = $1
+		ORDER BY s.priority, s.store_id
+	`, productID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list stock sources: %w", err)
+	}
+	defer rows.Close()
+
+	sources := []models.StockSource{}
+	for rows.Next() {
+		var s models.StockSource
+		if err := rows.Scan(&s.ProductID, &s.StoreID, &s.StoreName, &s.Quantity, &s.Priority, &s.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan stock source: %w", err)
+		}
+		sources = append(sources, s)
+	}
+	return sources, rows.Err()
+}
+
+// setStockSources replaces a product's stock sources; pgx.ErrNoRows if the product does not exist
+func (h *Handler) setStockSources(ctx context.Context, productID string, sources []models.StockSourceInput) error {
+	tx, err := h.db.Pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	var exists bool
+	if err := tx.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM admin_products WHERE product_uuid::text = $1)`, productID).Scan(&exists); err != nil {
+		return fmt.Errorf("failed to look up product: %w", err)
+	}
+	if !exists {
+		return pgx.ErrNoRows
+	}
+
+	if _, err := tx.Exec(ctx, `DELETE FROM app_product_stock_sources WHERE product_id = $1::uuid`, productID); err != nil {
+		return fmt.Errorf("failed to clear stock sources: %w", err)
+	}
+	for _, s := range sources {
+		if _, err := tx.Exec(ctx, `
+			INSERT INTO app_product_stock_sources (product_id, store_id, quantity, priority)
+			VALUES ($1::uuid, $2, $3, $4)
+		`, productID, s.StoreID, s.Quantity, s.Priority); err != nil {
+			return fmt.Errorf("failed to save stock source for store %d: %w", s.StoreID, err)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit stock sources: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/expotoworld/expotoworld/backend/order-service/internal/models"
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+)
+
+// GetStockSources handles GET /api/admin/products/:product_id/stock-sources
+func (h *Handler) GetStockSources(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	sources, err := h.getStockSources(ctx, c.Param("product_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to get stock sources", Message: err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, models.SuccessResponse{
+		Message: "Stock sources retrieved successfully",
+		Data: gin.H{
+			"strategy": allocationStrategyFromEnv(),
+			"sources":  sources,
+		},
+	})
+}
+
+// SetStockSources handles PUT /api/admin/products/:product_id/stock-sources
+// Replaces the stores/warehouses a product ships from with their stock and allocation priority.
+func (h *Handler) SetStockSources(c *gin.Context) {
+	var req models.SetStockSourcesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid request data", Message: err.Error()})
+		return
+	}
+	seen := map[int]bool{}
+	for _, s := range req.Sources {
+		if seen[s.StoreID] {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid request data", Message: "Each store may appear only once"})
+			return
+		}
+		seen[s.StoreID] = true
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	productID := c.Param("product_id")
+	err := h.setStockSources(ctx, productID, req.Sources)
+	if errors.Is(err, pgx.ErrNoRows) {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "Product not found", Message: "No product with this ID"})
+		return
+	}
+	if err != nil {
+		status := http.StatusInternalServerError
+		if strings.Contains(err.Error(), "foreign key") {
+			status = http.StatusBadRequest
+		}
+		c.JSON(status, models.ErrorResponse{Error: "Failed to set stock sources", Message: err.Error()})
+		return
+	}
+
+	sources, err := h.getStockSources(ctx, productID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to get stock sources", Message: err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, models.SuccessResponse{
+		Message: "Stock sources updated successfully",
+		Data:    sources,
+	})
+}
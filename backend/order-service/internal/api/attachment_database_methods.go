@@ -0,0 +1,83 @@
+package api
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/expotoworld/expotoworld/backend/order-service/internal/models"
+	"github.com/jackc/pgx/v5"
+)
+
+const attachmentColumns = `id::text, order_id::text, doc_type, file_name, content_type, size_bytes, s3_key, visible_to, uploaded_by, uploader_role, COALESCE(note, ''), created_at`
+
+func scanAttachment(row pgx.Row, a *models.OrderAttachment) error {
+	return row.Scan(&a.ID, &a.OrderID, &a.DocType, &a.FileName, &a.ContentType, &a.SizeBytes, &a.S3Key,
+		&a.VisibleTo, &a.UploadedBy, &a.UploaderRole, &a.Note, &a.CreatedAt)
+}
+
+// getOrderAttachments returns all attachments of an order, oldest first
+func (h *Handler) getOrderAttachments(ctx context.Context, orderID string) ([]models.OrderAttachment, error) {
+	rows, err := h.db.Pool.Query(ctx, `SELECT `+attachmentColumns+` FROM app_order_attachments WHERE order_id::text = $1 ORDER BY created_at`, orderID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get attachments: %w", err)
+	}
+	defer rows.Close()
+
+	attachments := []models.OrderAttachment{}
+	for rows.Next() {
+		var a models.OrderAttachment
+		if err := scanAttachment(rows, &a); err != nil {
+			return nil, fmt.Errorf("failed to scan attachment: %w", err)
+		}
+		attachments = append(attachments, a)
+	}
+	return attachments, rows.Err()
+}
+
+// getOrderAttachment returns one attachment of an order
+func (h *Handler) getOrderAttachment(ctx context.Context, orderID, attachmentID string) (*models.OrderAttachment, error) {
+	var a models.OrderAttachment
+	err := scanAttachment(h.db.Pool.QueryRow(ctx, `SELECT `+attachmentColumns+` FROM app_order_attachments WHERE id::text = $1 AND order_id::text = $2`, attachmentID, orderID), &a)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, errAttachmentNotFound
+		}
+		return nil, fmt.Errorf("failed to get attachment: %w", err)
+	}
+	return &a, nil
+}
+
+// insertOrderAttachment records an uploaded document
+func (h *Handler) insertOrderAttachment(ctx context.Context, a *models.OrderAttachment) error {
+	err := h.db.Pool.QueryRow(ctx, `
+		INSERT INTO app_order_attachments (order_id, doc_type, file_name, content_type, size_bytes, s3_key, visible_to, uploaded_by, uploader_role, note)
+		VALUES ($1::uuid, $2, $3, $4, $5, $6, $7, $8, $9, NULLIF($10, ''))
+		RETURNING id::text, created_at
+	`, a.OrderID, string(a.DocType), a.FileName, a.ContentType, a.SizeBytes, a.S3Key, a.VisibleTo, a.UploadedBy, a.UploaderRole, a.Note).Scan(&a.ID, &a.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to save attachment: %w", err)
+	}
+	return nil
+}
+
+// deleteOrderAttachment removes an attachment record
+func (h *Handler) deleteOrderAttachment(ctx context.Context, attachmentID string) error {
+	if _, err := h.db.Pool.Exec(ctx, `DELETE FROM app_order_attachments WHERE id::text = $1`, attachmentID); err != nil {
+		return fmt.Errorf("failed to delete attachment: %w", err)
+	}
+	return nil
+}
+
+// orderOwnedBy reports whether the order exists and was placed by the user
+func (h *Handler) orderOwnedBy(ctx context.Context, orderID, userID string) (bool, error) {
+	var ok bool
+	err := h.db.Pool.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM app_orders WHERE id::text = $1 AND user_id::text = $2)`, orderID, userID).Scan(&ok)
+	return ok, err
+}
+
+// orderExists reports whether the order exists
+func (h *Handler) orderExists(ctx context.Context, orderID string) (bool, error) {
+	var ok bool
+	err := h.db.Pool.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM app_orders WHERE id::text = $1)`, orderID).Scan(&ok)
+	return ok, err
+}
@@ -0,0 +1,296 @@
+package api
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/expotoworld/expotoworld/backend/common/auth"
+	"github.com/expotoworld/expotoworld/backend/common/storage"
+	"github.com/expotoworld/expotoworld/backend/order-service/internal/models"
+	"github.com/gin-gonic/gin"
+)
+
+// Roles under which attachment routes are mounted. Admins see every attachment; manufacturers and
+// customers see those shared with their audience plus their own uploads.
+const (
+	AttachmentRoleAdmin        = "admin"
+	AttachmentRoleManufacturer = models.AttachmentAudienceManufacturer
+	AttachmentRoleCustomer     = models.AttachmentAudienceCustomer
+)
+
+var errAttachmentNotFound = errors.New("attachment not found")
+
+// allowedAttachmentTypes maps sniffed content types to the stored file extension
+var allowedAttachmentTypes = map[string]string{
+	"application/pdf": ".pdf",
+	"image/jpeg":      ".jpg",
+	"image/png":       ".png",
+	"image/webp":      ".webp",
+}
+
+// customerAttachmentTypes are the documents a customer may upload themselves
+var customerAttachmentTypes = map[models.AttachmentDocType]bool{
+	models.AttachmentDeliveryProof: true,
+	models.AttachmentDamagePhoto:   true,
+	models.AttachmentOther:         true,
+}
+
+type attachmentViewer struct {
+	Role   string
+	UserID string
+}
+
+func (v attachmentViewer) canSee(a *models.OrderAttachment) bool {
+	return v.Role == AttachmentRoleAdmin || a.UploadedBy == v.UserID || a.VisibleToAudience(v.Role)
+}
+
+func maxAttachmentBytes() int64 {
+	mb := 10
+	if v, err := strconv.Atoi(os.Getenv("ORDER_ATTACHMENT_MAX_MB")); err == nil && v > 0 {
+		mb = v
+	}
+	return int64(mb) << 20
+}
+
+// authorizeOrderAttachments verifies the caller may access the order in the given role
+func (h *Handler) authorizeOrderAttachments(ctx context.Context, c *gin.Context, orderID, role string) (attachmentViewer, bool) {
+	userID, ok := GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse{Error: "Invalid user", Message: "Could not extract user ID from token"})
+		return attachmentViewer{}, false
+	}
+	viewer := attachmentViewer{Role: role, UserID: userID}
+
+	var allowed bool
+	var err error
+	switch role {
+	case AttachmentRoleAdmin:
+		allowed, err = h.orderExists(ctx, orderID)
+	case AttachmentRoleManufacturer:
+		orgIDs := auth.OrgIDs(c, "Manufacturer")
+		if len(orgIDs) == 0 {
+			c.JSON(http.StatusForbidden, models.ErrorResponse{Error: "Not a manufacturer", Message: "No manufacturer organization memberships"})
+			return viewer, false
+		}
+		allowed, err = h.orderBelongsToAnyOrg(ctx, orderID, orgIDs)
+	default:
+		allowed, err = h.orderOwnedBy(ctx, orderID, userID)
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to verify order", Message: err.Error()})
+		return viewer, false
+	}
+	if !allowed {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "Order not found", Message: "Order not found or not accessible"})
+		return viewer, false
+	}
+	return viewer, true
+}
+
+// visibleAttachments returns the order's attachments the viewer may see; failures yield an empty list
+func (h *Handler) visibleAttachments(ctx context.Context, orderID string, viewer attachmentViewer) []models.OrderAttachment {
+	all, err := h.getOrderAttachments(ctx, orderID)
+	if err != nil {
+		fmt.Printf("Warning: Failed to load attachments for order %s: %v\n", orderID, err)
+		return []models.OrderAttachment{}
+	}
+	visible := make([]models.OrderAttachment, 0, len(all))
+	for i := range all {
+		if viewer.canSee(&all[i]) {
+			visible = append(visible, all[i])
+		}
+	}
+	return visible
+}
+
+// UploadOrderAttachment handles POST .../orders/:order_id/attachments (multipart)
+// Fields: file (PDF, JPEG, PNG or WebP), doc_type, optional note and, for admins, visible_to
+// (comma-separated: manufacturer, customer). Others share with the doc type's default audiences.
+func (h *Handler) UploadOrderAttachment(role string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if h.attachments == nil {
+			c.JSON(http.StatusServiceUnavailable, models.ErrorResponse{Error: "Attachments unavailable", Message: "Attachment storage is not configured"})
+			return
+		}
+		orderID := c.Param("order_id")
+		maxBytes := maxAttachmentBytes()
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxBytes+1<<20)
+
+		docType := models.AttachmentDocType(strings.TrimSpace(c.PostForm("doc_type")))
+		if !docType.IsValid() {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid doc_type", Message: "doc_type must be one of: customs_form, delivery_proof, damage_photo, invoice, other"})
+			return
+		}
+		if role == AttachmentRoleCustomer && !customerAttachmentTypes[docType] {
+			c.JSON(http.StatusForbidden, models.ErrorResponse{Error: "Forbidden", Message: "Customers may upload delivery_proof, damage_photo or other documents"})
+			return
+		}
+		fh, err := c.FormFile("file")
+		if err != nil {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid request", Message: "multipart field 'file' is required"})
+			return
+		}
+		if fh.Size <= 0 || fh.Size > maxBytes {
+			c.JSON(http.StatusRequestEntityTooLarge, models.ErrorResponse{Error: "File too large", Message: fmt.Sprintf("Attachments are limited to %d MB", maxBytes>>20)})
+			return
+		}
+
+		visibleTo := docType.DefaultAudiences()
+		if role == AttachmentRoleAdmin {
+			if raw, ok := c.GetPostForm("visible_to"); ok {
+				visibleTo = []string{}
+				for _, a := range strings.Split(raw, ",") {
+					a = strings.TrimSpace(a)
+					if a == "" {
+						continue
+					}
+					if a != models.AttachmentAudienceManufacturer && a != models.AttachmentAudienceCustomer {
+						c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid visible_to", Message: "visible_to may contain manufacturer and customer"})
+						return
+					}
+					visibleTo = append(visibleTo, a)
+				}
+			}
+		}
+
+		f, err := fh.Open()
+		if err != nil {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid request", Message: err.Error()})
+			return
+		}
+		defer f.Close()
+		head := make([]byte, 512)
+		n, _ := io.ReadFull(f, head)
+		contentType := http.DetectContentType(head[:n])
+		ext, ok := allowedAttachmentTypes[contentType]
+		if !ok {
+			c.JSON(http.StatusUnsupportedMediaType, models.ErrorResponse{Error: "Unsupported file type", Message: "Allowed types: PDF, JPEG, PNG, WebP (got " + contentType + ")"})
+			return
+		}
+		if _, err := f.Seek(0, io.SeekStart); err != nil {
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to read file", Message: err.Error()})
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+		defer cancel()
+		viewer, ok := h.authorizeOrderAttachments(ctx, c, orderID, role)
+		if !ok {
+			return
+		}
+
+		suffix := make([]byte, 16)
+		if _, err := rand.Read(suffix); err != nil {
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to upload attachment", Message: err.Error()})
+			return
+		}
+		attachment := &models.OrderAttachment{
+			OrderID:      orderID,
+			DocType:      docType,
+			FileName:     filepath.Base(fh.Filename),
+			ContentType:  contentType,
+			SizeBytes:    fh.Size,
+			VisibleTo:    visibleTo,
+			UploadedBy:   viewer.UserID,
+			UploaderRole: role,
+			Note:         strings.TrimSpace(c.PostForm("note")),
+			S3Key:        fmt.Sprintf("orders/%s/%s%s", orderID, hex.EncodeToString(suffix), ext),
+		}
+		if err := h.attachments.PutObject(ctx, attachment.S3Key, f, storage.ObjectMeta{ContentType: contentType, Size: fh.Size}); err != nil {
+			c.JSON(http.StatusBadGateway, models.ErrorResponse{Error: "Failed to upload attachment", Message: err.Error()})
+			return
+		}
+		if err := h.insertOrderAttachment(ctx, attachment); err != nil {
+			if delErr := h.attachments.Delete(ctx, attachment.S3Key); delErr != nil {
+				fmt.Printf("Warning: Failed to remove orphaned attachment %s: %v\n", attachment.S3Key, delErr)
+			}
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to upload attachment", Message: err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusCreated, models.SuccessResponse{Message: "Attachment uploaded successfully", Data: attachment})
+	}
+}
+
+// DownloadOrderAttachment handles GET .../orders/:order_id/attachments/:attachment_id/download
+// and returns a short-lived signed URL.
+func (h *Handler) DownloadOrderAttachment(role string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		orderID := c.Param("order_id")
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		viewer, ok := h.authorizeOrderAttachments(ctx, c, orderID, role)
+		if !ok {
+			return
+		}
+		attachment, err := h.getOrderAttachment(ctx, orderID, c.Param("attachment_id"))
+		if err == nil && !viewer.canSee(attachment) {
+			err = errAttachmentNotFound
+		}
+		if err != nil {
+			status := http.StatusInternalServerError
+			if errors.Is(err, errAttachmentNotFound) {
+				status = http.StatusNotFound
+			}
+			c.JSON(status, models.ErrorResponse{Error: "Attachment not available", Message: err.Error()})
+			return
+		}
+
+		ttl := 5 * time.Minute
+		url, err := h.attachments.DownloadURL(ctx, attachment.S3Key, attachment.FileName, ttl)
+		if err != nil {
+			c.JSON(http.StatusServiceUnavailable, models.ErrorResponse{Error: "Attachment unavailable", Message: err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"url": url, "expires_at": time.Now().Add(ttl), "file_name": attachment.FileName, "content_type": attachment.ContentType})
+	}
+}
+
+// DeleteOrderAttachment handles DELETE .../orders/:order_id/attachments/:attachment_id
+// Admins may delete any attachment; others only their own uploads.
+func (h *Handler) DeleteOrderAttachment(role string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		orderID := c.Param("order_id")
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		viewer, ok := h.authorizeOrderAttachments(ctx, c, orderID, role)
+		if !ok {
+			return
+		}
+		attachment, err := h.getOrderAttachment(ctx, orderID, c.Param("attachment_id"))
+		if err == nil && !viewer.canSee(attachment) {
+			err = errAttachmentNotFound
+		}
+		if err != nil {
+			status := http.StatusInternalServerError
+			if errors.Is(err, errAttachmentNotFound) {
+				status = http.StatusNotFound
+			}
+			c.JSON(status, models.ErrorResponse{Error: "Attachment not available", Message: err.Error()})
+			return
+		}
+		if role != AttachmentRoleAdmin && attachment.UploadedBy != viewer.UserID {
+			c.JSON(http.StatusForbidden, models.ErrorResponse{Error: "Forbidden", Message: "Only the uploader or an admin can delete this attachment"})
+			return
+		}
+
+		if err := h.deleteOrderAttachment(ctx, attachment.ID); err != nil {
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to delete attachment", Message: err.Error()})
+			return
+		}
+		if err := h.attachments.Delete(ctx, attachment.S3Key); err != nil {
+			fmt.Printf("Warning: Failed to delete attachment object %s: %v\n", attachment.S3Key, err)
+		}
+		c.JSON(http.StatusOK, models.SuccessResponse{Message: "Attachment deleted successfully"})
+	}
+}
@@ -2,92 +2,34 @@ package api
 
 import (
 	"net/http"
-	"os"
-	"strings"
 
+	"github.com/expotoworld/expotoworld/backend/common/auth"
+	"github.com/expotoworld/expotoworld/backend/common/rbac"
 	"github.com/expotoworld/expotoworld/backend/order-service/internal/models"
 	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt/v5"
 )
 
-// AuthMiddleware validates JWT tokens
-func AuthMiddleware() gin.HandlerFunc {
-	return func(c *gin.Context) {
-		authHeader := c.GetHeader("Authorization")
-		if authHeader == "" {
-			c.JSON(http.StatusUnauthorized, models.ErrorResponse{
-				Error:   "Authorization header required",
-				Message: "Please provide a valid authorization token",
-			})
-			c.Abort()
-			return
-		}
-
-		// Extract token from "Bearer <token>"
-		tokenParts := strings.Split(authHeader, " ")
-		if len(tokenParts) != 2 || tokenParts[0] != "Bearer" {
-			c.JSON(http.StatusUnauthorized, models.ErrorResponse{
-				Error:   "Invalid authorization format",
-				Message: "Authorization header must be in format 'Bearer <token>'",
-			})
-			c.Abort()
-			return
-		}
-
-		tokenString := tokenParts[1]
-
-		// Parse and validate token
-		secret := os.Getenv("JWT_SECRET")
-		if secret == "" {
-			c.JSON(http.StatusInternalServerError, models.ErrorResponse{
-				Error:   "Server not configured",
-				Message: "JWT secret missing",
-			})
-			c.Abort()
-			return
-		}
-
-		token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, jwt.ErrSignatureInvalid
-			}
-			return []byte(secret), nil
-		})
-
-		if err != nil || !token.Valid {
-			c.JSON(http.StatusUnauthorized, models.ErrorResponse{
-				Error:   "Invalid token",
-				Message: "The provided token is invalid or expired",
-			})
-			c.Abort()
-			return
-		}
+// authConfig verifies auth-service tokens; regional admins' admin_scope claim is parsed for
+// AdminScopeMiddleware
+var authConfig = auth.Config{
+	AdminScope: func(v interface{}) interface{} { return parseAdminScope(v) },
+}
 
-		// Extract claims
-		if claims, ok := token.Claims.(jwt.MapClaims); ok {
-			c.Set("user_id", claims["user_id"])
-			c.Set("email", claims["email"])
-			if r, ok := claims["role"].(string); ok {
-				c.Set("role", r)
-			}
-			if orgs, ok := claims["org_memberships"]; ok {
-				c.Set("org_memberships", orgs)
-			}
-		}
+// CheckTokenVersions makes the auth middleware reject tokens revoked since they were issued (forced
+// logout, sign-in method change); call it before setting up the routes
+func CheckTokenVersions(versions auth.VersionChecker) {
+	authConfig.Versions = versions
+}
 
-		c.Next()
-	}
+// AuthMiddleware validates JWT tokens
+func AuthMiddleware() gin.HandlerFunc {
+	return authConfig.Required()
 }
 
 // GetUserID extracts user ID from the JWT token claims
 func GetUserID(c *gin.Context) (string, bool) {
-	userID, exists := c.Get("user_id")
-	if !exists {
-		return "", false
-	}
-
-	userIDStr, ok := userID.(string)
-	return userIDStr, ok
+	userID := auth.UserID(c)
+	return userID, userID != ""
 }
 
 // ValidateMiniAppType validates and returns the mini-app type from URL parameter
@@ -106,19 +48,7 @@ func ValidateMiniAppType(c *gin.Context) (models.MiniAppType, bool) {
 	return miniAppType, true
 }
 
-// AdminMiddleware ensures the user has strict Admin role for admin endpoints
+// AdminMiddleware ensures the token grants the orders:manage permission for admin endpoints
 func AdminMiddleware() gin.HandlerFunc {
-	return func(c *gin.Context) {
-		roleVal, exists := c.Get("role")
-		role, _ := roleVal.(string)
-		if !exists || role != "Admin" {
-			c.JSON(http.StatusForbidden, models.ErrorResponse{
-				Error:   "Admin access required",
-				Message: "Admin role required",
-			})
-			c.Abort()
-			return
-		}
-		c.Next()
-	}
+	return authConfig.RequirePermission(rbac.OrdersManage)
 }
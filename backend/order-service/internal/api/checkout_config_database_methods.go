@@ -0,0 +1,130 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/expotoworld/expotoworld/backend/order-service/internal/models"
+	"github.com/jackc/pgx/v5"
+)
+
+// allMiniAppTypes lists the mini-apps in display order
+var allMiniAppTypes = []models.MiniAppType{
+	models.MiniAppTypeRetailStore,
+	models.MiniAppTypeUnmannedStore,
+	models.MiniAppTypeExhibitionSales,
+	models.MiniAppTypeGroupBuying,
+}
+
+// defaultCheckoutConfig is the configuration of a mini-app without stored rules: unrestricted and without fees
+func defaultCheckoutConfig(miniAppType models.MiniAppType) *models.CheckoutConfig {
+	return &models.CheckoutConfig{
+		MiniAppType:     miniAppType,
+		PaymentMethods:  []string{},
+		DeliveryOptions: []models.DeliveryOption{},
+	}
+}
+
+const checkoutConfigColumns = `mini_app_type, payment_methods, min_order_value, delivery_options::text, service_fee, updated_by, updated_at`
+
+func scanCheckoutConfig(row pgx.Row) (*models.CheckoutConfig, error) {
+	var cfg models.CheckoutConfig
+	var options string
+	if err := row.Scan(&cfg.MiniAppType, &cfg.PaymentMethods, &cfg.MinOrderValue, &options, &cfg.ServiceFee,
+		&cfg.UpdatedBy, &cfg.UpdatedAt); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(options), &cfg.DeliveryOptions); err != nil {
+		return nil, fmt.Errorf("failed to decode delivery options: %w", err)
+	}
+	if cfg.PaymentMethods == nil {
+		cfg.PaymentMethods = []string{}
+	}
+	if cfg.DeliveryOptions == nil {
+		cfg.DeliveryOptions = []models.DeliveryOption{}
+	}
+	return &cfg, nil
+}
+
+// getCheckoutConfig returns the checkout rules of a mini-app, or the defaults if none are stored
+func (h *Handler) getCheckoutConfig(ctx context.Context, miniAppType models.MiniAppType) (*models.CheckoutConfig, error) {
+	cfg, err := scanCheckoutConfig(h.db.Pool.QueryRow(ctx, `
+		SELECT `+checkoutConfigColumns+` FROM app_checkout_configs WHERE mini_app_type = $1
+	`, string(miniAppType)))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return defaultCheckoutConfig(miniAppType), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get checkout config: %w", err)
+	}
+	return cfg, nil
+}
+
+// getCheckoutConfigs returns the checkout rules of every mini-app, defaults included
+func (h *Handler) getCheckoutConfigs(ctx context.Context) ([]models.CheckoutConfig, error) {
+	rows, err := h.db.Pool.Query(ctx, `SELECT `+checkoutConfigColumns+` FROM app_checkout_configs`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list checkout configs: %w", err)
+	}
+	defer rows.Close()
+
+	stored := map[models.MiniAppType]*models.CheckoutConfig{}
+	for rows.Next() {
+		cfg, err := scanCheckoutConfig(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan checkout config: %w", err)
+		}
+		stored[cfg.MiniAppType] = cfg
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list checkout configs: %w", err)
+	}
+
+	configs := make([]models.CheckoutConfig, 0, len(allMiniAppTypes))
+	for _, t := range allMiniAppTypes {
+		if cfg, ok := stored[t]; ok {
+			configs = append(configs, *cfg)
+		} else {
+			configs = append(configs, *defaultCheckoutConfig(t))
+		}
+	}
+	return configs, nil
+}
+
+// setCheckoutConfig creates or replaces the checkout rules of a mini-app
+func (h *Handler) setCheckoutConfig(ctx context.Context, miniAppType models.MiniAppType, req *models.SetCheckoutConfigRequest, actorID string) (*models.CheckoutConfig, error) {
+	options, err := json.Marshal(req.DeliveryOptions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode delivery options: %w", err)
+	}
+	cfg, err := scanCheckoutConfig(h.db.Pool.QueryRow(ctx, `
+		INSERT INTO app_checkout_configs (mini_app_type, payment_methods, min_order_value, delivery_options, service_fee, updated_by, updated_at)
+		VALUES ($1, $2, $3, $4::jsonb, $5, NULLIF($6, ''), now())
+		ON CONFLICT (mini_app_type) DO UPDATE SET
+			payment_methods = EXCLUDED.payment_methods,
+			min_order_value = EXCLUDED.min_order_value,
+			delivery_options = EXCLUDED.delivery_options,
+			service_fee = EXCLUDED.service_fee,
+			updated_by = EXCLUDED.updated_by,
+			updated_at = now()
+		RETURNING `+checkoutConfigColumns,
+		string(miniAppType), req.PaymentMethods, req.MinOrderValue, string(options), req.ServiceFee, actorID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to set checkout config: %w", err)
+	}
+	return cfg, nil
+}
+
+// deleteCheckoutConfig restores the defaults of a mini-app; pgx.ErrNoRows if it had no stored rules
+func (h *Handler) deleteCheckoutConfig(ctx context.Context, miniAppType models.MiniAppType) error {
+	tag, err := h.db.Pool.Exec(ctx, `DELETE FROM app_checkout_configs WHERE mini_app_type = $1`, string(miniAppType))
+	if err != nil {
+		return fmt.Errorf("failed to delete checkout config: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
@@ -0,0 +1,205 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/expotoworld/expotoworld/backend/order-service/internal/models"
+	"github.com/expotoworld/expotoworld/backend/order-service/internal/money"
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+)
+
+// checkoutCharges are the checkout choices and fees resolved for an order
+type checkoutCharges struct {
+	PaymentMethod  string
+	DeliveryOption string
+	DeliveryFee    money.Amount
+	ServiceFee     money.Amount
+}
+
+// Total is the amount charged on top of the items
+func (ch *checkoutCharges) Total() money.Amount {
+	return ch.DeliveryFee + ch.ServiceFee
+}
+
+// resolveCheckout applies a mini-app's checkout rules to an order of the given subtotal.
+// Empty choices take the configured defaults; an empty message means the order is allowed.
+func resolveCheckout(cfg *models.CheckoutConfig, req *models.CreateOrderRequest, subtotal money.Amount) (*checkoutCharges, string) {
+	ch := &checkoutCharges{
+		PaymentMethod:  strings.ToLower(strings.TrimSpace(req.PaymentMethod)),
+		DeliveryOption: strings.TrimSpace(req.DeliveryOption),
+		ServiceFee:     cfg.ServiceFee,
+	}
+
+	if len(cfg.PaymentMethods) > 0 {
+		if ch.PaymentMethod == "" {
+			ch.PaymentMethod = cfg.PaymentMethods[0]
+		} else if !containsString(cfg.PaymentMethods, ch.PaymentMethod) {
+			return nil, fmt.Sprintf("Payment method must be one of: %s", strings.Join(cfg.PaymentMethods, ", "))
+		}
+	}
+
+	if subtotal < cfg.MinOrderValue {
+		return nil, fmt.Sprintf("Minimum order value is %s", cfg.MinOrderValue.Format(money.DefaultCurrency))
+	}
+
+	if len(cfg.DeliveryOptions) == 0 {
+		if ch.DeliveryOption != "" {
+			return nil, "This mini-app does not offer delivery options"
+		}
+		return ch, ""
+	}
+	if ch.DeliveryOption == "" {
+		ch.DeliveryOption = cfg.DeliveryOptions[0].Code
+	}
+	codes := make([]string, 0, len(cfg.DeliveryOptions))
+	for _, opt := range cfg.DeliveryOptions {
+		if opt.Code == ch.DeliveryOption {
+			ch.DeliveryFee = opt.Fee
+			return ch, ""
+		}
+		codes = append(codes, opt.Code)
+	}
+	return nil, fmt.Sprintf("Delivery option must be one of: %s", strings.Join(codes, ", "))
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeCheckoutConfigRequest lowercases payment methods and rejects duplicates; an empty message means valid
+func normalizeCheckoutConfigRequest(req *models.SetCheckoutConfigRequest) string {
+	methods := make([]string, 0, len(req.PaymentMethods))
+	for _, m := range req.PaymentMethods {
+		m = strings.ToLower(strings.TrimSpace(m))
+		if m == "" {
+			return "Payment methods must not be blank"
+		}
+		if containsString(methods, m) {
+			return fmt.Sprintf("Duplicate payment method: %s", m)
+		}
+		methods = append(methods, m)
+	}
+	req.PaymentMethods = methods
+
+	codes := make([]string, 0, len(req.DeliveryOptions))
+	for i := range req.DeliveryOptions {
+		opt := &req.DeliveryOptions[i]
+		opt.Code = strings.TrimSpace(opt.Code)
+		opt.Label = strings.TrimSpace(opt.Label)
+		if opt.Code == "" {
+			return "Delivery option codes must not be blank"
+		}
+		if containsString(codes, opt.Code) {
+			return fmt.Sprintf("Duplicate delivery option: %s", opt.Code)
+		}
+		codes = append(codes, opt.Code)
+	}
+	if req.DeliveryOptions == nil {
+		req.DeliveryOptions = []models.DeliveryOption{}
+	}
+	return ""
+}
+
+// GetCheckoutConfig handles GET /api/checkout-config/:mini_app_type
+// Lets the app show the payment methods, delivery options and fees before checkout.
+func (h *Handler) GetCheckoutConfig(c *gin.Context) {
+	miniAppType, ok := ValidateMiniAppType(c)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	cfg, err := h.getCheckoutConfig(ctx, miniAppType)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to get checkout config", Message: err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, models.SuccessResponse{
+		Message: "Checkout config retrieved successfully",
+		Data:    cfg,
+	})
+}
+
+// GetCheckoutConfigs handles GET /api/admin/checkout-configs
+func (h *Handler) GetCheckoutConfigs(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	configs, err := h.getCheckoutConfigs(ctx)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to get checkout configs", Message: err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, models.SuccessResponse{
+		Message: "Checkout configs retrieved successfully",
+		Data:    configs,
+	})
+}
+
+// SetCheckoutConfig handles PUT /api/admin/checkout-configs/:mini_app_type
+func (h *Handler) SetCheckoutConfig(c *gin.Context) {
+	miniAppType, ok := ValidateMiniAppType(c)
+	if !ok {
+		return
+	}
+	var req models.SetCheckoutConfigRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid request data", Message: err.Error()})
+		return
+	}
+	if msg := normalizeCheckoutConfigRequest(&req); msg != "" {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid request data", Message: msg})
+		return
+	}
+
+	adminUserID, _ := GetUserID(c)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	cfg, err := h.setCheckoutConfig(ctx, miniAppType, &req, adminUserID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to set checkout config", Message: err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, models.SuccessResponse{
+		Message: "Checkout config updated successfully",
+		Data:    cfg,
+	})
+}
+
+// DeleteCheckoutConfig handles DELETE /api/admin/checkout-configs/:mini_app_type
+// The mini-app falls back to unrestricted checkout without fees.
+func (h *Handler) DeleteCheckoutConfig(c *gin.Context) {
+	miniAppType, ok := ValidateMiniAppType(c)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	err := h.deleteCheckoutConfig(ctx, miniAppType)
+	if errors.Is(err, pgx.ErrNoRows) {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "Checkout config not found", Message: "No checkout config for this mini-app"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to delete checkout config", Message: err.Error()})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
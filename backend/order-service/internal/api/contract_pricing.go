@@ -0,0 +1,66 @@
+package api
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/expotoworld/expotoworld/backend/order-service/internal/models"
+	"github.com/expotoworld/expotoworld/backend/order-service/internal/money"
+)
+
+// getContractPrices returns the effective contract price per product_uuid for the given organizations.
+// Prices come from the app_effective_org_prices view maintained by catalog-service; when a user
+// belongs to several partner organizations the lowest contract price applies.
+func (h *Handler) getContractPrices(ctx context.Context, orgIDs []string, productUUIDs []string) (map[string]money.Amount, error) {
+	prices := map[string]money.Amount{}
+	if len(orgIDs) == 0 || len(productUUIDs) == 0 {
+		return prices, nil
+	}
+	rows, err := h.db.Pool.Query(ctx, `
+		SELECT p.product_uuid::text, MIN(ep.price)
+		FROM app_effective_org_prices ep
+		JOIN admin_products p ON p.product_id = ep.product_id
+		WHERE ep.org_id::text = ANY($1) AND p.product_uuid::text = ANY($2)
+		GROUP BY p.product_uuid
+	`, orgIDs, productUUIDs)
+	if err != nil {
+		return nil, fmt.Errorf("getContractPrices: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id string
+		var price money.Amount
+		if err := rows.Scan(&id, &price); err != nil {
+			return nil, fmt.Errorf("getContractPrices: %w", err)
+		}
+		prices[id] = price
+	}
+	return prices, rows.Err()
+}
+
+// applyContractPrices overrides each cart item's unit price with the caller's contract price, if any.
+// Items without a contract price keep the default main_price.
+func (h *Handler) applyContractPrices(ctx context.Context, orgIDs []string, cartItems []models.Cart) error {
+	if len(orgIDs) == 0 {
+		return nil
+	}
+	ids := make([]string, 0, len(cartItems))
+	for _, item := range cartItems {
+		ids = append(ids, item.ProductID)
+	}
+	prices, err := h.getContractPrices(ctx, orgIDs, ids)
+	if err != nil {
+		return err
+	}
+	for i := range cartItems {
+		price, ok := prices[cartItems[i].ProductID]
+		if !ok || cartItems[i].Product == nil {
+			continue
+		}
+		// Copy so the contract price never leaks into shared product data
+		product := *cartItems[i].Product
+		product.MainPrice = price
+		cartItems[i].Product = &product
+	}
+	return nil
+}
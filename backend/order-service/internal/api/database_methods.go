@@ -3,9 +3,11 @@ package api
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/expotoworld/expotoworld/backend/order-service/internal/logging"
 	"github.com/expotoworld/expotoworld/backend/order-service/internal/models"
+	"github.com/expotoworld/expotoworld/backend/order-service/internal/money"
 )
 
 // getCartItems gets all cart items for a user and mini-app type
@@ -346,8 +348,46 @@ func (h *Handler) validateCartStockBeforeOrder(ctx context.Context, cartItems []
 	return nil
 }
 
+// orderOrigin describes how an order was captured; the zero value is a regular online checkout
+type orderOrigin struct {
+	Channel     models.OrderChannel
+	ExternalRef string
+	Status      models.OrderStatus
+	PlacedAt    *time.Time
+	ActorID     string
+	Note        string
+	// DeductStock takes stock for every item inside the order transaction, failing the order when short
+	DeductStock bool
+	// Gift ships the order to a recipient other than the buyer
+	Gift *models.OrderGift
+	// Location is the delivery point for nearest-store allocation; defaults to the order's store
+	Location *geoPoint
+	// Checkout records the payment method, delivery option and fees included in the total
+	Checkout *checkoutCharges
+}
+
 // createOrder creates a new order with items
-func (h *Handler) createOrder(ctx context.Context, userID string, miniAppType models.MiniAppType, storeID *int, totalAmount float64, cartItems []models.Cart) (*models.Order, error) {
+func (h *Handler) createOrder(ctx context.Context, userID string, miniAppType models.MiniAppType, storeID *int, totalAmount money.Amount, cartItems []models.Cart) (*models.Order, error) {
+	return h.createOrderWithOrigin(ctx, userID, miniAppType, storeID, totalAmount, cartItems, orderOrigin{})
+}
+
+// createOrderWithOrigin creates a new order with items, recording its channel and initial status
+func (h *Handler) createOrderWithOrigin(ctx context.Context, userID string, miniAppType models.MiniAppType, storeID *int, totalAmount money.Amount, cartItems []models.Cart, origin orderOrigin) (*models.Order, error) {
+	if origin.Channel == "" {
+		origin.Channel = models.OrderChannelOnline
+	}
+	if origin.Status == "" {
+		origin.Status = models.OrderStatusPending
+	}
+	if origin.ActorID == "" {
+		origin.ActorID = userID
+	}
+
+	checkout := origin.Checkout
+	if checkout == nil {
+		checkout = &checkoutCharges{}
+	}
+
 	// Start transaction
 	tx, err := h.db.Pool.Begin(ctx)
 	if err != nil {
@@ -358,41 +398,100 @@ func (h *Handler) createOrder(ctx context.Context, userID string, miniAppType mo
 	// Create order
 	var order models.Order
 	orderQuery := `
-		INSERT INTO app_orders (user_id, mini_app_type, total_amount, status)
-		VALUES ($1, $2, $3, $4)
-		RETURNING id, user_id, mini_app_type, total_amount, status, created_at, updated_at
+		INSERT INTO app_orders (user_id, mini_app_type, total_amount, status, channel, external_ref, created_at, store_id,
+			payment_method, delivery_option, delivery_fee, service_fee)
+		VALUES ($1, $2, $3, $4, $5, NULLIF($6, ''), COALESCE($7, CURRENT_TIMESTAMP), $8,
+			NULLIF($9, ''), NULLIF($10, ''), $11, $12)
+		RETURNING id, user_id, mini_app_type, total_amount, currency, status, channel,
+			payment_method, delivery_option, delivery_fee, service_fee, created_at, updated_at
 	`
 
-	err = tx.QueryRow(ctx, orderQuery, userID, string(miniAppType), totalAmount, string(models.OrderStatusPending)).Scan(
+	err = tx.QueryRow(ctx, orderQuery, userID, string(miniAppType), totalAmount, string(origin.Status),
+		string(origin.Channel), origin.ExternalRef, origin.PlacedAt, storeID,
+		checkout.PaymentMethod, checkout.DeliveryOption, checkout.DeliveryFee, checkout.ServiceFee).Scan(
 		&order.ID,
 		&order.UserID,
 		&order.MiniAppType,
 		&order.TotalAmount,
+		&order.Currency,
 		&order.Status,
+		&order.Channel,
+		&order.PaymentMethod,
+		&order.DeliveryOption,
+		&order.DeliveryFee,
+		&order.ServiceFee,
 		&order.CreatedAt,
 		&order.UpdatedAt,
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create order: %w", err)
 	}
+	if err = insertOrderEvent(ctx, tx, order.ID, models.OrderEventCreated, nil, &order.Status, origin.Note, origin.ActorID, &order.CreatedAt); err != nil {
+		return nil, err
+	}
+	if origin.Gift != nil {
+		if err = insertOrderGift(ctx, tx, order.ID, origin.Gift); err != nil {
+			return nil, err
+		}
+		gift := *origin.Gift
+		gift.OrderID, gift.CreatedAt = order.ID, order.CreatedAt
+		order.Gift = &gift
+	}
+	if order.TotalAmount > 0 {
+		if err = postLedger(ctx, tx, order.ID, models.LedgerEntryCharge, models.LedgerAccountReceivable, models.LedgerAccountRevenue,
+			order.TotalAmount, "Order placed", origin.ExternalRef, origin.ActorID, &order.CreatedAt); err != nil {
+			return nil, err
+		}
+	}
+
+	if origin.DeductStock {
+		for _, item := range cartItems {
+			tag, err := tx.Exec(ctx, `
+				UPDATE admin_products
+				SET stock_left = stock_left - $1, updated_at = CURRENT_TIMESTAMP
+				WHERE product_uuid = $2 AND stock_left >= $1
+			`, item.Quantity, item.ProductID)
+			if err != nil {
+				return nil, fmt.Errorf("failed to update stock for product %s: %w", item.ProductID, err)
+			}
+			if tag.RowsAffected() == 0 {
+				return nil, fmt.Errorf("insufficient stock for product %s", item.ProductID)
+			}
+		}
+	}
 
 	// Resolve organization relationships for routing/notifications
 	partners, _ := h.getPartnersForStore(ctx, storeID)
 
+	// Allocate each line to a store/warehouse holding its stock
+	strategy := allocationStrategyFromEnv()
+	location := origin.Location
+	if location == nil && strategy == models.AllocationNearest {
+		location = storeLocation(ctx, tx, storeID)
+	}
+
 	// Create order items
 	var orderItems []models.OrderItem
 	for _, cartItem := range cartItems {
 		unitPrice := cartItem.Product.MainPrice
-		totalPrice := float64(cartItem.Quantity) * unitPrice
+		totalPrice := unitPrice.Mul(cartItem.Quantity)
+
+		sourceStoreID, appliedStrategy, err := allocateStock(ctx, tx, cartItem.ProductID, cartItem.Quantity, strategy, location)
+		if err != nil {
+			return nil, err
+		}
+		if sourceStoreID == nil {
+			sourceStoreID = storeID
+		}
 
 		var orderItem models.OrderItem
 		itemQuery := `
-			INSERT INTO app_order_items (order_id, product_id, quantity, price)
-			VALUES ($1, $2, $3, $4)
+			INSERT INTO app_order_items (order_id, product_id, quantity, price, source_store_id, allocation_strategy)
+			VALUES ($1, $2, $3, $4, $5, $6)
 			RETURNING id, order_id, product_id, quantity, price
 		`
 
-		err = tx.QueryRow(ctx, itemQuery, order.ID, cartItem.ProductID, cartItem.Quantity, totalPrice).Scan(
+		err = tx.QueryRow(ctx, itemQuery, order.ID, cartItem.ProductID, cartItem.Quantity, totalPrice, sourceStoreID, appliedStrategy).Scan(
 			&orderItem.ID,
 			&orderItem.OrderID,
 			&orderItem.ProductID,
@@ -404,6 +503,8 @@ func (h *Handler) createOrder(ctx context.Context, userID string, miniAppType mo
 		}
 
 		orderItem.UnitPrice = unitPrice
+		orderItem.SourceStoreID = sourceStoreID
+		orderItem.AllocationStrategy = appliedStrategy
 		orderItem.Product = cartItem.Product
 
 		// Resolve per-item organizations
@@ -432,11 +533,14 @@ func (h *Handler) createOrder(ctx context.Context, userID string, miniAppType mo
 		return nil, fmt.Errorf("failed to commit transaction: %w", err)
 	}
 
-	// Update product stock levels after successful order creation (only for UnmannedStore)
-	if err = h.updateProductStock(ctx, cartItems, miniAppType); err != nil {
-		// Log error but don't fail the order creation since the order was already committed
-		// In a production system, you might want to implement compensation logic here
-		fmt.Printf("Warning: Failed to update product stock after order creation: %v\n", err)
+	// Update product stock levels after successful order creation (only for UnmannedStore);
+	// orders that deducted stock in the transaction are already done
+	if !origin.DeductStock {
+		if err = h.updateProductStock(ctx, cartItems, miniAppType); err != nil {
+			// Log error but don't fail the order creation since the order was already committed
+			// In a production system, you might want to implement compensation logic here
+			fmt.Printf("Warning: Failed to update product stock after order creation: %v\n", err)
+		}
 	}
 
 	order.Items = orderItems
@@ -469,7 +573,8 @@ func (h *Handler) createOrder(ctx context.Context, userID string, miniAppType mo
 // getUserOrders retrieves all orders for a user and mini-app type
 func (h *Handler) getUserOrders(ctx context.Context, userID string, miniAppType models.MiniAppType) ([]models.Order, error) {
 	query := `
-		SELECT id, user_id, mini_app_type, total_amount, status, created_at, updated_at
+		SELECT id, user_id, mini_app_type, total_amount, currency, status,
+			payment_method, delivery_option, delivery_fee, service_fee, created_at, updated_at
 		FROM app_orders
 		WHERE user_id = $1 AND mini_app_type = $2
 		ORDER BY created_at DESC
@@ -489,7 +594,12 @@ func (h *Handler) getUserOrders(ctx context.Context, userID string, miniAppType
 			&order.UserID,
 			&order.MiniAppType,
 			&order.TotalAmount,
+			&order.Currency,
 			&order.Status,
+			&order.PaymentMethod,
+			&order.DeliveryOption,
+			&order.DeliveryFee,
+			&order.ServiceFee,
 			&order.CreatedAt,
 			&order.UpdatedAt,
 		)
@@ -518,7 +628,8 @@ func (h *Handler) getUserOrders(ctx context.Context, userID string, miniAppType
 func (h *Handler) getOrderByID(ctx context.Context, orderID string, userID string) (*models.Order, error) {
 	var order models.Order
 	query := `
-		SELECT id, user_id, mini_app_type, total_amount, status, created_at, updated_at
+		SELECT id, user_id, mini_app_type, total_amount, currency, status,
+			payment_method, delivery_option, delivery_fee, service_fee, created_at, updated_at
 		FROM app_orders
 		WHERE id = $1 AND user_id = $2
 	`
@@ -528,13 +639,21 @@ func (h *Handler) getOrderByID(ctx context.Context, orderID string, userID strin
 		&order.UserID,
 		&order.MiniAppType,
 		&order.TotalAmount,
+		&order.Currency,
 		&order.Status,
+		&order.PaymentMethod,
+		&order.DeliveryOption,
+		&order.DeliveryFee,
+		&order.ServiceFee,
 		&order.CreatedAt,
 		&order.UpdatedAt,
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get order: %w", err)
 	}
+	if order.Gift, err = getOrderGift(ctx, h.db.Pool, order.ID); err != nil {
+		return nil, err
+	}
 
 	// Get order items
 	items, err := h.getOrderItems(ctx, order.ID)
@@ -551,6 +670,7 @@ func (h *Handler) getOrderItems(ctx context.Context, orderID string) ([]models.O
 	query := `
 		SELECT
 			oi.id, oi.order_id, oi.product_id, oi.quantity, oi.price,
+			oi.source_store_id, oi.allocation_strategy,
 			p.product_uuid, p.sku, p.title, p.main_price, p.stock_left,
 			p.minimum_order_quantity, p.is_active
 		FROM app_order_items oi
@@ -576,6 +696,8 @@ func (h *Handler) getOrderItems(ctx context.Context, orderID string) ([]models.O
 			&item.ProductID,
 			&item.Quantity,
 			&item.TotalPrice,
+			&item.SourceStoreID,
+			&item.AllocationStrategy,
 			&product.ID,
 			&product.SKU,
 			&product.Title,
@@ -0,0 +1,41 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/expotoworld/expotoworld/backend/order-service/internal/models"
+	"github.com/jackc/pgx/v5"
+)
+
+// insertOrderGift stores the gift recipient for a new order
+func insertOrderGift(ctx context.Context, q execer, orderID string, g *models.OrderGift) error {
+	_, err := q.Exec(ctx, `
+		INSERT INTO app_order_gifts (order_id, recipient_name, recipient_phone, address_line1, address_line2, postal_code, city, country, message, hide_prices)
+		VALUES ($1, $2, NULLIF($3, ''), $4, NULLIF($5, ''), $6, $7, $8, NULLIF($9, ''), $10)
+	`, orderID, g.RecipientName, g.RecipientPhone, g.AddressLine1, g.AddressLine2, g.PostalCode, g.City, g.Country, g.Message, g.HidePrices)
+	if err != nil {
+		return fmt.Errorf("failed to save gift details: %w", err)
+	}
+	return nil
+}
+
+// getOrderGift returns the order's gift details, or nil if it is not a gift
+func getOrderGift(ctx context.Context, q queryRower, orderID string) (*models.OrderGift, error) {
+	var g models.OrderGift
+	err := q.QueryRow(ctx, `
+		SELECT order_id::text, recipient_name, COALESCE(recipient_phone, ''), address_line1, COALESCE(address_line2, ''),
+			postal_code, city, country, COALESCE(message, ''), hide_prices, created_at
+		FROM app_order_gifts
+		WHERE order_id::text = $1
+	`, orderID).Scan(&g.OrderID, &g.RecipientName, &g.RecipientPhone, &g.AddressLine1, &g.AddressLine2,
+		&g.PostalCode, &g.City, &g.Country, &g.Message, &g.HidePrices, &g.CreatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get gift details: %w", err)
+	}
+	return &g, nil
+}
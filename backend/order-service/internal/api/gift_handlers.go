@@ -0,0 +1,169 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"html/template"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/expotoworld/expotoworld/backend/order-service/internal/models"
+	"github.com/gin-gonic/gin"
+)
+
+// giftFromRequest validates checkout gift details; an empty message means valid
+func giftFromRequest(req *models.OrderGiftRequest) (*models.OrderGift, string) {
+	g := &models.OrderGift{
+		RecipientName:  strings.TrimSpace(req.RecipientName),
+		RecipientPhone: strings.TrimSpace(req.RecipientPhone),
+		AddressLine1:   strings.TrimSpace(req.AddressLine1),
+		AddressLine2:   strings.TrimSpace(req.AddressLine2),
+		PostalCode:     strings.TrimSpace(req.PostalCode),
+		City:           strings.TrimSpace(req.City),
+		Country:        strings.ToUpper(strings.TrimSpace(req.Country)),
+		Message:        strings.TrimSpace(req.Message),
+		HidePrices:     true,
+	}
+	if req.HidePrices != nil {
+		g.HidePrices = *req.HidePrices
+	}
+	switch {
+	case g.RecipientName == "":
+		return nil, "recipient_name is required"
+	case g.AddressLine1 == "" || g.PostalCode == "" || g.City == "":
+		return nil, "address_line1, postal_code and city are required"
+	case len(g.Country) != 2 || strings.Trim(g.Country, "ABCDEFGHIJKLMNOPQRSTUVWXYZ") != "":
+		return nil, "country must be an ISO 3166-1 alpha-2 code"
+	}
+	return g, ""
+}
+
+type documentLine struct {
+	SKU       string
+	Title     string
+	Quantity  int
+	UnitPrice string
+	LineTotal string
+}
+
+// orderDocument is the data behind a printable packing slip or invoice
+type orderDocument struct {
+	Type       models.OrderDocumentType
+	Title      string
+	Company    string
+	OrderID    string
+	OrderRef   string
+	Date       string
+	BuyerName  string
+	BuyerEmail string
+	Gift       *models.OrderGift
+	ShowPrices bool
+	Lines      []documentLine
+	Total      string
+}
+
+var orderDocumentTemplate = template.Must(template.New("document").Parse(`<!DOCTYPE html>
+<html><head><meta charset="utf-8"><title>{{.Title}} {{.OrderRef}}</title>
+<style>
+body{font-family:Helvetica,Arial,sans-serif;font-size:13px;margin:32px;color:#222}
+h1{font-size:20px;margin:0 0 4px}
+table{width:100%;border-collapse:collapse;margin-top:16px}
+th,td{text-align:left;padding:6px;border-bottom:1px solid #ddd}
+td.num,th.num{text-align:right}
+.cols{display:flex;gap:48px;margin-top:16px}
+.gift{margin-top:20px;padding:12px;border:1px dashed #999;white-space:pre-wrap}
+</style></head><body>
+<h1>{{.Title}}</h1>
+<div>{{.Company}} &middot; Order {{.OrderRef}} &middot; {{.Date}}</div>
+<div class="cols">
+{{if eq .Type "invoice"}}<div><strong>Billed to</strong><br>{{.BuyerName}}<br>{{.BuyerEmail}}</div>{{end}}
+{{with .Gift}}<div><strong>Ship to</strong><br>{{.RecipientName}}<br>{{.AddressLine1}}<br>{{if .AddressLine2}}{{.AddressLine2}}<br>{{end}}{{.PostalCode}} {{.City}}<br>{{.Country}}{{if .RecipientPhone}}<br>{{.RecipientPhone}}{{end}}</div>{{else}}{{if ne $.Type "invoice"}}<div><strong>Customer</strong><br>{{$.BuyerName}}</div>{{end}}{{end}}
+</div>
+<table>
+<tr><th>SKU</th><th>Item</th><th class="num">Qty</th>{{if .ShowPrices}}<th class="num">Unit price</th><th class="num">Total</th>{{end}}</tr>
+{{range .Lines}}<tr><td>{{.SKU}}</td><td>{{.Title}}</td><td class="num">{{.Quantity}}</td>{{if $.ShowPrices}}<td class="num">{{.UnitPrice}}</td><td class="num">{{.LineTotal}}</td>{{end}}</tr>
+{{end}}{{if .ShowPrices}}<tr><td colspan="4" class="num"><strong>Total</strong></td><td class="num"><strong>{{.Total}}</strong></td></tr>{{end}}
+</table>
+{{with .Gift}}{{if .Message}}<div class="gift"><strong>Gift message</strong>
+{{.Message}}</div>{{end}}{{if eq $.Type "invoice"}}<p>This order was sent as a gift to {{.RecipientName}}.</p>{{end}}{{end}}
+</body></html>
+`))
+
+// buildOrderDocument lays out the order for printing. Packing slips travel in the parcel, so
+// gift orders that hide prices leave them out; invoices go to the buyer and always show them.
+func buildOrderDocument(docType models.OrderDocumentType, detail *models.AdminOrderDetailResponse) *orderDocument {
+	doc := &orderDocument{
+		Type:       docType,
+		Title:      "Packing slip",
+		Company:    os.Getenv("ORDER_DOCUMENT_COMPANY_NAME"),
+		OrderID:    detail.Order.ID,
+		OrderRef:   shortOrderID(detail.Order.ID),
+		Date:       detail.Order.CreatedAt.Format("2006-01-02"),
+		BuyerName:  detail.Order.UserName,
+		BuyerEmail: detail.Order.UserEmail,
+		Gift:       detail.Gift,
+		ShowPrices: detail.Gift == nil || !detail.Gift.HidePrices,
+		Total:      detail.Order.TotalAmount.Format(detail.Order.Currency),
+	}
+	if doc.Company == "" {
+		doc.Company = "Expotoworld"
+	}
+	if doc.BuyerName == "" {
+		doc.BuyerName = doc.BuyerEmail
+	}
+	if docType == models.OrderDocumentInvoice {
+		doc.Title = "Invoice"
+		doc.ShowPrices = true
+	}
+	for _, it := range detail.Items {
+		line := documentLine{Quantity: it.Quantity, LineTotal: it.TotalPrice.Format(detail.Order.Currency)}
+		if it.Quantity > 0 {
+			line.UnitPrice = it.TotalPrice.Div(it.Quantity).Format(detail.Order.Currency)
+		}
+		if it.Product != nil {
+			line.SKU, line.Title = it.Product.SKU, it.Product.Title
+		}
+		doc.Lines = append(doc.Lines, line)
+	}
+	return doc
+}
+
+// GetOrderDocument handles GET .../orders/:order_id/documents/:doc_type (packing-slip or invoice)
+// and renders a printable HTML page. Customers can only get their invoice.
+func (h *Handler) GetOrderDocument(role string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		docType := models.OrderDocumentType(c.Param("doc_type"))
+		if docType != models.OrderDocumentPackingSlip && docType != models.OrderDocumentInvoice {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid document type", Message: "doc_type must be packing-slip or invoice"})
+			return
+		}
+		if role == AttachmentRoleCustomer && docType != models.OrderDocumentInvoice {
+			c.JSON(http.StatusForbidden, models.ErrorResponse{Error: "Forbidden", Message: "Packing slips are only available to fulfillment staff"})
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		orderID := c.Param("order_id")
+		if _, ok := h.authorizeOrderAttachments(ctx, c, orderID, role); !ok {
+			return
+		}
+		detail, err := h.getAdminOrderByID(ctx, orderID)
+		if err != nil {
+			c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "Order not found", Message: err.Error()})
+			return
+		}
+
+		var buf bytes.Buffer
+		if err := orderDocumentTemplate.Execute(&buf, buildOrderDocument(docType, detail)); err != nil {
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to render document", Message: err.Error()})
+			return
+		}
+		c.Header("Content-Disposition", fmt.Sprintf(`inline; filename="%s-%s.html"`, docType, shortOrderID(orderID)))
+		c.Data(http.StatusOK, "text/html; charset=utf-8", buf.Bytes())
+	}
+}
@@ -7,20 +7,25 @@ import (
 	"strconv"
 	"time"
 
+	"github.com/expotoworld/expotoworld/backend/common/auth"
+	"github.com/expotoworld/expotoworld/backend/common/storage"
 	"github.com/expotoworld/expotoworld/backend/order-service/internal/db"
 	"github.com/expotoworld/expotoworld/backend/order-service/internal/models"
+	"github.com/expotoworld/expotoworld/backend/order-service/internal/money"
 	"github.com/gin-gonic/gin"
 )
 
 // Handler holds the database connection and provides HTTP handlers
 type Handler struct {
-	db *db.Database
+	db          *db.Database
+	attachments storage.Store
 }
 
 // NewHandler creates a new handler instance
-func NewHandler(database *db.Database) *Handler {
+func NewHandler(database *db.Database, attachments storage.Store) *Handler {
 	return &Handler{
-		db: database,
+		db:          database,
+		attachments: attachments,
 	}
 }
 
@@ -370,6 +375,18 @@ func (h *Handler) CreateOrder(c *gin.Context) {
 		return
 	}
 
+	var gift *models.OrderGift
+	if req.Gift != nil {
+		var msg string
+		if gift, msg = giftFromRequest(req.Gift); msg != "" {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Error:   "Invalid gift details",
+				Message: msg,
+			})
+			return
+		}
+	}
+
 	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
 	defer cancel()
 
@@ -404,14 +421,42 @@ func (h *Handler) CreateOrder(c *gin.Context) {
 		}
 	}
 
+	// Partner purchases bill at the organization's contract prices (falls back to main_price)
+	if err := h.applyContractPrices(ctx, auth.OrgIDs(c, "Partner"), cartItems); err != nil {
+		fmt.Printf("Warning: Failed to resolve contract prices, using default prices: %v\n", err)
+	}
+
 	// Calculate total amount
-	var totalAmount float64
+	var totalAmount money.Amount
 	for _, item := range cartItems {
-		totalAmount += float64(item.Quantity) * item.Product.MainPrice
+		totalAmount += item.Product.MainPrice.Mul(item.Quantity)
+	}
+
+	// Apply the mini-app's checkout rules; fees are added to the items total
+	checkoutCfg, err := h.getCheckoutConfig(ctx, miniAppType)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "Failed to get checkout config",
+			Message: err.Error(),
+		})
+		return
 	}
+	charges, msg := resolveCheckout(checkoutCfg, &req, totalAmount)
+	if msg != "" {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Checkout not allowed",
+			Message: msg,
+		})
+		return
+	}
+	totalAmount += charges.Total()
 
-	// Create order (we'll implement this method)
-	order, err := h.createOrder(ctx, userID, miniAppType, req.StoreID, totalAmount, cartItems)
+	// Create order (gift orders also record the recipient)
+	origin := orderOrigin{Gift: gift, Checkout: charges}
+	if req.Latitude != nil && req.Longitude != nil {
+		origin.Location = &geoPoint{Lat: *req.Latitude, Lng: *req.Longitude}
+	}
+	order, err := h.createOrderWithOrigin(ctx, userID, miniAppType, req.StoreID, totalAmount, cartItems, origin)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
 			Error:   "Failed to create order",
@@ -428,6 +473,7 @@ func (h *Handler) CreateOrder(c *gin.Context) {
 		fmt.Printf("Warning: Failed to clear cart after order creation: %v\n", err)
 	}
 
+	setOrderTracking(order)
 	c.JSON(http.StatusCreated, models.SuccessResponse{
 		Message: "Order created successfully",
 		Data:    order,
@@ -498,6 +544,8 @@ func (h *Handler) GetOrder(c *gin.Context) {
 		})
 		return
 	}
+	order.Attachments = h.visibleAttachments(ctx, orderID, attachmentViewer{Role: AttachmentRoleCustomer, UserID: userID})
+	setOrderTracking(order)
 
 	c.JSON(http.StatusOK, models.SuccessResponse{
 		Message: "Order retrieved successfully",
@@ -0,0 +1,241 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/expotoworld/expotoworld/backend/order-service/internal/models"
+	"github.com/expotoworld/expotoworld/backend/order-service/internal/money"
+	"github.com/jackc/pgx/v5"
+)
+
+var (
+	errRefundExceedsBalance   = errors.New("refund exceeds the order's net amount")
+	errAdjustmentBelowZero    = errors.New("adjustment would make the order's net amount negative")
+	errOrderCancelledNoCharge = errors.New("order is cancelled and has no net amount left")
+)
+
+// queryRower is satisfied by both the pool and a transaction
+type queryRower interface {
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+// postLedger records a balanced posting: amount is debited to one account and credited to another,
+// in the order's currency
+func postLedger(ctx context.Context, q execer, orderID string, entryType models.LedgerEntryType, debitAccount, creditAccount string, amount money.Amount, description, externalRef, actorID string, postedAt *time.Time) error {
+	at := time.Now()
+	if postedAt != nil {
+		at = *postedAt
+	}
+	var actor *string
+	if actorID != "" {
+		actor = &actorID
+	}
+	_, err := q.Exec(ctx, `
+		WITH p AS (
+			SELECT gen_random_uuid() AS posting_id,
+				COALESCE((SELECT currency FROM app_orders WHERE id = $1::uuid), '`+money.DefaultCurrency+`') AS currency
+		)
+		INSERT INTO app_order_ledger (posting_id, order_id, entry_type, account, debit, credit, currency, description, external_ref, actor_id, posted_at)
+		SELECT p.posting_id, $1::uuid, $2, l.account, l.debit, l.credit, p.currency, NULLIF($6, ''), NULLIF($7, ''), $8, $9
+		FROM p, (VALUES ($3::text, $5::numeric, 0::numeric), ($4::text, 0::numeric, $5::numeric)) AS l(account, debit, credit)
+	`, orderID, string(entryType), debitAccount, creditAccount, amount, description, externalRef, actor, at)
+	if err != nil {
+		return fmt.Errorf("failed to post ledger entry: %w", err)
+	}
+	return nil
+}
+
+// orderLedgerBalance sums an order's postings by type. Each posting has exactly one revenue or refunds line,
+// so the sums are taken from those lines only.
+func orderLedgerBalance(ctx context.Context, q queryRower, orderID string) (models.OrderLedgerBalance, error) {
+	var b models.OrderLedgerBalance
+	err := q.QueryRow(ctx, `
+		SELECT
+			COALESCE(SUM(credit) FILTER (WHERE entry_type = 'charge' AND account = 'revenue'), 0),
+			COALESCE(SUM(credit - debit) FILTER (WHERE entry_type = 'adjustment' AND account = 'revenue'), 0),
+			COALESCE(SUM(debit) FILTER (WHERE entry_type = 'refund' AND account = 'refunds'), 0),
+			COALESCE(SUM(debit) FILTER (WHERE entry_type = 'reversal' AND account = 'revenue'), 0)
+		FROM app_order_ledger
+		WHERE order_id::text = $1
+	`, orderID).Scan(&b.Charged, &b.Adjusted, &b.Refunded, &b.Reversed)
+	if err != nil {
+		return b, fmt.Errorf("failed to get ledger balance: %w", err)
+	}
+	b.NetRevenue = b.Charged + b.Adjusted - b.Refunded - b.Reversed
+	return b, nil
+}
+
+// getOrderLedger returns an order's ledger lines in posting order together with the balance
+func (h *Handler) getOrderLedger(ctx context.Context, orderID string) (*models.OrderLedgerResponse, error) {
+	exists, err := h.orderExists(ctx, orderID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get order: %w", err)
+	}
+	if !exists {
+		return nil, errOrderNotFound
+	}
+
+	lines, err := h.queryLedgerLines(ctx, `WHERE order_id::text = $1`, orderID)
+	if err != nil {
+		return nil, err
+	}
+	balance, err := orderLedgerBalance(ctx, h.db.Pool, orderID)
+	if err != nil {
+		return nil, err
+	}
+	resp := &models.OrderLedgerResponse{OrderID: orderID, Currency: money.DefaultCurrency, Lines: lines, Balance: balance}
+	if len(lines) > 0 {
+		resp.Currency = lines[0].Currency
+	}
+	return resp, nil
+}
+
+// getLedgerLines returns every ledger line posted in [from, to), debit line first within a posting
+func (h *Handler) getLedgerLines(ctx context.Context, from, to time.Time) ([]models.LedgerLine, error) {
+	return h.queryLedgerLines(ctx, `WHERE posted_at >= $1 AND posted_at < $2`, from, to)
+}
+
+func (h *Handler) queryLedgerLines(ctx context.Context, where string, args ...any) ([]models.LedgerLine, error) {
+	rows, err := h.db.Pool.Query(ctx, `
+		SELECT id::text, posting_id::text, order_id::text, entry_type, account, debit, credit, currency,
+			COALESCE(description, ''), COALESCE(external_ref, ''), actor_id, posted_at
+		FROM app_order_ledger
+		`+where+`
+		ORDER BY posted_at ASC, posting_id ASC, debit DESC
+	`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ledger lines: %w", err)
+	}
+	defer rows.Close()
+
+	lines := []models.LedgerLine{}
+	for rows.Next() {
+		var l models.LedgerLine
+		if err := rows.Scan(&l.ID, &l.PostingID, &l.OrderID, &l.EntryType, &l.Account, &l.Debit, &l.Credit, &l.Currency,
+			&l.Description, &l.ExternalRef, &l.ActorID, &l.PostedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan ledger line: %w", err)
+		}
+		lines = append(lines, l)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get ledger lines: %w", err)
+	}
+	return lines, nil
+}
+
+// lockOrderForLedger locks the order row so concurrent postings see each other's balance
+func lockOrderForLedger(ctx context.Context, tx pgx.Tx, orderID string) (models.OrderStatus, error) {
+	var status models.OrderStatus
+	err := tx.QueryRow(ctx, `SELECT status FROM app_orders WHERE id::text = $1 FOR UPDATE`, orderID).Scan(&status)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return "", errOrderNotFound
+		}
+		return "", fmt.Errorf("failed to get order: %w", err)
+	}
+	return status, nil
+}
+
+// recordRefund posts a refund (debit refunds, credit receivable) and notes it on the order timeline
+func (h *Handler) recordRefund(ctx context.Context, orderID string, req *models.RecordRefundRequest, actorID string) (*models.OrderLedgerBalance, error) {
+	amount := req.Amount
+	tx, err := h.db.Pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := lockOrderForLedger(ctx, tx, orderID); err != nil {
+		return nil, err
+	}
+	balance, err := orderLedgerBalance(ctx, tx, orderID)
+	if err != nil {
+		return nil, err
+	}
+	if amount > balance.NetRevenue {
+		return nil, errRefundExceedsBalance
+	}
+	if err := postLedger(ctx, tx, orderID, models.LedgerEntryRefund, models.LedgerAccountRefunds, models.LedgerAccountReceivable,
+		amount, req.Reason, req.ExternalRef, actorID, nil); err != nil {
+		return nil, err
+	}
+	note := fmt.Sprintf("Refunded %s: %s", amount, req.Reason)
+	if err := insertOrderEvent(ctx, tx, orderID, models.OrderEventRefunded, nil, nil, note, actorID, nil); err != nil {
+		return nil, err
+	}
+
+	balance, err = orderLedgerBalance(ctx, tx, orderID)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return &balance, nil
+}
+
+// recordAdjustment posts a signed correction against revenue
+func (h *Handler) recordAdjustment(ctx context.Context, orderID string, req *models.RecordAdjustmentRequest, actorID string) (*models.OrderLedgerBalance, error) {
+	amount := req.Amount
+	tx, err := h.db.Pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	status, err := lockOrderForLedger(ctx, tx, orderID)
+	if err != nil {
+		return nil, err
+	}
+	balance, err := orderLedgerBalance(ctx, tx, orderID)
+	if err != nil {
+		return nil, err
+	}
+	if status == models.OrderStatusCancelled && balance.NetRevenue == 0 {
+		return nil, errOrderCancelledNoCharge
+	}
+	if balance.NetRevenue+amount < 0 {
+		return nil, errAdjustmentBelowZero
+	}
+	debit, credit := models.LedgerAccountReceivable, models.LedgerAccountRevenue
+	if amount < 0 {
+		debit, credit = credit, debit
+	}
+	if err := postLedger(ctx, tx, orderID, models.LedgerEntryAdjustment, debit, credit, amount.Abs(), req.Reason, req.ExternalRef, actorID, nil); err != nil {
+		return nil, err
+	}
+
+	balance, err = orderLedgerBalance(ctx, tx, orderID)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return &balance, nil
+}
+
+// reverseUnpaidOrder cancels the outstanding charge of an order that was never marked paid.
+// Paid orders keep their revenue until a refund is recorded explicitly.
+func reverseUnpaidOrder(ctx context.Context, tx pgx.Tx, orderID, reason, actorID string) error {
+	var paid bool
+	if err := tx.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM app_order_events WHERE order_id::text = $1 AND event_type = $2)`,
+		orderID, string(models.OrderEventPaid)).Scan(&paid); err != nil {
+		return fmt.Errorf("failed to check payment: %w", err)
+	}
+	if paid {
+		return nil
+	}
+	balance, err := orderLedgerBalance(ctx, tx, orderID)
+	if err != nil {
+		return err
+	}
+	if balance.NetRevenue <= 0 {
+		return nil
+	}
+	return postLedger(ctx, tx, orderID, models.LedgerEntryReversal, models.LedgerAccountRevenue, models.LedgerAccountReceivable,
+		balance.NetRevenue, reason, "", actorID, nil)
+}
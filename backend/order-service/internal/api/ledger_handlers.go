@@ -0,0 +1,261 @@
+package api
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/expotoworld/expotoworld/backend/order-service/internal/models"
+	"github.com/gin-gonic/gin"
+)
+
+// writeLedgerError maps ledger errors to HTTP responses
+func writeLedgerError(c *gin.Context, action string, err error) {
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, errOrderNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, errRefundExceedsBalance), errors.Is(err, errAdjustmentBelowZero), errors.Is(err, errOrderCancelledNoCharge):
+		status = http.StatusConflict
+	}
+	c.JSON(status, models.ErrorResponse{
+		Error:   action,
+		Message: err.Error(),
+	})
+}
+
+// GetOrderLedger handles GET /api/admin/orders/:order_id/ledger
+func (h *Handler) GetOrderLedger(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	ledger, err := h.getOrderLedger(ctx, c.Param("order_id"))
+	if err != nil {
+		writeLedgerError(c, "Failed to get order ledger", err)
+		return
+	}
+	c.JSON(http.StatusOK, models.SuccessResponse{
+		Message: "Order ledger retrieved successfully",
+		Data:    ledger,
+	})
+}
+
+// RecordRefund handles POST /api/admin/orders/:order_id/refunds
+// The refund may not exceed the order's net amount (charges and adjustments less earlier refunds).
+func (h *Handler) RecordRefund(c *gin.Context) {
+	var req models.RecordRefundRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid request data",
+			Message: err.Error(),
+		})
+		return
+	}
+	if req.Amount <= 0 {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid amount", Message: "amount must be at least 0.01"})
+		return
+	}
+	adminUserID, _ := GetUserID(c)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	balance, err := h.recordRefund(ctx, c.Param("order_id"), &req, adminUserID)
+	if err != nil {
+		writeLedgerError(c, "Failed to record refund", err)
+		return
+	}
+	c.JSON(http.StatusCreated, models.SuccessResponse{
+		Message: "Refund recorded successfully",
+		Data:    balance,
+	})
+}
+
+// RecordAdjustment handles POST /api/admin/orders/:order_id/adjustments
+func (h *Handler) RecordAdjustment(c *gin.Context) {
+	var req models.RecordAdjustmentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid request data",
+			Message: err.Error(),
+		})
+		return
+	}
+	if req.Amount == 0 {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid amount", Message: "amount must be non-zero (in cents)"})
+		return
+	}
+	adminUserID, _ := GetUserID(c)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	balance, err := h.recordAdjustment(ctx, c.Param("order_id"), &req, adminUserID)
+	if err != nil {
+		writeLedgerError(c, "Failed to record adjustment", err)
+		return
+	}
+	c.JSON(http.StatusCreated, models.SuccessResponse{
+		Message: "Adjustment recorded successfully",
+		Data:    balance,
+	})
+}
+
+// ExportLedger handles GET /api/admin/accounting/export
+// Period as for settlements (?month= or ?date_from=&date_to=). ?format=csv (default) writes one row per
+// ledger line; ?format=datev writes a DATEV EXTF Buchungsstapel with one booking per posting.
+func (h *Handler) ExportLedger(c *gin.Context) {
+	from, to, msg := parseReportPeriod(c)
+	if msg != "" {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid period", Message: msg})
+		return
+	}
+	format := strings.ToLower(c.DefaultQuery("format", "csv"))
+	if format != "csv" && format != "datev" {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid format", Message: "format must be csv or datev"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	lines, err := h.getLedgerLines(ctx, from, to)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "Failed to export ledger",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	name := fmt.Sprintf("ledger-%s-%s", from.Format("20060102"), to.AddDate(0, 0, -1).Format("20060102"))
+	if format == "datev" {
+		name = "EXTF_" + name
+	}
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.csv"`, name))
+	c.Status(http.StatusOK)
+
+	w := csv.NewWriter(c.Writer)
+	if format == "datev" {
+		err = writeDATEV(w, lines, from, to)
+	} else {
+		err = writeLedgerCSV(w, lines)
+	}
+	if err != nil {
+		// Headers are already sent; the truncated file is the best signal left
+		c.Error(err)
+	}
+}
+
+func writeLedgerCSV(w *csv.Writer, lines []models.LedgerLine) error {
+	_ = w.Write([]string{"posted_at", "posting_id", "order_id", "entry_type", "account", "debit", "credit", "currency", "description", "external_ref", "actor_id"})
+	for _, l := range lines {
+		actor := ""
+		if l.ActorID != nil {
+			actor = *l.ActorID
+		}
+		_ = w.Write([]string{
+			l.PostedAt.UTC().Format(time.RFC3339),
+			l.PostingID,
+			l.OrderID,
+			string(l.EntryType),
+			l.Account,
+			l.Debit.String(),
+			l.Credit.String(),
+			l.Currency,
+			l.Description,
+			l.ExternalRef,
+			actor,
+		})
+	}
+	w.Flush()
+	return w.Error()
+}
+
+// datevAccounts maps ledger accounts to SKR03 numbers, overridable per environment
+func datevAccounts() map[string]string {
+	accounts := map[string]string{
+		models.LedgerAccountReceivable: "1400", // Forderungen aus Lieferungen und Leistungen
+		models.LedgerAccountRevenue:    "8400", // Erlöse 19% USt
+		models.LedgerAccountRefunds:    "8720", // Erlösschmälerungen 19% USt
+	}
+	for account, env := range map[string]string{
+		models.LedgerAccountReceivable: "ORDER_DATEV_ACCOUNT_RECEIVABLE",
+		models.LedgerAccountRevenue:    "ORDER_DATEV_ACCOUNT_REVENUE",
+		models.LedgerAccountRefunds:    "ORDER_DATEV_ACCOUNT_REFUNDS",
+	} {
+		if v := strings.TrimSpace(os.Getenv(env)); v != "" {
+			accounts[account] = v
+		}
+	}
+	return accounts
+}
+
+// writeDATEV writes the DATEV format header (EXTF v700, Buchungsstapel) and one booking per posting:
+// the debit line's account as Konto, the credit line's as Gegenkonto.
+func writeDATEV(w *csv.Writer, lines []models.LedgerLine, from, to time.Time) error {
+	w.Comma = ';'
+	w.UseCRLF = true
+	accounts := datevAccounts()
+
+	now := time.Now().UTC()
+	_ = w.Write([]string{
+		"EXTF", "700", "21", "Buchungsstapel", "13",
+		now.Format("20060102150405") + fmt.Sprintf("%03d", now.Nanosecond()/int(time.Millisecond)),
+		"", "RE", "", "",
+		os.Getenv("ORDER_DATEV_CONSULTANT_NUMBER"),
+		os.Getenv("ORDER_DATEV_CLIENT_NUMBER"),
+		time.Date(from.Year(), 1, 1, 0, 0, 0, 0, time.UTC).Format("20060102"),
+		"4",
+		from.Format("20060102"),
+		to.AddDate(0, 0, -1).Format("20060102"),
+		"Order ledger", "", "1", "0", "0", "EUR",
+	})
+	_ = w.Write([]string{
+		"Umsatz (ohne Soll/Haben-Kz)", "Soll/Haben-Kennzeichen", "WKZ Umsatz", "Kurs", "Basis-Umsatz", "WKZ Basis-Umsatz",
+		"Konto", "Gegenkonto (ohne BU-Schlüssel)", "BU-Schlüssel", "Belegdatum", "Belegfeld 1", "Belegfeld 2", "Skonto", "Buchungstext",
+	})
+
+	for i := 0; i < len(lines); i++ {
+		debit := lines[i]
+		if i+1 >= len(lines) || lines[i+1].PostingID != debit.PostingID {
+			return fmt.Errorf("posting %s is not balanced", debit.PostingID)
+		}
+		credit := lines[i+1]
+		i++
+
+		text := string(debit.EntryType) + " " + shortOrderID(debit.OrderID)
+		if debit.Description != "" {
+			text += " " + debit.Description
+		}
+		ref := debit.ExternalRef
+		if r := []rune(ref); len(r) > 12 {
+			ref = string(r[:12])
+		}
+		if r := []rune(text); len(r) > 60 {
+			text = string(r[:60])
+		}
+		_ = w.Write([]string{
+			strings.Replace(debit.Debit.String(), ".", ",", 1),
+			"S",
+			debit.Currency,
+			"", "", "",
+			accounts[debit.Account],
+			accounts[credit.Account],
+			"",
+			debit.PostedAt.UTC().Format("0201"),
+			debit.OrderID,
+			ref,
+			"",
+			text,
+		})
+	}
+	w.Flush()
+	return w.Error()
+}
@@ -7,6 +7,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/expotoworld/expotoworld/backend/common/auth"
 	"github.com/expotoworld/expotoworld/backend/order-service/internal/models"
 	"github.com/gin-gonic/gin"
 )
@@ -14,7 +15,7 @@ import (
 // GetManufacturerOrders returns orders that include at least one item owned by any of the manufacturer's orgs
 func (h *Handler) GetManufacturerOrders(c *gin.Context) {
 	// Extract manufacturer org IDs from JWT
-	orgIDs := extractManufacturerOrgIDs(c)
+	orgIDs := auth.OrgIDs(c, "Manufacturer")
 	if len(orgIDs) == 0 {
 		c.JSON(http.StatusOK, models.AdminOrderListResponse{Orders: []models.AdminOrderResponse{}, Total: 0, Page: 1, Limit: 20, TotalPages: 0})
 		return
@@ -52,7 +53,7 @@ func (h *Handler) GetManufacturerOrders(c *gin.Context) {
 
 // GetManufacturerOrder returns detailed info for a single order if it includes at least one product owned by manufacturer orgs
 func (h *Handler) GetManufacturerOrder(c *gin.Context) {
-	orgIDs := extractManufacturerOrgIDs(c)
+	orgIDs := auth.OrgIDs(c, "Manufacturer")
 	if len(orgIDs) == 0 {
 		c.JSON(http.StatusForbidden, models.ErrorResponse{Error: "Not a manufacturer", Message: "No manufacturer organization memberships"})
 		return
@@ -81,12 +82,14 @@ func (h *Handler) GetManufacturerOrder(c *gin.Context) {
 		c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "Order not found", Message: err.Error()})
 		return
 	}
+	userID, _ := GetUserID(c)
+	detail.Attachments = h.visibleAttachments(ctx, orderID, attachmentViewer{Role: AttachmentRoleManufacturer, UserID: userID})
 	c.JSON(http.StatusOK, detail)
 }
 
 // UpdateManufacturerOrderStatus allows a manufacturer to update status for orders that include their products
 func (h *Handler) UpdateManufacturerOrderStatus(c *gin.Context) {
-	orgIDs := extractManufacturerOrgIDs(c)
+	orgIDs := auth.OrgIDs(c, "Manufacturer")
 	if len(orgIDs) == 0 {
 		c.JSON(http.StatusForbidden, models.ErrorResponse{Error: "Not a manufacturer", Message: "No manufacturer organization memberships"})
 		return
@@ -124,31 +127,6 @@ func (h *Handler) UpdateManufacturerOrderStatus(c *gin.Context) {
 	c.JSON(http.StatusOK, models.SuccessResponse{Message: "Order status updated"})
 }
 
-// extractManufacturerOrgIDs pulls org_ids from JWT where org_type == "Manufacturer"
-func extractManufacturerOrgIDs(c *gin.Context) []string {
-	v, ok := c.Get("org_memberships")
-	if !ok || v == nil {
-		return nil
-	}
-	arr, ok := v.([]interface{})
-	if !ok {
-		return nil
-	}
-	res := make([]string, 0, len(arr))
-	for _, item := range arr {
-		m, ok := item.(map[string]interface{})
-		if !ok {
-			continue
-		}
-		if t, ok := m["org_type"].(string); ok && t == "Manufacturer" {
-			if id, ok := m["org_id"].(string); ok && id != "" {
-				res = append(res, id)
-			}
-		}
-	}
-	return res
-}
-
 // getManufacturerOrders queries orders filtered by products owned by any of the given org IDs
 func (h *Handler) getManufacturerOrders(ctx context.Context, req *models.AdminOrderListRequest, orgIDs []string) ([]models.AdminOrderResponse, int, error) {
 	// Build WHERE for general filters
@@ -225,8 +203,9 @@ func (h *Handler) getManufacturerOrders(ctx context.Context, req *models.AdminOr
 	listQ := fmt.Sprintf(`
 		SELECT o.id, o.user_id, COALESCE(u.email, '') as user_email,
 		COALESCE(TRIM(COALESCE(u.first_name,'')||' '||COALESCE(u.last_name,'')), u.username) as user_name,
-		o.mini_app_type, o.total_amount, o.status,
+		o.mini_app_type, o.total_amount, o.currency, o.status,
 		(SELECT COUNT(*) FROM app_order_items oi WHERE oi.order_id = o.id) as item_count,
+		EXISTS (SELECT 1 FROM app_order_gifts g WHERE g.order_id = o.id) as is_gift,
 		o.created_at, o.updated_at
 		FROM app_orders o
 		LEFT JOIN app_users u ON o.user_id = u.id
@@ -241,7 +220,7 @@ func (h *Handler) getManufacturerOrders(ctx context.Context, req *models.AdminOr
 	var orders []models.AdminOrderResponse
 	for rows.Next() {
 		var o models.AdminOrderResponse
-		if err := rows.Scan(&o.ID, &o.UserID, &o.UserEmail, &o.UserName, &o.MiniAppType, &o.TotalAmount, &o.Status, &o.ItemCount, &o.CreatedAt, &o.UpdatedAt); err != nil {
+		if err := rows.Scan(&o.ID, &o.UserID, &o.UserEmail, &o.UserName, &o.MiniAppType, &o.TotalAmount, &o.Currency, &o.Status, &o.ItemCount, &o.IsGift, &o.CreatedAt, &o.UpdatedAt); err != nil {
 			return nil, 0, fmt.Errorf("scan failed: %w", err)
 		}
 		orders = append(orders, o)
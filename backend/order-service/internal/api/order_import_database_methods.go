@@ -0,0 +1,220 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/expotoworld/expotoworld/backend/order-service/internal/models"
+	"github.com/expotoworld/expotoworld/backend/order-service/internal/money"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// orderImportLookups caches users, stores and products resolved while validating an import file
+type orderImportLookups struct {
+	users    map[string]string
+	stores   map[int]bool
+	products map[string]*models.Product
+}
+
+// importOfflineOrders validates the parsed rows order by order and creates an offline order for each
+// valid group. A group is all-or-nothing: one bad row rejects the whole order. Stock is checked across
+// the whole file so two orders cannot both claim the last units.
+func (h *Handler) importOfflineOrders(ctx context.Context, rows []orderImportRow, actorID string, dryRun bool) *models.OrderImportResult {
+	result := &models.OrderImportResult{DryRun: dryRun, Orders: []models.OrderImportOrder{}, Rejects: []models.OrderImportReject{}}
+	lookups := &orderImportLookups{users: map[string]string{}, stores: map[int]bool{}, products: map[string]*models.Product{}}
+	remaining := map[string]int{} // product_uuid -> stock not yet claimed by earlier orders in the file
+
+	var refs []string
+	groups := map[string][]orderImportRow{}
+	for _, row := range rows {
+		if _, ok := groups[row.OrderRef]; !ok {
+			refs = append(refs, row.OrderRef)
+		}
+		groups[row.OrderRef] = append(groups[row.OrderRef], row)
+	}
+
+	for _, ref := range refs {
+		group := groups[ref]
+		reject := func(failed int, msg string) {
+			for _, row := range group {
+				rowMsg := msg
+				if failed != 0 && row.Row != failed {
+					rowMsg = fmt.Sprintf("order rejected because row %d failed", failed)
+				}
+				result.Rejects = append(result.Rejects, models.OrderImportReject{Row: row.Row, OrderRef: ref, Error: rowMsg})
+			}
+		}
+
+		userID, items, total, failedRow, msg := h.validateOfflineOrder(ctx, group, lookups, remaining)
+		if msg != "" {
+			reject(failedRow, msg)
+			continue
+		}
+
+		imported := models.OrderImportOrder{OrderRef: ref, ItemCount: len(items), TotalAmount: total}
+		if !dryRun {
+			first := group[0]
+			order, err := h.createOrderWithOrigin(ctx, userID, first.MiniAppType, first.StoreID, total, items, orderOrigin{
+				Channel:     models.OrderChannelOffline,
+				ExternalRef: ref,
+				Status:      models.OrderStatusDelivered,
+				PlacedAt:    first.SoldAt,
+				ActorID:     actorID,
+				Note:        "Imported offline sale " + ref,
+				DeductStock: true,
+			})
+			if err != nil {
+				var pgErr *pgconn.PgError
+				if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+					reject(0, "order_ref was already imported")
+				} else {
+					reject(0, err.Error())
+				}
+				continue
+			}
+			imported.OrderID = order.ID
+		}
+
+		for _, it := range items {
+			remaining[it.ProductID] -= it.Quantity
+		}
+		result.Orders = append(result.Orders, imported)
+		result.OrdersCreated++
+		result.RowsImported += len(group)
+	}
+	return result
+}
+
+// validateOfflineOrder checks one order's rows and builds its items at the sold (or default) prices.
+// On failure it returns the offending row (0 when the whole order is at fault) and a message.
+func (h *Handler) validateOfflineOrder(ctx context.Context, group []orderImportRow, lookups *orderImportLookups, remaining map[string]int) (string, []models.Cart, money.Amount, int, string) {
+	first := group[0]
+
+	var existingID string
+	err := h.db.Pool.QueryRow(ctx, `SELECT id::text FROM app_orders WHERE channel = $1 AND external_ref = $2`,
+		string(models.OrderChannelOffline), first.OrderRef).Scan(&existingID)
+	if err == nil {
+		return "", nil, 0, 0, "order_ref was already imported as order " + existingID
+	} else if err != pgx.ErrNoRows {
+		return "", nil, 0, 0, "failed to check for duplicate import: " + err.Error()
+	}
+
+	for _, row := range group[1:] {
+		if row.UserID != first.UserID || row.UserEmail != first.UserEmail || row.MiniAppType != first.MiniAppType ||
+			!sameIntPtr(row.StoreID, first.StoreID) || !sameTimePtr(row.SoldAt, first.SoldAt) {
+			return "", nil, 0, row.Row, "customer, mini_app_type, store_id and sold_at must match the order's first row"
+		}
+	}
+
+	userKey := first.UserID + "|" + first.UserEmail
+	userID, ok := lookups.users[userKey]
+	if !ok {
+		userID, err = h.resolveCustomerUserID(ctx, first.UserID, first.UserEmail)
+		if err != nil {
+			if errors.Is(err, errQuoteCustomerNone) {
+				return "", nil, 0, first.Row, "unknown user"
+			}
+			return "", nil, 0, first.Row, err.Error()
+		}
+		lookups.users[userKey] = userID
+	}
+
+	if first.StoreID != nil {
+		exists, ok := lookups.stores[*first.StoreID]
+		if !ok {
+			if err := h.db.Pool.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM admin_stores WHERE store_id = $1)`, *first.StoreID).Scan(&exists); err != nil {
+				return "", nil, 0, first.Row, "failed to check store: " + err.Error()
+			}
+			lookups.stores[*first.StoreID] = exists
+		}
+		if !exists {
+			return "", nil, 0, first.Row, "unknown store_id"
+		}
+	}
+
+	items := make([]models.Cart, 0, len(group))
+	claimed := map[string]int{}
+	var total money.Amount
+	for _, row := range group {
+		product, err := h.getImportProduct(ctx, lookups, row)
+		if err != nil {
+			return "", nil, 0, row.Row, err.Error()
+		}
+		if !product.IsActive {
+			return "", nil, 0, row.Row, fmt.Sprintf("product '%s' is not active", product.Title)
+		}
+
+		available, seen := remaining[product.ID]
+		if !seen {
+			available = product.StockLeft
+			remaining[product.ID] = available
+		}
+		claimed[product.ID] += row.Quantity
+		if claimed[product.ID] > available {
+			return "", nil, 0, row.Row, fmt.Sprintf("insufficient stock for product '%s': requested %d, available %d",
+				product.Title, claimed[product.ID], available)
+		}
+
+		// Copy so the sold price never leaks into the cached product
+		line := *product
+		if row.UnitPrice != nil {
+			line.MainPrice = *row.UnitPrice
+		}
+		items = append(items, models.Cart{
+			UserID:      userID,
+			ProductID:   product.ID,
+			Quantity:    row.Quantity,
+			MiniAppType: row.MiniAppType,
+			Product:     &line,
+		})
+		total += line.MainPrice.Mul(row.Quantity)
+	}
+	return userID, items, total, 0, ""
+}
+
+// getImportProduct resolves a row's product by product_id (UUID) or SKU
+func (h *Handler) getImportProduct(ctx context.Context, lookups *orderImportLookups, row orderImportRow) (*models.Product, error) {
+	key := "id:" + row.ProductID
+	if row.ProductID == "" {
+		key = "sku:" + row.SKU
+	}
+	if p, ok := lookups.products[key]; ok {
+		return p, nil
+	}
+
+	var product models.Product
+	err := h.db.Pool.QueryRow(ctx, `
+		SELECT product_uuid::text, sku, title, main_price, stock_left, minimum_order_quantity, is_active
+		FROM admin_products
+		WHERE ($1 <> '' AND product_uuid::text = $1) OR ($1 = '' AND sku = $2)
+		LIMIT 1
+	`, row.ProductID, row.SKU).Scan(
+		&product.ID,
+		&product.SKU,
+		&product.Title,
+		&product.MainPrice,
+		&product.StockLeft,
+		&product.MinimumOrderQuantity,
+		&product.IsActive,
+	)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, fmt.Errorf("unknown product %s", strings.TrimPrefix(strings.TrimPrefix(key, "id:"), "sku:"))
+		}
+		return nil, fmt.Errorf("failed to get product: %w", err)
+	}
+	lookups.products[key] = &product
+	return &product, nil
+}
+
+func sameIntPtr(a, b *int) bool {
+	return (a == nil && b == nil) || (a != nil && b != nil && *a == *b)
+}
+
+func sameTimePtr(a, b *time.Time) bool {
+	return (a == nil && b == nil) || (a != nil && b != nil && a.Equal(*b))
+}
@@ -0,0 +1,258 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/expotoworld/expotoworld/backend/order-service/internal/models"
+	"github.com/expotoworld/expotoworld/backend/order-service/internal/money"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	maxOrderImportBytes = 5 << 20
+	maxOrderImportRows  = 5000
+)
+
+// orderImportRow is one parsed line of an offline order import file.
+// Rows sharing an order_ref make up a single order.
+type orderImportRow struct {
+	Row         int
+	OrderRef    string
+	UserID      string
+	UserEmail   string
+	MiniAppType models.MiniAppType
+	StoreID     *int
+	SKU         string
+	ProductID   string
+	Quantity    int
+	UnitPrice   *money.Amount
+	SoldAt      *time.Time
+}
+
+var errOrderImportHeader = errors.New("invalid header")
+
+// ImportOfflineOrders handles POST /api/admin/orders/import[?dry_run=true]
+// Accepts a CSV file (multipart field "file", or a raw text/csv body) of sales recorded offline at expos.
+// Columns (header row required, any order): order_ref, user_id | user_email, sku | product_id, quantity,
+// and optionally mini_app_type (default ExhibitionSales), store_id, unit_price (default main_price), sold_at.
+// Comma, semicolon and tab delimiters are accepted, so spreadsheet (Excel) exports can be uploaded as-is.
+// Each order is created with channel "offline" and status "delivered"; rejected rows are reported per row.
+func (h *Handler) ImportOfflineOrders(c *gin.Context) {
+	actorID, _ := GetUserID(c)
+	dryRun, _ := strconv.ParseBool(c.DefaultQuery("dry_run", "false"))
+
+	var src io.Reader
+	if strings.HasPrefix(c.ContentType(), "multipart/") {
+		fh, err := c.FormFile("file")
+		if err != nil {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid request", Message: "multipart field 'file' is required"})
+			return
+		}
+		f, err := fh.Open()
+		if err != nil {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid request", Message: err.Error()})
+			return
+		}
+		defer f.Close()
+		src = f
+	} else {
+		src = c.Request.Body
+	}
+
+	data, err := io.ReadAll(io.LimitReader(src, maxOrderImportBytes+1))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid request", Message: err.Error()})
+		return
+	}
+	if len(data) > maxOrderImportBytes {
+		c.JSON(http.StatusRequestEntityTooLarge, models.ErrorResponse{Error: "File too large", Message: "Import files are limited to 5 MB"})
+		return
+	}
+
+	rows, rejects, err := parseOrderImportCSV(data)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid import file", Message: err.Error()})
+		return
+	}
+	if len(rows)+len(rejects) > maxOrderImportRows {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid import file", Message: "Import files are limited to " + strconv.Itoa(maxOrderImportRows) + " rows"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+
+	result := h.importOfflineOrders(ctx, rows, actorID, dryRun)
+	result.TotalRows = len(rows) + len(rejects)
+	result.Rejects = append(rejects, result.Rejects...)
+	result.RowsRejected = len(result.Rejects)
+	fmt.Printf("[ORDER_IMPORT] actor=%s dry_run=%t rows=%d imported=%d rejected=%d orders=%d\n",
+		actorID, dryRun, result.TotalRows, result.RowsImported, result.RowsRejected, result.OrdersCreated)
+
+	c.JSON(http.StatusOK, result)
+}
+
+// parseOrderImportCSV parses an import file. Malformed rows become rejects; a bad header is an error.
+func parseOrderImportCSV(data []byte) ([]orderImportRow, []models.OrderImportReject, error) {
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf")) // Excel writes a UTF-8 BOM
+
+	r := csv.NewReader(bytes.NewReader(data))
+	r.Comma = detectCSVDelimiter(data)
+	r.FieldsPerRecord = -1
+	r.TrimLeadingSpace = true
+
+	header, err := r.Read()
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", errOrderImportHeader, err)
+	}
+	col := map[string]int{}
+	for i, name := range header {
+		col[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	has := func(name string) bool { _, ok := col[name]; return ok }
+	switch {
+	case !has("order_ref"):
+		return nil, nil, fmt.Errorf("%w: missing order_ref column", errOrderImportHeader)
+	case !has("quantity"):
+		return nil, nil, fmt.Errorf("%w: missing quantity column", errOrderImportHeader)
+	case !has("sku") && !has("product_id"):
+		return nil, nil, fmt.Errorf("%w: missing sku or product_id column", errOrderImportHeader)
+	case !has("user_id") && !has("user_email"):
+		return nil, nil, fmt.Errorf("%w: missing user_id or user_email column", errOrderImportHeader)
+	}
+
+	var rows []orderImportRow
+	var rejects []models.OrderImportReject
+	line := 1
+	for {
+		record, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		line++
+		if err != nil {
+			rejects = append(rejects, models.OrderImportReject{Row: line, Error: err.Error()})
+			continue
+		}
+		get := func(name string) string {
+			if i, ok := col[name]; ok && i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+		if strings.Join(record, "") == "" {
+			continue // blank line
+		}
+
+		row := orderImportRow{
+			Row:         line,
+			OrderRef:    get("order_ref"),
+			UserID:      get("user_id"),
+			UserEmail:   get("user_email"),
+			MiniAppType: models.MiniAppType(get("mini_app_type")),
+			SKU:         get("sku"),
+			ProductID:   get("product_id"),
+		}
+		if msg := row.parseFields(get); msg != "" {
+			rejects = append(rejects, models.OrderImportReject{Row: line, OrderRef: row.OrderRef, Error: msg})
+			continue
+		}
+		rows = append(rows, row)
+	}
+	return rows, rejects, nil
+}
+
+// parseFields validates and converts the row's typed columns; an empty message means valid
+func (row *orderImportRow) parseFields(get func(string) string) string {
+	switch {
+	case row.OrderRef == "":
+		return "order_ref is required"
+	case len(row.OrderRef) > 100:
+		return "order_ref must be at most 100 characters"
+	case row.UserID == "" && row.UserEmail == "":
+		return "user_id or user_email is required"
+	case row.SKU == "" && row.ProductID == "":
+		return "sku or product_id is required"
+	}
+	if row.MiniAppType == "" {
+		row.MiniAppType = models.MiniAppTypeExhibitionSales
+	}
+	if !row.MiniAppType.IsValid() {
+		return "invalid mini_app_type: " + string(row.MiniAppType)
+	}
+
+	qty, err := strconv.Atoi(get("quantity"))
+	if err != nil || qty <= 0 {
+		return "quantity must be a positive integer"
+	}
+	row.Quantity = qty
+
+	if v := get("store_id"); v != "" {
+		id, err := strconv.Atoi(v)
+		if err != nil || id <= 0 {
+			return "invalid store_id"
+		}
+		row.StoreID = &id
+	}
+	if row.MiniAppType.RequiresStore() && row.StoreID == nil {
+		return "store_id is required for " + string(row.MiniAppType)
+	}
+
+	if v := get("unit_price"); v != "" {
+		// Accept decimal commas from European spreadsheet exports
+		price, err := money.Parse(strings.Replace(v, ",", ".", 1))
+		if err != nil || price < 0 {
+			return "invalid unit_price"
+		}
+		row.UnitPrice = &price
+	}
+
+	if v := get("sold_at"); v != "" {
+		t, ok := parseImportTime(v)
+		if !ok {
+			return "invalid sold_at (expected RFC3339 or YYYY-MM-DD HH:MM)"
+		}
+		if t.After(time.Now().Add(5 * time.Minute)) {
+			return "sold_at is in the future"
+		}
+		row.SoldAt = &t
+	}
+	return ""
+}
+
+// parseImportTime accepts RFC3339 timestamps and the plain formats spreadsheets produce (UTC)
+func parseImportTime(v string) (time.Time, bool) {
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return t, true
+	}
+	for _, layout := range []string{"2006-01-02 15:04:05", "2006-01-02 15:04", "2006-01-02T15:04:05", "2006-01-02"} {
+		if t, err := time.ParseInLocation(layout, v, time.UTC); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// detectCSVDelimiter picks the most frequent of comma, semicolon and tab in the header line
+func detectCSVDelimiter(data []byte) rune {
+	first := data
+	if i := bytes.IndexByte(data, '\n'); i >= 0 {
+		first = data[:i]
+	}
+	best, bestCount := ',', bytes.Count(first, []byte{','})
+	for _, d := range []rune{';', '\t'} {
+		if n := bytes.Count(first, []byte{byte(d)}); n > bestCount {
+			best, bestCount = d, n
+		}
+	}
+	return best
+}
@@ -0,0 +1,62 @@
+package api
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/expotoworld/expotoworld/backend/order-service/internal/models"
+	"github.com/jackc/pgx/v5"
+)
+
+const printJobColumns = `id::text, doc_type, order_ids, status, missing_order_ids, page_count, COALESCE(s3_key, ''), COALESCE(error, ''),
+	requested_by, created_at, finished_at`
+
+func scanPrintJob(row pgx.Row, j *models.PrintJob) error {
+	return row.Scan(&j.ID, &j.DocType, &j.OrderIDs, &j.Status, &j.MissingOrderIDs, &j.PageCount, &j.S3Key, &j.Error,
+		&j.RequestedBy, &j.CreatedAt, &j.FinishedAt)
+}
+
+// insertPrintJob queues a print job
+func (h *Handler) insertPrintJob(ctx context.Context, j *models.PrintJob) error {
+	err := scanPrintJob(h.db.Pool.QueryRow(ctx, `
+		INSERT INTO app_order_print_jobs (doc_type, order_ids, requested_by)
+		VALUES ($1, $2, $3)
+		RETURNING `+printJobColumns,
+		string(j.DocType), j.OrderIDs, j.RequestedBy), j)
+	if err != nil {
+		return fmt.Errorf("failed to create print job: %w", err)
+	}
+	return nil
+}
+
+// getPrintJob returns a print job. Jobs still unfinished after printJobTimeout were cut short (the
+// process restarted mid-render) and are reported as failed.
+func (h *Handler) getPrintJob(ctx context.Context, id string) (*models.PrintJob, error) {
+	if _, err := h.db.Pool.Exec(ctx, `
+		UPDATE app_order_print_jobs SET status = 'failed', error = 'Interrupted before completion', finished_at = now()
+		WHERE id::text = $1 AND status IN ('queued', 'running') AND created_at < now() - make_interval(secs => $2)
+	`, id, printJobTimeout.Seconds()); err != nil {
+		return nil, fmt.Errorf("failed to expire print job: %w", err)
+	}
+	var j models.PrintJob
+	if err := scanPrintJob(h.db.Pool.QueryRow(ctx, `SELECT `+printJobColumns+` FROM app_order_print_jobs WHERE id::text = $1`, id), &j); err != nil {
+		return nil, err
+	}
+	return &j, nil
+}
+
+// setPrintJobRunning marks a queued job as being rendered
+func (h *Handler) setPrintJobRunning(ctx context.Context, id string) error {
+	_, err := h.db.Pool.Exec(ctx, `UPDATE app_order_print_jobs SET status = 'running' WHERE id::text = $1`, id)
+	return err
+}
+
+// finishPrintJob records the outcome of a job: the stored PDF, or the error that stopped it
+func (h *Handler) finishPrintJob(ctx context.Context, j *models.PrintJob) error {
+	_, err := h.db.Pool.Exec(ctx, `
+		UPDATE app_order_print_jobs
+		SET status = $2, missing_order_ids = $3, page_count = $4, s3_key = NULLIF($5, ''), error = NULLIF($6, ''), finished_at = now()
+		WHERE id::text = $1
+	`, j.ID, string(j.Status), j.MissingOrderIDs, j.PageCount, j.S3Key, j.Error)
+	return err
+}
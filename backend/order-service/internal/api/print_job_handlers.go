@@ -0,0 +1,301 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/expotoworld/expotoworld/backend/common/storage"
+	"github.com/expotoworld/expotoworld/backend/order-service/internal/logging"
+	"github.com/expotoworld/expotoworld/backend/order-service/internal/models"
+	"github.com/expotoworld/expotoworld/backend/order-service/internal/pdf"
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+)
+
+// printJobTimeout bounds rendering a job; jobs older than this that never finished are reported failed
+const printJobTimeout = 10 * time.Minute
+
+// Page layout (points, measured from the top-left corner)
+const (
+	printMargin = 48.0
+	printBottom = pdf.PageHeight - 60
+)
+
+// PrintOrders handles POST /api/admin/orders/print. It queues a job that renders the packing slips,
+// invoices or shipping labels of the given orders into one PDF in S3, and returns 202 with the job;
+// poll GET /api/admin/orders/print/:job_id for the download link.
+func (h *Handler) PrintOrders(c *gin.Context) {
+	if h.attachments == nil {
+		c.JSON(http.StatusServiceUnavailable, models.ErrorResponse{Error: "Printing unavailable", Message: "Document storage is not configured"})
+		return
+	}
+	var req models.PrintOrdersRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid request data", Message: err.Error()})
+		return
+	}
+	if req.DocType == "" {
+		req.DocType = models.OrderDocumentPackingSlip
+	}
+	switch req.DocType {
+	case models.OrderDocumentPackingSlip, models.OrderDocumentInvoice, models.OrderDocumentShippingLabel:
+	default:
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid document type", Message: "doc_type must be packing-slip, invoice or shipping-label"})
+		return
+	}
+	adminUserID, ok := GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse{Error: "Invalid admin user", Message: "Could not extract admin user ID from token"})
+		return
+	}
+
+	// Keep the requested order, dropping repeats
+	seen := make(map[string]bool, len(req.OrderIDs))
+	job := &models.PrintJob{DocType: req.DocType, RequestedBy: adminUserID}
+	for _, id := range req.OrderIDs {
+		if !seen[id] {
+			seen[id] = true
+			job.OrderIDs = append(job.OrderIDs, id)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := h.insertPrintJob(ctx, job); err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to queue print job", Message: err.Error()})
+		return
+	}
+
+	go h.runPrintJob(*job)
+
+	c.JSON(http.StatusAccepted, job)
+}
+
+// GetPrintJob handles GET /api/admin/orders/print/:job_id; finished jobs include a 15-minute download URL
+func (h *Handler) GetPrintJob(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	job, err := h.getPrintJob(ctx, c.Param("job_id"))
+	if errors.Is(err, pgx.ErrNoRows) {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "Print job not found", Message: "No print job with this id"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to get print job", Message: err.Error()})
+		return
+	}
+	if job.Status == models.PrintJobDone && job.S3Key != "" {
+		name := fmt.Sprintf("%s-%s.pdf", job.DocType, job.CreatedAt.Format("20060102-1504"))
+		url, err := h.attachments.DownloadURL(ctx, job.S3Key, name, 15*time.Minute)
+		if err != nil {
+			c.JSON(http.StatusServiceUnavailable, models.ErrorResponse{Error: "Print output unavailable", Message: err.Error()})
+			return
+		}
+		job.DownloadURL = url
+	}
+	c.JSON(http.StatusOK, job)
+}
+
+// runPrintJob renders and uploads a job, recording the outcome on the job row
+func (h *Handler) runPrintJob(job models.PrintJob) {
+	ctx, cancel := context.WithTimeout(context.Background(), printJobTimeout)
+	defer cancel()
+
+	if err := h.setPrintJobRunning(ctx, job.ID); err != nil {
+		logging.LogKV("error", "PrintJobFailed", map[string]interface{}{"job_id": job.ID, "error": err.Error()})
+		return
+	}
+
+	job.MissingOrderIDs = []string{}
+	doc := pdf.New()
+	err := func() error {
+		for _, orderID := range job.OrderIDs {
+			detail, err := h.getAdminOrderByID(ctx, orderID)
+			if err != nil {
+				if err.Error() == "order not found" {
+					job.MissingOrderIDs = append(job.MissingOrderIDs, orderID)
+					continue
+				}
+				return err
+			}
+			if job.DocType == models.OrderDocumentShippingLabel {
+				renderShippingLabel(doc, detail)
+			} else {
+				renderOrderDocument(doc, buildOrderDocument(job.DocType, detail))
+			}
+		}
+		if doc.PageCount() == 0 {
+			return fmt.Errorf("none of the orders exist")
+		}
+		body := doc.Bytes()
+		key := fmt.Sprintf("print-jobs/%s/%s.pdf", job.CreatedAt.UTC().Format("2006/01"), job.ID)
+		if err := h.attachments.PutObject(ctx, key, bytes.NewReader(body), storage.ObjectMeta{ContentType: "application/pdf", Size: int64(len(body))}); err != nil {
+			return fmt.Errorf("failed to upload PDF: %w", err)
+		}
+		job.S3Key = key
+		job.PageCount = doc.PageCount()
+		return nil
+	}()
+
+	job.Status = models.PrintJobDone
+	if err != nil {
+		job.Status, job.Error = models.PrintJobFailed, err.Error()
+		logging.LogKV("error", "PrintJobFailed", map[string]interface{}{"job_id": job.ID, "error": err.Error()})
+	}
+	saveCtx, saveCancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer saveCancel()
+	if err := h.finishPrintJob(saveCtx, &job); err != nil {
+		logging.LogKV("error", "PrintJobSaveFailed", map[string]interface{}{"job_id": job.ID, "error": err.Error()})
+	}
+}
+
+// renderOrderDocument lays out a packing slip or invoice, continuing on further pages when the
+// item table doesn't fit
+func renderOrderDocument(doc *pdf.Document, d *orderDocument) {
+	right := pdf.PageWidth - printMargin
+	doc.AddPage()
+	doc.Text(printMargin, 64, 18, true, d.Title)
+	doc.Text(printMargin, 84, 10, false, fmt.Sprintf("%s  |  Order %s  |  %s", d.Company, d.OrderRef, d.Date))
+
+	y := 116.0
+	var block []string
+	switch {
+	case d.Type == models.OrderDocumentInvoice:
+		block = []string{"Billed to", d.BuyerName, d.BuyerEmail}
+	case d.Gift == nil:
+		block = []string{"Customer", d.BuyerName}
+	}
+	if g := d.Gift; g != nil {
+		block = append(block, "Ship to")
+		block = append(block, giftAddressLines(g)...)
+	}
+	for i, line := range block {
+		bold := line == "Billed to" || line == "Ship to" || line == "Customer"
+		if bold && i > 0 {
+			y += 8
+		}
+		doc.Text(printMargin, y, 10, bold, line)
+		y += 14
+	}
+
+	header := func() {
+		y += 12
+		doc.Text(printMargin, y, 9, true, "SKU")
+		doc.Text(printMargin+110, y, 9, true, "Item")
+		doc.TextRight(right-170, y, 9, true, "Qty")
+		if d.ShowPrices {
+			doc.TextRight(right-80, y, 9, true, "Unit price")
+			doc.TextRight(right, y, 9, true, "Total")
+		}
+		y += 6
+		doc.Line(printMargin, y, right, y)
+		y += 14
+	}
+	header()
+	for _, l := range d.Lines {
+		if y > printBottom {
+			doc.AddPage()
+			doc.Text(printMargin, 64, 10, false, fmt.Sprintf("%s %s (continued)", d.Title, d.OrderRef))
+			y = 72
+			header()
+		}
+		doc.Text(printMargin, y, 9, false, pdf.Truncate(l.SKU, 20))
+		itemWidth := 44
+		if !d.ShowPrices {
+			itemWidth = 60
+		}
+		doc.Text(printMargin+110, y, 9, false, pdf.Truncate(l.Title, itemWidth))
+		doc.TextRight(right-170, y, 9, false, fmt.Sprint(l.Quantity))
+		if d.ShowPrices {
+			doc.TextRight(right-80, y, 9, false, l.UnitPrice)
+			doc.TextRight(right, y, 9, false, l.LineTotal)
+		}
+		y += 16
+	}
+	if d.ShowPrices {
+		doc.Line(printMargin, y-10, right, y-10)
+		doc.TextRight(right-80, y+4, 10, true, "Total")
+		doc.TextRight(right, y+4, 10, true, d.Total)
+		y += 20
+	}
+	if g := d.Gift; g != nil && g.Message != "" {
+		y += 16
+		doc.Text(printMargin, y, 10, true, "Gift message")
+		for _, line := range wrapText(g.Message, 90) {
+			y += 14
+			doc.Text(printMargin, y, 10, false, line)
+		}
+	}
+}
+
+// renderShippingLabel prints one label per page: the recipient address (the gift address, or the
+// buyer for orders shipped to themselves) and the order reference
+func renderShippingLabel(doc *pdf.Document, detail *models.AdminOrderDetailResponse) {
+	doc.AddPage()
+	x, y := printMargin, printMargin
+	doc.Rect(x, y, 300, 200)
+	doc.Text(x+16, y+28, 9, false, "SHIP TO")
+
+	lines := []string{detail.Order.UserName, detail.Order.UserEmail}
+	if lines[0] == "" {
+		lines = lines[1:]
+	}
+	if detail.Gift != nil {
+		lines = giftAddressLines(detail.Gift)
+	}
+	ly := y + 52
+	for i, line := range lines {
+		size := 12.0
+		if i == 0 {
+			size = 14
+		}
+		doc.Text(x+16, ly, size, i == 0, pdf.Truncate(line, 36))
+		ly += 18
+	}
+
+	items := 0
+	for _, it := range detail.Items {
+		items += it.Quantity
+	}
+	doc.Line(x, y+170, x+300, y+170)
+	doc.Text(x+16, y+188, 10, true, "Order "+shortOrderID(detail.Order.ID))
+	doc.TextRight(x+284, y+188, 10, false, fmt.Sprintf("%d item(s)", items))
+}
+
+func giftAddressLines(g *models.OrderGift) []string {
+	lines := []string{g.RecipientName, g.AddressLine1}
+	if g.AddressLine2 != "" {
+		lines = append(lines, g.AddressLine2)
+	}
+	lines = append(lines, g.PostalCode+" "+g.City, g.Country)
+	if g.RecipientPhone != "" {
+		lines = append(lines, g.RecipientPhone)
+	}
+	return lines
+}
+
+// wrapText breaks s into lines of at most width characters at spaces (and at existing newlines)
+func wrapText(s string, width int) []string {
+	var out []string
+	for _, para := range strings.Split(s, "\n") {
+		line := ""
+		for _, word := range strings.Fields(para) {
+			if line != "" && len([]rune(line))+1+len([]rune(word)) > width {
+				out = append(out, line)
+				line = ""
+			}
+			if line != "" {
+				line += " "
+			}
+			line += word
+		}
+		out = append(out, line)
+	}
+	return out
+}
@@ -0,0 +1,313 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/expotoworld/expotoworld/backend/order-service/internal/models"
+	"github.com/expotoworld/expotoworld/backend/order-service/internal/money"
+	"github.com/jackc/pgx/v5"
+)
+
+var (
+	errQuoteNotFound     = errors.New("quote not found")
+	errQuoteNotEditable  = errors.New("quote can only be modified while in draft")
+	errQuoteNotSendable  = errors.New("only draft quotes can be sent")
+	errQuoteUnavailable  = errors.New("quote is not open for conversion")
+	errQuoteExpired      = errors.New("quote is outside its validity window")
+	errQuoteCustomerNone = errors.New("customer not found")
+)
+
+const quoteColumns = `
+	id, created_by, customer_user_id, mini_app_type, store_id, status, COALESCE(notes, ''),
+	valid_from, valid_until, total_amount, currency, converted_order_id, sent_at, created_at, updated_at
+`
+
+func scanQuote(row pgx.Row, q *models.Quote) error {
+	return row.Scan(
+		&q.ID,
+		&q.CreatedBy,
+		&q.CustomerUserID,
+		&q.MiniAppType,
+		&q.StoreID,
+		&q.Status,
+		&q.Notes,
+		&q.ValidFrom,
+		&q.ValidUntil,
+		&q.TotalAmount,
+		&q.Currency,
+		&q.ConvertedOrderID,
+		&q.SentAt,
+		&q.CreatedAt,
+		&q.UpdatedAt,
+	)
+}
+
+// resolveCustomerUserID returns the user id for a quote customer given either an id or an email
+func (h *Handler) resolveCustomerUserID(ctx context.Context, userID, email string) (string, error) {
+	var id string
+	var err error
+	if userID != "" {
+		err = h.db.Pool.QueryRow(ctx, `SELECT id::text FROM app_users WHERE id::text = $1`, userID).Scan(&id)
+	} else {
+		err = h.db.Pool.QueryRow(ctx, `SELECT id::text FROM app_users WHERE LOWER(email) = LOWER($1)`, email).Scan(&id)
+	}
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return "", errQuoteCustomerNone
+		}
+		return "", fmt.Errorf("failed to resolve customer: %w", err)
+	}
+	return id, nil
+}
+
+// replaceQuoteItems validates products and rewrites the quote's lines, returning the new total
+func (h *Handler) replaceQuoteItems(ctx context.Context, tx pgx.Tx, quoteID string, items []models.QuoteItemRequest) (money.Amount, error) {
+	if _, err := tx.Exec(ctx, `DELETE FROM app_quote_items WHERE quote_id = $1`, quoteID); err != nil {
+		return 0, fmt.Errorf("failed to clear quote items: %w", err)
+	}
+
+	var total money.Amount
+	for _, it := range items {
+		product, err := h.getProduct(ctx, it.ProductID)
+		if err != nil {
+			return 0, fmt.Errorf("product %s not found", it.ProductID)
+		}
+		if !product.IsActive {
+			return 0, fmt.Errorf("product %s is not active", it.ProductID)
+		}
+		if _, err := tx.Exec(ctx, `
+			INSERT INTO app_quote_items (quote_id, product_id, quantity, unit_price, list_price)
+			VALUES ($1, $2, $3, $4, $5)
+		`, quoteID, it.ProductID, it.Quantity, it.UnitPrice, product.MainPrice); err != nil {
+			return 0, fmt.Errorf("failed to create quote item: %w", err)
+		}
+		total += it.UnitPrice.Mul(it.Quantity)
+	}
+	return total, nil
+}
+
+// createQuote inserts a draft quote with its items
+func (h *Handler) createQuote(ctx context.Context, createdBy, customerUserID string, req *models.CreateQuoteRequest) (*models.Quote, error) {
+	tx, err := h.db.Pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	validFrom := time.Now()
+	if req.ValidFrom != nil {
+		validFrom = *req.ValidFrom
+	}
+
+	var quoteID string
+	err = tx.QueryRow(ctx, `
+		INSERT INTO app_quotes (created_by, customer_user_id, mini_app_type, store_id, status, notes, valid_from, valid_until)
+		VALUES ($1, $2, $3, $4, $5, NULLIF($6, ''), $7, $8)
+		RETURNING id
+	`, createdBy, customerUserID, string(req.MiniAppType), req.StoreID, string(models.QuoteStatusDraft), req.Notes, validFrom, req.ValidUntil).Scan(&quoteID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create quote: %w", err)
+	}
+
+	total, err := h.replaceQuoteItems(ctx, tx, quoteID, req.Items)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := tx.Exec(ctx, `UPDATE app_quotes SET total_amount = $1 WHERE id = $2`, total, quoteID); err != nil {
+		return nil, fmt.Errorf("failed to update quote total: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return h.getQuoteByID(ctx, quoteID)
+}
+
+// updateQuote replaces the contents of a draft quote
+func (h *Handler) updateQuote(ctx context.Context, quoteID string, req *models.UpdateQuoteRequest) (*models.Quote, error) {
+	tx, err := h.db.Pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	var status models.QuoteStatus
+	if err := tx.QueryRow(ctx, `SELECT status FROM app_quotes WHERE id = $1 FOR UPDATE`, quoteID).Scan(&status); err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, errQuoteNotFound
+		}
+		return nil, fmt.Errorf("failed to get quote: %w", err)
+	}
+	if status != models.QuoteStatusDraft {
+		return nil, errQuoteNotEditable
+	}
+
+	total, err := h.replaceQuoteItems(ctx, tx, quoteID, req.Items)
+	if err != nil {
+		return nil, err
+	}
+	_, err = tx.Exec(ctx, `
+		UPDATE app_quotes
+		SET notes = NULLIF($2, ''), store_id = $3, valid_from = COALESCE($4, valid_from), valid_until = $5,
+			total_amount = $6, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1
+	`, quoteID, req.Notes, req.StoreID, req.ValidFrom, req.ValidUntil, total)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update quote: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return h.getQuoteByID(ctx, quoteID)
+}
+
+// setQuoteStatus moves a quote from one of the allowed statuses to a new status
+func (h *Handler) setQuoteStatus(ctx context.Context, quoteID string, from []models.QuoteStatus, to models.QuoteStatus) error {
+	fromStr := make([]string, len(from))
+	for i, s := range from {
+		fromStr[i] = string(s)
+	}
+	tag, err := h.db.Pool.Exec(ctx, `
+		UPDATE app_quotes
+		SET status = $2,
+			sent_at = CASE WHEN $2 = 'sent' THEN CURRENT_TIMESTAMP ELSE sent_at END,
+			updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND status = ANY($3)
+	`, quoteID, string(to), fromStr)
+	if err != nil {
+		return fmt.Errorf("failed to update quote status: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return errQuoteNotFound
+	}
+	return nil
+}
+
+// getQuoteByID retrieves a quote with its items
+func (h *Handler) getQuoteByID(ctx context.Context, quoteID string) (*models.Quote, error) {
+	var q models.Quote
+	row := h.db.Pool.QueryRow(ctx, `SELECT `+quoteColumns+` FROM app_quotes WHERE id::text = $1`, quoteID)
+	if err := scanQuote(row, &q); err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, errQuoteNotFound
+		}
+		return nil, fmt.Errorf("failed to get quote: %w", err)
+	}
+
+	rows, err := h.db.Pool.Query(ctx, `
+		SELECT qi.id, qi.quote_id, qi.product_id, qi.quantity, qi.unit_price, qi.list_price,
+			COALESCE(p.sku, ''), COALESCE(p.title, ''), COALESCE(p.main_price, 0), COALESCE(p.stock_left, 0),
+			COALESCE(p.minimum_order_quantity, 1), COALESCE(p.is_active, false)
+		FROM app_quote_items qi
+		LEFT JOIN admin_products p ON p.product_uuid = qi.product_id
+		WHERE qi.quote_id = $1
+		ORDER BY qi.id
+	`, q.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get quote items: %w", err)
+	}
+	defer rows.Close()
+
+	q.Items = []models.QuoteItem{}
+	for rows.Next() {
+		var it models.QuoteItem
+		var p models.Product
+		if err := rows.Scan(&it.ID, &it.QuoteID, &it.ProductID, &it.Quantity, &it.UnitPrice, &it.ListPrice,
+			&p.SKU, &p.Title, &p.MainPrice, &p.StockLeft, &p.MinimumOrderQuantity, &p.IsActive); err != nil {
+			return nil, fmt.Errorf("failed to scan quote item: %w", err)
+		}
+		p.ID = it.ProductID
+		it.Product = &p
+		q.Items = append(q.Items, it)
+	}
+	return &q, rows.Err()
+}
+
+// listQuotes lists quotes created by a user (or all quotes when createdBy is empty)
+func (h *Handler) listQuotes(ctx context.Context, createdBy, customerUserID, status string) ([]models.Quote, error) {
+	rows, err := h.db.Pool.Query(ctx, `
+		SELECT `+quoteColumns+`
+		FROM app_quotes
+		WHERE ($1 = '' OR created_by::text = $1)
+			AND ($2 = '' OR customer_user_id::text = $2)
+			AND ($3 = '' OR status = $3)
+		ORDER BY created_at DESC
+		LIMIT 200
+	`, createdBy, customerUserID, status)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list quotes: %w", err)
+	}
+	defer rows.Close()
+
+	quotes := []models.Quote{}
+	for rows.Next() {
+		var q models.Quote
+		if err := scanQuote(rows, &q); err != nil {
+			return nil, fmt.Errorf("failed to scan quote: %w", err)
+		}
+		quotes = append(quotes, q)
+	}
+	return quotes, rows.Err()
+}
+
+// convertQuoteToOrder claims a sent quote for the customer and creates an order at the negotiated prices.
+// The claim is released again if order creation fails, so the customer can retry.
+func (h *Handler) convertQuoteToOrder(ctx context.Context, quoteID, customerUserID string) (*models.Order, error) {
+	quote, err := h.getQuoteByID(ctx, quoteID)
+	if err != nil {
+		return nil, err
+	}
+	if quote.CustomerUserID != customerUserID {
+		return nil, errQuoteNotFound
+	}
+	if quote.Status != models.QuoteStatusSent {
+		return nil, errQuoteUnavailable
+	}
+	if quote.IsExpired(time.Now()) {
+		return nil, errQuoteExpired
+	}
+
+	// Atomically claim the quote so concurrent calls cannot convert it twice
+	tag, err := h.db.Pool.Exec(ctx, `
+		UPDATE app_quotes SET status = $2, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND status = $3 AND now() >= valid_from AND now() < valid_until
+	`, quote.ID, string(models.QuoteStatusConverted), string(models.QuoteStatusSent))
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim quote: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return nil, errQuoteUnavailable
+	}
+
+	// Reuse the regular order path with the negotiated unit prices
+	items := make([]models.Cart, 0, len(quote.Items))
+	var total money.Amount
+	for _, it := range quote.Items {
+		product := *it.Product
+		product.MainPrice = it.UnitPrice
+		items = append(items, models.Cart{
+			UserID:      customerUserID,
+			ProductID:   it.ProductID,
+			Quantity:    it.Quantity,
+			MiniAppType: quote.MiniAppType,
+			Product:     &product,
+		})
+		total += it.UnitPrice.Mul(it.Quantity)
+	}
+
+	order, err := h.createOrder(ctx, customerUserID, quote.MiniAppType, quote.StoreID, total, items)
+	if err != nil {
+		_, _ = h.db.Pool.Exec(ctx, `UPDATE app_quotes SET status = $2, updated_at = CURRENT_TIMESTAMP WHERE id = $1`,
+			quote.ID, string(models.QuoteStatusSent))
+		return nil, err
+	}
+
+	if _, err := h.db.Pool.Exec(ctx, `UPDATE app_quotes SET converted_order_id = $2 WHERE id = $1`, quote.ID, order.ID); err != nil {
+		fmt.Printf("Warning: Failed to link quote %s to order %s: %v\n", quote.ID, order.ID, err)
+	}
+	return order, nil
+}
@@ -0,0 +1,343 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/expotoworld/expotoworld/backend/order-service/internal/models"
+	"github.com/gin-gonic/gin"
+)
+
+// QuoteAuthorMiddleware allows Admin and Partner roles to assemble and send quotes
+func QuoteAuthorMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		roleVal, _ := c.Get("role")
+		role, _ := roleVal.(string)
+		if role != "Admin" && role != "Partner" {
+			c.JSON(http.StatusForbidden, models.ErrorResponse{
+				Error:   "Access denied",
+				Message: "Admin or Partner role required",
+			})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// writeQuoteError maps quote errors to HTTP responses
+func writeQuoteError(c *gin.Context, action string, err error) {
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, errQuoteNotFound), errors.Is(err, errQuoteCustomerNone):
+		status = http.StatusNotFound
+	case errors.Is(err, errQuoteNotEditable), errors.Is(err, errQuoteNotSendable), errors.Is(err, errQuoteUnavailable):
+		status = http.StatusConflict
+	case errors.Is(err, errQuoteExpired):
+		status = http.StatusGone
+	}
+	c.JSON(status, models.ErrorResponse{
+		Error:   action,
+		Message: err.Error(),
+	})
+}
+
+// canManageQuote reports whether the caller may view or modify a quote they did not necessarily create
+func canManageQuote(c *gin.Context, quote *models.Quote) bool {
+	roleVal, _ := c.Get("role")
+	if role, _ := roleVal.(string); role == "Admin" {
+		return true
+	}
+	userID, _ := GetUserID(c)
+	return quote.CreatedBy == userID
+}
+
+// CreateQuote handles POST /api/quotes
+func (h *Handler) CreateQuote(c *gin.Context) {
+	var req models.CreateQuoteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid request data",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	userID, ok := GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+			Error:   "Invalid user",
+			Message: "Could not extract user ID from token",
+		})
+		return
+	}
+
+	if req.MiniAppType == "" {
+		req.MiniAppType = models.MiniAppTypeGroupBuying
+	}
+	if !req.MiniAppType.IsValid() {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid mini-app type",
+			Message: "Mini-app type must be one of: RetailStore, UnmannedStore, ExhibitionSales, GroupBuying",
+		})
+		return
+	}
+	if req.MiniAppType.RequiresStore() && req.StoreID == nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Store ID required",
+			Message: "This mini-app requires a store selection",
+		})
+		return
+	}
+	if req.CustomerUserID == "" && req.CustomerEmail == "" {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Customer required",
+			Message: "Provide customer_user_id or customer_email",
+		})
+		return
+	}
+	validFrom := time.Now()
+	if req.ValidFrom != nil {
+		validFrom = *req.ValidFrom
+	}
+	if !req.ValidUntil.After(validFrom) {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid validity window",
+			Message: "valid_until must be after valid_from",
+		})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	customerID, err := h.resolveCustomerUserID(ctx, req.CustomerUserID, req.CustomerEmail)
+	if err != nil {
+		writeQuoteError(c, "Failed to resolve customer", err)
+		return
+	}
+
+	quote, err := h.createQuote(ctx, userID, customerID, &req)
+	if err != nil {
+		writeQuoteError(c, "Failed to create quote", err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, models.SuccessResponse{
+		Message: "Quote created successfully",
+		Data:    quote,
+	})
+}
+
+// GetQuotes handles GET /api/quotes (admins see all quotes, partners see their own)
+func (h *Handler) GetQuotes(c *gin.Context) {
+	userID, _ := GetUserID(c)
+	createdBy := userID
+	if roleVal, _ := c.Get("role"); roleVal == "Admin" {
+		createdBy = c.Query("created_by")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	quotes, err := h.listQuotes(ctx, createdBy, c.Query("customer_user_id"), c.Query("status"))
+	if err != nil {
+		writeQuoteError(c, "Failed to get quotes", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponse{
+		Message: "Quotes retrieved successfully",
+		Data:    quotes,
+	})
+}
+
+// GetQuote handles GET /api/quotes/:quote_id
+func (h *Handler) GetQuote(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	quote, err := h.getQuoteByID(ctx, c.Param("quote_id"))
+	if err == nil && !canManageQuote(c, quote) {
+		err = errQuoteNotFound
+	}
+	if err != nil {
+		writeQuoteError(c, "Failed to get quote", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponse{
+		Message: "Quote retrieved successfully",
+		Data:    quote,
+	})
+}
+
+// UpdateQuote handles PUT /api/quotes/:quote_id (draft quotes only)
+func (h *Handler) UpdateQuote(c *gin.Context) {
+	var req models.UpdateQuoteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid request data",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	existing, err := h.getQuoteByID(ctx, c.Param("quote_id"))
+	if err == nil && !canManageQuote(c, existing) {
+		err = errQuoteNotFound
+	}
+	if err != nil {
+		writeQuoteError(c, "Failed to update quote", err)
+		return
+	}
+	if existing.MiniAppType.RequiresStore() && req.StoreID == nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Store ID required",
+			Message: "This mini-app requires a store selection",
+		})
+		return
+	}
+
+	quote, err := h.updateQuote(ctx, existing.ID, &req)
+	if err != nil {
+		writeQuoteError(c, "Failed to update quote", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponse{
+		Message: "Quote updated successfully",
+		Data:    quote,
+	})
+}
+
+// SendQuote handles POST /api/quotes/:quote_id/send, making the quote visible to the customer
+func (h *Handler) SendQuote(c *gin.Context) {
+	h.transitionQuote(c, []models.QuoteStatus{models.QuoteStatusDraft}, models.QuoteStatusSent, "Quote sent successfully")
+}
+
+// CancelQuote handles POST /api/quotes/:quote_id/cancel
+func (h *Handler) CancelQuote(c *gin.Context) {
+	h.transitionQuote(c, []models.QuoteStatus{models.QuoteStatusDraft, models.QuoteStatusSent}, models.QuoteStatusCancelled, "Quote cancelled successfully")
+}
+
+func (h *Handler) transitionQuote(c *gin.Context, from []models.QuoteStatus, to models.QuoteStatus, message string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	quote, err := h.getQuoteByID(ctx, c.Param("quote_id"))
+	if err == nil && !canManageQuote(c, quote) {
+		err = errQuoteNotFound
+	}
+	if err != nil {
+		writeQuoteError(c, "Failed to update quote", err)
+		return
+	}
+	if to == models.QuoteStatusSent && !time.Now().Before(quote.ValidUntil) {
+		writeQuoteError(c, "Failed to send quote", errQuoteExpired)
+		return
+	}
+
+	if err := h.setQuoteStatus(ctx, quote.ID, from, to); err != nil {
+		if errors.Is(err, errQuoteNotFound) {
+			err = errQuoteNotSendable
+			if to == models.QuoteStatusCancelled {
+				err = errQuoteUnavailable
+			}
+		}
+		writeQuoteError(c, "Failed to update quote", err)
+		return
+	}
+
+	quote, _ = h.getQuoteByID(ctx, quote.ID)
+	c.JSON(http.StatusOK, models.SuccessResponse{
+		Message: message,
+		Data:    quote,
+	})
+}
+
+// GetMyQuotes handles GET /api/my-quotes (quotes sent to the current customer)
+func (h *Handler) GetMyQuotes(c *gin.Context) {
+	userID, ok := GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+			Error:   "Invalid user",
+			Message: "Could not extract user ID from token",
+		})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	quotes, err := h.listQuotes(ctx, "", userID, "")
+	if err != nil {
+		writeQuoteError(c, "Failed to get quotes", err)
+		return
+	}
+	// Drafts are private to the author
+	visible := make([]models.Quote, 0, len(quotes))
+	for _, q := range quotes {
+		if q.Status != models.QuoteStatusDraft {
+			visible = append(visible, q)
+		}
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponse{
+		Message: "Quotes retrieved successfully",
+		Data:    visible,
+	})
+}
+
+// GetMyQuote handles GET /api/my-quotes/:quote_id
+func (h *Handler) GetMyQuote(c *gin.Context) {
+	userID, _ := GetUserID(c)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	quote, err := h.getQuoteByID(ctx, c.Param("quote_id"))
+	if err == nil && (quote.CustomerUserID != userID || quote.Status == models.QuoteStatusDraft) {
+		err = errQuoteNotFound
+	}
+	if err != nil {
+		writeQuoteError(c, "Failed to get quote", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponse{
+		Message: "Quote retrieved successfully",
+		Data:    quote,
+	})
+}
+
+// ConvertQuote handles POST /api/my-quotes/:quote_id/convert, turning a sent quote into an order
+func (h *Handler) ConvertQuote(c *gin.Context) {
+	userID, ok := GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+			Error:   "Invalid user",
+			Message: "Could not extract user ID from token",
+		})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	order, err := h.convertQuoteToOrder(ctx, c.Param("quote_id"), userID)
+	if err != nil {
+		writeQuoteError(c, "Failed to convert quote", err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, models.SuccessResponse{
+		Message: "Order created from quote successfully",
+		Data:    order,
+	})
+}
@@ -0,0 +1,52 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/expotoworld/expotoworld/backend/order-service/internal/models"
+)
+
+// getSettlements totals delivered order lines per manufacturer for orders placed in [from, to).
+// Commission rates come from app_commission_rate, maintained by catalog-service; each line is
+// rated as of its order's placement and rounded to the cent. orgIDs limits the manufacturers (nil = all).
+func (h *Handler) getSettlements(ctx context.Context, from, to time.Time, orgIDs []string) (*models.SettlementReport, error) {
+	rows, err := h.db.Pool.Query(ctx, `
+		SELECT l.manufacturer_org_id::text, COALESCE(org.name, ''),
+			COUNT(DISTINCT o.id), COUNT(*), COALESCE(SUM(oi.quantity), 0),
+			COALESCE(SUM(oi.quantity * oi.price), 0),
+			COALESCE(SUM(ROUND(oi.quantity * oi.price * cr.rate_pct / 100, 2)), 0),
+			COUNT(*) FILTER (WHERE cr.rate_id IS NULL)
+		FROM app_orders o
+		JOIN app_order_items oi ON oi.order_id = o.id
+		JOIN app_order_item_org_links l ON l.order_item_id = oi.id
+		LEFT JOIN admin_products p ON p.product_uuid = oi.product_id
+		LEFT JOIN admin_organizations org ON org.org_id = l.manufacturer_org_id
+		LEFT JOIN LATERAL app_commission_rate(p.product_id, l.manufacturer_org_id, o.created_at) cr ON true
+		WHERE o.status = $1 AND o.created_at >= $2 AND o.created_at < $3
+		  AND l.manufacturer_org_id IS NOT NULL
+		  AND ($4::text[] IS NULL OR l.manufacturer_org_id::text = ANY($4))
+		GROUP BY l.manufacturer_org_id, org.name
+		ORDER BY org.name, l.manufacturer_org_id
+	`, string(models.OrderStatusDelivered), from, to, orgIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query settlements: %w", err)
+	}
+	defer rows.Close()
+
+	report := &models.SettlementReport{PeriodStart: from, PeriodEnd: to, Settlements: []models.ManufacturerSettlement{}}
+	for rows.Next() {
+		var s models.ManufacturerSettlement
+		if err := rows.Scan(&s.ManufacturerOrgID, &s.ManufacturerName, &s.OrderCount, &s.ItemCount, &s.UnitsSold,
+			&s.GrossSales, &s.Commission, &s.UnratedItems); err != nil {
+			return nil, fmt.Errorf("failed to scan settlement: %w", err)
+		}
+		s.NetPayout = s.GrossSales - s.Commission
+		report.TotalGross += s.GrossSales
+		report.TotalCommission += s.Commission
+		report.TotalPayout += s.NetPayout
+		report.Settlements = append(report.Settlements, s)
+	}
+	return report, rows.Err()
+}
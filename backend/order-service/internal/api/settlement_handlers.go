@@ -0,0 +1,92 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/expotoworld/expotoworld/backend/common/auth"
+	"github.com/expotoworld/expotoworld/backend/order-service/internal/models"
+	"github.com/gin-gonic/gin"
+)
+
+// GetSettlements handles GET /api/admin/settlements
+// Period: ?month=YYYY-MM, or ?date_from=YYYY-MM-DD&date_to=YYYY-MM-DD (inclusive); defaults to last month (UTC).
+// Optional ?manufacturer_org_id= limits the report to one manufacturer.
+func (h *Handler) GetSettlements(c *gin.Context) {
+	from, to, msg := parseReportPeriod(c)
+	if msg != "" {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid period", Message: msg})
+		return
+	}
+	var orgIDs []string
+	if id := strings.TrimSpace(c.Query("manufacturer_org_id")); id != "" {
+		orgIDs = []string{id}
+	}
+	h.writeSettlements(c, from, to, orgIDs)
+}
+
+// GetManufacturerSettlements handles GET /api/manufacturer/settlements for the caller's own organizations
+func (h *Handler) GetManufacturerSettlements(c *gin.Context) {
+	orgIDs := auth.OrgIDs(c, "Manufacturer")
+	if len(orgIDs) == 0 {
+		c.JSON(http.StatusForbidden, models.ErrorResponse{Error: "Forbidden", Message: "Manufacturer membership required"})
+		return
+	}
+	from, to, msg := parseReportPeriod(c)
+	if msg != "" {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid period", Message: msg})
+		return
+	}
+	h.writeSettlements(c, from, to, orgIDs)
+}
+
+func (h *Handler) writeSettlements(c *gin.Context, from, to time.Time, orgIDs []string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	report, err := h.getSettlements(ctx, from, to, orgIDs)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "Failed to compute settlements",
+			Message: err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
+
+// parseReportPeriod returns the half-open UTC period [from, to); an empty message means valid
+func parseReportPeriod(c *gin.Context) (time.Time, time.Time, string) {
+	if month := c.Query("month"); month != "" {
+		start, err := time.Parse("2006-01", month)
+		if err != nil {
+			return time.Time{}, time.Time{}, "month must be YYYY-MM"
+		}
+		return start, start.AddDate(0, 1, 0), ""
+	}
+
+	dateFrom, dateTo := c.Query("date_from"), c.Query("date_to")
+	if dateFrom == "" && dateTo == "" {
+		now := time.Now().UTC()
+		thisMonth := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+		return thisMonth.AddDate(0, -1, 0), thisMonth, ""
+	}
+	if dateFrom == "" || dateTo == "" {
+		return time.Time{}, time.Time{}, "date_from and date_to must be given together"
+	}
+	from, err := time.Parse("2006-01-02", dateFrom)
+	if err != nil {
+		return time.Time{}, time.Time{}, "date_from must be YYYY-MM-DD"
+	}
+	to, err := time.Parse("2006-01-02", dateTo)
+	if err != nil {
+		return time.Time{}, time.Time{}, "date_to must be YYYY-MM-DD"
+	}
+	to = to.AddDate(0, 0, 1)
+	if !to.After(from) {
+		return time.Time{}, time.Time{}, "date_to must not be before date_from"
+	}
+	return from, to, ""
+}
@@ -0,0 +1,216 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/expotoworld/expotoworld/backend/order-service/internal/models"
+	"github.com/jackc/pgx/v5"
+)
+
+// agingOrdersCTE selects orders that have been in their current status longer than its SLA threshold.
+// An order entered its status at the latest timeline event that set it; orders without such an event
+// fall back to their creation (pending) or last update time.
+const agingOrdersCTE = `
+	WITH current_status AS (
+		SELECT o.id, o.status::text AS status, t.max_hours,
+			COALESCE(
+				(SELECT MAX(e.occurred_at) FROM app_order_events e WHERE e.order_id = o.id AND e.new_status = o.status::text),
+				CASE WHEN o.status::text = 'pending' THEN o.created_at ELSE o.updated_at END
+			) AS entered_at
+		FROM app_orders o
+		JOIN app_order_sla_thresholds t ON t.status = o.status::text
+	), aging AS (
+		SELECT * FROM current_status
+		WHERE entered_at < now() - make_interval(hours => max_hours)
+	)`
+
+// getOrderSLAThresholds lists the configured thresholds
+func (h *Handler) getOrderSLAThresholds(ctx context.Context) ([]models.OrderSLAThreshold, error) {
+	rows, err := h.db.Pool.Query(ctx, `
+		SELECT status, max_hours, updated_by, updated_at FROM app_order_sla_thresholds ORDER BY max_hours, status
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list SLA thresholds: %w", err)
+	}
+	defer rows.Close()
+
+	thresholds := []models.OrderSLAThreshold{}
+	for rows.Next() {
+		var t models.OrderSLAThreshold
+		if err := rows.Scan(&t.Status, &t.MaxHours, &t.UpdatedBy, &t.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan SLA threshold: %w", err)
+		}
+		thresholds = append(thresholds, t)
+	}
+	return thresholds, rows.Err()
+}
+
+// setOrderSLAThreshold creates or replaces the threshold of a status
+func (h *Handler) setOrderSLAThreshold(ctx context.Context, status models.OrderStatus, maxHours int, actorID string) (*models.OrderSLAThreshold, error) {
+	var t models.OrderSLAThreshold
+	err := h.db.Pool.QueryRow(ctx, `
+		INSERT INTO app_order_sla_thresholds (status, max_hours, updated_by, updated_at)
+		VALUES ($1, $2, NULLIF($3, ''), now())
+		ON CONFLICT (status) DO UPDATE SET max_hours = EXCLUDED.max_hours, updated_by = EXCLUDED.updated_by, updated_at = now()
+		RETURNING status, max_hours, updated_by, updated_at
+	`, string(status), maxHours, actorID).Scan(&t.Status, &t.MaxHours, &t.UpdatedBy, &t.UpdatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to set SLA threshold: %w", err)
+	}
+	return &t, nil
+}
+
+// deleteOrderSLAThreshold stops tracking a status; pgx.ErrNoRows if it had no threshold
+func (h *Handler) deleteOrderSLAThreshold(ctx context.Context, status models.OrderStatus) error {
+	tag, err := h.db.Pool.Exec(ctx, `DELETE FROM app_order_sla_thresholds WHERE status = $1`, string(status))
+	if err != nil {
+		return fmt.Errorf("failed to delete SLA threshold: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
+
+// getAgingOrders lists orders past their status' threshold, most overdue first
+func (h *Handler) getAgingOrders(ctx context.Context, req *models.AgingOrderListRequest) ([]models.AgingOrder, error) {
+	var where []string
+	var args []interface{}
+	if req.Status != "" {
+		args = append(args, req.Status)
+		where = append(where, fmt.Sprintf("a.status = $%d", len(args)))
+	}
+	if req.StoreID != nil {
+		args = append(args, *req.StoreID)
+		where = append(where, fmt.Sprintf("o.store_id = $%d", len(args)))
+	}
+	if req.ScopeStoreIDs != nil {
+		args = append(args, req.ScopeStoreIDs)
+		where = append(where, fmt.Sprintf("o.store_id = ANY($%d::int[])", len(args)))
+	}
+	whereClause := ""
+	if len(where) > 0 {
+		whereClause = "WHERE " + strings.Join(where, " AND ")
+	}
+	args = append(args, req.Limit)
+
+	rows, err := h.db.Pool.Query(ctx, agingOrdersCTE+fmt.Sprintf(`
+		SELECT o.id::text, o.user_id::text, COALESCE(u.email, ''), o.mini_app_type, o.store_id, o.total_amount,
+			a.status, a.entered_at, EXTRACT(EPOCH FROM now() - a.entered_at) / 3600, a.max_hours, b.flagged_at
+		FROM aging a
+		JOIN app_orders o ON o.id = a.id
+		LEFT JOIN app_users u ON o.user_id = u.id
+		LEFT JOIN app_order_sla_breaches b
+			ON b.order_id = a.id AND b.status = a.status AND b.entered_at = a.entered_at AND b.resolved_at IS NULL
+		%s
+		ORDER BY now() - a.entered_at - make_interval(hours => a.max_hours) DESC
+		LIMIT $%d
+	`, whereClause, len(args)), args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list aging orders: %w", err)
+	}
+	defer rows.Close()
+
+	orders := []models.AgingOrder{}
+	for rows.Next() {
+		var o models.AgingOrder
+		if err := rows.Scan(&o.OrderID, &o.UserID, &o.UserEmail, &o.MiniAppType, &o.StoreID, &o.TotalAmount,
+			&o.Status, &o.EnteredStatusAt, &o.AgeHours, &o.MaxHours, &o.FlaggedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan aging order: %w", err)
+		}
+		o.OverdueHours = o.AgeHours - float64(o.MaxHours)
+		orders = append(orders, o)
+	}
+	return orders, rows.Err()
+}
+
+// slaBreach is a breach newly flagged by a checker run
+type slaBreach struct {
+	OrderID   string
+	Status    string
+	EnteredAt time.Time
+	MaxHours  int
+}
+
+// slaCheckResult summarizes one checker run
+type slaCheckResult struct {
+	Flagged  []slaBreach
+	Resolved int64
+	Open     map[string]int // open breaches by status
+}
+
+// checkOrderSLAs flags orders that newly breached their threshold and resolves breaches of orders that
+// moved on, all in one transaction so concurrent instances don't double-flag.
+func (h *Handler) checkOrderSLAs(ctx context.Context) (*slaCheckResult, error) {
+	tx, err := h.db.Pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	// Serialize runs across service instances
+	if _, err := tx.Exec(ctx, `SELECT pg_advisory_xact_lock(hashtext('app_order_sla_check'))`); err != nil {
+		return nil, fmt.Errorf("failed to lock SLA check: %w", err)
+	}
+
+	res := &slaCheckResult{Open: map[string]int{}}
+	rows, err := tx.Query(ctx, agingOrdersCTE+`
+		INSERT INTO app_order_sla_breaches (order_id, status, entered_at, max_hours)
+		SELECT id, status, entered_at, max_hours FROM aging
+		ON CONFLICT (order_id, status, entered_at) DO NOTHING
+		RETURNING order_id::text, status, entered_at, max_hours
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to flag SLA breaches: %w", err)
+	}
+	for rows.Next() {
+		var b slaBreach
+		if err := rows.Scan(&b.OrderID, &b.Status, &b.EnteredAt, &b.MaxHours); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan SLA breach: %w", err)
+		}
+		res.Flagged = append(res.Flagged, b)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to flag SLA breaches: %w", err)
+	}
+
+	tag, err := tx.Exec(ctx, agingOrdersCTE+`
+		UPDATE app_order_sla_breaches b SET resolved_at = now()
+		WHERE b.resolved_at IS NULL
+			AND NOT EXISTS (
+				SELECT 1 FROM aging a WHERE a.id = b.order_id AND a.status = b.status AND a.entered_at = b.entered_at
+			)
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve SLA breaches: %w", err)
+	}
+	res.Resolved = tag.RowsAffected()
+
+	rows, err = tx.Query(ctx, `SELECT status, COUNT(*) FROM app_order_sla_breaches WHERE resolved_at IS NULL GROUP BY status`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count open SLA breaches: %w", err)
+	}
+	for rows.Next() {
+		var status string
+		var n int
+		if err := rows.Scan(&status, &n); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan open SLA breaches: %w", err)
+		}
+		res.Open[status] = n
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to count open SLA breaches: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit SLA check: %w", err)
+	}
+	return res, nil
+}
@@ -0,0 +1,181 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/expotoworld/expotoworld/backend/order-service/internal/logging"
+	"github.com/expotoworld/expotoworld/backend/order-service/internal/models"
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+)
+
+// parseSLAStatus reads the :status param; an empty message means valid
+func parseSLAStatus(c *gin.Context) (models.OrderStatus, string) {
+	status := models.OrderStatus(c.Param("status"))
+	if !status.IsSLATracked() {
+		return "", "Status must be one of: pending, confirmed, processing, shipped"
+	}
+	return status, ""
+}
+
+// GetOrderSLAThresholds handles GET /api/admin/sla-thresholds
+func (h *Handler) GetOrderSLAThresholds(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	thresholds, err := h.getOrderSLAThresholds(ctx)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to get SLA thresholds", Message: err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, models.SuccessResponse{
+		Message: "SLA thresholds retrieved successfully",
+		Data:    thresholds,
+	})
+}
+
+// SetOrderSLAThreshold handles PUT /api/admin/sla-thresholds/:status
+func (h *Handler) SetOrderSLAThreshold(c *gin.Context) {
+	status, msg := parseSLAStatus(c)
+	if msg != "" {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid status", Message: msg})
+		return
+	}
+	var req models.SetOrderSLAThresholdRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid request data", Message: err.Error()})
+		return
+	}
+
+	adminUserID, _ := GetUserID(c)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	threshold, err := h.setOrderSLAThreshold(ctx, status, req.MaxHours, adminUserID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to set SLA threshold", Message: err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, models.SuccessResponse{
+		Message: "SLA threshold updated successfully",
+		Data:    threshold,
+	})
+}
+
+// DeleteOrderSLAThreshold handles DELETE /api/admin/sla-thresholds/:status
+func (h *Handler) DeleteOrderSLAThreshold(c *gin.Context) {
+	status, msg := parseSLAStatus(c)
+	if msg != "" {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid status", Message: msg})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	err := h.deleteOrderSLAThreshold(ctx, status)
+	if errors.Is(err, pgx.ErrNoRows) {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "SLA threshold not found", Message: "No threshold for this status"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to delete SLA threshold", Message: err.Error()})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// GetAgingOrders handles GET /api/admin/orders/aging
+// Lists orders stuck in a status past its SLA threshold; optional ?status=, ?store_id= and ?limit= (default 100, max 500).
+func (h *Handler) GetAgingOrders(c *gin.Context) {
+	var req models.AgingOrderListRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid query parameters", Message: err.Error()})
+		return
+	}
+	if req.Status != "" && !models.OrderStatus(req.Status).IsSLATracked() {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid status", Message: "Status must be one of: pending, confirmed, processing, shipped"})
+		return
+	}
+	if req.Limit <= 0 {
+		req.Limit = 100
+	}
+	if req.Limit > 500 {
+		req.Limit = 500
+	}
+	if scope := getAdminScope(c); scope != nil {
+		req.ScopeStoreIDs = scope.stores
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	orders, err := h.getAgingOrders(ctx, &req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to get aging orders", Message: err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, models.SuccessResponse{
+		Message: "Aging orders retrieved successfully",
+		Data:    orders,
+	})
+}
+
+// StartSLAChecker periodically flags orders stuck past their SLA threshold (ORDER_SLA_CHECK_MINUTES, default 15).
+// Each run logs an OrderSLABreached entry per new breach and an OrderSLACheck summary whose open_breaches
+// field feeds the CloudWatch alarm. It returns when ctx is cancelled.
+func (h *Handler) StartSLAChecker(ctx context.Context) {
+	minutes := 15
+	if v, err := strconv.Atoi(os.Getenv("ORDER_SLA_CHECK_MINUTES")); err == nil && v > 0 {
+		minutes = v
+	}
+
+	run := func() {
+		jobCtx, cancel := context.WithTimeout(ctx, 2*time.Minute)
+		defer cancel()
+
+		res, err := h.checkOrderSLAs(jobCtx)
+		if err != nil {
+			logging.LogKV("error", "OrderSLACheckFailed", map[string]interface{}{"error": err.Error()})
+			return
+		}
+		for _, b := range res.Flagged {
+			logging.LogKV("warn", "OrderSLABreached", map[string]interface{}{
+				"order_id":   b.OrderID,
+				"status":     b.Status,
+				"entered_at": b.EnteredAt.UTC().Format(time.RFC3339),
+				"max_hours":  b.MaxHours,
+			})
+		}
+		fields := map[string]interface{}{
+			"new_breaches": len(res.Flagged),
+			"resolved":     res.Resolved,
+		}
+		open := 0
+		for status, n := range res.Open {
+			fields[fmt.Sprintf("open_%s", status)] = n
+			open += n
+		}
+		fields["open_breaches"] = open
+		logging.LogKV("info", "OrderSLACheck", fields)
+	}
+
+	run()
+	ticker := time.NewTicker(time.Duration(minutes) * time.Minute)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			run()
+		}
+	}
+}
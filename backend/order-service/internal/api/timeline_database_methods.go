@@ -0,0 +1,172 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/expotoworld/expotoworld/backend/order-service/internal/models"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+var errOrderNotFound = errors.New("order not found")
+
+// execer is satisfied by both the pool and a transaction
+type execer interface {
+	Exec(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error)
+}
+
+// insertOrderEvent appends an entry to an order's timeline
+func insertOrderEvent(ctx context.Context, q execer, orderID string, eventType models.OrderEventType, oldStatus, newStatus *models.OrderStatus, note, actorID string, occurredAt *time.Time) error {
+	at := time.Now()
+	if occurredAt != nil {
+		at = *occurredAt
+	}
+	var actor *string
+	if actorID != "" {
+		actor = &actorID
+	}
+	_, err := q.Exec(ctx, `
+		INSERT INTO app_order_events (order_id, event_type, old_status, new_status, note, actor_id, occurred_at)
+		VALUES ($1, $2, $3, $4, NULLIF($5, ''), $6, $7)
+	`, orderID, string(eventType), oldStatus, newStatus, note, actor, at)
+	if err != nil {
+		return fmt.Errorf("failed to record order event: %w", err)
+	}
+	return nil
+}
+
+// getOrderTimeline returns the order's current status and its events in chronological order.
+// Orders placed before events were recorded get a synthesized "created" entry.
+func (h *Handler) getOrderTimeline(ctx context.Context, orderID string) (*models.OrderTimelineResponse, error) {
+	timeline := &models.OrderTimelineResponse{OrderID: orderID, Events: []models.OrderEvent{}}
+
+	var createdAt time.Time
+	err := h.db.Pool.QueryRow(ctx, `SELECT status, created_at FROM app_orders WHERE id::text = $1`, orderID).Scan(&timeline.Status, &createdAt)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, errOrderNotFound
+		}
+		return nil, fmt.Errorf("failed to get order: %w", err)
+	}
+
+	rows, err := h.db.Pool.Query(ctx, `
+		SELECT id::text, order_id::text, event_type, old_status, new_status, COALESCE(note, ''), actor_id, occurred_at
+		FROM app_order_events
+		WHERE order_id::text = $1
+		ORDER BY occurred_at ASC, id ASC
+	`, orderID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get order events: %w", err)
+	}
+	defer rows.Close()
+
+	hasCreated := false
+	for rows.Next() {
+		var e models.OrderEvent
+		if err := rows.Scan(&e.ID, &e.OrderID, &e.EventType, &e.OldStatus, &e.NewStatus, &e.Note, &e.ActorID, &e.OccurredAt); err != nil {
+			return nil, fmt.Errorf("failed to scan order event: %w", err)
+		}
+		if e.EventType == models.OrderEventCreated {
+			hasCreated = true
+		}
+		timeline.Events = append(timeline.Events, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get order events: %w", err)
+	}
+	if !hasCreated {
+		created := models.OrderEvent{OrderID: orderID, EventType: models.OrderEventCreated, OccurredAt: createdAt}
+		timeline.Events = append([]models.OrderEvent{created}, timeline.Events...)
+	}
+
+	schedule, err := h.getOrderSchedule(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+	timeline.Schedule = schedule
+
+	return timeline, nil
+}
+
+// getOrderSchedule returns the booked slot for an order, or nil when none is set
+func (h *Handler) getOrderSchedule(ctx context.Context, orderID string) (*models.OrderSchedule, error) {
+	var s models.OrderSchedule
+	err := h.db.Pool.QueryRow(ctx, `
+		SELECT order_id::text, kind, starts_at, ends_at, COALESCE(location, ''), COALESCE(notes, ''), sequence, updated_at
+		FROM app_order_schedules
+		WHERE order_id::text = $1
+	`, orderID).Scan(&s.OrderID, &s.Kind, &s.StartsAt, &s.EndsAt, &s.Location, &s.Notes, &s.Sequence, &s.UpdatedAt)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get order schedule: %w", err)
+	}
+	return &s, nil
+}
+
+// setOrderSchedule books or moves an order's slot and records it on the timeline.
+// The sequence is bumped on every change so calendar clients update the existing event.
+func (h *Handler) setOrderSchedule(ctx context.Context, orderID string, req *models.SetOrderScheduleRequest, actorID string) (*models.OrderSchedule, error) {
+	kind := req.Kind
+	if kind == "" {
+		kind = "pickup"
+	}
+
+	tx, err := h.db.Pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	var exists bool
+	if err := tx.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM app_orders WHERE id::text = $1)`, orderID).Scan(&exists); err != nil {
+		return nil, fmt.Errorf("failed to get order: %w", err)
+	}
+	if !exists {
+		return nil, errOrderNotFound
+	}
+
+	var s models.OrderSchedule
+	err = tx.QueryRow(ctx, `
+		INSERT INTO app_order_schedules (order_id, kind, starts_at, ends_at, location, notes)
+		VALUES ($1::uuid, $2, $3, $4, NULLIF($5, ''), NULLIF($6, ''))
+		ON CONFLICT (order_id) DO UPDATE SET
+			kind = EXCLUDED.kind,
+			starts_at = EXCLUDED.starts_at,
+			ends_at = EXCLUDED.ends_at,
+			location = EXCLUDED.location,
+			notes = EXCLUDED.notes,
+			sequence = app_order_schedules.sequence + 1,
+			updated_at = now()
+		RETURNING order_id::text, kind, starts_at, ends_at, COALESCE(location, ''), COALESCE(notes, ''), sequence, updated_at
+	`, orderID, kind, req.StartsAt, req.EndsAt, req.Location, req.Notes).Scan(
+		&s.OrderID, &s.Kind, &s.StartsAt, &s.EndsAt, &s.Location, &s.Notes, &s.Sequence, &s.UpdatedAt,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to set order schedule: %w", err)
+	}
+
+	note := fmt.Sprintf("%s %s - %s", kind, s.StartsAt.UTC().Format(time.RFC3339), s.EndsAt.UTC().Format(time.RFC3339))
+	if err := insertOrderEvent(ctx, tx, orderID, models.OrderEventScheduled, nil, nil, note, actorID, nil); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return &s, nil
+}
+
+// orderBelongsToUser reports whether the order was placed by the user
+func (h *Handler) orderBelongsToUser(ctx context.Context, orderID, userID string) (bool, error) {
+	var ok bool
+	err := h.db.Pool.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM app_orders WHERE id::text = $1 AND user_id::text = $2)`, orderID, userID).Scan(&ok)
+	if err != nil {
+		return false, fmt.Errorf("failed to get order: %w", err)
+	}
+	return ok, nil
+}
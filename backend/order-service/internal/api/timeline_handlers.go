@@ -0,0 +1,286 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/expotoworld/expotoworld/backend/order-service/internal/models"
+	"github.com/gin-gonic/gin"
+)
+
+// writeTimelineError maps timeline errors to HTTP responses
+func writeTimelineError(c *gin.Context, action string, err error) {
+	status := http.StatusInternalServerError
+	if errors.Is(err, errOrderNotFound) {
+		status = http.StatusNotFound
+	}
+	c.JSON(status, models.ErrorResponse{
+		Error:   action,
+		Message: err.Error(),
+	})
+}
+
+// GetOrderTimeline handles GET /api/order/:order_id/timeline
+func (h *Handler) GetOrderTimeline(c *gin.Context) {
+	userID, ok := GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+			Error:   "Invalid user",
+			Message: "Could not extract user ID from token",
+		})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	orderID := c.Param("order_id")
+	owned, err := h.orderBelongsToUser(ctx, orderID, userID)
+	if err == nil && !owned {
+		err = errOrderNotFound
+	}
+	if err != nil {
+		writeTimelineError(c, "Failed to get order timeline", err)
+		return
+	}
+
+	timeline, err := h.getOrderTimeline(ctx, orderID)
+	if err != nil {
+		writeTimelineError(c, "Failed to get order timeline", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponse{
+		Message: "Order timeline retrieved successfully",
+		Data:    timeline,
+	})
+}
+
+// GetOrderCalendar handles GET /api/order/:order_id/calendar.ics, returning the booked
+// pickup/delivery slot as an iCalendar attachment
+func (h *Handler) GetOrderCalendar(c *gin.Context) {
+	userID, ok := GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+			Error:   "Invalid user",
+			Message: "Could not extract user ID from token",
+		})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	order, err := h.getOrderByID(ctx, c.Param("order_id"), userID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Error:   "Order not found",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	schedule, err := h.getOrderSchedule(ctx, order.ID)
+	if err != nil {
+		writeTimelineError(c, "Failed to get order schedule", err)
+		return
+	}
+	if schedule == nil {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Error:   "No schedule",
+			Message: "No pickup or delivery slot has been booked for this order",
+		})
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="order-%s.ics"`, shortOrderID(order.ID)))
+	c.Data(http.StatusOK, "text/calendar; charset=utf-8", []byte(buildOrderICS(order, schedule, time.Now())))
+}
+
+// GetAdminOrderTimeline handles GET /api/admin/orders/:order_id/timeline
+func (h *Handler) GetAdminOrderTimeline(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	timeline, err := h.getOrderTimeline(ctx, c.Param("order_id"))
+	if err != nil {
+		writeTimelineError(c, "Failed to get order timeline", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponse{
+		Message: "Order timeline retrieved successfully",
+		Data:    timeline,
+	})
+}
+
+// RecordOrderEvent handles POST /api/admin/orders/:order_id/events (paid, packed, ready_for_pickup, collected)
+func (h *Handler) RecordOrderEvent(c *gin.Context) {
+	var req models.RecordOrderEventRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid request data",
+			Message: err.Error(),
+		})
+		return
+	}
+	if !req.EventType.IsMilestone() {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid event type",
+			Message: "Event type must be one of: paid, packed, ready_for_pickup, collected",
+		})
+		return
+	}
+
+	adminUserID, _ := GetUserID(c)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	orderID := c.Param("order_id")
+	if _, err := h.getOrderTimeline(ctx, orderID); err != nil {
+		writeTimelineError(c, "Failed to record order event", err)
+		return
+	}
+	if err := insertOrderEvent(ctx, h.db.Pool, orderID, req.EventType, nil, nil, req.Note, adminUserID, req.OccurredAt); err != nil {
+		writeTimelineError(c, "Failed to record order event", err)
+		return
+	}
+
+	timeline, err := h.getOrderTimeline(ctx, orderID)
+	if err != nil {
+		writeTimelineError(c, "Failed to get order timeline", err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, models.SuccessResponse{
+		Message: "Order event recorded successfully",
+		Data:    timeline,
+	})
+}
+
+// SetOrderSchedule handles PUT /api/admin/orders/:order_id/schedule
+func (h *Handler) SetOrderSchedule(c *gin.Context) {
+	var req models.SetOrderScheduleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid request data",
+			Message: err.Error(),
+		})
+		return
+	}
+	if !req.EndsAt.After(req.StartsAt) {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid slot",
+			Message: "ends_at must be after starts_at",
+		})
+		return
+	}
+
+	adminUserID, _ := GetUserID(c)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	schedule, err := h.setOrderSchedule(ctx, c.Param("order_id"), &req, adminUserID)
+	if err != nil {
+		writeTimelineError(c, "Failed to set order schedule", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponse{
+		Message: "Order schedule updated successfully",
+		Data:    schedule,
+	})
+}
+
+func shortOrderID(id string) string {
+	if len(id) > 8 {
+		return id[:8]
+	}
+	return id
+}
+
+// buildOrderICS renders a single-event iCalendar (RFC 5545) document for an order's slot
+func buildOrderICS(order *models.Order, s *models.OrderSchedule, now time.Time) string {
+	const stamp = "20060102T150405Z"
+
+	domain := os.Getenv("ICS_UID_DOMAIN")
+	if domain == "" {
+		domain = "expotoworld.com"
+	}
+	summary := fmt.Sprintf("Order %s pickup", shortOrderID(order.ID))
+	if s.Kind == "delivery" {
+		summary = fmt.Sprintf("Order %s delivery", shortOrderID(order.ID))
+	}
+	status := "CONFIRMED"
+	if order.Status == models.OrderStatusCancelled {
+		status = "CANCELLED"
+	}
+
+	var desc strings.Builder
+	fmt.Fprintf(&desc, "Order %s (%d items, total %s)", order.ID, len(order.Items), order.TotalAmount.Format(order.Currency))
+	if s.Notes != "" {
+		desc.WriteString("\n")
+		desc.WriteString(s.Notes)
+	}
+
+	lines := []string{
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"PRODID:-//ExpoToWorld//Order Service//EN",
+		"CALSCALE:GREGORIAN",
+		"METHOD:PUBLISH",
+		"BEGIN:VEVENT",
+		fmt.Sprintf("UID:order-%s-%s@%s", order.ID, s.Kind, domain),
+		"DTSTAMP:" + now.UTC().Format(stamp),
+		"DTSTART:" + s.StartsAt.UTC().Format(stamp),
+		"DTEND:" + s.EndsAt.UTC().Format(stamp),
+		fmt.Sprintf("SEQUENCE:%d", s.Sequence),
+		"STATUS:" + status,
+		"SUMMARY:" + icsEscape(summary),
+		"DESCRIPTION:" + icsEscape(desc.String()),
+	}
+	if s.Location != "" {
+		lines = append(lines, "LOCATION:"+icsEscape(s.Location))
+	}
+	lines = append(lines, "END:VEVENT", "END:VCALENDAR")
+
+	var b strings.Builder
+	for _, l := range lines {
+		b.WriteString(icsFold(l))
+		b.WriteString("\r\n")
+	}
+	return b.String()
+}
+
+// icsEscape escapes TEXT values per RFC 5545 section 3.3.11
+func icsEscape(v string) string {
+	r := strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`)
+	return r.Replace(v)
+}
+
+// icsFold splits content lines longer than 75 octets without breaking UTF-8 sequences
+func icsFold(line string) string {
+	const limit = 75
+	if len(line) <= limit {
+		return line
+	}
+	var b strings.Builder
+	width := 0
+	for _, r := range line {
+		n := len(string(r))
+		if width+n > limit {
+			b.WriteString("\r\n ")
+			width = 1
+		}
+		b.WriteRune(r)
+		width += n
+	}
+	return b.String()
+}
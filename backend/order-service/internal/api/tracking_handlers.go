@@ -0,0 +1,163 @@
+package api
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/expotoworld/expotoworld/backend/order-service/internal/models"
+	"github.com/gin-gonic/gin"
+)
+
+// Tracking tokens are the order UUID (32 hex digits) followed by a truncated HMAC of it, so they can
+// be checked without a lookup table. They are keyed by ORDER_TRACKING_SECRET; rotating the secret
+// invalidates every link that was sent out.
+const (
+	trackingIDLen  = 32
+	trackingMACLen = 16
+)
+
+var errInvalidTrackingToken = errors.New("invalid tracking token")
+
+func trackingSecret() []byte {
+	return []byte(os.Getenv("ORDER_TRACKING_SECRET"))
+}
+
+func trackingMAC(purpose, compactID string) []byte {
+	mac := hmac.New(sha256.New, trackingSecret())
+	mac.Write([]byte(purpose + ":" + compactID))
+	return mac.Sum(nil)
+}
+
+// orderTrackingToken returns the public tracking token for an order, or "" when tracking is not configured
+func orderTrackingToken(orderID string) string {
+	if len(trackingSecret()) == 0 {
+		return ""
+	}
+	id := strings.ReplaceAll(strings.ToLower(orderID), "-", "")
+	if len(id) != trackingIDLen {
+		return ""
+	}
+	return id + base64.RawURLEncoding.EncodeToString(trackingMAC("track", id)[:trackingMACLen])
+}
+
+// orderTrackingURL is the link sent to customers: ORDER_TRACKING_BASE_URL followed by the token
+func orderTrackingURL(token string) string {
+	base := strings.TrimRight(os.Getenv("ORDER_TRACKING_BASE_URL"), "/")
+	if base == "" || token == "" {
+		return ""
+	}
+	return base + "/" + token
+}
+
+// parseTrackingToken verifies a token and returns the order id it was issued for
+func parseTrackingToken(token string) (string, error) {
+	if len(trackingSecret()) == 0 || len(token) <= trackingIDLen {
+		return "", errInvalidTrackingToken
+	}
+	id := strings.ToLower(token[:trackingIDLen])
+	raw, err := hex.DecodeString(id)
+	if err != nil {
+		return "", errInvalidTrackingToken
+	}
+	mac, err := base64.RawURLEncoding.DecodeString(token[trackingIDLen:])
+	if err != nil || !hmac.Equal(mac, trackingMAC("track", id)[:trackingMACLen]) {
+		return "", errInvalidTrackingToken
+	}
+	return fmt.Sprintf("%x-%x-%x-%x-%x", raw[0:4], raw[4:6], raw[6:8], raw[8:10], raw[10:16]), nil
+}
+
+// orderPickupCode derives the 6-digit code a customer shows when collecting an order
+func orderPickupCode(orderID string) string {
+	id := strings.ReplaceAll(strings.ToLower(orderID), "-", "")
+	return fmt.Sprintf("%06d", binary.BigEndian.Uint32(trackingMAC("pickup", id))%1000000)
+}
+
+// setOrderTracking fills the tracking link fields of an order returned to its customer
+func setOrderTracking(order *models.Order) {
+	order.TrackingToken = orderTrackingToken(order.ID)
+	order.TrackingURL = orderTrackingURL(order.TrackingToken)
+}
+
+// GetOrderTracking handles GET /track/:token, the unauthenticated status page linked from emails
+func (h *Handler) GetOrderTracking(c *gin.Context) {
+	orderID, err := parseTrackingToken(c.Param("token"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Error:   "Order not found",
+			Message: "The tracking link is invalid",
+		})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	tracking, err := h.getOrderTracking(ctx, orderID)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, errOrderNotFound) {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, models.ErrorResponse{
+			Error:   "Failed to get order tracking",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	// The token grants read access to this order only; keep it out of shared caches
+	c.Header("Cache-Control", "private, no-store")
+	c.Header("Referrer-Policy", "no-referrer")
+	c.JSON(http.StatusOK, models.SuccessResponse{
+		Message: "Order tracking retrieved successfully",
+		Data:    tracking,
+	})
+}
+
+// getOrderTracking builds the public view of an order from its timeline
+func (h *Handler) getOrderTracking(ctx context.Context, orderID string) (*models.OrderTrackingResponse, error) {
+	timeline, err := h.getOrderTimeline(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+	t := &models.OrderTrackingResponse{
+		OrderNumber: shortOrderID(orderID),
+		Status:      timeline.Status,
+		Events:      make([]models.TrackingEvent, 0, len(timeline.Events)),
+	}
+	if s := timeline.Schedule; s != nil {
+		t.Schedule = &models.TrackingSchedule{StartsAt: s.StartsAt, EndsAt: s.EndsAt}
+	}
+	if err := h.db.Pool.QueryRow(ctx, `
+		SELECT o.created_at, (SELECT COUNT(*) FROM app_order_items oi WHERE oi.order_id = o.id)
+		FROM app_orders o
+		WHERE o.id::text = $1
+	`, orderID).Scan(&t.CreatedAt, &t.ItemCount); err != nil {
+		return nil, fmt.Errorf("failed to get order: %w", err)
+	}
+
+	ready := false
+	for _, e := range timeline.Events {
+		t.Events = append(t.Events, models.TrackingEvent{EventType: e.EventType, NewStatus: e.NewStatus, OccurredAt: e.OccurredAt})
+		switch e.EventType {
+		case models.OrderEventReadyForPickup:
+			ready = true
+		case models.OrderEventCollected:
+			ready = false
+		}
+	}
+	if ready && t.Status != models.OrderStatusCancelled && t.Status != models.OrderStatusDelivered {
+		t.PickupCode = orderPickupCode(orderID)
+	}
+	return t, nil
+}
@@ -2,6 +2,7 @@ package db
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"net"
@@ -9,6 +10,7 @@ import (
 	"strconv"
 	"time"
 
+	"github.com/expotoworld/expotoworld/backend/common/auth"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
@@ -193,6 +195,17 @@ func (db *Database) Health(ctx context.Context) error {
 	return db.Pool.Ping(ctx)
 }
 
+// TokenVersion returns the user's access token version from app_users (owned by auth-service);
+// auth.ErrTokenRevoked if the user no longer exists
+func (db *Database) TokenVersion(ctx context.Context, userID string) (int, error) {
+	var version int
+	err := db.Pool.QueryRow(ctx, `SELECT token_version FROM app_users WHERE id::text = $1`, userID).Scan(&version)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, auth.ErrTokenRevoked
+	}
+	return version, err
+}
+
 // InitSchema verifies and gently migrates the required schema
 func (db *Database) InitSchema(ctx context.Context) error {
 	// 1) Check required tables
@@ -314,6 +327,288 @@ func (db *Database) InitSchema(ctx context.Context) error {
 		return fmt.Errorf("failed to create idx_carts_user_mini_app_store: %w", err)
 	}
 
+	// 6) Quotes (draft orders) for group-buying coordinators
+	if _, err := db.Pool.Exec(ctx, `
+		CREATE TABLE IF NOT EXISTS app_quotes (
+			id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+			created_by UUID NOT NULL,
+			customer_user_id UUID NOT NULL,
+			mini_app_type VARCHAR(50) NOT NULL DEFAULT 'GroupBuying',
+			store_id INTEGER NULL,
+			status VARCHAR(20) NOT NULL DEFAULT 'draft',
+			notes TEXT,
+			valid_from TIMESTAMPTZ NOT NULL DEFAULT now(),
+			valid_until TIMESTAMPTZ NOT NULL,
+			total_amount NUMERIC(12,2) NOT NULL DEFAULT 0,
+			converted_order_id UUID NULL,
+			sent_at TIMESTAMPTZ NULL,
+			created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+			updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+		);
+		CREATE TABLE IF NOT EXISTS app_quote_items (
+			id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+			quote_id UUID NOT NULL REFERENCES app_quotes(id) ON DELETE CASCADE,
+			product_id UUID NOT NULL,
+			quantity INTEGER NOT NULL CHECK (quantity > 0),
+			unit_price NUMERIC(12,2) NOT NULL,
+			list_price NUMERIC(12,2) NOT NULL DEFAULT 0
+		);
+		CREATE INDEX IF NOT EXISTS idx_quotes_customer ON app_quotes(customer_user_id, status);
+		CREATE INDEX IF NOT EXISTS idx_quotes_created_by ON app_quotes(created_by, created_at DESC);
+		CREATE INDEX IF NOT EXISTS idx_quote_items_quote ON app_quote_items(quote_id);
+	`); err != nil {
+		return fmt.Errorf("failed to ensure quote tables: %w", err)
+	}
+
+	// 7) Order timeline events and scheduled pickup/delivery slots
+	if _, err := db.Pool.Exec(ctx, `
+		CREATE TABLE IF NOT EXISTS app_order_events (
+			id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+			order_id UUID NOT NULL,
+			event_type VARCHAR(30) NOT NULL,
+			old_status VARCHAR(20) NULL,
+			new_status VARCHAR(20) NULL,
+			note TEXT,
+			actor_id TEXT NULL,
+			occurred_at TIMESTAMPTZ NOT NULL DEFAULT now()
+		);
+		CREATE INDEX IF NOT EXISTS idx_order_events_order ON app_order_events(order_id, occurred_at);
+		CREATE TABLE IF NOT EXISTS app_order_schedules (
+			order_id UUID PRIMARY KEY,
+			kind VARCHAR(20) NOT NULL DEFAULT 'pickup',
+			starts_at TIMESTAMPTZ NOT NULL,
+			ends_at TIMESTAMPTZ NOT NULL,
+			location TEXT,
+			notes TEXT,
+			sequence INTEGER NOT NULL DEFAULT 0,
+			created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+			updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+			CHECK (ends_at > starts_at)
+		);
+	`); err != nil {
+		return fmt.Errorf("failed to ensure order timeline tables: %w", err)
+	}
+
+	// 8) Sales channel and external reference for orders imported from offline (expo) sales
+	if _, err := db.Pool.Exec(ctx, `
+		ALTER TABLE app_orders ADD COLUMN IF NOT EXISTS channel VARCHAR(20) NOT NULL DEFAULT 'online';
+		ALTER TABLE app_orders ADD COLUMN IF NOT EXISTS external_ref VARCHAR(100) NULL;
+		CREATE UNIQUE INDEX IF NOT EXISTS ux_orders_channel_external_ref ON app_orders(channel, external_ref) WHERE external_ref IS NOT NULL;
+	`); err != nil {
+		return fmt.Errorf("failed to ensure order channel columns: %w", err)
+	}
+
+	// 9) Documents attached to orders (files live in S3; visible_to lists non-admin audiences)
+	if _, err := db.Pool.Exec(ctx, `
+		CREATE TABLE IF NOT EXISTS app_order_attachments (
+			id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+			order_id UUID NOT NULL,
+			doc_type VARCHAR(30) NOT NULL,
+			file_name TEXT NOT NULL,
+			content_type VARCHAR(100) NOT NULL,
+			size_bytes BIGINT NOT NULL,
+			s3_key TEXT NOT NULL,
+			visible_to TEXT[] NOT NULL DEFAULT '{}',
+			uploaded_by TEXT NOT NULL,
+			uploader_role VARCHAR(20) NOT NULL,
+			note TEXT,
+			created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+		);
+		CREATE INDEX IF NOT EXISTS idx_order_attachments_order ON app_order_attachments(order_id, created_at);
+	`); err != nil {
+		return fmt.Errorf("failed to ensure order attachments table: %w", err)
+	}
+
+	// 10) Append-only order ledger: each posting is a debit and a credit line sharing posting_id
+	if _, err := db.Pool.Exec(ctx, `
+		CREATE TABLE IF NOT EXISTS app_order_ledger (
+			id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+			posting_id UUID NOT NULL,
+			order_id UUID NOT NULL,
+			entry_type VARCHAR(20) NOT NULL,
+			account VARCHAR(30) NOT NULL,
+			debit NUMERIC(12,2) NOT NULL DEFAULT 0,
+			credit NUMERIC(12,2) NOT NULL DEFAULT 0,
+			currency CHAR(3) NOT NULL DEFAULT 'EUR',
+			description TEXT,
+			external_ref VARCHAR(100),
+			actor_id TEXT,
+			posted_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+			CHECK (debit >= 0 AND credit >= 0 AND (debit = 0) <> (credit = 0))
+		);
+		CREATE INDEX IF NOT EXISTS idx_order_ledger_order ON app_order_ledger(order_id, posted_at);
+		CREATE INDEX IF NOT EXISTS idx_order_ledger_posted ON app_order_ledger(posted_at);
+	`); err != nil {
+		return fmt.Errorf("failed to ensure order ledger table: %w", err)
+	}
+
+	// Backfill orders placed before the ledger existed: a charge at order time and, for cancelled
+	// orders never marked paid, a reversal at their last update. Only orders without ledger lines are touched.
+	if _, err := db.Pool.Exec(ctx, `
+		WITH missing AS (
+			SELECT o.id, o.total_amount, o.status, o.created_at, o.updated_at
+			FROM app_orders o
+			WHERE o.total_amount > 0
+				AND NOT EXISTS (SELECT 1 FROM app_order_ledger l WHERE l.order_id = o.id)
+		), postings AS (
+			SELECT id, total_amount, created_at AS posted_at, 'charge' AS entry_type, gen_random_uuid() AS posting_id FROM missing
+			UNION ALL
+			SELECT m.id, m.total_amount, COALESCE(m.updated_at, m.created_at), 'reversal', gen_random_uuid() FROM missing m
+			WHERE m.status = 'cancelled'
+				AND NOT EXISTS (SELECT 1 FROM app_order_events e WHERE e.order_id = m.id AND e.event_type = 'paid')
+		)
+		INSERT INTO app_order_ledger (posting_id, order_id, entry_type, account, debit, credit, description, posted_at)
+		SELECT p.posting_id, p.id, p.entry_type, s.account, s.debit, s.credit, 'Backfilled from order history', p.posted_at
+		FROM postings p
+		CROSS JOIN LATERAL (VALUES
+			(CASE WHEN p.entry_type = 'charge' THEN 'receivable' ELSE 'revenue' END, p.total_amount, 0::numeric),
+			(CASE WHEN p.entry_type = 'charge' THEN 'revenue' ELSE 'receivable' END, 0::numeric, p.total_amount)
+		) AS s(account, debit, credit)
+	`); err != nil {
+		return fmt.Errorf("failed to backfill order ledger: %w", err)
+	}
+
+	// 11) Gift orders: separate recipient address and message for the packing slip
+	if _, err := db.Pool.Exec(ctx, `
+		CREATE TABLE IF NOT EXISTS app_order_gifts (
+			order_id UUID PRIMARY KEY,
+			recipient_name TEXT NOT NULL,
+			recipient_phone VARCHAR(40),
+			address_line1 TEXT NOT NULL,
+			address_line2 TEXT,
+			postal_code VARCHAR(20) NOT NULL,
+			city TEXT NOT NULL,
+			country CHAR(2) NOT NULL,
+			message TEXT,
+			hide_prices BOOLEAN NOT NULL DEFAULT true,
+			created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+			updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+		);
+	`); err != nil {
+		return fmt.Errorf("failed to ensure order gifts table: %w", err)
+	}
+
+	// 12) Store of location-based orders, used to confine regional admins to their stores' orders.
+	// Orders placed before the column existed take the store of their products.
+	if _, err := db.Pool.Exec(ctx, `
+		ALTER TABLE app_orders ADD COLUMN IF NOT EXISTS store_id INTEGER NULL;
+		CREATE INDEX IF NOT EXISTS idx_app_orders_store ON app_orders(store_id, created_at DESC) WHERE store_id IS NOT NULL;
+		UPDATE app_orders o SET store_id = (
+			SELECT p.store_id FROM app_order_items i
+			JOIN admin_products p ON p.product_uuid::text = i.product_id::text
+			WHERE i.order_id = o.id AND p.store_id IS NOT NULL
+			LIMIT 1)
+		WHERE o.store_id IS NULL AND o.mini_app_type IN ('UnmannedStore', 'ExhibitionSales');
+	`); err != nil {
+		return fmt.Errorf("failed to ensure orders.store_id: %w", err)
+	}
+
+	// 13) Order SLAs: per-status thresholds and the breaches flagged by the background checker.
+	// A breach stays open until the order leaves the status (or the threshold no longer applies).
+	if _, err := db.Pool.Exec(ctx, `
+		CREATE TABLE IF NOT EXISTS app_order_sla_thresholds (
+			status VARCHAR(20) PRIMARY KEY,
+			max_hours INTEGER NOT NULL CHECK (max_hours > 0),
+			updated_by TEXT NULL,
+			updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+		);
+		INSERT INTO app_order_sla_thresholds (status, max_hours) VALUES
+			('confirmed', 48), ('processing', 72), ('shipped', 240)
+		ON CONFLICT (status) DO NOTHING;
+		CREATE TABLE IF NOT EXISTS app_order_sla_breaches (
+			id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+			order_id UUID NOT NULL,
+			status VARCHAR(20) NOT NULL,
+			entered_at TIMESTAMPTZ NOT NULL,
+			max_hours INTEGER NOT NULL,
+			flagged_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+			resolved_at TIMESTAMPTZ NULL
+		);
+		CREATE UNIQUE INDEX IF NOT EXISTS ux_order_sla_breaches ON app_order_sla_breaches(order_id, status, entered_at);
+		CREATE INDEX IF NOT EXISTS idx_order_sla_breaches_open ON app_order_sla_breaches(flagged_at) WHERE resolved_at IS NULL;
+	`); err != nil {
+		return fmt.Errorf("failed to ensure order SLA tables: %w", err)
+	}
+
+	// 14) Multi-warehouse stock: per-store stock of a product, and the source each order line was allocated from.
+	// Products without sources keep the single-store behaviour (admin_products.stock_left).
+	if _, err := db.Pool.Exec(ctx, `
+		CREATE TABLE IF NOT EXISTS app_product_stock_sources (
+			product_id UUID NOT NULL,
+			store_id INTEGER NOT NULL REFERENCES admin_stores(store_id) ON DELETE CASCADE,
+			quantity INTEGER NOT NULL DEFAULT 0 CHECK (quantity >= 0),
+			priority INTEGER NOT NULL DEFAULT 100,
+			updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+			PRIMARY KEY (product_id, store_id)
+		);
+		ALTER TABLE app_order_items ADD COLUMN IF NOT EXISTS source_store_id INTEGER NULL;
+		ALTER TABLE app_order_items ADD COLUMN IF NOT EXISTS allocation_strategy VARCHAR(20) NULL;
+		CREATE INDEX IF NOT EXISTS idx_app_order_items_source_store ON app_order_items(source_store_id) WHERE source_store_id IS NOT NULL;
+	`); err != nil {
+		return fmt.Errorf("failed to ensure stock source tables: %w", err)
+	}
+
+	// 15) Money is held as exact decimals with a currency. Legacy float columns are rounded to the cent
+	// and converted in place; existing rows are priced in EUR.
+	if _, err := db.Pool.Exec(ctx, `
+		DO $$
+		DECLARE c RECORD;
+		BEGIN
+			FOR c IN
+				SELECT table_name, column_name FROM information_schema.columns
+				WHERE table_schema = 'public'
+					AND data_type IN ('double precision', 'real')
+					AND (table_name, column_name) IN (('app_orders', 'total_amount'), ('app_order_items', 'price'))
+			LOOP
+				EXECUTE format('ALTER TABLE %I ALTER COLUMN %I TYPE NUMERIC(12,2) USING ROUND(%I::numeric, 2)',
+					c.table_name, c.column_name, c.column_name);
+			END LOOP;
+		END $$;
+		ALTER TABLE app_orders ADD COLUMN IF NOT EXISTS currency CHAR(3) NOT NULL DEFAULT 'EUR';
+		ALTER TABLE app_quotes ADD COLUMN IF NOT EXISTS currency CHAR(3) NOT NULL DEFAULT 'EUR';
+	`); err != nil {
+		return fmt.Errorf("failed to migrate money columns: %w", err)
+	}
+
+	// 16) Checkout rules per mini-app (payment methods, minimum order, delivery options, service fee).
+	// A mini-app without a row checks out unrestricted and without fees.
+	if _, err := db.Pool.Exec(ctx, `
+		CREATE TABLE IF NOT EXISTS app_checkout_configs (
+			mini_app_type VARCHAR(50) PRIMARY KEY,
+			payment_methods TEXT[] NOT NULL DEFAULT '{}',
+			min_order_value NUMERIC(12,2) NOT NULL DEFAULT 0 CHECK (min_order_value >= 0),
+			delivery_options JSONB NOT NULL DEFAULT '[]',
+			service_fee NUMERIC(12,2) NOT NULL DEFAULT 0 CHECK (service_fee >= 0),
+			updated_by TEXT,
+			updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+		);
+		ALTER TABLE app_orders ADD COLUMN IF NOT EXISTS payment_method VARCHAR(30);
+		ALTER TABLE app_orders ADD COLUMN IF NOT EXISTS delivery_option VARCHAR(30);
+		ALTER TABLE app_orders ADD COLUMN IF NOT EXISTS delivery_fee NUMERIC(12,2) NOT NULL DEFAULT 0;
+		ALTER TABLE app_orders ADD COLUMN IF NOT EXISTS service_fee NUMERIC(12,2) NOT NULL DEFAULT 0;
+	`); err != nil {
+		return fmt.Errorf("failed to create checkout config schema: %w", err)
+	}
+
+	// 17) Bulk print jobs: merged packing slip/label PDFs rendered in the background and stored in S3
+	if _, err := db.Pool.Exec(ctx, `
+		CREATE TABLE IF NOT EXISTS app_order_print_jobs (
+			id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+			doc_type VARCHAR(30) NOT NULL,
+			order_ids TEXT[] NOT NULL,
+			status VARCHAR(20) NOT NULL DEFAULT 'queued',
+			missing_order_ids TEXT[] NOT NULL DEFAULT '{}',
+			page_count INTEGER NOT NULL DEFAULT 0,
+			s3_key TEXT,
+			error TEXT,
+			requested_by TEXT NOT NULL,
+			created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+			finished_at TIMESTAMPTZ
+		);
+	`); err != nil {
+		return fmt.Errorf("failed to create print jobs table: %w", err)
+	}
+
 	log.Println("Order service database schema verified successfully")
 	return nil
 }
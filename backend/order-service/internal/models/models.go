@@ -2,6 +2,8 @@ package models
 
 import (
 	"time"
+
+	"github.com/expotoworld/expotoworld/backend/order-service/internal/money"
 )
 
 // MiniAppType represents the type of mini-app
@@ -70,36 +72,53 @@ type CartItem struct {
 
 // Order represents a completed order
 type Order struct {
-	ID          string      `json:"id" db:"id"`
-	UserID      string      `json:"user_id" db:"user_id"`
-	MiniAppType MiniAppType `json:"mini_app_type" db:"mini_app_type"`
-	TotalAmount float64     `json:"total_amount" db:"total_amount"`
-	Status      OrderStatus `json:"status" db:"status"`
-	Items       []OrderItem `json:"items"`
-	CreatedAt   time.Time   `json:"created_at" db:"created_at"`
-	UpdatedAt   time.Time   `json:"updated_at" db:"updated_at"`
+	ID          string       `json:"id" db:"id"`
+	UserID      string       `json:"user_id" db:"user_id"`
+	MiniAppType MiniAppType  `json:"mini_app_type" db:"mini_app_type"`
+	TotalAmount money.Amount `json:"total_amount" db:"total_amount"`
+	Currency    string       `json:"currency" db:"currency"`
+	Status      OrderStatus  `json:"status" db:"status"`
+	Channel     OrderChannel `json:"channel,omitempty" db:"channel"`
+	// Checkout choices and fees; TotalAmount includes the fees
+	PaymentMethod  *string           `json:"payment_method,omitempty" db:"payment_method"`
+	DeliveryOption *string           `json:"delivery_option,omitempty" db:"delivery_option"`
+	DeliveryFee    money.Amount      `json:"delivery_fee" db:"delivery_fee"`
+	ServiceFee     money.Amount      `json:"service_fee" db:"service_fee"`
+	Gift           *OrderGift        `json:"gift,omitempty"`
+	Items          []OrderItem       `json:"items"`
+	Attachments    []OrderAttachment `json:"attachments,omitempty"`
+	// Public tracking link, set when order tracking is configured
+	TrackingToken string    `json:"tracking_token,omitempty"`
+	TrackingURL   string    `json:"tracking_url,omitempty"`
+	CreatedAt     time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at" db:"updated_at"`
 }
 
 // OrderItem represents an item in an order
 type OrderItem struct {
-	ID         string   `json:"id" db:"id"`
-	OrderID    string   `json:"order_id" db:"order_id"`
-	ProductID  string   `json:"product_id" db:"product_id"`
-	Quantity   int      `json:"quantity" db:"quantity"`
-	UnitPrice  float64  `json:"unit_price" db:"unit_price"`
-	TotalPrice float64  `json:"total_price" db:"total_price"`
-	Product    *Product `json:"product,omitempty"` // Populated when needed
+	ID         string       `json:"id" db:"id"`
+	OrderID    string       `json:"order_id" db:"order_id"`
+	ProductID  string       `json:"product_id" db:"product_id"`
+	Quantity   int          `json:"quantity" db:"quantity"`
+	UnitPrice  money.Amount `json:"unit_price" db:"unit_price"`
+	TotalPrice money.Amount `json:"total_price" db:"total_price"`
+	Product    *Product     `json:"product,omitempty"` // Populated when needed
+
+	// Store/warehouse the line is fulfilled from, and the strategy that picked it
+	// (nil strategy: the product has no stock sources and ships from the order's store)
+	SourceStoreID      *int                `json:"source_store_id,omitempty"`
+	AllocationStrategy *AllocationStrategy `json:"allocation_strategy,omitempty"`
 }
 
 // Product represents a product (simplified for order service)
 type Product struct {
-	ID                   string  `json:"id" db:"id"`
-	SKU                  string  `json:"sku" db:"sku"`
-	Title                string  `json:"title" db:"title"`
-	MainPrice            float64 `json:"main_price" db:"main_price"`
-	StockLeft            int     `json:"stock_left" db:"stock_left"`
-	MinimumOrderQuantity int     `json:"minimum_order_quantity" db:"minimum_order_quantity"`
-	IsActive             bool    `json:"is_active" db:"is_active"`
+	ID                   string       `json:"id" db:"id"`
+	SKU                  string       `json:"sku" db:"sku"`
+	Title                string       `json:"title" db:"title"`
+	MainPrice            money.Amount `json:"main_price" db:"main_price"`
+	StockLeft            int          `json:"stock_left" db:"stock_left"`
+	MinimumOrderQuantity int          `json:"minimum_order_quantity" db:"minimum_order_quantity"`
+	IsActive             bool         `json:"is_active" db:"is_active"`
 }
 
 // DisplayStock returns the stock quantity with buffer applied (actual - 5)
@@ -145,7 +164,14 @@ type UpdateCartItemRequest struct {
 
 // CreateOrderRequest represents a request to create an order
 type CreateOrderRequest struct {
-	StoreID *int `json:"store_id,omitempty"` // Required for location-based mini-apps
+	StoreID *int              `json:"store_id,omitempty"` // Required for location-based mini-apps
+	Gift    *OrderGiftRequest `json:"gift,omitempty"`     // Ship to someone else, with an optional message
+	// Delivery location, used by the nearest-store stock allocation strategy
+	Latitude  *float64 `json:"latitude,omitempty" binding:"omitempty,min=-90,max=90"`
+	Longitude *float64 `json:"longitude,omitempty" binding:"omitempty,min=-180,max=180"`
+	// Checkout choices, checked against the mini-app's checkout configuration (defaults apply when empty)
+	PaymentMethod  string `json:"payment_method,omitempty" binding:"max=30"`
+	DeliveryOption string `json:"delivery_option,omitempty" binding:"max=30"`
 }
 
 // ErrorResponse represents an error response
@@ -176,22 +202,26 @@ type AdminOrderListRequest struct {
 	Search      string `form:"search"`     // Search in order ID, user email, product names
 	SortBy      string `form:"sort_by"`    // created_at, total_amount, status
 	SortOrder   string `form:"sort_order"` // asc, desc
+	// ScopeStoreIDs limits regional admins to their stores' orders (nil = unrestricted)
+	ScopeStoreIDs []int `form:"-"`
 }
 
 // AdminOrderResponse represents an order in admin list view
 type AdminOrderResponse struct {
-	ID          string      `json:"id"`
-	UserID      string      `json:"user_id"`
-	UserEmail   string      `json:"user_email"`
-	UserName    string      `json:"user_name"`
-	MiniAppType MiniAppType `json:"mini_app_type"`
-	StoreID     *int        `json:"store_id,omitempty"`
-	StoreName   string      `json:"store_name,omitempty"`
-	TotalAmount float64     `json:"total_amount"`
-	Status      OrderStatus `json:"status"`
-	ItemCount   int         `json:"item_count"`
-	CreatedAt   time.Time   `json:"created_at"`
-	UpdatedAt   time.Time   `json:"updated_at"`
+	ID          string       `json:"id"`
+	UserID      string       `json:"user_id"`
+	UserEmail   string       `json:"user_email"`
+	UserName    string       `json:"user_name"`
+	MiniAppType MiniAppType  `json:"mini_app_type"`
+	StoreID     *int         `json:"store_id,omitempty"`
+	StoreName   string       `json:"store_name,omitempty"`
+	TotalAmount money.Amount `json:"total_amount"`
+	Currency    string       `json:"currency"`
+	Status      OrderStatus  `json:"status"`
+	ItemCount   int          `json:"item_count"`
+	IsGift      bool         `json:"is_gift"`
+	CreatedAt   time.Time    `json:"created_at"`
+	UpdatedAt   time.Time    `json:"updated_at"`
 }
 
 // AdminOrderListResponse represents the response for admin order listing
@@ -206,8 +236,11 @@ type AdminOrderListResponse struct {
 // AdminOrderDetailResponse represents detailed order information for admin
 type AdminOrderDetailResponse struct {
 	Order         AdminOrderResponse  `json:"order"`
+	Gift          *OrderGift          `json:"gift,omitempty"`
 	Items         []OrderItem         `json:"items"`
 	StatusHistory []OrderStatusChange `json:"status_history,omitempty"`
+	Attachments   []OrderAttachment   `json:"attachments"`
+	TrackingURL   string              `json:"tracking_url,omitempty"`
 }
 
 // OrderStatusChange represents a status change record
@@ -236,28 +269,53 @@ type BulkUpdateOrdersRequest struct {
 
 // OrderStatistics represents order statistics for admin dashboard
 type OrderStatistics struct {
-	TotalOrders      int                     `json:"total_orders"`
-	TotalRevenue     float64                 `json:"total_revenue"`
-	OrdersByStatus   map[OrderStatus]int     `json:"orders_by_status"`
-	OrdersByMiniApp  map[MiniAppType]int     `json:"orders_by_mini_app"`
-	RevenueByMiniApp map[MiniAppType]float64 `json:"revenue_by_mini_app"`
-	DailyStats       []DailyOrderStats       `json:"daily_stats"`
-	TopProducts      []ProductOrderStats     `json:"top_products"`
+	TotalOrders      int                          `json:"total_orders"`
+	TotalRevenue     money.Amount                 `json:"total_revenue"`
+	OrdersByStatus   map[OrderStatus]int          `json:"orders_by_status"`
+	OrdersByMiniApp  map[MiniAppType]int          `json:"orders_by_mini_app"`
+	RevenueByMiniApp map[MiniAppType]money.Amount `json:"revenue_by_mini_app"`
+	DailyStats       []DailyOrderStats            `json:"daily_stats"`
+	TopProducts      []ProductOrderStats          `json:"top_products"`
 }
 
 // DailyOrderStats represents daily order statistics
 type DailyOrderStats struct {
-	Date       string  `json:"date"`
-	OrderCount int     `json:"order_count"`
-	Revenue    float64 `json:"revenue"`
+	Date       string       `json:"date"`
+	OrderCount int          `json:"order_count"`
+	Revenue    money.Amount `json:"revenue"`
 }
 
 // ProductOrderStats represents product order statistics
 type ProductOrderStats struct {
-	ProductID    string  `json:"product_id"`
-	ProductTitle string  `json:"product_title"`
-	OrderCount   int     `json:"order_count"`
-	TotalRevenue float64 `json:"total_revenue"`
+	ProductID    string       `json:"product_id"`
+	ProductTitle string       `json:"product_title"`
+	OrderCount   int          `json:"order_count"`
+	TotalRevenue money.Amount `json:"total_revenue"`
+}
+
+// ManufacturerSettlement is what a manufacturer earned from delivered orders in a period.
+// Commission uses the catalog rate in effect when each order was placed; lines with no
+// configured rate are charged no commission and counted in UnratedItems for follow-up.
+type ManufacturerSettlement struct {
+	ManufacturerOrgID string       `json:"manufacturer_org_id"`
+	ManufacturerName  string       `json:"manufacturer_name"`
+	OrderCount        int          `json:"order_count"`
+	ItemCount         int          `json:"item_count"`
+	UnitsSold         int          `json:"units_sold"`
+	GrossSales        money.Amount `json:"gross_sales"`
+	Commission        money.Amount `json:"commission"`
+	NetPayout         money.Amount `json:"net_payout"`
+	UnratedItems      int          `json:"unrated_items"`
+}
+
+// SettlementReport is the settlement run for a period [PeriodStart, PeriodEnd)
+type SettlementReport struct {
+	PeriodStart     time.Time                `json:"period_start"`
+	PeriodEnd       time.Time                `json:"period_end"`
+	Settlements     []ManufacturerSettlement `json:"settlements"`
+	TotalGross      money.Amount             `json:"total_gross"`
+	TotalCommission money.Amount             `json:"total_commission"`
+	TotalPayout     money.Amount             `json:"total_payout"`
 }
 
 // Admin Cart Models
@@ -278,17 +336,17 @@ type AdminCartListRequest struct {
 
 // AdminCartResponse represents a cart in admin list view
 type AdminCartResponse struct {
-	ID          string      `json:"id"`
-	UserID      string      `json:"user_id"`
-	UserEmail   string      `json:"user_email"`
-	UserName    string      `json:"user_name"`
-	MiniAppType MiniAppType `json:"mini_app_type"`
-	StoreID     *int        `json:"store_id,omitempty"`
-	StoreName   string      `json:"store_name,omitempty"`
-	ItemCount   int         `json:"item_count"`
-	TotalValue  float64     `json:"total_value"`
-	CreatedAt   time.Time   `json:"created_at"`
-	UpdatedAt   time.Time   `json:"updated_at"`
+	ID          string       `json:"id"`
+	UserID      string       `json:"user_id"`
+	UserEmail   string       `json:"user_email"`
+	UserName    string       `json:"user_name"`
+	MiniAppType MiniAppType  `json:"mini_app_type"`
+	StoreID     *int         `json:"store_id,omitempty"`
+	StoreName   string       `json:"store_name,omitempty"`
+	ItemCount   int          `json:"item_count"`
+	TotalValue  money.Amount `json:"total_value"`
+	CreatedAt   time.Time    `json:"created_at"`
+	UpdatedAt   time.Time    `json:"updated_at"`
 }
 
 // AdminCartListResponse represents the response for admin cart listing
@@ -314,10 +372,535 @@ type AdminCartUpdateRequest struct {
 
 // CartStatistics represents comprehensive cart statistics for admin dashboard
 type CartStatistics struct {
-	TotalCarts         int                     `json:"total_carts"`
-	TotalCartValue     float64                 `json:"total_cart_value"`
-	AverageCartValue   float64                 `json:"average_cart_value"`
-	CartsByMiniApp     map[MiniAppType]int     `json:"carts_by_mini_app"`
-	CartValueByMiniApp map[MiniAppType]float64 `json:"cart_value_by_mini_app"`
-	AbandonedCarts     int                     `json:"abandoned_carts"` // Carts older than 7 days
+	TotalCarts         int                          `json:"total_carts"`
+	TotalCartValue     money.Amount                 `json:"total_cart_value"`
+	AverageCartValue   money.Amount                 `json:"average_cart_value"`
+	CartsByMiniApp     map[MiniAppType]int          `json:"carts_by_mini_app"`
+	CartValueByMiniApp map[MiniAppType]money.Amount `json:"cart_value_by_mini_app"`
+	AbandonedCarts     int                          `json:"abandoned_carts"` // Carts older than 7 days
+}
+
+// Quote (draft order) models
+
+// QuoteStatus represents the lifecycle state of a quote
+type QuoteStatus string
+
+const (
+	QuoteStatusDraft     QuoteStatus = "draft"
+	QuoteStatusSent      QuoteStatus = "sent"
+	QuoteStatusConverted QuoteStatus = "converted"
+	QuoteStatusCancelled QuoteStatus = "cancelled"
+)
+
+// Quote is a priced proposal assembled by an admin or partner for a specific customer.
+// Once sent, the customer can convert it into an order while it is within its validity window.
+type Quote struct {
+	ID               string       `json:"id"`
+	CreatedBy        string       `json:"created_by"`
+	CustomerUserID   string       `json:"customer_user_id"`
+	MiniAppType      MiniAppType  `json:"mini_app_type"`
+	StoreID          *int         `json:"store_id,omitempty"`
+	Status           QuoteStatus  `json:"status"`
+	Notes            string       `json:"notes,omitempty"`
+	ValidFrom        time.Time    `json:"valid_from"`
+	ValidUntil       time.Time    `json:"valid_until"`
+	TotalAmount      money.Amount `json:"total_amount"`
+	Currency         string       `json:"currency"`
+	ConvertedOrderID *string      `json:"converted_order_id,omitempty"`
+	Items            []QuoteItem  `json:"items"`
+	SentAt           *time.Time   `json:"sent_at,omitempty"`
+	CreatedAt        time.Time    `json:"created_at"`
+	UpdatedAt        time.Time    `json:"updated_at"`
+}
+
+// IsExpired reports whether the quote is outside its validity window at t
+func (q *Quote) IsExpired(t time.Time) bool {
+	return t.Before(q.ValidFrom) || !t.Before(q.ValidUntil)
+}
+
+// QuoteItem is a product line with a negotiated unit price
+type QuoteItem struct {
+	ID        string       `json:"id"`
+	QuoteID   string       `json:"quote_id"`
+	ProductID string       `json:"product_id"`
+	Quantity  int          `json:"quantity"`
+	UnitPrice money.Amount `json:"unit_price"`
+	ListPrice money.Amount `json:"list_price"`
+	Product   *Product     `json:"product,omitempty"`
+}
+
+// QuoteItemRequest represents a product line in a quote request
+type QuoteItemRequest struct {
+	ProductID string       `json:"product_id" binding:"required"`
+	Quantity  int          `json:"quantity" binding:"required,min=1"`
+	UnitPrice money.Amount `json:"unit_price" binding:"required,gt=0"`
+}
+
+// CreateQuoteRequest represents a request to create a quote
+type CreateQuoteRequest struct {
+	CustomerUserID string             `json:"customer_user_id"`
+	CustomerEmail  string             `json:"customer_email"`
+	MiniAppType    MiniAppType        `json:"mini_app_type"` // defaults to GroupBuying
+	StoreID        *int               `json:"store_id,omitempty"`
+	Notes          string             `json:"notes"`
+	ValidFrom      *time.Time         `json:"valid_from,omitempty"` // defaults to now
+	ValidUntil     time.Time          `json:"valid_until" binding:"required"`
+	Items          []QuoteItemRequest `json:"items" binding:"required,min=1,dive"`
+}
+
+// UpdateQuoteRequest represents a request to replace the contents of a draft quote
+type UpdateQuoteRequest struct {
+	Notes      string             `json:"notes"`
+	StoreID    *int               `json:"store_id,omitempty"`
+	ValidFrom  *time.Time         `json:"valid_from,omitempty"`
+	ValidUntil time.Time          `json:"valid_until" binding:"required"`
+	Items      []QuoteItemRequest `json:"items" binding:"required,min=1,dive"`
+}
+
+// OrderEventType identifies a milestone in an order's timeline
+type OrderEventType string
+
+const (
+	OrderEventCreated        OrderEventType = "created"
+	OrderEventPaid           OrderEventType = "paid"
+	OrderEventPacked         OrderEventType = "packed"
+	OrderEventReadyForPickup OrderEventType = "ready_for_pickup"
+	OrderEventCollected      OrderEventType = "collected"
+	OrderEventStatusChanged  OrderEventType = "status_changed"
+	OrderEventScheduled      OrderEventType = "scheduled"
+	OrderEventRefunded       OrderEventType = "refunded"
+)
+
+// IsMilestone reports whether the event type can be recorded manually by staff
+func (e OrderEventType) IsMilestone() bool {
+	switch e {
+	case OrderEventPaid, OrderEventPacked, OrderEventReadyForPickup, OrderEventCollected:
+		return true
+	default:
+		return false
+	}
+}
+
+// OrderEvent is an entry in an order's timeline
+type OrderEvent struct {
+	ID         string         `json:"id"`
+	OrderID    string         `json:"order_id"`
+	EventType  OrderEventType `json:"event_type"`
+	OldStatus  *OrderStatus   `json:"old_status,omitempty"`
+	NewStatus  *OrderStatus   `json:"new_status,omitempty"`
+	Note       string         `json:"note,omitempty"`
+	ActorID    *string        `json:"actor_id,omitempty"`
+	OccurredAt time.Time      `json:"occurred_at"`
+}
+
+// OrderSchedule is the pickup or delivery slot booked for an order
+type OrderSchedule struct {
+	OrderID   string    `json:"order_id"`
+	Kind      string    `json:"kind"` // pickup or delivery
+	StartsAt  time.Time `json:"starts_at"`
+	EndsAt    time.Time `json:"ends_at"`
+	Location  string    `json:"location,omitempty"`
+	Notes     string    `json:"notes,omitempty"`
+	Sequence  int       `json:"-"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// OrderTimelineResponse is an order's events plus its scheduled slot, if any
+type OrderTimelineResponse struct {
+	OrderID  string         `json:"order_id"`
+	Status   OrderStatus    `json:"status"`
+	Events   []OrderEvent   `json:"events"`
+	Schedule *OrderSchedule `json:"schedule,omitempty"`
+}
+
+// TrackingEvent is a timeline entry as shown on the public tracking page (no staff notes or actors)
+type TrackingEvent struct {
+	EventType  OrderEventType `json:"event_type"`
+	NewStatus  *OrderStatus   `json:"new_status,omitempty"`
+	OccurredAt time.Time      `json:"occurred_at"`
+}
+
+// TrackingSchedule is the booked slot as shown on the public tracking page (window only, no location or notes)
+type TrackingSchedule struct {
+	StartsAt time.Time `json:"starts_at"`
+	EndsAt   time.Time `json:"ends_at"`
+}
+
+// OrderTrackingResponse is the unauthenticated view of an order opened from a tracking link
+type OrderTrackingResponse struct {
+	OrderNumber string            `json:"order_number"`
+	Status      OrderStatus       `json:"status"`
+	ItemCount   int               `json:"item_count"`
+	Events      []TrackingEvent   `json:"events"`
+	Schedule    *TrackingSchedule `json:"schedule,omitempty"`
+	// PickupCode is shown once the order is ready for pickup and until it is collected
+	PickupCode string    `json:"pickup_code,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// RecordOrderEventRequest represents a request to record a timeline milestone
+type RecordOrderEventRequest struct {
+	EventType  OrderEventType `json:"event_type" binding:"required"`
+	Note       string         `json:"note"`
+	OccurredAt *time.Time     `json:"occurred_at,omitempty"` // defaults to now
+}
+
+// SetOrderScheduleRequest represents a request to book or move a pickup/delivery slot
+type SetOrderScheduleRequest struct {
+	Kind     string    `json:"kind" binding:"omitempty,oneof=pickup delivery"` // defaults to pickup
+	StartsAt time.Time `json:"starts_at" binding:"required"`
+	EndsAt   time.Time `json:"ends_at" binding:"required"`
+	Location string    `json:"location"`
+	Notes    string    `json:"notes"`
+}
+
+// OrderChannel records where an order was captured
+type OrderChannel string
+
+const (
+	OrderChannelOnline  OrderChannel = "online"
+	OrderChannelOffline OrderChannel = "offline" // recorded at an expo booth and imported later
+)
+
+// OrderImportOrder is an order created (or, on a dry run, validated) from an import file
+type OrderImportOrder struct {
+	OrderRef    string       `json:"order_ref"`
+	OrderID     string       `json:"order_id,omitempty"`
+	ItemCount   int          `json:"item_count"`
+	TotalAmount money.Amount `json:"total_amount"`
+}
+
+// OrderImportReject explains why a row of an import file was not imported
+type OrderImportReject struct {
+	Row      int    `json:"row"`
+	OrderRef string `json:"order_ref,omitempty"`
+	Error    string `json:"error"`
+}
+
+// OrderImportResult summarizes an offline order import
+type OrderImportResult struct {
+	DryRun        bool                `json:"dry_run"`
+	TotalRows     int                 `json:"total_rows"`
+	RowsImported  int                 `json:"rows_imported"`
+	RowsRejected  int                 `json:"rows_rejected"`
+	OrdersCreated int                 `json:"orders_created"`
+	Orders        []OrderImportOrder  `json:"orders"`
+	Rejects       []OrderImportReject `json:"rejects"`
+}
+
+// AttachmentDocType classifies a document attached to an order
+type AttachmentDocType string
+
+const (
+	AttachmentCustomsForm   AttachmentDocType = "customs_form"
+	AttachmentDeliveryProof AttachmentDocType = "delivery_proof"
+	AttachmentDamagePhoto   AttachmentDocType = "damage_photo"
+	AttachmentInvoice       AttachmentDocType = "invoice"
+	AttachmentOther         AttachmentDocType = "other"
+)
+
+// IsValid checks if the document type is known
+func (t AttachmentDocType) IsValid() bool {
+	switch t {
+	case AttachmentCustomsForm, AttachmentDeliveryProof, AttachmentDamagePhoto, AttachmentInvoice, AttachmentOther:
+		return true
+	default:
+		return false
+	}
+}
+
+// Attachment audiences besides admins, who can always see every attachment
+const (
+	AttachmentAudienceManufacturer = "manufacturer"
+	AttachmentAudienceCustomer     = "customer"
+)
+
+// DefaultAudiences returns who may see a document of this type when the uploader doesn't say
+func (t AttachmentDocType) DefaultAudiences() []string {
+	switch t {
+	case AttachmentCustomsForm:
+		return []string{AttachmentAudienceManufacturer}
+	case AttachmentDeliveryProof, AttachmentDamagePhoto:
+		return []string{AttachmentAudienceManufacturer, AttachmentAudienceCustomer}
+	case AttachmentInvoice:
+		return []string{AttachmentAudienceCustomer}
+	default:
+		return []string{}
+	}
+}
+
+// OrderAttachment is a document stored in S3 and linked to an order
+type OrderAttachment struct {
+	ID           string            `json:"id"`
+	OrderID      string            `json:"order_id"`
+	DocType      AttachmentDocType `json:"doc_type"`
+	FileName     string            `json:"file_name"`
+	ContentType  string            `json:"content_type"`
+	SizeBytes    int64             `json:"size_bytes"`
+	VisibleTo    []string          `json:"visible_to"`
+	UploadedBy   string            `json:"uploaded_by"`
+	UploaderRole string            `json:"uploader_role"`
+	Note         string            `json:"note,omitempty"`
+	S3Key        string            `json:"-"`
+	CreatedAt    time.Time         `json:"created_at"`
+}
+
+// VisibleToAudience reports whether the attachment is shared with the given audience
+func (a *OrderAttachment) VisibleToAudience(audience string) bool {
+	for _, v := range a.VisibleTo {
+		if v == audience {
+			return true
+		}
+	}
+	return false
+}
+
+// LedgerEntryType classifies a posting in an order's ledger
+type LedgerEntryType string
+
+const (
+	LedgerEntryCharge     LedgerEntryType = "charge"     // order placed: receivable up, revenue up
+	LedgerEntryRefund     LedgerEntryType = "refund"     // money returned to the customer
+	LedgerEntryAdjustment LedgerEntryType = "adjustment" // manual price correction (signed)
+	LedgerEntryReversal   LedgerEntryType = "reversal"   // outstanding charge cancelled with the order
+)
+
+// Ledger accounts; every posting debits one and credits another by the same amount
+const (
+	LedgerAccountReceivable = "receivable"
+	LedgerAccountRevenue    = "revenue"
+	LedgerAccountRefunds    = "refunds"
+)
+
+// LedgerLine is one side of a posting; lines sharing a PostingID balance to zero
+type LedgerLine struct {
+	ID          string          `json:"id"`
+	PostingID   string          `json:"posting_id"`
+	OrderID     string          `json:"order_id"`
+	EntryType   LedgerEntryType `json:"entry_type"`
+	Account     string          `json:"account"`
+	Debit       money.Amount    `json:"debit"`
+	Credit      money.Amount    `json:"credit"`
+	Currency    string          `json:"currency"`
+	Description string          `json:"description,omitempty"`
+	ExternalRef string          `json:"external_ref,omitempty"`
+	ActorID     *string         `json:"actor_id,omitempty"`
+	PostedAt    time.Time       `json:"posted_at"`
+}
+
+// OrderLedgerBalance sums an order's postings by type; NetRevenue is what the order is still worth
+// (and the most that can be refunded)
+type OrderLedgerBalance struct {
+	Charged    money.Amount `json:"charged"`
+	Adjusted   money.Amount `json:"adjusted"`
+	Refunded   money.Amount `json:"refunded"`
+	Reversed   money.Amount `json:"reversed"`
+	NetRevenue money.Amount `json:"net_revenue"`
+}
+
+// OrderLedgerResponse is an order's ledger lines in posting order plus the balance
+type OrderLedgerResponse struct {
+	OrderID  string             `json:"order_id"`
+	Currency string             `json:"currency"`
+	Lines    []LedgerLine       `json:"lines"`
+	Balance  OrderLedgerBalance `json:"balance"`
+}
+
+// RecordRefundRequest represents a request to refund part or all of an order
+type RecordRefundRequest struct {
+	Amount      money.Amount `json:"amount" binding:"required,gt=0"`
+	Reason      string       `json:"reason" binding:"required"`
+	ExternalRef string       `json:"external_ref"` // e.g. the payment provider's refund ID
+}
+
+// RecordAdjustmentRequest represents a manual correction; positive amounts charge more, negative ones credit the customer
+type RecordAdjustmentRequest struct {
+	Amount      money.Amount `json:"amount" binding:"required"`
+	Reason      string       `json:"reason" binding:"required"`
+	ExternalRef string       `json:"external_ref"`
+}
+
+// OrderGift holds the recipient of a gift order. The buyer stays the order's user; the parcel goes to the recipient.
+type OrderGift struct {
+	OrderID        string    `json:"order_id"`
+	RecipientName  string    `json:"recipient_name"`
+	RecipientPhone string    `json:"recipient_phone,omitempty"`
+	AddressLine1   string    `json:"address_line1"`
+	AddressLine2   string    `json:"address_line2,omitempty"`
+	PostalCode     string    `json:"postal_code"`
+	City           string    `json:"city"`
+	Country        string    `json:"country"`
+	Message        string    `json:"message,omitempty"`
+	HidePrices     bool      `json:"hide_prices"` // packing slip leaves out prices and totals
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// OrderGiftRequest marks an order as a gift at checkout
+type OrderGiftRequest struct {
+	RecipientName  string `json:"recipient_name" binding:"required,max=200"`
+	RecipientPhone string `json:"recipient_phone" binding:"max=40"`
+	AddressLine1   string `json:"address_line1" binding:"required,max=200"`
+	AddressLine2   string `json:"address_line2" binding:"max=200"`
+	PostalCode     string `json:"postal_code" binding:"required,max=20"`
+	City           string `json:"city" binding:"required,max=100"`
+	Country        string `json:"country" binding:"required,len=2"` // ISO 3166-1 alpha-2
+	Message        string `json:"message" binding:"max=500"`
+	HidePrices     *bool  `json:"hide_prices,omitempty"` // defaults to true
+}
+
+// OrderDocumentType is a printable document rendered from an order
+type OrderDocumentType string
+
+const (
+	OrderDocumentPackingSlip   OrderDocumentType = "packing-slip"
+	OrderDocumentInvoice       OrderDocumentType = "invoice"
+	OrderDocumentShippingLabel OrderDocumentType = "shipping-label" // bulk printing only
+)
+
+// OrderSLAThreshold is the longest an order may stay in a status before it counts as stuck
+type OrderSLAThreshold struct {
+	Status    OrderStatus `json:"status"`
+	MaxHours  int         `json:"max_hours"`
+	UpdatedBy *string     `json:"updated_by,omitempty"`
+	UpdatedAt time.Time   `json:"updated_at"`
+}
+
+// IsSLATracked reports whether orders can get stuck in the status (terminal statuses cannot)
+func (s OrderStatus) IsSLATracked() bool {
+	switch s {
+	case OrderStatusPending, OrderStatusConfirmed, OrderStatusProcessing, OrderStatusShipped:
+		return true
+	}
+	return false
+}
+
+// SetOrderSLAThresholdRequest sets the threshold of one status
+type SetOrderSLAThresholdRequest struct {
+	MaxHours int `json:"max_hours" binding:"required,min=1,max=8760"`
+}
+
+// AgingOrder is an order that has stayed in its status longer than the status' SLA threshold
+type AgingOrder struct {
+	OrderID         string       `json:"order_id"`
+	UserID          string       `json:"user_id"`
+	UserEmail       string       `json:"user_email"`
+	MiniAppType     MiniAppType  `json:"mini_app_type"`
+	StoreID         *int         `json:"store_id,omitempty"`
+	TotalAmount     money.Amount `json:"total_amount"`
+	Status          OrderStatus  `json:"status"`
+	EnteredStatusAt time.Time    `json:"entered_status_at"`
+	AgeHours        float64      `json:"age_hours"`
+	MaxHours        int          `json:"max_hours"`
+	OverdueHours    float64      `json:"overdue_hours"`
+	FlaggedAt       *time.Time   `json:"flagged_at,omitempty"` // when the background checker first flagged the breach
+}
+
+// AgingOrderListRequest filters the aging orders report
+type AgingOrderListRequest struct {
+	Status  string `form:"status"`
+	StoreID *int   `form:"store_id"`
+	Limit   int    `form:"limit"`
+	// ScopeStoreIDs limits regional admins to their stores' orders (nil = unrestricted)
+	ScopeStoreIDs []int `form:"-"`
+}
+
+// AllocationStrategy picks the store/warehouse a line item ships from when its product is stocked in several
+type AllocationStrategy string
+
+const (
+	AllocationNearest   AllocationStrategy = "nearest"    // closest source to the delivery location (or the order's store)
+	AllocationMostStock AllocationStrategy = "most_stock" // source with the most stock left
+	AllocationPriority  AllocationStrategy = "priority"   // lowest configured priority number first
+)
+
+// IsValid reports whether the strategy is known
+func (s AllocationStrategy) IsValid() bool {
+	switch s {
+	case AllocationNearest, AllocationMostStock, AllocationPriority:
+		return true
+	}
+	return false
+}
+
+// StockSource is a product's stock held at one store/warehouse
+type StockSource struct {
+	ProductID string    `json:"product_id"`
+	StoreID   int       `json:"store_id"`
+	StoreName string    `json:"store_name,omitempty"`
+	Quantity  int       `json:"quantity"`
+	Priority  int       `json:"priority"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// StockSourceInput sets one source's stock and allocation priority
+type StockSourceInput struct {
+	StoreID  int `json:"store_id" binding:"required,min=1"`
+	Quantity int `json:"quantity" binding:"min=0"`
+	Priority int `json:"priority" binding:"min=0"`
+}
+
+// SetStockSourcesRequest replaces all stock sources of a product (an empty list removes them)
+type SetStockSourcesRequest struct {
+	Sources []StockSourceInput `json:"sources" binding:"max=100,dive"`
+}
+
+// DeliveryOption is a way to receive the order offered at checkout, with its fee
+type DeliveryOption struct {
+	Code  string       `json:"code" binding:"required,max=30"`
+	Label string       `json:"label" binding:"max=100"`
+	Fee   money.Amount `json:"fee" binding:"min=0"`
+}
+
+// CheckoutConfig holds the checkout rules of a mini-app. Empty lists leave the choice unrestricted;
+// otherwise the first payment method and delivery option are the defaults when the order names none.
+// UpdatedAt is nil for a mini-app that was never configured (no restrictions, no fees).
+type CheckoutConfig struct {
+	MiniAppType     MiniAppType      `json:"mini_app_type"`
+	PaymentMethods  []string         `json:"payment_methods"`
+	MinOrderValue   money.Amount     `json:"min_order_value"`
+	DeliveryOptions []DeliveryOption `json:"delivery_options"`
+	ServiceFee      money.Amount     `json:"service_fee"`
+	UpdatedBy       *string          `json:"updated_by,omitempty"`
+	UpdatedAt       *time.Time       `json:"updated_at,omitempty"`
+}
+
+// SetCheckoutConfigRequest replaces the checkout rules of a mini-app
+type SetCheckoutConfigRequest struct {
+	PaymentMethods  []string         `json:"payment_methods" binding:"max=20,dive,required,max=30"`
+	MinOrderValue   money.Amount     `json:"min_order_value" binding:"min=0"`
+	DeliveryOptions []DeliveryOption `json:"delivery_options" binding:"max=20,dive"`
+	ServiceFee      money.Amount     `json:"service_fee" binding:"min=0"`
+}
+
+// PrintJobStatus is the state of a bulk print job
+type PrintJobStatus string
+
+const (
+	PrintJobQueued  PrintJobStatus = "queued"
+	PrintJobRunning PrintJobStatus = "running"
+	PrintJobDone    PrintJobStatus = "done"
+	PrintJobFailed  PrintJobStatus = "failed"
+)
+
+// PrintOrdersRequest asks for one merged PDF of the documents of several orders
+type PrintOrdersRequest struct {
+	OrderIDs []string          `json:"order_ids" binding:"required,min=1,max=500,dive,required"`
+	DocType  OrderDocumentType `json:"doc_type"` // packing-slip (default), invoice or shipping-label
+}
+
+// PrintJob is a bulk print request rendered in the background. Orders that no longer exist are
+// listed in MissingOrderIDs; DownloadURL is a short-lived link set once the job is done.
+type PrintJob struct {
+	ID              string            `json:"id"`
+	DocType         OrderDocumentType `json:"doc_type"`
+	OrderIDs        []string          `json:"order_ids"`
+	Status          PrintJobStatus    `json:"status"`
+	MissingOrderIDs []string          `json:"missing_order_ids"`
+	PageCount       int               `json:"page_count"`
+	Error           string            `json:"error,omitempty"`
+	RequestedBy     string            `json:"requested_by"`
+	CreatedAt       time.Time         `json:"created_at"`
+	FinishedAt      *time.Time        `json:"finished_at,omitempty"`
+	DownloadURL     string            `json:"download_url,omitempty"`
+	S3Key           string            `json:"-"`
 }
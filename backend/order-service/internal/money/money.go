@@ -0,0 +1,252 @@
+// Package money holds monetary amounts as integer minor units so that cart, order, quote,
+// ledger and statistics totals add up exactly instead of accumulating float64 rounding error.
+package money
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// DefaultCurrency is the ISO 4217 code amounts are priced in unless a row records another
+const DefaultCurrency = "EUR"
+
+// minorDigits is the number of fraction digits stored in minor units (cents)
+const minorDigits = 2
+
+// ErrInvalidAmount is returned when a value cannot be read as a decimal amount
+var ErrInvalidAmount = errors.New("invalid money amount")
+
+// Amount is a monetary value in minor units (cents). It encodes to JSON as a decimal number
+// with two fraction digits (12.30), so API payloads keep their shape, and maps to NUMERIC columns.
+type Amount int64
+
+// FromCents returns the amount for a number of minor units
+func FromCents(cents int64) Amount {
+	return Amount(cents)
+}
+
+// FromFloat converts a float to the nearest cent, rounding half away from zero.
+// Only use it at the edge, for values that arrive as floats (e.g. spreadsheet cells).
+func FromFloat(f float64) Amount {
+	return Amount(math.Round(f * 100))
+}
+
+// Parse reads a decimal string such as "12.3", "-0.05" or "7". Digits beyond the cent are
+// rounded half away from zero.
+func Parse(s string) (Amount, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, ErrInvalidAmount
+	}
+	neg := false
+	switch s[0] {
+	case '-':
+		neg = true
+		s = s[1:]
+	case '+':
+		s = s[1:]
+	}
+	intPart, fracPart, _ := strings.Cut(s, ".")
+	if intPart == "" && fracPart == "" {
+		return 0, ErrInvalidAmount
+	}
+	if !allDigits(intPart) || !allDigits(fracPart) {
+		return 0, ErrInvalidAmount
+	}
+	if intPart == "" {
+		intPart = "0"
+	}
+
+	roundUp := len(fracPart) > minorDigits && fracPart[minorDigits] >= '5'
+	if len(fracPart) > minorDigits {
+		fracPart = fracPart[:minorDigits]
+	}
+	fracPart += strings.Repeat("0", minorDigits-len(fracPart))
+
+	cents, err := strconv.ParseInt(intPart+fracPart, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %s", ErrInvalidAmount, err)
+	}
+	if roundUp {
+		cents++
+	}
+	if neg {
+		cents = -cents
+	}
+	return Amount(cents), nil
+}
+
+func allDigits(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// Cents returns the amount in minor units
+func (a Amount) Cents() int64 {
+	return int64(a)
+}
+
+// Float64 returns the amount in major units; for display and ratios only, never for sums
+func (a Amount) Float64() float64 {
+	return float64(a) / 100
+}
+
+// Mul returns the amount multiplied by a quantity
+func (a Amount) Mul(qty int) Amount {
+	return a * Amount(qty)
+}
+
+// Div splits the amount into n shares (n > 0), rounding half away from zero
+func (a Amount) Div(n int) Amount {
+	if n <= 0 {
+		return 0
+	}
+	q, r := a/Amount(n), a%Amount(n)
+	if 2*r.Abs() >= Amount(n) {
+		if a < 0 {
+			q--
+		} else {
+			q++
+		}
+	}
+	return q
+}
+
+// Abs returns the absolute amount
+func (a Amount) Abs() Amount {
+	if a < 0 {
+		return -a
+	}
+	return a
+}
+
+// String formats the amount with two fraction digits, e.g. "12.30" or "-0.05"
+func (a Amount) String() string {
+	sign := ""
+	c := int64(a)
+	if c < 0 {
+		sign = "-"
+		c = -c
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, c/100, c%100)
+}
+
+// Format renders the amount followed by its currency code, e.g. "12.30 EUR"
+func (a Amount) Format(currency string) string {
+	if currency == "" {
+		currency = DefaultCurrency
+	}
+	return a.String() + " " + currency
+}
+
+// MarshalJSON encodes the amount as a JSON number with two fraction digits
+func (a Amount) MarshalJSON() ([]byte, error) {
+	return []byte(a.String()), nil
+}
+
+// UnmarshalJSON accepts a JSON number or a numeric string and reads it without going through float64
+func (a *Amount) UnmarshalJSON(data []byte) error {
+	s := string(data)
+	if s == "null" {
+		return nil
+	}
+	if unquoted, err := strconv.Unquote(s); err == nil {
+		s = unquoted
+	}
+	v, err := Parse(s)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrInvalidAmount, string(data))
+	}
+	*a = v
+	return nil
+}
+
+// ScanNumeric implements pgtype.NumericScanner so NUMERIC columns are read exactly
+func (a *Amount) ScanNumeric(n pgtype.Numeric) error {
+	if !n.Valid {
+		*a = 0
+		return nil
+	}
+	if n.NaN || n.InfinityModifier != pgtype.Finite {
+		return fmt.Errorf("%w: not a finite number", ErrInvalidAmount)
+	}
+	v, err := centsFromNumeric(n.Int, n.Exp)
+	if err != nil {
+		return err
+	}
+	*a = v
+	return nil
+}
+
+// NumericValue implements pgtype.NumericValuer so amounts are written to NUMERIC columns exactly
+func (a Amount) NumericValue() (pgtype.Numeric, error) {
+	return pgtype.Numeric{Int: big.NewInt(int64(a)), Exp: -minorDigits, Valid: true}, nil
+}
+
+// Scan implements sql.Scanner for drivers and column types that hand over strings or floats
+func (a *Amount) Scan(src any) error {
+	switch v := src.(type) {
+	case nil:
+		*a = 0
+	case string:
+		p, err := Parse(v)
+		if err != nil {
+			return err
+		}
+		*a = p
+	case []byte:
+		p, err := Parse(string(v))
+		if err != nil {
+			return err
+		}
+		*a = p
+	case int64:
+		*a = Amount(v * 100)
+	case float64:
+		*a = FromFloat(v)
+	default:
+		return fmt.Errorf("%w: cannot scan %T", ErrInvalidAmount, src)
+	}
+	return nil
+}
+
+// Value implements driver.Valuer as a decimal string
+func (a Amount) Value() (driver.Value, error) {
+	return a.String(), nil
+}
+
+// centsFromNumeric converts unscaled*10^exp to cents, rounding half away from zero
+func centsFromNumeric(unscaled *big.Int, exp int32) (Amount, error) {
+	v := new(big.Int).Set(unscaled)
+	shift := int64(exp) + minorDigits
+	ten := big.NewInt(10)
+	if shift >= 0 {
+		v.Mul(v, new(big.Int).Exp(ten, big.NewInt(shift), nil))
+	} else {
+		div := new(big.Int).Exp(ten, big.NewInt(-shift), nil)
+		q, r := new(big.Int).QuoRem(v, div, new(big.Int))
+		if new(big.Int).Mul(new(big.Int).Abs(r), big.NewInt(2)).Cmp(div) >= 0 {
+			if v.Sign() < 0 {
+				q.Sub(q, big.NewInt(1))
+			} else {
+				q.Add(q, big.NewInt(1))
+			}
+		}
+		v = q
+	}
+	if !v.IsInt64() {
+		return 0, fmt.Errorf("%w: out of range", ErrInvalidAmount)
+	}
+	return Amount(v.Int64()), nil
+}
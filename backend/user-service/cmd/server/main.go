@@ -1,9 +1,13 @@
 package main
 
 import (
+	"context"
 	"log"
 	"os"
+	"time"
 
+	"github.com/expotoworld/expotoworld/backend/common/auth"
+	"github.com/expotoworld/expotoworld/backend/common/httpserver"
 	"github.com/expotoworld/expotoworld/backend/user-service/internal/api"
 	"github.com/expotoworld/expotoworld/backend/user-service/internal/db"
 	"github.com/expotoworld/expotoworld/backend/user-service/internal/logging"
@@ -31,11 +35,27 @@ func main() {
 	}
 	if database != nil {
 		defer database.Close()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		if err := database.EnsureSchema(ctx); err != nil {
+			log.Printf("[WARN] Schema check failed: %v", err)
+		}
+		cancel()
+
+		// Reject tokens revoked by a forced logout or sign-in method change
+		api.CheckTokenVersions(auth.NewVersionCache(auth.VersionFunc(database.TokenVersion), auth.VersionCacheTTL()))
 	}
 
 	// Initialize handlers
 	handler := api.NewHandler(database)
 
+	// Purge soft-deleted users past the retention period
+	if database != nil {
+		jobsCtx, stopJobs := context.WithCancel(context.Background())
+		defer stopJobs()
+		go handler.StartUserPurgeJob(jobsCtx)
+	}
+
 	// Set up Gin router
 	router := setupRouter(handler)
 
@@ -45,9 +65,9 @@ func main() {
 		port = "8083" // Different port from other services
 	}
 
-	log.Printf("Starting user service on port %s", port)
-	if err := router.Run(":" + port); err != nil {
-		log.Fatalf("Failed to start server: %v", err)
+	// Serve until SIGTERM, then drain in-flight requests before the deferred job stop and database close
+	if err := httpserver.Run("user service", router, port); err != nil {
+		log.Printf("[ERROR] %v", err)
 	}
 }
 
@@ -69,6 +89,15 @@ func setupRouter(handler *api.Handler) *gin.Engine {
 	router.GET("/ready", handler.Health)
 	router.GET("/health", handler.Health)
 
+	// Self-service routes for any authenticated user
+	meGroup := router.Group("/api/me")
+	meGroup.Use(api.AuthMiddleware())
+	{
+		meGroup.GET("", handler.GetMe)
+		meGroup.GET("/custom-fields", handler.GetMyCustomFields)
+		meGroup.PUT("/custom-fields", handler.UpdateMyCustomFields)
+	}
+
 	// Admin API routes with authentication and admin middleware
 	adminGroup := router.Group("/api/admin")
 	adminGroup.Use(api.AuthMiddleware())
@@ -82,7 +111,14 @@ func setupRouter(handler *api.Handler) *gin.Engine {
 		adminGroup.PUT("/users/:user_id", handler.UpdateUser)
 		adminGroup.DELETE("/users/:user_id", handler.DeleteUser)
 		adminGroup.POST("/users/:user_id/status", handler.UpdateUserStatus)
+		adminGroup.POST("/users/:user_id/reverify", handler.RequireReverification)
 		adminGroup.POST("/users/bulk-update", handler.BulkUpdateUsers)
+
+		// Custom profile field schema
+		adminGroup.GET("/custom-fields", handler.GetCustomFields)
+		adminGroup.POST("/custom-fields", handler.CreateCustomField)
+		adminGroup.PUT("/custom-fields/:key", handler.UpdateCustomField)
+		adminGroup.DELETE("/custom-fields/:key", handler.DeleteCustomField)
 	}
 
 	return router
@@ -3,6 +3,7 @@ module github.com/expotoworld/expotoworld/backend/user-service
 go 1.23
 
 require (
+	github.com/expotoworld/expotoworld/backend/common v0.0.0
 	github.com/gin-gonic/gin v1.10.0
 	github.com/golang-jwt/jwt/v5 v5.2.1
 	github.com/joho/godotenv v1.5.1
@@ -40,3 +41,5 @@ require (
 	gopkg.in/check.v1 v1.0.0-20201130134442-10cb98267c6c // indirect
 	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
+
+replace github.com/expotoworld/expotoworld/backend/common => ../common
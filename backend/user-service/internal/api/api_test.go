@@ -1,12 +1,17 @@
 package api
 
 import (
+	"context"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"testing"
+	"time"
 
+	"github.com/expotoworld/expotoworld/backend/common/auth"
+	"github.com/expotoworld/expotoworld/backend/common/jwks"
 	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
 )
 
 // setGinTestMode ensures Gin does not write noisy logs during tests
@@ -78,3 +83,48 @@ func TestAuthMiddleware_ValidJWT(t *testing.T) {
 	}
 }
 
+func TestAuthMiddleware_RejectsRevokedToken(t *testing.T) {
+	setGinTestMode()
+	saved := authConfig
+	defer func() { authConfig = saved }()
+	authConfig.Keys = jwks.NewVerifier("", []byte("test-secret"))
+	// The user's tokens were revoked once (token_version 1)
+	CheckTokenVersions(auth.VersionFunc(func(context.Context, string) (int, error) { return 1, nil }))
+
+	r := gin.New()
+	r.Use(AuthMiddleware())
+	r.GET("/api/me", func(c *gin.Context) { c.JSON(http.StatusOK, gin.H{"ok": true}) })
+
+	for version, want := range map[int]int{0: http.StatusUnauthorized, 1: http.StatusOK} {
+		token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+			"user_id":              "u1",
+			auth.TokenVersionClaim: version,
+			"exp":                  time.Now().Add(time.Hour).Unix(),
+		}).SignedString([]byte("test-secret"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		req := httptest.NewRequest(http.MethodGet, "/api/me", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != want {
+			t.Fatalf("token_version %d: expected %d, got %d", version, want, w.Code)
+		}
+	}
+}
+
+func TestGetMe_RejectsTokenWithoutUserID(t *testing.T) {
+	setGinTestMode()
+	h := NewHandler(nil)
+	r := gin.New()
+	r.GET("/api/me", h.GetMe)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/me", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401 without user_id claim, got %d", w.Code)
+	}
+}
@@ -0,0 +1,286 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/expotoworld/expotoworld/backend/user-service/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// attachCustomFields fills the custom field values of a page of users (best effort)
+func (h *Handler) attachCustomFields(ctx context.Context, users []models.User) {
+	if len(users) == 0 {
+		return
+	}
+	ids := make([]string, len(users))
+	for i := range users {
+		ids[i] = users[i].ID
+	}
+	values, err := h.customFields.GetValuesForUsers(ctx, ids)
+	if err != nil {
+		log.Printf("[USER-API] Failed to load custom fields: %v", err)
+		return
+	}
+	for i := range users {
+		users[i].CustomFields = values[users[i].ID]
+	}
+}
+
+// attachUserCustomFields fills a single user's custom field values (best effort)
+func (h *Handler) attachUserCustomFields(ctx context.Context, user *models.User) {
+	values, err := h.customFields.GetValues(ctx, user.ID)
+	if err != nil {
+		log.Printf("[USER-API] Failed to load custom fields for user %s: %v", user.ID, err)
+		return
+	}
+	user.CustomFields = values
+}
+
+// normalizeCustomFields validates custom field values against the active schema, writing a 400 on failure
+func (h *Handler) normalizeCustomFields(ctx context.Context, c *gin.Context, values map[string]interface{}, userOnly bool) (map[string]interface{}, bool) {
+	defs, err := h.customFields.ListDefinitions(ctx, true)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "Failed to load custom fields",
+			Message: err.Error(),
+		})
+		return nil, false
+	}
+	normalized, err := models.NormalizeCustomFieldValues(defs, values, userOnly)
+	if err != nil {
+		var verr models.CustomFieldValidationError
+		if errors.As(err, &verr) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Invalid custom fields",
+				"message": err.Error(),
+				"fields":  verr,
+			})
+			return nil, false
+		}
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid custom fields", Message: err.Error()})
+		return nil, false
+	}
+	return normalized, true
+}
+
+// GetCustomFields handles GET /api/admin/custom-fields (including inactive fields)
+func (h *Handler) GetCustomFields(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	defs, err := h.customFields.ListDefinitions(ctx, false)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "Failed to retrieve custom fields",
+			Message: err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"fields": defs})
+}
+
+// CreateCustomField handles POST /api/admin/custom-fields
+func (h *Handler) CreateCustomField(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var req models.CustomFieldDefinitionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid request data",
+			Message: err.Error(),
+		})
+		return
+	}
+	def, msg := req.ToDefinition()
+	if msg != "" {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid custom field", Message: msg})
+		return
+	}
+
+	adminEmail, _ := c.Get("email")
+	log.Printf("[AUDIT][CUSTOM_FIELDS][CREATE] by=%v key=%s type=%s", adminEmail, def.Key, def.Type)
+
+	created, err := h.customFields.CreateDefinition(ctx, def)
+	if err != nil {
+		if strings.Contains(err.Error(), "duplicate") || strings.Contains(err.Error(), "unique") {
+			c.JSON(http.StatusConflict, models.ErrorResponse{
+				Error:   "Custom field already exists",
+				Message: "A custom field with this key already exists",
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "Failed to create custom field",
+			Message: err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusCreated, models.SuccessResponse{Message: "Custom field created successfully", Data: created})
+}
+
+// UpdateCustomField handles PUT /api/admin/custom-fields/{key} (full replacement of the definition)
+func (h *Handler) UpdateCustomField(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var req models.CustomFieldDefinitionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid request data",
+			Message: err.Error(),
+		})
+		return
+	}
+	req.Key = c.Param("key")
+	def, msg := req.ToDefinition()
+	if msg != "" {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid custom field", Message: msg})
+		return
+	}
+
+	adminEmail, _ := c.Get("email")
+	log.Printf("[AUDIT][CUSTOM_FIELDS][UPDATE] by=%v key=%s type=%s active=%t", adminEmail, def.Key, def.Type, def.IsActive)
+
+	updated, err := h.customFields.UpdateDefinition(ctx, def)
+	if err != nil {
+		switch {
+		case strings.Contains(err.Error(), "not found"):
+			c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "Custom field not found", Message: "The specified custom field does not exist"})
+		case strings.Contains(err.Error(), "cannot change") || strings.Contains(err.Error(), "still used"):
+			c.JSON(http.StatusConflict, models.ErrorResponse{Error: "Custom field in use", Message: err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to update custom field", Message: err.Error()})
+		}
+		return
+	}
+	c.JSON(http.StatusOK, models.SuccessResponse{Message: "Custom field updated successfully", Data: updated})
+}
+
+// DeleteCustomField handles DELETE /api/admin/custom-fields/{key}
+// Deleting removes every stored value; set is_active=false instead to hide a field but keep its data.
+func (h *Handler) DeleteCustomField(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	key := c.Param("key")
+	adminEmail, _ := c.Get("email")
+	log.Printf("[AUDIT][CUSTOM_FIELDS][DELETE] by=%v key=%s", adminEmail, key)
+
+	if err := h.customFields.DeleteDefinition(ctx, key); err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "Custom field not found", Message: "The specified custom field does not exist"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to delete custom field", Message: err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, models.SuccessResponse{Message: "Custom field deleted successfully"})
+}
+
+// GetMyCustomFields handles GET /api/me/custom-fields[?mini_app_type=GroupBuying]
+// Returns the active field schema (optionally only fields asked for in a mini-app) and the user's values.
+func (h *Handler) GetMyCustomFields(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	userIDVal, _ := c.Get("user_id")
+	userID, _ := userIDVal.(string)
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+			Error:   "Invalid token",
+			Message: "Token does not identify a user",
+		})
+		return
+	}
+
+	defs, err := h.customFields.ListDefinitions(ctx, true)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "Failed to retrieve custom fields",
+			Message: err.Error(),
+		})
+		return
+	}
+	miniAppType := c.Query("mini_app_type")
+	fields := make([]models.CustomFieldDefinition, 0, len(defs))
+	for i := range defs {
+		if defs[i].AppliesTo(miniAppType) {
+			fields = append(fields, defs[i])
+		}
+	}
+
+	values, err := h.customFields.GetValues(ctx, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "Failed to retrieve custom field values",
+			Message: err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"fields": fields, "values": values})
+}
+
+// UpdateMyCustomFields handles PUT /api/me/custom-fields
+// Body: {"custom_fields": {"company_name": "ACME", "dietary_preferences": ["vegan"]}}; null clears a field.
+// Only user-editable fields can be set here.
+func (h *Handler) UpdateMyCustomFields(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	userIDVal, _ := c.Get("user_id")
+	userID, _ := userIDVal.(string)
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+			Error:   "Invalid token",
+			Message: "Token does not identify a user",
+		})
+		return
+	}
+
+	var req struct {
+		CustomFields map[string]interface{} `json:"custom_fields" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid request body",
+			Message: err.Error(),
+		})
+		return
+	}
+	values, ok := h.normalizeCustomFields(ctx, c, req.CustomFields, true)
+	if !ok {
+		return
+	}
+
+	if err := h.customFields.SetValues(ctx, userID, values); err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			c.JSON(http.StatusNotFound, models.ErrorResponse{
+				Error:   "User not found",
+				Message: "The authenticated user does not exist",
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "Failed to update custom fields",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	current, err := h.customFields.GetValues(ctx, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "Failed to retrieve custom field values",
+			Message: err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, models.SuccessResponse{Message: "Custom fields updated successfully", Data: current})
+}
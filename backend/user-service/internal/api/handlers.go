@@ -17,13 +17,17 @@ import (
 
 // Handler handles HTTP requests
 type Handler struct {
-	userRepo *db.UserRepository
+	userRepo     *db.UserRepository
+	customFields *db.CustomFieldRepository
+	httpClient   *http.Client
 }
 
 // NewHandler creates a new handler
 func NewHandler(database *db.Database) *Handler {
 	return &Handler{
-		userRepo: db.NewUserRepository(database),
+		userRepo:     db.NewUserRepository(database),
+		customFields: db.NewCustomFieldRepository(database),
+		httpClient:   &http.Client{Timeout: 10 * time.Second},
 	}
 }
 
@@ -102,6 +106,7 @@ func (h *Handler) GetUsers(c *gin.Context) {
 		})
 		return
 	}
+	h.attachCustomFields(ctx, response.Users)
 
 	c.JSON(http.StatusOK, response)
 }
@@ -197,6 +202,8 @@ func (h *Handler) GetUser(c *gin.Context) {
 		return
 	}
 
+	h.attachUserCustomFields(ctx, user)
+
 	// Get user order statistics
 	orderStats, err := h.userRepo.GetUserOrderStats(ctx, userID)
 	if err != nil {
@@ -247,6 +254,15 @@ func (h *Handler) UpdateUser(c *gin.Context) {
 		return
 	}
 
+	// Validate custom profile fields against the admin-defined schema
+	if updates.CustomFields != nil {
+		values, ok := h.normalizeCustomFields(ctx, c, updates.CustomFields, false)
+		if !ok {
+			return
+		}
+		updates.CustomFields = values
+	}
+
 	// Audit log
 	adminEmail, _ := c.Get("email")
 	adminRole, _ := c.Get("role")
@@ -275,6 +291,8 @@ func (h *Handler) UpdateUser(c *gin.Context) {
 }
 
 // DeleteUser handles DELETE /api/admin/users/{user_id}
+// The user is anonymized and deactivated rather than removed (see UserRepository.DeleteUser),
+// and their sessions are revoked in auth-service.
 func (h *Handler) DeleteUser(c *gin.Context) {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
@@ -310,8 +328,21 @@ func (h *Handler) DeleteUser(c *gin.Context) {
 		return
 	}
 
+	// The user is already anonymized and deactivated, so a failed revocation is reported but not fatal
+	sessionsRevoked := true
+	revoked, err := h.revokeUserSessions(ctx, c.GetHeader("Authorization"), userID)
+	if err != nil {
+		sessionsRevoked = false
+		log.Printf("[AUDIT][USERS][DELETE] session revocation failed target_user_id=%s: %v", userID, err)
+	}
+
 	c.JSON(http.StatusOK, models.SuccessResponse{
 		Message: "User deleted successfully",
+		Data: gin.H{
+			"user_id":          userID,
+			"sessions_revoked": sessionsRevoked,
+			"revoked_sessions": revoked,
+		},
 	})
 }
 
@@ -367,6 +398,101 @@ func (h *Handler) UpdateUserStatus(c *gin.Context) {
 	})
 }
 
+// RequireReverification handles POST /api/admin/users/{user_id}/reverify
+func (h *Handler) RequireReverification(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	userID := c.Param("user_id")
+	if userID == "" {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid user ID",
+			Message: "User ID is required",
+		})
+		return
+	}
+
+	var req models.ReverificationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid request body",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	if !models.ValidateReverificationChannel(req.Channel) {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid channel",
+			Message: "Channel must be one of: email, phone, all",
+		})
+		return
+	}
+
+	// Audit log
+	adminEmail, _ := c.Get("email")
+	adminRole, _ := c.Get("role")
+	log.Printf("[AUDIT][USERS][REVERIFY] by=%v role=%v target_user_id=%s channel=%s reason=%s", adminEmail, adminRole, userID, req.Channel, req.Reason)
+
+	if err := h.userRepo.RequireReverification(ctx, userID, req.Channel); err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			c.JSON(http.StatusNotFound, models.ErrorResponse{
+				Error:   "User not found",
+				Message: "The specified user does not exist",
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "Failed to require re-verification",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponse{
+		Message: "Re-verification required; the user will verify with their next login code",
+		Data: gin.H{
+			"user_id": userID,
+			"channel": req.Channel,
+		},
+	})
+}
+
+// GetMe handles GET /api/me for the authenticated user
+func (h *Handler) GetMe(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	userIDVal, _ := c.Get("user_id")
+	userID, _ := userIDVal.(string)
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+			Error:   "Invalid token",
+			Message: "Token does not identify a user",
+		})
+		return
+	}
+
+	user, err := h.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			c.JSON(http.StatusNotFound, models.ErrorResponse{
+				Error:   "User not found",
+				Message: "The authenticated user does not exist",
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "Failed to retrieve user",
+			Message: err.Error(),
+		})
+		return
+	}
+	h.attachUserCustomFields(ctx, user)
+
+	c.JSON(http.StatusOK, gin.H{"user": user})
+}
+
 // GetUserAnalytics handles GET /api/admin/users/analytics
 func (h *Handler) GetUserAnalytics(c *gin.Context) {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
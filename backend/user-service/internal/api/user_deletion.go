@@ -0,0 +1,95 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// revokeUserSessions asks auth-service (AUTH_SERVICE_URL) to revoke every active session of the user,
+// authenticating with the calling admin's bearer token.
+func (h *Handler) revokeUserSessions(ctx context.Context, authHeader, userID string) (int64, error) {
+	base := strings.TrimRight(strings.TrimSpace(os.Getenv("AUTH_SERVICE_URL")), "/")
+	if base == "" {
+		return 0, fmt.Errorf("AUTH_SERVICE_URL not configured")
+	}
+
+	body, err := json.Marshal(map[string]interface{}{"filter": map[string]string{"user_id": userID}})
+	if err != nil {
+		return 0, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, base+"/api/auth/admin/sessions/revoke", bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", authHeader)
+
+	resp, err := h.httpClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return 0, fmt.Errorf("auth-service returned %d: %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+	var out struct {
+		Revoked int64 `json:"revoked"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return 0, fmt.Errorf("failed to decode auth-service response: %w", err)
+	}
+	return out.Revoked, nil
+}
+
+// StartUserPurgeJob hard-deletes soft-deleted users once they are past the retention period
+// (USER_RETENTION_DAYS, default 3650), checking every USER_PURGE_INTERVAL_HOURS (default 24).
+// It returns when ctx is cancelled.
+func (h *Handler) StartUserPurgeJob(ctx context.Context) {
+	retentionDays := envInt("USER_RETENTION_DAYS", 3650)
+	intervalHours := envInt("USER_PURGE_INTERVAL_HOURS", 24)
+	retention := time.Duration(retentionDays) * 24 * time.Hour
+
+	run := func() {
+		jobCtx, cancel := context.WithTimeout(ctx, 5*time.Minute)
+		defer cancel()
+
+		purged, err := h.userRepo.PurgeDeletedUsers(jobCtx, retention, 500)
+		if err != nil {
+			log.Printf("[USERS][PURGE] failed after purging %d users: %v", purged, err)
+			return
+		}
+		if purged > 0 {
+			log.Printf("[USERS][PURGE] purged %d users deleted more than %d days ago", purged, retentionDays)
+		}
+	}
+
+	run()
+	ticker := time.NewTicker(time.Duration(intervalHours) * time.Hour)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			run()
+		}
+	}
+}
+
+// envInt reads a positive integer environment variable, falling back to def
+func envInt(key string, def int) int {
+	if v, err := strconv.Atoi(os.Getenv(key)); err == nil && v > 0 {
+		return v
+	}
+	return def
+}
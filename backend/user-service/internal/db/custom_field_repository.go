@@ -0,0 +1,265 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"github.com/expotoworld/expotoworld/backend/user-service/internal/models"
+	"github.com/lib/pq"
+)
+
+// CustomFieldRepository handles custom profile field definitions and values
+type CustomFieldRepository struct {
+	db *Database
+}
+
+// NewCustomFieldRepository creates a new custom field repository
+func NewCustomFieldRepository(db *Database) *CustomFieldRepository {
+	return &CustomFieldRepository{db: db}
+}
+
+const customFieldColumns = `field_key, label, field_type, options, required, max_length, mini_app_types,
+	user_editable, is_active, display_order, created_at, updated_at`
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanCustomField(row rowScanner) (*models.CustomFieldDefinition, error) {
+	var d models.CustomFieldDefinition
+	var options []byte
+	var maxLength sql.NullInt64
+	var miniApps pq.StringArray
+	if err := row.Scan(&d.Key, &d.Label, &d.Type, &options, &d.Required, &maxLength, &miniApps,
+		&d.UserEditable, &d.IsActive, &d.DisplayOrder, &d.CreatedAt, &d.UpdatedAt); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(options, &d.Options); err != nil {
+		return nil, fmt.Errorf("invalid options for field %s: %w", d.Key, err)
+	}
+	if maxLength.Valid {
+		n := int(maxLength.Int64)
+		d.MaxLength = &n
+	}
+	d.MiniAppTypes = []string(miniApps)
+	if d.MiniAppTypes == nil {
+		d.MiniAppTypes = []string{}
+	}
+	return &d, nil
+}
+
+// ListDefinitions returns the custom field definitions in display order
+func (r *CustomFieldRepository) ListDefinitions(ctx context.Context, activeOnly bool) ([]models.CustomFieldDefinition, error) {
+	rows, err := r.db.DB.QueryContext(ctx, `
+		SELECT `+customFieldColumns+`
+		FROM app_user_custom_fields
+		WHERE is_active OR NOT $1
+		ORDER BY display_order, field_key
+	`, activeOnly)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list custom fields: %w", err)
+	}
+	defer rows.Close()
+
+	defs := []models.CustomFieldDefinition{}
+	for rows.Next() {
+		d, err := scanCustomField(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan custom field: %w", err)
+		}
+		defs = append(defs, *d)
+	}
+	return defs, rows.Err()
+}
+
+// GetDefinition returns one custom field definition
+func (r *CustomFieldRepository) GetDefinition(ctx context.Context, key string) (*models.CustomFieldDefinition, error) {
+	d, err := scanCustomField(r.db.DB.QueryRowContext(ctx, `SELECT `+customFieldColumns+` FROM app_user_custom_fields WHERE field_key = $1`, key))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("custom field not found")
+		}
+		return nil, fmt.Errorf("failed to get custom field: %w", err)
+	}
+	return d, nil
+}
+
+// CreateDefinition adds a custom field definition
+func (r *CustomFieldRepository) CreateDefinition(ctx context.Context, d *models.CustomFieldDefinition) (*models.CustomFieldDefinition, error) {
+	options, err := json.Marshal(optionsOrEmpty(d.Options))
+	if err != nil {
+		return nil, err
+	}
+	_, err = r.db.DB.ExecContext(ctx, `
+		INSERT INTO app_user_custom_fields (field_key, label, field_type, options, required, max_length, mini_app_types, user_editable, is_active, display_order)
+		VALUES ($1, $2, $3, $4::jsonb, $5, $6, $7, $8, $9, $10)
+	`, d.Key, d.Label, string(d.Type), string(options), d.Required, d.MaxLength, pq.Array(d.MiniAppTypes), d.UserEditable, d.IsActive, d.DisplayOrder)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create custom field: %w", err)
+	}
+	return r.GetDefinition(ctx, d.Key)
+}
+
+// UpdateDefinition replaces a custom field definition. The type of a field that already has stored
+// values cannot change, and options still in use cannot be removed.
+func (r *CustomFieldRepository) UpdateDefinition(ctx context.Context, d *models.CustomFieldDefinition) (*models.CustomFieldDefinition, error) {
+	current, err := r.GetDefinition(ctx, d.Key)
+	if err != nil {
+		return nil, err
+	}
+	if current.Type != d.Type {
+		var used bool
+		if err := r.db.DB.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM app_user_custom_field_values WHERE field_key = $1)`, d.Key).Scan(&used); err != nil {
+			return nil, fmt.Errorf("failed to check custom field values: %w", err)
+		}
+		if used {
+			return nil, fmt.Errorf("cannot change the type of a field with stored values")
+		}
+	}
+	options, err := json.Marshal(optionsOrEmpty(d.Options))
+	if err != nil {
+		return nil, err
+	}
+	if d.Type == models.CustomFieldSelect || d.Type == models.CustomFieldMultiSelect {
+		var inUse sql.NullString
+		err := r.db.DB.QueryRowContext(ctx, `
+			SELECT MIN(o) FROM app_user_custom_field_values v,
+				jsonb_array_elements_text(CASE WHEN jsonb_typeof(v.value) = 'array' THEN v.value ELSE jsonb_build_array(v.value) END) o
+			WHERE v.field_key = $1 AND NOT ($2::jsonb ? o)
+		`, d.Key, string(options)).Scan(&inUse)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check custom field options: %w", err)
+		}
+		if inUse.Valid {
+			return nil, fmt.Errorf("option %q is still used by some users", inUse.String)
+		}
+	}
+
+	_, err = r.db.DB.ExecContext(ctx, `
+		UPDATE app_user_custom_fields
+		SET label = $2, field_type = $3, options = $4::jsonb, required = $5, max_length = $6, mini_app_types = $7,
+			user_editable = $8, is_active = $9, display_order = $10, updated_at = now()
+		WHERE field_key = $1
+	`, d.Key, d.Label, string(d.Type), string(options), d.Required, d.MaxLength, pq.Array(d.MiniAppTypes), d.UserEditable, d.IsActive, d.DisplayOrder)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update custom field: %w", err)
+	}
+	return r.GetDefinition(ctx, d.Key)
+}
+
+// DeleteDefinition removes a custom field definition together with all stored values
+func (r *CustomFieldRepository) DeleteDefinition(ctx context.Context, key string) error {
+	result, err := r.db.DB.ExecContext(ctx, `DELETE FROM app_user_custom_fields WHERE field_key = $1`, key)
+	if err != nil {
+		return fmt.Errorf("failed to delete custom field: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return fmt.Errorf("custom field not found")
+	}
+	return nil
+}
+
+// GetValues returns a user's custom field values (active fields only)
+func (r *CustomFieldRepository) GetValues(ctx context.Context, userID string) (map[string]interface{}, error) {
+	all, err := r.GetValuesForUsers(ctx, []string{userID})
+	if err != nil {
+		return nil, err
+	}
+	if values, ok := all[userID]; ok {
+		return values, nil
+	}
+	return map[string]interface{}{}, nil
+}
+
+// GetValuesForUsers returns the custom field values (active fields only) of several users, keyed by user ID
+func (r *CustomFieldRepository) GetValuesForUsers(ctx context.Context, userIDs []string) (map[string]map[string]interface{}, error) {
+	out := map[string]map[string]interface{}{}
+	if len(userIDs) == 0 {
+		return out, nil
+	}
+	rows, err := r.db.DB.QueryContext(ctx, `
+		SELECT v.user_id::text, v.field_key, v.value
+		FROM app_user_custom_field_values v
+		JOIN app_user_custom_fields f ON f.field_key = v.field_key AND f.is_active
+		WHERE v.user_id::text = ANY($1)
+	`, pq.Array(userIDs))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get custom field values: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var userID, key string
+		var raw []byte
+		if err := rows.Scan(&userID, &key, &raw); err != nil {
+			return nil, fmt.Errorf("failed to scan custom field value: %w", err)
+		}
+		var v interface{}
+		if err := json.Unmarshal(raw, &v); err != nil {
+			return nil, fmt.Errorf("invalid value for custom field %s: %w", key, err)
+		}
+		if out[userID] == nil {
+			out[userID] = map[string]interface{}{}
+		}
+		out[userID][key] = v
+	}
+	return out, rows.Err()
+}
+
+// SetValues stores already validated values for a user (nil clears a field)
+func (r *CustomFieldRepository) SetValues(ctx context.Context, userID string, values map[string]interface{}) error {
+	tx, err := r.db.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var exists bool
+	if err := tx.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM app_users WHERE id::text = $1)`, userID).Scan(&exists); err != nil {
+		return fmt.Errorf("failed to check user: %w", err)
+	}
+	if !exists {
+		return fmt.Errorf("user not found")
+	}
+	if err := setCustomFieldValues(ctx, tx, userID, values); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// setCustomFieldValues upserts or clears custom field values within a transaction
+func setCustomFieldValues(ctx context.Context, tx *sql.Tx, userID string, values map[string]interface{}) error {
+	for key, v := range values {
+		if v == nil {
+			if _, err := tx.ExecContext(ctx, `DELETE FROM app_user_custom_field_values WHERE user_id = $1 AND field_key = $2`, userID, key); err != nil {
+				return fmt.Errorf("failed to clear custom field %s: %w", key, err)
+			}
+			continue
+		}
+		raw, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Errorf("failed to encode custom field %s: %w", key, err)
+		}
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO app_user_custom_field_values (user_id, field_key, value)
+			VALUES ($1, $2, $3::jsonb)
+			ON CONFLICT (user_id, field_key) DO UPDATE SET value = EXCLUDED.value, updated_at = now()
+		`, userID, key, string(raw))
+		if err != nil {
+			return fmt.Errorf("failed to set custom field %s: %w", key, err)
+		}
+	}
+	return nil
+}
+
+func optionsOrEmpty(options []string) []string {
+	if options == nil {
+		return []string{}
+	}
+	return options
+}
@@ -3,12 +3,14 @@ package db
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"log"
 	"net"
 	"os"
 	"time"
 
+	"github.com/expotoworld/expotoworld/backend/common/auth"
 	"github.com/lib/pq"
 )
 
@@ -167,3 +169,56 @@ func (d *Database) Close() error {
 func (d *Database) Health() error {
 	return d.DB.Ping()
 }
+
+// TokenVersion returns the user's access token version from app_users (owned by auth-service);
+// auth.ErrTokenRevoked if the user no longer exists
+func (d *Database) TokenVersion(ctx context.Context, userID string) (int, error) {
+	var version int
+	err := d.DB.QueryRowContext(ctx, `SELECT token_version FROM app_users WHERE id::text = $1`, userID).Scan(&version)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, auth.ErrTokenRevoked
+	}
+	return version, err
+}
+
+// EnsureSchema adds the columns this service relies on (idempotent).
+// The app_users table itself is owned by auth-service.
+func (d *Database) EnsureSchema(ctx context.Context) error {
+	stmts := []string{
+		"ALTER TABLE app_users ADD COLUMN IF NOT EXISTS email_verified_at TIMESTAMP WITH TIME ZONE",
+		"ALTER TABLE app_users ADD COLUMN IF NOT EXISTS phone_verified_at TIMESTAMP WITH TIME ZONE",
+		// Set when an admin deletes the user: the row is anonymized and kept for order history until purged
+		"ALTER TABLE app_users ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP WITH TIME ZONE",
+		"CREATE INDEX IF NOT EXISTS idx_app_users_deleted_at ON app_users(deleted_at) WHERE deleted_at IS NOT NULL",
+		// Admin-defined custom profile fields and their per-user values
+		`CREATE TABLE IF NOT EXISTS app_user_custom_fields (
+			field_key TEXT PRIMARY KEY,
+			label TEXT NOT NULL,
+			field_type TEXT NOT NULL CHECK (field_type IN ('text','number','boolean','date','select','multiselect')),
+			options JSONB NOT NULL DEFAULT '[]'::jsonb,
+			required BOOLEAN NOT NULL DEFAULT false,
+			max_length INTEGER,
+			mini_app_types TEXT[] NOT NULL DEFAULT '{}',
+			user_editable BOOLEAN NOT NULL DEFAULT true,
+			is_active BOOLEAN NOT NULL DEFAULT true,
+			display_order INTEGER NOT NULL DEFAULT 0,
+			created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now(),
+			updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now()
+		)`,
+		`CREATE TABLE IF NOT EXISTS app_user_custom_field_values (
+			user_id UUID NOT NULL,
+			field_key TEXT NOT NULL REFERENCES app_user_custom_fields(field_key) ON DELETE CASCADE,
+			value JSONB NOT NULL,
+			updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now(),
+			PRIMARY KEY (user_id, field_key)
+		)`,
+		"CREATE INDEX IF NOT EXISTS idx_user_custom_field_values_key ON app_user_custom_field_values(field_key)",
+	}
+	for _, stmt := range stmts {
+		if _, err := d.DB.ExecContext(ctx, stmt); err != nil {
+			return fmt.Errorf("failed to ensure schema: %w", err)
+		}
+	}
+	log.Println("[USER-DB] Schema verified")
+	return nil
+}
@@ -0,0 +1,118 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// DeleteUser soft-deletes a user: the row and its ID are kept so orders and refresh tokens stay valid,
+// but all personal data is scrubbed, the account is deactivated and deleted_at is set.
+// Carts and custom profile field values are removed. Deleting an already deleted user is a no-op.
+func (r *UserRepository) DeleteUser(ctx context.Context, userID string) error {
+	tx, err := r.db.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, `
+		UPDATE app_users SET
+			username = 'deleted-' || id::text,
+			email = NULL,
+			phone = NULL,
+			first_name = NULL,
+			middle_name = NULL,
+			last_name = NULL,
+			email_verified_at = NULL,
+			phone_verified_at = NULL,
+			status = 'deactivated',
+			deleted_at = COALESCE(deleted_at, now()),
+			updated_at = now()
+		WHERE id = $1`, userID)
+	if err != nil {
+		return fmt.Errorf("failed to anonymize user: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("user not found")
+	}
+
+	if _, err = tx.ExecContext(ctx, "DELETE FROM app_carts WHERE user_id = $1", userID); err != nil {
+		return fmt.Errorf("failed to delete user carts: %w", err)
+	}
+
+	if _, err = tx.ExecContext(ctx, "DELETE FROM app_user_custom_field_values WHERE user_id = $1", userID); err != nil {
+		return fmt.Errorf("failed to delete user custom fields: %w", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// PurgeDeletedUsers hard-deletes users soft-deleted more than retention ago, together with the
+// orders and refresh tokens that still reference them. Users are purged one per transaction.
+func (r *UserRepository) PurgeDeletedUsers(ctx context.Context, retention time.Duration, limit int) (int, error) {
+	rows, err := r.db.DB.QueryContext(ctx, `
+		SELECT id FROM app_users
+		WHERE deleted_at IS NOT NULL AND deleted_at < $1
+		ORDER BY deleted_at
+		LIMIT $2`, time.Now().Add(-retention), limit)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list users to purge: %w", err)
+	}
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("failed to scan user to purge: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("failed to list users to purge: %w", err)
+	}
+
+	purged := 0
+	for _, id := range ids {
+		if err := r.purgeUser(ctx, id); err != nil {
+			return purged, err
+		}
+		purged++
+	}
+	return purged, nil
+}
+
+func (r *UserRepository) purgeUser(ctx context.Context, userID string) error {
+	tx, err := r.db.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	stmts := []struct{ what, query string }{
+		{"orders", "DELETE FROM app_orders WHERE user_id = $1"},
+		{"refresh tokens", "DELETE FROM app_refresh_tokens WHERE user_id = $1"},
+		{"user", "DELETE FROM app_users WHERE id = $1 AND deleted_at IS NOT NULL"},
+	}
+	for _, s := range stmts {
+		if _, err := tx.ExecContext(ctx, s.query, userID); err != nil {
+			return fmt.Errorf("failed to purge %s of user %s: %w", s.what, userID, err)
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
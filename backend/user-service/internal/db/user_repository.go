@@ -29,8 +29,8 @@ func (r *UserRepository) GetUsers(ctx context.Context, params models.UserSearchP
 		log.Printf("[USER-DB] orders table not found; using fallback (no join) for GetUsers")
 	}
 
-	// Common WHERE building
-	var whereConditions []string
+	// Common WHERE building (soft-deleted users are anonymized and not listed)
+	whereConditions := []string{"u.deleted_at IS NULL"}
 	var args []interface{}
 	argIndex := 1
 
@@ -85,6 +85,7 @@ func (r *UserRepository) GetUsers(ctx context.Context, params models.UserSearchP
 	selectWithJoin := `
 		SELECT u.id, u.username, u.email, u.phone,
 		       u.first_name, u.middle_name, u.last_name, u.role, u.status, u.last_login,
+		       u.email_verified_at, u.phone_verified_at,
 		       u.created_at, u.updated_at,
 		       COALESCE(order_stats.order_count, 0) as order_count,
 		       COALESCE(order_stats.total_spent, 0) as total_spent
@@ -100,6 +101,7 @@ func (r *UserRepository) GetUsers(ctx context.Context, params models.UserSearchP
 	selectNoJoin := `
 		SELECT u.id, u.username, u.email, u.phone,
 		       u.first_name, u.middle_name, u.last_name, u.role, u.status, u.last_login,
+		       u.email_verified_at, u.phone_verified_at,
 		       u.created_at, u.updated_at,
 		       0 as order_count,
 		       0 as total_spent
@@ -140,6 +142,7 @@ func (r *UserRepository) GetUsers(ctx context.Context, params models.UserSearchP
 	for rows.Next() {
 		var user models.User
 		var lastLogin sql.NullTime
+		var emailVerifiedAt, phoneVerifiedAt *time.Time
 		err := rows.Scan(
 			&user.ID,
 			&user.Username,
@@ -151,6 +154,8 @@ func (r *UserRepository) GetUsers(ctx context.Context, params models.UserSearchP
 			&user.Role,
 			&user.Status,
 			&lastLogin,
+			&emailVerifiedAt,
+			&phoneVerifiedAt,
 			&user.CreatedAt,
 			&user.UpdatedAt,
 			&user.OrderCount,
@@ -179,6 +184,7 @@ func (r *UserRepository) GetUsers(ctx context.Context, params models.UserSearchP
 		if lastLogin.Valid {
 			user.LastLogin = &lastLogin.Time
 		}
+		user.SetVerification(emailVerifiedAt, phoneVerifiedAt)
 
 		users = append(users, user)
 	}
@@ -208,7 +214,7 @@ func (r *UserRepository) GetUsers(ctx context.Context, params models.UserSearchP
 func (r *UserRepository) getUserCount(ctx context.Context, params models.UserSearchParams) (int, error) {
 	query := "SELECT COUNT(*) FROM app_users u"
 
-	var whereConditions []string
+	whereConditions := []string{"u.deleted_at IS NULL"}
 	var args []interface{}
 	argIndex := 1
 
@@ -258,6 +264,7 @@ func (r *UserRepository) GetUserByID(ctx context.Context, userID string) (*model
 	query := `
 		SELECT u.id, u.username, u.email, u.phone,
 		       u.first_name, u.middle_name, u.last_name, u.role, u.status, u.last_login,
+		       u.email_verified_at, u.phone_verified_at,
 		       u.created_at, u.updated_at,
 		       COALESCE(order_stats.order_count, 0) as order_count,
 		       COALESCE(order_stats.total_spent, 0) as total_spent
@@ -275,6 +282,7 @@ func (r *UserRepository) GetUserByID(ctx context.Context, userID string) (*model
 
 	var user models.User
 	var lastLogin sql.NullTime
+	var emailVerifiedAt, phoneVerifiedAt *time.Time
 	err := r.db.DB.QueryRowContext(ctx, query, userID).Scan(
 		&user.ID,
 		&user.Username,
@@ -286,6 +294,8 @@ func (r *UserRepository) GetUserByID(ctx context.Context, userID string) (*model
 		&user.Role,
 		&user.Status,
 		&lastLogin,
+		&emailVerifiedAt,
+		&phoneVerifiedAt,
 		&user.CreatedAt,
 		&user.UpdatedAt,
 		&user.OrderCount,
@@ -319,6 +329,7 @@ func (r *UserRepository) GetUserByID(ctx context.Context, userID string) (*model
 	if lastLogin.Valid {
 		user.LastLogin = &lastLogin.Time
 	}
+	user.SetVerification(emailVerifiedAt, phoneVerifiedAt)
 
 	return &user, nil
 }
@@ -410,14 +421,18 @@ func (r *UserRepository) UpdateUser(ctx context.Context, userID string, updates
 		args = append(args, *updates.LastName)
 		argIndex++
 	}
+	// Changing a contact detail revokes its verification; the user re-verifies with
+	// the next code login (right-hand sides see the pre-update row)
 	if updates.Phone != nil {
 		setParts = append(setParts, fmt.Sprintf("phone = NULLIF(TRIM($%d), '')", argIndex))
+		setParts = append(setParts, fmt.Sprintf("phone_verified_at = CASE WHEN phone IS DISTINCT FROM NULLIF(TRIM($%d), '') THEN NULL ELSE phone_verified_at END", argIndex))
 		args = append(args, *updates.Phone)
 		argIndex++
 	}
 
 	if updates.Email != nil {
 		setParts = append(setParts, fmt.Sprintf("email = $%d", argIndex))
+		setParts = append(setParts, fmt.Sprintf("email_verified_at = CASE WHEN email IS DISTINCT FROM $%d THEN NULL ELSE email_verified_at END", argIndex))
 		args = append(args, *updates.Email)
 		argIndex++
 	}
@@ -434,7 +449,7 @@ func (r *UserRepository) UpdateUser(ctx context.Context, userID string, updates
 		argIndex++
 	}
 
-	if len(setParts) == 0 {
+	if len(setParts) == 0 && len(updates.CustomFields) == 0 {
 		return fmt.Errorf("no fields to update")
 	}
 
@@ -448,7 +463,14 @@ func (r *UserRepository) UpdateUser(ctx context.Context, userID string, updates
 
 	query := fmt.Sprintf("UPDATE app_users SET %s WHERE id = $%d", strings.Join(setParts, ", "), argIndex)
 
-	result, err := r.db.DB.ExecContext(ctx, query, args...)
+	// Profile columns and custom field values (validated by the caller) change together
+	tx, err := r.db.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, query, args...)
 	if err != nil {
 		return fmt.Errorf("failed to update user: %w", err)
 	}
@@ -462,35 +484,35 @@ func (r *UserRepository) UpdateUser(ctx context.Context, userID string, updates
 		return fmt.Errorf("user not found")
 	}
 
-	return nil
-}
-
-// DeleteUser performs a hard delete of a user from the database
-func (r *UserRepository) DeleteUser(ctx context.Context, userID string) error {
-	// Start a transaction to ensure data consistency
-	tx, err := r.db.DB.BeginTx(ctx, nil)
-	if err != nil {
-		return fmt.Errorf("failed to begin transaction: %w", err)
+	if err := setCustomFieldValues(ctx, tx, userID, updates.CustomFields); err != nil {
+		return err
 	}
-	defer tx.Rollback()
 
-	// Delete related data first (orders, carts, etc.)
-	// Delete user's carts
-	_, err = tx.ExecContext(ctx, "DELETE FROM app_carts WHERE user_id = $1", userID)
-	if err != nil {
-		return fmt.Errorf("failed to delete user carts: %w", err)
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
 	}
 
-	// Delete user's orders
-	_, err = tx.ExecContext(ctx, "DELETE FROM app_orders WHERE user_id = $1", userID)
-	if err != nil {
-		return fmt.Errorf("failed to delete user orders: %w", err)
+	return nil
+}
+
+// RequireReverification clears the verification timestamps for the given channel ("email", "phone" or "all")
+func (r *UserRepository) RequireReverification(ctx context.Context, userID, channel string) error {
+	var setSQL string
+	switch channel {
+	case "email":
+		setSQL = "email_verified_at = NULL"
+	case "phone":
+		setSQL = "phone_verified_at = NULL"
+	case "all":
+		setSQL = "email_verified_at = NULL, phone_verified_at = NULL"
+	default:
+		return fmt.Errorf("unsupported verification channel: %s", channel)
 	}
 
-	// Finally delete the user
-	result, err := tx.ExecContext(ctx, "DELETE FROM app_users WHERE id = $1", userID)
+	query := fmt.Sprintf("UPDATE app_users SET %s, updated_at = $1 WHERE id = $2", setSQL)
+	result, err := r.db.DB.ExecContext(ctx, query, time.Now(), userID)
 	if err != nil {
-		return fmt.Errorf("failed to delete user: %w", err)
+		return fmt.Errorf("failed to require re-verification: %w", err)
 	}
 
 	rowsAffected, err := result.RowsAffected()
@@ -502,11 +524,6 @@ func (r *UserRepository) DeleteUser(ctx context.Context, userID string) error {
 		return fmt.Errorf("user not found")
 	}
 
-	// Commit the transaction
-	if err = tx.Commit(); err != nil {
-		return fmt.Errorf("failed to commit transaction: %w", err)
-	}
-
 	return nil
 }
 
@@ -0,0 +1,271 @@
+package models
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// CustomFieldType is the value type of an admin-defined profile field
+type CustomFieldType string
+
+const (
+	CustomFieldText        CustomFieldType = "text"
+	CustomFieldNumber      CustomFieldType = "number"
+	CustomFieldBoolean     CustomFieldType = "boolean"
+	CustomFieldDate        CustomFieldType = "date" // YYYY-MM-DD
+	CustomFieldSelect      CustomFieldType = "select"
+	CustomFieldMultiSelect CustomFieldType = "multiselect"
+)
+
+// DefaultCustomFieldMaxLength caps text values when the definition sets no max_length
+const DefaultCustomFieldMaxLength = 500
+
+var customFieldKeyPattern = regexp.MustCompile(`^[a-z][a-z0-9_]{0,62}$`)
+
+// CustomFieldDefinition describes an extra profile field (e.g. company_name, vat_number, dietary_preferences).
+// MiniAppTypes lists the mini-apps that ask for the field (empty = all); UserEditable fields can be set
+// by users through /api/me, the others only by admins.
+type CustomFieldDefinition struct {
+	Key          string          `json:"key"`
+	Label        string          `json:"label"`
+	Type         CustomFieldType `json:"type"`
+	Options      []string        `json:"options,omitempty"`
+	Required     bool            `json:"required"`
+	MaxLength    *int            `json:"max_length,omitempty"`
+	MiniAppTypes []string        `json:"mini_app_types"`
+	UserEditable bool            `json:"user_editable"`
+	IsActive     bool            `json:"is_active"`
+	DisplayOrder int             `json:"display_order"`
+	CreatedAt    time.Time       `json:"created_at"`
+	UpdatedAt    time.Time       `json:"updated_at"`
+}
+
+// CustomFieldDefinitionRequest creates or replaces a field definition; Key comes from the body on
+// create and from the URL on update.
+type CustomFieldDefinitionRequest struct {
+	Key          string          `json:"key"`
+	Label        string          `json:"label" binding:"required"`
+	Type         CustomFieldType `json:"type" binding:"required"`
+	Options      []string        `json:"options,omitempty"`
+	Required     bool            `json:"required"`
+	MaxLength    *int            `json:"max_length,omitempty"`
+	MiniAppTypes []string        `json:"mini_app_types,omitempty"`
+	UserEditable *bool           `json:"user_editable,omitempty"`
+	IsActive     *bool           `json:"is_active,omitempty"`
+	DisplayOrder int             `json:"display_order"`
+}
+
+// ToDefinition validates the request and converts it; an empty message means valid
+func (r *CustomFieldDefinitionRequest) ToDefinition() (*CustomFieldDefinition, string) {
+	d := &CustomFieldDefinition{
+		Key:          strings.TrimSpace(r.Key),
+		Label:        strings.TrimSpace(r.Label),
+		Type:         r.Type,
+		Required:     r.Required,
+		MaxLength:    r.MaxLength,
+		MiniAppTypes: []string{},
+		UserEditable: true,
+		IsActive:     true,
+		DisplayOrder: r.DisplayOrder,
+	}
+	if r.UserEditable != nil {
+		d.UserEditable = *r.UserEditable
+	}
+	if r.IsActive != nil {
+		d.IsActive = *r.IsActive
+	}
+	if !customFieldKeyPattern.MatchString(d.Key) {
+		return nil, "key must be lowercase letters, digits and underscores, starting with a letter (max 63)"
+	}
+	if d.Label == "" {
+		return nil, "label is required"
+	}
+
+	switch d.Type {
+	case CustomFieldText, CustomFieldNumber, CustomFieldBoolean, CustomFieldDate:
+		if len(r.Options) > 0 {
+			return nil, "options are only allowed for select and multiselect fields"
+		}
+	case CustomFieldSelect, CustomFieldMultiSelect:
+		seen := map[string]bool{}
+		for _, o := range r.Options {
+			o = strings.TrimSpace(o)
+			if o == "" || seen[o] {
+				return nil, "options must be non-empty and unique"
+			}
+			seen[o] = true
+			d.Options = append(d.Options, o)
+		}
+		if len(d.Options) == 0 {
+			return nil, "options are required for select and multiselect fields"
+		}
+	default:
+		return nil, "type must be one of: text, number, boolean, date, select, multiselect"
+	}
+
+	if d.MaxLength != nil && (d.Type != CustomFieldText || *d.MaxLength < 1 || *d.MaxLength > 10000) {
+		return nil, "max_length must be between 1 and 10000 and is only allowed for text fields"
+	}
+	for _, m := range r.MiniAppTypes {
+		if m = strings.TrimSpace(m); m != "" {
+			d.MiniAppTypes = append(d.MiniAppTypes, m)
+		}
+	}
+	return d, ""
+}
+
+// AppliesTo reports whether the field is asked for in the given mini-app ("" = any)
+func (d *CustomFieldDefinition) AppliesTo(miniAppType string) bool {
+	if miniAppType == "" || len(d.MiniAppTypes) == 0 {
+		return true
+	}
+	for _, m := range d.MiniAppTypes {
+		if strings.EqualFold(m, miniAppType) {
+			return true
+		}
+	}
+	return false
+}
+
+// NormalizeValue checks a JSON-decoded value against the definition and returns the value to store.
+// A nil result means the value is cleared.
+func (d *CustomFieldDefinition) NormalizeValue(v interface{}) (interface{}, error) {
+	if v == nil {
+		if d.Required {
+			return nil, fmt.Errorf("is required")
+		}
+		return nil, nil
+	}
+	switch d.Type {
+	case CustomFieldText:
+		s, ok := v.(string)
+		if !ok {
+			return nil, fmt.Errorf("must be a string")
+		}
+		s = strings.TrimSpace(s)
+		max := DefaultCustomFieldMaxLength
+		if d.MaxLength != nil {
+			max = *d.MaxLength
+		}
+		if len([]rune(s)) > max {
+			return nil, fmt.Errorf("must be at most %d characters", max)
+		}
+		if s == "" {
+			return d.NormalizeValue(nil)
+		}
+		return s, nil
+	case CustomFieldNumber:
+		n, ok := v.(float64)
+		if !ok || math.IsNaN(n) || math.IsInf(n, 0) {
+			return nil, fmt.Errorf("must be a number")
+		}
+		return n, nil
+	case CustomFieldBoolean:
+		b, ok := v.(bool)
+		if !ok {
+			return nil, fmt.Errorf("must be true or false")
+		}
+		return b, nil
+	case CustomFieldDate:
+		s, ok := v.(string)
+		if !ok {
+			return nil, fmt.Errorf("must be a date (YYYY-MM-DD)")
+		}
+		if _, err := time.Parse("2006-01-02", s); err != nil {
+			return nil, fmt.Errorf("must be a date (YYYY-MM-DD)")
+		}
+		return s, nil
+	case CustomFieldSelect:
+		s, ok := v.(string)
+		if !ok || !d.hasOption(s) {
+			return nil, fmt.Errorf("must be one of: %s", strings.Join(d.Options, ", "))
+		}
+		return s, nil
+	case CustomFieldMultiSelect:
+		arr, ok := v.([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("must be a list")
+		}
+		out := []string{}
+		seen := map[string]bool{}
+		for _, item := range arr {
+			s, ok := item.(string)
+			if !ok || !d.hasOption(s) {
+				return nil, fmt.Errorf("values must be among: %s", strings.Join(d.Options, ", "))
+			}
+			if !seen[s] {
+				seen[s] = true
+				out = append(out, s)
+			}
+		}
+		if len(out) == 0 {
+			return d.NormalizeValue(nil)
+		}
+		sort.Strings(out)
+		return out, nil
+	}
+	return nil, fmt.Errorf("has an unsupported type")
+}
+
+func (d *CustomFieldDefinition) hasOption(v string) bool {
+	for _, o := range d.Options {
+		if o == v {
+			return true
+		}
+	}
+	return false
+}
+
+// CustomFieldValidationError lists the invalid fields of an update (key -> problem)
+type CustomFieldValidationError map[string]string
+
+func (e CustomFieldValidationError) Error() string {
+	keys := make([]string, 0, len(e))
+	for k := range e {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	parts := make([]string, 0, len(keys))
+	for _, k := range keys {
+		parts = append(parts, k+" "+e[k])
+	}
+	return "invalid custom fields: " + strings.Join(parts, "; ")
+}
+
+// NormalizeCustomFieldValues validates an update against the active definitions. Unknown or inactive keys
+// are rejected, and so are non user-editable fields when userOnly is set. A nil value clears the field.
+func NormalizeCustomFieldValues(defs []CustomFieldDefinition, values map[string]interface{}, userOnly bool) (map[string]interface{}, error) {
+	byKey := make(map[string]*CustomFieldDefinition, len(defs))
+	for i := range defs {
+		if defs[i].IsActive {
+			byKey[defs[i].Key] = &defs[i]
+		}
+	}
+	out := make(map[string]interface{}, len(values))
+	problems := CustomFieldValidationError{}
+	for key, v := range values {
+		d, ok := byKey[key]
+		if !ok {
+			problems[key] = "is not a known field"
+			continue
+		}
+		if userOnly && !d.UserEditable {
+			problems[key] = "cannot be changed by the user"
+			continue
+		}
+		nv, err := d.NormalizeValue(v)
+		if err != nil {
+			problems[key] = err.Error()
+			continue
+		}
+		out[key] = nv
+	}
+	if len(problems) > 0 {
+		return nil, problems
+	}
+	return out, nil
+}
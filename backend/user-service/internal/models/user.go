@@ -43,6 +43,23 @@ type User struct {
 	LastLogin  *time.Time `json:"last_login,omitempty"`
 	OrderCount int        `json:"order_count,omitempty"`
 	TotalSpent float64    `json:"total_spent,omitempty"`
+
+	// Contact verification (cleared when an admin edits the email/phone)
+	EmailVerified   bool       `json:"email_verified"`
+	EmailVerifiedAt *time.Time `json:"email_verified_at,omitempty"`
+	PhoneVerified   bool       `json:"phone_verified"`
+	PhoneVerifiedAt *time.Time `json:"phone_verified_at,omitempty"`
+
+	// Admin-defined profile fields (key -> typed value), see CustomFieldDefinition
+	CustomFields map[string]interface{} `json:"custom_fields,omitempty"`
+}
+
+// SetVerification fills the verification flags from nullable timestamps
+func (u *User) SetVerification(emailVerifiedAt, phoneVerifiedAt *time.Time) {
+	u.EmailVerifiedAt = emailVerifiedAt
+	u.EmailVerified = emailVerifiedAt != nil && u.Email != nil
+	u.PhoneVerifiedAt = phoneVerifiedAt
+	u.PhoneVerified = phoneVerifiedAt != nil && u.Phone != nil
 }
 
 // UserListResponse represents paginated user list response
@@ -89,6 +106,25 @@ type UserUpdateRequest struct {
 	Email      *string     `json:"email,omitempty"`
 	Role       *UserRole   `json:"role,omitempty"`
 	Status     *UserStatus `json:"status,omitempty"`
+
+	// CustomFields sets admin-defined profile fields; a null value clears the field
+	CustomFields map[string]interface{} `json:"custom_fields,omitempty"`
+}
+
+// ReverificationRequest represents an admin request to require re-verification of contact details
+type ReverificationRequest struct {
+	Channel string `json:"channel" binding:"required"` // "email", "phone" or "all"
+	Reason  string `json:"reason,omitempty"`
+}
+
+// ValidateReverificationChannel validates the channel of a re-verification request
+func ValidateReverificationChannel(channel string) bool {
+	switch channel {
+	case "email", "phone", "all":
+		return true
+	default:
+		return false
+	}
 }
 
 // UserStatusUpdateRequest represents user status update request
@@ -32,6 +32,7 @@ type result struct {
 	UserRateLimits          int64
 	RevokedRefreshTokens24h int64
 	ExpiredRefreshTokens7d  int64
+	InactiveRefreshTokens   int64
 }
 
 func getSecret(ctx context.Context, sm *secretsmanager.Client, secretArn string) (string, error) {
@@ -73,6 +74,7 @@ func putMetrics(ctx context.Context, cw *cloudwatch.Client, ns string, r result)
 	metrics = append(metrics,
 		cwtypes.MetricDatum{MetricName: awsStr("RowsDeleted"), Timestamp: &now, Unit: cwtypes.StandardUnitCount, Value: awsFloat(r.RevokedRefreshTokens24h), Dimensions: dims("Table", "app_refresh_tokens_revoked_24h")},
 		cwtypes.MetricDatum{MetricName: awsStr("RowsDeleted"), Timestamp: &now, Unit: cwtypes.StandardUnitCount, Value: awsFloat(r.ExpiredRefreshTokens7d), Dimensions: dims("Table", "app_refresh_tokens_expired_7d")},
+		cwtypes.MetricDatum{MetricName: awsStr("RowsRevoked"), Timestamp: &now, Unit: cwtypes.StandardUnitCount, Value: awsFloat(r.InactiveRefreshTokens), Dimensions: dims("Table", "app_refresh_tokens_inactive")},
 	)
 	_, err := cw.PutMetricData(ctx, &cloudwatch.PutMetricDataInput{
 		Namespace:  &ns,
@@ -100,6 +102,13 @@ func handler(ctx context.Context) (string, error) {
 			stmtTimeoutMs = n
 		}
 	}
+	// Must match auth-service REFRESH_TOKEN_INACTIVITY_DAYS; 0 disables inactivity revocation
+	inactivityDays := int64(14)
+	if v := os.Getenv("REFRESH_TOKEN_INACTIVITY_DAYS"); v != "" {
+		if n, err := strconv.ParseInt(v, 10, 64); err == nil {
+			inactivityDays = n
+		}
+	}
 
 	// AWS SDK clients
 	awsCfg, err := config.LoadDefaultConfig(ctx, config.WithRegion(region))
@@ -189,10 +198,17 @@ func handler(ctx context.Context) (string, error) {
 	if err != nil {
 		return "", fmt.Errorf("refresh tokens expired>3d: %w", err)
 	}
+	// 3) Revoke tokens unused for the inactivity window (deleted by step 1 on a later run)
+	if inactivityDays > 0 {
+		res.InactiveRefreshTokens, err = run(fmt.Sprintf(`UPDATE app_refresh_tokens SET revoked = true WHERE revoked = false AND COALESCE(last_used_at, issued_at) < now() - interval '%d days'`, inactivityDays), stmtTimeoutMs)
+		if err != nil {
+			return "", fmt.Errorf("refresh tokens inactive: %w", err)
+		}
+	}
 
-	log.Printf("[CLEANUP] Deleted rows: admin_codes_used=%d admin_codes_expired=%d admin_rate_limits=%d user_codes_used=%d user_codes_expired=%d user_phone_used=%d user_phone_expired=%d user_rate_limits=%d refresh_tokens_revoked_24h=%d refresh_tokens_expired_7d=%d",
+	log.Printf("[CLEANUP] Deleted rows: admin_codes_used=%d admin_codes_expired=%d admin_rate_limits=%d user_codes_used=%d user_codes_expired=%d user_phone_used=%d user_phone_expired=%d user_rate_limits=%d refresh_tokens_revoked_24h=%d refresh_tokens_expired_7d=%d refresh_tokens_inactive_revoked=%d",
 		res.AdminCodesUsed, res.AdminCodesExpired, res.AdminRateLimits,
-		res.UserCodesUsed, res.UserCodesExpired, res.UserPhoneCodesUsed, res.UserPhoneCodesExp, res.UserRateLimits, res.RevokedRefreshTokens24h, res.ExpiredRefreshTokens7d)
+		res.UserCodesUsed, res.UserCodesExpired, res.UserPhoneCodesUsed, res.UserPhoneCodesExp, res.UserRateLimits, res.RevokedRefreshTokens24h, res.ExpiredRefreshTokens7d, res.InactiveRefreshTokens)
 
 	if err := putMetrics(ctx, cw, ns, res); err != nil {
 		log.Printf("PutMetricData failed: %v", err)
@@ -0,0 +1,366 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// event controls a single run. By default the lambda only reports; Enqueue schedules
+// orphans older than the age threshold for deletion and Purge deletes the due ones.
+type event struct {
+	Enqueue bool `json:"enqueue"`
+	Purge   bool `json:"purge"`
+}
+
+type result struct {
+	Listed   int      `json:"listed"`
+	Orphans  int      `json:"orphans"`
+	Missing  int      `json:"missing"`
+	Enqueued int      `json:"enqueued"`
+	Deleted  int      `json:"deleted"`
+	Retained int      `json:"retained"`
+	Errors   int      `json:"errors"`
+	Samples  []string `json:"orphan_samples,omitempty"`
+	Absent   []string `json:"missing_samples,omitempty"`
+}
+
+type logSummary struct {
+	Bucket              string         `json:"bucket"`
+	Prefixes            []string       `json:"prefixes"`
+	ListedCount         int            `json:"listed_count"`
+	OrphanCount         int            `json:"orphan_count"`
+	MissingCount        int            `json:"missing_count"`
+	EnqueuedCount       int            `json:"enqueued_count"`
+	DeletedCount        int            `json:"deleted_count"`
+	RetainedCount       int            `json:"retained_count"`
+	ErrorCount          int            `json:"error_count"`
+	ErrorReasons        map[string]int `json:"error_reasons"`
+	ExecutionDurationMs int64          `json:"execution_duration_ms"`
+	Timestamp           string         `json:"ts"`
+}
+
+// referenceQueries return every image URL the catalog still points at
+var referenceQueries = []string{
+	`SELECT image_url FROM admin_product_images WHERE image_url IS NOT NULL AND image_url <> ''`,
+	`SELECT image_url FROM admin_product_categories WHERE image_url IS NOT NULL AND image_url <> ''`,
+	`SELECT image_url FROM admin_subcategories WHERE image_url IS NOT NULL AND image_url <> ''`,
+	`SELECT image_url FROM admin_stores WHERE image_url IS NOT NULL AND image_url <> ''`,
+}
+
+const maxSamples = 50
+
+func getSecret(ctx context.Context, sm *secretsmanager.Client, secretArn string) (string, error) {
+	out, err := sm.GetSecretValue(ctx, &secretsmanager.GetSecretValueInput{SecretId: &secretArn})
+	if err != nil {
+		return "", fmt.Errorf("get secret: %w", err)
+	}
+	var payload struct {
+		DatabaseURL string `json:"DATABASE_URL"`
+	}
+	if err := json.Unmarshal([]byte(*out.SecretString), &payload); err != nil {
+		return "", fmt.Errorf("parse secret: %w", err)
+	}
+	if payload.DatabaseURL == "" {
+		return "", fmt.Errorf("DATABASE_URL missing in secret")
+	}
+	return payload.DatabaseURL, nil
+}
+
+func handler(ctx context.Context, ev event) (result, error) {
+	start := time.Now()
+	res := result{}
+	region := os.Getenv("AWS_REGION")
+	if region == "" {
+		region = os.Getenv("AWS_DEFAULT_REGION")
+	}
+	if region == "" {
+		region = "eu-central-1"
+	}
+	awsCfg, err := config.LoadDefaultConfig(ctx, config.WithRegion(region))
+	if err != nil {
+		return res, err
+	}
+	s3c := s3.NewFromConfig(awsCfg)
+	sm := secretsmanager.NewFromConfig(awsCfg)
+
+	// Same bucket/CDN configuration as catalog-service uploads
+	bucket := os.Getenv("MEDIA_BUCKET")
+	if bucket == "" {
+		bucket = "expotoworld-media"
+	}
+	cdnBase := strings.TrimRight(os.Getenv("ASSETS_CDN_BASE_URL"), "/")
+	if cdnBase == "" {
+		cdnBase = "https://assets.expotoworld.com"
+	}
+	prefixes := []string{}
+	for _, p := range strings.Split(envOr("CATALOG_IMAGE_PREFIXES", "admin-panel/products/,admin-panel/categories/,admin-panel/subcategories/,admin-panel/stores/"), ",") {
+		if p = normalizePrefix(p); p != "" {
+			prefixes = append(prefixes, p)
+		}
+	}
+	minAge := time.Duration(envInt("ORPHAN_MIN_AGE_HOURS", 72)) * time.Hour
+	enqueue := ev.Enqueue || os.Getenv("ENQUEUE_ORPHANS") == "true"
+	purge := ev.Purge || os.Getenv("PURGE_PENDING") == "true"
+
+	secretArn := os.Getenv("SECRETS_ARN")
+	if secretArn == "" {
+		return res, fmt.Errorf("SECRETS_ARN env var is required")
+	}
+	dsn, err := getSecret(ctx, sm, secretArn)
+	if err != nil {
+		return res, err
+	}
+	pool, err := pgxpool.New(ctx, dsn)
+	if err != nil {
+		return res, err
+	}
+	defer pool.Close()
+
+	// Ensure required tables exist (idempotent)
+	{
+		stmts := []string{
+			`CREATE TABLE IF NOT EXISTS catalog_image_pending_deletion (
+					object_key TEXT PRIMARY KEY,
+					bucket TEXT NOT NULL,
+					last_modified TIMESTAMPTZ,
+					requested_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+					not_before TIMESTAMPTZ NOT NULL DEFAULT (now() + interval '24 hours'),
+					attempts INTEGER NOT NULL DEFAULT 0,
+					last_checked_at TIMESTAMPTZ
+				);`,
+			`CREATE INDEX IF NOT EXISTS idx_catalog_image_pending_due ON catalog_image_pending_deletion(not_before);`,
+		}
+		for _, s := range stmts {
+			if _, err := pool.Exec(ctx, s); err != nil {
+				return res, fmt.Errorf("schema init: %w", err)
+			}
+		}
+	}
+
+	errorReasons := map[string]int{}
+
+	referenced, err := loadReferencedKeys(ctx, pool, cdnBase)
+	if err != nil {
+		return res, err
+	}
+
+	// Inventory: every object under the managed prefixes
+	type object struct {
+		key          string
+		lastModified time.Time
+	}
+	objects := map[string]object{}
+	for _, p := range prefixes {
+		prefix := p
+		var token *string
+		for {
+			out, err := s3c.ListObjectsV2(ctx, &s3.ListObjectsV2Input{Bucket: &bucket, Prefix: &prefix, ContinuationToken: token})
+			if err != nil {
+				return res, fmt.Errorf("list %s: %w", prefix, err)
+			}
+			for _, o := range out.Contents {
+				if o.Key == nil || strings.HasSuffix(*o.Key, "/") {
+					continue
+				}
+				obj := object{key: *o.Key}
+				if o.LastModified != nil {
+					obj.lastModified = *o.LastModified
+				}
+				objects[obj.key] = obj
+			}
+			if out.NextContinuationToken == nil {
+				break
+			}
+			token = out.NextContinuationToken
+		}
+	}
+	res.Listed = len(objects)
+
+	// Orphans: objects nobody references
+	for key, obj := range objects {
+		if referenced[key] {
+			continue
+		}
+		res.Orphans++
+		if len(res.Samples) < maxSamples {
+			res.Samples = append(res.Samples, key)
+		}
+		if !enqueue || obj.lastModified.IsZero() || time.Since(obj.lastModified) < minAge {
+			continue
+		}
+		tag, err := pool.Exec(ctx, `INSERT INTO catalog_image_pending_deletion (object_key, bucket, last_modified)
+			VALUES ($1, $2, $3) ON CONFLICT (object_key) DO NOTHING`, key, bucket, obj.lastModified)
+		if err != nil {
+			res.Errors++
+			errorReasons["enqueue_error"]++
+			continue
+		}
+		res.Enqueued += int(tag.RowsAffected())
+	}
+
+	// Missing: references inside our prefixes with no object behind them
+	for key := range referenced {
+		if !hasAnyPrefix(key, prefixes) {
+			continue
+		}
+		if _, ok := objects[key]; ok {
+			continue
+		}
+		res.Missing++
+		if len(res.Absent) < maxSamples {
+			res.Absent = append(res.Absent, key)
+		}
+	}
+
+	if purge {
+		rows, err := pool.Query(ctx, `SELECT object_key, bucket FROM catalog_image_pending_deletion WHERE not_before <= now() ORDER BY not_before ASC LIMIT 200`)
+		if err != nil {
+			return res, err
+		}
+		type pendingItem struct{ key, bucket string }
+		items := []pendingItem{}
+		for rows.Next() {
+			var it pendingItem
+			_ = rows.Scan(&it.key, &it.bucket)
+			items = append(items, it)
+		}
+		rows.Close()
+
+		// Re-read references so an image attached after enqueueing is never deleted
+		referenced, err = loadReferencedKeys(ctx, pool, cdnBase)
+		if err != nil {
+			return res, err
+		}
+		for _, it := range items {
+			key := it.key
+			if !hasAnyPrefix(key, prefixes) {
+				res.Errors++
+				errorReasons["outside_prefix"]++
+				_, _ = pool.Exec(ctx, `DELETE FROM catalog_image_pending_deletion WHERE object_key=$1`, key)
+				continue
+			}
+			if referenced[key] {
+				_, _ = pool.Exec(ctx, `DELETE FROM catalog_image_pending_deletion WHERE object_key=$1`, key)
+				res.Retained++
+				continue
+			}
+			if _, err := s3c.DeleteObject(ctx, &s3.DeleteObjectInput{Bucket: &it.bucket, Key: &key}); err != nil {
+				res.Errors++
+				reason := "s3_delete_error"
+				msg := strings.ToLower(err.Error())
+				if strings.Contains(msg, "accessdenied") {
+					reason = "s3_access_denied"
+				} else if strings.Contains(msg, "timeout") {
+					reason = "s3_timeout"
+				}
+				errorReasons[reason]++
+				_, _ = pool.Exec(ctx, `UPDATE catalog_image_pending_deletion SET not_before = now() + interval '1 hour', attempts = attempts + 1, last_checked_at = now() WHERE object_key=$1`, key)
+				continue
+			}
+			_, _ = pool.Exec(ctx, `DELETE FROM catalog_image_pending_deletion WHERE object_key=$1`, key)
+			res.Deleted++
+		}
+	}
+
+	summary := logSummary{
+		Bucket:              bucket,
+		Prefixes:            prefixes,
+		ListedCount:         res.Listed,
+		OrphanCount:         res.Orphans,
+		MissingCount:        res.Missing,
+		EnqueuedCount:       res.Enqueued,
+		DeletedCount:        res.Deleted,
+		RetainedCount:       res.Retained,
+		ErrorCount:          res.Errors,
+		ErrorReasons:        errorReasons,
+		ExecutionDurationMs: time.Since(start).Milliseconds(),
+		Timestamp:           time.Now().UTC().Format(time.RFC3339),
+	}
+	b, _ := json.Marshal(summary)
+	log.Printf("%s", b)
+	return res, nil
+}
+
+// loadReferencedKeys returns the S3 keys of all image URLs stored in the catalog
+func loadReferencedKeys(ctx context.Context, pool *pgxpool.Pool, cdnBase string) (map[string]bool, error) {
+	keys := map[string]bool{}
+	for _, q := range referenceQueries {
+		rows, err := pool.Query(ctx, q)
+		if err != nil {
+			return nil, fmt.Errorf("load references: %w", err)
+		}
+		for rows.Next() {
+			var u string
+			if err := rows.Scan(&u); err != nil {
+				rows.Close()
+				return nil, fmt.Errorf("scan reference: %w", err)
+			}
+			if key := keyFromURL(u, cdnBase); key != "" {
+				keys[key] = true
+			}
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return nil, fmt.Errorf("load references: %w", err)
+		}
+	}
+	return keys, nil
+}
+
+// keyFromURL maps a stored CDN (or legacy absolute) URL back to its object key
+func keyFromURL(u, cdnBase string) string {
+	u = strings.TrimSpace(u)
+	if strings.HasPrefix(u, cdnBase+"/") {
+		return strings.TrimPrefix(u, cdnBase+"/")
+	}
+	if parsed, err := url.Parse(u); err == nil && parsed.Host != "" {
+		return strings.TrimLeft(parsed.Path, "/")
+	}
+	return strings.TrimLeft(u, "/")
+}
+
+func envOr(key, def string) string {
+	if v := strings.TrimSpace(os.Getenv(key)); v != "" {
+		return v
+	}
+	return def
+}
+
+func envInt(key string, def int) int {
+	if v, err := strconv.Atoi(os.Getenv(key)); err == nil && v >= 0 {
+		return v
+	}
+	return def
+}
+
+func normalizePrefix(p string) string {
+	p = strings.TrimLeft(strings.TrimSpace(p), "/")
+	if p != "" && !strings.HasSuffix(p, "/") {
+		p += "/"
+	}
+	return p
+}
+
+func hasAnyPrefix(key string, prefixes []string) bool {
+	for _, p := range prefixes {
+		if p != "" && strings.HasPrefix(key, p) {
+			return true
+		}
+	}
+	return false
+}
+
+func main() { lambda.Start(handler) }
@@ -70,10 +70,15 @@ func handler(ctx context.Context, _ event) (result, error) {
 	s3c := s3.NewFromConfig(awsCfg)
 	sm := secretsmanager.NewFromConfig(awsCfg)
 
+	// Same configuration as ebook-service (MEDIA_BUCKET / EBOOK_MEDIA_PREFIX)
 	bucket := os.Getenv("MEDIA_BUCKET")
 	if bucket == "" {
 		bucket = "expotoworld-media"
 	}
+	mediaPrefix := normalizePrefix(os.Getenv("EBOOK_MEDIA_PREFIX"))
+	if mediaPrefix == "" {
+		mediaPrefix = "ebooks/huashangdao/"
+	}
 	secretArn := os.Getenv("SECRETS_ARN")
 	if secretArn == "" {
 		return res, fmt.Errorf("SECRETS_ARN env var is required")
@@ -121,6 +126,7 @@ func handler(ctx context.Context, _ event) (result, error) {
 					created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
 					updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
 				);`,
+			`ALTER TABLE ebook_media_pending_deletion ADD COLUMN IF NOT EXISTS media_bucket TEXT;`,
 		}
 		for _, s := range stmts {
 			if _, err := pool.Exec(ctx, s); err != nil {
@@ -129,22 +135,46 @@ func handler(ctx context.Context, _ event) (result, error) {
 		}
 	}
 
+	// Allowed namespaces: the environment prefix plus any per-ebook prefix overrides
+	allowedPrefixes := []string{mediaPrefix}
+	if prows, err := pool.Query(ctx, `SELECT media_prefix FROM ebooks WHERE media_prefix IS NOT NULL AND media_prefix <> ''`); err == nil {
+		for prows.Next() {
+			var p string
+			if err := prows.Scan(&p); err == nil {
+				allowedPrefixes = append(allowedPrefixes, normalizePrefix(p))
+			}
+		}
+		prows.Close()
+	}
+
 	// Fetch batch of pending
-	rows, err := pool.Query(ctx, `SELECT media_key FROM ebook_media_pending_deletion WHERE not_before <= now() ORDER BY not_before ASC LIMIT 100`)
+	rows, err := pool.Query(ctx, `SELECT media_key, COALESCE(media_bucket, '') FROM ebook_media_pending_deletion WHERE not_before <= now() ORDER BY not_before ASC LIMIT 100`)
 	if err != nil {
 		return res, err
 	}
 	defer rows.Close()
-	keys := []string{}
+	type pendingItem struct{ key, bucket string }
+	items := []pendingItem{}
 	for rows.Next() {
-		var k string
-		_ = rows.Scan(&k)
-		keys = append(keys, k)
+		var it pendingItem
+		_ = rows.Scan(&it.key, &it.bucket)
+		if it.bucket == "" {
+			it.bucket = bucket
+		}
+		items = append(items, it)
 	}
-	res.Checked = len(keys)
+	res.Checked = len(items)
 
 	errorReasons := map[string]int{}
-	for _, key := range keys {
+	for _, it := range items {
+		key := it.key
+		if !hasAnyPrefix(key, allowedPrefixes) {
+			// Never delete outside our namespace (e.g. another environment's media)
+			res.Errors++
+			errorReasons["outside_prefix"]++
+			_, _ = pool.Exec(ctx, `DELETE FROM ebook_media_pending_deletion WHERE media_key=$1`, key)
+			continue
+		}
 		var inAutosave bool
 		var manualRefs, publishedRefs int
 		err := pool.QueryRow(ctx, `SELECT in_autosave, manual_refs, published_refs FROM ebook_media_usage WHERE media_key=$1`, key).Scan(&inAutosave, &manualRefs, &publishedRefs)
@@ -153,7 +183,7 @@ func handler(ctx context.Context, _ event) (result, error) {
 			res.Retained++
 			continue
 		}
-		_, err = s3c.DeleteObject(ctx, &s3.DeleteObjectInput{Bucket: &bucket, Key: &key})
+		_, err = s3c.DeleteObject(ctx, &s3.DeleteObjectInput{Bucket: &it.bucket, Key: &key})
 		if err != nil {
 			res.Errors++
 			reason := "s3_delete_error"
@@ -190,4 +220,21 @@ func handler(ctx context.Context, _ event) (result, error) {
 	return res, nil
 }
 
+func normalizePrefix(p string) string {
+	p = strings.TrimLeft(strings.TrimSpace(p), "/")
+	if p != "" && !strings.HasSuffix(p, "/") {
+		p += "/"
+	}
+	return p
+}
+
+func hasAnyPrefix(key string, prefixes []string) bool {
+	for _, p := range prefixes {
+		if p != "" && strings.HasPrefix(key, p) {
+			return true
+		}
+	}
+	return false
+}
+
 func main() { lambda.Start(handler) }